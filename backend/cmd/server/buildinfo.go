@@ -0,0 +1,53 @@
+package main
+
+import (
+	"runtime/debug"
+
+	"github.com/agent-racer/backend/internal/update"
+	"github.com/agent-racer/backend/internal/ws"
+)
+
+// applyBuildInfo fills in build metadata that -ldflags did not set from the
+// module and VCS stamps the Go toolchain embeds, so binaries built with
+// `go install` or a plain `go build` still report where they came from.
+// Without ldflags the date is the commit time rather than the build time.
+func applyBuildInfo(info *debug.BuildInfo) {
+	if info == nil {
+		return
+	}
+	if version == "dev" && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		version = info.Main.Version
+	}
+	var revision, modified string
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			revision = s.Value
+		case "vcs.time":
+			if buildDate == "" {
+				buildDate = s.Value
+			}
+		case "vcs.modified":
+			modified = s.Value
+		}
+	}
+	if commit == "" && revision != "" {
+		if len(revision) > 7 {
+			revision = revision[:7]
+		}
+		if modified == "true" {
+			revision += "-dirty"
+		}
+		commit = revision
+	}
+}
+
+// updatePayload converts a release found by the update checker into the
+// notice sent to clients.
+func updatePayload(rel update.Release) ws.UpdateAvailablePayload {
+	return ws.UpdateAvailablePayload{
+		Current: version,
+		Latest:  rel.Version,
+		URL:     rel.URL,
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"runtime/debug"
+	"testing"
+)
+
+func restoreBuildVars(t *testing.T) {
+	t.Helper()
+	v, c, d := version, commit, buildDate
+	t.Cleanup(func() {
+		version, commit, buildDate = v, c, d
+	})
+}
+
+func TestApplyBuildInfo_FillsMissingFields(t *testing.T) {
+	restoreBuildVars(t)
+	version, commit, buildDate = "dev", "", ""
+
+	applyBuildInfo(&debug.BuildInfo{
+		Main: debug.Module{Version: "v1.4.0"},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs.revision", Value: "0123456789abcdef"},
+			{Key: "vcs.time", Value: "2026-02-03T04:05:06Z"},
+			{Key: "vcs.modified", Value: "true"},
+		},
+	})
+
+	if version != "v1.4.0" {
+		t.Errorf("version = %q, want v1.4.0", version)
+	}
+	if commit != "0123456-dirty" {
+		t.Errorf("commit = %q, want 0123456-dirty", commit)
+	}
+	if buildDate != "2026-02-03T04:05:06Z" {
+		t.Errorf("buildDate = %q", buildDate)
+	}
+}
+
+func TestApplyBuildInfo_KeepsLdflags(t *testing.T) {
+	restoreBuildVars(t)
+	version, commit, buildDate = "v2.0.0", "abcdef1", "2026-05-06T07:08:09Z"
+
+	applyBuildInfo(&debug.BuildInfo{
+		Main: debug.Module{Version: "(devel)"},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs.revision", Value: "fedcba9876543210"},
+			{Key: "vcs.time", Value: "2020-01-01T00:00:00Z"},
+		},
+	})
+
+	if version != "v2.0.0" || commit != "abcdef1" || buildDate != "2026-05-06T07:08:09Z" {
+		t.Errorf("ldflags values overwritten: %q %q %q", version, commit, buildDate)
+	}
+}
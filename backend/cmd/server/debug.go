@@ -0,0 +1,257 @@
+package main
+
+import (
+	"archive/zip"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/agent-racer/backend/internal/config"
+	"github.com/agent-racer/backend/internal/crash"
+	"gopkg.in/yaml.v3"
+)
+
+// bundleMaxCrashReports caps how many recent crash reports go into a bundle.
+const bundleMaxCrashReports = 10
+
+// bundleMaxLogBytes caps how much of a log file (its tail) goes into a bundle.
+const bundleMaxLogBytes = 1 << 20
+
+// redacted replaces secret config values in a bundle.
+const redacted = "REDACTED"
+
+// bundleOptions describes what goes into a debug bundle.
+type bundleOptions struct {
+	cfg       *config.Config
+	cfgPath   string
+	warnings  []string
+	crashDir  string
+	logPath   string
+	serverURL string
+	token     string
+	client    *http.Client
+	now       time.Time
+}
+
+// healthEndpoints are fetched from a running server into the bundle.
+var healthEndpoints = []struct {
+	path string
+	file string
+}{
+	{"/healthz", "health/healthz.json"},
+	{"/api/health?probe=ready", "health/ready.json"},
+	{"/api/debug/broadcaster", "health/broadcaster.json"},
+	{"/api/debug/store", "health/store.json"},
+}
+
+// runDebug dispatches `debug` subcommands and returns the exit code.
+func runDebug(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] != "bundle" {
+		_, _ = fmt.Fprintln(stderr, "usage: agent-racer-server debug bundle [flags]")
+		return 2
+	}
+	return runDebugBundle(args[1:], stdout, stderr)
+}
+
+// runDebugBundle writes a zip of version info, redacted config, recent
+// crash reports, an optional log file and live health snapshots.
+func runDebugBundle(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("agent-racer-server debug bundle", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	cfgPath := fs.String("config", "", "Path to config file (defaults to ~/.config/agent-racer/config.yaml)")
+	out := fs.String("o", "", "Output zip path (defaults to agent-racer-debug-<timestamp>.zip)")
+	logPath := fs.String("log", "", "Server log file to include, e.g. redirected stderr (last 1 MiB)")
+	serverURL := fs.String("url", "", "Base URL of the running server (defaults to the configured host and port)")
+	token := fs.String("token", "", "Auth token for the running server (defaults to server.auth_token)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	path := *cfgPath
+	if path == "" {
+		path = config.DefaultConfigPath()
+	}
+	cfg, warnings, err := config.LoadOrDefault(path)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "load config: %v\n", err)
+		return 1
+	}
+
+	opts := bundleOptions{
+		cfg:       cfg,
+		cfgPath:   path,
+		warnings:  warnings,
+		crashDir:  config.DefaultCrashDir(),
+		logPath:   *logPath,
+		serverURL: *serverURL,
+		token:     *token,
+		client:    &http.Client{Timeout: 5 * time.Second},
+		now:       time.Now(),
+	}
+	if opts.serverURL == "" {
+		opts.serverURL = fmt.Sprintf("%s://%s:%d", cfg.Server.Scheme(), cfg.Server.Host, cfg.Server.Port)
+	}
+	if opts.token == "" {
+		opts.token = config.NormalizeAuthToken(cfg.Server.AuthToken)
+	}
+
+	dest := *out
+	if dest == "" {
+		dest = "agent-racer-debug-" + opts.now.UTC().Format("20060102T150405Z") + ".zip"
+	}
+	f, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "create bundle: %v\n", err)
+		return 1
+	}
+	if err := writeBundle(f, opts); err != nil {
+		_ = f.Close()
+		_ = os.Remove(dest)
+		_, _ = fmt.Fprintf(stderr, "write bundle: %v\n", err)
+		return 1
+	}
+	if err := f.Close(); err != nil {
+		_, _ = fmt.Fprintf(stderr, "write bundle: %v\n", err)
+		return 1
+	}
+	_, _ = fmt.Fprintf(stdout, "Debug bundle written to %s\n", dest)
+	return 0
+}
+
+// writeBundle writes the debug bundle zip to w. Missing optional inputs
+// (no crash reports, server not running) are noted in the bundle rather
+// than failing it.
+func writeBundle(w io.Writer, opts bundleOptions) error {
+	zw := zip.NewWriter(w)
+	var notes []string
+
+	add := func(name string, data []byte) error {
+		fw, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: opts.now})
+		if err != nil {
+			return err
+		}
+		_, err = fw.Write(data)
+		return err
+	}
+
+	info := fmt.Sprintf("version: %s\ncommit: %s\nbuilt: %s\ngo: %s\nplatform: %s/%s\ncreated: %s\nconfig: %s\n",
+		version, commit, buildDate, runtime.Version(), runtime.GOOS, runtime.GOARCH, opts.now.UTC().Format(time.RFC3339), opts.cfgPath)
+	if err := add("version.txt", []byte(info)); err != nil {
+		return err
+	}
+
+	cfgData, err := redactedConfig(opts.cfg)
+	if err != nil {
+		return fmt.Errorf("encode config: %w", err)
+	}
+	for _, warning := range opts.warnings {
+		cfgData = append(cfgData, []byte("# warning: "+warning+"\n")...)
+	}
+	if err := add("config.yaml", cfgData); err != nil {
+		return err
+	}
+
+	reports, err := crash.List(opts.crashDir)
+	if err != nil {
+		notes = append(notes, fmt.Sprintf("crash reports: %v", err))
+	}
+	if len(reports) > bundleMaxCrashReports {
+		reports = reports[:bundleMaxCrashReports]
+	}
+	for i := 0; i < len(reports); i++ {
+		data, err := os.ReadFile(reports[i])
+		if err != nil {
+			notes = append(notes, fmt.Sprintf("crash report %s: %v", filepath.Base(reports[i]), err))
+			continue
+		}
+		if err := add("crashes/"+filepath.Base(reports[i]), data); err != nil {
+			return err
+		}
+	}
+
+	if opts.logPath != "" {
+		data, err := readTail(opts.logPath, bundleMaxLogBytes)
+		if err != nil {
+			notes = append(notes, fmt.Sprintf("log %s: %v", opts.logPath, err))
+		} else if err := add("logs/"+filepath.Base(opts.logPath), data); err != nil {
+			return err
+		}
+	}
+
+	for _, ep := range healthEndpoints {
+		data, err := fetchHealth(opts, ep.path)
+		if err != nil {
+			notes = append(notes, fmt.Sprintf("%s: %v", ep.path, err))
+			continue
+		}
+		if err := add(ep.file, data); err != nil {
+			return err
+		}
+	}
+
+	if len(notes) > 0 {
+		if err := add("notes.txt", []byte(strings.Join(notes, "\n")+"\n")); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// redactedConfig returns the effective config as YAML with secrets removed.
+func redactedConfig(cfg *config.Config) ([]byte, error) {
+	cp := *cfg
+	if cp.Server.AuthToken != "" {
+		cp.Server.AuthToken = redacted
+	}
+	return yaml.Marshal(&cp)
+}
+
+// fetchHealth GETs path from the running server.
+func fetchHealth(opts bundleOptions, path string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(opts.serverURL, "/")+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if opts.token != "" {
+		req.Header.Set("Authorization", "Bearer "+opts.token)
+	}
+	resp, err := opts.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, bundleMaxLogBytes))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusServiceUnavailable {
+		return nil, errors.New(resp.Status)
+	}
+	return body, nil
+}
+
+// readTail returns up to max bytes from the end of the file at path.
+func readTail(path string, max int64) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	st, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if st.Size() > max {
+		if _, err := f.Seek(st.Size()-max, io.SeekStart); err != nil {
+			return nil, err
+		}
+	}
+	return io.ReadAll(f)
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agent-racer/backend/internal/config"
+	"github.com/agent-racer/backend/internal/crash"
+)
+
+func readZip(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("open zip: %v", err)
+	}
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := io.ReadAll(rc)
+		_ = rc.Close()
+		files[f.Name] = string(b)
+	}
+	return files
+}
+
+func TestWriteBundle(t *testing.T) {
+	crashDir := t.TempDir()
+	if _, err := crash.NewReporter(crashDir, "test").Record("poll/claude", "boom", nil); err != nil {
+		t.Fatal(err)
+	}
+	logPath := filepath.Join(t.TempDir(), "server.log")
+	if err := os.WriteFile(logPath, []byte("line one\nline two\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var gotAuth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/debug/broadcaster" {
+			gotAuth = r.Header.Get("Authorization")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"status":"ok"}`))
+	}))
+	defer srv.Close()
+
+	cfg, _, err := config.LoadOrDefault(filepath.Join(t.TempDir(), "missing.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Server.AuthToken = "s3cret-token-value"
+	var buf bytes.Buffer
+	err = writeBundle(&buf, bundleOptions{
+		cfg:       cfg,
+		cfgPath:   "/home/u/.config/agent-racer/config.yaml",
+		crashDir:  crashDir,
+		logPath:   logPath,
+		serverURL: srv.URL,
+		token:     cfg.Server.AuthToken,
+		client:    srv.Client(),
+		now:       time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("writeBundle: %v", err)
+	}
+
+	files := readZip(t, buf.Bytes())
+	if !strings.Contains(files["version.txt"], "version: "+version) {
+		t.Errorf("version.txt = %q", files["version.txt"])
+	}
+	if strings.Contains(files["config.yaml"], "s3cret-token-value") || !strings.Contains(files["config.yaml"], redacted) {
+		t.Errorf("config.yaml should have auth_token redacted:\n%s", files["config.yaml"])
+	}
+	if files["logs/server.log"] != "line one\nline two\n" {
+		t.Errorf("logs/server.log = %q", files["logs/server.log"])
+	}
+	if files["health/healthz.json"] != `{"status":"ok"}` {
+		t.Errorf("health/healthz.json = %q", files["health/healthz.json"])
+	}
+	if gotAuth != "Bearer s3cret-token-value" {
+		t.Errorf("Authorization = %q, want bearer token", gotAuth)
+	}
+	if !strings.Contains(files["notes.txt"], "/api/debug/broadcaster: 401") {
+		t.Errorf("notes.txt should record the failed endpoint, got %q", files["notes.txt"])
+	}
+	crashes := 0
+	for name := range files {
+		if strings.HasPrefix(name, "crashes/crash-") {
+			crashes++
+		}
+	}
+	if crashes != 1 {
+		t.Errorf("bundle has %d crash reports, want 1", crashes)
+	}
+}
+
+func TestRedactedConfigKeepsSlackSecretsOut(t *testing.T) {
+	t.Setenv("AGENT_RACER_SLACK_SIGNING_SECRET", "slack-signing-value")
+	t.Setenv("AGENT_RACER_SLACK_BOT_TOKEN", "xoxb-bot-value")
+	cfg, _, err := config.LoadOrDefault(filepath.Join(t.TempDir(), "missing.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.ChatOps.Slack.Enabled = true
+
+	data, err := redactedConfig(cfg)
+	if err != nil {
+		t.Fatalf("redactedConfig: %v", err)
+	}
+	out := string(data)
+	for _, secret := range []string{"slack-signing-value", "xoxb-bot-value"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("config.yaml contains Slack secret %q:\n%s", secret, out)
+		}
+	}
+	if !strings.Contains(out, "signing_secret_env: AGENT_RACER_SLACK_SIGNING_SECRET") {
+		t.Errorf("config.yaml should name the signing secret variable:\n%s", out)
+	}
+}
+
+func TestReadTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "big.log")
+	if err := os.WriteFile(path, []byte("0123456789"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	got, err := readTail(path, 4)
+	if err != nil || string(got) != "6789" {
+		t.Fatalf("readTail = (%q, %v), want 6789", got, err)
+	}
+}
+
+func TestRunDebugUsage(t *testing.T) {
+	var stderr bytes.Buffer
+	if code := runDebug(nil, io.Discard, &stderr); code != 2 {
+		t.Fatalf("exit code = %d, want 2", code)
+	}
+	if !strings.Contains(stderr.String(), "debug bundle") {
+		t.Errorf("usage = %q", stderr.String())
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/agent-racer/backend/internal/config"
+	"github.com/agent-racer/backend/internal/monitor"
+	"github.com/agent-racer/backend/internal/replay"
+	"github.com/agent-racer/backend/internal/session"
+)
+
+// dayDuration is a duration flag that also accepts whole days, e.g. "90d".
+type dayDuration time.Duration
+
+func (d *dayDuration) String() string { return time.Duration(*d).String() }
+
+func (d *dayDuration) Set(s string) error {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid number of days %q", s)
+		}
+		*d = dayDuration(time.Duration(n) * 24 * time.Hour)
+		return nil
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = dayDuration(v)
+	return nil
+}
+
+// importOptions describes where `import` reads transcripts from and which
+// replay directory it writes them into.
+type importOptions struct {
+	cfg       *config.Config
+	sources   []monitor.Source
+	replayDir string
+	since     time.Duration
+	now       time.Time
+	dryRun    bool
+}
+
+// runImport backfills the replay history from agent transcripts already on
+// disk and returns the exit code.
+func runImport(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("agent-racer-server import", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	cfgPath := fs.String("config", "", "Path to config file (defaults to ~/.config/agent-racer/config.yaml)")
+	since := dayDuration(30 * 24 * time.Hour)
+	fs.Var(&since, "since", "How far back to import transcripts, e.g. 90d or 12h")
+	dryRun := fs.Bool("dry-run", false, "Print what would be imported without writing anything")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	path := *cfgPath
+	if path == "" {
+		path = config.DefaultConfigPath()
+	}
+	cfg, _, err := config.LoadOrDefault(path)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "load config: %v\n", err)
+		return 1
+	}
+
+	opts := importOptions{
+		cfg:       cfg,
+		sources:   transcriptSources(cfg, time.Duration(since)),
+		replayDir: config.DefaultReplayDir(),
+		since:     time.Duration(since),
+		now:       time.Now(),
+		dryRun:    *dryRun,
+	}
+	if err := importTranscripts(opts, stdout, stderr); err != nil {
+		_, _ = fmt.Fprintf(stderr, "import: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// importTranscripts reads the finished sessions in opts' transcripts and
+// writes those not yet in the replay history into it. Sessions whose
+// transcript was written within monitor.session_stale_after may still be
+// running; they are left for the live monitor to pick up.
+func importTranscripts(opts importOptions, stdout, stderr io.Writer) error {
+	cutoff := opts.now.Add(-opts.cfg.Monitor.SessionStaleAfter)
+	var sessions []*session.SessionState
+	perSource := make(map[string]int)
+	for _, src := range opts.sources {
+		states, err := monitor.ReadTranscripts(src, opts.cfg)
+		if err != nil {
+			_, _ = fmt.Fprintf(stderr, "warning: %v\n", err)
+		}
+		for _, s := range states {
+			if s.LastActivityAt.After(cutoff) {
+				continue
+			}
+			sessions = append(sessions, s)
+			perSource[src.Name()]++
+		}
+	}
+	for _, src := range opts.sources {
+		_, _ = fmt.Fprintf(stdout, "  %-8s %d finished sessions\n", src.Name(), perSource[src.Name()])
+	}
+
+	if opts.dryRun {
+		_, _ = fmt.Fprintf(stdout, "dry run: %d sessions found, nothing written\n", len(sessions))
+		return nil
+	}
+	path, n, err := replay.Import(opts.replayDir, sessions, opts.cfg.Privacy.NewPrivacyFilter())
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		_, _ = fmt.Fprintln(stdout, "nothing new to import")
+		return nil
+	}
+	_, _ = fmt.Fprintf(stdout, "imported %d sessions into %s\n", n, path)
+	if days := opts.cfg.Replay.RetentionDays; days > 0 && opts.since > time.Duration(days)*24*time.Hour {
+		_, _ = fmt.Fprintf(stdout, "note: replay.retention_days is %d; sessions older than that are pruned when the server next starts\n", days)
+	}
+	return nil
+}
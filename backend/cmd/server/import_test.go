@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agent-racer/backend/internal/config"
+	"github.com/agent-racer/backend/internal/monitor"
+	"github.com/agent-racer/backend/internal/replay"
+	"github.com/agent-racer/backend/internal/session"
+)
+
+// transcriptStub reports one transcript per entry in last, each ending at
+// that time.
+type transcriptStub struct {
+	last map[string]time.Time
+}
+
+func (s transcriptStub) Name() string { return "claude" }
+
+func (s transcriptStub) Discover() ([]monitor.SessionHandle, error) {
+	var handles []monitor.SessionHandle
+	for id := range s.last {
+		handles = append(handles, monitor.SessionHandle{SessionID: id, Source: "claude", WorkingDir: "/src/" + id, LogPath: id + ".jsonl"})
+	}
+	return handles, nil
+}
+
+func (s transcriptStub) Parse(h monitor.SessionHandle, offset int64) (monitor.SourceUpdate, int64, error) {
+	if offset > 0 {
+		return monitor.SourceUpdate{}, offset, nil
+	}
+	return monitor.SourceUpdate{MessageCount: 3, LastTime: s.last[h.SessionID]}, 100, nil
+}
+
+func TestImportTranscripts(t *testing.T) {
+	cfg, _, err := config.LoadOrDefault(filepath.Join(t.TempDir(), "missing.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	opts := importOptions{
+		cfg:       cfg,
+		sources:   []monitor.Source{transcriptStub{last: map[string]time.Time{"old": now.Add(-48 * time.Hour), "live": now.Add(-time.Second)}}},
+		replayDir: t.TempDir(),
+		since:     90 * 24 * time.Hour,
+		now:       now,
+		dryRun:    true,
+	}
+
+	var stdout, stderr bytes.Buffer
+	if err := importTranscripts(opts, &stdout, &stderr); err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if states, _ := replay.FinalStates(opts.replayDir); len(states) != 0 {
+		t.Fatalf("dry run wrote %d sessions", len(states))
+	}
+
+	opts.dryRun = false
+	if err := importTranscripts(opts, &stdout, &stderr); err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	states, err := replay.FinalStates(opts.replayDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(states) != 1 || states[0].ID != "claude:old" || states[0].Activity != session.Complete {
+		t.Fatalf("imported = %+v, want only the finished session", states)
+	}
+	if !strings.Contains(stdout.String(), "imported 1 sessions") || !strings.Contains(stdout.String(), "retention_days is 7") {
+		t.Errorf("stdout = %q", stdout.String())
+	}
+
+	stdout.Reset()
+	if err := importTranscripts(opts, &stdout, &stderr); err != nil || !strings.Contains(stdout.String(), "nothing new") {
+		t.Errorf("second import = %v, %q", err, stdout.String())
+	}
+}
+
+func TestDayDuration(t *testing.T) {
+	var d dayDuration
+	if err := d.Set("90d"); err != nil || time.Duration(d) != 90*24*time.Hour {
+		t.Errorf("Set(90d) = %v, %v", time.Duration(d), err)
+	}
+	if err := d.Set("36h"); err != nil || time.Duration(d) != 36*time.Hour {
+		t.Errorf("Set(36h) = %v, %v", time.Duration(d), err)
+	}
+	if err := d.Set("xd"); err == nil {
+		t.Error("Set(xd) succeeded")
+	}
+}
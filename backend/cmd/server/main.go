@@ -11,22 +11,57 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
+	"reflect"
+	"runtime"
+	"runtime/debug"
 	"sync"
 	"syscall"
 	"time"
 
+	"github.com/agent-racer/backend/internal/benchmark"
+	"github.com/agent-racer/backend/internal/chatops"
+	"github.com/agent-racer/backend/internal/commentary"
 	"github.com/agent-racer/backend/internal/config"
+	"github.com/agent-racer/backend/internal/crash"
+	"github.com/agent-racer/backend/internal/director"
 	"github.com/agent-racer/backend/internal/frontend"
 	"github.com/agent-racer/backend/internal/gamification"
+	"github.com/agent-racer/backend/internal/handover"
+	"github.com/agent-racer/backend/internal/heats"
+	"github.com/agent-racer/backend/internal/launch"
 	"github.com/agent-racer/backend/internal/mock"
 	"github.com/agent-racer/backend/internal/monitor"
+	"github.com/agent-racer/backend/internal/names"
 	"github.com/agent-racer/backend/internal/replay"
 	"github.com/agent-racer/backend/internal/session"
+	"github.com/agent-racer/backend/internal/share"
+	"github.com/agent-racer/backend/internal/status"
 	"github.com/agent-racer/backend/internal/tracks"
+	"github.com/agent-racer/backend/internal/update"
+	"github.com/agent-racer/backend/internal/watchdog"
 	"github.com/agent-racer/backend/internal/ws"
 )
 
-var version = "dev"
+// Build metadata, injected by the Makefile via -ldflags -X. Fields left
+// empty are filled from the toolchain's embedded build info at startup.
+var (
+	version   = "dev"
+	commit    = ""
+	buildDate = ""
+)
+
+// shutdownTimeout bounds the graceful shutdown: notifying and closing
+// WebSocket clients and draining in-flight HTTP requests.
+const shutdownTimeout = 10 * time.Second
+
+// handoverTimeout bounds how long a running server waits for its
+// replacement to become ready during a SIGUSR2 upgrade.
+const handoverTimeout = 30 * time.Second
+
+// envHandoverToken carries a generated auth token to the replacement
+// process during a handover, so dashboards can reconnect with the token
+// they already have.
+const envHandoverToken = "AGENT_RACER_HANDOVER_TOKEN"
 
 type serverOptions struct {
 	mockMode    bool
@@ -36,7 +71,9 @@ type serverOptions struct {
 	showVersion bool
 }
 
-func buildSources(cfg *config.Config) []monitor.Source {
+// buildSources returns the enabled sources. The external source is passed
+// in because producers push to it, so it outlives rebuilds on reload.
+func buildSources(cfg *config.Config, external *monitor.ExternalSource) []monitor.Source {
 	var sources []monitor.Source
 	if cfg.Sources.Claude {
 		sources = append(sources, monitor.NewClaudeSource(10*time.Minute))
@@ -47,6 +84,21 @@ func buildSources(cfg *config.Config) []monitor.Source {
 	if cfg.Sources.Gemini {
 		sources = append(sources, monitor.NewGeminiSource(10*time.Minute))
 	}
+	if r := cfg.Sources.Remote; r.Enabled {
+		sources = append(sources, monitor.NewRemoteSource(r.URL, os.Getenv(r.APIKeyEnv), r.Interval, 10*time.Minute))
+	}
+	if s := cfg.Sources.SSH; s.Enabled {
+		sources = append(sources, monitor.NewSSHSource(s.Host, s.Path, s.IdentityFile, s.Interval, 10*time.Minute, config.DefaultSSHMirrorDir()))
+	}
+	if k := cfg.Sources.Kubernetes; k.Enabled {
+		sources = append(sources, monitor.NewKubernetesSource(k.Context, k.Namespace, k.Selector, k.Container, k.Interval, 10*time.Minute, config.DefaultKubernetesMirrorDir()))
+	}
+	if s := cfg.Sources.Self; s.Enabled {
+		sources = append(sources, monitor.NewSelfSource(s.Interval, s.MemoryBudget))
+	}
+	if cfg.Sources.External.Enabled && external != nil {
+		sources = append(sources, external)
+	}
 	return sources
 }
 
@@ -72,7 +124,34 @@ func printVersion(output io.Writer) {
 	_, _ = fmt.Fprintln(output, version)
 }
 
+// versionInfo returns the build metadata reported by /api/version.
+func versionInfo() ws.VersionInfo {
+	return ws.VersionInfo{
+		Version:   version,
+		Commit:    commit,
+		BuildDate: buildDate,
+		GoVersion: runtime.Version(),
+		Frontend:  frontend.Hash(),
+	}
+}
+
 func main() {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		applyBuildInfo(info)
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "debug" {
+		os.Exit(runDebug(os.Args[2:], os.Stdout, os.Stderr))
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "stats" {
+		os.Exit(runStats(os.Args[2:], os.Stdout, os.Stderr))
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		os.Exit(runImport(os.Args[2:], os.Stdout, os.Stderr))
+	}
+
 	opts, err := parseArgs(os.Args[1:], os.Stderr)
 	if err != nil {
 		os.Exit(2)
@@ -109,8 +188,19 @@ func main() {
 	}
 
 	store := session.NewStore()
+	store.SetHistory(cfg.Debug.StoreHistory)
+	store.SetEventLog(cfg.Monitor.EventLogSize)
+	store.SetMaxSubagents(cfg.Monitor.MaxSubagents)
 	broadcaster := ws.NewBroadcaster(store, cfg.Monitor.BroadcastThrottle, cfg.Monitor.SnapshotInterval, cfg.Server.MaxConnections)
 	broadcaster.SetPrivacyFilter(cfg.Privacy.NewPrivacyFilter())
+	broadcaster.SetAutoThrottle(cfg.Monitor.BroadcastThrottleMax, cfg.Monitor.BroadcastTargetRate)
+	broadcaster.SetCatchUpWindow(cfg.Monitor.CatchUpWindow)
+	broadcaster.SetLanguage(cfg.Display.Language)
+	if aliases, err := names.Load(config.DefaultNamesPath()); err != nil {
+		log.Printf("Warning: session names unavailable: %v", err)
+	} else {
+		broadcaster.SetAliases(aliases)
+	}
 
 	frontendDir := ""
 	if opts.devMode {
@@ -152,6 +242,16 @@ func main() {
 		log.Println("========================================")
 		authToken = ""
 	}
+	// ephemeralToken is set when the token is not from config and must be
+	// passed on to a replacement process during a handover.
+	ephemeralToken := authToken == ""
+	if ephemeralToken {
+		if inherited := os.Getenv(envHandoverToken); inherited != "" {
+			authToken = inherited
+			log.Println("Reusing auth token from the previous server process")
+		}
+	}
+	_ = os.Unsetenv(envHandoverToken)
 	if authToken == "" {
 		var err error
 		authToken, err = config.GenerateToken()
@@ -192,16 +292,31 @@ func main() {
 	}
 
 	// Stats tracker for gamification system.
-	gamStore := gamification.NewStore("")
+	// Mock sessions would otherwise count towards the real lifetime stats
+	// and unlock achievements nobody earned.
+	var gamStore gamification.Backend
+	if opts.mockMode {
+		gamStore = gamification.NewMemoryStore()
+		log.Println("Mock mode: stats and achievements are kept in memory and discarded on exit")
+	} else {
+		gamStore, err = gamification.OpenBackend(cfg.Gamification.Storage.Backend, cfg.Gamification.Storage.Path)
+		if err != nil {
+			log.Fatalf("Failed to open stats storage: %v", err)
+		}
+	}
 	seasonCfg := &gamification.SeasonConfig{
 		Enabled: cfg.Gamification.BattlePass.Enabled,
 		Season:  cfg.Gamification.BattlePass.Season,
+		End:     cfg.Gamification.BattlePass.SeasonEnd,
 	}
 	tracker, statsCh, err := gamification.NewStatsTracker(gamStore, cfg.Monitor.StatsEventBuffer, seasonCfg)
 	if err != nil {
 		log.Fatalf("Failed to initialize stats tracker: %v", err)
 	}
 
+	tracker.SetLocation(cfg.Display.Location())
+	tracker.SetToolAchievements(cfg.Gamification.ToolAchievements)
+	tracker.SetGrindLimits(cfg.Gamification.AntiGrind)
 	tracker.OnBattlePassProgress(func(progress gamification.BattlePassProgress, recentXP []gamification.XPEntry) {
 		broadcaster.BroadcastBattlePassProgress(ws.BattlePassProgressPayload{
 			XP:           progress.XP,
@@ -213,6 +328,7 @@ func main() {
 	})
 
 	tracker.OnAchievement(func(a gamification.Achievement, rw *gamification.Reward) {
+		a = a.Localize(server.Config().Display.Language)
 		payload := ws.AchievementUnlockedPayload{
 			ID:          a.ID,
 			Name:        a.Name,
@@ -234,6 +350,16 @@ func main() {
 	// Wire up replay API handler (serves replays even when recording is disabled).
 	replayAPIHandler := replay.NewHandler(replayDir, server.Authorize)
 	server.SetReplayHandler(replayAPIHandler)
+	server.SetRecapHistory(func(since time.Time) ([]*session.SessionState, error) {
+		return replay.FinalStatesSince(replayDir, since)
+	})
+
+	// Read-only session share links (POST /api/sessions/{id}/share).
+	if shareManager, err := share.NewManager(config.DefaultShareDir()); err != nil {
+		log.Printf("Session sharing disabled: %v", err)
+	} else {
+		server.SetShareManager(shareManager)
+	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -245,21 +371,60 @@ func main() {
 		tracker.Run(ctx)
 	}()
 
+	// Quick-launch templates: new sessions open in tmux and are on the
+	// track before their first log line. Pipelines hand the baton on as
+	// the monitor reports sessions ending.
+	launcher := launch.New(store)
+	launcher.Configure(cfg.Launch.Settings())
+	launcher.OnUpdate(broadcaster.QueueUpdate)
+	launcher.OnRemove(broadcaster.QueueRemoval)
+	launcher.OnPipeline(broadcaster.BroadcastPipeline)
+	server.SetLauncher(launcher)
+	go launcher.Run(ctx)
+
+	// Slack slash commands: /racer status, and /racer notify to hear when
+	// a session finishes.
+	bridge := chatops.New(store.GetAll, broadcaster.FilterSessions)
+	bridge.Configure(cfg.ChatOps.Slack.Settings())
+	server.SetChatOps(bridge)
+	go bridge.Run(ctx)
+
 	var mon *monitor.Monitor
+	var external *monitor.ExternalSource
+	var gen *mock.MockGenerator
 	if opts.mockMode {
 		log.Println("Starting in mock mode")
-		gen := mock.NewGenerator(store, broadcaster, cfg.Monitor.MockTickInterval)
+		gen = mock.NewGenerator(store, broadcaster, cfg.Monitor.MockTickInterval)
 		gen.SetStatsEvents(statsCh)
+		gen.SetRaceRules(cfg.RaceRules())
 		gen.Start(ctx)
 	} else {
 		log.Println("Starting in real mode (process monitoring)")
-		sources := buildSources(cfg)
+		external = monitor.NewExternalSource(cfg.Sources.External.SessionTTL)
+		sources := buildSources(cfg, external)
 		mon = monitor.NewMonitor(cfg, store, broadcaster, sources)
 		mon.SetStatsEvents(statsCh)
+		mon.SetTerminalHook(func(state *session.SessionState) {
+			launcher.Terminal(state)
+			bridge.Terminal(state)
+		})
+		mon.SetApprovalHook(func(state *session.SessionState) {
+			broadcaster.BroadcastApprovalNeeded(state, time.Now())
+		})
+		mon.SetCrashReporter(crash.NewReporter(config.DefaultCrashDir(), version))
+		// Live sessions are ranked against the last 1,000 that finished,
+		// starting from whatever the replay files remember.
+		history := session.NewBaseline(1000)
+		mon.SetBaseline(history)
+		if cfg.Replay.Enabled {
+			go seedBaseline(history, replayDir)
+		}
 		if rec != nil {
 			mon.SetSnapshotHook(rec.WriteSnapshot)
 		}
 		server.SetHealthCheck(mon.SourceHealthSnapshot)
+		server.SetHealthHistory(mon.SourceHealthHistory)
+		server.SetExternalSessions(external.Put)
 		go mon.Start(ctx)
 	}
 
@@ -267,6 +432,112 @@ func main() {
 		server.SetHealthHook(mon.SourceHealthSnapshot)
 	}
 
+	// History for the public /status page. It samples even while the page
+	// is disabled so enabling it on reload shows bars straight away.
+	statusBoard := status.NewBoard(func() []*session.SessionState {
+		return broadcaster.FilterSessions(store.GetAll())
+	})
+	server.SetStatusBoard(statusBoard)
+	go statusBoard.Run(ctx)
+
+	// Focus hints for wall dashboards; off until enabled via /api/director.
+	dir := director.New(func() []*session.SessionState {
+		return broadcaster.FilterSessions(store.GetAll())
+	})
+	dir.OnFocus(func(f director.Focus) {
+		broadcaster.BroadcastDirectorFocus(ws.DirectorFocusPayload{
+			SessionID: f.SessionID,
+			Name:      f.Name,
+			Reason:    string(f.Reason),
+			Until:     f.Until,
+		})
+	})
+	server.SetDirector(dir)
+	go dir.Run(ctx)
+
+	// Inverse alert: nothing has happened for too long during work hours.
+	dog := watchdog.New(func() []*session.SessionState {
+		return broadcaster.FilterSessions(store.GetAll())
+	})
+	dog.Configure(cfg.Watchdog.Settings(cfg.Display.Location()))
+	dog.OnAlert(func(a watchdog.Alert) {
+		log.Printf("Watchdog: no session activity since %s", a.QuietSince.Format(time.RFC3339))
+		broadcaster.BroadcastWatchdogAlert(ws.WatchdogAlertPayload{
+			QuietSince:      a.QuietSince,
+			At:              a.At,
+			LastSessionID:   a.LastSessionID,
+			LastSessionName: a.LastSessionName,
+		})
+	})
+	go dog.Run(ctx)
+
+	// Announcer lines; tracks state while disabled so a reload that turns
+	// it on does not replay the whole session list.
+	caster := commentary.New(func() []*session.SessionState {
+		return broadcaster.FilterSessions(store.GetAll())
+	})
+	caster.Configure(cfg.Commentary.Enabled, cfg.Display.Language, cfg.Commentary.Templates)
+	caster.OnLine(func(l commentary.Line) {
+		broadcaster.BroadcastCommentary(ws.CommentaryPayload{
+			Event:      string(l.Event),
+			SessionIDs: l.SessionIDs,
+			Text:       l.Text,
+			At:         l.At,
+		})
+	})
+	go caster.Run(ctx)
+
+	// Races between sessions, started via POST /api/heats or detected when
+	// sessions on one project start together.
+	heatMgr := heats.New(func() []*session.SessionState {
+		return broadcaster.FilterSessions(store.GetAll())
+	})
+	heatMgr.SetMetric(cfg.Race.ProgressMetric)
+	heatMgr.SetAutoWindow(cfg.Race.AutoHeatWindow)
+	heatMgr.OnUpdate(broadcaster.BroadcastHeat)
+	heatMgr.OnResult(func(h heats.Heat) {
+		tracker.RecordHeat(h.Result())
+		broadcaster.BroadcastRaceFinished(h)
+	})
+	server.SetHeats(heatMgr)
+	go heatMgr.Run(ctx)
+
+	// Benchmark runner: its agents are found by the real session sources,
+	// so there is nothing to measure in mock mode.
+	var bench *benchmark.Runner
+	if !opts.mockMode {
+		if bench, err = benchmark.NewRunner(config.DefaultBenchmarksDir(), store.GetAll); err != nil {
+			log.Printf("Benchmarks disabled: %v", err)
+		} else {
+			bench.Configure(cfg.Benchmarks.Settings())
+			server.SetBenchmarks(bench)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				bench.Run(ctx)
+			}()
+		}
+	}
+
+	server.SetVersionInfo(versionInfo())
+
+	// Once-a-day release check; development builds have nothing to compare.
+	if cfg.Updates.Check && !opts.mockMode && update.Enabled(version) {
+		checker := update.NewChecker(version, cfg.Updates.Repo, config.DefaultUpdateStatePath())
+		checker.OnUpdate(func(rel update.Release) {
+			broadcaster.SetUpdateAvailable(updatePayload(rel))
+		})
+		server.SetUpdateStatus(func() *ws.UpdateAvailablePayload {
+			rel := checker.Latest()
+			if rel == nil {
+				return nil
+			}
+			p := updatePayload(*rel)
+			return &p
+		})
+		go checker.Run(ctx)
+	}
+
 	mux := http.NewServeMux()
 	server.SetupRoutes(mux)
 	httpServer := ws.NewHTTPServer(cfg.Server.Host, cfg.Server.Port, cfg.Server.TLSEnabled(), mux)
@@ -316,6 +587,15 @@ func main() {
 				oldCfg.Monitor.SnapshotInterval != newCfg.Monitor.SnapshotInterval {
 				broadcaster.SetConfig(newCfg.Monitor.BroadcastThrottle, newCfg.Monitor.SnapshotInterval)
 			}
+			if oldCfg.Monitor.BroadcastThrottleMax != newCfg.Monitor.BroadcastThrottleMax ||
+				oldCfg.Monitor.BroadcastTargetRate != newCfg.Monitor.BroadcastTargetRate {
+				broadcaster.SetAutoThrottle(newCfg.Monitor.BroadcastThrottleMax, newCfg.Monitor.BroadcastTargetRate)
+			}
+			broadcaster.SetCatchUpWindow(newCfg.Monitor.CatchUpWindow)
+			broadcaster.SetLanguage(newCfg.Display.Language)
+			store.SetHistory(newCfg.Debug.StoreHistory)
+			store.SetEventLog(newCfg.Monitor.EventLogSize)
+			store.SetMaxSubagents(newCfg.Monitor.MaxSubagents)
 
 			// Apply monitor-level config (models, token norm, timings).
 			if mon != nil {
@@ -323,10 +603,30 @@ func main() {
 
 				// Rebuild sources if source configuration changed.
 				if oldCfg.Sources != newCfg.Sources {
-					mon.SetSources(buildSources(newCfg))
+					external.SetTTL(newCfg.Sources.External.SessionTTL)
+					mon.SetSources(buildSources(newCfg, external))
 				}
 			}
 
+			if gen != nil {
+				gen.SetRaceRules(newCfg.RaceRules())
+			}
+			caster.Configure(newCfg.Commentary.Enabled, newCfg.Display.Language, newCfg.Commentary.Templates)
+			heatMgr.SetMetric(newCfg.Race.ProgressMetric)
+			heatMgr.SetAutoWindow(newCfg.Race.AutoHeatWindow)
+			tracker.SetLocation(newCfg.Display.Location())
+			tracker.SetGrindLimits(newCfg.Gamification.AntiGrind)
+			if bench != nil {
+				bench.Configure(newCfg.Benchmarks.Settings())
+			}
+			launcher.Configure(newCfg.Launch.Settings())
+			bridge.Configure(newCfg.ChatOps.Slack.Settings())
+			// Reconfiguring restarts the quiet spell, so only do it when
+			// the watchdog's own settings changed.
+			if !reflect.DeepEqual(oldCfg.Watchdog, newCfg.Watchdog) || oldCfg.Display.TimeZone != newCfg.Display.TimeZone {
+				dog.Configure(newCfg.Watchdog.Settings(newCfg.Display.Location()))
+			}
+
 			server.SetConfig(newCfg)
 			log.Printf("Config reload complete (%d change(s) applied)", len(changes))
 		}
@@ -334,39 +634,109 @@ func main() {
 
 	cleanup := func() {
 		signal.Stop(sighupCh)
+		tracker.Flush() // record events already queued before the monitor stops
 		cancel()
 		<-sighupDone // wait for any in-flight reload to finish
 		broadcaster.Stop()
-		wg.Wait() // allow stats tracker to flush
+		wg.Wait() // allow stats tracker to save
+		if err := gamStore.Close(); err != nil {
+			log.Printf("Closing stats storage: %v", err)
+		}
 		if rec != nil {
 			rec.Close()
 		}
 	}
 
+	// The listener may be inherited from systemd socket activation or from
+	// a previous server process handing over during an upgrade.
+	ln, lnSource, err := handover.Listen(httpServer.Addr)
+	if err != nil {
+		cleanup()
+		log.Fatalf("Server error: %v", err)
+	}
+	if lnSource != handover.SourceNew {
+		log.Printf("Using listener inherited from %s", lnSource)
+	}
+
+	// SIGINT/SIGTERM: tell clients why they are being disconnected, close
+	// their sockets with a going-away frame, then drain in-flight HTTP
+	// requests. SIGUSR2: start the (possibly upgraded) binary on the same
+	// listener and shut down the same way once it is ready, so clients
+	// only see a brief reconnect. cleanup runs once Serve returns.
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
 	defer signal.Stop(sigCh)
+	upgradeCh := make(chan os.Signal, 1)
+	if sigs := handover.UpgradeSignals(); len(sigs) > 0 {
+		signal.Notify(upgradeCh, sigs...)
+	}
+	defer signal.Stop(upgradeCh)
+	shutdownDone := make(chan struct{})
 	go func() {
-		sig := <-sigCh
-		log.Printf("Shutting down after signal: %s", sig)
-		cancel()
-		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer close(shutdownDone)
+		var reason string
+		for reason == "" {
+			select {
+			case sig := <-sigCh:
+				log.Printf("Shutting down after signal: %s", sig)
+				reason = "server received " + sig.String()
+			case <-upgradeCh:
+				log.Println("Upgrade requested: starting a new server on the same listener")
+				tracker.Save() // the new process loads stats on startup
+				var env []string
+				if ephemeralToken {
+					env = append(env, envHandoverToken+"="+authToken)
+				}
+				proc, err := handover.Spawn(ln, os.Args[1:], env, handoverTimeout)
+				if err != nil {
+					log.Printf("Handover failed, still serving: %v", err)
+					continue
+				}
+				log.Printf("Handed over to pid %d", proc.Pid)
+				tracker.Release() // the new process owns the stats from here on
+				reason = "server upgrading"
+			}
+		}
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), shutdownTimeout)
 		defer shutdownCancel()
+		broadcaster.Shutdown(shutdownCtx, reason)
 		if err := httpServer.Shutdown(shutdownCtx); err != nil {
 			log.Printf("HTTP shutdown error: %v", err)
 		}
 	}()
 
-	log.Printf("Server listening on %s (%s)", httpServer.Addr, cfg.Server.Scheme())
+	log.Printf("Server listening on %s (%s)", ln.Addr(), cfg.Server.Scheme())
+	if err := handover.NotifyReady(); err != nil {
+		log.Printf("Handover ready notification failed: %v", err)
+	}
 	var listenErr error
 	if cfg.Server.TLSEnabled() {
-		listenErr = httpServer.ListenAndServeTLS(cfg.Server.TLSCert, cfg.Server.TLSKey)
+		listenErr = httpServer.ServeTLS(ln, cfg.Server.TLSCert, cfg.Server.TLSKey)
 	} else {
-		listenErr = httpServer.ListenAndServe()
+		listenErr = httpServer.Serve(ln)
 	}
 	if listenErr != nil && !errors.Is(listenErr, http.ErrServerClosed) {
 		cleanup()
 		log.Fatalf("Server error: %v", listenErr)
 	}
+	<-shutdownDone // Serve returns as soon as Shutdown starts
 	cleanup()
+	log.Println("Shutdown complete")
+}
+
+// seedBaseline adds the finished sessions recorded in the replay files in
+// dir to b.
+func seedBaseline(b *session.Baseline, dir string) {
+	states, err := replay.FinalStates(dir)
+	if err != nil {
+		log.Printf("Percentile history unavailable: %v", err)
+		return
+	}
+	var done []*session.SessionState
+	for _, s := range states {
+		if s.IsTerminal() {
+			done = append(done, s)
+		}
+	}
+	b.Add(done...)
 }
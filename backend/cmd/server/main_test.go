@@ -14,6 +14,7 @@ import (
 
 	"github.com/agent-racer/backend/internal/config"
 	"github.com/agent-racer/backend/internal/gamification"
+	"github.com/agent-racer/backend/internal/monitor"
 	"github.com/agent-racer/backend/internal/session"
 	"github.com/agent-racer/backend/internal/ws"
 )
@@ -81,12 +82,22 @@ func TestBuildSources(t *testing.T) {
 			sources: config.SourcesConfig{Claude: true, Codex: true, Gemini: true},
 			want:    []string{"claude", "codex", "gemini"},
 		},
+		{
+			name:    "self",
+			sources: config.SourcesConfig{Self: config.SelfSourceConfig{Enabled: true, Interval: time.Second, MemoryBudget: 64}},
+			want:    []string{"self"},
+		},
+		{
+			name:    "external",
+			sources: config.SourcesConfig{External: config.ExternalSourceConfig{Enabled: true, SessionTTL: time.Minute}},
+			want:    []string{"external"},
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			cfg := &config.Config{Sources: tt.sources}
-			sources := buildSources(cfg)
+			sources := buildSources(cfg, monitor.NewExternalSource(time.Minute))
 			if len(sources) != len(tt.want) {
 				t.Fatalf("got %d sources, want %d", len(sources), len(tt.want))
 			}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/agent-racer/backend/internal/config"
+	"github.com/agent-racer/backend/internal/gamification"
+	"github.com/agent-racer/backend/internal/monitor"
+	"github.com/agent-racer/backend/internal/replay"
+	"github.com/agent-racer/backend/internal/session"
+)
+
+// rebuildOptions describes where `stats rebuild` reads history from and
+// where it writes the result.
+type rebuildOptions struct {
+	cfg       *config.Config
+	from      string // "replays" or "transcripts"
+	replayDir string
+	sources   []monitor.Source // read when from is "transcripts"
+	store     gamification.Backend
+	dryRun    bool
+}
+
+// runStats dispatches `stats` subcommands and returns the exit code.
+func runStats(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] != "rebuild" {
+		_, _ = fmt.Fprintln(stderr, "usage: agent-racer-server stats rebuild [flags]")
+		return 2
+	}
+	return runStatsRebuild(args[1:], stdout, stderr)
+}
+
+// runStatsRebuild recomputes the gamification stats file from replay
+// recordings or agent transcripts on disk.
+func runStatsRebuild(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("agent-racer-server stats rebuild", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	cfgPath := fs.String("config", "", "Path to config file (defaults to ~/.config/agent-racer/config.yaml)")
+	from := fs.String("from", "replays", `History to rebuild from: "replays" (recorded sessions) or "transcripts" (agent logs on disk)`)
+	since := dayDuration(365 * 24 * time.Hour)
+	fs.Var(&since, "since", "With -from transcripts, how far back to read transcripts, e.g. 90d or 12h")
+	dryRun := fs.Bool("dry-run", false, "Print what would change without writing the stats file")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *from != "replays" && *from != "transcripts" {
+		_, _ = fmt.Fprintf(stderr, "-from must be replays or transcripts, got %q\n", *from)
+		return 2
+	}
+
+	path := *cfgPath
+	if path == "" {
+		path = config.DefaultConfigPath()
+	}
+	cfg, _, err := config.LoadOrDefault(path)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "load config: %v\n", err)
+		return 1
+	}
+
+	store, err := gamification.OpenBackend(cfg.Gamification.Storage.Backend, cfg.Gamification.Storage.Path)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "open stats storage: %v\n", err)
+		return 1
+	}
+	defer func() { _ = store.Close() }()
+
+	opts := rebuildOptions{
+		cfg:       cfg,
+		from:      *from,
+		replayDir: config.DefaultReplayDir(),
+		sources:   transcriptSources(cfg, time.Duration(since)),
+		store:     store,
+		dryRun:    *dryRun,
+	}
+
+	if err := rebuildStats(opts, stdout, stderr); err != nil {
+		_, _ = fmt.Fprintf(stderr, "stats rebuild: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// rebuildStats recomputes stats from opts' history, prints how they differ
+// from the saved ones and, unless it is a dry run, replaces them; a JSON
+// store keeps the file it replaces as its backup. Stats that can't be
+// loaded are rebuilt from scratch.
+func rebuildStats(opts rebuildOptions, stdout, stderr io.Writer) error {
+	var sessions []*session.SessionState
+	switch opts.from {
+	case "replays":
+		var err error
+		if sessions, err = replay.FinalStates(opts.replayDir); err != nil {
+			return fmt.Errorf("read replays: %w", err)
+		}
+	case "transcripts":
+		for _, src := range opts.sources {
+			states, err := monitor.ReadTranscripts(src, opts.cfg)
+			if err != nil {
+				_, _ = fmt.Fprintf(stderr, "warning: %v\n", err)
+			}
+			sessions = append(sessions, states...)
+		}
+	}
+	if len(sessions) == 0 {
+		return errors.New("no past sessions found; nothing to rebuild from")
+	}
+
+	prev, err := opts.store.Load()
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "warning: %v; rebuilding from scratch\n", err)
+		prev = nil
+	}
+	stats := gamification.Rebuild(sessions, prev, opts.cfg.Display.Location(), opts.cfg.Gamification.ToolAchievements, opts.cfg.Gamification.AntiGrind)
+
+	_, _ = fmt.Fprintf(stdout, "rebuilt from %d sessions in %s\n", len(sessions), opts.from)
+	if prev == nil {
+		prev = &gamification.Stats{}
+	}
+	printStatsChange(stdout, "sessions", prev.TotalSessions, stats.TotalSessions)
+	printStatsChange(stdout, "completions", prev.TotalCompletions, stats.TotalCompletions)
+	printStatsChange(stdout, "errors", prev.TotalErrors, stats.TotalErrors)
+	printStatsChange(stdout, "achievements", len(prev.AchievementsUnlocked), len(stats.AchievementsUnlocked))
+	printStatsChange(stdout, "battle pass XP", prev.BattlePass.XP, stats.BattlePass.XP)
+	if stats.TotalSessions < prev.TotalSessions {
+		_, _ = fmt.Fprintln(stdout, "note: the history holds fewer sessions than the current stats count")
+	}
+	if opts.dryRun {
+		_, _ = fmt.Fprintln(stdout, "dry run: stats file not written")
+		return nil
+	}
+
+	if err := opts.store.Save(stats); err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(stdout, "wrote %s\n", opts.store.Path())
+	if js, ok := opts.store.(*gamification.Store); ok {
+		if _, err := os.Stat(js.BackupPath()); err == nil {
+			_, _ = fmt.Fprintf(stdout, "previous stats saved to %s\n", js.BackupPath())
+		}
+	}
+	return nil
+}
+
+// transcriptSources returns the enabled sources that keep transcripts on
+// this machine, discovering those written within since.
+func transcriptSources(cfg *config.Config, since time.Duration) []monitor.Source {
+	var sources []monitor.Source
+	if cfg.Sources.Claude {
+		sources = append(sources, monitor.NewClaudeSource(since))
+	}
+	if cfg.Sources.Codex {
+		sources = append(sources, monitor.NewCodexSource(since))
+	}
+	if cfg.Sources.Gemini {
+		sources = append(sources, monitor.NewGeminiSource(since))
+	}
+	return sources
+}
+
+func printStatsChange(w io.Writer, label string, before, after int) {
+	_, _ = fmt.Fprintf(w, "  %-15s %d → %d\n", label, before, after)
+}
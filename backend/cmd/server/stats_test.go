@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agent-racer/backend/internal/config"
+	"github.com/agent-racer/backend/internal/gamification"
+	"github.com/agent-racer/backend/internal/replay"
+	"github.com/agent-racer/backend/internal/session"
+)
+
+func writeReplay(t *testing.T, dir string, sessions ...*session.SessionState) {
+	t.Helper()
+	data, err := json.Marshal(replay.Snapshot{Timestamp: time.Now(), Sessions: sessions})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "2026-01-05_09-00-00.jsonl"), append(data, '\n'), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRebuildStats(t *testing.T) {
+	cfg, _, err := config.LoadOrDefault(filepath.Join(t.TempDir(), "missing.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayDir := t.TempDir()
+	start := time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)
+	end := start.Add(time.Hour)
+	writeReplay(t, replayDir,
+		&session.SessionState{ID: "a", Source: "claude", Activity: session.Complete, StartedAt: start, LastActivityAt: end, CompletedAt: &end},
+		&session.SessionState{ID: "b", Source: "codex", Activity: session.Errored, StartedAt: start, LastActivityAt: end, CompletedAt: &end},
+	)
+
+	store := gamification.NewStore(t.TempDir())
+	if err := os.WriteFile(store.Path(), []byte("{corrupt"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	opts := rebuildOptions{cfg: cfg, from: "replays", replayDir: replayDir, store: store, dryRun: true}
+
+	var stdout, stderr bytes.Buffer
+	if err := rebuildStats(opts, &stdout, &stderr); err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if !strings.Contains(stderr.String(), "rebuilding from scratch") || !strings.Contains(stdout.String(), "sessions        0 → 2") {
+		t.Errorf("stdout = %q, stderr = %q", stdout.String(), stderr.String())
+	}
+	if data, _ := os.ReadFile(store.Path()); string(data) != "{corrupt" {
+		t.Fatal("dry run wrote the stats file")
+	}
+
+	opts.dryRun = false
+	if err := rebuildStats(opts, &stdout, &stderr); err != nil {
+		t.Fatalf("rebuild: %v", err)
+	}
+	stats, err := store.Load()
+	if err != nil {
+		t.Fatalf("load rebuilt stats: %v", err)
+	}
+	if stats.TotalSessions != 2 || stats.TotalCompletions != 1 || stats.TotalErrors != 1 {
+		t.Errorf("stats = %d sessions, %d completions, %d errors", stats.TotalSessions, stats.TotalCompletions, stats.TotalErrors)
+	}
+	if data, _ := os.ReadFile(store.Path() + ".bak"); string(data) != "{corrupt" {
+		t.Errorf("backup = %q, want the previous file", data)
+	}
+
+	opts.replayDir = filepath.Join(t.TempDir(), "missing")
+	if err := rebuildStats(opts, &stdout, &stderr); err == nil {
+		t.Error("rebuilding from no history succeeded")
+	}
+}
+
+func TestRunStatsUsage(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := runStats(nil, &stdout, &stderr); code != 2 {
+		t.Errorf("runStats() = %d, want 2", code)
+	}
+	if code := runStats([]string{"rebuild", "-from", "database"}, &stdout, &stderr); code != 2 {
+		t.Errorf("runStats(-from database) = %d, want 2", code)
+	}
+}
@@ -0,0 +1,516 @@
+// Package benchmark runs configured agent commands side by side, on a
+// schedule or on demand, and keeps a table of how each one did. Each agent
+// runs as a child process in its own scratch directory (a fresh git
+// worktree when the task names a repository), so the usual session sources
+// pick it up and it races like any other session; the runner then reads
+// its final numbers back from the session store.
+package benchmark
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/agent-racer/backend/internal/session"
+)
+
+const (
+	// DefaultTimeout bounds a single agent run.
+	DefaultTimeout = 30 * time.Minute
+	// MinSchedule keeps a misconfigured schedule from launching agents
+	// back to back.
+	MinSchedule = 10 * time.Minute
+
+	// maxResults bounds the saved results table; the oldest rows go
+	// first, and their logs with them.
+	maxResults = 500
+	// sampleInterval is how often a running agent's session is looked up.
+	sampleInterval = time.Second
+	resultsFile    = "results.json"
+)
+
+var (
+	ErrNoTasks     = errors.New("no benchmark tasks configured")
+	ErrUnknownTask = errors.New("unknown benchmark task")
+	ErrBusy        = errors.New("benchmark already running")
+)
+
+// Agent is one contestant in a task.
+type Agent struct {
+	Label   string   `yaml:"label" json:"label"`
+	Command []string `yaml:"command" json:"command"` // argv, e.g. [claude, -p, "fix the flaky test"]
+}
+
+// Task is one benchmark: the same job given to every agent at once.
+type Task struct {
+	Name string `yaml:"name" json:"name"`
+	// Repo is a git repository to run in. Each agent gets its own
+	// detached worktree of HEAD, removed afterwards. Empty runs each agent
+	// in an empty scratch directory.
+	Repo   string  `yaml:"repo" json:"repo,omitempty"`
+	Agents []Agent `yaml:"agents" json:"agents"`
+}
+
+// Equal reports whether t and o describe the same task.
+func (t Task) Equal(o Task) bool {
+	return t.Name == o.Name && t.Repo == o.Repo && slices.EqualFunc(t.Agents, o.Agents, func(a, b Agent) bool {
+		return a.Label == b.Label && slices.Equal(a.Command, b.Command)
+	})
+}
+
+// ValidateTasks returns one message per problem in tasks.
+func ValidateTasks(tasks []Task) []string {
+	var errs []string
+	names := make(map[string]bool, len(tasks))
+	for i := 0; i < len(tasks); i++ {
+		t := tasks[i]
+		if t.Name == "" {
+			errs = append(errs, fmt.Sprintf("task %d: name is required", i))
+		} else if names[t.Name] {
+			errs = append(errs, fmt.Sprintf("task %q: duplicate name", t.Name))
+		}
+		names[t.Name] = true
+		if len(t.Agents) == 0 {
+			errs = append(errs, fmt.Sprintf("task %q: needs at least one agent", t.Name))
+		}
+		labels := make(map[string]bool, len(t.Agents))
+		for j := 0; j < len(t.Agents); j++ {
+			a := t.Agents[j]
+			if a.Label == "" || strings.ContainsAny(a.Label, `/\`) {
+				errs = append(errs, fmt.Sprintf("task %q agent %d: label must be non-empty and contain no slashes", t.Name, j))
+			} else if labels[a.Label] {
+				errs = append(errs, fmt.Sprintf("task %q: duplicate agent label %q", t.Name, a.Label))
+			}
+			labels[a.Label] = true
+			if len(a.Command) == 0 || a.Command[0] == "" {
+				errs = append(errs, fmt.Sprintf("task %q agent %q: command is required", t.Name, a.Label))
+			}
+		}
+	}
+	return errs
+}
+
+// Settings are the runner options taken from config.
+type Settings struct {
+	// Schedule runs every task this often; 0 runs them only on request.
+	Schedule time.Duration
+	Timeout  time.Duration
+	Tasks    []Task
+}
+
+// Result is one agent's row in the benchmarks table.
+type Result struct {
+	RunID           string    `json:"runId"`
+	Task            string    `json:"task"`
+	Agent           string    `json:"agent"`
+	StartedAt       time.Time `json:"startedAt"`
+	EndedAt         time.Time `json:"endedAt"`
+	DurationSeconds float64   `json:"durationSeconds"`
+	ExitCode        int       `json:"exitCode"`
+	Error           string    `json:"error,omitempty"` // launch failure or timeout
+	Log             string    `json:"log,omitempty"`   // combined stdout and stderr
+
+	// Taken from the agent's session; empty when no session was seen.
+	SessionID       string `json:"sessionId,omitempty"`
+	Model           string `json:"model,omitempty"`
+	TokensUsed      int    `json:"tokensUsed"`
+	MessageCount    int    `json:"messageCount"`
+	ToolCallCount   int    `json:"toolCallCount"`
+	CompactionCount int    `json:"compactionCount"`
+}
+
+// Run names one task run started by Trigger.
+type Run struct {
+	Task  string `json:"task"`
+	RunID string `json:"runId"`
+}
+
+// Runner launches tasks and keeps their results.
+type Runner struct {
+	dir      string
+	sessions func() []*session.SessionState
+	now      func() time.Time
+
+	mu       sync.Mutex
+	ctx      context.Context
+	settings Settings
+	running  map[string]string // task name -> run ID
+	results  []Result
+	wake     chan struct{}
+	wg       sync.WaitGroup
+}
+
+// NewRunner returns a runner keeping results and logs in dir and looking
+// agents' sessions up in sessions.
+func NewRunner(dir string, sessions func() []*session.SessionState) (*Runner, error) {
+	r := &Runner{
+		dir:      dir,
+		sessions: sessions,
+		now:      time.Now,
+		ctx:      context.Background(),
+		settings: Settings{Timeout: DefaultTimeout},
+		running:  make(map[string]string),
+		wake:     make(chan struct{}, 1),
+	}
+	data, err := os.ReadFile(filepath.Join(dir, resultsFile))
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return nil, err
+	default:
+		if err := json.Unmarshal(data, &r.results); err != nil {
+			return nil, fmt.Errorf("read %s: %w", resultsFile, err)
+		}
+	}
+	return r, nil
+}
+
+// Configure replaces the settings. Runs already started keep theirs.
+func (r *Runner) Configure(s Settings) {
+	if s.Timeout <= 0 {
+		s.Timeout = DefaultTimeout
+	}
+	r.mu.Lock()
+	r.settings = s
+	r.settings.Tasks = append([]Task(nil), s.Tasks...)
+	r.mu.Unlock()
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Tasks returns the names of the configured tasks.
+func (r *Runner) Tasks() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	names := make([]string, 0, len(r.settings.Tasks))
+	for _, t := range r.settings.Tasks {
+		names = append(names, t.Name)
+	}
+	return names
+}
+
+// Running returns the tasks with a run in progress.
+func (r *Runner) Running() []Run {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]Run, 0, len(r.running))
+	for task, id := range r.running {
+		out = append(out, Run{Task: task, RunID: id})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Task < out[j].Task })
+	return out
+}
+
+// Results returns the results table, newest first.
+func (r *Runner) Results() []Result {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]Result, len(r.results))
+	for i := 0; i < len(r.results); i++ {
+		out[i] = r.results[len(r.results)-1-i]
+	}
+	return out
+}
+
+// Run fires the schedule until ctx is done, then waits for runs in
+// progress to stop. Runs started by Trigger are cancelled with ctx.
+func (r *Runner) Run(ctx context.Context) {
+	r.mu.Lock()
+	r.ctx = ctx
+	r.mu.Unlock()
+	defer r.wg.Wait()
+
+	for {
+		r.mu.Lock()
+		schedule := r.settings.Schedule
+		r.mu.Unlock()
+
+		var tick <-chan time.Time
+		var timer *time.Timer
+		if schedule > 0 {
+			timer = time.NewTimer(schedule)
+			tick = timer.C
+		}
+		select {
+		case <-ctx.Done():
+			if timer != nil {
+				timer.Stop()
+			}
+			return
+		case <-r.wake:
+		case <-tick:
+			if _, err := r.Trigger(""); err != nil && !errors.Is(err, ErrBusy) && !errors.Is(err, ErrNoTasks) {
+				slog.Warn("scheduled benchmark failed to start", "error", err)
+			}
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
+
+// Trigger starts the named task, or every idle task when name is empty,
+// and returns without waiting for them.
+func (r *Runner) Trigger(name string) ([]Run, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.settings.Tasks) == 0 {
+		return nil, ErrNoTasks
+	}
+
+	var tasks []Task
+	for _, t := range r.settings.Tasks {
+		if name != "" && t.Name != name {
+			continue
+		}
+		if _, busy := r.running[t.Name]; busy && name == "" {
+			continue
+		}
+		tasks = append(tasks, t)
+	}
+	switch {
+	case name != "" && len(tasks) == 0:
+		return nil, fmt.Errorf("%w %q", ErrUnknownTask, name)
+	case name != "" && r.running[name] != "":
+		return nil, fmt.Errorf("%w: %s", ErrBusy, name)
+	case len(tasks) == 0:
+		return nil, ErrBusy
+	}
+
+	stamp := r.now().UTC().Format("20060102T150405")
+	runs := make([]Run, 0, len(tasks))
+	for _, t := range tasks {
+		id := stamp + "-" + runSuffix() + "-" + t.Name
+		r.running[t.Name] = id
+		runs = append(runs, Run{Task: t.Name, RunID: id})
+		r.wg.Add(1)
+		go r.runTask(r.ctx, t, id, r.settings.Timeout)
+	}
+	return runs, nil
+}
+
+// runSuffix returns a short random string that keeps apart the IDs of
+// runs started in the same second.
+func runSuffix() string {
+	b := make([]byte, 3)
+	_, _ = rand.Read(b) // never fails
+	return hex.EncodeToString(b)
+}
+
+// runTask runs every agent of t at once and records their results.
+func (r *Runner) runTask(ctx context.Context, t Task, runID string, timeout time.Duration) {
+	defer r.wg.Done()
+	slog.Info("benchmark started", "task", t.Name, "run", runID, "agents", len(t.Agents))
+
+	results := make([]Result, len(t.Agents))
+	var wg sync.WaitGroup
+	for i := 0; i < len(t.Agents); i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = r.runAgent(ctx, t, t.Agents[i], runID, timeout)
+		}(i)
+	}
+	wg.Wait()
+
+	r.mu.Lock()
+	delete(r.running, t.Name)
+	r.results = append(r.results, results...)
+	var evicted []Result
+	if n := len(r.results); n > maxResults {
+		evicted = append(evicted, r.results[:n-maxResults]...)
+		r.results = append([]Result(nil), r.results[n-maxResults:]...)
+	}
+	saved := append([]Result(nil), r.results...)
+	r.mu.Unlock()
+
+	if err := r.save(saved); err != nil {
+		slog.Error("failed to save benchmark results", "error", err)
+	}
+	r.removeLogs(evicted)
+	slog.Info("benchmark finished", "task", t.Name, "run", runID)
+}
+
+// runAgent runs one agent to completion in a scratch directory.
+func (r *Runner) runAgent(ctx context.Context, t Task, a Agent, runID string, timeout time.Duration) Result {
+	res := Result{RunID: runID, Task: t.Name, Agent: a.Label, StartedAt: r.now(), ExitCode: -1}
+	finish := func() Result {
+		res.EndedAt = r.now()
+		res.DurationSeconds = res.EndedAt.Sub(res.StartedAt).Seconds()
+		return res
+	}
+
+	dir, cleanup, err := workspace(ctx, t.Repo)
+	if err != nil {
+		res.Error = err.Error()
+		return finish()
+	}
+	defer cleanup()
+
+	logDir := filepath.Join(r.dir, "logs")
+	if err := os.MkdirAll(logDir, 0o755); err != nil {
+		res.Error = err.Error()
+		return finish()
+	}
+	res.Log = filepath.Join(logDir, runID+"-"+a.Label+".log")
+	logFile, err := os.Create(res.Log)
+	if err != nil {
+		res.Error = err.Error()
+		return finish()
+	}
+	defer logFile.Close()
+
+	runCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	cmd := exec.CommandContext(runCtx, a.Command[0], a.Command[1:]...)
+	cmd.Dir = dir
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	cmd.WaitDelay = 5 * time.Second
+	if err := cmd.Start(); err != nil {
+		res.Error = err.Error()
+		return finish()
+	}
+
+	// Follow the agent's session while it runs: it may leave the store
+	// soon after the process exits.
+	done := make(chan struct{})
+	sampled := make(chan *session.SessionState, 1)
+	go func() {
+		var last *session.SessionState
+		ticker := time.NewTicker(sampleInterval)
+		defer ticker.Stop()
+		for {
+			if s := matchSession(r.sessions(), dir); s != nil {
+				last = s
+			}
+			select {
+			case <-done:
+				sampled <- last
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	err = cmd.Wait()
+	close(done)
+	last := <-sampled
+	if s := matchSession(r.sessions(), dir); s != nil {
+		last = s
+	}
+
+	res.ExitCode = cmd.ProcessState.ExitCode()
+	switch {
+	case errors.Is(runCtx.Err(), context.DeadlineExceeded):
+		res.Error = fmt.Sprintf("timed out after %s", timeout)
+	case ctx.Err() != nil:
+		res.Error = "cancelled"
+	case err != nil && res.ExitCode < 0:
+		res.Error = err.Error()
+	}
+	if last != nil {
+		res.SessionID = last.ID
+		res.Model = last.Model
+		res.TokensUsed = last.TokensUsed
+		res.MessageCount = last.MessageCount
+		res.ToolCallCount = last.ToolCallCount
+		res.CompactionCount = last.CompactionCount
+	}
+	return finish()
+}
+
+// workspace creates the directory an agent runs in and returns a function
+// that removes it.
+func workspace(ctx context.Context, repo string) (string, func(), error) {
+	tmp, err := os.MkdirTemp("", "agent-racer-bench-")
+	if err != nil {
+		return "", nil, err
+	}
+	// Sessions report resolved paths (macOS /tmp is a symlink).
+	if resolved, err := filepath.EvalSymlinks(tmp); err == nil {
+		tmp = resolved
+	}
+	dir := filepath.Join(tmp, "work")
+	removeTmp := func() { _ = os.RemoveAll(tmp) }
+
+	if repo == "" {
+		if err := os.Mkdir(dir, 0o755); err != nil {
+			removeTmp()
+			return "", nil, err
+		}
+		return dir, removeTmp, nil
+	}
+
+	out, err := exec.CommandContext(ctx, "git", "-C", repo, "worktree", "add", "--detach", dir, "HEAD").CombinedOutput()
+	if err != nil {
+		removeTmp()
+		return "", nil, fmt.Errorf("git worktree add: %v: %s", err, strings.TrimSpace(string(out)))
+	}
+	return dir, func() {
+		if out, err := exec.Command("git", "-C", repo, "worktree", "remove", "--force", dir).CombinedOutput(); err != nil {
+			slog.Warn("failed to remove benchmark worktree", "dir", dir, "error", err, "output", strings.TrimSpace(string(out)))
+		}
+		removeTmp()
+	}, nil
+}
+
+// matchSession returns the most recently started session working in dir
+// or below it.
+func matchSession(states []*session.SessionState, dir string) *session.SessionState {
+	var best *session.SessionState
+	for _, s := range states {
+		if s.WorkingDir != dir && !strings.HasPrefix(s.WorkingDir, dir+string(filepath.Separator)) {
+			continue
+		}
+		if best == nil || s.StartedAt.After(best.StartedAt) {
+			best = s
+		}
+	}
+	return best
+}
+
+// removeLogs deletes the logs of results dropped from the table. Only
+// files in the runner's own logs directory are touched.
+func (r *Runner) removeLogs(results []Result) {
+	logDir := filepath.Join(r.dir, "logs")
+	for i := 0; i < len(results); i++ {
+		path := results[i].Log
+		if path == "" || filepath.Dir(path) != logDir {
+			continue
+		}
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			slog.Warn("failed to remove benchmark log", "path", path, "error", err)
+		}
+	}
+}
+
+func (r *Runner) save(results []Result) error {
+	data, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(r.dir, 0o755); err != nil {
+		return err
+	}
+	path := filepath.Join(r.dir, resultsFile)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,247 @@
+package benchmark
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agent-racer/backend/internal/session"
+)
+
+func sh(label, script string) Agent {
+	return Agent{Label: label, Command: []string{"sh", "-c", script}}
+}
+
+func newTestRunner(t *testing.T, tasks ...Task) *Runner {
+	t.Helper()
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	r, err := NewRunner(t.TempDir(), func() []*session.SessionState { return nil })
+	if err != nil {
+		t.Fatalf("NewRunner: %v", err)
+	}
+	r.Configure(Settings{Tasks: tasks})
+	return r
+}
+
+// waitIdle waits for every triggered run to finish.
+func waitIdle(t *testing.T, r *Runner) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for len(r.Running()) > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("benchmark still running after 10s")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	r.wg.Wait()
+}
+
+func TestRunnerRecordsResults(t *testing.T) {
+	r := newTestRunner(t, Task{Name: "echo", Agents: []Agent{
+		sh("ok", "echo hello; pwd"),
+		sh("fails", "exit 3"),
+	}})
+
+	runs, err := r.Trigger("")
+	if err != nil || len(runs) != 1 || runs[0].Task != "echo" {
+		t.Fatalf("Trigger = %+v, %v", runs, err)
+	}
+	waitIdle(t, r)
+
+	results := r.Results()
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2", len(results))
+	}
+	byAgent := map[string]Result{}
+	for _, res := range results {
+		byAgent[res.Agent] = res
+	}
+	if ok := byAgent["ok"]; ok.ExitCode != 0 || ok.Error != "" || ok.RunID != runs[0].RunID {
+		t.Errorf("ok result = %+v", ok)
+	}
+	if f := byAgent["fails"]; f.ExitCode != 3 {
+		t.Errorf("fails exit code = %d, want 3", f.ExitCode)
+	}
+
+	// Output lands in the log; the scratch directory is gone afterwards.
+	out, err := os.ReadFile(byAgent["ok"].Log)
+	if err != nil || !strings.HasPrefix(string(out), "hello\n") {
+		t.Fatalf("log = %q, %v", out, err)
+	}
+	dir := strings.TrimSpace(strings.TrimPrefix(string(out), "hello\n"))
+	if filepath.Base(dir) != "work" {
+		t.Errorf("agent ran in %q, want a scratch work dir", dir)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("scratch dir %s left behind", dir)
+	}
+
+	// The table survives a restart.
+	again, err := NewRunner(r.dir, nil)
+	if err != nil {
+		t.Fatalf("NewRunner: %v", err)
+	}
+	if len(again.Results()) != 2 {
+		t.Errorf("reloaded %d results, want 2", len(again.Results()))
+	}
+}
+
+func TestRunnerPrunesEvictedLogs(t *testing.T) {
+	r := newTestRunner(t, Task{Name: "echo", Agents: []Agent{sh("a", "echo hi")}})
+	logDir := filepath.Join(r.dir, "logs")
+	if err := os.MkdirAll(logDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	oldest := filepath.Join(logDir, "old.log")
+	kept := filepath.Join(logDir, "kept.log")
+	for _, path := range []string{oldest, kept} {
+		if err := os.WriteFile(path, []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r.results = make([]Result, maxResults)
+	r.results[0].Log = oldest
+	r.results[1].Log = kept
+
+	if _, err := r.Trigger("echo"); err != nil {
+		t.Fatalf("Trigger: %v", err)
+	}
+	waitIdle(t, r)
+
+	if _, err := os.Stat(oldest); !os.IsNotExist(err) {
+		t.Errorf("evicted result's log still there (%v)", err)
+	}
+	if _, err := os.Stat(kept); err != nil {
+		t.Errorf("kept result's log: %v", err)
+	}
+	if newest := r.Results()[0]; newest.Agent != "a" {
+		t.Fatalf("newest result = %+v", newest)
+	} else if _, err := os.Stat(newest.Log); err != nil {
+		t.Errorf("new log: %v", err)
+	}
+}
+
+func TestRunIDsUniqueWithinASecond(t *testing.T) {
+	r := newTestRunner(t, Task{Name: "echo", Agents: []Agent{sh("a", "true")}})
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	r.now = func() time.Time { return at }
+
+	first, err := r.Trigger("echo")
+	if err != nil {
+		t.Fatalf("Trigger: %v", err)
+	}
+	waitIdle(t, r)
+	second, err := r.Trigger("echo")
+	if err != nil {
+		t.Fatalf("Trigger: %v", err)
+	}
+	waitIdle(t, r)
+	if first[0].RunID == second[0].RunID {
+		t.Errorf("both runs got ID %q", first[0].RunID)
+	}
+	results := r.Results()
+	if results[0].Log == results[1].Log {
+		t.Errorf("both runs logged to %s", results[0].Log)
+	}
+}
+
+func TestRunnerTimeout(t *testing.T) {
+	r := newTestRunner(t)
+	r.Configure(Settings{Timeout: 100 * time.Millisecond, Tasks: []Task{{Name: "slow", Agents: []Agent{sh("sleeper", "sleep 5")}}}})
+	if _, err := r.Trigger("slow"); err != nil {
+		t.Fatalf("Trigger: %v", err)
+	}
+	waitIdle(t, r)
+	res := r.Results()[0]
+	if !strings.Contains(res.Error, "timed out") || res.DurationSeconds > 4 {
+		t.Errorf("result = %+v, want a timeout well before 5s", res)
+	}
+}
+
+func TestTriggerErrors(t *testing.T) {
+	r := newTestRunner(t)
+	if _, err := r.Trigger(""); !errors.Is(err, ErrNoTasks) {
+		t.Errorf("no tasks: err = %v", err)
+	}
+
+	r.Configure(Settings{Tasks: []Task{{Name: "slow", Agents: []Agent{sh("a", "sleep 0.3")}}}})
+	if _, err := r.Trigger("nope"); !errors.Is(err, ErrUnknownTask) {
+		t.Errorf("unknown task: err = %v", err)
+	}
+	if _, err := r.Trigger("slow"); err != nil {
+		t.Fatalf("Trigger: %v", err)
+	}
+	if _, err := r.Trigger("slow"); !errors.Is(err, ErrBusy) {
+		t.Errorf("named busy task: err = %v", err)
+	}
+	if _, err := r.Trigger(""); !errors.Is(err, ErrBusy) {
+		t.Errorf("all tasks busy: err = %v", err)
+	}
+	waitIdle(t, r)
+}
+
+func TestRunnerUsesWorktree(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	repo := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"-c", "user.name=t", "-c", "user.email=t@example.com", "commit", "-q", "--allow-empty", "-m", "init"},
+	} {
+		if out, err := exec.Command("git", append([]string{"-C", repo}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+	r := newTestRunner(t, Task{Name: "wt", Repo: repo, Agents: []Agent{sh("a", "git rev-parse --is-inside-work-tree")}})
+	if _, err := r.Trigger("wt"); err != nil {
+		t.Fatalf("Trigger: %v", err)
+	}
+	waitIdle(t, r)
+	if res := r.Results()[0]; res.ExitCode != 0 || res.Error != "" {
+		t.Errorf("result = %+v", res)
+	}
+	out, _ := exec.Command("git", "-C", repo, "worktree", "list").Output()
+	if n := strings.Count(strings.TrimSpace(string(out)), "\n"); n != 0 {
+		t.Errorf("worktree not removed:\n%s", out)
+	}
+}
+
+func TestMatchSession(t *testing.T) {
+	t0 := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	states := []*session.SessionState{
+		{ID: "other", WorkingDir: "/tmp/b/work"},
+		{ID: "old", WorkingDir: "/tmp/a/work", StartedAt: t0},
+		{ID: "new", WorkingDir: "/tmp/a/work/sub", StartedAt: t0.Add(time.Minute)},
+		{ID: "prefix", WorkingDir: "/tmp/a/workshop", StartedAt: t0.Add(time.Hour)},
+	}
+	if s := matchSession(states, "/tmp/a/work"); s == nil || s.ID != "new" {
+		t.Errorf("matchSession = %+v, want new", s)
+	}
+	if s := matchSession(states, "/tmp/c/work"); s != nil {
+		t.Errorf("matchSession = %+v, want nil", s)
+	}
+}
+
+func TestValidateTasks(t *testing.T) {
+	errs := ValidateTasks([]Task{
+		{Name: "ok", Agents: []Agent{{Label: "a", Command: []string{"true"}}}},
+		{Name: "ok", Agents: []Agent{{Label: "a/b", Command: []string{"true"}}, {Label: "c"}}},
+		{Agents: nil},
+	})
+	want := []string{"duplicate name", "label", "command is required", "name is required", "at least one agent"}
+	if len(errs) != len(want) {
+		t.Fatalf("got %d errors, want %d: %v", len(errs), len(want), errs)
+	}
+	for i, w := range want {
+		if !strings.Contains(errs[i], w) {
+			t.Errorf("errs[%d] = %q, want it to mention %q", i, errs[i], w)
+		}
+	}
+}
@@ -0,0 +1,465 @@
+// Package chatops answers chat slash commands about the race. In Slack,
+// "/racer status" lists the running sessions and "/racer notify <session>"
+// posts to the channel when that session finishes.
+package chatops
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/agent-racer/backend/internal/session"
+)
+
+const (
+	// maxCommandBody bounds a slash command request; Slack's are a few
+	// hundred bytes.
+	maxCommandBody = 64 << 10
+	// maxClockSkew is how old a signed request may be before it is
+	// treated as a replay.
+	maxClockSkew = 5 * time.Minute
+	// maxSubscriptions bounds the notify requests held at once.
+	maxSubscriptions = 256
+	// terminalBuffer is how many finished sessions may wait to be posted.
+	terminalBuffer = 64
+	// statusLimit is how many sessions /racer status lists.
+	statusLimit = 15
+
+	postMessageURL = "https://slack.com/api/chat.postMessage"
+)
+
+// Settings configure the Slack bridge.
+type Settings struct {
+	Enabled bool
+	// SigningSecret verifies that commands come from Slack.
+	SigningSecret string
+	// BotToken, if set, posts completions with chat.postMessage. Without
+	// it they go to the command's response URL, which Slack only accepts
+	// for 30 minutes.
+	BotToken string
+}
+
+// subscription is a channel waiting to hear that a session finished.
+type subscription struct {
+	channelID   string
+	responseURL string
+	user        string
+}
+
+// Bridge answers Slack slash commands and posts the completions channels
+// asked for.
+type Bridge struct {
+	sessions func() []*session.SessionState
+	filter   func([]*session.SessionState) []*session.SessionState
+	client   *http.Client
+	now      func() time.Time
+	postURL  string
+
+	terminal chan *session.SessionState
+
+	mu       sync.Mutex
+	settings Settings
+	// subs maps session IDs, as clients see them, to the channels
+	// waiting on them.
+	subs map[string][]subscription
+}
+
+// New returns a disabled bridge. all lists every stored session and
+// filter renames and masks sessions the way WebSocket clients see them,
+// so chat shows what the dashboard would.
+func New(all func() []*session.SessionState, filter func([]*session.SessionState) []*session.SessionState) *Bridge {
+	b := &Bridge{
+		filter:   filter,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		now:      time.Now,
+		postURL:  postMessageURL,
+		terminal: make(chan *session.SessionState, terminalBuffer),
+		subs:     make(map[string][]subscription),
+	}
+	b.sessions = func() []*session.SessionState { return filter(all()) }
+	return b
+}
+
+// Configure applies new settings. Disabling the bridge drops pending
+// notify requests.
+func (b *Bridge) Configure(s Settings) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.settings = s
+	if !s.Enabled {
+		b.subs = make(map[string][]subscription)
+	}
+}
+
+func (b *Bridge) current() Settings {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.settings
+}
+
+// Terminal queues a finished session to be posted to the channels waiting
+// on it. It never blocks; the event is dropped if Run falls far behind.
+func (b *Bridge) Terminal(state *session.SessionState) {
+	select {
+	case b.terminal <- state:
+	default:
+	}
+}
+
+// Run posts completions until ctx is done.
+func (b *Bridge) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case state := <-b.terminal:
+			b.handleTerminal(ctx, state)
+		}
+	}
+}
+
+func (b *Bridge) handleTerminal(ctx context.Context, state *session.SessionState) {
+	visible := b.filter([]*session.SessionState{state})
+	if len(visible) == 0 {
+		return
+	}
+	s := visible[0]
+	b.mu.Lock()
+	subs := b.subs[s.ID]
+	delete(b.subs, s.ID)
+	settings := b.settings
+	b.mu.Unlock()
+
+	text := finishLine(s)
+	for i := 0; i < len(subs); i++ {
+		if err := b.post(ctx, settings, subs[i], text); err != nil {
+			slog.Warn("slack notify failed", "session", s.ID, "channel", subs[i].channelID, "error", err)
+		}
+	}
+}
+
+// post sends text to the channel sub was made from.
+func (b *Bridge) post(ctx context.Context, settings Settings, sub subscription, text string) error {
+	if settings.BotToken != "" {
+		body, _ := json.Marshal(map[string]string{"channel": sub.channelID, "text": text})
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.postURL, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json; charset=utf-8")
+		req.Header.Set("Authorization", "Bearer "+settings.BotToken)
+		resp, err := b.client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = resp.Body.Close() }()
+		var result struct {
+			OK    bool   `json:"ok"`
+			Error string `json:"error"`
+		}
+		if err := json.NewDecoder(io.LimitReader(resp.Body, maxCommandBody)).Decode(&result); err != nil {
+			return fmt.Errorf("chat.postMessage: %s", resp.Status)
+		}
+		if !result.OK {
+			return fmt.Errorf("chat.postMessage: %s", result.Error)
+		}
+		return nil
+	}
+
+	if sub.responseURL == "" {
+		return fmt.Errorf("no bot token and no response URL")
+	}
+	body, _ := json.Marshal(Response{Type: inChannel, Text: text})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.responseURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("response URL: %s", resp.Status)
+	}
+	return nil
+}
+
+// Slack response types: visible to the whole channel, or to the user who
+// ran the command.
+const (
+	inChannel = "in_channel"
+	ephemeral = "ephemeral"
+)
+
+// Response is a slash command reply, in Slack's format.
+type Response struct {
+	Type string `json:"response_type"`
+	Text string `json:"text"`
+}
+
+// ServeHTTP answers a Slack slash command. Requests must carry a valid
+// Slack signature; the server's own auth token is not used.
+func (b *Bridge) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	settings := b.current()
+	if !settings.Enabled {
+		http.Error(w, "slack commands are not enabled", http.StatusNotFound)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxCommandBody+1))
+	if err != nil || len(body) > maxCommandBody {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := verifySignature(settings.SigningSecret, r.Header, body, b.now()); err != nil {
+		slog.Warn("slack command rejected", "addr", r.RemoteAddr, "error", err)
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		http.Error(w, "invalid form", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(b.command(form))
+}
+
+// command runs the command in form and returns the reply.
+func (b *Bridge) command(form url.Values) Response {
+	verb, arg, _ := strings.Cut(strings.TrimSpace(form.Get("text")), " ")
+	switch strings.ToLower(verb) {
+	case "", "status":
+		return Response{Type: inChannel, Text: statusText(b.sessions(), b.now())}
+	case "notify":
+		return b.subscribe(form, strings.TrimSpace(arg))
+	default:
+		cmd := form.Get("command")
+		if cmd == "" {
+			cmd = "/racer"
+		}
+		return Response{Type: ephemeral, Text: fmt.Sprintf("Usage: `%[1]s status` lists running sessions; `%[1]s notify <session>` posts here when one finishes.", cmd)}
+	}
+}
+
+// subscribe asks for the channel in form to hear when the session named
+// by query finishes.
+func (b *Bridge) subscribe(form url.Values, query string) Response {
+	if query == "" {
+		return Response{Type: ephemeral, Text: "Which session? Give its name or ID, as `status` shows them."}
+	}
+	s, err := findSession(b.sessions(), query)
+	if err != nil {
+		return Response{Type: ephemeral, Text: err.Error()}
+	}
+	if s.IsTerminal() {
+		return Response{Type: ephemeral, Text: fmt.Sprintf("*%s* has already finished: %s.", s.Name, s.Activity)}
+	}
+
+	sub := subscription{
+		channelID:   form.Get("channel_id"),
+		responseURL: form.Get("response_url"),
+		user:        form.Get("user_name"),
+	}
+	b.mu.Lock()
+	n := 0
+	for _, subs := range b.subs {
+		n += len(subs)
+	}
+	if n >= maxSubscriptions {
+		b.mu.Unlock()
+		return Response{Type: ephemeral, Text: "Too many sessions are being watched already; try again once some finish."}
+	}
+	for _, existing := range b.subs[s.ID] {
+		if existing.channelID == sub.channelID {
+			b.mu.Unlock()
+			return Response{Type: ephemeral, Text: fmt.Sprintf("This channel is already waiting on *%s*.", s.Name)}
+		}
+	}
+	b.subs[s.ID] = append(b.subs[s.ID], sub)
+	b.mu.Unlock()
+
+	text := fmt.Sprintf("I'll post here when *%s* finishes.", s.Name)
+	if sub.user != "" {
+		text = fmt.Sprintf("@%s asked me to post here when *%s* finishes.", sub.user, s.Name)
+	}
+	return Response{Type: inChannel, Text: text}
+}
+
+// findSession picks the session query names: an exact ID, then a name
+// (case-insensitive), then an ID prefix. Running sessions win over
+// finished ones with the same name.
+func findSession(all []*session.SessionState, query string) (*session.SessionState, error) {
+	for i := 0; i < len(all); i++ {
+		if all[i].ID == query {
+			return all[i], nil
+		}
+	}
+	var matches []*session.SessionState
+	for i := 0; i < len(all); i++ {
+		if strings.EqualFold(all[i].Name, query) {
+			matches = append(matches, all[i])
+		}
+	}
+	if len(matches) == 0 {
+		for i := 0; i < len(all); i++ {
+			if strings.HasPrefix(all[i].ID, query) {
+				matches = append(matches, all[i])
+			}
+		}
+	}
+	if len(matches) > 1 {
+		running := matches[:0:0]
+		for i := 0; i < len(matches); i++ {
+			if !matches[i].IsTerminal() {
+				running = append(running, matches[i])
+			}
+		}
+		if len(running) > 0 {
+			matches = running
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("No session matches %q.", query)
+	case 1:
+		return matches[0], nil
+	}
+	ids := make([]string, len(matches))
+	for i := 0; i < len(matches); i++ {
+		ids[i] = "`" + matches[i].ID + "`"
+	}
+	return nil, fmt.Errorf("%q matches %d sessions; use an ID: %s", query, len(matches), strings.Join(ids, ", "))
+}
+
+// statusText lists the running sessions, leaders first.
+func statusText(all []*session.SessionState, now time.Time) string {
+	running := make([]*session.SessionState, 0, len(all))
+	for i := 0; i < len(all); i++ {
+		if !all[i].IsTerminal() {
+			running = append(running, all[i])
+		}
+	}
+	if len(running) == 0 {
+		return "No sessions are racing right now."
+	}
+	sort.Slice(running, func(i, j int) bool {
+		pi, pj := running[i].Position, running[j].Position
+		if pi != pj && pi > 0 && pj > 0 {
+			return pi < pj
+		}
+		if (pi > 0) != (pj > 0) {
+			return pi > 0
+		}
+		return running[i].ID < running[j].ID
+	})
+
+	var sb strings.Builder
+	if len(running) == 1 {
+		sb.WriteString("1 session racing:")
+	} else {
+		fmt.Fprintf(&sb, "%d sessions racing:", len(running))
+	}
+	for i := 0; i < len(running) && i < statusLimit; i++ {
+		s := running[i]
+		fmt.Fprintf(&sb, "\n• *%s* — %s", s.Name, s.Activity)
+		if s.Model != "" {
+			fmt.Fprintf(&sb, ", %s", s.Model)
+		}
+		if s.MaxContextTokens > 0 {
+			fmt.Fprintf(&sb, ", %d%% context", int(s.ContextUtilization*100))
+		}
+		if !s.StartedAt.IsZero() {
+			fmt.Fprintf(&sb, ", %s", formatElapsed(now.Sub(s.StartedAt)))
+		}
+		fmt.Fprintf(&sb, " (`%s`)", s.ID)
+	}
+	if len(running) > statusLimit {
+		fmt.Fprintf(&sb, "\n…and %d more", len(running)-statusLimit)
+	}
+	return sb.String()
+}
+
+// finishLine announces that s reached a terminal state.
+func finishLine(s *session.SessionState) string {
+	verb := "finished"
+	switch s.Activity {
+	case session.Errored:
+		verb = "errored out"
+	case session.Lost:
+		verb = "was lost"
+	}
+	line := fmt.Sprintf(":checkered_flag: *%s* %s", s.Name, verb)
+	if s.CompletedAt != nil && !s.StartedAt.IsZero() {
+		line += " after " + formatElapsed(s.CompletedAt.Sub(s.StartedAt))
+	}
+	if s.Outcome != "" {
+		line += " (" + strings.ReplaceAll(string(s.Outcome), "_", " ") + ")"
+	}
+	return line + "."
+}
+
+// formatElapsed renders d as "42s", "12m" or "3h05m".
+func formatElapsed(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return strconv.Itoa(int(d/time.Second)) + "s"
+	case d < time.Hour:
+		return strconv.Itoa(int(d/time.Minute)) + "m"
+	}
+	return fmt.Sprintf("%dh%02dm", int(d/time.Hour), int(d%time.Hour/time.Minute))
+}
+
+// verifySignature checks Slack's v0 request signature: an HMAC-SHA256 of
+// "v0:<timestamp>:<body>" keyed with the signing secret.
+func verifySignature(secret string, h http.Header, body []byte, now time.Time) error {
+	if secret == "" {
+		return fmt.Errorf("no signing secret configured")
+	}
+	ts := h.Get("X-Slack-Request-Timestamp")
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return fmt.Errorf("missing or invalid timestamp")
+	}
+	if skew := now.Sub(time.Unix(sec, 0)); skew > maxClockSkew || skew < -maxClockSkew {
+		return fmt.Errorf("timestamp %s is too far from now", ts)
+	}
+	got, ok := strings.CutPrefix(h.Get("X-Slack-Signature"), "v0=")
+	if !ok {
+		return fmt.Errorf("missing signature")
+	}
+	sig, err := hex.DecodeString(got)
+	if err != nil {
+		return fmt.Errorf("malformed signature")
+	}
+	if !hmac.Equal(sig, sign(secret, ts, body)) {
+		return fmt.Errorf("signature mismatch")
+	}
+	return nil
+}
+
+func sign(secret, ts string, body []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + ts + ":"))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,184 @@
+package chatops
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agent-racer/backend/internal/session"
+)
+
+const testSecret = "8f14e45fceea167a5a36dedd4bea2543"
+
+var testNow = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func newTestBridge(sessions ...*session.SessionState) *Bridge {
+	all := func() []*session.SessionState { return sessions }
+	identity := func(s []*session.SessionState) []*session.SessionState { return s }
+	b := New(all, identity)
+	b.now = func() time.Time { return testNow }
+	b.Configure(Settings{Enabled: true, SigningSecret: testSecret})
+	return b
+}
+
+// slashCommand builds a signed slash command request.
+func slashCommand(text, responseURL string) *http.Request {
+	form := url.Values{
+		"command":      {"/racer"},
+		"text":         {text},
+		"channel_id":   {"C123"},
+		"user_name":    {"ana"},
+		"response_url": {responseURL},
+	}
+	body := form.Encode()
+	ts := strconv.FormatInt(testNow.Unix(), 10)
+	req := httptest.NewRequest(http.MethodPost, "/api/chatops/slack", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-Slack-Request-Timestamp", ts)
+	req.Header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(sign(testSecret, ts, []byte(body))))
+	return req
+}
+
+func serve(t *testing.T, b *Bridge, req *http.Request) Response {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	b.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %q", rec.Code, rec.Body.String())
+	}
+	var resp Response
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return resp
+}
+
+func TestServeHTTP_RejectsBadSignatures(t *testing.T) {
+	b := newTestBridge()
+
+	tampered := slashCommand("status", "")
+	tampered.Header.Set("X-Slack-Signature", "v0="+strings.Repeat("0", 64))
+	stale := slashCommand("status", "")
+	b.now = func() time.Time { return testNow.Add(10 * time.Minute) }
+	for name, req := range map[string]*http.Request{"tampered": tampered, "stale": stale} {
+		rec := httptest.NewRecorder()
+		b.ServeHTTP(rec, req)
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("%s: status = %d, want 401", name, rec.Code)
+		}
+	}
+
+	b.now = func() time.Time { return testNow }
+	b.Configure(Settings{})
+	rec := httptest.NewRecorder()
+	b.ServeHTTP(rec, slashCommand("status", ""))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("disabled: status = %d, want 404", rec.Code)
+	}
+}
+
+func TestStatus_ListsRunningSessionsInOrder(t *testing.T) {
+	done := testNow.Add(-time.Minute)
+	b := newTestBridge(
+		&session.SessionState{ID: "claude:b", Name: "second", Activity: session.ToolUse, Position: 2, StartedAt: testNow.Add(-90 * time.Second)},
+		&session.SessionState{ID: "claude:a", Name: "first", Activity: session.Thinking, Position: 1, Model: "opus",
+			MaxContextTokens: 200000, ContextUtilization: 0.42, StartedAt: testNow.Add(-65 * time.Minute)},
+		&session.SessionState{ID: "claude:c", Name: "finished", Activity: session.Complete, CompletedAt: &done},
+	)
+
+	resp := serve(t, b, slashCommand("status", ""))
+	if resp.Type != inChannel {
+		t.Errorf("response_type = %q, want %q", resp.Type, inChannel)
+	}
+	want := "2 sessions racing:\n" +
+		"• *first* — thinking, opus, 42% context, 1h05m (`claude:a`)\n" +
+		"• *second* — tool_use, 1m (`claude:b`)"
+	if resp.Text != want {
+		t.Errorf("text =\n%s\nwant\n%s", resp.Text, want)
+	}
+}
+
+func TestNotify_PostsWhenSessionFinishes(t *testing.T) {
+	posted := make(chan Response, 1)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var resp Response
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &resp)
+		posted <- resp
+	}))
+	defer hook.Close()
+
+	running := &session.SessionState{ID: "claude:abc123", Name: "refactor", Activity: session.Thinking, StartedAt: testNow.Add(-3 * time.Minute)}
+	b := newTestBridge(running)
+
+	resp := serve(t, b, slashCommand("notify REFACTOR", hook.URL))
+	if resp.Type != inChannel || !strings.Contains(resp.Text, "*refactor*") {
+		t.Fatalf("notify reply = %+v", resp)
+	}
+	if again := serve(t, b, slashCommand("notify claude:abc", hook.URL)); again.Type != ephemeral {
+		t.Errorf("second notify from the same channel = %+v, want an ephemeral refusal", again)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go b.Run(ctx)
+
+	finished := running.Clone()
+	finished.Activity = session.Complete
+	completed := testNow
+	finished.CompletedAt = &completed
+	b.Terminal(finished)
+
+	select {
+	case got := <-posted:
+		want := ":checkered_flag: *refactor* finished after 3m."
+		if got.Text != want || got.Type != inChannel {
+			t.Errorf("posted %+v, want in_channel %q", got, want)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no completion posted")
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.subs) != 0 {
+		t.Errorf("subscriptions left after posting: %v", b.subs)
+	}
+}
+
+func TestFindSession(t *testing.T) {
+	all := []*session.SessionState{
+		{ID: "claude:aaa1", Name: "api", Activity: session.Thinking},
+		{ID: "claude:aaa2", Name: "web", Activity: session.Thinking},
+		{ID: "codex:bbb", Name: "API", Activity: session.Complete},
+	}
+	tests := []struct {
+		query, want, errPart string
+	}{
+		{query: "claude:aaa2", want: "claude:aaa2"},
+		{query: "api", want: "claude:aaa1"}, // the running one wins
+		{query: "codex", want: "codex:bbb"},
+		{query: "claude:aaa", errPart: "matches 2 sessions"},
+		{query: "nope", errPart: "No session matches"},
+	}
+	for _, tt := range tests {
+		got, err := findSession(all, tt.query)
+		if tt.errPart != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.errPart) {
+				t.Errorf("findSession(%q) error = %v, want %q", tt.query, err, tt.errPart)
+			}
+			continue
+		}
+		if err != nil || got.ID != tt.want {
+			t.Errorf("findSession(%q) = %v, %v; want %s", tt.query, got, err, tt.want)
+		}
+	}
+}
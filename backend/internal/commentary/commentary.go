@@ -0,0 +1,375 @@
+// Package commentary turns notable race events into short announcer lines.
+//
+// A Generator diffs successive session snapshots and renders a template for
+// each event it spots: a session joining, compacting, taking the lead,
+// finishing, crashing, finishing within a whisker of another, or passing a
+// run-time or token milestone. Templates
+// are plain strings with {placeholder} fields so they can be overridden from
+// config without any templating language.
+package commentary
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/agent-racer/backend/internal/session"
+)
+
+// Event names a kind of notable moment.
+type Event string
+
+const (
+	EventStart       Event = "start"
+	EventCompaction  Event = "compaction"
+	EventLeadChange  Event = "lead_change"
+	EventFinish      Event = "finish"
+	EventCrash       Event = "crash"
+	EventPhotoFinish Event = "photo_finish"
+	EventDuration    Event = "duration_milestone"
+	EventTokens      Event = "token_milestone"
+)
+
+// PollInterval is how often Run looks at the sessions.
+const PollInterval = time.Second
+
+// PhotoFinishWindow is how close two completions must be for the second one
+// to be called as a photo finish rather than a plain finish.
+const PhotoFinishWindow = 5 * time.Second
+
+// DefaultTemplates are used for any event the config does not override.
+var DefaultTemplates = map[Event]string{
+	EventStart:       "{name} rolls onto the grid!",
+	EventCompaction:  "{name} pits for compaction!",
+	EventLeadChange:  "{name} takes the lead from {other}!",
+	EventFinish:      "{name} crosses the finish line!",
+	EventCrash:       "{name} crashes out!",
+	EventPhotoFinish: "Photo finish between {name} and {other}!",
+	EventDuration:    "{name} has been on track for {milestone}!",
+	EventTokens:      "{name} has burned through {milestone} tokens!",
+}
+
+// localeTemplates translates DefaultTemplates by language.
+var localeTemplates = map[string]map[Event]string{
+	"de": {
+		EventStart:       "{name} rollt in die Startaufstellung!",
+		EventCompaction:  "{name} kommt zum Kompaktieren an die Box!",
+		EventLeadChange:  "{name} übernimmt die Führung von {other}!",
+		EventFinish:      "{name} überquert die Ziellinie!",
+		EventCrash:       "{name} scheidet mit einem Crash aus!",
+		EventPhotoFinish: "Fotofinish zwischen {name} und {other}!",
+		EventDuration:    "{name} ist seit {milestone} auf der Strecke!",
+		EventTokens:      "{name} hat {milestone} Tokens verbrannt!",
+	},
+	"es": {
+		EventStart:       "¡{name} sale a la parrilla!",
+		EventCompaction:  "¡{name} entra en boxes para compactar!",
+		EventLeadChange:  "¡{name} le arrebata el liderato a {other}!",
+		EventFinish:      "¡{name} cruza la línea de meta!",
+		EventCrash:       "¡{name} se estrella y abandona!",
+		EventPhotoFinish: "¡Foto finish entre {name} y {other}!",
+		EventDuration:    "¡{name} lleva {milestone} en pista!",
+		EventTokens:      "¡{name} ya ha quemado {milestone} tokens!",
+	},
+}
+
+// Templates returns the built-in template for every event in lang, using
+// DefaultTemplates for a language or event without a translation.
+func Templates(lang string) map[Event]string {
+	templates := make(map[Event]string, len(DefaultTemplates))
+	for ev, tmpl := range DefaultTemplates {
+		if t, ok := localeTemplates[lang][ev]; ok {
+			tmpl = t
+		}
+		templates[ev] = tmpl
+	}
+	return templates
+}
+
+// Placeholders lists the fields a template may reference.
+var Placeholders = []string{"name", "other", "model", "source", "project", "position", "compactions", "milestone"}
+
+var placeholderRe = regexp.MustCompile(`\{([^{}]*)\}`)
+
+// ValidateTemplates checks config overrides: every key must be a known event
+// and every {field} a known placeholder. An empty template is allowed and
+// silences that event.
+func ValidateTemplates(templates map[string]string) []string {
+	var errs []string
+	keys := make([]string, 0, len(templates))
+	for k := range templates {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if _, ok := DefaultTemplates[Event(k)]; !ok {
+			errs = append(errs, fmt.Sprintf("unknown event %q", k))
+			continue
+		}
+		for _, m := range placeholderRe.FindAllStringSubmatch(templates[k], -1) {
+			if !isPlaceholder(m[1]) {
+				errs = append(errs, fmt.Sprintf("%s: unknown placeholder {%s}", k, m[1]))
+			}
+		}
+	}
+	return errs
+}
+
+func isPlaceholder(name string) bool {
+	for i := 0; i < len(Placeholders); i++ {
+		if Placeholders[i] == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Line is one rendered piece of commentary.
+type Line struct {
+	Event      Event
+	SessionIDs []string // subject first, then any other session involved
+	Text       string
+	At         time.Time
+}
+
+// snap is the slice of a session the generator remembers between polls.
+type snap struct {
+	name        string
+	terminal    bool
+	compactions int
+	position    int
+	elapsed     time.Duration
+	tokens      int
+}
+
+type finish struct {
+	id   string
+	name string
+	at   time.Time
+}
+
+// Generator watches session snapshots and emits Lines. It keeps tracking
+// while disabled so that turning it on does not replay everything at once.
+type Generator struct {
+	sessions  func() []*session.SessionState
+	mu        sync.Mutex
+	enabled   bool
+	templates map[Event]string
+	prev      map[string]snap
+	primed    bool
+	last      *finish
+	onLine    func(Line)
+	now       func() time.Time
+}
+
+// New returns a disabled Generator using the default templates. sessions
+// feeds Run and should already have the privacy filter applied.
+func New(sessions func() []*session.SessionState) *Generator {
+	g := &Generator{
+		sessions: sessions,
+		prev:     make(map[string]snap),
+		now:      time.Now,
+	}
+	g.Configure(false, "", nil)
+	return g
+}
+
+// OnLine registers the callback for rendered lines. It is called
+// synchronously from Observe.
+func (g *Generator) OnLine(fn func(Line)) {
+	g.mu.Lock()
+	g.onLine = fn
+	g.mu.Unlock()
+}
+
+// Configure switches emission on or off, picks the language of the
+// built-in templates and replaces the template overrides. Keys are event
+// names; unknown keys are ignored.
+func (g *Generator) Configure(enabled bool, lang string, overrides map[string]string) {
+	templates := Templates(lang)
+	for k, tmpl := range overrides {
+		if _, ok := templates[Event(k)]; ok {
+			templates[Event(k)] = tmpl
+		}
+	}
+	g.mu.Lock()
+	g.enabled = enabled
+	g.templates = templates
+	g.mu.Unlock()
+}
+
+// Run observes the sessions every PollInterval until ctx is cancelled.
+func (g *Generator) Run(ctx context.Context) {
+	ticker := time.NewTicker(PollInterval)
+	defer ticker.Stop()
+	for {
+		g.Observe(g.sessions())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Observe compares sessions against the previous call and emits a line for
+// each notable change. The first call only records state.
+func (g *Generator) Observe(sessions []*session.SessionState) {
+	g.mu.Lock()
+	lines := g.diffLocked(sessions)
+	fn := g.onLine
+	enabled := g.enabled
+	g.mu.Unlock()
+
+	if !enabled || fn == nil {
+		return
+	}
+	for i := 0; i < len(lines); i++ {
+		fn(lines[i])
+	}
+}
+
+func (g *Generator) diffLocked(sessions []*session.SessionState) []Line {
+	now := g.now()
+	next := make(map[string]snap, len(sessions))
+	var lines []Line
+
+	var leader, prevLeader string
+	for id, p := range g.prev {
+		if p.position == 1 && !p.terminal {
+			prevLeader = id
+		}
+	}
+
+	for _, s := range sessions {
+		cur := snap{
+			name:        displayName(s),
+			terminal:    s.IsTerminal(),
+			compactions: s.CompactionCount,
+			position:    s.Position,
+			elapsed:     s.Elapsed(),
+			tokens:      s.TokensBurned,
+		}
+		next[s.ID] = cur
+		if s.Position == 1 && !s.IsTerminal() {
+			leader = s.ID
+		}
+		if !g.primed {
+			continue
+		}
+
+		p, seen := g.prev[s.ID]
+		if !seen {
+			if !s.IsTerminal() {
+				lines = g.appendLine(lines, EventStart, s, "", now)
+			}
+			continue
+		}
+		if cur.compactions > p.compactions && !s.IsTerminal() {
+			lines = g.appendLine(lines, EventCompaction, s, "", now)
+		}
+		if !p.terminal {
+			for _, m := range session.MilestonesSince(p.elapsed, p.tokens, s) {
+				lines = g.appendMilestone(lines, s, m, now)
+			}
+		}
+		if p.terminal || !s.IsTerminal() {
+			continue
+		}
+		switch s.Activity {
+		case session.Complete:
+			at := now
+			if s.CompletedAt != nil {
+				at = *s.CompletedAt
+			}
+			if g.last != nil && g.last.id != s.ID && absDuration(at.Sub(g.last.at)) <= PhotoFinishWindow {
+				lines = g.appendLine(lines, EventPhotoFinish, s, g.last.name, now, g.last.id)
+			} else {
+				lines = g.appendLine(lines, EventFinish, s, "", now)
+			}
+			g.last = &finish{id: s.ID, name: cur.name, at: at}
+		case session.Errored, session.Lost:
+			lines = g.appendLine(lines, EventCrash, s, "", now)
+		}
+	}
+
+	if g.primed && leader != "" && prevLeader != "" && leader != prevLeader {
+		for _, s := range sessions {
+			if s.ID == leader {
+				lines = g.appendLine(lines, EventLeadChange, s, g.prev[prevLeader].name, now, prevLeader)
+				break
+			}
+		}
+	}
+
+	g.prev = next
+	g.primed = true
+	return lines
+}
+
+func (g *Generator) appendLine(lines []Line, ev Event, s *session.SessionState, other string, at time.Time, otherIDs ...string) []Line {
+	tmpl := g.templates[ev]
+	if tmpl == "" {
+		return lines
+	}
+	return append(lines, Line{
+		Event:      ev,
+		SessionIDs: append([]string{s.ID}, otherIDs...),
+		Text:       Render(tmpl, s, other),
+		At:         at,
+	})
+}
+
+// appendMilestone renders the line for s passing m; {milestone} is its
+// short label, such as "4h" or "1M".
+func (g *Generator) appendMilestone(lines []Line, s *session.SessionState, m session.Milestone, at time.Time) []Line {
+	ev := EventDuration
+	if m.Kind == session.MilestoneTokens {
+		ev = EventTokens
+	}
+	tmpl := strings.ReplaceAll(g.templates[ev], "{milestone}", m.Label())
+	if tmpl == "" {
+		return lines
+	}
+	return append(lines, Line{
+		Event:      ev,
+		SessionIDs: []string{s.ID},
+		Text:       Render(tmpl, s, ""),
+		At:         at,
+	})
+}
+
+// Render fills the placeholders in tmpl from s. other is the name of the
+// second session involved, if any. {milestone} is only meaningful in
+// milestone lines, which fill it in first; elsewhere it renders empty.
+func Render(tmpl string, s *session.SessionState, other string) string {
+	return strings.NewReplacer(
+		"{name}", displayName(s),
+		"{other}", other,
+		"{model}", s.Model,
+		"{source}", s.Source,
+		"{project}", s.Project,
+		"{position}", strconv.Itoa(s.Position),
+		"{compactions}", strconv.Itoa(s.CompactionCount),
+		"{milestone}", "",
+	).Replace(tmpl)
+}
+
+func displayName(s *session.SessionState) string {
+	if s.Name != "" {
+		return s.Name
+	}
+	return s.ID
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
@@ -0,0 +1,206 @@
+package commentary
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agent-racer/backend/internal/i18n"
+	"github.com/agent-racer/backend/internal/session"
+)
+
+func newTestGenerator(t *testing.T, overrides map[string]string) (*Generator, *[]Line) {
+	t.Helper()
+	g := New(nil)
+	g.now = func() time.Time { return time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC) }
+	g.Configure(true, "", overrides)
+	var lines []Line
+	g.OnLine(func(l Line) { lines = append(lines, l) })
+	return g, &lines
+}
+
+func racer(id string, pos int) *session.SessionState {
+	return &session.SessionState{ID: id, Name: id, Activity: session.Thinking, Position: pos}
+}
+
+func TestObserveFirstCallOnlyPrimes(t *testing.T) {
+	g, lines := newTestGenerator(t, nil)
+	g.Observe([]*session.SessionState{racer("opus", 1), racer("sonnet", 2)})
+	if len(*lines) != 0 {
+		t.Fatalf("got %d lines on first observe, want 0: %+v", len(*lines), *lines)
+	}
+}
+
+func TestObserveEvents(t *testing.T) {
+	g, lines := newTestGenerator(t, nil)
+	opus, sonnet := racer("opus", 1), racer("sonnet", 2)
+	g.Observe([]*session.SessionState{opus, sonnet})
+
+	haiku := racer("haiku", 3)
+	opus.CompactionCount = 1
+	g.Observe([]*session.SessionState{opus, sonnet, haiku})
+
+	opus.Position, sonnet.Position = 2, 1
+	g.Observe([]*session.SessionState{opus, sonnet, haiku})
+
+	haiku.Activity = session.Errored
+	g.Observe([]*session.SessionState{opus, sonnet, haiku})
+
+	want := []string{
+		"opus pits for compaction!",
+		"haiku rolls onto the grid!",
+		"sonnet takes the lead from opus!",
+		"haiku crashes out!",
+	}
+	var got []string
+	for _, l := range *lines {
+		got = append(got, l.Text)
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("lines:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if lead := (*lines)[2]; lead.Event != EventLeadChange || len(lead.SessionIDs) != 2 || lead.SessionIDs[1] != "opus" {
+		t.Errorf("lead change line = %+v", lead)
+	}
+}
+
+func TestObservePhotoFinish(t *testing.T) {
+	g, lines := newTestGenerator(t, nil)
+	opus, sonnet, haiku := racer("opus", 1), racer("sonnet", 2), racer("haiku", 3)
+	g.Observe([]*session.SessionState{opus, sonnet, haiku})
+
+	t0 := time.Date(2026, 5, 1, 11, 59, 0, 0, time.UTC)
+	t1 := t0.Add(2 * time.Second)
+	t2 := t0.Add(time.Minute)
+	opus.Activity, opus.CompletedAt = session.Complete, &t0
+	g.Observe([]*session.SessionState{opus, sonnet, haiku})
+	sonnet.Activity, sonnet.CompletedAt = session.Complete, &t1
+	g.Observe([]*session.SessionState{opus, sonnet, haiku})
+	haiku.Activity, haiku.CompletedAt = session.Complete, &t2
+	g.Observe([]*session.SessionState{opus, sonnet, haiku})
+
+	if len(*lines) != 3 {
+		t.Fatalf("got %d lines, want 3: %+v", len(*lines), *lines)
+	}
+	if l := (*lines)[0]; l.Event != EventFinish {
+		t.Errorf("first line = %+v, want finish", l)
+	}
+	if l := (*lines)[1]; l.Event != EventPhotoFinish || l.Text != "Photo finish between sonnet and opus!" {
+		t.Errorf("second line = %+v, want photo finish", l)
+	}
+	if l := (*lines)[2]; l.Event != EventFinish {
+		t.Errorf("third line = %+v, want plain finish", l)
+	}
+}
+
+func TestObserveMilestones(t *testing.T) {
+	g, lines := newTestGenerator(t, nil)
+	start := time.Date(2026, 5, 1, 8, 0, 0, 0, time.UTC)
+	a := racer("opus", 1)
+	a.StartedAt, a.LastActivityAt, a.TokensBurned = start, start.Add(50*time.Minute), 90_000
+	g.Observe([]*session.SessionState{a})
+
+	a.LastActivityAt, a.TokensBurned = start.Add(61*time.Minute), 120_000
+	g.Observe([]*session.SessionState{a})
+	g.Observe([]*session.SessionState{a})
+
+	want := []string{"opus has been on track for 1h!", "opus has burned through 100k tokens!"}
+	if len(*lines) != len(want) {
+		t.Fatalf("got %+v, want %q", *lines, want)
+	}
+	for i := 0; i < len(want); i++ {
+		if (*lines)[i].Text != want[i] {
+			t.Errorf("line %d = %q, want %q", i, (*lines)[i].Text, want[i])
+		}
+	}
+	if (*lines)[1].Event != EventTokens {
+		t.Errorf("event = %q, want %q", (*lines)[1].Event, EventTokens)
+	}
+}
+
+func TestConfigureOverridesAndSilences(t *testing.T) {
+	g, lines := newTestGenerator(t, map[string]string{
+		"compaction": "Box box! {name} ({model}) stops, compaction #{compactions}",
+		"start":      "",
+	})
+	a := racer("a", 1)
+	a.Model = "claude-opus-4-5"
+	g.Observe([]*session.SessionState{a})
+
+	a.CompactionCount = 2
+	g.Observe([]*session.SessionState{a, racer("b", 2)})
+
+	if len(*lines) != 1 {
+		t.Fatalf("got %d lines, want 1: %+v", len(*lines), *lines)
+	}
+	if got, want := (*lines)[0].Text, "Box box! a (claude-opus-4-5) stops, compaction #2"; got != want {
+		t.Errorf("text = %q, want %q", got, want)
+	}
+}
+
+func TestDisabledTracksWithoutEmitting(t *testing.T) {
+	g, lines := newTestGenerator(t, nil)
+	g.Configure(false, "", nil)
+	g.Observe([]*session.SessionState{racer("a", 1)})
+	g.Observe([]*session.SessionState{racer("a", 1), racer("b", 2)})
+	if len(*lines) != 0 {
+		t.Fatalf("disabled generator emitted %+v", *lines)
+	}
+
+	// Sessions seen while disabled are not announced once enabled.
+	g.Configure(true, "", nil)
+	g.Observe([]*session.SessionState{racer("a", 1), racer("b", 2)})
+	if len(*lines) != 0 {
+		t.Fatalf("enabling replayed old events: %+v", *lines)
+	}
+}
+
+func TestValidateTemplates(t *testing.T) {
+	errs := ValidateTemplates(map[string]string{
+		"finish":  "{name} wins",
+		"crash":   "{nme} crashes",
+		"victory": "{name}",
+	})
+	if len(errs) != 2 {
+		t.Fatalf("got %d errors, want 2: %v", len(errs), errs)
+	}
+	if !strings.Contains(errs[0], "{nme}") || !strings.Contains(errs[1], `"victory"`) {
+		t.Errorf("unexpected errors: %v", errs)
+	}
+}
+
+func TestConfigureLanguage(t *testing.T) {
+	g, lines := newTestGenerator(t, nil)
+	g.Configure(true, "de", map[string]string{"crash": "{name} is out"})
+	g.Observe([]*session.SessionState{racer("a", 1)})
+	crashed := racer("a", 1)
+	crashed.Activity = session.Errored
+	g.Observe([]*session.SessionState{crashed, racer("b", 2)})
+
+	var got []string
+	for _, l := range *lines {
+		got = append(got, l.Text)
+	}
+	want := []string{"a is out", "b rollt in die Startaufstellung!"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("lines = %q, want %q (overrides beat the language)", got, want)
+	}
+}
+
+func TestTemplatesCoverEveryLanguage(t *testing.T) {
+	for _, lang := range i18n.Languages {
+		templates := Templates(lang)
+		for ev, def := range DefaultTemplates {
+			tmpl := templates[ev]
+			if lang != i18n.Default && tmpl == def {
+				t.Errorf("%s: %s is not translated", lang, ev)
+			}
+			if errs := ValidateTemplates(map[string]string{string(ev): tmpl}); len(errs) > 0 {
+				t.Errorf("%s: %v", lang, errs)
+			}
+		}
+	}
+	if got := Templates("xx")[EventFinish]; got != DefaultTemplates[EventFinish] {
+		t.Errorf("unknown language finish = %q, want English", got)
+	}
+}
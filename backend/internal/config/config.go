@@ -6,14 +6,27 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"maps"
+	"net/url"
 	"os"
 	"path"
 	"path/filepath"
+	"regexp"
 	"slices"
 	"strings"
 	"time"
-
+	"unicode/utf8"
+
+	"github.com/agent-racer/backend/internal/benchmark"
+	"github.com/agent-racer/backend/internal/chatops"
+	"github.com/agent-racer/backend/internal/commentary"
+	"github.com/agent-racer/backend/internal/gamification"
+	"github.com/agent-racer/backend/internal/i18n"
+	"github.com/agent-racer/backend/internal/launch"
+	"github.com/agent-racer/backend/internal/links"
 	"github.com/agent-racer/backend/internal/session"
+	"github.com/agent-racer/backend/internal/tokenizer"
+	"github.com/agent-racer/backend/internal/watchdog"
 	"gopkg.in/yaml.v3"
 )
 
@@ -32,6 +45,319 @@ type Config struct {
 	Gamification GamificationConfig `yaml:"gamification"`
 	Replay       ReplayConfig       `yaml:"replay"`
 	Track        TrackConfig        `yaml:"track"`
+	Race         RaceConfig         `yaml:"race"`
+	Links        LinksConfig        `yaml:"links"`
+	Updates      UpdatesConfig      `yaml:"updates"`
+	Share        ShareConfig        `yaml:"share"`
+	Embed        EmbedConfig        `yaml:"embed"`
+	Status       StatusConfig       `yaml:"status"`
+	Display      DisplayConfig      `yaml:"display"`
+	GraphQL      GraphQLConfig      `yaml:"graphql"`
+	Commentary   CommentaryConfig   `yaml:"commentary"`
+	Reactions    ReactionsConfig    `yaml:"reactions"`
+	ChatOps      ChatOpsConfig      `yaml:"chatops"`
+	Benchmarks   BenchmarksConfig   `yaml:"benchmarks"`
+	Launch       LaunchConfig       `yaml:"launch"`
+	Watchdog     WatchdogConfig     `yaml:"watchdog"`
+	Energy       EnergyConfig       `yaml:"energy"`
+	Debug        DebugConfig        `yaml:"debug"`
+}
+
+// WatchdogConfig raises an alert when no session has been active for a
+// while during the hours agents are expected to be running.
+type WatchdogConfig struct {
+	Enabled bool `yaml:"enabled"`
+
+	// QuietAfter is how long every session may go without activity before
+	// the alert is raised.
+	QuietAfter time.Duration `yaml:"quiet_after"`
+
+	// WorkHours is the daily window agents are expected to run in, as
+	// "HH:MM-HH:MM" in display.time_zone. Empty means all day.
+	WorkHours string `yaml:"work_hours"`
+
+	// WorkDays are the days, "mon" to "sun", the window applies on. Empty
+	// means every day.
+	WorkDays []string `yaml:"work_days"`
+}
+
+// Settings converts the config into watchdog.Settings, with hours in loc.
+// Invalid hours and days are dropped; Validate reports them.
+func (w WatchdogConfig) Settings(loc *time.Location) watchdog.Settings {
+	hours, _ := watchdog.ParseHours(w.WorkHours)
+	s := watchdog.Settings{Enabled: w.Enabled, QuietAfter: w.QuietAfter, Hours: hours, Location: loc}
+	for i := 0; i < len(w.WorkDays); i++ {
+		if d, err := watchdog.ParseWeekday(w.WorkDays[i]); err == nil {
+			s.Days = append(s.Days, d)
+		}
+	}
+	return s
+}
+
+// EnergyConfig estimates the energy and emissions behind each session's
+// tokens: a rough, purely informational stat.
+type EnergyConfig struct {
+	Enabled bool `yaml:"enabled"`
+
+	// JoulesPer1KTokens maps a model class to joules per 1,000 tokens. A
+	// model uses the longest class its ID contains, else "default".
+	JoulesPer1KTokens map[string]float64 `yaml:"joules_per_1k_tokens"`
+
+	// GramsCO2PerKWh is the carbon intensity of the grid powering the
+	// models.
+	GramsCO2PerKWh float64 `yaml:"grams_co2_per_kwh"`
+}
+
+// Model converts the config into a session.EnergyModel. The zero model,
+// used while disabled, estimates nothing.
+func (e EnergyConfig) Model() session.EnergyModel {
+	if !e.Enabled {
+		return session.EnergyModel{}
+	}
+	return session.EnergyModel{JoulesPer1K: maps.Clone(e.JoulesPer1KTokens), GramsCO2PerKWh: e.GramsCO2PerKWh}
+}
+
+// DebugConfig holds settings for diagnosing the server itself.
+type DebugConfig struct {
+	// StoreHistory is how long the session store keeps what it held after
+	// each change, for /api/debug/store/at. Zero keeps nothing.
+	StoreHistory time.Duration `yaml:"store_history"`
+}
+
+// LaunchConfig holds the session templates POST /api/launch can start and
+// the pipelines that chain them.
+type LaunchConfig struct {
+	Templates []launch.Template `yaml:"templates"`
+	// Pipelines launch each stage's template when the previous stage's
+	// session completes.
+	Pipelines []launch.Pipeline `yaml:"pipelines"`
+}
+
+// Settings converts the config into launch.Settings.
+func (l LaunchConfig) Settings() launch.Settings {
+	return launch.Settings{Templates: l.Templates, Pipelines: l.Pipelines}
+}
+
+// BenchmarksConfig controls the benchmark runner, which launches the
+// configured agents side by side and records how each did.
+type BenchmarksConfig struct {
+	// Schedule runs every task this often. 0 runs them only on
+	// POST /api/benchmarks/run.
+	Schedule time.Duration `yaml:"schedule"`
+
+	// Timeout stops an agent that runs longer than this.
+	Timeout time.Duration `yaml:"timeout"`
+
+	Tasks []benchmark.Task `yaml:"tasks"`
+}
+
+// Settings converts the config into benchmark.Settings.
+func (b BenchmarksConfig) Settings() benchmark.Settings {
+	return benchmark.Settings{Schedule: b.Schedule, Timeout: b.Timeout, Tasks: b.Tasks}
+}
+
+// CommentaryConfig controls server-side race commentary broadcast as
+// "commentary" WebSocket messages.
+type CommentaryConfig struct {
+	Enabled bool `yaml:"enabled"`
+
+	// Templates overrides the built-in line for an event, keyed by event
+	// name. An empty string silences that event.
+	Templates map[string]string `yaml:"templates"`
+}
+
+// ReactionsConfig controls the emoji reactions viewers send about a
+// session, which are passed on to every client as "reaction" messages.
+type ReactionsConfig struct {
+	Enabled bool `yaml:"enabled"`
+
+	// Record counts each reaction against its session, so the counts
+	// appear in snapshots, the store history and replays.
+	Record bool `yaml:"record"`
+
+	// Emoji is the set viewers may choose from; anything else is dropped.
+	Emoji []string `yaml:"emoji"`
+}
+
+// ChatOpsConfig holds the chat integrations that answer slash commands.
+type ChatOpsConfig struct {
+	Slack SlackConfig `yaml:"slack"`
+}
+
+// SlackConfig enables the /racer slash command at /api/chatops/slack.
+type SlackConfig struct {
+	Enabled bool `yaml:"enabled"`
+
+	// SigningSecretEnv names the environment variable holding the Slack
+	// app's signing secret, used to check that commands really come from
+	// Slack. Like sources.remote.api_key_env, it keeps the secret out of
+	// the file.
+	SigningSecretEnv string `yaml:"signing_secret_env"`
+
+	// BotTokenEnv names the environment variable holding a bot token
+	// (xoxb-…) that lets "/racer notify" post completions with
+	// chat.postMessage. Without one they are sent to the command's
+	// response URL, which Slack expires after 30 minutes.
+	BotTokenEnv string `yaml:"bot_token_env"`
+}
+
+// Settings converts the config into chatops.Settings, reading the secrets
+// from the environment.
+func (s SlackConfig) Settings() chatops.Settings {
+	return chatops.Settings{
+		Enabled:       s.Enabled,
+		SigningSecret: envValue(s.SigningSecretEnv),
+		BotToken:      envValue(s.BotTokenEnv),
+	}
+}
+
+// envValue returns the value of the environment variable name, or "" when
+// name is empty.
+func envValue(name string) string {
+	if name == "" {
+		return ""
+	}
+	return os.Getenv(name)
+}
+
+// maxReactionRunes bounds an allowed reaction: long enough for emoji
+// built from several code points, too short for a message.
+const maxReactionRunes = 8
+
+// StatusConfig controls the public status page at /status.
+type StatusConfig struct {
+	// Enabled serves the page. It needs no auth token, so it is off by
+	// default; it shows only what the privacy filter lets through.
+	Enabled bool `yaml:"enabled"`
+
+	// MinDuration hides sessions that have been running for less than this.
+	MinDuration time.Duration `yaml:"min_duration"`
+}
+
+// DisplayConfig sets how clients show timestamps unless they ask for
+// something else over the WebSocket.
+type DisplayConfig struct {
+	// TimeZone is an IANA zone name such as "Europe/Berlin". Empty leaves
+	// each client in its own local zone.
+	TimeZone string `yaml:"time_zone"`
+
+	// Clock is "12h" or "24h". Empty follows each client's locale.
+	Clock string `yaml:"clock"`
+
+	// Language is the language of achievement names and descriptions and
+	// of the built-in commentary lines, as a code from i18n.Languages.
+	Language string `yaml:"language"`
+}
+
+// Clock formats accepted by DisplayConfig.Clock.
+const (
+	Clock12h = "12h"
+	Clock24h = "24h"
+)
+
+// ValidateTimeZone reports whether name is empty or a known IANA time zone.
+func ValidateTimeZone(name string) error {
+	if name == "" {
+		return nil
+	}
+	if _, err := time.LoadLocation(name); err != nil {
+		return fmt.Errorf("unknown time zone %q", name)
+	}
+	return nil
+}
+
+// Location returns the zone named by TimeZone, or the server's local zone
+// when it is empty or unknown.
+func (d DisplayConfig) Location() *time.Location {
+	if d.TimeZone == "" {
+		return time.Local
+	}
+	loc, err := time.LoadLocation(d.TimeZone)
+	if err != nil {
+		return time.Local
+	}
+	return loc
+}
+
+// ValidateClock reports whether clock is empty, Clock12h or Clock24h.
+func ValidateClock(clock string) error {
+	if clock != "" && clock != Clock12h && clock != Clock24h {
+		return fmt.Errorf("clock must be %s or %s, got %q", Clock12h, Clock24h, clock)
+	}
+	return nil
+}
+
+// GraphQLConfig controls the /graphql query endpoint.
+type GraphQLConfig struct {
+	// Enabled serves /graphql. Requests need the auth token, like the REST
+	// API.
+	Enabled bool `yaml:"enabled"`
+}
+
+// EmbedConfig controls the single-session widget served at /embed/{id}.
+type EmbedConfig struct {
+	// FrameAncestors lists the origins allowed to put the widget in an
+	// iframe, as CSP frame-ancestors sources. The default, 'self', allows
+	// only the dashboard's own origin; "*" allows any page.
+	FrameAncestors []string `yaml:"frame_ancestors"`
+}
+
+// ShareConfig controls read-only session share links.
+type ShareConfig struct {
+	// DefaultTTL is how long a link stays valid when the request does not
+	// ask for a specific lifetime.
+	DefaultTTL time.Duration `yaml:"default_ttl"`
+
+	// MaxTTL caps the lifetime a request may ask for.
+	MaxTTL time.Duration `yaml:"max_ttl"`
+}
+
+// UpdatesConfig controls the daily check for new releases.
+type UpdatesConfig struct {
+	// Check looks up the latest GitHub release once a day and tells
+	// connected clients when a newer version is available. It contacts
+	// GitHub, so it is off until the user opts in. Development builds
+	// never check.
+	Check bool `yaml:"check"`
+
+	// Repo is the GitHub "owner/name" whose releases are checked.
+	Repo string `yaml:"repo"`
+}
+
+// suggestedIssuePattern matches branches such as "issue-123" and "gh-42".
+// The example config offers it for links.issue_pattern; it is not the
+// default, as issue links name the repository.
+const suggestedIssuePattern = `(?i)(?:^|[/_-])(?:issue|gh)[-_]?(\d+)`
+
+// LinksConfig controls linking sessions to the issue and pull request
+// their branch is working on.
+type LinksConfig struct {
+	// IssuePattern is a regular expression matched against the branch
+	// name. Its first capture group is the issue number. Empty, the
+	// default, disables issue links.
+	IssuePattern string `yaml:"issue_pattern"`
+
+	// IssueURLTemplate builds the issue URL from "{repo}" (the web URL of
+	// the origin remote) and "{number}".
+	IssueURLTemplate string `yaml:"issue_url_template"`
+
+	// PRLookup runs `gh pr view` to find a pull request for the branch.
+	// Opt-in: it uses the GitHub CLI's own authentication.
+	PRLookup bool `yaml:"pr_lookup"`
+
+	// CacheTTL is how long a resolved link is reused before it is looked
+	// up again.
+	CacheTTL time.Duration `yaml:"cache_ttl"`
+}
+
+// Options converts the config into links.Options.
+func (l *LinksConfig) Options() links.Options {
+	return links.Options{
+		IssuePattern:     l.IssuePattern,
+		IssueURLTemplate: l.IssueURLTemplate,
+		PRLookup:         l.PRLookup,
+		CacheTTL:         l.CacheTTL,
+	}
 }
 
 // ReplayConfig controls session replay recording.
@@ -47,9 +373,65 @@ type TrackConfig struct {
 	Active string `yaml:"active"` // track ID to use; empty = default linear track
 }
 
+// RaceConfig controls how sessions are ranked against each other.
+type RaceConfig struct {
+	// ProgressMetric decides who is ahead: context, tokens, messages,
+	// tool_calls or elapsed. It drives each session's position, overtake
+	// events and the order of sessions in payloads.
+	ProgressMetric session.ProgressMetric `yaml:"progress_metric"`
+
+	// Laps decides what completes a lap: "compaction" (each context
+	// compaction) or "tokens" (every LapTokens tokens burned).
+	Laps      session.LapMode `yaml:"laps"`
+	LapTokens int             `yaml:"lap_tokens"`
+
+	// AutoHeatWindow races sessions on the same project automatically
+	// when they start within this long of each other; the first to
+	// complete wins. 0 disables it.
+	AutoHeatWindow time.Duration `yaml:"auto_heat_window"`
+
+	// SpeedSmoothing is the time constant of the moving average that
+	// smooths each session's burn rate into burnRateSmoothed and speed.
+	// 0 passes the raw rate through.
+	SpeedSmoothing time.Duration `yaml:"speed_smoothing"`
+}
+
+// Rules converts the config into session.RaceRules.
+func (r RaceConfig) Rules() session.RaceRules {
+	return session.RaceRules{
+		Metric: r.ProgressMetric,
+		Laps:   session.LapRule{Mode: r.Laps, Tokens: r.LapTokens},
+		// Energy is filled in by Config.RaceRules.
+		SpeedSmoothing: r.SpeedSmoothing,
+	}
+}
+
+// RaceRules is Race.Rules with the energy model added.
+func (c *Config) RaceRules() session.RaceRules {
+	rules := c.Race.Rules()
+	rules.Energy = c.Energy.Model()
+	return rules
+}
+
 // GamificationConfig holds settings for the gamification subsystem.
 type GamificationConfig struct {
 	BattlePass BattlePassConfig `yaml:"battle_pass"`
+	Storage    StorageConfig    `yaml:"storage"`
+	// ToolAchievements are extra achievements over the tool calls
+	// sessions make. Changes take effect on restart.
+	ToolAchievements []gamification.ToolAchievement `yaml:"tool_achievements"`
+	// AntiGrind caps the XP that merely observing sessions can earn.
+	AntiGrind gamification.GrindLimits `yaml:"anti_grind"`
+}
+
+// StorageConfig selects where gamification stats are kept. Changes take
+// effect on restart.
+type StorageConfig struct {
+	// Backend is "json" (the default) or "sqlite".
+	Backend string `yaml:"backend"`
+	// Path is the stats directory for json or the database file for
+	// sqlite. Empty uses the XDG state directory.
+	Path string `yaml:"path"`
 }
 
 // BattlePassConfig controls seasonal battle pass behavior.
@@ -59,6 +441,8 @@ type BattlePassConfig struct {
 	// Season identifies the current season (e.g. "2025-07").
 	// Changing this value triggers a season rotation on next startup.
 	Season string `yaml:"season"`
+	// SeasonEnd decides what a season leaves when it rotates.
+	SeasonEnd gamification.SeasonEndRules `yaml:"season_end"`
 }
 
 // PrivacyConfig controls what session metadata is exposed to connected clients.
@@ -84,6 +468,16 @@ type PrivacyConfig struct {
 	// directory matches any pattern are excluded from broadcast.
 	// BlockedPaths is evaluated after AllowedPaths.
 	BlockedPaths []string `yaml:"blocked_paths"`
+
+	// ShowTopics labels sessions with a short topic derived from the
+	// first user prompt (secrets, emails and paths are redacted). Off by
+	// default because prompts can reveal what a project is about.
+	ShowTopics bool `yaml:"show_topics"`
+
+	// ShowViewerNames includes the names dashboards and TUIs connect
+	// with in presence messages. Viewers choose their own names; with
+	// this off, presence only counts them.
+	ShowViewerNames bool `yaml:"show_viewer_names"`
 }
 
 // NewPrivacyFilter converts the config into a session.PrivacyFilter.
@@ -95,6 +489,7 @@ func (p *PrivacyConfig) NewPrivacyFilter() *session.PrivacyFilter {
 		MaskTmuxTargets: p.MaskTmuxTargets,
 		AllowedPaths:    p.AllowedPaths,
 		BlockedPaths:    p.BlockedPaths,
+		HideViewerNames: !p.ShowViewerNames,
 	}
 }
 
@@ -107,6 +502,7 @@ type TokenNormConfig struct {
 	//   "usage"         -- use real token counts from the source
 	//   "estimate"      -- estimate tokens from message count
 	//   "message_count" -- same as estimate (message-count heuristic)
+	//   "tokenizer"     -- count tokens in message text with Tokenizer
 	// A "default" key provides the fallback for unlisted sources.
 	Strategies map[string]string `yaml:"strategies"`
 
@@ -114,12 +510,115 @@ type TokenNormConfig struct {
 	// "estimate" and "message_count" strategies. Also used as a fallback
 	// when a "usage" source has not yet reported token data.
 	TokensPerMessage int `yaml:"tokens_per_message"`
+
+	// Tokenizer names the tokenizer the "tokenizer" strategy counts
+	// message text with: "cl100k", "o200k", "approx", "bytes", or one
+	// registered with tokenizer.Register.
+	Tokenizer string `yaml:"tokenizer"`
+
+	// CompactionThreshold is the context utilization (0-1] at which the
+	// agent is expected to compact. Sessions forecast when they will get
+	// there from their burn rate.
+	CompactionThreshold float64 `yaml:"compaction_threshold"`
 }
 
 type SourcesConfig struct {
-	Claude bool `yaml:"claude"`
-	Codex  bool `yaml:"codex"`
-	Gemini bool `yaml:"gemini"`
+	Claude     bool                   `yaml:"claude"`
+	Codex      bool                   `yaml:"codex"`
+	Gemini     bool                   `yaml:"gemini"`
+	Remote     RemoteSourceConfig     `yaml:"remote"`
+	SSH        SSHSourceConfig        `yaml:"ssh"`
+	Kubernetes KubernetesSourceConfig `yaml:"kubernetes"`
+	Self       SelfSourceConfig       `yaml:"self"`
+	External   ExternalSourceConfig   `yaml:"external"`
+}
+
+// ExternalSourceConfig controls accepting sessions that scripts and CI jobs
+// report with PUT /api/external/sessions/{id}.
+type ExternalSourceConfig struct {
+	Enabled bool `yaml:"enabled"`
+
+	// SessionTTL is how long a reported session stays on the track without
+	// another report before it is marked lost.
+	SessionTTL time.Duration `yaml:"session_ttl"`
+}
+
+// SelfSourceConfig controls putting the server itself on the track as a
+// session, a health indicator for wall dashboards.
+type SelfSourceConfig struct {
+	Enabled bool `yaml:"enabled"`
+
+	// Interval is how often the server reports itself.
+	Interval time.Duration `yaml:"interval"`
+
+	// MemoryBudget is the heap, in MiB, that counts as the server's full
+	// context window.
+	MemoryBudget int `yaml:"memory_budget_mb"`
+}
+
+// KubernetesSourceConfig controls following agent runs in Kubernetes pods,
+// such as batch Jobs running `claude -p --output-format stream-json`.
+type KubernetesSourceConfig struct {
+	Enabled bool `yaml:"enabled"`
+
+	// Context is the kubeconfig context to use. Empty uses the current
+	// context.
+	Context string `yaml:"context"`
+
+	// Namespace limits discovery to one namespace. Empty watches all
+	// namespaces the credentials can list.
+	Namespace string `yaml:"namespace"`
+
+	// Selector is the label selector agent pods carry.
+	Selector string `yaml:"selector"`
+
+	// Container names the container whose logs hold the agent output, for
+	// pods with sidecars. Empty reads the pod's only container.
+	Container string `yaml:"container"`
+
+	// Interval is how often pods are listed and their logs fetched.
+	Interval time.Duration `yaml:"interval"`
+}
+
+// SSHSourceConfig controls following Claude Code sessions on a machine
+// reachable over SSH, without running a second server there.
+type SSHSourceConfig struct {
+	Enabled bool `yaml:"enabled"`
+
+	// Host is the ssh destination: "user@host", an ssh_config alias or
+	// "ssh://user@host:port".
+	Host string `yaml:"host"`
+
+	// Path is the Claude projects directory on the remote host. A leading
+	// "~/" is the remote home directory.
+	Path string `yaml:"path"`
+
+	// IdentityFile is the private key passed to ssh -i. Empty uses the
+	// ssh agent and ssh_config.
+	IdentityFile string `yaml:"identity_file"`
+
+	// Interval is how often the remote directory is listed and new
+	// transcript bytes are copied.
+	Interval time.Duration `yaml:"interval"`
+}
+
+// RemoteSourceConfig controls polling an HTTP endpoint for sessions running
+// on other machines, such as a teammate's agent-racer /api/sessions or a
+// proxy in front of a team's usage API.
+type RemoteSourceConfig struct {
+	Enabled bool `yaml:"enabled"`
+
+	// URL returns the sessions as a JSON array, or as {"sessions": [...]},
+	// in the SessionState wire format.
+	URL string `yaml:"url"`
+
+	// APIKeyEnv names the environment variable holding the key sent as a
+	// bearer token. Keeping it out of the file keeps it out of backups.
+	APIKeyEnv string `yaml:"api_key_env"`
+
+	// Interval is how often URL is fetched. The monitor's poll_interval
+	// is usually far shorter than a remote API should be hit.
+	Interval time.Duration `yaml:"interval"`
 }
 
 type ServerConfig struct {
@@ -149,12 +648,22 @@ type MonitorConfig struct {
 	PollInterval            time.Duration `yaml:"poll_interval"`
 	SnapshotInterval        time.Duration `yaml:"snapshot_interval"`
 	BroadcastThrottle       time.Duration `yaml:"broadcast_throttle"`
+	BroadcastThrottleMax    time.Duration `yaml:"broadcast_throttle_max"` // longest the throttle may grow to under load; at or below broadcast_throttle keeps it fixed
+	BroadcastTargetRate     float64       `yaml:"broadcast_target_rate"`  // messages per second per client the throttle aims for; 0 keeps it fixed
+	CatchUpWindow           time.Duration `yaml:"catch_up_window"`        // broadcasts kept for reconnecting clients; 0 disables
+	EventLogSize            int           `yaml:"event_log_size"`         // store mutations kept in the event log that feeds the broadcaster; 0 disables
+	MaxSubagents            int           `yaml:"max_subagents"`          // subagents the store keeps per session, oldest finished dropped first; 0 is unlimited
+	SubagentContextShare    float64       `yaml:"subagent_context_share"` // share of the session's context window one subagent may use before a subagent_budget warning; 0 disables
+	SubagentTokenCap        int           `yaml:"subagent_token_cap"`     // tokens one subagent may use before a warning, overriding subagent_context_share; 0 uses the share
 	SessionStaleAfter       time.Duration `yaml:"session_stale_after"`
 	CompletionRemoveAfter   time.Duration `yaml:"completion_remove_after"`
 	SessionEndDir           string        `yaml:"session_end_dir"`
 	ChurningCPUThreshold    float64       `yaml:"churning_cpu_threshold"`
 	ChurningRequiresNetwork bool          `yaml:"churning_requires_network"`
+	ApprovalPromptAfter     time.Duration `yaml:"approval_prompt_after"` // tool call with no result from an idle agent that counts as a permission prompt; 0 disables
 	HealthWarningThreshold  int           `yaml:"health_warning_threshold"`
+	HealthFlapThreshold     int           `yaml:"health_flap_threshold"` // transitions in health_flap_window that silence a source's health events; 0 disables
+	HealthFlapWindow        time.Duration `yaml:"health_flap_window"`
 	StatsEventBuffer        int           `yaml:"stats_event_buffer"`
 	MockTickInterval        time.Duration `yaml:"mock_tick_interval"`
 }
@@ -230,6 +739,17 @@ func (c *Config) Validate() error {
 	if c.Monitor.BroadcastThrottle <= 0 {
 		errs = append(errs, fmt.Sprintf("monitor.broadcast_throttle: must be positive, got %s", c.Monitor.BroadcastThrottle))
 	}
+	if c.Monitor.BroadcastThrottleMax < 0 {
+		errs = append(errs, fmt.Sprintf("monitor.broadcast_throttle_max: must be 0 or positive, got %s", c.Monitor.BroadcastThrottleMax))
+	} else if c.Monitor.BroadcastThrottleMax > 0 && c.Monitor.BroadcastThrottleMax < c.Monitor.BroadcastThrottle {
+		errs = append(errs, fmt.Sprintf("monitor.broadcast_throttle_max: must be 0 or at least broadcast_throttle (%s), got %s", c.Monitor.BroadcastThrottle, c.Monitor.BroadcastThrottleMax))
+	}
+	if c.Monitor.BroadcastTargetRate < 0 {
+		errs = append(errs, fmt.Sprintf("monitor.broadcast_target_rate: must be 0 or positive, got %g", c.Monitor.BroadcastTargetRate))
+	}
+	if c.Monitor.CatchUpWindow < 0 {
+		errs = append(errs, fmt.Sprintf("monitor.catch_up_window: must be 0 or positive, got %s", c.Monitor.CatchUpWindow))
+	}
 	// 0 means "disable stale detection"; negative is nonsensical.
 	if c.Monitor.SessionStaleAfter < 0 {
 		errs = append(errs, fmt.Sprintf("monitor.session_stale_after: must not be negative, got %s", c.Monitor.SessionStaleAfter))
@@ -240,14 +760,41 @@ func (c *Config) Validate() error {
 	if c.Monitor.ChurningCPUThreshold < 0 {
 		errs = append(errs, fmt.Sprintf("monitor.churning_cpu_threshold: must not be negative, got %g", c.Monitor.ChurningCPUThreshold))
 	}
+	if c.Monitor.ApprovalPromptAfter < 0 {
+		errs = append(errs, fmt.Sprintf("monitor.approval_prompt_after: must not be negative, got %s", c.Monitor.ApprovalPromptAfter))
+	}
 	if c.Monitor.HealthWarningThreshold < 0 {
 		errs = append(errs, fmt.Sprintf("monitor.health_warning_threshold: must not be negative, got %d", c.Monitor.HealthWarningThreshold))
 	}
+	if c.Monitor.EventLogSize < 0 {
+		errs = append(errs, fmt.Sprintf("monitor.event_log_size: must not be negative, got %d", c.Monitor.EventLogSize))
+	}
+	if c.Monitor.MaxSubagents < 0 {
+		errs = append(errs, fmt.Sprintf("monitor.max_subagents: must not be negative, got %d", c.Monitor.MaxSubagents))
+	}
+	if c.Monitor.SubagentContextShare < 0 || c.Monitor.SubagentContextShare > 1 {
+		errs = append(errs, fmt.Sprintf("monitor.subagent_context_share: must be in [0, 1], got %g", c.Monitor.SubagentContextShare))
+	}
+	if c.Monitor.SubagentTokenCap < 0 {
+		errs = append(errs, fmt.Sprintf("monitor.subagent_token_cap: must not be negative, got %d", c.Monitor.SubagentTokenCap))
+	}
+	if c.Monitor.HealthFlapThreshold < 0 {
+		errs = append(errs, fmt.Sprintf("monitor.health_flap_threshold: must not be negative, got %d", c.Monitor.HealthFlapThreshold))
+	}
+	if c.Monitor.HealthFlapThreshold > 0 && c.Monitor.HealthFlapWindow <= 0 {
+		errs = append(errs, fmt.Sprintf("monitor.health_flap_window: must be positive when health_flap_threshold is set, got %s", c.Monitor.HealthFlapWindow))
+	}
 
 	// Token normalization — used as a multiplier; zero/negative is meaningless.
 	if c.TokenNorm.TokensPerMessage <= 0 {
 		errs = append(errs, fmt.Sprintf("token_normalization.tokens_per_message: must be positive, got %d", c.TokenNorm.TokensPerMessage))
 	}
+	if _, ok := tokenizer.Lookup(c.TokenNorm.Tokenizer); !ok {
+		errs = append(errs, fmt.Sprintf("token_normalization.tokenizer: must be one of %s, got %q", strings.Join(tokenizer.Names(), ", "), c.TokenNorm.Tokenizer))
+	}
+	if c.TokenNorm.CompactionThreshold <= 0 || c.TokenNorm.CompactionThreshold > 1 {
+		errs = append(errs, fmt.Sprintf("token_normalization.compaction_threshold: must be in (0, 1], got %g", c.TokenNorm.CompactionThreshold))
+	}
 
 	// Sound volumes — negative makes no sense.
 	if c.Sound.MasterVolume < 0 {
@@ -260,11 +807,216 @@ func (c *Config) Validate() error {
 		errs = append(errs, fmt.Sprintf("sound.sfx_volume: must not be negative, got %g", c.Sound.SfxVolume))
 	}
 
+	// Sources
+	if c.Sources.Remote.Enabled {
+		if u, err := url.Parse(c.Sources.Remote.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Sprintf("sources.remote.url: must be an http(s) URL, got %q", c.Sources.Remote.URL))
+		}
+		if c.Sources.Remote.Interval < time.Second {
+			errs = append(errs, fmt.Sprintf("sources.remote.interval: must be at least 1s, got %v", c.Sources.Remote.Interval))
+		}
+	}
+	if c.Sources.SSH.Enabled {
+		// The host is passed to ssh after "--", but a leading dash is
+		// still never a real destination.
+		if c.Sources.SSH.Host == "" || strings.HasPrefix(c.Sources.SSH.Host, "-") {
+			errs = append(errs, fmt.Sprintf("sources.ssh.host: must be an ssh destination, got %q", c.Sources.SSH.Host))
+		}
+		if c.Sources.SSH.Path == "" {
+			errs = append(errs, "sources.ssh.path: must not be empty")
+		}
+		if c.Sources.SSH.Interval < time.Second {
+			errs = append(errs, fmt.Sprintf("sources.ssh.interval: must be at least 1s, got %v", c.Sources.SSH.Interval))
+		}
+	}
+	if c.Sources.Kubernetes.Enabled {
+		if c.Sources.Kubernetes.Selector == "" {
+			errs = append(errs, "sources.kubernetes.selector: must not be empty")
+		}
+		if c.Sources.Kubernetes.Interval < time.Second {
+			errs = append(errs, fmt.Sprintf("sources.kubernetes.interval: must be at least 1s, got %v", c.Sources.Kubernetes.Interval))
+		}
+	}
+	if c.Sources.Self.Enabled {
+		if c.Sources.Self.Interval < time.Second {
+			errs = append(errs, fmt.Sprintf("sources.self.interval: must be at least 1s, got %v", c.Sources.Self.Interval))
+		}
+		if c.Sources.Self.MemoryBudget <= 0 {
+			errs = append(errs, fmt.Sprintf("sources.self.memory_budget_mb: must be positive, got %d", c.Sources.Self.MemoryBudget))
+		}
+	}
+	if c.Sources.External.Enabled && c.Sources.External.SessionTTL < time.Second {
+		errs = append(errs, fmt.Sprintf("sources.external.session_ttl: must be at least 1s, got %v", c.Sources.External.SessionTTL))
+	}
+
 	// Replay — 0 means keep forever; negative is nonsensical.
 	if c.Replay.RetentionDays < 0 {
 		errs = append(errs, fmt.Sprintf("replay.retention_days: must not be negative, got %d", c.Replay.RetentionDays))
 	}
 
+	// Gamification
+	switch c.Gamification.Storage.Backend {
+	case "", "json", "sqlite":
+	default:
+		errs = append(errs, fmt.Sprintf("gamification.storage.backend: must be json or sqlite, got %q", c.Gamification.Storage.Backend))
+	}
+	for _, e := range gamification.ValidateToolAchievements(c.Gamification.ToolAchievements) {
+		errs = append(errs, "gamification.tool_achievements: "+e)
+	}
+	for _, e := range gamification.ValidateSeasonEndRules(c.Gamification.BattlePass.SeasonEnd) {
+		errs = append(errs, "gamification.battle_pass.season_end."+e)
+	}
+	if c.Gamification.AntiGrind.ObservedXPPerHour < 0 {
+		errs = append(errs, fmt.Sprintf("gamification.anti_grind.observed_xp_per_hour: must not be negative, got %d", c.Gamification.AntiGrind.ObservedXPPerHour))
+	}
+	if c.Gamification.AntiGrind.MinSessionDuration < 0 {
+		errs = append(errs, fmt.Sprintf("gamification.anti_grind.min_session_duration: must not be negative, got %s", c.Gamification.AntiGrind.MinSessionDuration))
+	}
+
+	if c.Debug.StoreHistory < 0 {
+		errs = append(errs, fmt.Sprintf("debug.store_history: must not be negative, got %s", c.Debug.StoreHistory))
+	}
+
+	// Links
+	if c.Links.IssuePattern != "" {
+		if re, err := regexp.Compile(c.Links.IssuePattern); err != nil {
+			errs = append(errs, fmt.Sprintf("links.issue_pattern: invalid regular expression: %v", err))
+		} else if re.NumSubexp() < 1 {
+			errs = append(errs, "links.issue_pattern: must contain a capture group for the issue number")
+		}
+		if !strings.Contains(c.Links.IssueURLTemplate, "{number}") {
+			errs = append(errs, fmt.Sprintf("links.issue_url_template: must contain {number}, got %q", c.Links.IssueURLTemplate))
+		}
+	}
+	if c.Links.CacheTTL <= 0 {
+		errs = append(errs, fmt.Sprintf("links.cache_ttl: must be positive, got %s", c.Links.CacheTTL))
+	}
+
+	// Share
+	if c.Share.DefaultTTL <= 0 {
+		errs = append(errs, fmt.Sprintf("share.default_ttl: must be positive, got %s", c.Share.DefaultTTL))
+	}
+	if c.Share.MaxTTL < c.Share.DefaultTTL {
+		errs = append(errs, fmt.Sprintf("share.max_ttl: must be at least share.default_ttl (%s), got %s", c.Share.DefaultTTL, c.Share.MaxTTL))
+	}
+
+	// Embed
+	if len(c.Embed.FrameAncestors) == 0 {
+		errs = append(errs, "embed.frame_ancestors: must list at least one source (use 'none' to disable framing)")
+	}
+	for _, src := range c.Embed.FrameAncestors {
+		if src == "" || strings.ContainsAny(src, " \t;,") {
+			errs = append(errs, fmt.Sprintf("embed.frame_ancestors: invalid source %q", src))
+		}
+	}
+
+	// Status
+	if c.Status.MinDuration < 0 {
+		errs = append(errs, fmt.Sprintf("status.min_duration: must be non-negative, got %s", c.Status.MinDuration))
+	}
+
+	// Display
+	if err := ValidateTimeZone(c.Display.TimeZone); err != nil {
+		errs = append(errs, "display.time_zone: "+err.Error())
+	}
+	if err := ValidateClock(c.Display.Clock); err != nil {
+		errs = append(errs, "display.clock: "+err.Error())
+	}
+	if !i18n.Supported(c.Display.Language) {
+		errs = append(errs, fmt.Sprintf("display.language: must be one of %v, got %q", i18n.Languages, c.Display.Language))
+	}
+
+	// Race
+	if !c.Race.ProgressMetric.Valid() {
+		errs = append(errs, fmt.Sprintf("race.progress_metric: must be one of %v, got %q", session.ProgressMetrics, c.Race.ProgressMetric))
+	}
+	if c.Race.Laps != session.LapsCompaction && c.Race.Laps != session.LapsTokens {
+		errs = append(errs, fmt.Sprintf("race.laps: must be compaction or tokens, got %q", c.Race.Laps))
+	}
+	if c.Race.Laps == session.LapsTokens && c.Race.LapTokens <= 0 {
+		errs = append(errs, fmt.Sprintf("race.lap_tokens: must be positive when race.laps is tokens, got %d", c.Race.LapTokens))
+	}
+	if c.Race.AutoHeatWindow < 0 {
+		errs = append(errs, fmt.Sprintf("race.auto_heat_window: must be >= 0, got %s", c.Race.AutoHeatWindow))
+	}
+	if c.Race.SpeedSmoothing < 0 {
+		errs = append(errs, fmt.Sprintf("race.speed_smoothing: must be >= 0, got %s", c.Race.SpeedSmoothing))
+	}
+
+	// Commentary
+	for _, e := range commentary.ValidateTemplates(c.Commentary.Templates) {
+		errs = append(errs, "commentary.templates: "+e)
+	}
+
+	// Chat ops
+	if c.ChatOps.Slack.Enabled && envValue(c.ChatOps.Slack.SigningSecretEnv) == "" {
+		errs = append(errs, fmt.Sprintf("chatops.slack.signing_secret_env: %q must name a set environment variable when chatops.slack.enabled is true", c.ChatOps.Slack.SigningSecretEnv))
+	}
+
+	// Watchdog
+	if c.Watchdog.Enabled && c.Watchdog.QuietAfter < watchdog.MinQuietAfter {
+		errs = append(errs, fmt.Sprintf("watchdog.quiet_after: must be at least %s, got %s", watchdog.MinQuietAfter, c.Watchdog.QuietAfter))
+	}
+	if _, err := watchdog.ParseHours(c.Watchdog.WorkHours); err != nil {
+		errs = append(errs, "watchdog.work_hours: "+err.Error())
+	}
+	for i := 0; i < len(c.Watchdog.WorkDays); i++ {
+		if _, err := watchdog.ParseWeekday(c.Watchdog.WorkDays[i]); err != nil {
+			errs = append(errs, fmt.Sprintf("watchdog.work_days[%d]: %v", i, err))
+		}
+	}
+
+	// Energy
+	for _, class := range slices.Sorted(maps.Keys(c.Energy.JoulesPer1KTokens)) {
+		if j := c.Energy.JoulesPer1KTokens[class]; class == "" || j < 0 {
+			errs = append(errs, fmt.Sprintf("energy.joules_per_1k_tokens[%q]: must name a model class and be >= 0, got %v", class, j))
+		}
+	}
+	if c.Energy.GramsCO2PerKWh < 0 {
+		errs = append(errs, fmt.Sprintf("energy.grams_co2_per_kwh: must be >= 0, got %v", c.Energy.GramsCO2PerKWh))
+	}
+
+	// Reactions
+	seenEmoji := make(map[string]bool, len(c.Reactions.Emoji))
+	for i := 0; i < len(c.Reactions.Emoji); i++ {
+		e := c.Reactions.Emoji[i]
+		switch n := utf8.RuneCountInString(e); {
+		case n == 0 || strings.TrimSpace(e) != e:
+			errs = append(errs, fmt.Sprintf("reactions.emoji[%d]: must be non-empty with no surrounding spaces, got %q", i, e))
+		case n > maxReactionRunes:
+			errs = append(errs, fmt.Sprintf("reactions.emoji[%d]: must be at most %d characters, got %q", i, maxReactionRunes, e))
+		case seenEmoji[e]:
+			errs = append(errs, fmt.Sprintf("reactions.emoji[%d]: duplicate %q", i, e))
+		}
+		seenEmoji[e] = true
+	}
+
+	// Benchmarks — 0 disables the schedule.
+	if c.Benchmarks.Schedule != 0 && c.Benchmarks.Schedule < benchmark.MinSchedule {
+		errs = append(errs, fmt.Sprintf("benchmarks.schedule: must be 0 or at least %s, got %s", benchmark.MinSchedule, c.Benchmarks.Schedule))
+	}
+	if c.Benchmarks.Timeout <= 0 {
+		errs = append(errs, fmt.Sprintf("benchmarks.timeout: must be positive, got %s", c.Benchmarks.Timeout))
+	}
+	for _, e := range benchmark.ValidateTasks(c.Benchmarks.Tasks) {
+		errs = append(errs, "benchmarks.tasks: "+e)
+	}
+
+	// Launch
+	for _, e := range launch.ValidateTemplates(c.Launch.Templates) {
+		errs = append(errs, "launch.templates: "+e)
+	}
+	for _, e := range launch.ValidatePipelines(c.Launch.Pipelines, c.Launch.Templates) {
+		errs = append(errs, "launch.pipelines: "+e)
+	}
+
+	// Updates
+	if c.Updates.Check {
+		if owner, name, ok := strings.Cut(c.Updates.Repo, "/"); !ok || owner == "" || name == "" || strings.Contains(name, "/") {
+			errs = append(errs, fmt.Sprintf("updates.repo: must be owner/name, got %q", c.Updates.Repo))
+		}
+	}
+
 	if len(errs) == 0 {
 		return nil
 	}
@@ -305,18 +1057,44 @@ func defaultConfig() *Config {
 			PollInterval:            time.Second,
 			SnapshotInterval:        5 * time.Second,
 			BroadcastThrottle:       100 * time.Millisecond,
+			BroadcastThrottleMax:    time.Second,
+			BroadcastTargetRate:     5,
+			CatchUpWindow:           10 * time.Minute,
+			MaxSubagents:            200,
+			SubagentContextShare:    0.5,
 			SessionStaleAfter:       2 * time.Minute,
 			CompletionRemoveAfter:   5 * time.Minute,
 			SessionEndDir:           filepath.Join(defaultStateDir(), "agent-racer", "session-end"),
 			ChurningCPUThreshold:    15.0,
 			ChurningRequiresNetwork: false,
 			HealthWarningThreshold:  3,
+			HealthFlapThreshold:     4,
+			HealthFlapWindow:        5 * time.Minute,
 			StatsEventBuffer:        256,
 		},
 		Sources: SourcesConfig{
 			Claude: true,
 			Codex:  false,
 			Gemini: false,
+			Remote: RemoteSourceConfig{
+				APIKeyEnv: "AGENT_RACER_REMOTE_API_KEY",
+				Interval:  15 * time.Second,
+			},
+			SSH: SSHSourceConfig{
+				Path:     "~/.claude/projects",
+				Interval: 5 * time.Second,
+			},
+			Kubernetes: KubernetesSourceConfig{
+				Selector: "agent-racer/track=true",
+				Interval: 10 * time.Second,
+			},
+			Self: SelfSourceConfig{
+				Interval:     5 * time.Second,
+				MemoryBudget: 512,
+			},
+			External: ExternalSourceConfig{
+				SessionTTL: 10 * time.Minute,
+			},
 		},
 		Models: map[string]int{
 			"claude-*-4-6*": 1000000,
@@ -329,6 +1107,7 @@ func defaultConfig() *Config {
 			MaskWorkingDirs: true,
 			MaskPIDs:        true,
 			MaskTmuxTargets: true,
+			ShowViewerNames: true,
 		},
 		Sound: SoundConfig{
 			Enabled:       true,
@@ -340,17 +1119,89 @@ func defaultConfig() *Config {
 		},
 		TokenNorm: TokenNormConfig{
 			Strategies: map[string]string{
-				"claude":  "usage",
-				"codex":   "usage",
-				"gemini":  "usage",
-				"default": "estimate",
+				"claude":     "usage",
+				"codex":      "usage",
+				"gemini":     "usage",
+				"remote":     "usage",
+				"ssh":        "usage",
+				"kubernetes": "usage",
+				"self":       "usage",
+				"external":   "usage",
+				"default":    "estimate",
 			},
 			TokensPerMessage: 2000,
+			Tokenizer:        tokenizer.Default,
+			// Claude Code compacts with about 20% of the window left.
+			CompactionThreshold: 0.8,
 		},
 		Replay: ReplayConfig{
 			Enabled:       true,
 			RetentionDays: 7,
 		},
+		Gamification: GamificationConfig{
+			BattlePass: BattlePassConfig{
+				SeasonEnd: gamification.SeasonEndRules{
+					CarryoverPercent: 10,
+					KeepTierRewards:  true,
+				},
+			},
+			AntiGrind: gamification.GrindLimits{
+				ObservedXPPerHour:  200,
+				MinSessionDuration: 10 * time.Second,
+			},
+		},
+		Links: LinksConfig{
+			IssueURLTemplate: "{repo}/issues/{number}",
+			CacheTTL:         10 * time.Minute,
+		},
+		Updates: UpdatesConfig{
+			Repo: "mrf/agent-racer",
+		},
+		Share: ShareConfig{
+			DefaultTTL: 24 * time.Hour,
+			MaxTTL:     7 * 24 * time.Hour,
+		},
+		Embed: EmbedConfig{
+			FrameAncestors: []string{"'self'"},
+		},
+		Status: StatusConfig{
+			MinDuration: 10 * time.Minute,
+		},
+		Display: DisplayConfig{
+			Language: i18n.Default,
+		},
+		Race: RaceConfig{
+			ProgressMetric: session.MetricContext,
+			Laps:           session.LapsCompaction,
+			LapTokens:      100000,
+			AutoHeatWindow: 2 * time.Minute,
+			SpeedSmoothing: 20 * time.Second,
+		},
+		Reactions: ReactionsConfig{
+			Enabled: true,
+			Emoji:   []string{"🎉", "👀", "🔥", "👏", "😬", "🚀"},
+		},
+		ChatOps: ChatOpsConfig{
+			Slack: SlackConfig{
+				SigningSecretEnv: "AGENT_RACER_SLACK_SIGNING_SECRET",
+				BotTokenEnv:      "AGENT_RACER_SLACK_BOT_TOKEN",
+			},
+		},
+		Benchmarks: BenchmarksConfig{
+			Timeout: benchmark.DefaultTimeout,
+		},
+		Watchdog: WatchdogConfig{
+			QuietAfter: 30 * time.Minute,
+		},
+		Energy: EnergyConfig{
+			JoulesPer1KTokens: map[string]float64{
+				"opus":                     1500,
+				"sonnet":                   500,
+				"haiku":                    150,
+				session.DefaultEnergyClass: 500,
+			},
+			GramsCO2PerKWh: 400,
+		},
 	}
 }
 
@@ -423,6 +1274,17 @@ func (c *Config) TokenStrategy(source string) string {
 	return "estimate"
 }
 
+func defaultCacheDir() string {
+	if value := os.Getenv("XDG_CACHE_HOME"); value != "" {
+		return value
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(homeDir, ".cache")
+}
+
 func defaultStateDir() string {
 	if value := os.Getenv("XDG_STATE_HOME"); value != "" {
 		return value
@@ -436,7 +1298,7 @@ func defaultStateDir() string {
 
 // Diff compares two configs and returns human-readable descriptions of what changed.
 // Only sections that are safe to reload at runtime are compared (models, privacy,
-// sources, token normalization, monitor timings, sound, gamification, replay, track).
+// sources, token normalization, monitor timings, sound, gamification, replay, track, links).
 func Diff(old, new *Config) []string {
 	var changes []string
 
@@ -464,6 +1326,66 @@ func Diff(old, new *Config) []string {
 	if old.Sources.Gemini != new.Sources.Gemini {
 		changes = append(changes, fmt.Sprintf("sources.gemini: %v → %v", old.Sources.Gemini, new.Sources.Gemini))
 	}
+	if old.Sources.Remote.Enabled != new.Sources.Remote.Enabled {
+		changes = append(changes, fmt.Sprintf("sources.remote.enabled: %v → %v", old.Sources.Remote.Enabled, new.Sources.Remote.Enabled))
+	}
+	if old.Sources.Remote.URL != new.Sources.Remote.URL {
+		changes = append(changes, fmt.Sprintf("sources.remote.url: %q → %q", old.Sources.Remote.URL, new.Sources.Remote.URL))
+	}
+	if old.Sources.Remote.APIKeyEnv != new.Sources.Remote.APIKeyEnv {
+		changes = append(changes, fmt.Sprintf("sources.remote.api_key_env: %q → %q", old.Sources.Remote.APIKeyEnv, new.Sources.Remote.APIKeyEnv))
+	}
+	if old.Sources.Remote.Interval != new.Sources.Remote.Interval {
+		changes = append(changes, fmt.Sprintf("sources.remote.interval: %v → %v", old.Sources.Remote.Interval, new.Sources.Remote.Interval))
+	}
+	if old.Sources.SSH.Enabled != new.Sources.SSH.Enabled {
+		changes = append(changes, fmt.Sprintf("sources.ssh.enabled: %v → %v", old.Sources.SSH.Enabled, new.Sources.SSH.Enabled))
+	}
+	if old.Sources.SSH.Host != new.Sources.SSH.Host {
+		changes = append(changes, fmt.Sprintf("sources.ssh.host: %q → %q", old.Sources.SSH.Host, new.Sources.SSH.Host))
+	}
+	if old.Sources.SSH.Path != new.Sources.SSH.Path {
+		changes = append(changes, fmt.Sprintf("sources.ssh.path: %q → %q", old.Sources.SSH.Path, new.Sources.SSH.Path))
+	}
+	if old.Sources.SSH.IdentityFile != new.Sources.SSH.IdentityFile {
+		changes = append(changes, fmt.Sprintf("sources.ssh.identity_file: %q → %q", old.Sources.SSH.IdentityFile, new.Sources.SSH.IdentityFile))
+	}
+	if old.Sources.SSH.Interval != new.Sources.SSH.Interval {
+		changes = append(changes, fmt.Sprintf("sources.ssh.interval: %v → %v", old.Sources.SSH.Interval, new.Sources.SSH.Interval))
+	}
+	if old.Sources.Kubernetes.Enabled != new.Sources.Kubernetes.Enabled {
+		changes = append(changes, fmt.Sprintf("sources.kubernetes.enabled: %v → %v", old.Sources.Kubernetes.Enabled, new.Sources.Kubernetes.Enabled))
+	}
+	if old.Sources.Kubernetes.Context != new.Sources.Kubernetes.Context {
+		changes = append(changes, fmt.Sprintf("sources.kubernetes.context: %q → %q", old.Sources.Kubernetes.Context, new.Sources.Kubernetes.Context))
+	}
+	if old.Sources.Kubernetes.Namespace != new.Sources.Kubernetes.Namespace {
+		changes = append(changes, fmt.Sprintf("sources.kubernetes.namespace: %q → %q", old.Sources.Kubernetes.Namespace, new.Sources.Kubernetes.Namespace))
+	}
+	if old.Sources.Kubernetes.Selector != new.Sources.Kubernetes.Selector {
+		changes = append(changes, fmt.Sprintf("sources.kubernetes.selector: %q → %q", old.Sources.Kubernetes.Selector, new.Sources.Kubernetes.Selector))
+	}
+	if old.Sources.Kubernetes.Container != new.Sources.Kubernetes.Container {
+		changes = append(changes, fmt.Sprintf("sources.kubernetes.container: %q → %q", old.Sources.Kubernetes.Container, new.Sources.Kubernetes.Container))
+	}
+	if old.Sources.Kubernetes.Interval != new.Sources.Kubernetes.Interval {
+		changes = append(changes, fmt.Sprintf("sources.kubernetes.interval: %v → %v", old.Sources.Kubernetes.Interval, new.Sources.Kubernetes.Interval))
+	}
+	if old.Sources.Self.Enabled != new.Sources.Self.Enabled {
+		changes = append(changes, fmt.Sprintf("sources.self.enabled: %v → %v", old.Sources.Self.Enabled, new.Sources.Self.Enabled))
+	}
+	if old.Sources.Self.Interval != new.Sources.Self.Interval {
+		changes = append(changes, fmt.Sprintf("sources.self.interval: %v → %v", old.Sources.Self.Interval, new.Sources.Self.Interval))
+	}
+	if old.Sources.Self.MemoryBudget != new.Sources.Self.MemoryBudget {
+		changes = append(changes, fmt.Sprintf("sources.self.memory_budget_mb: %d → %d", old.Sources.Self.MemoryBudget, new.Sources.Self.MemoryBudget))
+	}
+	if old.Sources.External.Enabled != new.Sources.External.Enabled {
+		changes = append(changes, fmt.Sprintf("sources.external.enabled: %v → %v", old.Sources.External.Enabled, new.Sources.External.Enabled))
+	}
+	if old.Sources.External.SessionTTL != new.Sources.External.SessionTTL {
+		changes = append(changes, fmt.Sprintf("sources.external.session_ttl: %v → %v", old.Sources.External.SessionTTL, new.Sources.External.SessionTTL))
+	}
 
 	// Privacy
 	if old.Privacy.MaskWorkingDirs != new.Privacy.MaskWorkingDirs {
@@ -484,11 +1406,23 @@ func Diff(old, new *Config) []string {
 	if !slices.Equal(old.Privacy.BlockedPaths, new.Privacy.BlockedPaths) {
 		changes = append(changes, fmt.Sprintf("privacy.blocked_paths: %v → %v", old.Privacy.BlockedPaths, new.Privacy.BlockedPaths))
 	}
+	if old.Privacy.ShowTopics != new.Privacy.ShowTopics {
+		changes = append(changes, fmt.Sprintf("privacy.show_topics: %v → %v", old.Privacy.ShowTopics, new.Privacy.ShowTopics))
+	}
+	if old.Privacy.ShowViewerNames != new.Privacy.ShowViewerNames {
+		changes = append(changes, fmt.Sprintf("privacy.show_viewer_names: %v → %v", old.Privacy.ShowViewerNames, new.Privacy.ShowViewerNames))
+	}
 
 	// Token normalization
 	if old.TokenNorm.TokensPerMessage != new.TokenNorm.TokensPerMessage {
 		changes = append(changes, fmt.Sprintf("token_normalization.tokens_per_message: %d → %d", old.TokenNorm.TokensPerMessage, new.TokenNorm.TokensPerMessage))
 	}
+	if old.TokenNorm.Tokenizer != new.TokenNorm.Tokenizer {
+		changes = append(changes, fmt.Sprintf("token_normalization.tokenizer: %q → %q", old.TokenNorm.Tokenizer, new.TokenNorm.Tokenizer))
+	}
+	if old.TokenNorm.CompactionThreshold != new.TokenNorm.CompactionThreshold {
+		changes = append(changes, fmt.Sprintf("token_normalization.compaction_threshold: %g → %g", old.TokenNorm.CompactionThreshold, new.TokenNorm.CompactionThreshold))
+	}
 	for k, v := range new.TokenNorm.Strategies {
 		if ov, ok := old.TokenNorm.Strategies[k]; !ok {
 			changes = append(changes, fmt.Sprintf("token_normalization.strategies: added %s=%s", k, v))
@@ -512,6 +1446,15 @@ func Diff(old, new *Config) []string {
 	if old.Monitor.BroadcastThrottle != new.Monitor.BroadcastThrottle {
 		changes = append(changes, fmt.Sprintf("monitor.broadcast_throttle: %s → %s", old.Monitor.BroadcastThrottle, new.Monitor.BroadcastThrottle))
 	}
+	if old.Monitor.BroadcastThrottleMax != new.Monitor.BroadcastThrottleMax {
+		changes = append(changes, fmt.Sprintf("monitor.broadcast_throttle_max: %s → %s", old.Monitor.BroadcastThrottleMax, new.Monitor.BroadcastThrottleMax))
+	}
+	if old.Monitor.BroadcastTargetRate != new.Monitor.BroadcastTargetRate {
+		changes = append(changes, fmt.Sprintf("monitor.broadcast_target_rate: %g → %g", old.Monitor.BroadcastTargetRate, new.Monitor.BroadcastTargetRate))
+	}
+	if old.Monitor.CatchUpWindow != new.Monitor.CatchUpWindow {
+		changes = append(changes, fmt.Sprintf("monitor.catch_up_window: %s → %s", old.Monitor.CatchUpWindow, new.Monitor.CatchUpWindow))
+	}
 	if old.Monitor.SessionStaleAfter != new.Monitor.SessionStaleAfter {
 		changes = append(changes, fmt.Sprintf("monitor.session_stale_after: %s → %s", old.Monitor.SessionStaleAfter, new.Monitor.SessionStaleAfter))
 	}
@@ -524,9 +1467,30 @@ func Diff(old, new *Config) []string {
 	if old.Monitor.ChurningRequiresNetwork != new.Monitor.ChurningRequiresNetwork {
 		changes = append(changes, fmt.Sprintf("monitor.churning_requires_network: %v → %v", old.Monitor.ChurningRequiresNetwork, new.Monitor.ChurningRequiresNetwork))
 	}
+	if old.Monitor.ApprovalPromptAfter != new.Monitor.ApprovalPromptAfter {
+		changes = append(changes, fmt.Sprintf("monitor.approval_prompt_after: %s → %s", old.Monitor.ApprovalPromptAfter, new.Monitor.ApprovalPromptAfter))
+	}
 	if old.Monitor.HealthWarningThreshold != new.Monitor.HealthWarningThreshold {
 		changes = append(changes, fmt.Sprintf("monitor.health_warning_threshold: %d → %d", old.Monitor.HealthWarningThreshold, new.Monitor.HealthWarningThreshold))
 	}
+	if old.Monitor.EventLogSize != new.Monitor.EventLogSize {
+		changes = append(changes, fmt.Sprintf("monitor.event_log_size: %d → %d", old.Monitor.EventLogSize, new.Monitor.EventLogSize))
+	}
+	if old.Monitor.MaxSubagents != new.Monitor.MaxSubagents {
+		changes = append(changes, fmt.Sprintf("monitor.max_subagents: %d → %d", old.Monitor.MaxSubagents, new.Monitor.MaxSubagents))
+	}
+	if old.Monitor.SubagentContextShare != new.Monitor.SubagentContextShare {
+		changes = append(changes, fmt.Sprintf("monitor.subagent_context_share: %g → %g", old.Monitor.SubagentContextShare, new.Monitor.SubagentContextShare))
+	}
+	if old.Monitor.SubagentTokenCap != new.Monitor.SubagentTokenCap {
+		changes = append(changes, fmt.Sprintf("monitor.subagent_token_cap: %d → %d", old.Monitor.SubagentTokenCap, new.Monitor.SubagentTokenCap))
+	}
+	if old.Monitor.HealthFlapThreshold != new.Monitor.HealthFlapThreshold {
+		changes = append(changes, fmt.Sprintf("monitor.health_flap_threshold: %d → %d", old.Monitor.HealthFlapThreshold, new.Monitor.HealthFlapThreshold))
+	}
+	if old.Monitor.HealthFlapWindow != new.Monitor.HealthFlapWindow {
+		changes = append(changes, fmt.Sprintf("monitor.health_flap_window: %s → %s", old.Monitor.HealthFlapWindow, new.Monitor.HealthFlapWindow))
+	}
 	if old.Monitor.StatsEventBuffer != new.Monitor.StatsEventBuffer {
 		changes = append(changes, fmt.Sprintf("monitor.stats_event_buffer: %d → %d", old.Monitor.StatsEventBuffer, new.Monitor.StatsEventBuffer))
 	}
@@ -543,6 +1507,24 @@ func Diff(old, new *Config) []string {
 	if old.Gamification.BattlePass.Season != new.Gamification.BattlePass.Season {
 		changes = append(changes, fmt.Sprintf("gamification.battle_pass.season: %s → %s", old.Gamification.BattlePass.Season, new.Gamification.BattlePass.Season))
 	}
+	if !old.Gamification.BattlePass.SeasonEnd.Equal(new.Gamification.BattlePass.SeasonEnd) {
+		changes = append(changes, "gamification.battle_pass.season_end: changed")
+	}
+	if old.Gamification.Storage.Backend != new.Gamification.Storage.Backend {
+		changes = append(changes, fmt.Sprintf("gamification.storage.backend: %q → %q", old.Gamification.Storage.Backend, new.Gamification.Storage.Backend))
+	}
+	if old.Gamification.Storage.Path != new.Gamification.Storage.Path {
+		changes = append(changes, fmt.Sprintf("gamification.storage.path: %q → %q", old.Gamification.Storage.Path, new.Gamification.Storage.Path))
+	}
+	if !slices.EqualFunc(old.Gamification.ToolAchievements, new.Gamification.ToolAchievements, gamification.ToolAchievement.Equal) {
+		changes = append(changes, "gamification.tool_achievements: changed")
+	}
+	if old.Gamification.AntiGrind.ObservedXPPerHour != new.Gamification.AntiGrind.ObservedXPPerHour {
+		changes = append(changes, fmt.Sprintf("gamification.anti_grind.observed_xp_per_hour: %d → %d", old.Gamification.AntiGrind.ObservedXPPerHour, new.Gamification.AntiGrind.ObservedXPPerHour))
+	}
+	if old.Gamification.AntiGrind.MinSessionDuration != new.Gamification.AntiGrind.MinSessionDuration {
+		changes = append(changes, fmt.Sprintf("gamification.anti_grind.min_session_duration: %s → %s", old.Gamification.AntiGrind.MinSessionDuration, new.Gamification.AntiGrind.MinSessionDuration))
+	}
 
 	// Replay
 	if old.Replay.Enabled != new.Replay.Enabled {
@@ -557,6 +1539,161 @@ func Diff(old, new *Config) []string {
 		changes = append(changes, fmt.Sprintf("track.active: %s → %s", old.Track.Active, new.Track.Active))
 	}
 
+	// Links
+	if old.Links.IssuePattern != new.Links.IssuePattern {
+		changes = append(changes, fmt.Sprintf("links.issue_pattern: %q → %q", old.Links.IssuePattern, new.Links.IssuePattern))
+	}
+	if old.Links.IssueURLTemplate != new.Links.IssueURLTemplate {
+		changes = append(changes, fmt.Sprintf("links.issue_url_template: %q → %q", old.Links.IssueURLTemplate, new.Links.IssueURLTemplate))
+	}
+	if old.Links.PRLookup != new.Links.PRLookup {
+		changes = append(changes, fmt.Sprintf("links.pr_lookup: %v → %v", old.Links.PRLookup, new.Links.PRLookup))
+	}
+	if old.Links.CacheTTL != new.Links.CacheTTL {
+		changes = append(changes, fmt.Sprintf("links.cache_ttl: %s → %s", old.Links.CacheTTL, new.Links.CacheTTL))
+	}
+
+	// Share
+	if old.Share.DefaultTTL != new.Share.DefaultTTL {
+		changes = append(changes, fmt.Sprintf("share.default_ttl: %s → %s", old.Share.DefaultTTL, new.Share.DefaultTTL))
+	}
+	if old.Share.MaxTTL != new.Share.MaxTTL {
+		changes = append(changes, fmt.Sprintf("share.max_ttl: %s → %s", old.Share.MaxTTL, new.Share.MaxTTL))
+	}
+
+	// Embed
+	if !slices.Equal(old.Embed.FrameAncestors, new.Embed.FrameAncestors) {
+		changes = append(changes, fmt.Sprintf("embed.frame_ancestors: %v → %v", old.Embed.FrameAncestors, new.Embed.FrameAncestors))
+	}
+
+	// Status
+	if old.Status.Enabled != new.Status.Enabled {
+		changes = append(changes, fmt.Sprintf("status.enabled: %v → %v", old.Status.Enabled, new.Status.Enabled))
+	}
+	if old.Status.MinDuration != new.Status.MinDuration {
+		changes = append(changes, fmt.Sprintf("status.min_duration: %s → %s", old.Status.MinDuration, new.Status.MinDuration))
+	}
+
+	// Display
+	if old.Display.TimeZone != new.Display.TimeZone {
+		changes = append(changes, fmt.Sprintf("display.time_zone: %q → %q", old.Display.TimeZone, new.Display.TimeZone))
+	}
+	if old.Display.Clock != new.Display.Clock {
+		changes = append(changes, fmt.Sprintf("display.clock: %q → %q", old.Display.Clock, new.Display.Clock))
+	}
+	if old.Display.Language != new.Display.Language {
+		changes = append(changes, fmt.Sprintf("display.language: %s → %s", old.Display.Language, new.Display.Language))
+	}
+
+	// GraphQL
+	if old.GraphQL.Enabled != new.GraphQL.Enabled {
+		changes = append(changes, fmt.Sprintf("graphql.enabled: %v → %v", old.GraphQL.Enabled, new.GraphQL.Enabled))
+	}
+
+	// Race
+	if old.Race.ProgressMetric != new.Race.ProgressMetric {
+		changes = append(changes, fmt.Sprintf("race.progress_metric: %s → %s", old.Race.ProgressMetric, new.Race.ProgressMetric))
+	}
+	if old.Race.Laps != new.Race.Laps {
+		changes = append(changes, fmt.Sprintf("race.laps: %s → %s", old.Race.Laps, new.Race.Laps))
+	}
+	if old.Race.LapTokens != new.Race.LapTokens {
+		changes = append(changes, fmt.Sprintf("race.lap_tokens: %d → %d", old.Race.LapTokens, new.Race.LapTokens))
+	}
+	if old.Race.AutoHeatWindow != new.Race.AutoHeatWindow {
+		changes = append(changes, fmt.Sprintf("race.auto_heat_window: %s → %s", old.Race.AutoHeatWindow, new.Race.AutoHeatWindow))
+	}
+	if old.Race.SpeedSmoothing != new.Race.SpeedSmoothing {
+		changes = append(changes, fmt.Sprintf("race.speed_smoothing: %s → %s", old.Race.SpeedSmoothing, new.Race.SpeedSmoothing))
+	}
+
+	// Commentary
+	if old.Commentary.Enabled != new.Commentary.Enabled {
+		changes = append(changes, fmt.Sprintf("commentary.enabled: %v → %v", old.Commentary.Enabled, new.Commentary.Enabled))
+	}
+	if !maps.Equal(old.Commentary.Templates, new.Commentary.Templates) {
+		changes = append(changes, "commentary.templates: changed")
+	}
+
+	// Reactions
+	if old.Reactions.Enabled != new.Reactions.Enabled {
+		changes = append(changes, fmt.Sprintf("reactions.enabled: %v → %v", old.Reactions.Enabled, new.Reactions.Enabled))
+	}
+	if old.Reactions.Record != new.Reactions.Record {
+		changes = append(changes, fmt.Sprintf("reactions.record: %v → %v", old.Reactions.Record, new.Reactions.Record))
+	}
+	if !slices.Equal(old.Reactions.Emoji, new.Reactions.Emoji) {
+		changes = append(changes, "reactions.emoji: changed")
+	}
+
+	// Chat ops
+	if old.ChatOps.Slack.Enabled != new.ChatOps.Slack.Enabled {
+		changes = append(changes, fmt.Sprintf("chatops.slack.enabled: %v → %v", old.ChatOps.Slack.Enabled, new.ChatOps.Slack.Enabled))
+	}
+	if old.ChatOps.Slack.SigningSecretEnv != new.ChatOps.Slack.SigningSecretEnv {
+		changes = append(changes, fmt.Sprintf("chatops.slack.signing_secret_env: %q → %q", old.ChatOps.Slack.SigningSecretEnv, new.ChatOps.Slack.SigningSecretEnv))
+	}
+	if old.ChatOps.Slack.BotTokenEnv != new.ChatOps.Slack.BotTokenEnv {
+		changes = append(changes, fmt.Sprintf("chatops.slack.bot_token_env: %q → %q", old.ChatOps.Slack.BotTokenEnv, new.ChatOps.Slack.BotTokenEnv))
+	}
+
+	// Benchmarks
+	if old.Benchmarks.Schedule != new.Benchmarks.Schedule {
+		changes = append(changes, fmt.Sprintf("benchmarks.schedule: %s → %s", old.Benchmarks.Schedule, new.Benchmarks.Schedule))
+	}
+	if old.Benchmarks.Timeout != new.Benchmarks.Timeout {
+		changes = append(changes, fmt.Sprintf("benchmarks.timeout: %s → %s", old.Benchmarks.Timeout, new.Benchmarks.Timeout))
+	}
+	if !slices.EqualFunc(old.Benchmarks.Tasks, new.Benchmarks.Tasks, benchmark.Task.Equal) {
+		changes = append(changes, "benchmarks.tasks: changed")
+	}
+
+	// Launch
+	if !slices.EqualFunc(old.Launch.Templates, new.Launch.Templates, launch.Template.Equal) {
+		changes = append(changes, "launch.templates: changed")
+	}
+	if !slices.EqualFunc(old.Launch.Pipelines, new.Launch.Pipelines, launch.Pipeline.Equal) {
+		changes = append(changes, "launch.pipelines: changed")
+	}
+
+	// Watchdog
+	if old.Watchdog.Enabled != new.Watchdog.Enabled {
+		changes = append(changes, fmt.Sprintf("watchdog.enabled: %v → %v", old.Watchdog.Enabled, new.Watchdog.Enabled))
+	}
+	if old.Watchdog.QuietAfter != new.Watchdog.QuietAfter {
+		changes = append(changes, fmt.Sprintf("watchdog.quiet_after: %s → %s", old.Watchdog.QuietAfter, new.Watchdog.QuietAfter))
+	}
+	if old.Watchdog.WorkHours != new.Watchdog.WorkHours {
+		changes = append(changes, fmt.Sprintf("watchdog.work_hours: %q → %q", old.Watchdog.WorkHours, new.Watchdog.WorkHours))
+	}
+	if !slices.Equal(old.Watchdog.WorkDays, new.Watchdog.WorkDays) {
+		changes = append(changes, fmt.Sprintf("watchdog.work_days: %v → %v", old.Watchdog.WorkDays, new.Watchdog.WorkDays))
+	}
+
+	// Energy
+	if old.Energy.Enabled != new.Energy.Enabled {
+		changes = append(changes, fmt.Sprintf("energy.enabled: %v → %v", old.Energy.Enabled, new.Energy.Enabled))
+	}
+	if !maps.Equal(old.Energy.JoulesPer1KTokens, new.Energy.JoulesPer1KTokens) {
+		changes = append(changes, "energy.joules_per_1k_tokens: changed")
+	}
+	if old.Energy.GramsCO2PerKWh != new.Energy.GramsCO2PerKWh {
+		changes = append(changes, fmt.Sprintf("energy.grams_co2_per_kwh: %v → %v", old.Energy.GramsCO2PerKWh, new.Energy.GramsCO2PerKWh))
+	}
+
+	// Debug
+	if old.Debug.StoreHistory != new.Debug.StoreHistory {
+		changes = append(changes, fmt.Sprintf("debug.store_history: %s → %s", old.Debug.StoreHistory, new.Debug.StoreHistory))
+	}
+
+	// Updates
+	if old.Updates.Check != new.Updates.Check {
+		changes = append(changes, fmt.Sprintf("updates.check: %v → %v", old.Updates.Check, new.Updates.Check))
+	}
+	if old.Updates.Repo != new.Updates.Repo {
+		changes = append(changes, fmt.Sprintf("updates.repo: %s → %s", old.Updates.Repo, new.Updates.Repo))
+	}
+
 	return changes
 }
 
@@ -581,6 +1718,47 @@ func DefaultReplayDir() string {
 	return filepath.Join(defaultStateDir(), "agent-racer", "replays")
 }
 
+// DefaultCrashDir returns the XDG-compliant path for crash reports.
+func DefaultCrashDir() string {
+	return filepath.Join(defaultStateDir(), "agent-racer", "crashes")
+}
+
+// DefaultShareDir returns the XDG-compliant path for shared session
+// snapshots and the key that signs their links.
+func DefaultShareDir() string {
+	return filepath.Join(defaultStateDir(), "agent-racer", "shares")
+}
+
+// DefaultNamesPath returns the XDG-compliant path of the file holding
+// session and directory display names set through the API.
+func DefaultNamesPath() string {
+	return filepath.Join(defaultStateDir(), "agent-racer", "names.json")
+}
+
+// DefaultBenchmarksDir returns the XDG-compliant path for the benchmark
+// results table and agent logs.
+func DefaultBenchmarksDir() string {
+	return filepath.Join(defaultStateDir(), "agent-racer", "benchmarks")
+}
+
+// DefaultSSHMirrorDir returns the XDG-compliant path where transcripts read
+// over SSH are mirrored. The copies can be rebuilt, so they are cache.
+func DefaultSSHMirrorDir() string {
+	return filepath.Join(defaultCacheDir(), "agent-racer", "ssh")
+}
+
+// DefaultKubernetesMirrorDir returns the XDG-compliant path where pod logs
+// are copied so they can be parsed like local transcripts.
+func DefaultKubernetesMirrorDir() string {
+	return filepath.Join(defaultCacheDir(), "agent-racer", "kubernetes")
+}
+
+// DefaultUpdateStatePath returns the XDG-compliant path where the result of
+// the last release check is kept between restarts.
+func DefaultUpdateStatePath() string {
+	return filepath.Join(defaultStateDir(), "agent-racer", "update-check.json")
+}
+
 // NormalizeAuthToken trims surrounding whitespace from a configured auth token.
 func NormalizeAuthToken(token string) string {
 	return strings.TrimSpace(token)
@@ -3,8 +3,15 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/agent-racer/backend/internal/benchmark"
+	"github.com/agent-racer/backend/internal/gamification"
+	"github.com/agent-racer/backend/internal/launch"
 )
 
 func TestTokenStrategy(t *testing.T) {
@@ -470,6 +477,16 @@ func TestDefaultConfigIncludesCodexFallbacks(t *testing.T) {
 	}
 }
 
+func TestDefaultConfigDoesNotCheckForUpdates(t *testing.T) {
+	cfg := defaultConfig()
+	if cfg.Updates.Check {
+		t.Error("Updates.Check = true by default, want opt-in")
+	}
+	if cfg.Updates.Repo != "mrf/agent-racer" {
+		t.Errorf("Updates.Repo = %q, want mrf/agent-racer", cfg.Updates.Repo)
+	}
+}
+
 func TestNormalizeAuthToken(t *testing.T) {
 	if got := NormalizeAuthToken("  abc123  "); got != "abc123" {
 		t.Errorf("NormalizeAuthToken() = %q, want %q", got, "abc123")
@@ -516,13 +533,79 @@ func TestDiffDetectsChanges(t *testing.T) {
 
 	// Sources
 	new.Sources.Codex = true
+	new.Sources.Remote.URL = "https://racer.example.com/api/sessions"
+	new.Sources.SSH.Host = "ci@build-01"
+	new.Sources.Kubernetes.Namespace = "agents"
+	new.Sources.Self.Enabled = true
+	new.Sources.External.Enabled = true
 
 	// Privacy
 	new.Privacy.MaskWorkingDirs = false
 	new.Privacy.BlockedPaths = []string{"/tmp/secret"}
+	new.Privacy.ShowTopics = true
+	new.Privacy.ShowViewerNames = false
+
+	// Monitor
+	new.Monitor.EventLogSize = 4096
+	new.Monitor.MaxSubagents = 50
+	new.Monitor.SubagentContextShare = 0.3
+	new.Monitor.SubagentTokenCap = 80000
+	new.Monitor.ApprovalPromptAfter = 30 * time.Second
+	new.Monitor.HealthFlapThreshold = 6
 
 	// Token norm
 	new.TokenNorm.TokensPerMessage = 3000
+	new.TokenNorm.Tokenizer = "bytes"
+	new.TokenNorm.CompactionThreshold = 0.9
+
+	// Updates
+	new.Updates.Check = true
+
+	// Share
+	new.Share.DefaultTTL = time.Hour
+
+	// Embed
+	new.Embed.FrameAncestors = []string{"https://grafana.example.com"}
+
+	// Status
+	new.Status.Enabled = true
+	new.Display.TimeZone = "Europe/Berlin"
+	new.Display.Language = "de"
+
+	// GraphQL
+	new.GraphQL.Enabled = true
+
+	// Race
+	new.Race.ProgressMetric = "tokens"
+	new.Race.Laps = "tokens"
+	new.Race.AutoHeatWindow = 0
+	new.Race.SpeedSmoothing = 0
+
+	// Commentary
+	new.Commentary.Templates = map[string]string{"compaction": "{name} dives into the pits"}
+	// Reactions
+	new.Reactions.Record = true
+	new.Reactions.Emoji = []string{"🏁"}
+	// Chat ops
+	new.ChatOps.Slack.Enabled = true
+	new.ChatOps.Slack.SigningSecretEnv = "SLACK_SECRET"
+
+	// Benchmarks
+	new.Benchmarks.Schedule = 24 * time.Hour
+	new.Benchmarks.Tasks = []benchmark.Task{{Name: "fix", Agents: []benchmark.Agent{{Label: "opus", Command: []string{"claude", "-p", "fix it"}}}}}
+	// Launch
+	new.Launch.Templates = []launch.Template{{Name: "claude", Command: []string{"claude"}}}
+	new.Launch.Pipelines = []launch.Pipeline{{Name: "relay", Stages: []string{"claude", "claude"}}}
+	// Watchdog
+	new.Watchdog.Enabled = true
+	new.Watchdog.WorkHours = "09:00-18:00"
+	new.Watchdog.WorkDays = []string{"mon", "fri"}
+	// Energy
+	new.Energy.Enabled = true
+	new.Energy.JoulesPer1KTokens = map[string]float64{"default": 300}
+	new.Energy.GramsCO2PerKWh = 50
+	// Debug
+	new.Debug.StoreHistory = 15 * time.Minute
 
 	changes := Diff(old, new)
 	if len(changes) == 0 {
@@ -539,9 +622,51 @@ func TestDiffDetectsChanges(t *testing.T) {
 		"models: added claude-opus-4-5=300000",
 		"models: removed default",
 		"sources.codex: false → true",
+		`sources.remote.url: "" → "https://racer.example.com/api/sessions"`,
+		`sources.ssh.host: "" → "ci@build-01"`,
+		`sources.kubernetes.namespace: "" → "agents"`,
+		"sources.self.enabled: false → true",
+		"sources.external.enabled: false → true",
 		"privacy.mask_working_dirs: true → false",
 		"privacy.blocked_paths: [] → [/tmp/secret]",
+		"privacy.show_topics: false → true",
+		"privacy.show_viewer_names: true → false",
+		"monitor.event_log_size: 0 → 4096",
+		"monitor.max_subagents: 200 → 50",
+		"monitor.subagent_context_share: 0.5 → 0.3",
+		"monitor.subagent_token_cap: 0 → 80000",
+		"monitor.approval_prompt_after: 0s → 30s",
+		"monitor.health_flap_threshold: 4 → 6",
 		"token_normalization.tokens_per_message: 2000 → 3000",
+		`token_normalization.tokenizer: "cl100k" → "bytes"`,
+		"token_normalization.compaction_threshold: 0.8 → 0.9",
+		"updates.check: false → true",
+		"share.default_ttl: 24h0m0s → 1h0m0s",
+		"embed.frame_ancestors: ['self'] → [https://grafana.example.com]",
+		"status.enabled: false → true",
+		`display.time_zone: "" → "Europe/Berlin"`,
+		"display.language: en → de",
+		"graphql.enabled: false → true",
+		"race.progress_metric: context → tokens",
+		"race.laps: compaction → tokens",
+		"race.auto_heat_window: 2m0s → 0s",
+		"race.speed_smoothing: 20s → 0s",
+		"commentary.templates: changed",
+		"reactions.record: false → true",
+		"reactions.emoji: changed",
+		"chatops.slack.enabled: false → true",
+		`chatops.slack.signing_secret_env: "AGENT_RACER_SLACK_SIGNING_SECRET" → "SLACK_SECRET"`,
+		"benchmarks.schedule: 0s → 24h0m0s",
+		"benchmarks.tasks: changed",
+		"launch.templates: changed",
+		"launch.pipelines: changed",
+		"watchdog.enabled: false → true",
+		`watchdog.work_hours: "" → "09:00-18:00"`,
+		"watchdog.work_days: [] → [mon fri]",
+		"energy.enabled: false → true",
+		"energy.joules_per_1k_tokens: changed",
+		"energy.grams_co2_per_kwh: 400 → 50",
+		"debug.store_history: 0s → 15m0s",
 	}
 	for _, w := range want {
 		if !found[w] {
@@ -557,8 +682,14 @@ func TestDefaultConfigTokenNorm(t *testing.T) {
 		t.Errorf("TokensPerMessage = %d, want 2000", cfg.TokenNorm.TokensPerMessage)
 	}
 
-	if len(cfg.TokenNorm.Strategies) != 4 {
-		t.Errorf("len(Strategies) = %d, want 4", len(cfg.TokenNorm.Strategies))
+	if len(cfg.TokenNorm.Strategies) != 9 {
+		t.Errorf("len(Strategies) = %d, want 9", len(cfg.TokenNorm.Strategies))
+	}
+	if got := cfg.TokenStrategy("remote"); got != "usage" {
+		t.Errorf("TokenStrategy(remote) = %q, want usage", got)
+	}
+	if got := cfg.TokenStrategy("kubernetes"); got != "usage" {
+		t.Errorf("TokenStrategy(kubernetes) = %q, want usage", got)
 	}
 }
 
@@ -606,6 +737,24 @@ func TestDiffDetectsBroadcastThrottleChange(t *testing.T) {
 	}
 }
 
+func TestDiffDetectsAutoThrottleChanges(t *testing.T) {
+	old := defaultConfig()
+	new := defaultConfig()
+	new.Monitor.BroadcastThrottleMax = 2 * time.Second
+	new.Monitor.BroadcastTargetRate = 2.5
+
+	changes := Diff(old, new)
+	want := []string{
+		"monitor.broadcast_throttle_max: 1s → 2s",
+		"monitor.broadcast_target_rate: 5 → 2.5",
+	}
+	for _, w := range want {
+		if !slices.Contains(changes, w) {
+			t.Errorf("Expected %q in changes: %v", w, changes)
+		}
+	}
+}
+
 func TestDiffDetectsSnapshotIntervalChange(t *testing.T) {
 	old := defaultConfig()
 	new := defaultConfig()
@@ -660,13 +809,14 @@ func TestDiffDetectsMultipleMonitorChanges(t *testing.T) {
 	new.Monitor.SnapshotInterval = 10 * 1000000000
 	new.Monitor.StatsEventBuffer = 512
 	new.Monitor.SessionStaleAfter = 3 * 60 * 1000000000
+	new.Monitor.CatchUpWindow = 0
 
 	changes := Diff(old, new)
 	if len(changes) == 0 {
 		t.Fatal("Diff should detect multiple changes")
 	}
 
-	expectedCount := 5
+	expectedCount := 6
 	if len(changes) < expectedCount {
 		t.Errorf("Expected at least %d changes, got %d: %v", expectedCount, len(changes), changes)
 	}
@@ -677,6 +827,7 @@ func TestDiffDetectsMultipleMonitorChanges(t *testing.T) {
 		"monitor.broadcast_throttle: 100ms → 150ms",
 		"monitor.session_stale_after: 2m0s → 3m0s",
 		"monitor.stats_event_buffer: 256 → 512",
+		"monitor.catch_up_window: 10m0s → 0s",
 	}
 
 	found := map[string]bool{}
@@ -696,6 +847,11 @@ func TestDiffDetectsGamificationChanges(t *testing.T) {
 	new := defaultConfig()
 	new.Gamification.BattlePass.Enabled = true
 	new.Gamification.BattlePass.Season = "2026-03"
+	new.Gamification.BattlePass.SeasonEnd.CarryoverPercent = 25
+	new.Gamification.Storage.Backend = "sqlite"
+	new.Gamification.ToolAchievements = []gamification.ToolAchievement{{ID: "shell", Name: "Shell", Tier: gamification.TierGold, Tool: "Bash", MinCalls: 10}}
+	new.Gamification.AntiGrind.ObservedXPPerHour = 0
+	new.Gamification.AntiGrind.MinSessionDuration = time.Minute
 
 	changes := Diff(old, new)
 	if len(changes) == 0 {
@@ -710,6 +866,11 @@ func TestDiffDetectsGamificationChanges(t *testing.T) {
 	want := []string{
 		"gamification.battle_pass.enabled: false → true",
 		"gamification.battle_pass.season:  → 2026-03",
+		"gamification.battle_pass.season_end: changed",
+		"gamification.storage.backend: \"\" → \"sqlite\"",
+		"gamification.tool_achievements: changed",
+		"gamification.anti_grind.observed_xp_per_hour: 200 → 0",
+		"gamification.anti_grind.min_session_duration: 10s → 1m0s",
 	}
 	for _, w := range want {
 		if !found[w] {
@@ -789,21 +950,149 @@ func TestValidateRejectsInvalidValues(t *testing.T) {
 		{"poll_interval zero", func(c *Config) { c.Monitor.PollInterval = 0 }, "poll_interval"},
 		{"snapshot_interval zero", func(c *Config) { c.Monitor.SnapshotInterval = 0 }, "snapshot_interval"},
 		{"broadcast_throttle zero", func(c *Config) { c.Monitor.BroadcastThrottle = 0 }, "broadcast_throttle"},
+		{"broadcast_throttle_max below throttle", func(c *Config) { c.Monitor.BroadcastThrottleMax = 50 * time.Millisecond }, "broadcast_throttle_max"},
+		{"broadcast_target_rate negative", func(c *Config) { c.Monitor.BroadcastTargetRate = -1 }, "broadcast_target_rate"},
+		{"catch_up_window negative", func(c *Config) { c.Monitor.CatchUpWindow = -time.Second }, "catch_up_window"},
 		{"session_stale_after negative", func(c *Config) { c.Monitor.SessionStaleAfter = -1 }, "session_stale_after"},
 		{"stats_event_buffer zero", func(c *Config) { c.Monitor.StatsEventBuffer = 0 }, "stats_event_buffer"},
 		{"churning_cpu_threshold negative", func(c *Config) { c.Monitor.ChurningCPUThreshold = -1 }, "churning_cpu_threshold"},
 		{"health_warning_threshold negative", func(c *Config) { c.Monitor.HealthWarningThreshold = -1 }, "health_warning_threshold"},
+		{"event_log_size negative", func(c *Config) { c.Monitor.EventLogSize = -1 }, "event_log_size"},
+		{"max_subagents negative", func(c *Config) { c.Monitor.MaxSubagents = -1 }, "max_subagents"},
+		{"subagent_context_share above 1", func(c *Config) { c.Monitor.SubagentContextShare = 1.5 }, "subagent_context_share"},
+		{"subagent_token_cap negative", func(c *Config) { c.Monitor.SubagentTokenCap = -1 }, "subagent_token_cap"},
+		{"approval_prompt_after negative", func(c *Config) { c.Monitor.ApprovalPromptAfter = -time.Second }, "approval_prompt_after"},
+		{"health_flap_threshold negative", func(c *Config) { c.Monitor.HealthFlapThreshold = -1 }, "health_flap_threshold"},
+		{"health_flap_window zero", func(c *Config) { c.Monitor.HealthFlapWindow = 0 }, "health_flap_window"},
 
 		// Token normalization
 		{"tokens_per_message zero", func(c *Config) { c.TokenNorm.TokensPerMessage = 0 }, "tokens_per_message"},
+		{"unknown tokenizer", func(c *Config) { c.TokenNorm.Tokenizer = "tiktoken" }, "token_normalization.tokenizer"},
+		{"compaction threshold above one", func(c *Config) { c.TokenNorm.CompactionThreshold = 1.5 }, "token_normalization.compaction_threshold"},
 
 		// Sound volumes
 		{"master_volume negative", func(c *Config) { c.Sound.MasterVolume = -0.5 }, "master_volume"},
 		{"ambient_volume negative", func(c *Config) { c.Sound.AmbientVolume = -1 }, "ambient_volume"},
 		{"sfx_volume negative", func(c *Config) { c.Sound.SfxVolume = -0.1 }, "sfx_volume"},
 
+		// Gamification
+		{"unknown storage backend", func(c *Config) { c.Gamification.Storage.Backend = "postgres" }, "gamification.storage.backend"},
+		{"carryover over 100%", func(c *Config) { c.Gamification.BattlePass.SeasonEnd.CarryoverPercent = 150 }, "gamification.battle_pass.season_end.carryover_percent"},
+		{"unknown season end reward", func(c *Config) {
+			c.Gamification.BattlePass.SeasonEnd.Rewards = map[int][]string{5: {"chrome_wheels"}}
+		}, "gamification.battle_pass.season_end.rewards"},
+		{"tool achievement without tool", func(c *Config) {
+			c.Gamification.ToolAchievements = []gamification.ToolAchievement{{ID: "shell", Name: "Shell", Tier: gamification.TierGold, MinCalls: 10}}
+		}, "gamification.tool_achievements"},
+		{"negative hourly XP cap", func(c *Config) { c.Gamification.AntiGrind.ObservedXPPerHour = -1 }, "gamification.anti_grind.observed_xp_per_hour"},
+		{"negative min session duration", func(c *Config) { c.Gamification.AntiGrind.MinSessionDuration = -time.Second }, "gamification.anti_grind.min_session_duration"},
+
+		// Sources
+		{"remote without url", func(c *Config) { c.Sources.Remote.Enabled = true }, "sources.remote.url"},
+		{"remote interval too short", func(c *Config) {
+			c.Sources.Remote = RemoteSourceConfig{Enabled: true, URL: "https://racer.example.com/api/sessions", Interval: 100 * time.Millisecond}
+		}, "sources.remote.interval"},
+		{"ssh without host", func(c *Config) { c.Sources.SSH.Enabled = true }, "sources.ssh.host"},
+		{"ssh host looks like an option", func(c *Config) {
+			c.Sources.SSH.Enabled = true
+			c.Sources.SSH.Host = "-oProxyCommand=sh"
+		}, "sources.ssh.host"},
+		{"ssh without path", func(c *Config) {
+			c.Sources.SSH = SSHSourceConfig{Enabled: true, Host: "build-01", Interval: time.Second}
+		}, "sources.ssh.path"},
+		{"kubernetes without selector", func(c *Config) {
+			c.Sources.Kubernetes = KubernetesSourceConfig{Enabled: true, Interval: time.Second}
+		}, "sources.kubernetes.selector"},
+		{"kubernetes interval too short", func(c *Config) {
+			c.Sources.Kubernetes.Enabled = true
+			c.Sources.Kubernetes.Interval = 0
+		}, "sources.kubernetes.interval"},
+		{"self interval too short", func(c *Config) {
+			c.Sources.Self.Enabled = true
+			c.Sources.Self.Interval = 0
+		}, "sources.self.interval"},
+		{"self without memory budget", func(c *Config) {
+			c.Sources.Self.Enabled = true
+			c.Sources.Self.MemoryBudget = 0
+		}, "sources.self.memory_budget_mb"},
+		{"external ttl too short", func(c *Config) {
+			c.Sources.External.Enabled = true
+			c.Sources.External.SessionTTL = 0
+		}, "sources.external.session_ttl"},
+
 		// Replay
 		{"retention_days negative", func(c *Config) { c.Replay.RetentionDays = -1 }, "retention_days"},
+
+		// Debug
+		{"store_history negative", func(c *Config) { c.Debug.StoreHistory = -time.Minute }, "debug.store_history"},
+
+		// Links
+		{"issue_pattern invalid", func(c *Config) { c.Links.IssuePattern = "issue-(" }, "links.issue_pattern"},
+		{"issue_pattern without group", func(c *Config) { c.Links.IssuePattern = `issue-\d+` }, "capture group"},
+		{"issue_url_template without number", func(c *Config) {
+			c.Links.IssuePattern = suggestedIssuePattern
+			c.Links.IssueURLTemplate = "{repo}/issues"
+		}, "links.issue_url_template"},
+		{"cache_ttl zero", func(c *Config) { c.Links.CacheTTL = 0 }, "links.cache_ttl"},
+
+		// Share
+		{"share default_ttl zero", func(c *Config) { c.Share.DefaultTTL = 0 }, "share.default_ttl"},
+		{"share max_ttl below default", func(c *Config) { c.Share.MaxTTL = time.Hour }, "share.max_ttl"},
+
+		// Embed
+		{"frame_ancestors empty", func(c *Config) { c.Embed.FrameAncestors = nil }, "embed.frame_ancestors"},
+		{"frame_ancestors with separator", func(c *Config) { c.Embed.FrameAncestors = []string{"https://a.example; script-src *"} }, "embed.frame_ancestors"},
+
+		// Status
+		{"status min_duration negative", func(c *Config) { c.Status.MinDuration = -time.Minute }, "status.min_duration"},
+		{"display unknown time_zone", func(c *Config) { c.Display.TimeZone = "Mars/Olympus" }, "display.time_zone"},
+		{"display bad clock", func(c *Config) { c.Display.Clock = "24" }, "display.clock"},
+		{"display unsupported language", func(c *Config) { c.Display.Language = "tlh" }, "display.language"},
+
+		// Race
+		{"unknown progress metric", func(c *Config) { c.Race.ProgressMetric = "speed" }, "race.progress_metric"},
+		{"unknown lap mode", func(c *Config) { c.Race.Laps = "milestones" }, "race.laps"},
+		{"token laps without size", func(c *Config) { c.Race.Laps = "tokens"; c.Race.LapTokens = 0 }, "race.lap_tokens"},
+		{"negative auto heat window", func(c *Config) { c.Race.AutoHeatWindow = -time.Second }, "race.auto_heat_window"},
+		{"negative speed smoothing", func(c *Config) { c.Race.SpeedSmoothing = -time.Second }, "race.speed_smoothing"},
+
+		// Commentary
+		{"commentary unknown event", func(c *Config) { c.Commentary.Templates = map[string]string{"pitstop": "{name}"} }, "commentary.templates"},
+		{"commentary unknown placeholder", func(c *Config) { c.Commentary.Templates = map[string]string{"finish": "{driver} wins"} }, "commentary.templates"},
+		{"reaction emoji empty", func(c *Config) { c.Reactions.Emoji = []string{""} }, "reactions.emoji[0]"},
+		{"reaction emoji too long", func(c *Config) { c.Reactions.Emoji = []string{"🎉", "great work team"} }, "reactions.emoji[1]"},
+		{"reaction emoji duplicate", func(c *Config) { c.Reactions.Emoji = []string{"🎉", "🎉"} }, "reactions.emoji[1]"},
+		{"slack without signing secret", func(c *Config) { c.ChatOps.Slack.Enabled = true; c.ChatOps.Slack.SigningSecretEnv = "" }, "chatops.slack.signing_secret_env"},
+		{"slack signing secret unset", func(c *Config) {
+			c.ChatOps.Slack.Enabled = true
+			c.ChatOps.Slack.SigningSecretEnv = "AGENT_RACER_TEST_UNSET_SECRET"
+		}, "chatops.slack.signing_secret_env"},
+
+		// Watchdog
+		{"watchdog quiet too short", func(c *Config) { c.Watchdog.Enabled = true; c.Watchdog.QuietAfter = time.Second }, "watchdog.quiet_after"},
+		{"watchdog bad hours", func(c *Config) { c.Watchdog.WorkHours = "9 to 5" }, "watchdog.work_hours"},
+		{"watchdog unknown day", func(c *Config) { c.Watchdog.WorkDays = []string{"mon", "funday"} }, "watchdog.work_days[1]"},
+		{"energy negative rate", func(c *Config) { c.Energy.JoulesPer1KTokens["opus"] = -1 }, `energy.joules_per_1k_tokens["opus"]`},
+		{"energy negative intensity", func(c *Config) { c.Energy.GramsCO2PerKWh = -1 }, "energy.grams_co2_per_kwh"},
+
+		// Benchmarks
+		{"benchmark schedule too short", func(c *Config) { c.Benchmarks.Schedule = time.Minute }, "benchmarks.schedule"},
+		{"benchmark timeout zero", func(c *Config) { c.Benchmarks.Timeout = 0 }, "benchmarks.timeout"},
+		{"benchmark task without agents", func(c *Config) { c.Benchmarks.Tasks = []benchmark.Task{{Name: "fix"}} }, "benchmarks.tasks"},
+
+		// Launch
+		{"launch template without command", func(c *Config) { c.Launch.Templates = []launch.Template{{Name: "claude"}} }, "launch.templates"},
+		{"launch template relative cwd", func(c *Config) {
+			c.Launch.Templates = []launch.Template{{Name: "claude", Command: []string{"claude"}, Cwd: "src/app"}}
+		}, "launch.templates"},
+		{"pipeline with unknown stage", func(c *Config) {
+			c.Launch.Templates = []launch.Template{{Name: "claude", Command: []string{"claude"}}}
+			c.Launch.Pipelines = []launch.Pipeline{{Name: "relay", Stages: []string{"claude", "review"}}}
+		}, "launch.pipelines"},
+
+		// Updates
+		{"repo without owner", func(c *Config) { c.Updates.Check = true; c.Updates.Repo = "agent-racer" }, "updates.repo"},
+		{"repo with extra path", func(c *Config) { c.Updates.Check = true; c.Updates.Repo = "mrf/agent-racer/releases" }, "updates.repo"},
 	}
 
 	for _, tt := range tests {
@@ -829,6 +1118,20 @@ func TestValidateAllowsZeroSessionStaleAfter(t *testing.T) {
 	}
 }
 
+func TestSlackSettingsReadSecretsFromEnvironment(t *testing.T) {
+	t.Setenv("AGENT_RACER_SLACK_SIGNING_SECRET", "s3cret")
+	t.Setenv("AGENT_RACER_SLACK_BOT_TOKEN", "xoxb-1")
+	cfg := defaultConfig()
+	cfg.ChatOps.Slack.Enabled = true
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("slack with signing secret in the environment should be valid: %v", err)
+	}
+	got := cfg.ChatOps.Slack.Settings()
+	if got.SigningSecret != "s3cret" || got.BotToken != "xoxb-1" {
+		t.Errorf("Settings() = %+v, want secrets from the environment", got)
+	}
+}
+
 func TestValidateCollectsMultipleErrors(t *testing.T) {
 	cfg := defaultConfig()
 	cfg.Monitor.PollInterval = 0
@@ -904,3 +1207,26 @@ func TestServerConfigScheme(t *testing.T) {
 		t.Errorf("Scheme() = %q, want %q", s, "https")
 	}
 }
+
+func TestSuggestedIssuePatternMatchesCommonBranches(t *testing.T) {
+	if p := defaultConfig().Links.IssuePattern; p != "" {
+		t.Errorf("default issue_pattern = %q, want issue links off", p)
+	}
+	re := regexp.MustCompile(suggestedIssuePattern)
+	tests := map[string]string{
+		"issue-123":           "123",
+		"feature/issue_45-ui": "45",
+		"GH-7-fix":            "7",
+		"fix-login":           "",
+		"main":                "",
+	}
+	for branch, want := range tests {
+		got := ""
+		if m := re.FindStringSubmatch(branch); m != nil {
+			got = m[1]
+		}
+		if got != want {
+			t.Errorf("branch %q: issue = %q, want %q", branch, got, want)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/agent-racer/backend/internal/session"
+	"gopkg.in/yaml.v3"
+)
+
+// RepoFileName is the file a repository overrides the server's settings
+// for its sessions in. The nearest one at or above a session's working
+// directory applies, up to the repository root.
+const RepoFileName = ".agent-racer.yaml"
+
+// Notification kinds a repository can mute, as listed in
+// SessionState.Muted.
+const (
+	NotifyCompletion = "completion"
+	NotifyMilestone  = "milestone"
+	NotifyBudget     = "budget"
+)
+
+var notifyKinds = []string{NotifyCompletion, NotifyMilestone, NotifyBudget}
+
+// RepoOverrides are the per-repository settings read from RepoFileName.
+// Unset fields leave the global config in charge.
+type RepoOverrides struct {
+	// Name replaces the name derived from the working directory.
+	Name string `yaml:"name"`
+
+	// Tags label the repository's sessions for filtering and grouping.
+	Tags []string `yaml:"tags"`
+
+	// Privacy is "masked" to mask the working directory even when
+	// privacy.mask_working_dirs is off, or "hidden" to keep the
+	// repository's sessions off the track entirely.
+	Privacy session.PrivacyLevel `yaml:"privacy"`
+
+	// TokenBudget is how many tokens a session may burn, across
+	// compactions, before it is flagged over budget. 0 means no limit.
+	TokenBudget int `yaml:"token_budget"`
+
+	// Notifications turns kinds of client notifications off for the
+	// repository's sessions. Every kind is on unless set to false.
+	Notifications map[string]bool `yaml:"notifications"`
+}
+
+// LoadRepoOverrides reads the RepoFileName that applies to dir. It returns
+// nil without error when there is none.
+func LoadRepoOverrides(dir string) (*RepoOverrides, error) {
+	path := findRepoFile(dir)
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var o RepoOverrides
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&o); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := o.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &o, nil
+}
+
+// findRepoFile returns the nearest RepoFileName at or above dir, stopping
+// at the directory holding .git, or "" if there is none.
+func findRepoFile(dir string) string {
+	if dir == "" {
+		return ""
+	}
+	dir = filepath.Clean(dir)
+	for {
+		path := filepath.Join(dir, RepoFileName)
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+			return path
+		}
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return ""
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// Validate reports overrides that make no sense.
+func (o *RepoOverrides) Validate() error {
+	var errs []string
+	switch o.Privacy {
+	case session.PrivacyDefault, session.PrivacyMasked, session.PrivacyHidden:
+	default:
+		errs = append(errs, fmt.Sprintf("privacy: must be %q or %q, got %q", session.PrivacyMasked, session.PrivacyHidden, o.Privacy))
+	}
+	if o.TokenBudget < 0 {
+		errs = append(errs, fmt.Sprintf("token_budget: must not be negative, got %d", o.TokenBudget))
+	}
+	for kind := range o.Notifications {
+		if !slices.Contains(notifyKinds, kind) {
+			errs = append(errs, fmt.Sprintf("notifications: unknown kind %q, want one of %s", kind, strings.Join(notifyKinds, ", ")))
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	slices.Sort(errs)
+	return errors.New(strings.Join(errs, "; "))
+}
+
+// Apply merges the overrides into s. Fields left unset keep what s has.
+func (o *RepoOverrides) Apply(s *session.SessionState) {
+	if o.Name != "" {
+		s.Name = o.Name
+	}
+	if len(o.Tags) > 0 {
+		s.Tags = append([]string(nil), o.Tags...)
+	}
+	if o.Privacy != session.PrivacyDefault {
+		s.Privacy = o.Privacy
+	}
+	if o.TokenBudget > 0 {
+		s.TokenBudget = o.TokenBudget
+	}
+	for i := 0; i < len(notifyKinds); i++ {
+		if on, ok := o.Notifications[notifyKinds[i]]; ok && !on {
+			s.Muted = append(s.Muted, notifyKinds[i])
+		}
+	}
+}
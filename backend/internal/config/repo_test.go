@@ -0,0 +1,100 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/agent-racer/backend/internal/session"
+)
+
+func TestLoadRepoOverrides(t *testing.T) {
+	repo := t.TempDir()
+	if err := os.Mkdir(filepath.Join(repo, ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	sub := filepath.Join(repo, "services", "api")
+	if err := os.MkdirAll(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if o, err := LoadRepoOverrides(sub); o != nil || err != nil {
+		t.Fatalf("without a file: %+v, %v", o, err)
+	}
+
+	writeRepoFile(t, repo, `
+name: payments
+tags: [backend, billing]
+privacy: masked
+token_budget: 400000
+notifications:
+  milestone: false
+  completion: true
+`)
+	o, err := LoadRepoOverrides(sub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &session.SessionState{Name: "api", WorkingDir: sub}
+	o.Apply(s)
+	if s.Name != "payments" || strings.Join(s.Tags, ",") != "backend,billing" ||
+		s.Privacy != session.PrivacyMasked || s.TokenBudget != 400_000 {
+		t.Errorf("applied %+v", s)
+	}
+	if strings.Join(s.Muted, ",") != NotifyMilestone {
+		t.Errorf("Muted = %v, want [%s]", s.Muted, NotifyMilestone)
+	}
+
+	// The nearest file wins.
+	writeRepoFile(t, sub, "tags: [api]\n")
+	o, err = LoadRepoOverrides(sub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o.Name != "" || len(o.Tags) != 1 || o.Tags[0] != "api" {
+		t.Errorf("nearest file = %+v", o)
+	}
+}
+
+func TestLoadRepoOverridesStopsAtRepoRoot(t *testing.T) {
+	outer := t.TempDir()
+	writeRepoFile(t, outer, "name: outer\n")
+	repo := filepath.Join(outer, "repo")
+	if err := os.MkdirAll(filepath.Join(repo, ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if o, err := LoadRepoOverrides(repo); o != nil || err != nil {
+		t.Errorf("picked up a file outside the repository: %+v, %v", o, err)
+	}
+}
+
+func TestLoadRepoOverridesRejectsInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want string
+	}{
+		{"privacy", "privacy: secret\n", "privacy"},
+		{"budget", "token_budget: -1\n", "token_budget"},
+		{"notification kind", "notifications:\n  overtake: false\n", "overtake"},
+		{"unknown field", "colour: red\n", "colour"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeRepoFile(t, dir, tt.yaml)
+			_, err := LoadRepoOverrides(dir)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want one mentioning %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func writeRepoFile(t *testing.T, dir, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, RepoFileName), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,137 @@
+// Package crash records recovered panics as crash-report files so they
+// survive the process and can be attached to bug reports with
+// `agent-racer-server debug bundle`.
+package crash
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxStackSize bounds the stack trace captured for a report.
+const maxStackSize = 64 << 10
+
+// DefaultKeep is how many crash reports are retained; older ones are
+// pruned each time a new report is written.
+const DefaultKeep = 20
+
+// filePrefix and fileSuffix identify crash reports within the directory.
+const (
+	filePrefix = "crash-"
+	fileSuffix = ".txt"
+)
+
+var unsafeComponent = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// Reporter writes crash reports to a directory. A nil Reporter is valid
+// and discards reports. It is safe for concurrent use.
+type Reporter struct {
+	dir     string
+	version string
+	keep    int
+	now     func() time.Time
+	mu      sync.Mutex
+}
+
+// NewReporter creates a Reporter that writes to dir and stamps each
+// report with version. The directory is created on first write.
+func NewReporter(dir, version string) *Reporter {
+	return &Reporter{dir: dir, version: version, keep: DefaultKeep, now: time.Now}
+}
+
+// Dir returns the directory reports are written to.
+func (r *Reporter) Dir() string {
+	if r == nil {
+		return ""
+	}
+	return r.dir
+}
+
+// Stack returns the calling goroutine's stack trace, up to maxStackSize.
+// Call it from the deferred function that recovered the panic so the
+// panicking frames are included.
+func Stack() []byte {
+	buf := make([]byte, maxStackSize)
+	return buf[:runtime.Stack(buf, false)]
+}
+
+// Record writes a report for a panic recovered in component and returns
+// the file path.
+func (r *Reporter) Record(component string, recovered any, stack []byte) (string, error) {
+	if r == nil {
+		return "", nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := os.MkdirAll(r.dir, 0o700); err != nil {
+		return "", fmt.Errorf("create crash dir: %w", err)
+	}
+
+	now := r.now().UTC()
+	safe := strings.Trim(unsafeComponent.ReplaceAllString(component, "_"), "_")
+	if safe == "" {
+		safe = "unknown"
+	}
+	name := filePrefix + now.Format("20060102T150405.000000000Z") + "-" + safe + fileSuffix
+	path := filepath.Join(r.dir, name)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "time: %s\n", now.Format(time.RFC3339Nano))
+	fmt.Fprintf(&b, "version: %s\n", r.version)
+	fmt.Fprintf(&b, "go: %s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	fmt.Fprintf(&b, "component: %s\n", component)
+	fmt.Fprintf(&b, "panic: %v\n\n", recovered)
+	b.Write(stack)
+
+	if err := os.WriteFile(path, []byte(b.String()), 0o600); err != nil {
+		return "", fmt.Errorf("write crash report: %w", err)
+	}
+	r.pruneLocked()
+	return path, nil
+}
+
+// pruneLocked removes all but the newest r.keep reports.
+func (r *Reporter) pruneLocked() {
+	reports, err := List(r.dir)
+	if err != nil || len(reports) <= r.keep {
+		return
+	}
+	for i := r.keep; i < len(reports); i++ {
+		_ = os.Remove(reports[i])
+	}
+}
+
+// List returns the crash report paths in dir, newest first. A missing
+// directory yields no reports and no error.
+func List(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.Type().IsRegular() && strings.HasPrefix(name, filePrefix) && strings.HasSuffix(name, fileSuffix) {
+			names = append(names, name)
+		}
+	}
+	// Names start with a fixed-width UTC timestamp, so lexical order is
+	// chronological.
+	sort.Sort(sort.Reverse(sort.StringSlice(names)))
+	paths := make([]string, len(names))
+	for i := 0; i < len(names); i++ {
+		paths[i] = filepath.Join(dir, names[i])
+	}
+	return paths, nil
+}
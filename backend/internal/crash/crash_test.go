@@ -0,0 +1,83 @@
+package crash
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecordWritesReport(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "crashes")
+	r := NewReporter(dir, "v1.2.3")
+
+	var path string
+	func() {
+		defer func() {
+			if rec := recover(); rec != nil {
+				var err error
+				path, err = r.Record("poll/claude", rec, Stack())
+				if err != nil {
+					t.Fatalf("Record: %v", err)
+				}
+			}
+		}()
+		panic("boom")
+	}()
+
+	if filepath.Dir(path) != dir || !strings.HasSuffix(path, "-poll_claude.txt") {
+		t.Fatalf("path = %q, want crash-*-poll_claude.txt in %s", path, dir)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := string(data)
+	for _, want := range []string{"version: v1.2.3", "component: poll/claude", "panic: boom", "TestRecordWritesReport"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("report missing %q:\n%s", want, report)
+		}
+	}
+}
+
+func TestRecordPrunesOldReports(t *testing.T) {
+	dir := t.TempDir()
+	r := NewReporter(dir, "dev")
+	r.keep = 3
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	r.now = func() time.Time { return now }
+
+	for i := 0; i < 5; i++ {
+		now = now.Add(time.Second)
+		if _, err := r.Record("test", i, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reports, err := List(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reports) != 3 {
+		t.Fatalf("kept %d reports, want 3", len(reports))
+	}
+	newest, _ := os.ReadFile(reports[0])
+	if !strings.Contains(string(newest), "panic: 4") {
+		t.Errorf("newest report = %q, want panic 4", newest)
+	}
+}
+
+func TestNilReporter(t *testing.T) {
+	var r *Reporter
+	if path, err := r.Record("x", "y", nil); path != "" || err != nil {
+		t.Fatalf("nil Record = (%q, %v), want no-op", path, err)
+	}
+}
+
+func TestListMissingDir(t *testing.T) {
+	reports, err := List(filepath.Join(t.TempDir(), "nope"))
+	if err != nil || len(reports) != 0 {
+		t.Fatalf("List = (%v, %v), want empty", reports, err)
+	}
+}
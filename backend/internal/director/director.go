@@ -0,0 +1,320 @@
+// Package director picks which session a wall dashboard should focus on,
+// cycling between the interesting ones: the fastest burners, sessions that
+// just errored and sessions about to reach their context limit.
+package director
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/agent-racer/backend/internal/session"
+)
+
+// Reason explains why a session was picked.
+type Reason string
+
+const (
+	ReasonErrored   Reason = "errored"
+	ReasonFinishing Reason = "finishing"
+	ReasonBurnRate  Reason = "burn_rate"
+	// ReasonRotation is used when no session is interesting by any rule;
+	// the director then simply cycles through the running sessions.
+	ReasonRotation Reason = "rotation"
+)
+
+// Rules lists the selectable reasons in priority order.
+var Rules = []Reason{ReasonErrored, ReasonFinishing, ReasonBurnRate}
+
+const (
+	// DefaultDwell is how long each focus lasts by default.
+	DefaultDwell = 20 * time.Second
+	// MinDwell keeps the wall from flickering between sessions.
+	MinDwell = 5 * time.Second
+	// MaxDwell bounds a single focus.
+	MaxDwell = 10 * time.Minute
+
+	// DefaultFinishingThreshold is the context utilization above which a
+	// session counts as about to finish.
+	DefaultFinishingThreshold = 0.85
+
+	// erroredWindow is how long after failing a session stays interesting.
+	erroredWindow = 2 * time.Minute
+	// burnRateTop is how many of the fastest burners are candidates.
+	burnRateTop = 3
+	// recentMemory is how many recent picks the director avoids repeating.
+	recentMemory = 5
+)
+
+// Settings are the runtime-adjustable director options.
+type Settings struct {
+	Enabled            bool     `json:"enabled"`
+	DwellSeconds       int      `json:"dwellSeconds"`
+	Rules              []Reason `json:"rules"`
+	FinishingThreshold float64  `json:"finishingThreshold"`
+}
+
+// DefaultSettings returns the settings a new director starts with. The
+// director is off until enabled through the API.
+func DefaultSettings() Settings {
+	return Settings{
+		DwellSeconds:       int(DefaultDwell / time.Second),
+		Rules:              append([]Reason(nil), Rules...),
+		FinishingThreshold: DefaultFinishingThreshold,
+	}
+}
+
+// Validate reports the first problem with s.
+func (s Settings) Validate() error {
+	dwell := time.Duration(s.DwellSeconds) * time.Second
+	if dwell < MinDwell || dwell > MaxDwell {
+		return fmt.Errorf("dwellSeconds must be between %d and %d", int(MinDwell/time.Second), int(MaxDwell/time.Second))
+	}
+	for _, r := range s.Rules {
+		known := false
+		for _, k := range Rules {
+			if r == k {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("unknown rule %q", r)
+		}
+	}
+	if s.FinishingThreshold <= 0 || s.FinishingThreshold > 1 {
+		return fmt.Errorf("finishingThreshold must be in (0, 1], got %g", s.FinishingThreshold)
+	}
+	return nil
+}
+
+func (s Settings) dwell() time.Duration {
+	return time.Duration(s.DwellSeconds) * time.Second
+}
+
+func (s Settings) has(r Reason) bool {
+	for _, x := range s.Rules {
+		if x == r {
+			return true
+		}
+	}
+	return false
+}
+
+// Focus is one director decision.
+type Focus struct {
+	SessionID string    `json:"sessionId"`
+	Name      string    `json:"name"`
+	Reason    Reason    `json:"reason"`
+	Until     time.Time `json:"until"`
+}
+
+// Status is what GET /api/director reports.
+type Status struct {
+	Settings
+	Current *Focus `json:"current,omitempty"`
+}
+
+// Director periodically picks a focus while enabled and hands it to the
+// OnFocus callback.
+type Director struct {
+	sessions func() []*session.SessionState
+	now      func() time.Time
+
+	mu       sync.Mutex
+	settings Settings
+	current  *Focus
+	recent   []string
+	onFocus  func(Focus)
+	wake     chan struct{}
+}
+
+// New returns a director choosing among sessions, which should already be
+// privacy-filtered since focus hints go to every client.
+func New(sessions func() []*session.SessionState) *Director {
+	return &Director{
+		sessions: sessions,
+		now:      time.Now,
+		settings: DefaultSettings(),
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// OnFocus registers fn to receive every new focus. Must be called before Run.
+func (d *Director) OnFocus(fn func(Focus)) {
+	d.onFocus = fn
+}
+
+// Status returns the current settings and focus.
+func (d *Director) Status() Status {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	st := Status{Settings: d.settings}
+	st.Rules = append([]Reason(nil), d.settings.Rules...)
+	if d.current != nil {
+		f := *d.current
+		st.Current = &f
+	}
+	return st
+}
+
+// Configure replaces the settings and picks a new focus right away.
+func (d *Director) Configure(s Settings) error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+	d.mu.Lock()
+	d.settings = s
+	d.settings.Rules = append([]Reason(nil), s.Rules...)
+	if !s.Enabled {
+		d.current = nil
+		d.recent = nil
+	}
+	d.mu.Unlock()
+
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Run picks a focus every dwell period while enabled, until ctx is done.
+func (d *Director) Run(ctx context.Context) {
+	timer := time.NewTimer(d.Status().dwell())
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-d.wake:
+		case <-timer.C:
+		}
+		if f, ok := d.Next(); ok && d.onFocus != nil {
+			d.onFocus(f)
+		}
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(d.Status().dwell())
+	}
+}
+
+// Next picks the next focus. It returns false while the director is
+// disabled or there is nothing to look at.
+func (d *Director) Next() (Focus, bool) {
+	states := d.sessions()
+	now := d.now()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.settings.Enabled {
+		return Focus{}, false
+	}
+
+	cands := candidates(states, d.settings, now)
+	if len(cands) == 0 {
+		d.current = nil
+		return Focus{}, false
+	}
+
+	pick := cands[0]
+	for _, c := range cands {
+		if !d.recentlyShown(c.state.ID) {
+			pick = c
+			break
+		}
+	}
+	// Once everything has had a turn, start over from the top.
+	if d.recentlyShown(pick.state.ID) {
+		d.recent = nil
+	}
+	d.recent = append(d.recent, pick.state.ID)
+	if keep := min(recentMemory, len(cands)-1); len(d.recent) > keep {
+		d.recent = d.recent[len(d.recent)-keep:]
+	}
+
+	f := Focus{
+		SessionID: pick.state.ID,
+		Name:      pick.state.Name,
+		Reason:    pick.reason,
+		Until:     now.Add(d.settings.dwell()),
+	}
+	d.current = &f
+	return f, true
+}
+
+func (d *Director) recentlyShown(id string) bool {
+	for _, r := range d.recent {
+		if r == id {
+			return true
+		}
+	}
+	return false
+}
+
+type candidate struct {
+	state  *session.SessionState
+	reason Reason
+}
+
+// candidates ranks the sessions worth focusing on, most interesting first.
+// Each session appears once, under its highest-priority reason.
+func candidates(states []*session.SessionState, s Settings, now time.Time) []candidate {
+	var out []candidate
+	seen := make(map[string]bool)
+	add := func(list []*session.SessionState, r Reason) {
+		for _, st := range list {
+			if !seen[st.ID] {
+				seen[st.ID] = true
+				out = append(out, candidate{state: st, reason: r})
+			}
+		}
+	}
+
+	var errored, finishing, burning, running []*session.SessionState
+	for _, st := range states {
+		switch {
+		case st.Activity == session.Errored:
+			if st.CompletedAt != nil && now.Sub(*st.CompletedAt) <= erroredWindow {
+				errored = append(errored, st)
+			}
+		case st.IsTerminal():
+		default:
+			running = append(running, st)
+			if st.ContextUtilization >= s.FinishingThreshold {
+				finishing = append(finishing, st)
+			}
+			if st.BurnRatePerMinute > 0 {
+				burning = append(burning, st)
+			}
+		}
+	}
+
+	sort.SliceStable(errored, func(i, j int) bool { return errored[i].CompletedAt.After(*errored[j].CompletedAt) })
+	sort.SliceStable(finishing, func(i, j int) bool { return finishing[i].ContextUtilization > finishing[j].ContextUtilization })
+	sort.SliceStable(burning, func(i, j int) bool { return burning[i].BurnRatePerMinute > burning[j].BurnRatePerMinute })
+	if len(burning) > burnRateTop {
+		burning = burning[:burnRateTop]
+	}
+
+	if s.has(ReasonErrored) {
+		add(errored, ReasonErrored)
+	}
+	if s.has(ReasonFinishing) {
+		add(finishing, ReasonFinishing)
+	}
+	if s.has(ReasonBurnRate) {
+		add(burning, ReasonBurnRate)
+	}
+	if len(out) == 0 {
+		sort.SliceStable(running, func(i, j int) bool { return running[i].ID < running[j].ID })
+		add(running, ReasonRotation)
+	}
+	return out
+}
@@ -0,0 +1,137 @@
+package director
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/agent-racer/backend/internal/session"
+)
+
+var testNow = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func newTestDirector(states []*session.SessionState) *Director {
+	d := New(func() []*session.SessionState { return states })
+	d.now = func() time.Time { return testNow }
+	return d
+}
+
+func enable(t *testing.T, d *Director) {
+	t.Helper()
+	s := DefaultSettings()
+	s.Enabled = true
+	if err := d.Configure(s); err != nil {
+		t.Fatalf("Configure: %v", err)
+	}
+}
+
+func TestNext_DisabledByDefault(t *testing.T) {
+	d := newTestDirector([]*session.SessionState{{ID: "a", Activity: session.Thinking}})
+	if _, ok := d.Next(); ok {
+		t.Error("Next picked a focus while disabled")
+	}
+}
+
+func TestNext_PrioritizesAndCycles(t *testing.T) {
+	failedAt := testNow.Add(-30 * time.Second)
+	oldFail := testNow.Add(-10 * time.Minute)
+	states := []*session.SessionState{
+		{ID: "burner", Activity: session.ToolUse, BurnRatePerMinute: 9000},
+		{ID: "slow", Activity: session.Thinking, BurnRatePerMinute: 10},
+		{ID: "finishing", Activity: session.Thinking, ContextUtilization: 0.95, BurnRatePerMinute: 50},
+		{ID: "failed", Activity: session.Errored, CompletedAt: &failedAt},
+		{ID: "failed-long-ago", Activity: session.Errored, CompletedAt: &oldFail},
+		{ID: "done", Activity: session.Complete},
+	}
+	d := newTestDirector(states)
+	enable(t, d)
+
+	want := []struct {
+		id     string
+		reason Reason
+	}{
+		{"failed", ReasonErrored},
+		{"finishing", ReasonFinishing},
+		{"burner", ReasonBurnRate},
+		{"slow", ReasonBurnRate},
+		{"failed", ReasonErrored},
+	}
+	for i, w := range want {
+		f, ok := d.Next()
+		if !ok {
+			t.Fatalf("pick %d: no focus", i)
+		}
+		if f.SessionID != w.id || f.Reason != w.reason {
+			t.Errorf("pick %d = %s (%s), want %s (%s)", i, f.SessionID, f.Reason, w.id, w.reason)
+		}
+		if !f.Until.Equal(testNow.Add(DefaultDwell)) {
+			t.Errorf("pick %d until = %v", i, f.Until)
+		}
+	}
+	if cur := d.Status().Current; cur == nil || cur.SessionID != "failed" {
+		t.Errorf("Status().Current = %+v", cur)
+	}
+}
+
+func TestNext_RotatesWhenNothingStandsOut(t *testing.T) {
+	d := newTestDirector([]*session.SessionState{
+		{ID: "b", Activity: session.Idle},
+		{ID: "a", Activity: session.Waiting},
+		{ID: "x", Activity: session.Complete},
+	})
+	enable(t, d)
+
+	var got []string
+	for i := 0; i < 3; i++ {
+		f, _ := d.Next()
+		if f.Reason != ReasonRotation {
+			t.Errorf("reason = %s, want rotation", f.Reason)
+		}
+		got = append(got, f.SessionID)
+	}
+	if got[0] != "a" || got[1] != "b" || got[2] != "a" {
+		t.Errorf("rotation = %v, want [a b a]", got)
+	}
+}
+
+func TestSettingsValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Settings)
+	}{
+		{"dwell too short", func(s *Settings) { s.DwellSeconds = 1 }},
+		{"dwell too long", func(s *Settings) { s.DwellSeconds = 3600 }},
+		{"unknown rule", func(s *Settings) { s.Rules = []Reason{"vibes"} }},
+		{"threshold zero", func(s *Settings) { s.FinishingThreshold = 0 }},
+	}
+	if err := DefaultSettings().Validate(); err != nil {
+		t.Fatalf("default settings invalid: %v", err)
+	}
+	for _, tt := range tests {
+		s := DefaultSettings()
+		tt.modify(&s)
+		if err := s.Validate(); err == nil {
+			t.Errorf("%s: expected error", tt.name)
+		}
+	}
+}
+
+func TestRun_FocusesImmediatelyWhenEnabled(t *testing.T) {
+	d := newTestDirector([]*session.SessionState{{ID: "a", Activity: session.Thinking}})
+	got := make(chan Focus, 1)
+	d.OnFocus(func(f Focus) { got <- f })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go d.Run(ctx)
+	enable(t, d)
+
+	select {
+	case f := <-got:
+		if f.SessionID != "a" {
+			t.Errorf("focus = %+v", f)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no focus after enabling")
+	}
+}
@@ -0,0 +1,94 @@
+package frontend
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+)
+
+// immutablePrefix is where Vite writes content-hashed bundles. Their names
+// change whenever their content does, so browsers may cache them forever.
+const immutablePrefix = "assets/"
+
+// assetHandler serves a static file tree with strong, content-hash ETags.
+// Everything outside immutablePrefix is served with "no-cache" so browsers
+// revalidate index.html on every load and pick up new bundles after an
+// upgrade instead of running stale JS.
+type assetHandler struct {
+	files http.Handler
+	etags map[string]string
+	hash  string
+}
+
+// newAssetHandler hashes every file in fsys and returns a handler serving it.
+func newAssetHandler(fsys fs.FS) (*assetHandler, error) {
+	etags := make(map[string]string)
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if d.IsDir() || name == ".build-manifest" {
+			return nil
+		}
+		f, err := fsys.Open(name)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = f.Close() }()
+		h := sha256.New()
+		if _, err := io.Copy(h, f); err != nil {
+			return fmt.Errorf("cannot hash %q: %w", name, err)
+		}
+		etags[name] = hex.EncodeToString(h.Sum(nil))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(etags))
+	for name := range etags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	total := sha256.New()
+	for _, name := range names {
+		_, _ = fmt.Fprintf(total, "%s  %s\n", etags[name], name)
+	}
+
+	return &assetHandler{
+		files: http.FileServer(http.FS(fsys)),
+		etags: etags,
+		hash:  hex.EncodeToString(total.Sum(nil))[:12],
+	}, nil
+}
+
+// ServeHTTP sets caching headers and delegates to http.FileServer, which
+// answers If-None-Match with 304 once the ETag header is present.
+func (h *assetHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+	if name == "" || strings.HasSuffix(r.URL.Path, "/") {
+		name = path.Join(name, "index.html")
+	}
+	if name == ".build-manifest" {
+		http.NotFound(w, r)
+		return
+	}
+	// http.FileServer redirects explicit /index.html requests to the
+	// directory, so only the redirect target gets validators.
+	if sum, ok := h.etags[name]; ok && !strings.HasSuffix(r.URL.Path, "/index.html") {
+		w.Header().Set("ETag", `"`+sum[:16]+`"`)
+		if strings.HasPrefix(name, immutablePrefix) {
+			w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+		} else {
+			w.Header().Set("Cache-Control", "no-cache")
+		}
+	}
+	h.files.ServeHTTP(w, r)
+}
@@ -0,0 +1,97 @@
+package frontend
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+)
+
+func testAssets(t *testing.T, indexHTML string) *assetHandler {
+	t.Helper()
+	h, err := newAssetHandler(fstest.MapFS{
+		"index.html":          &fstest.MapFile{Data: []byte(indexHTML)},
+		"assets/index-ab.js":  &fstest.MapFile{Data: []byte("console.log('hi')")},
+		".build-manifest":     &fstest.MapFile{Data: []byte("manifest")},
+		"favicon.svg":         &fstest.MapFile{Data: []byte("<svg/>")},
+		"assets/index-cd.css": &fstest.MapFile{Data: []byte("body{}")},
+	})
+	if err != nil {
+		t.Fatalf("newAssetHandler: %v", err)
+	}
+	return h
+}
+
+func get(h http.Handler, path, etag string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAssetHandler_ETagAndRevalidation(t *testing.T) {
+	h := testAssets(t, "<html>v1</html>")
+
+	rec := get(h, "/", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET / = %d, want 200", rec.Code)
+	}
+	etag := rec.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("expected ETag on index.html")
+	}
+	if cc := rec.Header().Get("Cache-Control"); cc != "no-cache" {
+		t.Errorf("index Cache-Control = %q, want no-cache", cc)
+	}
+
+	if rec := get(h, "/", etag); rec.Code != http.StatusNotModified {
+		t.Errorf("conditional GET / = %d, want 304", rec.Code)
+	}
+	if rec := get(h, "/", `"stale"`); rec.Code != http.StatusOK {
+		t.Errorf("GET / with stale ETag = %d, want 200", rec.Code)
+	}
+}
+
+func TestAssetHandler_HashedBundlesAreImmutable(t *testing.T) {
+	h := testAssets(t, "<html>v1</html>")
+
+	rec := get(h, "/assets/index-ab.js", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET bundle = %d, want 200", rec.Code)
+	}
+	if cc := rec.Header().Get("Cache-Control"); cc != "public, max-age=31536000, immutable" {
+		t.Errorf("bundle Cache-Control = %q", cc)
+	}
+	if rec.Header().Get("ETag") == "" {
+		t.Error("expected ETag on bundle")
+	}
+}
+
+func TestAssetHandler_HidesManifest(t *testing.T) {
+	h := testAssets(t, "<html>v1</html>")
+	if rec := get(h, "/.build-manifest", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("GET manifest = %d, want 404", rec.Code)
+	}
+}
+
+func TestAssetHandler_HashTracksContent(t *testing.T) {
+	a := testAssets(t, "<html>v1</html>")
+	b := testAssets(t, "<html>v1</html>")
+	c := testAssets(t, "<html>v2</html>")
+
+	if a.hash == "" {
+		t.Fatal("expected non-empty build hash")
+	}
+	if a.hash != b.hash {
+		t.Errorf("identical trees hashed differently: %s vs %s", a.hash, b.hash)
+	}
+	if a.hash == c.hash {
+		t.Error("changed index.html did not change the build hash")
+	}
+	if get(a, "/", "").Header().Get("ETag") == get(c, "/", "").Header().Get("ETag") {
+		t.Error("changed index.html kept the same ETag")
+	}
+}
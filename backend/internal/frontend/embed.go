@@ -6,15 +6,31 @@ import (
 	"embed"
 	"io/fs"
 	"net/http"
+	"sync"
 )
 
 //go:embed static/*
 var staticFiles embed.FS
 
-func Handler() http.Handler {
+var embedded = sync.OnceValue(func() *assetHandler {
 	sub, err := fs.Sub(staticFiles, "static")
 	if err != nil {
 		panic(err)
 	}
-	return http.FileServer(http.FS(sub))
+	h, err := newAssetHandler(sub)
+	if err != nil {
+		panic(err)
+	}
+	return h
+})
+
+// Handler serves the embedded frontend with content-hash ETags.
+func Handler() http.Handler {
+	return embedded()
+}
+
+// Hash identifies the embedded frontend build. It changes whenever any
+// embedded file does, letting clients detect a backend/frontend version skew.
+func Hash() string {
+	return embedded().hash
 }
@@ -7,3 +7,8 @@ import "net/http"
 func Handler() http.Handler {
 	return nil
 }
+
+// Hash returns "" when the frontend is not embedded.
+func Hash() string {
+	return ""
+}
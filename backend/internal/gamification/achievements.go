@@ -25,6 +25,8 @@ const (
 	CategoryPerformanceEndurance Category = "Performance & Endurance"
 	CategorySpectacle            Category = "Spectacle"
 	CategoryStreaks              Category = "Streaks"
+	CategoryRacing               Category = "Racing"
+	CategoryToolUsage            Category = "Tool Usage" // configured ToolAchievements
 )
 
 // Achievement describes a single unlockable goal.
@@ -44,9 +46,14 @@ type AchievementEngine struct {
 	registry []Achievement
 }
 
-// NewAchievementEngine creates an engine pre-loaded with the full achievement set.
-func NewAchievementEngine() *AchievementEngine {
-	return &AchievementEngine{registry: buildRegistry()}
+// NewAchievementEngine creates an engine pre-loaded with the full achievement
+// set, followed by the configured tool achievements.
+func NewAchievementEngine(tools ...ToolAchievement) *AchievementEngine {
+	registry := buildRegistry()
+	for i := 0; i < len(tools); i++ {
+		registry = append(registry, tools[i].achievement())
+	}
+	return &AchievementEngine{registry: registry}
 }
 
 // Registry returns a shallow copy of all registered achievements.
@@ -233,6 +240,18 @@ func buildRegistry() []Achievement {
 			Tier:        TierSilver, Category: CategoryPerformanceEndurance,
 			Condition: func(s *Stats) bool { return s.MaxSessionDurationSec >= 7200 },
 		},
+		{
+			ID: "ultramarathon", Name: "Ultramarathon",
+			Description: "A single session keeps running for 8 hours",
+			Tier:        TierGold, Category: CategoryPerformanceEndurance,
+			Condition: func(s *Stats) bool { return s.MilestonesReached["duration_8h"] >= 1 },
+		},
+		{
+			ID: "token_millionaire", Name: "Token Millionaire",
+			Description: "A single session burns 1,000,000 tokens",
+			Tier:        TierGold, Category: CategoryPerformanceEndurance,
+			Condition: func(s *Stats) bool { return s.MilestonesReached["tokens_1m"] >= 1 },
+		},
 		{
 			ID: "tool_fiend", Name: "Tool Fiend",
 			Description: "A single session makes 500 or more tool calls",
@@ -295,6 +314,24 @@ func buildRegistry() []Achievement {
 				return s.MaxHighUtilizationSimultaneous >= 3
 			},
 		},
+		{
+			ID: "delegator", Name: "Delegator",
+			Description: "Start 10 subagents",
+			Tier:        TierBronze, Category: CategorySpectacle,
+			Condition: func(s *Stats) bool { return s.TotalSubagents >= 10 },
+		},
+		{
+			ID: "swarm", Name: "Swarm",
+			Description: "Start 100 subagents",
+			Tier:        TierSilver, Category: CategorySpectacle,
+			Condition: func(s *Stats) bool { return s.TotalSubagents >= 100 },
+		},
+		{
+			ID: "hive_mind", Name: "Hive Mind",
+			Description: "Have 500 subagents return their results",
+			Tier:        TierGold, Category: CategorySpectacle,
+			Condition: func(s *Stats) bool { return s.SubagentsCompleted >= 500 },
+		},
 
 		// ── Streaks ────────────────────────────────────────────────────────
 
@@ -317,5 +354,50 @@ func buildRegistry() []Achievement {
 			Tier:        TierGold, Category: CategoryStreaks,
 			Condition: func(s *Stats) bool { return s.ConsecutiveCompletions >= 25 },
 		},
+		{
+			ID: "daily_driver", Name: "Daily Driver",
+			Description: "Complete a daily quest 3 days in a row",
+			Tier:        TierBronze, Category: CategoryStreaks,
+			Condition: func(s *Stats) bool { return s.DailyQuests.LongestStreak >= 3 },
+		},
+		{
+			ID: "creature_of_habit", Name: "Creature of Habit",
+			Description: "Complete a daily quest 7 days in a row",
+			Tier:        TierSilver, Category: CategoryStreaks,
+			Condition: func(s *Stats) bool { return s.DailyQuests.LongestStreak >= 7 },
+		},
+		{
+			ID: "iron_will", Name: "Iron Will",
+			Description: "Complete a daily quest 30 days in a row",
+			Tier:        TierGold, Category: CategoryStreaks,
+			Condition: func(s *Stats) bool { return s.DailyQuests.LongestStreak >= 30 },
+		},
+
+		// ── Racing ─────────────────────────────────────────────────────────
+
+		{
+			ID: "three_way_win", Name: "Three-Way Winner",
+			Description: "Win a race between 3 or more sessions",
+			Tier:        TierBronze, Category: CategoryRacing,
+			Condition: func(s *Stats) bool { return s.LargestFieldWon >= 3 },
+		},
+		{
+			ID: "pack_leader", Name: "Pack Leader",
+			Description: "Win a race between 5 or more sessions",
+			Tier:        TierSilver, Category: CategoryRacing,
+			Condition: func(s *Stats) bool { return s.LargestFieldWon >= 5 },
+		},
+		{
+			ID: "serial_winner", Name: "Serial Winner",
+			Description: "Win 10 races",
+			Tier:        TierGold, Category: CategoryRacing,
+			Condition: func(s *Stats) bool { return s.RacesWon >= 10 },
+		},
+		{
+			ID: "champion", Name: "Champion",
+			Description: "Win 50 races",
+			Tier:        TierPlatinum, Category: CategoryRacing,
+			Condition: func(s *Stats) bool { return s.RacesWon >= 50 },
+		},
 	}
 }
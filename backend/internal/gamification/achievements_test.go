@@ -31,6 +31,7 @@ func TestRegistry_AllCategoriesCovered(t *testing.T) {
 		CategoryPerformanceEndurance: false,
 		CategorySpectacle:            false,
 		CategoryStreaks:              false,
+		CategoryRacing:               false,
 	}
 	for _, a := range NewAchievementEngine().Registry() {
 		all[a.Category] = true
@@ -379,6 +380,23 @@ func TestPerformance_Marathon(t *testing.T) {
 	}
 }
 
+func TestPerformance_Milestones(t *testing.T) {
+	e := NewAchievementEngine()
+	s := newStats()
+	s.MilestonesReached["duration_4h"] = 3
+	s.MilestonesReached["tokens_500k"] = 2
+	if u := e.Evaluate(s); hasID(u, "ultramarathon") || hasID(u, "token_millionaire") {
+		t.Error("milestone achievements unlocked below 8h and 1M tokens")
+	}
+
+	s.MilestonesReached["duration_8h"] = 1
+	s.MilestonesReached["tokens_1m"] = 1
+	u := e.Evaluate(s)
+	if !hasID(u, "ultramarathon") || !hasID(u, "token_millionaire") {
+		t.Errorf("unlocked %v, want ultramarathon and token_millionaire", u)
+	}
+}
+
 func TestPerformance_ToolFiend(t *testing.T) {
 	e := NewAchievementEngine()
 
@@ -0,0 +1,46 @@
+package gamification
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// Storage backends for OpenBackend.
+const (
+	BackendJSON   = "json"
+	BackendSQLite = "sqlite"
+)
+
+// Backend loads and saves Stats. Store, a JSON file, is the default;
+// SQLiteStore keeps them in a database, and MemoryStore only until exit.
+// Session history is not part of it: the replay recordings stay on disk
+// as JSONL whichever backend keeps the stats.
+type Backend interface {
+	// Load returns the saved stats, or empty stats with initialized maps
+	// when nothing has been saved yet.
+	Load() (*Stats, error)
+	// Save replaces the saved stats with st. It must be safe to call
+	// from more than one goroutine.
+	Save(st *Stats) error
+	// Path returns where the stats are kept, for messages.
+	Path() string
+	// Close releases the backend; it must not be used afterwards.
+	Close() error
+}
+
+// OpenBackend opens the backend of the given kind at path. An empty kind
+// means BackendJSON, and an empty path the default location in the XDG
+// state directory: a directory for JSON, a database file for SQLite.
+func OpenBackend(kind, path string) (Backend, error) {
+	switch kind {
+	case "", BackendJSON:
+		return NewStore(path), nil
+	case BackendSQLite:
+		if path == "" {
+			path = filepath.Join(defaultStatsDir(), sqliteFileName)
+		}
+		return OpenSQLite(path)
+	default:
+		return nil, fmt.Errorf("unknown stats backend %q", kind)
+	}
+}
@@ -12,6 +12,9 @@ const (
 	XPNewModel         = 50
 	XPNewSource        = 100
 	XPWeeklyChallenge  = 150
+	XPDailyQuest       = 40
+	XPHeatRaced        = 40
+	XPMilestone        = 20
 )
 
 // AchievementXP returns the XP award for unlocking an achievement of the given tier.
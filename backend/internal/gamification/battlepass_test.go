@@ -19,6 +19,7 @@ func TestAwardXP_EachAwardType(t *testing.T) {
 		{"XPNewModel", XPNewModel},
 		{"XPNewSource", XPNewSource},
 		{"XPWeeklyChallenge", XPWeeklyChallenge},
+		{"XPMilestone", XPMilestone},
 	}
 	for _, tc := range awards {
 		t.Run(tc.name, func(t *testing.T) {
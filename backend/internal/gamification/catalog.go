@@ -0,0 +1,113 @@
+package gamification
+
+// achievementText is an achievement's name and description in one language.
+type achievementText struct {
+	Name        string
+	Description string
+}
+
+// achievementCatalog translates the registry by language and achievement
+// ID. English lives in the registry itself.
+var achievementCatalog = map[string]map[string]achievementText{
+	"de": {
+		"first_lap":         {"Erste Runde", "Beobachte deine erste Agenten-Sitzung"},
+		"pit_crew":          {"Boxencrew", "Führe 10 Sitzungen aus"},
+		"veteran_driver":    {"Alter Hase", "Führe 50 Sitzungen aus"},
+		"century_club":      {"Hunderterclub", "Führe 100 Sitzungen aus"},
+		"track_legend":      {"Streckenlegende", "Führe 500 Sitzungen aus"},
+		"home_turf":         {"Heimstrecke", "Führe 5 Claude-Sitzungen aus"},
+		"gemini_rising":     {"Gemini im Aufwind", "Schließe deine erste Gemini-CLI-Sitzung ab"},
+		"codex_curious":     {"Codex-Neugier", "Schließe deine erste Codex-Sitzung ab"},
+		"triple_threat":     {"Dreifache Bedrohung", "Nutze alle 3 Agentenquellen (Claude, Gemini, Codex)"},
+		"polyglot":          {"Polyglott", "Führe mindestens 10 Sitzungen aus jeder Agentenquelle aus"},
+		"opus_enthusiast":   {"Opus-Enthusiast", "Führe 5 Sitzungen mit einem Opus-Modell aus"},
+		"sonnet_fan":        {"Sonnet-Fan", "Führe 5 Sitzungen mit einem Sonnet-Modell aus"},
+		"haiku_speedster":   {"Haiku-Flitzer", "Führe 5 Sitzungen mit einem Haiku-Modell aus"},
+		"full_spectrum":     {"Volles Spektrum", "Nutze mindestens ein Opus-, ein Sonnet- und ein Haiku-Modell"},
+		"model_collector":   {"Modellsammler", "Nutze 5 oder mehr verschiedene Modell-IDs"},
+		"connoisseur":       {"Kenner", "Nutze 10 oder mehr verschiedene Modell-IDs"},
+		"redline":           {"Drehzahlgrenze", "Eine Sitzung erreicht mindestens 95 % Kontextauslastung"},
+		"afterburner":       {"Nachbrenner", "Eine Sitzung verbraucht 5.000 oder mehr Tokens pro Minute"},
+		"marathon":          {"Marathon", "Eine einzelne Sitzung läuft 2 Stunden oder länger"},
+		"ultramarathon":     {"Ultramarathon", "Eine einzelne Sitzung läuft 8 Stunden lang"},
+		"token_millionaire": {"Token-Millionär", "Eine einzelne Sitzung verbraucht 1.000.000 Tokens"},
+		"tool_fiend":        {"Werkzeugnarr", "Eine einzelne Sitzung macht 500 oder mehr Tool-Aufrufe"},
+		"conversationalist": {"Plaudertasche", "Eine einzelne Sitzung tauscht 200 oder mehr Nachrichten aus"},
+		"clean_sweep":       {"Weiße Weste", "Schließe 10 Sitzungen in Folge ohne Fehler ab"},
+		"photo_finish":      {"Fotofinish", "Zwei Sitzungen enden innerhalb von 10 Sekunden"},
+		"grid_start":        {"Startaufstellung", "Lass 3 oder mehr Sitzungen gleichzeitig fahren"},
+		"full_grid":         {"Volles Feld", "Lass 5 oder mehr Sitzungen gleichzeitig fahren"},
+		"grid_full":         {"Startfeld komplett", "Lass 10 oder mehr Sitzungen gleichzeitig fahren"},
+		"crash_survivor":    {"Crash überlebt", "Eine Sitzung scheitert, danach wird eine neue erfolgreich abgeschlossen"},
+		"burning_rubber":    {"Qualmende Reifen", "3 oder mehr Sitzungen gleichzeitig über 50 % Kontextauslastung"},
+		"delegator":         {"Delegierer", "Starte 10 Subagenten"},
+		"swarm":             {"Schwarm", "Starte 100 Subagenten"},
+		"hive_mind":         {"Schwarmintelligenz", "Lass 500 Subagenten ihre Ergebnisse liefern"},
+		"hat_trick":         {"Hattrick", "Schließe 3 Sitzungen in Folge ohne Fehler ab"},
+		"on_a_roll":         {"Lauf", "Schließe 10 Sitzungen in Folge ohne Fehler ab"},
+		"untouchable":       {"Unantastbar", "Schließe 25 Sitzungen in Folge ohne Fehler ab"},
+		"daily_driver":      {"Stammfahrer", "Erfülle an 3 Tagen in Folge eine Tagesquest"},
+		"creature_of_habit": {"Gewohnheitstier", "Erfülle an 7 Tagen in Folge eine Tagesquest"},
+		"iron_will":         {"Eiserner Wille", "Erfülle an 30 Tagen in Folge eine Tagesquest"},
+		"three_way_win":     {"Dreikampfsieger", "Gewinne ein Rennen zwischen 3 oder mehr Sitzungen"},
+		"pack_leader":       {"Rudelführer", "Gewinne ein Rennen zwischen 5 oder mehr Sitzungen"},
+		"serial_winner":     {"Seriensieger", "Gewinne 10 Rennen"},
+		"champion":          {"Champion", "Gewinne 50 Rennen"},
+	},
+	"es": {
+		"first_lap":         {"Primera vuelta", "Observa tu primera sesión de agente"},
+		"pit_crew":          {"Equipo de boxes", "Ejecuta 10 sesiones"},
+		"veteran_driver":    {"Piloto veterano", "Ejecuta 50 sesiones"},
+		"century_club":      {"Club del centenar", "Ejecuta 100 sesiones"},
+		"track_legend":      {"Leyenda del circuito", "Ejecuta 500 sesiones"},
+		"home_turf":         {"En casa", "Ejecuta 5 sesiones de Claude"},
+		"gemini_rising":     {"Gemini en ascenso", "Completa tu primera sesión de Gemini CLI"},
+		"codex_curious":     {"Curiosidad por Codex", "Completa tu primera sesión de Codex"},
+		"triple_threat":     {"Triple amenaza", "Usa las 3 fuentes de agentes (Claude, Gemini, Codex)"},
+		"polyglot":          {"Políglota", "Ejecuta 10 o más sesiones de cada fuente de agentes"},
+		"opus_enthusiast":   {"Entusiasta de Opus", "Ejecuta 5 sesiones con cualquier modelo Opus"},
+		"sonnet_fan":        {"Fan de Sonnet", "Ejecuta 5 sesiones con cualquier modelo Sonnet"},
+		"haiku_speedster":   {"Bólido Haiku", "Ejecuta 5 sesiones con cualquier modelo Haiku"},
+		"full_spectrum":     {"Espectro completo", "Usa al menos un modelo Opus, uno Sonnet y uno Haiku"},
+		"model_collector":   {"Coleccionista de modelos", "Usa 5 o más ID de modelo distintos"},
+		"connoisseur":       {"Conocedor", "Usa 10 o más ID de modelo distintos"},
+		"redline":           {"Al límite", "Una sesión alcanza el 95 % o más de uso del contexto"},
+		"afterburner":       {"Posquemador", "Una sesión consume 5000 o más tokens por minuto"},
+		"marathon":          {"Maratón", "Una sola sesión dura 2 horas o más"},
+		"ultramarathon":     {"Ultramaratón", "Una sola sesión sigue en marcha durante 8 horas"},
+		"token_millionaire": {"Millonario de tokens", "Una sola sesión consume 1.000.000 de tokens"},
+		"tool_fiend":        {"Adicto a las herramientas", "Una sola sesión hace 500 o más llamadas a herramientas"},
+		"conversationalist": {"Conversador", "Una sola sesión intercambia 200 o más mensajes"},
+		"clean_sweep":       {"Pleno", "Completa 10 sesiones seguidas sin errores"},
+		"photo_finish":      {"Foto finish", "Dos sesiones terminan con menos de 10 segundos de diferencia"},
+		"grid_start":        {"Parrilla de salida", "Ten 3 o más sesiones corriendo a la vez"},
+		"full_grid":         {"Parrilla llena", "Ten 5 o más sesiones corriendo a la vez"},
+		"grid_full":         {"Parrilla completa", "Ten 10 o más sesiones corriendo a la vez"},
+		"crash_survivor":    {"Superviviente", "Una sesión falla y después una nueva termina con éxito"},
+		"burning_rubber":    {"Quemando rueda", "3 o más sesiones por encima del 50 % de uso del contexto a la vez"},
+		"delegator":         {"Delegador", "Inicia 10 subagentes"},
+		"swarm":             {"Enjambre", "Inicia 100 subagentes"},
+		"hive_mind":         {"Mente colmena", "Haz que 500 subagentes entreguen sus resultados"},
+		"hat_trick":         {"Triplete", "Completa 3 sesiones seguidas sin errores"},
+		"on_a_roll":         {"Racha", "Completa 10 sesiones seguidas sin errores"},
+		"untouchable":       {"Intocable", "Completa 25 sesiones seguidas sin errores"},
+		"daily_driver":      {"Piloto diario", "Completa una misión diaria 3 días seguidos"},
+		"creature_of_habit": {"Animal de costumbres", "Completa una misión diaria 7 días seguidos"},
+		"iron_will":         {"Voluntad de hierro", "Completa una misión diaria 30 días seguidos"},
+		"three_way_win":     {"Ganador a tres", "Gana una carrera entre 3 o más sesiones"},
+		"pack_leader":       {"Líder del pelotón", "Gana una carrera entre 5 o más sesiones"},
+		"serial_winner":     {"Ganador en serie", "Gana 10 carreras"},
+		"champion":          {"Campeón", "Gana 50 carreras"},
+	},
+}
+
+// Localize returns a copy of a with its name and description in lang,
+// keeping the English text for any language or achievement the catalog
+// does not cover.
+func (a Achievement) Localize(lang string) Achievement {
+	if t, ok := achievementCatalog[lang][a.ID]; ok {
+		a.Name = t.Name
+		a.Description = t.Description
+	}
+	return a
+}
@@ -0,0 +1,49 @@
+package gamification
+
+import (
+	"testing"
+
+	"github.com/agent-racer/backend/internal/i18n"
+)
+
+func TestAchievementCatalog_CoversRegistry(t *testing.T) {
+	registry := NewAchievementEngine().Registry()
+	for _, lang := range i18n.Languages {
+		if lang == i18n.Default {
+			continue
+		}
+		catalog, ok := achievementCatalog[lang]
+		if !ok {
+			t.Errorf("no achievement catalog for %q", lang)
+			continue
+		}
+		for _, a := range registry {
+			if text, ok := catalog[a.ID]; !ok || text.Name == "" || text.Description == "" {
+				t.Errorf("%s: %q is not translated", lang, a.ID)
+			}
+		}
+		if len(catalog) != len(registry) {
+			t.Errorf("%s: catalog has %d entries, registry %d", lang, len(catalog), len(registry))
+		}
+	}
+}
+
+func TestAchievementLocalize(t *testing.T) {
+	a := Achievement{ID: "first_lap", Name: "First Lap", Description: "Observe your first agent session", Tier: TierBronze}
+
+	if got := a.Localize("de"); got.Name != "Erste Runde" || got.Tier != TierBronze {
+		t.Errorf("de = %+v", got)
+	}
+	if a.Name != "First Lap" {
+		t.Error("Localize modified the receiver")
+	}
+	for _, lang := range []string{"en", "", "xx"} {
+		if got := a.Localize(lang); got.Name != "First Lap" {
+			t.Errorf("Localize(%q).Name = %q, want the English name", lang, got.Name)
+		}
+	}
+	custom := Achievement{ID: "not_in_catalog", Name: "Custom"}
+	if got := custom.Localize("es"); got.Name != "Custom" {
+		t.Errorf("uncatalogued achievement renamed to %q", got.Name)
+	}
+}
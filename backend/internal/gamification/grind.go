@@ -0,0 +1,65 @@
+package gamification
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/agent-racer/backend/internal/session"
+)
+
+// GrindLimits keep sessions that do nothing from farming battle pass XP,
+// whether a script spawns them in a loop or a mock source is left on. The
+// zero value imposes no limits.
+type GrindLimits struct {
+	// ObservedXPPerHour caps the session_observed XP earned within one
+	// clock hour. 0 means no cap.
+	ObservedXPPerHour int `yaml:"observed_xp_per_hour"`
+	// MinSessionDuration is how long a session must run before its
+	// completion earns XP; sessions created and completed faster count as
+	// one rapid cycle and earn none. 0 turns the check off.
+	MinSessionDuration time.Duration `yaml:"min_session_duration"`
+}
+
+// SetGrindLimits sets the XP caps applied from now on. Safe to call while
+// the tracker runs.
+func (t *StatsTracker) SetGrindLimits(l GrindLimits) {
+	t.mu.Lock()
+	t.limits = l
+	t.mu.Unlock()
+}
+
+// observedXPLocked returns how much of the session_observed award a new
+// session at now may earn under the hourly cap, recording the rest as
+// suppressed. Caller must hold t.mu.
+func (t *StatsTracker) observedXPLocked(now time.Time) int {
+	if hour := now.Truncate(time.Hour); !hour.Equal(t.observedHour) {
+		t.observedHour = hour
+		t.observedXP = 0
+	}
+	allowed := XPSessionObserved
+	if limit := t.limits.ObservedXPPerHour; limit > 0 {
+		allowed = max(0, min(allowed, limit-t.observedXP))
+		if allowed < XPSessionObserved && t.observedXP < limit {
+			slog.Info("session XP capped for the rest of the hour", "limit", limit, "hour", t.observedHour)
+		}
+	}
+	t.observedXP += allowed
+	t.suppressXPLocked("session_observed", XPSessionObserved-allowed)
+	return allowed
+}
+
+// rapidCycle reports whether s completed too soon after it started to earn
+// completion XP.
+func (l GrindLimits) rapidCycle(s *session.SessionState) bool {
+	if l.MinSessionDuration <= 0 || s.CompletedAt == nil || s.StartedAt.IsZero() {
+		return false
+	}
+	return s.CompletedAt.Sub(s.StartedAt) < l.MinSessionDuration
+}
+
+// suppressXPLocked records n XP withheld for reason. Caller must hold t.mu.
+func (t *StatsTracker) suppressXPLocked(reason string, n int) {
+	if n > 0 {
+		t.stats.SuppressedXP[reason] += n
+	}
+}
@@ -0,0 +1,63 @@
+package gamification
+
+import (
+	"testing"
+	"time"
+
+	"github.com/agent-racer/backend/internal/session"
+)
+
+func TestStatsTracker_GrindLimits(t *testing.T) {
+	tr := newTracker(newStats())
+	tr.SetGrindLimits(GrindLimits{ObservedXPPerHour: 25, MinSessionDuration: 10 * time.Second})
+	now := time.Date(2026, 3, 2, 9, 10, 0, 0, time.UTC)
+	tr.now = func() time.Time { return now }
+
+	xp := map[string]int{}
+	tr.OnBattlePassProgress(func(_ BattlePassProgress, recent []XPEntry) {
+		for i := 0; i < len(recent); i++ {
+			xp[recent[i].Reason] += recent[i].Amount
+		}
+	})
+
+	run := func(id string, lasted time.Duration) {
+		start := now
+		s := &session.SessionState{ID: id, Source: "claude", StartedAt: start}
+		tr.processEvent(session.Event{Type: session.EventNew, State: s})
+		done := *s
+		done.Activity = session.Complete
+		end := start.Add(lasted)
+		done.CompletedAt = &end
+		tr.processEvent(session.Event{Type: session.EventTerminal, State: &done})
+	}
+
+	run("a", time.Minute)
+	run("b", time.Second) // a rapid create/complete cycle
+	run("c", time.Minute) // only 5 XP left under the cap
+	run("d", time.Minute) // nothing left this hour
+	if xp["session_observed"] != 25 || xp["session_complete"] != 3*XPSessionCompletes {
+		t.Errorf("awarded %v, want session_observed=25 and session_complete=%d", xp, 3*XPSessionCompletes)
+	}
+	if got := tr.stats.SuppressedXP; got["session_observed"] != 15 || got["session_complete"] != XPSessionCompletes {
+		t.Errorf("SuppressedXP = %v, want session_observed=15 and session_complete=%d", got, XPSessionCompletes)
+	}
+	if tr.stats.TotalSessions != 4 || tr.stats.TotalCompletions != 4 {
+		t.Errorf("counted %d sessions and %d completions, want 4 and 4", tr.stats.TotalSessions, tr.stats.TotalCompletions)
+	}
+
+	// The cap starts over with the next clock hour.
+	now = time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	run("e", time.Minute)
+	if xp["session_observed"] != 25+XPSessionObserved {
+		t.Errorf("session_observed = %d after the hour turned, want %d", xp["session_observed"], 25+XPSessionObserved)
+	}
+
+	// Without limits nothing is withheld.
+	tr.SetGrindLimits(GrindLimits{})
+	for i := 0; i < 5; i++ {
+		run(string(rune('f'+i)), 0)
+	}
+	if got := tr.stats.SuppressedXP; got["session_observed"] != 15 || got["session_complete"] != XPSessionCompletes {
+		t.Errorf("SuppressedXP = %v after lifting limits, want it unchanged", got)
+	}
+}
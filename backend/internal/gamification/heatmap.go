@@ -0,0 +1,44 @@
+package gamification
+
+import "time"
+
+// Heatmap counts session starts and completions by weekday and hour of day,
+// indexed [time.Weekday][hour] (Sunday is 0), in the tracker's location.
+type Heatmap struct {
+	Starts      [7][24]int `json:"starts"`
+	Completions [7][24]int `json:"completions"`
+}
+
+// SetLocation sets the time zone that heatmap buckets are counted in. Nil
+// means the server's local zone. Counts already recorded stay where they
+// are.
+func (t *StatsTracker) SetLocation(loc *time.Location) {
+	if loc == nil {
+		loc = time.Local
+	}
+	t.mu.Lock()
+	t.loc = loc
+	t.mu.Unlock()
+}
+
+// Heatmap returns the starts and completions recorded so far and the name
+// of the zone they are counted in.
+func (t *StatsTracker) Heatmap() (Heatmap, string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.stats.Heatmap, t.location().String()
+}
+
+// location returns the zone for heatmap buckets. Caller must hold t.mu.
+func (t *StatsTracker) location() *time.Location {
+	if t.loc == nil {
+		return time.Local
+	}
+	return t.loc
+}
+
+// bucket counts at into counts, in loc.
+func bucket(counts *[7][24]int, at time.Time, loc *time.Location) {
+	at = at.In(loc)
+	counts[at.Weekday()][at.Hour()]++
+}
@@ -0,0 +1,45 @@
+package gamification
+
+import (
+	"testing"
+	"time"
+
+	"github.com/agent-racer/backend/internal/session"
+)
+
+func TestHeatmapBucketsStartsAndCompletions(t *testing.T) {
+	tracker, eventCh := startTracker(t)
+	tokyo := time.FixedZone("JST", 9*60*60)
+	tracker.SetLocation(tokyo)
+
+	// Saturday 23:30 UTC is Sunday 08:30 in Tokyo.
+	started := time.Date(2026, 5, 2, 23, 30, 0, 0, time.UTC)
+	completed := started.Add(2 * time.Hour)
+	eventCh <- session.Event{
+		Type:  session.EventNew,
+		State: &session.SessionState{ID: "s1", StartedAt: started},
+	}
+	eventCh <- session.Event{
+		Type:  session.EventTerminal,
+		State: &session.SessionState{ID: "s1", Activity: session.Complete, StartedAt: started, CompletedAt: &completed},
+	}
+	eventCh <- session.Event{
+		Type:  session.EventTerminal,
+		State: &session.SessionState{ID: "s2", Activity: session.Errored, StartedAt: started, CompletedAt: &completed},
+	}
+	tracker.Flush()
+
+	heatmap, zone := tracker.Heatmap()
+	if zone != "JST" {
+		t.Errorf("zone = %q, want JST", zone)
+	}
+	if heatmap.Starts[time.Sunday][8] != 1 {
+		t.Errorf("Starts[Sunday] = %v, want one start at 8", heatmap.Starts[time.Sunday])
+	}
+	if heatmap.Completions[time.Sunday][10] != 1 {
+		t.Errorf("Completions[Sunday] = %v, want one completion at 10, none for the error", heatmap.Completions[time.Sunday])
+	}
+	if stats := tracker.Stats(); stats.Heatmap != heatmap {
+		t.Error("Stats().Heatmap differs from Heatmap()")
+	}
+}
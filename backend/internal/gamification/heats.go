@@ -0,0 +1,66 @@
+package gamification
+
+import "time"
+
+// maxHeatHistory bounds Stats.HeatHistory; older results are dropped.
+const maxHeatHistory = 50
+
+// HeatEntry is one participant's final placing in a heat.
+type HeatEntry struct {
+	SessionID string `json:"sessionId"`
+	Name      string `json:"name"`
+	Model     string `json:"model,omitempty"`
+	Rank      int    `json:"rank"`
+	Finished  bool   `json:"finished"`
+}
+
+// HeatResult is the final standing of a heat.
+type HeatResult struct {
+	ID        string      `json:"id"`
+	Name      string      `json:"name"`
+	StartedAt time.Time   `json:"startedAt"`
+	EndedAt   time.Time   `json:"endedAt"`
+	WinnerID  string      `json:"winnerId,omitempty"`
+	Auto      bool        `json:"auto,omitempty"` // detected from sessions started together
+	Entries   []HeatEntry `json:"entries"`
+}
+
+func (r HeatResult) clone() HeatResult {
+	r.Entries = append([]HeatEntry(nil), r.Entries...)
+	return r
+}
+
+// RecordHeat adds a finished heat to the history, credits the winner and
+// its model and awards XP for racing it. Stats are saved on the next tick.
+func (t *StatsTracker) RecordHeat(r HeatResult) {
+	t.mu.Lock()
+	t.stats.HeatsRaced++
+	if r.WinnerID != "" {
+		t.stats.RacesWon++
+		t.stats.LargestFieldWon = max(t.stats.LargestFieldWon, len(r.Entries))
+	}
+	for _, e := range r.Entries {
+		if e.SessionID == r.WinnerID && r.WinnerID != "" && e.Model != "" {
+			t.stats.HeatWinsPerModel[e.Model]++
+		}
+	}
+	t.stats.HeatHistory = append(t.stats.HeatHistory, r.clone())
+	if n := len(t.stats.HeatHistory); n > maxHeatHistory {
+		t.stats.HeatHistory = append([]HeatResult(nil), t.stats.HeatHistory[n-maxHeatHistory:]...)
+	}
+
+	xp := []XPEntry{{Reason: "heat_raced", Amount: XPHeatRaced}}
+	awardXP(&t.stats.BattlePass, XPHeatRaced)
+	unlocked := t.achieveEngine.Evaluate(t.stats)
+	for _, a := range unlocked {
+		awardXP(&t.stats.BattlePass, AchievementXP(a.Tier))
+	}
+	progress := getProgress(&t.stats.BattlePass)
+	t.dirty = true
+	t.mu.Unlock()
+
+	if t.onBattlePass != nil {
+		t.onBattlePass(progress, xp)
+	}
+	t.notifyAchievements(unlocked)
+}
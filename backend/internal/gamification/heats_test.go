@@ -0,0 +1,87 @@
+package gamification
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRecordHeat(t *testing.T) {
+	tracker, _ := startTracker(t)
+	var gotXP []XPEntry
+	tracker.OnBattlePassProgress(func(_ BattlePassProgress, xp []XPEntry) { gotXP = xp })
+
+	start := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	tracker.RecordHeat(HeatResult{
+		ID:        "h1",
+		Name:      "refactor shootout",
+		StartedAt: start,
+		EndedAt:   start.Add(10 * time.Minute),
+		WinnerID:  "b",
+		Entries: []HeatEntry{
+			{SessionID: "b", Model: "claude-sonnet-4-5", Rank: 1, Finished: true},
+			{SessionID: "a", Model: "claude-opus-4-5", Rank: 2, Finished: true},
+		},
+	})
+
+	stats := tracker.Stats()
+	if stats.HeatsRaced != 1 || len(stats.HeatHistory) != 1 {
+		t.Fatalf("HeatsRaced = %d, history = %d, want 1, 1", stats.HeatsRaced, len(stats.HeatHistory))
+	}
+	if stats.HeatWinsPerModel["claude-sonnet-4-5"] != 1 || stats.HeatWinsPerModel["claude-opus-4-5"] != 0 {
+		t.Errorf("HeatWinsPerModel = %v", stats.HeatWinsPerModel)
+	}
+	if stats.BattlePass.XP != XPHeatRaced || len(gotXP) != 1 || gotXP[0].Reason != "heat_raced" {
+		t.Errorf("XP = %d, entries %+v", stats.BattlePass.XP, gotXP)
+	}
+
+	// The copy returned by Stats does not share entries with the tracker.
+	stats.HeatHistory[0].Entries[0].Rank = 9
+	if tracker.Stats().HeatHistory[0].Entries[0].Rank != 1 {
+		t.Error("Stats() shares HeatHistory entries")
+	}
+}
+
+func TestRecordHeat_HistoryIsBounded(t *testing.T) {
+	tracker, _ := startTracker(t)
+	for i := 0; i < maxHeatHistory+5; i++ {
+		tracker.RecordHeat(HeatResult{ID: fmt.Sprintf("h%d", i)})
+	}
+	stats := tracker.Stats()
+	if len(stats.HeatHistory) != maxHeatHistory {
+		t.Fatalf("history = %d, want %d", len(stats.HeatHistory), maxHeatHistory)
+	}
+	if stats.HeatHistory[0].ID != "h5" || stats.HeatsRaced != maxHeatHistory+5 {
+		t.Errorf("oldest = %s, raced = %d", stats.HeatHistory[0].ID, stats.HeatsRaced)
+	}
+}
+
+func TestRecordHeat_WinUnlocksRacingAchievements(t *testing.T) {
+	tracker, _ := startTracker(t)
+	var unlocked []string
+	tracker.OnAchievement(func(a Achievement, _ *Reward) { unlocked = append(unlocked, a.ID) })
+
+	tracker.RecordHeat(HeatResult{
+		ID:       "h1",
+		WinnerID: "a",
+		Auto:     true,
+		Entries:  []HeatEntry{{SessionID: "a", Rank: 1, Finished: true}, {SessionID: "b", Rank: 2}, {SessionID: "c", Rank: 3}},
+	})
+
+	stats := tracker.Stats()
+	if stats.RacesWon != 1 || stats.LargestFieldWon != 3 {
+		t.Errorf("RacesWon = %d, LargestFieldWon = %d, want 1, 3", stats.RacesWon, stats.LargestFieldWon)
+	}
+	if len(unlocked) != 1 || unlocked[0] != "three_way_win" {
+		t.Errorf("unlocked = %v, want [three_way_win]", unlocked)
+	}
+	if _, ok := stats.AchievementsUnlocked["three_way_win"]; !ok {
+		t.Error("three_way_win not recorded in stats")
+	}
+
+	// A heat nobody finished is raced but not won.
+	tracker.RecordHeat(HeatResult{ID: "h2", Entries: []HeatEntry{{SessionID: "d"}, {SessionID: "e"}}})
+	if stats := tracker.Stats(); stats.RacesWon != 1 || stats.HeatsRaced != 2 {
+		t.Errorf("RacesWon = %d, HeatsRaced = %d, want 1, 2", stats.RacesWon, stats.HeatsRaced)
+	}
+}
@@ -0,0 +1,48 @@
+package gamification
+
+import (
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps stats in memory only, so they are gone when the server
+// exits. Mock mode uses it to keep demo sessions out of the real stats.
+type MemoryStore struct {
+	mu sync.Mutex
+	st *Stats // last saved; nil before the first Save
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore { return &MemoryStore{} }
+
+// Path describes the store in messages; there is no file.
+func (s *MemoryStore) Path() string { return "(in memory)" }
+
+// Load returns a copy of the last saved stats, or empty stats.
+func (s *MemoryStore) Load() (*Stats, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.st == nil {
+		return newStats(), nil
+	}
+	return s.st.clone(), nil
+}
+
+// Save keeps a copy of st.
+func (s *MemoryStore) Save(st *Stats) error {
+	st.Version = statsVersion
+	st.LastUpdated = time.Now().UTC()
+	cp := st.clone()
+	s.mu.Lock()
+	s.st = cp
+	s.mu.Unlock()
+	return nil
+}
+
+// Close discards the saved stats.
+func (s *MemoryStore) Close() error {
+	s.mu.Lock()
+	s.st = nil
+	s.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,27 @@
+package gamification
+
+import "testing"
+
+func TestMemoryStore_SaveAndLoad(t *testing.T) {
+	s := NewMemoryStore()
+	empty, err := s.Load()
+	if err != nil || empty.TotalSessions != 0 || empty.SessionsPerModel == nil {
+		t.Fatalf("Load() on a new store = %+v, %v", empty, err)
+	}
+
+	st := newStats()
+	st.TotalSessions = 42
+	st.SessionsPerModel["claude-opus-4-5"] = 7
+	if err := s.Save(st); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	st.SessionsPerModel["claude-opus-4-5"] = 8 // must not reach the saved copy
+
+	got, err := s.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got.TotalSessions != 42 || got.SessionsPerModel["claude-opus-4-5"] != 7 || got.Version != statsVersion {
+		t.Errorf("Load() = %d sessions, %v, version %d", got.TotalSessions, got.SessionsPerModel, got.Version)
+	}
+}
@@ -2,10 +2,15 @@ package gamification
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strconv"
+	"sync"
 	"time"
 )
 
@@ -14,8 +19,9 @@ const (
 	// can use it to apply migrations in the future.
 	statsVersion = 1
 
-	statsFileName = "stats.json"
-	appDirName    = "agent-racer"
+	statsFileName  = "stats.json"
+	backupFileName = statsFileName + ".bak"
+	appDirName     = "agent-racer"
 )
 
 var (
@@ -39,12 +45,38 @@ type Stats struct {
 	TotalCompletions       int `json:"totalCompletions"`
 	TotalErrors            int `json:"totalErrors"`
 	ConsecutiveCompletions int `json:"consecutiveCompletions"`
+	TotalTokensBurned      int `json:"totalTokensBurned"`
 
 	// Per-dimension breakdowns
 	SessionsPerSource   map[string]int `json:"sessionsPerSource"`
 	SessionsPerModel    map[string]int `json:"sessionsPerModel"`
 	DistinctModelsUsed  int            `json:"distinctModelsUsed"`
 	DistinctSourcesUsed int            `json:"distinctSourcesUsed"`
+	ToolCallsPerMCP     map[string]int `json:"toolCallsPerMcp"`
+	ToolCalls           map[string]int `json:"toolCalls"` // tool name -> calls
+	SlashCommandsUsed   map[string]int `json:"slashCommandsUsed"`
+	TotalHookEvents     int            `json:"totalHookEvents"`
+	TotalModelSwitches  int            `json:"totalModelSwitches"`
+	TotalSubagents      int            `json:"totalSubagents"`     // subagents that did any work
+	SubagentsCompleted  int            `json:"subagentsCompleted"` // subagents that returned a result
+	OutcomesPerKind     map[string]int `json:"outcomesPerKind"`    // session.OutcomeKind -> terminal sessions
+	MilestonesReached   map[string]int `json:"milestonesReached"`  // session.Milestone key -> sessions that passed it
+
+	// ToolAchievementSessions counts, by per-session ToolAchievement ID,
+	// the completed sessions that met it.
+	ToolAchievementSessions map[string]int `json:"toolAchievementSessions"`
+
+	// Prompt caching across all sessions; see session.CacheUsage
+	TotalCacheReadTokens  int     `json:"totalCacheReadTokens"`
+	TotalCacheWriteTokens int     `json:"totalCacheWriteTokens"`
+	TotalUncachedTokens   int     `json:"totalUncachedTokens"`
+	CacheHitRatio         float64 `json:"cacheHitRatio"`
+	CacheSavedTokens      int     `json:"cacheSavedTokens"`
+
+	// Estimated energy and emissions across all sessions; see
+	// session.EnergyModel
+	TotalEnergyWh float64 `json:"totalEnergyWh"`
+	TotalCO2Grams float64 `json:"totalCo2Grams"`
 
 	// Peak metrics (all-time highs)
 	MaxContextUtilization          float64 `json:"maxContextUtilization"`
@@ -56,12 +88,36 @@ type Stats struct {
 	MaxSessionDurationSec          float64 `json:"maxSessionDurationSec"`
 	PhotoFinishSeen                bool    `json:"photoFinishSeen"`
 
+	// Heats (see RecordHeat)
+	HeatsRaced       int            `json:"heatsRaced"`
+	HeatWinsPerModel map[string]int `json:"heatWinsPerModel"`
+	HeatHistory      []HeatResult   `json:"heatHistory,omitempty"`
+	RacesWon         int            `json:"racesWon"`        // heats, explicit or auto, that ended with a winner
+	LargestFieldWon  int            `json:"largestFieldWon"` // most sessions in a heat that ended with a winner
+
+	// When sessions start and complete (see Heatmap)
+	Heatmap Heatmap `json:"heatmap"`
+
 	// Gamification state
 	AchievementsUnlocked map[string]time.Time `json:"achievementsUnlocked"`
 	BattlePass           BattlePass           `json:"battlePass"`
 	ArchivedSeasons      []ArchivedSeason     `json:"archivedSeasons,omitempty"`
 	Equipped             Equipped             `json:"equipped"`
 	WeeklyChallenges     WeeklyChallengeState `json:"weeklyChallenges"`
+	DailyQuests          DailyQuestState      `json:"dailyQuests"`
+	SuppressedXP         map[string]int       `json:"suppressedXp"` // XP reason -> XP withheld by GrindLimits
+
+	// AchievementsAcknowledged holds when a client reported showing each
+	// unlock; the rest unlocked while nobody was watching.
+	AchievementsAcknowledged map[string]time.Time `json:"achievementsAcknowledged"`
+
+	// SeasonStart is when the battle pass season began and the lifetime
+	// totals then; nil for a season that began before it was kept.
+	SeasonStart *SeasonStart `json:"seasonStart,omitempty"`
+
+	// SeasonRewards holds the cosmetics granted for good when a season
+	// ended, by reward ID, with the season that earned each.
+	SeasonRewards map[string]string `json:"seasonRewards"`
 
 	LastUpdated time.Time `json:"lastUpdated"`
 }
@@ -111,6 +167,16 @@ type ArchivedSeason struct {
 	Tier     int    `json:"tier"`
 	XP       int    `json:"xp"`
 	Archived string `json:"archived"` // RFC 3339 timestamp
+
+	// Started and Totals are missing for seasons that began before
+	// season totals were kept.
+	Started string        `json:"started,omitempty"` // RFC 3339 timestamp
+	Totals  *SeasonTotals `json:"totals,omitempty"`
+
+	// Rewards and CarriedXP are what the season left the player under
+	// the SeasonEndRules in force when it ended.
+	Rewards   []string `json:"rewards,omitempty"` // newly granted reward IDs
+	CarriedXP int      `json:"carriedXp,omitempty"`
 }
 
 // Equipped tracks which cosmetic item is active in each slot.
@@ -127,7 +193,8 @@ type Equipped struct {
 
 // Store handles loading and saving Stats to disk.
 type Store struct {
-	dir string // directory containing stats.json
+	dir string     // directory containing stats.json
+	mu  sync.Mutex // serializes Save, which Equip calls outside the Run loop
 }
 
 // NewStore creates a Store that reads/writes stats in the given directory.
@@ -145,14 +212,40 @@ func (s *Store) Path() string {
 	return filepath.Join(s.dir, statsFileName)
 }
 
+// Close does nothing; Store holds no open files between calls.
+func (s *Store) Close() error { return nil }
+
+// BackupPath returns the path of the copy of the stats file that Save
+// replaced last, which Load falls back to.
+func (s *Store) BackupPath() string {
+	return filepath.Join(s.dir, backupFileName)
+}
+
 // Load reads stats from disk. If the file does not exist, a zero-value
-// Stats with initialized maps and the current version is returned.
+// Stats with initialized maps and the current version is returned. If it
+// can't be read or parsed, e.g. after a power cut mid-write on a
+// filesystem that doesn't order rename after data, the backup is loaded
+// instead with a warning; the error is only returned when that fails too.
 func (s *Store) Load() (*Stats, error) {
-	data, err := os.ReadFile(s.Path())
+	st, err := readStats(s.Path())
+	if err == nil {
+		return st, nil
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		return newStats(), nil
+	}
+
+	backup, bakErr := readStats(s.BackupPath())
+	if bakErr != nil {
+		return nil, err
+	}
+	slog.Warn("stats file unreadable, loaded backup", "path", s.Path(), "backup", s.BackupPath(), "error", err)
+	return backup, nil
+}
+
+func readStats(path string) (*Stats, error) {
+	data, err := os.ReadFile(path)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return newStats(), nil
-		}
 		return nil, fmt.Errorf("reading stats: %w", err)
 	}
 
@@ -168,6 +261,8 @@ func (s *Store) Load() (*Stats, error) {
 // Save writes stats to disk using an atomic temp-file-then-rename pattern.
 // The directory is created if it does not already exist.
 func (s *Store) Save(st *Stats) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	if err := os.MkdirAll(s.dir, 0o700); err != nil {
 		return fmt.Errorf("creating stats dir: %w", err)
 	}
@@ -204,6 +299,9 @@ func (s *Store) Save(st *Stats) error {
 	if err := tmp.Close(); err != nil {
 		return fmt.Errorf("closing temp file: %w", err)
 	}
+	if err := s.rotateBackup(); err != nil {
+		return fmt.Errorf("rotating stats backup: %w", err)
+	}
 	if err := renameFile(tmpPath, s.Path()); err != nil {
 		return fmt.Errorf("renaming stats file: %w", err)
 	}
@@ -215,6 +313,37 @@ func (s *Store) Save(st *Stats) error {
 	return nil
 }
 
+// rotateBackup makes the current stats file the backup. It links rather
+// than renames so that the stats file exists at every moment.
+func (s *Store) rotateBackup() error {
+	if err := os.Remove(s.BackupPath()); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	err := os.Link(s.Path(), s.BackupPath())
+	if err == nil || errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	// Some filesystems don't support hard links.
+	return copyFile(s.Path(), s.BackupPath())
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
+
 func syncDir(path string) error {
 	dir, err := openDir(path)
 	if err != nil {
@@ -230,12 +359,23 @@ func syncDir(path string) error {
 // newStats returns a Stats with initialized maps and the current version.
 func newStats() *Stats {
 	st := &Stats{
-		Version:              statsVersion,
-		SessionsPerSource:    make(map[string]int),
-		SessionsPerModel:     make(map[string]int),
-		AchievementsUnlocked: make(map[string]time.Time),
+		Version:                  statsVersion,
+		SessionsPerSource:        make(map[string]int),
+		SessionsPerModel:         make(map[string]int),
+		ToolCallsPerMCP:          make(map[string]int),
+		ToolCalls:                make(map[string]int),
+		SlashCommandsUsed:        make(map[string]int),
+		OutcomesPerKind:          make(map[string]int),
+		MilestonesReached:        make(map[string]int),
+		ToolAchievementSessions:  make(map[string]int),
+		HeatWinsPerModel:         make(map[string]int),
+		AchievementsUnlocked:     make(map[string]time.Time),
+		AchievementsAcknowledged: make(map[string]time.Time),
+		SuppressedXP:             make(map[string]int),
+		SeasonRewards:            make(map[string]string),
 	}
 	initWeeklyChallengeState(&st.WeeklyChallenges)
+	initDailyQuestState(&st.DailyQuests)
 	return st
 }
 
@@ -247,10 +387,46 @@ func (st *Stats) initMaps() {
 	if st.SessionsPerModel == nil {
 		st.SessionsPerModel = make(map[string]int)
 	}
+	if st.ToolCallsPerMCP == nil {
+		st.ToolCallsPerMCP = make(map[string]int)
+	}
+	if st.ToolCalls == nil {
+		st.ToolCalls = make(map[string]int)
+	}
+	if st.SlashCommandsUsed == nil {
+		st.SlashCommandsUsed = make(map[string]int)
+	}
+	if st.OutcomesPerKind == nil {
+		st.OutcomesPerKind = make(map[string]int)
+	}
+	if st.MilestonesReached == nil {
+		st.MilestonesReached = make(map[string]int)
+	}
+	if st.ToolAchievementSessions == nil {
+		st.ToolAchievementSessions = make(map[string]int)
+	}
+	if st.HeatWinsPerModel == nil {
+		st.HeatWinsPerModel = make(map[string]int)
+	}
 	if st.AchievementsUnlocked == nil {
 		st.AchievementsUnlocked = make(map[string]time.Time)
 	}
+	if st.AchievementsAcknowledged == nil {
+		// Stats saved before unlocks were acknowledged: don't replay
+		// every toast the user has long since seen.
+		st.AchievementsAcknowledged = make(map[string]time.Time, len(st.AchievementsUnlocked))
+		for id, at := range st.AchievementsUnlocked {
+			st.AchievementsAcknowledged[id] = at
+		}
+	}
+	if st.SuppressedXP == nil {
+		st.SuppressedXP = make(map[string]int)
+	}
+	if st.SeasonRewards == nil {
+		st.SeasonRewards = make(map[string]string)
+	}
 	initWeeklyChallengeState(&st.WeeklyChallenges)
+	initDailyQuestState(&st.DailyQuests)
 }
 
 // clone returns a deep copy of Stats with all maps duplicated.
@@ -264,14 +440,64 @@ func (st *Stats) clone() *Stats {
 	for k, v := range st.SessionsPerModel {
 		cp.SessionsPerModel[k] = v
 	}
+	cp.ToolCallsPerMCP = make(map[string]int, len(st.ToolCallsPerMCP))
+	for k, v := range st.ToolCallsPerMCP {
+		cp.ToolCallsPerMCP[k] = v
+	}
+	cp.ToolCalls = make(map[string]int, len(st.ToolCalls))
+	for k, v := range st.ToolCalls {
+		cp.ToolCalls[k] = v
+	}
+	cp.SlashCommandsUsed = make(map[string]int, len(st.SlashCommandsUsed))
+	for k, v := range st.SlashCommandsUsed {
+		cp.SlashCommandsUsed[k] = v
+	}
+	cp.OutcomesPerKind = make(map[string]int, len(st.OutcomesPerKind))
+	for k, v := range st.OutcomesPerKind {
+		cp.OutcomesPerKind[k] = v
+	}
+	cp.MilestonesReached = make(map[string]int, len(st.MilestonesReached))
+	for k, v := range st.MilestonesReached {
+		cp.MilestonesReached[k] = v
+	}
+	cp.ToolAchievementSessions = make(map[string]int, len(st.ToolAchievementSessions))
+	for k, v := range st.ToolAchievementSessions {
+		cp.ToolAchievementSessions[k] = v
+	}
+	cp.HeatWinsPerModel = make(map[string]int, len(st.HeatWinsPerModel))
+	for k, v := range st.HeatWinsPerModel {
+		cp.HeatWinsPerModel[k] = v
+	}
+	if len(st.HeatHistory) > 0 {
+		cp.HeatHistory = make([]HeatResult, len(st.HeatHistory))
+		for i := 0; i < len(st.HeatHistory); i++ {
+			cp.HeatHistory[i] = st.HeatHistory[i].clone()
+		}
+	}
 	cp.AchievementsUnlocked = make(map[string]time.Time, len(st.AchievementsUnlocked))
 	for k, v := range st.AchievementsUnlocked {
 		cp.AchievementsUnlocked[k] = v
 	}
+	cp.AchievementsAcknowledged = make(map[string]time.Time, len(st.AchievementsAcknowledged))
+	for k, v := range st.AchievementsAcknowledged {
+		cp.AchievementsAcknowledged[k] = v
+	}
+	cp.SuppressedXP = make(map[string]int, len(st.SuppressedXP))
+	for k, v := range st.SuppressedXP {
+		cp.SuppressedXP[k] = v
+	}
+	cp.SeasonRewards = make(map[string]string, len(st.SeasonRewards))
+	for k, v := range st.SeasonRewards {
+		cp.SeasonRewards[k] = v
+	}
 	if len(st.ArchivedSeasons) > 0 {
 		cp.ArchivedSeasons = make([]ArchivedSeason, len(st.ArchivedSeasons))
 		copy(cp.ArchivedSeasons, st.ArchivedSeasons)
 	}
+	if st.SeasonStart != nil {
+		start := *st.SeasonStart
+		cp.SeasonStart = &start
+	}
 	if len(st.WeeklyChallenges.ActiveIDs) > 0 {
 		cp.WeeklyChallenges.ActiveIDs = make([]string, len(st.WeeklyChallenges.ActiveIDs))
 		copy(cp.WeeklyChallenges.ActiveIDs, st.WeeklyChallenges.ActiveIDs)
@@ -292,6 +518,14 @@ func (st *Stats) clone() *Stats {
 	for k, v := range st.WeeklyChallenges.XPAwarded {
 		cp.WeeklyChallenges.XPAwarded[k] = v
 	}
+	if len(st.DailyQuests.ActiveIDs) > 0 {
+		cp.DailyQuests.ActiveIDs = make([]string, len(st.DailyQuests.ActiveIDs))
+		copy(cp.DailyQuests.ActiveIDs, st.DailyQuests.ActiveIDs)
+	}
+	cp.DailyQuests.XPAwarded = make(map[string]bool, len(st.DailyQuests.XPAwarded))
+	for k, v := range st.DailyQuests.XPAwarded {
+		cp.DailyQuests.XPAwarded[k] = v
+	}
 	return &cp
 }
 
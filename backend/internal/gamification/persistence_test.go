@@ -185,7 +185,7 @@ func TestStore_SaveOverwriteCleansTempFiles(t *testing.T) {
 
 	entries, _ := os.ReadDir(dir)
 	for _, e := range entries {
-		if e.Name() != statsFileName {
+		if e.Name() != statsFileName && e.Name() != backupFileName {
 			t.Errorf("unexpected file left behind: %s", e.Name())
 		}
 	}
@@ -205,6 +205,54 @@ func TestStore_LoadCorruptJSON(t *testing.T) {
 	}
 }
 
+func TestStore_SaveKeepsPreviousFileAsBackup(t *testing.T) {
+	s := NewStore(t.TempDir())
+	for i := 1; i <= 3; i++ {
+		st := newStats()
+		st.TotalSessions = i
+		if err := s.Save(st); err != nil {
+			t.Fatalf("Save %d: %v", i, err)
+		}
+	}
+
+	backup, err := readStats(s.BackupPath())
+	if err != nil {
+		t.Fatalf("read backup: %v", err)
+	}
+	if backup.TotalSessions != 2 {
+		t.Errorf("backup TotalSessions = %d, want the previous save's 2", backup.TotalSessions)
+	}
+	if info, err := os.Stat(s.BackupPath()); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("backup mode = %v, %v; want 0600", info, err)
+	}
+}
+
+func TestStore_LoadFallsBackToBackup(t *testing.T) {
+	for _, corrupt := range []string{"", "{\"totalSessions\": 4", "\x00\x00\x00"} {
+		s := NewStore(t.TempDir())
+		st := newStats()
+		st.TotalSessions = 3
+		if err := s.Save(st); err != nil {
+			t.Fatal(err)
+		}
+		st.TotalSessions = 4
+		if err := s.Save(st); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(s.Path(), []byte(corrupt), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		loaded, err := s.Load()
+		if err != nil {
+			t.Fatalf("Load(%q) error: %v", corrupt, err)
+		}
+		if loaded.TotalSessions != 3 || loaded.SessionsPerModel == nil {
+			t.Errorf("Load(%q) TotalSessions = %d, want the backup's 3", corrupt, loaded.TotalSessions)
+		}
+	}
+}
+
 func TestStore_LoadInitializesMaps(t *testing.T) {
 	dir := t.TempDir()
 	s := NewStore(dir)
@@ -386,7 +434,7 @@ func TestStore_SaveReturnsErrorIfDirSyncFailsAfterRename(t *testing.T) {
 		t.Fatalf("ReadDir() error: %v", readErr)
 	}
 	for i := 0; i < len(entries); i++ {
-		if entries[i].Name() != statsFileName {
+		if entries[i].Name() != statsFileName && entries[i].Name() != backupFileName {
 			t.Fatalf("unexpected file left behind: %s", entries[i].Name())
 		}
 	}
@@ -484,7 +532,7 @@ func TestStore_AtomicWriteSurvivesCrash(t *testing.T) {
 		t.Fatalf("ReadDir error: %v", err)
 	}
 	for _, e := range entries {
-		if e.Name() != statsFileName {
+		if e.Name() != statsFileName && e.Name() != backupFileName {
 			t.Errorf("unexpected file in directory: %s", e.Name())
 		}
 	}
@@ -510,13 +558,13 @@ func TestStore_AtomicWriteNoTempFileLeak(t *testing.T) {
 	}
 
 	for _, e := range entries {
-		if e.Name() != statsFileName {
+		if e.Name() != statsFileName && e.Name() != backupFileName {
 			t.Errorf("unexpected file left in dir: %s", e.Name())
 		}
 	}
 
-	if len(entries) != 1 {
-		t.Errorf("expected exactly 1 file, got %d", len(entries))
+	if len(entries) != 2 {
+		t.Errorf("expected the stats file and its backup, got %d files", len(entries))
 	}
 }
 
@@ -800,3 +848,33 @@ func TestStats_CloneDeepCopiesAllWeeklyChallengeFields(t *testing.T) {
 		t.Error("WeekStart not properly cloned")
 	}
 }
+
+func TestStore_LoadAcknowledgesUnlocksSavedBeforeTracking(t *testing.T) {
+	s := NewStore(t.TempDir())
+	at := time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)
+	data := []byte(`{"version": 2, "achievementsUnlocked": {"first_lap": "2026-01-05T09:00:00Z"}}`)
+	if err := os.WriteFile(s.Path(), data, 0o644); err != nil {
+		t.Fatalf("WriteFile error: %v", err)
+	}
+
+	st, err := s.Load()
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if got, ok := st.AchievementsAcknowledged["first_lap"]; !ok || !got.Equal(at) {
+		t.Errorf("AchievementsAcknowledged = %v, want first_lap at its unlock time", st.AchievementsAcknowledged)
+	}
+
+	// Once tracked, an unlock nobody has seen stays unacknowledged.
+	st.AchievementsUnlocked["pit_crew"] = at
+	if err := s.Save(st); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	st, err = s.Load()
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if _, ok := st.AchievementsAcknowledged["pit_crew"]; ok {
+		t.Error("pit_crew acknowledged without a client showing it")
+	}
+}
@@ -0,0 +1,207 @@
+package gamification
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"sort"
+	"time"
+)
+
+// Quest describes a single daily quest: a small goal that resets at local
+// midnight, unlike the weekly challenges.
+type Quest struct {
+	ID          string
+	Description string
+	// Progress returns (current, target) for the day so far.
+	Progress func(snap *DaySnapshot) (current, target int)
+}
+
+// DaySnapshot counts what happened on the current quest day.
+type DaySnapshot struct {
+	SessionsStarted   int `json:"sessionsStarted"`
+	SessionsCompleted int `json:"sessionsCompleted"`
+	Context95Count    int `json:"context95Count"` // sessions that reached 95% context
+	NewModels         int `json:"newModels"`      // models used for the first time ever
+	SubagentsStarted  int `json:"subagentsStarted"`
+	TokensBurned      int `json:"tokensBurned"`
+}
+
+// QuestProgress is the progress of one of the day's quests.
+type QuestProgress struct {
+	ID          string `json:"id"`
+	Description string `json:"description"`
+	Current     int    `json:"current"`
+	Target      int    `json:"target"`
+	Complete    bool   `json:"complete"`
+}
+
+// DailyQuests is the JSON shape of /api/quests.
+type DailyQuests struct {
+	Day           string          `json:"day"` // YYYY-MM-DD in the display time zone
+	Quests        []QuestProgress `json:"quests"`
+	Streak        int             `json:"streak"`
+	LongestStreak int             `json:"longestStreak"`
+}
+
+// DailyQuestState is persisted in Stats to track the current day's quests
+// and the streak of days on which one was completed.
+type DailyQuestState struct {
+	Day       string          `json:"day"`
+	ActiveIDs []string        `json:"activeIds"`
+	Snapshot  DaySnapshot     `json:"snapshot"`
+	XPAwarded map[string]bool `json:"xpAwarded"`
+
+	// Streak is how many days in a row, up to the current one, at least
+	// one quest was completed; LastCompletedDay is the latest such day.
+	Streak           int    `json:"streak"`
+	LongestStreak    int    `json:"longestStreak"`
+	LastCompletedDay string `json:"lastCompletedDay,omitempty"`
+}
+
+const (
+	questsPerDay = 3
+	dayLayout    = "2006-01-02"
+)
+
+// questPool is the full set of available daily quests.
+var questPool = []Quest{
+	{
+		ID:          "complete_1_session",
+		Description: "Complete a session today",
+		Progress:    func(snap *DaySnapshot) (int, int) { return snap.SessionsCompleted, 1 },
+	},
+	{
+		ID:          "complete_3_sessions",
+		Description: "Complete 3 sessions today",
+		Progress:    func(snap *DaySnapshot) (int, int) { return snap.SessionsCompleted, 3 },
+	},
+	{
+		ID:          "run_3_sessions",
+		Description: "Run 3 sessions today",
+		Progress:    func(snap *DaySnapshot) (int, int) { return snap.SessionsStarted, 3 },
+	},
+	{
+		ID:          "context_95",
+		Description: "Push a session to 95% context",
+		Progress:    func(snap *DaySnapshot) (int, int) { return snap.Context95Count, 1 },
+	},
+	{
+		ID:          "new_model",
+		Description: "Use a model you have never used before",
+		Progress:    func(snap *DaySnapshot) (int, int) { return snap.NewModels, 1 },
+	},
+	{
+		ID:          "start_subagent",
+		Description: "Start a subagent",
+		Progress:    func(snap *DaySnapshot) (int, int) { return snap.SubagentsStarted, 1 },
+	},
+	{
+		ID:          "burn_200k_tokens",
+		Description: "Burn 200K tokens today",
+		Progress:    func(snap *DaySnapshot) (int, int) { return snap.TokensBurned, 200_000 },
+	},
+}
+
+// questByID returns the Quest from the pool with the given ID, or ok=false.
+func questByID(id string) (Quest, bool) {
+	for i := 0; i < len(questPool); i++ {
+		if questPool[i].ID == id {
+			return questPool[i], true
+		}
+	}
+	return Quest{}, false
+}
+
+// selectQuests deterministically picks questsPerDay quests for day, the
+// same way selectChallenges picks a week's challenges.
+func selectQuests(day string) []string {
+	n := len(questPool)
+	h := sha256.Sum256([]byte("quests " + day))
+	seed := binary.BigEndian.Uint64(h[:8])
+
+	indices := make([]int, n)
+	for i := 0; i < n; i++ {
+		indices[i] = i
+	}
+	for i := n - 1; i > 0; i-- {
+		seed = seed*6364136223846793005 + 1442695040888963407 // LCG step
+		j := int(seed % uint64(i+1))
+		indices[i], indices[j] = indices[j], indices[i]
+	}
+
+	ids := make([]string, min(questsPerDay, n))
+	for i := 0; i < len(ids); i++ {
+		ids[i] = questPool[indices[i]].ID
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// EvaluateQuests computes progress for the day's quests.
+func EvaluateQuests(state *DailyQuestState) []QuestProgress {
+	out := make([]QuestProgress, 0, len(state.ActiveIDs))
+	for i := 0; i < len(state.ActiveIDs); i++ {
+		q, ok := questByID(state.ActiveIDs[i])
+		if !ok {
+			continue
+		}
+		cur, tgt := q.Progress(&state.Snapshot)
+		out = append(out, QuestProgress{
+			ID:          q.ID,
+			Description: q.Description,
+			Current:     cur,
+			Target:      tgt,
+			Complete:    cur >= tgt,
+		})
+	}
+	return out
+}
+
+// RotateQuestsIfNeeded starts a new set of quests when now falls on a
+// later day in loc than the current one, breaking the streak if the day
+// before went without a completed quest. Returns true if rotation occurred.
+func RotateQuestsIfNeeded(state *DailyQuestState, now time.Time, loc *time.Location) bool {
+	day := now.In(loc).Format(dayLayout)
+	if day == state.Day {
+		return false
+	}
+	state.Day = day
+	state.ActiveIDs = selectQuests(day)
+	state.Snapshot = DaySnapshot{}
+	state.XPAwarded = make(map[string]bool)
+	if state.LastCompletedDay != previousDay(day) {
+		state.Streak = 0
+	}
+	return true
+}
+
+// recordQuestCompleted extends the streak for a quest completed on the
+// current day. Only the first completion of a day counts.
+func recordQuestCompleted(state *DailyQuestState) {
+	if state.LastCompletedDay == state.Day {
+		return
+	}
+	if state.LastCompletedDay == previousDay(state.Day) {
+		state.Streak++
+	} else {
+		state.Streak = 1
+	}
+	state.LastCompletedDay = state.Day
+	state.LongestStreak = max(state.LongestStreak, state.Streak)
+}
+
+// previousDay returns the calendar day before day, both as YYYY-MM-DD.
+func previousDay(day string) string {
+	d, err := time.Parse(dayLayout, day)
+	if err != nil {
+		return ""
+	}
+	return d.AddDate(0, 0, -1).Format(dayLayout)
+}
+
+// initDailyQuestState ensures the state has initialized maps.
+func initDailyQuestState(s *DailyQuestState) {
+	if s.XPAwarded == nil {
+		s.XPAwarded = make(map[string]bool)
+	}
+}
@@ -0,0 +1,136 @@
+package gamification
+
+import (
+	"testing"
+	"time"
+
+	"github.com/agent-racer/backend/internal/session"
+)
+
+func TestSelectQuests(t *testing.T) {
+	a := selectQuests("2026-03-02")
+	if len(a) != questsPerDay {
+		t.Fatalf("got %d quests, want %d", len(a), questsPerDay)
+	}
+	seen := map[string]bool{}
+	for i := 0; i < len(a); i++ {
+		if _, ok := questByID(a[i]); !ok || seen[a[i]] {
+			t.Errorf("quest %q unknown or repeated in %v", a[i], a)
+		}
+		seen[a[i]] = true
+	}
+	b := selectQuests("2026-03-02")
+	for i := 0; i < len(a); i++ {
+		if a[i] != b[i] {
+			t.Fatalf("selection not deterministic: %v vs %v", a, b)
+		}
+	}
+}
+
+func TestRotateQuestsAtLocalMidnight(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("no tzdata:", err)
+	}
+	var state DailyQuestState
+	// 23:30 UTC on March 1st is already March 2nd in Berlin.
+	if !RotateQuestsIfNeeded(&state, time.Date(2026, 3, 1, 23, 30, 0, 0, time.UTC), berlin) {
+		t.Fatal("first call did not rotate")
+	}
+	if state.Day != "2026-03-02" {
+		t.Errorf("Day = %q, want 2026-03-02", state.Day)
+	}
+	state.Snapshot.SessionsCompleted = 2
+	if RotateQuestsIfNeeded(&state, time.Date(2026, 3, 2, 22, 59, 0, 0, time.UTC), berlin) {
+		t.Error("rotated before local midnight")
+	}
+	if !RotateQuestsIfNeeded(&state, time.Date(2026, 3, 2, 23, 0, 0, 0, time.UTC), berlin) {
+		t.Error("did not rotate at local midnight")
+	}
+	if state.Snapshot.SessionsCompleted != 0 {
+		t.Error("snapshot not reset")
+	}
+}
+
+func TestQuestStreak(t *testing.T) {
+	var state DailyQuestState
+	day := func(d int) time.Time { return time.Date(2026, 3, d, 12, 0, 0, 0, time.UTC) }
+
+	for d := 1; d <= 3; d++ {
+		RotateQuestsIfNeeded(&state, day(d), time.UTC)
+		recordQuestCompleted(&state)
+		recordQuestCompleted(&state) // a second quest the same day
+	}
+	if state.Streak != 3 || state.LongestStreak != 3 {
+		t.Fatalf("streak = %d, longest %d, want 3 and 3", state.Streak, state.LongestStreak)
+	}
+
+	// March 4th goes by without a quest; the streak survives until the 5th.
+	RotateQuestsIfNeeded(&state, day(4), time.UTC)
+	if state.Streak != 3 {
+		t.Errorf("streak = %d on a day still open, want 3", state.Streak)
+	}
+	RotateQuestsIfNeeded(&state, day(5), time.UTC)
+	if state.Streak != 0 {
+		t.Errorf("streak = %d after a missed day, want 0", state.Streak)
+	}
+	recordQuestCompleted(&state)
+	if state.Streak != 1 || state.LongestStreak != 3 {
+		t.Errorf("streak = %d, longest %d, want 1 and 3", state.Streak, state.LongestStreak)
+	}
+}
+
+func TestStatsTracker_DailyQuests(t *testing.T) {
+	tr := newTracker(newStats())
+	tr.loc = time.UTC
+	now := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	tr.now = func() time.Time { return now }
+
+	// Make the day's quests one that a single completed session satisfies.
+	RotateQuestsIfNeeded(&tr.stats.DailyQuests, now, time.UTC)
+	tr.stats.DailyQuests.ActiveIDs = []string{"complete_1_session", "context_95"}
+
+	var recent []XPEntry
+	tr.OnBattlePassProgress(func(_ BattlePassProgress, xp []XPEntry) { recent = append(recent, xp...) })
+
+	s := &session.SessionState{ID: "s1", Source: "claude", Model: "claude-opus-4"}
+	tr.processEvent(session.Event{Type: session.EventNew, State: s})
+	s.ContextUtilization = 0.96
+	tr.processEvent(session.Event{Type: session.EventUpdate, State: s})
+	tr.processEvent(session.Event{Type: session.EventUpdate, State: s})
+	done := *s
+	done.Activity = session.Complete
+	tr.processEvent(session.Event{Type: session.EventTerminal, State: &done})
+
+	dq := tr.stats.DailyQuests
+	if dq.Snapshot.SessionsStarted != 1 || dq.Snapshot.SessionsCompleted != 1 || dq.Snapshot.Context95Count != 1 || dq.Snapshot.NewModels != 1 {
+		t.Errorf("snapshot = %+v", dq.Snapshot)
+	}
+	awarded := 0
+	for i := 0; i < len(recent); i++ {
+		if recent[i].Reason == "daily_quest_complete_1_session" || recent[i].Reason == "daily_quest_context_95" {
+			awarded++
+			if recent[i].Amount != XPDailyQuest {
+				t.Errorf("%s awarded %d XP, want %d", recent[i].Reason, recent[i].Amount, XPDailyQuest)
+			}
+		}
+	}
+	if awarded != 2 {
+		t.Errorf("daily quest XP awarded %d times, want 2: %+v", awarded, recent)
+	}
+	if dq.Streak != 1 || dq.LastCompletedDay != "2026-03-02" {
+		t.Errorf("streak = %d on %q, want 1 on 2026-03-02", dq.Streak, dq.LastCompletedDay)
+	}
+
+	// The next morning brings new quests with nothing done yet.
+	now = now.Add(24 * time.Hour)
+	q := tr.Quests()
+	if q.Day != "2026-03-03" || q.Streak != 1 {
+		t.Errorf("next day = %q with streak %d, want 2026-03-03 and 1", q.Day, q.Streak)
+	}
+	for i := 0; i < len(q.Quests); i++ {
+		if q.Quests[i].Current != 0 {
+			t.Errorf("quest %s carried over progress %d", q.Quests[i].ID, q.Quests[i].Current)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package gamification
+
+import (
+	"sort"
+	"time"
+
+	"github.com/agent-racer/backend/internal/session"
+)
+
+// Rebuild recomputes stats from the final states of past sessions, replaying
+// each as the monitor would have reported it: discovered at its start, one
+// update with its final counters, its end if it reached one, and the start
+// and completion of each subagent it kept. Heatmap
+// buckets are counted in loc (nil = local time), tools are the configured
+// tool achievements to award alongside the built-in ones, and limits apply
+// to XP as they would have live.
+//
+// Session history says nothing about heats, cosmetics, weekly challenges or
+// daily quests, so when prev is non-nil those are carried over from it, as
+// are its achievements with their original unlock times. The battle pass keeps
+// prev's season and never loses XP, and the season's totals are measured
+// again from when prev says it started.
+func Rebuild(sessions []*session.SessionState, prev *Stats, loc *time.Location, tools []ToolAchievement, limits GrindLimits) *Stats {
+	t := newTracker(newStats())
+	t.loc = loc
+	t.limits = limits
+	t.SetToolAchievements(tools)
+
+	events := make([]rebuildEvent, 0, 3*len(sessions))
+	for _, s := range sessions {
+		if s == nil || s.StartedAt.IsZero() {
+			continue
+		}
+		events = append(events, rebuildEvent{at: s.StartedAt, typ: session.EventNew, state: s})
+		last := s.LastActivityAt
+		if last.Before(s.StartedAt) {
+			last = s.StartedAt
+		}
+		events = append(events, rebuildEvent{at: last, typ: session.EventUpdate, state: s})
+		if s.IsTerminal() {
+			end := last
+			if s.CompletedAt != nil && !s.CompletedAt.Before(s.StartedAt) {
+				end = *s.CompletedAt
+			}
+			events = append(events, rebuildEvent{at: end, typ: session.EventTerminal, state: s})
+		}
+		for i := 0; i < len(s.Subagents); i++ {
+			sub := &s.Subagents[i]
+			if sub.MessageCount == 0 && sub.CompletedAt == nil {
+				continue
+			}
+			events = append(events, rebuildEvent{at: sub.StartedAt, typ: session.EventSubagentStarted, state: s, sub: sub})
+			if sub.CompletedAt != nil {
+				events = append(events, rebuildEvent{at: *sub.CompletedAt, typ: session.EventSubagentCompleted, state: s, sub: sub})
+			}
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		if !events[i].at.Equal(events[j].at) {
+			return events[i].at.Before(events[j].at)
+		}
+		return events[i].typ < events[j].typ
+	})
+
+	var seasonStart *SeasonStart
+	if prev != nil && prev.SeasonStart != nil {
+		seasonStart = &SeasonStart{At: prev.SeasonStart.At}
+	}
+	measured := false
+
+	active := 0
+	for i := 0; i < len(events); i++ {
+		ev := events[i]
+		if seasonStart != nil && !measured && !ev.at.Before(seasonStart.At) {
+			seasonStart.Totals = totalsOf(t.stats)
+			measured = true
+		}
+		switch ev.typ {
+		case session.EventNew:
+			active++
+		case session.EventTerminal:
+			active--
+		}
+		t.now = func() time.Time { return ev.at }
+		t.processEvent(session.Event{Type: ev.typ, State: ev.state, Subagent: ev.sub, ActiveCount: active})
+	}
+
+	if seasonStart != nil && !measured {
+		seasonStart.Totals = totalsOf(t.stats)
+	}
+
+	stats := t.stats
+	stats.SeasonStart = seasonStart
+	if prev != nil {
+		carryOver(stats, prev)
+		// Heat stats carried over may complete achievements on their own.
+		for _, a := range t.achieveEngine.Evaluate(stats) {
+			awardXP(&stats.BattlePass, AchievementXP(a.Tier))
+		}
+	}
+	return stats
+}
+
+// rebuildEvent is a session event Rebuild replays at the time it happened.
+type rebuildEvent struct {
+	at    time.Time
+	typ   session.EventType
+	state *session.SessionState
+	sub   *session.SubagentState // for subagent events
+}
+
+// carryOver copies into rebuilt what session history can't recompute.
+func carryOver(rebuilt, prev *Stats) {
+	rebuilt.HeatsRaced = prev.HeatsRaced
+	rebuilt.HeatWinsPerModel = make(map[string]int, len(prev.HeatWinsPerModel))
+	for k, v := range prev.HeatWinsPerModel {
+		rebuilt.HeatWinsPerModel[k] = v
+	}
+	rebuilt.HeatHistory = append([]HeatResult(nil), prev.HeatHistory...)
+	rebuilt.RacesWon = prev.RacesWon
+	rebuilt.LargestFieldWon = prev.LargestFieldWon
+
+	rebuilt.Equipped = prev.Equipped
+	for id, season := range prev.SeasonRewards {
+		rebuilt.SeasonRewards[id] = season
+	}
+	rebuilt.ArchivedSeasons = append([]ArchivedSeason(nil), prev.ArchivedSeasons...)
+	prevCopy := prev.clone()
+	rebuilt.WeeklyChallenges = prevCopy.WeeklyChallenges
+	rebuilt.DailyQuests = prevCopy.DailyQuests
+
+	for id, at := range prev.AchievementsAcknowledged {
+		rebuilt.AchievementsAcknowledged[id] = at
+	}
+	for id, at := range prev.AchievementsUnlocked {
+		if was, ok := rebuilt.AchievementsUnlocked[id]; !ok || at.Before(was) {
+			rebuilt.AchievementsUnlocked[id] = at
+		}
+	}
+
+	bp := BattlePass{Season: prev.BattlePass.Season}
+	awardXP(&bp, max(rebuilt.BattlePass.XP, prev.BattlePass.XP))
+	rebuilt.BattlePass = bp
+}
@@ -0,0 +1,101 @@
+package gamification
+
+import (
+	"testing"
+	"time"
+
+	"github.com/agent-racer/backend/internal/session"
+)
+
+func pastSession(id string, activity session.Activity, start time.Time, dur time.Duration) *session.SessionState {
+	end := start.Add(dur)
+	s := &session.SessionState{
+		ID:             id,
+		Source:         "claude",
+		Model:          "claude-sonnet-4-5",
+		Activity:       activity,
+		StartedAt:      start,
+		LastActivityAt: end,
+		MessageCount:   12,
+		ToolCallCount:  4,
+	}
+	if s.IsTerminal() {
+		s.CompletedAt = &end
+	}
+	return s
+}
+
+func TestRebuild(t *testing.T) {
+	start := time.Date(2026, 2, 2, 9, 0, 0, 0, time.UTC) // a Monday
+	sessions := []*session.SessionState{
+		pastSession("a", session.Complete, start, time.Hour),
+		pastSession("b", session.Errored, start.Add(10*time.Minute), 5*time.Minute),
+		pastSession("c", session.Complete, start.Add(12*time.Minute), 30*time.Minute),
+		{ID: "no-start", Activity: session.Complete},
+	}
+
+	done := start.Add(20 * time.Minute)
+	sessions[0].Subagents = []session.SubagentState{
+		{ID: "sub-1", StartedAt: start.Add(time.Minute), MessageCount: 3, CompletedAt: &done},
+		{ID: "sub-2", StartedAt: start.Add(2 * time.Minute), MessageCount: 1},
+		{ID: "phantom", StartedAt: start.Add(3 * time.Minute)},
+	}
+
+	stats := Rebuild(sessions, nil, time.UTC, nil, GrindLimits{})
+	if stats.TotalSubagents != 2 || stats.SubagentsCompleted != 1 {
+		t.Errorf("TotalSubagents = %d, SubagentsCompleted = %d, want 2 and 1", stats.TotalSubagents, stats.SubagentsCompleted)
+	}
+	if stats.TotalSessions != 3 || stats.TotalCompletions != 2 || stats.TotalErrors != 1 {
+		t.Errorf("sessions = %d, completions = %d, errors = %d; want 3, 2, 1",
+			stats.TotalSessions, stats.TotalCompletions, stats.TotalErrors)
+	}
+	if stats.MaxConcurrentActive != 3 {
+		t.Errorf("MaxConcurrentActive = %d, want 3", stats.MaxConcurrentActive)
+	}
+	if stats.MaxSessionDurationSec != time.Hour.Seconds() {
+		t.Errorf("MaxSessionDurationSec = %v, want an hour", stats.MaxSessionDurationSec)
+	}
+	if stats.Heatmap.Starts[time.Monday][9] != 3 || stats.Heatmap.Completions[time.Monday][9] != 1 || stats.Heatmap.Completions[time.Monday][10] != 1 {
+		t.Errorf("heatmap = %+v", stats.Heatmap)
+	}
+	if _, ok := stats.AchievementsUnlocked["first_lap"]; !ok {
+		t.Errorf("achievements = %v, want first_lap", stats.AchievementsUnlocked)
+	}
+	if stats.BattlePass.XP == 0 {
+		t.Error("no battle pass XP for rebuilt sessions")
+	}
+}
+
+func TestRebuild_CarriesOverWhatHistoryLacks(t *testing.T) {
+	start := time.Date(2026, 2, 2, 9, 0, 0, 0, time.UTC)
+	unlockedAt := start.Add(-24 * time.Hour)
+	prev := newStats()
+	prev.HeatsRaced, prev.RacesWon, prev.LargestFieldWon = 4, 2, 3
+	prev.HeatWinsPerModel["claude-opus-4-5"] = 2
+	prev.Equipped.Paint = "paint_gold"
+	prev.BattlePass = BattlePass{Season: "2026-02", Tier: 9, XP: 8500}
+	prev.AchievementsUnlocked["first_lap"] = unlockedAt
+
+	stats := Rebuild([]*session.SessionState{pastSession("a", session.Complete, start, time.Minute)}, prev, time.UTC, nil, GrindLimits{})
+
+	if stats.HeatsRaced != 4 || stats.RacesWon != 2 || stats.HeatWinsPerModel["claude-opus-4-5"] != 2 {
+		t.Errorf("heats = %d raced, %d won, %v", stats.HeatsRaced, stats.RacesWon, stats.HeatWinsPerModel)
+	}
+	if stats.Equipped.Paint != "paint_gold" {
+		t.Errorf("Equipped = %+v", stats.Equipped)
+	}
+	if !stats.AchievementsUnlocked["first_lap"].Equal(unlockedAt) {
+		t.Errorf("first_lap unlocked at %v, want the original %v", stats.AchievementsUnlocked["first_lap"], unlockedAt)
+	}
+	if _, ok := stats.AchievementsUnlocked["three_way_win"]; !ok {
+		t.Error("carried-over heat wins did not unlock three_way_win")
+	}
+	if stats.BattlePass.Season != "2026-02" || stats.BattlePass.XP < 8500 || stats.BattlePass.Tier != 9 {
+		t.Errorf("BattlePass = %+v, want season kept and no XP lost", stats.BattlePass)
+	}
+	// The rebuild must not alias prev.
+	stats.HeatWinsPerModel["claude-opus-4-5"] = 9
+	if prev.HeatWinsPerModel["claude-opus-4-5"] != 2 {
+		t.Error("Rebuild shares HeatWinsPerModel with prev")
+	}
+}
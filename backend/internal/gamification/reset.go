@@ -0,0 +1,108 @@
+package gamification
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ResetScope names the part of the stats a reset clears.
+type ResetScope string
+
+const (
+	// ResetAll clears every stat, achievement, unlock and archived season.
+	// The season label is kept.
+	ResetAll ResetScope = "all"
+	// ResetChallenges restarts this week's challenges and today's quests,
+	// including the quest streak.
+	ResetChallenges ResetScope = "challenges"
+	// ResetBattlePass puts the current season back to tier 0 with no XP.
+	// Archived seasons and the rewards they granted are kept.
+	ResetBattlePass ResetScope = "battle_pass"
+	// ResetPeaks clears the all-time highs, such as the longest session
+	// and the highest burn rate.
+	ResetPeaks ResetScope = "peaks"
+)
+
+// ResetScopes lists every scope Reset accepts.
+var ResetScopes = []ResetScope{ResetAll, ResetChallenges, ResetBattlePass, ResetPeaks}
+
+// ErrUnknownResetScope is returned when Reset receives a scope that is not
+// one of ResetScopes.
+var ErrUnknownResetScope = errors.New("unknown reset scope")
+
+// ValidResetScope reports whether scope is one of ResetScopes.
+func ValidResetScope(scope ResetScope) bool {
+	for i := 0; i < len(ResetScopes); i++ {
+		if ResetScopes[i] == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Reset clears the stats in scope and persists the result immediately.
+// Resets that touch the battle pass report the new progress to the
+// OnBattlePassProgress callback. If the save fails the reset still holds
+// in memory and is saved again with the next change; the save error is
+// returned. It is safe for concurrent use.
+func (t *StatsTracker) Reset(scope ResetScope) error {
+	if !ValidResetScope(scope) {
+		return fmt.Errorf("%w: %s", ErrUnknownResetScope, scope)
+	}
+	now := t.now()
+
+	t.mu.Lock()
+	switch scope {
+	case ResetAll:
+		fresh := newStats()
+		fresh.BattlePass.Season = t.stats.BattlePass.Season
+		fresh.SeasonStart = &SeasonStart{At: now.UTC()}
+		t.stats = fresh
+		t.resetGoalsLocked(now)
+	case ResetChallenges:
+		t.resetGoalsLocked(now)
+	case ResetBattlePass:
+		t.stats.BattlePass = BattlePass{Season: t.stats.BattlePass.Season}
+		t.stats.SeasonStart = &SeasonStart{At: now.UTC(), Totals: totalsOf(t.stats)}
+	case ResetPeaks:
+		st := t.stats
+		st.MaxContextUtilization = 0
+		st.MaxBurnRate = 0
+		st.MaxConcurrentActive = 0
+		st.MaxHighUtilizationSimultaneous = 0
+		st.MaxToolCalls = 0
+		st.MaxMessages = 0
+		st.MaxSessionDurationSec = 0
+		st.PhotoFinishSeen = false
+	}
+	t.stats.LastUpdated = now
+	t.dirty = false
+	stats := t.stats.clone()
+	progress := getProgress(&t.stats.BattlePass)
+	t.mu.Unlock()
+
+	saveErr := t.persist.Save(stats)
+	if saveErr != nil {
+		t.mu.Lock()
+		t.dirty = true
+		t.mu.Unlock()
+	}
+	if (scope == ResetAll || scope == ResetBattlePass) && t.onBattlePass != nil {
+		t.onBattlePass(progress, nil)
+	}
+	if saveErr != nil {
+		return fmt.Errorf("saving stats after %s reset: %w", scope, saveErr)
+	}
+	return nil
+}
+
+// resetGoalsLocked starts the weekly challenges and daily quests over, as
+// if the week and day had just begun. Caller must hold t.mu.
+func (t *StatsTracker) resetGoalsLocked(now time.Time) {
+	t.stats.WeeklyChallenges = WeeklyChallengeState{}
+	RotateChallengesIfNeeded(&t.stats.WeeklyChallenges, now)
+	t.stats.DailyQuests = DailyQuestState{}
+	initDailyQuestState(&t.stats.DailyQuests)
+	RotateQuestsIfNeeded(&t.stats.DailyQuests, now, t.location())
+}
@@ -0,0 +1,144 @@
+package gamification
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newResetTracker returns a tracker over stats with progress in every
+// section a reset can clear.
+func newResetTracker(t *testing.T) (*StatsTracker, *Store) {
+	t.Helper()
+	store := NewStore(t.TempDir())
+	st := newStats()
+	st.TotalSessions = 40
+	st.MaxBurnRate = 1200
+	st.MaxSessionDurationSec = 7200
+	st.PhotoFinishSeen = true
+	st.BattlePass = BattlePass{Season: "2026-03", Tier: 4, XP: 3400}
+	st.ArchivedSeasons = []ArchivedSeason{{Season: "2026-02", Tier: 6, XP: 5100}}
+	st.SeasonRewards["spark_trail"] = "2026-02"
+	st.AchievementsUnlocked["first_lap"] = time.Now().Add(-time.Hour)
+	RotateChallengesIfNeeded(&st.WeeklyChallenges, time.Now())
+	st.WeeklyChallenges.Snapshot.TotalCompletions = 7
+	st.DailyQuests.Streak = 5
+	st.DailyQuests.LongestStreak = 9
+	if err := store.Save(st); err != nil {
+		t.Fatal(err)
+	}
+	tracker, _, err := NewStatsTracker(store, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tracker, store
+}
+
+func TestReset_Peaks(t *testing.T) {
+	tracker, store := newResetTracker(t)
+	if err := tracker.Reset(ResetPeaks); err != nil {
+		t.Fatal(err)
+	}
+	st, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.MaxBurnRate != 0 || st.MaxSessionDurationSec != 0 || st.PhotoFinishSeen {
+		t.Errorf("peaks survived the reset: burn rate %v, duration %v, photo finish %v", st.MaxBurnRate, st.MaxSessionDurationSec, st.PhotoFinishSeen)
+	}
+	if st.TotalSessions != 40 || st.BattlePass.Tier != 4 {
+		t.Errorf("a peaks reset changed totals or the battle pass: %d sessions, tier %d", st.TotalSessions, st.BattlePass.Tier)
+	}
+}
+
+func TestReset_BattlePass(t *testing.T) {
+	tracker, _ := newResetTracker(t)
+	var got *BattlePassProgress
+	tracker.OnBattlePassProgress(func(p BattlePassProgress, _ []XPEntry) { got = &p })
+
+	if err := tracker.Reset(ResetBattlePass); err != nil {
+		t.Fatal(err)
+	}
+	st := tracker.Stats()
+	if st.BattlePass != (BattlePass{Season: "2026-03"}) {
+		t.Errorf("battle pass = %+v, want 2026-03 from scratch", st.BattlePass)
+	}
+	if len(st.ArchivedSeasons) != 1 || st.SeasonRewards["spark_trail"] == "" {
+		t.Error("a battle pass reset dropped archived seasons or their rewards")
+	}
+	if got == nil || got.XP != 0 {
+		t.Errorf("progress callback = %+v, want it told of 0 XP", got)
+	}
+	if totals := tracker.Seasons().Current.Totals; totals == nil || totals.Sessions != 0 {
+		t.Errorf("season totals = %+v, want the season measured from the reset", totals)
+	}
+}
+
+func TestReset_Challenges(t *testing.T) {
+	tracker, _ := newResetTracker(t)
+	if err := tracker.Reset(ResetChallenges); err != nil {
+		t.Fatal(err)
+	}
+	st := tracker.Stats()
+	if st.WeeklyChallenges.Snapshot.TotalCompletions != 0 || len(st.WeeklyChallenges.ActiveIDs) == 0 {
+		t.Errorf("weekly challenges = %+v, want this week's set with no progress", st.WeeklyChallenges)
+	}
+	if st.DailyQuests.Streak != 0 || st.DailyQuests.LongestStreak != 0 || len(st.DailyQuests.ActiveIDs) == 0 {
+		t.Errorf("daily quests = %+v, want today's set with no streak", st.DailyQuests)
+	}
+	if st.BattlePass.Tier != 4 {
+		t.Errorf("a challenges reset changed the battle pass: tier %d", st.BattlePass.Tier)
+	}
+}
+
+func TestReset_All(t *testing.T) {
+	tracker, store := newResetTracker(t)
+	if err := tracker.Reset(ResetAll); err != nil {
+		t.Fatal(err)
+	}
+	st, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.TotalSessions != 0 || len(st.AchievementsUnlocked) != 0 || len(st.ArchivedSeasons) != 0 || len(st.SeasonRewards) != 0 {
+		t.Errorf("full reset left %d sessions, %d achievements, %d seasons and %d rewards",
+			st.TotalSessions, len(st.AchievementsUnlocked), len(st.ArchivedSeasons), len(st.SeasonRewards))
+	}
+	if st.BattlePass != (BattlePass{Season: "2026-03"}) {
+		t.Errorf("battle pass = %+v, want the season label kept", st.BattlePass)
+	}
+	if len(st.WeeklyChallenges.ActiveIDs) == 0 || len(st.DailyQuests.ActiveIDs) == 0 {
+		t.Error("full reset left no challenges or quests to work on")
+	}
+}
+
+func TestReset_ReturnsSaveError(t *testing.T) {
+	tracker, store := newResetTracker(t)
+	// A file where the stats directory should be can't be written to, even
+	// by root.
+	if err := os.RemoveAll(filepath.Dir(store.Path())); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Dir(store.Path()), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tracker.Reset(ResetPeaks); err == nil {
+		t.Fatal("Reset with an unwritable stats path returned nil")
+	}
+	if tracker.Stats().MaxBurnRate != 0 {
+		t.Error("reset was not applied in memory")
+	}
+	if !tracker.dirty {
+		t.Error("unsaved reset not left dirty for the next save")
+	}
+}
+
+func TestReset_UnknownScope(t *testing.T) {
+	tracker, _ := newResetTracker(t)
+	if err := tracker.Reset("heatmap"); !errors.Is(err, ErrUnknownResetScope) {
+		t.Errorf("Reset(heatmap) = %v, want ErrUnknownResetScope", err)
+	}
+}
@@ -80,14 +80,17 @@ func (r *RewardRegistry) RewardForAchievement(achievementID string) (Reward, boo
 }
 
 // IsUnlocked reports whether the player has earned the named reward.
-// A reward is earned when its UnlockedBy achievement appears in
-// stats.AchievementsUnlocked, or — for battle pass rewards — when the
-// player's tier has reached the tier that grants it.
+// A reward is earned when a past season granted it, when its UnlockedBy
+// achievement appears in stats.AchievementsUnlocked, or — for battle pass
+// rewards — when the player's tier has reached the tier that grants it.
 func (r *RewardRegistry) IsUnlocked(rewardID string, stats *Stats) bool {
 	rw, ok := r.rewards[rewardID]
 	if !ok {
 		return false
 	}
+	if _, granted := stats.SeasonRewards[rewardID]; granted {
+		return true
+	}
 	if rw.UnlockedBy != "" {
 		_, earned := stats.AchievementsUnlocked[rw.UnlockedBy]
 		return earned
@@ -0,0 +1,169 @@
+package gamification
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"time"
+)
+
+// SeasonTotals are the lifetime counters a battle pass season is measured
+// by. A season's totals are the difference between them at its start and
+// at its end.
+type SeasonTotals struct {
+	Sessions     int     `json:"sessions"`
+	Completions  int     `json:"completions"`
+	Errors       int     `json:"errors"`
+	TokensBurned int     `json:"tokensBurned"`
+	EnergyWh     float64 `json:"energyWh"`
+	Achievements int     `json:"achievements"` // unlocked
+}
+
+// SeasonStart records when the current season began and the lifetime
+// totals at that moment.
+type SeasonStart struct {
+	At     time.Time    `json:"at"`
+	Totals SeasonTotals `json:"totals"`
+}
+
+// CurrentSeason is the season in progress as /api/gamification/seasons
+// reports it.
+type CurrentSeason struct {
+	Season  string        `json:"season"`
+	Tier    int           `json:"tier"`
+	XP      int           `json:"xp"`
+	Started string        `json:"started,omitempty"` // RFC 3339 timestamp
+	Totals  *SeasonTotals `json:"totals,omitempty"`  // so far
+}
+
+// SeasonHistory is the JSON shape of /api/gamification/seasons.
+type SeasonHistory struct {
+	Current  CurrentSeason    `json:"current"`
+	Archived []ArchivedSeason `json:"archived"` // newest first
+}
+
+// SeasonEndRules decide what a battle pass season leaves the player when
+// it ends. The zero value leaves nothing: the next season starts from
+// tier 0 and tier cosmetics are only usable while the tier is held.
+type SeasonEndRules struct {
+	// CarryoverPercent of the final XP, 0 to 100, starts the next season.
+	CarryoverPercent int `yaml:"carryover_percent"`
+	// KeepTierRewards grants the cosmetics of every tier the season
+	// reached for good.
+	KeepTierRewards bool `yaml:"keep_tier_rewards"`
+	// Rewards grants more cosmetics by final tier: a season that ends at
+	// or above a tier earns the reward IDs listed under it.
+	Rewards map[int][]string `yaml:"rewards"`
+}
+
+// Equal reports whether r and o are the same rules.
+func (r SeasonEndRules) Equal(o SeasonEndRules) bool {
+	return r.CarryoverPercent == o.CarryoverPercent && r.KeepTierRewards == o.KeepTierRewards &&
+		maps.EqualFunc(r.Rewards, o.Rewards, slices.Equal[[]string])
+}
+
+// ValidateSeasonEndRules returns one message per problem in r.
+func ValidateSeasonEndRules(r SeasonEndRules) []string {
+	var errs []string
+	if r.CarryoverPercent < 0 || r.CarryoverPercent > 100 {
+		errs = append(errs, fmt.Sprintf("carryover_percent: must be between 0 and 100, got %d", r.CarryoverPercent))
+	}
+	reg := NewRewardRegistry()
+	tiers := make([]int, 0, len(r.Rewards))
+	for tier := range r.Rewards {
+		tiers = append(tiers, tier)
+	}
+	sort.Ints(tiers)
+	for i := 0; i < len(tiers); i++ {
+		tier := tiers[i]
+		if tier < 1 || tier > maxTiers {
+			errs = append(errs, fmt.Sprintf("rewards: tier must be between 1 and %d, got %d", maxTiers, tier))
+		}
+		ids := r.Rewards[tier]
+		for j := 0; j < len(ids); j++ {
+			if _, ok := reg.Lookup(ids[j]); !ok {
+				errs = append(errs, fmt.Sprintf("rewards: tier %d: unknown reward %q", tier, ids[j]))
+			}
+		}
+	}
+	return errs
+}
+
+// grants returns the reward IDs a season that ended at tier earns under
+// r, sorted and without repeats.
+func (r SeasonEndRules) grants(tier int) []string {
+	seen := make(map[string]bool)
+	if r.KeepTierRewards {
+		for t := 1; t <= min(tier, maxTiers); t++ {
+			ids := tierRewards(t)
+			for i := 0; i < len(ids); i++ {
+				seen[ids[i]] = true
+			}
+		}
+	}
+	for from, ids := range r.Rewards {
+		if tier < from {
+			continue
+		}
+		for i := 0; i < len(ids); i++ {
+			seen[ids[i]] = true
+		}
+	}
+	out := make([]string, 0, len(seen))
+	for id := range seen {
+		out = append(out, id)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// totalsOf reads the season counters from st.
+func totalsOf(st *Stats) SeasonTotals {
+	return SeasonTotals{
+		Sessions:     st.TotalSessions,
+		Completions:  st.TotalCompletions,
+		Errors:       st.TotalErrors,
+		TokensBurned: st.TotalTokensBurned,
+		EnergyWh:     st.TotalEnergyWh,
+		Achievements: len(st.AchievementsUnlocked),
+	}
+}
+
+// sub returns what was added to the counters between start and t.
+func (t SeasonTotals) sub(start SeasonTotals) SeasonTotals {
+	return SeasonTotals{
+		Sessions:     max(0, t.Sessions-start.Sessions),
+		Completions:  max(0, t.Completions-start.Completions),
+		Errors:       max(0, t.Errors-start.Errors),
+		TokensBurned: max(0, t.TokensBurned-start.TokensBurned),
+		EnergyWh:     max(0, t.EnergyWh-start.EnergyWh),
+		Achievements: max(0, t.Achievements-start.Achievements),
+	}
+}
+
+// Seasons returns the current battle pass season with its totals so far
+// and every archived season, newest first. It is safe for concurrent use.
+func (t *StatsTracker) Seasons() SeasonHistory {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	st := t.stats
+	h := SeasonHistory{
+		Current: CurrentSeason{
+			Season: st.BattlePass.Season,
+			Tier:   st.BattlePass.Tier,
+			XP:     st.BattlePass.XP,
+		},
+		Archived: make([]ArchivedSeason, 0, len(st.ArchivedSeasons)),
+	}
+	if start := st.SeasonStart; start != nil {
+		h.Current.Started = start.At.UTC().Format(time.RFC3339)
+		totals := totalsOf(st).sub(start.Totals)
+		h.Current.Totals = &totals
+	}
+	for i := len(st.ArchivedSeasons) - 1; i >= 0; i-- {
+		h.Archived = append(h.Archived, st.ArchivedSeasons[i])
+	}
+	return h
+}
@@ -0,0 +1,138 @@
+package gamification
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/agent-racer/backend/internal/session"
+)
+
+func TestSeasonRotation_ArchivesSeasonTotals(t *testing.T) {
+	store := NewStore(t.TempDir())
+	began := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	initial := newStats()
+	initial.BattlePass = BattlePass{Season: "2025-06", Tier: 3, XP: 2400}
+	initial.TotalSessions, initial.TotalCompletions, initial.TotalTokensBurned = 12, 9, 800_000
+	initial.AchievementsUnlocked["first_lap"] = began.Add(time.Hour)
+	initial.SeasonStart = &SeasonStart{At: began, Totals: SeasonTotals{Sessions: 4, Completions: 3, TokensBurned: 300_000}}
+	if err := store.Save(initial); err != nil {
+		t.Fatal(err)
+	}
+
+	tracker, _, err := NewStatsTracker(store, 0, &SeasonConfig{Enabled: true, Season: "2025-07"})
+	if err != nil {
+		t.Fatalf("NewStatsTracker: %v", err)
+	}
+	h := tracker.Seasons()
+	if len(h.Archived) != 1 {
+		t.Fatalf("archived %d seasons, want 1", len(h.Archived))
+	}
+	got := h.Archived[0]
+	want := SeasonTotals{Sessions: 8, Completions: 6, TokensBurned: 500_000, Achievements: 1}
+	if got.Started != "2025-06-01T00:00:00Z" || got.Totals == nil || *got.Totals != want {
+		t.Errorf("archived = %+v with totals %+v, want started 2025-06-01 and %+v", got, got.Totals, want)
+	}
+	if h.Current.Season != "2025-07" || h.Current.Started == "" || h.Current.Totals == nil || *h.Current.Totals != (SeasonTotals{}) {
+		t.Errorf("current = %+v, want 2025-07 just started with nothing counted", h.Current)
+	}
+
+	tracker.processEvent(session.Event{Type: session.EventNew, State: &session.SessionState{ID: "s1", Source: "claude"}})
+	if got := tracker.Seasons().Current.Totals; got == nil || got.Sessions != 1 {
+		t.Errorf("current totals after a session = %+v, want 1 session", got)
+	}
+}
+
+func TestSeasonRotation_LegacySeasonHasNoTotals(t *testing.T) {
+	st := newStats()
+	st.BattlePass = BattlePass{Season: "2025-06", Tier: 2, XP: 1200}
+	st.ArchivedSeasons = []ArchivedSeason{{Season: "2025-05", Tier: 1, XP: 300}}
+	if !rotateSeason(st, "2025-07", SeasonEndRules{}) {
+		t.Fatal("season did not rotate")
+	}
+
+	h := newTracker(st).Seasons()
+	if len(h.Archived) != 2 || h.Archived[0].Season != "2025-06" || h.Archived[1].Season != "2025-05" {
+		t.Fatalf("archived = %+v, want 2025-06 then 2025-05", h.Archived)
+	}
+	if h.Archived[0].Totals != nil || h.Archived[0].Started != "" {
+		t.Errorf("a season begun without a recorded start has totals %+v", h.Archived[0].Totals)
+	}
+}
+
+func TestRebuild_MeasuresSeasonFromItsStart(t *testing.T) {
+	start := time.Date(2026, 2, 2, 9, 0, 0, 0, time.UTC)
+	sessions := []*session.SessionState{
+		pastSession("a", session.Complete, start, time.Hour),
+		pastSession("b", session.Complete, start.Add(24*time.Hour), time.Hour),
+		pastSession("c", session.Errored, start.Add(48*time.Hour), time.Hour),
+	}
+	prev := newStats()
+	prev.BattlePass = BattlePass{Season: "2026-02"}
+	prev.SeasonStart = &SeasonStart{At: start.Add(12 * time.Hour)}
+
+	got := Rebuild(sessions, prev, time.UTC, nil, GrindLimits{})
+	if got.SeasonStart == nil || !got.SeasonStart.At.Equal(prev.SeasonStart.At) {
+		t.Fatalf("SeasonStart = %+v, want it kept from prev", got.SeasonStart)
+	}
+	totals := newTracker(got).Seasons().Current.Totals
+	if totals == nil || totals.Sessions != 2 || totals.Completions != 1 || totals.Errors != 1 {
+		t.Errorf("season totals = %+v, want the 2 sessions since the season started", totals)
+	}
+}
+
+func TestSeasonRotation_EndRewardsAndCarryover(t *testing.T) {
+	st := newStats()
+	st.BattlePass = BattlePass{Season: "2025-06", Tier: 3, XP: 2400}
+	st.SeasonRewards["bronze_badge"] = "2025-05"
+	end := SeasonEndRules{
+		CarryoverPercent: 50,
+		KeepTierRewards:  true,
+		Rewards:          map[int][]string{3: {"flame_trail"}, 8: {"aero_body"}},
+	}
+	if !rotateSeason(st, "2025-07", end) {
+		t.Fatal("season did not rotate")
+	}
+
+	archived := st.ArchivedSeasons[0]
+	if want := []string{"flame_trail", "spark_trail"}; !slices.Equal(archived.Rewards, want) {
+		t.Errorf("granted %v, want %v and not the bronze badge kept from 2025-05", archived.Rewards, want)
+	}
+	if st.SeasonRewards["spark_trail"] != "2025-06" || st.SeasonRewards["bronze_badge"] != "2025-05" {
+		t.Errorf("season rewards = %v", st.SeasonRewards)
+	}
+	if archived.CarriedXP != 1200 || st.BattlePass != (BattlePass{Season: "2025-07", Tier: 2, XP: 1200}) {
+		t.Errorf("carried %d XP into %+v, want 1200 XP at tier 2", archived.CarriedXP, st.BattlePass)
+	}
+
+	reg := NewRewardRegistry()
+	if !reg.IsUnlocked("flame_trail", st) || reg.IsUnlocked("aero_body", st) {
+		t.Error("flame_trail should stay unlocked after the season and aero_body was never earned")
+	}
+}
+
+func TestSeasonRotation_ZeroRulesResetEverything(t *testing.T) {
+	st := newStats()
+	st.BattlePass = BattlePass{Season: "2025-06", Tier: 3, XP: 2400}
+	rotateSeason(st, "2025-07", SeasonEndRules{})
+
+	if st.BattlePass != (BattlePass{Season: "2025-07"}) || len(st.SeasonRewards) != 0 {
+		t.Errorf("battle pass = %+v, rewards = %v; want a clean start", st.BattlePass, st.SeasonRewards)
+	}
+	if NewRewardRegistry().IsUnlocked("spark_trail", st) {
+		t.Error("spark_trail is unlocked after its tier was reset")
+	}
+}
+
+func TestValidateSeasonEndRules(t *testing.T) {
+	errs := ValidateSeasonEndRules(SeasonEndRules{
+		CarryoverPercent: 120,
+		Rewards:          map[int][]string{0: {"gold_badge"}, 5: {"chrome_wheels"}},
+	})
+	if len(errs) != 3 {
+		t.Errorf("errors = %q, want carryover, tier and reward problems", errs)
+	}
+	if errs := ValidateSeasonEndRules(SeasonEndRules{CarryoverPercent: 25, Rewards: map[int][]string{10: {"gold_badge"}}}); len(errs) != 0 {
+		t.Errorf("valid rules rejected: %q", errs)
+	}
+}
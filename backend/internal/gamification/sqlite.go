@@ -0,0 +1,97 @@
+package gamification
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	_ "modernc.org/sqlite" // registers the "sqlite" driver
+)
+
+const sqliteFileName = "stats.db"
+
+// sqliteSchema holds one row of stats, as the same JSON document Store
+// writes, so both backends share the Stats migrations in UnmarshalJSON.
+const sqliteSchema = `CREATE TABLE IF NOT EXISTS stats (
+	id         INTEGER PRIMARY KEY CHECK (id = 1),
+	version    INTEGER NOT NULL,
+	data       TEXT NOT NULL,
+	updated_at TEXT NOT NULL
+)`
+
+// SQLiteStore keeps stats in a SQLite database. Writes are transactional,
+// so a crash leaves either the old stats or the new ones. The driver is
+// pure Go, so it works in CGO_ENABLED=0 builds such as the Docker image.
+type SQLiteStore struct {
+	path string
+	db   *sql.DB
+}
+
+// OpenSQLite opens or creates the stats database at path, creating its
+// directory if needed.
+func OpenSQLite(path string) (*SQLiteStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("creating stats dir: %w", err)
+	}
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, fmt.Errorf("opening stats database: %w", err)
+	}
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("opening stats database %s: %w", path, err)
+	}
+	if err := os.Chmod(path, 0o600); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("opening stats database %s: %w", path, err)
+	}
+	return &SQLiteStore{path: path, db: db}, nil
+}
+
+// Path returns the database file.
+func (s *SQLiteStore) Path() string { return s.path }
+
+// Load reads stats from the database, or returns empty stats if none have
+// been saved.
+func (s *SQLiteStore) Load() (*Stats, error) {
+	var data string
+	err := s.db.QueryRow(`SELECT data FROM stats WHERE id = 1`).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return newStats(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading stats: %w", err)
+	}
+
+	var st Stats
+	if err := json.Unmarshal([]byte(data), &st); err != nil {
+		return nil, fmt.Errorf("parsing stats: %w", err)
+	}
+	st.initMaps()
+	return &st, nil
+}
+
+// Save writes st to the database, replacing what was there.
+func (s *SQLiteStore) Save(st *Stats) error {
+	st.Version = statsVersion
+	st.LastUpdated = time.Now().UTC()
+
+	data, err := json.Marshal(st)
+	if err != nil {
+		return fmt.Errorf("marshaling stats: %w", err)
+	}
+	_, err = s.db.Exec(`INSERT INTO stats (id, version, data, updated_at) VALUES (1, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET version = excluded.version, data = excluded.data, updated_at = excluded.updated_at`,
+		st.Version, string(data), st.LastUpdated.Format(time.RFC3339Nano))
+	if err != nil {
+		return fmt.Errorf("writing stats: %w", err)
+	}
+	return nil
+}
+
+// Close closes the database.
+func (s *SQLiteStore) Close() error { return s.db.Close() }
@@ -0,0 +1,114 @@
+package gamification
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestSQLiteStore_SaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "stats.db")
+	s, err := OpenSQLite(path)
+	if err != nil {
+		t.Fatalf("OpenSQLite: %v", err)
+	}
+	defer func() { _ = s.Close() }()
+
+	empty, err := s.Load()
+	if err != nil || empty.TotalSessions != 0 || empty.SessionsPerModel == nil {
+		t.Fatalf("Load() on a new database = %+v, %v", empty, err)
+	}
+
+	st := newStats()
+	st.TotalSessions = 42
+	st.SessionsPerModel["claude-opus-4-5"] = 7
+	st.BattlePass = BattlePass{Season: "2026-03", Tier: 3, XP: 2100}
+	st.Heatmap.Starts[2][14] = 5
+	if err := s.Save(st); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	st.TotalSessions = 43
+	if err := s.Save(st); err != nil {
+		t.Fatalf("second Save: %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err = OpenSQLite(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	loaded, err := s.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if loaded.TotalSessions != 43 || loaded.SessionsPerModel["claude-opus-4-5"] != 7 ||
+		loaded.BattlePass != st.BattlePass || loaded.Heatmap.Starts[2][14] != 5 || loaded.Version != statsVersion {
+		t.Errorf("loaded = %+v", loaded)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("database mode = %v, %v; want 0600", info, err)
+	}
+}
+
+func TestSQLiteStore_ConcurrentSaves(t *testing.T) {
+	s, err := OpenSQLite(filepath.Join(t.TempDir(), "stats.db"))
+	if err != nil {
+		t.Fatalf("OpenSQLite: %v", err)
+	}
+	defer func() { _ = s.Close() }()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			st := newStats()
+			st.TotalSessions = n
+			if err := s.Save(st); err != nil {
+				t.Errorf("Save %d: %v", n, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if _, err := s.Load(); err != nil {
+		t.Errorf("Load after concurrent saves: %v", err)
+	}
+}
+
+func TestOpenBackend(t *testing.T) {
+	dir := t.TempDir()
+	for _, tc := range []struct {
+		kind, path string
+		want       string
+	}{
+		{"", dir, filepath.Join(dir, statsFileName)},
+		{BackendJSON, dir, filepath.Join(dir, statsFileName)},
+		{BackendSQLite, filepath.Join(dir, "hub.db"), filepath.Join(dir, "hub.db")},
+	} {
+		b, err := OpenBackend(tc.kind, tc.path)
+		if err != nil {
+			t.Fatalf("OpenBackend(%q): %v", tc.kind, err)
+		}
+		if b.Path() != tc.want {
+			t.Errorf("OpenBackend(%q).Path() = %s, want %s", tc.kind, b.Path(), tc.want)
+		}
+		_ = b.Close()
+	}
+
+	t.Setenv("XDG_STATE_HOME", dir)
+	b, err := OpenBackend(BackendSQLite, "")
+	if err != nil {
+		t.Fatalf("OpenBackend(sqlite, default): %v", err)
+	}
+	if want := filepath.Join(dir, appDirName, sqliteFileName); b.Path() != want {
+		t.Errorf("default sqlite path = %s, want %s", b.Path(), want)
+	}
+	_ = b.Close()
+
+	if _, err := OpenBackend("postgres", ""); err == nil {
+		t.Error("OpenBackend(postgres) succeeded")
+	}
+}
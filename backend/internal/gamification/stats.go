@@ -3,6 +3,7 @@ package gamification
 import (
 	"context"
 	"log/slog"
+	"sort"
 	"sync"
 	"time"
 
@@ -32,37 +33,53 @@ type BattlePassCallback func(progress BattlePassProgress, recentXP []XPEntry)
 // It receives events from the monitor via a channel and periodically persists
 // the accumulated stats to disk.
 type StatsTracker struct {
-	persist           *Store
+	persist           Backend
 	stats             *Stats
 	events            chan session.Event
 	flushCh           chan chan struct{}
+	saveCh            chan chan struct{}
 	mu                sync.Mutex
 	dirty             bool
-	counted           map[string]bool  // session IDs already counted for TotalSessions
-	contextMilestones map[string]uint8 // session ID -> bitmask: bit0=50%, bit1=90%
-	lastTokens        map[string]int   // session ID -> last seen TokensUsed (for delta tracking)
-	highUtilSessions  map[string]bool  // session IDs currently at or above 50% context utilization
-	lastCompletionAt  time.Time        // tracks last completion time for photo_finish
-
-	achieveEngine  *AchievementEngine
-	rewardRegistry *RewardRegistry
-	onAchievement  AchievementCallback
-	onBattlePass   BattlePassCallback
+	released          bool                          // stats handed to another process; never saved again
+	counted           map[string]bool               // session IDs already counted for TotalSessions
+	contextMilestones map[string]uint8              // session ID -> bitmask: bit0=50%, bit1=90%
+	lastTokens        map[string]int                // session ID -> last seen TokensUsed (for delta tracking)
+	lastMCPCalls      map[string]map[string]int     // session ID -> last seen MCPToolCalls (for delta tracking)
+	lastToolCounts    map[string]map[string]int     // session ID -> last seen ToolCounts (for delta tracking)
+	lastCommands      map[string]map[string]int     // session ID -> last seen SlashCommands (for delta tracking)
+	lastHookEvents    map[string]int                // session ID -> last seen HookEventCount (for delta tracking)
+	lastModelSwitches map[string]int                // session ID -> last seen ModelSwitches (for delta tracking)
+	lastCache         map[string]session.CacheUsage // session ID -> last seen cache totals (for delta tracking)
+	lastEnergy        map[string]energyTotals       // session ID -> last seen energy estimate (for delta tracking)
+	highUtilSessions  map[string]bool               // session IDs currently at or above 50% context utilization
+	lastCompletionAt  time.Time                     // tracks last completion time for photo_finish
+	loc               *time.Location                // zone for heatmap buckets; nil is time.Local
+	limits            GrindLimits                   // XP caps against farming
+	observedHour      time.Time                     // clock hour observedXP counts within
+	observedXP        int                           // session_observed XP awarded in observedHour
+	now               func() time.Time              // when an event happened; time.Now except in Rebuild
+
+	achieveEngine    *AchievementEngine
+	toolAchievements []ToolAchievement
+	rewardRegistry   *RewardRegistry
+	onAchievement    AchievementCallback
+	onBattlePass     BattlePassCallback
 }
 
 // SeasonConfig controls which battle pass season is active.
 type SeasonConfig struct {
 	Enabled bool
 	Season  string // e.g. "2025-07"
+	End     SeasonEndRules
 }
 
-// NewStatsTracker creates a StatsTracker backed by the given persistence store.
+// NewStatsTracker creates a StatsTracker backed by the given persistence backend.
 // It loads existing stats from disk and returns a send-only channel for the
 // monitor to deliver events on. bufferSize controls the channel capacity;
 // values <= 0 use defaultEventBufferSize. If sc is non-nil and the configured
 // season differs from the persisted season, a season rotation is performed.
 // The caller must run Run in a goroutine.
-func NewStatsTracker(persist *Store, bufferSize int, sc *SeasonConfig) (*StatsTracker, chan<- session.Event, error) {
+func NewStatsTracker(persist Backend, bufferSize int, sc *SeasonConfig) (*StatsTracker, chan<- session.Event, error) {
 	if bufferSize <= 0 {
 		bufferSize = defaultEventBufferSize
 	}
@@ -72,7 +89,7 @@ func NewStatsTracker(persist *Store, bufferSize int, sc *SeasonConfig) (*StatsTr
 	}
 
 	if sc != nil && sc.Enabled {
-		if rotateSeason(stats, sc.Season) {
+		if rotateSeason(stats, sc.Season, sc.End) {
 			if err := persist.Save(stats); err != nil {
 				return nil, nil, err
 			}
@@ -85,42 +102,131 @@ func NewStatsTracker(persist *Store, bufferSize int, sc *SeasonConfig) (*StatsTr
 	RotateChallengesIfNeeded(&stats.WeeklyChallenges, time.Now())
 
 	ch := make(chan session.Event, bufferSize)
-	t := &StatsTracker{
-		persist:           persist,
+	t := newTracker(stats)
+	t.persist = persist
+	t.events = ch
+	t.flushCh = make(chan chan struct{})
+	t.saveCh = make(chan chan struct{})
+	return t, ch, nil
+}
+
+// newTracker returns a tracker that accumulates into stats, with nothing
+// to persist to or receive events from.
+func newTracker(stats *Stats) *StatsTracker {
+	return &StatsTracker{
 		stats:             stats,
-		events:            ch,
-		flushCh:           make(chan chan struct{}),
 		counted:           make(map[string]bool),
 		contextMilestones: make(map[string]uint8),
 		lastTokens:        make(map[string]int),
+		lastMCPCalls:      make(map[string]map[string]int),
+		lastToolCounts:    make(map[string]map[string]int),
+		lastCommands:      make(map[string]map[string]int),
+		lastHookEvents:    make(map[string]int),
+		lastModelSwitches: make(map[string]int),
+		lastCache:         make(map[string]session.CacheUsage),
+		lastEnergy:        make(map[string]energyTotals),
 		highUtilSessions:  make(map[string]bool),
 		achieveEngine:     NewAchievementEngine(),
 		rewardRegistry:    NewRewardRegistry(),
+		now:               time.Now,
 	}
-	return t, ch, nil
 }
 
 // rotateSeason checks if the configured season differs from the persisted one.
-// When it does, it archives the old season's XP/tier, resets the battle pass
-// to tier 0/XP 0 with the new season label, and returns true.
+// When it does, it archives the old season's XP/tier, grants its end
+// rewards, starts the battle pass over with the new season label and the
+// carried-over XP, and returns true.
 // Achievements and equipped cosmetics are left intact (permanent).
-func rotateSeason(stats *Stats, season string) bool {
+func rotateSeason(stats *Stats, season string, end SeasonEndRules) bool {
 	if stats.BattlePass.Season == season {
 		return false
 	}
+	now := time.Now().UTC()
+	carried := 0
 	// Only archive if there was a previous season with progress.
 	if stats.BattlePass.Season != "" && (stats.BattlePass.Tier > 0 || stats.BattlePass.XP > 0) {
-		stats.ArchivedSeasons = append(stats.ArchivedSeasons, ArchivedSeason{
+		archived := ArchivedSeason{
 			Season:   stats.BattlePass.Season,
 			Tier:     stats.BattlePass.Tier,
 			XP:       stats.BattlePass.XP,
-			Archived: time.Now().UTC().Format(time.RFC3339),
-		})
+			Archived: now.Format(time.RFC3339),
+		}
+		if start := stats.SeasonStart; start != nil {
+			archived.Started = start.At.UTC().Format(time.RFC3339)
+			totals := totalsOf(stats).sub(start.Totals)
+			archived.Totals = &totals
+		}
+		granted := end.grants(stats.BattlePass.Tier)
+		for i := 0; i < len(granted); i++ {
+			if _, ok := stats.SeasonRewards[granted[i]]; !ok {
+				stats.SeasonRewards[granted[i]] = archived.Season
+				archived.Rewards = append(archived.Rewards, granted[i])
+			}
+		}
+		archived.CarriedXP = stats.BattlePass.XP * min(max(end.CarryoverPercent, 0), 100) / 100
+		stats.ArchivedSeasons = append(stats.ArchivedSeasons, archived)
+		carried = archived.CarriedXP
 	}
 	stats.BattlePass = BattlePass{Season: season}
+	if carried > 0 {
+		awardXP(&stats.BattlePass, carried)
+	}
+	stats.SeasonStart = &SeasonStart{At: now, Totals: totalsOf(stats)}
 	return true
 }
 
+// SetToolAchievements adds the configured tool achievements to the ones
+// the tracker awards. Must be called before Run.
+func (t *StatsTracker) SetToolAchievements(tools []ToolAchievement) {
+	t.toolAchievements = tools
+	t.achieveEngine = NewAchievementEngine(tools...)
+}
+
+// Achievements returns every achievement the tracker can award, built-in
+// and configured.
+func (t *StatsTracker) Achievements() []Achievement {
+	return t.achieveEngine.Registry()
+}
+
+// AcknowledgeAchievements records that a client has shown the unlocks of
+// ids, or of every unlocked achievement when ids is empty, and persists the
+// change. It returns the IDs that were not acknowledged before; IDs that
+// are unknown or still locked are skipped. It is safe for concurrent use.
+func (t *StatsTracker) AcknowledgeAchievements(ids []string) []string {
+	t.mu.Lock()
+	if len(ids) == 0 {
+		ids = make([]string, 0, len(t.stats.AchievementsUnlocked))
+		for id := range t.stats.AchievementsUnlocked {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+	}
+	now := time.Now()
+	acked := []string{}
+	for i := 0; i < len(ids); i++ {
+		id := ids[i]
+		if _, ok := t.stats.AchievementsUnlocked[id]; !ok {
+			continue
+		}
+		if _, ok := t.stats.AchievementsAcknowledged[id]; ok {
+			continue
+		}
+		t.stats.AchievementsAcknowledged[id] = now
+		acked = append(acked, id)
+	}
+	if len(acked) == 0 {
+		t.mu.Unlock()
+		return acked
+	}
+	stats := t.stats.clone()
+	t.mu.Unlock()
+
+	if err := t.persist.Save(stats); err != nil {
+		slog.Error("failed to save stats after acknowledging achievements", "error", err)
+	}
+	return acked
+}
+
 // OnAchievement registers a callback invoked whenever an achievement unlocks.
 // Must be called before Run.
 func (t *StatsTracker) OnAchievement(cb AchievementCallback) {
@@ -149,6 +255,10 @@ func (t *StatsTracker) Run(ctx context.Context) {
 		case done := <-t.flushCh:
 			t.drainEvents()
 			close(done)
+		case done := <-t.saveCh:
+			t.drainEvents()
+			t.save()
+			close(done)
 		case <-ticker.C:
 			now := time.Now()
 			t.mu.Lock()
@@ -183,6 +293,25 @@ func (t *StatsTracker) Flush() {
 	<-done
 }
 
+// Save processes all queued events and writes stats to disk immediately,
+// e.g. before handing over to a new server process that will load them.
+// Like Flush, it requires Run to be running.
+func (t *StatsTracker) Save() {
+	done := make(chan struct{})
+	t.saveCh <- done
+	<-done
+}
+
+// Release stops the tracker writing stats, once a new server process has
+// loaded them after a handover. Events are still processed but no longer
+// saved, so the final save on shutdown cannot overwrite the new process's
+// stats.
+func (t *StatsTracker) Release() {
+	t.mu.Lock()
+	t.released = true
+	t.mu.Unlock()
+}
+
 // Stats returns a deep copy of the current aggregate stats.
 func (t *StatsTracker) Stats() *Stats {
 	t.mu.Lock()
@@ -203,7 +332,7 @@ func (t *StatsTracker) rotateChallengesLocked(now time.Time) bool {
 func (t *StatsTracker) processEvent(ev session.Event) {
 	var rotateNow time.Time
 	if ev.Type == session.EventTerminal {
-		rotateNow = time.Now()
+		rotateNow = t.now()
 	}
 
 	t.mu.Lock()
@@ -217,6 +346,10 @@ func (t *StatsTracker) processEvent(ev session.Event) {
 	}
 
 	wc := &t.stats.WeeklyChallenges
+	dq := &t.stats.DailyQuests
+	if RotateQuestsIfNeeded(dq, t.now(), t.location()) {
+		t.dirty = true
+	}
 
 	switch ev.Type {
 	case session.EventNew:
@@ -227,11 +360,18 @@ func (t *StatsTracker) processEvent(ev session.Event) {
 		t.counted[s.ID] = true
 		t.stats.TotalSessions++
 		t.stats.SessionsPerSource[s.Source]++
+		startedAt := s.StartedAt
+		if startedAt.IsZero() {
+			startedAt = t.now()
+		}
+		bucket(&t.stats.Heatmap.Starts, startedAt, t.location())
 		t.stats.DistinctSourcesUsed = len(t.stats.SessionsPerSource)
 		if ev.ActiveCount > t.stats.MaxConcurrentActive {
 			t.stats.MaxConcurrentActive = ev.ActiveCount
 		}
-		trackXP("session_observed", XPSessionObserved)
+		if xp := t.observedXPLocked(t.now()); xp > 0 {
+			trackXP("session_observed", xp)
+		}
 		if t.stats.SessionsPerSource[s.Source] == 1 {
 			trackXP("new_source", XPNewSource)
 		}
@@ -239,6 +379,7 @@ func (t *StatsTracker) processEvent(ev session.Event) {
 		// Weekly challenge: count new session and source.
 		wc.Snapshot.TotalSessions++
 		wc.Snapshot.SessionsPerSource[s.Source]++
+		dq.Snapshot.SessionsStarted++
 
 	case session.EventUpdate:
 		if s.ContextUtilization > t.stats.MaxContextUtilization {
@@ -270,15 +411,22 @@ func (t *StatsTracker) processEvent(ev session.Event) {
 			trackXP("context_50pct", XPContext50Pct)
 			t.contextMilestones[s.ID] = mask | 0x01
 		}
+		if s.ContextUtilization >= 0.95 && mask&0x04 == 0 {
+			t.contextMilestones[s.ID] |= 0x04
+			dq.Snapshot.Context95Count++
+		}
 
-		// Weekly challenge: accumulate token delta (TokensUsed is cumulative).
+		// Token totals: accumulate the delta (TokensUsed is cumulative).
 		if s.TokensUsed > 0 {
 			prev := t.lastTokens[s.ID]
 			if delta := s.TokensUsed - prev; delta > 0 {
+				t.stats.TotalTokensBurned += delta
 				wc.Snapshot.TokensBurned += delta
+				dq.Snapshot.TokensBurned += delta
 			}
 			t.lastTokens[s.ID] = s.TokensUsed
 		}
+		t.accumulateCountsLocked(s)
 
 	case session.EventTerminal:
 		// Check for challenge rotation on terminal events (cheaper than every event).
@@ -288,14 +436,25 @@ func (t *StatsTracker) processEvent(ev session.Event) {
 		case session.Complete:
 			t.stats.TotalCompletions++
 			t.stats.ConsecutiveCompletions++
-			trackXP("session_complete", XPSessionCompletes)
+			if t.limits.rapidCycle(s) {
+				t.suppressXPLocked("session_complete", XPSessionCompletes)
+				slog.Debug("no completion XP for a rapid create/complete cycle", "session", s.ID)
+			} else {
+				trackXP("session_complete", XPSessionCompletes)
+			}
 			wc.Snapshot.TotalCompletions++
+			dq.Snapshot.SessionsCompleted++
 
-			now := time.Now()
+			now := t.now()
 			if !t.lastCompletionAt.IsZero() && now.Sub(t.lastCompletionAt) <= 10*time.Second {
 				t.stats.PhotoFinishSeen = true
 			}
 			t.lastCompletionAt = now
+			completedAt := now
+			if s.CompletedAt != nil {
+				completedAt = *s.CompletedAt
+			}
+			bucket(&t.stats.Heatmap.Completions, completedAt, t.location())
 		case session.Errored:
 			t.stats.TotalErrors++
 			t.stats.ConsecutiveCompletions = 0
@@ -303,16 +462,26 @@ func (t *StatsTracker) processEvent(ev session.Event) {
 		case session.Lost:
 			t.stats.ConsecutiveCompletions = 0
 		}
+		if s.Outcome != "" {
+			t.stats.OutcomesPerKind[string(s.Outcome)]++
+		}
 
 		if s.Model != "" {
 			t.stats.SessionsPerModel[s.Model]++
 			t.stats.DistinctModelsUsed = len(t.stats.SessionsPerModel)
 			if t.stats.SessionsPerModel[s.Model] == 1 {
 				trackXP("new_model", XPNewModel)
+				dq.Snapshot.NewModels++
 			}
 			wc.Snapshot.SessionsPerModel[s.Model]++
 			wc.Snapshot.DistinctModels = len(wc.Snapshot.SessionsPerModel)
 		}
+		t.accumulateCountsLocked(s)
+		for i := 0; i < len(t.toolAchievements); i++ {
+			if t.toolAchievements[i].metBy(s) {
+				t.stats.ToolAchievementSessions[t.toolAchievements[i].ID]++
+			}
+		}
 		if s.ToolCallCount > t.stats.MaxToolCalls {
 			t.stats.MaxToolCalls = s.ToolCallCount
 		}
@@ -329,7 +498,25 @@ func (t *StatsTracker) processEvent(ev session.Event) {
 		delete(t.counted, s.ID)
 		delete(t.contextMilestones, s.ID)
 		delete(t.lastTokens, s.ID)
+		delete(t.lastMCPCalls, s.ID)
+		delete(t.lastToolCounts, s.ID)
+		delete(t.lastCommands, s.ID)
+		delete(t.lastHookEvents, s.ID)
+		delete(t.lastModelSwitches, s.ID)
+		delete(t.lastCache, s.ID)
+		delete(t.lastEnergy, s.ID)
 		delete(t.highUtilSessions, s.ID)
+
+	case session.EventSubagentStarted:
+		t.stats.TotalSubagents++
+		dq.Snapshot.SubagentsStarted++
+
+	case session.EventSubagentCompleted:
+		t.stats.SubagentsCompleted++
+
+	case session.EventMilestone:
+		t.stats.MilestonesReached[ev.Milestone.Key()]++
+		trackXP("milestone_"+ev.Milestone.Key(), XPMilestone)
 	}
 
 	// Award XP for newly completed weekly challenges.
@@ -340,6 +527,16 @@ func (t *StatsTracker) processEvent(ev session.Event) {
 		}
 	}
 
+	// Award XP for newly completed daily quests, which also keep the
+	// streak going.
+	for _, qp := range EvaluateQuests(dq) {
+		if qp.Complete && !dq.XPAwarded[qp.ID] {
+			dq.XPAwarded[qp.ID] = true
+			trackXP("daily_quest_"+qp.ID, XPDailyQuest)
+			recordQuestCompleted(dq)
+		}
+	}
+
 	t.dirty = true
 
 	// Evaluate achievements while still holding the lock so stats are consistent.
@@ -360,15 +557,102 @@ func (t *StatsTracker) processEvent(ev session.Event) {
 		t.onBattlePass(bpProgress, xpEntries)
 	}
 
-	if t.onAchievement != nil {
-		for _, a := range unlocked {
-			var rw *Reward
-			if found, ok := t.rewardRegistry.RewardForAchievement(a.ID); ok {
-				rw = &found
-			}
-			t.onAchievement(a, rw)
+	t.notifyAchievements(unlocked)
+}
+
+// notifyAchievements passes each newly unlocked achievement and the reward
+// it unlocks to the OnAchievement callback. Call without holding t.mu.
+func (t *StatsTracker) notifyAchievements(unlocked []Achievement) {
+	if t.onAchievement == nil {
+		return
+	}
+	for _, a := range unlocked {
+		var rw *Reward
+		if found, ok := t.rewardRegistry.RewardForAchievement(a.ID); ok {
+			rw = &found
+		}
+		t.onAchievement(a, rw)
+	}
+}
+
+// accumulateCountsLocked folds the growth in a session's cumulative
+// per-tool, per-MCP-server, slash command and hook counters since its last
+// event into the all-time totals. Caller must hold t.mu.
+func (t *StatsTracker) accumulateCountsLocked(s *session.SessionState) {
+	t.lastToolCounts[s.ID] = addCountDeltas(t.stats.ToolCalls, t.lastToolCounts[s.ID], s.ToolCounts)
+	t.lastMCPCalls[s.ID] = addCountDeltas(t.stats.ToolCallsPerMCP, t.lastMCPCalls[s.ID], s.MCPToolCalls)
+	t.lastCommands[s.ID] = addCountDeltas(t.stats.SlashCommandsUsed, t.lastCommands[s.ID], s.SlashCommands)
+	if delta := s.HookEventCount - t.lastHookEvents[s.ID]; delta > 0 {
+		t.stats.TotalHookEvents += delta
+		t.lastHookEvents[s.ID] = s.HookEventCount
+	}
+	if delta := s.ModelSwitches - t.lastModelSwitches[s.ID]; delta > 0 {
+		t.stats.TotalModelSwitches += delta
+		t.lastModelSwitches[s.ID] = s.ModelSwitches
+	}
+	t.accumulateCacheLocked(s)
+	t.accumulateEnergyLocked(s)
+}
+
+// accumulateCacheLocked adds the growth in a session's prompt-cache totals
+// to the aggregate and recomputes its hit ratio and savings.
+func (t *StatsTracker) accumulateCacheLocked(s *session.SessionState) {
+	cur := session.CacheUsage{
+		ReadTokens:     s.CacheReadTokens,
+		WriteTokens:    s.CacheWriteTokens,
+		UncachedTokens: s.UncachedTokens,
+	}
+	prev := t.lastCache[s.ID]
+	if cur == prev {
+		return
+	}
+	t.lastCache[s.ID] = cur
+	t.stats.TotalCacheReadTokens += max(cur.ReadTokens-prev.ReadTokens, 0)
+	t.stats.TotalCacheWriteTokens += max(cur.WriteTokens-prev.WriteTokens, 0)
+	t.stats.TotalUncachedTokens += max(cur.UncachedTokens-prev.UncachedTokens, 0)
+
+	total := session.CacheUsage{
+		ReadTokens:     t.stats.TotalCacheReadTokens,
+		WriteTokens:    t.stats.TotalCacheWriteTokens,
+		UncachedTokens: t.stats.TotalUncachedTokens,
+	}
+	t.stats.CacheHitRatio = total.HitRatio()
+	t.stats.CacheSavedTokens = total.SavedTokens()
+}
+
+// energyTotals is a session's energy estimate; see session.EnergyModel.
+type energyTotals struct {
+	wh, co2Grams float64
+}
+
+// accumulateEnergyLocked adds the growth in a session's energy estimate to
+// the lifetime totals.
+func (t *StatsTracker) accumulateEnergyLocked(s *session.SessionState) {
+	prev := t.lastEnergy[s.ID]
+	if s.EnergyWh <= prev.wh {
+		return
+	}
+	t.stats.TotalEnergyWh += s.EnergyWh - prev.wh
+	t.stats.TotalCO2Grams += max(s.CO2Grams-prev.co2Grams, 0)
+	t.lastEnergy[s.ID] = energyTotals{wh: s.EnergyWh, co2Grams: s.CO2Grams}
+}
+
+// addCountDeltas adds cur[k]-prev[k] (when positive) to total for every key
+// in cur and returns prev updated to cur, allocating it when needed.
+func addCountDeltas(total, prev, cur map[string]int) map[string]int {
+	if len(cur) == 0 {
+		return prev
+	}
+	if prev == nil {
+		prev = make(map[string]int, len(cur))
+	}
+	for k, n := range cur {
+		if delta := n - prev[k]; delta > 0 {
+			total[k] += delta
 		}
+		prev[k] = n
 	}
+	return prev
 }
 
 // Challenges returns the current weekly challenge progress.
@@ -380,6 +664,23 @@ func (t *StatsTracker) Challenges() []ChallengeProgress {
 	return EvaluateChallenges(&t.stats.WeeklyChallenges)
 }
 
+// Quests returns the day's quests and the quest streak.
+func (t *StatsTracker) Quests() DailyQuests {
+	now := t.now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	dq := &t.stats.DailyQuests
+	if RotateQuestsIfNeeded(dq, now, t.location()) {
+		t.dirty = true
+	}
+	return DailyQuests{
+		Day:           dq.Day,
+		Quests:        EvaluateQuests(dq),
+		Streak:        dq.Streak,
+		LongestStreak: dq.LongestStreak,
+	}
+}
+
 // Equip validates and equips rewardID using the given registry, persists
 // the change immediately, and returns the updated loadout. It is safe for
 // concurrent use.
@@ -418,6 +719,10 @@ func (t *StatsTracker) mutateLoadout(fn func() error) (Equipped, error) {
 
 func (t *StatsTracker) save() {
 	t.mu.Lock()
+	if t.released {
+		t.mu.Unlock()
+		return
+	}
 	stats := t.stats.clone()
 	t.dirty = false
 	t.mu.Unlock()
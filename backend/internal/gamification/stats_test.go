@@ -908,6 +908,9 @@ func TestStatsTracker_TokensBurned_UsesDelta(t *testing.T) {
 	if stats.WeeklyChallenges.Snapshot.TokensBurned != 40_000 {
 		t.Errorf("TokensBurned = %d, want 40000 (delta tracking)", stats.WeeklyChallenges.Snapshot.TokensBurned)
 	}
+	if stats.TotalTokensBurned != 40_000 {
+		t.Errorf("TotalTokensBurned = %d, want 40000", stats.TotalTokensBurned)
+	}
 }
 
 func TestStatsTracker_TokensBurned_MultipleSessions(t *testing.T) {
@@ -1281,3 +1284,297 @@ func TestSeasonRotation_PersistsToDisk(t *testing.T) {
 		t.Fatalf("persisted ArchivedSeasons length = %d, want 1", len(loaded.ArchivedSeasons))
 	}
 }
+
+func TestStatsTracker_ToolCallsPerMCP_UsesDelta(t *testing.T) {
+	tracker, eventCh := startTracker(t)
+
+	eventCh <- session.Event{
+		Type:        session.EventNew,
+		State:       &session.SessionState{ID: "s1", Source: "claude"},
+		ActiveCount: 1,
+	}
+	eventCh <- session.Event{
+		Type:        session.EventUpdate,
+		State:       &session.SessionState{ID: "s1", MCPToolCalls: map[string]int{"github": 2}},
+		ActiveCount: 1,
+	}
+	eventCh <- session.Event{
+		Type:        session.EventUpdate,
+		State:       &session.SessionState{ID: "s1", MCPToolCalls: map[string]int{"github": 3, "linear": 1}},
+		ActiveCount: 1,
+	}
+	eventCh <- session.Event{
+		Type:  session.EventTerminal,
+		State: &session.SessionState{ID: "s1", Activity: session.Complete, MCPToolCalls: map[string]int{"github": 4, "linear": 1}},
+	}
+
+	tracker.Flush()
+
+	stats := tracker.Stats()
+	if stats.ToolCallsPerMCP["github"] != 4 {
+		t.Errorf("ToolCallsPerMCP[github] = %d, want 4", stats.ToolCallsPerMCP["github"])
+	}
+	if stats.ToolCallsPerMCP["linear"] != 1 {
+		t.Errorf("ToolCallsPerMCP[linear] = %d, want 1", stats.ToolCallsPerMCP["linear"])
+	}
+}
+
+func TestStatsTracker_SlashCommandsAndHooks_UseDelta(t *testing.T) {
+	tracker, eventCh := startTracker(t)
+
+	eventCh <- session.Event{
+		Type:        session.EventNew,
+		State:       &session.SessionState{ID: "s1", Source: "claude"},
+		ActiveCount: 1,
+	}
+	eventCh <- session.Event{
+		Type:        session.EventUpdate,
+		State:       &session.SessionState{ID: "s1", SlashCommands: map[string]int{"/compact": 1}, HookEventCount: 2},
+		ActiveCount: 1,
+	}
+	eventCh <- session.Event{
+		Type:  session.EventTerminal,
+		State: &session.SessionState{ID: "s1", Activity: session.Complete, SlashCommands: map[string]int{"/compact": 2, "/review": 1}, HookEventCount: 3},
+	}
+
+	tracker.Flush()
+
+	stats := tracker.Stats()
+	if stats.SlashCommandsUsed["/compact"] != 2 || stats.SlashCommandsUsed["/review"] != 1 {
+		t.Errorf("SlashCommandsUsed = %v, want /compact=2 /review=1", stats.SlashCommandsUsed)
+	}
+	if stats.TotalHookEvents != 3 {
+		t.Errorf("TotalHookEvents = %d, want 3", stats.TotalHookEvents)
+	}
+}
+
+func TestStatsTracker_ModelSwitches_UseDelta(t *testing.T) {
+	tracker, eventCh := startTracker(t)
+
+	eventCh <- session.Event{
+		Type:        session.EventNew,
+		State:       &session.SessionState{ID: "s1", Source: "claude"},
+		ActiveCount: 1,
+	}
+	eventCh <- session.Event{
+		Type:        session.EventUpdate,
+		State:       &session.SessionState{ID: "s1", ModelSwitches: 1},
+		ActiveCount: 1,
+	}
+	eventCh <- session.Event{
+		Type:        session.EventUpdate,
+		State:       &session.SessionState{ID: "s1", ModelSwitches: 1},
+		ActiveCount: 1,
+	}
+	eventCh <- session.Event{
+		Type:  session.EventTerminal,
+		State: &session.SessionState{ID: "s1", Activity: session.Complete, ModelSwitches: 2},
+	}
+
+	tracker.Flush()
+
+	if got := tracker.Stats().TotalModelSwitches; got != 2 {
+		t.Errorf("TotalModelSwitches = %d, want 2", got)
+	}
+}
+
+func TestStatsTracker_OutcomesPerKind(t *testing.T) {
+	tracker, eventCh := startTracker(t)
+
+	eventCh <- session.Event{
+		Type:  session.EventTerminal,
+		State: &session.SessionState{ID: "s1", Activity: session.Complete, Outcome: session.OutcomeCommitted},
+	}
+	eventCh <- session.Event{
+		Type:  session.EventTerminal,
+		State: &session.SessionState{ID: "s2", Activity: session.Complete, Outcome: session.OutcomeCommitted},
+	}
+	eventCh <- session.Event{
+		Type:  session.EventTerminal,
+		State: &session.SessionState{ID: "s3", Activity: session.Errored, Outcome: session.OutcomeErrored},
+	}
+	eventCh <- session.Event{
+		Type:  session.EventTerminal,
+		State: &session.SessionState{ID: "s4", Activity: session.Lost},
+	}
+
+	tracker.Flush()
+
+	stats := tracker.Stats()
+	want := map[string]int{"committed": 2, "errored": 1}
+	if len(stats.OutcomesPerKind) != len(want) {
+		t.Fatalf("OutcomesPerKind = %v, want %v", stats.OutcomesPerKind, want)
+	}
+	for k, v := range want {
+		if stats.OutcomesPerKind[k] != v {
+			t.Errorf("OutcomesPerKind[%s] = %d, want %d", k, stats.OutcomesPerKind[k], v)
+		}
+	}
+}
+
+func TestStatsTracker_SavePersistsQueuedEvents(t *testing.T) {
+	tracker, eventCh := startTracker(t)
+
+	eventCh <- session.Event{
+		Type:  session.EventNew,
+		State: &session.SessionState{ID: "s1", Source: "claude"},
+	}
+	tracker.Save()
+
+	saved, err := tracker.persist.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if saved.TotalSessions != 1 {
+		t.Errorf("saved TotalSessions = %d, want 1", saved.TotalSessions)
+	}
+}
+
+func TestStatsTracker_ReleaseStopsSaving(t *testing.T) {
+	tracker, eventCh := startTracker(t)
+
+	eventCh <- session.Event{
+		Type:  session.EventNew,
+		State: &session.SessionState{ID: "s1", Source: "claude"},
+	}
+	tracker.Save()
+	tracker.Release()
+	eventCh <- session.Event{
+		Type:  session.EventNew,
+		State: &session.SessionState{ID: "s2", Source: "claude"},
+	}
+	tracker.Save()
+
+	saved, err := tracker.persist.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if saved.TotalSessions != 1 {
+		t.Errorf("saved TotalSessions = %d, want 1 (nothing saved after Release)", saved.TotalSessions)
+	}
+}
+
+func TestStatsTracker_AccumulatesCacheUsage(t *testing.T) {
+	tracker, eventCh := startTracker(t)
+
+	eventCh <- session.Event{
+		Type:        session.EventUpdate,
+		State:       &session.SessionState{ID: "s1", CacheReadTokens: 6000, CacheWriteTokens: 1000, UncachedTokens: 1000},
+		ActiveCount: 1,
+	}
+	// Repeated totals add nothing; growth adds the difference.
+	eventCh <- session.Event{
+		Type:        session.EventUpdate,
+		State:       &session.SessionState{ID: "s1", CacheReadTokens: 6000, CacheWriteTokens: 1000, UncachedTokens: 1000},
+		ActiveCount: 1,
+	}
+	eventCh <- session.Event{
+		Type:        session.EventTerminal,
+		State:       &session.SessionState{ID: "s1", Activity: session.Complete, CacheReadTokens: 8000, CacheWriteTokens: 1000, UncachedTokens: 1000},
+		ActiveCount: 0,
+	}
+	tracker.Flush()
+
+	stats := tracker.Stats()
+	if stats.TotalCacheReadTokens != 8000 || stats.TotalCacheWriteTokens != 1000 || stats.TotalUncachedTokens != 1000 {
+		t.Errorf("cache totals = %d/%d/%d, want 8000/1000/1000",
+			stats.TotalCacheReadTokens, stats.TotalCacheWriteTokens, stats.TotalUncachedTokens)
+	}
+	if stats.CacheHitRatio != 0.8 || stats.CacheSavedTokens != 6950 {
+		t.Errorf("hit ratio = %v, saved = %d; want 0.8 and 6950", stats.CacheHitRatio, stats.CacheSavedTokens)
+	}
+}
+
+func TestStatsTracker_CountsSubagents(t *testing.T) {
+	tracker, eventCh := startTracker(t)
+
+	parent := &session.SessionState{ID: "s1", Source: "claude"}
+	eventCh <- session.Event{Type: session.EventNew, State: parent, ActiveCount: 1}
+	for i := 0; i < 10; i++ {
+		sub := &session.SubagentState{ID: fmt.Sprintf("toolu_%d", i), SessionID: "s1"}
+		eventCh <- session.Event{Type: session.EventSubagentStarted, State: parent, Subagent: sub, ActiveCount: 1}
+		if i < 4 {
+			eventCh <- session.Event{Type: session.EventSubagentCompleted, State: parent, Subagent: sub, ActiveCount: 1}
+		}
+	}
+	tracker.Flush()
+
+	stats := tracker.Stats()
+	if stats.TotalSubagents != 10 || stats.SubagentsCompleted != 4 {
+		t.Errorf("TotalSubagents = %d, SubagentsCompleted = %d, want 10 and 4", stats.TotalSubagents, stats.SubagentsCompleted)
+	}
+	if _, ok := stats.AchievementsUnlocked["delegator"]; !ok {
+		t.Error("delegator not unlocked after 10 subagents")
+	}
+	if stats.TotalSessions != 1 {
+		t.Errorf("TotalSessions = %d, want 1: subagent events are not sessions", stats.TotalSessions)
+	}
+}
+
+func TestStatsTracker_CountsMilestones(t *testing.T) {
+	tracker, eventCh := startTracker(t)
+
+	s := &session.SessionState{ID: "s1", Source: "claude"}
+	eventCh <- session.Event{Type: session.EventNew, State: s, ActiveCount: 1}
+	for _, m := range []session.Milestone{
+		{SessionID: "s1", Kind: session.MilestoneDuration, Value: 8 * 3600},
+		{SessionID: "s1", Kind: session.MilestoneTokens, Value: 500_000},
+	} {
+		eventCh <- session.Event{Type: session.EventMilestone, State: s, Milestone: &m, ActiveCount: 1}
+	}
+	tracker.Flush()
+
+	stats := tracker.Stats()
+	if stats.MilestonesReached["duration_8h"] != 1 || stats.MilestonesReached["tokens_500k"] != 1 {
+		t.Errorf("MilestonesReached = %v", stats.MilestonesReached)
+	}
+	if _, ok := stats.AchievementsUnlocked["ultramarathon"]; !ok {
+		t.Error("ultramarathon not unlocked after an 8h milestone")
+	}
+	if _, ok := stats.AchievementsUnlocked["token_millionaire"]; ok {
+		t.Error("token_millionaire unlocked at 500k tokens")
+	}
+}
+
+func TestStatsTracker_AccumulatesEnergy(t *testing.T) {
+	tracker, eventCh := startTracker(t)
+
+	eventCh <- session.Event{Type: session.EventNew, State: &session.SessionState{ID: "s1"}, ActiveCount: 1}
+	eventCh <- session.Event{Type: session.EventUpdate, State: &session.SessionState{ID: "s1", EnergyWh: 2, CO2Grams: 0.8}, ActiveCount: 1}
+	eventCh <- session.Event{Type: session.EventUpdate, State: &session.SessionState{ID: "s1", EnergyWh: 2, CO2Grams: 0.8}, ActiveCount: 1}
+	eventCh <- session.Event{Type: session.EventTerminal, State: &session.SessionState{ID: "s1", Activity: session.Complete, EnergyWh: 5, CO2Grams: 2}, ActiveCount: 0}
+	tracker.Flush()
+
+	stats := tracker.Stats()
+	if stats.TotalEnergyWh != 5 || stats.TotalCO2Grams != 2 {
+		t.Errorf("totals = %v Wh, %v g, want 5 Wh, 2 g", stats.TotalEnergyWh, stats.TotalCO2Grams)
+	}
+}
+
+func TestStatsTracker_AcknowledgeAchievements(t *testing.T) {
+	tracker, _ := startTracker(t)
+	tracker.mu.Lock()
+	tracker.stats.AchievementsUnlocked["first_lap"] = time.Now()
+	tracker.stats.AchievementsUnlocked["pit_crew"] = time.Now()
+	tracker.stats.AchievementsUnlocked["redline"] = time.Now()
+	tracker.mu.Unlock()
+
+	got := tracker.AcknowledgeAchievements([]string{"first_lap", "marathon", "nope"})
+	if fmt.Sprint(got) != "[first_lap]" {
+		t.Errorf("acknowledged %v, want [first_lap]; locked and unknown IDs are skipped", got)
+	}
+	if got := tracker.AcknowledgeAchievements(nil); fmt.Sprint(got) != "[pit_crew redline]" {
+		t.Errorf("acknowledging all = %v, want [pit_crew redline]", got)
+	}
+	if got := tracker.AcknowledgeAchievements(nil); len(got) != 0 {
+		t.Errorf("acknowledging again = %v, want nothing new", got)
+	}
+
+	saved, err := tracker.persist.Load()
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if len(saved.AchievementsAcknowledged) != 3 {
+		t.Errorf("saved acknowledgements = %v, want all 3", saved.AchievementsAcknowledged)
+	}
+}
@@ -0,0 +1,127 @@
+package gamification
+
+import (
+	"fmt"
+
+	"github.com/agent-racer/backend/internal/session"
+)
+
+// ToolAchievement is an achievement defined in config over how often a
+// tool is called. Tool is named as in SessionState.ToolCounts, e.g. "Bash",
+// "Edit" or "mcp__github__create_issue".
+//
+// By default it unlocks once Tool has been called MinCalls times across
+// all sessions. With PerSession it unlocks on the first completed session
+// whose calls of Tool fall between MinCalls and MaxCalls, so "a session
+// with zero Bash" is per_session with max_calls 0.
+type ToolAchievement struct {
+	ID          string `yaml:"id"`
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"` // empty describes the condition
+	Tier        Tier   `yaml:"tier"`
+	Tool        string `yaml:"tool"`
+	PerSession  bool   `yaml:"per_session"`
+	MinCalls    int    `yaml:"min_calls"`
+	// MaxCalls caps a session's calls of Tool; nil leaves them uncapped.
+	// Only with PerSession.
+	MaxCalls *int `yaml:"max_calls"`
+}
+
+// Equal reports whether a and o describe the same achievement.
+func (a ToolAchievement) Equal(o ToolAchievement) bool {
+	sameMax := (a.MaxCalls == nil) == (o.MaxCalls == nil) && (a.MaxCalls == nil || *a.MaxCalls == *o.MaxCalls)
+	return a.ID == o.ID && a.Name == o.Name && a.Description == o.Description && a.Tier == o.Tier &&
+		a.Tool == o.Tool && a.PerSession == o.PerSession && a.MinCalls == o.MinCalls && sameMax
+}
+
+// ValidateToolAchievements returns one message per problem in tools.
+func ValidateToolAchievements(tools []ToolAchievement) []string {
+	var errs []string
+	ids := make(map[string]bool, len(tools))
+	builtin := buildRegistry()
+	for i := 0; i < len(builtin); i++ {
+		ids[builtin[i].ID] = true
+	}
+	for i := 0; i < len(tools); i++ {
+		a := tools[i]
+		if a.ID == "" {
+			errs = append(errs, fmt.Sprintf("achievement %d: id is required", i))
+		} else if ids[a.ID] {
+			errs = append(errs, fmt.Sprintf("achievement %q: id is already taken", a.ID))
+		}
+		ids[a.ID] = true
+		if a.Name == "" {
+			errs = append(errs, fmt.Sprintf("achievement %q: name is required", a.ID))
+		}
+		switch a.Tier {
+		case TierBronze, TierSilver, TierGold, TierPlatinum:
+		default:
+			errs = append(errs, fmt.Sprintf("achievement %q: tier must be bronze, silver, gold or platinum, got %q", a.ID, a.Tier))
+		}
+		if a.Tool == "" {
+			errs = append(errs, fmt.Sprintf("achievement %q: tool is required", a.ID))
+		}
+		if a.MinCalls < 0 {
+			errs = append(errs, fmt.Sprintf("achievement %q: min_calls must not be negative, got %d", a.ID, a.MinCalls))
+		}
+		switch {
+		case !a.PerSession && a.MaxCalls != nil:
+			errs = append(errs, fmt.Sprintf("achievement %q: max_calls needs per_session", a.ID))
+		case !a.PerSession && a.MinCalls == 0:
+			errs = append(errs, fmt.Sprintf("achievement %q: min_calls must be positive", a.ID))
+		case a.PerSession && a.MaxCalls == nil && a.MinCalls == 0:
+			errs = append(errs, fmt.Sprintf("achievement %q: needs min_calls or max_calls", a.ID))
+		case a.MaxCalls != nil && *a.MaxCalls < a.MinCalls:
+			errs = append(errs, fmt.Sprintf("achievement %q: max_calls %d is below min_calls %d", a.ID, *a.MaxCalls, a.MinCalls))
+		}
+	}
+	return errs
+}
+
+// achievement returns a as a registry entry.
+func (a ToolAchievement) achievement() Achievement {
+	out := Achievement{
+		ID:          a.ID,
+		Name:        a.Name,
+		Description: a.Description,
+		Tier:        a.Tier,
+		Category:    CategoryToolUsage,
+	}
+	if out.Description == "" {
+		out.Description = a.describe()
+	}
+	if a.PerSession {
+		out.Condition = func(s *Stats) bool { return s.ToolAchievementSessions[a.ID] >= 1 }
+	} else {
+		out.Condition = func(s *Stats) bool { return s.ToolCalls[a.Tool] >= a.MinCalls }
+	}
+	return out
+}
+
+// describe words the condition for achievements configured without a
+// description.
+func (a ToolAchievement) describe() string {
+	switch {
+	case !a.PerSession:
+		return fmt.Sprintf("Make %d %s calls", a.MinCalls, a.Tool)
+	case a.MaxCalls == nil:
+		return fmt.Sprintf("Complete a session with %d or more %s calls", a.MinCalls, a.Tool)
+	case *a.MaxCalls == 0:
+		return fmt.Sprintf("Complete a session without a single %s call", a.Tool)
+	case a.MinCalls == 0:
+		return fmt.Sprintf("Complete a session with at most %d %s calls", *a.MaxCalls, a.Tool)
+	default:
+		return fmt.Sprintf("Complete a session with %d to %d %s calls", a.MinCalls, *a.MaxCalls, a.Tool)
+	}
+}
+
+// metBy reports whether the finished session s counts towards a per-session
+// achievement. Only completed sessions that called some tool count, so an
+// empty or abandoned session is not "a session without Bash".
+func (a ToolAchievement) metBy(s *session.SessionState) bool {
+	if !a.PerSession || s.Activity != session.Complete || s.ToolCallCount == 0 {
+		return false
+	}
+	n := s.ToolCounts[a.Tool]
+	return n >= a.MinCalls && (a.MaxCalls == nil || n <= *a.MaxCalls)
+}
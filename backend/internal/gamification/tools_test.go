@@ -0,0 +1,125 @@
+package gamification
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/agent-racer/backend/internal/session"
+)
+
+func intPtr(n int) *int { return &n }
+
+func TestValidateToolAchievements(t *testing.T) {
+	valid := ToolAchievement{ID: "shell_jockey", Name: "Shell Jockey", Tier: TierGold, Tool: "Bash", MinCalls: 1000}
+	tests := []struct {
+		name string
+		edit func(*ToolAchievement)
+		want string
+	}{
+		{"valid lifetime", func(*ToolAchievement) {}, ""},
+		{"valid per session", func(a *ToolAchievement) { a.PerSession, a.MinCalls, a.MaxCalls = true, 0, intPtr(0) }, ""},
+		{"missing id", func(a *ToolAchievement) { a.ID = "" }, "id is required"},
+		{"built-in id", func(a *ToolAchievement) { a.ID = "first_lap" }, "already taken"},
+		{"missing name", func(a *ToolAchievement) { a.Name = "" }, "name is required"},
+		{"bad tier", func(a *ToolAchievement) { a.Tier = "diamond" }, "tier must be"},
+		{"missing tool", func(a *ToolAchievement) { a.Tool = "" }, "tool is required"},
+		{"negative min", func(a *ToolAchievement) { a.MinCalls = -1 }, "must not be negative"},
+		{"lifetime without min", func(a *ToolAchievement) { a.MinCalls = 0 }, "min_calls must be positive"},
+		{"lifetime with max", func(a *ToolAchievement) { a.MaxCalls = intPtr(5) }, "max_calls needs per_session"},
+		{"per session without bounds", func(a *ToolAchievement) { a.PerSession, a.MinCalls = true, 0 }, "needs min_calls or max_calls"},
+		{"max below min", func(a *ToolAchievement) { a.PerSession, a.MaxCalls = true, intPtr(10) }, "below min_calls"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := valid
+			tt.edit(&a)
+			errs := ValidateToolAchievements([]ToolAchievement{a})
+			if tt.want == "" {
+				if len(errs) != 0 {
+					t.Errorf("errors = %v, want none", errs)
+				}
+				return
+			}
+			if len(errs) != 1 || !strings.Contains(errs[0], tt.want) {
+				t.Errorf("errors = %v, want one containing %q", errs, tt.want)
+			}
+		})
+	}
+
+	if errs := ValidateToolAchievements([]ToolAchievement{valid, valid}); len(errs) != 1 {
+		t.Errorf("duplicate ids: errors = %v, want one", errs)
+	}
+}
+
+func TestToolAchievementDescribe(t *testing.T) {
+	tests := []struct {
+		a    ToolAchievement
+		want string
+	}{
+		{ToolAchievement{Tool: "Bash", MinCalls: 1000}, "Make 1000 Bash calls"},
+		{ToolAchievement{Tool: "Bash", PerSession: true, MaxCalls: intPtr(0)}, "Complete a session without a single Bash call"},
+		{ToolAchievement{Tool: "Edit", PerSession: true, MinCalls: 50}, "Complete a session with 50 or more Edit calls"},
+		{ToolAchievement{Tool: "Edit", PerSession: true, MaxCalls: intPtr(3)}, "Complete a session with at most 3 Edit calls"},
+		{ToolAchievement{Tool: "Edit", PerSession: true, MinCalls: 1, MaxCalls: intPtr(3)}, "Complete a session with 1 to 3 Edit calls"},
+	}
+	for _, tt := range tests {
+		if got := tt.a.achievement().Description; got != tt.want {
+			t.Errorf("Description = %q, want %q", got, tt.want)
+		}
+	}
+	named := ToolAchievement{Tool: "Bash", MinCalls: 1, Description: "Say hello to the shell"}
+	if got := named.achievement().Description; got != named.Description {
+		t.Errorf("Description = %q, want the configured one", got)
+	}
+}
+
+func TestStatsTracker_ToolAchievements(t *testing.T) {
+	tr := newTracker(newStats())
+	tr.SetToolAchievements([]ToolAchievement{
+		{ID: "shell_jockey", Name: "Shell Jockey", Tier: TierGold, Tool: "Bash", MinCalls: 10},
+		{ID: "hands_off", Name: "Hands Off", Tier: TierSilver, Tool: "Bash", PerSession: true, MaxCalls: intPtr(0)},
+	})
+	var unlocked []string
+	tr.OnAchievement(func(a Achievement, _ *Reward) {
+		if a.Category == CategoryToolUsage {
+			unlocked = append(unlocked, a.ID)
+		}
+	})
+
+	run := func(id string, activity session.Activity, tools map[string]int) {
+		calls := 0
+		for _, n := range tools {
+			calls += n
+		}
+		s := &session.SessionState{ID: id, Source: "claude", ToolCounts: tools, ToolCallCount: calls}
+		tr.processEvent(session.Event{Type: session.EventNew, State: s})
+		tr.processEvent(session.Event{Type: session.EventUpdate, State: s})
+		done := *s
+		done.Activity = activity
+		tr.processEvent(session.Event{Type: session.EventTerminal, State: &done})
+	}
+
+	run("a", session.Complete, map[string]int{"Bash": 6, "Read": 2})
+	run("b", session.Errored, map[string]int{"Read": 4}) // not completed
+	run("c", session.Complete, nil)                      // no tool calls at all
+	if got := tr.stats.ToolCalls; got["Bash"] != 6 || got["Read"] != 6 {
+		t.Fatalf("ToolCalls = %v, want Bash=6 Read=6", got)
+	}
+	if len(unlocked) != 0 {
+		t.Fatalf("unlocked %v too early", unlocked)
+	}
+
+	run("d", session.Complete, map[string]int{"Bash": 4})
+	run("e", session.Complete, map[string]int{"Edit": 3})
+	if strings.Join(unlocked, ",") != "shell_jockey,hands_off" {
+		t.Errorf("unlocked = %v, want shell_jockey then hands_off", unlocked)
+	}
+	if tr.stats.ToolAchievementSessions["hands_off"] != 1 {
+		t.Errorf("ToolAchievementSessions = %v, want hands_off=1", tr.stats.ToolAchievementSessions)
+	}
+
+	reg := tr.Achievements()
+	if last := reg[len(reg)-1]; last.ID != "hands_off" || last.Category != CategoryToolUsage {
+		t.Errorf("last registered = %s in %q, want hands_off in %q", last.ID, last.Category, CategoryToolUsage)
+	}
+}
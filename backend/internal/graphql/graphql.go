@@ -0,0 +1,525 @@
+// Package graphql executes read-only GraphQL queries against plain Go
+// values. A Schema maps root query fields to resolvers; whatever a resolver
+// returns is walked by reflection, and its fields are addressed by their
+// JSON names, so a query sees the same shape the REST API returns:
+//
+//	{ sessions { name tokensUsed subagents { slug } } }
+//
+// Structs are objects; maps, times and scalars are leaves. Mutations,
+// subscriptions and introspection are not supported.
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// Request is a GraphQL request as sent over HTTP.
+type Request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName,omitempty"`
+	Variables     map[string]any `json:"variables,omitempty"`
+}
+
+// Response is the result of Execute. Data is nil when the query is
+// invalid; otherwise root fields whose resolver failed are null and listed
+// in Errors.
+type Response struct {
+	Data   any     `json:"data,omitempty"`
+	Errors []Error `json:"errors,omitempty"`
+}
+
+// Error is one entry of Response.Errors.
+type Error struct {
+	Message string `json:"message"`
+	Path    []any  `json:"path,omitempty"`
+}
+
+// Args holds a root field's arguments, with variables substituted. Numbers
+// are int or float64, lists are []any and input objects map[string]any.
+type Args map[string]any
+
+// String returns the string argument name, or "" if it is absent or null.
+func (a Args) String(name string) (string, error) {
+	switch v := a[name].(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	}
+	return "", fmt.Errorf("argument %q must be a string", name)
+}
+
+// Int returns the integer argument name, or def if it is absent or null.
+func (a Args) Int(name string, def int) (int, error) {
+	switch v := a[name].(type) {
+	case nil:
+		return def, nil
+	case int:
+		return v, nil
+	case float64:
+		// JSON variables decode as float64.
+		if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+			return int(v), nil
+		}
+	}
+	return 0, fmt.Errorf("argument %q must be an integer", name)
+}
+
+// Resolver computes a root field's value.
+type Resolver func(ctx context.Context, args Args) (any, error)
+
+// Field is a root query field.
+type Field struct {
+	Args    []string // accepted argument names
+	Resolve Resolver
+}
+
+// Schema is the set of root query fields.
+type Schema struct {
+	Query map[string]Field
+}
+
+// Execute parses and runs req.
+func (s *Schema) Execute(ctx context.Context, req Request) Response {
+	doc, err := parse(req.Query)
+	if err != nil {
+		return failed(err)
+	}
+	op, err := doc.operation(req.OperationName)
+	if err != nil {
+		return failed(err)
+	}
+	if op.kind != "query" {
+		return failed(fmt.Errorf("%s operations are not supported", op.kind))
+	}
+	vars, err := op.coerceVariables(req.Variables)
+	if err != nil {
+		return failed(err)
+	}
+
+	e := &executor{ctx: ctx, doc: doc, vars: vars}
+	fields, err := e.collect(op.selection, map[string]bool{})
+	if err != nil {
+		return failed(err)
+	}
+	// Unknown root fields and arguments fail the whole request, as
+	// validation errors do in GraphQL.
+	for _, f := range fields {
+		if f.name == "__typename" {
+			continue
+		}
+		if strings.HasPrefix(f.name, "__") {
+			return failed(fmt.Errorf("introspection is not supported"))
+		}
+		root, ok := s.Query[f.name]
+		if !ok {
+			return failed(fmt.Errorf("cannot query field %q on type Query", f.name))
+		}
+		for _, a := range f.args {
+			if !contains(root.Args, a.name) {
+				return failed(fmt.Errorf("unknown argument %q on field Query.%s", a.name, f.name))
+			}
+		}
+	}
+
+	data := make(object, 0, len(fields))
+	for _, f := range fields {
+		key := f.responseKey()
+		if f.name == "__typename" {
+			data = append(data, member{key, "Query"})
+			continue
+		}
+		val, err := e.resolveRoot(s.Query[f.name], f)
+		var invalid invalidQuery
+		if errors.As(err, &invalid) {
+			return failed(invalid.error)
+		}
+		if err != nil {
+			e.errors = append(e.errors, Error{Message: err.Error(), Path: []any{key}})
+			val = nil
+		}
+		data = append(data, member{key, val})
+	}
+	return Response{Data: data, Errors: e.errors}
+}
+
+func failed(err error) Response {
+	return Response{Errors: []Error{{Message: err.Error()}}}
+}
+
+func (d *document) operation(name string) (*operation, error) {
+	if name == "" {
+		if len(d.operations) > 1 {
+			return nil, fmt.Errorf("operationName is required when the document has several operations")
+		}
+		return d.operations[0], nil
+	}
+	for _, op := range d.operations {
+		if op.name == name {
+			return op, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown operation %q", name)
+}
+
+func (op *operation) coerceVariables(given map[string]any) (map[string]any, error) {
+	vars := make(map[string]any, len(op.variables))
+	for _, def := range op.variables {
+		v, ok := given[def.name]
+		if !ok && def.defaultV.kind != valueNull {
+			var err error
+			if v, err = def.defaultV.toAny(nil); err != nil {
+				return nil, err
+			}
+		}
+		if v == nil && def.nonNull {
+			return nil, fmt.Errorf("variable $%s is required", def.name)
+		}
+		vars[def.name] = v
+	}
+	return vars, nil
+}
+
+func (v value) toAny(vars map[string]any) (any, error) {
+	switch v.kind {
+	case valueNull:
+		return nil, nil
+	case valueVariable:
+		val, ok := vars[v.raw]
+		if !ok {
+			return nil, fmt.Errorf("variable $%s is not defined", v.raw)
+		}
+		return val, nil
+	case valueList:
+		out := make([]any, 0, len(v.list))
+		for _, item := range v.list {
+			val, err := item.toAny(vars)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, val)
+		}
+		return out, nil
+	case valueObject:
+		out := make(map[string]any, len(v.object))
+		for _, a := range v.object {
+			val, err := a.value.toAny(vars)
+			if err != nil {
+				return nil, err
+			}
+			out[a.name] = val
+		}
+		return out, nil
+	}
+	return v.resolved, nil
+}
+
+// ─── Execution ───────────────────────────────────────────────────────────────
+
+type executor struct {
+	ctx    context.Context
+	doc    *document
+	vars   map[string]any
+	errors []Error
+}
+
+// invalidQuery marks a selection that does not fit the resolved value's
+// type. It fails the whole request, as a validation error would in a
+// server with a static schema.
+type invalidQuery struct{ error }
+
+func (e *executor) resolveRoot(root Field, f *field) (any, error) {
+	args := make(Args, len(f.args))
+	for _, a := range f.args {
+		v, err := a.value.toAny(e.vars)
+		if err != nil {
+			return nil, err
+		}
+		args[a.name] = v
+	}
+	val, err := root.Resolve(e.ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	out, err := e.complete(reflect.ValueOf(val), f)
+	if err != nil {
+		return nil, invalidQuery{err}
+	}
+	return out, nil
+}
+
+// collect flattens fragments and applies @skip/@include, merging fields
+// that share a response key.
+func (e *executor) collect(sel []selection, visiting map[string]bool) ([]*field, error) {
+	var out []*field
+	byKey := map[string]*field{}
+	add := func(f *field) {
+		key := f.responseKey()
+		if prev, ok := byKey[key]; ok {
+			prev.selection = append(prev.selection, f.selection...)
+			return
+		}
+		cp := *f
+		cp.selection = append([]selection(nil), f.selection...)
+		byKey[key] = &cp
+		out = append(out, &cp)
+	}
+
+	for _, s := range sel {
+		include, err := e.included(s.directives)
+		if err != nil {
+			return nil, err
+		}
+		if !include {
+			continue
+		}
+		var nested []selection
+		switch {
+		case s.field != nil:
+			add(s.field)
+			continue
+		case s.spread != "":
+			frag, ok := e.doc.fragments[s.spread]
+			if !ok {
+				return nil, fmt.Errorf("unknown fragment %q", s.spread)
+			}
+			if visiting[s.spread] {
+				return nil, fmt.Errorf("fragment %q spreads itself", s.spread)
+			}
+			nested = frag
+		default:
+			nested = s.inline
+		}
+		if s.spread != "" {
+			visiting[s.spread] = true
+		}
+		fields, err := e.collect(nested, visiting)
+		delete(visiting, s.spread)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range fields {
+			add(f)
+		}
+	}
+	return out, nil
+}
+
+func (e *executor) included(dirs []directive) (bool, error) {
+	for _, d := range dirs {
+		if d.name != "skip" && d.name != "include" {
+			return false, fmt.Errorf("unknown directive @%s", d.name)
+		}
+		if len(d.args) != 1 || d.args[0].name != "if" {
+			return false, fmt.Errorf("@%s needs a single \"if\" argument", d.name)
+		}
+		v, err := d.args[0].value.toAny(e.vars)
+		if err != nil {
+			return false, err
+		}
+		cond, ok := v.(bool)
+		if !ok {
+			return false, fmt.Errorf("@%s(if:) must be a boolean", d.name)
+		}
+		if cond == (d.name == "skip") {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// complete shapes v to f's selection set.
+func (e *executor) complete(v reflect.Value, f *field) (any, error) {
+	for v.IsValid() && (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return nil, nil
+	}
+	t := v.Type()
+
+	if isLeaf(t) {
+		if len(f.selection) > 0 {
+			return nil, fmt.Errorf("field %q of type %s has no subfields", f.name, typeName(t))
+		}
+		return v.Interface(), nil
+	}
+
+	if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		if t.Kind() == reflect.Slice && v.IsNil() {
+			return nil, nil
+		}
+		out := make([]any, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			item, err := e.complete(v.Index(i), f)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = item
+		}
+		return out, nil
+	}
+
+	// Struct.
+	if len(f.selection) == 0 {
+		return nil, fmt.Errorf("field %q of type %s needs a selection of subfields", f.name, typeName(t))
+	}
+	fields, err := e.collect(f.selection, map[string]bool{})
+	if err != nil {
+		return nil, err
+	}
+	index := jsonIndex(t)
+	obj := make(object, 0, len(fields))
+	for _, sub := range fields {
+		key := sub.responseKey()
+		if sub.name == "__typename" {
+			obj = append(obj, member{key, typeName(t)})
+			continue
+		}
+		if len(sub.args) > 0 {
+			return nil, fmt.Errorf("field %s.%s takes no arguments", typeName(t), sub.name)
+		}
+		idx, ok := index[sub.name]
+		if !ok {
+			return nil, fmt.Errorf("cannot query field %q on type %s", sub.name, typeName(t))
+		}
+		var val any
+		// A nil embedded pointer leaves its fields null.
+		if fv, err := v.FieldByIndexErr(idx); err == nil {
+			if val, err = e.complete(fv, sub); err != nil {
+				return nil, err
+			}
+		}
+		obj = append(obj, member{key, val})
+	}
+	return obj, nil
+}
+
+// isLeaf reports whether t is returned whole rather than by selection.
+func isLeaf(t reflect.Type) bool {
+	if t == timeType || t.Implements(marshalerType) || reflect.PointerTo(t).Implements(marshalerType) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		return false
+	case reflect.Slice, reflect.Array:
+		return t.Elem().Kind() == reflect.Uint8 || isLeaf(derefType(t.Elem()))
+	}
+	return true
+}
+
+func derefType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+// typeName is the GraphQL name of t: its Go name, capitalized.
+func typeName(t reflect.Type) string {
+	name := t.Name()
+	if name == "" {
+		return "Object"
+	}
+	r := []rune(name)
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
+
+var indexCache sync.Map // reflect.Type -> map[string][]int
+
+// jsonIndex maps t's JSON field names to field indexes, following embedded
+// structs the way encoding/json does.
+func jsonIndex(t reflect.Type) map[string][]int {
+	if idx, ok := indexCache.Load(t); ok {
+		return idx.(map[string][]int)
+	}
+	idx := map[string][]int{}
+	var walk func(t reflect.Type, prefix []int)
+	walk = func(t reflect.Type, prefix []int) {
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			tag := f.Tag.Get("json")
+			if tag == "-" {
+				continue
+			}
+			name, _, _ := strings.Cut(tag, ",")
+			path := append(append([]int(nil), prefix...), i)
+			if f.Anonymous && name == "" && derefType(f.Type).Kind() == reflect.Struct {
+				walk(derefType(f.Type), path)
+				continue
+			}
+			if !f.IsExported() {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			if _, shadowed := idx[name]; !shadowed || len(path) == 1 {
+				idx[name] = path
+			}
+		}
+	}
+	walk(t, nil)
+	indexCache.Store(t, idx)
+	return idx
+}
+
+func contains(list []string, s string) bool {
+	for i := 0; i < len(list); i++ {
+		if list[i] == s {
+			return true
+		}
+	}
+	return false
+}
+
+// ─── Ordered output ──────────────────────────────────────────────────────────
+
+// object is a result map that keeps the query's field order, as GraphQL
+// requires.
+type object []member
+
+type member struct {
+	key string
+	val any
+}
+
+func (o object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i := 0; i < len(o); i++ {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(o[i].key)
+		buf.Write(key)
+		buf.WriteByte(':')
+		val, err := json.Marshal(o[i].val)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(val)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
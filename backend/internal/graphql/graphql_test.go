@@ -0,0 +1,173 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+type sub struct {
+	Slug string `json:"slug"`
+}
+
+type base struct {
+	ID string `json:"id"`
+}
+
+type racer struct {
+	base
+	Name      string         `json:"name"`
+	Tokens    int            `json:"tokensUsed"`
+	Started   time.Time      `json:"startedAt"`
+	Tools     map[string]int `json:"tools,omitempty"`
+	Subagents []sub          `json:"subagents,omitempty"`
+	Secret    string         `json:"-"`
+	Leader    *racer         `json:"leader,omitempty"`
+}
+
+func testSchema() *Schema {
+	racers := []*racer{
+		{base: base{ID: "a"}, Name: "alpha", Tokens: 10, Started: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+			Tools: map[string]int{"Bash": 2}, Subagents: []sub{{Slug: "x"}, {Slug: "y"}}, Secret: "s"},
+		{base: base{ID: "b"}, Name: "beta", Tokens: 20},
+	}
+	return &Schema{Query: map[string]Field{
+		"racers": {Resolve: func(context.Context, Args) (any, error) { return racers, nil }},
+		"racer": {Args: []string{"id"}, Resolve: func(_ context.Context, args Args) (any, error) {
+			id, err := args.String("id")
+			if err != nil {
+				return nil, err
+			}
+			for _, r := range racers {
+				if r.ID == id {
+					return r, nil
+				}
+			}
+			return nil, nil
+		}},
+		"top": {Args: []string{"n"}, Resolve: func(_ context.Context, args Args) (any, error) {
+			n, err := args.Int("n", 1)
+			if err != nil {
+				return nil, err
+			}
+			return racers[:n], nil
+		}},
+		"broken": {Resolve: func(context.Context, Args) (any, error) { return nil, errors.New("boom") }},
+	}}
+}
+
+func run(t *testing.T, req Request) (string, []Error) {
+	t.Helper()
+	resp := testSchema().Execute(context.Background(), req)
+	if resp.Data == nil {
+		return "", resp.Errors
+	}
+	data, err := json.Marshal(resp.Data)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	return string(data), resp.Errors
+}
+
+func TestExecute_SelectsFieldsInQueryOrder(t *testing.T) {
+	got, errs := run(t, Request{Query: `{ racers { tokensUsed name subagents { slug } } }`})
+	if len(errs) > 0 {
+		t.Fatalf("errors: %v", errs)
+	}
+	want := `{"racers":[{"tokensUsed":10,"name":"alpha","subagents":[{"slug":"x"},{"slug":"y"}]},{"tokensUsed":20,"name":"beta","subagents":null}]}`
+	if got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestExecute_LeavesEmbeddedAndTypename(t *testing.T) {
+	got, errs := run(t, Request{Query: `{ racer(id: "a") { __typename id startedAt tools leader { name } } }`})
+	if len(errs) > 0 {
+		t.Fatalf("errors: %v", errs)
+	}
+	want := `{"racer":{"__typename":"Racer","id":"a","startedAt":"2026-01-02T03:04:05Z","tools":{"Bash":2},"leader":null}}`
+	if got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestExecute_VariablesAliasesFragmentsDirectives(t *testing.T) {
+	got, errs := run(t, Request{
+		Query: `
+			query Board($id: String!, $n: Int = 2, $full: Boolean!) {
+				one: racer(id: $id) { ...Basics }
+				top(n: $n) { name ... @include(if: $full) { tokensUsed } }
+			}
+			fragment Basics on Racer { id name @skip(if: true) }`,
+		OperationName: "Board",
+		Variables:     map[string]any{"id": "b", "full": false},
+	})
+	if len(errs) > 0 {
+		t.Fatalf("errors: %v", errs)
+	}
+	want := `{"one":{"id":"b"},"top":[{"name":"alpha"},{"name":"beta"}]}`
+	if got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestExecute_ResolverErrorLeavesPartialData(t *testing.T) {
+	got, errs := run(t, Request{Query: `{ broken racers { name } }`})
+	want := `{"broken":null,"racers":[{"name":"alpha"},{"name":"beta"}]}`
+	if got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+	if len(errs) != 1 || errs[0].Message != "boom" || len(errs[0].Path) != 1 || errs[0].Path[0] != "broken" {
+		t.Fatalf("errors = %+v", errs)
+	}
+}
+
+func TestExecute_RequestErrors(t *testing.T) {
+	tests := []struct {
+		name, query, want string
+	}{
+		{"syntax", `{ racers { name }`, "unexpected end"},
+		{"unknown root", `{ drivers { name } }`, `cannot query field "drivers"`},
+		{"unknown argument", `{ racers(limit: 1) { name } }`, `unknown argument "limit"`},
+		{"mutation", `mutation { racers { name } }`, "not supported"},
+		{"introspection", `{ __schema { types { name } } }`, "introspection"},
+		{"missing variable", `query($id: String!) { racer(id: $id) { name } }`, "$id is required"},
+		{"fragment cycle", `{ racers { ...A } } fragment A on Racer { ...A }`, "spreads itself"},
+		{"unknown field", `{ racers { name nope } }`, `cannot query field "nope" on type Racer`},
+		{"json tag dash", `{ racers { Secret } }`, `cannot query field "Secret"`},
+		{"object without selection", `{ racers }`, "needs a selection"},
+		{"leaf with selection", `{ racers { name { x } } }`, "has no subfields"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, errs := run(t, Request{Query: tt.query})
+			if got != "" {
+				t.Errorf("data = %s, want none", got)
+			}
+			if len(errs) != 1 || !strings.Contains(errs[0].Message, tt.want) {
+				t.Errorf("errors = %+v, want %q", errs, tt.want)
+			}
+		})
+	}
+}
+
+func TestArgsInt(t *testing.T) {
+	args := Args{"a": 3, "b": float64(4), "c": 1.5, "d": "x"}
+	if n, err := args.Int("a", 0); n != 3 || err != nil {
+		t.Errorf("a = %d, %v", n, err)
+	}
+	if n, err := args.Int("b", 0); n != 4 || err != nil {
+		t.Errorf("b = %d, %v", n, err)
+	}
+	if n, err := args.Int("missing", 7); n != 7 || err != nil {
+		t.Errorf("missing = %d, %v", n, err)
+	}
+	for _, name := range []string{"c", "d"} {
+		if _, err := args.Int(name, 0); err == nil {
+			t.Errorf("%s: want error", name)
+		}
+	}
+}
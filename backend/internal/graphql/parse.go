@@ -0,0 +1,570 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// document is a parsed request: its operations and named fragments.
+type document struct {
+	operations []*operation
+	fragments  map[string][]selection
+}
+
+type operation struct {
+	name      string
+	kind      string // only "query" is executable
+	variables []variableDef
+	selection []selection
+}
+
+type variableDef struct {
+	name     string
+	nonNull  bool
+	defaultV value
+}
+
+// selection is a field, a fragment spread or an inline fragment. Exactly one
+// of field, spread and inline is set.
+type selection struct {
+	field      *field
+	spread     string
+	inline     []selection
+	directives []directive
+}
+
+type field struct {
+	alias, name string
+	args        []argument
+	selection   []selection
+}
+
+func (f *field) responseKey() string {
+	if f.alias != "" {
+		return f.alias
+	}
+	return f.name
+}
+
+type argument struct {
+	name  string
+	value value
+}
+
+type directive struct {
+	name string
+	args []argument
+}
+
+// value is a literal from the query; variables are resolved at execution.
+type value struct {
+	kind     valueKind
+	raw      string // scalar text, enum name or variable name
+	list     []value
+	object   []argument
+	resolved any // decoded scalar for int, float, string and bool
+}
+
+type valueKind int
+
+const (
+	valueNull valueKind = iota
+	valueInt
+	valueFloat
+	valueString
+	valueBool
+	valueEnum
+	valueVariable
+	valueList
+	valueObject
+)
+
+// parse reads a GraphQL executable document. It accepts the query language
+// the executor understands: operations, variables, aliases, arguments,
+// fragments and the @skip/@include directives.
+func parse(src string) (*document, error) {
+	p := &parser{lex: lexer{src: src}}
+	p.next()
+	doc := &document{fragments: map[string][]selection{}}
+	for p.tok.kind != tokEOF {
+		switch {
+		case p.tok.is(tokPunct, "{"):
+			sel, err := p.selectionSet()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, &operation{kind: "query", selection: sel})
+		case p.tok.is(tokName, "query"), p.tok.is(tokName, "mutation"), p.tok.is(tokName, "subscription"):
+			op, err := p.operation()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, op)
+		case p.tok.is(tokName, "fragment"):
+			name, sel, err := p.fragment()
+			if err != nil {
+				return nil, err
+			}
+			if _, dup := doc.fragments[name]; dup {
+				return nil, fmt.Errorf("fragment %q is defined more than once", name)
+			}
+			doc.fragments[name] = sel
+		default:
+			return nil, p.unexpected()
+		}
+	}
+	if len(doc.operations) == 0 {
+		return nil, fmt.Errorf("document has no operations")
+	}
+	return doc, nil
+}
+
+type parser struct {
+	lex lexer
+	tok token
+	err error
+}
+
+func (p *parser) next() {
+	if p.err != nil {
+		return
+	}
+	p.tok, p.err = p.lex.next()
+	if p.err != nil {
+		p.tok = token{kind: tokEOF}
+	}
+}
+
+func (p *parser) unexpected() error {
+	if p.err != nil {
+		return p.err
+	}
+	if p.tok.kind == tokEOF {
+		return fmt.Errorf("unexpected end of query")
+	}
+	return fmt.Errorf("unexpected %q at offset %d", p.tok.text, p.tok.pos)
+}
+
+func (p *parser) expect(kind tokenKind, text string) error {
+	if !p.tok.is(kind, text) {
+		return p.unexpected()
+	}
+	p.next()
+	return nil
+}
+
+func (p *parser) name() (string, error) {
+	if p.tok.kind != tokName {
+		return "", p.unexpected()
+	}
+	n := p.tok.text
+	p.next()
+	return n, nil
+}
+
+func (p *parser) operation() (*operation, error) {
+	op := &operation{kind: p.tok.text}
+	p.next()
+	if p.tok.kind == tokName {
+		op.name = p.tok.text
+		p.next()
+	}
+	if p.tok.is(tokPunct, "(") {
+		p.next()
+		for !p.tok.is(tokPunct, ")") {
+			if err := p.expect(tokPunct, "$"); err != nil {
+				return nil, err
+			}
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(tokPunct, ":"); err != nil {
+				return nil, err
+			}
+			nonNull, err := p.typeRef()
+			if err != nil {
+				return nil, err
+			}
+			def := variableDef{name: name, nonNull: nonNull}
+			if p.tok.is(tokPunct, "=") {
+				p.next()
+				if def.defaultV, err = p.value(true); err != nil {
+					return nil, err
+				}
+			}
+			op.variables = append(op.variables, def)
+		}
+		p.next()
+	}
+	if _, err := p.directives(); err != nil {
+		return nil, err
+	}
+	sel, err := p.selectionSet()
+	if err != nil {
+		return nil, err
+	}
+	op.selection = sel
+	return op, nil
+}
+
+// typeRef skips a variable type such as [String!]! and reports whether it
+// is non-null. Types are not checked beyond that: resolvers validate their
+// own arguments.
+func (p *parser) typeRef() (bool, error) {
+	if p.tok.is(tokPunct, "[") {
+		p.next()
+		if _, err := p.typeRef(); err != nil {
+			return false, err
+		}
+		if err := p.expect(tokPunct, "]"); err != nil {
+			return false, err
+		}
+	} else if _, err := p.name(); err != nil {
+		return false, err
+	}
+	if p.tok.is(tokPunct, "!") {
+		p.next()
+		return true, nil
+	}
+	return false, nil
+}
+
+func (p *parser) fragment() (string, []selection, error) {
+	p.next()
+	name, err := p.name()
+	if err != nil {
+		return "", nil, err
+	}
+	if name == "on" {
+		return "", nil, fmt.Errorf("fragment cannot be named \"on\"")
+	}
+	if err := p.expect(tokName, "on"); err != nil {
+		return "", nil, err
+	}
+	if _, err := p.name(); err != nil {
+		return "", nil, err
+	}
+	if _, err := p.directives(); err != nil {
+		return "", nil, err
+	}
+	sel, err := p.selectionSet()
+	return name, sel, err
+}
+
+func (p *parser) selectionSet() ([]selection, error) {
+	if err := p.expect(tokPunct, "{"); err != nil {
+		return nil, err
+	}
+	var out []selection
+	for !p.tok.is(tokPunct, "}") {
+		sel, err := p.selection()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, sel)
+	}
+	p.next()
+	if len(out) == 0 {
+		return nil, fmt.Errorf("empty selection set")
+	}
+	return out, nil
+}
+
+func (p *parser) selection() (selection, error) {
+	if p.tok.is(tokPunct, "...") {
+		p.next()
+		if p.tok.kind == tokName && p.tok.text != "on" {
+			name := p.tok.text
+			p.next()
+			dirs, err := p.directives()
+			return selection{spread: name, directives: dirs}, err
+		}
+		if p.tok.is(tokName, "on") {
+			p.next()
+			if _, err := p.name(); err != nil {
+				return selection{}, err
+			}
+		}
+		dirs, err := p.directives()
+		if err != nil {
+			return selection{}, err
+		}
+		sel, err := p.selectionSet()
+		return selection{inline: sel, directives: dirs}, err
+	}
+
+	f := &field{}
+	name, err := p.name()
+	if err != nil {
+		return selection{}, err
+	}
+	if p.tok.is(tokPunct, ":") {
+		p.next()
+		f.alias = name
+		if name, err = p.name(); err != nil {
+			return selection{}, err
+		}
+	}
+	f.name = name
+	if f.args, err = p.arguments(false); err != nil {
+		return selection{}, err
+	}
+	dirs, err := p.directives()
+	if err != nil {
+		return selection{}, err
+	}
+	if p.tok.is(tokPunct, "{") {
+		if f.selection, err = p.selectionSet(); err != nil {
+			return selection{}, err
+		}
+	}
+	return selection{field: f, directives: dirs}, nil
+}
+
+func (p *parser) arguments(constant bool) ([]argument, error) {
+	if !p.tok.is(tokPunct, "(") {
+		return nil, nil
+	}
+	p.next()
+	var args []argument
+	for !p.tok.is(tokPunct, ")") {
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(tokPunct, ":"); err != nil {
+			return nil, err
+		}
+		v, err := p.value(constant)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, argument{name: name, value: v})
+	}
+	p.next()
+	return args, nil
+}
+
+func (p *parser) directives() ([]directive, error) {
+	var dirs []directive
+	for p.tok.is(tokPunct, "@") {
+		p.next()
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		args, err := p.arguments(false)
+		if err != nil {
+			return nil, err
+		}
+		dirs = append(dirs, directive{name: name, args: args})
+	}
+	return dirs, nil
+}
+
+func (p *parser) value(constant bool) (value, error) {
+	t := p.tok
+	switch {
+	case t.is(tokPunct, "$"):
+		if constant {
+			return value{}, fmt.Errorf("variable not allowed at offset %d", t.pos)
+		}
+		p.next()
+		name, err := p.name()
+		return value{kind: valueVariable, raw: name}, err
+	case t.is(tokPunct, "["):
+		p.next()
+		v := value{kind: valueList}
+		for !p.tok.is(tokPunct, "]") {
+			item, err := p.value(constant)
+			if err != nil {
+				return value{}, err
+			}
+			v.list = append(v.list, item)
+		}
+		p.next()
+		return v, nil
+	case t.is(tokPunct, "{"):
+		p.next()
+		v := value{kind: valueObject}
+		for !p.tok.is(tokPunct, "}") {
+			name, err := p.name()
+			if err != nil {
+				return value{}, err
+			}
+			if err := p.expect(tokPunct, ":"); err != nil {
+				return value{}, err
+			}
+			item, err := p.value(constant)
+			if err != nil {
+				return value{}, err
+			}
+			v.object = append(v.object, argument{name: name, value: item})
+		}
+		p.next()
+		return v, nil
+	case t.kind == tokInt:
+		p.next()
+		n, err := strconv.ParseInt(t.text, 10, 64)
+		if err != nil {
+			return value{}, fmt.Errorf("bad integer %q", t.text)
+		}
+		return value{kind: valueInt, raw: t.text, resolved: int(n)}, nil
+	case t.kind == tokFloat:
+		p.next()
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return value{}, fmt.Errorf("bad float %q", t.text)
+		}
+		return value{kind: valueFloat, raw: t.text, resolved: f}, nil
+	case t.kind == tokString:
+		p.next()
+		return value{kind: valueString, raw: t.text, resolved: t.text}, nil
+	case t.kind == tokName:
+		p.next()
+		switch t.text {
+		case "true", "false":
+			return value{kind: valueBool, raw: t.text, resolved: t.text == "true"}, nil
+		case "null":
+			return value{kind: valueNull}, nil
+		}
+		return value{kind: valueEnum, raw: t.text, resolved: t.text}, nil
+	}
+	return value{}, p.unexpected()
+}
+
+// ─── Lexer ───────────────────────────────────────────────────────────────────
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokPunct
+	tokName
+	tokInt
+	tokFloat
+	tokString
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func (t token) is(kind tokenKind, text string) bool {
+	return t.kind == kind && t.text == text
+}
+
+type lexer struct {
+	src string
+	pos int
+}
+
+func (l *lexer) next() (token, error) {
+	l.skipIgnored()
+	if l.pos >= len(l.src) {
+		return token{kind: tokEOF, pos: l.pos}, nil
+	}
+	start := l.pos
+	c := l.src[l.pos]
+	switch {
+	case strings.HasPrefix(l.src[l.pos:], "..."):
+		l.pos += 3
+		return token{kind: tokPunct, text: "...", pos: start}, nil
+	case strings.IndexByte("!$()[]{}:=@|&", c) >= 0:
+		l.pos++
+		return token{kind: tokPunct, text: string(c), pos: start}, nil
+	case c == '_' || isLetter(c):
+		for l.pos < len(l.src) && (l.src[l.pos] == '_' || isLetter(l.src[l.pos]) || isDigit(l.src[l.pos])) {
+			l.pos++
+		}
+		return token{kind: tokName, text: l.src[start:l.pos], pos: start}, nil
+	case c == '-' || isDigit(c):
+		return l.number()
+	case c == '"':
+		return l.string()
+	}
+	return token{}, fmt.Errorf("unexpected character %q at offset %d", c, start)
+}
+
+// skipIgnored skips whitespace, commas and comments.
+func (l *lexer) skipIgnored() {
+	for l.pos < len(l.src) {
+		switch l.src[l.pos] {
+		case ' ', '\t', '\n', '\r', ',':
+			l.pos++
+		case '#':
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+				l.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (l *lexer) number() (token, error) {
+	start := l.pos
+	kind := tokInt
+	if l.src[l.pos] == '-' {
+		l.pos++
+	}
+	digits := func() {
+		for l.pos < len(l.src) && isDigit(l.src[l.pos]) {
+			l.pos++
+		}
+	}
+	digits()
+	if l.pos < len(l.src) && l.src[l.pos] == '.' {
+		kind = tokFloat
+		l.pos++
+		digits()
+	}
+	if l.pos < len(l.src) && (l.src[l.pos] == 'e' || l.src[l.pos] == 'E') {
+		kind = tokFloat
+		l.pos++
+		if l.pos < len(l.src) && (l.src[l.pos] == '+' || l.src[l.pos] == '-') {
+			l.pos++
+		}
+		digits()
+	}
+	return token{kind: kind, text: l.src[start:l.pos], pos: start}, nil
+}
+
+func (l *lexer) string() (token, error) {
+	start := l.pos
+	if strings.HasPrefix(l.src[l.pos:], `"""`) {
+		end := strings.Index(l.src[l.pos+3:], `"""`)
+		if end < 0 {
+			return token{}, fmt.Errorf("unterminated block string at offset %d", start)
+		}
+		text := l.src[l.pos+3 : l.pos+3+end]
+		l.pos += end + 6
+		return token{kind: tokString, text: text, pos: start}, nil
+	}
+	l.pos++
+	for l.pos < len(l.src) {
+		switch l.src[l.pos] {
+		case '\\':
+			l.pos += 2
+			continue
+		case '\n':
+			return token{}, fmt.Errorf("unterminated string at offset %d", start)
+		case '"':
+			l.pos++
+			text, err := strconv.Unquote(l.src[start:l.pos])
+			if err != nil {
+				return token{}, fmt.Errorf("bad string at offset %d", start)
+			}
+			return token{kind: tokString, text: text, pos: start}, nil
+		}
+		l.pos++
+	}
+	return token{}, fmt.Errorf("unterminated string at offset %d", start)
+}
+
+func isLetter(c byte) bool { return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' }
+func isDigit(c byte) bool  { return c >= '0' && c <= '9' }
@@ -0,0 +1,202 @@
+// Package handover lets a new server process take over the listening
+// socket of a running one, so upgrading the binary only costs connected
+// dashboards a brief reconnect instead of refused connections.
+//
+// Two mechanisms are supported:
+//
+//   - systemd socket activation: when LISTEN_PID/LISTEN_FDS name this
+//     process, the first passed descriptor (fd 3) is used as the listener.
+//   - re-exec handover: Spawn starts the current executable with the
+//     listener inherited as fd 3 and waits until the child reports it is
+//     ready (NotifyReady) before the parent shuts down.
+package handover
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Environment variables used between parent and child during a re-exec
+// handover. The listener is always passed as fd 3 and the readiness pipe
+// as fd 4 (the first two ExtraFiles).
+const (
+	envListenFD = "AGENT_RACER_LISTEN_FD"
+	envReadyFD  = "AGENT_RACER_READY_FD"
+)
+
+// systemd socket activation variables; the first passed fd is 3.
+const (
+	envSystemdPID = "LISTEN_PID"
+	envSystemdFDs = "LISTEN_FDS"
+	firstFD       = 3
+)
+
+// Source describes where a listener came from.
+type Source string
+
+const (
+	SourceNew     Source = "new"
+	SourceSystemd Source = "systemd"
+	SourceParent  Source = "parent"
+)
+
+// Listen returns the listener this process should serve on: one passed by
+// systemd or by a parent process during handover, or else a new TCP
+// listener on addr.
+func Listen(addr string) (net.Listener, Source, error) {
+	if fd, ok := inheritedFD(); ok {
+		ln, err := listenerFromFD(fd)
+		if err != nil {
+			return nil, "", err
+		}
+		src := SourceSystemd
+		if os.Getenv(envListenFD) != "" {
+			src = SourceParent
+		}
+		_ = os.Unsetenv(envListenFD)
+		_ = os.Unsetenv(envSystemdPID)
+		_ = os.Unsetenv(envSystemdFDs)
+		return ln, src, nil
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, "", err
+	}
+	return ln, SourceNew, nil
+}
+
+// inheritedFD returns the descriptor of an inherited listener, if any.
+func inheritedFD() (uintptr, bool) {
+	if v := os.Getenv(envListenFD); v != "" {
+		fd, err := strconv.Atoi(v)
+		return uintptr(fd), err == nil && fd >= firstFD
+	}
+	if pid, err := strconv.Atoi(os.Getenv(envSystemdPID)); err != nil || pid != os.Getpid() {
+		return 0, false
+	}
+	n, err := strconv.Atoi(os.Getenv(envSystemdFDs))
+	return firstFD, err == nil && n >= 1
+}
+
+func listenerFromFD(fd uintptr) (net.Listener, error) {
+	f := os.NewFile(fd, "inherited-listener")
+	if f == nil {
+		return nil, fmt.Errorf("inherited fd %d is not valid", fd)
+	}
+	defer func() { _ = f.Close() }() // net.FileListener dups the descriptor
+	ln, err := net.FileListener(f)
+	if err != nil {
+		return nil, fmt.Errorf("inherited fd %d: %w", fd, err)
+	}
+	return ln, nil
+}
+
+// NotifyReady tells the parent process of a re-exec handover that this
+// process is serving, so the parent can shut down. It is a no-op when the
+// process was not started by Spawn.
+func NotifyReady() error {
+	v := os.Getenv(envReadyFD)
+	if v == "" {
+		return nil
+	}
+	_ = os.Unsetenv(envReadyFD)
+	fd, err := strconv.Atoi(v)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", envReadyFD, err)
+	}
+	f := os.NewFile(uintptr(fd), "handover-ready")
+	if f == nil {
+		return fmt.Errorf("invalid %s: %d", envReadyFD, fd)
+	}
+	defer func() { _ = f.Close() }()
+	_, err = f.Write([]byte{1})
+	return err
+}
+
+// filer is implemented by listeners that can expose their descriptor.
+type filer interface {
+	File() (*os.File, error)
+}
+
+// Spawn starts a new instance of the current executable with args and
+// the current environment plus env, hands it ln, and waits up to timeout
+// for it to call NotifyReady. On success the caller should stop accepting
+// on ln and shut down; the child keeps serving on the same socket. On
+// failure the child is killed and the caller can keep serving.
+func Spawn(ln net.Listener, args, env []string, timeout time.Duration) (*os.Process, error) {
+	fl, ok := ln.(filer)
+	if !ok {
+		return nil, fmt.Errorf("listener %T cannot be handed over", ln)
+	}
+	lnFile, err := fl.File()
+	if err != nil {
+		return nil, fmt.Errorf("listener fd: %w", err)
+	}
+	defer func() { _ = lnFile.Close() }()
+
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	readyR, readyW, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = readyR.Close() }()
+
+	cmd := exec.Command(exe, args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.ExtraFiles = []*os.File{lnFile, readyW}
+	cmd.Env = append(childEnv(os.Environ()), env...)
+	cmd.Env = append(cmd.Env,
+		envListenFD+"="+strconv.Itoa(firstFD),
+		envReadyFD+"="+strconv.Itoa(firstFD+1))
+	err = cmd.Start()
+	_ = readyW.Close() // the child holds its own copy
+	if err != nil {
+		return nil, fmt.Errorf("start %s: %w", exe, err)
+	}
+
+	ready := make(chan error, 1)
+	go func() {
+		buf := make([]byte, 1)
+		_, err := readyR.Read(buf)
+		ready <- err
+	}()
+	select {
+	case err := <-ready:
+		if err == nil {
+			return cmd.Process, nil
+		}
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return nil, errors.New("new process exited before becoming ready")
+	case <-time.After(timeout):
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return nil, fmt.Errorf("new process not ready after %s", timeout)
+	}
+}
+
+// childEnv drops inherited-listener variables so the child only sees the
+// ones Spawn sets.
+func childEnv(env []string) []string {
+	out := make([]string, 0, len(env))
+	for _, kv := range env {
+		name, _, _ := strings.Cut(kv, "=")
+		switch name {
+		case envListenFD, envReadyFD, envSystemdPID, envSystemdFDs:
+			continue
+		}
+		out = append(out, kv)
+	}
+	return out
+}
@@ -0,0 +1,122 @@
+package handover
+
+import (
+	"bufio"
+	"net"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+)
+
+const childEnvVar = "HANDOVER_TEST_CHILD"
+
+// TestHandoverChild is the child half of TestSpawnHandsOverListener. It
+// only runs when re-executed by Spawn.
+func TestHandoverChild(t *testing.T) {
+	if os.Getenv(childEnvVar) == "" {
+		t.Skip("helper process for TestSpawnHandsOverListener")
+	}
+	ln, src, err := Listen("")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	if src != SourceParent {
+		t.Fatalf("source = %q, want %q", src, SourceParent)
+	}
+	if err := NotifyReady(); err != nil {
+		t.Fatalf("NotifyReady: %v", err)
+	}
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatalf("Accept: %v", err)
+	}
+	_, _ = conn.Write([]byte("child\n"))
+	_ = conn.Close()
+}
+
+func TestSpawnHandsOverListener(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(childEnvVar, "1")
+
+	proc, err := Spawn(ln, []string{"-test.run=^TestHandoverChild$"}, nil, 10*time.Second)
+	if err != nil {
+		t.Fatalf("Spawn: %v", err)
+	}
+	addr := ln.Addr().String()
+	_ = ln.Close() // parent stops accepting; the child still holds the socket
+
+	conn, err := net.DialTimeout("tcp", addr, 5*time.Second)
+	if err != nil {
+		t.Fatalf("dial after handover: %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil || line != "child\n" {
+		t.Fatalf("read = (%q, %v), want child", line, err)
+	}
+	state, err := proc.Wait()
+	if err != nil || !state.Success() {
+		t.Fatalf("child exit = (%v, %v), want success", state, err)
+	}
+}
+
+func TestListenInheritedFD(t *testing.T) {
+	orig, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = orig.Close() }()
+	f, err := orig.(*net.TCPListener).File()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(envListenFD, strconv.Itoa(int(f.Fd())))
+
+	ln, src, err := Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	defer func() { _ = ln.Close() }()
+	if src != SourceParent {
+		t.Errorf("source = %q, want %q", src, SourceParent)
+	}
+	if ln.Addr().String() != orig.Addr().String() {
+		t.Errorf("addr = %s, want inherited %s", ln.Addr(), orig.Addr())
+	}
+	if os.Getenv(envListenFD) != "" {
+		t.Errorf("%s should be cleared after use", envListenFD)
+	}
+}
+
+func TestListenSystemdIgnoresOtherPID(t *testing.T) {
+	t.Setenv(envSystemdPID, strconv.Itoa(os.Getpid()+1))
+	t.Setenv(envSystemdFDs, "1")
+
+	ln, src, err := Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	defer func() { _ = ln.Close() }()
+	if src != SourceNew {
+		t.Errorf("source = %q, want %q", src, SourceNew)
+	}
+}
+
+func TestChildEnv(t *testing.T) {
+	got := childEnv([]string{"HOME=/home/u", "LISTEN_FDS=1", "LISTEN_PID=9", envListenFD + "=3", envReadyFD + "=4", "PATH=/bin"})
+	if len(got) != 2 || got[0] != "HOME=/home/u" || got[1] != "PATH=/bin" {
+		t.Errorf("childEnv = %v, want HOME and PATH only", got)
+	}
+}
+
+func TestNotifyReadyWithoutParent(t *testing.T) {
+	t.Setenv(envReadyFD, "")
+	if err := NotifyReady(); err != nil {
+		t.Fatalf("NotifyReady = %v, want nil", err)
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !windows
+
+package handover
+
+import (
+	"os"
+	"syscall"
+)
+
+// UpgradeSignals returns the signals that request a re-exec handover.
+func UpgradeSignals() []os.Signal {
+	return []os.Signal{syscall.SIGUSR2}
+}
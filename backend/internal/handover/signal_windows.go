@@ -0,0 +1,11 @@
+//go:build windows
+
+package handover
+
+import "os"
+
+// UpgradeSignals returns nil: descriptor inheritance for re-exec handover
+// is not supported on Windows.
+func UpgradeSignals() []os.Signal {
+	return nil
+}
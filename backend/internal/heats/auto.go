@@ -0,0 +1,140 @@
+package heats
+
+import (
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/agent-racer/backend/internal/session"
+)
+
+// SetAutoWindow sets how close together sessions on the same project must
+// start to be raced automatically. Zero turns automatic heats off.
+func (m *Manager) SetAutoWindow(d time.Duration) {
+	m.mu.Lock()
+	m.autoWindow = d
+	m.mu.Unlock()
+}
+
+// raceKey groups sessions working on the same thing: the repository shared
+// by all its worktrees, or the working directory outside a repository.
+func raceKey(s *session.SessionState) string {
+	if s.Project != "" {
+		return s.Project
+	}
+	if s.WorkingDir != "" {
+		return filepath.Clean(s.WorkingDir)
+	}
+	return ""
+}
+
+// detectLocked starts an auto heat for each set of running sessions that
+// share a raceKey and started within autoWindow of the first of them, and
+// adds latecomers to an auto heat nobody has finished yet. A session is
+// entered in at most one heat. Caller must hold m.mu.
+func (m *Manager) detectLocked(sessions []*session.SessionState, now time.Time) {
+	present := make(map[string]bool, len(sessions))
+	for _, s := range sessions {
+		present[s.ID] = true
+	}
+	for id := range m.raced {
+		if !present[id] {
+			delete(m.raced, id)
+		}
+	}
+	if m.autoWindow <= 0 {
+		return
+	}
+
+	groups := make(map[string][]*session.SessionState)
+	for _, s := range sessions {
+		if m.raced[s.ID] || s.IsTerminal() || s.StartedAt.IsZero() {
+			continue
+		}
+		if key := raceKey(s); key != "" {
+			groups[key] = append(groups[key], s)
+		}
+	}
+	keys := make([]string, 0, len(groups))
+	for key := range groups {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		waiting := m.joinLocked(key, groups[key])
+		sort.Slice(waiting, func(i, j int) bool {
+			if !waiting[i].StartedAt.Equal(waiting[j].StartedAt) {
+				return waiting[i].StartedAt.Before(waiting[j].StartedAt)
+			}
+			return waiting[i].ID < waiting[j].ID
+		})
+		for len(waiting) >= MinParticipants {
+			n := 1
+			for n < len(waiting) && waiting[n].StartedAt.Sub(waiting[0].StartedAt) <= m.autoWindow {
+				n++
+			}
+			if n >= MinParticipants {
+				m.startAutoLocked(key, waiting[:n], now)
+			}
+			// A session left on its own stays unraced, so one that starts
+			// later can still pair with it on a later tick.
+			waiting = waiting[n:]
+		}
+	}
+}
+
+// joinLocked adds those of candidates that started within autoWindow of an
+// undecided auto heat on key to it, and returns the rest.
+func (m *Manager) joinLocked(key string, candidates []*session.SessionState) []*session.SessionState {
+	var rest []*session.SessionState
+	for _, s := range candidates {
+		var joined bool
+		for _, h := range m.heats {
+			if !h.Auto || h.key != key || h.Status == StatusFinished || len(h.finishedAt) > 0 {
+				continue
+			}
+			if s.StartedAt.Sub(h.StartAt) > m.autoWindow {
+				continue
+			}
+			h.ids = append(h.ids, s.ID)
+			h.last[s.ID] = s
+			m.raced[s.ID] = true
+			joined = true
+			break
+		}
+		if !joined {
+			rest = append(rest, s)
+		}
+	}
+	return rest
+}
+
+// startAutoLocked starts an auto heat between field, which is sorted by
+// start time. Caller must hold m.mu.
+func (m *Manager) startAutoLocked(key string, field []*session.SessionState, now time.Time) {
+	h := &heat{
+		Heat: Heat{
+			ID:      m.newIDLocked(now),
+			Name:    field[0].Name,
+			StartAt: field[0].StartedAt,
+			Finish:  Finish{Kind: FinishFirst},
+			Metric:  m.metric,
+			Auto:    true,
+		},
+		key:        key,
+		ids:        make([]string, 0, len(field)),
+		last:       make(map[string]*session.SessionState, len(field)),
+		baseLaps:   make(map[string]int, len(field)),
+		finishedAt: make(map[string]time.Time),
+	}
+	if field[0].Project != "" {
+		h.Name = field[0].Project
+	}
+	for _, s := range field {
+		h.ids = append(h.ids, s.ID)
+		h.last[s.ID] = s
+		m.raced[s.ID] = true
+	}
+	m.heats = append(m.heats, h)
+}
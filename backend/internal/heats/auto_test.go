@@ -0,0 +1,85 @@
+package heats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/agent-racer/backend/internal/session"
+)
+
+func starter(id, project string, started time.Duration) *session.SessionState {
+	return &session.SessionState{ID: id, Name: id, Project: project, Activity: session.Thinking, StartedAt: t0.Add(started)}
+}
+
+func TestAutoHeatRacesSessionsStartedTogether(t *testing.T) {
+	a, b := starter("a", "api", 0), starter("b", "api", 30*time.Second)
+	other := starter("x", "web", 10*time.Second)
+	late := starter("late", "api", 10*time.Minute)
+	f := newFixture(a, b, other, late)
+	f.m.SetAutoWindow(time.Minute)
+	f.now = t0.Add(11 * time.Minute)
+
+	f.m.Tick()
+	heats := f.m.List()
+	if len(heats) != 1 {
+		t.Fatalf("got %d heats, want one for a and b", len(heats))
+	}
+	h := heats[0]
+	if !h.Auto || h.Name != "api" || h.Finish.Kind != FinishFirst || !h.StartAt.Equal(a.StartedAt) || len(h.Standings) != 2 {
+		t.Fatalf("auto heat = %+v", h)
+	}
+
+	// b completes first and wins; the heat is over without waiting for a.
+	done := t0.Add(12 * time.Minute)
+	f.now = done
+	b.Activity, b.CompletedAt = session.Complete, &done
+	f.m.Tick()
+	if len(f.results) != 1 || f.results[0].WinnerID != "b" || order(f.results[0]) != "b,a" {
+		t.Fatalf("results = %+v", f.results)
+	}
+
+	// Racing once is enough: a and b are not matched again.
+	f.m.Tick()
+	if n := len(f.m.List()); n != 1 {
+		t.Errorf("got %d heats after the race, want 1", n)
+	}
+}
+
+func TestAutoHeatLatecomerJoinsUndecidedRace(t *testing.T) {
+	a, b := starter("a", "api", 0), starter("b", "api", 20*time.Second)
+	f := newFixture(a, b)
+	f.m.SetAutoWindow(time.Minute)
+	f.now = t0.Add(30 * time.Second)
+	f.m.Tick()
+
+	c := starter("c", "api", 50*time.Second)
+	f.states = append(f.states, c)
+	f.now = t0.Add(time.Minute)
+	f.m.Tick()
+
+	heats := f.m.List()
+	if len(heats) != 1 || len(heats[0].Standings) != 3 {
+		t.Fatalf("heats = %+v, want c to join the race", heats)
+	}
+}
+
+func TestAutoHeatSkipsExplicitHeatsAndDisabledWindow(t *testing.T) {
+	a, b := starter("a", "api", 0), starter("b", "api", time.Second)
+	f := newFixture(a, b)
+	f.now = t0.Add(time.Minute)
+
+	f.m.Tick()
+	if n := len(f.m.List()); n != 0 {
+		t.Fatalf("window 0 started %d heats", n)
+	}
+
+	if _, err := f.m.Create(Request{SessionIDs: []string{"a", "b"}}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	f.m.SetAutoWindow(time.Minute)
+	f.m.Tick()
+	heats := f.m.List()
+	if len(heats) != 1 || heats[0].Auto {
+		t.Errorf("heats = %+v, want only the explicit one", heats)
+	}
+}
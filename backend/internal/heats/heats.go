@@ -0,0 +1,485 @@
+// Package heats runs explicit races between a chosen group of sessions,
+// e.g. the same task launched in three models side by side. A heat has its
+// own start time, participants and finish condition, and reports standings
+// as the sessions progress. Sessions that start on the same project close
+// together are raced automatically, first to complete wins.
+package heats
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/agent-racer/backend/internal/gamification"
+	"github.com/agent-racer/backend/internal/session"
+)
+
+// FinishKind decides when a heat is over.
+type FinishKind string
+
+const (
+	// FinishAll ends the heat once every participant has completed or
+	// dropped out. It is the default.
+	FinishAll FinishKind = "all"
+	// FinishFirst ends the heat as soon as one participant completes.
+	FinishFirst FinishKind = "first"
+	// FinishLaps ends the heat when a participant reaches Finish.Laps laps.
+	FinishLaps FinishKind = "laps"
+)
+
+// Status is where a heat is in its life.
+type Status string
+
+const (
+	StatusPending  Status = "pending" // StartAt is in the future
+	StatusRunning  Status = "running"
+	StatusFinished Status = "finished"
+)
+
+const (
+	// PollInterval is how often standings are recomputed.
+	PollInterval = time.Second
+	// MinParticipants is the smallest field a heat can have.
+	MinParticipants = 2
+	// maxFinished bounds how many finished heats List keeps reporting.
+	maxFinished = 20
+)
+
+// Finish is a heat's finish condition.
+type Finish struct {
+	Kind FinishKind `json:"kind"`
+	Laps int        `json:"laps,omitempty"` // FinishLaps only
+}
+
+// Request is the body of POST /api/heats.
+type Request struct {
+	Name       string                 `json:"name"`
+	SessionIDs []string               `json:"sessionIds"`
+	StartAt    *time.Time             `json:"startAt,omitempty"` // defaults to now
+	Finish     Finish                 `json:"finish"`
+	Metric     session.ProgressMetric `json:"metric,omitempty"` // defaults to race.progress_metric
+}
+
+// Standing is one participant's place in a heat.
+type Standing struct {
+	SessionID  string           `json:"sessionId"`
+	Name       string           `json:"name"`
+	Model      string           `json:"model,omitempty"`
+	Rank       int              `json:"rank"`
+	Activity   session.Activity `json:"activity"`
+	LapCount   int              `json:"lapCount"`
+	Finished   bool             `json:"finished"`
+	Out        bool             `json:"out"` // errored, lost or completed short of the laps
+	FinishedAt *time.Time       `json:"finishedAt,omitempty"`
+}
+
+// Heat is a snapshot of one heat.
+type Heat struct {
+	ID        string                 `json:"id"`
+	Name      string                 `json:"name"`
+	Status    Status                 `json:"status"`
+	StartAt   time.Time              `json:"startAt"`
+	EndedAt   *time.Time             `json:"endedAt,omitempty"`
+	Finish    Finish                 `json:"finish"`
+	Metric    session.ProgressMetric `json:"metric"`
+	WinnerID  string                 `json:"winnerId,omitempty"`
+	Auto      bool                   `json:"auto,omitempty"` // detected rather than started via Create
+	Standings []Standing             `json:"standings"`
+}
+
+// Result converts a finished heat into the record kept in stats history.
+func (h Heat) Result() gamification.HeatResult {
+	r := gamification.HeatResult{
+		ID:        h.ID,
+		Name:      h.Name,
+		StartedAt: h.StartAt,
+		WinnerID:  h.WinnerID,
+		Auto:      h.Auto,
+		Entries:   make([]gamification.HeatEntry, 0, len(h.Standings)),
+	}
+	if h.EndedAt != nil {
+		r.EndedAt = *h.EndedAt
+	}
+	for _, st := range h.Standings {
+		r.Entries = append(r.Entries, gamification.HeatEntry{
+			SessionID: st.SessionID,
+			Name:      st.Name,
+			Model:     st.Model,
+			Rank:      st.Rank,
+			Finished:  st.Finished,
+		})
+	}
+	return r
+}
+
+func (h Heat) clone() Heat {
+	h.Standings = append([]Standing(nil), h.Standings...)
+	if h.EndedAt != nil {
+		t := *h.EndedAt
+		h.EndedAt = &t
+	}
+	return h
+}
+
+type heat struct {
+	Heat
+	key        string // raceKey of the participants; auto heats only
+	ids        []string
+	last       map[string]*session.SessionState // last state seen per participant
+	baseLaps   map[string]int                   // LapCount when the heat started
+	finishedAt map[string]time.Time
+}
+
+// Manager tracks heats and recomputes their standings.
+type Manager struct {
+	sessions func() []*session.SessionState
+	now      func() time.Time
+
+	mu         sync.Mutex
+	metric     session.ProgressMetric
+	autoWindow time.Duration   // 0 disables automatic heats
+	raced      map[string]bool // sessions ever entered in a heat
+	nextID     int
+	heats      []*heat
+	onUpdate   func(Heat)
+	onResult   func(Heat)
+}
+
+// New returns a manager whose participants are looked up in sessions. It
+// should return privacy-filtered sessions, since standings go to every
+// client; heats then refer to sessions by the IDs clients see.
+func New(sessions func() []*session.SessionState) *Manager {
+	return &Manager{
+		sessions: sessions,
+		now:      time.Now,
+		metric:   session.MetricContext,
+		raced:    make(map[string]bool),
+	}
+}
+
+// OnUpdate registers fn to receive a heat whenever its standings or status
+// change. Must be called before Run.
+func (m *Manager) OnUpdate(fn func(Heat)) {
+	m.onUpdate = fn
+}
+
+// OnResult registers fn to receive each heat once, when it finishes. Must
+// be called before Run.
+func (m *Manager) OnResult(fn func(Heat)) {
+	m.onResult = fn
+}
+
+// SetMetric sets the ranking metric for heats created without one.
+func (m *Manager) SetMetric(metric session.ProgressMetric) {
+	m.mu.Lock()
+	m.metric = metric
+	m.mu.Unlock()
+}
+
+// Validate reports the first problem with r that does not depend on which
+// sessions are running.
+func (r Request) Validate() error {
+	if len(r.SessionIDs) < MinParticipants {
+		return fmt.Errorf("a heat needs at least %d sessions", MinParticipants)
+	}
+	seen := make(map[string]bool, len(r.SessionIDs))
+	for _, id := range r.SessionIDs {
+		if seen[id] {
+			return fmt.Errorf("session %q listed twice", id)
+		}
+		seen[id] = true
+	}
+	switch r.Finish.Kind {
+	case "", FinishAll, FinishFirst:
+	case FinishLaps:
+		if r.Finish.Laps <= 0 {
+			return fmt.Errorf("finish.laps must be > 0")
+		}
+	default:
+		return fmt.Errorf("unknown finish kind %q", r.Finish.Kind)
+	}
+	if r.Metric != "" && !r.Metric.Valid() {
+		return fmt.Errorf("unknown metric %q", r.Metric)
+	}
+	return nil
+}
+
+// Create starts a heat. Every participant must be a current session.
+func (m *Manager) Create(r Request) (Heat, error) {
+	if err := r.Validate(); err != nil {
+		return Heat{}, err
+	}
+	byID := make(map[string]*session.SessionState)
+	for _, s := range m.sessions() {
+		byID[s.ID] = s
+	}
+	last := make(map[string]*session.SessionState, len(r.SessionIDs))
+	for _, id := range r.SessionIDs {
+		s, ok := byID[id]
+		if !ok {
+			return Heat{}, fmt.Errorf("unknown session %q", id)
+		}
+		if s.IsTerminal() {
+			return Heat{}, fmt.Errorf("session %q has already finished", id)
+		}
+		last[id] = s
+	}
+
+	now := m.now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	h := &heat{
+		Heat: Heat{
+			ID:      m.newIDLocked(now),
+			Name:    r.Name,
+			StartAt: now,
+			Finish:  r.Finish,
+			Metric:  r.Metric,
+		},
+		ids:        append([]string(nil), r.SessionIDs...),
+		last:       last,
+		finishedAt: make(map[string]time.Time),
+	}
+	if h.Name == "" {
+		h.Name = fmt.Sprintf("Heat %d", m.nextID)
+	}
+	if r.StartAt != nil {
+		h.StartAt = *r.StartAt
+	}
+	if h.Finish.Kind == "" {
+		h.Finish.Kind = FinishAll
+	}
+	if h.Metric == "" {
+		h.Metric = m.metric
+	}
+	h.advance(now)
+	m.heats = append(m.heats, h)
+	for _, id := range h.ids {
+		m.raced[id] = true
+	}
+	return h.clone(), nil
+}
+
+// newIDLocked returns the ID for a heat created at now. The time keeps IDs
+// unique across restarts in the saved history. Caller must hold m.mu.
+func (m *Manager) newIDLocked(now time.Time) string {
+	m.nextID++
+	return fmt.Sprintf("heat-%s-%d", now.UTC().Format("20060102T150405"), m.nextID)
+}
+
+// List returns running and pending heats plus the most recently finished
+// ones, oldest first.
+func (m *Manager) List() []Heat {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make([]Heat, 0, len(m.heats))
+	for _, h := range m.heats {
+		out = append(out, h.clone())
+	}
+	return out
+}
+
+// Get returns the heat with the given ID.
+func (m *Manager) Get(id string) (Heat, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, h := range m.heats {
+		if h.ID == id {
+			return h.clone(), true
+		}
+	}
+	return Heat{}, false
+}
+
+// Run recomputes standings every PollInterval until ctx is done.
+func (m *Manager) Run(ctx context.Context) {
+	ticker := time.NewTicker(PollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.Tick()
+		}
+	}
+}
+
+// Tick recomputes the standings of every unfinished heat and reports the
+// ones that changed.
+func (m *Manager) Tick() {
+	sessions := m.sessions()
+	byID := make(map[string]*session.SessionState, len(sessions))
+	for _, s := range sessions {
+		byID[s.ID] = s
+	}
+	now := m.now()
+
+	var updated, finished []Heat
+	m.mu.Lock()
+	m.detectLocked(sessions, now)
+	for _, h := range m.heats {
+		if h.Status == StatusFinished {
+			continue
+		}
+		for _, id := range h.ids {
+			if s, ok := byID[id]; ok {
+				h.last[id] = s
+			}
+		}
+		before := h.clone()
+		h.advance(now)
+		if h.Status == before.Status && reflect.DeepEqual(h.Standings, before.Standings) {
+			continue
+		}
+		updated = append(updated, h.clone())
+		if h.Status == StatusFinished {
+			finished = append(finished, h.clone())
+		}
+	}
+	m.pruneLocked()
+	m.mu.Unlock()
+
+	for _, h := range updated {
+		if m.onUpdate != nil {
+			m.onUpdate(h)
+		}
+	}
+	for _, h := range finished {
+		if m.onResult != nil {
+			m.onResult(h)
+		}
+	}
+}
+
+// pruneLocked drops the oldest finished heats beyond maxFinished. Caller
+// must hold m.mu.
+func (m *Manager) pruneLocked() {
+	done := 0
+	for _, h := range m.heats {
+		if h.Status == StatusFinished {
+			done++
+		}
+	}
+	if done <= maxFinished {
+		return
+	}
+	kept := m.heats[:0]
+	for _, h := range m.heats {
+		if h.Status == StatusFinished && done > maxFinished {
+			done--
+			continue
+		}
+		kept = append(kept, h)
+	}
+	m.heats = kept
+}
+
+// advance recomputes standings from the last seen states and applies the
+// finish condition.
+func (h *heat) advance(now time.Time) {
+	if now.Before(h.StartAt) {
+		h.Status = StatusPending
+	} else if h.Status != StatusFinished {
+		h.Status = StatusRunning
+	}
+	// Laps count from the green flag, not from each session's own start.
+	if h.Status == StatusRunning && h.baseLaps == nil {
+		// Auto heats started with the sessions themselves, so their
+		// baseline stays at zero.
+		h.baseLaps = make(map[string]int, len(h.ids))
+		for _, id := range h.ids {
+			h.baseLaps[id] = h.last[id].LapCount
+		}
+	}
+
+	standings := make([]Standing, 0, len(h.ids))
+	for _, id := range h.ids {
+		s := h.last[id]
+		st := Standing{
+			SessionID: id,
+			Name:      s.Name,
+			Model:     s.Model,
+			Activity:  s.Activity,
+			LapCount:  s.LapCount - h.baseLaps[id],
+		}
+		if h.Status == StatusRunning {
+			h.markFinish(&st, s, now)
+		}
+		if at, ok := h.finishedAt[id]; ok {
+			st.Finished = true
+			at := at
+			st.FinishedAt = &at
+		}
+		standings = append(standings, st)
+	}
+
+	sort.SliceStable(standings, func(i, j int) bool {
+		a, b := standings[i], standings[j]
+		if a.Finished != b.Finished {
+			return a.Finished
+		}
+		if a.Finished {
+			return a.FinishedAt.Before(*b.FinishedAt)
+		}
+		if a.Out != b.Out {
+			return !a.Out
+		}
+		if h.Finish.Kind == FinishLaps && a.LapCount != b.LapCount {
+			return a.LapCount > b.LapCount
+		}
+		return h.Metric.Ahead(h.last[a.SessionID], h.last[b.SessionID])
+	})
+	for i := 0; i < len(standings); i++ {
+		standings[i].Rank = i + 1
+	}
+	h.Standings = standings
+
+	if h.Status == StatusRunning && h.over() {
+		h.Status = StatusFinished
+		end := now
+		h.EndedAt = &end
+		if standings[0].Finished {
+			h.WinnerID = standings[0].SessionID
+		}
+	}
+}
+
+// markFinish records when s crossed the line, or marks it out of the heat.
+func (h *heat) markFinish(st *Standing, s *session.SessionState, now time.Time) {
+	if _, ok := h.finishedAt[s.ID]; ok {
+		return
+	}
+	switch {
+	case h.Finish.Kind == FinishLaps && st.LapCount >= h.Finish.Laps:
+		h.finishedAt[s.ID] = now
+	case h.Finish.Kind != FinishLaps && s.Activity == session.Complete:
+		at := now
+		if s.CompletedAt != nil {
+			at = *s.CompletedAt
+		}
+		h.finishedAt[s.ID] = at
+	case s.IsTerminal():
+		st.Out = true
+	}
+}
+
+func (h *heat) over() bool {
+	finished, out := 0, 0
+	for _, st := range h.Standings {
+		switch {
+		case st.Finished:
+			finished++
+		case st.Out:
+			out++
+		}
+	}
+	if finished+out == len(h.Standings) {
+		return true
+	}
+	return h.Finish.Kind != FinishAll && finished > 0
+}
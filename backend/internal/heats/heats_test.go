@@ -0,0 +1,189 @@
+package heats
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agent-racer/backend/internal/session"
+)
+
+var t0 = time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+
+type fixture struct {
+	m       *Manager
+	states  []*session.SessionState
+	now     time.Time
+	updates []Heat
+	results []Heat
+}
+
+func newFixture(states ...*session.SessionState) *fixture {
+	f := &fixture{states: states, now: t0}
+	f.m = New(func() []*session.SessionState { return f.states })
+	f.m.now = func() time.Time { return f.now }
+	f.m.OnUpdate(func(h Heat) { f.updates = append(f.updates, h) })
+	f.m.OnResult(func(h Heat) { f.results = append(f.results, h) })
+	return f
+}
+
+func racer(id, model string, util float64) *session.SessionState {
+	return &session.SessionState{ID: id, Name: id, Model: model, Activity: session.Thinking, ContextUtilization: util}
+}
+
+func order(h Heat) string {
+	var ids []string
+	for _, st := range h.Standings {
+		ids = append(ids, st.SessionID)
+	}
+	return strings.Join(ids, ",")
+}
+
+func TestHeatRunsToAllFinished(t *testing.T) {
+	opus, sonnet, haiku := racer("opus", "claude-opus-4-5", 0.2), racer("sonnet", "claude-sonnet-4-5", 0.4), racer("haiku", "claude-haiku-4-5", 0.1)
+	f := newFixture(opus, sonnet, haiku)
+	h, err := f.m.Create(Request{Name: "refactor", SessionIDs: []string{"opus", "sonnet", "haiku"}})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if h.Status != StatusRunning || h.Finish.Kind != FinishAll || order(h) != "sonnet,opus,haiku" {
+		t.Fatalf("new heat = %s %s %s", h.Status, h.Finish.Kind, order(h))
+	}
+
+	// Nothing changed: no update.
+	f.m.Tick()
+	if len(f.updates) != 0 {
+		t.Fatalf("unchanged tick reported %d updates", len(f.updates))
+	}
+
+	// Haiku finishes first even though it was last on progress.
+	done := t0.Add(time.Minute)
+	f.now = done
+	haiku.Activity, haiku.CompletedAt = session.Complete, &done
+	f.m.Tick()
+	if len(f.updates) != 1 || order(f.updates[0]) != "haiku,sonnet,opus" || !f.updates[0].Standings[0].Finished {
+		t.Fatalf("after finish: %+v", f.updates)
+	}
+
+	// Opus crashes; sonnet completes and the heat is over.
+	opus.Activity = session.Errored
+	sonnet.Activity = session.Complete
+	f.now = t0.Add(2 * time.Minute)
+	f.m.Tick()
+	if len(f.results) != 1 {
+		t.Fatalf("got %d results, want 1", len(f.results))
+	}
+	res := f.results[0]
+	if res.Status != StatusFinished || res.WinnerID != "haiku" || order(res) != "haiku,sonnet,opus" {
+		t.Errorf("result = %s winner %q order %s", res.Status, res.WinnerID, order(res))
+	}
+	if !res.Standings[2].Out || res.EndedAt == nil || !res.EndedAt.Equal(f.now) {
+		t.Errorf("result standings = %+v, ended %v", res.Standings, res.EndedAt)
+	}
+
+	r := res.Result()
+	if r.WinnerID != "haiku" || len(r.Entries) != 3 || r.Entries[0].Model != "claude-haiku-4-5" || !r.EndedAt.Equal(f.now) {
+		t.Errorf("Result() = %+v", r)
+	}
+
+	// Finished heats are left alone.
+	f.m.Tick()
+	if len(f.results) != 1 {
+		t.Errorf("finished heat reported again")
+	}
+}
+
+func TestHeatFirstToLaps(t *testing.T) {
+	a, b := racer("a", "", 0.5), racer("b", "", 0.5)
+	a.LapCount, b.LapCount = 4, 0
+	f := newFixture(a, b)
+	if _, err := f.m.Create(Request{SessionIDs: []string{"a", "b"}, Finish: Finish{Kind: FinishLaps, Laps: 2}}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	// Laps count from the start of the heat, so a's head start is ignored.
+	a.LapCount, b.LapCount = 5, 2
+	f.m.Tick()
+	if len(f.results) != 1 {
+		t.Fatalf("got %d results, want 1", len(f.results))
+	}
+	res := f.results[0]
+	if res.WinnerID != "b" || res.Standings[0].LapCount != 2 || res.Standings[1].LapCount != 1 {
+		t.Errorf("result = %+v", res)
+	}
+}
+
+func TestHeatPendingUntilStart(t *testing.T) {
+	a, b := racer("a", "", 0.1), racer("b", "", 0.2)
+	f := newFixture(a, b)
+	start := t0.Add(time.Minute)
+	h, err := f.m.Create(Request{SessionIDs: []string{"a", "b"}, StartAt: &start, Finish: Finish{Kind: FinishFirst}})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if h.Status != StatusPending || h.Name != "Heat 1" {
+		t.Fatalf("heat = %+v, want pending Heat 1", h)
+	}
+
+	f.now = start
+	f.m.Tick()
+	if len(f.updates) != 1 || f.updates[0].Status != StatusRunning {
+		t.Fatalf("updates = %+v, want running", f.updates)
+	}
+	b.Activity = session.Complete
+	f.m.Tick()
+	if len(f.results) != 1 || f.results[0].WinnerID != "b" {
+		t.Errorf("first to finish should win at once: %+v", f.results)
+	}
+}
+
+func TestCreateRejects(t *testing.T) {
+	done := racer("done", "", 0)
+	done.Activity = session.Complete
+	f := newFixture(racer("a", "", 0), racer("b", "", 0), done)
+
+	tests := []struct {
+		name string
+		req  Request
+		want string
+	}{
+		{"too few", Request{SessionIDs: []string{"a"}}, "at least"},
+		{"duplicate", Request{SessionIDs: []string{"a", "a"}}, "twice"},
+		{"unknown session", Request{SessionIDs: []string{"a", "zz"}}, "unknown session"},
+		{"finished session", Request{SessionIDs: []string{"a", "done"}}, "already finished"},
+		{"bad finish", Request{SessionIDs: []string{"a", "b"}, Finish: Finish{Kind: "photo"}}, "finish kind"},
+		{"no laps", Request{SessionIDs: []string{"a", "b"}, Finish: Finish{Kind: FinishLaps}}, "finish.laps"},
+		{"bad metric", Request{SessionIDs: []string{"a", "b"}, Metric: "speed"}, "metric"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := f.m.Create(tt.req)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+	if len(f.m.List()) != 0 {
+		t.Error("rejected requests created heats")
+	}
+}
+
+func TestListKeepsRecentFinished(t *testing.T) {
+	a, b := racer("a", "", 0), racer("b", "", 0)
+	f := newFixture(a, b)
+	for i := 0; i < maxFinished+3; i++ {
+		if _, err := f.m.Create(Request{SessionIDs: []string{"a", "b"}, Finish: Finish{Kind: FinishFirst}}); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		a.Activity = session.Complete
+		f.m.Tick()
+		a.Activity = session.Thinking
+	}
+	heats := f.m.List()
+	if len(heats) != maxFinished {
+		t.Fatalf("List() = %d heats, want %d", len(heats), maxFinished)
+	}
+	if _, ok := f.m.Get(heats[0].ID); !ok {
+		t.Error("Get cannot find a listed heat")
+	}
+}
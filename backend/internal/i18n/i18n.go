@@ -0,0 +1,18 @@
+// Package i18n lists the languages the server can write its user-facing
+// text in: achievement names and descriptions, and commentary lines. Each
+// of those packages keeps its own catalog and falls back to English for
+// anything a catalog lacks.
+package i18n
+
+import "slices"
+
+// Default is the language the built-in text is written in.
+const Default = "en"
+
+// Languages lists the supported language codes, Default first.
+var Languages = []string{Default, "de", "es"}
+
+// Supported reports whether lang is one of Languages.
+func Supported(lang string) bool {
+	return slices.Contains(Languages, lang)
+}
@@ -38,14 +38,16 @@ func (t TokenUsage) TotalContext() int {
 
 // Entry is the top-level structure of a Claude JSONL line.
 type Entry struct {
-	Type      string          `json:"type"`
-	Subtype   string          `json:"subtype,omitempty"`
-	UUID      string          `json:"uuid"`
-	SessionID string          `json:"sessionId"`
-	Slug      string          `json:"slug"`
-	Timestamp string          `json:"timestamp"`
-	Cwd       string          `json:"cwd"`
-	Message   json.RawMessage `json:"message"`
+	Type             string          `json:"type"`
+	Subtype          string          `json:"subtype,omitempty"`
+	UUID             string          `json:"uuid"`
+	SessionID        string          `json:"sessionId"`
+	Slug             string          `json:"slug"`
+	Timestamp        string          `json:"timestamp"`
+	Cwd              string          `json:"cwd"`
+	IsMeta           bool            `json:"isMeta,omitempty"`           // injected by the client, not typed by the user
+	IsCompactSummary bool            `json:"isCompactSummary,omitempty"` // user message holding the summary a compaction left
+	Message          json.RawMessage `json:"message"`
 }
 
 // ParseTimestamp parses the entry's RFC3339Nano timestamp.
@@ -62,6 +64,7 @@ func (e *Entry) ParseTimestamp() (time.Time, bool) {
 
 // MessageContent is the message object inside assistant/user entries.
 type MessageContent struct {
+	ID      string          `json:"id"`
 	Model   string          `json:"model"`
 	Role    string          `json:"role"`
 	Usage   *TokenUsage     `json:"usage,omitempty"`
@@ -0,0 +1,387 @@
+// Package launch starts new agent sessions from named templates. Each
+// launch opens a tmux window running the template's command and
+// pre-registers a placeholder session in the store, so the car is on the
+// track before the agent has written its first log line. The monitor
+// adopts the placeholder once the session's log turns up.
+//
+// Templates can be chained into pipelines, where each stage launches when
+// the previous stage's session completes.
+package launch
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/agent-racer/backend/internal/session"
+)
+
+const (
+	// ClaimTimeout is how long a placeholder waits for its session's log
+	// before it is dropped from the store.
+	ClaimTimeout = 2 * time.Minute
+	// DefaultSource is the session source a template launches when it
+	// does not name one.
+	DefaultSource = "claude"
+
+	// SessionIDPlaceholder and ModelPlaceholder are substituted in a
+	// template's command.
+	SessionIDPlaceholder = "{session_id}"
+	ModelPlaceholder     = "{model}"
+
+	sweepInterval = 10 * time.Second
+	// terminalBuffer is how many session endings can queue up for the
+	// pipeline runner.
+	terminalBuffer = 64
+	// paneFormat makes tmux print the new pane as "session:window.pane".
+	paneFormat = "#{session_name}:#{window_index}.#{pane_index}"
+)
+
+var (
+	ErrUnknownTemplate = errors.New("unknown launch template")
+	ErrInvalidModel    = errors.New("invalid model")
+	ErrNoTmux          = errors.New("tmux not available")
+)
+
+// maxModelLen bounds the model a launch request may ask for.
+const maxModelLen = 128
+
+// validName matches template names and tmux session/window names.
+func validName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+// validModel matches model names a launch request may substitute into a
+// command: letters and digits, then those plus '.', '-', '_', ':' and '/'.
+// Nothing a shell would interpret gets through, and a leading '-' cannot
+// turn the model into a flag.
+func validModel(s string) bool {
+	if s == "" || len(s) > maxModelLen {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' {
+			continue
+		}
+		if i == 0 || !(c == '.' || c == '-' || c == '_' || c == ':' || c == '/') {
+			return false
+		}
+	}
+	return true
+}
+
+// Template is a named way to start an agent.
+type Template struct {
+	Name string `yaml:"name" json:"name"`
+	// Command is the argv to run. "{session_id}" is replaced with the
+	// session ID the placeholder is registered under, so an agent that
+	// accepts one (claude --session-id) is adopted as soon as it logs;
+	// "{model}" is replaced with the model.
+	Command []string `yaml:"command" json:"command"`
+	Cwd     string   `yaml:"cwd" json:"cwd,omitempty"`
+	Model   string   `yaml:"model" json:"model,omitempty"`
+	// Source is the session source that will discover the agent.
+	Source string `yaml:"source" json:"source"`
+	// TmuxSession is the tmux session to open the window in, created if
+	// missing. Empty uses the most recently used session.
+	TmuxSession string `yaml:"tmux_session" json:"tmuxSession,omitempty"`
+	// Window names the tmux window; empty uses the template name.
+	Window string `yaml:"window" json:"window,omitempty"`
+}
+
+// Equal reports whether t and o describe the same template.
+func (t Template) Equal(o Template) bool {
+	return t.Name == o.Name && slices.Equal(t.Command, o.Command) && t.Cwd == o.Cwd &&
+		t.Model == o.Model && t.Source == o.Source && t.TmuxSession == o.TmuxSession && t.Window == o.Window
+}
+
+// ValidateTemplates returns one message per problem in templates.
+func ValidateTemplates(templates []Template) []string {
+	var errs []string
+	names := make(map[string]bool, len(templates))
+	for i := 0; i < len(templates); i++ {
+		t := templates[i]
+		if !validName(t.Name) {
+			errs = append(errs, fmt.Sprintf("template %d: name must be non-empty letters, digits, '.', '-' or '_'", i))
+		} else if names[t.Name] {
+			errs = append(errs, fmt.Sprintf("template %q: duplicate name", t.Name))
+		}
+		names[t.Name] = true
+		if len(t.Command) == 0 || t.Command[0] == "" {
+			errs = append(errs, fmt.Sprintf("template %q: command is required", t.Name))
+		}
+		if t.Cwd != "" && !filepath.IsAbs(t.Cwd) {
+			errs = append(errs, fmt.Sprintf("template %q: cwd must be absolute, got %q", t.Name, t.Cwd))
+		}
+		if t.TmuxSession != "" && !validName(t.TmuxSession) {
+			errs = append(errs, fmt.Sprintf("template %q: invalid tmux_session %q", t.Name, t.TmuxSession))
+		}
+		if t.Window != "" && !validName(t.Window) {
+			errs = append(errs, fmt.Sprintf("template %q: invalid window %q", t.Name, t.Window))
+		}
+	}
+	return errs
+}
+
+// Settings are the launcher options taken from config.
+type Settings struct {
+	Templates []Template
+	Pipelines []Pipeline
+}
+
+// Request is the body of POST /api/launch.
+type Request struct {
+	Template string `json:"template"`
+	// Model overrides the template's model.
+	Model string `json:"model,omitempty"`
+}
+
+// runTmux runs tmux with args and returns its trimmed stdout. Replaced in
+// tests.
+var runTmux = func(args ...string) (string, error) {
+	path, err := exec.LookPath("tmux")
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrNoTmux, err)
+	}
+	out, err := exec.Command(path, args...).Output()
+	if err != nil {
+		var ee *exec.ExitError
+		if errors.As(err, &ee) && len(ee.Stderr) > 0 {
+			return "", fmt.Errorf("tmux %s: %s", args[0], strings.TrimSpace(string(ee.Stderr)))
+		}
+		return "", fmt.Errorf("tmux %s: %w", args[0], err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// Launcher starts sessions from templates and expires placeholders that
+// are never claimed.
+type Launcher struct {
+	store *session.Store
+	now   func() time.Time
+
+	terminal chan *session.SessionState
+
+	mu         sync.Mutex
+	templates  []Template
+	pipelines  []Pipeline
+	pending    map[string]time.Time // placeholder ID -> claim deadline
+	runs       []*PipelineRun
+	nextRunID  int
+	onUpdate   func([]*session.SessionState)
+	onRemove   func([]string)
+	onPipeline func(PipelineRun)
+}
+
+// New returns a Launcher that registers placeholders in store.
+func New(store *session.Store) *Launcher {
+	return &Launcher{
+		store:    store,
+		now:      time.Now,
+		terminal: make(chan *session.SessionState, terminalBuffer),
+		pending:  make(map[string]time.Time),
+	}
+}
+
+// OnUpdate sets the callback run with each new placeholder.
+func (l *Launcher) OnUpdate(fn func([]*session.SessionState)) {
+	l.mu.Lock()
+	l.onUpdate = fn
+	l.mu.Unlock()
+}
+
+// OnRemove sets the callback run with the IDs of expired placeholders.
+func (l *Launcher) OnRemove(fn func([]string)) {
+	l.mu.Lock()
+	l.onRemove = fn
+	l.mu.Unlock()
+}
+
+// Configure replaces the templates and pipelines. Sessions and pipeline
+// runs already started carry on.
+func (l *Launcher) Configure(s Settings) {
+	l.mu.Lock()
+	l.templates = slices.Clone(s.Templates)
+	l.pipelines = slices.Clone(s.Pipelines)
+	l.mu.Unlock()
+}
+
+// Templates returns the configured templates.
+func (l *Launcher) Templates() []Template {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return slices.Clone(l.templates)
+}
+
+func (l *Launcher) template(name string) (Template, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i := 0; i < len(l.templates); i++ {
+		if l.templates[i].Name == name {
+			return l.templates[i], true
+		}
+	}
+	return Template{}, false
+}
+
+// Launch opens a tmux window for the named template and registers its
+// placeholder session, which it returns.
+func (l *Launcher) Launch(req Request) (*session.SessionState, error) {
+	t, ok := l.template(req.Template)
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownTemplate, req.Template)
+	}
+	model := t.Model
+	if req.Model != "" {
+		// The model ends up in argv, which tmux hands to a shell when the
+		// command is a single string.
+		if !validModel(req.Model) {
+			return nil, fmt.Errorf("%w %q", ErrInvalidModel, req.Model)
+		}
+		model = req.Model
+	}
+	source := t.Source
+	if source == "" {
+		source = DefaultSource
+	}
+	window := t.Window
+	if window == "" {
+		window = t.Name
+	}
+
+	sessionID, err := newUUID()
+	if err != nil {
+		return nil, err
+	}
+	argv := make([]string, len(t.Command))
+	for i := 0; i < len(t.Command); i++ {
+		a := strings.ReplaceAll(t.Command[i], SessionIDPlaceholder, sessionID)
+		argv[i] = strings.ReplaceAll(a, ModelPlaceholder, model)
+	}
+
+	target, err := openWindow(t.TmuxSession, window, t.Cwd, argv)
+	if err != nil {
+		return nil, err
+	}
+
+	now := l.now()
+	state := &session.SessionState{
+		ID:             source + ":" + sessionID,
+		Name:           window,
+		Source:         source,
+		Activity:       session.Starting,
+		Model:          model,
+		WorkingDir:     t.Cwd,
+		StartedAt:      now,
+		LastActivityAt: now,
+		TmuxTarget:     target,
+		Launched:       true,
+	}
+
+	l.mu.Lock()
+	l.pending[state.ID] = now.Add(ClaimTimeout)
+	onUpdate := l.onUpdate
+	l.mu.Unlock()
+
+	l.store.UpdateAndNotify(state, func() {
+		if onUpdate != nil {
+			onUpdate([]*session.SessionState{state})
+		}
+	})
+	return state, nil
+}
+
+// openWindow starts argv in a new tmux window and returns its pane target.
+func openWindow(tmuxSession, window, cwd string, argv []string) (string, error) {
+	args := []string{"new-window", "-d", "-P", "-F", paneFormat, "-n", window}
+	if tmuxSession != "" {
+		if _, err := runTmux("has-session", "-t", "="+tmuxSession); err != nil {
+			if errors.Is(err, ErrNoTmux) {
+				return "", err
+			}
+			args = []string{"new-session", "-d", "-P", "-F", paneFormat, "-s", tmuxSession, "-n", window}
+		} else {
+			args = append(args, "-t", tmuxSession+":")
+		}
+	}
+	if cwd != "" {
+		args = append(args, "-c", cwd)
+	}
+	args = append(args, argv...)
+	return runTmux(args...)
+}
+
+// Run advances pipelines as sessions end and drops placeholders whose
+// session never showed up, until ctx is cancelled.
+func (l *Launcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(sweepInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case state := <-l.terminal:
+			l.handleTerminal(state)
+		case <-ticker.C:
+			l.Sweep()
+		}
+	}
+}
+
+// Sweep forgets placeholders the monitor has adopted and removes the ones
+// past their claim deadline.
+func (l *Launcher) Sweep() {
+	now := l.now()
+	l.mu.Lock()
+	var expired []string
+	for id, deadline := range l.pending {
+		state, ok := l.store.Get(id)
+		switch {
+		case !ok || !state.LastDataReceivedAt.IsZero():
+			delete(l.pending, id)
+		case now.After(deadline):
+			delete(l.pending, id)
+			expired = append(expired, id)
+		}
+	}
+	onRemove := l.onRemove
+	l.mu.Unlock()
+
+	if len(expired) == 0 {
+		return
+	}
+	l.store.BatchRemoveAndNotify(expired, func() {
+		if onRemove != nil {
+			onRemove(expired)
+		}
+	})
+	l.expire(expired)
+}
+
+// newUUID returns a random RFC 4122 version 4 UUID.
+func newUUID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
@@ -0,0 +1,172 @@
+package launch
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agent-racer/backend/internal/session"
+)
+
+// fakeTmux records tmux invocations and answers has-session with
+// hasSession.
+func fakeTmux(t *testing.T, hasSession bool) *[][]string {
+	t.Helper()
+	var calls [][]string
+	orig := runTmux
+	runTmux = func(args ...string) (string, error) {
+		calls = append(calls, args)
+		if args[0] == "has-session" && !hasSession {
+			return "", errors.New("can't find session")
+		}
+		return "work:2.0", nil
+	}
+	t.Cleanup(func() { runTmux = orig })
+	return &calls
+}
+
+func TestLaunchSubstitutesAndRegisters(t *testing.T) {
+	calls := fakeTmux(t, true)
+	store := session.NewStore()
+	l := New(store)
+	l.Configure(Settings{Templates: []Template{{
+		Name:        "opus",
+		Command:     []string{"claude", "--session-id", "{session_id}", "--model", "{model}"},
+		Cwd:         "/src/app",
+		Model:       "opus",
+		TmuxSession: "work",
+	}}})
+	var broadcast []*session.SessionState
+	l.OnUpdate(func(s []*session.SessionState) { broadcast = append(broadcast, s...) })
+
+	state, err := l.Launch(Request{Template: "opus", Model: "sonnet"})
+	if err != nil {
+		t.Fatalf("Launch: %v", err)
+	}
+	id := strings.TrimPrefix(state.ID, "claude:")
+	if id == state.ID || len(id) != 36 {
+		t.Fatalf("ID = %q, want claude:<uuid>", state.ID)
+	}
+
+	if len(*calls) != 2 || (*calls)[0][0] != "has-session" {
+		t.Fatalf("tmux calls = %v", *calls)
+	}
+	nw := (*calls)[1]
+	if nw[0] != "new-window" || !slices.Contains(nw, "work:") || !slices.Contains(nw, "/src/app") {
+		t.Errorf("new-window args = %v", nw)
+	}
+	if want := []string{"claude", "--session-id", id, "--model", "sonnet"}; !slices.Equal(nw[len(nw)-5:], want) {
+		t.Errorf("argv = %v, want %v", nw[len(nw)-5:], want)
+	}
+
+	got, ok := store.Get(state.ID)
+	if !ok || !got.Launched || got.TmuxTarget != "work:2.0" || got.Model != "sonnet" || got.WorkingDir != "/src/app" {
+		t.Errorf("stored = %+v, %v", got, ok)
+	}
+	if len(broadcast) != 1 || broadcast[0].ID != state.ID {
+		t.Errorf("broadcast = %+v", broadcast)
+	}
+}
+
+func TestLaunchCreatesMissingTmuxSession(t *testing.T) {
+	calls := fakeTmux(t, false)
+	l := New(session.NewStore())
+	l.Configure(Settings{Templates: []Template{{Name: "a", Command: []string{"codex"}, Source: "codex", TmuxSession: "agents"}}})
+
+	state, err := l.Launch(Request{Template: "a"})
+	if err != nil {
+		t.Fatalf("Launch: %v", err)
+	}
+	if !strings.HasPrefix(state.ID, "codex:") {
+		t.Errorf("ID = %q, want codex source", state.ID)
+	}
+	if args := (*calls)[1]; args[0] != "new-session" || !slices.Contains(args, "agents") {
+		t.Errorf("args = %v, want new-session -s agents", args)
+	}
+}
+
+func TestLaunchErrors(t *testing.T) {
+	orig := runTmux
+	runTmux = func(args ...string) (string, error) { return "", ErrNoTmux }
+	t.Cleanup(func() { runTmux = orig })
+
+	store := session.NewStore()
+	l := New(store)
+	l.Configure(Settings{Templates: []Template{{Name: "a", Command: []string{"claude"}}}})
+
+	if _, err := l.Launch(Request{Template: "b"}); !errors.Is(err, ErrUnknownTemplate) {
+		t.Errorf("unknown template err = %v", err)
+	}
+	for _, model := range []string{"x; rm -rf ~", "$(id)", "x --dangerously-skip-permissions", "--help", "-x", "a`b`", strings.Repeat("m", maxModelLen+1)} {
+		if _, err := l.Launch(Request{Template: "a", Model: model}); !errors.Is(err, ErrInvalidModel) {
+			t.Errorf("model %q err = %v, want ErrInvalidModel", model, err)
+		}
+	}
+	if _, err := l.Launch(Request{Template: "a", Model: "claude-opus-4-6"}); !errors.Is(err, ErrNoTmux) {
+		t.Errorf("valid model err = %v, want ErrNoTmux", err)
+	}
+	if _, err := l.Launch(Request{Template: "a"}); !errors.Is(err, ErrNoTmux) {
+		t.Errorf("no tmux err = %v", err)
+	}
+	if n := len(store.GetAll()); n != 0 {
+		t.Errorf("store has %d sessions after failed launches", n)
+	}
+}
+
+func TestSweep(t *testing.T) {
+	fakeTmux(t, true)
+	store := session.NewStore()
+	l := New(store)
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	l.now = func() time.Time { return now }
+	l.Configure(Settings{Templates: []Template{{Name: "a", Command: []string{"claude"}}}})
+	var removed []string
+	l.OnRemove(func(ids []string) { removed = append(removed, ids...) })
+
+	claimed, _ := l.Launch(Request{Template: "a"})
+	unclaimed, _ := l.Launch(Request{Template: "a"})
+
+	// The monitor adopts the first one.
+	s, _ := store.Get(claimed.ID)
+	s.LastDataReceivedAt = now
+	store.Update(s)
+
+	l.Sweep()
+	if len(removed) != 0 {
+		t.Fatalf("removed before the deadline: %v", removed)
+	}
+
+	now = now.Add(ClaimTimeout + time.Second)
+	l.Sweep()
+	if !slices.Equal(removed, []string{unclaimed.ID}) {
+		t.Errorf("removed = %v, want %v", removed, []string{unclaimed.ID})
+	}
+	if _, ok := store.Get(claimed.ID); !ok {
+		t.Error("claimed session was removed")
+	}
+	if _, ok := store.Get(unclaimed.ID); ok {
+		t.Error("unclaimed placeholder still in store")
+	}
+}
+
+func TestValidateTemplates(t *testing.T) {
+	errs := ValidateTemplates([]Template{
+		{Name: "ok", Command: []string{"claude"}, Cwd: "/src", TmuxSession: "work", Window: "w-1"},
+		{Name: "ok", Command: []string{"claude"}},
+		{Name: "bad name", Command: []string{"claude"}},
+		{Name: "nocmd"},
+		{Name: "rel", Command: []string{"claude"}, Cwd: "src"},
+		{Name: "tmux", Command: []string{"claude"}, TmuxSession: "a:b", Window: "x y"},
+	})
+	want := []string{"duplicate name", "template 2: name", "command is required", "cwd must be absolute", "invalid tmux_session", "invalid window"}
+	if len(errs) != len(want) {
+		t.Fatalf("errs = %q", errs)
+	}
+	for i := 0; i < len(want); i++ {
+		if !strings.Contains(errs[i], want[i]) {
+			t.Errorf("errs[%d] = %q, want %q", i, errs[i], want[i])
+		}
+	}
+}
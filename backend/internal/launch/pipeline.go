@@ -0,0 +1,334 @@
+package launch
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/agent-racer/backend/internal/session"
+)
+
+// maxFinishedRuns bounds how many finished pipeline runs Runs reports.
+const maxFinishedRuns = 20
+
+var (
+	ErrUnknownPipeline = errors.New("unknown pipeline")
+	ErrInvalidPipeline = errors.New("invalid pipeline")
+)
+
+// Pipeline is a relay: each stage's template launches when the previous
+// stage's session completes.
+type Pipeline struct {
+	Name   string   `yaml:"name" json:"name"`
+	Stages []string `yaml:"stages" json:"stages"` // template names, in order
+}
+
+// Equal reports whether p and o describe the same pipeline.
+func (p Pipeline) Equal(o Pipeline) bool {
+	return p.Name == o.Name && slices.Equal(p.Stages, o.Stages)
+}
+
+// ValidatePipelines returns one message per problem in pipelines, checking
+// stage names against templates.
+func ValidatePipelines(pipelines []Pipeline, templates []Template) []string {
+	var errs []string
+	known := make(map[string]bool, len(templates))
+	for i := 0; i < len(templates); i++ {
+		known[templates[i].Name] = true
+	}
+	names := make(map[string]bool, len(pipelines))
+	for i := 0; i < len(pipelines); i++ {
+		p := pipelines[i]
+		if !validName(p.Name) {
+			errs = append(errs, fmt.Sprintf("pipeline %d: name must be non-empty letters, digits, '.', '-' or '_'", i))
+		} else if names[p.Name] {
+			errs = append(errs, fmt.Sprintf("pipeline %q: duplicate name", p.Name))
+		}
+		names[p.Name] = true
+		errs = append(errs, validateStages(p.Name, p.Stages, known)...)
+	}
+	return errs
+}
+
+func validateStages(name string, stages []string, known map[string]bool) []string {
+	var errs []string
+	if len(stages) < 2 {
+		errs = append(errs, fmt.Sprintf("pipeline %q: needs at least two stages", name))
+	}
+	for i := 0; i < len(stages); i++ {
+		if !known[stages[i]] {
+			errs = append(errs, fmt.Sprintf("pipeline %q stage %d: unknown template %q", name, i, stages[i]))
+		}
+	}
+	return errs
+}
+
+// StageStatus is where one leg of a pipeline run is.
+type StageStatus string
+
+const (
+	StagePending StageStatus = "pending"
+	StageRunning StageStatus = "running"
+	StageDone    StageStatus = "done"
+	StageFailed  StageStatus = "failed"
+	StageSkipped StageStatus = "skipped" // an earlier stage failed
+)
+
+// RunStatus is where a pipeline run is.
+type RunStatus string
+
+const (
+	RunRunning  RunStatus = "running"
+	RunFinished RunStatus = "finished" // every stage completed
+	RunFailed   RunStatus = "failed"
+)
+
+// Stage is one leg of a pipeline run.
+type Stage struct {
+	Template  string      `json:"template"`
+	Status    StageStatus `json:"status"`
+	SessionID string      `json:"sessionId,omitempty"`
+	StartedAt *time.Time  `json:"startedAt,omitempty"`
+	EndedAt   *time.Time  `json:"endedAt,omitempty"`
+	Error     string      `json:"error,omitempty"`
+}
+
+// PipelineRun is a snapshot of one run of a pipeline.
+type PipelineRun struct {
+	ID        string     `json:"id"`
+	Pipeline  string     `json:"pipeline"`
+	Status    RunStatus  `json:"status"`
+	Leg       int        `json:"leg"` // index of the stage holding the baton
+	StartedAt time.Time  `json:"startedAt"`
+	EndedAt   *time.Time `json:"endedAt,omitempty"`
+	Stages    []Stage    `json:"stages"`
+}
+
+func (r PipelineRun) clone() PipelineRun {
+	r.Stages = slices.Clone(r.Stages)
+	return r
+}
+
+// PipelineRequest is the body of POST /api/pipelines: a configured
+// pipeline by name, or an ad hoc one when Stages is set.
+type PipelineRequest struct {
+	Pipeline string   `json:"pipeline"`
+	Stages   []string `json:"stages,omitempty"`
+}
+
+// Pipelines returns the configured pipelines.
+func (l *Launcher) Pipelines() []Pipeline {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return slices.Clone(l.pipelines)
+}
+
+// Runs returns running pipeline runs and the most recent finished ones,
+// oldest first.
+func (l *Launcher) Runs() []PipelineRun {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	out := make([]PipelineRun, 0, len(l.runs))
+	for _, r := range l.runs {
+		out = append(out, r.clone())
+	}
+	return out
+}
+
+// OnPipeline sets the callback run whenever a pipeline run changes.
+func (l *Launcher) OnPipeline(fn func(PipelineRun)) {
+	l.mu.Lock()
+	l.onPipeline = fn
+	l.mu.Unlock()
+}
+
+// StartPipeline launches the first stage of a pipeline. A run whose first
+// launch fails is still recorded, as failed, and returned with the error.
+func (l *Launcher) StartPipeline(req PipelineRequest) (PipelineRun, error) {
+	name, stages := req.Pipeline, req.Stages
+	l.mu.Lock()
+	if len(stages) == 0 {
+		i := slices.IndexFunc(l.pipelines, func(p Pipeline) bool { return p.Name == name })
+		if i < 0 {
+			l.mu.Unlock()
+			return PipelineRun{}, fmt.Errorf("%w %q", ErrUnknownPipeline, name)
+		}
+		stages = l.pipelines[i].Stages
+	} else {
+		if name == "" {
+			name = "adhoc"
+		}
+		known := make(map[string]bool, len(l.templates))
+		for i := 0; i < len(l.templates); i++ {
+			known[l.templates[i].Name] = true
+		}
+		if errs := validateStages(name, stages, known); len(errs) > 0 {
+			l.mu.Unlock()
+			return PipelineRun{}, fmt.Errorf("%w: %s", ErrInvalidPipeline, errs[0])
+		}
+	}
+
+	now := l.now()
+	l.nextRunID++
+	run := &PipelineRun{
+		ID:        fmt.Sprintf("pipe-%s-%d", now.UTC().Format("20060102T150405"), l.nextRunID),
+		Pipeline:  name,
+		Status:    RunRunning,
+		StartedAt: now,
+		Stages:    make([]Stage, len(stages)),
+	}
+	for i := 0; i < len(stages); i++ {
+		run.Stages[i] = Stage{Template: stages[i], Status: StagePending}
+	}
+	l.runs = append(l.runs, run)
+	l.mu.Unlock()
+
+	err := l.launchStage(run, 0)
+	l.mu.Lock()
+	snap := run.clone()
+	l.mu.Unlock()
+	return snap, err
+}
+
+// Terminal tells the launcher a session has ended. It must not block, so
+// the work happens on the Run goroutine; the event is dropped when that
+// falls far behind.
+func (l *Launcher) Terminal(state *session.SessionState) {
+	select {
+	case l.terminal <- state:
+	default:
+	}
+}
+
+// handleTerminal passes the baton when the session holding it completes,
+// and fails the run when it errors or is lost.
+func (l *Launcher) handleTerminal(state *session.SessionState) {
+	l.mu.Lock()
+	run := l.runHolding(state.ID)
+	if run == nil {
+		l.mu.Unlock()
+		return
+	}
+	now := l.now()
+	leg := &run.Stages[run.Leg]
+	leg.EndedAt = &now
+	if state.Activity != session.Complete {
+		l.failLocked(run, fmt.Sprintf("session ended %s", state.Activity), now)
+		l.mu.Unlock()
+		l.notify(run)
+		return
+	}
+	leg.Status = StageDone
+	if run.Leg == len(run.Stages)-1 {
+		run.Status = RunFinished
+		run.EndedAt = &now
+		l.pruneLocked()
+		l.mu.Unlock()
+		l.notify(run)
+		return
+	}
+	next := run.Leg + 1
+	l.mu.Unlock()
+	_ = l.launchStage(run, next)
+}
+
+// expire fails runs whose baton holder never turned up.
+func (l *Launcher) expire(ids []string) {
+	var changed []*PipelineRun
+	l.mu.Lock()
+	now := l.now()
+	for i := 0; i < len(ids); i++ {
+		if run := l.runHolding(ids[i]); run != nil {
+			l.failLocked(run, "session never started", now)
+			changed = append(changed, run)
+		}
+	}
+	l.mu.Unlock()
+	for i := 0; i < len(changed); i++ {
+		l.notify(changed[i])
+	}
+}
+
+// launchStage hands the baton to stage i and notifies.
+func (l *Launcher) launchStage(run *PipelineRun, i int) error {
+	l.mu.Lock()
+	template := run.Stages[i].Template
+	l.mu.Unlock()
+
+	state, err := l.Launch(Request{Template: template})
+
+	l.mu.Lock()
+	now := l.now()
+	run.Leg = i
+	stage := &run.Stages[i]
+	stage.StartedAt = &now
+	if err != nil {
+		l.failLocked(run, err.Error(), now)
+	} else {
+		stage.Status = StageRunning
+		stage.SessionID = state.ID
+	}
+	l.mu.Unlock()
+	l.notify(run)
+	return err
+}
+
+// runHolding returns the running run whose current stage is sessionID.
+// Caller must hold l.mu.
+func (l *Launcher) runHolding(sessionID string) *PipelineRun {
+	for _, r := range l.runs {
+		if r.Status == RunRunning && r.Stages[r.Leg].Status == StageRunning && r.Stages[r.Leg].SessionID == sessionID {
+			return r
+		}
+	}
+	return nil
+}
+
+// failLocked fails the current stage and skips the rest. Caller must hold
+// l.mu.
+func (l *Launcher) failLocked(run *PipelineRun, reason string, now time.Time) {
+	stage := &run.Stages[run.Leg]
+	stage.Status = StageFailed
+	stage.Error = reason
+	stage.EndedAt = &now
+	for i := run.Leg + 1; i < len(run.Stages); i++ {
+		run.Stages[i].Status = StageSkipped
+	}
+	run.Status = RunFailed
+	run.EndedAt = &now
+	l.pruneLocked()
+}
+
+// pruneLocked drops the oldest finished runs beyond maxFinishedRuns.
+// Caller must hold l.mu.
+func (l *Launcher) pruneLocked() {
+	done := 0
+	for _, r := range l.runs {
+		if r.Status != RunRunning {
+			done++
+		}
+	}
+	if done <= maxFinishedRuns {
+		return
+	}
+	kept := l.runs[:0]
+	for _, r := range l.runs {
+		if r.Status != RunRunning && done > maxFinishedRuns {
+			done--
+			continue
+		}
+		kept = append(kept, r)
+	}
+	l.runs = kept
+}
+
+func (l *Launcher) notify(run *PipelineRun) {
+	l.mu.Lock()
+	snap := run.clone()
+	fn := l.onPipeline
+	l.mu.Unlock()
+	if fn != nil {
+		fn(snap)
+	}
+}
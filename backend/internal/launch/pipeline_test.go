@@ -0,0 +1,125 @@
+package launch
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agent-racer/backend/internal/session"
+)
+
+func newPipelineLauncher(t *testing.T) (*Launcher, *[]PipelineRun) {
+	t.Helper()
+	fakeTmux(t, true)
+	l := New(session.NewStore())
+	l.Configure(Settings{
+		Templates: []Template{
+			{Name: "plan", Command: []string{"claude"}},
+			{Name: "build", Command: []string{"claude"}},
+		},
+		Pipelines: []Pipeline{{Name: "relay", Stages: []string{"plan", "build"}}},
+	})
+	var updates []PipelineRun
+	l.OnPipeline(func(r PipelineRun) { updates = append(updates, r) })
+	return l, &updates
+}
+
+func TestPipelinePassesBatonOnCompletion(t *testing.T) {
+	l, updates := newPipelineLauncher(t)
+
+	run, err := l.StartPipeline(PipelineRequest{Pipeline: "relay"})
+	if err != nil {
+		t.Fatalf("StartPipeline: %v", err)
+	}
+	if run.Status != RunRunning || run.Leg != 0 || run.Stages[0].Status != StageRunning || run.Stages[1].Status != StagePending {
+		t.Fatalf("started run = %+v", run)
+	}
+
+	first := run.Stages[0].SessionID
+	l.handleTerminal(&session.SessionState{ID: first, Activity: session.Complete})
+	got := l.Runs()[0]
+	if got.Leg != 1 || got.Stages[0].Status != StageDone || got.Stages[1].Status != StageRunning {
+		t.Fatalf("after first leg = %+v", got)
+	}
+	second := got.Stages[1].SessionID
+	if second == "" || second == first {
+		t.Fatalf("second stage session = %q", second)
+	}
+
+	// A repeated ending for the first session changes nothing.
+	l.handleTerminal(&session.SessionState{ID: first, Activity: session.Complete})
+
+	l.handleTerminal(&session.SessionState{ID: second, Activity: session.Complete})
+	got = l.Runs()[0]
+	if got.Status != RunFinished || got.EndedAt == nil || got.Stages[1].Status != StageDone {
+		t.Errorf("finished run = %+v", got)
+	}
+	if n := len(*updates); n != 3 {
+		t.Errorf("got %d pipeline updates, want 3 (start, handoff, finish)", n)
+	}
+}
+
+func TestPipelineFailsWhenStageErrors(t *testing.T) {
+	l, _ := newPipelineLauncher(t)
+	run, _ := l.StartPipeline(PipelineRequest{Pipeline: "relay"})
+
+	l.handleTerminal(&session.SessionState{ID: run.Stages[0].SessionID, Activity: session.Errored})
+	got := l.Runs()[0]
+	if got.Status != RunFailed || got.Stages[0].Status != StageFailed || got.Stages[1].Status != StageSkipped {
+		t.Errorf("failed run = %+v", got)
+	}
+	if !strings.Contains(got.Stages[0].Error, "errored") {
+		t.Errorf("stage error = %q", got.Stages[0].Error)
+	}
+}
+
+func TestPipelineFailsWhenSessionNeverStarts(t *testing.T) {
+	l, _ := newPipelineLauncher(t)
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	l.now = func() time.Time { return now }
+	l.StartPipeline(PipelineRequest{Pipeline: "relay"})
+
+	now = now.Add(ClaimTimeout + time.Second)
+	l.Sweep()
+	if got := l.Runs()[0]; got.Status != RunFailed || got.Stages[0].Error != "session never started" {
+		t.Errorf("run = %+v", got)
+	}
+}
+
+func TestStartPipelineErrors(t *testing.T) {
+	l, _ := newPipelineLauncher(t)
+	if _, err := l.StartPipeline(PipelineRequest{Pipeline: "nope"}); !errors.Is(err, ErrUnknownPipeline) {
+		t.Errorf("unknown pipeline err = %v", err)
+	}
+	if _, err := l.StartPipeline(PipelineRequest{Stages: []string{"plan"}}); !errors.Is(err, ErrInvalidPipeline) {
+		t.Errorf("one-stage err = %v", err)
+	}
+	if _, err := l.StartPipeline(PipelineRequest{Stages: []string{"plan", "review"}}); !errors.Is(err, ErrInvalidPipeline) {
+		t.Errorf("unknown stage err = %v", err)
+	}
+
+	run, err := l.StartPipeline(PipelineRequest{Stages: []string{"build", "plan"}})
+	if err != nil || run.Pipeline != "adhoc" || run.Stages[0].Template != "build" {
+		t.Errorf("ad hoc run = %+v, %v", run, err)
+	}
+}
+
+func TestValidatePipelines(t *testing.T) {
+	templates := []Template{{Name: "a", Command: []string{"claude"}}}
+	errs := ValidatePipelines([]Pipeline{
+		{Name: "ok", Stages: []string{"a", "a"}},
+		{Name: "ok", Stages: []string{"a", "a"}},
+		{Name: "short", Stages: []string{"a"}},
+		{Name: "missing", Stages: []string{"a", "b"}},
+	}, templates)
+	want := []string{"duplicate name", "at least two stages", "unknown template \"b\""}
+	if len(errs) != len(want) {
+		t.Fatalf("errs = %q", errs)
+	}
+	for i := 0; i < len(want); i++ {
+		if !strings.Contains(errs[i], want[i]) {
+			t.Errorf("errs[%d] = %q, want %q", i, errs[i], want[i])
+		}
+	}
+}
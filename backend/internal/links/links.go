@@ -0,0 +1,262 @@
+// Package links associates sessions with the issue and pull request they
+// are working on. Issue links are derived from the branch name using a
+// configurable pattern and the repository's origin remote; pull request
+// links come from the GitHub CLI (`gh pr view`) when that lookup is
+// enabled, reusing whatever authentication gh already has.
+//
+// Lookups shell out to git and gh, so results are cached per working
+// directory and branch and refreshed in the background: Lookup never
+// blocks on a subprocess.
+package links
+
+import (
+	"context"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// commandTimeout bounds each git/gh invocation.
+const commandTimeout = 5 * time.Second
+
+// Links holds the URLs resolved for a session's working directory and branch.
+// Empty fields mean no link was found.
+type Links struct {
+	IssueURL string
+	PRURL    string
+}
+
+// Options configures a lookup. The zero value disables all linking.
+type Options struct {
+	// IssuePattern is a regular expression matched against the branch
+	// name; its first capture group is the issue number. Empty disables
+	// issue links.
+	IssuePattern string
+	// IssueURLTemplate builds the issue URL. "{repo}" expands to the
+	// repository's web URL (derived from the origin remote) and
+	// "{number}" to the captured issue number.
+	IssueURLTemplate string
+	// PRLookup enables `gh pr view` to find an open pull request for the
+	// branch.
+	PRLookup bool
+	// CacheTTL is how long a resolved result is served before it is
+	// refreshed in the background.
+	CacheTTL time.Duration
+}
+
+func (o Options) enabled() bool {
+	return o.IssuePattern != "" || o.PRLookup
+}
+
+// CommandRunner runs name with args in dir and returns its stdout.
+type CommandRunner func(ctx context.Context, dir, name string, args ...string) ([]byte, error)
+
+type entry struct {
+	links      Links
+	resolvedAt time.Time
+	refreshing bool
+}
+
+// Resolver caches issue and pull request links keyed by working directory
+// and branch. It is safe for concurrent use.
+type Resolver struct {
+	mu       sync.Mutex
+	entries  map[string]*entry
+	patterns map[string]*regexp.Regexp
+	run      CommandRunner
+	now      func() time.Time
+	wg       sync.WaitGroup
+}
+
+// NewResolver creates a Resolver that runs git and gh from PATH.
+func NewResolver() *Resolver {
+	return NewResolverWithRunner(execRunner)
+}
+
+// NewResolverWithRunner creates a Resolver that uses run to invoke git and
+// gh. It exists so tests can avoid real subprocesses.
+func NewResolverWithRunner(run CommandRunner) *Resolver {
+	return &Resolver{
+		entries:  make(map[string]*entry),
+		patterns: make(map[string]*regexp.Regexp),
+		run:      run,
+		now:      time.Now,
+	}
+}
+
+// Lookup returns the cached links for dir and branch. When no result is
+// cached, or the cached result is older than opts.CacheTTL, a background
+// refresh is started and the (possibly empty) cached value is returned;
+// the refreshed result is visible to later calls.
+func (r *Resolver) Lookup(opts Options, dir, branch string) Links {
+	if dir == "" || branch == "" || !opts.enabled() {
+		return Links{}
+	}
+
+	key := dir + "\x00" + branch
+	now := r.now()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	e, ok := r.entries[key]
+	if !ok {
+		e = &entry{}
+		r.entries[key] = e
+	}
+	stale := e.resolvedAt.IsZero() || now.Sub(e.resolvedAt) >= opts.CacheTTL
+	if stale && !e.refreshing {
+		e.refreshing = true
+		r.evictLocked(now, opts.CacheTTL)
+		r.wg.Add(1)
+		go r.refresh(key, opts, dir, branch)
+	}
+	return e.links
+}
+
+// Wait blocks until all in-flight background refreshes have finished.
+func (r *Resolver) Wait() {
+	r.wg.Wait()
+}
+
+// evictLocked drops entries that have not been refreshed for several TTLs,
+// i.e. directories and branches no session has looked up recently.
+// Caller must hold r.mu.
+func (r *Resolver) evictLocked(now time.Time, ttl time.Duration) {
+	for key, e := range r.entries {
+		if !e.refreshing && !e.resolvedAt.IsZero() && now.Sub(e.resolvedAt) > 4*ttl {
+			delete(r.entries, key)
+		}
+	}
+}
+
+func (r *Resolver) refresh(key string, opts Options, dir, branch string) {
+	defer r.wg.Done()
+
+	links := r.resolve(opts, dir, branch)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e, ok := r.entries[key]
+	if !ok {
+		e = &entry{}
+		r.entries[key] = e
+	}
+	e.links = links
+	e.resolvedAt = r.now()
+	e.refreshing = false
+}
+
+func (r *Resolver) resolve(opts Options, dir, branch string) Links {
+	var links Links
+	if number := r.issueNumber(opts.IssuePattern, branch); number != "" {
+		if repo := r.repoURL(dir); repo != "" {
+			tmpl := opts.IssueURLTemplate
+			if tmpl == "" {
+				tmpl = "{repo}/issues/{number}"
+			}
+			links.IssueURL = strings.NewReplacer("{repo}", repo, "{number}", number).Replace(tmpl)
+		}
+	}
+	if opts.PRLookup && !isDefaultBranch(branch) {
+		links.PRURL = r.prURL(dir, branch)
+	}
+	return links
+}
+
+// issueNumber returns the first capture group of pattern matched against
+// branch, or "" when the pattern is empty, invalid or does not match.
+func (r *Resolver) issueNumber(pattern, branch string) string {
+	if pattern == "" {
+		return ""
+	}
+	r.mu.Lock()
+	re, ok := r.patterns[pattern]
+	if !ok {
+		re, _ = regexp.Compile(pattern)
+		r.patterns[pattern] = re
+	}
+	r.mu.Unlock()
+	if re == nil {
+		return ""
+	}
+	m := re.FindStringSubmatch(branch)
+	if len(m) < 2 {
+		return ""
+	}
+	return m[1]
+}
+
+func (r *Resolver) repoURL(dir string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+	out, err := r.run(ctx, dir, "git", "remote", "get-url", "origin")
+	if err != nil {
+		return ""
+	}
+	return RepoWebURL(strings.TrimSpace(string(out)))
+}
+
+func (r *Resolver) prURL(dir, branch string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+	out, err := r.run(ctx, dir, "gh", "pr", "view", branch, "--json", "url", "--jq", ".url")
+	if err != nil {
+		return ""
+	}
+	url := strings.TrimSpace(string(out))
+	if !strings.HasPrefix(url, "https://") {
+		return ""
+	}
+	return url
+}
+
+// RepoWebURL converts a git remote URL into the repository's https web
+// URL. It understands scp-style ("git@github.com:owner/repo.git"),
+// ssh:// and http(s):// remotes, and strips embedded credentials. It
+// returns "" for remotes it cannot map (e.g. local paths).
+func RepoWebURL(remote string) string {
+	remote = strings.TrimSuffix(strings.TrimSuffix(remote, "/"), ".git")
+	var host, path string
+	switch {
+	case strings.HasPrefix(remote, "https://"), strings.HasPrefix(remote, "http://"), strings.HasPrefix(remote, "ssh://"):
+		rest := remote[strings.Index(remote, "://")+3:]
+		var ok bool
+		host, path, ok = strings.Cut(rest, "/")
+		if !ok {
+			return ""
+		}
+		if i := strings.LastIndexByte(host, '@'); i >= 0 {
+			host = host[i+1:]
+		}
+		if i := strings.IndexByte(host, ':'); i >= 0 && strings.HasPrefix(remote, "ssh://") {
+			host = host[:i]
+		}
+	case strings.Contains(remote, "@") && strings.Contains(remote, ":"):
+		userHost, p, _ := strings.Cut(remote, ":")
+		host = userHost[strings.LastIndexByte(userHost, '@')+1:]
+		path = p
+	default:
+		return ""
+	}
+	if host == "" || path == "" {
+		return ""
+	}
+	return "https://" + host + "/" + path
+}
+
+func isDefaultBranch(branch string) bool {
+	return branch == "main" || branch == "master"
+}
+
+func execRunner(ctx context.Context, dir, name string, args ...string) ([]byte, error) {
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Dir = dir
+	return cmd.Output()
+}
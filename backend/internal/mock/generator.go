@@ -4,6 +4,7 @@ import (
 	"context"
 	"math"
 	"math/rand"
+	"sync"
 	"time"
 
 	"github.com/agent-racer/backend/internal/session"
@@ -40,6 +41,7 @@ func NewGenerator(store *session.Store, broadcaster *ws.Broadcaster, tickInterva
 		store:        store,
 		broadcaster:  broadcaster,
 		tickInterval: tickInterval,
+		speeds:       session.NewSpeedScale(),
 	}
 }
 
@@ -49,6 +51,24 @@ type MockGenerator struct {
 	sessions     []*mockSession
 	statsEvents  chan<- session.Event
 	tickInterval time.Duration
+	speeds       *session.SpeedScale
+
+	mu    sync.Mutex
+	rules session.RaceRules
+}
+
+// SetRaceRules sets how mock racers are ranked and how their laps are
+// counted. Safe for concurrent use.
+func (g *MockGenerator) SetRaceRules(rules session.RaceRules) {
+	g.mu.Lock()
+	g.rules = rules
+	g.mu.Unlock()
+}
+
+func (g *MockGenerator) raceRules() session.RaceRules {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.rules
 }
 
 // SetStatsEvents configures a channel for session lifecycle events so that
@@ -170,6 +190,38 @@ func (g *MockGenerator) Start(ctx context.Context) {
 	go g.run(ctx)
 }
 
+// assignPositions ranks the mock racers the same way the monitor ranks real
+// sessions, commits the new positions and laps, and broadcasts any
+// overtakes, completed laps and milestones.
+func (g *MockGenerator) assignPositions(prev, updates []*session.SessionState) []*session.SessionState {
+	updates, overtakes, laps := session.Race(prev, updates, g.raceRules())
+	for _, u := range updates {
+		for _, ms := range g.sessions {
+			if ms.state.ID == u.ID {
+				ms.state.Position = u.Position
+				ms.state.PositionDelta = u.PositionDelta
+				ms.state.LapCount = u.LapCount
+				ms.state.LapProgress = u.LapProgress
+				ms.state.TokensBurned = u.TokensBurned
+				ms.state.EnergyWh = u.EnergyWh
+				ms.state.CO2Grams = u.CO2Grams
+			}
+		}
+		g.store.Update(u)
+	}
+	for _, o := range overtakes {
+		g.broadcaster.BroadcastOvertake(o)
+	}
+	for _, l := range laps {
+		g.broadcaster.BroadcastLap(l)
+	}
+	milestones := session.ApplyMilestones(prev, updates)
+	for i := 0; i < len(milestones); i++ {
+		g.broadcaster.BroadcastMilestone(milestones[i])
+	}
+	return updates
+}
+
 func (g *MockGenerator) run(ctx context.Context) {
 	ticker := time.NewTicker(g.tickInterval)
 	defer ticker.Stop()
@@ -181,6 +233,7 @@ func (g *MockGenerator) run(ctx context.Context) {
 			return
 		case <-ticker.C:
 			tick++
+			prev := g.store.GetAll()
 			var updates []*session.SessionState
 			for _, ms := range g.sessions {
 				if ms.completed {
@@ -197,7 +250,7 @@ func (g *MockGenerator) run(ctx context.Context) {
 				}
 			}
 			if len(updates) > 0 {
-				g.broadcaster.QueueUpdate(updates)
+				g.broadcaster.QueueUpdate(g.assignPositions(prev, updates))
 			}
 		}
 	}
@@ -238,6 +291,9 @@ func (g *MockGenerator) advanceMock(ms *mockSession, tick int) {
 	} else {
 		ms.state.BurnRatePerMinute = 0
 	}
+	// Racers pull away from a standstill rather than jumping to speed.
+	ms.state.BurnRateSmoothed = session.SmoothRate(ms.state.BurnRateSmoothed, ms.state.BurnRatePerMinute, g.tickInterval, g.raceRules().SpeedSmoothing)
+	ms.state.Speed = g.speeds.Speed(ms.state.Model, ms.state.BurnRateSmoothed)
 	ms.prevTokens = prevTokens
 
 	// Advance subagents: spawn, cycle activity, complete
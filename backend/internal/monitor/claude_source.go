@@ -43,6 +43,8 @@ func readFirstTimestamp(path string) (time.Time, bool) {
 type ClaudeSource struct {
 	// discoverWindow controls how far back to look for session files.
 	discoverWindow time.Duration
+	// files caches the projects tree between Discover calls.
+	files *dirCache
 }
 
 // NewClaudeSource creates a ClaudeSource that discovers session files
@@ -54,7 +56,10 @@ func NewClaudeSource(discoverWindow time.Duration) *ClaudeSource {
 func (c *ClaudeSource) Name() string { return "claude" }
 
 func (c *ClaudeSource) Discover() ([]SessionHandle, error) {
-	paths, err := FindRecentSessionFiles(c.discoverWindow)
+	if c.files == nil {
+		c.files = newDirCache()
+	}
+	paths, err := findRecentSessionFiles(c.files, c.discoverWindow)
 	if err != nil {
 		return nil, err
 	}
@@ -79,6 +84,22 @@ func (c *ClaudeSource) Discover() ([]SessionHandle, error) {
 }
 
 func (c *ClaudeSource) Parse(handle SessionHandle, offset int64) (SourceUpdate, int64, error) {
+	update, newOffset, err := parseClaudeUpdate(handle, offset)
+	if err != nil || newOffset == offset {
+		return update, newOffset, err
+	}
+
+	if handle.WorkingDir == "" && update.WorkingDir == "" {
+		update.WorkingDir = workingDirFromFile(handle.LogPath)
+	}
+
+	return update, newOffset, nil
+}
+
+// parseClaudeUpdate reads a Claude JSONL transcript at handle.LogPath from
+// offset into a SourceUpdate. Sources that copy Claude output to a local
+// file (SSHSource, KubernetesSource) share it.
+func parseClaudeUpdate(handle SessionHandle, offset int64) (SourceUpdate, int64, error) {
 	result, newOffset, err := ParseSessionJSONL(handle.LogPath, offset, handle.KnownSlug, handle.KnownSubagentParents)
 	if err != nil {
 		return SourceUpdate{}, offset, err
@@ -96,12 +117,20 @@ func (c *ClaudeSource) Parse(handle SessionHandle, offset int64) (SourceUpdate,
 		MessageCount:      result.MessageCount,
 		ToolCalls:         result.ToolCalls,
 		LastTool:          result.LastTool,
+		MCPToolCalls:      result.MCPToolCalls,
+		FilesTouched:      result.FilesTouched,
+		LastCommand:       result.LastCommand,
+		SlashCommands:     result.SlashCommands,
+		HookEvents:        result.HookEvents,
+		FirstPrompt:       result.FirstPrompt,
 		Activity:          result.LastActivity,
 		LastTime:          result.LastTime,
 		WorkingDir:        result.WorkingDir,
 		Subagents:         result.Subagents,
 		CompactionCount:   result.CompactionCount,
 		LastAssistantText: result.LastAssistantText,
+		MessageText:       result.MessageText,
+		Cache:             result.Cache,
 	}
 
 	if result.LatestUsage != nil {
@@ -109,9 +138,5 @@ func (c *ClaudeSource) Parse(handle SessionHandle, offset int64) (SourceUpdate,
 		update.TokensOut = result.LatestUsage.OutputTokens
 	}
 
-	if handle.WorkingDir == "" && update.WorkingDir == "" {
-		update.WorkingDir = workingDirFromFile(handle.LogPath)
-	}
-
 	return update, newOffset, nil
 }
@@ -9,6 +9,7 @@ import (
 	"os"
 
 	"github.com/agent-racer/backend/internal/jsonl"
+	"github.com/agent-racer/backend/internal/session"
 	"path/filepath"
 	"strings"
 	"time"
@@ -25,10 +26,13 @@ import (
 // The CODEX_HOME environment variable can override the base directory.
 type CodexSource struct {
 	discoverWindow time.Duration
+	// files caches the YYYY/MM/DD tree between Discover calls, so past
+	// days cost a stat each instead of a walk.
+	files *dirCache
 }
 
 func NewCodexSource(discoverWindow time.Duration) *CodexSource {
-	return &CodexSource{discoverWindow: discoverWindow}
+	return &CodexSource{discoverWindow: discoverWindow, files: newDirCache()}
 }
 
 func (c *CodexSource) Name() string { return "codex" }
@@ -59,38 +63,21 @@ func (c *CodexSource) Discover() ([]SessionHandle, error) {
 	cutoff := time.Now().Add(-c.discoverWindow)
 	var handles []SessionHandle
 
-	// Walk YYYY/MM/DD directory structure.
-	err := filepath.WalkDir(sessionsDir, func(path string, d os.DirEntry, err error) error {
-		if err != nil {
-			return nil // skip unreadable dirs
-		}
-		if d.IsDir() {
-			return nil
-		}
-		if !strings.HasPrefix(d.Name(), "rollout-") || !strings.HasSuffix(d.Name(), ".jsonl") {
-			return nil
-		}
-
-		info, err := d.Info()
-		if err != nil {
-			return nil
-		}
-		if info.ModTime().Before(cutoff) {
-			return nil
-		}
+	isRollout := func(name string) bool {
+		return strings.HasPrefix(name, "rollout-") && strings.HasSuffix(name, ".jsonl")
+	}
+	files, err := c.files.recentFiles(sessionsDir, -1, isRollout, cutoff)
+	if err != nil {
+		return nil, err
+	}
 
-		sessionID := codexSessionIDFromFilename(d.Name())
+	for _, f := range files {
 		handles = append(handles, SessionHandle{
-			SessionID: sessionID,
-			LogPath:   path,
+			SessionID: codexSessionIDFromFilename(filepath.Base(f.path)),
+			LogPath:   f.path,
 			Source:    "codex",
-			StartedAt: info.ModTime(), // approximation; refined by parsing
+			StartedAt: f.modTime, // approximation; refined by parsing
 		})
-
-		return nil
-	})
-	if err != nil {
-		return nil, err
 	}
 
 	return handles, nil
@@ -191,6 +178,9 @@ type codexParsed struct {
 	messages         int
 	toolCalls        int
 	timestamp        time.Time
+	mcpServer        string   // MCP server that served the tool call
+	shellProgram     string   // program a shell tool call ran
+	patchedFiles     []string // files a patch tool call edited
 }
 
 // parseCodexLine parses a single line from a Codex rollout JSONL file.
@@ -356,28 +346,21 @@ func parseCodexBareItem(line []byte) codexParsed {
 		parsed.toolCalls = 1
 		parsed.activity = "tool_use"
 		var cmd struct {
-			Command string `json:"command"`
+			Command json.RawMessage `json:"command"`
 		}
-		if json.Unmarshal(line, &cmd) == nil && cmd.Command != "" {
+		if json.Unmarshal(line, &cmd) == nil && len(cmd.Command) > 0 {
 			parsed.lastTool = "Bash"
+			parsed.shellProgram = codexShellProgram(cmd.Command)
 		}
 	case "file_change":
 		parsed.toolCalls = 1
 		parsed.activity = "tool_use"
 		parsed.lastTool = "FileEdit"
+		parsed.patchedFiles = codexChangedFiles(line)
 	case "mcp_tool_call":
 		parsed.toolCalls = 1
 		parsed.activity = "tool_use"
-		var mcp struct {
-			ToolName string `json:"tool_name"`
-			Name     string `json:"name"`
-		}
-		if json.Unmarshal(line, &mcp) == nil {
-			parsed.lastTool = mcp.ToolName
-			if parsed.lastTool == "" {
-				parsed.lastTool = mcp.Name
-			}
-		}
+		parseCodexMCPCall(line, &parsed)
 	case "token_count":
 		parseCodexTokenCount(line, &parsed)
 	case "tool_call":
@@ -395,9 +378,14 @@ func parseCodexBareItem(line []byte) codexParsed {
 
 func parseCodexResponseItem(payload json.RawMessage, parsed *codexParsed) {
 	var item struct {
-		Type     string `json:"type"`
-		ToolName string `json:"tool_name"`
-		Name     string `json:"name"`
+		Type      string          `json:"type"`
+		Name      string          `json:"name"`
+		Arguments string          `json:"arguments"` // function_call: JSON-encoded
+		Input     string          `json:"input"`     // custom_tool_call: free-form
+		Command   json.RawMessage `json:"command"`
+		Action    struct {
+			Command json.RawMessage `json:"command"`
+		} `json:"action"`
 	}
 	if json.Unmarshal(payload, &item) != nil {
 		return
@@ -411,21 +399,26 @@ func parseCodexResponseItem(payload json.RawMessage, parsed *codexParsed) {
 		parsed.toolCalls = 1
 		parsed.activity = "tool_use"
 		parsed.lastTool = "Bash"
+		parsed.shellProgram = codexShellProgram(item.Command)
+	case "local_shell_call":
+		parsed.toolCalls = 1
+		parsed.activity = "tool_use"
+		parsed.lastTool = "local_shell"
+		parsed.shellProgram = codexShellProgram(item.Action.Command)
 	case "file_change":
 		parsed.toolCalls = 1
 		parsed.activity = "tool_use"
 		parsed.lastTool = "FileEdit"
+		parsed.patchedFiles = codexChangedFiles(payload)
 	case "mcp_tool_call":
 		parsed.toolCalls = 1
 		parsed.activity = "tool_use"
-		parsed.lastTool = item.ToolName
-		if parsed.lastTool == "" {
-			parsed.lastTool = item.Name
-		}
+		parseCodexMCPCall(payload, parsed)
 	case "function_call", "custom_tool_call":
 		parsed.toolCalls = 1
 		parsed.activity = "tool_use"
 		parsed.lastTool = item.Name
+		parseCodexFunctionCall(item.Name, item.Arguments, item.Input, parsed)
 	case "tool_call":
 		parseCodexToolCall(payload, parsed)
 	case "reasoning":
@@ -514,6 +507,177 @@ func parseCodexToolCall(payload json.RawMessage, parsed *codexParsed) {
 	}
 }
 
+// parseCodexMCPCall reads an mcp_tool_call item. Older rollouts put the
+// server in "name" next to "tool_name"; newer ones use "server" and "tool".
+func parseCodexMCPCall(raw json.RawMessage, parsed *codexParsed) {
+	var mcp struct {
+		Server   string `json:"server"`
+		Tool     string `json:"tool"`
+		ToolName string `json:"tool_name"`
+		Name     string `json:"name"`
+	}
+	if json.Unmarshal(raw, &mcp) != nil {
+		return
+	}
+	parsed.lastTool = mcp.ToolName
+	if parsed.lastTool == "" {
+		parsed.lastTool = mcp.Tool
+	}
+	if parsed.lastTool == "" {
+		parsed.lastTool = mcp.Name
+	}
+	parsed.mcpServer = mcp.Server
+	if parsed.mcpServer == "" && mcp.ToolName != "" {
+		parsed.mcpServer = mcp.Name
+	}
+}
+
+// codexShellTools are the function names Codex has used for its shell tool.
+var codexShellTools = map[string]bool{
+	"shell":          true,
+	"shell_command":  true,
+	"exec_command":   true,
+	"container.exec": true,
+	"local_shell":    true,
+}
+
+// parseCodexFunctionCall extracts what a function or custom tool call did:
+// the program a shell call ran, the files apply_patch touched, or the MCP
+// server a qualified "server__tool" name belongs to.
+func parseCodexFunctionCall(name, arguments, input string, parsed *codexParsed) {
+	switch {
+	case codexShellTools[name]:
+		var args struct {
+			Command json.RawMessage `json:"command"`
+			Cmd     json.RawMessage `json:"cmd"`
+		}
+		if json.Unmarshal([]byte(arguments), &args) == nil {
+			if len(args.Command) == 0 {
+				args.Command = args.Cmd
+			}
+			parsed.shellProgram = codexShellProgram(args.Command)
+		}
+	case name == "apply_patch":
+		patch := input
+		if patch == "" {
+			var args struct {
+				Input string `json:"input"`
+				Patch string `json:"patch"`
+			}
+			if json.Unmarshal([]byte(arguments), &args) == nil {
+				patch = args.Input
+				if patch == "" {
+					patch = args.Patch
+				}
+			}
+		}
+		parsed.patchedFiles = patchFiles(patch)
+	default:
+		parsed.mcpServer = codexMCPServer(name)
+	}
+}
+
+// codexMCPServer returns the server of an MCP tool name. Codex qualifies
+// MCP tools as "server__tool"; the Claude-style "mcp__server__tool" is
+// accepted too.
+func codexMCPServer(name string) string {
+	if server := session.MCPServerFromTool(name); server != "" {
+		return server
+	}
+	server, tool, ok := strings.Cut(name, "__")
+	if !ok || server == "" || tool == "" {
+		return ""
+	}
+	return server
+}
+
+// codexShellProgram returns the program a shell command ran. The command
+// may be a string or an argv array; "bash -lc <script>" wrappers are
+// unwrapped, and leading "cd dir &&" steps and VAR=value assignments are
+// skipped, so "cd repo && GOFLAGS=-v go test" counts as "go".
+func codexShellProgram(raw json.RawMessage) string {
+	var script string
+	var argv []string
+	if json.Unmarshal(raw, &argv) == nil {
+		if len(argv) >= 3 && isShell(argv[0]) && strings.HasPrefix(argv[1], "-") && strings.HasSuffix(argv[1], "c") {
+			script = argv[2]
+		} else {
+			script = strings.Join(argv, " ")
+		}
+	} else if json.Unmarshal(raw, &script) != nil {
+		return ""
+	}
+
+	for _, step := range strings.FieldsFunc(script, func(r rune) bool { return r == ';' || r == '&' || r == '\n' }) {
+		fields := strings.Fields(step)
+		i := 0
+		for i < len(fields) && isEnvAssignment(fields[i]) {
+			i++
+		}
+		if i == len(fields) || fields[i] == "cd" {
+			continue
+		}
+		return filepath.Base(fields[i])
+	}
+	return ""
+}
+
+func isShell(name string) bool {
+	switch filepath.Base(name) {
+	case "bash", "sh", "zsh", "dash":
+		return true
+	}
+	return false
+}
+
+func isEnvAssignment(field string) bool {
+	name, _, ok := strings.Cut(field, "=")
+	if !ok || name == "" {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if c != '_' && (c < 'A' || c > 'Z') && (c < 'a' || c > 'z') && (i == 0 || c < '0' || c > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// patchFiles returns the files an apply_patch body adds, updates or deletes.
+func patchFiles(patch string) []string {
+	var files []string
+	for _, line := range strings.Split(patch, "\n") {
+		for _, prefix := range []string{"*** Add File: ", "*** Update File: ", "*** Delete File: "} {
+			if path, ok := strings.CutPrefix(line, prefix); ok {
+				if path = strings.TrimSpace(path); path != "" {
+					files = append(files, path)
+				}
+			}
+		}
+	}
+	return files
+}
+
+// codexChangedFiles returns the paths listed in a file_change item.
+func codexChangedFiles(raw json.RawMessage) []string {
+	var item struct {
+		Changes []struct {
+			Path string `json:"path"`
+		} `json:"changes"`
+	}
+	if json.Unmarshal(raw, &item) != nil {
+		return nil
+	}
+	var files []string
+	for _, c := range item.Changes {
+		if c.Path != "" {
+			files = append(files, c.Path)
+		}
+	}
+	return files
+}
+
 func parseCodexModel(raw json.RawMessage) string {
 	if len(raw) == 0 || string(raw) == "null" {
 		return ""
@@ -570,11 +734,31 @@ func mergeCodexParsed(update *SourceUpdate, parsed codexParsed) {
 	// Messages and tool calls are deltas.
 	update.MessageCount += parsed.messages
 	update.ToolCalls += parsed.toolCalls
+	if parsed.toolCalls > 0 && parsed.lastTool != "" {
+		update.ToolCounts = addCount(update.ToolCounts, parsed.lastTool)
+	}
+	if parsed.mcpServer != "" {
+		update.MCPToolCalls = addCount(update.MCPToolCalls, parsed.mcpServer)
+	}
+	if parsed.shellProgram != "" {
+		update.ShellCommands = addCount(update.ShellCommands, parsed.shellProgram)
+	}
+	for _, path := range parsed.patchedFiles {
+		update.FilesPatched = addCount(update.FilesPatched, path)
+	}
 	if !parsed.timestamp.IsZero() {
 		update.LastTime = parsed.timestamp
 	}
 }
 
+func addCount(counts map[string]int, key string) map[string]int {
+	if counts == nil {
+		counts = make(map[string]int)
+	}
+	counts[key]++
+	return counts
+}
+
 // codexSessionIDFromFilename extracts the UUID from a rollout filename.
 // Format: rollout-{timestamp}-{uuid}.jsonl
 func codexSessionIDFromFilename(name string) string {
@@ -419,6 +419,97 @@ func TestCodexSourceParseNullInfoTokenCount(t *testing.T) {
 	}
 }
 
+func TestCodexSourceParseToolDetail(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "rollout-tool-detail.jsonl")
+
+	content := `{"timestamp":"2026-03-08T00:41:42Z","type":"session_meta","payload":{"id":"tool-detail","model":"gpt-5.4"}}
+{"timestamp":"2026-03-08T00:41:43Z","type":"response_item","payload":{"type":"function_call","name":"shell","arguments":"{\"command\":[\"bash\",\"-lc\",\"cd repo && GOFLAGS=-v go test ./...\"]}","call_id":"c1"}}
+{"timestamp":"2026-03-08T00:41:44Z","type":"response_item","payload":{"type":"function_call","name":"shell_command","arguments":"{\"command\":\"git status\"}","call_id":"c2"}}
+{"timestamp":"2026-03-08T00:41:45Z","type":"response_item","payload":{"type":"custom_tool_call","name":"apply_patch","input":"*** Begin Patch\n*** Update File: internal/a.go\n@@\n-x\n+y\n*** Add File: internal/b.go\n+package b\n*** End Patch\n","call_id":"c3"}}
+{"timestamp":"2026-03-08T00:41:46Z","type":"response_item","payload":{"type":"function_call","name":"github__create_issue","arguments":"{}","call_id":"c4"}}
+{"timestamp":"2026-03-08T00:41:47Z","type":"response_item","payload":{"type":"local_shell_call","action":{"type":"exec","command":["/usr/bin/rg","-n","TODO"]},"call_id":"c5"}}
+{"timestamp":"2026-03-08T00:41:48Z","type":"response_item","payload":{"type":"function_call","name":"shell_command","arguments":"{\"command\":\"git diff\"}","call_id":"c6"}}
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	src := NewCodexSource(10 * time.Minute)
+	update, _, err := src.Parse(SessionHandle{SessionID: "tool-detail", LogPath: path, Source: "codex"}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if update.ToolCalls != 6 {
+		t.Errorf("ToolCalls = %d, want 6", update.ToolCalls)
+	}
+	checkCounts(t, "ToolCounts", update.ToolCounts, map[string]int{
+		"shell": 1, "shell_command": 2, "apply_patch": 1, "github__create_issue": 1, "local_shell": 1,
+	})
+	checkCounts(t, "ShellCommands", update.ShellCommands, map[string]int{"go": 1, "git": 2, "rg": 1})
+	checkCounts(t, "FilesPatched", update.FilesPatched, map[string]int{"internal/a.go": 1, "internal/b.go": 1})
+	checkCounts(t, "MCPToolCalls", update.MCPToolCalls, map[string]int{"github": 1})
+}
+
+func TestCodexSourceParseBareToolDetail(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "rollout-bare-detail.jsonl")
+
+	content := `{"session_id":"bare-detail","model":"o3"}
+{"type":"command_execution","command":"npm run build"}
+{"type":"file_change","changes":[{"path":"/work/app/src/main.ts","kind":"update"}]}
+{"type":"mcp_tool_call","tool_name":"database_query","name":"db"}
+{"type":"mcp_tool_call","server":"linear","tool":"list_issues"}
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	src := NewCodexSource(10 * time.Minute)
+	update, _, err := src.Parse(SessionHandle{SessionID: "bare-detail", LogPath: path, Source: "codex"}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkCounts(t, "ToolCounts", update.ToolCounts, map[string]int{"Bash": 1, "FileEdit": 1, "database_query": 1, "list_issues": 1})
+	checkCounts(t, "ShellCommands", update.ShellCommands, map[string]int{"npm": 1})
+	checkCounts(t, "FilesPatched", update.FilesPatched, map[string]int{"/work/app/src/main.ts": 1})
+	checkCounts(t, "MCPToolCalls", update.MCPToolCalls, map[string]int{"db": 1, "linear": 1})
+}
+
+func checkCounts(t *testing.T, name string, got, want map[string]int) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Errorf("%s = %v, want %v", name, got, want)
+		return
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s[%q] = %d, want %d", name, k, got[k], v)
+		}
+	}
+}
+
+func TestCodexShellProgram(t *testing.T) {
+	tests := []struct {
+		raw, want string
+	}{
+		{`"ls -la"`, "ls"},
+		{`["git","log","-1"]`, "git"},
+		{`["bash","-lc","cd /repo; make test"]`, "make"},
+		{`["/bin/zsh","-c","FOO=1 BAR=2 ./scripts/run.sh"]`, "run.sh"},
+		{`"cd /repo"`, ""},
+		{`""`, ""},
+		{`42`, ""},
+	}
+	for _, tt := range tests {
+		if got := codexShellProgram([]byte(tt.raw)); got != tt.want {
+			t.Errorf("codexShellProgram(%s) = %q, want %q", tt.raw, got, tt.want)
+		}
+	}
+}
+
 func TestCodexSessionIDFromFilenameFallback(t *testing.T) {
 	// Short filename that doesn't contain a full UUID.
 	got := codexSessionIDFromFilename("rollout-short.jsonl")
@@ -0,0 +1,109 @@
+package monitor
+
+import (
+	"path"
+	"strconv"
+	"strings"
+)
+
+// mountInfo is one line of /proc/<pid>/mountinfo: the filesystem dev,
+// the directory inside that filesystem mounted (root), and where it is
+// mounted in the process's namespace (point).
+type mountInfo struct {
+	dev   string
+	root  string
+	point string
+}
+
+// parseMountInfo reads the mountinfo format documented in proc(5):
+//
+//	36 35 98:0 /mnt1 /mnt2 rw,noatime master:1 - ext3 /dev/root rw
+//
+// Lines it can't read are skipped.
+func parseMountInfo(data string) []mountInfo {
+	var mounts []mountInfo
+	for _, line := range strings.Split(data, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 5 {
+			continue
+		}
+		mounts = append(mounts, mountInfo{
+			dev:   fields[2],
+			root:  unescapeMountPath(fields[3]),
+			point: unescapeMountPath(fields[4]),
+		})
+	}
+	return mounts
+}
+
+// unescapeMountPath decodes the octal escapes (\040 for a space) the
+// kernel uses in mountinfo paths.
+func unescapeMountPath(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+4 <= len(s) {
+			if n, err := strconv.ParseUint(s[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(n))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// translateMountPath maps dir, a path in a container's mount namespace
+// described by inner, to the same directory in the host namespace
+// described by host. It finds the container mount holding dir, then a
+// host mount of the same filesystem that exposes that part of it. The
+// second result is false if the host has no such mount.
+func translateMountPath(dir string, inner, host []mountInfo) (string, bool) {
+	in, ok := longestMount(dir, inner)
+	if !ok {
+		return "", false
+	}
+	src := path.Join(in.root, strings.TrimPrefix(dir, in.point))
+
+	best := -1
+	for i := 0; i < len(host); i++ {
+		h := host[i]
+		if h.dev != in.dev || !underPath(src, h.root) {
+			continue
+		}
+		if best < 0 || len(h.root) > len(host[best].root) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return "", false
+	}
+	h := host[best]
+	return path.Join(h.point, strings.TrimPrefix(src, h.root)), true
+}
+
+// longestMount returns the mount with the deepest mount point containing
+// dir. Later mounts shadow earlier ones at the same point.
+func longestMount(dir string, mounts []mountInfo) (mountInfo, bool) {
+	best := -1
+	for i := 0; i < len(mounts); i++ {
+		if !underPath(dir, mounts[i].point) {
+			continue
+		}
+		if best < 0 || len(mounts[i].point) >= len(mounts[best].point) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return mountInfo{}, false
+	}
+	return mounts[best], true
+}
+
+// underPath reports whether p is dir or inside it.
+func underPath(p, dir string) bool {
+	return dir == "/" || p == dir || strings.HasPrefix(p, dir+"/")
+}
@@ -0,0 +1,36 @@
+//go:build linux
+
+package monitor
+
+import (
+	"fmt"
+	"os"
+)
+
+// containerHostDir returns where cwd, the working directory of pid as seen
+// from inside its own mount namespace, lives on the host. It is empty for
+// processes sharing the server's mount namespace, and when the directory
+// isn't reachable from the host (e.g. it's only in the container's image).
+func containerHostDir(pid int, cwd string) string {
+	self, err := os.Readlink("/proc/self/ns/mnt")
+	if err != nil {
+		return ""
+	}
+	if ns, err := os.Readlink(fmt.Sprintf("/proc/%d/ns/mnt", pid)); err != nil || ns == self {
+		return ""
+	}
+
+	inner, err := os.ReadFile(fmt.Sprintf("/proc/%d/mountinfo", pid))
+	if err != nil {
+		return ""
+	}
+	host, err := os.ReadFile("/proc/self/mountinfo")
+	if err != nil {
+		return ""
+	}
+	dir, ok := translateMountPath(cwd, parseMountInfo(string(inner)), parseMountInfo(string(host)))
+	if !ok || dir == cwd {
+		return ""
+	}
+	return dir
+}
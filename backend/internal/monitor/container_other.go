@@ -0,0 +1,9 @@
+//go:build !linux
+
+package monitor
+
+// containerHostDir is Linux-only: elsewhere containers run in a VM whose
+// processes the server can't see.
+func containerHostDir(pid int, cwd string) string {
+	return ""
+}
@@ -0,0 +1,117 @@
+package monitor
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// Host and container views of a devcontainer that bind-mounts
+// /home/kim/src/app at /workspaces/app. 8:1 is the host's ext4 root;
+// 0:52 is the container's overlay rootfs.
+const (
+	hostMountInfo = `22 1 8:1 / / rw,relatime shared:1 - ext4 /dev/sda1 rw
+40 22 8:2 / /home rw,relatime shared:2 - ext4 /dev/sda2 rw
+61 22 0:52 / /var/lib/docker/overlay2/abc/merged rw - overlay overlay rw`
+	containerMountInfo = `500 480 0:52 / / rw,relatime - overlay overlay rw
+501 500 8:2 /kim/src/app /workspaces/app rw,relatime - ext4 /dev/sda2 rw
+502 500 8:2 /kim/My\040Docs /docs rw,relatime - ext4 /dev/sda2 rw`
+)
+
+func TestParseMountInfo(t *testing.T) {
+	got := parseMountInfo(containerMountInfo)
+	want := []mountInfo{
+		{dev: "0:52", root: "/", point: "/"},
+		{dev: "8:2", root: "/kim/src/app", point: "/workspaces/app"},
+		{dev: "8:2", root: "/kim/My Docs", point: "/docs"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseMountInfo = %+v, want %+v", got, want)
+	}
+}
+
+func TestTranslateMountPath(t *testing.T) {
+	inner := parseMountInfo(containerMountInfo)
+	host := parseMountInfo(hostMountInfo)
+
+	tests := []struct {
+		dir, want string
+		ok        bool
+	}{
+		{"/workspaces/app", "/home/kim/src/app", true},
+		{"/workspaces/app/pkg/api", "/home/kim/src/app/pkg/api", true},
+		{"/docs/notes", "/home/kim/My Docs/notes", true},
+		// Only in the image: visible on the host through the overlay.
+		{"/opt/tool", "/var/lib/docker/overlay2/abc/merged/opt/tool", true},
+		// Looks like the bind mount but isn't under it.
+		{"/workspaces/application", "/var/lib/docker/overlay2/abc/merged/workspaces/application", true},
+	}
+	for _, tt := range tests {
+		got, ok := translateMountPath(tt.dir, inner, host)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("translateMountPath(%q) = %q, %v; want %q, %v", tt.dir, got, ok, tt.want, tt.ok)
+		}
+	}
+
+	// A filesystem the host doesn't mount can't be translated.
+	if got, ok := translateMountPath("/data", []mountInfo{{dev: "0:99", root: "/", point: "/data"}}, host); ok {
+		t.Errorf("unmounted filesystem translated to %q", got)
+	}
+}
+
+func TestPollMatchesContainerizedAgentByHostDir(t *testing.T) {
+	dir := t.TempDir()
+	jsonlPath := filepath.Join(dir, "session-box.jsonl")
+	now := time.Now().UTC()
+	writeJSONL(t, jsonlPath,
+		jsonlLine("assistant", "session-box", now.Format(time.RFC3339Nano), "claude-opus-4-5-20251101", "", "/home/kim/src/app"))
+
+	src := &testSource{
+		handles: []SessionHandle{newTestHandle("session-box", jsonlPath, "/home/kim/src/app", now)},
+	}
+	cfg := defaultTestConfig()
+	cfg.Monitor.ChurningCPUThreshold = 15.0
+	m, store, _ := newPollTestMonitor(src, cfg)
+	m.discoverProcessActivity = func(prevCPU map[int]cpuSample, elapsed time.Duration) ([]ProcessActivity, map[int]cpuSample) {
+		return []ProcessActivity{
+			{PID: 77, CPU: 40, WorkingDir: "/workspaces/app", HostDir: "/home/kim/src/app"},
+		}, prevCPU
+	}
+
+	m.poll()
+
+	state, ok := store.Get("claude:session-box")
+	if !ok {
+		t.Fatal("session should exist after poll")
+	}
+	if state.PID != 77 || !state.IsChurning {
+		t.Errorf("PID = %d, churning = %v; want the containerized process", state.PID, state.IsChurning)
+	}
+	if got := m.hostPath("/workspaces/app"); got != "/home/kim/src/app" {
+		t.Errorf("hostPath = %q, want the host directory", got)
+	}
+}
+
+func TestRefreshProcessActivityDropsAmbiguousHostDirs(t *testing.T) {
+	m, _, _ := newPollTestMonitor(&testSource{}, defaultTestConfig())
+	m.discoverProcessActivity = func(prevCPU map[int]cpuSample, elapsed time.Duration) ([]ProcessActivity, map[int]cpuSample) {
+		return []ProcessActivity{
+			{PID: 1, WorkingDir: "/workspace", HostDir: "/home/kim/a"},
+			{PID: 2, WorkingDir: "/workspace", HostDir: "/home/kim/b"},
+			{PID: 3, WorkingDir: "/src", HostDir: "/home/kim/c"},
+		}, prevCPU
+	}
+
+	byDir := m.refreshProcessActivity(time.Now())
+
+	if got := m.hostPath("/workspace"); got != "/workspace" {
+		t.Errorf("hostPath(/workspace) = %q, want it left alone when two containers share it", got)
+	}
+	if got := m.hostPath("/src"); got != "/home/kim/c" {
+		t.Errorf("hostPath(/src) = %q, want /home/kim/c", got)
+	}
+	if byDir["/home/kim/a"].PID != 1 || byDir["/home/kim/b"].PID != 2 {
+		t.Errorf("host dirs should still match their own processes: %+v", byDir)
+	}
+}
@@ -0,0 +1,150 @@
+package monitor
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// coldRecheckInterval is how often discovery re-stats files that were
+// outside the window when last seen. Appending to a file doesn't change
+// its directory's mtime, so without a recheck a resumed old session would
+// never be found again.
+const coldRecheckInterval = time.Minute
+
+// racyWindow covers filesystems with coarse mtimes: a directory changed
+// this close to when it was listed may have changed again within the same
+// tick, so it is listed again until its mtime is older than that.
+const racyWindow = 2 * time.Second
+
+// dirCache remembers directory listings and file mtimes between discovery
+// passes, so a tree holding thousands of old sessions isn't listed and
+// stat'ed on every poll. A directory is listed again only when its mtime
+// changes, which it does when entries are created, removed or renamed.
+// Files last seen inside the window are stat'ed on every pass, older ones
+// every coldRecheckInterval. A dirCache is not safe for concurrent use.
+type dirCache struct {
+	dirs map[string]*cachedDir
+	pass int
+
+	now     func() time.Time
+	stat    func(string) (os.FileInfo, error)
+	readDir func(string) ([]os.DirEntry, error)
+}
+
+// cachedDir is one directory's listing.
+type cachedDir struct {
+	mtime    time.Time
+	listedAt time.Time
+	checked  time.Time            // when cold files were last stat'ed
+	subdirs  []string             // names
+	files    map[string]time.Time // matching file name -> mtime
+	pass     int                  // last pass that visited the directory
+}
+
+func newDirCache() *dirCache {
+	return &dirCache{
+		dirs:    make(map[string]*cachedDir),
+		now:     time.Now,
+		stat:    os.Stat,
+		readDir: os.ReadDir,
+	}
+}
+
+// recentFile is a file recentFiles found, with its mtime when last stat'ed.
+type recentFile struct {
+	path    string
+	modTime time.Time
+}
+
+// recentFiles returns the files under root that match and were modified
+// after cutoff. Files are taken from the directories fileDepth levels below
+// root, or from every level when fileDepth is negative. An unreadable root
+// is an error; unreadable directories under it are skipped.
+func (c *dirCache) recentFiles(root string, fileDepth int, match func(name string) bool, cutoff time.Time) ([]recentFile, error) {
+	c.pass++
+	var found []recentFile
+	err := c.visit(root, fileDepth, match, cutoff, &found)
+	for dir, d := range c.dirs {
+		if d.pass != c.pass {
+			delete(c.dirs, dir)
+		}
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].path < found[j].path })
+	return found, err
+}
+
+func (c *dirCache) visit(dir string, fileDepth int, match func(string) bool, cutoff time.Time, found *[]recentFile) error {
+	info, err := c.stat(dir)
+	if err != nil {
+		return err
+	}
+	now := c.now()
+	d := c.dirs[dir]
+	if d == nil || !info.ModTime().Equal(d.mtime) || !d.mtime.Before(d.listedAt.Add(-racyWindow)) {
+		d, err = c.list(dir, info.ModTime(), now, fileDepth <= 0, match)
+		if err != nil {
+			delete(c.dirs, dir)
+			return err
+		}
+		c.dirs[dir] = d
+	} else {
+		cold := now.Sub(d.checked) >= coldRecheckInterval
+		for name, mtime := range d.files {
+			if !cold && !mtime.After(cutoff) {
+				continue
+			}
+			fi, err := c.stat(filepath.Join(dir, name))
+			if err != nil {
+				delete(d.files, name)
+				continue
+			}
+			d.files[name] = fi.ModTime()
+		}
+		if cold {
+			d.checked = now
+		}
+	}
+	d.pass = c.pass
+
+	if fileDepth <= 0 {
+		for name, mtime := range d.files {
+			if mtime.After(cutoff) {
+				*found = append(*found, recentFile{path: filepath.Join(dir, name), modTime: mtime})
+			}
+		}
+	}
+	if fileDepth != 0 {
+		for i := 0; i < len(d.subdirs); i++ {
+			_ = c.visit(filepath.Join(dir, d.subdirs[i]), fileDepth-1, match, cutoff, found)
+		}
+	}
+	return nil
+}
+
+// list reads dir and, when withFiles is set, stats every matching file in
+// it.
+func (c *dirCache) list(dir string, mtime, now time.Time, withFiles bool, match func(string) bool) (*cachedDir, error) {
+	entries, err := c.readDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	d := &cachedDir{mtime: mtime, listedAt: now, checked: now, files: make(map[string]time.Time)}
+	for i := 0; i < len(entries); i++ {
+		name := entries[i].Name()
+		if entries[i].IsDir() {
+			d.subdirs = append(d.subdirs, name)
+			continue
+		}
+		if !withFiles || !match(name) {
+			continue
+		}
+		fi, err := c.stat(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		d.files[name] = fi.ModTime()
+	}
+	return d, nil
+}
@@ -0,0 +1,153 @@
+package monitor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// countingDirCache is a dirCache that counts its filesystem calls.
+type countingDirCache struct {
+	*dirCache
+	reads, stats int
+	now          time.Time
+}
+
+func newCountingDirCache(now time.Time) *countingDirCache {
+	c := &countingDirCache{dirCache: newDirCache(), now: now}
+	c.dirCache.now = func() time.Time { return c.now }
+	c.dirCache.readDir = func(dir string) ([]os.DirEntry, error) {
+		c.reads++
+		return os.ReadDir(dir)
+	}
+	c.dirCache.stat = func(path string) (os.FileInfo, error) {
+		c.stats++
+		return os.Stat(path)
+	}
+	return c
+}
+
+func (c *countingDirCache) find(t *testing.T, root string, fileDepth int, window time.Duration) []string {
+	t.Helper()
+	c.reads, c.stats = 0, 0
+	isJSONL := func(name string) bool { return strings.HasSuffix(name, ".jsonl") }
+	files, err := c.recentFiles(root, fileDepth, isJSONL, c.now.Add(-window))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, f := range files {
+		rel, _ := filepath.Rel(root, f.path)
+		names = append(names, rel)
+	}
+	return names
+}
+
+// touch creates path with the given mtime.
+func touch(t *testing.T, path string, mtime time.Time) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("{}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func setMtime(t *testing.T, path string, mtime time.Time) {
+	t.Helper()
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDirCacheSkipsUnchangedDirectories(t *testing.T) {
+	root := t.TempDir()
+	now := time.Now()
+	old := now.Add(-48 * time.Hour)
+	for i := 0; i < 50; i++ {
+		touch(t, filepath.Join(root, "old-project", fmt.Sprintf("s%02d.jsonl", i)), old)
+	}
+	touch(t, filepath.Join(root, "live", "hot.jsonl"), now.Add(-time.Minute))
+	setMtime(t, filepath.Join(root, "old-project"), old)
+	setMtime(t, filepath.Join(root, "live"), old)
+	setMtime(t, root, old)
+
+	c := newCountingDirCache(now)
+	if got := c.find(t, root, 1, 10*time.Minute); len(got) != 1 || got[0] != "live/hot.jsonl" {
+		t.Fatalf("first pass = %v", got)
+	}
+	if c.reads != 3 || c.stats < 51 {
+		t.Errorf("first pass: %d reads, %d stats; want every directory listed and every file stat'ed", c.reads, c.stats)
+	}
+
+	c.now = now.Add(time.Second)
+	if got := c.find(t, root, 1, 10*time.Minute); len(got) != 1 {
+		t.Fatalf("second pass = %v", got)
+	}
+	// Three directories and the one file inside the window.
+	if c.reads != 0 || c.stats != 4 {
+		t.Errorf("second pass: %d reads, %d stats; want 0 and 4", c.reads, c.stats)
+	}
+}
+
+func TestDirCacheFindsNewAndResumedFiles(t *testing.T) {
+	root := t.TempDir()
+	now := time.Now()
+	old := now.Add(-48 * time.Hour)
+	touch(t, filepath.Join(root, "p", "resumed.jsonl"), old)
+	setMtime(t, filepath.Join(root, "p"), old)
+	setMtime(t, root, old)
+
+	c := newCountingDirCache(now)
+	if got := c.find(t, root, 1, 10*time.Minute); len(got) != 0 {
+		t.Fatalf("first pass = %v", got)
+	}
+
+	// A new file changes its directory's mtime, so it is found at once.
+	touch(t, filepath.Join(root, "p", "new.jsonl"), now)
+	setMtime(t, filepath.Join(root, "p"), old.Add(time.Hour))
+	if got := c.find(t, root, 1, 10*time.Minute); len(got) != 1 || got[0] != "p/new.jsonl" {
+		t.Errorf("after create = %v", got)
+	}
+
+	// Appending to an old file doesn't; it is found at the next recheck.
+	setMtime(t, filepath.Join(root, "p", "resumed.jsonl"), now)
+	if got := c.find(t, root, 1, 10*time.Minute); len(got) != 1 {
+		t.Errorf("before recheck = %v, want only new.jsonl", got)
+	}
+	c.now = now.Add(coldRecheckInterval)
+	if got := c.find(t, root, 1, 10*time.Minute); len(got) != 2 {
+		t.Errorf("after recheck = %v, want both files", got)
+	}
+}
+
+func TestDirCacheWalksNestedDirectories(t *testing.T) {
+	root := t.TempDir()
+	now := time.Now()
+	old := now.Add(-48 * time.Hour)
+	touch(t, filepath.Join(root, "2026", "01", "02", "rollout-old.jsonl"), old)
+	touch(t, filepath.Join(root, "2026", "03", "01", "rollout-new.jsonl"), now)
+	touch(t, filepath.Join(root, "2026", "03", "skip.txt"), now)
+
+	c := newCountingDirCache(now)
+	got := c.find(t, root, -1, 10*time.Minute)
+	if len(got) != 1 || got[0] != filepath.Join("2026", "03", "01", "rollout-new.jsonl") {
+		t.Fatalf("found %v", got)
+	}
+
+	// Removed directories are dropped from the cache.
+	if err := os.RemoveAll(filepath.Join(root, "2026", "01")); err != nil {
+		t.Fatal(err)
+	}
+	c.find(t, root, -1, 10*time.Minute)
+	if _, ok := c.dirs[filepath.Join(root, "2026", "01", "02")]; ok {
+		t.Error("removed directory still cached")
+	}
+}
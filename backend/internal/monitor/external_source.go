@@ -0,0 +1,197 @@
+package monitor
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/agent-racer/backend/internal/ws"
+)
+
+// ExternalSourceName is the name of the source that sessions reported over
+// PUT /api/external/sessions/{id} race under.
+const ExternalSourceName = "external"
+
+// maxExternalSessions caps how many reported sessions are held at once, so
+// a runaway script can't grow the server without bound.
+const maxExternalSessions = 256
+
+// externalRetention is how long a session is remembered after its
+// heartbeat lapses, so a producer that was only slow can carry on.
+const externalRetention = 10 * time.Minute
+
+// ErrTooManyExternalSessions is returned by ExternalSource.Put when it
+// already holds maxExternalSessions sessions.
+var ErrTooManyExternalSessions = errors.New("too many external sessions")
+
+// ExternalSource implements Source for sessions that scripts and CI jobs
+// push to the server, such as a "terraform apply" run reported as an agent.
+// Producers send the whole state each time; the source remembers what it
+// last reported for each session and hands the monitor deltas, like
+// RemoteSource does.
+//
+// Every report, even a duplicate, is a heartbeat. A session that goes its
+// heartbeat TTL without one is marked lost by the monitor; see
+// HeartbeatSource. It is forgotten externalRetention later.
+//
+// Unlike other sources, Put is called from HTTP handlers while the monitor
+// polls, so ExternalSource is safe for concurrent use.
+type ExternalSource struct {
+	mu       sync.Mutex
+	ttl      time.Duration // heartbeat TTL for sessions that don't set one
+	sessions map[string]*externalSession
+	now      func() time.Time
+}
+
+type externalSession struct {
+	doc        ws.ExternalSessionUpdate
+	version    int64     // documents applied; the monitor's offset
+	receivedAt time.Time // last heartbeat
+	ended      bool
+
+	reported ws.ExternalSessionUpdate // as of the last Parse that returned data
+}
+
+// NewExternalSource returns a source whose sessions are expected to report
+// at least every ttl unless they ask for a different heartbeat.
+func NewExternalSource(ttl time.Duration) *ExternalSource {
+	return &ExternalSource{
+		ttl:      ttl,
+		sessions: make(map[string]*externalSession),
+		now:      time.Now,
+	}
+}
+
+func (e *ExternalSource) Name() string { return ExternalSourceName }
+
+// SetTTL changes the heartbeat TTL for sessions that don't set their own.
+func (e *ExternalSource) SetTTL(ttl time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.ttl = ttl
+}
+
+// Put applies a producer's document for session id. Documents carry
+// totals, so applying one twice changes nothing; one whose Seq is at or
+// below the last applied, or that arrives after the session ended, is
+// acknowledged with Applied false and dropped.
+func (e *ExternalSource) Put(id string, doc ws.ExternalSessionUpdate) (ws.ExternalSessionResult, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	now := e.now()
+	e.expireLocked(now)
+
+	s, ok := e.sessions[id]
+	if !ok {
+		if len(e.sessions) >= maxExternalSessions {
+			return ws.ExternalSessionResult{}, ErrTooManyExternalSessions
+		}
+		s = &externalSession{}
+		e.sessions[id] = s
+	}
+	if s.ended {
+		return ws.ExternalSessionResult{ID: id, Seq: s.doc.Seq, Applied: false}, nil
+	}
+	s.receivedAt = now
+	if doc.Seq != 0 && doc.Seq <= s.doc.Seq {
+		return ws.ExternalSessionResult{ID: id, Seq: s.doc.Seq, Applied: false}, nil
+	}
+
+	if doc.LastActivityAt.IsZero() {
+		doc.LastActivityAt = now
+	}
+	if doc.StartedAt.IsZero() {
+		doc.StartedAt = s.doc.StartedAt
+		if doc.StartedAt.IsZero() {
+			doc.StartedAt = now
+		}
+	}
+	if doc.Seq == 0 {
+		doc.Seq = s.doc.Seq
+	}
+	s.doc = doc
+	s.version++
+	s.ended = isRemoteTerminal(doc.Activity)
+	return ws.ExternalSessionResult{ID: id, Seq: doc.Seq, Applied: true}, nil
+}
+
+func (e *ExternalSource) Discover() ([]SessionHandle, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.expireLocked(e.now())
+
+	handles := make([]SessionHandle, 0, len(e.sessions))
+	for id, s := range e.sessions {
+		handles = append(handles, SessionHandle{
+			SessionID:  id,
+			WorkingDir: s.doc.WorkingDir,
+			Name:       s.doc.Name,
+			Source:     ExternalSourceName,
+			StartedAt:  s.doc.StartedAt,
+		})
+	}
+	return handles, nil
+}
+
+// Parse reports what changed in a session since it was last reported. The
+// offset counts the documents applied.
+func (e *ExternalSource) Parse(handle SessionHandle, offset int64) (SourceUpdate, int64, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	s, ok := e.sessions[handle.SessionID]
+	if !ok || s.version <= offset {
+		return SourceUpdate{}, offset, nil
+	}
+	d, prev := s.doc, s.reported
+	s.reported = d
+
+	update := SourceUpdate{
+		Model:             d.Model,
+		TokensIn:          d.TokensUsed,
+		MessageCount:      max(d.MessageCount-prev.MessageCount, 0),
+		ToolCalls:         max(d.ToolCallCount-prev.ToolCallCount, 0),
+		LastTool:          d.CurrentTool,
+		LastTime:          d.LastActivityAt,
+		WorkingDir:        d.WorkingDir,
+		Branch:            d.Branch,
+		MaxContextTokens:  d.MaxContextTokens,
+		LastAssistantText: d.LastMessage,
+	}
+	switch d.Activity {
+	case "thinking", "tool_use", "waiting", "needs_approval":
+		update.Activity = d.Activity
+	case "complete", "errored":
+		update.Ended = d.Activity
+	}
+	return update, s.version, nil
+}
+
+// Heartbeat implements HeartbeatSource.
+func (e *ExternalSource) Heartbeat(sessionID string) (time.Time, time.Duration, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	s, ok := e.sessions[sessionID]
+	if !ok {
+		return time.Time{}, 0, false
+	}
+	return s.receivedAt, e.heartbeatTTL(s), true
+}
+
+// heartbeatTTL returns how long s may go between reports. Caller must hold
+// e.mu.
+func (e *ExternalSource) heartbeatTTL(s *externalSession) time.Duration {
+	if s.doc.HeartbeatSeconds > 0 {
+		return time.Duration(s.doc.HeartbeatSeconds) * time.Second
+	}
+	return e.ttl
+}
+
+// expireLocked forgets sessions externalRetention after their heartbeat
+// lapsed. Caller must hold e.mu.
+func (e *ExternalSource) expireLocked(now time.Time) {
+	for id, s := range e.sessions {
+		if now.Sub(s.receivedAt) > e.heartbeatTTL(s)+externalRetention {
+			delete(e.sessions, id)
+		}
+	}
+}
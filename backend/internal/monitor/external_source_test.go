@@ -0,0 +1,168 @@
+package monitor
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/agent-racer/backend/internal/session"
+	"github.com/agent-racer/backend/internal/ws"
+)
+
+func TestExternalSourceDedupesRetries(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	src := NewExternalSource(time.Minute)
+	src.now = func() time.Time { return now }
+
+	put := func(doc ws.ExternalSessionUpdate) ws.ExternalSessionResult {
+		t.Helper()
+		res, err := src.Put("tf", doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+
+	if res := put(ws.ExternalSessionUpdate{Seq: 1, Name: "terraform apply", Activity: "tool_use", ToolCallCount: 3}); !res.Applied {
+		t.Fatalf("first document not applied: %+v", res)
+	}
+	handles, _ := src.Discover()
+	if len(handles) != 1 || handles[0].Name != "terraform apply" || !handles[0].StartedAt.Equal(now) {
+		t.Fatalf("handles = %+v", handles)
+	}
+	update, offset, _ := src.Parse(handles[0], 0)
+	if update.ToolCalls != 3 || update.Activity != "tool_use" || !update.LastTime.Equal(now) {
+		t.Errorf("first update = %+v", update)
+	}
+
+	// A retry of the same document, and a late one, change nothing.
+	if res := put(ws.ExternalSessionUpdate{Seq: 1, Activity: "tool_use", ToolCallCount: 3}); res.Applied {
+		t.Error("retry applied")
+	}
+	put(ws.ExternalSessionUpdate{Seq: 3, Activity: "tool_use", ToolCallCount: 7})
+	if res := put(ws.ExternalSessionUpdate{Seq: 2, Activity: "waiting", ToolCallCount: 5}); res.Applied || res.Seq != 3 {
+		t.Errorf("out-of-order document = %+v, want ignored at seq 3", res)
+	}
+	update, offset, _ = src.Parse(handles[0], offset)
+	if update.ToolCalls != 4 || update.Activity != "tool_use" {
+		t.Errorf("second update = %+v, want 4 more tool calls", update)
+	}
+	if again, off, _ := src.Parse(handles[0], offset); again.HasData() || off != offset {
+		t.Errorf("reported again without a new document: %+v", again)
+	}
+
+	put(ws.ExternalSessionUpdate{Seq: 4, Activity: "complete", ToolCallCount: 7})
+	if update, _, _ := src.Parse(handles[0], offset); update.Ended != "complete" {
+		t.Errorf("final update = %+v, want ended", update)
+	}
+	if res := put(ws.ExternalSessionUpdate{Seq: 5, Activity: "thinking"}); res.Applied {
+		t.Error("update after the session ended was applied")
+	}
+
+	now = now.Add(time.Minute + externalRetention + time.Second)
+	if handles, _ := src.Discover(); len(handles) != 0 {
+		t.Errorf("expired session still discovered: %+v", handles)
+	}
+}
+
+func TestExternalSourceHeartbeat(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	start := now
+	src := NewExternalSource(time.Minute)
+	src.now = func() time.Time { return now }
+
+	src.Put("default", ws.ExternalSessionUpdate{Seq: 1})
+	src.Put("slow", ws.ExternalSessionUpdate{HeartbeatSeconds: 3600})
+	if _, ttl, _ := src.Heartbeat("default"); ttl != time.Minute {
+		t.Errorf("default ttl = %v, want the source's", ttl)
+	}
+	if _, ttl, _ := src.Heartbeat("slow"); ttl != time.Hour {
+		t.Errorf("slow ttl = %v, want 1h", ttl)
+	}
+
+	// A duplicate isn't applied but still counts as a heartbeat.
+	now = now.Add(30 * time.Second)
+	src.Put("default", ws.ExternalSessionUpdate{Seq: 1})
+	if last, _, _ := src.Heartbeat("default"); !last.Equal(now) {
+		t.Errorf("last heartbeat = %v, want %v", last, now)
+	}
+
+	now = start.Add(10 * time.Minute)
+	if handles, _ := src.Discover(); len(handles) != 2 {
+		t.Errorf("discovered %d sessions, want both until retention runs out", len(handles))
+	}
+	now = start.Add(12 * time.Minute)
+	if handles, _ := src.Discover(); len(handles) != 1 || handles[0].SessionID != "slow" {
+		t.Errorf("discovered %+v, want only the session with a long heartbeat", handles)
+	}
+	if _, _, ok := src.Heartbeat("missing"); ok {
+		t.Error("Heartbeat reported an unknown session")
+	}
+}
+
+func TestExternalSourceLimitsSessions(t *testing.T) {
+	src := NewExternalSource(time.Minute)
+	for i := 0; i < maxExternalSessions; i++ {
+		if _, err := src.Put(fmt.Sprintf("job-%d", i), ws.ExternalSessionUpdate{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := src.Put("one-more", ws.ExternalSessionUpdate{}); !errors.Is(err, ErrTooManyExternalSessions) {
+		t.Errorf("err = %v, want ErrTooManyExternalSessions", err)
+	}
+	if _, err := src.Put("job-0", ws.ExternalSessionUpdate{Seq: 1}); err != nil {
+		t.Errorf("updating a held session failed: %v", err)
+	}
+}
+
+func TestPollTracksExternalSession(t *testing.T) {
+	cfg := defaultTestConfig()
+	cfg.TokenNorm.Strategies[ExternalSourceName] = "usage"
+	src := NewExternalSource(time.Minute)
+	m, store, _ := newPollTestMonitorWithSources([]Source{src}, cfg)
+
+	if _, err := src.Put("deploy-1", ws.ExternalSessionUpdate{
+		Name: "deploy", Activity: "thinking", TokensUsed: 10, MaxContextTokens: 40, LastMessage: "planning",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	m.poll()
+
+	state, ok := store.Get("external:deploy-1")
+	if !ok {
+		t.Fatal("external session not tracked")
+	}
+	if state.Name != "deploy" || state.TokensUsed != 10 || state.MaxContextTokens != 40 || state.Activity != session.Thinking {
+		t.Errorf("state = %+v", state)
+	}
+}
+
+func TestPollMarksExternalSessionLostOnMissedHeartbeat(t *testing.T) {
+	cfg := defaultTestConfig()
+	cfg.Monitor.SessionStaleAfter = time.Hour
+	src := NewExternalSource(time.Minute)
+	reported := time.Now()
+	src.now = func() time.Time { return reported }
+	m, store, _ := newPollTestMonitorWithSources([]Source{src}, cfg)
+
+	src.Put("quiet", ws.ExternalSessionUpdate{Activity: "thinking", HeartbeatSeconds: 5})
+	src.Put("chatty", ws.ExternalSessionUpdate{Activity: "thinking"})
+	m.poll()
+
+	// Ten seconds on, quiet has missed its heartbeat; chatty's is a minute.
+	reported = reported.Add(-10 * time.Second)
+	src.mu.Lock()
+	for _, s := range src.sessions {
+		s.receivedAt = reported
+	}
+	src.mu.Unlock()
+	m.poll()
+
+	if state, _ := store.Get("external:quiet"); state.Activity != session.Lost {
+		t.Errorf("quiet activity = %v, want lost", state.Activity)
+	}
+	if state, _ := store.Get("external:chatty"); state.Activity != session.Thinking {
+		t.Errorf("chatty activity = %v, want still thinking", state.Activity)
+	}
+}
@@ -1,6 +1,7 @@
 package monitor
 
 import (
+	"bytes"
 	"crypto/sha256"
 	"encoding/json"
 	"fmt"
@@ -10,13 +11,16 @@ import (
 	"strings"
 	"time"
 
+	"github.com/agent-racer/backend/internal/session"
 	"github.com/shirou/gopsutil/v3/process"
 )
 
 // GeminiSource implements Source for Google Gemini CLI sessions. It discovers
 // sessions by scanning ~/.gemini/tmp/*/chats/ for recently-modified session
-// JSON files and parses them fully on each poll (since Gemini rewrites the
-// entire JSON file on each update).
+// JSON files. Gemini rewrites the entire JSON file on each update, so every
+// poll re-reads the file, but only the last message already reported and the
+// ones appended since are decoded; earlier ones are skipped without being
+// unmarshalled.
 //
 // The key challenge is that Gemini uses a one-way SHA-256 hash of the
 // project directory as the folder name. We maintain a hash-to-path lookup
@@ -35,16 +39,21 @@ type GeminiSource struct {
 	// mtime's UnixNano value.
 	lastParsed map[string]time.Time
 
-	// prevCounts tracks the absolute message/tool counts from the previous
-	// parse of each session file so we can return deltas to the monitor.
-	prevCounts map[string]geminiAbsoluteCounts
+	// seenMessages tracks how much of each session file has already been
+	// reported to the monitor. The next parse skips all but the last of
+	// those messages and returns counts for the remainder, which are the
+	// deltas the monitor accumulates.
+	seenMessages map[string]geminiSeen
 }
 
-// geminiAbsoluteCounts holds the absolute counts from the last full parse
-// of a Gemini session file. Used to compute deltas for the monitor.
-type geminiAbsoluteCounts struct {
-	Messages  int
-	ToolCalls int
+// geminiSeen records what has been reported from one session file. Gemini
+// CLI rewrites its last message in place after creating it, filling in
+// tokens and tool calls, so that message is decoded again on the next
+// parse and only what it gained since is counted.
+type geminiSeen struct {
+	messages      int // messages reported, the last included
+	lastMessages  int // MessageCount the last message contributed
+	lastToolCalls int // ToolCalls the last message contributed
 }
 
 func NewGeminiSource(discoverWindow time.Duration) *GeminiSource {
@@ -52,7 +61,7 @@ func NewGeminiSource(discoverWindow time.Duration) *GeminiSource {
 		discoverWindow: discoverWindow,
 		hashToPath:     make(map[string]string),
 		lastParsed:     make(map[string]time.Time),
-		prevCounts:     make(map[string]geminiAbsoluteCounts),
+		seenMessages:   make(map[string]geminiSeen),
 	}
 }
 
@@ -155,9 +164,9 @@ func (g *GeminiSource) discoverFromDir(tmpDir string) []SessionHandle {
 			delete(g.lastParsed, path)
 		}
 	}
-	for path := range g.prevCounts {
+	for path := range g.seenMessages {
 		if !activeLogPaths[path] {
-			delete(g.prevCounts, path)
+			delete(g.seenMessages, path)
 		}
 	}
 
@@ -188,25 +197,28 @@ func (g *GeminiSource) Parse(handle SessionHandle, offset int64) (SourceUpdate,
 		return SourceUpdate{}, offset, err
 	}
 
-	update := parseGeminiSession(data)
-
-	// Convert absolute counts to deltas. The monitor accumulates deltas,
-	// but Gemini re-parses the entire file each time returning absolute
-	// counts. We track previous values and return the difference.
-	prev := g.prevCounts[handle.LogPath]
-	current := geminiAbsoluteCounts{
-		Messages:  update.MessageCount,
-		ToolCalls: update.ToolCalls,
+	// Only the last message already reported and the ones after it are
+	// decoded, so the counts in update are already deltas. A file with
+	// fewer messages than we have seen was replaced rather than appended
+	// to; start it over.
+	seen := g.seenMessages[handle.LogPath]
+	update, next, err := parseGeminiMessages(data, seen)
+	if err == nil && next.messages < seen.messages {
+		update, next, err = parseGeminiMessages(data, geminiSeen{})
 	}
 
-	update.MessageCount = max(current.Messages-prev.Messages, 0)
-	update.ToolCalls = max(current.ToolCalls-prev.ToolCalls, 0)
-	g.prevCounts[handle.LogPath] = current
-
 	// Use the new mtime as the offset (encoded as UnixNano).
 	newOffset := currentMtime.UnixNano()
 	g.lastParsed[handle.LogPath] = currentMtime
 
+	if err != nil {
+		// Most likely caught mid-rewrite. Keep the seen count so the next
+		// modification is measured against what was actually reported.
+		slog.Debug("unreadable session file", "source", "gemini", "path", handle.LogPath, "error", err)
+		return SourceUpdate{}, newOffset, nil
+	}
+	g.seenMessages[handle.LogPath] = next
+
 	if update.HasData() {
 		update.LastTime = currentMtime
 		slog.Debug("parsed session", "source", "gemini", "path", handle.LogPath)
@@ -215,121 +227,227 @@ func (g *GeminiSource) Parse(handle SessionHandle, offset int64) (SourceUpdate,
 	return update, newOffset, nil
 }
 
-// parseGeminiSession parses the complete Gemini session JSON file and
-// returns a SourceUpdate with absolute counts. The caller (GeminiSource.Parse)
-// converts these to deltas before returning to the monitor.
+// parseGeminiSession parses a complete Gemini session JSON file and returns
+// a SourceUpdate with absolute counts, or an empty update if the file is not
+// valid JSON.
+func parseGeminiSession(data []byte) SourceUpdate {
+	update, _, err := parseGeminiMessages(data, geminiSeen{})
+	if err != nil {
+		return SourceUpdate{}
+	}
+	return update
+}
+
+// parseGeminiMessages streams the message array of a Gemini session file
+// and returns an update covering what was added since seen, together with
+// what has been seen once the update is reported. Messages before the last
+// one in seen are skipped without being unmarshalled; that last one is
+// decoded again and counts only the messages and tool calls it gained.
 //
 // The Gemini CLI session format is a JSON object with a "messages" array.
 // Each message has "type" (not "role") with values "user", "gemini", or
 // "info". Model responses use "gemini" and carry token data in a "tokens"
 // field and tool calls in a "toolCalls" array -- both at the message level,
 // not nested inside content parts.
-func parseGeminiSession(data []byte) SourceUpdate {
-	messages := unmarshalGeminiMessages(data)
-	if messages == nil {
-		return SourceUpdate{}
+func parseGeminiMessages(data []byte, seen geminiSeen) (SourceUpdate, geminiSeen, error) {
+	var update SourceUpdate
+	next := geminiSeen{}
+
+	skip := 0
+	if seen.messages > 0 {
+		skip = seen.messages - 1
+	}
+	i := skip
+	total, err := decodeGeminiMessages(data, skip, func(msg *geminiMessage) {
+		messages, toolCalls := update.MessageCount, update.ToolCalls
+		applyGeminiMessage(&update, msg)
+		next.lastMessages = update.MessageCount - messages
+		next.lastToolCalls = update.ToolCalls - toolCalls
+		if i == seen.messages-1 {
+			// Reported before; count only what it has gained.
+			update.MessageCount -= min(seen.lastMessages, next.lastMessages)
+			update.ToolCalls -= min(seen.lastToolCalls, next.lastToolCalls)
+		}
+		i++
+	})
+	if err != nil {
+		return SourceUpdate{}, seen, err
 	}
+	next.messages = total
 
-	var update SourceUpdate
+	// Only a parse from the start can be sure no message named a model;
+	// later ones leave Model empty and the monitor keeps the previous one.
+	if update.Model == "" && skip == 0 {
+		update.Model = extractGeminiModel(data)
+	}
+
+	return update, next, nil
+}
 
-	for _, msg := range messages {
-		// Resolve message kind: CLI uses "type", API uses "role".
-		kind := msg.Role
-		if kind == "" {
-			kind = msg.Type
+// applyGeminiMessage folds one session message into update.
+func applyGeminiMessage(update *SourceUpdate, msg *geminiMessage) {
+	// Resolve message kind: CLI uses "type", API uses "role".
+	kind := msg.Role
+	if kind == "" {
+		kind = msg.Type
+	}
+
+	switch kind {
+	case "user":
+		update.MessageCount++
+		update.Activity = "waiting"
+		appendGeminiText(update, msg, session.RoleUser)
+	case "model", "gemini":
+		update.MessageCount++
+		update.Activity = "thinking"
+		appendGeminiText(update, msg, session.RoleAssistant)
+
+		// Gemini CLI puts tool calls at the message level.
+		for _, tc := range msg.ToolCallsList {
+			update.ToolCalls++
+			update.Activity = "tool_use"
+			update.LastTool = tc.Name
 		}
 
-		switch kind {
-		case "user":
-			update.MessageCount++
-			update.Activity = "waiting"
-		case "model", "gemini":
-			update.MessageCount++
+		// Gemini CLI puts thoughts at the message level.
+		if len(msg.Thoughts) > 0 {
 			update.Activity = "thinking"
+		}
 
-			// Gemini CLI puts tool calls at the message level.
-			for _, tc := range msg.ToolCallsList {
+		// Gemini API puts tool calls inside content parts.
+		for _, part := range msg.Content.Parts {
+			if part.FunctionCall != nil {
 				update.ToolCalls++
 				update.Activity = "tool_use"
-				update.LastTool = tc.Name
+				update.LastTool = part.FunctionCall.Name
 			}
-
-			// Gemini CLI puts thoughts at the message level.
-			if len(msg.Thoughts) > 0 {
+			if part.Thought != "" {
 				update.Activity = "thinking"
 			}
+		}
 
-			// Gemini API puts tool calls inside content parts.
-			for _, part := range msg.Content.Parts {
-				if part.FunctionCall != nil {
-					update.ToolCalls++
-					update.Activity = "tool_use"
-					update.LastTool = part.FunctionCall.Name
-				}
-				if part.Thought != "" {
-					update.Activity = "thinking"
-				}
+		// Token usage: prefer CLI "tokens" field, fall back to
+		// API "usageMetadata" format.
+		if msg.Tokens != nil {
+			if msg.Tokens.Input > 0 {
+				update.TokensIn = msg.Tokens.Input
 			}
-
-			// Token usage: prefer CLI "tokens" field, fall back to
-			// API "usageMetadata" format.
-			if msg.Tokens != nil {
-				if msg.Tokens.Input > 0 {
-					update.TokensIn = msg.Tokens.Input
-				}
-				if msg.Tokens.Output > 0 {
-					update.TokensOut = msg.Tokens.Output
-				}
-			} else if msg.UsageMetadata != nil {
-				if msg.UsageMetadata.PromptTokenCount > 0 {
-					update.TokensIn = msg.UsageMetadata.PromptTokenCount
-				}
-				if msg.UsageMetadata.CandidatesTokenCount > 0 {
-					update.TokensOut = msg.UsageMetadata.CandidatesTokenCount
-				}
+			if msg.Tokens.Output > 0 {
+				update.TokensOut = msg.Tokens.Output
+			}
+		} else if msg.UsageMetadata != nil {
+			if msg.UsageMetadata.PromptTokenCount > 0 {
+				update.TokensIn = msg.UsageMetadata.PromptTokenCount
+			}
+			if msg.UsageMetadata.CandidatesTokenCount > 0 {
+				update.TokensOut = msg.UsageMetadata.CandidatesTokenCount
 			}
-		}
-
-		if msg.Model != "" {
-			update.Model = msg.Model
 		}
 	}
 
-	if update.Model == "" {
-		update.Model = extractGeminiModel(data)
+	if msg.Model != "" {
+		update.Model = msg.Model
 	}
-
-	return update
 }
 
-// unmarshalGeminiMessages extracts the message array from a Gemini session
-// file. The file may be a bare JSON array or a wrapper object with a
-// "messages", "conversation", or "history" field.
-func unmarshalGeminiMessages(data []byte) []geminiMessage {
-	var messages []geminiMessage
-	if json.Unmarshal(data, &messages) == nil {
-		return messages
+// geminiMessageKeys are the wrapper-object fields that may hold the message
+// array, in order of preference.
+var geminiMessageKeys = []string{"messages", "conversation", "history"}
+
+// decodeGeminiMessages walks the message array of a Gemini session file and
+// calls fn for each message after the first skip, returning how many messages
+// the array holds. The file may be a bare JSON array or a wrapper object with
+// a "messages", "conversation", or "history" field; a wrapper with none of
+// them has no messages.
+func decodeGeminiMessages(data []byte, skip int, fn func(*geminiMessage)) (int, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	tok, err := dec.Token()
+	if err != nil {
+		return 0, err
 	}
 
-	var wrapper struct {
-		Messages     []geminiMessage `json:"messages"`
-		Conversation []geminiMessage `json:"conversation"`
-		History      []geminiMessage `json:"history"`
+	switch tok {
+	case json.Delim('['):
+		return decodeGeminiArray(dec, skip, fn)
+	case json.Delim('{'):
+	default:
+		return 0, fmt.Errorf("unexpected %v at top level", tok)
 	}
-	if json.Unmarshal(data, &wrapper) != nil {
-		return nil
+
+	// Find the preferred message field. "messages" is streamed as soon as it
+	// is reached; the fallbacks are buffered in case a better one follows.
+	var found json.RawMessage
+	rank := len(geminiMessageKeys)
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return 0, err
+		}
+		key, _ := tok.(string)
+		if key == geminiMessageKeys[0] {
+			tok, err := dec.Token()
+			if err != nil {
+				return 0, err
+			}
+			if tok == json.Delim('[') {
+				return decodeGeminiArray(dec, skip, fn)
+			}
+			if tok != nil {
+				return 0, fmt.Errorf("%s is not an array", key)
+			}
+			continue
+		}
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return 0, err
+		}
+		for i := 0; i < rank; i++ {
+			if key == geminiMessageKeys[i] && string(raw) != "null" {
+				found, rank = raw, i
+				break
+			}
+		}
+	}
+	if _, err := dec.Token(); err != nil {
+		return 0, err
+	}
+	if found == nil {
+		return 0, nil
 	}
 
-	switch {
-	case wrapper.Messages != nil:
-		return wrapper.Messages
-	case wrapper.Conversation != nil:
-		return wrapper.Conversation
-	case wrapper.History != nil:
-		return wrapper.History
-	default:
-		return nil
+	dec = json.NewDecoder(bytes.NewReader(found))
+	if tok, err := dec.Token(); err != nil {
+		return 0, err
+	} else if tok != json.Delim('[') {
+		return 0, fmt.Errorf("%s is not an array", geminiMessageKeys[rank])
+	}
+	return decodeGeminiArray(dec, skip, fn)
+}
+
+// decodeGeminiArray decodes the elements of a JSON array whose opening
+// bracket dec has already consumed. The first skip elements are consumed
+// as raw bytes rather than unmarshalled.
+func decodeGeminiArray(dec *json.Decoder, skip int, fn func(*geminiMessage)) (int, error) {
+	n := 0
+	for dec.More() {
+		if n < skip {
+			var raw json.RawMessage
+			if err := dec.Decode(&raw); err != nil {
+				return 0, err
+			}
+		} else {
+			var msg geminiMessage
+			if err := dec.Decode(&msg); err != nil {
+				return 0, err
+			}
+			fn(&msg)
+		}
+		n++
+	}
+	if _, err := dec.Token(); err != nil {
+		return 0, err
 	}
+	return n, nil
 }
 
 func extractGeminiModel(data []byte) string {
@@ -369,6 +487,19 @@ func findGeminiModel(value any, depth int) string {
 	return ""
 }
 
+// appendGeminiText adds the message's text, in either content format, to
+// update.MessageText as role.
+func appendGeminiText(update *SourceUpdate, msg *geminiMessage, role string) {
+	if msg.Content.Text != "" {
+		update.MessageText = append(update.MessageText, MessageText{Role: role, Text: msg.Content.Text})
+	}
+	for _, part := range msg.Content.Parts {
+		if part.Text != "" {
+			update.MessageText = append(update.MessageText, MessageText{Role: role, Text: part.Text})
+		}
+	}
+}
+
 // geminiMessage represents a message in a Gemini session JSON file.
 // Supports both the real Gemini CLI format (type/tokens/toolCalls at
 // message level, content as a plain string) and the Gemini API format
@@ -388,6 +519,9 @@ type geminiMessage struct {
 // (Gemini CLI format) or an object with parts (Gemini API format).
 type geminiContent struct {
 	Parts []geminiPart `json:"parts"`
+
+	// Text is the content when it was a plain string.
+	Text string `json:"-"`
 }
 
 func (c *geminiContent) UnmarshalJSON(data []byte) error {
@@ -399,10 +533,10 @@ func (c *geminiContent) UnmarshalJSON(data []byte) error {
 		return nil
 	}
 	// Accept plain string (Gemini CLI format) -- parts stay empty
-	// since we only need structured content parts for tool calls
-	// and thoughts.
-	var ignore string
-	if json.Unmarshal(data, &ignore) == nil {
+	// and the string is kept as Text.
+	var text string
+	if json.Unmarshal(data, &text) == nil {
+		c.Text = text
 		return nil
 	}
 	// Unknown shape -- ignore silently.
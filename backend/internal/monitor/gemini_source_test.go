@@ -3,8 +3,11 @@ package monitor
 import (
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 	"time"
+
+	"github.com/agent-racer/backend/internal/session"
 )
 
 func TestGeminiSourceName(t *testing.T) {
@@ -529,6 +532,178 @@ func TestGeminiSourceParseDeltaConversion(t *testing.T) {
 	}
 }
 
+func TestGeminiSourceParseOnlyDecodesNewMessages(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "session-2026-01-30T10-00-incr.json")
+	handle := SessionHandle{SessionID: "incr", LogPath: path, Source: "gemini"}
+	src := NewGeminiSource(10 * time.Minute)
+
+	mtime := time.Now()
+	write := func(data string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		// Make sure each rewrite gets a newer mtime.
+		mtime = mtime.Add(time.Second)
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(`{"messages":[{"type":"user","content":"hi"},{"type":"gemini","content":"ok","model":"gemini-2.5-pro"}]}`)
+	update, _, err := src.Parse(handle, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if update.MessageCount != 2 || update.Model != "gemini-2.5-pro" {
+		t.Fatalf("first parse = %+v, want 2 messages on gemini-2.5-pro", update)
+	}
+
+	// The first message no longer unmarshals into geminiMessage; only the
+	// appended one should be decoded, so the parse still succeeds.
+	write(`{"messages":[{"type":7},{"type":"gemini","content":"ok","model":"gemini-2.5-pro"},{"type":"gemini","toolCalls":[{"name":"run_shell_command"}]}]}`)
+	update, _, err = src.Parse(handle, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if update.MessageCount != 1 || update.ToolCalls != 1 || update.LastTool != "run_shell_command" {
+		t.Errorf("appended parse = %+v, want 1 message with 1 run_shell_command call", update)
+	}
+	// The last message reported before is decoded again and names the
+	// model; the skipped first message does not matter.
+	if update.Model != "gemini-2.5-pro" {
+		t.Errorf("appended parse Model = %q, want gemini-2.5-pro", update.Model)
+	}
+
+	// A shorter file was replaced, not appended to: count it from scratch.
+	write(`[{"role":"user","content":{"parts":[{"text":"new"}]}}]`)
+	update, _, err = src.Parse(handle, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if update.MessageCount != 1 || update.Activity != "waiting" {
+		t.Errorf("rewritten parse = %+v, want 1 waiting message", update)
+	}
+	if got := src.seenMessages[path].messages; got != 1 {
+		t.Errorf("seenMessages = %d, want 1", got)
+	}
+}
+
+func TestGeminiSourceParseCountsLastMessageUpdatedInPlace(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "session-2026-01-30T10-00-inplace.json")
+	handle := SessionHandle{SessionID: "inplace", LogPath: path, Source: "gemini"}
+	src := NewGeminiSource(10 * time.Minute)
+
+	mtime := time.Now()
+	write := func(data string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		mtime = mtime.Add(time.Second)
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Gemini CLI writes the model message before its tokens and tool
+	// calls are known...
+	write(`{"messages":[{"type":"user","content":"hi"},{"type":"gemini","content":""}]}`)
+	update, _, err := src.Parse(handle, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if update.MessageCount != 2 || update.ToolCalls != 0 {
+		t.Fatalf("first parse = %+v, want 2 messages and no tool calls", update)
+	}
+
+	// ...then fills them in on the same message.
+	write(`{"messages":[{"type":"user","content":"hi"},{"type":"gemini","content":"","toolCalls":[{"name":"read_file"}],"tokens":{"input":900,"output":40}}]}`)
+	update, _, err = src.Parse(handle, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if update.MessageCount != 0 || update.ToolCalls != 1 || update.LastTool != "read_file" {
+		t.Errorf("in-place parse = %+v, want no new messages and 1 read_file call", update)
+	}
+	if update.TokensIn != 900 || update.TokensOut != 40 {
+		t.Errorf("in-place parse tokens = %d/%d, want 900/40", update.TokensIn, update.TokensOut)
+	}
+
+	// Another tool call on the same message counts once more; the first
+	// is not counted again.
+	write(`{"messages":[{"type":"user","content":"hi"},{"type":"gemini","content":"","toolCalls":[{"name":"read_file"},{"name":"write_file"}],"tokens":{"input":1200,"output":80}}]}`)
+	update, _, err = src.Parse(handle, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if update.MessageCount != 0 || update.ToolCalls != 1 || update.TokensIn != 1200 {
+		t.Errorf("second in-place parse = %+v, want 1 more tool call at 1200 tokens in", update)
+	}
+}
+
+func TestGeminiSourceParseKeepsSeenCountOnBadJSON(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "session-2026-01-30T10-00-partial.json")
+	handle := SessionHandle{SessionID: "partial", LogPath: path, Source: "gemini"}
+	src := NewGeminiSource(10 * time.Minute)
+
+	full := `[{"type":"user","content":"a"},{"type":"gemini","content":"b"}]`
+	if err := os.WriteFile(path, []byte(full), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := src.Parse(handle, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	// Caught halfway through a rewrite.
+	later := time.Now().Add(time.Minute)
+	if err := os.WriteFile(path, []byte(full[:20]), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	update, offset, err := src.Parse(handle, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if update.HasData() || offset != later.UnixNano() {
+		t.Errorf("partial parse = %+v at %d, want no data at %d", update, offset, later.UnixNano())
+	}
+	if got := src.seenMessages[path].messages; got != 2 {
+		t.Errorf("seenMessages = %d, want 2 kept from the last good parse", got)
+	}
+}
+
+func TestDecodeGeminiMessagesWrapperKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want int
+	}{
+		{"bare array", `[{"type":"user"},{"type":"user"}]`, 2},
+		{"messages", `{"sessionId":"x","messages":[{"type":"user"}]}`, 1},
+		{"messages preferred over earlier history", `{"history":[{},{},{}],"messages":[{"type":"user"}]}`, 1},
+		{"conversation preferred over history", `{"history":[{}],"conversation":[{},{}]}`, 2},
+		{"null messages falls back", `{"messages":null,"history":[{}]}`, 1},
+		{"no message field", `{}`, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeGeminiMessages([]byte(tt.data), 0, func(*geminiMessage) {})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("total = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestGeminiContentUnmarshalString(t *testing.T) {
 	// Content as a plain string (Gemini CLI format).
 	data := []byte(`"hello world"`)
@@ -536,10 +711,29 @@ func TestGeminiContentUnmarshalString(t *testing.T) {
 	if err := c.UnmarshalJSON(data); err != nil {
 		t.Fatal(err)
 	}
-	// Plain strings result in empty Parts (we don't need text content).
+	// Plain strings result in empty Parts and keep the string as Text.
 	if len(c.Parts) != 0 {
 		t.Errorf("expected 0 parts for string content, got %d", len(c.Parts))
 	}
+	if c.Text != "hello world" {
+		t.Errorf("Text = %q, want the string content", c.Text)
+	}
+}
+
+func TestParseGeminiSessionMessageText(t *testing.T) {
+	data := []byte(`{"messages":[
+		{"type":"user","content":"fix the build"},
+		{"role":"model","content":{"parts":[{"text":"looking"},{"functionCall":{"name":"run"}}]}},
+		{"type":"info","content":"not context"}
+	]}`)
+	update := parseGeminiSession(data)
+	want := []MessageText{
+		{Role: session.RoleUser, Text: "fix the build"},
+		{Role: session.RoleAssistant, Text: "looking"},
+	}
+	if !reflect.DeepEqual(update.MessageText, want) {
+		t.Errorf("MessageText = %q, want %q", update.MessageText, want)
+	}
 }
 
 func TestGeminiContentUnmarshalObject(t *testing.T) {
@@ -578,8 +772,8 @@ func TestGeminiSourceDiscoverPrunesStaleEntries(t *testing.T) {
 	src.lastParsed[staleLogPath] = time.Now()
 	src.lastParsed[sessionFile] = time.Now()
 
-	src.prevCounts[staleLogPath] = geminiAbsoluteCounts{Messages: 5, ToolCalls: 2}
-	src.prevCounts[sessionFile] = geminiAbsoluteCounts{Messages: 3, ToolCalls: 1}
+	src.seenMessages[staleLogPath] = geminiSeen{messages: 5}
+	src.seenMessages[sessionFile] = geminiSeen{messages: 3}
 
 	// Use discoverFromDir directly to bypass geminiBaseDir and process scanning.
 	handles := src.discoverFromDir(tmpDir)
@@ -604,12 +798,12 @@ func TestGeminiSourceDiscoverPrunesStaleEntries(t *testing.T) {
 		t.Error("active entry was incorrectly pruned from lastParsed")
 	}
 
-	// Same pruning applies to prevCounts.
-	if _, ok := src.prevCounts[staleLogPath]; ok {
-		t.Error("stale entry not pruned from prevCounts")
+	// Same pruning applies to seenMessages.
+	if _, ok := src.seenMessages[staleLogPath]; ok {
+		t.Error("stale entry not pruned from seenMessages")
 	}
-	if _, ok := src.prevCounts[sessionFile]; !ok {
-		t.Error("active entry was incorrectly pruned from prevCounts")
+	if _, ok := src.seenMessages[sessionFile]; !ok {
+		t.Error("active entry was incorrectly pruned from seenMessages")
 	}
 }
 
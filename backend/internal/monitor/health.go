@@ -25,6 +25,16 @@ func sanitizeHealthError(raw string) string {
 	return absPathRe.ReplaceAllString(raw, "<path>")
 }
 
+// healthHistorySize is how many status transitions each source keeps.
+const healthHistorySize = 32
+
+// flapPolicy silences a source's health events while its status changes
+// more than limit times within window. A zero limit never silences.
+type flapPolicy struct {
+	limit  int
+	window time.Duration
+}
+
 // sourceHealth tracks failure and recovery counts for a single source.
 // Used by the monitor to detect degraded/failed sources and emit WS alerts.
 //
@@ -33,6 +43,11 @@ func sanitizeHealthError(raw string) string {
 // A flapping source (alternating success/failure) stays in its degraded
 // state until it accumulates enough consecutive successes.
 //
+// Every status change is kept in a short history. A source whose status
+// keeps changing, such as one on a flaky network mount, is flapping: its
+// changes are recorded as suppressed instead of emitted, and the status it
+// settles on is emitted once it calms down.
+//
 // Fields are protected by mu because poll() writes them from the monitor
 // goroutine while sourceHealthSnapshot() reads them from the broadcaster.
 type sourceHealth struct {
@@ -49,6 +64,8 @@ type sourceHealth struct {
 	lastParseFail       time.Time
 	lastEmittedStatus   ws.SourceHealthStatus
 	lastEmittedAt       time.Time
+	lastStatus          ws.SourceHealthStatus       // status at the last check, emitted or not
+	history             []ws.SourceHealthTransition // oldest first
 }
 
 func newSourceHealth() *sourceHealth {
@@ -57,6 +74,7 @@ func newSourceHealth() *sourceHealth {
 		parseSuccesses:      make(map[string]int),
 		parseStickyDegraded: make(map[string]bool),
 		lastEmittedStatus:   ws.StatusHealthy,
+		lastStatus:          ws.StatusHealthy,
 	}
 }
 
@@ -122,24 +140,76 @@ func (h *sourceHealth) snapshot(threshold int) (status ws.SourceHealthStatus, di
 }
 
 // snapshotAndEmit returns a consistent copy of all health fields and whether
-// the status changed since the last emission. If the status changed, it
-// updates lastEmittedStatus. This combines snapshot + emission check in a
-// single lock acquisition.
-func (h *sourceHealth) snapshotAndEmit(threshold int) (status ws.SourceHealthStatus, discoverFailures int, parseFailures int, lastErr string, changed bool) {
+// the status should be emitted: it changed since the last emission and the
+// source isn't flapping. Status changes are recorded in the history, and
+// lastEmittedStatus is updated when changed is true. This combines
+// snapshot + emission check in a single lock acquisition.
+func (h *sourceHealth) snapshotAndEmit(threshold int, flap flapPolicy, now time.Time) (status ws.SourceHealthStatus, discoverFailures int, parseFailures int, lastErr string, changed bool) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 	status = h.statusLocked(threshold)
+	discoverFailures = h.discoverFailures
+	parseFailures = h.degradedSessionCountLocked(threshold)
+	lastErr = h.lastErrorLocked()
+
+	transitioned := status != h.lastStatus
+	if transitioned {
+		h.recordTransitionLocked(ws.SourceHealthTransition{
+			From:      h.lastStatus,
+			To:        status,
+			LastError: sanitizeHealthError(lastErr),
+			Timestamp: now,
+		})
+		h.lastStatus = status
+	}
+	if h.flappingLocked(flap, now) {
+		if transitioned {
+			h.history[len(h.history)-1].Suppressed = true
+		}
+		return status, discoverFailures, parseFailures, lastErr, false
+	}
 	changed = status != h.lastEmittedStatus
 	if changed {
 		h.lastEmittedStatus = status
-		h.lastEmittedAt = time.Now()
+		h.lastEmittedAt = now
 	}
-	discoverFailures = h.discoverFailures
-	parseFailures = h.degradedSessionCountLocked(threshold)
-	lastErr = h.lastErrorLocked()
 	return
 }
 
+// recordTransitionLocked appends t to the history, dropping the oldest
+// transition when full. Caller must hold h.mu.
+func (h *sourceHealth) recordTransitionLocked(t ws.SourceHealthTransition) {
+	if len(h.history) == healthHistorySize {
+		copy(h.history, h.history[1:])
+		h.history = h.history[:healthHistorySize-1]
+	}
+	h.history = append(h.history, t)
+}
+
+// flappingLocked reports whether more than flap.limit transitions happened
+// within flap.window of now. Caller must hold h.mu.
+func (h *sourceHealth) flappingLocked(flap flapPolicy, now time.Time) bool {
+	if flap.limit <= 0 {
+		return false
+	}
+	cutoff := now.Add(-flap.window)
+	recent := 0
+	for i := len(h.history) - 1; i >= 0 && h.history[i].Timestamp.After(cutoff); i-- {
+		recent++
+	}
+	return recent > flap.limit
+}
+
+// historySnapshot returns the current status, whether the source is
+// flapping, and a copy of its transition history.
+func (h *sourceHealth) historySnapshot(threshold int, flap flapPolicy, now time.Time) (ws.SourceHealthStatus, bool, []ws.SourceHealthTransition) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	history := make([]ws.SourceHealthTransition, len(h.history))
+	copy(history, h.history)
+	return h.statusLocked(threshold), h.flappingLocked(flap, now), history
+}
+
 // statusLocked computes health status with hysteresis:
 //   - Enter Failed when discover failures reach threshold
 //   - Exit Failed only after threshold consecutive successes
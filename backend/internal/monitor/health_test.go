@@ -3,6 +3,7 @@ package monitor
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/agent-racer/backend/internal/ws"
 )
@@ -282,3 +283,77 @@ func TestSourceHealthLastError(t *testing.T) {
 		t.Errorf("lastError = %q, want %q", h.lastError(), "parse fail")
 	}
 }
+
+func TestSourceHealthSuppressesFlaps(t *testing.T) {
+	h := newSourceHealth()
+	flap := flapPolicy{limit: 2, window: time.Minute}
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	// With a threshold of 1 every poll can flip the status.
+	steps := []struct {
+		fail        bool
+		wantChanged bool
+	}{
+		{true, true},
+		{false, true},
+		{true, false}, // third change in a minute: flapping
+		{false, false},
+		{true, false},
+	}
+	for i, step := range steps {
+		if step.fail {
+			h.recordDiscoverFailure(fmt.Errorf("stale NFS handle"))
+		} else {
+			h.recordDiscoverSuccess()
+		}
+		now = now.Add(time.Second)
+		if _, _, _, _, changed := h.snapshotAndEmit(1, flap, now); changed != step.wantChanged {
+			t.Errorf("step %d: changed = %v, want %v", i, changed, step.wantChanged)
+		}
+	}
+
+	status, flapping, history := h.historySnapshot(1, flap, now)
+	if status != ws.StatusFailed || !flapping {
+		t.Errorf("status = %s, flapping = %v; want failed and flapping", status, flapping)
+	}
+	if len(history) != 5 {
+		t.Fatalf("history has %d transitions, want 5", len(history))
+	}
+	for i, tr := range history {
+		if tr.Suppressed != (i >= 2) {
+			t.Errorf("transition %d (%s -> %s) suppressed = %v", i, tr.From, tr.To, tr.Suppressed)
+		}
+	}
+
+	// Once it settles, the status it settled on is emitted.
+	now = now.Add(2 * time.Minute)
+	status, _, _, _, changed := h.snapshotAndEmit(1, flap, now)
+	if !changed || status != ws.StatusFailed {
+		t.Errorf("after settling: status = %s, changed = %v; want failed emitted", status, changed)
+	}
+}
+
+func TestSourceHealthHistoryIsBounded(t *testing.T) {
+	h := newSourceHealth()
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < healthHistorySize+8; i++ {
+		if i%2 == 0 {
+			h.recordDiscoverFailure(fmt.Errorf("fail"))
+		} else {
+			h.recordDiscoverSuccess()
+		}
+		now = now.Add(time.Minute)
+		h.snapshotAndEmit(1, flapPolicy{}, now)
+	}
+
+	_, flapping, history := h.historySnapshot(1, flapPolicy{}, now)
+	if flapping {
+		t.Error("a zero flap limit should never report flapping")
+	}
+	if len(history) != healthHistorySize {
+		t.Fatalf("history has %d transitions, want %d", len(history), healthHistorySize)
+	}
+	if !history[len(history)-1].Timestamp.Equal(now) {
+		t.Errorf("newest transition at %v, want %v", history[len(history)-1].Timestamp, now)
+	}
+}
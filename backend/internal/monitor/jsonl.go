@@ -1,15 +1,19 @@
 package monitor
 
 import (
+	"bytes"
 	"encoding/json"
 	"log/slog"
 	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/agent-racer/backend/internal/jsonl"
+	"github.com/agent-racer/backend/internal/session"
 )
 
 // maxDecodePathCandidates bounds ambiguous decode search so a long
@@ -35,8 +39,30 @@ type SubagentParseResult struct {
 	FirstTime       time.Time
 	LastTime        time.Time
 	Completed       bool
+	TaskCalls       []string // tool_use IDs of the subagents this one spawned
 }
 
+// subagentTools are the tools that spawn a subagent. A subagent's progress
+// entries name the call that spawned it as their parentToolUseID, whether
+// the session made it or another subagent did.
+var subagentTools = map[string]bool{
+	"Task":  true,
+	"Agent": true,
+}
+
+// approvalTools are the tools with which Claude asks the user to approve
+// something before it carries on: a plan, or an answer to a question. A
+// session whose last entry calls one is blocked on the user, not thinking.
+var approvalTools = map[string]bool{
+	"ExitPlanMode":    true,
+	"AskUserQuestion": true,
+}
+
+// syntheticModel is the model Claude Code records on messages it writes
+// itself, such as the placeholder reply after an interrupted turn. It says
+// nothing about the model the session is running.
+const syntheticModel = "<synthetic>"
+
 // maxLastTextLen caps the text stored in LastAssistantText to avoid
 // bloating session state with large message bodies.
 const maxLastTextLen = 500
@@ -49,12 +75,22 @@ type ParseResult struct {
 	MessageCount      int
 	ToolCalls         int
 	LastTool          string
+	MCPToolCalls      map[string]int // MCP server name -> tool calls in this chunk
+	FilesTouched      map[string]int // file path -> tool calls reading or editing it in this chunk
 	LastActivity      string
 	LastTime          time.Time
 	WorkingDir        string
 	Subagents         map[string]*SubagentParseResult // keyed by toolUseID
 	CompactionCount   int                             // number of compact_boundary events in this chunk
 	LastAssistantText string                          // last text content block from an assistant message
+	LastCommand       string                          // most recent slash command invoked (e.g. "/compact")
+	SlashCommands     map[string]int                  // slash command -> invocations in this chunk
+	HookEvents        int                             // number of hook-related system entries in this chunk
+	FirstPrompt       string                          // first human-typed user message in this chunk
+	MessageText       []MessageText                   // text, tool inputs and tool results of messages in this chunk
+	Cache             session.CacheUsage              // prompt-cache usage summed over the API calls in this chunk
+
+	lastUsageID string // message ID whose usage is already in Cache
 }
 
 // ParseSessionJSONL incrementally parses a Claude JSONL session file from
@@ -100,14 +136,31 @@ func ParseSessionJSONL(path string, offset int64, knownSlug string, knownParents
 			result.MessageCount++
 			result.LastActivity = "waiting"
 			checkSubagentCompletion(entry.Message, result, knownParents)
+			role := session.RoleUser
+			if entry.IsMeta || entry.IsCompactSummary {
+				role = session.RoleSystem
+			}
+			result.MessageText = appendUserText(result.MessageText, entry.Message, role)
+			if cmd := slashCommandFromMessage(entry.Message); cmd != "" {
+				result.LastCommand = cmd
+				if result.SlashCommands == nil {
+					result.SlashCommands = make(map[string]int)
+				}
+				result.SlashCommands[cmd]++
+			} else if result.FirstPrompt == "" && !entry.IsMeta {
+				result.FirstPrompt = promptFromMessage(entry.Message)
+			}
 
 		case "progress":
-			parseProgressEntry(line, result)
+			parseProgressEntry(line, result, knownParents)
 
 		case "system":
 			if entry.Subtype == "compact_boundary" {
 				result.CompactionCount++
 			}
+			if strings.Contains(entry.Subtype, "hook") {
+				result.HookEvents++
+			}
 		}
 
 		return true
@@ -129,12 +182,20 @@ func parseAssistantMessage(raw json.RawMessage, result *ParseResult) {
 		return
 	}
 
-	if msg.Model != "" {
+	if msg.Model != "" && msg.Model != syntheticModel {
 		result.Model = msg.Model
 	}
 
 	if msg.Usage != nil {
 		result.LatestUsage = msg.Usage
+		// Claude Code writes one entry per content block, each repeating
+		// the response's usage; count each response once.
+		if msg.ID == "" || msg.ID != result.lastUsageID {
+			result.lastUsageID = msg.ID
+			result.Cache.ReadTokens += msg.Usage.CacheReadInputTokens
+			result.Cache.WriteTokens += msg.Usage.CacheCreationInputTokens
+			result.Cache.UncachedTokens += msg.Usage.InputTokens
+		}
 	}
 
 	// Parse content blocks for tool use and text content.
@@ -146,11 +207,27 @@ func parseAssistantMessage(raw json.RawMessage, result *ParseResult) {
 	for _, block := range blocks {
 		switch block.Type {
 		case "tool_use":
+			if len(block.Input) > 0 {
+				result.MessageText = append(result.MessageText, MessageText{Role: session.RoleAssistant, Text: string(block.Input)})
+			}
+			if path := toolFilePath(block.Input); path != "" {
+				result.FilesTouched = addCount(result.FilesTouched, path)
+			}
 			result.ToolCalls++
 			result.LastTool = block.Name
 			result.LastActivity = "tool_use"
+			if approvalTools[block.Name] {
+				result.LastActivity = "needs_approval"
+			}
+			if server := session.MCPServerFromTool(block.Name); server != "" {
+				if result.MCPToolCalls == nil {
+					result.MCPToolCalls = make(map[string]int)
+				}
+				result.MCPToolCalls[server]++
+			}
 		case "text":
 			if block.Text != "" {
+				result.MessageText = append(result.MessageText, MessageText{Role: session.RoleAssistant, Text: block.Text})
 				t := block.Text
 				if len(t) > maxLastTextLen {
 					t = t[:maxLastTextLen]
@@ -161,9 +238,172 @@ func parseAssistantMessage(raw json.RawMessage, result *ParseResult) {
 	}
 }
 
+// toolFilePath returns the file a tool_use input names, as Read, Edit,
+// Write, MultiEdit and NotebookEdit do, or "" if it names none.
+func toolFilePath(input json.RawMessage) string {
+	if !bytes.Contains(input, []byte(`"file_path"`)) && !bytes.Contains(input, []byte(`"notebook_path"`)) {
+		return ""
+	}
+	var args struct {
+		FilePath     string `json:"file_path"`
+		NotebookPath string `json:"notebook_path"`
+	}
+	if err := json.Unmarshal(input, &args); err != nil {
+		return ""
+	}
+	if args.FilePath != "" {
+		return args.FilePath
+	}
+	return args.NotebookPath
+}
+
+// commandNamePattern matches the marker Claude Code writes into the user
+// message when a slash command is invoked, e.g.
+// "<command-name>/compact</command-name>".
+var commandNamePattern = regexp.MustCompile(`<command-name>\s*(/?[^<\s]+)\s*</command-name>`)
+
+// maxCommandNameLen bounds the stored command name so a malformed entry
+// cannot bloat session state.
+const maxCommandNameLen = 64
+
+// slashCommandFromMessage returns the slash command recorded in a user
+// message (always with a leading "/"), or "" if the message is not a
+// command invocation. The content may be a plain string or an array of
+// text blocks.
+func slashCommandFromMessage(raw json.RawMessage) string {
+	if raw == nil || !bytes.Contains(raw, []byte("command-name")) {
+		return ""
+	}
+
+	var msg jsonl.MessageContent
+	if err := json.Unmarshal(raw, &msg); err != nil {
+		return ""
+	}
+
+	var text string
+	if err := json.Unmarshal(msg.Content, &text); err != nil {
+		var blocks []jsonl.ContentBlock
+		if err := json.Unmarshal(msg.Content, &blocks); err != nil {
+			return ""
+		}
+		for _, block := range blocks {
+			if block.Type == "text" && strings.Contains(block.Text, "<command-name>") {
+				text = block.Text
+				break
+			}
+		}
+	}
+
+	m := commandNamePattern.FindStringSubmatch(text)
+	if m == nil {
+		return ""
+	}
+	cmd := m[1]
+	if !strings.HasPrefix(cmd, "/") {
+		cmd = "/" + cmd
+	}
+	if len(cmd) > maxCommandNameLen {
+		// Back off to the start of a rune so none is cut in half.
+		end := maxCommandNameLen
+		for end > 0 && !utf8.RuneStart(cmd[end]) {
+			end--
+		}
+		cmd = cmd[:end]
+	}
+	return cmd
+}
+
+// promptFromMessage returns the text a human typed in a user message, or ""
+// when the message carries only tool results or client-injected markup
+// (command output, caveats, reminders). At most maxPromptScan bytes are
+// returned.
+func promptFromMessage(raw json.RawMessage) string {
+	if raw == nil {
+		return ""
+	}
+	var msg jsonl.MessageContent
+	if err := json.Unmarshal(raw, &msg); err != nil {
+		return ""
+	}
+
+	var text string
+	if err := json.Unmarshal(msg.Content, &text); err != nil {
+		var blocks []jsonl.ContentBlock
+		if err := json.Unmarshal(msg.Content, &blocks); err != nil {
+			return ""
+		}
+		for _, block := range blocks {
+			if block.Type == "text" && strings.TrimSpace(block.Text) != "" {
+				text = block.Text
+				break
+			}
+		}
+	}
+
+	text = strings.TrimSpace(text)
+	if text == "" || strings.HasPrefix(text, "<") {
+		return ""
+	}
+	if len(text) > maxPromptScan {
+		// Back off to the start of a rune so none is cut in half.
+		end := maxPromptScan
+		for end > 0 && !utf8.RuneStart(text[end]) {
+			end--
+		}
+		text = text[:end]
+	}
+	return text
+}
+
+// appendUserText appends the text a user message puts into the context:
+// typed text, attributed to role, and tool results, which may be a string
+// or text blocks.
+func appendUserText(texts []MessageText, raw json.RawMessage, role string) []MessageText {
+	if raw == nil {
+		return texts
+	}
+	var msg jsonl.MessageContent
+	if err := json.Unmarshal(raw, &msg); err != nil {
+		return texts
+	}
+	return appendContentText(texts, msg.Content, role)
+}
+
+// appendContentText appends the text in content, which is either a plain
+// string or an array of blocks. tool_result blocks nest another content,
+// whose text is attributed to tool results.
+func appendContentText(texts []MessageText, content json.RawMessage, role string) []MessageText {
+	var text string
+	if err := json.Unmarshal(content, &text); err == nil {
+		if text != "" {
+			texts = append(texts, MessageText{Role: role, Text: text})
+		}
+		return texts
+	}
+	var blocks []jsonl.ContentBlock
+	if err := json.Unmarshal(content, &blocks); err != nil {
+		return texts
+	}
+	for _, block := range blocks {
+		switch block.Type {
+		case "text":
+			if block.Text != "" {
+				texts = append(texts, MessageText{Role: role, Text: block.Text})
+			}
+		case "tool_result":
+			if len(block.Content) > 0 {
+				texts = appendContentText(texts, block.Content, session.RoleToolResult)
+			}
+		}
+	}
+	return texts
+}
+
 // parseProgressEntry handles a type:"progress" JSONL line, accumulating
-// subagent state into result.Subagents keyed by toolUseID.
-func parseProgressEntry(line []byte, result *ParseResult) {
+// subagent state into result.Subagents keyed by toolUseID. A subagent's
+// tool results can complete the subagents it spawned, so they are checked
+// against knownParents as the session's are.
+func parseProgressEntry(line []byte, result *ParseResult, knownParents map[string]string) {
 	var entry jsonl.ProgressEntry
 	if err := json.Unmarshal(line, &entry); err != nil || entry.ToolUseID == "" {
 		return
@@ -238,6 +478,7 @@ func parseProgressEntry(line []byte, result *ParseResult) {
 	case "user":
 		sub.MessageCount++
 		sub.LastActivity = "waiting"
+		checkSubagentCompletion(pd.Message.Message, result, knownParents)
 	}
 }
 
@@ -249,7 +490,7 @@ func parseSubagentAssistantMessage(raw json.RawMessage, sub *SubagentParseResult
 		return
 	}
 
-	if msg.Model != "" {
+	if msg.Model != "" && msg.Model != syntheticModel {
 		sub.Model = msg.Model
 	}
 	if msg.Usage != nil {
@@ -266,6 +507,9 @@ func parseSubagentAssistantMessage(raw json.RawMessage, sub *SubagentParseResult
 			sub.ToolCalls++
 			sub.LastTool = block.Name
 			sub.LastActivity = "tool_use"
+			if subagentTools[block.Name] && block.ID != "" {
+				sub.TaskCalls = append(sub.TaskCalls, block.ID)
+			}
 		}
 	}
 }
@@ -362,44 +606,28 @@ func SessionIDFromPath(path string) string {
 // FindRecentSessionFiles finds all active session files across all projects
 // modified within the given duration
 func FindRecentSessionFiles(within time.Duration) ([]string, error) {
+	return findRecentSessionFiles(newDirCache(), within)
+}
+
+// findRecentSessionFiles is FindRecentSessionFiles through a listing cache
+// kept between calls.
+func findRecentSessionFiles(cache *dirCache, within time.Duration) ([]string, error) {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		return nil, err
 	}
 
 	projectsDir := filepath.Join(homeDir, ".claude", "projects")
-	projectEntries, err := os.ReadDir(projectsDir)
+	isJSONL := func(name string) bool { return strings.HasSuffix(name, ".jsonl") }
+	files, err := cache.recentFiles(projectsDir, 1, isJSONL, time.Now().Add(-within))
 	if err != nil {
 		return nil, err
 	}
-
-	cutoff := time.Now().Add(-within)
-	var results []string
-
-	for _, projEntry := range projectEntries {
-		if !projEntry.IsDir() {
-			continue
-		}
-		projPath := filepath.Join(projectsDir, projEntry.Name())
-		files, err := os.ReadDir(projPath)
-		if err != nil {
-			continue
-		}
-		for _, f := range files {
-			if f.IsDir() || !strings.HasSuffix(f.Name(), ".jsonl") {
-				continue
-			}
-			info, err := f.Info()
-			if err != nil {
-				continue
-			}
-			if info.ModTime().After(cutoff) {
-				results = append(results, filepath.Join(projPath, f.Name()))
-			}
-		}
+	paths := make([]string, len(files))
+	for i := 0; i < len(files); i++ {
+		paths[i] = files[i].path
 	}
-
-	return results, nil
+	return paths, nil
 }
 
 // DecodeProjectPath reverses the encoding to get the original working dir.
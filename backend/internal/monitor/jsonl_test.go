@@ -5,8 +5,12 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
+	"unicode/utf8"
+
+	"github.com/agent-racer/backend/internal/session"
 )
 
 func TestEncodeProjectPath(t *testing.T) {
@@ -395,6 +399,29 @@ func TestTokenUsageTotalContext(t *testing.T) {
 	}
 }
 
+func TestParseSessionJSONLSumsCacheUsagePerResponse(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "test-cache.jsonl")
+
+	// msg_1 is split over two entries that repeat its usage.
+	content := `{"type":"assistant","message":{"id":"msg_1","model":"claude-opus-4-6","role":"assistant","content":[{"type":"text","text":"reading"}],"usage":{"input_tokens":10,"cache_creation_input_tokens":500,"cache_read_input_tokens":2000,"output_tokens":5}},"sessionId":"test-cache","timestamp":"2026-01-30T10:00:01.000Z"}
+{"type":"assistant","message":{"id":"msg_1","model":"claude-opus-4-6","role":"assistant","content":[{"type":"tool_use","name":"Read","id":"toolu_1","input":{}}],"usage":{"input_tokens":10,"cache_creation_input_tokens":500,"cache_read_input_tokens":2000,"output_tokens":5}},"sessionId":"test-cache","timestamp":"2026-01-30T10:00:01.000Z"}
+{"type":"assistant","message":{"id":"msg_2","model":"claude-opus-4-6","role":"assistant","content":[{"type":"text","text":"done"}],"usage":{"input_tokens":20,"cache_creation_input_tokens":100,"cache_read_input_tokens":2500,"output_tokens":5}},"sessionId":"test-cache","timestamp":"2026-01-30T10:00:02.000Z"}
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, _, err := ParseSessionJSONL(path, 0, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := session.CacheUsage{ReadTokens: 4500, WriteTokens: 600, UncachedTokens: 30}
+	if result.Cache != want {
+		t.Errorf("Cache = %+v, want %+v", result.Cache, want)
+	}
+}
+
 func TestSessionIDFromPath(t *testing.T) {
 	path := "/home/user/.claude/projects/-home-user-proj/abc-123-def.jsonl"
 	id := SessionIDFromPath(path)
@@ -915,3 +942,105 @@ func TestSubagentActivityTransitions(t *testing.T) {
 		t.Errorf("ToolCalls = %d, want 1", sub.ToolCalls)
 	}
 }
+
+func TestParseSessionJSONLCountsMCPToolCalls(t *testing.T) {
+	path := writeJSONLLines(t,
+		`{"type":"assistant","message":{"model":"claude-opus-4-6","role":"assistant","content":[{"type":"tool_use","id":"t1","name":"mcp__github__create_pr","input":{}},{"type":"tool_use","id":"t2","name":"Read","input":{}}]},"sessionId":"test-mcp","timestamp":"2026-01-30T10:00:00.000Z"}`,
+		`{"type":"assistant","message":{"model":"claude-opus-4-6","role":"assistant","content":[{"type":"tool_use","id":"t3","name":"mcp__github__list_issues","input":{}},{"type":"tool_use","id":"t4","name":"mcp__linear__get_issue","input":{}}]},"sessionId":"test-mcp","timestamp":"2026-01-30T10:00:01.000Z"}`,
+	)
+
+	result := parseJSONL(t, path)
+
+	if result.ToolCalls != 4 {
+		t.Errorf("ToolCalls = %d, want 4", result.ToolCalls)
+	}
+	if result.MCPToolCalls["github"] != 2 {
+		t.Errorf("MCPToolCalls[github] = %d, want 2", result.MCPToolCalls["github"])
+	}
+	if result.MCPToolCalls["linear"] != 1 {
+		t.Errorf("MCPToolCalls[linear] = %d, want 1", result.MCPToolCalls["linear"])
+	}
+	if len(result.MCPToolCalls) != 2 {
+		t.Errorf("MCPToolCalls = %v, want only github and linear", result.MCPToolCalls)
+	}
+}
+
+func TestParseSessionJSONLCountsFilesTouched(t *testing.T) {
+	path := writeJSONLLines(t,
+		`{"type":"assistant","message":{"model":"claude-opus-4-6","role":"assistant","content":[{"type":"tool_use","id":"t1","name":"Read","input":{"file_path":"/repo/services/api/main.go"}},{"type":"tool_use","id":"t2","name":"Edit","input":{"file_path":"/repo/services/api/main.go","old_string":"a","new_string":"b"}}]},"sessionId":"test-files","timestamp":"2026-01-30T10:00:00.000Z"}`,
+		`{"type":"assistant","message":{"model":"claude-opus-4-6","role":"assistant","content":[{"type":"tool_use","id":"t3","name":"NotebookEdit","input":{"notebook_path":"/repo/notebooks/eval.ipynb"}},{"type":"tool_use","id":"t4","name":"Bash","input":{"command":"go test ./..."}}]},"sessionId":"test-files","timestamp":"2026-01-30T10:00:01.000Z"}`,
+	)
+
+	result := parseJSONL(t, path)
+
+	want := map[string]int{"/repo/services/api/main.go": 2, "/repo/notebooks/eval.ipynb": 1}
+	if len(result.FilesTouched) != len(want) {
+		t.Fatalf("FilesTouched = %v, want %v", result.FilesTouched, want)
+	}
+	for file, n := range want {
+		if result.FilesTouched[file] != n {
+			t.Errorf("FilesTouched[%s] = %d, want %d", file, result.FilesTouched[file], n)
+		}
+	}
+}
+
+func TestParseSessionJSONLSlashCommandsAndHooks(t *testing.T) {
+	path := writeJSONLLines(t,
+		`{"type":"user","message":{"role":"user","content":"<command-message>compact</command-message>\n<command-name>/compact</command-name>\n<command-args></command-args>"},"sessionId":"test-cmd","timestamp":"2026-01-30T10:00:00.000Z"}`,
+		`{"type":"system","subtype":"compact_boundary","sessionId":"test-cmd","timestamp":"2026-01-30T10:00:01.000Z"}`,
+		`{"type":"user","message":{"role":"user","content":[{"type":"text","text":"<command-name>review</command-name><command-args>42</command-args>"}]},"sessionId":"test-cmd","timestamp":"2026-01-30T10:00:02.000Z"}`,
+		`{"type":"user","message":{"role":"user","content":"please fix the /compact handling"},"sessionId":"test-cmd","timestamp":"2026-01-30T10:00:03.000Z"}`,
+		`{"type":"system","subtype":"stop_hook_summary","sessionId":"test-cmd","timestamp":"2026-01-30T10:00:04.000Z"}`,
+		`{"type":"system","subtype":"turn_duration","sessionId":"test-cmd","timestamp":"2026-01-30T10:00:05.000Z"}`,
+	)
+
+	result := parseJSONL(t, path)
+
+	if result.LastCommand != "/review" {
+		t.Errorf("LastCommand = %q, want /review", result.LastCommand)
+	}
+	if result.SlashCommands["/compact"] != 1 || result.SlashCommands["/review"] != 1 || len(result.SlashCommands) != 2 {
+		t.Errorf("SlashCommands = %v, want /compact=1 /review=1", result.SlashCommands)
+	}
+	if result.HookEvents != 1 {
+		t.Errorf("HookEvents = %d, want 1", result.HookEvents)
+	}
+}
+
+func TestSlashCommandFromMessageKeepsRunesWhole(t *testing.T) {
+	// "é" is two bytes, so maxCommandNameLen falls in the middle of one.
+	name := "/" + strings.Repeat("é", maxCommandNameLen)
+	raw := []byte(`{"role":"user","content":"<command-name>` + name + `</command-name>"}`)
+	got := slashCommandFromMessage(raw)
+	if !utf8.ValidString(got) {
+		t.Errorf("command cut mid-rune: ends with % x", got[len(got)-3:])
+	}
+	if len(got) != maxCommandNameLen-1 || !strings.HasPrefix(name, got) {
+		t.Errorf("command = %q (%d bytes), want a prefix of %d bytes", got, len(got), maxCommandNameLen-1)
+	}
+}
+
+func TestParseSessionJSONLMessageText(t *testing.T) {
+	path := writeJSONLLines(t,
+		`{"type":"user","message":{"role":"user","content":"list the files"},"sessionId":"test-text","timestamp":"2026-01-30T10:00:00.000Z"}`,
+		`{"type":"assistant","message":{"role":"assistant","model":"claude-opus-4-6","content":[{"type":"thinking","thinking":"hmm"},{"type":"text","text":"Sure."},{"type":"tool_use","id":"t1","name":"Bash","input":{"command":"ls"}}]},"sessionId":"test-text","timestamp":"2026-01-30T10:00:01.000Z"}`,
+		`{"type":"user","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":"a.go\nb.go"}]},"sessionId":"test-text","timestamp":"2026-01-30T10:00:02.000Z"}`,
+		`{"type":"user","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t2","content":[{"type":"text","text":"ok"}]}]},"sessionId":"test-text","timestamp":"2026-01-30T10:00:03.000Z"}`,
+		`{"type":"system","subtype":"turn_duration","content":"ignored","sessionId":"test-text","timestamp":"2026-01-30T10:00:04.000Z"}`,
+		`{"type":"user","isCompactSummary":true,"message":{"role":"user","content":"Summary of the conversation"},"sessionId":"test-text","timestamp":"2026-01-30T10:00:05.000Z"}`,
+	)
+
+	result := parseJSONL(t, path)
+
+	want := []MessageText{
+		{Role: session.RoleUser, Text: "list the files"},
+		{Role: session.RoleAssistant, Text: "Sure."},
+		{Role: session.RoleAssistant, Text: `{"command":"ls"}`},
+		{Role: session.RoleToolResult, Text: "a.go\nb.go"},
+		{Role: session.RoleToolResult, Text: "ok"},
+		{Role: session.RoleSystem, Text: "Summary of the conversation"},
+	}
+	if !reflect.DeepEqual(result.MessageText, want) {
+		t.Errorf("MessageText = %q, want %q", result.MessageText, want)
+	}
+}
@@ -0,0 +1,340 @@
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// kubectlTimeout bounds a refresh: the pod listing and every log fetch.
+const kubectlTimeout = 30 * time.Second
+
+// KubernetesWorkingDirAnnotation is the pod annotation naming the project a
+// batch agent works on, for pods whose logs don't carry a cwd.
+const KubernetesWorkingDirAnnotation = "agent-racer/working-dir"
+
+// KubernetesSource implements Source for agent runs in Kubernetes pods,
+// such as Jobs that run `claude -p --output-format stream-json` over a
+// batch of tasks. It lists pods matching a label selector with kubectl,
+// copies each pod's new log lines into a local mirror, and parses the
+// mirror with the Claude parser. A pod that exits ends its racer: a
+// Succeeded pod completes and a Failed one errors. kubectl runs in Run,
+// off the poll loop; Discover returns what the last refresh mirrored.
+//
+// Commands go through kubectl so the user's kubeconfig, contexts and
+// credential plugins apply.
+type KubernetesSource struct {
+	context        string
+	namespace      string
+	selector       string
+	container      string
+	interval       time.Duration
+	discoverWindow time.Duration
+	mirrorDir      string
+
+	// run executes kubectl with args and returns its standard output.
+	// Replaced in tests.
+	run func(ctx context.Context, args ...string) ([]byte, error)
+	now func() time.Time
+
+	// mu guards what Discover and Parse read. The refresh goroutine is
+	// the only writer.
+	mu      sync.Mutex
+	handles []SessionHandle
+	err     error // from the last refresh
+	pods    map[string]*kubePodState
+}
+
+// kubePodState is what the source remembers about a pod between polls.
+type kubePodState struct {
+	// lastLog is the timestamp of the newest log line in the mirror. Only
+	// the refresh goroutine uses it.
+	lastLog time.Time
+	// ended is "complete" or "errored" once the pod has exited.
+	ended      string
+	finishedAt time.Time
+	reported   bool
+}
+
+// NewKubernetesSource returns a source for pods matching selector in
+// namespace (all namespaces when empty) of kubeContext (the current
+// context when empty). container picks the container whose logs are read
+// in multi-container pods. Logs are mirrored under mirrorDir.
+func NewKubernetesSource(kubeContext, namespace, selector, container string, interval, discoverWindow time.Duration, mirrorDir string) *KubernetesSource {
+	return &KubernetesSource{
+		context:        kubeContext,
+		namespace:      namespace,
+		selector:       selector,
+		container:      container,
+		interval:       interval,
+		discoverWindow: discoverWindow,
+		mirrorDir:      mirrorDir,
+		run:            runKubectl,
+		now:            time.Now,
+		pods:           make(map[string]*kubePodState),
+	}
+}
+
+func (k *KubernetesSource) Name() string { return "kubernetes" }
+
+// Run refreshes the pod list and log mirrors every interval until ctx is
+// done.
+func (k *KubernetesSource) Run(ctx context.Context) {
+	runRefresher(ctx, k.interval, k.refresh)
+}
+
+// Discover returns a handle for each running or recently exited pod the
+// last refresh found, or the error it failed with.
+func (k *KubernetesSource) Discover() ([]SessionHandle, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.handles, k.err
+}
+
+// refresh lists the pods, mirrors their new log lines and records the
+// result for Discover and Parse.
+func (k *KubernetesSource) refresh(ctx context.Context) {
+	now := k.now()
+	ctx, cancel := context.WithTimeout(ctx, kubectlTimeout)
+	defer cancel()
+
+	pods, err := k.listPods(ctx)
+	if err != nil {
+		k.mu.Lock()
+		k.handles, k.err = nil, err
+		k.mu.Unlock()
+		return
+	}
+
+	active := make(map[string]bool, len(pods))
+	seen := make(map[string]bool, len(pods))
+	handles := make([]SessionHandle, 0, len(pods))
+	for _, pod := range pods {
+		ended, finishedAt := pod.outcome()
+		if pod.Status.Phase != "Running" && ended == "" {
+			// Pending pods have no logs yet.
+			continue
+		}
+		if ended != "" && now.Sub(finishedAt) > k.discoverWindow {
+			continue
+		}
+
+		id := pod.Metadata.Namespace + "/" + pod.Metadata.Name
+		k.mu.Lock()
+		state := k.pods[id]
+		if state == nil {
+			state = &kubePodState{}
+			k.pods[id] = state
+		}
+		wasEnded := state.ended != ""
+		k.mu.Unlock()
+		local := filepath.Join(k.mirrorDir, pod.Metadata.Namespace, pod.Metadata.Name+".jsonl")
+		// An exited pod's log was read in full when it was first seen
+		// exited; it won't grow.
+		if !wasEnded {
+			if err := k.sync(ctx, pod, state, local); err != nil {
+				slog.Warn("mirror failed", "source", "kubernetes", "pod", id, "error", err)
+				continue
+			}
+		}
+		seen[id] = true
+		active[local] = true
+		k.mu.Lock()
+		state.ended, state.finishedAt = ended, finishedAt
+		k.mu.Unlock()
+
+		startedAt := pod.Metadata.CreationTimestamp
+		if pod.Status.StartTime != nil {
+			startedAt = *pod.Status.StartTime
+		}
+		handles = append(handles, SessionHandle{
+			SessionID:  id,
+			LogPath:    local,
+			WorkingDir: pod.Metadata.Annotations[KubernetesWorkingDirAnnotation],
+			Source:     "kubernetes",
+			StartedAt:  startedAt,
+		})
+	}
+	k.mu.Lock()
+	for id := range k.pods {
+		if !seen[id] {
+			delete(k.pods, id)
+		}
+	}
+	k.handles, k.err = handles, nil
+	k.mu.Unlock()
+	pruneMirror(k.mirrorDir, active)
+}
+
+// Parse reads the pod's log mirror as a Claude transcript. Once the pod
+// has exited and its last lines are parsed, the update carries Ended.
+func (k *KubernetesSource) Parse(handle SessionHandle, offset int64) (SourceUpdate, int64, error) {
+	update, newOffset, err := parseClaudeUpdate(handle, offset)
+	if err != nil {
+		return update, newOffset, err
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if state := k.pods[handle.SessionID]; state != nil && state.ended != "" && !state.reported {
+		state.reported = true
+		update.Ended = state.ended
+		if state.finishedAt.After(update.LastTime) {
+			update.LastTime = state.finishedAt
+		}
+	}
+	return update, newOffset, nil
+}
+
+// kubePod is the part of a Pod object the source reads.
+type kubePod struct {
+	Metadata struct {
+		Name              string            `json:"name"`
+		Namespace         string            `json:"namespace"`
+		Annotations       map[string]string `json:"annotations"`
+		CreationTimestamp time.Time         `json:"creationTimestamp"`
+	} `json:"metadata"`
+	Status struct {
+		Phase             string     `json:"phase"`
+		StartTime         *time.Time `json:"startTime"`
+		ContainerStatuses []struct {
+			State struct {
+				Terminated *struct {
+					FinishedAt time.Time `json:"finishedAt"`
+				} `json:"terminated"`
+			} `json:"state"`
+		} `json:"containerStatuses"`
+	} `json:"status"`
+}
+
+// outcome maps an exited pod's phase to a terminal activity and reports
+// when its last container stopped. Running pods return "".
+func (p kubePod) outcome() (string, time.Time) {
+	var ended string
+	switch p.Status.Phase {
+	case "Succeeded":
+		ended = "complete"
+	case "Failed":
+		ended = "errored"
+	default:
+		return "", time.Time{}
+	}
+	var finishedAt time.Time
+	for _, cs := range p.Status.ContainerStatuses {
+		if t := cs.State.Terminated; t != nil && t.FinishedAt.After(finishedAt) {
+			finishedAt = t.FinishedAt
+		}
+	}
+	if finishedAt.IsZero() {
+		finishedAt = p.Metadata.CreationTimestamp
+	}
+	return ended, finishedAt
+}
+
+// listPods runs kubectl get pods for the configured selector.
+func (k *KubernetesSource) listPods(ctx context.Context) ([]kubePod, error) {
+	args := append(k.baseArgs(), "get", "pods", "--selector="+k.selector, "--output=json")
+	if k.namespace != "" {
+		args = append(args, "--namespace="+k.namespace)
+	} else {
+		args = append(args, "--all-namespaces")
+	}
+	out, err := k.run(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+	var list struct {
+		Items []kubePod `json:"items"`
+	}
+	if err := json.Unmarshal(out, &list); err != nil {
+		return nil, fmt.Errorf("decode pod list: %w", err)
+	}
+	return list.Items, nil
+}
+
+// sync appends the pod's log lines newer than state.lastLog to the mirror
+// at local. kubectl --timestamps prefixes each line with an RFC 3339 time,
+// which lets --since-time fetch only recent output; that filter is
+// second-granular, so lines already mirrored are dropped by timestamp. A
+// pod the source hasn't seen this run is fetched from the start.
+func (k *KubernetesSource) sync(ctx context.Context, pod kubePod, state *kubePodState, local string) error {
+	args := append(k.baseArgs(), "logs", "--namespace="+pod.Metadata.Namespace, pod.Metadata.Name, "--timestamps")
+	if k.container != "" {
+		args = append(args, "--container="+k.container)
+	}
+	fresh := state.lastLog.IsZero()
+	if !fresh {
+		args = append(args, "--since-time="+state.lastLog.UTC().Format(time.RFC3339))
+	}
+	out, err := k.run(ctx, args...)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	last := state.lastLog
+	for _, raw := range bytes.Split(out, []byte("\n")) {
+		stamp, line, ok := bytes.Cut(raw, []byte(" "))
+		if !ok {
+			continue
+		}
+		ts, err := time.Parse(time.RFC3339Nano, string(stamp))
+		if err != nil || !ts.After(last) {
+			continue
+		}
+		last = ts
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+
+	if err := os.MkdirAll(filepath.Dir(local), 0o700); err != nil {
+		return err
+	}
+	flags := os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	if fresh {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	file, err := os.OpenFile(local, flags, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(buf.Bytes()); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	state.lastLog = last
+	return nil
+}
+
+// baseArgs returns the flags shared by every kubectl call.
+func (k *KubernetesSource) baseArgs() []string {
+	if k.context == "" {
+		return nil
+	}
+	return []string{"--context=" + k.context}
+}
+
+// runKubectl runs kubectl with args.
+func runKubectl(ctx context.Context, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "kubectl", args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("kubectl: %w: %s", err, msg)
+		}
+		return nil, fmt.Errorf("kubectl: %w", err)
+	}
+	return out, nil
+}
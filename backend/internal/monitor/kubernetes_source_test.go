@@ -0,0 +1,195 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agent-racer/backend/internal/session"
+)
+
+// fakeKubectl answers kubectl get pods and kubectl logs from fields the
+// test sets, and records each call.
+type fakeKubectl struct {
+	pods  string
+	logs  map[string]string // pod name -> kubectl logs --timestamps output
+	calls [][]string
+}
+
+func (f *fakeKubectl) run(ctx context.Context, args ...string) ([]byte, error) {
+	f.calls = append(f.calls, args)
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "get":
+			return []byte(f.pods), nil
+		case "logs":
+			return []byte(f.logs[args[i+2]]), nil
+		}
+	}
+	return nil, fmt.Errorf("unexpected kubectl %v", args)
+}
+
+func podJSON(name, phase, finishedAt string) string {
+	status := fmt.Sprintf(`"phase":%q,"startTime":"2026-03-01T12:00:00Z"`, phase)
+	if finishedAt != "" {
+		status += fmt.Sprintf(`,"containerStatuses":[{"state":{"terminated":{"finishedAt":%q}}}]`, finishedAt)
+	}
+	return fmt.Sprintf(`{"metadata":{"name":%q,"namespace":"batch","annotations":{"agent-racer/working-dir":"/repo/app"},"creationTimestamp":"2026-03-01T11:59:00Z"},"status":{%s}}`, name, status)
+}
+
+func newTestKubernetesSource(t *testing.T, kc *fakeKubectl, now *time.Time) *KubernetesSource {
+	t.Helper()
+	src := NewKubernetesSource("prod", "batch", "agent-racer/track=true", "", 10*time.Second, 10*time.Minute, t.TempDir())
+	src.run = kc.run
+	src.now = func() time.Time { return *now }
+	return src
+}
+
+func TestKubernetesSourceMirrorsPodLogs(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 1, 0, 0, time.UTC)
+	kc := &fakeKubectl{
+		pods: `{"items":[` + podJSON("fix-1", "Running", "") + `,` + podJSON("fix-2", "Pending", "") + `]}`,
+		logs: map[string]string{
+			"fix-1": `2026-03-01T12:00:01.000000001Z {"type":"system","subtype":"init","cwd":"/workspace","timestamp":"2026-03-01T12:00:01Z"}` + "\n" +
+				`2026-03-01T12:00:02.000000001Z {"type":"assistant","timestamp":"2026-03-01T12:00:02Z","message":{"role":"assistant","model":"claude-opus-4-6","content":[{"type":"text","text":"on it"}]}}` + "\n",
+		},
+	}
+	src := newTestKubernetesSource(t, kc, &now)
+
+	src.refresh(context.Background())
+	handles, err := src.Discover()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(handles) != 1 || handles[0].SessionID != "batch/fix-1" || handles[0].WorkingDir != "/repo/app" || handles[0].Source != "kubernetes" {
+		t.Fatalf("handles = %+v, want only the running pod", handles)
+	}
+	if got := strings.Join(kc.calls[0], " "); got != "--context=prod get pods --selector=agent-racer/track=true --output=json --namespace=batch" {
+		t.Errorf("list call = %q", got)
+	}
+
+	update, offset, err := src.Parse(handles[0], 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if update.MessageCount != 1 || update.Model != "claude-opus-4-6" || update.Ended != "" {
+		t.Errorf("first update = %+v", update)
+	}
+
+	// kubectl's --since-time is second-granular, so the next fetch repeats
+	// the last line; the mirror must not.
+	kc.logs["fix-1"] = `2026-03-01T12:00:02.000000001Z {"type":"assistant","timestamp":"2026-03-01T12:00:02Z","message":{"role":"assistant","model":"claude-opus-4-6","content":[{"type":"text","text":"on it"}]}}` + "\n" +
+		`2026-03-01T12:00:09Z {"type":"assistant","timestamp":"2026-03-01T12:00:09Z","message":{"role":"assistant","model":"claude-opus-4-6","content":[{"type":"text","text":"done"}]}}` + "\n"
+	now = now.Add(10 * time.Second)
+	src.refresh(context.Background())
+	if _, err := src.Discover(); err != nil {
+		t.Fatal(err)
+	}
+	logs := kc.calls[len(kc.calls)-1]
+	if last := logs[len(logs)-1]; last != "--since-time=2026-03-01T12:00:02Z" {
+		t.Errorf("logs call = %v, want a --since-time fetch", logs)
+	}
+	update, _, err = src.Parse(handles[0], offset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if update.MessageCount != 1 {
+		t.Errorf("second update counted %d messages, want 1", update.MessageCount)
+	}
+}
+
+func TestKubernetesSourceEndsExitedPods(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 1, 0, 0, time.UTC)
+	kc := &fakeKubectl{
+		pods: `{"items":[` + podJSON("ok", "Succeeded", "2026-03-01T12:00:50Z") + `,` +
+			podJSON("bad", "Failed", "2026-03-01T12:00:55Z") + `,` +
+			podJSON("stale", "Succeeded", "2026-03-01T11:00:00Z") + `]}`,
+		logs: map[string]string{
+			"ok":  `2026-03-01T12:00:01Z {"type":"user","timestamp":"2026-03-01T12:00:01Z","message":{"role":"user","content":"go"}}` + "\n",
+			"bad": `2026-03-01T12:00:01Z {"type":"user","timestamp":"2026-03-01T12:00:01Z","message":{"role":"user","content":"go"}}` + "\n",
+		},
+	}
+	src := newTestKubernetesSource(t, kc, &now)
+
+	src.refresh(context.Background())
+	handles, err := src.Discover()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(handles) != 2 {
+		t.Fatalf("handles = %+v, want the two pods that exited recently", handles)
+	}
+	want := map[string]string{"batch/ok": "complete", "batch/bad": "errored"}
+	for _, h := range handles {
+		update, offset, err := src.Parse(h, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if update.Ended != want[h.SessionID] {
+			t.Errorf("%s: Ended = %q, want %q", h.SessionID, update.Ended, want[h.SessionID])
+		}
+		if again, _, _ := src.Parse(h, offset); again.HasData() {
+			t.Errorf("%s: reported again: %+v", h.SessionID, again)
+		}
+	}
+
+	// Exited pods aren't fetched again, and are pruned once gone.
+	n := len(kc.calls)
+	now = now.Add(10 * time.Second)
+	src.refresh(context.Background())
+	if _, err := src.Discover(); err != nil {
+		t.Fatal(err)
+	}
+	if len(kc.calls) != n+1 {
+		t.Errorf("calls after exit = %v, want only the pod list", kc.calls[n:])
+	}
+	kc.pods = `{"items":[]}`
+	now = now.Add(10 * time.Second)
+	src.refresh(context.Background())
+	if _, err := src.Discover(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Dir(handles[0].LogPath)); !os.IsNotExist(err) {
+		t.Errorf("mirror not pruned: %v", err)
+	}
+}
+
+// endingSource reports one session whose update says it has ended.
+type endingSource struct {
+	testSource
+	ended string
+}
+
+func (s *endingSource) Parse(handle SessionHandle, offset int64) (SourceUpdate, int64, error) {
+	update, newOffset, err := s.testSource.Parse(handle, offset)
+	update.Ended = s.ended
+	return update, newOffset, err
+}
+
+func TestPollMarksEndedUpdatesTerminal(t *testing.T) {
+	dir := t.TempDir()
+	jsonlPath := filepath.Join(dir, "session-job.jsonl")
+	now := time.Now().UTC()
+	writeJSONL(t, jsonlPath,
+		jsonlLine("assistant", "session-job", now.Format(time.RFC3339Nano), "claude-opus-4-5-20251101", "", "/repo"))
+
+	src := &endingSource{
+		testSource: testSource{handles: []SessionHandle{newTestHandle("session-job", jsonlPath, "/repo", now)}},
+		ended:      "errored",
+	}
+	m, store, _ := newPollTestMonitorWithSources([]Source{src}, defaultTestConfig())
+
+	m.poll()
+
+	state, ok := store.Get("claude:session-job")
+	if !ok {
+		t.Fatal("session should exist after poll")
+	}
+	if state.Activity != session.Errored || state.CompletedAt == nil {
+		t.Errorf("activity = %v, completedAt = %v; want errored with a completion time", state.Activity, state.CompletedAt)
+	}
+}
@@ -6,18 +6,22 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"math"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
-	"runtime"
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/agent-racer/backend/internal/config"
+	"github.com/agent-racer/backend/internal/crash"
+	"github.com/agent-racer/backend/internal/links"
 	"github.com/agent-racer/backend/internal/session"
+	"github.com/agent-racer/backend/internal/tokenizer"
 	"github.com/agent-racer/backend/internal/ws"
 )
 
@@ -33,6 +37,15 @@ type trackedSession struct {
 	fileOffset     int64
 	lastDataTime   time.Time
 	tokenSnapshots []tokenSnapshot
+	baseline       repoBaseline       // repository state when the session was first seen
+	baselineRoot   string             // repository the baseline was taken in
+	cacheCollapsed time.Time          // when a cache collapse was last announced
+	hidden         bool               // the repository's .agent-racer.yaml keeps it off the track
+	subProjects    *subProjectTracker // files touched per package; nil until the first touch
+	smoothedRate   float64            // BurnRateSmoothed as of smoothedAt
+	smoothedAt     time.Time
+	// textTokens counts message text per role since the last compaction.
+	textTokens session.TokenBreakdown
 }
 
 // trackingKey returns the composite key used to identify a tracked session.
@@ -143,12 +156,20 @@ func absDuration(d time.Duration) time.Duration {
 const defaultTmuxResolverTTL = 5 * time.Second
 const defaultProcessActivityInterval = 5 * time.Second
 
+// TerminalHook is called with a snapshot of each session as it completes,
+// errors or is lost.
+type TerminalHook func(*session.SessionState)
+
+// ApprovalHook is called with a snapshot of each session as it starts
+// waiting for the user to approve something.
+type ApprovalHook func(*session.SessionState)
+
 // SnapshotHook is called after each poll with the current snapshot of all sessions.
 // It is called synchronously from the poll goroutine; implementations must not block.
 type SnapshotHook func([]*session.SessionState)
 
 type Monitor struct {
-	mu                      sync.RWMutex // protects cfg, sources, health
+	mu                      sync.RWMutex // protects cfg, sources, health, runCtx, refreshers
 	cfg                     *config.Config
 	store                   *session.Store
 	broadcaster             *ws.Broadcaster
@@ -159,12 +180,15 @@ type Monitor struct {
 	prevCPU                 map[int]cpuSample
 	lastProcessPoll         time.Time
 	processActivity         map[string]ProcessActivity
+	hostDirs                map[string]string        // containerized agent's working dir -> same dir on the host
 	statsEvents             chan<- session.Event     // nil disables stats event emission
 	statsDropped            int64                    // events dropped since last log
 	statsLastDropLog        time.Time                // last time a drop was logged
 	health                  map[string]*sourceHealth // keyed by source name
 	reconfigureCh           chan struct{}            // signals Start() to recreate its poll ticker
 	snapshotHook            SnapshotHook             // optional hook called after each poll
+	terminalHook            TerminalHook             // optional hook called on terminal transitions
+	approvalHook            ApprovalHook             // optional hook called when a session starts needing approval
 	discoverProcessActivity func(map[int]cpuSample, time.Duration) ([]ProcessActivity, map[int]cpuSample)
 	processPollInterval     time.Duration
 	newTmuxResolver         func() *TmuxResolver // injectable for tests
@@ -172,6 +196,17 @@ type Monitor struct {
 	tmuxResolver            *TmuxResolver        // cached resolver (nil means tmux unavailable)
 	tmuxResolverNext        time.Time            // next refresh time for cached resolver
 	tmuxResolverSet         bool                 // true after first resolver attempt
+	links                   *links.Resolver      // cached issue/PR link lookups
+	speeds                  *session.SpeedScale  // fastest smoothed burn rate per model
+	history                 *session.Baseline    // finished sessions to rank live ones against; nil disables
+	crashReporter           *crash.Reporter      // nil disables crash-report files
+	lastPollDuration        atomic.Int64         // nanoseconds the last poll took
+	outcomes                chan outcomeResult   // outcomes worked out off the poll loop
+
+	// runCtx is Start's context, nil before Start. refreshers holds the
+	// cancel func of each BackgroundSource's Run goroutine.
+	runCtx     context.Context
+	refreshers map[BackgroundSource]context.CancelFunc
 }
 
 func NewMonitor(cfg *config.Config, store *session.Store, broadcaster *ws.Broadcaster, sources []Source) *Monitor {
@@ -195,11 +230,38 @@ func NewMonitor(cfg *config.Config, store *session.Store, broadcaster *ws.Broadc
 		reconfigureCh:           make(chan struct{}, 1),
 		newTmuxResolver:         NewTmuxResolver,
 		tmuxResolverTTL:         defaultTmuxResolverTTL,
+		links:                   links.NewResolver(),
+		speeds:                  session.NewSpeedScale(),
+		outcomes:                make(chan outcomeResult, outcomeBuffer),
 	}
+	m.attachSelfSources(sources)
 	broadcaster.SetHealthHook(m.SourceHealthSnapshot)
 	return m
 }
 
+// attachSelfSources gives any SelfSource among sources the monitor's own
+// figures to report.
+func (m *Monitor) attachSelfSources(sources []Source) {
+	for _, src := range sources {
+		if self, ok := src.(*SelfSource); ok {
+			self.setStats(m.selfStats)
+		}
+	}
+}
+
+// selfStats reports the monitor's figures for SelfSource.
+func (m *Monitor) selfStats() SelfStats {
+	return SelfStats{
+		WSClients:    m.broadcaster.ClientCount(),
+		PollDuration: m.LastPollDuration(),
+	}
+}
+
+// LastPollDuration returns how long the last poll took.
+func (m *Monitor) LastPollDuration() time.Duration {
+	return time.Duration(m.lastPollDuration.Load())
+}
+
 // SetConfig replaces the monitor's config pointer. The new config is read on
 // the next poll tick. Only fields consulted during polling are affected
 // (models, token normalization, monitor timings, churning thresholds).
@@ -238,8 +300,59 @@ func (m *Monitor) SetSources(newSources []Source) {
 			newHealth[name] = newSourceHealth()
 		}
 	}
+	m.attachSelfSources(newSources)
 	m.sources = newSources
 	m.health = newHealth
+	m.syncRefreshersLocked()
+}
+
+// syncRefreshersLocked starts Run for each BackgroundSource among the
+// monitor's sources that isn't running yet and stops it for sources no
+// longer in use. It does nothing before Start. Caller must hold m.mu.
+func (m *Monitor) syncRefreshersLocked() {
+	if m.runCtx == nil {
+		return
+	}
+	if m.refreshers == nil {
+		m.refreshers = make(map[BackgroundSource]context.CancelFunc)
+	}
+	inUse := make(map[BackgroundSource]bool)
+	for _, src := range m.sources {
+		bg, ok := src.(BackgroundSource)
+		if !ok {
+			continue
+		}
+		inUse[bg] = true
+		if _, running := m.refreshers[bg]; running {
+			continue
+		}
+		ctx, cancel := context.WithCancel(m.runCtx)
+		m.refreshers[bg] = cancel
+		go m.runRefresh(ctx, bg)
+	}
+	for bg, cancel := range m.refreshers {
+		if !inUse[bg] {
+			cancel()
+			delete(m.refreshers, bg)
+		}
+	}
+}
+
+// runRefresh runs src's refresher until ctx is done, recovering a panic
+// the same way pollSource does.
+func (m *Monitor) runRefresh(ctx context.Context, src BackgroundSource) {
+	defer func() {
+		if r := recover(); r != nil {
+			stack := crash.Stack()
+			slog.Error("panic recovered in source refresh", "source", src.Name(), "error", r, "stack", string(stack))
+			if path, err := m.crashReporter.Record("refresh/"+src.Name(), r, stack); err != nil {
+				slog.Warn("crash report not written", "error", err)
+			} else if path != "" {
+				slog.Info("crash report written", "path", path)
+			}
+		}
+	}()
+	src.Run(ctx)
 }
 
 // SetStatsEvents configures a channel for session lifecycle events.
@@ -256,21 +369,60 @@ func (m *Monitor) SetSnapshotHook(fn SnapshotHook) {
 	m.snapshotHook = fn
 }
 
+// SetTerminalHook registers a function to be called when a session reaches
+// a terminal state. Pass nil to disable. The hook is called synchronously;
+// it must not block. Must be called before Start.
+func (m *Monitor) SetTerminalHook(fn TerminalHook) {
+	m.terminalHook = fn
+}
+
+// SetBaseline registers the finished sessions live ones are ranked against
+// in SessionState.Percentiles; sessions reaching a terminal state are added
+// to it. Pass nil to disable. Must be called before Start.
+func (m *Monitor) SetBaseline(b *session.Baseline) {
+	m.history = b
+}
+
+// SetApprovalHook registers a function to be called when a session starts
+// waiting for approval. Pass nil to disable. The hook is called
+// synchronously; it must not block. Must be called before Start.
+func (m *Monitor) SetApprovalHook(fn ApprovalHook) {
+	m.approvalHook = fn
+}
+
+// SetCrashReporter registers a reporter that receives a crash-report file
+// for every panic recovered while polling a source. Pass nil to disable.
+// Must be called before Start.
+func (m *Monitor) SetCrashReporter(r *crash.Reporter) {
+	m.crashReporter = r
+}
+
 // emitEvent sends a session event to the stats channel if configured.
-// Uses non-blocking send to avoid stalling the monitor if the consumer
-// falls behind. Dropped events are counted and logged at most once per
-// 10 seconds to avoid log spam under sustained backpressure.
 func (m *Monitor) emitEvent(evType session.EventType, state *session.SessionState) {
-	if m.statsEvents == nil {
+	m.sendEvent(session.Event{Type: evType, State: state})
+}
+
+// emitSubagentEvent tells the stats tracker about a subagent of state
+// starting or completing.
+func (m *Monitor) emitSubagentEvent(evType session.EventType, state *session.SessionState, sub session.SubagentState) {
+	m.sendEvent(session.Event{Type: evType, State: state, Subagent: &sub})
+}
+
+// sendEvent stamps ev with a snapshot of its state and the active count and
+// sends it to the stats channel. Uses non-blocking send to avoid stalling
+// the monitor if the consumer falls behind. Dropped events are counted and
+// logged at most once per 10 seconds to avoid log spam under sustained
+// backpressure.
+func (m *Monitor) sendEvent(ev session.Event) {
+	// The server's own car earns nothing.
+	if m.statsEvents == nil || ev.State.Source == SelfSourceName {
 		return
 	}
-	snap := *state
+	snap := *ev.State
+	ev.State = &snap
+	ev.ActiveCount = m.store.ActiveCount()
 	select {
-	case m.statsEvents <- session.Event{
-		Type:        evType,
-		State:       &snap,
-		ActiveCount: m.store.ActiveCount(),
-	}:
+	case m.statsEvents <- ev:
 	default:
 		m.statsDropped++
 		now := time.Now()
@@ -283,13 +435,15 @@ func (m *Monitor) emitEvent(evType session.EventType, state *session.SessionStat
 }
 
 func (m *Monitor) Start(ctx context.Context) {
-	m.mu.RLock()
+	m.mu.Lock()
 	pollInterval := m.cfg.Monitor.PollInterval
 	sourceNames := make([]string, len(m.sources))
 	for i, s := range m.sources {
 		sourceNames[i] = s.Name()
 	}
-	m.mu.RUnlock()
+	m.runCtx = ctx
+	m.syncRefreshersLocked()
+	m.mu.Unlock()
 
 	ticker := time.NewTicker(pollInterval)
 	defer ticker.Stop()
@@ -314,12 +468,15 @@ func (m *Monitor) Start(ctx context.Context) {
 			slog.Info("monitor poll interval updated", "interval", newInterval)
 		case <-ticker.C:
 			m.poll()
+		case r := <-m.outcomes:
+			m.applyOutcome(r)
 		}
 	}
 }
 
 func (m *Monitor) poll() {
 	now := time.Now()
+	defer func() { m.lastPollDuration.Store(int64(time.Since(now))) }()
 
 	// Snapshot mutable fields under the read lock so that concurrent
 	// SetConfig/SetSources calls from the SIGHUP goroutine don't race
@@ -330,8 +487,13 @@ func (m *Monitor) poll() {
 	health := m.health
 	m.mu.RUnlock()
 
+	m.applyOutcomes()
 	m.consumeSessionEndMarkers(cfg, now)
 
+	// Refreshed before parsing so new sessions from containerized agents
+	// resolve their branch against the host copy of the project.
+	activityByDir := m.refreshProcessActivity(now)
+
 	// Collect active session keys from all sources for stale detection.
 	activeKeys := make(map[string]bool)
 
@@ -348,10 +510,9 @@ func (m *Monitor) poll() {
 	// Emit health events for sources that crossed a status threshold.
 	m.maybeEmitHealthEvents(cfg, sources, health)
 
-	activityByDir := m.refreshProcessActivity(now)
-
 	// Apply churning state to non-terminal, non-waiting sessions.
-	// Terminal sessions are done; waiting means blocked on user input.
+	// Terminal sessions are done; waiting and needing approval mean
+	// blocked on user input.
 	// For active states (starting, idle, thinking, tool_use) the backend
 	// sets the flag and lets the frontend decide visibility -- Racer.js
 	// suppresses churning visuals when thinking/tool_use animations are
@@ -360,12 +521,19 @@ func (m *Monitor) poll() {
 	requireNetwork := cfg.Monitor.ChurningRequiresNetwork
 	for _, state := range updates {
 		churning := false
-		if !state.IsTerminal() && state.Activity != session.Waiting {
+		if !state.IsTerminal() && state.Activity != session.Waiting && state.Activity != session.NeedsApproval {
 			if pa, ok := activityByDir[state.WorkingDir]; ok {
 				churning = pa.IsChurning(cpuThreshold, requireNetwork)
 				if pa.PID > 0 && state.PID == 0 {
 					state.PID = pa.PID
 				}
+				// Claude doesn't log a permission prompt, but a tool call
+				// that has had no result for a while from an agent that
+				// isn't working is almost always sitting on one.
+				promptAfter := cfg.Monitor.ApprovalPromptAfter
+				if promptAfter > 0 && !churning && state.Activity == session.ToolUse && now.Sub(state.LastDataReceivedAt) >= promptAfter {
+					state.Activity = session.NeedsApproval
+				}
 			}
 		}
 		state.IsChurning = churning
@@ -410,6 +578,12 @@ func (m *Monitor) poll() {
 	}
 
 	// Mark stale sessions as lost (disappeared without session end marker).
+	heartbeats := make(map[string]HeartbeatSource)
+	for _, src := range sources {
+		if hb, ok := src.(HeartbeatSource); ok {
+			heartbeats[src.Name()] = hb
+		}
+	}
 	var toRemove []string
 	for key, ts := range m.tracked {
 		if activeKeys[key] {
@@ -419,11 +593,17 @@ func (m *Monitor) poll() {
 				continue
 			}
 			// Still discovered and not stale by time — skip.
-			isStale := cfg.Monitor.SessionStaleAfter > 0 && now.Sub(ts.lastDataTime) > cfg.Monitor.SessionStaleAfter
+			last, threshold := ts.lastDataTime, cfg.Monitor.SessionStaleAfter
+			if hb, ok := heartbeats[sourceFromKey(key)]; ok {
+				if at, ttl, known := hb.Heartbeat(ts.handle.SessionID); known {
+					last, threshold = at, ttl
+				}
+			}
+			isStale := threshold > 0 && now.Sub(last) > threshold
 			if !isStale {
 				continue
 			}
-			slog.Info("session stale", "session", key, "lastData", ts.lastDataTime.Format("15:04:05"), "age", now.Sub(ts.lastDataTime).Round(time.Second), "threshold", cfg.Monitor.SessionStaleAfter)
+			slog.Info("session stale", "session", key, "lastData", last.Format("15:04:05"), "age", now.Sub(last).Round(time.Second), "threshold", threshold)
 		}
 
 		if state, ok := getSessionState(key); ok {
@@ -476,17 +656,24 @@ func (m *Monitor) poll() {
 
 	// Compute racing positions and detect overtakes before committing.
 	if len(updates) > 0 {
-		m.updatePositions(updates)
+		updates = m.updatePositions(updates, cfg.RaceRules())
 	}
 
 	// Atomically commit all session updates to the store and then queue
 	// the broadcast. The notify callback runs after the write lock is
 	// released to avoid lock inversion with the broadcaster.
+	var approvals []*session.SessionState
+	if m.approvalHook != nil {
+		approvals = m.newApprovals(updates)
+	}
 	if len(updates) > 0 {
 		m.store.BatchUpdateAndNotify(updates, func() {
 			m.broadcaster.QueueUpdate(updates)
 		})
 	}
+	for _, state := range approvals {
+		m.approvalHook(state)
+	}
 
 	m.flushRemovals(now)
 
@@ -495,6 +682,22 @@ func (m *Monitor) poll() {
 	}
 }
 
+// newApprovals returns snapshots of the sessions in updates that need
+// approval and didn't in the store.
+func (m *Monitor) newApprovals(updates []*session.SessionState) []*session.SessionState {
+	var approvals []*session.SessionState
+	for _, state := range updates {
+		if state.Activity != session.NeedsApproval {
+			continue
+		}
+		if prev, ok := m.store.Get(state.ID); ok && prev.Activity == session.NeedsApproval {
+			continue
+		}
+		approvals = append(approvals, state.Clone())
+	}
+	return approvals
+}
+
 func (m *Monitor) refreshProcessActivity(now time.Time) map[string]ProcessActivity {
 	if m.discoverProcessActivity == nil {
 		return m.processActivity
@@ -512,20 +715,46 @@ func (m *Monitor) refreshProcessActivity(now time.Time) map[string]ProcessActivi
 	m.lastProcessPoll = now
 
 	activityByDir := make(map[string]ProcessActivity, len(activities))
-	for _, a := range activities {
+	add := func(dir string, a ProcessActivity) {
 		// If multiple processes share a CWD, keep the one with higher CPU.
-		if existing, ok := activityByDir[a.WorkingDir]; ok {
-			if a.CPU > existing.CPU {
-				activityByDir[a.WorkingDir] = a
-			}
+		if existing, ok := activityByDir[dir]; ok && a.CPU <= existing.CPU {
+			return
+		}
+		activityByDir[dir] = a
+	}
+	hostDirs := make(map[string]string)
+	ambiguous := make(map[string]bool)
+	for _, a := range activities {
+		add(a.WorkingDir, a)
+		if a.HostDir == "" {
 			continue
 		}
-		activityByDir[a.WorkingDir] = a
+		// A containerized agent's transcript may carry either path,
+		// depending on whether it was written inside the container.
+		add(a.HostDir, a)
+		if prev, ok := hostDirs[a.WorkingDir]; ok && prev != a.HostDir {
+			// Two containers using the same path for different projects.
+			ambiguous[a.WorkingDir] = true
+		}
+		hostDirs[a.WorkingDir] = a.HostDir
+	}
+	for dir := range ambiguous {
+		delete(hostDirs, dir)
 	}
 	m.processActivity = activityByDir
+	m.hostDirs = hostDirs
 	return m.processActivity
 }
 
+// hostPath returns where dir is on the host, for running git in the
+// project of an agent that reported a path inside its container.
+func (m *Monitor) hostPath(dir string) string {
+	if host, ok := m.hostDirs[dir]; ok {
+		return host
+	}
+	return dir
+}
+
 func (m *Monitor) cachedTmuxResolver(now time.Time) *TmuxResolver {
 	if m.newTmuxResolver == nil {
 		return nil
@@ -550,10 +779,14 @@ func (m *Monitor) pollSource(src Source, cfg *config.Config, sh *sourceHealth, n
 
 	defer func() {
 		if r := recover(); r != nil {
-			buf := make([]byte, 4096)
-			n := runtime.Stack(buf, false)
-			slog.Error("panic recovered in poll", "source", src.Name(), "error", r, "stack", string(buf[:n]))
+			stack := crash.Stack()
+			slog.Error("panic recovered in poll", "source", src.Name(), "error", r, "stack", string(stack))
 			sh.recordPanic(fmt.Errorf("panic: %v", r))
+			if path, err := m.crashReporter.Record("poll/"+src.Name(), r, stack); err != nil {
+				slog.Warn("crash report not written", "error", err)
+			} else if path != "" {
+				slog.Info("crash report written", "path", path)
+			}
 		}
 	}()
 
@@ -616,6 +849,10 @@ func (m *Monitor) pollSource(src Source, cfg *config.Config, sh *sourceHealth, n
 			}
 		}
 
+		if ts.hidden {
+			continue
+		}
+
 		// Check for resumed sessions that were already removed from store.
 		if m.removedKeys[key] {
 			if !hasNewData {
@@ -626,12 +863,19 @@ func (m *Monitor) pollSource(src Source, cfg *config.Config, sh *sourceHealth, n
 		}
 
 		state, existed := m.store.Get(key)
+		// A launched session is pre-registered before its log exists;
+		// the first time the log is seen, build it up like a new one.
+		var placeholder *session.SessionState
+		if existed && !exists && state.Launched && !state.IsTerminal() {
+			placeholder, existed = state, false
+		}
 		if existed && state.IsTerminal() {
 			if !hasNewData {
 				continue
 			}
 			// New JSONL data on a terminal session — it's being resumed.
 			state.CompletedAt = nil
+			state.Outcome = ""
 			state.Subagents = nil // Reset stale subagent state to prevent double-counting.
 			delete(m.pendingRemoval, key)
 			slog.Info("session resumed", "source", src.Name(), "from", state.Activity, "session", h.SessionID, "newData", newOffset-oldOffset)
@@ -659,15 +903,33 @@ func (m *Monitor) pollSource(src Source, cfg *config.Config, sh *sourceHealth, n
 			if workingDir == "" {
 				workingDir = update.WorkingDir
 			}
+			overrides := loadRepoOverrides(m.hostPath(workingDir))
+			if overrides != nil && overrides.Privacy == session.PrivacyHidden {
+				ts.hidden = true
+				slog.Debug("session hidden by repository overrides", "source", src.Name(), "session", h.SessionID)
+				continue
+			}
 			state = &session.SessionState{
 				ID:         key,
 				Name:       nameFromPath(workingDir),
 				Source:     h.Source,
 				StartedAt:  startedAt,
 				WorkingDir: workingDir,
-				Branch:     detectBranch(workingDir),
+				Branch:     detectBranch(m.hostPath(workingDir)),
 				LogPath:    h.LogPath,
 			}
+			if h.Name != "" {
+				state.Name = h.Name
+			}
+			state.Project, state.Worktree = resolveProject(m.hostPath(workingDir), state.Branch)
+			ts.baseline = captureBaseline(m.hostPath(workingDir))
+			ts.baselineRoot = repoRoot(m.hostPath(workingDir))
+			applyRepoOverrides(state, overrides)
+			if placeholder != nil {
+				state.StartedAt = placeholder.StartedAt
+				state.TmuxTarget = placeholder.TmuxTarget
+				state.Launched = true
+			}
 		}
 
 		if h.LogPath != "" && h.LogPath != state.LogPath {
@@ -677,7 +939,21 @@ func (m *Monitor) pollSource(src Source, cfg *config.Config, sh *sourceHealth, n
 		if update.WorkingDir != "" && update.WorkingDir != state.WorkingDir {
 			state.WorkingDir = update.WorkingDir
 			state.Name = nameFromPath(update.WorkingDir)
-			state.Branch = detectBranch(update.WorkingDir)
+			state.Branch = detectBranch(m.hostPath(update.WorkingDir))
+			state.Project, state.Worktree = resolveProject(m.hostPath(update.WorkingDir), state.Branch)
+			ts.moveBaseline(m.hostPath(update.WorkingDir))
+			if ts.subProjects != nil && ts.subProjects.root != repoRoot(m.hostPath(update.WorkingDir)) {
+				ts.subProjects = nil
+				state.SubProject = ""
+			}
+			overrides := loadRepoOverrides(m.hostPath(update.WorkingDir))
+			if overrides != nil && overrides.Privacy == session.PrivacyHidden {
+				// Moved into a repository that hides its sessions.
+				ts.hidden = true
+				m.pendingRemoval[key] = now
+				continue
+			}
+			applyRepoOverrides(state, overrides)
 		}
 
 		// Only classify activity when we have new data or a fresh session.
@@ -688,6 +964,20 @@ func (m *Monitor) pollSource(src Source, cfg *config.Config, sh *sourceHealth, n
 		}
 
 		if update.Model != "" {
+			if existed && state.Model != "" && update.Model != state.Model {
+				state.ModelSwitches++
+				at := update.LastTime
+				if at.IsZero() {
+					at = now
+				}
+				m.broadcaster.BroadcastModelChanged(ws.ModelChangedPayload{
+					SessionID: state.ID,
+					Name:      state.Name,
+					OldModel:  state.Model,
+					NewModel:  update.Model,
+					At:        at,
+				})
+			}
 			state.Model = update.Model
 		}
 
@@ -719,6 +1009,16 @@ func (m *Monitor) pollSource(src Source, cfg *config.Config, sh *sourceHealth, n
 		// that estimation strategies can use the updated counts.
 		state.MessageCount += update.MessageCount
 		state.ToolCallCount += update.ToolCalls
+		state.MCPToolCalls = session.MergeCounts(state.MCPToolCalls, update.MCPToolCalls)
+		state.ToolCounts = session.MergeCounts(state.ToolCounts, update.ToolCounts)
+		state.ShellCommands = session.MergeCounts(state.ShellCommands, update.ShellCommands)
+		state.FilesPatched = session.MergeCounts(state.FilesPatched, update.FilesPatched)
+		touched := update.FilesTouched
+		if touched == nil {
+			touched = update.FilesPatched
+		}
+		state.TouchFiles(touched)
+		m.attributeSubProject(ts, state, touched)
 		state.CompactionCount += update.CompactionCount
 		if update.LastTool != "" {
 			state.CurrentTool = update.LastTool
@@ -726,19 +1026,74 @@ func (m *Monitor) pollSource(src Source, cfg *config.Config, sh *sourceHealth, n
 		if update.LastAssistantText != "" {
 			state.LastAssistantText = update.LastAssistantText
 		}
+		if update.LastCommand != "" {
+			state.LastCommand = update.LastCommand
+		}
+		state.SlashCommands = session.MergeCounts(state.SlashCommands, update.SlashCommands)
+		state.HookEventCount += update.HookEvents
+		if !cfg.Privacy.ShowTopics {
+			state.Topic = ""
+		} else if state.Topic == "" && update.FirstPrompt != "" {
+			state.Topic = topicFromPrompt(update.FirstPrompt)
+		}
+
+		linked := m.links.Lookup(cfg.Links.Options(), m.hostPath(state.WorkingDir), state.Branch)
+		state.IssueURL, state.PRURL = linked.IssueURL, linked.PRURL
 
-		mergeSubagents(state, update.Subagents)
+		subsStarted, subsCompleted := mergeSubagents(state, update.Subagents)
 
 		m.resolveTokens(cfg, state, update, maxTokens)
+		attributeTokens(cfg, ts, state, update)
+		subBudget := subagentBudget(cfg, state)
+		subsOverBudget := flagOverBudgetSubagents(state, subBudget)
+		if state.AddCacheUsage(update.Cache) && now.Sub(ts.cacheCollapsed) >= cacheCollapseCooldown {
+			ts.cacheCollapsed = now
+			m.broadcaster.BroadcastCacheCollapse(ws.CacheCollapsePayload{
+				SessionID:       state.ID,
+				Name:            state.Name,
+				HitRatio:        update.Cache.HitRatio(),
+				SessionHitRatio: state.CacheHitRatio,
+				MissedTokens:    update.Cache.WriteTokens + update.Cache.UncachedTokens,
+				At:              now,
+			})
+		}
 
 		// Calculate burn rate from token history
 		state.BurnRatePerMinute = m.calculateBurnRate(ts, state.TokensUsed, now)
+		state.BurnRateSmoothed = ts.smoothBurnRate(state.BurnRatePerMinute, cfg.Race.SpeedSmoothing, now)
+		state.Speed = m.speeds.Speed(state.Model, state.BurnRateSmoothed)
+		if !state.IsTerminal() {
+			state.Percentiles = m.history.Rank(state)
+		}
+		forecastCompaction(ts, state, cfg.TokenNorm.CompactionThreshold, now)
 
 		if !existed {
 			m.emitEvent(session.EventNew, state)
 		} else if hasNewData {
 			m.emitEvent(session.EventUpdate, state)
 		}
+		for i := 0; i < len(subsStarted); i++ {
+			m.broadcaster.BroadcastSubagentStarted(state, subsStarted[i])
+			m.emitSubagentEvent(session.EventSubagentStarted, state, subsStarted[i])
+		}
+		for i := 0; i < len(subsCompleted); i++ {
+			m.broadcaster.BroadcastSubagentCompleted(state, subsCompleted[i])
+			m.emitSubagentEvent(session.EventSubagentCompleted, state, subsCompleted[i])
+		}
+		for i := 0; i < len(subsOverBudget); i++ {
+			at := subsOverBudget[i].LastActivityAt
+			if at.IsZero() {
+				at = now
+			}
+			m.broadcaster.BroadcastSubagentBudget(state, subsOverBudget[i], subBudget, at)
+		}
+		if update.Ended != "" && !state.IsTerminal() {
+			completedAt := now
+			if !update.LastTime.IsZero() {
+				completedAt = update.LastTime
+			}
+			m.markTerminal(cfg, state, determineActivityFromReason(update.Ended), completedAt)
+		}
 		updates = append(updates, state)
 	}
 
@@ -754,6 +1109,24 @@ func (m *Monitor) markTerminal(cfg *config.Config, state *session.SessionState,
 	wasTerminal := state.IsTerminal()
 	state.Activity = activity
 	state.CompletedAt = &completedAt
+	state.SecondsToCompact, state.CompactionETA = 0, time.Time{}
+	resolving := false
+	if !wasTerminal {
+		var base repoBaseline
+		if ts, ok := m.tracked[state.ID]; ok {
+			base = ts.baseline
+		}
+		dir := m.hostPath(state.WorkingDir)
+		if activity == session.Complete && base.ok && dir != "" {
+			// Comparing with the repository runs git up to four times, too
+			// slow for the poll loop. The completion is broadcast now; the
+			// outcome and everything that records it follow.
+			resolving = true
+			m.resolveOutcome(state.Clone(), dir, base)
+		} else {
+			state.Outcome = sessionOutcome(activity, dir, base)
+		}
+	}
 	m.store.UpdateAndNotify(state, func() {
 		if !wasTerminal {
 			slog.Info("session terminal", "session", state.ID, "name", state.Name, "activity", activity)
@@ -761,12 +1134,79 @@ func (m *Monitor) markTerminal(cfg *config.Config, state *session.SessionState,
 		}
 		m.broadcaster.QueueUpdate([]*session.SessionState{state})
 	})
-	if !wasTerminal {
-		m.emitEvent(session.EventTerminal, state)
+	if !wasTerminal && !resolving {
+		m.recordTerminal(state)
 	}
 	m.scheduleRemoval(cfg, state.ID, completedAt)
 }
 
+// recordTerminal hands a session that has just reached a terminal state,
+// outcome included, to the stats tracker, the baseline and the terminal
+// hook.
+func (m *Monitor) recordTerminal(state *session.SessionState) {
+	m.emitEvent(session.EventTerminal, state)
+	if m.history != nil {
+		m.history.Add(state)
+	}
+	if m.terminalHook != nil {
+		m.terminalHook(state.Clone())
+	}
+}
+
+// resolveOutcome works out the outcome of state, which has just completed
+// in dir, on its own goroutine. The poll loop applies the result; once
+// Start has returned, nothing will, and the result is dropped.
+func (m *Monitor) resolveOutcome(state *session.SessionState, dir string, base repoBaseline) {
+	m.mu.RLock()
+	ctx := m.runCtx
+	m.mu.RUnlock()
+	var stopped <-chan struct{}
+	if ctx != nil {
+		stopped = ctx.Done()
+	}
+	go func() {
+		r := outcomeResult{state: state, kind: repoOutcome(dir, base)}
+		select {
+		case m.outcomes <- r:
+		case <-stopped:
+		}
+	}()
+}
+
+// applyOutcomes applies the outcomes resolved since the last poll.
+func (m *Monitor) applyOutcomes() {
+	for {
+		select {
+		case r := <-m.outcomes:
+			m.applyOutcome(r)
+		default:
+			return
+		}
+	}
+}
+
+// applyOutcome stores a resolved outcome and records the completion it
+// belongs to. A session resumed in the meantime is left alone; it is
+// recorded when it ends again. One removed from the store already is
+// still recorded, as it was when it completed.
+func (m *Monitor) applyOutcome(r outcomeResult) {
+	state := r.state
+	cur, inStore := m.store.Get(state.ID)
+	if inStore {
+		if !cur.IsTerminal() {
+			return
+		}
+		state = cur
+	}
+	state.Outcome = r.kind
+	if inStore && r.kind != "" {
+		m.store.UpdateAndNotify(state, func() {
+			m.broadcaster.QueueUpdate([]*session.SessionState{state})
+		})
+	}
+	m.recordTerminal(state)
+}
+
 // scheduleRemoval enqueues a session for removal after CompletionRemoveAfter.
 // A zero duration removes immediately; a negative duration disables removal.
 func (m *Monitor) scheduleRemoval(cfg *config.Config, sessionID string, completedAt time.Time) {
@@ -945,6 +1385,8 @@ func classifyActivityFromUpdate(update SourceUpdate) session.Activity {
 		return session.Thinking
 	case "waiting":
 		return session.Waiting
+	case "needs_approval":
+		return session.NeedsApproval
 	default:
 		if update.MessageCount == 0 && !update.HasData() {
 			return session.Idle
@@ -959,7 +1401,8 @@ func classifyActivityFromUpdate(update SourceUpdate) session.Activity {
 // resolveTokens applies the configured token normalization strategy for the
 // session's source. For "usage" it prefers real token data and falls back to
 // estimation when unavailable. For "estimate" and "message_count" it always
-// derives tokens from the accumulated message count.
+// derives tokens from the accumulated message count. For "tokenizer" it
+// adds the tokens in each update's message text.
 //
 // This method sets TokensUsed, TokenEstimated, MaxContextTokens, and
 // ContextUtilization on the session state.
@@ -999,6 +1442,20 @@ func (m *Monitor) resolveTokens(cfg *config.Config, state *session.SessionState,
 			state.TokenEstimated = true
 		}
 
+	case "tokenizer":
+		if len(update.MessageText) > 0 {
+			tok := configuredTokenizer(cfg)
+			for _, text := range update.MessageText {
+				state.TokensUsed += tok.Count(text.Text)
+			}
+		} else {
+			// The source doesn't extract text; price the messages.
+			state.TokensUsed += update.MessageCount * tokensPerMsg
+		}
+		if state.MessageCount > 0 {
+			state.TokenEstimated = true
+		}
+
 	default:
 		// Unknown strategy: use real data only, no estimation.
 		if update.TokensIn > 0 && update.TokensIn > state.TokensUsed {
@@ -1010,6 +1467,36 @@ func (m *Monitor) resolveTokens(cfg *config.Config, state *session.SessionState,
 	state.UpdateUtilization()
 }
 
+// configuredTokenizer returns the tokenizer named in config, or the
+// default when that name isn't registered.
+func configuredTokenizer(cfg *config.Config) tokenizer.Tokenizer {
+	tok, ok := tokenizer.Lookup(cfg.TokenNorm.Tokenizer)
+	if !ok {
+		tok, _ = tokenizer.Lookup(tokenizer.Default)
+	}
+	return tok
+}
+
+// attributeTokens counts the update's message text by role and splits
+// state.TokensUsed in the same proportions. A compaction replaces the
+// context, so the counts restart with the chunk that contains one.
+func attributeTokens(cfg *config.Config, ts *trackedSession, state *session.SessionState, update SourceUpdate) {
+	if update.CompactionCount > 0 {
+		ts.textTokens = session.TokenBreakdown{}
+	}
+	if len(update.MessageText) > 0 {
+		tok := configuredTokenizer(cfg)
+		for _, text := range update.MessageText {
+			ts.textTokens.Add(text.Role, tok.Count(text.Text))
+		}
+	}
+	state.TokenBreakdown = ts.textTokens.Scale(state.TokensUsed)
+}
+
+// cacheCollapseCooldown keeps a session whose cache keeps going cold from
+// announcing it on every poll.
+const cacheCollapseCooldown = 5 * time.Minute
+
 const (
 	burnRateWindow    = 60 * time.Second
 	maxTokenSnapshots = 120
@@ -1071,6 +1558,67 @@ func (m *Monitor) calculateBurnRate(ts *trackedSession, currentTokens int, now t
 	return 0
 }
 
+// smoothBurnRate folds rate into the session's moving average and returns
+// the result; the first rate seen is taken as it is.
+func (ts *trackedSession) smoothBurnRate(rate float64, window time.Duration, now time.Time) float64 {
+	if !ts.smoothedAt.IsZero() {
+		rate = session.SmoothRate(ts.smoothedRate, rate, now.Sub(ts.smoothedAt), window)
+	}
+	ts.smoothedRate, ts.smoothedAt = rate, now
+	return rate
+}
+
+// maxCompactionForecast is the furthest ahead a compaction is forecast; a
+// session burning that slowly is as good as idle.
+const maxCompactionForecast = 24 * time.Hour
+
+// forecastCompaction fits a least-squares line to the session's recent
+// token snapshots and sets when it will reach threshold of its context
+// window. Sessions that aren't growing, or are already past it, get no
+// forecast.
+func forecastCompaction(ts *trackedSession, state *session.SessionState, threshold float64, now time.Time) {
+	state.SecondsToCompact, state.CompactionETA = 0, time.Time{}
+	if threshold <= 0 || state.MaxContextTokens <= 0 || state.BurnRatePerMinute <= 0 {
+		return
+	}
+	remaining := threshold*float64(state.MaxContextTokens) - float64(state.TokensUsed)
+	rate := tokenTrend(ts.tokenSnapshots)
+	if remaining <= 0 || rate <= 0 {
+		return
+	}
+	eta := time.Duration(remaining / rate * float64(time.Second))
+	if eta > maxCompactionForecast {
+		return
+	}
+	state.SecondsToCompact = int(math.Ceil(eta.Seconds()))
+	state.CompactionETA = now.Add(eta)
+}
+
+// tokenTrend returns the least-squares slope of tokens over time, in
+// tokens per second. It uses every snapshot rather than the two at the
+// window's ends, so one large tool result moves it less.
+func tokenTrend(snaps []tokenSnapshot) float64 {
+	n := float64(len(snaps))
+	if n < 2 {
+		return 0
+	}
+	t0 := snaps[0].timestamp
+	var sumX, sumY, sumXY, sumXX float64
+	for i := 0; i < len(snaps); i++ {
+		x := snaps[i].timestamp.Sub(t0).Seconds()
+		y := float64(snaps[i].tokens)
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+	denom := n*sumXX - sumX*sumX
+	if denom == 0 {
+		return 0
+	}
+	return (n*sumXY - sumX*sumY) / denom
+}
+
 // healthThreshold returns the configured health warning threshold,
 // falling back to 3 if unconfigured or zero.
 func healthThreshold(cfg *config.Config) int {
@@ -1080,14 +1628,21 @@ func healthThreshold(cfg *config.Config) int {
 	return 3
 }
 
+// healthFlapPolicy returns the configured flap suppression.
+func healthFlapPolicy(cfg *config.Config) flapPolicy {
+	return flapPolicy{limit: cfg.Monitor.HealthFlapThreshold, window: cfg.Monitor.HealthFlapWindow}
+}
+
 // maybeEmitHealthEvents checks each source's health status and emits a
-// source_health WS event when the status transitions (e.g. healthy -> failed).
+// source_health WS event when the status transitions (e.g. healthy -> failed),
+// unless the source is flapping.
 func (m *Monitor) maybeEmitHealthEvents(cfg *config.Config, sources []Source, health map[string]*sourceHealth) {
 	threshold := healthThreshold(cfg)
+	flap := healthFlapPolicy(cfg)
 	now := time.Now()
 	for _, src := range sources {
 		sh := health[src.Name()]
-		status, discoverFailures, parseFailures, lastErr, changed := sh.snapshotAndEmit(threshold)
+		status, discoverFailures, parseFailures, lastErr, changed := sh.snapshotAndEmit(threshold, flap, now)
 		if !changed {
 			continue
 		}
@@ -1139,18 +1694,50 @@ func (m *Monitor) SourceHealthSnapshot() []ws.SourceHealthPayload {
 	return result
 }
 
+// SourceHealthHistory returns every source's recent health transitions.
+// Used by /api/health/sources/history.
+func (m *Monitor) SourceHealthHistory() []ws.SourceHealthHistory {
+	m.mu.RLock()
+	cfg := m.cfg
+	sources := m.sources
+	health := m.health
+	m.mu.RUnlock()
+
+	threshold := healthThreshold(cfg)
+	flap := healthFlapPolicy(cfg)
+	now := time.Now()
+	result := make([]ws.SourceHealthHistory, 0, len(sources))
+	for _, src := range sources {
+		status, flapping, transitions := health[src.Name()].historySnapshot(threshold, flap, now)
+		result = append(result, ws.SourceHealthHistory{
+			Source:      src.Name(),
+			Status:      status,
+			Flapping:    flapping,
+			Transitions: transitions,
+		})
+	}
+	return result
+}
+
 // mergeSubagents converts SubagentParseResults into SubagentState entries
 // on the session. It merges incrementally: existing subagents are updated
 // with new data, new subagents are appended, and subagents absent from the
-// parsed set are pruned (unless already completed).
-func mergeSubagents(state *session.SessionState, parsed map[string]*SubagentParseResult) {
+// parsed set are pruned (unless already completed, or still the parent of
+// one that stays). Subagents spawned by other subagents are linked to them,
+// and the list is kept in tree order; see linkSubagents.
+//
+// It returns the subagents that started, that is did their first work or
+// finished without any, and those that completed in this batch. Zero-message
+// entries don't count as started, since they may be phantoms pruned later.
+func mergeSubagents(state *session.SessionState, parsed map[string]*SubagentParseResult) (started, completed []session.SubagentState) {
 	// Build index of existing subagents by ID for fast lookup.
 	existing := make(map[string]int, len(state.Subagents))
 	for i, sub := range state.Subagents {
 		existing[sub.ID] = i
 	}
 
-	for _, pr := range parsed {
+	var startedIDs, completedIDs []string
+	for _, pr := range sortedSubagentResults(parsed) {
 		activity := classifySubagentActivity(pr)
 		tokens := 0
 		if pr.LatestUsage != nil {
@@ -1158,10 +1745,13 @@ func mergeSubagents(state *session.SessionState, parsed map[string]*SubagentPars
 		}
 
 		var sub *session.SubagentState
+		wasStarted, wasCompleted := false, false
 
 		if idx, ok := existing[pr.ID]; ok {
 			// Update existing subagent.
 			sub = &state.Subagents[idx]
+			wasStarted = sub.MessageCount > 0 || sub.CompletedAt != nil
+			wasCompleted = sub.CompletedAt != nil
 			if pr.Slug != "" {
 				sub.Slug = pr.Slug
 			}
@@ -1177,6 +1767,7 @@ func mergeSubagents(state *session.SessionState, parsed map[string]*SubagentPars
 			}
 			sub.MessageCount += pr.MessageCount
 			sub.ToolCallCount += pr.ToolCalls
+			sub.TaskCalls = append(sub.TaskCalls, pr.TaskCalls...)
 			if !pr.LastTime.IsZero() {
 				sub.LastActivityAt = pr.LastTime
 			}
@@ -1195,7 +1786,9 @@ func mergeSubagents(state *session.SessionState, parsed map[string]*SubagentPars
 				ToolCallCount:   pr.ToolCalls,
 				StartedAt:       pr.FirstTime,
 				LastActivityAt:  pr.LastTime,
+				TaskCalls:       append([]string(nil), pr.TaskCalls...),
 			})
+			existing[pr.ID] = len(state.Subagents) - 1
 			sub = &state.Subagents[len(state.Subagents)-1]
 		}
 
@@ -1204,6 +1797,12 @@ func mergeSubagents(state *session.SessionState, parsed map[string]*SubagentPars
 			sub.CompletedAt = &completedAt
 			sub.Activity = session.Complete
 		}
+		if !wasStarted && (sub.MessageCount > 0 || sub.CompletedAt != nil) {
+			startedIDs = append(startedIDs, sub.ID)
+		}
+		if !wasCompleted && sub.CompletedAt != nil {
+			completedIDs = append(completedIDs, sub.ID)
+		}
 	}
 
 	// Prune subagents absent from the current batch. Retain subagents
@@ -1212,19 +1811,178 @@ func mergeSubagents(state *session.SessionState, parsed map[string]*SubagentPars
 	// subagents between progress entry batches, not phantoms. The
 	// phantom filter in parseProgressEntry prevents fake entries from
 	// accumulating messages, so only truly stale zero-message entries
-	// get pruned here.
-	n := 0
-	for i := range state.Subagents {
+	// get pruned here. A pruned entry's children would lose their place
+	// in the tree, so the ancestors of every retained subagent stay too.
+	linkSubagents(state.Subagents)
+	keep := make([]bool, len(state.Subagents))
+	for i := 0; i < len(state.Subagents); i++ {
 		_, inParsed := parsed[state.Subagents[i].ID]
-		keep := inParsed ||
+		keep[i] = inParsed ||
 			state.Subagents[i].Activity == session.Complete ||
 			state.Subagents[i].MessageCount > 0
-		if keep {
+	}
+	for i := 0; i < len(state.Subagents); i++ {
+		if !keep[i] {
+			continue
+		}
+		// Bounded by the list's length in case the links form a cycle.
+		j := i
+		for steps := 0; steps < len(state.Subagents); steps++ {
+			p, ok := existing[state.Subagents[j].ParentID]
+			if !ok || keep[p] {
+				break
+			}
+			keep[p] = true
+			j = p
+		}
+	}
+	n := 0
+	for i := 0; i < len(state.Subagents); i++ {
+		if keep[i] {
 			state.Subagents[n] = state.Subagents[i]
 			n++
 		}
 	}
-	state.Subagents = state.Subagents[:n]
+	state.Subagents = orderSubagentTree(state.Subagents[:n])
+
+	index := make(map[string]int, len(state.Subagents))
+	for i := 0; i < len(state.Subagents); i++ {
+		index[state.Subagents[i].ID] = i
+	}
+	for _, id := range startedIDs {
+		started = append(started, state.Subagents[index[id]].Clone())
+	}
+	for _, id := range completedIDs {
+		completed = append(completed, state.Subagents[index[id]].Clone())
+	}
+	// Report in the order they happened.
+	sortSubagents(started, func(sa session.SubagentState) time.Time { return sa.StartedAt })
+	sortSubagents(completed, func(sa session.SubagentState) time.Time { return *sa.CompletedAt })
+	return started, completed
+}
+
+// sortedSubagentResults returns parsed's subagents in the order they first
+// appeared, so new ones are appended to the session deterministically.
+func sortedSubagentResults(parsed map[string]*SubagentParseResult) []*SubagentParseResult {
+	out := make([]*SubagentParseResult, 0, len(parsed))
+	for _, pr := range parsed {
+		out = append(out, pr)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].FirstTime.Equal(out[j].FirstTime) {
+			return out[i].FirstTime.Before(out[j].FirstTime)
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
+
+// linkSubagents sets each subagent's ParentID to the subagent whose Task
+// call spawned it, or "" when the session itself made the call.
+func linkSubagents(subs []session.SubagentState) {
+	owner := make(map[string]string)
+	for i := 0; i < len(subs); i++ {
+		for _, call := range subs[i].TaskCalls {
+			owner[call] = subs[i].ID
+		}
+	}
+	for i := 0; i < len(subs); i++ {
+		parent := owner[subs[i].ParentToolUseID]
+		if parent == subs[i].ID {
+			parent = ""
+		}
+		subs[i].ParentID = parent
+	}
+}
+
+// orderSubagentTree returns subs in tree order, each subagent followed by
+// the ones it spawned, with Depth set. Siblings keep their order in subs.
+// Subagents whose parent isn't in subs, or that sit on a cycle, are treated
+// as the session's own.
+func orderSubagentTree(subs []session.SubagentState) []session.SubagentState {
+	present := make(map[string]bool, len(subs))
+	for i := 0; i < len(subs); i++ {
+		present[subs[i].ID] = true
+	}
+	children := make(map[string][]int, len(subs))
+	var roots []int
+	for i := 0; i < len(subs); i++ {
+		if p := subs[i].ParentID; p != "" && present[p] {
+			children[p] = append(children[p], i)
+		} else {
+			roots = append(roots, i)
+		}
+	}
+
+	out := make([]session.SubagentState, 0, len(subs))
+	placed := make([]bool, len(subs))
+	var visit func(i, depth int)
+	visit = func(i, depth int) {
+		if placed[i] {
+			return
+		}
+		placed[i] = true
+		sub := subs[i]
+		sub.Depth = depth
+		out = append(out, sub)
+		for _, c := range children[sub.ID] {
+			visit(c, depth+1)
+		}
+	}
+	for _, r := range roots {
+		visit(r, 1)
+	}
+	for i := 0; i < len(subs); i++ {
+		if !placed[i] {
+			subs[i].ParentID = ""
+			visit(i, 1)
+		}
+	}
+	return out
+}
+
+// subagentBudget returns how many tokens one of state's subagents may use
+// before it is flagged: the configured cap, or else the configured share of
+// state's context window. It returns 0 when budgets are off or the window
+// isn't known yet.
+func subagentBudget(cfg *config.Config, state *session.SessionState) int {
+	if cfg.Monitor.SubagentTokenCap > 0 {
+		return cfg.Monitor.SubagentTokenCap
+	}
+	if cfg.Monitor.SubagentContextShare <= 0 || state.MaxContextTokens <= 0 {
+		return 0
+	}
+	return int(cfg.Monitor.SubagentContextShare * float64(state.MaxContextTokens))
+}
+
+// flagOverBudgetSubagents sets OverBudget on state's subagents that have
+// reached budget and returns copies of the ones newly flagged, so each is
+// warned about once. A budget of 0 flags nothing.
+func flagOverBudgetSubagents(state *session.SessionState, budget int) []session.SubagentState {
+	if budget <= 0 {
+		return nil
+	}
+	var flagged []session.SubagentState
+	for i := 0; i < len(state.Subagents); i++ {
+		sa := &state.Subagents[i]
+		if sa.OverBudget || sa.TokensUsed < budget {
+			continue
+		}
+		sa.OverBudget = true
+		flagged = append(flagged, sa.Clone())
+	}
+	return flagged
+}
+
+// sortSubagents orders subs by the time at returns, then by ID.
+func sortSubagents(subs []session.SubagentState, at func(session.SubagentState) time.Time) {
+	sort.Slice(subs, func(i, j int) bool {
+		ai, aj := at(subs[i]), at(subs[j])
+		if !ai.Equal(aj) {
+			return ai.Before(aj)
+		}
+		return subs[i].ID < subs[j].ID
+	})
 }
 
 // knownSlug returns the session's slug from the store, or "" if unknown.
@@ -1275,6 +2033,44 @@ func classifySubagentActivity(pr *SubagentParseResult) session.Activity {
 	}
 }
 
+// attributeSubProject counts the files touched towards their packages and
+// sets state's SubProject to the one worked in most.
+func (m *Monitor) attributeSubProject(ts *trackedSession, state *session.SessionState, touched map[string]int) {
+	if len(touched) == 0 || state.WorkingDir == "" {
+		return
+	}
+	if ts.subProjects == nil {
+		ts.subProjects = newSubProjectTracker(m.hostPath(state.WorkingDir))
+	}
+	for path, n := range touched {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(state.WorkingDir, path)
+		}
+		ts.subProjects.touch(m.hostPath(path), n)
+	}
+	state.SubProject = ts.subProjects.subProject()
+}
+
+// loadRepoOverrides returns the .agent-racer.yaml settings for dir, or
+// nil when there are none or the file is broken, which is logged.
+func loadRepoOverrides(dir string) *config.RepoOverrides {
+	o, err := config.LoadRepoOverrides(dir)
+	if err != nil {
+		slog.Warn("ignoring repository overrides", "error", err)
+		return nil
+	}
+	return o
+}
+
+// applyRepoOverrides replaces state's repository settings with those in o,
+// which may be nil.
+func applyRepoOverrides(state *session.SessionState, o *config.RepoOverrides) {
+	state.Tags, state.Privacy, state.TokenBudget, state.Muted = nil, session.PrivacyDefault, 0, nil
+	if o != nil {
+		o.Apply(state)
+	}
+}
+
 func nameFromPath(path string) string {
 	parts := splitPath(path)
 	// If the path is inside a .claude/worktrees/<slug>/ directory,
@@ -1339,111 +2135,33 @@ func workingDirFromFile(sessionFile string) string {
 	return DecodeProjectPath(projectDir)
 }
 
-// updatePositions computes racing positions for all non-terminal sessions,
-// sets Position/PositionDelta on the supplied updates, and broadcasts
-// overtake events when a session passes another.
-func (m *Monitor) updatePositions(updates []*session.SessionState) {
-	// Get current store state (these carry the previous positions).
-	allSessions := m.store.GetAll()
-
-	// Build a lookup of previous positions and names from the store.
-	prevPos := make(map[string]int, len(allSessions))
-	prevNames := make(map[string]string, len(allSessions))
-	for _, s := range allSessions {
-		if s.Position > 0 {
-			prevPos[s.ID] = s.Position
-		}
-		prevNames[s.ID] = s.Name
-	}
-
-	// Build a combined view: updates override store entries.
-	updateMap := make(map[string]*session.SessionState, len(updates))
-	for _, u := range updates {
-		updateMap[u.ID] = u
-	}
-	combined := make([]*session.SessionState, 0, len(allSessions)+len(updates))
-	seen := make(map[string]bool, len(allSessions))
-	for _, s := range allSessions {
-		seen[s.ID] = true
-		if u, ok := updateMap[s.ID]; ok {
-			combined = append(combined, u)
-		} else {
-			combined = append(combined, s)
-		}
-	}
-	for _, u := range updates {
-		if !seen[u.ID] {
-			combined = append(combined, u)
-		}
-	}
-
-	// Collect non-terminal sessions and sort by context utilization descending.
-	type utilEntry struct {
-		id   string
-		name string
-		util float64
-	}
-	racing := make([]utilEntry, 0, len(combined))
-	for _, s := range combined {
-		if !s.IsTerminal() {
-			racing = append(racing, utilEntry{s.ID, s.Name, s.ContextUtilization})
-		}
-	}
-	sort.Slice(racing, func(i, j int) bool {
-		return racing[i].util > racing[j].util
-	})
-
-	// Assign new positions.
-	newPos := make(map[string]int, len(racing))
-	for i := 0; i < len(racing); i++ {
-		newPos[racing[i].id] = i + 1
+// updatePositions assigns racing positions and laps, broadcasts overtakes,
+// completed laps and milestones, and returns updates extended with any
+// session whose position changed as a side effect.
+func (m *Monitor) updatePositions(updates []*session.SessionState, rules session.RaceRules) []*session.SessionState {
+	current := m.store.GetAll()
+	updates, overtakes, laps := session.Race(current, updates, rules)
+	for _, o := range overtakes {
+		m.broadcaster.BroadcastOvertake(o)
 	}
-
-	// Build reverse map of previous order: position -> ID.
-	prevOrder := make(map[int]string, len(allSessions))
-	for _, s := range allSessions {
-		if s.Position > 0 && !s.IsTerminal() {
-			prevOrder[s.Position] = s.ID
-		}
+	for _, l := range laps {
+		m.broadcaster.BroadcastLap(l)
 	}
-
-	// Set position/delta on updates and emit overtake events.
 	for _, u := range updates {
-		np, active := newPos[u.ID]
-		if !active {
-			u.Position = 0
-			u.PositionDelta = 0
-			continue
-		}
-		pp := prevPos[u.ID]
-		u.Position = np
-		if pp > 0 {
-			u.PositionDelta = pp - np // positive = moved up
-		}
-
-		// Overtake: moved up and the session now occupying our previous slot is a different car.
-		if u.PositionDelta <= 0 {
-			continue
-		}
-		overtakenID, ok := prevOrder[np]
-		if !ok || overtakenID == u.ID {
-			continue
-		}
-		overtakenName := prevNames[overtakenID]
-		if overtakenName == "" {
-			overtakenName = overtakenID
-		}
-		msg, err := ws.NewOvertakeMessage(ws.OvertakePayload{
-			OvertakerID:   u.ID,
-			OvertakerName: u.Name,
-			OvertakenID:   overtakenID,
-			OvertakenName: overtakenName,
-			NewPosition:   np,
-		})
-		if err != nil {
-			slog.Error("marshal overtake event failed", "error", err)
-			continue
+		if u.TokenBudget > 0 && !u.OverBudget && u.TokensBurned >= u.TokenBudget {
+			u.OverBudget = true
+			slog.Info("session over token budget", "session", u.ID, "name", u.Name, "budget", u.TokenBudget, "burned", u.TokensBurned)
+		}
+	}
+	milestones := session.ApplyMilestones(current, updates)
+	for i := 0; i < len(milestones); i++ {
+		m.broadcaster.BroadcastMilestone(milestones[i])
+		for _, u := range updates {
+			if u.ID == milestones[i].SessionID {
+				m.sendEvent(session.Event{Type: session.EventMilestone, State: u, Milestone: &milestones[i]})
+				break
+			}
 		}
-		m.broadcaster.BroadcastMessage(msg)
 	}
+	return updates
 }
@@ -1,14 +1,17 @@
 package monitor
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/agent-racer/backend/internal/config"
+	"github.com/agent-racer/backend/internal/crash"
 	"github.com/agent-racer/backend/internal/session"
 	"github.com/agent-racer/backend/internal/ws"
 )
@@ -65,12 +68,19 @@ func sourceUpdateFromResult(r *ParseResult) SourceUpdate {
 		MessageCount:      r.MessageCount,
 		ToolCalls:         r.ToolCalls,
 		LastTool:          r.LastTool,
+		MCPToolCalls:      r.MCPToolCalls,
 		Activity:          r.LastActivity,
 		LastTime:          r.LastTime,
 		WorkingDir:        r.WorkingDir,
 		Subagents:         r.Subagents,
 		CompactionCount:   r.CompactionCount,
 		LastAssistantText: r.LastAssistantText,
+		LastCommand:       r.LastCommand,
+		SlashCommands:     r.SlashCommands,
+		HookEvents:        r.HookEvents,
+		FirstPrompt:       r.FirstPrompt,
+		MessageText:       r.MessageText,
+		Cache:             r.Cache,
 	}
 	if r.LatestUsage != nil {
 		update.TokensIn = r.LatestUsage.TotalContext()
@@ -488,6 +498,56 @@ func TestPollWorkingDirUpdatedMidSession(t *testing.T) {
 	}
 }
 
+func TestPollAppliesRepoOverrides(t *testing.T) {
+	dir := t.TempDir()
+	repo := filepath.Join(dir, "payments")
+	secret := filepath.Join(dir, "secret")
+	for _, d := range []string{repo, secret} {
+		if err := os.MkdirAll(filepath.Join(d, ".git"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeRepoOverrides(t, repo, "name: billing\ntags: [backend]\ntoken_budget: 5000\n")
+	writeRepoOverrides(t, secret, "privacy: hidden\n")
+
+	now := time.Now().UTC()
+	ts := now.Format(time.RFC3339Nano)
+	shown := filepath.Join(dir, "shown.jsonl")
+	hidden := filepath.Join(dir, "hidden.jsonl")
+	writeJSONL(t, shown, jsonlLine("assistant", "shown", ts, "claude-opus-4-5-20251101", "", repo))
+	writeJSONL(t, hidden, jsonlLine("assistant", "hidden", ts, "claude-opus-4-5-20251101", "", secret))
+
+	src := &testSource{
+		handles: []SessionHandle{
+			newTestHandle("shown", shown, repo, now),
+			newTestHandle("hidden", hidden, secret, now),
+		},
+	}
+	m, store, _ := newPollTestMonitor(src, defaultTestConfig())
+
+	m.poll()
+	appendJSONL(t, hidden, jsonlLine("assistant", "hidden", now.Add(time.Second).Format(time.RFC3339Nano), "claude-opus-4-5-20251101", "", secret))
+	m.poll()
+
+	state, ok := store.Get("claude:shown")
+	if !ok {
+		t.Fatal("session should exist after poll")
+	}
+	if state.Name != "billing" || len(state.Tags) != 1 || state.Tags[0] != "backend" || state.TokenBudget != 5000 {
+		t.Errorf("overrides not applied: name %q, tags %v, budget %d", state.Name, state.Tags, state.TokenBudget)
+	}
+	if _, ok := store.Get("claude:hidden"); ok {
+		t.Error("session in a hidden repository was tracked")
+	}
+}
+
+func writeRepoOverrides(t *testing.T, dir, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, config.RepoFileName), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestPollStaleSessionMarkedLost(t *testing.T) {
 	dir := t.TempDir()
 	jsonlPath := filepath.Join(dir, "session-stale.jsonl")
@@ -576,6 +636,54 @@ func TestPollSessionResumesAfterTerminal(t *testing.T) {
 	}
 }
 
+func TestPollAdoptsLaunchedPlaceholder(t *testing.T) {
+	dir := t.TempDir()
+	jsonlPath := filepath.Join(dir, "session-launch.jsonl")
+
+	now := time.Now().UTC()
+	launchedAt := now.Add(-10 * time.Second)
+	cfg := defaultTestConfig()
+	src := &testSource{}
+	m, store, _ := newPollTestMonitor(src, cfg)
+	store.Update(&session.SessionState{
+		ID:         "claude:session-launch",
+		Name:       "opus",
+		Source:     "claude",
+		Activity:   session.Starting,
+		StartedAt:  launchedAt,
+		TmuxTarget: "work:2.0",
+		Launched:   true,
+	})
+	events := make(chan session.Event, 10)
+	m.SetStatsEvents(events)
+
+	writeJSONL(t, jsonlPath,
+		jsonlLine("user", "session-launch", now.Format(time.RFC3339Nano), "", "", "/tmp/launch")+
+			jsonlLine("assistant", "session-launch", now.Add(time.Second).Format(time.RFC3339Nano), "claude-opus-4-5-20251101", "", "/tmp/launch"))
+	src.handles = []SessionHandle{newTestHandle("session-launch", jsonlPath, "/tmp/launch", now)}
+
+	m.poll()
+
+	state, ok := store.Get("claude:session-launch")
+	if !ok {
+		t.Fatal("launched session missing after poll")
+	}
+	if state.Name != "launch" || state.WorkingDir != "/tmp/launch" || state.MessageCount != 2 {
+		t.Errorf("state not built from the log: name=%q dir=%q messages=%d", state.Name, state.WorkingDir, state.MessageCount)
+	}
+	if !state.Launched || state.TmuxTarget != "work:2.0" || !state.StartedAt.Equal(launchedAt) {
+		t.Errorf("placeholder fields lost: launched=%v tmux=%q started=%v", state.Launched, state.TmuxTarget, state.StartedAt)
+	}
+	select {
+	case ev := <-events:
+		if ev.Type != session.EventNew {
+			t.Errorf("first event = %v, want EventNew", ev.Type)
+		}
+	default:
+		t.Error("no event emitted for the adopted session")
+	}
+}
+
 func TestPollMultipleSources(t *testing.T) {
 	dir := t.TempDir()
 	path1 := filepath.Join(dir, "session-1.jsonl")
@@ -1242,6 +1350,53 @@ func TestPollSetSourcesRace(t *testing.T) {
 	wg.Wait()
 }
 
+// backgroundTestSource reports when the monitor starts and stops its Run.
+type backgroundTestSource struct {
+	testSource
+	started chan struct{}
+	stopped chan struct{}
+}
+
+func newBackgroundTestSource() *backgroundTestSource {
+	return &backgroundTestSource{started: make(chan struct{}), stopped: make(chan struct{})}
+}
+
+func (s *backgroundTestSource) Run(ctx context.Context) {
+	close(s.started)
+	<-ctx.Done()
+	close(s.stopped)
+}
+
+func TestStartRunsBackgroundSourcesUntilRemoved(t *testing.T) {
+	first, second := newBackgroundTestSource(), newBackgroundTestSource()
+	m, _, _ := newPollTestMonitorWithSources([]Source{first}, defaultTestConfig())
+
+	wait := func(ch chan struct{}, what string) {
+		t.Helper()
+		select {
+		case <-ch:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for %s", what)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		m.Start(ctx)
+		close(done)
+	}()
+	wait(first.started, "first source to start")
+
+	m.SetSources([]Source{second})
+	wait(first.stopped, "removed source to stop")
+	wait(second.started, "added source to start")
+
+	cancel()
+	wait(second.stopped, "source to stop with the monitor")
+	wait(done, "Start to return")
+}
+
 // TestPollSourceHealthSnapshotRace verifies that concurrent
 // sourceHealthSnapshot calls (from the broadcaster goroutine) do not
 // race with SetSources or poll.
@@ -1328,6 +1483,35 @@ func TestPollRecoversPanicInDiscover(t *testing.T) {
 	}
 }
 
+func TestPollPanicWritesCrashReport(t *testing.T) {
+	panicker := &panicSource{
+		name:            "panicky",
+		panicOnDiscover: true,
+	}
+
+	cfg := defaultTestConfig()
+	m, _, _ := newPollTestMonitorWithSources([]Source{panicker}, cfg)
+	dir := t.TempDir()
+	m.SetCrashReporter(crash.NewReporter(dir, "test"))
+
+	m.poll()
+
+	reports, err := crash.List(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reports) != 1 {
+		t.Fatalf("got %d crash reports, want 1", len(reports))
+	}
+	data, err := os.ReadFile(reports[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "component: poll/panicky") || !strings.Contains(string(data), "panicSource") {
+		t.Errorf("crash report missing component or stack:\n%s", data)
+	}
+}
+
 func TestPollRecoversPanicInParse(t *testing.T) {
 	dir := t.TempDir()
 	jsonlPath := filepath.Join(dir, "session-panic.jsonl")
@@ -2240,3 +2424,179 @@ func TestPollSubagentMessageCountStableOnNoData(t *testing.T) {
 		t.Errorf("subagent MessageCount after no-data poll = %d, want 1 (unchanged)", state.Subagents[0].MessageCount)
 	}
 }
+
+func TestPollTopicRequiresShowTopics(t *testing.T) {
+	dir := t.TempDir()
+	jsonlPath := filepath.Join(dir, "session-topic.jsonl")
+
+	now := time.Now().UTC()
+	ts := now.Format(time.RFC3339Nano)
+	writeJSONL(t, jsonlPath,
+		`{"type":"user","message":{"role":"user","content":"please fix the flaky websocket test"},"sessionId":"session-topic","cwd":"/home/user/project","timestamp":"`+ts+`"}`+"\n")
+
+	for _, show := range []bool{false, true} {
+		src := &testSource{
+			handles: []SessionHandle{newTestHandle("session-topic", jsonlPath, "/home/user/project", now)},
+		}
+		cfg := defaultTestConfig()
+		cfg.Privacy.ShowTopics = show
+		m, store, _ := newPollTestMonitor(src, cfg)
+
+		m.poll()
+
+		state, ok := store.Get("claude:session-topic")
+		if !ok {
+			t.Fatal("session should exist in store after first poll")
+		}
+		want := ""
+		if show {
+			want = "fix the flaky websocket test"
+		}
+		if state.Topic != want {
+			t.Errorf("show_topics=%v: Topic = %q, want %q", show, state.Topic, want)
+		}
+	}
+}
+
+func cacheUsageLine(sessionID, msgID string, ts time.Time, read, write, uncached int) string {
+	return fmt.Sprintf(
+		`{"type":"assistant","message":{"id":%q,"model":"claude-opus-4-6","role":"assistant","content":[{"type":"text","text":"ok"}],"usage":{"input_tokens":%d,"cache_creation_input_tokens":%d,"cache_read_input_tokens":%d,"output_tokens":10}},"sessionId":%q,"timestamp":%q}`,
+		msgID, uncached, write, read, sessionID, ts.Format(time.RFC3339Nano),
+	) + "\n"
+}
+
+func TestPollTracksCacheUsageAndCollapse(t *testing.T) {
+	dir := t.TempDir()
+	jsonlPath := filepath.Join(dir, "session-cache.jsonl")
+	now := time.Now().UTC()
+	writeJSONL(t, jsonlPath,
+		cacheUsageLine("session-cache", "msg_1", now, 0, 30000, 100)+
+			cacheUsageLine("session-cache", "msg_2", now, 150000, 1000, 100))
+
+	src := &testSource{handles: []SessionHandle{newTestHandle("session-cache", jsonlPath, "/repo", now)}}
+	m, store, _ := newPollTestMonitorWithSources([]Source{src}, defaultTestConfig())
+
+	m.poll()
+
+	state, ok := store.Get("claude:session-cache")
+	if !ok {
+		t.Fatal("session should exist after poll")
+	}
+	if state.CacheReadTokens != 150000 || state.CacheWriteTokens != 31000 || state.UncachedTokens != 200 {
+		t.Errorf("cache totals = %d/%d/%d", state.CacheReadTokens, state.CacheWriteTokens, state.UncachedTokens)
+	}
+	if state.CacheHitRatio < session.CacheWarmRatio || state.CacheSavedTokens <= 0 {
+		t.Errorf("hit ratio = %v, saved = %d; want a warm session that saved tokens", state.CacheHitRatio, state.CacheSavedTokens)
+	}
+	if !m.tracked["claude:session-cache"].cacheCollapsed.IsZero() {
+		t.Fatal("warm-up reported as a collapse")
+	}
+
+	f, err := os.OpenFile(jsonlPath, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.WriteString(cacheUsageLine("session-cache", "msg_3", now.Add(time.Second), 1000, 60000, 200))
+	_ = f.Close()
+
+	m.poll()
+
+	if m.tracked["claude:session-cache"].cacheCollapsed.IsZero() {
+		t.Error("cache collapse not reported")
+	}
+}
+
+func TestPollMarksPlanApprovalOnce(t *testing.T) {
+	dir := t.TempDir()
+	jsonlPath := filepath.Join(dir, "session-plan.jsonl")
+	now := time.Now().UTC()
+	writeJSONL(t, jsonlPath,
+		jsonlLine("user", "session-plan", now.Format(time.RFC3339Nano), "", "", "/repo")+
+			jsonlLine("assistant", "session-plan", now.Format(time.RFC3339Nano), "claude-opus-4-6", "ExitPlanMode", "/repo"))
+
+	src := &testSource{handles: []SessionHandle{newTestHandle("session-plan", jsonlPath, "/repo", now)}}
+	m, store, _ := newPollTestMonitor(src, defaultTestConfig())
+	var approvals []*session.SessionState
+	m.SetApprovalHook(func(s *session.SessionState) { approvals = append(approvals, s) })
+
+	m.poll()
+	m.poll()
+
+	state, _ := store.Get("claude:session-plan")
+	if state.Activity != session.NeedsApproval {
+		t.Errorf("activity = %v, want needs_approval", state.Activity)
+	}
+	if len(approvals) != 1 || approvals[0].CurrentTool != "ExitPlanMode" {
+		t.Fatalf("approval hook calls = %d, want 1 for ExitPlanMode", len(approvals))
+	}
+
+	appendJSONL(t, jsonlPath, jsonlLine("user", "session-plan", now.Add(time.Second).Format(time.RFC3339Nano), "", "", "/repo"))
+	m.poll()
+	if state, _ := store.Get("claude:session-plan"); state.Activity != session.Waiting {
+		t.Errorf("activity after the answer = %v, want waiting", state.Activity)
+	}
+}
+
+func TestPollTreatsStalledToolCallAsPermissionPrompt(t *testing.T) {
+	dir := t.TempDir()
+	jsonlPath := filepath.Join(dir, "session-perm.jsonl")
+	now := time.Now().UTC()
+	writeJSONL(t, jsonlPath, jsonlLine("assistant", "session-perm", now.Format(time.RFC3339Nano), "claude-opus-4-6", "Bash", "/repo"))
+
+	cfg := defaultTestConfig()
+	cfg.Monitor.ApprovalPromptAfter = 30 * time.Second
+	cfg.Monitor.ChurningCPUThreshold = 15
+	src := &testSource{handles: []SessionHandle{newTestHandle("session-perm", jsonlPath, "/repo", now)}}
+	m, store, _ := newPollTestMonitor(src, cfg)
+	cpu := 0.0
+	m.processPollInterval = 0
+	m.discoverProcessActivity = func(prevCPU map[int]cpuSample, _ time.Duration) ([]ProcessActivity, map[int]cpuSample) {
+		return []ProcessActivity{{PID: 42, CPU: cpu, WorkingDir: "/repo"}}, prevCPU
+	}
+
+	m.poll()
+	state, _ := store.Get("claude:session-perm")
+	if state.Activity != session.ToolUse {
+		t.Fatalf("activity = %v, want tool_use while the call is fresh", state.Activity)
+	}
+
+	// The command is still running in a busy process.
+	state.LastDataReceivedAt = now.Add(-time.Minute)
+	store.Update(state)
+	cpu = 80
+	m.poll()
+	if state, _ := store.Get("claude:session-perm"); state.Activity != session.ToolUse {
+		t.Errorf("activity = %v, want tool_use while the agent is busy", state.Activity)
+	}
+
+	cpu = 0
+	m.poll()
+	if state, _ := store.Get("claude:session-perm"); state.Activity != session.NeedsApproval {
+		t.Errorf("activity = %v, want needs_approval for a stalled call from an idle agent", state.Activity)
+	}
+}
+
+func TestPollCountsModelSwitches(t *testing.T) {
+	dir := t.TempDir()
+	jsonlPath := filepath.Join(dir, "session-switch.jsonl")
+	now := time.Now().UTC()
+	ts := now.Format(time.RFC3339Nano)
+	writeJSONL(t, jsonlPath, jsonlLine("assistant", "session-switch", ts, "claude-opus-4-6", "", "/repo"))
+
+	src := &testSource{handles: []SessionHandle{newTestHandle("session-switch", jsonlPath, "/repo", now)}}
+	m, store, _ := newPollTestMonitor(src, defaultTestConfig())
+	m.poll()
+
+	// Claude Code's placeholder replies are not a switch.
+	appendJSONL(t, jsonlPath, jsonlLine("assistant", "session-switch", ts, "<synthetic>", "", "/repo"))
+	m.poll()
+	for i := 0; i < 2; i++ {
+		appendJSONL(t, jsonlPath, jsonlLine("assistant", "session-switch", ts, "claude-sonnet-4-6", "", "/repo"))
+		m.poll()
+	}
+
+	state, _ := store.Get("claude:session-switch")
+	if state.Model != "claude-sonnet-4-6" || state.ModelSwitches != 1 {
+		t.Errorf("model = %q after %d switches, want claude-sonnet-4-6 after 1", state.Model, state.ModelSwitches)
+	}
+}
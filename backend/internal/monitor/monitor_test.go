@@ -2,6 +2,7 @@ package monitor
 
 import (
 	"fmt"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -666,6 +667,60 @@ func TestResolveTokensMessageCountStrategy(t *testing.T) {
 	}
 }
 
+func TestResolveTokensTokenizerStrategy(t *testing.T) {
+	m := newTestMonitor(config.TokenNormConfig{
+		Strategies:       map[string]string{"default": "tokenizer"},
+		TokensPerMessage: 2000,
+		Tokenizer:        "bytes",
+	})
+
+	state := &session.SessionState{Source: "custom", MessageCount: 2}
+	m.resolveTokens(m.cfg, state, SourceUpdate{MessageCount: 2, MessageText: []MessageText{{Text: "abcdefgh"}, {Text: "abcd"}}}, 200000)
+	if state.TokensUsed != 3 || !state.TokenEstimated {
+		t.Errorf("TokensUsed = %d, estimated = %v; want 3 counted tokens", state.TokensUsed, state.TokenEstimated)
+	}
+
+	// Counts accumulate across updates.
+	state.MessageCount++
+	m.resolveTokens(m.cfg, state, SourceUpdate{MessageCount: 1, MessageText: []MessageText{{Text: "abcdefghijkl"}}}, 200000)
+	if state.TokensUsed != 6 {
+		t.Errorf("TokensUsed = %d after second update, want 6", state.TokensUsed)
+	}
+
+	// A source without message text falls back to the per-message price.
+	state.MessageCount++
+	m.resolveTokens(m.cfg, state, SourceUpdate{MessageCount: 1}, 200000)
+	if state.TokensUsed != 2006 {
+		t.Errorf("TokensUsed = %d without text, want 2006", state.TokensUsed)
+	}
+}
+
+func TestAttributeTokensSplitsByRole(t *testing.T) {
+	cfg := &config.Config{TokenNorm: config.TokenNormConfig{Tokenizer: "bytes"}}
+	ts := &trackedSession{}
+	state := &session.SessionState{TokensUsed: 10000}
+
+	attributeTokens(cfg, ts, state, SourceUpdate{MessageText: []MessageText{
+		{Role: session.RoleUser, Text: strings.Repeat("u", 400)},
+		{Role: session.RoleToolResult, Text: strings.Repeat("r", 1200)},
+		{Role: session.RoleAssistant, Text: strings.Repeat("a", 400)},
+	}})
+	want := session.TokenBreakdown{User: 2000, Assistant: 2000, ToolResult: 6000}
+	if state.TokenBreakdown != want {
+		t.Errorf("TokenBreakdown = %+v, want %+v", state.TokenBreakdown, want)
+	}
+
+	// A compaction starts the split over from the summary.
+	state.TokensUsed = 3000
+	attributeTokens(cfg, ts, state, SourceUpdate{
+		CompactionCount: 1,
+		MessageText:     []MessageText{{Role: session.RoleSystem, Text: strings.Repeat("s", 400)}},
+	})
+	if want := (session.TokenBreakdown{System: 3000}); state.TokenBreakdown != want {
+		t.Errorf("TokenBreakdown after compaction = %+v, want %+v", state.TokenBreakdown, want)
+	}
+}
+
 func TestResolveTokensZeroMessages(t *testing.T) {
 	m := newTestMonitor(config.TokenNormConfig{
 		Strategies:       map[string]string{"default": "estimate"},
@@ -900,6 +955,59 @@ func TestCalculateBurnRate(t *testing.T) {
 	})
 }
 
+func TestForecastCompaction(t *testing.T) {
+	now := time.Now()
+	ts := &trackedSession{tokenSnapshots: []tokenSnapshot{
+		{tokens: 100000, timestamp: now.Add(-40 * time.Second)},
+		{tokens: 110000, timestamp: now.Add(-20 * time.Second)},
+		{tokens: 120000, timestamp: now},
+	}}
+
+	t.Run("forecasts_from_the_trend", func(t *testing.T) {
+		state := &session.SessionState{TokensUsed: 120000, MaxContextTokens: 200000, BurnRatePerMinute: 30000}
+		forecastCompaction(ts, state, 0.8, now)
+		// 40000 tokens left at 500 tokens/s.
+		if state.SecondsToCompact != 80 || !state.CompactionETA.Equal(now.Add(80*time.Second)) {
+			t.Errorf("forecast = %ds at %v, want 80s", state.SecondsToCompact, state.CompactionETA)
+		}
+	})
+
+	t.Run("past_threshold_clears_forecast", func(t *testing.T) {
+		state := &session.SessionState{TokensUsed: 170000, MaxContextTokens: 200000, BurnRatePerMinute: 30000,
+			SecondsToCompact: 5, CompactionETA: now}
+		forecastCompaction(ts, state, 0.8, now)
+		if state.SecondsToCompact != 0 || !state.CompactionETA.IsZero() {
+			t.Errorf("forecast = %ds at %v, want none", state.SecondsToCompact, state.CompactionETA)
+		}
+	})
+
+	t.Run("idle_session_has_no_forecast", func(t *testing.T) {
+		state := &session.SessionState{TokensUsed: 120000, MaxContextTokens: 200000}
+		forecastCompaction(ts, state, 0.8, now)
+		if state.SecondsToCompact != 0 {
+			t.Errorf("SecondsToCompact = %d, want 0 without a burn rate", state.SecondsToCompact)
+		}
+	})
+}
+
+func TestTokenTrendIgnoresOneSpike(t *testing.T) {
+	now := time.Now()
+	snaps := []tokenSnapshot{
+		{tokens: 1000, timestamp: now},
+		{tokens: 2000, timestamp: now.Add(10 * time.Second)},
+		{tokens: 3000, timestamp: now.Add(20 * time.Second)},
+		{tokens: 4000, timestamp: now.Add(30 * time.Second)},
+		{tokens: 25000, timestamp: now.Add(40 * time.Second)},
+	}
+	// The endpoints give 600 tokens/s; the fit also weighs the steady climb.
+	if got := tokenTrend(snaps); got < 300 || got > 550 {
+		t.Errorf("tokenTrend = %.0f tokens/s, want between the steady 100 and the endpoint 600", got)
+	}
+	if got := tokenTrend(snaps[:1]); got != 0 {
+		t.Errorf("tokenTrend of one snapshot = %v, want 0", got)
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Deadlock regression tests
 //
@@ -1044,6 +1152,72 @@ func TestMarkTerminal_StatsEventActiveCountIsValid(t *testing.T) {
 	}
 }
 
+// TestMarkTerminal_CallsTerminalHookOnce verifies the terminal hook sees
+// each session's first terminal transition and not repeats.
+func TestMarkTerminal_CallsTerminalHookOnce(t *testing.T) {
+	store := session.NewStore()
+	broadcaster := ws.NewBroadcaster(store, 100*time.Millisecond, 5*time.Second, 0)
+	m := &Monitor{
+		cfg: &config.Config{
+			Monitor: config.MonitorConfig{
+				CompletionRemoveAfter: -1,
+			},
+		},
+		store:          store,
+		broadcaster:    broadcaster,
+		tracked:        make(map[string]*trackedSession),
+		pendingRemoval: make(map[string]time.Time),
+		removedKeys:    make(map[string]bool),
+	}
+	var ended []*session.SessionState
+	m.SetTerminalHook(func(s *session.SessionState) { ended = append(ended, s) })
+
+	store.Update(&session.SessionState{ID: "claude:target", Activity: session.ToolUse})
+	state, _ := store.Get("claude:target")
+	m.markTerminal(m.cfg, state, session.Complete, time.Now())
+	state, _ = store.Get("claude:target")
+	m.markTerminal(m.cfg, state, session.Lost, time.Now())
+
+	if len(ended) != 1 {
+		t.Fatalf("hook called %d times, want 1", len(ended))
+	}
+	if ended[0].ID != "claude:target" || ended[0].Activity != session.Complete {
+		t.Errorf("hook got %s %s, want claude:target complete", ended[0].ID, ended[0].Activity)
+	}
+}
+
+// TestMarkTerminal_AddsToBaseline verifies finished sessions join the
+// baseline live ones are ranked against, once each.
+func TestMarkTerminal_AddsToBaseline(t *testing.T) {
+	store := session.NewStore()
+	broadcaster := ws.NewBroadcaster(store, 100*time.Millisecond, 5*time.Second, 0)
+	m := &Monitor{
+		cfg: &config.Config{
+			Monitor: config.MonitorConfig{
+				CompletionRemoveAfter: -1,
+			},
+		},
+		store:          store,
+		broadcaster:    broadcaster,
+		tracked:        make(map[string]*trackedSession),
+		pendingRemoval: make(map[string]time.Time),
+		removedKeys:    make(map[string]bool),
+	}
+	history := session.NewBaseline(10)
+	m.SetBaseline(history)
+
+	start := time.Now().Add(-time.Hour)
+	store.Update(&session.SessionState{ID: "claude:target", Activity: session.ToolUse, StartedAt: start, LastActivityAt: time.Now()})
+	state, _ := store.Get("claude:target")
+	m.markTerminal(m.cfg, state, session.Complete, time.Now())
+	state, _ = store.Get("claude:target")
+	m.markTerminal(m.cfg, state, session.Lost, time.Now())
+
+	if history.Len() != 1 {
+		t.Errorf("baseline holds %d sessions, want 1", history.Len())
+	}
+}
+
 // TestMarkTerminal_NoStatsEventsDoesNotDeadlock verifies that markTerminal()
 // works correctly when statsEvents is nil (the non-stats path). This is the
 // baseline: even without stats, the store must be accessible afterward.
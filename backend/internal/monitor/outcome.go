@@ -0,0 +1,146 @@
+package monitor
+
+import (
+	"context"
+	"hash/fnv"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/agent-racer/backend/internal/session"
+)
+
+// outcomeBuffer is how many resolved outcomes can wait for the poll loop.
+const outcomeBuffer = 64
+
+// outcomeResult is the outcome of a completed session, worked out off the
+// poll loop, with the session as it was when it completed.
+type outcomeResult struct {
+	state *session.SessionState
+	kind  session.OutcomeKind
+}
+
+// repoBaseline is the state of a session's git repository when the
+// session was first seen, compared against the repository at session end
+// to classify the outcome.
+type repoBaseline struct {
+	ok   bool
+	head string // commit hash; empty in a repository with no commits yet
+	tree uint64 // fingerprint of uncommitted changes
+}
+
+// captureBaseline records HEAD and a fingerprint of the working tree for
+// dir. The zero value is returned when dir is not a git repository.
+func captureBaseline(dir string) repoBaseline {
+	if dir == "" {
+		return repoBaseline{}
+	}
+	tree, ok := treeFingerprint(dir)
+	if !ok {
+		return repoBaseline{}
+	}
+	head, _ := runGit(dir, "rev-parse", "--verify", "--quiet", "HEAD")
+	return repoBaseline{ok: true, head: head, tree: tree}
+}
+
+// moveBaseline follows the session to dir. The baseline from the start of
+// the session is kept while dir is in the same repository, so commits made
+// before the agent changed directory still count; a new one is taken only
+// in a different repository.
+func (ts *trackedSession) moveBaseline(dir string) {
+	root := repoRoot(dir)
+	if ts.baseline.ok && root == ts.baselineRoot {
+		return
+	}
+	ts.baseline = captureBaseline(dir)
+	ts.baselineRoot = root
+}
+
+// sessionOutcome classifies how a session ended. Errored sessions are
+// reported as such regardless of the repository; completed sessions are
+// split by whether they produced new commits, left uncommitted changes,
+// or changed nothing. Lost sessions and sessions without a baseline have
+// no outcome.
+func sessionOutcome(activity session.Activity, dir string, base repoBaseline) session.OutcomeKind {
+	switch activity {
+	case session.Errored:
+		return session.OutcomeErrored
+	case session.Complete:
+	default:
+		return ""
+	}
+	if !base.ok || dir == "" {
+		return ""
+	}
+	return repoOutcome(dir, base)
+}
+
+// repoOutcome compares the repository in dir with base: new commits, then
+// uncommitted changes, then nothing changed.
+func repoOutcome(dir string, base repoBaseline) session.OutcomeKind {
+	if newCommits(dir, base.head) {
+		return session.OutcomeCommitted
+	}
+	tree, ok := treeFingerprint(dir)
+	if !ok {
+		return ""
+	}
+	if tree != base.tree {
+		return session.OutcomeUncommitted
+	}
+	return session.OutcomeNoChanges
+}
+
+// newCommits reports whether HEAD in dir has commits that are not
+// reachable from base. Switching to another existing branch is not
+// counted unless that branch is ahead of base.
+func newCommits(dir, base string) bool {
+	head, err := runGit(dir, "rev-parse", "--verify", "--quiet", "HEAD")
+	if err != nil || head == "" || head == base {
+		return false
+	}
+	if base == "" {
+		return true // first commit in a fresh repository
+	}
+	out, err := runGit(dir, "rev-list", "--count", base+"..HEAD")
+	if err != nil {
+		return false
+	}
+	n, err := strconv.Atoi(out)
+	return err == nil && n > 0
+}
+
+// treeFingerprint hashes the porcelain status and per-file line counts of
+// uncommitted changes. The numstat output catches further edits to files
+// that were already modified, which the status alone would not.
+func treeFingerprint(dir string) (uint64, bool) {
+	status, err := runGit(dir, "status", "--porcelain")
+	if err != nil {
+		return 0, false
+	}
+	// Fails in a repository without commits; status alone suffices there.
+	numstat, _ := runGit(dir, "diff", "HEAD", "--numstat")
+	h := fnv.New64a()
+	h.Write([]byte(status))
+	h.Write([]byte{0})
+	h.Write([]byte(numstat))
+	return h.Sum64(), true
+}
+
+// runGit runs git with args in dir and returns its trimmed stdout.
+func runGit(dir string, args ...string) (string, error) {
+	gitPath, err := exec.LookPath("git")
+	if err != nil {
+		return "", err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 1500*time.Millisecond)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, gitPath, append([]string{"-C", dir}, args...)...)
+	out, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
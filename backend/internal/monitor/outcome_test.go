@@ -0,0 +1,198 @@
+package monitor
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agent-racer/backend/internal/config"
+	"github.com/agent-racer/backend/internal/session"
+)
+
+func TestSessionOutcome(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	dir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=t", "GIT_AUTHOR_EMAIL=t@example.com",
+			"GIT_COMMITTER_NAME=t", "GIT_COMMITTER_EMAIL=t@example.com")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	git("init", "-q", "-b", "main")
+	write("a.txt", "one\n")
+	git("add", "a.txt")
+	git("commit", "-q", "-m", "init")
+	write("a.txt", "one\ntwo\n") // already dirty before the session starts
+
+	base := captureBaseline(dir)
+	if !base.ok {
+		t.Fatal("captureBaseline failed in a git repository")
+	}
+	if got := sessionOutcome(session.Complete, dir, base); got != session.OutcomeNoChanges {
+		t.Errorf("untouched repo = %q, want %q", got, session.OutcomeNoChanges)
+	}
+	if got := sessionOutcome(session.Errored, dir, base); got != session.OutcomeErrored {
+		t.Errorf("errored = %q, want %q", got, session.OutcomeErrored)
+	}
+	if got := sessionOutcome(session.Lost, dir, base); got != "" {
+		t.Errorf("lost = %q, want empty", got)
+	}
+
+	write("a.txt", "one\ntwo\nthree\n")
+	if got := sessionOutcome(session.Complete, dir, base); got != session.OutcomeUncommitted {
+		t.Errorf("further edit to dirty file = %q, want %q", got, session.OutcomeUncommitted)
+	}
+
+	git("commit", "-q", "-am", "work")
+	if got := sessionOutcome(session.Complete, dir, base); got != session.OutcomeCommitted {
+		t.Errorf("after commit = %q, want %q", got, session.OutcomeCommitted)
+	}
+}
+
+func TestSessionOutcomeWithoutRepository(t *testing.T) {
+	dir := t.TempDir()
+	base := captureBaseline(dir)
+	if base.ok {
+		t.Fatal("captureBaseline succeeded outside a git repository")
+	}
+	if got := sessionOutcome(session.Complete, dir, base); got != "" {
+		t.Errorf("outcome outside repo = %q, want empty", got)
+	}
+}
+
+// testGit runs git in dir with a fixed identity, skipping the test when git
+// is not installed.
+func testGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=t", "GIT_AUTHOR_EMAIL=t@example.com",
+		"GIT_COMMITTER_NAME=t", "GIT_COMMITTER_EMAIL=t@example.com")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+}
+
+// newTestRepo returns a git repository with one commit and a subdirectory.
+func newTestRepo(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	testGit(t, dir, "init", "-q", "-b", "main")
+	if err := os.MkdirAll(filepath.Join(dir, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "sub", "a.txt"), []byte("one\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	testGit(t, dir, "add", ".")
+	testGit(t, dir, "commit", "-q", "-m", "init")
+	return dir
+}
+
+func TestMoveBaselineKeepsBaselineWithinRepository(t *testing.T) {
+	dir := newTestRepo(t)
+	ts := &trackedSession{baseline: captureBaseline(dir), baselineRoot: repoRoot(dir)}
+
+	// Commit, then move into a subdirectory of the same repository.
+	if err := os.WriteFile(filepath.Join(dir, "b.txt"), []byte("two\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	testGit(t, dir, "add", "b.txt")
+	testGit(t, dir, "commit", "-q", "-m", "work")
+	ts.moveBaseline(filepath.Join(dir, "sub"))
+	if got := sessionOutcome(session.Complete, filepath.Join(dir, "sub"), ts.baseline); got != session.OutcomeCommitted {
+		t.Errorf("outcome after moving within the repo = %q, want %q", got, session.OutcomeCommitted)
+	}
+
+	// Another repository gets a baseline of its own.
+	other := newTestRepo(t)
+	ts.moveBaseline(other)
+	if ts.baselineRoot != repoRoot(other) {
+		t.Errorf("baseline root = %q, want %q", ts.baselineRoot, other)
+	}
+	if got := sessionOutcome(session.Complete, other, ts.baseline); got != session.OutcomeNoChanges {
+		t.Errorf("outcome in the new repo = %q, want %q", got, session.OutcomeNoChanges)
+	}
+}
+
+func TestResolveOutcome_GivesUpAfterStop(t *testing.T) {
+	dir := newTestRepo(t)
+	m := newTestMonitorWithStore(config.MonitorConfig{})
+	m.outcomes = make(chan outcomeResult) // nobody reads it any more
+	ctx, cancel := context.WithCancel(context.Background())
+	m.runCtx = ctx
+	cancel()
+
+	m.resolveOutcome(&session.SessionState{ID: "claude:s1"}, dir, captureBaseline(dir))
+	deadline := time.Now().Add(10 * time.Second)
+	for resolvingGoroutines() > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("outcome goroutine still blocked after the monitor stopped")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// resolvingGoroutines counts the goroutines resolveOutcome started that
+// have not returned.
+func resolvingGoroutines() int {
+	buf := make([]byte, 1<<20)
+	return strings.Count(string(buf[:runtime.Stack(buf, true)]), "(*Monitor).resolveOutcome.func")
+}
+
+func TestMarkTerminal_ResolvesOutcomeOffPollLoop(t *testing.T) {
+	dir := newTestRepo(t)
+	m := newTestMonitorWithStore(config.MonitorConfig{CompletionRemoveAfter: -1})
+	m.outcomes = make(chan outcomeResult, outcomeBuffer)
+	var ended []*session.SessionState
+	m.SetTerminalHook(func(s *session.SessionState) { ended = append(ended, s) })
+
+	m.store.Update(&session.SessionState{ID: "claude:s1", Activity: session.ToolUse, WorkingDir: dir})
+	m.tracked["claude:s1"] = &trackedSession{baseline: captureBaseline(dir), baselineRoot: repoRoot(dir)}
+	if err := os.WriteFile(filepath.Join(dir, "sub", "a.txt"), []byte("changed\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	state, _ := m.store.Get("claude:s1")
+	m.markTerminal(m.cfg, state, session.Complete, time.Now())
+	if len(ended) != 0 {
+		t.Fatal("terminal hook ran before the outcome was known")
+	}
+	if st, _ := m.store.Get("claude:s1"); st.Activity != session.Complete {
+		t.Errorf("activity = %s, want the completion stored straight away", st.Activity)
+	}
+
+	select {
+	case r := <-m.outcomes:
+		m.applyOutcome(r)
+	case <-time.After(10 * time.Second):
+		t.Fatal("outcome never resolved")
+	}
+	if len(ended) != 1 || ended[0].Outcome != session.OutcomeUncommitted {
+		t.Fatalf("terminal hook got %v, want one call with outcome %q", ended, session.OutcomeUncommitted)
+	}
+	if st, _ := m.store.Get("claude:s1"); st.Outcome != session.OutcomeUncommitted {
+		t.Errorf("stored outcome = %q, want %q", st.Outcome, session.OutcomeUncommitted)
+	}
+}
@@ -125,6 +125,10 @@ func readWSMessage(t *testing.T, conn *websocket.Conn, deadline time.Duration) w
 func readDelta(t *testing.T, conn *websocket.Conn) (ws.WSMessage, ws.DeltaPayload) {
 	t.Helper()
 	msg := readWSMessage(t, conn, 2*time.Second)
+	// Presence arrives whenever a client connects; it is not pipeline output.
+	for msg.Type == ws.MsgPresence {
+		msg = readWSMessage(t, conn, 2*time.Second)
+	}
 	if msg.Type != ws.MsgDelta {
 		t.Fatalf("expected %s, got %s", ws.MsgDelta, msg.Type)
 	}
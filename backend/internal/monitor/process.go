@@ -24,6 +24,9 @@ type ProcessActivity struct {
 	CPU        float64 // percent since last sample
 	TCPConns   int     // ESTABLISHED TCP connections
 	WorkingDir string
+	// HostDir is where WorkingDir is on the host when the process runs in
+	// a container with its project bind-mounted. Empty otherwise.
+	HostDir string
 }
 
 // IsChurning reports whether this process shows signs of active work:
@@ -121,6 +124,7 @@ func DiscoverProcessActivity(prevCPU map[int]cpuSample, elapsed time.Duration) (
 			CPU:        cpuPct,
 			TCPConns:   tcpConns,
 			WorkingDir: cwd,
+			HostDir:    containerHostDir(pid, cwd),
 		})
 	}
 
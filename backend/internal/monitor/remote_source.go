@@ -0,0 +1,240 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// remoteMaxBody caps how much of a sessions response is read.
+const remoteMaxBody = 8 << 20
+
+// RemoteSource implements Source for agents running on machines that don't
+// share a filesystem with the server. It polls an HTTP endpoint that lists
+// sessions in the same shape as GET /api/sessions -- another agent-racer
+// server, or a proxy in front of a team's usage API -- and maps each entry
+// into a SourceUpdate. The endpoint is fetched in Run, off the poll loop;
+// Discover and Parse read what the last fetch returned.
+//
+// The endpoint reports absolute counts; the source remembers what it last
+// reported for each session and hands the monitor deltas, like GeminiSource
+// does for its rewritten files.
+type RemoteSource struct {
+	url            string
+	apiKey         string
+	interval       time.Duration
+	discoverWindow time.Duration
+	client         *http.Client
+	now            func() time.Time
+
+	mu sync.Mutex
+	// fetchedAt is when sessions was last refreshed successfully; a failed
+	// fetch within three intervals of it keeps serving the cached list.
+	fetchedAt time.Time
+	err       error // from the last fetch
+	sessions  map[string]remoteSession
+
+	// reported holds the snapshot each session was last reported at.
+	reported map[string]remoteSession
+}
+
+// remoteSession is the subset of a SessionState the remote source reads.
+// Field names match the wire protocol, so a peer's /api/sessions works as
+// an endpoint unchanged.
+type remoteSession struct {
+	ID               string    `json:"id"`
+	Model            string    `json:"model"`
+	Activity         string    `json:"activity"`
+	TokensUsed       int       `json:"tokensUsed"`
+	MaxContextTokens int       `json:"maxContextTokens"`
+	MessageCount     int       `json:"messageCount"`
+	ToolCallCount    int       `json:"toolCallCount"`
+	CurrentTool      string    `json:"currentTool"`
+	WorkingDir       string    `json:"workingDir"`
+	Branch           string    `json:"branch"`
+	StartedAt        time.Time `json:"startedAt"`
+	LastActivityAt   time.Time `json:"lastActivityAt"`
+}
+
+// NewRemoteSource returns a source that fetches url every interval, sending
+// apiKey as a bearer token when it is non-empty. Sessions idle for longer
+// than discoverWindow are left out.
+func NewRemoteSource(url, apiKey string, interval, discoverWindow time.Duration) *RemoteSource {
+	return &RemoteSource{
+		url:            url,
+		apiKey:         apiKey,
+		interval:       interval,
+		discoverWindow: discoverWindow,
+		client:         &http.Client{Timeout: 10 * time.Second},
+		now:            time.Now,
+		sessions:       make(map[string]remoteSession),
+		reported:       make(map[string]remoteSession),
+	}
+}
+
+func (r *RemoteSource) Name() string { return "remote" }
+
+// Run fetches the endpoint every interval until ctx is done.
+func (r *RemoteSource) Run(ctx context.Context) {
+	runRefresher(ctx, r.interval, r.refresh)
+}
+
+// Discover returns a handle for each session in the last fetch that is
+// still running.
+func (r *RemoteSource) Discover() ([]SessionHandle, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	// Don't let a network blip mark every remote racer lost.
+	if r.err != nil && (r.fetchedAt.IsZero() || now.Sub(r.fetchedAt) > 3*r.interval) {
+		return nil, r.err
+	}
+
+	cutoff := now.Add(-r.discoverWindow)
+	var handles []SessionHandle
+	for id, s := range r.sessions {
+		if isRemoteTerminal(s.Activity) || s.LastActivityAt.Before(cutoff) {
+			continue
+		}
+		handles = append(handles, SessionHandle{
+			SessionID:  id,
+			WorkingDir: s.WorkingDir,
+			Source:     "remote",
+			StartedAt:  s.StartedAt,
+		})
+	}
+
+	for id := range r.reported {
+		if _, ok := r.sessions[id]; !ok {
+			delete(r.reported, id)
+		}
+	}
+
+	return handles, nil
+}
+
+// Parse reports what changed in a session since it was last reported. The
+// offset is the session's last activity time as UnixNano.
+func (r *RemoteSource) Parse(handle SessionHandle, offset int64) (SourceUpdate, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, ok := r.sessions[handle.SessionID]
+	if !ok {
+		return SourceUpdate{}, offset, nil
+	}
+	newOffset := max(offset, s.LastActivityAt.UnixNano())
+
+	prev, seen := r.reported[handle.SessionID]
+	if seen && prev == s {
+		return SourceUpdate{}, newOffset, nil
+	}
+	r.reported[handle.SessionID] = s
+
+	update := SourceUpdate{
+		Model:            s.Model,
+		TokensIn:         s.TokensUsed,
+		MessageCount:     max(s.MessageCount-prev.MessageCount, 0),
+		ToolCalls:        max(s.ToolCallCount-prev.ToolCallCount, 0),
+		LastTool:         s.CurrentTool,
+		LastTime:         s.LastActivityAt,
+		WorkingDir:       s.WorkingDir,
+		Branch:           s.Branch,
+		MaxContextTokens: s.MaxContextTokens,
+	}
+	switch s.Activity {
+	case "thinking", "tool_use", "waiting", "needs_approval":
+		update.Activity = s.Activity
+	}
+	return update, newOffset, nil
+}
+
+// refresh replaces the cached sessions with the endpoint's current list,
+// or records why it couldn't.
+func (r *RemoteSource) refresh(ctx context.Context) {
+	sessions, err := r.fetch(ctx)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.err = err
+	if err != nil {
+		if !r.fetchedAt.IsZero() {
+			slog.Warn("remote fetch failed, using cached sessions", "source", "remote", "error", err)
+		}
+		return
+	}
+	r.sessions = sessions
+	r.fetchedAt = r.now()
+}
+
+// fetch GETs the endpoint and returns its sessions by ID.
+func (r *RemoteSource) fetch(ctx context.Context) (map[string]remoteSession, error) {
+	ctx, cancel := context.WithTimeout(ctx, r.client.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if r.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+r.apiKey)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", r.url, resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, remoteMaxBody))
+	if err != nil {
+		return nil, err
+	}
+	list, err := decodeRemoteSessions(data)
+	if err != nil {
+		return nil, fmt.Errorf("GET %s: %w", r.url, err)
+	}
+
+	sessions := make(map[string]remoteSession, len(list))
+	for _, s := range list {
+		if s.ID != "" {
+			sessions[s.ID] = s
+		}
+	}
+	return sessions, nil
+}
+
+// decodeRemoteSessions accepts a bare array of sessions, as /api/sessions
+// returns, or an object wrapping one in a "sessions" field.
+func decodeRemoteSessions(data []byte) ([]remoteSession, error) {
+	var list []remoteSession
+	if err := json.Unmarshal(data, &list); err == nil {
+		return list, nil
+	}
+	var wrapper struct {
+		Sessions []remoteSession `json:"sessions"`
+	}
+	if err := json.Unmarshal(data, &wrapper); err != nil {
+		return nil, err
+	}
+	return wrapper.Sessions, nil
+}
+
+// isRemoteTerminal reports whether a remote activity means the session has
+// ended. Ended sessions are dropped from discovery, so they finish here
+// the same way a session whose log disappears does.
+func isRemoteTerminal(activity string) bool {
+	switch activity {
+	case "complete", "errored", "lost":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,187 @@
+package monitor
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// remoteServer serves body on every request and records the last
+// Authorization header.
+type remoteServer struct {
+	mu       sync.Mutex
+	body     string
+	status   int
+	requests int
+	auth     string
+}
+
+func (s *remoteServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests++
+	s.auth = r.Header.Get("Authorization")
+	if s.status != 0 {
+		w.WriteHeader(s.status)
+		return
+	}
+	_, _ = w.Write([]byte(s.body))
+}
+
+func (s *remoteServer) set(body string, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.body, s.status = body, status
+}
+
+func newTestRemoteSource(t *testing.T, rs *remoteServer, now *time.Time) *RemoteSource {
+	t.Helper()
+	srv := httptest.NewServer(rs)
+	t.Cleanup(srv.Close)
+	src := NewRemoteSource(srv.URL, "secret", 15*time.Second, 10*time.Minute)
+	src.now = func() time.Time { return *now }
+	return src
+}
+
+func TestRemoteSourceDiscoverAndDeltas(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	rs := &remoteServer{body: `[
+		{"id":"claude:a","model":"claude-opus-4-6","activity":"thinking","tokensUsed":5000,"messageCount":4,"toolCallCount":2,"workingDir":"/home/kim/app","lastActivityAt":"2026-03-01T11:59:50Z"},
+		{"id":"claude:old","activity":"thinking","lastActivityAt":"2026-03-01T10:00:00Z"},
+		{"id":"claude:done","activity":"complete","lastActivityAt":"2026-03-01T11:59:00Z"}
+	]`}
+	src := newTestRemoteSource(t, rs, &now)
+
+	src.refresh(context.Background())
+	handles, err := src.Discover()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(handles) != 1 || handles[0].SessionID != "claude:a" || handles[0].Source != "remote" || handles[0].WorkingDir != "/home/kim/app" {
+		t.Fatalf("handles = %+v, want only claude:a", handles)
+	}
+	if rs.auth != "Bearer secret" {
+		t.Errorf("Authorization = %q, want bearer key", rs.auth)
+	}
+
+	update, offset, err := src.Parse(handles[0], 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if update.MessageCount != 4 || update.ToolCalls != 2 || update.TokensIn != 5000 || update.Activity != "thinking" || update.Model != "claude-opus-4-6" {
+		t.Errorf("first update = %+v", update)
+	}
+	if want := time.Date(2026, 3, 1, 11, 59, 50, 0, time.UTC).UnixNano(); offset != want {
+		t.Errorf("offset = %d, want %d", offset, want)
+	}
+
+	// Unchanged snapshot: nothing to report.
+	update, offset2, _ := src.Parse(handles[0], offset)
+	if update.HasData() || offset2 != offset {
+		t.Errorf("unchanged update = %+v at %d, want none at %d", update, offset2, offset)
+	}
+
+	// Next fetch reports absolute counts; Parse hands back the difference.
+	rs.set(`{"sessions":[{"id":"claude:a","activity":"tool_use","currentTool":"Bash","tokensUsed":7000,"messageCount":6,"toolCallCount":5,"lastActivityAt":"2026-03-01T12:00:10Z"}]}`, 0)
+	now = now.Add(15 * time.Second)
+	src.refresh(context.Background())
+	if _, err := src.Discover(); err != nil {
+		t.Fatal(err)
+	}
+	update, _, _ = src.Parse(handles[0], offset)
+	if update.MessageCount != 2 || update.ToolCalls != 3 || update.LastTool != "Bash" || update.Activity != "tool_use" {
+		t.Errorf("second update = %+v, want 2 messages, 3 tool calls", update)
+	}
+}
+
+func TestRemoteSourceDiscoverServesLastFetch(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	rs := &remoteServer{body: `[{"id":"a","activity":"thinking","lastActivityAt":"2026-03-01T12:00:00Z"}]`}
+	src := newTestRemoteSource(t, rs, &now)
+
+	// Before the first fetch there is nothing to report, and no error.
+	if handles, err := src.Discover(); err != nil || len(handles) != 0 {
+		t.Fatalf("before fetch: handles = %+v, err = %v; want none", handles, err)
+	}
+
+	src.refresh(context.Background())
+	for i := 0; i < 3; i++ {
+		handles, err := src.Discover()
+		if err != nil || len(handles) != 1 {
+			t.Fatalf("handles = %+v, err = %v; want the fetched session", handles, err)
+		}
+	}
+	if rs.requests != 1 {
+		t.Errorf("requests = %d, want 1: Discover must not fetch", rs.requests)
+	}
+}
+
+func TestRemoteSourceRunFetchesUntilCancelled(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	rs := &remoteServer{body: `[]`}
+	src := newTestRemoteSource(t, rs, &now)
+	src.interval = 10 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		src.Run(ctx)
+		close(done)
+	}()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		rs.mu.Lock()
+		n := rs.requests
+		rs.mu.Unlock()
+		if n >= 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("requests = %d, want Run to fetch repeatedly", n)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after cancel")
+	}
+}
+
+func TestRemoteSourceKeepsCacheThroughBriefOutage(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	rs := &remoteServer{body: `[{"id":"a","activity":"thinking","lastActivityAt":"2026-03-01T12:00:00Z"}]`}
+	src := newTestRemoteSource(t, rs, &now)
+	src.refresh(context.Background())
+	if _, err := src.Discover(); err != nil {
+		t.Fatal(err)
+	}
+
+	rs.set("", http.StatusBadGateway)
+	now = now.Add(30 * time.Second)
+	src.refresh(context.Background())
+	handles, err := src.Discover()
+	if err != nil || len(handles) != 1 {
+		t.Fatalf("during outage: handles = %d, err = %v; want cached session", len(handles), err)
+	}
+
+	now = now.Add(30 * time.Second)
+	src.refresh(context.Background())
+	if _, err := src.Discover(); err == nil {
+		t.Error("want error once the cache is older than three intervals")
+	}
+}
+
+func TestRemoteSourceFirstFetchError(t *testing.T) {
+	now := time.Now()
+	rs := &remoteServer{body: `not json`}
+	src := newTestRemoteSource(t, rs, &now)
+	src.refresh(context.Background())
+	if _, err := src.Discover(); err == nil {
+		t.Error("want error for an undecodable first response")
+	}
+}
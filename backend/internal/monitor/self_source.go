@@ -0,0 +1,123 @@
+package monitor
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// SelfSourceName is the name of the source that puts the server itself on
+// the track.
+const SelfSourceName = "self"
+
+// selfSessionID is the ID of the one session SelfSource reports.
+const selfSessionID = "agent-racer"
+
+// processStart approximates when the server started, for uptime.
+var processStart = time.Now()
+
+// SelfStats is what the monitor tells SelfSource about the server.
+type SelfStats struct {
+	WSClients    int
+	PollDuration time.Duration
+}
+
+// SelfSource implements Source for the server itself, so a wall dashboard
+// shows whether the racer is healthy the same way it shows agents. Its one
+// session's context is the heap: TokensUsed is the peak heap in KiB and the
+// context window is the memory budget, so a leak drives the car toward the
+// finish line. The latest uptime, client count, poll duration and heap are
+// reported as the session's last message.
+//
+// The car waits when no dashboard is connected, and thinks otherwise.
+type SelfSource struct {
+	interval     time.Duration
+	memoryBudget int // MiB
+	stats        func() SelfStats
+	now          func() time.Time
+	readMem      func() (heap, peak uint64)
+
+	reportedAt time.Time
+}
+
+// NewSelfSource returns a source that reports the server every interval,
+// measuring its memory against memoryBudget MiB.
+func NewSelfSource(interval time.Duration, memoryBudget int) *SelfSource {
+	return &SelfSource{
+		interval:     interval,
+		memoryBudget: memoryBudget,
+		now:          time.Now,
+		readMem:      readHeap,
+	}
+}
+
+func (s *SelfSource) Name() string { return SelfSourceName }
+
+// setStats supplies the figures only the monitor knows. Without it the
+// source reports memory and uptime alone.
+func (s *SelfSource) setStats(stats func() SelfStats) {
+	s.stats = stats
+}
+
+func (s *SelfSource) Discover() ([]SessionHandle, error) {
+	return []SessionHandle{{
+		SessionID: selfSessionID,
+		Name:      selfSessionID,
+		Source:    SelfSourceName,
+		StartedAt: processStart,
+	}}, nil
+}
+
+// Parse reports the server at most once per interval. The offset counts
+// reports.
+func (s *SelfSource) Parse(handle SessionHandle, offset int64) (SourceUpdate, int64, error) {
+	now := s.now()
+	if !s.reportedAt.IsZero() && now.Sub(s.reportedAt) < s.interval {
+		return SourceUpdate{}, offset, nil
+	}
+	s.reportedAt = now
+
+	var stats SelfStats
+	if s.stats != nil {
+		stats = s.stats()
+	}
+	heap, peak := s.readMem()
+
+	activity := "thinking"
+	if stats.WSClients == 0 {
+		activity = "waiting"
+	}
+	return SourceUpdate{
+		Model:             selfSessionID,
+		TokensIn:          int(peak >> 10),
+		MaxContextTokens:  s.memoryBudget << 10,
+		Activity:          activity,
+		LastTime:          now,
+		LastAssistantText: selfSummary(now.Sub(processStart), stats, heap),
+	}, offset + 1, nil
+}
+
+// selfSummary formats a report as one line, e.g.
+// "up 3h12m · 2 clients · poll 4ms · heap 18 MiB".
+func selfSummary(uptime time.Duration, stats SelfStats, heap uint64) string {
+	clients := "1 client"
+	if stats.WSClients != 1 {
+		clients = fmt.Sprintf("%d clients", stats.WSClients)
+	}
+	up := uptime.Truncate(time.Minute).String()
+	up = strings.TrimSuffix(up, "0s")
+	if uptime < time.Minute {
+		up = "<1m"
+	}
+	return fmt.Sprintf("up %s · %s · poll %s · heap %d MiB",
+		up, clients, stats.PollDuration.Round(time.Millisecond), heap>>20)
+}
+
+// readHeap returns the heap in use and the most the process has obtained
+// from the OS for it, in bytes. HeapSys only grows, which suits TokensUsed.
+func readHeap() (heap, peak uint64) {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	return ms.HeapAlloc, ms.HeapSys
+}
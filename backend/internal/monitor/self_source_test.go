@@ -0,0 +1,75 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/agent-racer/backend/internal/session"
+)
+
+func TestSelfSourceReportsOncePerInterval(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	src := NewSelfSource(5*time.Second, 256)
+	src.now = func() time.Time { return now }
+	src.readMem = func() (uint64, uint64) { return 40 << 20, 64 << 20 }
+	src.setStats(func() SelfStats { return SelfStats{WSClients: 2, PollDuration: 3 * time.Millisecond} })
+
+	handles, err := src.Discover()
+	if err != nil || len(handles) != 1 || handles[0].Name != "agent-racer" {
+		t.Fatalf("handles = %+v, err = %v", handles, err)
+	}
+
+	update, offset, err := src.Parse(handles[0], 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if update.TokensIn != 64<<10 || update.MaxContextTokens != 256<<10 || update.Activity != "thinking" || offset != 1 {
+		t.Errorf("update = %+v, offset = %d", update, offset)
+	}
+
+	now = now.Add(2 * time.Second)
+	if again, off, _ := src.Parse(handles[0], offset); again.HasData() || off != offset {
+		t.Errorf("reported again within the interval: %+v", again)
+	}
+
+	now = now.Add(3 * time.Second)
+	src.setStats(func() SelfStats { return SelfStats{} })
+	if update, _, _ := src.Parse(handles[0], offset); update.Activity != "waiting" {
+		t.Errorf("activity with no clients = %q, want waiting", update.Activity)
+	}
+}
+
+func TestSelfSummary(t *testing.T) {
+	got := selfSummary(3*time.Hour+12*time.Minute+40*time.Second, SelfStats{WSClients: 1, PollDuration: 4200 * time.Microsecond}, 18<<20)
+	if want := "up 3h12m · 1 client · poll 4ms · heap 18 MiB"; got != want {
+		t.Errorf("selfSummary = %q, want %q", got, want)
+	}
+}
+
+func TestPollTracksSelfWithoutStats(t *testing.T) {
+	cfg := defaultTestConfig()
+	cfg.TokenNorm.Strategies[SelfSourceName] = "usage"
+	src := NewSelfSource(time.Second, 128)
+	m, store, _ := newPollTestMonitorWithSources([]Source{src}, cfg)
+	events := make(chan session.Event, 4)
+	m.SetStatsEvents(events)
+
+	m.poll()
+
+	state, ok := store.Get("self:agent-racer")
+	if !ok {
+		t.Fatal("self session not tracked")
+	}
+	if state.Name != "agent-racer" || state.MaxContextTokens != 128<<10 || state.TokensUsed == 0 {
+		t.Errorf("state = %+v", state)
+	}
+	if state.Activity != session.Waiting {
+		t.Errorf("activity = %v, want waiting with no clients connected", state.Activity)
+	}
+	if len(events) != 0 {
+		t.Errorf("self session sent %d stats events, want none", len(events))
+	}
+	if m.LastPollDuration() <= 0 {
+		t.Error("poll duration not recorded")
+	}
+}
@@ -1,6 +1,11 @@
 package monitor
 
-import "time"
+import (
+	"context"
+	"time"
+
+	"github.com/agent-racer/backend/internal/session"
+)
 
 // Source defines the interface for an agent session provider (e.g. Claude,
 // Codex, Gemini). Each implementation knows how to discover active sessions
@@ -35,6 +40,49 @@ type Source interface {
 	Parse(handle SessionHandle, offset int64) (SourceUpdate, int64, error)
 }
 
+// HeartbeatSource is implemented by sources whose sessions check in
+// rather than write a log, such as ExternalSource. For their sessions the
+// monitor replaces the monitor.session_stale_after check with each
+// session's own heartbeat: one that misses it is marked lost.
+type HeartbeatSource interface {
+	Source
+
+	// Heartbeat returns when a session last checked in and how long it
+	// may go between check-ins. ok is false for sessions the source no
+	// longer knows; those fall back to the usual checks.
+	Heartbeat(sessionID string) (last time.Time, ttl time.Duration, ok bool)
+}
+
+// BackgroundSource is implemented by sources that fetch sessions over the
+// network, such as SSHSource. Their Discover and Parse serve what Run last
+// fetched, so a slow or unreachable host never holds up the poll loop and
+// the other sources in it. The monitor runs Run in its own goroutine for
+// as long as the source is in use; it is the one method that may run
+// concurrently with the others.
+type BackgroundSource interface {
+	Source
+
+	// Run fetches the source's sessions now and then periodically until
+	// ctx is done.
+	Run(ctx context.Context)
+}
+
+// runRefresher calls refresh right away and then every interval until ctx
+// is done.
+func runRefresher(ctx context.Context, interval time.Duration, refresh func(context.Context)) {
+	refresh(ctx)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			refresh(ctx)
+		}
+	}
+}
+
 // SessionHandle identifies a single agent session discovered by a Source.
 // The monitor uses these as keys to track sessions and pass back into
 // Source.Parse on subsequent polls.
@@ -54,6 +102,10 @@ type SessionHandle struct {
 	// SourceUpdate.WorkingDir.
 	WorkingDir string
 
+	// Name is the session's display name, when the source knows a better
+	// one than the working directory's base name. Empty uses WorkingDir.
+	Name string
+
 	// Source is the lowercase name of the agent source that produced
 	// this handle (matches Source.Name()).
 	Source string
@@ -116,6 +168,32 @@ type SourceUpdate struct {
 	// chunk (e.g. "Read", "Bash"). Empty if no tool calls were found.
 	LastTool string
 
+	// MCPToolCalls counts new tool invocations in this chunk that were
+	// served by MCP servers, keyed by server name (e.g. "github" for
+	// "mcp__github__create_pr"). Values are deltas. Nil if none.
+	MCPToolCalls map[string]int
+
+	// ToolCounts counts new tool invocations in this chunk by tool name,
+	// for the per-session tool histogram. Values are deltas. Nil if none.
+	// This and the two fields below are only reported by sources that
+	// record tool arguments (currently Codex only).
+	ToolCounts map[string]int
+
+	// ShellCommands counts shell tool invocations in this chunk by the
+	// program they ran (e.g. "git", "go"), with wrappers such as
+	// "bash -lc" and leading env assignments stripped. Values are deltas.
+	ShellCommands map[string]int
+
+	// FilesPatched counts edits in this chunk by file path, as the agent
+	// named them. Values are deltas.
+	FilesPatched map[string]int
+
+	// FilesTouched counts tool calls in this chunk that read or edited a
+	// file, by path as the agent named it. Values are deltas. Sources
+	// that only report FilesPatched leave it nil; edits are then the
+	// only touches.
+	FilesTouched map[string]int
+
 	// Activity is a normalized activity classification for the most
 	// recent log entry: "thinking", "tool_use", "waiting", or empty
 	// if no entries were parsed.
@@ -156,6 +234,39 @@ type SourceUpdate struct {
 	// assistant in this chunk, truncated to a display-safe length.
 	// Empty means no text content was found.
 	LastAssistantText string
+
+	// LastCommand is the most recent slash command the user invoked in
+	// this chunk (e.g. "/compact", "/review"). Empty if none.
+	LastCommand string
+
+	// SlashCommands counts slash command invocations in this chunk,
+	// keyed by command. Values are deltas. Nil if none.
+	SlashCommands map[string]int
+
+	// HookEvents is the number of hook-related entries (e.g. stop hook
+	// summaries) found in this chunk. This is a delta.
+	HookEvents int
+
+	// FirstPrompt is the first human-typed user message found in this
+	// chunk, used to derive a topic label when topics are enabled. Empty
+	// if none. Only populated by sources that record user prompts
+	// (currently Claude only).
+	FirstPrompt string
+
+	// MessageText holds the text each message in this chunk adds to the
+	// context: prompts, replies, tool inputs and tool results. The
+	// "tokenizer" strategy counts it, and it feeds the session's
+	// TokenBreakdown. Nil when the source doesn't extract message text.
+	MessageText []MessageText
+
+	// Cache is prompt-cache usage summed over the API calls in this
+	// chunk. Zero when the source doesn't report cache usage.
+	Cache session.CacheUsage
+
+	// Ended is set when the source knows the session has finished, as
+	// "complete" or "errored", for sources with no SessionEnd hook (e.g.
+	// a Kubernetes pod that exited). Empty means still running.
+	Ended string
 }
 
 // HasData reports whether this update contains any meaningful data
@@ -168,6 +279,11 @@ func (u SourceUpdate) HasData() bool {
 		u.MessageCount > 0 ||
 		u.ToolCalls > 0 ||
 		u.LastTool != "" ||
+		len(u.MCPToolCalls) > 0 ||
+		len(u.ToolCounts) > 0 ||
+		len(u.ShellCommands) > 0 ||
+		len(u.FilesPatched) > 0 ||
+		len(u.FilesTouched) > 0 ||
 		u.Activity != "" ||
 		!u.LastTime.IsZero() ||
 		u.WorkingDir != "" ||
@@ -175,5 +291,18 @@ func (u SourceUpdate) HasData() bool {
 		u.MaxContextTokens > 0 ||
 		len(u.Subagents) > 0 ||
 		u.CompactionCount > 0 ||
-		u.LastAssistantText != ""
+		u.LastAssistantText != "" ||
+		u.LastCommand != "" ||
+		len(u.SlashCommands) > 0 ||
+		u.HookEvents > 0 ||
+		u.FirstPrompt != "" ||
+		u.Ended != ""
+}
+
+// MessageText is text a message added to the context, with the role it is
+// attributed to: one of session.RoleUser, RoleAssistant, RoleToolResult
+// or RoleSystem.
+type MessageText struct {
+	Role string
+	Text string
 }
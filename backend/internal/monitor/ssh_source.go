@@ -0,0 +1,304 @@
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/agent-racer/backend/internal/jsonl"
+)
+
+// sshCommandTimeout bounds a refresh: the listing and every transfer,
+// including connection setup.
+const sshCommandTimeout = 30 * time.Second
+
+// SSHSource implements Source for Claude Code sessions on another machine
+// reachable over SSH, such as a headless build server. It lists recent
+// transcripts with find(1) on the remote host, copies the bytes appended
+// since the last poll into a local mirror with tail(1), and parses the
+// mirror with the Claude parser. The remote commands run in Run, off the
+// poll loop; Discover returns what the last refresh mirrored.
+//
+// Commands go through the system ssh client so the user's ssh_config,
+// agent and known_hosts apply. A control master is kept open between
+// polls so each command reuses one connection.
+type SSHSource struct {
+	host           string
+	root           string
+	identityFile   string
+	interval       time.Duration
+	discoverWindow time.Duration
+	mirrorDir      string
+
+	// run executes a shell command on the remote host and returns its
+	// standard output. Replaced in tests.
+	run func(ctx context.Context, command string) ([]byte, error)
+
+	mu      sync.Mutex
+	handles []SessionHandle
+	err     error // from the last refresh
+}
+
+// NewSSHSource returns a source for the Claude projects directory root on
+// host, which may be anything ssh accepts as a destination ("user@host",
+// an ssh_config alias, "ssh://host:2222"). Transcripts are mirrored under
+// mirrorDir. identityFile may be empty to use ssh's defaults.
+func NewSSHSource(host, root, identityFile string, interval, discoverWindow time.Duration, mirrorDir string) *SSHSource {
+	s := &SSHSource{
+		host:           host,
+		root:           root,
+		identityFile:   identityFile,
+		interval:       interval,
+		discoverWindow: discoverWindow,
+		mirrorDir:      mirrorDir,
+	}
+	s.run = s.runSSH
+	return s
+}
+
+func (s *SSHSource) Name() string { return "ssh" }
+
+// Run refreshes the mirror every interval until ctx is done.
+func (s *SSHSource) Run(ctx context.Context) {
+	runRefresher(ctx, s.interval, s.refresh)
+}
+
+// Discover returns a handle for each recent remote transcript the last
+// refresh mirrored, or the error it failed with.
+func (s *SSHSource) Discover() ([]SessionHandle, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.handles, s.err
+}
+
+// refresh lists the remote transcripts and brings their mirrors up to
+// date for Discover to return.
+func (s *SSHSource) refresh(ctx context.Context) {
+	handles, err := s.mirror(ctx)
+	s.mu.Lock()
+	s.handles, s.err = handles, err
+	s.mu.Unlock()
+}
+
+// mirror fetches what the remote transcripts gained since the last
+// refresh and returns a handle for each.
+func (s *SSHSource) mirror(ctx context.Context) ([]SessionHandle, error) {
+	// The control master's socket lives in the mirror directory too.
+	if err := os.MkdirAll(s.mirrorDir, 0o700); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, sshCommandTimeout)
+	defer cancel()
+
+	files, err := s.listRemote(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	hostDir := filepath.Join(s.mirrorDir, sanitizeMirrorName(s.host))
+	active := make(map[string]bool, len(files))
+	handles := make([]SessionHandle, 0, len(files))
+	for _, f := range files {
+		local := filepath.Join(hostDir, filepath.Base(filepath.Dir(f.path)), filepath.Base(f.path))
+		if err := s.sync(ctx, f, local); err != nil {
+			slog.Warn("mirror failed", "source", "ssh", "host", s.host, "path", f.path, "error", err)
+			continue
+		}
+		active[local] = true
+
+		startedAt, _ := readFirstTimestamp(local)
+		handles = append(handles, SessionHandle{
+			SessionID: SessionIDFromPath(f.path),
+			LogPath:   local,
+			Source:    "ssh",
+			StartedAt: startedAt,
+		})
+	}
+	pruneMirror(hostDir, active)
+	return handles, nil
+}
+
+// Parse reads the local mirror exactly as ClaudeSource reads a local
+// transcript.
+func (s *SSHSource) Parse(handle SessionHandle, offset int64) (SourceUpdate, int64, error) {
+	return (&ClaudeSource{}).Parse(handle, offset)
+}
+
+// remoteFile is a transcript listed on the remote host.
+type remoteFile struct {
+	path string
+	size int64
+}
+
+// listRemote finds transcripts under root modified within the discover
+// window. wc -c prints the sizes so the mirror knows what to fetch.
+func (s *SSHSource) listRemote(ctx context.Context) ([]remoteFile, error) {
+	minutes := int(math.Ceil(s.discoverWindow.Minutes()))
+	cmd := fmt.Sprintf("find %s -mindepth 2 -maxdepth 2 -name '*.jsonl' -mmin -%d -exec wc -c {} +", remoteShellPath(s.root), minutes)
+	out, err := s.run(ctx, cmd)
+	if err != nil {
+		return nil, err
+	}
+	return parseWCOutput(out), nil
+}
+
+// parseWCOutput reads "size path" lines from wc -c, skipping the "total"
+// line it adds when given several files.
+func parseWCOutput(out []byte) []remoteFile {
+	var files []remoteFile
+	for _, line := range strings.Split(string(out), "\n") {
+		sizeField, path, ok := strings.Cut(strings.TrimSpace(line), " ")
+		if !ok {
+			continue
+		}
+		path = strings.TrimSpace(path)
+		size, err := strconv.ParseInt(sizeField, 10, 64)
+		if err != nil || !strings.HasSuffix(path, ".jsonl") {
+			continue
+		}
+		files = append(files, remoteFile{path: path, size: size})
+	}
+	return files
+}
+
+// sync brings the local mirror of f up to f.size. Transcripts only grow,
+// so normally just the new tail is fetched; a remote file shorter than
+// its mirror was replaced and is fetched again from the start.
+func (s *SSHSource) sync(ctx context.Context, f remoteFile, local string) error {
+	if f.size > jsonl.MaxFileSize {
+		return fmt.Errorf("file size %d exceeds max %d", f.size, jsonl.MaxFileSize)
+	}
+
+	var have int64
+	if info, err := os.Stat(local); err == nil {
+		have = info.Size()
+	}
+	if f.size == have {
+		return nil
+	}
+	if f.size < have {
+		have = 0
+	}
+
+	out, err := s.run(ctx, fmt.Sprintf("tail -c +%d %s", have+1, shellQuote(f.path)))
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(local), 0o700); err != nil {
+		return err
+	}
+	flags := os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	if have == 0 {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	file, err := os.OpenFile(local, flags, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(out); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// pruneMirror removes mirrored transcripts that are no longer listed, and
+// project directories left empty.
+func pruneMirror(hostDir string, active map[string]bool) {
+	projects, err := os.ReadDir(hostDir)
+	if err != nil {
+		return
+	}
+	for _, proj := range projects {
+		if !proj.IsDir() {
+			continue
+		}
+		dir := filepath.Join(hostDir, proj.Name())
+		files, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		kept := 0
+		for _, f := range files {
+			path := filepath.Join(dir, f.Name())
+			if active[path] {
+				kept++
+				continue
+			}
+			_ = os.Remove(path)
+		}
+		if kept == 0 {
+			_ = os.Remove(dir)
+		}
+	}
+}
+
+// runSSH runs command on the remote host with the system ssh client.
+func (s *SSHSource) runSSH(ctx context.Context, command string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "ssh", s.sshArgs(command)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("ssh %s: %w: %s", s.host, err, msg)
+		}
+		return nil, fmt.Errorf("ssh %s: %w", s.host, err)
+	}
+	return out, nil
+}
+
+// sshArgs builds the ssh argument list. BatchMode stops ssh from prompting
+// on a terminal the server doesn't have.
+func (s *SSHSource) sshArgs(command string) []string {
+	args := []string{
+		"-o", "BatchMode=yes",
+		"-o", "ConnectTimeout=10",
+		"-o", "ControlMaster=auto",
+		"-o", "ControlPath=" + filepath.Join(s.mirrorDir, "cm-%C"),
+		"-o", "ControlPersist=60s",
+	}
+	if s.identityFile != "" {
+		args = append(args, "-i", s.identityFile)
+	}
+	return append(args, "--", s.host, command)
+}
+
+// remoteShellPath quotes path for the remote shell, leaving a leading "~/"
+// for the remote $HOME to expand.
+func remoteShellPath(path string) string {
+	if path == "~" {
+		return `"$HOME"`
+	}
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		return `"$HOME"/` + shellQuote(rest)
+	}
+	return shellQuote(path)
+}
+
+// shellQuote single-quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// sanitizeMirrorName turns an ssh destination into a directory name.
+func sanitizeMirrorName(host string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_', r == '@':
+			return r
+		}
+		return '_'
+	}, host)
+}
@@ -0,0 +1,176 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newLocalSSHSource returns an SSHSource whose "remote" commands run in a
+// local shell, with root as the remote projects directory.
+func newLocalSSHSource(t *testing.T, root string) (*SSHSource, *[]string) {
+	t.Helper()
+	src := NewSSHSource("ci@build-01", root, "", 5*time.Second, 10*time.Minute, t.TempDir())
+	var commands []string
+	src.run = func(ctx context.Context, command string) ([]byte, error) {
+		commands = append(commands, command)
+		return exec.CommandContext(ctx, "sh", "-c", command).Output()
+	}
+	return src, &commands
+}
+
+func appendFile(t *testing.T, path, data string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSSHSourceMirrorsAppendedBytes(t *testing.T) {
+	root := t.TempDir()
+	proj := filepath.Join(root, "-srv-app")
+	if err := os.MkdirAll(proj, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	remote := filepath.Join(proj, "sess-1.jsonl")
+	first := `{"type":"user","sessionId":"sess-1","cwd":"/srv/app","timestamp":"2026-03-01T12:00:00Z","message":{"role":"user","content":"hi"}}` + "\n"
+	appendFile(t, remote, first)
+
+	src, commands := newLocalSSHSource(t, root)
+
+	src.refresh(context.Background())
+	handles, err := src.Discover()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(handles) != 1 || handles[0].SessionID != "sess-1" || handles[0].Source != "ssh" {
+		t.Fatalf("handles = %+v", handles)
+	}
+	update, offset, err := src.Parse(handles[0], 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if update.MessageCount != 1 || update.WorkingDir != "/srv/app" {
+		t.Errorf("first update = %+v", update)
+	}
+
+	// Discover serves the last refresh; only a refresh contacts the host.
+	appendFile(t, remote, `{"type":"assistant","timestamp":"2026-03-01T12:00:05Z","message":{"role":"assistant","model":"claude-opus-4-6","content":[{"type":"text","text":"hello"}]}}`+"\n")
+	n := len(*commands)
+	if _, err := src.Discover(); err != nil {
+		t.Fatal(err)
+	}
+	if len(*commands) != n {
+		t.Errorf("Discover ran %v", (*commands)[n:])
+	}
+
+	src.refresh(context.Background())
+	handles, err = src.Discover()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last, want := (*commands)[len(*commands)-1], fmt.Sprintf("tail -c +%d ", len(first)+1); !strings.HasPrefix(last, want) {
+		t.Errorf("fetch = %q, want a tail from the mirrored size", last)
+	}
+	update, _, err = src.Parse(handles[0], offset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if update.MessageCount != 1 || update.Model != "claude-opus-4-6" {
+		t.Errorf("appended update = %+v", update)
+	}
+
+	local, err := os.ReadFile(handles[0].LogPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := os.ReadFile(remote)
+	if string(local) != string(want) {
+		t.Errorf("mirror differs from remote:\n%s\nwant\n%s", local, want)
+	}
+}
+
+func TestSSHSourceRefetchesShrunkFileAndPrunes(t *testing.T) {
+	root := t.TempDir()
+	proj := filepath.Join(root, "-srv-app")
+	if err := os.MkdirAll(proj, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	remote := filepath.Join(proj, "sess-2.jsonl")
+	appendFile(t, remote, "{\"type\":\"user\"}\n{\"type\":\"user\"}\n")
+
+	src, _ := newLocalSSHSource(t, root)
+	src.refresh(context.Background())
+	handles, err := src.Discover()
+	if err != nil || len(handles) != 1 {
+		t.Fatalf("handles = %+v, err = %v", handles, err)
+	}
+	mirror := handles[0].LogPath
+
+	if err := os.WriteFile(remote, []byte("{\"type\":\"x\"}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	src.refresh(context.Background())
+	if _, err := src.Discover(); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.ReadFile(mirror); string(got) != "{\"type\":\"x\"}\n" {
+		t.Errorf("mirror after shrink = %q", got)
+	}
+
+	if err := os.Remove(remote); err != nil {
+		t.Fatal(err)
+	}
+	src.refresh(context.Background())
+	handles, err = src.Discover()
+	if err != nil || len(handles) != 0 {
+		t.Fatalf("handles = %+v, err = %v; want none", handles, err)
+	}
+	if _, err := os.Stat(filepath.Dir(mirror)); !os.IsNotExist(err) {
+		t.Errorf("mirror directory not pruned: %v", err)
+	}
+}
+
+func TestParseWCOutput(t *testing.T) {
+	out := []byte("   120 /home/ci/.claude/projects/-srv-app/a.jsonl\n  4000 /home/ci/.claude/projects/-srv-my app/b.jsonl\n  4120 total\n")
+	got := parseWCOutput(out)
+	want := []remoteFile{
+		{path: "/home/ci/.claude/projects/-srv-app/a.jsonl", size: 120},
+		{path: "/home/ci/.claude/projects/-srv-my app/b.jsonl", size: 4000},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseWCOutput = %+v, want %+v", got, want)
+	}
+}
+
+func TestSSHArgsAndQuoting(t *testing.T) {
+	src := NewSSHSource("ci@build-01", "~/.claude/projects", "~/.ssh/racer", time.Second, time.Minute, "/cache/ssh")
+	args := src.sshArgs("true")
+	tail := args[len(args)-5:]
+	if want := []string{"-i", "~/.ssh/racer", "--", "ci@build-01", "true"}; !reflect.DeepEqual(tail, want) {
+		t.Errorf("args end = %q, want %q", tail, want)
+	}
+
+	tests := map[string]string{
+		"~/.claude/projects": `"$HOME"/'.claude/projects'`,
+		"/srv/it's here":     `'/srv/it'\''s here'`,
+	}
+	for in, want := range tests {
+		if got := remoteShellPath(in); got != want {
+			t.Errorf("remoteShellPath(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/agent-racer/backend/internal/config"
 	"github.com/agent-racer/backend/internal/session"
 )
 
@@ -505,6 +506,48 @@ func TestMergeSubagentsCompletedSetsCompletedAt(t *testing.T) {
 	}
 }
 
+func TestMergeSubagentsReportsStartsAndCompletions(t *testing.T) {
+	ts := time.Date(2026, 2, 20, 16, 0, 0, 0, time.UTC)
+	state := &session.SessionState{ID: "sess-events"}
+
+	// A phantom with no messages hasn't started yet.
+	started, completed := mergeSubagents(state, map[string]*SubagentParseResult{
+		"toolu_a": {ID: "toolu_a", Slug: "a", FirstTime: ts, LastTime: ts},
+	})
+	if len(started) != 0 || len(completed) != 0 {
+		t.Fatalf("phantom: started %d, completed %d, want none", len(started), len(completed))
+	}
+
+	// toolu_a does its first work; toolu_b starts and finishes in one batch.
+	done := ts.Add(5 * time.Second)
+	started, completed = mergeSubagents(state, map[string]*SubagentParseResult{
+		"toolu_a": {ID: "toolu_a", MessageCount: 2, FirstTime: ts, LastTime: ts.Add(time.Second)},
+		"toolu_b": {ID: "toolu_b", MessageCount: 1, FirstTime: ts.Add(2 * time.Second), LastTime: done, Completed: true},
+	})
+	if len(started) != 2 || started[0].ID != "toolu_a" || started[1].ID != "toolu_b" {
+		t.Errorf("started = %+v, want toolu_a then toolu_b", started)
+	}
+	if len(completed) != 1 || completed[0].ID != "toolu_b" || !completed[0].CompletedAt.Equal(done) {
+		t.Errorf("completed = %+v, want toolu_b at %v", completed, done)
+	}
+
+	// More work from toolu_a, and toolu_b's final entries again, report
+	// nothing new; toolu_a finishing reports its completion once.
+	started, completed = mergeSubagents(state, map[string]*SubagentParseResult{
+		"toolu_a": {ID: "toolu_a", MessageCount: 1, LastTime: ts.Add(3 * time.Second)},
+		"toolu_b": {ID: "toolu_b", LastTime: done, Completed: true},
+	})
+	if len(started) != 0 || len(completed) != 0 {
+		t.Errorf("repeat: started %+v, completed %+v, want none", started, completed)
+	}
+	_, completed = mergeSubagents(state, map[string]*SubagentParseResult{
+		"toolu_a": {ID: "toolu_a", LastTime: ts.Add(9 * time.Second), Completed: true},
+	})
+	if len(completed) != 1 || completed[0].ID != "toolu_a" {
+		t.Errorf("completed = %+v, want toolu_a", completed)
+	}
+}
+
 func TestMergeSubagentsNilUsageKeepsZeroTokens(t *testing.T) {
 	state := &session.SessionState{ID: "sess-merge-4"}
 
@@ -1103,3 +1146,139 @@ func TestNonAgentProgressStillFiltered(t *testing.T) {
 		t.Errorf("expected 0 subagents (non-agent entries should be filtered), got %d", len(result.Subagents))
 	}
 }
+
+func TestSubagentNestedTaskCallsAndCompletion(t *testing.T) {
+	path := writeJSONLLines(t,
+		`{"type":"progress","toolUseID":"toolu_parent","parentToolUseID":"toolu_parent","sessionId":"sess-nest","slug":"plan","timestamp":"2026-02-20T17:00:00.000Z","data":{"message":{"type":"assistant","message":{"model":"claude-opus-4-5-20251101","role":"assistant","content":[{"type":"tool_use","name":"Task","id":"toolu_child","input":{}}]}}}}`,
+		`{"type":"progress","toolUseID":"toolu_child","parentToolUseID":"toolu_child","sessionId":"sess-nest","slug":"search","timestamp":"2026-02-20T17:00:01.000Z","data":{"message":{"type":"assistant","message":{"model":"claude-sonnet-4-6-20250514","role":"assistant","content":[{"type":"text","text":"searching"}]}}}}`,
+		`{"type":"progress","toolUseID":"toolu_parent","parentToolUseID":"toolu_parent","sessionId":"sess-nest","slug":"plan","timestamp":"2026-02-20T17:00:05.000Z","data":{"message":{"type":"user","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"toolu_child","content":"found it"}]}}}}`,
+	)
+
+	result := parseJSONL(t, path)
+	parent := requireSubagent(t, result, "toolu_parent")
+	child := requireSubagent(t, result, "toolu_child")
+
+	if len(parent.TaskCalls) != 1 || parent.TaskCalls[0] != "toolu_child" {
+		t.Errorf("parent TaskCalls = %v, want [toolu_child]", parent.TaskCalls)
+	}
+	if !child.Completed {
+		t.Error("child should be completed by the parent's tool_result")
+	}
+	if parent.Completed {
+		t.Error("parent should still be running")
+	}
+}
+
+func TestMergeSubagentsNestedTreeOrder(t *testing.T) {
+	ts := time.Date(2026, 2, 20, 17, 0, 0, 0, time.UTC)
+	state := &session.SessionState{ID: "sess-tree"}
+
+	mergeSubagents(state, map[string]*SubagentParseResult{
+		"toolu_a": {ID: "toolu_a", ParentToolUseID: "toolu_a", MessageCount: 1, FirstTime: ts, TaskCalls: []string{"toolu_c"}},
+		"toolu_b": {ID: "toolu_b", ParentToolUseID: "toolu_b", MessageCount: 1, FirstTime: ts.Add(time.Second)},
+		"toolu_c": {ID: "toolu_c", ParentToolUseID: "toolu_c", MessageCount: 1, FirstTime: ts.Add(2 * time.Second), TaskCalls: []string{"toolu_g"}},
+		"toolu_g": {ID: "toolu_g", ParentToolUseID: "toolu_g", MessageCount: 1, FirstTime: ts.Add(3 * time.Second)},
+	})
+
+	want := []struct {
+		id, parent string
+		depth      int
+	}{
+		{"toolu_a", "", 1},
+		{"toolu_c", "toolu_a", 2},
+		{"toolu_g", "toolu_c", 3},
+		{"toolu_b", "", 1},
+	}
+	if len(state.Subagents) != len(want) {
+		t.Fatalf("got %d subagents, want %d", len(state.Subagents), len(want))
+	}
+	for i := 0; i < len(want); i++ {
+		sa := state.Subagents[i]
+		if sa.ID != want[i].id || sa.ParentID != want[i].parent || sa.Depth != want[i].depth {
+			t.Errorf("Subagents[%d] = %s (parent %q, depth %d), want %s (parent %q, depth %d)",
+				i, sa.ID, sa.ParentID, sa.Depth, want[i].id, want[i].parent, want[i].depth)
+		}
+	}
+}
+
+func TestMergeSubagentsKeepsAncestorsOfRetained(t *testing.T) {
+	ts := time.Date(2026, 2, 20, 17, 0, 0, 0, time.UTC)
+	state := &session.SessionState{
+		ID: "sess-prune",
+		Subagents: []session.SubagentState{
+			{ID: "toolu_p", ParentToolUseID: "toolu_p", StartedAt: ts, TaskCalls: []string{"toolu_c"}},
+			{ID: "toolu_c", ParentToolUseID: "toolu_c", StartedAt: ts, TaskCalls: []string{"toolu_g"}},
+			{ID: "toolu_x", ParentToolUseID: "toolu_x", StartedAt: ts},
+		},
+	}
+
+	// Only the grandchild shows up in this batch. Its zero-message parent
+	// and grandparent stay so it keeps its place; the unrelated phantom goes.
+	mergeSubagents(state, map[string]*SubagentParseResult{
+		"toolu_g": {ID: "toolu_g", ParentToolUseID: "toolu_g", MessageCount: 1, FirstTime: ts.Add(time.Second)},
+	})
+
+	var ids []string
+	for i := 0; i < len(state.Subagents); i++ {
+		ids = append(ids, fmt.Sprintf("%s@%d", state.Subagents[i].ID, state.Subagents[i].Depth))
+	}
+	if got, want := fmt.Sprint(ids), "[toolu_p@1 toolu_c@2 toolu_g@3]"; got != want {
+		t.Errorf("subagents = %s, want %s", got, want)
+	}
+}
+
+func TestSubagentBudget(t *testing.T) {
+	tests := []struct {
+		name       string
+		share      float64
+		cap        int
+		maxContext int
+		want       int
+	}{
+		{"share of context window", 0.5, 0, 200000, 100000},
+		{"cap overrides share", 0.5, 80000, 200000, 80000},
+		{"cap without a known window", 0.5, 80000, 0, 80000},
+		{"unknown window", 0.5, 0, 0, 0},
+		{"disabled", 0, 0, 200000, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{Monitor: config.MonitorConfig{
+				SubagentContextShare: tt.share,
+				SubagentTokenCap:     tt.cap,
+			}}
+			state := &session.SessionState{MaxContextTokens: tt.maxContext}
+			if got := subagentBudget(cfg, state); got != tt.want {
+				t.Errorf("subagentBudget() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFlagOverBudgetSubagentsWarnsOnce(t *testing.T) {
+	state := &session.SessionState{
+		ID: "sess-budget",
+		Subagents: []session.SubagentState{
+			{ID: "toolu_small", TokensUsed: 20000},
+			{ID: "toolu_big", TokensUsed: 120000},
+		},
+	}
+
+	flagged := flagOverBudgetSubagents(state, 100000)
+	if len(flagged) != 1 || flagged[0].ID != "toolu_big" || !flagged[0].OverBudget {
+		t.Fatalf("flagged = %+v, want toolu_big", flagged)
+	}
+	if state.Subagents[0].OverBudget || !state.Subagents[1].OverBudget {
+		t.Errorf("OverBudget = %v, %v, want false, true", state.Subagents[0].OverBudget, state.Subagents[1].OverBudget)
+	}
+
+	state.Subagents[0].TokensUsed = 100000
+	state.Subagents[1].TokensUsed = 150000
+	flagged = flagOverBudgetSubagents(state, 100000)
+	if len(flagged) != 1 || flagged[0].ID != "toolu_small" {
+		t.Errorf("second pass flagged = %+v, want only toolu_small", flagged)
+	}
+	if got := flagOverBudgetSubagents(state, 0); got != nil {
+		t.Errorf("budget 0 flagged %+v, want none", got)
+	}
+}
@@ -0,0 +1,113 @@
+package monitor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// packageManifests are the files that make a directory a package or
+// service of its own inside a monorepo.
+var packageManifests = []string{
+	"go.mod", "package.json", "Cargo.toml", "pyproject.toml", "setup.py",
+	"pom.xml", "build.gradle", "build.gradle.kts", "composer.json", "Gemfile",
+	"mix.exs", "BUILD.bazel", "BUILD",
+}
+
+// subProjectTracker attributes a session to the package of its repository
+// it works in most, judged by the files its tool calls touch.
+type subProjectTracker struct {
+	root    string            // repository root, or the working dir outside a repository
+	pkgs    map[string]string // directory -> its package, relative to root; "" for the root
+	touches map[string]int    // package -> files touched in it
+}
+
+// newSubProjectTracker returns a tracker for a session working in dir, on
+// the host.
+func newSubProjectTracker(dir string) *subProjectTracker {
+	return &subProjectTracker{
+		root:    repoRoot(dir),
+		pkgs:    make(map[string]string),
+		touches: make(map[string]int),
+	}
+}
+
+// repoRoot returns the nearest directory at or above dir holding .git, or
+// dir itself when there is none.
+func repoRoot(dir string) string {
+	if dir == "" {
+		return ""
+	}
+	dir = filepath.Clean(dir)
+	for d := dir; ; {
+		if _, err := os.Stat(filepath.Join(d, ".git")); err == nil {
+			return d
+		}
+		parent := filepath.Dir(d)
+		if parent == d {
+			return dir
+		}
+		d = parent
+	}
+}
+
+// touch records n tool calls on the file at the absolute host path. Files
+// outside the repository are ignored.
+func (t *subProjectTracker) touch(path string, n int) {
+	if t.root == "" || !filepath.IsAbs(path) {
+		return
+	}
+	rel, err := filepath.Rel(t.root, filepath.Dir(path))
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return
+	}
+	t.touches[t.packageOf(rel)] += n
+}
+
+// packageOf returns the package the repository directory rel belongs to:
+// the nearest directory at or above it with a package manifest, relative
+// to the root. Lookups are cached, as the layout rarely changes during a
+// session.
+func (t *subProjectTracker) packageOf(rel string) string {
+	if rel == "." {
+		return ""
+	}
+	if pkg, ok := t.pkgs[rel]; ok {
+		return pkg
+	}
+	pkg := t.packageOf(filepath.Dir(rel))
+	for i := 0; i < len(packageManifests); i++ {
+		if _, err := os.Stat(filepath.Join(t.root, rel, packageManifests[i])); err == nil {
+			pkg = filepath.ToSlash(rel)
+			break
+		}
+	}
+	t.pkgs[rel] = pkg
+	return pkg
+}
+
+// subProject returns the package with the most touched files, preferring
+// the deeper one on a tie. It is "" until the session touches a file in a
+// package below the root.
+func (t *subProjectTracker) subProject() string {
+	best, most := "", 0
+	for pkg, n := range t.touches {
+		if pkg == "" {
+			continue
+		}
+		if n > most || (n == most && deeperPackage(pkg, best)) {
+			best, most = pkg, n
+		}
+	}
+	return best
+}
+
+// deeperPackage reports whether package a sits deeper than b, breaking
+// ties by name so the choice does not depend on map order.
+func deeperPackage(a, b string) bool {
+	da, db := strings.Count(a, "/"), strings.Count(b, "/")
+	if da != db {
+		return da > db
+	}
+	return a < b
+}
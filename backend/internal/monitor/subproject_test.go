@@ -0,0 +1,48 @@
+package monitor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSubProjectTracker(t *testing.T) {
+	repo := t.TempDir()
+	for _, f := range []string{
+		".git/HEAD",
+		"go.mod",
+		"services/api/go.mod",
+		"services/api/internal/handlers/users.go",
+		"services/web/package.json",
+		"docs/README.md",
+	} {
+		path := filepath.Join(repo, f)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The agent starts in a subdirectory; attribution is still by package.
+	tr := newSubProjectTracker(filepath.Join(repo, "services"))
+	if tr.root != repo {
+		t.Fatalf("root = %q, want %q", tr.root, repo)
+	}
+	if got := tr.subProject(); got != "" {
+		t.Errorf("subProject before any touch = %q", got)
+	}
+
+	tr.touch(filepath.Join(repo, "docs/README.md"), 5) // root package
+	tr.touch(filepath.Join(repo, "services/web/src/app.js"), 2)
+	if got := tr.subProject(); got != "services/web" {
+		t.Errorf("subProject = %q, want services/web", got)
+	}
+
+	tr.touch(filepath.Join(repo, "services/api/internal/handlers/users.go"), 3)
+	tr.touch("/elsewhere/notes.txt", 10)
+	if got := tr.subProject(); got != "services/api" {
+		t.Errorf("subProject = %q, want services/api", got)
+	}
+}
@@ -0,0 +1,99 @@
+package monitor
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxTopicLen caps the derived topic label in runes. Topics are meant to
+// fit next to a racer on the track, not reproduce the prompt.
+const maxTopicLen = 60
+
+// maxPromptScan bounds how much of the first user message is kept for
+// topic derivation.
+const maxPromptScan = 2000
+
+// topicFillerPrefixes are conversational openers stripped from the start
+// of a prompt so the topic leads with the task itself.
+var topicFillerPrefixes = []string{
+	"please ",
+	"can you ",
+	"could you ",
+	"would you ",
+	"i want you to ",
+	"i need you to ",
+	"i'd like you to ",
+	"help me ",
+	"let's ",
+	"lets ",
+	"hey, ",
+	"hey ",
+	"hi, ",
+	"hi ",
+}
+
+var (
+	// topicSecretPattern matches token-like strings (API keys, hashes,
+	// base64 blobs) that must never end up in a broadcast label.
+	topicSecretPattern = regexp.MustCompile(`\b(?:sk-|ghp_|gho_|github_pat_|xox[abpr]-|AKIA)[A-Za-z0-9_\-]+|\b[A-Za-z0-9_\-+/=]{32,}\b`)
+	// topicEmailPattern matches email addresses.
+	topicEmailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	// topicURLPattern matches http(s) URLs; only the host is kept.
+	topicURLPattern = regexp.MustCompile(`https?://([^/\s]+)\S*`)
+	// topicPathPattern matches absolute or home-relative file paths.
+	topicPathPattern = regexp.MustCompile(`(?:~|\B)/(?:[^\s/]+/)+([^\s/]+)`)
+)
+
+// topicFromPrompt derives a short, display-safe label from the first user
+// prompt of a session. It keeps only the first line, drops conversational
+// filler, redacts secrets, email addresses, URL paths and directory
+// prefixes, and truncates at a word boundary. Returns "" when nothing
+// meaningful remains.
+func topicFromPrompt(prompt string) string {
+	line := ""
+	for _, l := range strings.Split(prompt, "\n") {
+		if l = strings.TrimSpace(l); l != "" {
+			line = l
+			break
+		}
+	}
+	if line == "" {
+		return ""
+	}
+
+	line = strings.Trim(line, "#*>`_ \t")
+	line = topicSecretPattern.ReplaceAllString(line, "…")
+	line = topicEmailPattern.ReplaceAllString(line, "…")
+	line = topicURLPattern.ReplaceAllString(line, "$1")
+	line = topicPathPattern.ReplaceAllString(line, "$1")
+	line = strings.Join(strings.Fields(line), " ")
+
+	for stripped := true; stripped; {
+		stripped = false
+		lower := strings.ToLower(line)
+		for _, p := range topicFillerPrefixes {
+			if strings.HasPrefix(lower, p) {
+				line = strings.TrimSpace(line[len(p):])
+				stripped = true
+				break
+			}
+		}
+	}
+
+	// Prefer the first sentence when it is long enough to be descriptive.
+	if i := strings.IndexAny(line, ".?!"); i >= 20 && i < len(line)-1 {
+		line = line[:i]
+	}
+	line = strings.TrimRight(line, ".?!:, ")
+
+	if utf8.RuneCountInString(line) > maxTopicLen {
+		runes := []rune(line)
+		cut := string(runes[:maxTopicLen])
+		if i := strings.LastIndexByte(cut, ' '); i > maxTopicLen/2 {
+			cut = cut[:i]
+		}
+		line = strings.TrimRight(cut, ".,;: ") + "…"
+	}
+	return line
+}
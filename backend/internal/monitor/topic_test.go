@@ -0,0 +1,75 @@
+package monitor
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTopicFromPrompt(t *testing.T) {
+	tests := []struct {
+		name   string
+		prompt string
+		want   string
+	}{
+		{"plain", "fix flaky websocket test", "fix flaky websocket test"},
+		{"strips filler", "Please can you fix the flaky websocket test?", "fix the flaky websocket test"},
+		{"first line only", "\n\nadd retry to uploader\nhere is the stack trace:\n...", "add retry to uploader"},
+		{"first sentence", "Refactor the session store locking. It deadlocks under load and the tests time out.", "Refactor the session store locking"},
+		{"markdown heading", "## Migrate config loader", "Migrate config loader"},
+		{"redacts paths", "look at /home/alice/work/acme/main.go please", "look at main.go please"},
+		{"redacts urls", "triage https://github.com/acme/widgets/issues/42", "triage github.com"},
+		{"redacts email", "email bob@example.com the report", "email … the report"},
+		{"redacts keys", "use key sk-abc123def456 for the api", "use key … for the api"},
+		{"empty", "   \n  ", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := topicFromPrompt(tt.prompt); got != tt.want {
+				t.Errorf("topicFromPrompt(%q) = %q, want %q", tt.prompt, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTopicFromPromptTruncates(t *testing.T) {
+	prompt := strings.Repeat("rename every occurrence of the legacy name ", 5)
+	got := topicFromPrompt(prompt)
+	if n := utf8.RuneCountInString(got); n > maxTopicLen+1 {
+		t.Errorf("topic has %d runes, want at most %d", n, maxTopicLen+1)
+	}
+	if !strings.HasSuffix(got, "…") {
+		t.Errorf("truncated topic %q should end with an ellipsis", got)
+	}
+}
+
+func TestPromptFromMessageKeepsRunesWhole(t *testing.T) {
+	// "é" is two bytes, so maxPromptScan falls in the middle of one.
+	raw, err := json.Marshal(map[string]string{"role": "user", "content": "x" + strings.Repeat("é", maxPromptScan)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := promptFromMessage(raw)
+	if !utf8.ValidString(got) {
+		t.Errorf("prompt cut mid-rune: ends with % x", got[len(got)-3:])
+	}
+	if len(got) != maxPromptScan-1 {
+		t.Errorf("prompt is %d bytes, want %d", len(got), maxPromptScan-1)
+	}
+}
+
+func TestParseSessionJSONLFirstPrompt(t *testing.T) {
+	path := writeJSONLLines(t,
+		`{"type":"user","isMeta":true,"message":{"role":"user","content":"Caveat: the messages below were generated by the user while running local commands."},"sessionId":"test-topic","timestamp":"2026-01-30T10:00:00.000Z"}`,
+		`{"type":"user","message":{"role":"user","content":"<command-name>/clear</command-name>"},"sessionId":"test-topic","timestamp":"2026-01-30T10:00:01.000Z"}`,
+		`{"type":"user","message":{"role":"user","content":[{"type":"text","text":"fix flaky websocket test"}]},"sessionId":"test-topic","timestamp":"2026-01-30T10:00:02.000Z"}`,
+		`{"type":"user","message":{"role":"user","content":"now also update the docs"},"sessionId":"test-topic","timestamp":"2026-01-30T10:00:03.000Z"}`,
+	)
+
+	result := parseJSONL(t, path)
+
+	if result.FirstPrompt != "fix flaky websocket test" {
+		t.Errorf("FirstPrompt = %q, want first human-typed prompt", result.FirstPrompt)
+	}
+}
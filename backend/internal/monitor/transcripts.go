@@ -0,0 +1,125 @@
+package monitor
+
+import (
+	"fmt"
+
+	"github.com/agent-racer/backend/internal/config"
+	"github.com/agent-racer/backend/internal/session"
+)
+
+// ReadTranscripts parses every session src discovers from the start of its
+// log and returns each as a finished session, for rebuilding stats from
+// transcripts left on disk. Counters are summed and snapshots kept latest,
+// the way the poll loop merges them; a session whose log doesn't say how it
+// ended counts as complete at its last entry. Sessions with no timestamped
+// entries, or from a repository that hides them, are skipped. A session whose log can't be parsed is skipped and
+// reported in the returned error alongside the sessions that could be.
+func ReadTranscripts(src Source, cfg *config.Config) ([]*session.SessionState, error) {
+	handles, err := src.Discover()
+	if err != nil {
+		return nil, fmt.Errorf("%s: discover: %w", src.Name(), err)
+	}
+
+	var states []*session.SessionState
+	var firstErr error
+	for _, h := range handles {
+		state, err := readTranscript(src, cfg, h)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("%s: %s: %w", src.Name(), h.LogPath, err)
+			}
+			continue
+		}
+		if state != nil {
+			states = append(states, state)
+		}
+	}
+	return states, firstErr
+}
+
+func readTranscript(src Source, cfg *config.Config, h SessionHandle) (*session.SessionState, error) {
+	state := &session.SessionState{
+		ID:         trackingKey(h.Source, h.SessionID),
+		Name:       nameFromPath(h.WorkingDir),
+		Source:     h.Source,
+		StartedAt:  h.StartedAt,
+		WorkingDir: h.WorkingDir,
+		LogPath:    h.LogPath,
+	}
+	if h.Name != "" {
+		state.Name = h.Name
+	}
+
+	var ended string
+	var maxTokens int
+	var offset int64
+	for {
+		update, next, err := src.Parse(h, offset)
+		if err != nil {
+			return nil, err
+		}
+		if update.WorkingDir != "" && state.WorkingDir == "" {
+			state.WorkingDir = update.WorkingDir
+			if h.Name == "" {
+				state.Name = nameFromPath(update.WorkingDir)
+			}
+		}
+		if update.Model != "" {
+			if state.Model != "" && update.Model != state.Model {
+				state.ModelSwitches++
+			}
+			state.Model = update.Model
+		}
+		if !update.LastTime.IsZero() {
+			state.LastActivityAt = update.LastTime
+		}
+		if update.TokensIn > 0 {
+			state.TokensUsed = update.TokensIn
+		}
+		if update.MaxContextTokens > 0 {
+			maxTokens = update.MaxContextTokens
+		}
+		state.MessageCount += update.MessageCount
+		state.ToolCallCount += update.ToolCalls
+		state.MCPToolCalls = session.MergeCounts(state.MCPToolCalls, update.MCPToolCalls)
+		state.SlashCommands = session.MergeCounts(state.SlashCommands, update.SlashCommands)
+		state.CompactionCount += update.CompactionCount
+		state.HookEventCount += update.HookEvents
+		state.AddCacheUsage(update.Cache)
+		if update.Ended != "" {
+			ended = update.Ended
+		}
+		if next <= offset {
+			break
+		}
+		offset = next
+	}
+
+	if state.LastActivityAt.IsZero() {
+		return nil, nil
+	}
+	if o := loadRepoOverrides(state.WorkingDir); o != nil {
+		if o.Privacy == session.PrivacyHidden {
+			return nil, nil
+		}
+		o.Apply(state)
+	}
+	if state.StartedAt.IsZero() {
+		state.StartedAt = state.LastActivityAt
+	}
+	if maxTokens == 0 {
+		model := state.Model
+		if model == "" {
+			model = "unknown"
+		}
+		maxTokens = cfg.MaxContextTokens(model)
+	}
+	state.MaxContextTokens = maxTokens
+	if maxTokens > 0 {
+		state.ContextUtilization = min(float64(state.TokensUsed)/float64(maxTokens), 1)
+	}
+	completedAt := state.LastActivityAt
+	state.Activity = determineActivityFromReason(ended)
+	state.CompletedAt = &completedAt
+	return state, nil
+}
@@ -0,0 +1,62 @@
+package monitor
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/agent-racer/backend/internal/session"
+)
+
+func TestReadTranscripts(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Date(2025, 11, 3, 9, 0, 0, 0, time.UTC)
+	ts := func(d time.Duration) string { return start.Add(d).Format(time.RFC3339Nano) }
+
+	done := filepath.Join(dir, "done.jsonl")
+	writeJSONL(t, done,
+		jsonlLine("user", "done", ts(0), "", "", "/repo/api")+
+			jsonlLine("assistant", "done", ts(time.Minute), "claude-sonnet-4-5", "Read", "")+
+			jsonlLine("assistant", "done", ts(5*time.Minute), "claude-opus-4-5", "", ""))
+	empty := filepath.Join(dir, "empty.jsonl")
+	writeJSONL(t, empty, "")
+	broken := filepath.Join(dir, "broken.jsonl")
+	writeJSONL(t, broken, jsonlLine("user", "broken", ts(0), "", "", "/repo"))
+
+	src := &endingSource{testSource: testSource{
+		handles: []SessionHandle{
+			newTestHandle("done", done, "", time.Time{}),
+			newTestHandle("empty", empty, "/repo", start),
+			newTestHandle("broken", broken, "/repo", start),
+		},
+		parseErrs: map[string]error{"broken": errors.New("bad line")},
+	}}
+
+	states, err := ReadTranscripts(src, defaultTestConfig())
+	if err == nil {
+		t.Error("want the broken transcript reported")
+	}
+	if len(states) != 1 {
+		t.Fatalf("got %d sessions, want only done: %+v", len(states), states)
+	}
+	s := states[0]
+	if s.ID != "claude:done" || s.Name != "api" || s.WorkingDir != "/repo/api" {
+		t.Errorf("identity = %s/%s/%s", s.ID, s.Name, s.WorkingDir)
+	}
+	if s.Activity != session.Complete || s.CompletedAt == nil || !s.CompletedAt.Equal(start.Add(5*time.Minute)) {
+		t.Errorf("activity = %s, completedAt = %v; want complete at the last entry", s.Activity, s.CompletedAt)
+	}
+	if !s.StartedAt.Equal(s.LastActivityAt) {
+		t.Errorf("StartedAt = %v, want the last entry when the handle has none", s.StartedAt)
+	}
+	if s.Model != "claude-opus-4-5" || s.MessageCount != 3 || s.ToolCallCount != 1 {
+		t.Errorf("model = %s, messages = %d, tools = %d", s.Model, s.MessageCount, s.ToolCallCount)
+	}
+
+	src.ended = "crashed"
+	states, _ = ReadTranscripts(src, defaultTestConfig())
+	if len(states) != 1 || states[0].Activity != session.Errored {
+		t.Errorf("ended transcript = %+v, want errored", states)
+	}
+}
@@ -0,0 +1,99 @@
+package monitor
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// worktreeSeparator is the conventional separator in sibling worktree
+// directory names, e.g. "agent-racer--fix-login" for branch "fix-login".
+const worktreeSeparator = "--"
+
+// resolveProject determines the primary project a working directory
+// belongs to and, when the directory is a git worktree rather than the
+// main checkout, a short label for the worktree (normally the branch).
+// Git is consulted first; when it is unavailable or the directory is not
+// a repository, directory naming conventions are used instead.
+func resolveProject(dir, branch string) (project, worktree string) {
+	if dir == "" {
+		return "", ""
+	}
+	if project, worktree, ok := projectFromGit(dir, branch); ok {
+		return project, worktree
+	}
+	return projectFromPath(dir)
+}
+
+// projectFromGit asks git for the shared .git directory and the checkout
+// root. In a linked worktree the shared directory lives in the primary
+// checkout, so its parent names the project.
+func projectFromGit(dir, branch string) (project, worktree string, ok bool) {
+	gitPath, err := exec.LookPath("git")
+	if err != nil {
+		return "", "", false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 1500*time.Millisecond)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, gitPath, "-C", dir, "rev-parse",
+		"--path-format=absolute", "--git-common-dir", "--show-toplevel")
+	out, err := cmd.Output()
+	if err != nil {
+		return "", "", false
+	}
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	if len(lines) != 2 {
+		return "", "", false
+	}
+	commonDir, topLevel := filepath.Clean(lines[0]), filepath.Clean(lines[1])
+	if filepath.Base(commonDir) != ".git" {
+		// Bare repository or unusual layout; let path heuristics decide.
+		return "", "", false
+	}
+	primary := filepath.Dir(commonDir)
+	project = filepath.Base(primary)
+	if primary == topLevel {
+		return project, "", true
+	}
+
+	worktree = branch
+	if worktree == "" {
+		worktree = worktreeLabelFromDir(filepath.Base(topLevel), project)
+	}
+	return project, worktree, true
+}
+
+// projectFromPath applies directory naming conventions: Claude Code's
+// "<repo>/.claude/worktrees/<slug>" layout and sibling "<repo>--<branch>"
+// directories. Other paths are their own project.
+func projectFromPath(dir string) (project, worktree string) {
+	parts := splitPath(dir)
+	for i := 0; i < len(parts)-2; i++ {
+		if parts[i] == ".claude" && parts[i+1] == "worktrees" {
+			if i == 0 {
+				return parts[i+2], ""
+			}
+			return parts[i-1], parts[i+2]
+		}
+	}
+	if len(parts) == 0 {
+		return "", ""
+	}
+	base := parts[len(parts)-1]
+	if repo, wt, ok := strings.Cut(base, worktreeSeparator); ok && repo != "" && wt != "" {
+		return repo, wt
+	}
+	return base, ""
+}
+
+// worktreeLabelFromDir derives a worktree label from the checkout's
+// directory name, stripping a "<project>--" prefix when present.
+func worktreeLabelFromDir(base, project string) string {
+	if label, ok := strings.CutPrefix(base, project+worktreeSeparator); ok && label != "" {
+		return label
+	}
+	return base
+}
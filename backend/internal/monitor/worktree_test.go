@@ -0,0 +1,66 @@
+package monitor
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestProjectFromPath(t *testing.T) {
+	tests := []struct {
+		path         string
+		wantProject  string
+		wantWorktree string
+	}{
+		{"/home/user/agent-racer", "agent-racer", ""},
+		{"/home/user/agent-racer--fix-login", "agent-racer", "fix-login"},
+		{"/home/user/agent-racer/.claude/worktrees/brave-otter", "agent-racer", "brave-otter"},
+		{"/home/user/agent-racer/.claude/worktrees/brave-otter/backend", "agent-racer", "brave-otter"},
+		{"/home/user/--odd", "--odd", ""},
+		{"", "", ""},
+	}
+	for _, tt := range tests {
+		project, worktree := projectFromPath(tt.path)
+		if project != tt.wantProject || worktree != tt.wantWorktree {
+			t.Errorf("projectFromPath(%q) = (%q, %q), want (%q, %q)",
+				tt.path, project, worktree, tt.wantProject, tt.wantWorktree)
+		}
+	}
+}
+
+func TestResolveProjectGitWorktree(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	base := t.TempDir()
+	primary := filepath.Join(base, "widgets")
+	linked := filepath.Join(base, "scratch-checkout")
+
+	git := func(dir string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=t", "GIT_AUTHOR_EMAIL=t@example.com",
+			"GIT_COMMITTER_NAME=t", "GIT_COMMITTER_EMAIL=t@example.com")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	if err := os.MkdirAll(primary, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	git(primary, "init", "-q", "-b", "main")
+	git(primary, "commit", "-q", "--allow-empty", "-m", "init")
+	git(primary, "worktree", "add", "-q", "-b", "feature-x", linked)
+
+	if project, worktree := resolveProject(primary, "main"); project != "widgets" || worktree != "" {
+		t.Errorf("primary checkout = (%q, %q), want (widgets, \"\")", project, worktree)
+	}
+	if project, worktree := resolveProject(linked, "feature-x"); project != "widgets" || worktree != "feature-x" {
+		t.Errorf("linked worktree = (%q, %q), want (widgets, feature-x)", project, worktree)
+	}
+	if project, worktree := resolveProject(filepath.Join(linked), ""); project != "widgets" || worktree != "scratch-checkout" {
+		t.Errorf("linked worktree without branch = (%q, %q), want (widgets, scratch-checkout)", project, worktree)
+	}
+}
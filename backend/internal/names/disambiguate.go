@@ -0,0 +1,132 @@
+package names
+
+import (
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/agent-racer/backend/internal/session"
+)
+
+// Disambiguate gives sessions that share a name but not a working directory
+// distinct names, returned keyed by session ID. Sessions without a
+// collision, or without a working directory, are left out of the result.
+//
+// The name gains the nearest parent directories that tell the working
+// directories apart: two checkouts at ~/work/billing/api and
+// ~/work/search/api become "api (billing)" and "api (search)". With
+// hideDirs, which should match the privacy filter's MaskWorkingDirs, the
+// branch is used instead, and failing that a number.
+func Disambiguate(sessions []*session.SessionState, hideDirs bool) map[string]string {
+	// name -> working dir -> sessions
+	groups := make(map[string]map[string][]*session.SessionState)
+	for _, s := range sessions {
+		if s.WorkingDir == "" {
+			continue
+		}
+		dirs := groups[s.Name]
+		if dirs == nil {
+			dirs = make(map[string][]*session.SessionState)
+			groups[s.Name] = dirs
+		}
+		dir := filepath.Clean(s.WorkingDir)
+		dirs[dir] = append(dirs[dir], s)
+	}
+
+	var renames map[string]string
+	for name, byDir := range groups {
+		if len(byDir) < 2 {
+			continue
+		}
+		dirs := make([]string, 0, len(byDir))
+		for dir := range byDir {
+			dirs = append(dirs, dir)
+		}
+		sort.Strings(dirs)
+
+		var labels []string
+		if !hideDirs {
+			labels = parentLabels(dirs)
+		}
+		if labels == nil {
+			labels = branchLabels(dirs, byDir)
+		}
+		if labels == nil {
+			labels = make([]string, len(dirs))
+			for i := 0; i < len(dirs); i++ {
+				labels[i] = strconv.Itoa(i + 1)
+			}
+		}
+
+		if renames == nil {
+			renames = make(map[string]string)
+		}
+		for i := 0; i < len(dirs); i++ {
+			for _, s := range byDir[dirs[i]] {
+				renames[s.ID] = name + " (" + labels[i] + ")"
+			}
+		}
+	}
+	return renames
+}
+
+// parentLabels returns, for each directory, the fewest trailing components
+// of its parent that make every label distinct, or nil if even the full
+// parents collide.
+func parentLabels(dirs []string) []string {
+	parents := make([][]string, len(dirs))
+	longest := 0
+	for i := 0; i < len(dirs); i++ {
+		parents[i] = splitDir(filepath.Dir(dirs[i]))
+		longest = max(longest, len(parents[i]))
+	}
+	for n := 1; n <= longest; n++ {
+		labels := make([]string, len(dirs))
+		for i := 0; i < len(dirs); i++ {
+			p := parents[i]
+			labels[i] = strings.Join(p[max(len(p)-n, 0):], "/")
+		}
+		if distinct(labels) {
+			return labels
+		}
+	}
+	return nil
+}
+
+// branchLabels labels each directory with the branch of its first session,
+// or returns nil unless every directory has a distinct branch.
+func branchLabels(dirs []string, byDir map[string][]*session.SessionState) []string {
+	labels := make([]string, len(dirs))
+	for i := 0; i < len(dirs); i++ {
+		labels[i] = byDir[dirs[i]][0].Branch
+		if labels[i] == "" {
+			return nil
+		}
+	}
+	if !distinct(labels) {
+		return nil
+	}
+	return labels
+}
+
+func splitDir(dir string) []string {
+	var parts []string
+	for _, p := range strings.Split(filepath.ToSlash(dir), "/") {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	return parts
+}
+
+func distinct(labels []string) bool {
+	seen := make(map[string]bool, len(labels))
+	for _, l := range labels {
+		if l == "" || seen[l] {
+			return false
+		}
+		seen[l] = true
+	}
+	return true
+}
@@ -0,0 +1,76 @@
+package names
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/agent-racer/backend/internal/session"
+)
+
+func TestDisambiguate(t *testing.T) {
+	tests := []struct {
+		name     string
+		sessions []*session.SessionState
+		hideDirs bool
+		want     map[string]string
+	}{
+		{
+			name: "unique names untouched",
+			sessions: []*session.SessionState{
+				{ID: "a", Name: "api", WorkingDir: "/work/api"},
+				{ID: "b", Name: "web", WorkingDir: "/work/web"},
+			},
+		},
+		{
+			name: "same directory is the same project",
+			sessions: []*session.SessionState{
+				{ID: "a", Name: "api", WorkingDir: "/work/api"},
+				{ID: "b", Name: "api", WorkingDir: "/work/api/"},
+			},
+		},
+		{
+			name: "parent directory",
+			sessions: []*session.SessionState{
+				{ID: "a", Name: "api", WorkingDir: "/work/billing/api"},
+				{ID: "b", Name: "api", WorkingDir: "/work/search/api"},
+				{ID: "c", Name: "api", WorkingDir: "/work/search/api"},
+				{ID: "d", Name: "api"},
+			},
+			want: map[string]string{"a": "api (billing)", "b": "api (search)", "c": "api (search)"},
+		},
+		{
+			name: "as many parents as it takes",
+			sessions: []*session.SessionState{
+				{ID: "a", Name: "api", WorkingDir: "/home/ann/src/api"},
+				{ID: "b", Name: "api", WorkingDir: "/home/bob/src/api"},
+			},
+			want: map[string]string{"a": "api (ann/src)", "b": "api (bob/src)"},
+		},
+		{
+			name:     "branch when directories are hidden",
+			hideDirs: true,
+			sessions: []*session.SessionState{
+				{ID: "a", Name: "api", WorkingDir: "/work/billing/api", Branch: "main"},
+				{ID: "b", Name: "api", WorkingDir: "/work/search/api", Branch: "fix-auth"},
+			},
+			want: map[string]string{"a": "api (main)", "b": "api (fix-auth)"},
+		},
+		{
+			name:     "number when branches collide",
+			hideDirs: true,
+			sessions: []*session.SessionState{
+				{ID: "a", Name: "api", WorkingDir: "/work/search/api", Branch: "main"},
+				{ID: "b", Name: "api", WorkingDir: "/work/billing/api", Branch: "main"},
+			},
+			want: map[string]string{"b": "api (1)", "a": "api (2)"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Disambiguate(tt.sessions, tt.hideDirs)
+			if len(got) != len(tt.want) || (len(got) > 0 && !reflect.DeepEqual(got, tt.want)) {
+				t.Errorf("Disambiguate = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
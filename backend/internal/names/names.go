@@ -0,0 +1,167 @@
+// Package names keeps the display names users give sessions in place of
+// the one derived from their working directory, which is often ambiguous:
+// every checkout of a service called "api" would otherwise race as "api".
+//
+// An alias belongs either to one session or to a working directory, in
+// which case every session started there wears it. A session alias wins.
+// Aliases are saved to a JSON file so they survive restarts.
+//
+// Sessions without an alias that still share a name with a session from
+// another directory are renamed by Disambiguate.
+package names
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/agent-racer/backend/internal/session"
+)
+
+// MaxLen is the longest alias accepted, in characters.
+const MaxLen = 64
+
+// Scope says what an alias is attached to.
+type Scope string
+
+const (
+	ScopeSession Scope = "session"
+	ScopeDir     Scope = "dir"
+)
+
+// ErrInvalidName is returned for aliases that are too long or contain
+// control characters.
+var ErrInvalidName = errors.New("invalid name")
+
+type file struct {
+	Sessions map[string]string `json:"sessions,omitempty"` // session ID -> alias
+	Dirs     map[string]string `json:"dirs,omitempty"`     // working directory -> alias
+}
+
+// Aliases holds session and directory aliases backed by a file. It is safe
+// for concurrent use.
+type Aliases struct {
+	mu   sync.RWMutex
+	path string
+	f    file
+}
+
+// Load reads aliases from path. A missing file means no aliases yet.
+func Load(path string) (*Aliases, error) {
+	a := &Aliases{path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return a, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &a.f); err != nil {
+		return nil, fmt.Errorf("names: parse %s: %w", path, err)
+	}
+	return a, nil
+}
+
+// Lookup returns the alias for a session with the given ID and working
+// directory.
+func (a *Aliases) Lookup(id, workingDir string) (string, bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if name, ok := a.f.Sessions[id]; ok {
+		return name, true
+	}
+	if workingDir == "" {
+		return "", false
+	}
+	name, ok := a.f.Dirs[filepath.Clean(workingDir)]
+	return name, ok
+}
+
+// Apply returns s renamed to its alias. s itself is never modified; it is
+// returned as is when it has no alias.
+func (a *Aliases) Apply(s *session.SessionState) *session.SessionState {
+	name, ok := a.Lookup(s.ID, s.WorkingDir)
+	if !ok || name == s.Name {
+		return s
+	}
+	renamed := *s
+	renamed.Name = name
+	return &renamed
+}
+
+// Set gives key, a session ID or a working directory depending on scope,
+// the alias name and saves the aliases. An empty name removes the alias.
+func (a *Aliases) Set(scope Scope, key, name string) error {
+	name = strings.TrimSpace(name)
+	if err := Validate(name); err != nil {
+		return err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var m *map[string]string
+	switch scope {
+	case ScopeSession:
+		m = &a.f.Sessions
+	case ScopeDir:
+		m = &a.f.Dirs
+		key = filepath.Clean(key)
+	default:
+		return fmt.Errorf("names: unknown scope %q", scope)
+	}
+	prev, had := (*m)[key]
+	if name == "" {
+		delete(*m, key)
+	} else {
+		if *m == nil {
+			*m = make(map[string]string)
+		}
+		(*m)[key] = name
+	}
+	if err := a.saveLocked(); err != nil {
+		if had {
+			(*m)[key] = prev
+		} else {
+			delete(*m, key)
+		}
+		return err
+	}
+	return nil
+}
+
+// Validate reports whether name can be used as an alias. The empty name,
+// which removes an alias, is valid.
+func Validate(name string) error {
+	if utf8.RuneCountInString(name) > MaxLen {
+		return fmt.Errorf("%w: longer than %d characters", ErrInvalidName, MaxLen)
+	}
+	for _, r := range name {
+		if unicode.IsControl(r) {
+			return fmt.Errorf("%w: contains control characters", ErrInvalidName)
+		}
+	}
+	return nil
+}
+
+// saveLocked writes the aliases to a temporary file and renames it over
+// the old one, so a crash never leaves a half-written file. Caller must
+// hold a.mu.
+func (a *Aliases) saveLocked() error {
+	data, err := json.MarshalIndent(a.f, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(a.path), 0o700); err != nil {
+		return err
+	}
+	tmp := a.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, a.path)
+}
@@ -0,0 +1,100 @@
+package names
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/agent-racer/backend/internal/session"
+)
+
+func TestSessionAliasBeatsDirAlias(t *testing.T) {
+	a, err := Load(filepath.Join(t.TempDir(), "names.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Set(ScopeDir, "/work/billing/api/", "billing-api"); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Set(ScopeSession, "s2", "hotfix"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		id, dir string
+		want    string
+		ok      bool
+	}{
+		{"s1", "/work/billing/api", "billing-api", true},
+		{"s2", "/work/billing/api", "hotfix", true},
+		{"s3", "/work/search/api", "", false},
+		{"s4", "", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := a.Lookup(tt.id, tt.dir)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("Lookup(%q, %q) = %q, %v; want %q, %v", tt.id, tt.dir, got, ok, tt.want, tt.ok)
+		}
+	}
+
+	orig := &session.SessionState{ID: "s1", Name: "api", WorkingDir: "/work/billing/api"}
+	if renamed := a.Apply(orig); renamed.Name != "billing-api" || orig.Name != "api" {
+		t.Errorf("Apply: renamed %q, original %q", renamed.Name, orig.Name)
+	}
+}
+
+func TestAliasesPersist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "names.json")
+	a, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Set(ScopeSession, "s1", "one"); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Set(ScopeSession, "s2", "two"); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Set(ScopeSession, "s2", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name, _ := b.Lookup("s1", ""); name != "one" {
+		t.Errorf("s1 = %q after reload, want one", name)
+	}
+	if _, ok := b.Lookup("s2", ""); ok {
+		t.Error("cleared alias for s2 survived reload")
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("names file mode = %v, %v; want 0600", info, err)
+	}
+}
+
+func TestLoadRejectsCorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "names.json")
+	if err := os.WriteFile(path, []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Error("Load accepted a corrupt file")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, name := range []string{"", "billing-api", "αβγ 🚀", strings.Repeat("é", MaxLen)} {
+		if err := Validate(name); err != nil {
+			t.Errorf("Validate(%q) = %v", name, err)
+		}
+	}
+	for _, name := range []string{"bell\a", "new\nline", strings.Repeat("x", MaxLen+1)} {
+		if err := Validate(name); !errors.Is(err, ErrInvalidName) {
+			t.Errorf("Validate(%q) = %v, want ErrInvalidName", name, err)
+		}
+	}
+}
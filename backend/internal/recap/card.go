@@ -0,0 +1,144 @@
+package recap
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Cards are 1200x630, the 1.91:1 size link previews expect, with 60px
+// margins; cardInner is the width between them.
+const cardInner = 1080
+
+// mixColors paints the model mix bar, largest share first.
+var mixColors = []string{"#2bd576", "#4d9fff", "#ffb020", "#c77dff", "#ff4d6d", "#8a90a8"}
+
+// WriteSVG renders r as a standalone SVG card.
+func WriteSVG(w io.Writer, r Recap) error {
+	return cardTemplate.Execute(w, newCardData(r))
+}
+
+// cardData is the view model for cardTemplate.
+type cardData struct {
+	Recap
+	Range        string
+	Tokens       string
+	Energy       string
+	Longest      string
+	Mix          []mixSegment
+	Achievements []Achievement
+	MoreUnlocks  int
+}
+
+// mixSegment is one model's slice of the model mix bar.
+type mixSegment struct {
+	Label string
+	Color string
+	X     int
+	Width int
+}
+
+// cardAchievements is how many new achievements the card names.
+const cardAchievements = 3
+
+func newCardData(r Recap) cardData {
+	last := r.WeekEnd.AddDate(0, 0, -1)
+	d := cardData{
+		Recap:  r,
+		Range:  r.WeekStart.Format("Jan 2") + " – " + last.Format("Jan 2, 2006"),
+		Tokens: shortCount(r.Cost.Tokens),
+		Energy: fmt.Sprintf("%.1f Wh", r.Cost.EnergyWh),
+	}
+	if r.LongestSession != nil {
+		d.Longest = shortDuration(time.Duration(r.LongestSession.DurationSeconds * float64(time.Second)))
+	}
+
+	x := 0
+	for i := 0; i < len(r.ModelMix); i++ {
+		width := int(r.ModelMix[i].Share*cardInner + 0.5)
+		if i == len(r.ModelMix)-1 {
+			width = cardInner - x // absorb rounding
+		}
+		d.Mix = append(d.Mix, mixSegment{
+			Label: fmt.Sprintf("%s %d%%", r.ModelMix[i].Model, int(r.ModelMix[i].Share*100+0.5)),
+			Color: mixColors[min(i, len(mixColors)-1)],
+			X:     x,
+			Width: width,
+		})
+		x += width
+	}
+
+	d.Achievements = r.NewAchievements
+	if n := len(d.Achievements); n > cardAchievements {
+		d.Achievements = d.Achievements[:cardAchievements]
+		d.MoreUnlocks = n - cardAchievements
+	}
+	return d
+}
+
+// shortCount renders n as "950", "12.3K" or "4.1M".
+func shortCount(n int) string {
+	switch {
+	case n >= 1_000_000:
+		return strconv.FormatFloat(float64(n)/1_000_000, 'f', 1, 64) + "M"
+	case n >= 1_000:
+		return strconv.FormatFloat(float64(n)/1_000, 'f', 1, 64) + "K"
+	}
+	return strconv.Itoa(n)
+}
+
+// shortDuration renders d as "42s", "12m" or "3h05m".
+func shortDuration(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return strconv.Itoa(int(d/time.Second)) + "s"
+	case d < time.Hour:
+		return strconv.Itoa(int(d/time.Minute)) + "m"
+	}
+	return fmt.Sprintf("%dh%02dm", int(d/time.Hour), int(d%time.Hour/time.Minute))
+}
+
+var cardTemplate = template.Must(template.New("card").Funcs(template.FuncMap{
+	"add": func(a, b int) int { return a + b },
+	"mul": func(a, b int) int { return a * b },
+}).Parse(`<svg xmlns="http://www.w3.org/2000/svg" width="1200" height="630" viewBox="0 0 1200 630" role="img" aria-label="Your Week in Agents, {{.Range}}">
+<rect width="1200" height="630" fill="#0d0f1a"/>
+<g font-family="system-ui, -apple-system, Segoe UI, sans-serif" fill="#e6e8f0">
+<text x="60" y="90" font-size="48" font-weight="700">Your Week in Agents</text>
+<text x="60" y="130" font-size="24" fill="#8a90a8">{{.Range}}</text>
+<text x="60" y="230" font-size="64" font-weight="700">{{.Sessions}}</text>
+<text x="60" y="265" font-size="20" fill="#8a90a8">sessions · {{.Completed}} completed</text>
+<text x="420" y="230" font-size="64" font-weight="700">{{.Tokens}}</text>
+<text x="420" y="265" font-size="20" fill="#8a90a8">tokens · {{.Energy}}</text>
+{{- if .LongestSession}}
+<text x="780" y="230" font-size="64" font-weight="700">{{.Longest}}</text>
+<text x="780" y="265" font-size="20" fill="#8a90a8">longest session</text>
+{{- end}}
+{{- if .TopProject}}
+<text x="60" y="340" font-size="20" fill="#8a90a8">Top project</text>
+<text x="60" y="375" font-size="30" font-weight="600">{{.TopProject.Name}}</text>
+{{- end}}
+{{- if .NewAchievements}}
+<text x="620" y="340" font-size="20" fill="#8a90a8">New achievements</text>
+{{- range $i, $a := .Achievements}}
+<text x="620" y="{{add 375 (mul $i 34)}}" font-size="26">{{$a.Name}} <tspan fill="#8a90a8" font-size="18">{{$a.Tier}}</tspan></text>
+{{- end}}
+{{- if .MoreUnlocks}}
+<text x="620" y="{{add 375 (mul (len .Achievements) 34)}}" font-size="20" fill="#8a90a8">and {{.MoreUnlocks}} more</text>
+{{- end}}
+{{- end}}
+{{- if .Mix}}
+<text x="60" y="500" font-size="20" fill="#8a90a8">Model mix</text>
+<g transform="translate(60 520)">
+{{- range .Mix}}
+<rect x="{{.X}}" width="{{.Width}}" height="24" fill="{{.Color}}"><title>{{.Label}}</title></rect>
+{{- end}}
+</g>
+<text x="60" y="580" font-size="18" fill="#8a90a8">{{range $i, $m := .Mix}}{{if $i}} · {{end}}{{$m.Label}}{{end}}</text>
+{{- end}}
+<text x="1140" y="610" font-size="16" fill="#8a90a8" text-anchor="end">agent-racer</text>
+</g>
+</svg>
+`))
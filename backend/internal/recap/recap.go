@@ -0,0 +1,192 @@
+// Package recap builds what users share of their progress: "Your Week in
+// Agents", a summary of the sessions and achievements of one week, and
+// cards of lifetime stats, rendered as SVG.
+package recap
+
+import (
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/agent-racer/backend/internal/session"
+)
+
+// Recap is the JSON shape of GET /api/recap.
+type Recap struct {
+	WeekStart       time.Time       `json:"weekStart"` // Monday 00:00 in the display time zone
+	WeekEnd         time.Time       `json:"weekEnd"`   // the Monday after
+	Sessions        int             `json:"sessions"`
+	Completed       int             `json:"completed"`
+	TopProject      *ProjectTotal   `json:"topProject,omitempty"`
+	Cost            Cost            `json:"cost"`
+	LongestSession  *SessionSummary `json:"longestSession,omitempty"`
+	NewAchievements []Achievement   `json:"newAchievements"`
+	ModelMix        []ModelShare    `json:"modelMix"`
+}
+
+// ProjectTotal is what the week's sessions spent in one project.
+type ProjectTotal struct {
+	Name     string `json:"name"`
+	Sessions int    `json:"sessions"`
+	Tokens   int    `json:"tokens"`
+}
+
+// Cost is what the week's sessions consumed. There is no price list to
+// turn tokens into money, so cost is the tokens themselves and the
+// estimated energy and emissions of serving them.
+type Cost struct {
+	Tokens   int     `json:"tokens"`
+	EnergyWh float64 `json:"energyWh"`
+	CO2Grams float64 `json:"co2Grams"`
+}
+
+// SessionSummary identifies a session in the recap.
+type SessionSummary struct {
+	ID              string    `json:"id"`
+	Name            string    `json:"name"`
+	Project         string    `json:"project,omitempty"`
+	Model           string    `json:"model,omitempty"`
+	StartedAt       time.Time `json:"startedAt"`
+	DurationSeconds float64   `json:"durationSeconds"` // from start to last activity
+}
+
+// Achievement is an achievement unlocked during the week.
+type Achievement struct {
+	ID         string    `json:"id"`
+	Name       string    `json:"name"`
+	Tier       string    `json:"tier"`
+	UnlockedAt time.Time `json:"unlockedAt"`
+}
+
+// ModelShare is one model's part of the week's sessions.
+type ModelShare struct {
+	Model    string  `json:"model"`
+	Sessions int     `json:"sessions"`
+	Tokens   int     `json:"tokens"`
+	Share    float64 `json:"share"` // fraction of the week's sessions, 0-1
+}
+
+// WeekStart returns the Monday 00:00 in loc of the week containing t.
+func WeekStart(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	offset := (int(t.Weekday()) + 6) % 7 // days since Monday
+	return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, loc)
+}
+
+// Build summarises the week starting at weekStart. A session belongs to
+// the week it started in, so a session running past Sunday midnight is not
+// counted twice. unlocked may hold achievements from any week; only those
+// unlocked during this one are kept.
+func Build(sessions []*session.SessionState, unlocked []Achievement, weekStart time.Time) Recap {
+	end := weekStart.AddDate(0, 0, 7)
+	r := Recap{
+		WeekStart:       weekStart,
+		WeekEnd:         end,
+		NewAchievements: []Achievement{},
+		ModelMix:        []ModelShare{},
+	}
+
+	projects := make(map[string]*ProjectTotal)
+	models := make(map[string]*ModelShare)
+	var longest *session.SessionState
+	for i := 0; i < len(sessions); i++ {
+		s := sessions[i]
+		if s.StartedAt.Before(weekStart) || !s.StartedAt.Before(end) {
+			continue
+		}
+		r.Sessions++
+		if s.Activity == session.Complete {
+			r.Completed++
+		}
+		tokens := sessionTokens(s)
+		r.Cost.Tokens += tokens
+		r.Cost.EnergyWh += s.EnergyWh
+		r.Cost.CO2Grams += s.CO2Grams
+
+		if name := projectName(s); name != "" {
+			p := projects[name]
+			if p == nil {
+				p = &ProjectTotal{Name: name}
+				projects[name] = p
+			}
+			p.Sessions++
+			p.Tokens += tokens
+		}
+
+		model := s.Model
+		if model == "" {
+			model = "unknown"
+		}
+		m := models[model]
+		if m == nil {
+			m = &ModelShare{Model: model}
+			models[model] = m
+		}
+		m.Sessions++
+		m.Tokens += tokens
+
+		if longest == nil || s.Elapsed() > longest.Elapsed() {
+			longest = s
+		}
+	}
+
+	for _, p := range projects {
+		if r.TopProject == nil || p.Tokens > r.TopProject.Tokens ||
+			(p.Tokens == r.TopProject.Tokens && p.Name < r.TopProject.Name) {
+			r.TopProject = p
+		}
+	}
+
+	if longest != nil && longest.Elapsed() > 0 {
+		r.LongestSession = &SessionSummary{
+			ID:              longest.ID,
+			Name:            longest.Name,
+			Project:         projectName(longest),
+			Model:           longest.Model,
+			StartedAt:       longest.StartedAt,
+			DurationSeconds: longest.Elapsed().Seconds(),
+		}
+	}
+
+	for _, m := range models {
+		m.Share = float64(m.Sessions) / float64(r.Sessions)
+		r.ModelMix = append(r.ModelMix, *m)
+	}
+	sort.Slice(r.ModelMix, func(i, j int) bool {
+		if r.ModelMix[i].Sessions != r.ModelMix[j].Sessions {
+			return r.ModelMix[i].Sessions > r.ModelMix[j].Sessions
+		}
+		return r.ModelMix[i].Model < r.ModelMix[j].Model
+	})
+
+	for i := 0; i < len(unlocked); i++ {
+		if at := unlocked[i].UnlockedAt; !at.Before(weekStart) && at.Before(end) {
+			r.NewAchievements = append(r.NewAchievements, unlocked[i])
+		}
+	}
+	sort.Slice(r.NewAchievements, func(i, j int) bool {
+		return r.NewAchievements[i].UnlockedAt.Before(r.NewAchievements[j].UnlockedAt)
+	})
+	return r
+}
+
+// sessionTokens is the tokens s burned across compactions, falling back to
+// its last context size for sessions read back from replay files.
+func sessionTokens(s *session.SessionState) int {
+	if s.TokensBurned > 0 {
+		return s.TokensBurned
+	}
+	return s.TokensUsed
+}
+
+// projectName is the repository s worked in, or the last element of its
+// working directory when the repository is not known.
+func projectName(s *session.SessionState) string {
+	if s.Project != "" {
+		return s.Project
+	}
+	if s.WorkingDir == "" {
+		return ""
+	}
+	return filepath.Base(s.WorkingDir)
+}
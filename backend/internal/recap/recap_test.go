@@ -0,0 +1,149 @@
+package recap
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agent-racer/backend/internal/session"
+)
+
+func TestWeekStart(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("no tzdata:", err)
+	}
+	// Sunday 23:30 UTC is already Monday in Berlin.
+	got := WeekStart(time.Date(2026, 3, 8, 23, 30, 0, 0, time.UTC), berlin)
+	if want := time.Date(2026, 3, 9, 0, 0, 0, 0, berlin); !got.Equal(want) {
+		t.Errorf("WeekStart = %v, want %v", got, want)
+	}
+	got = WeekStart(time.Date(2026, 3, 8, 12, 0, 0, 0, time.UTC), time.UTC)
+	if want := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("WeekStart(Sunday) = %v, want %v", got, want)
+	}
+}
+
+func TestBuild(t *testing.T) {
+	week := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	at := func(day, hour int) time.Time { return week.AddDate(0, 0, day).Add(time.Duration(hour) * time.Hour) }
+	run := func(id, project, model string, day, hours, tokens int) *session.SessionState {
+		return &session.SessionState{
+			ID: id, Name: id, Project: project, Model: model, Activity: session.Complete,
+			StartedAt: at(day, 9), LastActivityAt: at(day, 9+hours),
+			TokensUsed: tokens, EnergyWh: 1.5, CO2Grams: 0.5,
+		}
+	}
+	sessions := []*session.SessionState{
+		run("before", "api", "claude-opus-4", -1, 1, 1_000_000), // the Sunday before
+		run("a", "api", "claude-opus-4", 0, 1, 100_000),
+		run("b", "web", "claude-sonnet-4", 2, 5, 150_000),
+		run("c", "api", "claude-sonnet-4", 6, 2, 80_000),
+		run("after", "web", "claude-opus-4", 7, 1, 1_000_000), // the next Monday
+	}
+	sessions[3].TokensBurned = 90_000 // preferred over the last context size
+	sessions[2].Activity = session.Errored
+
+	unlocked := []Achievement{
+		{ID: "old", Name: "Old", Tier: "bronze", UnlockedAt: at(-3, 0)},
+		{ID: "late", Name: "Late", Tier: "gold", UnlockedAt: at(5, 0)},
+		{ID: "early", Name: "Early", Tier: "silver", UnlockedAt: at(1, 0)},
+	}
+
+	r := Build(sessions, unlocked, week)
+	if r.Sessions != 3 || r.Completed != 2 {
+		t.Errorf("sessions = %d, completed = %d, want 3 and 2", r.Sessions, r.Completed)
+	}
+	if r.Cost.Tokens != 340_000 || r.Cost.EnergyWh != 4.5 {
+		t.Errorf("cost = %+v, want 340000 tokens and 4.5 Wh", r.Cost)
+	}
+	if r.TopProject == nil || r.TopProject.Name != "api" || r.TopProject.Tokens != 190_000 || r.TopProject.Sessions != 2 {
+		t.Errorf("top project = %+v, want api with 2 sessions and 190000 tokens", r.TopProject)
+	}
+	if r.LongestSession == nil || r.LongestSession.ID != "b" || r.LongestSession.DurationSeconds != 5*3600 {
+		t.Errorf("longest session = %+v, want b at 5h", r.LongestSession)
+	}
+	if len(r.NewAchievements) != 2 || r.NewAchievements[0].ID != "early" || r.NewAchievements[1].ID != "late" {
+		t.Errorf("new achievements = %+v, want early then late", r.NewAchievements)
+	}
+	if len(r.ModelMix) != 2 || r.ModelMix[0].Model != "claude-sonnet-4" || r.ModelMix[0].Sessions != 2 {
+		t.Fatalf("model mix = %+v, want claude-sonnet-4 first with 2 sessions", r.ModelMix)
+	}
+	if share := r.ModelMix[1].Share; share < 0.33 || share > 0.34 {
+		t.Errorf("claude-opus-4 share = %v, want 1/3", share)
+	}
+}
+
+func TestBuild_EmptyWeek(t *testing.T) {
+	r := Build(nil, nil, time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC))
+	if r.TopProject != nil || r.LongestSession != nil || r.NewAchievements == nil || r.ModelMix == nil {
+		t.Errorf("empty recap = %+v, want no top project or longest session and empty lists", r)
+	}
+	var b strings.Builder
+	if err := WriteSVG(&b, r); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), "Mar 2 – Mar 8, 2026") {
+		t.Errorf("card lacks the week range:\n%s", b.String())
+	}
+}
+
+func TestWriteSVG(t *testing.T) {
+	week := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	r := Recap{
+		WeekStart:      week,
+		WeekEnd:        week.AddDate(0, 0, 7),
+		Sessions:       4,
+		TopProject:     &ProjectTotal{Name: "<script>alert(1)</script>", Tokens: 1_250_000},
+		Cost:           Cost{Tokens: 1_250_000},
+		LongestSession: &SessionSummary{DurationSeconds: 3*3600 + 5*60},
+		ModelMix:       []ModelShare{{Model: "claude-opus-4", Share: 0.75}, {Model: "claude-sonnet-4", Share: 0.25}},
+	}
+	for i := 0; i < 5; i++ {
+		r.NewAchievements = append(r.NewAchievements, Achievement{Name: "Unlock " + string(rune('A'+i)), Tier: "bronze"})
+	}
+
+	var b strings.Builder
+	if err := WriteSVG(&b, r); err != nil {
+		t.Fatal(err)
+	}
+	svg := b.String()
+	for _, want := range []string{"1.2M", "3h05m", "claude-opus-4 75%", "Unlock C", "and 2 more", `width="810"`, `x="810" width="270"`} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("card lacks %q:\n%s", want, svg)
+		}
+	}
+	if strings.Contains(svg, "<script>") || strings.Contains(svg, "Unlock D") {
+		t.Errorf("card has an unescaped name or too many achievements:\n%s", svg)
+	}
+}
+
+func TestParseCardKeys(t *testing.T) {
+	keys, err := ParseCardKeys(" tokens, tier ,tokens")
+	if err != nil || strings.Join(keys, ",") != "tier,tokens" {
+		t.Errorf("ParseCardKeys = %v, %v; want [tier tokens] in card order", keys, err)
+	}
+	if keys, _ := ParseCardKeys(""); strings.Join(keys, ",") != "tier,streak,tokens" {
+		t.Errorf("default keys = %v", keys)
+	}
+	if _, err := ParseCardKeys("tier,projects"); err == nil {
+		t.Error("ParseCardKeys accepted an unknown stat")
+	}
+}
+
+func TestWriteStatCardSVG(t *testing.T) {
+	st := CardStats{Season: "2026-03", Tier: 4, XP: 3420, Streak: 6, LongestStreak: 9, Tokens: 12_400_000, Achievements: 17, AchievementsTotal: 52}
+	var b strings.Builder
+	if err := WriteStatCardSVG(&b, st, []string{"tier", "streak", "achievements"}); err != nil {
+		t.Fatal(err)
+	}
+	svg := b.String()
+	for _, want := range []string{"Season 2026-03", "Tier 4", "3420 XP", ">6</text>", "best 9", ">17</text>", "of 52", `x="240"`, `x="960"`} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("card lacks %q:\n%s", want, svg)
+		}
+	}
+	if strings.Contains(svg, "12.4M") {
+		t.Errorf("card shows tokens, which were not chosen:\n%s", svg)
+	}
+}
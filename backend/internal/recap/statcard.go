@@ -0,0 +1,122 @@
+package recap
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// CardStats are the lifetime figures a stat card can show. They are
+// counts only: nothing on a card names a session, project or model, so it
+// can be shared as it is.
+type CardStats struct {
+	Season            string
+	Tier              int
+	XP                int
+	Streak            int // daily quest streak
+	LongestStreak     int
+	Tokens            int
+	Sessions          int
+	Completions       int
+	Achievements      int // unlocked
+	AchievementsTotal int
+}
+
+// CardKeys are the stats a card can show, in the order it shows them.
+var CardKeys = []string{"tier", "streak", "tokens", "sessions", "achievements"}
+
+// DefaultCardKeys are shown when the request does not choose.
+var DefaultCardKeys = []string{"tier", "streak", "tokens"}
+
+// ParseCardKeys reads a comma-separated list of CardKeys, returning them
+// in card order without repeats. An empty list yields DefaultCardKeys.
+func ParseCardKeys(list string) ([]string, error) {
+	if strings.TrimSpace(list) == "" {
+		return DefaultCardKeys, nil
+	}
+	want := make(map[string]bool)
+	for _, k := range strings.Split(list, ",") {
+		k = strings.TrimSpace(k)
+		if !isCardKey(k) {
+			return nil, fmt.Errorf("unknown stat %q; want any of %s", k, strings.Join(CardKeys, ", "))
+		}
+		want[k] = true
+	}
+	var keys []string
+	for i := 0; i < len(CardKeys); i++ {
+		if want[CardKeys[i]] {
+			keys = append(keys, CardKeys[i])
+		}
+	}
+	return keys, nil
+}
+
+func isCardKey(k string) bool {
+	for i := 0; i < len(CardKeys); i++ {
+		if CardKeys[i] == k {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteStatCardSVG renders the chosen stats as a standalone SVG card.
+func WriteStatCardSVG(w io.Writer, st CardStats, keys []string) error {
+	d := statCardData{Season: st.Season}
+	for i := 0; i < len(keys); i++ {
+		width := cardInner / len(keys)
+		f := statFigure(st, keys[i])
+		f.X = 60 + i*width + width/2
+		d.Figures = append(d.Figures, f)
+	}
+	return statCardTemplate.Execute(w, d)
+}
+
+// statCardData is the view model for statCardTemplate.
+type statCardData struct {
+	Season  string
+	Figures []figure
+}
+
+// figure is one stat on the card: a big value over a label and a detail.
+type figure struct {
+	Value, Label, Detail string
+	X                    int // centre
+}
+
+func statFigure(st CardStats, key string) figure {
+	switch key {
+	case "tier":
+		return figure{Value: "Tier " + strconv.Itoa(st.Tier), Label: "battle pass", Detail: strconv.Itoa(st.XP) + " XP"}
+	case "streak":
+		return figure{Value: strconv.Itoa(st.Streak), Label: "day quest streak", Detail: "best " + strconv.Itoa(st.LongestStreak)}
+	case "tokens":
+		return figure{Value: shortCount(st.Tokens), Label: "tokens burned"}
+	case "sessions":
+		return figure{Value: shortCount(st.Sessions), Label: "sessions", Detail: shortCount(st.Completions) + " completed"}
+	case "achievements":
+		return figure{Value: strconv.Itoa(st.Achievements), Label: "achievements", Detail: "of " + strconv.Itoa(st.AchievementsTotal)}
+	}
+	return figure{}
+}
+
+var statCardTemplate = template.Must(template.New("statcard").Parse(`<svg xmlns="http://www.w3.org/2000/svg" width="1200" height="630" viewBox="0 0 1200 630" role="img" aria-label="Agent Racer stats">
+<rect width="1200" height="630" fill="#0d0f1a"/>
+<g font-family="system-ui, -apple-system, Segoe UI, sans-serif" fill="#e6e8f0" text-anchor="middle">
+<text x="600" y="110" font-size="48" font-weight="700">Agent Racer</text>
+{{- if .Season}}
+<text x="600" y="155" font-size="24" fill="#8a90a8">Season {{.Season}}</text>
+{{- end}}
+{{- range .Figures}}
+<text x="{{.X}}" y="340" font-size="72" font-weight="700">{{.Value}}</text>
+<text x="{{.X}}" y="390" font-size="24" fill="#8a90a8">{{.Label}}</text>
+{{- if .Detail}}
+<text x="{{.X}}" y="425" font-size="20" fill="#8a90a8">{{.Detail}}</text>
+{{- end}}
+{{- end}}
+<text x="1140" y="610" font-size="16" fill="#8a90a8" text-anchor="end">agent-racer</text>
+</g>
+</svg>
+`))
@@ -48,15 +48,29 @@ func (h *Handler) handleList(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	replays, err := h.List()
+	if err != nil {
+		http.Error(w, "failed to list replays", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if replays == nil {
+		_, _ = w.Write([]byte("[]\n"))
+		return
+	}
+	_ = json.NewEncoder(w).Encode(replays)
+}
+
+// List returns the replay files available for playback, newest first. A
+// missing replay directory yields an empty list.
+func (h *Handler) List() ([]ReplayInfo, error) {
 	entries, err := os.ReadDir(h.dir)
 	if err != nil {
 		if os.IsNotExist(err) {
-			w.Header().Set("Content-Type", "application/json")
-			_, _ = w.Write([]byte("[]\n"))
-			return
+			return nil, nil
 		}
-		http.Error(w, "failed to list replays", http.StatusInternalServerError)
-		return
+		return nil, err
 	}
 
 	var replays []ReplayInfo
@@ -79,13 +93,7 @@ func (h *Handler) handleList(w http.ResponseWriter, r *http.Request) {
 	sort.Slice(replays, func(i, j int) bool {
 		return replays[i].CreatedAt.After(replays[j].CreatedAt)
 	})
-
-	w.Header().Set("Content-Type", "application/json")
-	if replays == nil {
-		_, _ = w.Write([]byte("[]\n"))
-		return
-	}
-	_ = json.NewEncoder(w).Encode(replays)
+	return replays, nil
 }
 
 func (h *Handler) handleGet(w http.ResponseWriter, r *http.Request) {
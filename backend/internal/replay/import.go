@@ -0,0 +1,93 @@
+package replay
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/agent-racer/backend/internal/session"
+)
+
+// Import writes finished sessions that were never recorded into a new
+// replay file in dir, one snapshot per session at the time it ended, so
+// history read from the replays includes them. Sessions are sanitized as
+// the Recorder sanitizes them, with the user's filter pf (nil = none), and
+// those whose ID already appears in a replay are skipped, so importing the
+// same sessions twice adds nothing. The file is named and timestamped after
+// its oldest snapshot, so it sorts and ages with the recordings from that
+// time. It returns the file's path, empty when there was nothing to write,
+// and how many sessions went into it.
+func Import(dir string, sessions []*session.SessionState, pf *session.PrivacyFilter) (string, int, error) {
+	recorded, err := FinalStates(dir)
+	if err != nil {
+		return "", 0, err
+	}
+	seen := make(map[string]bool, len(recorded))
+	for _, s := range recorded {
+		seen[s.ID] = true
+	}
+
+	filtered := sessions
+	if pf != nil {
+		filtered = pf.FilterSlice(sessions)
+	}
+	var snaps []Snapshot
+	for _, s := range filtered {
+		end := endedAt(s)
+		if end.IsZero() || seen[s.ID] {
+			continue
+		}
+		seen[s.ID] = true
+		snaps = append(snaps, Snapshot{Timestamp: end.UTC(), Sessions: []*session.SessionState{replayPrivacy.Apply(s)}})
+	}
+	if len(snaps) == 0 {
+		return "", 0, nil
+	}
+	sort.SliceStable(snaps, func(i, j int) bool { return snaps[i].Timestamp.Before(snaps[j].Timestamp) })
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", 0, fmt.Errorf("replay: create dir %s: %w", dir, err)
+	}
+	first, last := snaps[0].Timestamp, snaps[len(snaps)-1].Timestamp
+	path := filepath.Join(dir, first.Local().Format("2006-01-02_15-04-05")+"-import.jsonl")
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return "", 0, fmt.Errorf("replay: create %s: %w", path, err)
+	}
+	enc := json.NewEncoder(f)
+	for i := 0; i < len(snaps); i++ {
+		if err := enc.Encode(snaps[i]); err != nil {
+			_ = f.Close()
+			_ = os.Remove(path)
+			return "", 0, fmt.Errorf("replay: write %s: %w", path, err)
+		}
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		_ = os.Remove(path)
+		return "", 0, fmt.Errorf("replay: sync %s: %w", path, err)
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(path)
+		return "", 0, fmt.Errorf("replay: close %s: %w", path, err)
+	}
+	if err := os.Chtimes(path, last, last); err != nil {
+		return "", 0, fmt.Errorf("replay: set time on %s: %w", path, err)
+	}
+	return path, len(snaps), nil
+}
+
+// endedAt returns when a finished session ended, or zero for one that
+// hasn't.
+func endedAt(s *session.SessionState) time.Time {
+	if !s.IsTerminal() {
+		return time.Time{}
+	}
+	if s.CompletedAt != nil {
+		return *s.CompletedAt
+	}
+	return s.LastActivityAt
+}
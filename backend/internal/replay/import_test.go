@@ -0,0 +1,70 @@
+package replay
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agent-racer/backend/internal/session"
+)
+
+func TestImport(t *testing.T) {
+	dir := t.TempDir()
+	base := time.Date(2025, 12, 1, 9, 0, 0, 0, time.UTC)
+	finished := func(id string, end time.Duration) *session.SessionState {
+		at := base.Add(end)
+		return &session.SessionState{
+			ID: id, Activity: session.Complete, StartedAt: base, LastActivityAt: at, CompletedAt: &at,
+			WorkingDir: "/home/me/" + id, PID: 42,
+		}
+	}
+	writeReplayFile(t, dir, "2025-12-01_08-00-00.jsonl", []Snapshot{
+		{Timestamp: base, Sessions: []*session.SessionState{finished("recorded", 0)}},
+	})
+
+	sessions := []*session.SessionState{
+		finished("late", 2*time.Hour),
+		finished("early", time.Hour),
+		finished("recorded", 3*time.Hour),
+		{ID: "running", Activity: session.Thinking, StartedAt: base},
+	}
+	path, n, err := Import(dir, sessions, nil)
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if n != 2 || !strings.HasSuffix(path, "-import.jsonl") {
+		t.Fatalf("Import = %s, %d; want an import file with 2 sessions", path, n)
+	}
+	if info, err := os.Stat(path); err != nil || !info.ModTime().Equal(base.Add(2*time.Hour)) {
+		t.Errorf("modtime = %v, %v; want the last session's end", info, err)
+	}
+
+	states, err := FinalStates(dir)
+	if err != nil {
+		t.Fatalf("FinalStates: %v", err)
+	}
+	byID := make(map[string]*session.SessionState)
+	for _, s := range states {
+		byID[s.ID] = s
+	}
+	if len(states) != 3 || byID["early"] == nil || byID["late"] == nil {
+		t.Fatalf("replayed sessions = %+v", states)
+	}
+	if s := byID["early"]; s.WorkingDir != "early" || s.PID != 0 {
+		t.Errorf("imported session not sanitized: dir %q, pid %d", s.WorkingDir, s.PID)
+	}
+	points, err := SessionTimeline(dir, "late", time.Time{}, 0)
+	if err != nil || len(points) != 1 {
+		t.Errorf("timeline of late = %+v, %v", points, err)
+	}
+
+	// Importing again finds nothing new.
+	path, n, err = Import(dir, sessions, nil)
+	if err != nil || path != "" || n != 0 {
+		t.Errorf("second Import = %q, %d, %v; want nothing written", path, n, err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 2 {
+		t.Errorf("got %d replay files, want 2", len(entries))
+	}
+}
@@ -0,0 +1,72 @@
+package replay
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/agent-racer/backend/internal/session"
+)
+
+// FinalStates scans every replay file in dir and returns the last recorded
+// state of each session, ordered by start time. Sessions are as sanitized
+// as the recorder left them: working directories are basenames and IDs may
+// be masked. A missing dir yields nil.
+func FinalStates(dir string) ([]*session.SessionState, error) {
+	return FinalStatesSince(dir, time.Time{})
+}
+
+// FinalStatesSince is FinalStates over the replay files written to at or
+// after since, which hold every session recorded since then along with
+// some that ended earlier.
+func FinalStatesSince(dir string, since time.Time) ([]*session.SessionState, error) {
+	files, err := replayFiles(dir, since)
+	if err != nil {
+		return nil, err
+	}
+
+	last := make(map[string]*session.SessionState)
+	for _, path := range files {
+		if err := collectFinalStates(last, path); err != nil {
+			return nil, err
+		}
+	}
+
+	var states []*session.SessionState
+	for _, s := range last {
+		states = append(states, s)
+	}
+	sort.Slice(states, func(i, j int) bool {
+		if !states[i].StartedAt.Equal(states[j].StartedAt) {
+			return states[i].StartedAt.Before(states[j].StartedAt)
+		}
+		return states[i].ID < states[j].ID
+	})
+	return states, nil
+}
+
+func collectFinalStates(last map[string]*session.SessionState, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxTimelineLineBytes)
+	for scanner.Scan() {
+		var snap Snapshot
+		// A server killed mid-write leaves a truncated last line.
+		if err := json.Unmarshal(scanner.Bytes(), &snap); err != nil {
+			continue
+		}
+		for _, s := range snap.Sessions {
+			if s != nil && s.ID != "" {
+				last[s.ID] = s
+			}
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,75 @@
+package replay
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/agent-racer/backend/internal/session"
+)
+
+func TestFinalStates(t *testing.T) {
+	dir := t.TempDir()
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	state := func(id string, activity session.Activity, started time.Duration) *session.SessionState {
+		return &session.SessionState{ID: id, Activity: activity, StartedAt: base.Add(started)}
+	}
+
+	writeReplayFile(t, dir, "2026-03-01_12-00-00.jsonl", []Snapshot{
+		{Timestamp: base, Sessions: []*session.SessionState{state("b", session.Thinking, time.Minute), state("a", session.Thinking, 0)}},
+		{Timestamp: base.Add(time.Minute), Sessions: []*session.SessionState{state("b", session.Complete, time.Minute)}},
+	})
+	writeReplayFile(t, dir, "2026-03-01_13-00-00.jsonl", []Snapshot{
+		{Timestamp: base.Add(time.Hour), Sessions: []*session.SessionState{state("a", session.Errored, 0)}},
+	})
+	// A truncated final line is skipped rather than failing the scan.
+	f, err := os.OpenFile(filepath.Join(dir, "2026-03-01_13-00-00.jsonl"), os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.WriteString(`{"t":"2026-03-01T14:00:00Z","s":[{"id":"a"`)
+	_ = f.Close()
+
+	states, err := FinalStates(dir)
+	if err != nil {
+		t.Fatalf("FinalStates: %v", err)
+	}
+	if len(states) != 2 {
+		t.Fatalf("got %d states, want 2: %+v", len(states), states)
+	}
+	if states[0].ID != "a" || states[0].Activity != session.Errored {
+		t.Errorf("states[0] = %s/%s, want a/errored", states[0].ID, states[0].Activity)
+	}
+	if states[1].ID != "b" || states[1].Activity != session.Complete {
+		t.Errorf("states[1] = %s/%s, want b/complete", states[1].ID, states[1].Activity)
+	}
+
+	missing, err := FinalStates(filepath.Join(dir, "missing"))
+	if err != nil || missing != nil {
+		t.Errorf("FinalStates(missing) = %v, %v; want nil, nil", missing, err)
+	}
+}
+
+func TestFinalStatesSince(t *testing.T) {
+	dir := t.TempDir()
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	writeReplayFile(t, dir, "2026-03-01_12-00-00.jsonl", []Snapshot{
+		{Timestamp: base, Sessions: []*session.SessionState{{ID: "old", StartedAt: base}}},
+	})
+	writeReplayFile(t, dir, "2026-03-08_12-00-00.jsonl", []Snapshot{
+		{Timestamp: base.AddDate(0, 0, 7), Sessions: []*session.SessionState{{ID: "new", StartedAt: base.AddDate(0, 0, 7)}}},
+	})
+	old := filepath.Join(dir, "2026-03-01_12-00-00.jsonl")
+	if err := os.Chtimes(old, base, base.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	states, err := FinalStatesSince(dir, base.AddDate(0, 0, 2))
+	if err != nil {
+		t.Fatalf("FinalStatesSince: %v", err)
+	}
+	if len(states) != 1 || states[0].ID != "new" {
+		t.Errorf("got %+v, want only the session from the file written since", states)
+	}
+}
@@ -0,0 +1,144 @@
+package replay
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/agent-racer/backend/internal/session"
+)
+
+// TimelinePoint is one sample of a single session's progress, taken from
+// the replay snapshots it appears in.
+type TimelinePoint struct {
+	Timestamp          time.Time        `json:"t"`
+	Activity           session.Activity `json:"activity"`
+	TokensUsed         int              `json:"tokensUsed"`
+	ContextUtilization float64          `json:"contextUtilization"`
+	CurrentTool        string           `json:"currentTool,omitempty"`
+}
+
+// maxTimelineLineBytes bounds a single replay line; snapshots with many
+// sessions can be large.
+const maxTimelineLineBytes = 16 << 20
+
+// SessionTimeline scans the replay files in dir for snapshots of session
+// id taken at or after since and returns them oldest first. Consecutive
+// samples that changed nothing are dropped, and the result is thinned
+// evenly to at most max points (0 = no limit). A missing dir yields nil.
+func SessionTimeline(dir, id string, since time.Time, max int) ([]TimelinePoint, error) {
+	files, err := replayFiles(dir, since)
+	if err != nil {
+		return nil, err
+	}
+
+	var points []TimelinePoint
+	for _, path := range files {
+		points, err = appendTimeline(points, path, id, since)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return thin(points, max), nil
+}
+
+// replayFiles lists the replay files in dir last written at or after since
+// (zero = all), oldest first. A missing dir yields nil.
+func replayFiles(dir string, since time.Time) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var files []string
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".jsonl") {
+			continue
+		}
+		// A file last written before since cannot hold a later snapshot.
+		if info, err := e.Info(); err == nil && !since.IsZero() && info.ModTime().Before(since) {
+			continue
+		}
+		files = append(files, filepath.Join(dir, e.Name()))
+	}
+	// Replay file names are start timestamps, so name order is time order.
+	sort.Strings(files)
+	return files, nil
+}
+
+func appendTimeline(points []TimelinePoint, path, id string, since time.Time) ([]TimelinePoint, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return points, err
+	}
+	defer func() { _ = f.Close() }()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxTimelineLineBytes)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		// Cheap pre-check before decoding the whole snapshot.
+		if !bytes.Contains(line, []byte(id)) {
+			continue
+		}
+		var snap Snapshot
+		if err := json.Unmarshal(line, &snap); err != nil {
+			continue
+		}
+		if snap.Timestamp.Before(since) {
+			continue
+		}
+		for _, s := range snap.Sessions {
+			if s.ID != id {
+				continue
+			}
+			p := TimelinePoint{
+				Timestamp:          snap.Timestamp,
+				Activity:           s.Activity,
+				TokensUsed:         s.TokensUsed,
+				ContextUtilization: s.ContextUtilization,
+				CurrentTool:        s.CurrentTool,
+			}
+			if n := len(points); n > 0 && samePoint(points[n-1], p) {
+				break
+			}
+			points = append(points, p)
+			break
+		}
+	}
+	return points, scanner.Err()
+}
+
+func samePoint(a, b TimelinePoint) bool {
+	return a.Activity == b.Activity && a.TokensUsed == b.TokensUsed && a.CurrentTool == b.CurrentTool
+}
+
+// thin keeps at most max points, evenly spaced, always including the last.
+func thin(points []TimelinePoint, max int) []TimelinePoint {
+	if max <= 0 || len(points) <= max {
+		return points
+	}
+	if max == 1 {
+		return points[len(points)-1:]
+	}
+	out := make([]TimelinePoint, 0, max)
+	step := float64(len(points)-1) / float64(max-1)
+	for i := 0; i < max; i++ {
+		out = append(out, points[int(float64(i)*step+0.5)])
+	}
+	return out
+}
+
+// SessionTimeline returns the timeline of session id from the replay files
+// this handler serves. See the package-level SessionTimeline.
+func (h *Handler) SessionTimeline(id string, since time.Time, max int) ([]TimelinePoint, error) {
+	return SessionTimeline(h.dir, id, since, max)
+}
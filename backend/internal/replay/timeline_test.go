@@ -0,0 +1,85 @@
+package replay
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/agent-racer/backend/internal/session"
+)
+
+func writeReplayFile(t *testing.T, dir, name string, snaps []Snapshot) {
+	t.Helper()
+	f, err := os.Create(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+	enc := json.NewEncoder(f)
+	for _, s := range snaps {
+		if err := enc.Encode(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestSessionTimeline(t *testing.T) {
+	dir := t.TempDir()
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	snap := func(offset time.Duration, sessions ...*session.SessionState) Snapshot {
+		return Snapshot{Timestamp: base.Add(offset), Sessions: sessions}
+	}
+	a := func(activity session.Activity, tokens int) *session.SessionState {
+		return &session.SessionState{ID: "a", Activity: activity, TokensUsed: tokens}
+	}
+	other := &session.SessionState{ID: "b", Activity: session.Thinking, TokensUsed: 9}
+
+	writeReplayFile(t, dir, "2026-03-01_11-00-00.jsonl", []Snapshot{
+		snap(-time.Minute, a(session.Starting, 0)),
+		snap(0, a(session.Thinking, 100), other),
+		snap(5*time.Second, a(session.Thinking, 100)), // unchanged, dropped
+	})
+	writeReplayFile(t, dir, "2026-03-01_12-30-00.jsonl", []Snapshot{
+		snap(10*time.Second, other),
+		snap(20*time.Second, a(session.ToolUse, 250)),
+		snap(30*time.Second, a(session.Complete, 300)),
+	})
+
+	points, err := SessionTimeline(dir, "a", base, 0)
+	if err != nil {
+		t.Fatalf("SessionTimeline: %v", err)
+	}
+	want := []struct {
+		activity session.Activity
+		tokens   int
+	}{
+		{session.Thinking, 100},
+		{session.ToolUse, 250},
+		{session.Complete, 300},
+	}
+	if len(points) != len(want) {
+		t.Fatalf("got %d points, want %d: %+v", len(points), len(want), points)
+	}
+	for i := 0; i < len(want); i++ {
+		if points[i].Activity != want[i].activity || points[i].TokensUsed != want[i].tokens {
+			t.Errorf("point %d = %+v, want %v/%d", i, points[i], want[i].activity, want[i].tokens)
+		}
+	}
+
+	thinned, err := SessionTimeline(dir, "a", base, 2)
+	if err != nil {
+		t.Fatalf("SessionTimeline: %v", err)
+	}
+	if len(thinned) != 2 || thinned[1].Activity != session.Complete {
+		t.Errorf("thinned = %+v, want 2 points ending with complete", thinned)
+	}
+}
+
+func TestSessionTimeline_MissingDir(t *testing.T) {
+	points, err := SessionTimeline(filepath.Join(t.TempDir(), "missing"), "a", time.Time{}, 0)
+	if err != nil || points != nil {
+		t.Errorf("SessionTimeline(missing) = %v, %v; want nil, nil", points, err)
+	}
+}
@@ -0,0 +1,166 @@
+package session
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Severity says how urgently a client should announce a session's
+// activity, so screen-reader clients need not rank activities themselves.
+type Severity string
+
+const (
+	SeverityInfo    Severity = "info"    // working or resting; announce politely, if at all
+	SeverityNotice  Severity = "notice"  // waiting for the user's next prompt
+	SeverityWarning Severity = "warning" // blocked on the user's approval
+	SeverityError   Severity = "error"   // errored or lost
+	SeveritySuccess Severity = "success" // finished
+)
+
+// Severity returns how urgently a session with activity a should be
+// announced.
+func (a Activity) Severity() Severity {
+	switch a {
+	case Waiting:
+		return SeverityNotice
+	case NeedsApproval:
+		return SeverityWarning
+	case Errored, Lost:
+		return SeverityError
+	case Complete:
+		return SeveritySuccess
+	}
+	return SeverityInfo
+}
+
+// statusPhrases holds, per language, the sentence for each activity and the
+// words for durations. {name}, {tool} and {duration} are filled in; an
+// activity has a second form, keyed with a trailing "+tool", for when the
+// session names the tool it is on.
+type statusPhrases struct {
+	sentences map[string]string
+	units     [3][2]string // hour, minute, second; singular then plural
+}
+
+var statusCatalog = map[string]statusPhrases{
+	"en": {
+		sentences: map[string]string{
+			"starting":            "Session {name} is starting",
+			"thinking":            "Session {name} is thinking, running for {duration}",
+			"tool_use":            "Session {name} is using a tool, running for {duration}",
+			"tool_use+tool":       "Session {name} is running {tool}, running for {duration}",
+			"waiting":             "Session {name} is waiting for your input, {duration}",
+			"needs_approval":      "Session {name} needs your approval, {duration}",
+			"needs_approval+tool": "Session {name} needs your approval for {tool}, {duration}",
+			"idle":                "Session {name} is idle, {duration}",
+			"complete":            "Session {name} finished after {duration}",
+			"errored":             "Session {name} stopped with an error after {duration}",
+			"lost":                "Session {name} was lost, last seen {duration} ago",
+		},
+		units: [3][2]string{{"hour", "hours"}, {"minute", "minutes"}, {"second", "seconds"}},
+	},
+	"de": {
+		sentences: map[string]string{
+			"starting":            "Sitzung {name} startet",
+			"thinking":            "Sitzung {name} denkt nach, läuft seit {duration}",
+			"tool_use":            "Sitzung {name} nutzt ein Werkzeug, läuft seit {duration}",
+			"tool_use+tool":       "Sitzung {name} führt {tool} aus, läuft seit {duration}",
+			"waiting":             "Sitzung {name} wartet auf deine Eingabe, seit {duration}",
+			"needs_approval":      "Sitzung {name} braucht deine Freigabe, seit {duration}",
+			"needs_approval+tool": "Sitzung {name} braucht deine Freigabe für {tool}, seit {duration}",
+			"idle":                "Sitzung {name} ist untätig, seit {duration}",
+			"complete":            "Sitzung {name} ist nach {duration} fertig",
+			"errored":             "Sitzung {name} ist nach {duration} mit einem Fehler abgebrochen",
+			"lost":                "Sitzung {name} ist verloren, zuletzt gesehen vor {duration}",
+		},
+		// Dative plural, as every sentence puts the duration after seit,
+		// nach or vor.
+		units: [3][2]string{{"Stunde", "Stunden"}, {"Minute", "Minuten"}, {"Sekunde", "Sekunden"}},
+	},
+	"es": {
+		sentences: map[string]string{
+			"starting":            "La sesión {name} está arrancando",
+			"thinking":            "La sesión {name} está pensando, lleva {duration}",
+			"tool_use":            "La sesión {name} está usando una herramienta, lleva {duration}",
+			"tool_use+tool":       "La sesión {name} está ejecutando {tool}, lleva {duration}",
+			"waiting":             "La sesión {name} espera tu respuesta desde hace {duration}",
+			"needs_approval":      "La sesión {name} necesita tu aprobación desde hace {duration}",
+			"needs_approval+tool": "La sesión {name} necesita tu aprobación para {tool} desde hace {duration}",
+			"idle":                "La sesión {name} está inactiva desde hace {duration}",
+			"complete":            "La sesión {name} terminó después de {duration}",
+			"errored":             "La sesión {name} se detuvo con un error después de {duration}",
+			"lost":                "La sesión {name} se perdió, vista por última vez hace {duration}",
+		},
+		units: [3][2]string{{"hora", "horas"}, {"minuto", "minutos"}, {"segundo", "segundos"}},
+	},
+}
+
+// Describe returns a copy of s with Severity and StatusText filled in for
+// clients, in lang (English when lang has no phrases) as of now. The
+// duration is how long the session has been running, for active ones, or
+// has been in its activity, for ones that are waiting, idle or done.
+func (s *SessionState) Describe(lang string, now time.Time) *SessionState {
+	phrases, ok := statusCatalog[lang]
+	if !ok {
+		phrases = statusCatalog["en"]
+	}
+
+	var since, until time.Time
+	switch s.Activity {
+	case Thinking, ToolUse:
+		since, until = s.StartedAt, now
+	case Waiting, NeedsApproval, Idle, Lost:
+		since, until = s.LastActivityAt, now
+	case Complete, Errored:
+		since, until = s.StartedAt, now
+		if s.CompletedAt != nil {
+			until = *s.CompletedAt
+		}
+	}
+
+	key := s.Activity.String()
+	if s.CurrentTool != "" && (s.Activity == ToolUse || s.Activity == NeedsApproval) {
+		key += "+tool"
+	}
+	tmpl, ok := phrases.sentences[key]
+	if !ok {
+		tmpl = statusCatalog["en"].sentences[key]
+	}
+	if since.IsZero() {
+		since = until
+	}
+
+	d := *s
+	d.Severity = s.Activity.Severity()
+	d.StatusText = strings.NewReplacer(
+		"{name}", s.Name,
+		"{tool}", s.CurrentTool,
+		"{duration}", phrases.duration(until.Sub(since)),
+	).Replace(tmpl)
+	return &d
+}
+
+// duration says d in whole hours and minutes, or in seconds under a
+// minute, e.g. "1 hour 5 minutes" or "40 seconds".
+func (p statusPhrases) duration(d time.Duration) string {
+	d = max(d, 0)
+	unit := func(n int, i int) string {
+		word := p.units[i][1]
+		if n == 1 {
+			word = p.units[i][0]
+		}
+		return strconv.Itoa(n) + " " + word
+	}
+	if d < time.Minute {
+		return unit(int(d/time.Second), 2)
+	}
+	hours, minutes := int(d/time.Hour), int(d%time.Hour/time.Minute)
+	switch {
+	case hours == 0:
+		return unit(minutes, 1)
+	case minutes == 0:
+		return unit(hours, 0)
+	}
+	return unit(hours, 0) + " " + unit(minutes, 1)
+}
@@ -0,0 +1,106 @@
+package session
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDescribe(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	done := now.Add(-10 * time.Minute)
+
+	tests := []struct {
+		name  string
+		state SessionState
+		lang  string
+		sev   Severity
+		want  string
+	}{
+		{
+			name:  "waiting counts from last activity",
+			state: SessionState{Name: "api", Activity: Waiting, StartedAt: now.Add(-time.Hour), LastActivityAt: now.Add(-3 * time.Minute)},
+			sev:   SeverityNotice,
+			want:  "Session api is waiting for your input, 3 minutes",
+		},
+		{
+			name:  "tool use counts from start",
+			state: SessionState{Name: "api", Activity: ToolUse, CurrentTool: "Bash", StartedAt: now.Add(-65 * time.Minute), LastActivityAt: now},
+			sev:   SeverityInfo,
+			want:  "Session api is running Bash, running for 1 hour 5 minutes",
+		},
+		{
+			name:  "approval without a tool",
+			state: SessionState{Name: "api", Activity: NeedsApproval, LastActivityAt: now.Add(-time.Second)},
+			sev:   SeverityWarning,
+			want:  "Session api needs your approval, 1 second",
+		},
+		{
+			name:  "complete counts to completion",
+			state: SessionState{Name: "api", Activity: Complete, StartedAt: done.Add(-2 * time.Hour), CompletedAt: &done},
+			sev:   SeveritySuccess,
+			want:  "Session api finished after 2 hours",
+		},
+		{
+			name:  "lost",
+			state: SessionState{Name: "api", Activity: Lost, LastActivityAt: now.Add(-40 * time.Second)},
+			sev:   SeverityError,
+			want:  "Session api was lost, last seen 40 seconds ago",
+		},
+		{
+			name:  "starting has no duration",
+			state: SessionState{Name: "api", Activity: Starting},
+			sev:   SeverityInfo,
+			want:  "Session api is starting",
+		},
+		{
+			name:  "german",
+			state: SessionState{Name: "api", Activity: Waiting, LastActivityAt: now.Add(-time.Minute)},
+			lang:  "de",
+			sev:   SeverityNotice,
+			want:  "Sitzung api wartet auf deine Eingabe, seit 1 Minute",
+		},
+		{
+			name:  "spanish",
+			state: SessionState{Name: "api", Activity: Errored, StartedAt: done.Add(-5 * time.Minute), CompletedAt: &done},
+			lang:  "es",
+			sev:   SeverityError,
+			want:  "La sesión api se detuvo con un error después de 5 minutos",
+		},
+		{
+			name:  "unknown language falls back to english",
+			state: SessionState{Name: "api", Activity: Idle, LastActivityAt: now.Add(-2 * time.Hour)},
+			lang:  "xx",
+			sev:   SeverityInfo,
+			want:  "Session api is idle, 2 hours",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.state.Describe(tt.lang, now)
+			if got.Severity != tt.sev {
+				t.Errorf("Severity = %q, want %q", got.Severity, tt.sev)
+			}
+			if got.StatusText != tt.want {
+				t.Errorf("StatusText = %q, want %q", got.StatusText, tt.want)
+			}
+			if tt.state.StatusText != "" {
+				t.Error("Describe modified the original state")
+			}
+		})
+	}
+}
+
+func TestStatusCatalogCoversEveryActivity(t *testing.T) {
+	for lang, phrases := range statusCatalog {
+		for a := Starting; a <= NeedsApproval; a++ {
+			if _, ok := phrases.sentences[a.String()]; !ok {
+				t.Errorf("%s: no sentence for %s", lang, a)
+			}
+		}
+		for _, key := range []string{"tool_use+tool", "needs_approval+tool"} {
+			if _, ok := phrases.sentences[key]; !ok {
+				t.Errorf("%s: no sentence for %s", lang, key)
+			}
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package session
+
+import (
+	"sort"
+	"sync"
+)
+
+// MinBaselineSessions is how many finished sessions a Baseline needs before
+// it ranks anything; with fewer, a percentile says little.
+const MinBaselineSessions = 10
+
+// Percentiles place a running session among the finished sessions in a
+// Baseline, as the percentage of them it is ahead of, from 0 to 100.
+// 95 for Duration reads "in the top 5% longest".
+type Percentiles struct {
+	Duration int `json:"duration"` // by Elapsed
+	BurnRate int `json:"burnRate"` // BurnRateSmoothed against their average burn rate
+}
+
+// Baseline remembers the durations and average burn rates of the most
+// recent finished sessions, to rank running ones against. Safe for
+// concurrent use.
+type Baseline struct {
+	mu    sync.RWMutex
+	limit int
+	// samples holds [duration seconds, tokens per minute] in the order the
+	// sessions finished; durations and rates are the same values sorted.
+	samples   [][2]float64
+	durations []float64
+	rates     []float64
+}
+
+// NewBaseline returns an empty baseline holding up to limit sessions.
+func NewBaseline(limit int) *Baseline {
+	return &Baseline{limit: max(limit, 1)}
+}
+
+// Add records finished sessions, dropping the oldest beyond the limit.
+// Sessions that never got going are skipped.
+func (b *Baseline) Add(states ...*SessionState) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, s := range states {
+		elapsed := s.Elapsed()
+		if elapsed <= 0 {
+			continue
+		}
+		tokens := s.TokensBurned
+		if tokens == 0 {
+			// Recorded replays don't keep TokensBurned.
+			tokens = s.TokensUsed
+		}
+		b.samples = append(b.samples, [2]float64{elapsed.Seconds(), float64(tokens) / elapsed.Minutes()})
+	}
+	if n := len(b.samples) - b.limit; n > 0 {
+		b.samples = append(b.samples[:0:0], b.samples[n:]...)
+	}
+
+	b.durations = b.durations[:0]
+	b.rates = b.rates[:0]
+	for i := 0; i < len(b.samples); i++ {
+		b.durations = append(b.durations, b.samples[i][0])
+		b.rates = append(b.rates, b.samples[i][1])
+	}
+	sort.Float64s(b.durations)
+	sort.Float64s(b.rates)
+}
+
+// Len returns how many finished sessions the baseline holds.
+func (b *Baseline) Len() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.samples)
+}
+
+// Rank places s among the finished sessions, or returns nil while there
+// are fewer than MinBaselineSessions of them. A nil Baseline ranks nothing.
+func (b *Baseline) Rank(s *SessionState) *Percentiles {
+	if b == nil {
+		return nil
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if len(b.samples) < MinBaselineSessions {
+		return nil
+	}
+	return &Percentiles{
+		Duration: percentileOf(b.durations, s.Elapsed().Seconds()),
+		BurnRate: percentileOf(b.rates, s.BurnRateSmoothed),
+	}
+}
+
+// percentileOf returns the percentage of sorted that v is above.
+func percentileOf(sorted []float64, v float64) int {
+	below := sort.SearchFloat64s(sorted, v)
+	return below * 100 / len(sorted)
+}
@@ -0,0 +1,48 @@
+package session
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBaselineRank(t *testing.T) {
+	start := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	finished := func(minutes, tokens int) *SessionState {
+		return &SessionState{StartedAt: start, LastActivityAt: start.Add(time.Duration(minutes) * time.Minute), TokensUsed: tokens}
+	}
+
+	b := NewBaseline(20)
+	// 10..100 minutes, all at 1,000 tokens a minute.
+	for i := 1; i < MinBaselineSessions; i++ {
+		b.Add(finished(i*10, i*10_000))
+	}
+	live := &SessionState{StartedAt: start, LastActivityAt: start.Add(95 * time.Minute), BurnRateSmoothed: 2000}
+	if p := b.Rank(live); p != nil {
+		t.Fatalf("ranked against %d sessions: %+v", b.Len(), p)
+	}
+	b.Add(finished(100, 100_000), &SessionState{StartedAt: start}) // the second never ran
+
+	p := b.Rank(live)
+	if p == nil {
+		t.Fatalf("no rank with %d sessions", b.Len())
+	}
+	if p.Duration != 90 || p.BurnRate != 100 {
+		t.Errorf("Rank = %+v, want duration 90, burn rate 100", *p)
+	}
+
+	// The oldest drop out once the limit is reached.
+	for i := 0; i < 20; i++ {
+		b.Add(finished(200, 0))
+	}
+	if b.Len() != 20 {
+		t.Errorf("Len = %d, want 20", b.Len())
+	}
+	if p := b.Rank(live); p.Duration != 0 {
+		t.Errorf("Duration = %d after the short sessions aged out, want 0", p.Duration)
+	}
+
+	var none *Baseline
+	if none.Rank(live) != nil {
+		t.Error("nil Baseline ranked a session")
+	}
+}
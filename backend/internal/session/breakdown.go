@@ -0,0 +1,60 @@
+package session
+
+// Roles a message's text is attributed to in a TokenBreakdown.
+const (
+	RoleUser       = "user"        // prompts the user typed
+	RoleAssistant  = "assistant"   // replies and tool inputs
+	RoleToolResult = "tool_result" // tool output fed back to the model
+	RoleSystem     = "system"      // text the client injected, such as compaction summaries
+)
+
+// TokenBreakdown splits a session's context tokens by the role of the
+// messages that put them there.
+type TokenBreakdown struct {
+	User       int `json:"user"`
+	Assistant  int `json:"assistant"`
+	ToolResult int `json:"toolResult"`
+	System     int `json:"system"`
+}
+
+// Add attributes n tokens to role. Unknown roles count as system.
+func (b *TokenBreakdown) Add(role string, n int) {
+	switch role {
+	case RoleUser:
+		b.User += n
+	case RoleAssistant:
+		b.Assistant += n
+	case RoleToolResult:
+		b.ToolResult += n
+	default:
+		b.System += n
+	}
+}
+
+// Total returns the tokens across all roles.
+func (b TokenBreakdown) Total() int {
+	return b.User + b.Assistant + b.ToolResult + b.System
+}
+
+// Scale returns b's shares applied to total, so the parts sum to total.
+// Rounding leftovers go to the largest part. A zero breakdown stays zero.
+func (b TokenBreakdown) Scale(total int) TokenBreakdown {
+	sum := b.Total()
+	if sum == 0 {
+		return TokenBreakdown{}
+	}
+	parts := [4]*int{&b.User, &b.Assistant, &b.ToolResult, &b.System}
+	largest := parts[0]
+	for i := 1; i < len(parts); i++ {
+		if *parts[i] > *largest {
+			largest = parts[i]
+		}
+	}
+	rest := total
+	for i := 0; i < len(parts); i++ {
+		*parts[i] = *parts[i] * total / sum
+		rest -= *parts[i]
+	}
+	*largest += rest
+	return b
+}
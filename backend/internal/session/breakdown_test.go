@@ -0,0 +1,23 @@
+package session
+
+import "testing"
+
+func TestTokenBreakdownScale(t *testing.T) {
+	var b TokenBreakdown
+	b.Add(RoleUser, 100)
+	b.Add(RoleAssistant, 300)
+	b.Add(RoleToolResult, 600)
+	b.Add("attachment", 0)
+
+	got := b.Scale(10001)
+	want := TokenBreakdown{User: 1000, Assistant: 3000, ToolResult: 6001}
+	if got != want {
+		t.Errorf("Scale = %+v, want %+v", got, want)
+	}
+	if got.Total() != 10001 {
+		t.Errorf("scaled total = %d, want 10001", got.Total())
+	}
+	if (TokenBreakdown{}).Scale(500) != (TokenBreakdown{}) {
+		t.Error("empty breakdown should scale to zero")
+	}
+}
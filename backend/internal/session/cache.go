@@ -0,0 +1,75 @@
+package session
+
+// Prompt caching prices, as multiples of the base input token price. A
+// cache read costs a tenth of an uncached input token; writing the cache
+// (five-minute TTL) costs a quarter more than one.
+const (
+	cacheReadPrice  = 0.1
+	cacheWritePrice = 1.25
+)
+
+// Thresholds for calling a batch of API calls a cache collapse: a session
+// that was mostly served from cache suddenly pays for most of its input
+// again, usually because something early in the context changed or a large
+// file was re-read.
+const (
+	// CacheWarmRatio is the hit ratio a session must have had.
+	CacheWarmRatio = 0.6
+	// CacheColdRatio is the hit ratio the batch must fall under.
+	CacheColdRatio = 0.2
+	// CacheCollapseMinTokens is how many uncached and newly cached input
+	// tokens the batch must pay for, so a short prompt doesn't count.
+	CacheCollapseMinTokens = 20000
+)
+
+// CacheUsage is prompt-cache usage summed over one or more API calls.
+type CacheUsage struct {
+	ReadTokens     int // served from the cache
+	WriteTokens    int // written to the cache
+	UncachedTokens int // neither read from nor written to the cache
+}
+
+// Total returns every input token the calls sent.
+func (u CacheUsage) Total() int {
+	return u.ReadTokens + u.WriteTokens + u.UncachedTokens
+}
+
+// HitRatio returns the share of input tokens served from the cache.
+func (u CacheUsage) HitRatio() float64 {
+	total := u.Total()
+	if total == 0 {
+		return 0
+	}
+	return float64(u.ReadTokens) / float64(total)
+}
+
+// SavedTokens estimates what caching saved, in uncached input tokens: each
+// read costs 0.9 less than paying for the token again and each write 0.25
+// more. Negative when writes outweigh the reads they paid for.
+func (u CacheUsage) SavedTokens() int {
+	return int(float64(u.ReadTokens)*(1-cacheReadPrice) - float64(u.WriteTokens)*(cacheWritePrice-1))
+}
+
+// AddCacheUsage adds u to the session's cache totals and recomputes its hit
+// ratio and savings. It reports whether u is a collapse: the session was
+// warm, and this batch paid for at least CacheCollapseMinTokens of input
+// with a hit ratio under CacheColdRatio.
+func (s *SessionState) AddCacheUsage(u CacheUsage) bool {
+	if u.Total() == 0 {
+		return false
+	}
+	wasWarm := s.CacheHitRatio >= CacheWarmRatio
+
+	s.CacheReadTokens += u.ReadTokens
+	s.CacheWriteTokens += u.WriteTokens
+	s.UncachedTokens += u.UncachedTokens
+	total := CacheUsage{
+		ReadTokens:     s.CacheReadTokens,
+		WriteTokens:    s.CacheWriteTokens,
+		UncachedTokens: s.UncachedTokens,
+	}
+	s.CacheHitRatio = total.HitRatio()
+	s.CacheSavedTokens = total.SavedTokens()
+
+	return wasWarm && u.HitRatio() < CacheColdRatio && u.WriteTokens+u.UncachedTokens >= CacheCollapseMinTokens
+}
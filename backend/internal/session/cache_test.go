@@ -0,0 +1,43 @@
+package session
+
+import "testing"
+
+func TestCacheUsage(t *testing.T) {
+	u := CacheUsage{ReadTokens: 8000, WriteTokens: 1000, UncachedTokens: 1000}
+	if got := u.HitRatio(); got != 0.8 {
+		t.Errorf("HitRatio = %v, want 0.8", got)
+	}
+	// 8000 reads save 7200; 1000 writes cost 250 extra.
+	if got := u.SavedTokens(); got != 6950 {
+		t.Errorf("SavedTokens = %d, want 6950", got)
+	}
+	if got := (CacheUsage{}).HitRatio(); got != 0 {
+		t.Errorf("empty HitRatio = %v, want 0", got)
+	}
+}
+
+func TestAddCacheUsage_Collapse(t *testing.T) {
+	s := &SessionState{}
+	if s.AddCacheUsage(CacheUsage{WriteTokens: 30000}) {
+		t.Error("cold start reported as a collapse")
+	}
+	if s.AddCacheUsage(CacheUsage{ReadTokens: 120000, UncachedTokens: 500}) {
+		t.Error("warm batch reported as a collapse")
+	}
+	if s.CacheHitRatio < CacheWarmRatio {
+		t.Fatalf("CacheHitRatio = %v, want a warm session", s.CacheHitRatio)
+	}
+
+	if s.AddCacheUsage(CacheUsage{ReadTokens: 1000, UncachedTokens: 5000}) {
+		t.Error("small miss reported as a collapse")
+	}
+	if !s.AddCacheUsage(CacheUsage{ReadTokens: 2000, WriteTokens: 40000}) {
+		t.Error("large miss on a warm session not reported")
+	}
+	if s.CacheReadTokens != 123000 || s.CacheWriteTokens != 70000 || s.UncachedTokens != 5500 {
+		t.Errorf("totals = %d/%d/%d", s.CacheReadTokens, s.CacheWriteTokens, s.UncachedTokens)
+	}
+	if s.AddCacheUsage(CacheUsage{}) {
+		t.Error("empty batch reported as a collapse")
+	}
+}
@@ -0,0 +1,232 @@
+package session
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/agent-racer/backend/internal/jsonl"
+)
+
+// ContextComposition approximates what fills a session's context window:
+// the entries since its last compaction, with token counts from a
+// tokenizer rather than the API. Served by /api/sessions/{id}/context.
+type ContextComposition struct {
+	Compactions        int                 `json:"compactions"` // compactions before the current context
+	Messages           ContextMessages     `json:"messages"`
+	Tokens             TokenBreakdown      `json:"tokens"`
+	LargestToolResults []ContextToolResult `json:"largestToolResults"` // biggest first
+	FileReads          []ContextFileRead   `json:"fileReads"`          // biggest first
+}
+
+// ContextMessages counts the messages in the context by role. Each tool
+// result counts once, even when several share a message.
+type ContextMessages struct {
+	User        int `json:"user"`
+	Assistant   int `json:"assistant"`
+	ToolResults int `json:"toolResults"`
+	System      int `json:"system"`
+}
+
+// ContextToolResult is one tool result in the context.
+type ContextToolResult struct {
+	Tool      string    `json:"tool"`
+	Path      string    `json:"path,omitempty"` // file the tool read or edited, if any
+	Tokens    int       `json:"tokens"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// ContextFileRead sums the Read results for one file.
+type ContextFileRead struct {
+	Path   string `json:"path"`
+	Reads  int    `json:"reads"`
+	Tokens int    `json:"tokens"`
+}
+
+// contextToolCall is a tool_use waiting for its result.
+type contextToolCall struct {
+	name string
+	path string
+}
+
+// ParseContextComposition reads the Claude JSONL log at path and returns
+// the composition of its current context, keeping the top largest tool
+// results. count prices a piece of text in tokens. Subagent entries are
+// left out: subagents have their own context.
+func ParseContextComposition(path string, top int, count func(string) int) (*ContextComposition, error) {
+	c := &ContextComposition{}
+	var results []ContextToolResult
+	reads := make(map[string]*ContextFileRead)
+	calls := make(map[string]contextToolCall)
+	var lastAssistantID string
+
+	_, err := jsonl.ForEachEntry(path, 0, func(entry *jsonl.Entry, line []byte) bool {
+		ts, _ := entry.ParseTimestamp()
+		switch entry.Type {
+		case "system":
+			if entry.Subtype == "compact_boundary" {
+				compactions := c.Compactions + 1
+				*c = ContextComposition{Compactions: compactions}
+				results = results[:0]
+				clear(reads)
+				clear(calls)
+			}
+
+		case "assistant":
+			var msg jsonl.MessageContent
+			if json.Unmarshal(entry.Message, &msg) != nil {
+				return true
+			}
+			// Claude Code writes a response's blocks as separate entries.
+			if msg.ID == "" || msg.ID != lastAssistantID {
+				c.Messages.Assistant++
+			}
+			lastAssistantID = msg.ID
+			var blocks []jsonl.ContentBlock
+			if json.Unmarshal(msg.Content, &blocks) != nil {
+				return true
+			}
+			for i := 0; i < len(blocks); i++ {
+				switch blocks[i].Type {
+				case "text":
+					c.Tokens.Assistant += count(blocks[i].Text)
+				case "tool_use":
+					c.Tokens.Assistant += count(string(blocks[i].Input))
+					calls[blocks[i].ID] = contextToolCall{name: blocks[i].Name, path: toolFilePath(blocks[i].Input)}
+				}
+			}
+
+		case "user":
+			var msg jsonl.MessageContent
+			if json.Unmarshal(entry.Message, &msg) != nil {
+				return true
+			}
+			if entry.IsMeta || entry.IsCompactSummary {
+				c.Messages.System++
+				c.Tokens.System += count(contentText(msg.Content))
+				return true
+			}
+			var blocks []jsonl.ContentBlock
+			if json.Unmarshal(msg.Content, &blocks) != nil {
+				// Plain string content is a typed prompt.
+				c.Messages.User++
+				c.Tokens.User += count(contentText(msg.Content))
+				return true
+			}
+			typed := false
+			for i := 0; i < len(blocks); i++ {
+				switch blocks[i].Type {
+				case "text":
+					typed = true
+					c.Tokens.User += count(blocks[i].Text)
+				case "tool_result":
+					call := calls[blocks[i].ToolUseID]
+					delete(calls, blocks[i].ToolUseID)
+					n := count(contentText(blocks[i].Content))
+					c.Messages.ToolResults++
+					c.Tokens.ToolResult += n
+					results = append(results, ContextToolResult{Tool: call.name, Path: call.path, Tokens: n, Timestamp: ts})
+					if call.name == "Read" && call.path != "" {
+						r := reads[call.path]
+						if r == nil {
+							r = &ContextFileRead{Path: call.path}
+							reads[call.path] = r
+						}
+						r.Reads++
+						r.Tokens += n
+					}
+				}
+			}
+			if typed {
+				c.Messages.User++
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Tokens > results[j].Tokens })
+	if len(results) > top {
+		results = results[:top]
+	}
+	c.LargestToolResults = append([]ContextToolResult{}, results...)
+
+	c.FileReads = make([]ContextFileRead, 0, len(reads))
+	for _, r := range reads {
+		c.FileReads = append(c.FileReads, *r)
+	}
+	sortFileReads(c.FileReads)
+	return c, nil
+}
+
+// MaskPaths rewrites file paths relative to workingDir, or to their base
+// name outside it, as PrivacyFilter does for FilesPatched.
+func (c *ContextComposition) MaskPaths(workingDir string) {
+	for i := 0; i < len(c.LargestToolResults); i++ {
+		if c.LargestToolResults[i].Path != "" {
+			c.LargestToolResults[i].Path = maskPath(c.LargestToolResults[i].Path, workingDir)
+		}
+	}
+	merged := make(map[string]int, len(c.FileReads))
+	reads := c.FileReads[:0]
+	for _, r := range c.FileReads {
+		r.Path = maskPath(r.Path, workingDir)
+		if i, ok := merged[r.Path]; ok {
+			reads[i].Reads += r.Reads
+			reads[i].Tokens += r.Tokens
+			continue
+		}
+		merged[r.Path] = len(reads)
+		reads = append(reads, r)
+	}
+	c.FileReads = reads
+	sortFileReads(c.FileReads)
+}
+
+// sortFileReads orders reads biggest first, then by path.
+func sortFileReads(reads []ContextFileRead) {
+	sort.Slice(reads, func(i, j int) bool {
+		if reads[i].Tokens != reads[j].Tokens {
+			return reads[i].Tokens > reads[j].Tokens
+		}
+		return reads[i].Path < reads[j].Path
+	})
+}
+
+// toolFilePath returns the file a tool call names in its input, if any.
+func toolFilePath(input json.RawMessage) string {
+	var params struct {
+		FilePath     string `json:"file_path"`
+		NotebookPath string `json:"notebook_path"`
+	}
+	if json.Unmarshal(input, &params) != nil {
+		return ""
+	}
+	if params.FilePath != "" {
+		return params.FilePath
+	}
+	return params.NotebookPath
+}
+
+// contentText joins the text in message or tool_result content, which is
+// either a string or an array of blocks.
+func contentText(content json.RawMessage) string {
+	var s string
+	if json.Unmarshal(content, &s) == nil {
+		return s
+	}
+	var blocks []jsonl.ContentBlock
+	if json.Unmarshal(content, &blocks) != nil {
+		return ""
+	}
+	var b strings.Builder
+	for i := 0; i < len(blocks); i++ {
+		if blocks[i].Type == "text" {
+			b.WriteString(blocks[i].Text)
+		}
+	}
+	return b.String()
+}
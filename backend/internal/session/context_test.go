@@ -0,0 +1,83 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeContextLog(t *testing.T, lines ...string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// byteCount prices text at one token per byte, so tests can read the
+// numbers off the input.
+func byteCount(s string) int { return len(s) }
+
+func TestParseContextComposition(t *testing.T) {
+	path := writeContextLog(t,
+		`{"type":"user","message":{"role":"user","content":"old prompt"},"timestamp":"2026-01-30T09:00:00Z"}`,
+		`{"type":"system","subtype":"compact_boundary","timestamp":"2026-01-30T10:00:00Z"}`,
+		`{"type":"user","isCompactSummary":true,"message":{"role":"user","content":"summary"},"timestamp":"2026-01-30T10:00:00Z"}`,
+		`{"type":"user","message":{"role":"user","content":[{"type":"text","text":"fix it"}]},"timestamp":"2026-01-30T10:00:01Z"}`,
+		`{"type":"assistant","message":{"id":"m1","role":"assistant","content":[{"type":"text","text":"ok"}]},"timestamp":"2026-01-30T10:00:02Z"}`,
+		`{"type":"assistant","message":{"id":"m1","role":"assistant","content":[{"type":"tool_use","id":"t1","name":"Read","input":{"file_path":"/repo/a.go"}}]},"timestamp":"2026-01-30T10:00:02Z"}`,
+		`{"type":"user","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":"package a"}]},"timestamp":"2026-01-30T10:00:03Z"}`,
+		`{"type":"assistant","message":{"id":"m2","role":"assistant","content":[{"type":"tool_use","id":"t2","name":"Bash","input":{"command":"go test"}},{"type":"tool_use","id":"t3","name":"Read","input":{"file_path":"/repo/a.go"}}]},"timestamp":"2026-01-30T10:00:04Z"}`,
+		`{"type":"user","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t2","content":[{"type":"text","text":"FAIL a_test.go:12 want 1 got 2"}]},{"type":"tool_result","tool_use_id":"t3","content":"package a\nfunc A() {}"}]},"timestamp":"2026-01-30T10:00:05Z"}`,
+	)
+
+	c, err := ParseContextComposition(path, 2, byteCount)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Compactions != 1 {
+		t.Errorf("Compactions = %d, want 1", c.Compactions)
+	}
+	if want := (ContextMessages{User: 1, Assistant: 2, ToolResults: 3, System: 1}); c.Messages != want {
+		t.Errorf("Messages = %+v, want %+v", c.Messages, want)
+	}
+	if c.Tokens.User != len("fix it") || c.Tokens.System != len("summary") || c.Tokens.ToolResult != 9+30+21 {
+		t.Errorf("Tokens = %+v", c.Tokens)
+	}
+
+	if len(c.LargestToolResults) != 2 {
+		t.Fatalf("LargestToolResults = %+v, want the top 2", c.LargestToolResults)
+	}
+	if r := c.LargestToolResults[0]; r.Tool != "Bash" || r.Tokens != 30 || r.Path != "" {
+		t.Errorf("largest = %+v, want the Bash result", r)
+	}
+	if r := c.LargestToolResults[1]; r.Tool != "Read" || r.Path != "/repo/a.go" || r.Tokens != 21 {
+		t.Errorf("second = %+v, want the second read of a.go", r)
+	}
+
+	if len(c.FileReads) != 1 || c.FileReads[0] != (ContextFileRead{Path: "/repo/a.go", Reads: 2, Tokens: 30}) {
+		t.Errorf("FileReads = %+v", c.FileReads)
+	}
+}
+
+func TestContextCompositionMaskPaths(t *testing.T) {
+	c := &ContextComposition{
+		LargestToolResults: []ContextToolResult{{Tool: "Read", Path: "/repo/src/a.go"}, {Tool: "Bash"}},
+		FileReads: []ContextFileRead{
+			{Path: "/repo/src/a.go", Reads: 1, Tokens: 50},
+			{Path: "/etc/hosts", Reads: 1, Tokens: 40},
+			{Path: "/tmp/x/hosts", Reads: 2, Tokens: 20},
+		},
+	}
+	c.MaskPaths("/repo")
+
+	if c.LargestToolResults[0].Path != "src/a.go" || c.LargestToolResults[1].Path != "" {
+		t.Errorf("LargestToolResults = %+v", c.LargestToolResults)
+	}
+	want := []ContextFileRead{{Path: "hosts", Reads: 3, Tokens: 60}, {Path: "src/a.go", Reads: 1, Tokens: 50}}
+	if len(c.FileReads) != 2 || c.FileReads[0] != want[0] || c.FileReads[1] != want[1] {
+		t.Errorf("FileReads = %+v, want %+v", c.FileReads, want)
+	}
+}
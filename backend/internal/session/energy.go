@@ -0,0 +1,63 @@
+package session
+
+import "strings"
+
+// DefaultEnergyClass is the EnergyModel rate used for models that match no
+// other class.
+const DefaultEnergyClass = "default"
+
+// EnergyModel estimates the energy it took to serve a session's tokens.
+// The numbers are rough by nature; they are meant for comparing sessions,
+// not for accounting.
+type EnergyModel struct {
+	// JoulesPer1K maps a model class to joules per 1,000 tokens. A model
+	// belongs to the longest class its ID contains, e.g. "opus" for
+	// "claude-opus-4-5", and to DefaultEnergyClass when none matches.
+	// A nil map turns estimation off.
+	JoulesPer1K map[string]float64
+	// GramsCO2PerKWh is the carbon intensity of the electricity.
+	GramsCO2PerKWh float64
+}
+
+// Rate returns the joules per 1,000 tokens for model.
+func (m EnergyModel) Rate(model string) float64 {
+	model = strings.ToLower(model)
+	class := DefaultEnergyClass
+	for c := range m.JoulesPer1K {
+		if c == DefaultEnergyClass || !strings.Contains(model, strings.ToLower(c)) {
+			continue
+		}
+		if class == DefaultEnergyClass || len(c) > len(class) || (len(c) == len(class) && c < class) {
+			class = c
+		}
+	}
+	return m.JoulesPer1K[class]
+}
+
+// ApplyEnergy adds the energy for the tokens each update burned since its
+// committed state in current to EnergyWh and CO2Grams, at the rate of the
+// update's model, so a model switch only changes the rate from then on.
+// A session seen for the first time is charged for all its tokens.
+// Updates must have been through ApplyLaps, which sets TokensBurned.
+func ApplyEnergy(current, updates []*SessionState, m EnergyModel) {
+	if m.JoulesPer1K == nil {
+		return
+	}
+	prev := make(map[string]*SessionState, len(current))
+	for _, s := range current {
+		prev[s.ID] = s
+	}
+
+	for _, u := range updates {
+		tokens := u.TokensBurned
+		if p := prev[u.ID]; p != nil {
+			u.EnergyWh = p.EnergyWh
+			tokens -= p.TokensBurned
+		}
+		if tokens > 0 {
+			// 3,600 J to the Wh.
+			u.EnergyWh += float64(tokens) / 1000 * m.Rate(u.Model) / 3600
+		}
+		u.CO2Grams = u.EnergyWh / 1000 * m.GramsCO2PerKWh
+	}
+}
@@ -0,0 +1,49 @@
+package session
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEnergyModelRate(t *testing.T) {
+	m := EnergyModel{JoulesPer1K: map[string]float64{"opus": 1500, "gpt": 700, "gpt-5": 900, DefaultEnergyClass: 500}}
+	tests := []struct {
+		model string
+		want  float64
+	}{
+		{"claude-opus-4-5", 1500},
+		{"GPT-5-codex", 900}, // the longest class wins
+		{"gpt-4o", 700},
+		{"gemini-2.5-pro", 500},
+		{"", 500},
+	}
+	for _, tt := range tests {
+		if got := m.Rate(tt.model); got != tt.want {
+			t.Errorf("Rate(%q) = %v, want %v", tt.model, got, tt.want)
+		}
+	}
+}
+
+func TestApplyEnergy(t *testing.T) {
+	m := EnergyModel{JoulesPer1K: map[string]float64{"opus": 3600, "haiku": 360}, GramsCO2PerKWh: 500}
+	first := &SessionState{ID: "a", Model: "claude-opus-4-5", TokensBurned: 10_000}
+	ApplyEnergy(nil, []*SessionState{first}, m)
+	if first.EnergyWh != 10 || first.CO2Grams != 5 {
+		t.Fatalf("first sighting: %v Wh, %v g, want 10 Wh, 5 g", first.EnergyWh, first.CO2Grams)
+	}
+
+	// After a switch to haiku, only the new tokens get haiku's rate.
+	next := first.Clone()
+	next.Model = "claude-haiku-4-5"
+	next.TokensBurned = 20_000
+	ApplyEnergy([]*SessionState{first}, []*SessionState{next}, m)
+	if math.Abs(next.EnergyWh-11) > 1e-9 || math.Abs(next.CO2Grams-5.5) > 1e-9 {
+		t.Errorf("after switch: %v Wh, %v g, want 11 Wh, 5.5 g", next.EnergyWh, next.CO2Grams)
+	}
+
+	off := &SessionState{ID: "b", TokensBurned: 10_000}
+	ApplyEnergy(nil, []*SessionState{off}, EnergyModel{})
+	if off.EnergyWh != 0 {
+		t.Errorf("disabled model estimated %v Wh", off.EnergyWh)
+	}
+}
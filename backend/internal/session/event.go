@@ -4,14 +4,19 @@ package session
 type EventType int
 
 const (
-	EventNew      EventType = iota // session first discovered
-	EventUpdate                    // per-poll state update (new data arrived)
-	EventTerminal                  // session reached terminal state
+	EventNew               EventType = iota // session first discovered
+	EventUpdate                             // per-poll state update (new data arrived)
+	EventTerminal                           // session reached terminal state
+	EventSubagentStarted                    // a subagent in the session did its first work
+	EventSubagentCompleted                  // a subagent in the session returned its result
+	EventMilestone                          // the session passed a duration or token milestone
 )
 
 // Event carries a session state snapshot to observers.
 type Event struct {
 	Type        EventType
-	State       *SessionState // snapshot (safe to retain)
-	ActiveCount int           // non-terminal sessions at event time
+	State       *SessionState  // snapshot (safe to retain)
+	Subagent    *SubagentState // the subagent, for subagent events; nil otherwise
+	Milestone   *Milestone     // the milestone, for EventMilestone; nil otherwise
+	ActiveCount int            // non-terminal sessions at event time
 }
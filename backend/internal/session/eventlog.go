@@ -0,0 +1,118 @@
+package session
+
+import (
+	"sort"
+	"time"
+)
+
+// MutationOp is the kind of change a Mutation records.
+type MutationOp int
+
+const (
+	MutationCreate   MutationOp = iota // session added to the store
+	MutationUpdate                     // stored session replaced
+	MutationTerminal                   // session replaced by a terminal state for the first time
+	MutationRemove                     // session removed from the store
+)
+
+// Mutation is one change to the store. Seq numbers every change the store
+// has made, without gaps. State is the session as stored after the change
+// and is nil for removals; it is shared with the store and every other
+// consumer, so it must not be modified.
+type Mutation struct {
+	Seq   uint64
+	Op    MutationOp
+	At    time.Time
+	ID    string
+	State *SessionState
+}
+
+// SetEventLog keeps the last size mutations in the store's event log and
+// hands every batch of changes to the functions registered with Subscribe.
+// Zero turns the log off and drops it, leaving the callers of the store to
+// tell others about their changes.
+func (s *Store) SetEventLog(size int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.logSize = max(size, 0)
+	if s.logSize == 0 {
+		s.eventLog = nil
+		return
+	}
+	s.trimEventLogLocked()
+}
+
+// EventLogging reports whether the store keeps an event log.
+func (s *Store) EventLogging() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.logSize > 0
+}
+
+// Subscribe registers fn to receive each batch of mutations, in the order
+// they were applied within the batch, while the event log is on. fn is
+// called after the store's lock is released, so it may read the store;
+// batches committed concurrently may arrive out of order, which Seq shows.
+func (s *Store) Subscribe(fn func([]Mutation)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.subscribers = append(s.subscribers, fn)
+}
+
+// MutationsSince returns the logged mutations after seq, oldest first. It
+// reports false when the log is off or no longer reaches back to seq, in
+// which case a consumer has missed changes and should start again from
+// GetAll.
+func (s *Store) MutationsSince(seq uint64) ([]Mutation, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.logSize == 0 {
+		return nil, false
+	}
+	log := s.eventLogLocked()
+	if seq < s.seq && (len(log) == 0 || log[0].Seq > seq+1) {
+		return nil, false
+	}
+	i := sort.Search(len(log), func(i int) bool { return log[i].Seq > seq })
+	return append([]Mutation(nil), log[i:]...), true
+}
+
+// eventLogLocked returns the retained part of the log. Caller must hold
+// s.mu.
+func (s *Store) eventLogLocked() []Mutation {
+	if len(s.eventLog) > s.logSize {
+		return s.eventLog[len(s.eventLog)-s.logSize:]
+	}
+	return s.eventLog
+}
+
+// trimEventLogLocked drops mutations that fell out of the log once it has
+// grown to twice its size, so trimming costs little per change. Caller must
+// hold s.mu for writing.
+func (s *Store) trimEventLogLocked() {
+	if len(s.eventLog) >= 2*s.logSize {
+		s.eventLog = append(s.eventLog[:0], s.eventLogLocked()...)
+	}
+}
+
+// commitLocked numbers a batch of mutations, appends it to the event log
+// and the history, and returns the subscribers to hand it to once the lock
+// is released. Caller must hold s.mu for writing.
+func (s *Store) commitLocked(batch []Mutation) []func([]Mutation) {
+	if len(batch) == 0 {
+		return nil
+	}
+	now := s.now()
+	for i := 0; i < len(batch); i++ {
+		s.seq++
+		batch[i].Seq = s.seq
+		batch[i].At = now
+	}
+	s.recordLocked(batch, now)
+	if s.logSize == 0 {
+		return nil
+	}
+	s.eventLog = append(s.eventLog, batch...)
+	s.trimEventLogLocked()
+	return s.subscribers
+}
@@ -0,0 +1,68 @@
+package session
+
+import "testing"
+
+func TestStoreEventLog(t *testing.T) {
+	s := NewStore()
+	var got [][]Mutation
+	s.Subscribe(func(batch []Mutation) { got = append(got, batch) })
+
+	s.Update(&SessionState{ID: "off"})
+	if len(got) != 0 {
+		t.Fatal("subscribers called while the log is off")
+	}
+	if _, ok := s.MutationsSince(0); ok {
+		t.Fatal("MutationsSince answered while the log is off")
+	}
+
+	s.SetEventLog(10)
+	s.BatchUpdateAndNotify([]*SessionState{
+		{ID: "a", Activity: Thinking},
+		{ID: "off", Activity: Thinking},
+	}, nil)
+	s.Update(&SessionState{ID: "a", Activity: Complete})
+	s.Update(&SessionState{ID: "a", Activity: Complete})
+	s.BatchRemoveAndNotify([]string{"a", "missing"}, nil)
+
+	if len(got) != 4 || len(got[0]) != 2 {
+		t.Fatalf("batches = %+v, want 4 with the first holding both updates", got)
+	}
+	wantOps := []MutationOp{MutationCreate, MutationUpdate, MutationTerminal, MutationUpdate, MutationRemove}
+	log, ok := s.MutationsSince(1)
+	if !ok || len(log) != len(wantOps) {
+		t.Fatalf("MutationsSince(1) = %+v, %v", log, ok)
+	}
+	for i := 0; i < len(wantOps); i++ {
+		if log[i].Op != wantOps[i] || log[i].Seq != uint64(i+2) {
+			t.Errorf("mutation %d = %+v, want op %d seq %d", i, log[i], wantOps[i], i+2)
+		}
+	}
+	if log[4].ID != "a" || log[4].State != nil {
+		t.Errorf("remove = %+v", log[4])
+	}
+	if log[1].State.Lane != 0 {
+		t.Errorf("update lost the stored lane: %+v", log[1].State)
+	}
+}
+
+func TestStoreEventLogIsBounded(t *testing.T) {
+	s := NewStore()
+	s.SetEventLog(3)
+	for i := 0; i < 20; i++ {
+		s.Update(&SessionState{ID: "a", MessageCount: i})
+	}
+	if len(s.eventLog) >= 6 {
+		t.Errorf("log holds %d mutations, want fewer than twice its size", len(s.eventLog))
+	}
+
+	log, ok := s.MutationsSince(17)
+	if !ok || len(log) != 3 || log[0].Seq != 18 || log[2].State.MessageCount != 19 {
+		t.Errorf("MutationsSince(17) = %+v, %v", log, ok)
+	}
+	if _, ok := s.MutationsSince(16); ok {
+		t.Error("MutationsSince should fail once the log no longer reaches back")
+	}
+	if log, ok := s.MutationsSince(20); !ok || len(log) != 0 {
+		t.Errorf("caught-up consumer got %+v, %v", log, ok)
+	}
+}
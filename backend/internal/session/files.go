@@ -0,0 +1,30 @@
+package session
+
+// MaxFilesTouched caps how many paths a session's FilesTouched keeps.
+const MaxFilesTouched = 200
+
+// TouchFiles adds the counts in files, keyed by path, to FilesTouched. Once
+// it holds MaxFilesTouched paths, a new path replaces the least touched one
+// (the first by name on a tie), so the files the session keeps coming back
+// to stay while one-off reads churn.
+func (s *SessionState) TouchFiles(files map[string]int) {
+	for path, n := range files {
+		if _, ok := s.FilesTouched[path]; !ok && len(s.FilesTouched) >= MaxFilesTouched {
+			s.evictLeastTouched()
+		}
+		if s.FilesTouched == nil {
+			s.FilesTouched = make(map[string]int)
+		}
+		s.FilesTouched[path] += n
+	}
+}
+
+func (s *SessionState) evictLeastTouched() {
+	victim, fewest := "", 0
+	for path, n := range s.FilesTouched {
+		if victim == "" || n < fewest || (n == fewest && path < victim) {
+			victim, fewest = path, n
+		}
+	}
+	delete(s.FilesTouched, victim)
+}
@@ -0,0 +1,37 @@
+package session
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestTouchFiles(t *testing.T) {
+	s := &SessionState{}
+	s.TouchFiles(map[string]int{"/src/a.go": 2})
+	s.TouchFiles(map[string]int{"/src/a.go": 1, "/src/b.go": 1})
+	if s.FilesTouched["/src/a.go"] != 3 || s.FilesTouched["/src/b.go"] != 1 {
+		t.Fatalf("FilesTouched = %v, want a.go=3 b.go=1", s.FilesTouched)
+	}
+
+	// Fill up to the cap with files touched twice; b.go is now the least
+	// touched and makes way for the next new path.
+	for i := len(s.FilesTouched); i < MaxFilesTouched; i++ {
+		s.TouchFiles(map[string]int{fmt.Sprintf("/src/f%03d.go", i): 2})
+	}
+	s.TouchFiles(map[string]int{"/src/new.go": 1})
+	if len(s.FilesTouched) != MaxFilesTouched {
+		t.Fatalf("len(FilesTouched) = %d, want %d", len(s.FilesTouched), MaxFilesTouched)
+	}
+	if _, ok := s.FilesTouched["/src/b.go"]; ok {
+		t.Error("least touched b.go kept past the cap")
+	}
+	if s.FilesTouched["/src/new.go"] != 1 || s.FilesTouched["/src/a.go"] != 3 {
+		t.Errorf("new.go = %d, a.go = %d, want 1 and 3", s.FilesTouched["/src/new.go"], s.FilesTouched["/src/a.go"])
+	}
+
+	// Touching a file already counted evicts nothing.
+	s.TouchFiles(map[string]int{"/src/new.go": 1})
+	if len(s.FilesTouched) != MaxFilesTouched || s.FilesTouched["/src/new.go"] != 2 {
+		t.Errorf("len = %d, new.go = %d after a repeat touch", len(s.FilesTouched), s.FilesTouched["/src/new.go"])
+	}
+}
@@ -0,0 +1,165 @@
+package session
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// InFolder returns the sessions working in folder or a directory under
+// it, best match first: running sessions before finished ones, then those
+// working in folder itself before those deeper in, then the most recently
+// active. Editors use it to find the session for an open workspace.
+func InFolder(sessions []*SessionState, folder string) []*SessionState {
+	if folder == "" {
+		return nil
+	}
+	folder = filepath.Clean(folder)
+	var matches []*SessionState
+	for i := 0; i < len(sessions); i++ {
+		if dirWithin(sessions[i].WorkingDir, folder) {
+			matches = append(matches, sessions[i])
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.IsTerminal() != b.IsTerminal() {
+			return !a.IsTerminal()
+		}
+		if ea, eb := filepath.Clean(a.WorkingDir) == folder, filepath.Clean(b.WorkingDir) == folder; ea != eb {
+			return ea
+		}
+		if !a.LastActivityAt.Equal(b.LastActivityAt) {
+			return a.LastActivityAt.After(b.LastActivityAt)
+		}
+		return a.ID < b.ID
+	})
+	return matches
+}
+
+// dirWithin reports whether dir is root or a directory under it. Both are
+// compared as cleaned paths.
+func dirWithin(dir, root string) bool {
+	if dir == "" {
+		return false
+	}
+	dir = filepath.Clean(dir)
+	if dir == root {
+		return true
+	}
+	if root == string(filepath.Separator) {
+		return strings.HasPrefix(dir, root)
+	}
+	return strings.HasPrefix(dir, root+string(filepath.Separator))
+}
+
+// DirMatch says how ForDir or ForTmux matched a session.
+type DirMatch string
+
+const (
+	MatchTmux     DirMatch = "tmux"     // the session runs in the tmux pane
+	MatchExact    DirMatch = "exact"    // the session works in the directory
+	MatchParent   DirMatch = "parent"   // the session works in a directory above it
+	MatchWorktree DirMatch = "worktree" // one of them is a worktree of the other's repository
+)
+
+var dirMatchRank = map[DirMatch]int{MatchExact: 3, MatchParent: 2, MatchWorktree: 1}
+
+// ForDir returns the running session a shell in dir belongs to, and how
+// it matched: one working in dir itself, else the one working in the
+// closest directory above it, else one working in the same repository
+// through a worktree. Ties go to the most recently active session. It
+// returns nil when no running session matches.
+func ForDir(sessions []*SessionState, dir string) (*SessionState, DirMatch) {
+	if dir == "" {
+		return nil, ""
+	}
+	dir = filepath.Clean(dir)
+	dirRoot := worktreeRepo(dir)
+
+	var best *SessionState
+	var bestMatch DirMatch
+	for i := 0; i < len(sessions); i++ {
+		s := sessions[i]
+		if s.IsTerminal() || s.WorkingDir == "" {
+			continue
+		}
+		wd := filepath.Clean(s.WorkingDir)
+		var m DirMatch
+		switch {
+		case wd == dir:
+			m = MatchExact
+		case dirWithin(dir, wd):
+			m = MatchParent
+		case dirRoot != "" && dirWithin(wd, dirRoot):
+			m = MatchWorktree
+		default:
+			if root := worktreeRepo(wd); root != "" && dirWithin(dir, root) {
+				m = MatchWorktree
+			}
+		}
+		if m == "" {
+			continue
+		}
+		if best == nil || betterDirMatch(s, m, best, bestMatch) {
+			best, bestMatch = s, m
+		}
+	}
+	return best, bestMatch
+}
+
+// betterDirMatch reports whether session a, matched as ma, beats b,
+// matched as mb.
+func betterDirMatch(a *SessionState, ma DirMatch, b *SessionState, mb DirMatch) bool {
+	if ma != mb {
+		return dirMatchRank[ma] > dirMatchRank[mb]
+	}
+	if ma == MatchParent {
+		// The closer ancestor is the deeper, longer path.
+		if la, lb := len(filepath.Clean(a.WorkingDir)), len(filepath.Clean(b.WorkingDir)); la != lb {
+			return la > lb
+		}
+	}
+	if !a.LastActivityAt.Equal(b.LastActivityAt) {
+		return a.LastActivityAt.After(b.LastActivityAt)
+	}
+	return a.ID < b.ID
+}
+
+// worktreeRepo returns the primary checkout of the worktree dir is in, by
+// the layouts the monitor recognizes: "<repo>/.claude/worktrees/<slug>"
+// and sibling "<repo>--<branch>" directories. It returns "" when dir is
+// not in a worktree by either convention.
+func worktreeRepo(dir string) string {
+	parts := strings.Split(dir, string(filepath.Separator))
+	for i := 0; i < len(parts); i++ {
+		if parts[i] == ".claude" && i+2 < len(parts) && parts[i+1] == "worktrees" {
+			return strings.Join(parts[:i], string(filepath.Separator))
+		}
+		if repo, wt, ok := strings.Cut(parts[i], "--"); ok && repo != "" && wt != "" {
+			return strings.Join(append(parts[:i:i], repo), string(filepath.Separator))
+		}
+	}
+	return ""
+}
+
+// ForTmux returns the running session in the tmux pane target
+// ("session:window.pane"), most recently active first when a pane was
+// reused. It returns nil when none runs there.
+func ForTmux(sessions []*SessionState, target string) *SessionState {
+	if target == "" {
+		return nil
+	}
+	var best *SessionState
+	for i := 0; i < len(sessions); i++ {
+		s := sessions[i]
+		if s.IsTerminal() || s.TmuxTarget != target {
+			continue
+		}
+		if best == nil || s.LastActivityAt.After(best.LastActivityAt) ||
+			(s.LastActivityAt.Equal(best.LastActivityAt) && s.ID < best.ID) {
+			best = s
+		}
+	}
+	return best
+}
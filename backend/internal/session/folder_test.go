@@ -0,0 +1,92 @@
+package session
+
+import (
+	"testing"
+	"time"
+)
+
+func TestInFolder(t *testing.T) {
+	now := time.Now()
+	sessions := []*SessionState{
+		{ID: "sub", WorkingDir: "/src/app/web", Activity: Thinking, LastActivityAt: now},
+		{ID: "root", WorkingDir: "/src/app", Activity: Idle, LastActivityAt: now.Add(-time.Hour)},
+		{ID: "done", WorkingDir: "/src/app/", Activity: Complete, LastActivityAt: now},
+		{ID: "sibling", WorkingDir: "/src/application", Activity: Thinking},
+		{ID: "elsewhere", WorkingDir: "/tmp", Activity: Thinking},
+		{ID: "nodir", Activity: Thinking},
+	}
+
+	got := InFolder(sessions, "/src/app/")
+	want := []string{"root", "sub", "done"}
+	if len(got) != len(want) {
+		t.Fatalf("InFolder = %d sessions, want %v", len(got), want)
+	}
+	for i := 0; i < len(want); i++ {
+		if got[i].ID != want[i] {
+			t.Errorf("InFolder[%d] = %s, want %s", i, got[i].ID, want[i])
+		}
+	}
+
+	if got := InFolder(sessions, ""); got != nil {
+		t.Errorf("InFolder(\"\") = %v, want none", got)
+	}
+	if got := InFolder(sessions, "/"); len(got) != 5 {
+		t.Errorf("InFolder(/) = %d sessions, want every one with a directory", len(got))
+	}
+}
+
+func TestForDir(t *testing.T) {
+	now := time.Now()
+	sessions := []*SessionState{
+		{ID: "repo", WorkingDir: "/src/app", Activity: Thinking, LastActivityAt: now},
+		{ID: "pkg", WorkingDir: "/src/app/pkg", Activity: Idle, LastActivityAt: now.Add(-time.Hour)},
+		{ID: "wt", WorkingDir: "/src/app/.claude/worktrees/fix-login", Activity: ToolUse},
+		{ID: "sibling", WorkingDir: "/src/lib--feature", Activity: Thinking},
+		{ID: "done", WorkingDir: "/src/done", Activity: Complete},
+	}
+	tests := []struct {
+		dir    string
+		wantID string
+		match  DirMatch
+	}{
+		{"/src/app", "repo", MatchExact},
+		{"/src/app/", "repo", MatchExact},
+		{"/src/app/pkg/util", "pkg", MatchParent}, // the closest ancestor wins
+		{"/src/app/cmd", "repo", MatchParent},
+		{"/src/app/.claude/worktrees/fix-login/web", "wt", MatchParent},
+		{"/src/app--hotfix/cmd", "repo", MatchWorktree}, // a sibling worktree of app
+		{"/src/lib", "sibling", MatchWorktree},          // the primary checkout of lib--feature
+		{"/src/done", "", ""},                           // finished sessions don't count
+		{"/elsewhere", "", ""},
+		{"", "", ""},
+	}
+	for _, tt := range tests {
+		got, match := ForDir(sessions, tt.dir)
+		gotID := ""
+		if got != nil {
+			gotID = got.ID
+		}
+		if gotID != tt.wantID || match != tt.match {
+			t.Errorf("ForDir(%q) = %q (%s), want %q (%s)", tt.dir, gotID, match, tt.wantID, tt.match)
+		}
+	}
+}
+
+func TestForTmux(t *testing.T) {
+	now := time.Now()
+	sessions := []*SessionState{
+		{ID: "old", TmuxTarget: "dev:1.0", Activity: Complete, LastActivityAt: now},
+		{ID: "new", TmuxTarget: "dev:1.0", Activity: Thinking, LastActivityAt: now.Add(-time.Minute)},
+		{ID: "other", TmuxTarget: "dev:2.0", Activity: Idle},
+		{ID: "untracked", Activity: Thinking},
+	}
+	for target, want := range map[string]string{"dev:1.0": "new", "dev:2.0": "other", "dev:3.0": "", "": ""} {
+		gotID := ""
+		if got := ForTmux(sessions, target); got != nil {
+			gotID = got.ID
+		}
+		if gotID != want {
+			t.Errorf("ForTmux(%q) = %q, want %q", target, gotID, want)
+		}
+	}
+}
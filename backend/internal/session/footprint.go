@@ -0,0 +1,168 @@
+package session
+
+import (
+	"sort"
+	"unsafe"
+)
+
+// largestSessions is how many sessions StoreMetrics lists by footprint.
+const largestSessions = 10
+
+// StoreMetrics is a point-in-time estimate of the memory the store holds,
+// served by /api/debug/store to track down a session that keeps growing.
+// Byte counts are estimates: struct sizes plus string and map contents,
+// without allocator overhead.
+type StoreMetrics struct {
+	Sessions         int    `json:"sessions"`
+	SessionBytes     int    `json:"sessionBytes"`
+	Subagents        int    `json:"subagents"`
+	MaxSubagents     int    `json:"maxSubagents"`     // per session; 0 is unlimited
+	SubagentsDropped uint64 `json:"subagentsDropped"` // since startup, to stay under MaxSubagents
+
+	HistoryFrames   int `json:"historyFrames"`
+	EventLogEntries int `json:"eventLogEntries"`
+	// Superseded states still referenced by the history or the event log.
+	RetainedStates int `json:"retainedStates"`
+	RetainedBytes  int `json:"retainedBytes"`
+
+	Largest []SessionFootprint `json:"largest"`
+}
+
+// SessionFootprint is the estimated size of one stored session.
+type SessionFootprint struct {
+	ID        string `json:"id"`
+	Subagents int    `json:"subagents"`
+	Bytes     int    `json:"bytes"`
+}
+
+// SetMaxSubagents caps how many subagents a stored session keeps. Sessions
+// over the cap lose their oldest finished subagents first, then their
+// oldest running ones, the next time they are updated. Zero is unlimited.
+func (s *Store) SetMaxSubagents(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.maxSubagents = max(n, 0)
+}
+
+// capSubagentsLocked trims state's subagents to the cap, keeping their
+// order. Caller must hold s.mu for writing.
+func (s *Store) capSubagentsLocked(state *SessionState) {
+	excess := len(state.Subagents) - s.maxSubagents
+	if s.maxSubagents == 0 || excess <= 0 {
+		return
+	}
+	s.subagentsDropped += uint64(excess)
+
+	drop := make([]bool, len(state.Subagents))
+	for i := 0; i < len(state.Subagents) && excess > 0; i++ {
+		if sa := &state.Subagents[i]; sa.CompletedAt != nil || sa.Activity == Complete {
+			drop[i] = true
+			excess--
+		}
+	}
+	for i := 0; i < len(state.Subagents) && excess > 0; i++ {
+		if !drop[i] {
+			drop[i] = true
+			excess--
+		}
+	}
+	kept := make([]SubagentState, 0, s.maxSubagents)
+	for i := 0; i < len(state.Subagents); i++ {
+		if !drop[i] {
+			kept = append(kept, state.Subagents[i])
+		}
+	}
+	state.Subagents = kept
+}
+
+// Metrics estimates what the store holds in memory. It walks every
+// retained state, so it is meant for diagnostics rather than polling.
+func (s *Store) Metrics() StoreMetrics {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	m := StoreMetrics{
+		Sessions:         len(s.sessions),
+		MaxSubagents:     s.maxSubagents,
+		SubagentsDropped: s.subagentsDropped,
+		HistoryFrames:    len(s.history),
+		EventLogEntries:  len(s.eventLogLocked()),
+	}
+	current := make(map[*SessionState]bool, len(s.sessions))
+	m.Largest = make([]SessionFootprint, 0, len(s.sessions))
+	for id, st := range s.sessions {
+		current[st] = true
+		n := st.footprint()
+		m.SessionBytes += n
+		m.Subagents += len(st.Subagents)
+		m.Largest = append(m.Largest, SessionFootprint{ID: id, Subagents: len(st.Subagents), Bytes: n})
+	}
+	sort.Slice(m.Largest, func(i, j int) bool {
+		if m.Largest[i].Bytes != m.Largest[j].Bytes {
+			return m.Largest[i].Bytes > m.Largest[j].Bytes
+		}
+		return m.Largest[i].ID < m.Largest[j].ID
+	})
+	if len(m.Largest) > largestSessions {
+		m.Largest = m.Largest[:largestSessions]
+	}
+
+	retained := make(map[*SessionState]bool)
+	retain := func(st *SessionState) {
+		if st == nil || current[st] || retained[st] {
+			return
+		}
+		retained[st] = true
+		m.RetainedBytes += st.footprint()
+	}
+	for i := 0; i < len(s.history); i++ {
+		for _, st := range s.history[i].sessions {
+			retain(st)
+		}
+	}
+	for _, mu := range s.eventLogLocked() {
+		retain(mu.State)
+	}
+	m.RetainedStates = len(retained)
+	return m
+}
+
+var (
+	sessionStateSize  = int(unsafe.Sizeof(SessionState{}))
+	subagentStateSize = int(unsafe.Sizeof(SubagentState{}))
+)
+
+// mapEntryOverhead approximates a map entry's bucket slot and hash bits
+// beyond its key's bytes.
+const mapEntryOverhead = 32
+
+// footprint estimates the bytes st holds.
+func (st *SessionState) footprint() int {
+	n := sessionStateSize
+	n += len(st.ID) + len(st.Name) + len(st.Topic) + len(st.Slug) + len(st.Source) +
+		len(st.CurrentTool) + len(st.Model) + len(st.WorkingDir) + len(st.Branch) +
+		len(st.Project) + len(st.Worktree) + len(st.SubProject) + len(st.IssueURL) + len(st.PRURL) +
+		len(st.TmuxTarget) + len(st.LastAssistantText) + len(st.LastCommand) +
+		len(st.StatusText) + len(st.LogPath)
+	n += countsFootprint(st.MCPToolCalls) + countsFootprint(st.ToolCounts) +
+		countsFootprint(st.ShellCommands) + countsFootprint(st.FilesPatched) + countsFootprint(st.FilesTouched) +
+		countsFootprint(st.SlashCommands) + countsFootprint(st.Reactions)
+	n += cap(st.Subagents) * subagentStateSize
+	for i := 0; i < len(st.Subagents); i++ {
+		sa := &st.Subagents[i]
+		n += len(sa.ID) + len(sa.ParentToolUseID) + len(sa.ParentID) + len(sa.SessionID) +
+			len(sa.Slug) + len(sa.Model) + len(sa.CurrentTool)
+		for _, call := range sa.TaskCalls {
+			n += len(call) + int(unsafe.Sizeof(call))
+		}
+	}
+	return n
+}
+
+func countsFootprint(m map[string]int) int {
+	n := 0
+	for k := range m {
+		n += len(k) + mapEntryOverhead
+	}
+	return n
+}
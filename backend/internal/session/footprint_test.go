@@ -0,0 +1,94 @@
+package session
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestStoreCapsSubagents(t *testing.T) {
+	s := NewStore()
+	s.SetMaxSubagents(3)
+
+	done := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	state := &SessionState{ID: "a", Activity: ToolUse}
+	for i := 0; i < 5; i++ {
+		sa := SubagentState{ID: fmt.Sprintf("sub-%d", i), Activity: ToolUse}
+		if i == 1 || i == 3 {
+			sa.Activity = Complete
+			sa.CompletedAt = &done
+		}
+		state.Subagents = append(state.Subagents, sa)
+	}
+	s.Update(state)
+
+	// The two finished subagents go first, keeping the rest in order.
+	got, _ := s.Get("a")
+	var ids []string
+	for _, sa := range got.Subagents {
+		ids = append(ids, sa.ID)
+	}
+	if fmt.Sprint(ids) != "[sub-0 sub-2 sub-4]" {
+		t.Errorf("subagents = %v, want [sub-0 sub-2 sub-4]", ids)
+	}
+	if len(state.Subagents) != 3 {
+		t.Errorf("caller's state kept %d subagents, want 3", len(state.Subagents))
+	}
+
+	// With none finished, the oldest running ones go.
+	state.Subagents = append(state.Subagents, SubagentState{ID: "sub-5"}, SubagentState{ID: "sub-6"})
+	s.Update(state)
+	got, _ = s.Get("a")
+	if len(got.Subagents) != 3 || got.Subagents[0].ID != "sub-4" {
+		t.Errorf("subagents = %+v, want sub-4 first of 3", got.Subagents)
+	}
+	if m := s.Metrics(); m.SubagentsDropped != 4 || m.MaxSubagents != 3 {
+		t.Errorf("SubagentsDropped = %d, MaxSubagents = %d, want 4 and 3", m.SubagentsDropped, m.MaxSubagents)
+	}
+}
+
+func TestStoreMaxSubagentsZeroIsUnlimited(t *testing.T) {
+	s := NewStore()
+	state := &SessionState{ID: "a"}
+	for i := 0; i < 500; i++ {
+		state.Subagents = append(state.Subagents, SubagentState{ID: fmt.Sprintf("sub-%d", i)})
+	}
+	s.Update(state)
+	if got, _ := s.Get("a"); len(got.Subagents) != 500 {
+		t.Errorf("subagents = %d, want 500", len(got.Subagents))
+	}
+}
+
+func TestStoreMetrics(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	s := NewStore()
+	s.now = func() time.Time { return now }
+	s.SetHistory(time.Hour)
+
+	s.Update(&SessionState{ID: "small"})
+	big := &SessionState{ID: "big", LastAssistantText: string(make([]byte, 4096))}
+	for i := 0; i < 10; i++ {
+		big.Subagents = append(big.Subagents, SubagentState{ID: fmt.Sprintf("sub-%d", i)})
+	}
+	s.Update(big)
+	s.Update(&SessionState{ID: "small", TokensUsed: 10})
+
+	m := s.Metrics()
+	if m.Sessions != 2 || m.Subagents != 10 {
+		t.Errorf("Sessions = %d, Subagents = %d, want 2 and 10", m.Sessions, m.Subagents)
+	}
+	if len(m.Largest) != 2 || m.Largest[0].ID != "big" || m.Largest[0].Subagents != 10 {
+		t.Errorf("Largest = %+v, want big first", m.Largest)
+	}
+	if m.Largest[0].Bytes < 4096+10*subagentStateSize {
+		t.Errorf("big footprint = %d, want at least its text and subagents", m.Largest[0].Bytes)
+	}
+	if m.SessionBytes != m.Largest[0].Bytes+m.Largest[1].Bytes {
+		t.Errorf("SessionBytes = %d, want the sum of the sessions", m.SessionBytes)
+	}
+	// Only the first "small" has been superseded; the history still
+	// holds it.
+	if m.HistoryFrames != 3 || m.RetainedStates != 1 || m.RetainedBytes != sessionStateSize+len("small") {
+		t.Errorf("HistoryFrames = %d, RetainedStates = %d, RetainedBytes = %d", m.HistoryFrames, m.RetainedStates, m.RetainedBytes)
+	}
+}
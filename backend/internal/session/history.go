@@ -0,0 +1,101 @@
+package session
+
+import (
+	"sort"
+	"time"
+)
+
+// maxHistoryFrames bounds the history however long its window, in case
+// something updates the store far more often than once a poll.
+const maxHistoryFrames = 20000
+
+// historyFrame is what the store held from at until the next frame.
+// Stored states are never modified in place, so frames share the states
+// of sessions that didn't change and a frame costs a map of pointers.
+type historyFrame struct {
+	at       time.Time
+	sessions map[string]*SessionState
+}
+
+// SetHistory keeps what the store held after each change for window, so
+// StateAt can answer what the dashboard was showing at a past moment.
+// Zero turns the history off and drops it.
+func (s *Store) SetHistory(window time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.historyWindow = window
+	if window <= 0 {
+		s.history = nil
+		return
+	}
+	s.pruneHistoryLocked(s.now())
+}
+
+// HistoryWindow returns how far back StateAt can look; zero when the
+// history is off.
+func (s *Store) HistoryWindow() time.Duration {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.historyWindow
+}
+
+// StateAt returns the sessions the store held at t, sorted by ID, and when
+// the store last changed before t. It reports false when the history is off
+// or doesn't reach back to t.
+func (s *Store) StateAt(t time.Time) ([]*SessionState, time.Time, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	i := sort.Search(len(s.history), func(i int) bool { return s.history[i].at.After(t) }) - 1
+	if i < 0 {
+		return nil, time.Time{}, false
+	}
+	frame := s.history[i]
+	result := make([]*SessionState, 0, len(frame.sessions))
+	for _, st := range frame.sessions {
+		result = append(result, st.Clone())
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result, frame.at, true
+}
+
+// recordLocked appends a frame for a committed batch of mutations, applying
+// them to the previous frame. Caller must hold s.mu for writing.
+func (s *Store) recordLocked(batch []Mutation, now time.Time) {
+	if s.historyWindow <= 0 {
+		return
+	}
+	var sessions map[string]*SessionState
+	if len(s.history) == 0 {
+		sessions = make(map[string]*SessionState, len(s.sessions))
+		for id, st := range s.sessions {
+			sessions[id] = st
+		}
+	} else {
+		prev := s.history[len(s.history)-1].sessions
+		sessions = make(map[string]*SessionState, len(prev)+len(batch))
+		for id, st := range prev {
+			sessions[id] = st
+		}
+		for i := 0; i < len(batch); i++ {
+			if batch[i].Op == MutationRemove {
+				delete(sessions, batch[i].ID)
+			} else {
+				sessions[batch[i].ID] = batch[i].State
+			}
+		}
+	}
+	s.history = append(s.history, historyFrame{at: now, sessions: sessions})
+	s.pruneHistoryLocked(now)
+}
+
+// pruneHistoryLocked drops frames that are no longer needed to answer for
+// the window, keeping the newest frame older than it. Caller must hold s.mu
+// for writing.
+func (s *Store) pruneHistoryLocked(now time.Time) {
+	cutoff := now.Add(-s.historyWindow)
+	drop := sort.Search(len(s.history), func(i int) bool { return s.history[i].at.After(cutoff) }) - 1
+	drop = max(drop, len(s.history)-maxHistoryFrames)
+	if drop > 0 {
+		s.history = append(s.history[:0], s.history[drop:]...)
+	}
+}
@@ -0,0 +1,75 @@
+package session
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStoreStateAt(t *testing.T) {
+	now := time.Date(2026, 3, 1, 14, 30, 0, 0, time.UTC)
+	s := NewStore()
+	s.now = func() time.Time { return now }
+	s.SetHistory(10 * time.Minute)
+
+	s.Update(&SessionState{ID: "a", Activity: Thinking, TokensUsed: 100})
+	now = now.Add(time.Minute)
+	s.Update(&SessionState{ID: "b", Activity: ToolUse})
+	now = now.Add(time.Minute)
+	s.Update(&SessionState{ID: "a", Activity: Waiting, TokensUsed: 900})
+	now = now.Add(time.Minute)
+	s.Remove("b")
+
+	if _, _, ok := s.StateAt(time.Date(2026, 3, 1, 14, 29, 0, 0, time.UTC)); ok {
+		t.Error("StateAt before the first change should fail")
+	}
+
+	// 14:31:30 is after b arrived and before a went waiting.
+	sessions, at, ok := s.StateAt(time.Date(2026, 3, 1, 14, 31, 30, 0, time.UTC))
+	if !ok {
+		t.Fatal("StateAt(14:31:30) failed")
+	}
+	if !at.Equal(time.Date(2026, 3, 1, 14, 31, 0, 0, time.UTC)) {
+		t.Errorf("at = %v, want 14:31", at)
+	}
+	if len(sessions) != 2 || sessions[0].ID != "a" || sessions[0].TokensUsed != 100 || sessions[1].ID != "b" {
+		t.Errorf("sessions = %+v", sessions)
+	}
+
+	// What StateAt returns is a copy.
+	sessions[0].TokensUsed = 5
+	if again, _, _ := s.StateAt(at); again[0].TokensUsed != 100 {
+		t.Error("modifying a returned session changed the history")
+	}
+
+	if sessions, _, _ := s.StateAt(now); len(sessions) != 1 || sessions[0].Activity != Waiting {
+		t.Errorf("latest = %+v, want only a, waiting", sessions)
+	}
+}
+
+func TestStoreHistoryWindow(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	s := NewStore()
+	s.now = func() time.Time { return now }
+
+	s.Update(&SessionState{ID: "a"})
+	if _, _, ok := s.StateAt(now); ok {
+		t.Fatal("history recorded while off")
+	}
+
+	s.SetHistory(5 * time.Minute)
+	for i := 0; i < 20; i++ {
+		now = now.Add(time.Minute)
+		s.Update(&SessionState{ID: "a", MessageCount: i})
+	}
+	if len(s.history) != 6 {
+		t.Errorf("kept %d frames, want the 5 in the window and the one before it", len(s.history))
+	}
+	if _, _, ok := s.StateAt(now.Add(-5 * time.Minute)); !ok {
+		t.Error("start of the window not answerable")
+	}
+
+	s.SetHistory(0)
+	if _, _, ok := s.StateAt(now); ok || s.history != nil {
+		t.Error("turning history off should drop it")
+	}
+}
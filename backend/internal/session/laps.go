@@ -0,0 +1,74 @@
+package session
+
+// LapMode decides what completes a lap.
+type LapMode string
+
+const (
+	// LapsCompaction counts one lap per context compaction, with the
+	// current lap's progress being context utilization. It is the default.
+	LapsCompaction LapMode = "compaction"
+	// LapsTokens counts one lap per LapRule.Tokens tokens burned, across
+	// compactions.
+	LapsTokens LapMode = "tokens"
+)
+
+// LapRule configures lap counting.
+type LapRule struct {
+	Mode   LapMode
+	Tokens int // tokens per lap in LapsTokens mode
+}
+
+// Lap records a session finishing a lap during ApplyLaps.
+type Lap struct {
+	SessionID string
+	Name      string
+	Lap       int // number of the lap just completed, 1-based
+}
+
+// ApplyLaps sets LapCount and LapProgress on every update, using current
+// (the last committed states) to carry token totals forward, and returns
+// one Lap per lap completed since then.
+func ApplyLaps(current, updates []*SessionState, rule LapRule) []Lap {
+	prev := make(map[string]*SessionState, len(current))
+	for _, s := range current {
+		prev[s.ID] = s
+	}
+
+	var laps []Lap
+	for _, u := range updates {
+		p := prev[u.ID]
+		before := 0
+		if p != nil {
+			before = p.LapCount
+		}
+
+		// Tokens burned only ever grow, so a compaction dropping
+		// TokensUsed does not take a lap back.
+		burned := u.TokensUsed
+		if p != nil {
+			burned = p.TokensBurned
+			if u.TokensUsed > p.TokensUsed {
+				burned += u.TokensUsed - p.TokensUsed
+			}
+		}
+		u.TokensBurned = burned
+
+		if rule.Mode == LapsTokens && rule.Tokens > 0 {
+			u.LapCount = burned / rule.Tokens
+			u.LapProgress = float64(burned%rule.Tokens) / float64(rule.Tokens)
+		} else {
+			u.LapCount = u.CompactionCount
+			u.LapProgress = u.ContextUtilization
+		}
+
+		// A session's first appearance sets its baseline quietly; only
+		// laps finished while we were watching are announced.
+		if p == nil {
+			continue
+		}
+		for lap := before + 1; lap <= u.LapCount; lap++ {
+			laps = append(laps, Lap{SessionID: u.ID, Name: u.Name, Lap: lap})
+		}
+	}
+	return laps
+}
@@ -0,0 +1,52 @@
+package session
+
+import "testing"
+
+func TestApplyLaps_Compaction(t *testing.T) {
+	rule := LapRule{Mode: LapsCompaction}
+	first := &SessionState{ID: "a", Name: "alpha", CompactionCount: 2, ContextUtilization: 0.3}
+	if laps := ApplyLaps(nil, []*SessionState{first}, rule); len(laps) != 0 {
+		t.Fatalf("first sighting announced %+v", laps)
+	}
+	if first.LapCount != 2 || first.LapProgress != 0.3 {
+		t.Errorf("lap = %d @ %.1f, want 2 @ 0.3", first.LapCount, first.LapProgress)
+	}
+
+	next := first.Clone()
+	next.CompactionCount = 3
+	next.ContextUtilization = 0.05
+	laps := ApplyLaps([]*SessionState{first}, []*SessionState{next}, rule)
+	if len(laps) != 1 || laps[0] != (Lap{SessionID: "a", Name: "alpha", Lap: 3}) {
+		t.Errorf("laps = %+v, want lap 3 for alpha", laps)
+	}
+}
+
+func TestApplyLaps_TokensSurviveCompaction(t *testing.T) {
+	rule := LapRule{Mode: LapsTokens, Tokens: 1000}
+	s := &SessionState{ID: "a", TokensUsed: 800}
+	ApplyLaps(nil, []*SessionState{s}, rule)
+	if s.LapCount != 0 || s.LapProgress != 0.8 {
+		t.Fatalf("lap = %d @ %.2f, want 0 @ 0.80", s.LapCount, s.LapProgress)
+	}
+
+	// Compaction drops the context to 100 tokens: nothing is burned.
+	compacted := s.Clone()
+	compacted.TokensUsed = 100
+	if laps := ApplyLaps([]*SessionState{s}, []*SessionState{compacted}, rule); len(laps) != 0 {
+		t.Fatalf("compaction announced %+v", laps)
+	}
+	if compacted.TokensBurned != 800 {
+		t.Fatalf("TokensBurned = %d, want 800", compacted.TokensBurned)
+	}
+
+	// Growing back by 2300 tokens takes the total to 3100.
+	grown := compacted.Clone()
+	grown.TokensUsed = 2400
+	laps := ApplyLaps([]*SessionState{compacted}, []*SessionState{grown}, rule)
+	if len(laps) != 3 || laps[0].Lap != 1 || laps[2].Lap != 3 {
+		t.Errorf("laps = %+v, want laps 1 to 3", laps)
+	}
+	if grown.LapCount != 3 || grown.LapProgress != 0.1 {
+		t.Errorf("lap = %d @ %.2f, want 3 @ 0.10", grown.LapCount, grown.LapProgress)
+	}
+}
@@ -0,0 +1,48 @@
+package session
+
+import "strings"
+
+// mcpToolPrefix is the prefix Claude Code uses for tools exposed by MCP
+// servers. Tool names take the form "mcp__<server>__<tool>".
+const mcpToolPrefix = "mcp__"
+
+// MCPServerFromTool returns the MCP server name encoded in a tool name such
+// as "mcp__github__create_pr" ("github"). It returns "" for built-in tools
+// and for names missing either the server or the tool segment.
+func MCPServerFromTool(name string) string {
+	rest, ok := strings.CutPrefix(name, mcpToolPrefix)
+	if !ok {
+		return ""
+	}
+	server, tool, ok := strings.Cut(rest, "__")
+	if !ok || server == "" || tool == "" {
+		return ""
+	}
+	return server
+}
+
+// MergeCounts adds every entry in delta to dst, allocating dst when nil,
+// and returns the (possibly new) map.
+func MergeCounts(dst, delta map[string]int) map[string]int {
+	if len(delta) == 0 {
+		return dst
+	}
+	if dst == nil {
+		dst = make(map[string]int, len(delta))
+	}
+	for k, v := range delta {
+		dst[k] += v
+	}
+	return dst
+}
+
+func cloneCounts(m map[string]int) map[string]int {
+	if m == nil {
+		return nil
+	}
+	c := make(map[string]int, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
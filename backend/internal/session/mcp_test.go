@@ -0,0 +1,36 @@
+package session
+
+import "testing"
+
+func TestMCPServerFromTool(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"mcp__github__create_pr", "github"},
+		{"mcp__claude_ai_Linear__list_issues", "claude_ai_Linear"},
+		{"mcp__sentry__get__event", "sentry"},
+		{"Read", ""},
+		{"Bash", ""},
+		{"mcp__github", ""},
+		{"mcp____tool", ""},
+		{"mcp__github__", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := MCPServerFromTool(tt.name); got != tt.want {
+			t.Errorf("MCPServerFromTool(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMergeCounts(t *testing.T) {
+	got := MergeCounts(nil, map[string]int{"github": 2})
+	got = MergeCounts(got, map[string]int{"github": 1, "linear": 3})
+	if got["github"] != 3 || got["linear"] != 3 {
+		t.Fatalf("MergeCounts = %v, want github=3 linear=3", got)
+	}
+	if MergeCounts(nil, nil) != nil {
+		t.Fatal("MergeCounts(nil, nil) should stay nil")
+	}
+}
@@ -0,0 +1,101 @@
+package session
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MilestoneKind says what a Milestone measures.
+type MilestoneKind string
+
+const (
+	// MilestoneDuration is time running: from the session's start to its
+	// last activity.
+	MilestoneDuration MilestoneKind = "duration"
+	// MilestoneTokens is tokens burned across compactions.
+	MilestoneTokens MilestoneKind = "tokens"
+)
+
+// DurationMilestones are the run times announced for a session.
+var DurationMilestones = []time.Duration{
+	time.Hour, 2 * time.Hour, 4 * time.Hour, 8 * time.Hour, 16 * time.Hour, 24 * time.Hour,
+}
+
+// TokenMilestones are the token totals announced for a session.
+var TokenMilestones = []int{100_000, 500_000, 1_000_000, 5_000_000, 10_000_000}
+
+// Milestone records a session passing one of the DurationMilestones or
+// TokenMilestones.
+type Milestone struct {
+	SessionID string
+	Name      string
+	Kind      MilestoneKind
+	Value     int64 // seconds for MilestoneDuration, tokens for MilestoneTokens
+}
+
+// Label is the milestone in short form: "4h" or "500k" and "1M" tokens.
+func (m Milestone) Label() string {
+	if m.Kind == MilestoneDuration {
+		return strconv.FormatInt(m.Value/3600, 10) + "h"
+	}
+	if m.Value >= 1_000_000 {
+		return strconv.FormatInt(m.Value/1_000_000, 10) + "M"
+	}
+	return strconv.FormatInt(m.Value/1000, 10) + "k"
+}
+
+// Key names the milestone independently of the session, e.g.
+// "duration_4h" or "tokens_1m".
+func (m Milestone) Key() string {
+	return string(m.Kind) + "_" + strings.ToLower(m.Label())
+}
+
+// Elapsed is how long s has been running, from its start to its last
+// activity; zero before either is known.
+func (s *SessionState) Elapsed() time.Duration {
+	if s.StartedAt.IsZero() || s.LastActivityAt.Before(s.StartedAt) {
+		return 0
+	}
+	return s.LastActivityAt.Sub(s.StartedAt)
+}
+
+// MilestonesSince returns the milestones s has passed since it had been
+// running for elapsed and had burned tokens, durations first, each kind
+// in ascending order.
+func MilestonesSince(elapsed time.Duration, tokens int, s *SessionState) []Milestone {
+	var out []Milestone
+	now := s.Elapsed()
+	for i := 0; i < len(DurationMilestones); i++ {
+		if d := DurationMilestones[i]; elapsed < d && now >= d {
+			out = append(out, Milestone{SessionID: s.ID, Name: s.Name, Kind: MilestoneDuration, Value: int64(d / time.Second)})
+		}
+	}
+	for i := 0; i < len(TokenMilestones); i++ {
+		if n := TokenMilestones[i]; tokens < n && s.TokensBurned >= n {
+			out = append(out, Milestone{SessionID: s.ID, Name: s.Name, Kind: MilestoneTokens, Value: int64(n)})
+		}
+	}
+	return out
+}
+
+// ApplyMilestones returns the milestones each update has passed since its
+// last committed state in current. Updates must have been through
+// ApplyLaps, which carries TokensBurned forward. As with laps, a session's
+// first appearance only sets its baseline.
+func ApplyMilestones(current, updates []*SessionState) []Milestone {
+	prev := make(map[string]*SessionState, len(current))
+	for _, s := range current {
+		prev[s.ID] = s
+	}
+
+	var out []Milestone
+	for _, u := range updates {
+		p := prev[u.ID]
+		if p == nil {
+			continue
+		}
+		out = append(out, MilestonesSince(p.Elapsed(), p.TokensBurned, u)...)
+	}
+	return out
+}
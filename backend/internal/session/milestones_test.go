@@ -0,0 +1,58 @@
+package session
+
+import (
+	"testing"
+	"time"
+)
+
+func TestApplyMilestones(t *testing.T) {
+	start := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	first := &SessionState{ID: "a", Name: "alpha", StartedAt: start, LastActivityAt: start.Add(90 * time.Minute), TokensBurned: 400_000}
+	if ms := ApplyMilestones(nil, []*SessionState{first}); len(ms) != 0 {
+		t.Fatalf("first sighting announced %+v", ms)
+	}
+
+	next := first.Clone()
+	next.LastActivityAt = start.Add(4*time.Hour + time.Minute)
+	next.TokensBurned = 1_200_000
+	ms := ApplyMilestones([]*SessionState{first}, []*SessionState{next})
+	var keys []string
+	for _, m := range ms {
+		if m.SessionID != "a" || m.Name != "alpha" {
+			t.Errorf("milestone %+v is not alpha's", m)
+		}
+		keys = append(keys, m.Key())
+	}
+	want := []string{"duration_2h", "duration_4h", "tokens_500k", "tokens_1m"}
+	if len(keys) != len(want) {
+		t.Fatalf("milestones = %v, want %v", keys, want)
+	}
+	for i := 0; i < len(want); i++ {
+		if keys[i] != want[i] {
+			t.Errorf("milestones = %v, want %v", keys, want)
+			break
+		}
+	}
+
+	// Nothing new is passed on the next poll.
+	if ms := ApplyMilestones([]*SessionState{next}, []*SessionState{next.Clone()}); len(ms) != 0 {
+		t.Errorf("repeated %+v", ms)
+	}
+}
+
+func TestMilestoneLabel(t *testing.T) {
+	tests := []struct {
+		m    Milestone
+		want string
+	}{
+		{Milestone{Kind: MilestoneDuration, Value: 3600}, "1h"},
+		{Milestone{Kind: MilestoneDuration, Value: 16 * 3600}, "16h"},
+		{Milestone{Kind: MilestoneTokens, Value: 100_000}, "100k"},
+		{Milestone{Kind: MilestoneTokens, Value: 5_000_000}, "5M"},
+	}
+	for _, tt := range tests {
+		if got := tt.m.Label(); got != tt.want {
+			t.Errorf("Label(%+v) = %q, want %q", tt.m, got, tt.want)
+		}
+	}
+}
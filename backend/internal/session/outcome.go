@@ -0,0 +1,21 @@
+package session
+
+// OutcomeKind classifies what a finished session left behind in its
+// working directory. It is only set once a session reaches a terminal
+// state, and stays empty when the outcome cannot be determined (for
+// example outside a git repository, or for lost sessions).
+type OutcomeKind string
+
+const (
+	// OutcomeCommitted means at least one new commit landed on HEAD
+	// while the session was running.
+	OutcomeCommitted OutcomeKind = "committed"
+	// OutcomeUncommitted means the working tree changed but nothing
+	// was committed.
+	OutcomeUncommitted OutcomeKind = "uncommitted"
+	// OutcomeNoChanges means the session completed without touching
+	// the repository.
+	OutcomeNoChanges OutcomeKind = "no_changes"
+	// OutcomeErrored means the session ended with an error.
+	OutcomeErrored OutcomeKind = "errored"
+)
@@ -0,0 +1,211 @@
+package session
+
+import (
+	"sort"
+	"time"
+)
+
+// ProgressMetric decides what "ahead" means when ranking sessions.
+type ProgressMetric string
+
+const (
+	// MetricContext ranks by context utilization, an estimate of how close
+	// a session is to its context ceiling. It is the default.
+	MetricContext   ProgressMetric = "context"
+	MetricTokens    ProgressMetric = "tokens"
+	MetricMessages  ProgressMetric = "messages"
+	MetricToolCalls ProgressMetric = "tool_calls"
+	// MetricElapsed ranks the longest-running session first.
+	MetricElapsed ProgressMetric = "elapsed"
+)
+
+// ProgressMetrics lists every valid metric, default first.
+var ProgressMetrics = []ProgressMetric{MetricContext, MetricTokens, MetricMessages, MetricToolCalls, MetricElapsed}
+
+// Valid reports whether m is one of ProgressMetrics.
+func (m ProgressMetric) Valid() bool {
+	for i := 0; i < len(ProgressMetrics); i++ {
+		if ProgressMetrics[i] == m {
+			return true
+		}
+	}
+	return false
+}
+
+// Ahead reports whether a ranks before b under m. Ties go to the session
+// that started earlier, then to the lower ID, so every caller produces the
+// same order. An unknown metric ranks like MetricContext.
+func (m ProgressMetric) Ahead(a, b *SessionState) bool {
+	switch m {
+	case MetricTokens:
+		if a.TokensUsed != b.TokensUsed {
+			return a.TokensUsed > b.TokensUsed
+		}
+	case MetricMessages:
+		if a.MessageCount != b.MessageCount {
+			return a.MessageCount > b.MessageCount
+		}
+	case MetricToolCalls:
+		if a.ToolCallCount != b.ToolCallCount {
+			return a.ToolCallCount > b.ToolCallCount
+		}
+	case MetricElapsed:
+		// Falls through to the start-time tie-break below.
+	default:
+		if a.ContextUtilization != b.ContextUtilization {
+			return a.ContextUtilization > b.ContextUtilization
+		}
+	}
+	if !a.StartedAt.Equal(b.StartedAt) {
+		return a.StartedAt.Before(b.StartedAt)
+	}
+	return a.ID < b.ID
+}
+
+// SortByPosition orders sessions for payloads: racing sessions by
+// Position, then everything else by ID.
+func SortByPosition(states []*SessionState) {
+	sort.SliceStable(states, func(i, j int) bool {
+		a, b := states[i], states[j]
+		if (a.Position > 0) != (b.Position > 0) {
+			return a.Position > 0
+		}
+		if a.Position != b.Position {
+			return a.Position < b.Position
+		}
+		return a.ID < b.ID
+	})
+}
+
+// RaceRules bundles the settings for one ranking pass.
+type RaceRules struct {
+	Metric ProgressMetric
+	Laps   LapRule
+	Energy EnergyModel
+	// SpeedSmoothing is the SmoothRate window for burn rates.
+	SpeedSmoothing time.Duration
+}
+
+// Race runs AssignPositions, ApplyLaps and ApplyEnergy over updates. It
+// returns the updates plus any session whose position changed, and the
+// overtakes and laps to announce.
+func Race(current, updates []*SessionState, rules RaceRules) ([]*SessionState, []Overtake, []Lap) {
+	out, overtakes := AssignPositions(current, updates, rules.Metric)
+	laps := ApplyLaps(current, out, rules.Laps)
+	ApplyEnergy(current, out, rules.Energy)
+	return out, overtakes, laps
+}
+
+// Overtake records one session passing another during AssignPositions.
+type Overtake struct {
+	OvertakerID   string
+	OvertakerName string
+	OvertakenID   string
+	OvertakenName string
+	NewPosition   int
+}
+
+// AssignPositions ranks every non-terminal session in current (the last
+// committed states) with updates applied on top, and sets Position and
+// PositionDelta on the updates. Sessions in current that are not being
+// updated but whose position changed are appended as clones, so the caller
+// commits and broadcasts the whole new order rather than leaving stale
+// positions behind. Terminal updates get position 0.
+//
+// Sessions are ranked by metric; see ProgressMetric.Ahead.
+//
+// The returned overtakes list sessions that moved up past a session that
+// is still racing.
+func AssignPositions(current, updates []*SessionState, metric ProgressMetric) ([]*SessionState, []Overtake) {
+	prev := make(map[string]*SessionState, len(current))
+	for _, s := range current {
+		prev[s.ID] = s
+	}
+	updated := make(map[string]bool, len(updates))
+	for _, u := range updates {
+		updated[u.ID] = true
+	}
+
+	// Combined view: updates override the committed states.
+	combined := make([]*SessionState, 0, len(current)+len(updates))
+	for _, s := range current {
+		if !updated[s.ID] {
+			combined = append(combined, s)
+		}
+	}
+	combined = append(combined, updates...)
+
+	racing := make([]*SessionState, 0, len(combined))
+	for _, s := range combined {
+		if !s.IsTerminal() {
+			racing = append(racing, s)
+		}
+	}
+	sort.SliceStable(racing, func(i, j int) bool {
+		return metric.Ahead(racing[i], racing[j])
+	})
+	newPos := make(map[string]int, len(racing))
+	for i := 0; i < len(racing); i++ {
+		newPos[racing[i].ID] = i + 1
+	}
+
+	// Previous order among sessions that were racing: position -> ID.
+	prevOrder := make(map[int]string, len(current))
+	for _, s := range current {
+		if s.Position > 0 && !s.IsTerminal() {
+			prevOrder[s.Position] = s.ID
+		}
+	}
+
+	out := updates
+	for _, s := range current {
+		if updated[s.ID] || s.IsTerminal() || newPos[s.ID] == s.Position {
+			continue
+		}
+		out = append(out, s.Clone())
+	}
+
+	var overtakes []Overtake
+	for _, u := range out {
+		np, active := newPos[u.ID]
+		if !active {
+			u.Position = 0
+			u.PositionDelta = 0
+			continue
+		}
+		pp := 0
+		if p, ok := prev[u.ID]; ok {
+			pp = p.Position
+		}
+		u.Position = np
+		u.PositionDelta = 0
+		if pp > 0 {
+			u.PositionDelta = pp - np // positive = moved up
+		}
+
+		// Overtake: moved up, and the car that held our new slot is a
+		// different one that is still racing.
+		if u.PositionDelta <= 0 {
+			continue
+		}
+		overtakenID, ok := prevOrder[np]
+		if !ok || overtakenID == u.ID {
+			continue
+		}
+		if _, racing := newPos[overtakenID]; !racing {
+			continue
+		}
+		overtakenName := prev[overtakenID].Name
+		if overtakenName == "" {
+			overtakenName = overtakenID
+		}
+		overtakes = append(overtakes, Overtake{
+			OvertakerID:   u.ID,
+			OvertakerName: u.Name,
+			OvertakenID:   overtakenID,
+			OvertakenName: overtakenName,
+			NewPosition:   np,
+		})
+	}
+	return out, overtakes
+}
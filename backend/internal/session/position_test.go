@@ -0,0 +1,138 @@
+package session
+
+import (
+	"testing"
+	"time"
+)
+
+func racer(id string, util float64, pos int) *SessionState {
+	return &SessionState{ID: id, Name: id, Activity: Thinking, ContextUtilization: util, Position: pos}
+}
+
+func positions(states []*SessionState) map[string]int {
+	out := make(map[string]int, len(states))
+	for _, s := range states {
+		out[s.ID] = s.Position
+	}
+	return out
+}
+
+func TestAssignPositions_RanksByUtilization(t *testing.T) {
+	updates := []*SessionState{racer("a", 0.2, 0), racer("b", 0.6, 0), racer("c", 0.4, 0)}
+	out, overtakes := AssignPositions(nil, updates, MetricContext)
+
+	got := positions(out)
+	if got["b"] != 1 || got["c"] != 2 || got["a"] != 3 {
+		t.Errorf("positions = %v, want b=1 c=2 a=3", got)
+	}
+	if len(overtakes) != 0 {
+		t.Errorf("first ranking reported overtakes: %+v", overtakes)
+	}
+}
+
+func TestAssignPositions_OvertakeMovesTheOtherCarToo(t *testing.T) {
+	current := []*SessionState{racer("a", 0.5, 1), racer("b", 0.4, 2)}
+	out, overtakes := AssignPositions(current, []*SessionState{racer("b", 0.7, 2)}, MetricContext)
+
+	if len(out) != 2 {
+		t.Fatalf("got %d states, want the update plus the passed car", len(out))
+	}
+	got := positions(out)
+	if got["b"] != 1 || got["a"] != 2 {
+		t.Errorf("positions = %v, want b=1 a=2", got)
+	}
+	if out[0].PositionDelta != 1 || out[1].PositionDelta != -1 {
+		t.Errorf("deltas = %d, %d, want 1, -1", out[0].PositionDelta, out[1].PositionDelta)
+	}
+	if len(overtakes) != 1 || overtakes[0].OvertakerID != "b" || overtakes[0].OvertakenID != "a" || overtakes[0].NewPosition != 1 {
+		t.Errorf("overtakes = %+v, want b passing a for P1", overtakes)
+	}
+	if current[0].Position != 1 {
+		t.Error("current states were modified")
+	}
+}
+
+func TestAssignPositions_FinisherIsNotOvertaken(t *testing.T) {
+	current := []*SessionState{racer("a", 0.5, 1), racer("b", 0.4, 2)}
+	done := racer("a", 0.5, 1)
+	done.Activity = Complete
+	out, overtakes := AssignPositions(current, []*SessionState{done}, MetricContext)
+
+	got := positions(out)
+	if got["a"] != 0 || got["b"] != 1 {
+		t.Errorf("positions = %v, want a=0 b=1", got)
+	}
+	if len(overtakes) != 0 {
+		t.Errorf("moving up behind a finisher reported %+v", overtakes)
+	}
+}
+
+func TestAssignPositions_TiesAreStable(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	late := racer("a", 0.5, 0)
+	late.StartedAt = start.Add(time.Minute)
+	early := racer("z", 0.5, 0)
+	early.StartedAt = start
+	twin := racer("m", 0.5, 0)
+	twin.StartedAt = start
+
+	for i := 0; i < 5; i++ {
+		out, _ := AssignPositions(nil, []*SessionState{late, twin, early}, MetricContext)
+		got := positions(out)
+		if got["m"] != 1 || got["z"] != 2 || got["a"] != 3 {
+			t.Fatalf("positions = %v, want m=1 z=2 a=3", got)
+		}
+	}
+}
+
+func TestAssignPositions_Metrics(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	a := &SessionState{ID: "a", Activity: Thinking, ContextUtilization: 0.9, TokensUsed: 100, MessageCount: 30, ToolCallCount: 1, StartedAt: start.Add(2 * time.Minute)}
+	b := &SessionState{ID: "b", Activity: Thinking, ContextUtilization: 0.1, TokensUsed: 900, MessageCount: 10, ToolCallCount: 2, StartedAt: start.Add(time.Minute)}
+	c := &SessionState{ID: "c", Activity: Thinking, ContextUtilization: 0.5, TokensUsed: 500, MessageCount: 20, ToolCallCount: 3, StartedAt: start}
+
+	tests := []struct {
+		metric ProgressMetric
+		want   string
+	}{
+		{MetricContext, "acb"},
+		{MetricTokens, "bca"},
+		{MetricMessages, "acb"},
+		{MetricToolCalls, "cba"},
+		{MetricElapsed, "cba"},
+	}
+	for _, tt := range tests {
+		out, _ := AssignPositions(nil, []*SessionState{a.Clone(), b.Clone(), c.Clone()}, tt.metric)
+		SortByPosition(out)
+		got := ""
+		for _, s := range out {
+			got += s.ID
+		}
+		if got != tt.want {
+			t.Errorf("%s: order = %s, want %s", tt.metric, got, tt.want)
+		}
+	}
+}
+
+func TestSortByPosition(t *testing.T) {
+	states := []*SessionState{
+		{ID: "done-b"},
+		{ID: "p2", Position: 2},
+		{ID: "done-a"},
+		{ID: "p1", Position: 1},
+	}
+	SortByPosition(states)
+	got := ""
+	for _, s := range states {
+		got += s.ID + " "
+	}
+	if got != "p1 p2 done-a done-b " {
+		t.Errorf("order = %q", got)
+	}
+}
+
+func TestProgressMetricValid(t *testing.T) {
+	if !MetricToolCalls.Valid() || ProgressMetric("speed").Valid() || ProgressMetric("").Valid() {
+		t.Error("Valid() disagrees with ProgressMetrics")
+	}
+}
@@ -4,6 +4,23 @@ import (
 	"crypto/sha256"
 	"fmt"
 	"path/filepath"
+	"strings"
+)
+
+// PrivacyLevel is how much of its sessions a repository lets out, set in
+// its .agent-racer.yaml. It tightens the server's PrivacyFilter and can
+// never loosen it.
+type PrivacyLevel string
+
+const (
+	// PrivacyDefault leaves the session to the PrivacyFilter.
+	PrivacyDefault PrivacyLevel = ""
+	// PrivacyMasked masks the session's working directory as
+	// MaskWorkingDirs does.
+	PrivacyMasked PrivacyLevel = "masked"
+	// PrivacyHidden keeps the session out of the server altogether: it
+	// is never tracked, so it is not broadcast, recorded or counted.
+	PrivacyHidden PrivacyLevel = "hidden"
 )
 
 // PrivacyFilter applies masking and path-based filtering to session state
@@ -15,6 +32,7 @@ type PrivacyFilter struct {
 	MaskTmuxTargets bool
 	AllowedPaths    []string
 	BlockedPaths    []string
+	HideViewerNames bool // presence messages count viewers without naming them
 }
 
 // IsAllowed reports whether a session with the given working directory should
@@ -67,9 +85,20 @@ func matchPathOrParent(pattern, path string) bool {
 func (f *PrivacyFilter) Apply(s *SessionState) *SessionState {
 	masked := *s
 
-	if f.MaskWorkingDirs && masked.WorkingDir != "" {
+	if (f.MaskWorkingDirs || s.Privacy == PrivacyMasked) && masked.WorkingDir != "" {
+		if masked.Topic != "" {
+			masked.Topic = strings.ReplaceAll(masked.Topic, masked.WorkingDir, filepath.Base(masked.WorkingDir))
+		}
+		masked.FilesPatched = maskPaths(masked.FilesPatched, masked.WorkingDir)
+		masked.FilesTouched = maskPaths(masked.FilesTouched, masked.WorkingDir)
 		masked.WorkingDir = filepath.Base(masked.WorkingDir)
 	}
+	if f.MaskWorkingDirs || s.Privacy == PrivacyMasked {
+		// Both URLs name the repository's owner and name, which is
+		// what masking the directory hides.
+		masked.IssueURL = ""
+		masked.PRURL = ""
+	}
 
 	if f.MaskSessionIDs && masked.ID != "" {
 		masked.ID = shortHash(masked.ID)
@@ -117,6 +146,32 @@ func (f *PrivacyFilter) FilterSlice(sessions []*SessionState) []*SessionState {
 	return result
 }
 
+// maskPaths rewrites absolute paths in files relative to workingDir, or to
+// their base name when they lie outside it, so masked sessions do not leak
+// directory layout through their patch counts.
+func maskPaths(files map[string]int, workingDir string) map[string]int {
+	if len(files) == 0 {
+		return files
+	}
+	out := make(map[string]int, len(files))
+	for path, n := range files {
+		out[maskPath(path, workingDir)] += n
+	}
+	return out
+}
+
+// maskPath makes an absolute path relative to workingDir, or reduces it to
+// its base name when it lies outside.
+func maskPath(path, workingDir string) string {
+	if !filepath.IsAbs(path) {
+		return path
+	}
+	if rel, err := filepath.Rel(workingDir, path); err == nil && rel != ".." && !strings.HasPrefix(rel, "../") {
+		return rel
+	}
+	return filepath.Base(path)
+}
+
 // IsNoop reports whether the filter does nothing (no masking, no path filtering).
 func (f *PrivacyFilter) IsNoop() bool {
 	return !f.MaskWorkingDirs && !f.MaskSessionIDs && !f.MaskPIDs && !f.MaskTmuxTargets &&
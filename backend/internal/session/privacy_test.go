@@ -96,6 +96,8 @@ func TestPrivacyFilter_Apply(t *testing.T) {
 		WorkingDir: "/home/user/projects/myproject",
 		PID:        12345,
 		TmuxTarget: "main:2.0",
+		IssueURL:   "https://github.com/acme/myproject/issues/12",
+		PRURL:      "https://github.com/acme/myproject/pull/34",
 	}
 
 	t.Run("mask working dirs", func(t *testing.T) {
@@ -104,6 +106,21 @@ func TestPrivacyFilter_Apply(t *testing.T) {
 		if result.WorkingDir != "myproject" {
 			t.Errorf("expected WorkingDir = %q, got %q", "myproject", result.WorkingDir)
 		}
+		if result.IssueURL != "" || result.PRURL != "" {
+			t.Errorf("links naming the repository kept: issue %q, PR %q", result.IssueURL, result.PRURL)
+		}
+	})
+
+	t.Run("repository masks its working dir", func(t *testing.T) {
+		s := original.Clone()
+		s.Privacy = PrivacyMasked
+		result := (&PrivacyFilter{}).Apply(s)
+		if result.WorkingDir != "myproject" {
+			t.Errorf("expected WorkingDir = %q, got %q", "myproject", result.WorkingDir)
+		}
+		if result.IssueURL != "" || result.PRURL != "" {
+			t.Errorf("links naming the repository kept: issue %q, PR %q", result.IssueURL, result.PRURL)
+		}
 	})
 
 	t.Run("mask session IDs", func(t *testing.T) {
@@ -137,7 +154,8 @@ func TestPrivacyFilter_Apply(t *testing.T) {
 		f := &PrivacyFilter{}
 		result := f.Apply(original)
 		if result.ID != original.ID || result.WorkingDir != original.WorkingDir ||
-			result.PID != original.PID || result.TmuxTarget != original.TmuxTarget {
+			result.PID != original.PID || result.TmuxTarget != original.TmuxTarget ||
+			result.IssueURL != original.IssueURL || result.PRURL != original.PRURL {
 			t.Error("no-op filter should not change any fields")
 		}
 	})
@@ -322,6 +340,43 @@ func TestPrivacyFilter_Apply_MaskWorkingDirs_SubagentsUnaffected(t *testing.T) {
 	}
 }
 
+func TestPrivacyFilter_Apply_MaskWorkingDirs_RelativizesPatchedFiles(t *testing.T) {
+	f := &PrivacyFilter{MaskWorkingDirs: true}
+	s := &SessionState{
+		WorkingDir: "/home/user/repo",
+		FilesPatched: map[string]int{
+			"/home/user/repo/internal/a.go": 2,
+			"internal/a.go":                 1,
+			"/etc/hosts":                    1,
+		},
+	}
+	got := f.Apply(s)
+	want := map[string]int{"internal/a.go": 3, "hosts": 1}
+	if len(got.FilesPatched) != len(want) {
+		t.Fatalf("FilesPatched = %v, want %v", got.FilesPatched, want)
+	}
+	for k, v := range want {
+		if got.FilesPatched[k] != v {
+			t.Errorf("FilesPatched[%q] = %d, want %d", k, got.FilesPatched[k], v)
+		}
+	}
+	if s.FilesPatched["/home/user/repo/internal/a.go"] != 2 {
+		t.Error("Apply modified the original FilesPatched")
+	}
+}
+
+func TestPrivacyFilter_Apply_MaskWorkingDirs_RelativizesTouchedFiles(t *testing.T) {
+	f := &PrivacyFilter{MaskWorkingDirs: true}
+	s := &SessionState{
+		WorkingDir:   "/home/user/repo",
+		FilesTouched: map[string]int{"/home/user/repo/cmd/main.go": 4},
+	}
+	got := f.Apply(s)
+	if len(got.FilesTouched) != 1 || got.FilesTouched["cmd/main.go"] != 4 {
+		t.Errorf("FilesTouched = %v, want cmd/main.go=4", got.FilesTouched)
+	}
+}
+
 func TestPrivacyFilter_Apply_MaskSessionIDs_MasksSubagentSessionID(t *testing.T) {
 	original := &SessionState{
 		ID:         "claude:abc123",
@@ -611,3 +666,20 @@ func TestPrivacyFilter_FilterSlice_EmptyWorkingDirAllowed(t *testing.T) {
 		t.Fatalf("expected 2 sessions (empty dir always allowed), got %d", len(result))
 	}
 }
+
+func TestPrivacyFilter_Apply_MaskWorkingDirs_ScrubsTopic(t *testing.T) {
+	f := &PrivacyFilter{MaskWorkingDirs: true}
+	s := &SessionState{
+		WorkingDir: "/home/user/secret-project",
+		Topic:      "fix build in /home/user/secret-project",
+	}
+
+	masked := f.Apply(s)
+
+	if masked.Topic != "fix build in secret-project" {
+		t.Errorf("Topic = %q, want working dir reduced to its base name", masked.Topic)
+	}
+	if s.Topic != "fix build in /home/user/secret-project" {
+		t.Error("Apply must not modify the original state")
+	}
+}
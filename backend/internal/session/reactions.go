@@ -0,0 +1,22 @@
+package session
+
+// AddReaction counts one more emoji reaction against session id and
+// returns the session as stored afterwards. Reactions belong to the store:
+// updates from elsewhere keep the stored counts, whatever they carry. It
+// reports false if the session does not exist.
+func (s *Store) AddReaction(id, emoji string) (*SessionState, bool) {
+	s.mu.Lock()
+	existing, ok := s.sessions[id]
+	if !ok {
+		s.mu.Unlock()
+		return nil, false
+	}
+	stored := existing.Clone()
+	stored.Reactions = MergeCounts(stored.Reactions, map[string]int{emoji: 1})
+	s.sessions[id] = stored
+	batch := []Mutation{{Op: MutationUpdate, ID: id, State: stored}}
+	subscribers := s.commitLocked(batch)
+	s.mu.Unlock()
+	publish(subscribers, batch, nil)
+	return stored.Clone(), true
+}
@@ -0,0 +1,46 @@
+package session
+
+import "testing"
+
+func TestAddReactionCountsAndSurvivesUpdates(t *testing.T) {
+	s := NewStore()
+	if _, ok := s.AddReaction("missing", "🎉"); ok {
+		t.Fatal("AddReaction on a missing session reported ok")
+	}
+
+	s.Update(&SessionState{ID: "a", Name: "v1"})
+	s.AddReaction("a", "🎉")
+	got, ok := s.AddReaction("a", "🎉")
+	if !ok {
+		t.Fatal("AddReaction reported the session missing")
+	}
+	if got.Reactions["🎉"] != 2 {
+		t.Errorf("returned count = %d, want 2", got.Reactions["🎉"])
+	}
+
+	// The monitor's next update carries no reactions; the store keeps them.
+	s.Update(&SessionState{ID: "a", Name: "v2"})
+	after, _ := s.Get("a")
+	if after.Reactions["🎉"] != 2 || after.Name != "v2" {
+		t.Errorf("after update: reactions = %v, name = %q", after.Reactions, after.Name)
+	}
+}
+
+func TestAddReactionIsLogged(t *testing.T) {
+	s := NewStore()
+	s.SetEventLog(10)
+	var got []Mutation
+	s.Subscribe(func(batch []Mutation) { got = append(got, batch...) })
+	s.Update(&SessionState{ID: "a"})
+	s.AddReaction("a", "👀")
+
+	if len(got) != 2 {
+		t.Fatalf("got %d mutations, want 2", len(got))
+	}
+	if got[1].Op != MutationUpdate || got[1].State.Reactions["👀"] != 1 {
+		t.Errorf("reaction mutation = %+v", got[1])
+	}
+	if got[1].Seq != got[0].Seq+1 {
+		t.Errorf("seq %d does not follow %d", got[1].Seq, got[0].Seq)
+	}
+}
@@ -0,0 +1,49 @@
+package session
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// minSpeedScale is the lowest burn rate, in tokens per minute, a speed of
+// 100 can stand for, so the first slow session of a model does not start
+// out flat out.
+const minSpeedScale = 1000.0
+
+// SmoothRate is one step of an exponential moving average of a burn rate:
+// it moves prev towards rate by how much of the time constant window has
+// elapsed, so the result does not depend on how often it is sampled. A
+// window of 0 turns smoothing off.
+func SmoothRate(prev, rate float64, elapsed, window time.Duration) float64 {
+	if window <= 0 || elapsed <= 0 {
+		return rate
+	}
+	alpha := 1 - math.Exp(-float64(elapsed)/float64(window))
+	return prev + alpha*(rate-prev)
+}
+
+// SpeedScale rates smoothed burn rates as a 0-100 speed against the
+// fastest rate it has seen for the same model.
+type SpeedScale struct {
+	mu  sync.Mutex
+	max map[string]float64 // model -> fastest smoothed rate
+}
+
+// NewSpeedScale returns a scale with no history.
+func NewSpeedScale() *SpeedScale {
+	return &SpeedScale{max: make(map[string]float64)}
+}
+
+// Speed records rate for model and returns it as a share of the model's
+// fastest rate so far, from 0 to 100.
+func (sc *SpeedScale) Speed(model string, rate float64) float64 {
+	if rate <= 0 {
+		return 0
+	}
+	sc.mu.Lock()
+	top := max(sc.max[model], rate)
+	sc.max[model] = top
+	sc.mu.Unlock()
+	return 100 * rate / max(top, minSpeedScale)
+}
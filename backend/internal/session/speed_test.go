@@ -0,0 +1,42 @@
+package session
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSmoothRate(t *testing.T) {
+	if got := SmoothRate(1000, 4000, time.Second, 0); got != 4000 {
+		t.Errorf("no window: %v, want the raw 4000", got)
+	}
+	// One time constant covers 1-1/e of the gap.
+	got := SmoothRate(1000, 4000, 20*time.Second, 20*time.Second)
+	if want := 1000 + 3000*(1-1/math.E); math.Abs(got-want) > 1e-9 {
+		t.Errorf("one window: %v, want %v", got, want)
+	}
+	// Sampling twice as often over the same span lands in the same place.
+	half := SmoothRate(SmoothRate(1000, 4000, 10*time.Second, 20*time.Second), 4000, 10*time.Second, 20*time.Second)
+	if math.Abs(half-got) > 1e-9 {
+		t.Errorf("two half steps: %v, one full step: %v", half, got)
+	}
+}
+
+func TestSpeedScale(t *testing.T) {
+	sc := NewSpeedScale()
+	if got := sc.Speed("opus", 500); got != 50 {
+		t.Errorf("first slow reading: %v, want 50 against the 1000/min floor", got)
+	}
+	if got := sc.Speed("opus", 4000); got != 100 {
+		t.Errorf("fastest yet: %v, want 100", got)
+	}
+	if got := sc.Speed("opus", 1000); got != 25 {
+		t.Errorf("after a 4000 peak: %v, want 25", got)
+	}
+	if got := sc.Speed("haiku", 2000); got != 100 {
+		t.Errorf("other model: %v, want 100 on its own scale", got)
+	}
+	if got := sc.Speed("opus", 0); got != 0 {
+		t.Errorf("stopped: %v, want 0", got)
+	}
+}
@@ -17,28 +17,31 @@ const (
 	Complete
 	Errored
 	Lost
+	NeedsApproval
 )
 
 var activityNames = map[Activity]string{
-	Starting: "starting",
-	Thinking: "thinking",
-	ToolUse:  "tool_use",
-	Waiting:  "waiting",
-	Idle:     "idle",
-	Complete: "complete",
-	Errored:  "errored",
-	Lost:     "lost",
+	Starting:      "starting",
+	Thinking:      "thinking",
+	ToolUse:       "tool_use",
+	Waiting:       "waiting",
+	Idle:          "idle",
+	Complete:      "complete",
+	Errored:       "errored",
+	Lost:          "lost",
+	NeedsApproval: "needs_approval",
 }
 
 var activityFromName = map[string]Activity{
-	"starting": Starting,
-	"thinking": Thinking,
-	"tool_use": ToolUse,
-	"waiting":  Waiting,
-	"idle":     Idle,
-	"complete": Complete,
-	"errored":  Errored,
-	"lost":     Lost,
+	"starting":       Starting,
+	"thinking":       Thinking,
+	"tool_use":       ToolUse,
+	"waiting":        Waiting,
+	"idle":           Idle,
+	"complete":       Complete,
+	"errored":        Errored,
+	"lost":           Lost,
+	"needs_approval": NeedsApproval,
 }
 
 func (a Activity) String() string {
@@ -71,62 +74,112 @@ func (a *Activity) UnmarshalJSON(data []byte) error {
 type SessionState struct {
 	ID                 string          `json:"id"`
 	Name               string          `json:"name"`
+	Topic              string          `json:"topic,omitempty"` // short label derived from the first prompt (opt-in)
 	Slug               string          `json:"slug,omitempty"` // Internal session name (e.g. "mighty-cuddling-castle")
 	Source             string          `json:"source"`
 	Activity           Activity        `json:"activity"`
 	TokensUsed         int             `json:"tokensUsed"`
 	TokenEstimated     bool            `json:"tokenEstimated"`
+	TokenBreakdown     TokenBreakdown  `json:"tokenBreakdown,omitzero"` // TokensUsed split by message role
 	MaxContextTokens   int             `json:"maxContextTokens"`
 	ContextUtilization float64         `json:"contextUtilization"`
 	CurrentTool        string          `json:"currentTool,omitempty"`
 	Model              string          `json:"model"`
 	WorkingDir         string          `json:"workingDir"`
 	Branch             string          `json:"branch,omitempty"`
+	Project            string          `json:"project,omitempty"`  // primary repository name, shared by all its worktrees
+	Worktree           string          `json:"worktree,omitempty"` // worktree label (usually the branch); empty for the main checkout
+	SubProject         string          `json:"subProject,omitempty"` // package or service within the repository the session works in most, e.g. "services/api"
+	Tags               []string        `json:"tags,omitempty"`     // labels from the repository's .agent-racer.yaml
+	Privacy            PrivacyLevel    `json:"privacy,omitempty"`  // the repository's privacy level; see PrivacyFilter
+	Muted              []string        `json:"muted,omitempty"`    // notification kinds the repository turned off: "completion", "milestone", "budget"
+	IssueURL           string          `json:"issueUrl,omitempty"`
+	PRURL              string          `json:"prUrl,omitempty"`
 	StartedAt          time.Time       `json:"startedAt"`
 	LastActivityAt     time.Time       `json:"lastActivityAt"`
 	LastDataReceivedAt time.Time       `json:"lastDataReceivedAt"`
 	CompletedAt        *time.Time      `json:"completedAt,omitempty"`
+	Outcome            OutcomeKind     `json:"outcome,omitempty"`
 	MessageCount       int             `json:"messageCount"`
 	ToolCallCount      int             `json:"toolCallCount"`
+	MCPToolCalls       map[string]int  `json:"mcpToolCalls,omitempty"` // MCP server name -> tool call count
+	ToolCounts         map[string]int  `json:"toolCounts,omitempty"`    // tool name -> invocation count
+	ShellCommands      map[string]int  `json:"shellCommands,omitempty"` // program run through the shell tool -> count
+	FilesPatched       map[string]int  `json:"filesPatched,omitempty"`  // path edited by a patch tool -> edit count
+	FilesTouched       map[string]int  `json:"-"`                       // path read or edited -> tool calls; see TouchFiles. Served by GET /api/sessions/{id} only
 	PID                int             `json:"pid,omitempty"`
 	IsChurning         bool            `json:"isChurning,omitempty"`
 	TmuxTarget         string          `json:"tmuxTarget,omitempty"`
+	Launched           bool            `json:"launched,omitempty"` // started via POST /api/launch
 	Lane               int             `json:"lane"`
 	BurnRatePerMinute  float64         `json:"burnRatePerMinute,omitempty"`
+	BurnRateSmoothed   float64         `json:"burnRateSmoothed,omitempty"` // BurnRatePerMinute averaged over race.speed_smoothing
+	Speed              float64         `json:"speed,omitempty"`            // 0-100: BurnRateSmoothed against the model's fastest; see SpeedScale
+	Percentiles        *Percentiles    `json:"percentiles,omitempty"`      // duration and burn rate against finished sessions; see Baseline
+	SecondsToCompact   int             `json:"estimatedSecondsToCompaction,omitempty"` // forecast from the burn rate; 0 when none
+	CompactionETA      time.Time       `json:"compactionEta,omitzero"`                 // when the forecast expects the compaction
 	CompactionCount    int             `json:"compactionCount,omitempty"`
+	CacheReadTokens    int             `json:"cacheReadTokens,omitempty"`  // input tokens served from the prompt cache
+	CacheWriteTokens   int             `json:"cacheWriteTokens,omitempty"` // input tokens written to the prompt cache
+	UncachedTokens     int             `json:"uncachedTokens,omitempty"`   // input tokens sent without caching
+	CacheHitRatio      float64         `json:"cacheHitRatio,omitempty"`    // CacheReadTokens over all input tokens
+	CacheSavedTokens   int             `json:"cacheSavedTokens,omitempty"` // estimated saving in uncached input tokens; see CacheUsage.SavedTokens
+	LapCount           int             `json:"lapCount"`    // laps completed; see LapRule
+	LapProgress        float64         `json:"lapProgress"` // 0-1 through the current lap
+	TokensBurned       int             `json:"-"`           // internal: tokens used across compactions, for token laps
+	TokenBudget        int             `json:"tokenBudget,omitempty"` // tokens the repository allows a session to burn; 0 for no limit
+	OverBudget         bool            `json:"overBudget,omitempty"`  // TokensBurned has reached TokenBudget
+	EnergyWh           float64         `json:"energyWh,omitempty"` // estimated energy to serve TokensBurned; see EnergyModel
+	CO2Grams           float64         `json:"co2Grams,omitempty"` // estimated emissions for EnergyWh
 	Subagents          []SubagentState `json:"subagents,omitempty"`
 	LastAssistantText  string          `json:"lastAssistantText,omitempty"`
+	LastCommand        string          `json:"lastCommand,omitempty"`    // most recent slash command, e.g. "/compact"
+	SlashCommands      map[string]int  `json:"slashCommands,omitempty"`  // slash command -> invocation count
+	HookEventCount     int             `json:"hookEventCount,omitempty"` // hook-related system entries seen
+	ModelSwitches      int             `json:"modelSwitches,omitempty"`  // times the model changed mid-session
 	Position           int             `json:"position,omitempty"`      // 1-based rank among non-terminal sessions
 	PositionDelta      int             `json:"positionDelta,omitempty"` // positive = moved up, negative = dropped
+	Severity           Severity        `json:"severity,omitempty"`      // how urgently to announce Activity; set on the way to clients
+	StatusText         string          `json:"statusText,omitempty"`    // spoken summary, e.g. "Session api is waiting for your input, 3 minutes"
+	Reactions          map[string]int  `json:"reactions,omitempty"`     // emoji -> times viewers reacted with it; owned by the Store
 	LogPath            string          `json:"-"` // internal: path to JSONL file, excluded from wire protocol
 }
 
 // SubagentState tracks a single subagent (Task tool invocation) within a
 // parent Claude Code session. Subagents share the parent's JSONL file and
-// are identified by their stable toolUseID.
+// are identified by their stable toolUseID. Subagents can spawn their own;
+// a session's Subagents list them in tree order, each followed by the ones
+// it spawned.
 type SubagentState struct {
-	ID              string     `json:"id"`              // toolUseID — stable across all progress entries
-	ParentToolUseID string     `json:"parentToolUseId"` // links to parent's tool_use block
-	SessionID       string     `json:"sessionId"`       // parent session ID
-	Slug            string     `json:"slug"`            // human-friendly display name
+	ID              string     `json:"id"`                 // toolUseID — stable across all progress entries
+	ParentToolUseID string     `json:"parentToolUseId"`    // links to parent's tool_use block
+	ParentID        string     `json:"parentId,omitempty"` // subagent that spawned this one; empty when the session did
+	Depth           int        `json:"depth"`              // 1 for the session's own subagents, 2 for theirs, and so on
+	SessionID       string     `json:"sessionId"`          // parent session ID
+	Slug            string     `json:"slug"`               // human-friendly display name
 	Model           string     `json:"model"`
 	Activity        Activity   `json:"activity"`
 	CurrentTool     string     `json:"currentTool,omitempty"`
 	TokensUsed      int        `json:"tokensUsed"`
+	OverBudget      bool       `json:"overBudget,omitempty"` // TokensUsed has passed the monitor's per-subagent budget
 	MessageCount    int        `json:"messageCount"`
 	ToolCallCount   int        `json:"toolCallCount"`
 	StartedAt       time.Time  `json:"startedAt"`
 	LastActivityAt  time.Time  `json:"lastActivityAt"`
 	CompletedAt     *time.Time `json:"completedAt,omitempty"`
+	TaskCalls       []string   `json:"-"` // internal: tool_use IDs of the subagents this one spawned
 }
 
-// clone returns a deep copy of the SubagentState, duplicating pointer fields
+// Clone returns a deep copy of the SubagentState, duplicating pointer fields
 // so the copy can be mutated independently of the original.
-func (sa SubagentState) clone() SubagentState {
+func (sa SubagentState) Clone() SubagentState {
 	if sa.CompletedAt != nil {
 		t := *sa.CompletedAt
 		sa.CompletedAt = &t
 	}
+	if sa.TaskCalls != nil {
+		sa.TaskCalls = append([]string(nil), sa.TaskCalls...)
+	}
 	return sa
 }
 
@@ -138,10 +191,27 @@ func (s *SessionState) Clone() *SessionState {
 		t := *s.CompletedAt
 		c.CompletedAt = &t
 	}
+	if s.Percentiles != nil {
+		p := *s.Percentiles
+		c.Percentiles = &p
+	}
+	if s.Tags != nil {
+		c.Tags = append([]string(nil), s.Tags...)
+	}
+	if s.Muted != nil {
+		c.Muted = append([]string(nil), s.Muted...)
+	}
+	c.MCPToolCalls = cloneCounts(s.MCPToolCalls)
+	c.ToolCounts = cloneCounts(s.ToolCounts)
+	c.ShellCommands = cloneCounts(s.ShellCommands)
+	c.FilesPatched = cloneCounts(s.FilesPatched)
+	c.FilesTouched = cloneCounts(s.FilesTouched)
+	c.SlashCommands = cloneCounts(s.SlashCommands)
+	c.Reactions = cloneCounts(s.Reactions)
 	if len(s.Subagents) > 0 {
 		c.Subagents = make([]SubagentState, len(s.Subagents))
 		for i, sa := range s.Subagents {
-			c.Subagents[i] = sa.clone()
+			c.Subagents[i] = sa.Clone()
 		}
 	}
 	return &c
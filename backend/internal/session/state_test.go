@@ -303,7 +303,7 @@ func TestSessionStateClone(t *testing.T) {
 func TestSubagentStateClone(t *testing.T) {
 	t.Run("preserves nil CompletedAt and scalar fields", func(t *testing.T) {
 		orig := SubagentState{ID: "sa-nil", Activity: Thinking}
-		c := orig.clone()
+		c := orig.Clone()
 
 		if c.CompletedAt != nil {
 			t.Errorf("expected nil CompletedAt, got %v", c.CompletedAt)
@@ -316,7 +316,7 @@ func TestSubagentStateClone(t *testing.T) {
 	t.Run("deep-copies CompletedAt", func(t *testing.T) {
 		ts := time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)
 		orig := SubagentState{ID: "sa-deep", CompletedAt: &ts}
-		c := orig.clone()
+		c := orig.Clone()
 
 		if c.CompletedAt == orig.CompletedAt {
 			t.Fatal("CompletedAt pointer not deep-copied")
@@ -375,3 +375,30 @@ func TestIsTerminal(t *testing.T) {
 		})
 	}
 }
+
+func TestCloneDeepCopiesCountMaps(t *testing.T) {
+	s := &SessionState{
+		ID:            "a",
+		MCPToolCalls:  map[string]int{"github": 1},
+		SlashCommands: map[string]int{"/compact": 1},
+		ShellCommands: map[string]int{"git": 1},
+		FilesTouched:  map[string]int{"/src/a.go": 1},
+	}
+	c := s.Clone()
+	c.MCPToolCalls["github"] = 5
+	c.SlashCommands["/compact"] = 5
+	c.ShellCommands["git"] = 5
+	c.FilesTouched["/src/a.go"] = 5
+	if s.MCPToolCalls["github"] != 1 {
+		t.Fatalf("original MCPToolCalls mutated through clone: %v", s.MCPToolCalls)
+	}
+	if s.SlashCommands["/compact"] != 1 {
+		t.Fatalf("original SlashCommands mutated through clone: %v", s.SlashCommands)
+	}
+	if s.ShellCommands["git"] != 1 {
+		t.Fatalf("original ShellCommands mutated through clone: %v", s.ShellCommands)
+	}
+	if s.FilesTouched["/src/a.go"] != 1 {
+		t.Fatalf("original FilesTouched mutated through clone: %v", s.FilesTouched)
+	}
+}
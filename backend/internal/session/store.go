@@ -3,17 +3,31 @@ package session
 import (
 	"sort"
 	"sync"
+	"time"
 )
 
 type Store struct {
 	mu       sync.RWMutex
 	sessions map[string]*SessionState
 	nextLane int
+
+	historyWindow time.Duration // 0 keeps no history; see SetHistory
+	history       []historyFrame
+	now           func() time.Time
+
+	maxSubagents     int // 0 is unlimited; see SetMaxSubagents
+	subagentsDropped uint64
+
+	seq         uint64 // last mutation committed
+	logSize     int    // 0 keeps no event log; see SetEventLog
+	eventLog    []Mutation
+	subscribers []func([]Mutation)
 }
 
 func NewStore() *Store {
 	return &Store{
 		sessions: make(map[string]*SessionState),
+		now:      time.Now,
 	}
 }
 
@@ -41,9 +55,7 @@ func (s *Store) GetAll() []*SessionState {
 }
 
 func (s *Store) Update(state *SessionState) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	s.updateLocked(state)
+	s.UpdateAndNotify(state, nil)
 }
 
 // UpdateAndNotify atomically updates a session and then calls notify after
@@ -51,53 +63,71 @@ func (s *Store) Update(state *SessionState) {
 // typically queues a broadcast (acquiring broadcaster.flushMu), and the
 // broadcaster's flush timer calls store.GetAll (acquiring store.mu.RLock).
 // Running notify inside the write lock would create a store.mu → flushMu →
-// store.mu cycle.
+// store.mu cycle. Event log subscribers are called the same way, before
+// notify.
 func (s *Store) UpdateAndNotify(state *SessionState, notify func()) {
-	s.mu.Lock()
-	s.updateLocked(state)
-	s.mu.Unlock()
-	if notify != nil {
-		notify()
-	}
+	s.BatchUpdateAndNotify([]*SessionState{state}, notify)
 }
 
 // BatchUpdateAndNotify atomically updates multiple sessions and then calls
 // notify after releasing the write lock. See UpdateAndNotify for rationale.
 func (s *Store) BatchUpdateAndNotify(states []*SessionState, notify func()) {
 	s.mu.Lock()
+	batch := make([]Mutation, 0, len(states))
 	for _, state := range states {
-		s.updateLocked(state)
+		batch = append(batch, s.updateLocked(state))
 	}
+	subscribers := s.commitLocked(batch)
 	s.mu.Unlock()
-	if notify != nil {
-		notify()
-	}
+	publish(subscribers, batch, notify)
 }
 
-func (s *Store) updateLocked(state *SessionState) {
+func (s *Store) updateLocked(state *SessionState) Mutation {
+	op := MutationCreate
 	if existing, ok := s.sessions[state.ID]; ok {
 		state.Lane = existing.Lane
+		state.Reactions = existing.Reactions
+		op = MutationUpdate
+		if state.IsTerminal() && !existing.IsTerminal() {
+			op = MutationTerminal
+		}
 	} else {
 		state.Lane = s.nextLane
 		s.nextLane++
 	}
-	s.sessions[state.ID] = state.Clone()
+	s.capSubagentsLocked(state)
+	stored := state.Clone()
+	s.sessions[state.ID] = stored
+	return Mutation{Op: op, ID: state.ID, State: stored}
 }
 
 func (s *Store) Remove(id string) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	delete(s.sessions, id)
+	s.BatchRemoveAndNotify([]string{id}, nil)
 }
 
 // BatchRemoveAndNotify atomically removes multiple sessions and then calls
 // notify after releasing the write lock. See UpdateAndNotify for rationale.
 func (s *Store) BatchRemoveAndNotify(ids []string, notify func()) {
 	s.mu.Lock()
+	var batch []Mutation
 	for _, id := range ids {
+		if _, ok := s.sessions[id]; !ok {
+			continue
+		}
 		delete(s.sessions, id)
+		batch = append(batch, Mutation{Op: MutationRemove, ID: id})
 	}
+	subscribers := s.commitLocked(batch)
 	s.mu.Unlock()
+	publish(subscribers, batch, notify)
+}
+
+// publish hands a committed batch to the event log's subscribers and then
+// calls notify. The store's lock must not be held.
+func publish(subscribers []func([]Mutation), batch []Mutation, notify func()) {
+	for _, fn := range subscribers {
+		fn(batch)
+	}
 	if notify != nil {
 		notify()
 	}
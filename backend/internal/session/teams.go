@@ -41,6 +41,8 @@ type TeamInfo struct {
 	AvgBurnRate     float64  `json:"avgBurnRate"`
 	CompletionCount int      `json:"completionCount"`
 	ErrorCount      int      `json:"errorCount"`
+	Worktrees       []string `json:"worktrees,omitempty"`   // distinct worktree labels among members, sorted
+	SubProjects     []string `json:"subProjects,omitempty"` // distinct SubProject values among members, sorted
 }
 
 // teamColor returns a deterministic color for the given team name by hashing it
@@ -56,9 +58,12 @@ func teamID(name string) string {
 	return fmt.Sprintf("%x", h[:4])
 }
 
-// ComputeTeams groups sessions by their working-directory basename and returns
-// one TeamInfo per unique project. Teams are sorted by total tokens descending.
-// Sessions with an empty or unparseable WorkingDir are grouped under "unknown".
+// ComputeTeams groups sessions by project and returns one TeamInfo per unique
+// project. A session's project is its resolved primary repository, so git
+// worktrees are grouped with their parent checkout; sessions without one fall
+// back to their working-directory basename. Teams are sorted by total tokens
+// descending. Sessions with an empty or unparseable WorkingDir are grouped
+// under "unknown".
 func ComputeTeams(sessions []*SessionState) []TeamInfo {
 	type entry struct {
 		name    string
@@ -67,7 +72,10 @@ func ComputeTeams(sessions []*SessionState) []TeamInfo {
 	byName := make(map[string]*entry)
 
 	for _, s := range sessions {
-		name := filepath.Base(s.WorkingDir)
+		name := s.Project
+		if name == "" {
+			name = filepath.Base(s.WorkingDir)
+		}
 		if name == "" || name == "." {
 			name = "unknown"
 		}
@@ -85,9 +93,20 @@ func ComputeTeams(sessions []*SessionState) []TeamInfo {
 		var burnRateSum float64
 		var burnRateCount int
 		memberIDs := make([]string, 0, len(e.members))
+		var worktrees, subProjects []string
+		seenWorktrees := make(map[string]bool)
+		seenSubProjects := make(map[string]bool)
 
 		for _, m := range e.members {
 			memberIDs = append(memberIDs, m.ID)
+			if m.Worktree != "" && !seenWorktrees[m.Worktree] {
+				seenWorktrees[m.Worktree] = true
+				worktrees = append(worktrees, m.Worktree)
+			}
+			if m.SubProject != "" && !seenSubProjects[m.SubProject] {
+				seenSubProjects[m.SubProject] = true
+				subProjects = append(subProjects, m.SubProject)
+			}
 			totalTokens += m.TokensUsed
 			if !m.IsTerminal() {
 				activeCount++
@@ -104,6 +123,9 @@ func ComputeTeams(sessions []*SessionState) []TeamInfo {
 			}
 		}
 
+		sort.Strings(worktrees)
+		sort.Strings(subProjects)
+
 		var avgBurnRate float64
 		if burnRateCount > 0 {
 			avgBurnRate = burnRateSum / float64(burnRateCount)
@@ -120,6 +142,8 @@ func ComputeTeams(sessions []*SessionState) []TeamInfo {
 			AvgBurnRate:     avgBurnRate,
 			CompletionCount: completionCount,
 			ErrorCount:      errorCount,
+			Worktrees:       worktrees,
+			SubProjects:     subProjects,
 		})
 	}
 
@@ -0,0 +1,168 @@
+package share
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/agent-racer/backend/internal/replay"
+)
+
+// RoutePrefix is where share links are served.
+const RoutePrefix = "/share/"
+
+// ServeHTTP serves GET /share/{token}: an HTML page by default, or the
+// snapshot as JSON with ?format=json or an application/json Accept header.
+// The token is the only credential, so no auth header is required.
+func (m *Manager) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	token := strings.TrimPrefix(r.URL.Path, RoutePrefix)
+
+	// The URL is the secret: keep it out of Referer headers and indexes.
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	w.Header().Set("X-Robots-Tag", "noindex")
+
+	sh, err := m.Open(token)
+	switch {
+	case errors.Is(err, ErrExpired):
+		http.Error(w, "this share link has expired", http.StatusGone)
+		return
+	case errors.Is(err, ErrInvalid):
+		http.Error(w, "share link not found", http.StatusNotFound)
+		return
+	case err != nil:
+		slog.Error("open share failed", "error", err)
+		http.Error(w, "failed to open share", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "private, max-age=60")
+	if r.URL.Query().Get("format") == "json" || strings.Contains(r.Header.Get("Accept"), "application/json") {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(sh)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'")
+	if err := pageTemplate.Execute(w, newPageData(sh)); err != nil {
+		slog.Error("render share page failed", "error", err)
+	}
+}
+
+// pageData is the view model for pageTemplate.
+type pageData struct {
+	*Share
+	ContextPct int
+	Duration   string
+	Sparkline  string
+	Changes    []replay.TimelinePoint
+}
+
+// sparkWidth and sparkHeight size the context-utilization sparkline.
+const (
+	sparkWidth  = 600
+	sparkHeight = 60
+)
+
+func newPageData(sh *Share) pageData {
+	s := sh.Session
+	d := pageData{Share: sh, ContextPct: int(s.ContextUtilization*100 + 0.5)}
+
+	end := sh.CreatedAt
+	if s.CompletedAt != nil {
+		end = *s.CompletedAt
+	}
+	if !s.StartedAt.IsZero() && end.After(s.StartedAt) {
+		d.Duration = end.Sub(s.StartedAt).Truncate(time.Second).String()
+	}
+
+	if n := len(sh.Timeline); n > 1 {
+		first, last := sh.Timeline[0].Timestamp, sh.Timeline[n-1].Timestamp
+		span := last.Sub(first).Seconds()
+		var pts []string
+		for i := 0; i < n; i++ {
+			x := 0.0
+			if span > 0 {
+				x = sh.Timeline[i].Timestamp.Sub(first).Seconds() / span * sparkWidth
+			}
+			y := sparkHeight - sh.Timeline[i].ContextUtilization*sparkHeight
+			pts = append(pts, fmt.Sprintf("%.1f,%.1f", x, y))
+		}
+		d.Sparkline = strings.Join(pts, " ")
+	}
+
+	// Activity changes read better than every sample.
+	for i := 0; i < len(sh.Timeline); i++ {
+		if i == 0 || sh.Timeline[i].Activity != sh.Timeline[i-1].Activity {
+			d.Changes = append(d.Changes, sh.Timeline[i])
+		}
+	}
+	return d
+}
+
+var pageTemplate = template.Must(template.New("share").Funcs(template.FuncMap{
+	"ts": func(t time.Time) string { return t.UTC().Format("2006-01-02 15:04:05 UTC") },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>{{.Session.Name}} · Agent Racer</title>
+<style>
+body { background: #0d0f1a; color: #e6e8f0; font: 14px/1.5 system-ui, sans-serif; margin: 0; padding: 24px; }
+main { max-width: 640px; margin: 0 auto; }
+h1 { font-size: 20px; margin: 0 0 4px; }
+.sub { color: #8a90a8; font-size: 12px; margin-bottom: 16px; }
+.bar { background: #22263a; border-radius: 4px; height: 14px; overflow: hidden; }
+.fill { background: linear-gradient(90deg, #2bd576, #ffb020, #ff4d6d); height: 100%; }
+dl { display: grid; grid-template-columns: max-content 1fr; gap: 4px 16px; margin: 16px 0; }
+dt { color: #8a90a8; }
+dd { margin: 0; }
+table { border-collapse: collapse; width: 100%; font-size: 12px; }
+td { border-top: 1px solid #22263a; padding: 4px 8px 4px 0; }
+svg { background: #151828; border-radius: 4px; width: 100%; height: auto; }
+footer { color: #8a90a8; font-size: 11px; margin-top: 24px; }
+</style>
+</head>
+<body>
+<main>
+<h1>{{.Session.Name}}</h1>
+<div class="sub">{{.Session.Source}}{{if .Session.Model}} · {{.Session.Model}}{{end}} · {{.Session.Activity}}</div>
+<div class="bar" title="Context {{.ContextPct}}%"><div class="fill" style="width: {{.ContextPct}}%"></div></div>
+<dl>
+<dt>Context</dt><dd>{{.ContextPct}}% ({{.Session.TokensUsed}} / {{.Session.MaxContextTokens}} tokens{{if .Session.TokenEstimated}}, estimated{{end}})</dd>
+{{- if .Session.Project}}<dt>Project</dt><dd>{{.Session.Project}}{{if .Session.Branch}} ({{.Session.Branch}}){{end}}</dd>{{end}}
+{{- if .Session.SubProject}}<dt>Package</dt><dd>{{.Session.SubProject}}</dd>{{end}}
+{{- if .Session.Topic}}<dt>Topic</dt><dd>{{.Session.Topic}}</dd>{{end}}
+{{- if not .Session.StartedAt.IsZero}}<dt>Started</dt><dd>{{ts .Session.StartedAt}}</dd>{{end}}
+{{- if .Duration}}<dt>Duration</dt><dd>{{.Duration}}</dd>{{end}}
+{{- if .Session.Outcome}}<dt>Outcome</dt><dd>{{.Session.Outcome}}</dd>{{end}}
+<dt>Messages</dt><dd>{{.Session.MessageCount}}</dd>
+<dt>Tool calls</dt><dd>{{.Session.ToolCallCount}}</dd>
+{{- if .Session.CurrentTool}}<dt>Current tool</dt><dd>{{.Session.CurrentTool}}</dd>{{end}}
+</dl>
+{{- if .Sparkline}}
+<svg viewBox="0 0 600 60" preserveAspectRatio="none" role="img" aria-label="Context utilization over time"><polyline fill="none" stroke="#2bd576" stroke-width="2" points="{{.Sparkline}}"/></svg>
+{{- end}}
+{{- if .Changes}}
+<table>
+{{- range .Changes}}
+<tr><td>{{ts .Timestamp}}</td><td>{{.Activity}}</td><td>{{.TokensUsed}} tokens</td><td>{{.CurrentTool}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+<footer>Read-only snapshot taken {{ts .CreatedAt}}. Link expires {{ts .ExpiresAt}}.</footer>
+</main>
+</body>
+</html>
+`))
@@ -0,0 +1,281 @@
+// Package share creates signed, expiring read-only links to a snapshot of a
+// single session, for pasting "look at this agent run" into chat.
+package share
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/agent-racer/backend/internal/replay"
+	"github.com/agent-racer/backend/internal/session"
+)
+
+var (
+	// ErrInvalid is returned by Open for malformed or forged tokens.
+	ErrInvalid = errors.New("invalid share link")
+	// ErrExpired is returned by Open once a link's expiry has passed.
+	ErrExpired = errors.New("share link expired")
+)
+
+// keyFile holds the HMAC key inside the share directory. Keeping it on
+// disk lets links survive server restarts.
+const keyFile = "key"
+
+// sigBytes is how much of the HMAC-SHA256 is kept in a token.
+const sigBytes = 16
+
+// embedScope is mixed into the signature of embed tokens, so that a share
+// token never opens as an embed token or the other way round.
+const embedScope = "embed:"
+
+// sharePrivacy is applied to every shared snapshot on top of the user's
+// privacy filter: a link leaves the machine, so local paths, PIDs and tmux
+// targets never go with it.
+var sharePrivacy = &session.PrivacyFilter{
+	MaskWorkingDirs: true,
+	MaskPIDs:        true,
+	MaskTmuxTargets: true,
+}
+
+// Share is a frozen, read-only snapshot of one session.
+type Share struct {
+	ID        string                 `json:"id"`
+	CreatedAt time.Time              `json:"createdAt"`
+	ExpiresAt time.Time              `json:"expiresAt"`
+	Session   *session.SessionState  `json:"session"`
+	Timeline  []replay.TimelinePoint `json:"timeline,omitempty"`
+}
+
+// Manager creates share links and resolves them back to snapshots. Shares
+// are stored as JSON files in a directory and deleted once expired.
+type Manager struct {
+	dir string
+	key []byte
+	now func() time.Time
+
+	mu sync.Mutex // serializes file writes and pruning
+}
+
+// NewManager returns a manager storing shares in dir, creating the
+// directory and signing key on first use.
+func NewManager(dir string) (*Manager, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("share: create dir %s: %w", dir, err)
+	}
+	key, err := loadOrCreateKey(filepath.Join(dir, keyFile))
+	if err != nil {
+		return nil, err
+	}
+	m := &Manager{dir: dir, key: key, now: time.Now}
+	m.prune()
+	return m, nil
+}
+
+func loadOrCreateKey(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		key, decErr := hex.DecodeString(strings.TrimSpace(string(data)))
+		if decErr == nil && len(key) >= 32 {
+			return key, nil
+		}
+		slog.Warn("share key unreadable, generating a new one", "path", path)
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("share: read key: %w", err)
+	}
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("share: generate key: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(hex.EncodeToString(key)+"\n"), 0o600); err != nil {
+		return nil, fmt.Errorf("share: write key: %w", err)
+	}
+	return key, nil
+}
+
+// Create stores a sanitized snapshot of state (and timeline, if any) and
+// returns it with the token that opens it until ttl has passed.
+func (m *Manager) Create(state *session.SessionState, timeline []replay.TimelinePoint, ttl time.Duration) (*Share, string, error) {
+	if ttl <= 0 {
+		return nil, "", fmt.Errorf("share: ttl must be positive, got %s", ttl)
+	}
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return nil, "", fmt.Errorf("share: generate id: %w", err)
+	}
+	now := m.now().UTC()
+	sh := &Share{
+		ID:        hex.EncodeToString(id),
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl).Truncate(time.Second),
+		Session:   Sanitize(state),
+		Timeline:  timeline,
+	}
+	data, err := json.Marshal(sh)
+	if err != nil {
+		return nil, "", err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pruneLocked()
+	if err := os.WriteFile(m.path(sh.ID), data, 0o600); err != nil {
+		return nil, "", fmt.Errorf("share: write %s: %w", sh.ID, err)
+	}
+	return sh, m.token(sh.ID, sh.ExpiresAt), nil
+}
+
+// Open verifies token and returns the share it points to.
+func (m *Manager) Open(token string) (*Share, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalid
+	}
+	id := parts[0]
+	if _, err := hex.DecodeString(id); err != nil || id == "" {
+		return nil, ErrInvalid
+	}
+	exp, err := strconv.ParseInt(parts[1], 36, 64)
+	if err != nil {
+		return nil, ErrInvalid
+	}
+	expiresAt := time.Unix(exp, 0).UTC()
+	if !hmac.Equal([]byte(token), []byte(m.token(id, expiresAt))) {
+		return nil, ErrInvalid
+	}
+	if !m.now().Before(expiresAt) {
+		return nil, ErrExpired
+	}
+
+	data, err := os.ReadFile(m.path(id))
+	if err != nil {
+		if os.IsNotExist(err) {
+			// Deleted by hand or pruned; treat like a revoked link.
+			return nil, ErrExpired
+		}
+		return nil, err
+	}
+	var sh Share
+	if err := json.Unmarshal(data, &sh); err != nil {
+		return nil, fmt.Errorf("share: decode %s: %w", id, err)
+	}
+	return &sh, nil
+}
+
+// EmbedToken returns a token that lets the /embed/{id} widget read the
+// live state of one session, and nothing else, until ttl has passed. It
+// stores nothing: the session ID and expiry are in the token itself.
+func (m *Manager) EmbedToken(sessionID string, ttl time.Duration) (string, time.Time, error) {
+	if ttl <= 0 {
+		return "", time.Time{}, fmt.Errorf("share: ttl must be positive, got %s", ttl)
+	}
+	if sessionID == "" {
+		return "", time.Time{}, errors.New("share: empty session id")
+	}
+	expiresAt := m.now().UTC().Add(ttl).Truncate(time.Second)
+	id := base64.RawURLEncoding.EncodeToString([]byte(sessionID))
+	return m.sign(embedScope, id, expiresAt), expiresAt, nil
+}
+
+// OpenEmbed verifies an embed token and returns the ID of the session it
+// grants read access to.
+func (m *Manager) OpenEmbed(token string) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", ErrInvalid
+	}
+	sessionID, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil || len(sessionID) == 0 {
+		return "", ErrInvalid
+	}
+	exp, err := strconv.ParseInt(parts[1], 36, 64)
+	if err != nil {
+		return "", ErrInvalid
+	}
+	expiresAt := time.Unix(exp, 0).UTC()
+	if !hmac.Equal([]byte(token), []byte(m.sign(embedScope, parts[0], expiresAt))) {
+		return "", ErrInvalid
+	}
+	if !m.now().Before(expiresAt) {
+		return "", ErrExpired
+	}
+	return string(sessionID), nil
+}
+
+// Sanitize returns a copy of s with everything that should not leave the
+// machine removed: paths, PIDs, tmux targets, the free-form assistant text
+// and topic, which can quote code or secrets, and the branch and commands
+// run, which can name private projects and tickets.
+func Sanitize(s *session.SessionState) *session.SessionState {
+	out := sharePrivacy.Apply(s)
+	out.LastAssistantText = ""
+	out.Topic = ""
+	out.Branch = ""
+	out.ShellCommands = nil
+	out.LastCommand = ""
+	out.SlashCommands = nil
+	out.LogPath = ""
+	return out
+}
+
+func (m *Manager) token(id string, expiresAt time.Time) string {
+	return m.sign("", id, expiresAt)
+}
+
+// sign returns id and expiresAt with their signature under scope. Share
+// tokens use the empty scope, which keeps links made before embed tokens
+// existed valid.
+func (m *Manager) sign(scope, id string, expiresAt time.Time) string {
+	payload := id + "." + strconv.FormatInt(expiresAt.Unix(), 36)
+	mac := hmac.New(sha256.New, m.key)
+	mac.Write([]byte(scope + payload))
+	return payload + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:sigBytes])
+}
+
+func (m *Manager) path(id string) string {
+	return filepath.Join(m.dir, id+".json")
+}
+
+func (m *Manager) prune() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pruneLocked()
+}
+
+// pruneLocked deletes expired shares. Caller must hold m.mu.
+func (m *Manager) pruneLocked() {
+	entries, err := os.ReadDir(m.dir)
+	if err != nil {
+		return
+	}
+	now := m.now()
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		path := filepath.Join(m.dir, e.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var sh struct {
+			ExpiresAt time.Time `json:"expiresAt"`
+		}
+		if json.Unmarshal(data, &sh) != nil || !now.Before(sh.ExpiresAt) {
+			_ = os.Remove(path)
+		}
+	}
+}
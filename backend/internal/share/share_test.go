@@ -0,0 +1,227 @@
+package share
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agent-racer/backend/internal/replay"
+	"github.com/agent-racer/backend/internal/session"
+)
+
+func testState() *session.SessionState {
+	return &session.SessionState{
+		ID:                 "sess-1",
+		Name:               "migrate-db",
+		Source:             "claude",
+		Activity:           session.ToolUse,
+		TokensUsed:         50000,
+		MaxContextTokens:   200000,
+		ContextUtilization: 0.25,
+		WorkingDir:         "/home/alice/work/secret-project",
+		PID:                4242,
+		TmuxTarget:         "main:1.0",
+		LastAssistantText:  "here is the API key sk-...",
+		Topic:              "rotate the acme prod db password",
+		Branch:             "acme/JIRA-1234-migrate",
+		ShellCommands:      map[string]int{"psql": 3},
+		LastCommand:        "/acme-deploy",
+		SlashCommands:      map[string]int{"/acme-deploy": 1},
+		StartedAt:          time.Now().Add(-time.Hour),
+	}
+}
+
+func newTestManager(t *testing.T) *Manager {
+	t.Helper()
+	m, err := NewManager(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	return m
+}
+
+func TestCreateOpen_RoundTripSanitized(t *testing.T) {
+	m := newTestManager(t)
+	timeline := []replay.TimelinePoint{{Timestamp: time.Now(), Activity: session.Thinking, TokensUsed: 10}}
+
+	sh, token, err := m.Create(testState(), timeline, time.Hour)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	got, err := m.Open(token)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if got.ID != sh.ID || got.Session.Name != "migrate-db" || len(got.Timeline) != 1 {
+		t.Errorf("opened share = %+v", got)
+	}
+	s := got.Session
+	if s.WorkingDir != "secret-project" || s.PID != 0 || s.TmuxTarget != "" || s.LastAssistantText != "" {
+		t.Errorf("snapshot not sanitized: dir=%q pid=%d tmux=%q text=%q", s.WorkingDir, s.PID, s.TmuxTarget, s.LastAssistantText)
+	}
+	if s.Topic != "" || s.Branch != "" || s.ShellCommands != nil || s.LastCommand != "" || s.SlashCommands != nil {
+		t.Errorf("snapshot not sanitized: topic=%q branch=%q shell=%v command=%q slash=%v",
+			s.Topic, s.Branch, s.ShellCommands, s.LastCommand, s.SlashCommands)
+	}
+}
+
+func TestOpen_RejectsTamperedToken(t *testing.T) {
+	m := newTestManager(t)
+	_, token, err := m.Create(testState(), nil, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parts := strings.Split(token, ".")
+	// Extending the expiry invalidates the signature.
+	forged := parts[0] + ".zzzzzz." + parts[2]
+	for _, bad := range []string{"", "nope", forged, token + "x"} {
+		if _, err := m.Open(bad); !errors.Is(err, ErrInvalid) {
+			t.Errorf("Open(%q) err = %v, want ErrInvalid", bad, err)
+		}
+	}
+
+	// A different key cannot open the link.
+	other := newTestManager(t)
+	if _, err := other.Open(token); !errors.Is(err, ErrInvalid) {
+		t.Errorf("Open with other key err = %v, want ErrInvalid", err)
+	}
+}
+
+func TestOpen_Expired(t *testing.T) {
+	m := newTestManager(t)
+	_, token, err := m.Create(testState(), nil, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	if _, err := m.Open(token); !errors.Is(err, ErrExpired) {
+		t.Errorf("Open after expiry err = %v, want ErrExpired", err)
+	}
+}
+
+func TestEmbedToken_OpensOnlyAsEmbedToken(t *testing.T) {
+	m := newTestManager(t)
+	token, expiresAt, err := m.EmbedToken("sess/1.a", time.Hour)
+	if err != nil {
+		t.Fatalf("EmbedToken: %v", err)
+	}
+	if time.Until(expiresAt) > time.Hour || time.Until(expiresAt) < 59*time.Minute {
+		t.Errorf("expiresAt = %s, want ~1h from now", expiresAt)
+	}
+	if id, err := m.OpenEmbed(token); err != nil || id != "sess/1.a" {
+		t.Errorf("OpenEmbed = %q, %v; want sess/1.a", id, err)
+	}
+
+	// Neither kind of token opens as the other.
+	if _, err := m.Open(token); !errors.Is(err, ErrInvalid) {
+		t.Errorf("Open(embed token) err = %v, want ErrInvalid", err)
+	}
+	_, shareToken, err := m.Create(testState(), nil, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.OpenEmbed(shareToken); !errors.Is(err, ErrInvalid) {
+		t.Errorf("OpenEmbed(share token) err = %v, want ErrInvalid", err)
+	}
+
+	// Pointing a token at another session breaks the signature.
+	parts := strings.Split(token, ".")
+	other := base64URL("sess-2") + "." + parts[1] + "." + parts[2]
+	if _, err := m.OpenEmbed(other); !errors.Is(err, ErrInvalid) {
+		t.Errorf("OpenEmbed(retargeted) err = %v, want ErrInvalid", err)
+	}
+
+	m.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	if _, err := m.OpenEmbed(token); !errors.Is(err, ErrExpired) {
+		t.Errorf("OpenEmbed after expiry err = %v, want ErrExpired", err)
+	}
+}
+
+func base64URL(s string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(s))
+}
+
+func TestNewManager_KeepsKeyAndPrunesExpired(t *testing.T) {
+	dir := t.TempDir()
+	m, err := NewManager(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, live, err := m.Create(testState(), nil, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expired, _, err := m.Create(testState(), nil, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, expired.ID+".json"), []byte(`{"expiresAt":"2000-01-01T00:00:00Z"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := NewManager(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reopened.Open(live); err != nil {
+		t.Errorf("link did not survive restart: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, expired.ID+".json")); !os.IsNotExist(err) {
+		t.Errorf("expired share not pruned: %v", err)
+	}
+}
+
+func TestServeHTTP(t *testing.T) {
+	m := newTestManager(t)
+	_, token, err := m.Create(testState(), nil, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, RoutePrefix+token, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET page = %d, want 200", rec.Code)
+	}
+	body := rec.Body.String()
+	if !strings.Contains(body, "migrate-db") || !strings.Contains(body, "25%") {
+		t.Errorf("page missing session details:\n%s", body)
+	}
+	if strings.Contains(body, "/home/alice") {
+		t.Error("page leaks the working directory")
+	}
+	if rec.Header().Get("Referrer-Policy") != "no-referrer" {
+		t.Error("expected Referrer-Policy: no-referrer")
+	}
+
+	rec = httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, RoutePrefix+token+"?format=json", nil))
+	var sh Share
+	if err := json.NewDecoder(rec.Body).Decode(&sh); err != nil {
+		t.Fatalf("decode JSON: %v", err)
+	}
+	if sh.Session == nil || sh.Session.ID != "sess-1" {
+		t.Errorf("JSON share = %+v", sh)
+	}
+
+	rec = httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, RoutePrefix+"bogus", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("GET bogus = %d, want 404", rec.Code)
+	}
+
+	m.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	rec = httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, RoutePrefix+token, nil))
+	if rec.Code != http.StatusGone {
+		t.Errorf("GET expired = %d, want 410", rec.Code)
+	}
+}
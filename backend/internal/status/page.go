@@ -0,0 +1,127 @@
+package status
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Route is where the status page is served.
+const Route = "/status"
+
+// refreshSeconds is how often the page reloads itself.
+const refreshSeconds = 60
+
+// Serve writes the status page for sessions running at least minDuration:
+// HTML by default, or JSON with ?format=json or an application/json Accept
+// header. It needs no auth; callers decide whether the page is enabled.
+func (b *Board) Serve(w http.ResponseWriter, r *http.Request, minDuration time.Duration) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	rows := b.Rows(minDuration)
+
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Robots-Tag", "noindex")
+	if r.URL.Query().Get("format") == "json" || strings.Contains(r.Header.Get("Accept"), "application/json") {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(struct {
+			GeneratedAt time.Time `json:"generatedAt"`
+			SlotMinutes int       `json:"slotMinutes"`
+			Sessions    []Row     `json:"sessions"`
+		}{b.now().UTC(), int(SlotWidth / time.Minute), rows})
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'")
+	data := pageData{Rows: rows, Now: b.now(), MinDuration: minDuration, Refresh: refreshSeconds}
+	if err := pageTemplate.Execute(w, data); err != nil {
+		slog.Error("render status page failed", "error", err)
+	}
+}
+
+type pageData struct {
+	Rows        []Row
+	Now         time.Time
+	MinDuration time.Duration
+	Refresh     int
+}
+
+// duration formats d to the minute, e.g. "7h 5m".
+func duration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	if d < time.Minute {
+		return "<1m"
+	}
+	h, m := int(d.Hours()), int(d.Minutes())%60
+	if h == 0 {
+		return fmt.Sprintf("%dm", m)
+	}
+	return fmt.Sprintf("%dh %dm", h, m)
+}
+
+var pageTemplate = template.Must(template.New("status").Funcs(template.FuncMap{
+	"pct": func(f float64) int { return int(f*100 + 0.5) },
+	"ago": func(now, t time.Time) string { return duration(now.Sub(t)) },
+	"uptime": func(now time.Time, r Row) string {
+		end := now
+		if r.CompletedAt != nil {
+			end = *r.CompletedAt
+		}
+		return duration(end.Sub(r.StartedAt))
+	},
+	"stale":   func(now time.Time, r Row) bool { return now.Sub(r.LastSeenAt) > 2*SampleInterval },
+	"ts":      func(t time.Time) string { return t.UTC().Format("2006-01-02 15:04 UTC") },
+	"hours":   func() int { return int(Window / time.Hour) },
+	"levelOf": levelOf,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<meta http-equiv="refresh" content="{{.Refresh}}">
+<title>Agent Racer status</title>
+<style>
+body { background: #0d0f1a; color: #e6e8f0; font: 14px/1.5 system-ui, sans-serif; margin: 0; padding: 24px; }
+main { max-width: 760px; margin: 0 auto; }
+h1 { font-size: 20px; margin: 0 0 4px; }
+.sub { color: #8a90a8; font-size: 12px; margin-bottom: 20px; }
+section { border-top: 1px solid #22263a; padding: 12px 0; }
+.head { display: flex; gap: 8px; align-items: baseline; }
+.name { font-weight: 600; }
+.meta { color: #8a90a8; font-size: 12px; }
+.state { margin-left: auto; font-size: 12px; text-transform: uppercase; letter-spacing: 0.04em; }
+.state.active { color: #2bd576; } .state.idle { color: #ffb020; } .state.done { color: #5b8def; } .state.down { color: #ff4d6d; }
+.bars { display: flex; gap: 2px; height: 24px; margin: 6px 0 2px; }
+.bars span { flex: 1; border-radius: 2px; background: #22263a; }
+.bars .active { background: #2bd576; } .bars .idle { background: #ffb020; } .bars .done { background: #5b8def; } .bars .down { background: #ff4d6d; }
+.axis { display: flex; justify-content: space-between; color: #8a90a8; font-size: 11px; }
+.empty { color: #8a90a8; }
+footer { color: #8a90a8; font-size: 11px; margin-top: 24px; }
+</style>
+</head>
+<body>
+<main>
+<h1>Agent Racer status</h1>
+<div class="sub">Sessions running {{if .MinDuration}}at least {{.MinDuration}}{{else}}now{{end}}, over the last {{hours}} hours. Updated {{ts .Now}}.</div>
+{{- range .Rows}}
+<section>
+<div class="head"><span class="name">{{.Name}}</span><span class="meta">{{.Source}} · up {{uptime $.Now .}} · context {{pct .ContextUtilization}}%</span><span class="state {{levelOf .Activity}}">{{.Activity}}{{if stale $.Now .}} · last seen {{ago $.Now .LastSeenAt}} ago{{end}}</span></div>
+<div class="bars" role="img" aria-label="Activity over the last {{hours}} hours">{{range .Bars}}<span class="{{.}}"></span>{{end}}</div>
+<div class="axis"><span>{{hours}}h ago</span><span>now</span></div>
+</section>
+{{- else}}
+<p class="empty">No long-running sessions right now.</p>
+{{- end}}
+<footer>Green: working · amber: idle or waiting · blue: finished · red: errored or lost · dark: not running. Each bar is 15 minutes.</footer>
+</main>
+</body>
+</html>
+`))
@@ -0,0 +1,199 @@
+// Package status keeps a short activity history of each session and renders
+// it as a public, uptime-style status page at /status.
+package status
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/agent-racer/backend/internal/session"
+)
+
+const (
+	// Slots is how many bars each session row shows.
+	Slots = 48
+	// SlotWidth is the time covered by one bar; Slots*SlotWidth is the
+	// window the page looks back over.
+	SlotWidth = 15 * time.Minute
+	// SampleInterval is how often the board samples the session store.
+	SampleInterval = 30 * time.Second
+)
+
+// Window is how far back the page looks.
+const Window = Slots * SlotWidth
+
+// Level summarizes what a session did during one slot. When a slot saw
+// several states the most notable wins: down > done > active > idle.
+type Level string
+
+const (
+	LevelNone   Level = "none"   // no sample (not running yet, or server down)
+	LevelIdle   Level = "idle"   // starting, waiting or idle
+	LevelActive Level = "active" // thinking or using tools
+	LevelDone   Level = "done"   // completed
+	LevelDown   Level = "down"   // errored or lost
+)
+
+var levelRank = map[Level]int{LevelNone: 0, LevelIdle: 1, LevelActive: 2, LevelDone: 3, LevelDown: 4}
+
+func levelOf(a session.Activity) Level {
+	switch a {
+	case session.Thinking, session.ToolUse:
+		return LevelActive
+	case session.Complete:
+		return LevelDone
+	case session.Errored, session.Lost:
+		return LevelDown
+	default:
+		return LevelIdle
+	}
+}
+
+// Row is one session on the status page.
+type Row struct {
+	ID                 string           `json:"id"`
+	Name               string           `json:"name"`
+	Source             string           `json:"source"`
+	Activity           session.Activity `json:"activity"`
+	StartedAt          time.Time        `json:"startedAt"`
+	CompletedAt        *time.Time       `json:"completedAt,omitempty"`
+	LastSeenAt         time.Time        `json:"lastSeenAt"`
+	ContextUtilization float64          `json:"contextUtilization"`
+	// Bars holds one Level per slot, oldest first; the last covers now.
+	Bars []Level `json:"bars"`
+}
+
+type entry struct {
+	state    *session.SessionState
+	lastSeen time.Time
+	slots    map[int64]Level
+}
+
+// Board samples sessions periodically and remembers, per session, the most
+// notable state seen in each slot of the last Window. Sessions stay on the
+// board for a Window after they were last seen, so a run that finished
+// overnight is still listed in the morning.
+type Board struct {
+	sessions func() []*session.SessionState
+	now      func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*entry
+}
+
+// NewBoard returns a board fed by sessions, which should already have the
+// privacy filter applied: the page is served without authentication.
+func NewBoard(sessions func() []*session.SessionState) *Board {
+	return &Board{
+		sessions: sessions,
+		now:      time.Now,
+		entries:  make(map[string]*entry),
+	}
+}
+
+// Run samples every SampleInterval until ctx is cancelled.
+func (b *Board) Run(ctx context.Context) {
+	b.Sample()
+	ticker := time.NewTicker(SampleInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			b.Sample()
+		}
+	}
+}
+
+// Sample records the current state of every session and forgets sessions
+// not seen for a whole Window.
+func (b *Board) Sample() {
+	states := b.sessions()
+	now := b.now()
+	slot := now.UnixNano() / int64(SlotWidth)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, s := range states {
+		e, ok := b.entries[s.ID]
+		if !ok {
+			e = &entry{slots: make(map[int64]Level)}
+			b.entries[s.ID] = e
+		}
+		e.state = s
+		e.lastSeen = now
+		if lvl := levelOf(s.Activity); levelRank[lvl] > levelRank[e.slots[slot]] {
+			e.slots[slot] = lvl
+		}
+		for k := range e.slots {
+			if k <= slot-Slots {
+				delete(e.slots, k)
+			}
+		}
+	}
+	for id, e := range b.entries {
+		if now.Sub(e.lastSeen) > Window {
+			delete(b.entries, id)
+		}
+	}
+}
+
+// Rows returns the sessions that have been running for at least
+// minDuration: unfinished ones first, then by start time.
+func (b *Board) Rows(minDuration time.Duration) []Row {
+	now := b.now()
+	current := now.UnixNano() / int64(SlotWidth)
+
+	b.mu.Lock()
+	rows := make([]Row, 0, len(b.entries))
+	for _, e := range b.entries {
+		s := e.state
+		end := e.lastSeen
+		if s.CompletedAt != nil {
+			end = *s.CompletedAt
+		}
+		if s.StartedAt.IsZero() || end.Sub(s.StartedAt) < minDuration {
+			continue
+		}
+		row := Row{
+			ID:                 s.ID,
+			Name:               s.Name,
+			Source:             s.Source,
+			Activity:           s.Activity,
+			StartedAt:          s.StartedAt,
+			CompletedAt:        s.CompletedAt,
+			LastSeenAt:         e.lastSeen,
+			ContextUtilization: s.ContextUtilization,
+			Bars:               make([]Level, Slots),
+		}
+		for i := 0; i < Slots; i++ {
+			lvl, ok := e.slots[current-Slots+1+int64(i)]
+			if !ok {
+				lvl = LevelNone
+			}
+			row.Bars[i] = lvl
+		}
+		rows = append(rows, row)
+	}
+	b.mu.Unlock()
+
+	sort.Slice(rows, func(i, j int) bool {
+		ti, tj := rows[i].finished(), rows[j].finished()
+		if ti != tj {
+			return !ti
+		}
+		if !rows[i].StartedAt.Equal(rows[j].StartedAt) {
+			return rows[i].StartedAt.Before(rows[j].StartedAt)
+		}
+		return rows[i].ID < rows[j].ID
+	})
+	return rows
+}
+
+func (r Row) finished() bool {
+	lvl := levelOf(r.Activity)
+	return lvl == LevelDone || lvl == LevelDown
+}
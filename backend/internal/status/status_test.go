@@ -0,0 +1,134 @@
+package status
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agent-racer/backend/internal/session"
+)
+
+// fakeClock lets tests move the board through slots.
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time { return c.t }
+
+func newTestBoard(states *[]*session.SessionState) (*Board, *fakeClock) {
+	clock := &fakeClock{t: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)}
+	b := NewBoard(func() []*session.SessionState { return *states })
+	b.now = clock.now
+	return b, clock
+}
+
+func TestSample_KeepsMostNotableLevelPerSlot(t *testing.T) {
+	start := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	s := &session.SessionState{ID: "s1", Name: "refactor", StartedAt: start, Activity: session.Thinking}
+	states := []*session.SessionState{s}
+	b, clock := newTestBoard(&states)
+
+	b.Sample()
+	s2 := *s
+	s2.Activity = session.Waiting
+	states = []*session.SessionState{&s2}
+	clock.t = clock.t.Add(time.Minute)
+	b.Sample() // same slot: active outranks idle
+
+	clock.t = clock.t.Add(SlotWidth)
+	b.Sample() // next slot: idle
+
+	rows := b.Rows(time.Hour)
+	if len(rows) != 1 {
+		t.Fatalf("got %d rows, want 1", len(rows))
+	}
+	bars := rows[0].Bars
+	if len(bars) != Slots {
+		t.Fatalf("got %d bars, want %d", len(bars), Slots)
+	}
+	if bars[Slots-2] != LevelActive || bars[Slots-1] != LevelIdle || bars[0] != LevelNone {
+		t.Errorf("bars = %v", bars)
+	}
+	if rows[0].Activity != session.Waiting {
+		t.Errorf("activity = %v, want latest state", rows[0].Activity)
+	}
+}
+
+func TestRows_FiltersShortAndForgetsOld(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	done := now.Add(-time.Minute)
+	states := []*session.SessionState{
+		{ID: "short", StartedAt: now.Add(-5 * time.Minute), Activity: session.Thinking},
+		{ID: "finished", StartedAt: now.Add(-3 * time.Hour), CompletedAt: &done, Activity: session.Complete},
+		{ID: "long", StartedAt: now.Add(-2 * time.Hour), Activity: session.ToolUse},
+	}
+	b, clock := newTestBoard(&states)
+	b.Sample()
+
+	rows := b.Rows(30 * time.Minute)
+	var ids []string
+	for _, r := range rows {
+		ids = append(ids, r.ID)
+	}
+	if got := strings.Join(ids, ","); got != "long,finished" {
+		t.Errorf("rows = %s, want long,finished (running first)", got)
+	}
+
+	// Sessions gone from the store stay listed for a Window.
+	states = nil
+	clock.t = clock.t.Add(Window / 2)
+	b.Sample()
+	if n := len(b.Rows(0)); n != 3 {
+		t.Errorf("after half a window: %d rows, want 3", n)
+	}
+	clock.t = clock.t.Add(Window)
+	b.Sample()
+	if n := len(b.Rows(0)); n != 0 {
+		t.Errorf("after a window: %d rows, want 0", n)
+	}
+}
+
+func TestServe(t *testing.T) {
+	states := []*session.SessionState{{
+		ID:                 "s1",
+		Name:               "overnight-<refactor>",
+		Source:             "claude",
+		StartedAt:          time.Date(2026, 3, 1, 1, 0, 0, 0, time.UTC),
+		Activity:           session.ToolUse,
+		ContextUtilization: 0.42,
+	}}
+	b, _ := newTestBoard(&states)
+	b.Sample()
+
+	rec := httptest.NewRecorder()
+	b.Serve(rec, httptest.NewRequest(http.MethodGet, Route, nil), 10*time.Minute)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{"overnight-&lt;refactor&gt;", "up 11h 0m", "context 42%", `<span class="active">`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("page missing %q:\n%s", want, body)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	b.Serve(rec, httptest.NewRequest(http.MethodGet, Route+"?format=json", nil), 10*time.Minute)
+	var got struct {
+		SlotMinutes int   `json:"slotMinutes"`
+		Sessions    []Row `json:"sessions"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if got.SlotMinutes != 15 || len(got.Sessions) != 1 || got.Sessions[0].Bars[Slots-1] != LevelActive {
+		t.Errorf("JSON = %+v", got)
+	}
+
+	rec = httptest.NewRecorder()
+	b.Serve(rec, httptest.NewRequest(http.MethodPost, Route, nil), 0)
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST = %d, want 405", rec.Code)
+	}
+}
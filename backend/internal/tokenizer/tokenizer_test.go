@@ -0,0 +1,81 @@
+package tokenizer
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestApprox(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"hello", 1},
+		{"hello world", 2},
+		{"internationalization", 5},
+		{"1234567", 3},
+		{"func main() {\n\treturn\n}", 9},
+		{"日本語", 3},
+	}
+	for _, tt := range tests {
+		if got := Approx(tt.text); got != tt.want {
+			t.Errorf("Approx(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestApproxTracksProseLength(t *testing.T) {
+	// cl100k encodes this sentence as 21 tokens.
+	text := "The quick brown fox jumps over the lazy dog, then naps under the old oak tree until sunset."
+	if got := Approx(text); got < 18 || got > 22 {
+		t.Errorf("Approx = %d, want about 21", got)
+	}
+}
+
+func TestBPEMatchesVocabulary(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want int
+	}{
+		{"cl100k", "", 0},
+		{"cl100k", "hello world", 2},
+		{"cl100k", "The quick brown fox jumps over the lazy dog, then naps under the old oak tree until sunset.", 21},
+		{"cl100k", "<|endoftext|>", 7}, // special tokens count as text
+		{"o200k", "hello world", 2},
+	}
+	for _, tt := range tests {
+		tok, ok := Lookup(tt.name)
+		if !ok {
+			t.Fatalf("%s not registered", tt.name)
+		}
+		if got := tok.Count(tt.text); got != tt.want {
+			t.Errorf("%s.Count(%q) = %d, want %d", tt.name, tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	if _, ok := Lookup(Default); !ok {
+		t.Fatalf("default tokenizer %q not registered", Default)
+	}
+	if _, ok := Lookup("nope"); ok {
+		t.Error("Lookup(nope) should fail")
+	}
+
+	Register("fixed", Func(func(string) int { return 7 }))
+	tok, ok := Lookup("fixed")
+	if !ok || tok.Count("anything") != 7 {
+		t.Errorf("registered tokenizer not returned")
+	}
+	if names := Names(); !slices.Contains(names, "fixed") || !slices.IsSorted(names) {
+		t.Errorf("Names() = %v", names)
+	}
+}
+
+func TestBytes(t *testing.T) {
+	if got := Bytes("abcde"); got != 2 {
+		t.Errorf("Bytes = %d, want 2", got)
+	}
+}
@@ -0,0 +1,254 @@
+// Package update checks GitHub releases for a newer version of the server.
+package update
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Interval is how often the latest release is looked up.
+const Interval = 24 * time.Hour
+
+// defaultAPIBase is the GitHub REST API root.
+const defaultAPIBase = "https://api.github.com"
+
+// Release describes a published release newer than the running build.
+type Release struct {
+	Version     string    `json:"version"`
+	URL         string    `json:"url"`
+	PublishedAt time.Time `json:"publishedAt,omitempty"`
+}
+
+// state is persisted between restarts so the check runs at most once per
+// Interval regardless of how often the server is restarted.
+type state struct {
+	CheckedAt time.Time `json:"checkedAt"`
+	Latest    *Release  `json:"latest,omitempty"`
+}
+
+// Checker periodically looks up the latest GitHub release and reports it
+// when it is newer than the running version.
+type Checker struct {
+	current   string
+	repo      string
+	statePath string
+	apiBase   string
+	client    *http.Client
+	now       func() time.Time
+	onUpdate  func(Release)
+
+	mu     sync.Mutex
+	latest *Release
+}
+
+// NewChecker returns a checker for the running version against the releases
+// of repo ("owner/name"). The last result is kept at statePath.
+func NewChecker(current, repo, statePath string) *Checker {
+	return &Checker{
+		current:   current,
+		repo:      repo,
+		statePath: statePath,
+		apiBase:   defaultAPIBase,
+		client:    &http.Client{Timeout: 10 * time.Second},
+		now:       time.Now,
+	}
+}
+
+// Enabled reports whether the running version can be compared with
+// releases. Development builds ("dev", bare commit hashes) cannot.
+func Enabled(current string) bool {
+	_, ok := parseVersion(current)
+	return ok
+}
+
+// OnUpdate registers a function called when a newer release is first found.
+// Must be called before Run.
+func (c *Checker) OnUpdate(fn func(Release)) {
+	c.onUpdate = fn
+}
+
+// Latest returns the newest known release if it is newer than the running
+// version, or nil. Safe for concurrent use.
+func (c *Checker) Latest() *Release {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.latest == nil {
+		return nil
+	}
+	r := *c.latest
+	return &r
+}
+
+// Run checks for a new release whenever Interval has passed since the last
+// check, until ctx is cancelled. A release found by an earlier run is
+// reported immediately.
+func (c *Checker) Run(ctx context.Context) {
+	st := c.loadState()
+	if st.Latest != nil {
+		c.record(*st.Latest)
+	}
+
+	wait := time.Duration(0)
+	if !st.CheckedAt.IsZero() {
+		wait = Interval - c.now().Sub(st.CheckedAt)
+	}
+	for {
+		if wait > 0 {
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+		}
+		if err := c.Check(ctx); err != nil && ctx.Err() == nil {
+			slog.Debug("update check failed", "error", err)
+		}
+		wait = Interval
+	}
+}
+
+// Check looks up the latest release once and records the time of the check.
+func (c *Checker) Check(ctx context.Context) error {
+	rel, err := c.fetchLatest(ctx)
+	if err != nil {
+		return err
+	}
+	st := state{CheckedAt: c.now().UTC()}
+	if newer(rel.Version, c.current) {
+		st.Latest = &rel
+		c.record(rel)
+	}
+	return c.saveState(st)
+}
+
+// record stores rel as the latest release and notifies the callback the
+// first time a given version is seen.
+func (c *Checker) record(rel Release) {
+	if !newer(rel.Version, c.current) {
+		return
+	}
+	c.mu.Lock()
+	seen := c.latest != nil && c.latest.Version == rel.Version
+	c.latest = &rel
+	c.mu.Unlock()
+	if !seen {
+		slog.Info("update available", "current", c.current, "latest", rel.Version, "url", rel.URL)
+		if c.onUpdate != nil {
+			c.onUpdate(rel)
+		}
+	}
+}
+
+func (c *Checker) fetchLatest(ctx context.Context) (Release, error) {
+	url := fmt.Sprintf("%s/repos/%s/releases/latest", strings.TrimSuffix(c.apiBase, "/"), c.repo)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return Release{}, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("User-Agent", "agent-racer/"+c.current)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return Release{}, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return Release{}, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+
+	var body struct {
+		TagName     string    `json:"tag_name"`
+		HTMLURL     string    `json:"html_url"`
+		PublishedAt time.Time `json:"published_at"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil {
+		return Release{}, fmt.Errorf("decode release: %w", err)
+	}
+	if body.TagName == "" {
+		return Release{}, errors.New("release has no tag")
+	}
+	return Release{Version: body.TagName, URL: body.HTMLURL, PublishedAt: body.PublishedAt}, nil
+}
+
+func (c *Checker) loadState() state {
+	var st state
+	data, err := os.ReadFile(c.statePath)
+	if err != nil {
+		return st
+	}
+	if err := json.Unmarshal(data, &st); err != nil {
+		slog.Debug("ignoring unreadable update state", "path", c.statePath, "error", err)
+		return state{}
+	}
+	return st
+}
+
+func (c *Checker) saveState(st state) error {
+	data, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.statePath), 0o755); err != nil {
+		return err
+	}
+	tmp := c.statePath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.statePath)
+}
+
+// newer reports whether release version latest is greater than current.
+// Versions that do not parse are never newer.
+func newer(latest, current string) bool {
+	l, ok := parseVersion(latest)
+	if !ok {
+		return false
+	}
+	c, ok := parseVersion(current)
+	if !ok {
+		return false
+	}
+	for i := 0; i < len(l); i++ {
+		if l[i] != c[i] {
+			return l[i] > c[i]
+		}
+	}
+	return false
+}
+
+// parseVersion extracts MAJOR.MINOR.PATCH from tags like "v1.2.3",
+// "1.2" or git-describe output such as "v1.2.3-4-gabc1234-dirty".
+func parseVersion(v string) ([3]int, bool) {
+	var out [3]int
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+	parts := strings.Split(v, ".")
+	if len(parts) < 2 || len(parts) > 3 {
+		return out, false
+	}
+	for i := 0; i < len(parts); i++ {
+		n, err := strconv.Atoi(parts[i])
+		if err != nil || n < 0 {
+			return out, false
+		}
+		out[i] = n
+	}
+	return out, true
+}
@@ -0,0 +1,188 @@
+package update
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNewer(t *testing.T) {
+	tests := []struct {
+		latest, current string
+		want            bool
+	}{
+		{"v1.2.0", "v1.1.9", true},
+		{"v1.10.0", "v1.9.0", true},
+		{"v2.0", "v1.9.9", true},
+		{"v1.2.3", "v1.2.3", false},
+		{"v1.2.3", "v1.2.3-4-gabc1234-dirty", false},
+		{"v1.2.4", "v1.2.3-4-gabc1234-dirty", true},
+		{"v1.2.2", "v1.2.3", false},
+		{"v1.3.0", "dev", false},
+		{"v1.3.0", "abc1234", false},
+		{"nightly", "v1.0.0", false},
+	}
+	for _, tt := range tests {
+		if got := newer(tt.latest, tt.current); got != tt.want {
+			t.Errorf("newer(%q, %q) = %v, want %v", tt.latest, tt.current, got, tt.want)
+		}
+	}
+}
+
+func TestEnabled(t *testing.T) {
+	if Enabled("dev") || Enabled("abc1234") || Enabled("") {
+		t.Error("development versions should not enable update checks")
+	}
+	if !Enabled("v0.4.1") || !Enabled("v0.4.1-2-gdeadbee") {
+		t.Error("release versions should enable update checks")
+	}
+}
+
+func releaseServer(t *testing.T, tag string, hits *atomic.Int32) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if r.URL.Path != "/repos/mrf/agent-racer/releases/latest" {
+			http.NotFound(w, r)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"tag_name":     tag,
+			"html_url":     "https://github.com/mrf/agent-racer/releases/tag/" + tag,
+			"published_at": "2026-01-02T03:04:05Z",
+		})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newTestChecker(t *testing.T, current, apiBase string) *Checker {
+	t.Helper()
+	c := NewChecker(current, "mrf/agent-racer", filepath.Join(t.TempDir(), "update-check.json"))
+	c.apiBase = apiBase
+	return c
+}
+
+func TestCheck_ReportsNewerRelease(t *testing.T) {
+	var hits atomic.Int32
+	srv := releaseServer(t, "v1.3.0", &hits)
+	c := newTestChecker(t, "v1.2.0", srv.URL)
+
+	var notified []Release
+	c.OnUpdate(func(r Release) { notified = append(notified, r) })
+
+	if err := c.Check(context.Background()); err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	latest := c.Latest()
+	if latest == nil || latest.Version != "v1.3.0" {
+		t.Fatalf("Latest() = %+v, want v1.3.0", latest)
+	}
+	if latest.URL == "" {
+		t.Error("expected release URL")
+	}
+
+	// A second check of the same release does not notify again.
+	if err := c.Check(context.Background()); err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if len(notified) != 1 {
+		t.Errorf("notified %d times, want 1", len(notified))
+	}
+}
+
+func TestCheck_UpToDate(t *testing.T) {
+	var hits atomic.Int32
+	srv := releaseServer(t, "v1.2.0", &hits)
+	c := newTestChecker(t, "v1.2.0", srv.URL)
+
+	if err := c.Check(context.Background()); err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if latest := c.Latest(); latest != nil {
+		t.Errorf("Latest() = %+v, want nil", latest)
+	}
+}
+
+func TestRun_SkipsRecentCheckAndRestoresResult(t *testing.T) {
+	var hits atomic.Int32
+	srv := releaseServer(t, "v1.3.0", &hits)
+	c := newTestChecker(t, "v1.2.0", srv.URL)
+
+	st, _ := json.Marshal(state{
+		CheckedAt: time.Now().Add(-time.Hour),
+		Latest:    &Release{Version: "v1.3.0", URL: "https://example.com/v1.3.0"},
+	})
+	if err := os.WriteFile(c.statePath, st, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	notified := make(chan Release, 1)
+	c.OnUpdate(func(r Release) { notified <- r })
+	go func() {
+		c.Run(ctx)
+		close(done)
+	}()
+
+	select {
+	case r := <-notified:
+		if r.Version != "v1.3.0" {
+			t.Errorf("restored release = %+v", r)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("persisted release was not reported")
+	}
+	cancel()
+	<-done
+
+	if n := hits.Load(); n != 0 {
+		t.Errorf("checked GitHub %d times within the interval, want 0", n)
+	}
+}
+
+func TestRun_ChecksWhenStale(t *testing.T) {
+	var hits atomic.Int32
+	srv := releaseServer(t, "v1.3.0", &hits)
+	c := newTestChecker(t, "v1.2.0", srv.URL)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	notified := make(chan Release, 1)
+	c.OnUpdate(func(r Release) { notified <- r })
+	go c.Run(ctx)
+
+	select {
+	case <-notified:
+	case <-time.After(2 * time.Second):
+		t.Fatal("no update reported")
+	}
+
+	// The state file is written just after the callback runs.
+	var data []byte
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		var err error
+		if data, err = os.ReadFile(c.statePath); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("state not saved: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	var st state
+	if err := json.Unmarshal(data, &st); err != nil {
+		t.Fatal(err)
+	}
+	if st.CheckedAt.IsZero() || st.Latest == nil {
+		t.Errorf("saved state = %+v", st)
+	}
+}
@@ -0,0 +1,224 @@
+// Package watchdog raises an alert when agents are expected to be working
+// but none has done anything for a while: an unattended batch run whose
+// agent died quietly otherwise goes unnoticed until someone looks.
+package watchdog
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/agent-racer/backend/internal/session"
+)
+
+// checkInterval is how often the watchdog looks at the sessions.
+const checkInterval = 30 * time.Second
+
+// MinQuietAfter keeps the watchdog from firing between two tool calls.
+const MinQuietAfter = time.Minute
+
+// Hours is a daily window in minutes after midnight. End before Start
+// wraps past midnight, e.g. 22:00-06:00. The zero value is all day.
+type Hours struct {
+	Start, End int
+}
+
+// ParseHours parses "HH:MM-HH:MM". An empty string is all day.
+func ParseHours(s string) (Hours, error) {
+	if s == "" {
+		return Hours{}, nil
+	}
+	from, to, ok := strings.Cut(s, "-")
+	if !ok {
+		return Hours{}, fmt.Errorf("hours must be HH:MM-HH:MM, got %q", s)
+	}
+	start, err := time.Parse("15:04", strings.TrimSpace(from))
+	if err != nil {
+		return Hours{}, fmt.Errorf("hours must be HH:MM-HH:MM, got %q", s)
+	}
+	end, err := time.Parse("15:04", strings.TrimSpace(to))
+	if err != nil {
+		return Hours{}, fmt.Errorf("hours must be HH:MM-HH:MM, got %q", s)
+	}
+	h := Hours{Start: start.Hour()*60 + start.Minute(), End: end.Hour()*60 + end.Minute()}
+	if h.Start == h.End {
+		return Hours{}, fmt.Errorf("hours %q are empty", s)
+	}
+	return h, nil
+}
+
+// contains reports whether the clock time t falls in the window.
+func (h Hours) contains(t time.Time) bool {
+	if h == (Hours{}) {
+		return true
+	}
+	m := t.Hour()*60 + t.Minute()
+	if h.Start < h.End {
+		return m >= h.Start && m < h.End
+	}
+	return m >= h.Start || m < h.End
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// ParseWeekday parses a day as its three-letter English abbreviation,
+// "mon" to "sun", in any case.
+func ParseWeekday(s string) (time.Weekday, error) {
+	d, ok := weekdays[strings.ToLower(strings.TrimSpace(s))]
+	if !ok {
+		return 0, fmt.Errorf("unknown day %q: use mon, tue, wed, thu, fri, sat or sun", s)
+	}
+	return d, nil
+}
+
+// Settings configure the watchdog.
+type Settings struct {
+	Enabled bool
+	// QuietAfter is how long no session may show activity before the
+	// alert is raised.
+	QuietAfter time.Duration
+	// Hours and Days are when agents are expected to be working. Empty
+	// Days means every day.
+	Hours Hours
+	Days  []time.Weekday
+	// Location is the zone Hours and Days are in; nil is the server's.
+	Location *time.Location
+}
+
+// onDuty reports whether agents are expected to be working at now.
+func (s Settings) onDuty(now time.Time) bool {
+	if s.Location != nil {
+		now = now.In(s.Location)
+	}
+	if len(s.Days) > 0 {
+		found := false
+		for i := 0; i < len(s.Days); i++ {
+			if s.Days[i] == now.Weekday() {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return s.Hours.contains(now)
+}
+
+// Alert says the sessions have been quiet for too long.
+type Alert struct {
+	// QuietSince is when the last session activity was seen, or when the
+	// work hours started if that was later.
+	QuietSince time.Time
+	At         time.Time
+	// LastSessionID and LastSessionName name the session that was active
+	// last, if any is still known.
+	LastSessionID   string
+	LastSessionName string
+}
+
+// Watchdog checks the sessions periodically and hands an Alert to the
+// OnAlert callback once per quiet spell.
+type Watchdog struct {
+	sessions func() []*session.SessionState
+	now      func() time.Time
+
+	mu       sync.Mutex
+	settings Settings
+	onAlert  func(Alert)
+	// dutyStart is when the watchdog saw the current work hours begin;
+	// zero while off duty.
+	dutyStart time.Time
+	// alerted is the QuietSince of the last alert, so one spell alerts
+	// once.
+	alerted time.Time
+}
+
+// New returns a disabled watchdog over sessions, which should already be
+// privacy-filtered since alerts go to every client.
+func New(sessions func() []*session.SessionState) *Watchdog {
+	return &Watchdog{sessions: sessions, now: time.Now}
+}
+
+// OnAlert registers fn to receive alerts. Must be called before Run.
+func (w *Watchdog) OnAlert(fn func(Alert)) {
+	w.onAlert = fn
+}
+
+// Configure replaces the settings. The current quiet spell is measured
+// afresh, so a reload does not fire an alert straight away.
+func (w *Watchdog) Configure(s Settings) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.settings = s
+	w.settings.Days = append([]time.Weekday(nil), s.Days...)
+	w.dutyStart = time.Time{}
+	w.alerted = time.Time{}
+}
+
+// Run checks the sessions every checkInterval until ctx is done.
+func (w *Watchdog) Run(ctx context.Context) {
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if a, ok := w.Check(); ok && w.onAlert != nil {
+			w.onAlert(a)
+		}
+	}
+}
+
+// Check looks at the sessions now and returns an alert if they have just
+// been quiet for too long during work hours.
+func (w *Watchdog) Check() (Alert, bool) {
+	now := w.now()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	s := w.settings
+	if !s.Enabled || s.QuietAfter <= 0 || !s.onDuty(now) {
+		w.dutyStart = time.Time{}
+		return Alert{}, false
+	}
+	if w.dutyStart.IsZero() {
+		w.dutyStart = now
+	}
+
+	a := Alert{QuietSince: w.dutyStart, At: now}
+	var last *session.SessionState
+	states := w.sessions()
+	for i := 0; i < len(states); i++ {
+		if t := lastSeen(states[i]); last == nil || t.After(lastSeen(last)) {
+			last = states[i]
+		}
+	}
+	if last != nil {
+		a.LastSessionID, a.LastSessionName = last.ID, last.Name
+		if t := lastSeen(last); t.After(a.QuietSince) {
+			a.QuietSince = t
+		}
+	}
+	if now.Sub(a.QuietSince) < s.QuietAfter || a.QuietSince.Equal(w.alerted) {
+		return Alert{}, false
+	}
+	w.alerted = a.QuietSince
+	return a, true
+}
+
+// lastSeen is the last time s did anything: its last activity, or when
+// it finished if that was later.
+func lastSeen(s *session.SessionState) time.Time {
+	t := s.LastActivityAt
+	if s.CompletedAt != nil && s.CompletedAt.After(t) {
+		t = *s.CompletedAt
+	}
+	return t
+}
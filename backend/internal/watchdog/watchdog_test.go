@@ -0,0 +1,85 @@
+package watchdog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/agent-racer/backend/internal/session"
+)
+
+func TestParseHours(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Hours
+		wantErr bool
+	}{
+		{"", Hours{}, false},
+		{"09:00-18:00", Hours{Start: 540, End: 1080}, false},
+		{"22:30 - 06:00", Hours{Start: 1350, End: 360}, false},
+		{"9-5", Hours{}, true},
+		{"09:00", Hours{}, true},
+		{"09:00-09:00", Hours{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseHours(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseHours(%q) = %+v, %v", tt.in, got, err)
+		}
+	}
+}
+
+func TestOnDuty(t *testing.T) {
+	s := Settings{Hours: Hours{Start: 22 * 60, End: 6 * 60}, Days: []time.Weekday{time.Monday}, Location: time.UTC}
+	mon := time.Date(2026, 3, 2, 23, 0, 0, 0, time.UTC)
+	if !s.onDuty(mon) {
+		t.Error("Monday 23:00 should be on duty")
+	}
+	if s.onDuty(mon.Add(-2 * time.Hour)) {
+		t.Error("Monday 21:00 should be off duty")
+	}
+	if s.onDuty(mon.Add(24 * time.Hour)) {
+		t.Error("Tuesday 23:00 should be off duty")
+	}
+}
+
+func TestCheck(t *testing.T) {
+	now := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	sessions := []*session.SessionState{
+		{ID: "a", Name: "batch", Activity: session.Thinking, LastActivityAt: now.Add(-time.Hour)},
+	}
+	w := New(func() []*session.SessionState { return sessions })
+	w.now = func() time.Time { return now }
+	w.Configure(Settings{Enabled: true, QuietAfter: 20 * time.Minute, Hours: Hours{Start: 9 * 60, End: 18 * 60}, Location: time.UTC})
+
+	// The watchdog only just came on duty: the quiet spell starts now.
+	if _, ok := w.Check(); ok {
+		t.Fatal("alerted on the first check")
+	}
+	now = now.Add(25 * time.Minute)
+	a, ok := w.Check()
+	if !ok || a.LastSessionName != "batch" || !a.QuietSince.Equal(now.Add(-25*time.Minute)) {
+		t.Fatalf("after 25 quiet minutes: %+v, %v", a, ok)
+	}
+	// One alert per spell.
+	now = now.Add(time.Minute)
+	if _, ok := w.Check(); ok {
+		t.Error("alerted twice for one quiet spell")
+	}
+
+	// Activity resumes, then stops again.
+	sessions[0].LastActivityAt = now
+	now = now.Add(10 * time.Minute)
+	if _, ok := w.Check(); ok {
+		t.Error("alerted 10 minutes after activity")
+	}
+	now = now.Add(15 * time.Minute)
+	if a, ok := w.Check(); !ok || !a.QuietSince.Equal(sessions[0].LastActivityAt) {
+		t.Errorf("second spell: %+v, %v", a, ok)
+	}
+
+	// Nothing is expected after hours.
+	now = time.Date(2026, 3, 2, 20, 0, 0, 0, time.UTC)
+	if _, ok := w.Check(); ok {
+		t.Error("alerted after hours")
+	}
+}
@@ -0,0 +1,126 @@
+// Single-session widget for /embed/{id}. Polls /embed/{id}/session and
+// renders the session's progress bar and state. Options:
+//   #token=...        embed token from POST /api/sessions/{id}/embed (kept in
+//                     the fragment so it is never sent with the page request)
+//   ?interval=10      poll interval in seconds (default 5, minimum 2)
+//   ?theme=light      light background; ?theme=transparent for overlays
+(function () {
+  'use strict';
+
+  const TERMINAL = new Set(['complete', 'errored', 'lost']);
+  const DEFAULT_INTERVAL_S = 5;
+  const MIN_INTERVAL_S = 2;
+
+  const sessionId = document.body.dataset.session;
+  const hash = new URLSearchParams(location.hash.replace(/^#/, ''));
+  const params = new URLSearchParams(location.search);
+  const token = hash.get('token') || '';
+  const interval = Math.max(MIN_INTERVAL_S, Number(params.get('interval')) || DEFAULT_INTERVAL_S) * 1000;
+
+  const theme = params.get('theme');
+  if (theme === 'light' || theme === 'transparent') {
+    document.body.classList.add(theme);
+  }
+
+  const el = {
+    racer: document.querySelector('.racer'),
+    name: document.querySelector('.name'),
+    activity: document.querySelector('.activity'),
+    bar: document.querySelector('.bar'),
+    fill: document.querySelector('.fill'),
+    context: document.querySelector('.context'),
+    tool: document.querySelector('.tool'),
+    elapsed: document.querySelector('.elapsed'),
+    status: document.querySelector('.status'),
+  };
+
+  let last = null;
+
+  function formatTokens(n) {
+    if (n >= 1000000) return (n / 1000000).toFixed(1) + 'M';
+    if (n >= 1000) return Math.round(n / 1000) + 'k';
+    return String(n);
+  }
+
+  function formatElapsed(ms) {
+    const s = Math.max(0, Math.floor(ms / 1000));
+    const h = Math.floor(s / 3600);
+    const m = Math.floor((s % 3600) / 60);
+    if (h > 0) return h + 'h ' + m + 'm';
+    if (m > 0) return m + 'm ' + (s % 60) + 's';
+    return s + 's';
+  }
+
+  function setStatus(text) {
+    el.status.textContent = text;
+    el.status.hidden = !text;
+  }
+
+  function render(s) {
+    const pct = Math.round(Math.min(1, Math.max(0, s.contextUtilization || 0)) * 100);
+    el.racer.dataset.activity = s.activity;
+    el.name.textContent = s.name || s.id;
+    el.activity.textContent = String(s.activity || '').replace('_', ' ');
+    el.fill.style.width = pct + '%';
+    el.bar.setAttribute('aria-valuenow', String(pct));
+    el.context.textContent = pct + '% · ' + formatTokens(s.tokensUsed || 0) +
+      (s.maxContextTokens ? ' / ' + formatTokens(s.maxContextTokens) : '') + ' tokens';
+    el.tool.textContent = s.currentTool || '';
+    document.title = (s.name || s.id) + ' · Agent Racer';
+    renderElapsed();
+  }
+
+  function renderElapsed() {
+    if (!last || !last.startedAt) return;
+    const end = last.completedAt ? Date.parse(last.completedAt) : Date.now();
+    el.elapsed.textContent = formatElapsed(end - Date.parse(last.startedAt));
+  }
+
+  async function poll() {
+    let res;
+    try {
+      res = await fetch('/embed/' + encodeURIComponent(sessionId) + '/session', {
+        headers: token ? { Authorization: 'Bearer ' + token } : {},
+        cache: 'no-store',
+      });
+    } catch {
+      setStatus('Server unreachable, retrying');
+      return schedule(interval);
+    }
+
+    if (res.status === 401) {
+      // Retrying will not help until the URL carries a valid token.
+      setStatus(token ? 'Embed token invalid or expired' : 'Unauthorized: add #token=<embed token> to the widget URL');
+      return;
+    }
+    if (res.status === 404) {
+      // Finished sessions are eventually dropped from the store; keep the
+      // last known state on screen.
+      setStatus(last ? 'Session ended' : 'Session not found');
+      if (last) return;
+      return schedule(interval);
+    }
+    if (res.status === 429) {
+      const retry = Number(res.headers.get('Retry-After')) || DEFAULT_INTERVAL_S;
+      return schedule(Math.max(interval, retry * 1000));
+    }
+    if (!res.ok) {
+      setStatus('Server error ' + res.status + ', retrying');
+      return schedule(interval);
+    }
+
+    last = await res.json();
+    setStatus('');
+    render(last);
+    if (!TERMINAL.has(last.activity)) {
+      schedule(interval);
+    }
+  }
+
+  function schedule(ms) {
+    setTimeout(poll, ms);
+  }
+
+  setInterval(renderElapsed, 1000);
+  poll();
+})();
@@ -0,0 +1,133 @@
+// Package widget serves a minimal single-session view at /embed/{id} for
+// iframing into other dashboards: one racer's progress bar and state.
+package widget
+
+import (
+	"embed"
+	"encoding/json"
+	"html/template"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/agent-racer/backend/internal/session"
+)
+
+// RoutePrefix is where the widget is served.
+const RoutePrefix = "/embed/"
+
+// assetPrefix holds the widget's script and stylesheet. Session IDs are
+// UUID-like, so "assets" cannot collide with one.
+const assetPrefix = RoutePrefix + "assets/"
+
+// stateSuffix follows the session ID in the path the widget polls.
+const stateSuffix = "/session"
+
+//go:embed assets/*
+var assetFiles embed.FS
+
+// Handler serves the widget page, its assets and the state it polls. The
+// page itself carries no session data; its script polls
+// GET /embed/{id}/session with the embed token from the #token= fragment.
+// That token is scoped to the one session and can read nothing else, so a
+// host page that leaks it gives away no more than the widget shows.
+type Handler struct {
+	frameAncestors func() []string
+	authorize      func(r *http.Request, sessionID string) bool
+	lookup         func(sessionID string) (*session.SessionState, bool)
+	assets         http.Handler
+}
+
+// NewHandler returns a widget handler. frameAncestors is consulted on every
+// request so config reloads take effect immediately. authorize reports
+// whether a poll may read the session, and lookup returns the session as
+// the widget may show it, or false if there is none.
+func NewHandler(frameAncestors func() []string, authorize func(r *http.Request, sessionID string) bool, lookup func(sessionID string) (*session.SessionState, bool)) *Handler {
+	sub, err := fs.Sub(assetFiles, "assets")
+	if err != nil {
+		panic(err)
+	}
+	return &Handler{
+		frameAncestors: frameAncestors,
+		authorize:      authorize,
+		lookup:         lookup,
+		assets:         http.StripPrefix(assetPrefix, http.FileServer(http.FS(sub))),
+	}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if strings.HasPrefix(r.URL.Path, assetPrefix) {
+		w.Header().Set("Cache-Control", "no-cache")
+		h.assets.ServeHTTP(w, r)
+		return
+	}
+
+	sessionID := strings.TrimPrefix(r.URL.Path, RoutePrefix)
+	sessionID, poll := strings.CutSuffix(sessionID, stateSuffix)
+	if sessionID == "" || strings.Contains(sessionID, "/") {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	if poll {
+		h.serveState(w, r, sessionID)
+		return
+	}
+
+	// The global security headers forbid framing; this page exists to be
+	// framed, so swap in a policy that allows the configured ancestors.
+	w.Header().Del("X-Frame-Options")
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; "+
+		"script-src 'self'; "+
+		"style-src 'self'; "+
+		"connect-src 'self'; "+
+		"base-uri 'none'; "+
+		"frame-ancestors "+strings.Join(h.frameAncestors(), " "))
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Robots-Tag", "noindex")
+	if err := pageTemplate.Execute(w, sessionID); err != nil {
+		slog.Error("render embed widget failed", "error", err)
+	}
+}
+
+// serveState returns the session's state to a poll carrying a token for it.
+func (h *Handler) serveState(w http.ResponseWriter, r *http.Request, sessionID string) {
+	w.Header().Set("Cache-Control", "no-store")
+	if !h.authorize(r, sessionID) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	state, ok := h.lookup(sessionID)
+	if !ok {
+		http.Error(w, "session not found", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(state)
+}
+
+var pageTemplate = template.Must(template.New("embed").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>Agent Racer</title>
+<link rel="stylesheet" href="/embed/assets/embed.css">
+<script src="/embed/assets/embed.js" defer></script>
+</head>
+<body data-session="{{.}}">
+<div class="racer" data-activity="starting">
+<div class="head"><span class="dot" aria-hidden="true"></span><span class="name">{{.}}</span><span class="activity"></span></div>
+<div class="bar" role="progressbar" aria-label="Context used" aria-valuemin="0" aria-valuemax="100" aria-valuenow="0"><div class="fill"></div></div>
+<div class="meta"><span class="context"></span><span class="tool"></span><span class="elapsed"></span></div>
+<div class="status" role="status" hidden></div>
+</div>
+</body>
+</html>
+`))
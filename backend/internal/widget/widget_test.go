@@ -0,0 +1,113 @@
+package widget
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/agent-racer/backend/internal/session"
+)
+
+// newTestHandler returns a handler serving session s1 to polls carrying
+// the token "s1-token".
+func newTestHandler(ancestors ...string) *Handler {
+	return NewHandler(func() []string { return ancestors },
+		func(r *http.Request, id string) bool {
+			return r.Header.Get("Authorization") == "Bearer "+id+"-token"
+		},
+		func(id string) (*session.SessionState, bool) {
+			if id != "s1" {
+				return nil, false
+			}
+			return &session.SessionState{ID: id, Name: "migrate", ContextUtilization: 0.4}, true
+		})
+}
+
+func TestServeHTTP_Page(t *testing.T) {
+	h := newTestHandler("https://grafana.example.com", "'self'")
+
+	rec := httptest.NewRecorder()
+	rec.Header().Set("X-Frame-Options", "DENY") // as set by the global security headers
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/embed/sess-1%3Cb%3E", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	if got := rec.Header().Get("X-Frame-Options"); got != "" {
+		t.Errorf("X-Frame-Options = %q, want it removed", got)
+	}
+	csp := rec.Header().Get("Content-Security-Policy")
+	if !strings.Contains(csp, "frame-ancestors https://grafana.example.com 'self'") {
+		t.Errorf("CSP = %q, want configured frame-ancestors", csp)
+	}
+	body := rec.Body.String()
+	if !strings.Contains(body, `data-session="sess-1&lt;b&gt;"`) {
+		t.Errorf("session id not escaped into page:\n%s", body)
+	}
+	if !strings.Contains(body, "/embed/assets/embed.js") {
+		t.Error("page does not load the widget script")
+	}
+}
+
+func TestServeHTTP_AssetsAndErrors(t *testing.T) {
+	h := newTestHandler("'self'")
+
+	for _, name := range []string{"embed.js", "embed.css"} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, assetPrefix+name, nil))
+		if rec.Code != http.StatusOK || rec.Body.Len() == 0 {
+			t.Errorf("GET %s = %d (%d bytes)", name, rec.Code, rec.Body.Len())
+		}
+	}
+
+	tests := []struct {
+		method, path string
+		want         int
+	}{
+		{http.MethodGet, RoutePrefix, http.StatusNotFound},
+		{http.MethodGet, RoutePrefix + "a/b", http.StatusNotFound},
+		{http.MethodPost, RoutePrefix + "s1", http.StatusMethodNotAllowed},
+		{http.MethodGet, RoutePrefix + "/session", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+		if rec.Code != tt.want {
+			t.Errorf("%s %s = %d, want %d", tt.method, tt.path, rec.Code, tt.want)
+		}
+	}
+}
+
+func TestServeHTTP_StateNeedsTokenForSession(t *testing.T) {
+	h := newTestHandler("'self'")
+
+	tests := []struct {
+		name, path, token string
+		want              int
+	}{
+		{"no token", "/embed/s1/session", "", http.StatusUnauthorized},
+		{"token for another session", "/embed/s1/session", "s2-token", http.StatusUnauthorized},
+		{"unknown session", "/embed/s2/session", "s2-token", http.StatusNotFound},
+		{"own token", "/embed/s1/session", "s1-token", http.StatusOK},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		if tt.token != "" {
+			r.Header.Set("Authorization", "Bearer "+tt.token)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, r)
+		if rec.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, rec.Code, tt.want)
+			continue
+		}
+		if tt.want != http.StatusOK {
+			continue
+		}
+		var got session.SessionState
+		if err := json.NewDecoder(rec.Body).Decode(&got); err != nil || got.Name != "migrate" {
+			t.Errorf("%s: body = %+v (%v)", tt.name, got, err)
+		}
+	}
+}
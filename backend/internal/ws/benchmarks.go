@@ -0,0 +1,88 @@
+package ws
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/agent-racer/backend/internal/benchmark"
+)
+
+// SetBenchmarks enables /api/benchmarks. Must be called before SetupRoutes.
+func (s *Server) SetBenchmarks(r *benchmark.Runner) {
+	s.benchmarks = r
+}
+
+type benchmarksResponse struct {
+	Tasks   []string           `json:"tasks"`
+	Running []benchmark.Run    `json:"running"`
+	Results []benchmark.Result `json:"results"`
+}
+
+// handleBenchmarks reports the configured tasks, the runs in progress and
+// the results table, newest first.
+func (s *Server) handleBenchmarks(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.authorize(r) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if s.benchmarks == nil {
+		http.Error(w, "benchmarks not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(benchmarksResponse{
+		Tasks:   s.benchmarks.Tasks(),
+		Running: s.benchmarks.Running(),
+		Results: s.benchmarks.Results(),
+	})
+}
+
+// handleBenchmarkRun starts a benchmark without waiting for it. The body
+// {"task": "name"} is optional; without it every idle task starts.
+func (s *Server) handleBenchmarkRun(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.authorize(r) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if s.benchmarks == nil {
+		http.Error(w, "benchmarks not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	var req struct {
+		Task string `json:"task"`
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBodySize)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	runs, err := s.benchmarks.Trigger(req.Task)
+	switch {
+	case errors.Is(err, benchmark.ErrBusy):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case errors.Is(err, benchmark.ErrUnknownTask):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	_ = json.NewEncoder(w).Encode(runs)
+}
@@ -1,6 +1,7 @@
 package ws
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"log/slog"
@@ -8,6 +9,9 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/agent-racer/backend/internal/heats"
+	"github.com/agent-racer/backend/internal/launch"
+	"github.com/agent-racer/backend/internal/names"
 	"github.com/agent-racer/backend/internal/session"
 	"github.com/gorilla/websocket"
 )
@@ -16,6 +20,9 @@ import (
 // concurrent WebSocket connections has been reached.
 var ErrTooManyConnections = errors.New("too many WebSocket connections")
 
+// ErrShuttingDown is returned by AddClient once Shutdown has begun.
+var ErrShuttingDown = errors.New("server shutting down")
+
 // writeWait is the maximum time allowed for a write to complete before the
 // connection is considered dead. Prevents goroutine leaks from stalled clients.
 var writeWait = 10 * time.Second
@@ -26,26 +33,69 @@ type client struct {
 	b      *Broadcaster
 	mu     sync.Mutex
 	closed bool
+	// goingAway is the close-frame reason written after the send queue
+	// drains; empty for an abrupt close.
+	goingAway string
+	// done is closed when writePump exits. Nil for clients built without
+	// newClient.
+	done chan struct{}
+
+	// Introspection counters, read by Broadcaster.Metrics.
+	connectedAt time.Time
+	remoteAddr  string
+	clientID    string // as given when connecting, to resume
+	snapshot    SnapshotOptions
+	enqueued    atomic.Uint64
+	written     atomic.Uint64
+	lastWrite   atomic.Int64 // unix nanoseconds of the last successful write
+
+	// What the client called itself in a hello message, when it last
+	// changed that, and when it last sent a reaction; guarded by mu.
+	name         string
+	kind         string
+	lastHello    time.Time
+	lastReaction time.Time
 }
 
 func newClient(conn *websocket.Conn, b *Broadcaster) *client {
 	c := &client{
-		conn: conn,
-		send: make(chan []byte, 64),
-		b:    b,
+		conn:        conn,
+		send:        make(chan []byte, 64),
+		b:           b,
+		done:        make(chan struct{}),
+		connectedAt: time.Now(),
+	}
+	if conn != nil {
+		c.remoteAddr = conn.RemoteAddr().String()
 	}
 	go c.writePump()
 	return c
 }
 
 func (c *client) writePump() {
-	defer func() { _ = c.conn.Close() }()
+	defer func() {
+		_ = c.conn.Close()
+		if c.done != nil {
+			close(c.done)
+		}
+	}()
 	for msg := range c.send {
 		_ = c.conn.SetWriteDeadline(time.Now().Add(writeWait))
 		if err := c.conn.WriteMessage(websocket.TextMessage, msg); err != nil {
 			c.b.RemoveClient(c)
 			return
 		}
+		c.written.Add(1)
+		c.lastWrite.Store(time.Now().UnixNano())
+	}
+
+	c.mu.Lock()
+	reason := c.goingAway
+	c.mu.Unlock()
+	if reason != "" {
+		_ = c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+		_ = c.conn.WriteMessage(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseGoingAway, reason))
 	}
 }
 
@@ -65,6 +115,19 @@ func (c *client) close() {
 	}
 }
 
+// closeGracefully stops accepting messages but lets writePump deliver what
+// is already queued, then send a going-away close frame with reason.
+func (c *client) closeGracefully(reason string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return
+	}
+	c.closed = true
+	c.goingAway = reason
+	close(c.send)
+}
+
 // trySend attempts a non-blocking send on the client's channel.
 // Returns true if the message was sent, false if the buffer was full
 // or the channel was already closed.
@@ -76,29 +139,71 @@ func (c *client) trySend(data []byte) bool {
 	}
 	select {
 	case c.send <- data:
+		c.enqueued.Add(1)
 		return true
 	default:
 		return false
 	}
 }
 
+// trySendAll queues frames back to back, so no other message lands
+// between them, or none of them if they don't all fit. Returns false when
+// nothing was queued.
+func (c *client) trySendAll(frames [][]byte) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed || cap(c.send)-len(c.send) < len(frames) {
+		return false
+	}
+	for i := 0; i < len(frames); i++ {
+		c.send <- frames[i]
+		c.enqueued.Add(1)
+	}
+	return true
+}
+
 type Broadcaster struct {
 	mu             sync.RWMutex
 	clients        map[*client]bool
 	maxConns       int
 	store          *session.Store
 	privacy        *session.PrivacyFilter
+	aliases        *names.Aliases // nil keeps derived names
+	language       string         // of session status text; "" for English
 	throttle       time.Duration
 	snapshotTicker *time.Ticker
 	stop           chan struct{}
 	snapshotReset  chan time.Duration // signals snapshotLoop to recreate its ticker
 	pendingUpdates []*session.SessionState
 	pendingRemoved []string
+	cued           map[string]bool   // guarded by flushMu; sessions already given a start cue
+	sentNames      map[string]string // guarded by flushMu; displayNames as of the last flush
+	sentHashes     map[string]uint64 // guarded by flushMu; fingerprint of each session as last sent
 	flushTimer     *time.Timer
 	flushMu        sync.Mutex
+	unlocks        unlockBatch
 	healthHook     func() []SourceHealthPayload
 	seq            atomic.Uint64
 	stopOnce       sync.Once
+	shuttingDown   bool   // guarded by mu; set by Shutdown
+	updateNotice   []byte // guarded by mu; update_available payload for new clients
+	backlog        backlog
+	auto           autoThrottle // guarded by flushMu
+
+	// Introspection state for Metrics. The flush fields are guarded by
+	// flushMu; the rest are atomics.
+	createdAt        time.Time
+	snapshotInterval time.Duration
+	pendingSince     time.Time
+	lastFlushAt      time.Time
+	lastFlushDelay   time.Duration
+	maxFlushDelay    time.Duration
+	flushes          atomic.Uint64
+	snapshots        atomic.Uint64
+	lastSnapshot     atomic.Int64 // unix nanoseconds of the last periodic snapshot
+	droppedClients   atomic.Uint64
+	broadcasts       atomic.Uint64 // messages sent to every client
+	unchangedSkipped atomic.Uint64 // queued updates dropped as identical to the last sent
 }
 
 func NewBroadcaster(store *session.Store, throttle, snapshotInterval time.Duration, maxConns int) *Broadcaster {
@@ -111,6 +216,12 @@ func NewBroadcaster(store *session.Store, throttle, snapshotInterval time.Durati
 		snapshotTicker: time.NewTicker(snapshotInterval),
 		stop:           make(chan struct{}),
 		snapshotReset:  make(chan time.Duration, 1),
+
+		createdAt:        time.Now(),
+		snapshotInterval: snapshotInterval,
+	}
+	if store != nil {
+		store.Subscribe(b.applyMutations)
 	}
 	go b.snapshotLoop()
 	return b
@@ -124,6 +235,36 @@ func (b *Broadcaster) SetPrivacyFilter(f *session.PrivacyFilter) {
 	b.mu.Unlock()
 }
 
+// SetAliases configures the display names that replace derived ones in
+// all outgoing session data. Pass nil to disable. Safe for concurrent use.
+func (b *Broadcaster) SetAliases(a *names.Aliases) {
+	b.mu.Lock()
+	b.aliases = a
+	b.mu.Unlock()
+}
+
+// SetLanguage sets the language of the status text sent with each
+// session. Safe for concurrent use.
+func (b *Broadcaster) SetLanguage(lang string) {
+	b.mu.Lock()
+	b.language = lang
+	b.mu.Unlock()
+}
+
+// statusLanguage returns the language set by SetLanguage.
+func (b *Broadcaster) statusLanguage() string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.language
+}
+
+// Aliases returns the aliases set by SetAliases.
+func (b *Broadcaster) Aliases() *names.Aliases {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.aliases
+}
+
 // SetHealthHook registers a function that returns the current source health
 // status for inclusion in snapshot broadcasts. Safe for concurrent use.
 func (b *Broadcaster) SetHealthHook(hook func() []SourceHealthPayload) {
@@ -140,14 +281,94 @@ func (b *Broadcaster) privacyFilter() *session.PrivacyFilter {
 	return f
 }
 
-// FilterSessions applies the privacy filter to the given sessions, removing
-// blocked sessions and masking sensitive fields.
+// FilterSessions renames sessions as clients see them, applies the
+// privacy filter, removing blocked sessions and masking sensitive fields,
+// and describes what is left for screen readers.
 func (b *Broadcaster) FilterSessions(sessions []*session.SessionState) []*session.SessionState {
-	return b.privacyFilter().FilterSlice(sessions)
+	pf := b.privacyFilter()
+	return present(pf, b.displayNames(pf, b.store.GetAll()), b.statusLanguage(), sessions)
+}
+
+// displayNames returns the names clients see for the sessions in all that
+// pf lets through, keyed by session ID, where they differ from the stored
+// name: the user's alias if there is one, otherwise a name disambiguated
+// from other projects with the same name. It works on unmasked sessions,
+// as aliases key on the real session ID and working directory.
+func (b *Broadcaster) displayNames(pf *session.PrivacyFilter, all []*session.SessionState) map[string]string {
+	aliases := b.Aliases()
+	renames := make(map[string]string)
+	derived := make([]*session.SessionState, 0, len(all))
+	for _, s := range all {
+		if !pf.IsAllowed(s.WorkingDir) {
+			continue
+		}
+		if aliases != nil {
+			if alias, ok := aliases.Lookup(s.ID, s.WorkingDir); ok {
+				if alias != s.Name {
+					renames[s.ID] = alias
+				}
+				continue
+			}
+		}
+		derived = append(derived, s)
+	}
+	for id, name := range names.Disambiguate(derived, pf.MaskWorkingDirs) {
+		renames[id] = name
+	}
+	return renames
+}
+
+// present renames sessions according to displayNames, passes them through
+// pf and fills in their severity and status text in lang.
+func present(pf *session.PrivacyFilter, renames map[string]string, lang string, sessions []*session.SessionState) []*session.SessionState {
+	if len(renames) > 0 {
+		renamed := make([]*session.SessionState, len(sessions))
+		for i := 0; i < len(sessions); i++ {
+			renamed[i] = sessions[i]
+			if name, ok := renames[sessions[i].ID]; ok {
+				cp := *sessions[i]
+				cp.Name = name
+				renamed[i] = &cp
+			}
+		}
+		sessions = renamed
+	}
+	visible := pf.FilterSlice(sessions)
+	now := time.Now()
+	for i := 0; i < len(visible); i++ {
+		visible[i] = visible[i].Describe(lang, now)
+	}
+	return visible
+}
+
+// displayName returns the name clients see for the stored session id, or
+// name when it isn't renamed. Event payloads carry names copied from the
+// monitor's state.
+func (b *Broadcaster) displayName(id, name string) string {
+	if renamed, ok := b.displayNames(b.privacyFilter(), b.store.GetAll())[id]; ok {
+		return renamed
+	}
+	return name
 }
 
 func (b *Broadcaster) AddClient(conn *websocket.Conn) (*client, error) {
+	return b.AddResumingClient(conn, "", 0, SnapshotOptions{})
+}
+
+// AddResumingClient adds a client that last saw seq since, as reported by
+// clientID, and wants snapshots framed as opts says. It is sent what it
+// missed (see SetCatchUpWindow) before the usual snapshot. A zero since
+// adds a plain client.
+func (b *Broadcaster) AddResumingClient(conn *websocket.Conn, clientID string, since uint64, opts SnapshotOptions) (*client, error) {
 	b.mu.Lock()
+	if b.shuttingDown {
+		b.mu.Unlock()
+		_ = conn.SetWriteDeadline(time.Now().Add(writeWait))
+		_ = conn.WriteMessage(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseGoingAway, ErrShuttingDown.Error()))
+		_ = conn.Close()
+		return nil, ErrShuttingDown
+	}
 	if b.maxConns > 0 && len(b.clients) >= b.maxConns {
 		b.mu.Unlock()
 		_ = conn.SetWriteDeadline(time.Now().Add(writeWait))
@@ -158,64 +379,371 @@ func (b *Broadcaster) AddClient(conn *websocket.Conn) (*client, error) {
 	}
 
 	c := newClient(conn, b)
+	c.clientID = clientID
+	c.snapshot = opts
 	b.clients[c] = true
 	b.mu.Unlock()
 
+	b.sendCatchUp(c, clientID, since)
+	b.backlog.connect(clientID, b.seq.Load())
 	b.SendSnapshot(c)
+	b.sendUpdateNotice(c)
+	b.broadcastPresence()
 
 	return c, nil
 }
 
 func (b *Broadcaster) RemoveClient(c *client) {
 	b.mu.Lock()
-	if _, ok := b.clients[c]; ok {
+	_, ok := b.clients[c]
+	if ok {
 		delete(b.clients, c)
 		c.close()
 	}
 	b.mu.Unlock()
+	if ok {
+		b.backlog.disconnect(c.clientID, time.Now())
+		b.broadcastPresence()
+	}
+}
+
+// storeFed reports whether the broadcaster is fed from the store's event
+// log. While it is, QueueUpdate, QueueRemoval and QueueCompletion ignore
+// their callers, who would otherwise have every change sent twice.
+func (b *Broadcaster) storeFed() bool {
+	return b.store != nil && b.store.EventLogging()
+}
+
+// applyMutations queues a batch from the store's event log: changed
+// sessions as updates, first terminal states as completions too, and
+// removals.
+func (b *Broadcaster) applyMutations(batch []session.Mutation) {
+	var updates []*session.SessionState
+	var removed []string
+	for i := 0; i < len(batch); i++ {
+		m := batch[i]
+		switch m.Op {
+		case session.MutationRemove:
+			removed = append(removed, m.ID)
+		case session.MutationTerminal:
+			b.queueCompletion(m.ID, m.State.Activity, m.State.Name)
+			updates = append(updates, m.State)
+		default:
+			updates = append(updates, m.State)
+		}
+	}
+	if len(updates) > 0 {
+		b.queueUpdate(updates)
+	}
+	if len(removed) > 0 {
+		b.queueRemoval(removed)
+	}
 }
 
 func (b *Broadcaster) QueueUpdate(states []*session.SessionState) {
+	if b.storeFed() {
+		return
+	}
+	b.queueUpdate(states)
+}
+
+func (b *Broadcaster) queueUpdate(states []*session.SessionState) {
 	b.flushMu.Lock()
 	defer b.flushMu.Unlock()
 
 	b.pendingUpdates = append(b.pendingUpdates, states...)
 
 	if b.flushTimer == nil {
-		b.flushTimer = time.AfterFunc(b.throttle, b.flush)
+		b.pendingSince = time.Now()
+		b.flushTimer = time.AfterFunc(b.throttleLocked(), b.flush)
 	}
 }
 
 func (b *Broadcaster) QueueRemoval(ids []string) {
+	if b.storeFed() {
+		return
+	}
+	b.queueRemoval(ids)
+}
+
+func (b *Broadcaster) queueRemoval(ids []string) {
 	b.flushMu.Lock()
 	defer b.flushMu.Unlock()
 
 	b.pendingRemoved = append(b.pendingRemoved, ids...)
+	for _, id := range ids {
+		delete(b.cued, id)
+		delete(b.sentHashes, id)
+	}
 
 	if b.flushTimer == nil {
-		b.flushTimer = time.AfterFunc(b.throttle, b.flush)
+		b.pendingSince = time.Now()
+		b.flushTimer = time.AfterFunc(b.throttleLocked(), b.flush)
+	}
+}
+
+// BroadcastOvertake announces that one session passed another.
+func (b *Broadcaster) BroadcastOvertake(o session.Overtake) {
+	msg, err := NewOvertakeMessage(OvertakePayload{
+		OvertakerID:   o.OvertakerID,
+		OvertakerName: b.displayName(o.OvertakerID, o.OvertakerName),
+		OvertakenID:   o.OvertakenID,
+		OvertakenName: b.displayName(o.OvertakenID, o.OvertakenName),
+		NewPosition:   o.NewPosition,
+	})
+	if err != nil {
+		slog.Error("broadcast overtake marshal failed", "error", err)
+		return
+	}
+	b.broadcast(msg)
+	b.BroadcastSoundCue(CueOvertake, o.OvertakerID)
+}
+
+// BroadcastLap announces a completed lap.
+func (b *Broadcaster) BroadcastLap(l session.Lap) {
+	msg, err := NewLapCompletedMessage(LapCompletedPayload{
+		SessionID: l.SessionID,
+		Name:      b.displayName(l.SessionID, l.Name),
+		Lap:       l.Lap,
+	})
+	if err != nil {
+		slog.Error("broadcast lap marshal failed", "error", err)
+		return
+	}
+	b.broadcast(msg)
+}
+
+// BroadcastMilestone announces a session passing a duration or token
+// milestone.
+func (b *Broadcaster) BroadcastMilestone(m session.Milestone) {
+	msg, err := NewMilestoneMessage(MilestonePayload{
+		SessionID: m.SessionID,
+		Name:      b.displayName(m.SessionID, m.Name),
+		Kind:      string(m.Kind),
+		Value:     m.Value,
+		Label:     m.Label(),
+	})
+	if err != nil {
+		slog.Error("broadcast milestone marshal failed", "error", err)
+		return
+	}
+	b.broadcast(msg)
+}
+
+// BroadcastCacheCollapse announces a session's prompt cache going cold.
+func (b *Broadcaster) BroadcastCacheCollapse(payload CacheCollapsePayload) {
+	payload.Name = b.displayName(payload.SessionID, payload.Name)
+	payload.SessionID = b.privacyFilter().Apply(&session.SessionState{ID: payload.SessionID}).ID
+	msg, err := NewCacheCollapseMessage(payload)
+	if err != nil {
+		slog.Error("broadcast cache collapse marshal failed", "error", err)
+		return
+	}
+	b.broadcast(msg)
+}
+
+// BroadcastModelChanged announces a session switching models.
+func (b *Broadcaster) BroadcastModelChanged(payload ModelChangedPayload) {
+	payload.Name = b.displayName(payload.SessionID, payload.Name)
+	payload.SessionID = b.privacyFilter().Apply(&session.SessionState{ID: payload.SessionID}).ID
+	msg, err := NewModelChangedMessage(payload)
+	if err != nil {
+		slog.Error("broadcast model changed marshal failed", "error", err)
+		return
+	}
+	b.broadcast(msg)
+}
+
+// BroadcastApprovalNeeded announces that state has started waiting for
+// approval, unless the privacy filter hides it.
+func (b *Broadcaster) BroadcastApprovalNeeded(state *session.SessionState, at time.Time) {
+	filter := b.privacyFilter()
+	if !filter.IsAllowed(state.WorkingDir) {
+		return
+	}
+	renamed := *state
+	renamed.Name = b.displayName(state.ID, state.Name)
+	masked := filter.Apply(&renamed)
+	msg, err := NewApprovalNeededMessage(ApprovalNeededPayload{
+		SessionID: masked.ID,
+		Name:      masked.Name,
+		Tool:      masked.CurrentTool,
+		At:        at,
+	})
+	if err != nil {
+		slog.Error("broadcast approval needed marshal failed", "error", err)
+		return
+	}
+	b.broadcast(msg)
+	b.BroadcastSoundCue(CueApproval, masked.ID)
+}
+
+// BroadcastWatchdogAlert warns clients that the sessions have gone quiet
+// during work hours.
+func (b *Broadcaster) BroadcastWatchdogAlert(payload WatchdogAlertPayload) {
+	msg, err := NewWatchdogAlertMessage(payload)
+	if err != nil {
+		slog.Error("broadcast watchdog alert marshal failed", "error", err)
+		return
+	}
+	b.broadcast(msg)
+}
+
+// BroadcastSubagentStarted announces a subagent of state doing its first
+// work, unless the privacy filter hides state.
+func (b *Broadcaster) BroadcastSubagentStarted(state *session.SessionState, sub session.SubagentState) {
+	b.broadcastSubagent(NewSubagentStartedMessage, state, sub, sub.StartedAt)
+}
+
+// BroadcastSubagentCompleted announces a subagent of state returning its
+// result, unless the privacy filter hides state.
+func (b *Broadcaster) BroadcastSubagentCompleted(state *session.SessionState, sub session.SubagentState) {
+	at := sub.LastActivityAt
+	if sub.CompletedAt != nil {
+		at = *sub.CompletedAt
+	}
+	b.broadcastSubagent(NewSubagentCompletedMessage, state, sub, at)
+}
+
+// BroadcastSubagentBudget warns that a subagent of state went over its
+// token budget, unless the privacy filter hides state.
+func (b *Broadcaster) BroadcastSubagentBudget(state *session.SessionState, sub session.SubagentState, budget int, at time.Time) {
+	filter := b.privacyFilter()
+	if !filter.IsAllowed(state.WorkingDir) {
+		return
+	}
+	share := 0.0
+	if state.MaxContextTokens > 0 {
+		share = float64(sub.TokensUsed) / float64(state.MaxContextTokens)
+	}
+	msg, err := NewSubagentBudgetMessage(SubagentBudgetPayload{
+		SessionID:    filter.Apply(&session.SessionState{ID: state.ID}).ID,
+		Name:         b.displayName(state.ID, state.Name),
+		SubagentID:   sub.ID,
+		Slug:         sub.Slug,
+		Model:        sub.Model,
+		TokensUsed:   sub.TokensUsed,
+		Budget:       budget,
+		ContextShare: share,
+		At:           at,
+	})
+	if err != nil {
+		slog.Error("broadcast subagent budget marshal failed", "error", err)
+		return
+	}
+	b.broadcast(msg)
+}
+
+func (b *Broadcaster) broadcastSubagent(newMsg func(SubagentPayload) (WSMessage, error), state *session.SessionState, sub session.SubagentState, at time.Time) {
+	filter := b.privacyFilter()
+	if !filter.IsAllowed(state.WorkingDir) {
+		return
+	}
+	msg, err := newMsg(SubagentPayload{
+		SessionID:       filter.Apply(&session.SessionState{ID: state.ID}).ID,
+		Name:            b.displayName(state.ID, state.Name),
+		SubagentID:      sub.ID,
+		ParentToolUseID: sub.ParentToolUseID,
+		ParentID:        sub.ParentID,
+		Depth:           sub.Depth,
+		Slug:            sub.Slug,
+		Model:           sub.Model,
+		TokensUsed:      sub.TokensUsed,
+		ToolCallCount:   sub.ToolCallCount,
+		At:              at,
+	})
+	if err != nil {
+		slog.Error("broadcast subagent marshal failed", "error", err)
+		return
+	}
+	b.broadcast(msg)
+}
+
+// BroadcastHeat sends a heat's current standings.
+func (b *Broadcaster) BroadcastHeat(h heats.Heat) {
+	msg, err := NewHeatStandingsMessage(h)
+	if err != nil {
+		slog.Error("broadcast heat marshal failed", "error", err)
+		return
+	}
+	b.broadcast(msg)
+}
+
+// BroadcastRaceFinished announces the winner and final standings of a heat,
+// explicit or auto, that finished with one.
+func (b *Broadcaster) BroadcastRaceFinished(h heats.Heat) {
+	if h.WinnerID == "" {
+		return
+	}
+	msg, err := NewRaceFinishedMessage(h)
+	if err != nil {
+		slog.Error("broadcast race finished marshal failed", "error", err)
+		return
 	}
+	b.broadcast(msg)
 }
 
-func (b *Broadcaster) BroadcastAchievement(payload AchievementUnlockedPayload) {
-	msg, err := NewAchievementUnlockedMessage(payload)
+// maskPipeline masks a pipeline run's session IDs the same way as the
+// sessions themselves.
+func (b *Broadcaster) maskPipeline(run launch.PipelineRun) launch.PipelineRun {
+	f := b.privacyFilter()
+	run.Stages = append([]launch.Stage(nil), run.Stages...)
+	for i := 0; i < len(run.Stages); i++ {
+		if id := run.Stages[i].SessionID; id != "" {
+			run.Stages[i].SessionID = f.Apply(&session.SessionState{ID: id}).ID
+		}
+	}
+	return run
+}
+
+// BroadcastPipeline sends a pipeline run's state.
+func (b *Broadcaster) BroadcastPipeline(run launch.PipelineRun) {
+	msg, err := NewPipelineUpdateMessage(b.maskPipeline(run))
 	if err != nil {
-		slog.Error("broadcast achievement marshal failed", "error", err)
+		slog.Error("broadcast pipeline marshal failed", "error", err)
 		return
 	}
 	b.broadcast(msg)
 }
 
-func (b *Broadcaster) BroadcastBattlePassProgress(payload BattlePassProgressPayload) {
-	msg, err := NewBattlePassProgressMessage(payload)
+// BroadcastSoundCue tells clients to play the sound for cue.
+func (b *Broadcaster) BroadcastSoundCue(cue SoundCue, sessionID string) {
+	msg, err := NewSoundCueMessage(SoundCuePayload{Cue: cue, SessionID: sessionID})
 	if err != nil {
-		slog.Error("broadcast battle pass progress marshal failed", "error", err)
+		slog.Error("broadcast sound cue marshal failed", "error", err)
+		return
+	}
+	b.broadcast(msg)
+}
+
+// BroadcastDirectorFocus sends a director focus hint to all clients.
+func (b *Broadcaster) BroadcastDirectorFocus(payload DirectorFocusPayload) {
+	msg, err := NewDirectorFocusMessage(payload)
+	if err != nil {
+		slog.Error("broadcast director focus marshal failed", "error", err)
+		return
+	}
+	b.broadcast(msg)
+}
+
+// BroadcastCommentary sends a server-generated commentary line to all clients.
+func (b *Broadcaster) BroadcastCommentary(payload CommentaryPayload) {
+	msg, err := NewCommentaryMessage(payload)
+	if err != nil {
+		slog.Error("broadcast commentary marshal failed", "error", err)
 		return
 	}
 	b.broadcast(msg)
 }
 
 func (b *Broadcaster) QueueCompletion(sessionID string, activity session.Activity, name string) {
+	if b.storeFed() {
+		return
+	}
+	b.queueCompletion(sessionID, activity, name)
+}
+
+func (b *Broadcaster) queueCompletion(sessionID string, activity session.Activity, name string) {
 	msg, err := NewCompletionMessage(CompletionPayload{
 		SessionID: sessionID,
 		Activity:  activity,
@@ -226,37 +754,105 @@ func (b *Broadcaster) QueueCompletion(sessionID string, activity session.Activit
 		return
 	}
 	b.broadcast(msg)
+	if activity == session.Complete {
+		b.BroadcastSoundCue(CueFinish, sessionID)
+	} else {
+		b.BroadcastSoundCue(CueError, sessionID)
+	}
 }
 
 func (b *Broadcaster) flush() {
+	b.mu.RLock()
+	clients := len(b.clients)
+	b.mu.RUnlock()
+
+	now := time.Now()
 	b.flushMu.Lock()
 	updates := b.pendingUpdates
 	removed := b.pendingRemoved
 	b.pendingUpdates = nil
 	b.pendingRemoved = nil
 	b.flushTimer = nil
+	b.recordFlushLocked(now)
+	b.retuneLocked(now, clients)
 	b.flushMu.Unlock()
 
 	if len(updates) == 0 && len(removed) == 0 {
 		return
 	}
 
-	filtered := b.privacyFilter().FilterSlice(updates)
-	if len(filtered) == 0 && len(removed) == 0 {
+	pf := b.privacyFilter()
+	all := b.store.GetAll()
+	renames := b.displayNames(pf, all)
+	lang := b.statusLanguage()
+	updates = b.withRenamed(latestByID(updates), all, renames)
+	buf := encodeBuffers.Get().(*encodeBuffer)
+	defer encodeBuffers.Put(buf)
+	if b.changedSince(buf, pf, renames, lang, updates) == 0 && len(removed) == 0 {
 		return
 	}
+	started := b.newStarts(updates, pf)
 
-	allSessions := b.privacyFilter().FilterSlice(b.store.GetAll())
-	msg, err := NewDeltaMessage(DeltaPayload{
-		Updates: filtered,
-		Removed: removed,
-		Teams:   session.ComputeTeams(allSessions),
-	})
-	if err != nil {
-		slog.Error("flush marshal failed", "error", err)
+	allSessions := present(pf, renames, lang, all)
+	buf.err = nil
+	buf.payload = buf.delta(buf.payload[:0], buf.updates, removed, session.ComputeTeams(allSessions))
+	if buf.err != nil {
+		slog.Error("flush marshal failed", "error", buf.err)
 		return
 	}
-	b.broadcast(msg)
+	b.broadcast(WSMessage{Type: MsgDelta, Payload: buf.payload})
+	for _, id := range started {
+		b.BroadcastSoundCue(CueStart, id)
+	}
+}
+
+// startCueWindow limits start cues to sessions that really just began, so
+// sessions found on server startup or after a restart stay quiet.
+const startCueWindow = time.Minute
+
+// withRenamed adds to updates the sessions in all whose display name has
+// changed since the last flush. A second "api" session arriving renames
+// the first, which clients would otherwise keep showing as "api" until it
+// next changes.
+func (b *Broadcaster) withRenamed(updates, all []*session.SessionState, renames map[string]string) []*session.SessionState {
+	b.flushMu.Lock()
+	prev := b.sentNames
+	b.sentNames = renames
+	b.flushMu.Unlock()
+
+	pending := make(map[string]bool, len(updates))
+	for _, s := range updates {
+		pending[s.ID] = true
+	}
+	for _, s := range all {
+		if !pending[s.ID] && renames[s.ID] != prev[s.ID] {
+			updates = append(updates, s)
+		}
+	}
+	return updates
+}
+
+// newStarts returns the client-facing IDs of updates that have not had a
+// start cue yet and began within startCueWindow, and marks every update as
+// seen. Sessions are tracked by their raw ID so removals can forget them.
+func (b *Broadcaster) newStarts(updates []*session.SessionState, pf *session.PrivacyFilter) []string {
+	b.flushMu.Lock()
+	defer b.flushMu.Unlock()
+
+	if b.cued == nil {
+		b.cued = make(map[string]bool)
+	}
+	var ids []string
+	for _, s := range updates {
+		if b.cued[s.ID] {
+			continue
+		}
+		b.cued[s.ID] = true
+		if pf.IsAllowed(s.WorkingDir) && !s.IsTerminal() && time.Since(s.StartedAt) < startCueWindow {
+			ids = append(ids, pf.Apply(s).ID)
+		}
+	}
+	return ids
 }
 
 // SetConfig applies timing changes from a new config. Takes effect on the
@@ -264,6 +860,8 @@ func (b *Broadcaster) flush() {
 func (b *Broadcaster) SetConfig(throttle, snapshotInterval time.Duration) {
 	b.flushMu.Lock()
 	b.throttle = throttle
+	b.auto.effective = 0 // retune from the new throttle
+	b.snapshotInterval = snapshotInterval
 	b.flushMu.Unlock()
 
 	// Drain any pending reset so the latest interval wins, then send.
@@ -279,7 +877,9 @@ func (b *Broadcaster) snapshotLoop() {
 	for {
 		select {
 		case <-ticker.C:
-			b.broadcast(b.snapshotMessage())
+			b.broadcastSnapshot()
+			b.snapshots.Add(1)
+			b.lastSnapshot.Store(time.Now().UnixNano())
 		case d := <-b.snapshotReset:
 			ticker.Stop()
 			ticker = time.NewTicker(d)
@@ -290,10 +890,11 @@ func (b *Broadcaster) snapshotLoop() {
 	}
 }
 
-// snapshotMessage builds a full snapshot WSMessage including sessions, teams,
-// and source health status (when a health hook is registered).
-func (b *Broadcaster) snapshotMessage() WSMessage {
-	allSessions := b.privacyFilter().FilterSlice(b.store.GetAll())
+// snapshotPayload builds a full snapshot including sessions, teams, and
+// source health status (when a health hook is registered).
+func (b *Broadcaster) snapshotPayload() SnapshotPayload {
+	allSessions := b.FilterSessions(b.store.GetAll())
+	session.SortByPosition(allSessions)
 	payload := SnapshotPayload{
 		Sessions: allSessions,
 		Teams:    session.ComputeTeams(allSessions),
@@ -304,20 +905,52 @@ func (b *Broadcaster) snapshotMessage() WSMessage {
 	if hook != nil {
 		payload.SourceHealth = hook()
 	}
-	msg, err := NewSnapshotMessage(payload)
-	if err != nil {
-		slog.Error("snapshot message marshal failed", "error", err)
-		return WSMessage{Type: MsgSnapshot}
+	return payload
+}
+
+// broadcastSnapshot sends every client the current snapshot, framed the
+// way it asked for. All frames share one seq.
+func (b *Broadcaster) broadcastSnapshot() {
+	payload := b.snapshotPayload()
+	seq := b.seq.Add(1)
+	b.broadcasts.Add(1)
+
+	b.mu.RLock()
+	clients := make([]*client, 0, len(b.clients))
+	for c := range b.clients {
+		clients = append(clients, c)
+	}
+	b.mu.RUnlock()
+
+	// A failed encoding is remembered as nil, so the clients sharing its
+	// options skip this snapshot while the others still get it.
+	framed := make(map[SnapshotOptions][][]byte)
+	for _, c := range clients {
+		frames, ok := framed[c.snapshot]
+		if !ok {
+			var err error
+			if frames, err = snapshotFrames(payload, seq, c.snapshot); err != nil {
+				slog.Error("snapshot message marshal failed", "pageSize", c.snapshot.PageSize, "lazySubagents", c.snapshot.LazySubagents, "error", err)
+			}
+			framed[c.snapshot] = frames
+		}
+		if frames == nil {
+			continue
+		}
+		if !c.trySendAll(frames) {
+			slog.Warn("dropping slow ws client")
+			b.droppedClients.Add(1)
+			b.RemoveClient(c)
+		}
 	}
-	return msg
 }
 
 func (b *Broadcaster) broadcast(msg WSMessage) {
 	msg.Seq = b.seq.Add(1)
-	data, err := json.Marshal(msg)
-	if err != nil {
-		slog.Error("broadcast marshal failed", "error", err)
-		return
+	b.broadcasts.Add(1)
+	data := appendMessage(make([]byte, 0, len(msg.Payload)+64), msg)
+	if msg.Type != MsgSnapshot && msg.Type != MsgServerShutdown {
+		b.backlog.add(msg.Seq, time.Now(), data)
 	}
 
 	b.mu.RLock()
@@ -331,6 +964,7 @@ func (b *Broadcaster) broadcast(msg WSMessage) {
 		if !c.trySend(data) {
 			// Client can't keep up or already closed, disconnect it
 			slog.Warn("dropping slow ws client")
+			b.droppedClients.Add(1)
 			b.RemoveClient(c)
 		}
 	}
@@ -338,13 +972,48 @@ func (b *Broadcaster) broadcast(msg WSMessage) {
 
 // SendSnapshot sends a sequenced snapshot to a single client.
 func (b *Broadcaster) SendSnapshot(c *client) {
-	msg := b.snapshotMessage()
-	msg.Seq = b.seq.Add(1)
-	data, err := json.Marshal(msg)
+	frames, err := snapshotFrames(b.snapshotPayload(), b.seq.Add(1), c.snapshot)
 	if err != nil {
 		slog.Error("snapshot marshal failed", "error", err)
 		return
 	}
+	if !c.trySendAll(frames) {
+		// Without its snapshot the client would show nothing until the
+		// next periodic one; make it reconnect instead.
+		slog.Warn("dropping ws client with no room for its snapshot", "client", c.clientID)
+		b.droppedClients.Add(1)
+		b.RemoveClient(c)
+	}
+}
+
+// SetUpdateAvailable announces a newer release to all connected clients
+// and to every client that connects afterwards.
+func (b *Broadcaster) SetUpdateAvailable(payload UpdateAvailablePayload) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		slog.Error("update notice marshal failed", "error", err)
+		return
+	}
+	b.mu.Lock()
+	b.updateNotice = data
+	b.mu.Unlock()
+	b.broadcast(WSMessage{Type: MsgUpdateAvailable, Payload: data})
+}
+
+// sendUpdateNotice sends the pending update_available notice, if any, to c.
+func (b *Broadcaster) sendUpdateNotice(c *client) {
+	b.mu.RLock()
+	payload := b.updateNotice
+	b.mu.RUnlock()
+	if payload == nil {
+		return
+	}
+	msg := WSMessage{Type: MsgUpdateAvailable, Seq: b.seq.Add(1), Payload: payload}
+	data, err := json.Marshal(msg)
+	if err != nil {
+		slog.Error("update notice marshal failed", "error", err)
+		return
+	}
 	c.trySend(data)
 }
 
@@ -353,6 +1022,58 @@ func (b *Broadcaster) BroadcastMessage(msg WSMessage) {
 	b.broadcast(msg)
 }
 
+// Shutdown flushes pending updates, sends every client a server_shutdown
+// message with reason, and closes each connection with a going-away close
+// frame once its queue has drained. New connections are refused from the
+// start. Clients still draining when ctx is done are closed abruptly.
+// Shutdown then stops the broadcaster as Stop does.
+func (b *Broadcaster) Shutdown(ctx context.Context, reason string) {
+	b.mu.Lock()
+	b.shuttingDown = true
+	b.mu.Unlock()
+
+	b.flushMu.Lock()
+	if b.flushTimer != nil {
+		b.flushTimer.Stop()
+	}
+	b.flushMu.Unlock()
+	b.flush()
+	b.flushUnlocks()
+
+	msg, err := NewServerShutdownMessage(ServerShutdownPayload{Reason: reason})
+	if err != nil {
+		slog.Error("shutdown message marshal failed", "error", err)
+	} else {
+		b.broadcast(msg)
+	}
+
+	b.mu.Lock()
+	clients := make([]*client, 0, len(b.clients))
+	for c := range b.clients {
+		clients = append(clients, c)
+	}
+	b.clients = make(map[*client]bool)
+	b.mu.Unlock()
+
+	for i := 0; i < len(clients); i++ {
+		clients[i].closeGracefully(reason)
+	}
+	for i := 0; i < len(clients); i++ {
+		if clients[i].done == nil {
+			continue
+		}
+		select {
+		case <-clients[i].done:
+		case <-ctx.Done():
+			if clients[i].conn != nil {
+				_ = clients[i].conn.Close()
+			}
+		}
+	}
+
+	b.Stop()
+}
+
 // Stop stops the snapshot loop and disconnects all active clients.
 func (b *Broadcaster) Stop() {
 	b.stopOnce.Do(func() {
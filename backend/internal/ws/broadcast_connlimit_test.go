@@ -47,13 +47,14 @@ func dialTestWSPair(t *testing.T) (*httptest.Server, *websocket.Conn, *websocket
 }
 
 // dialTestWS creates a test HTTP server that upgrades to WebSocket and returns
-// the server-side connection. The caller must close both the server and the
-// returned connection.
+// the server-side connection. The client end stays open until the test ends,
+// so writes to the server side succeed. The caller must close both the server
+// and the returned connection.
 func dialTestWS(t *testing.T) (*httptest.Server, *websocket.Conn) {
 	t.Helper()
 
 	srv, clientConn, serverConn := dialTestWSPair(t)
-	_ = clientConn.Close()
+	t.Cleanup(func() { _ = clientConn.Close() })
 	return srv, serverConn
 }
 
@@ -0,0 +1,156 @@
+package ws
+
+import (
+	"sort"
+	"time"
+)
+
+// BroadcasterMetrics is a point-in-time view of the broadcaster's queues
+// and timers, served by /api/debug/broadcaster to diagnose laggy clients.
+type BroadcasterMetrics struct {
+	Seq            uint64 `json:"seq"`
+	Clients        int    `json:"clients"`
+	MaxClients     int    `json:"maxClients"`
+	DroppedClients uint64 `json:"droppedClients"`
+
+	// Delta queue: updates and removals waiting for the throttle timer.
+	PendingUpdates   int        `json:"pendingUpdates"`
+	PendingRemovals  int        `json:"pendingRemovals"`
+	PendingForMs     int64      `json:"pendingForMs"`
+	ThrottleMs       int64      `json:"throttleMs"` // in use, after any auto-tuning
+	Flushes          uint64     `json:"flushes"`
+	LastFlushAt      *time.Time `json:"lastFlushAt,omitempty"`
+	LastFlushDelayMs int64      `json:"lastFlushDelayMs"`
+	MaxFlushDelayMs  int64      `json:"maxFlushDelayMs"`
+	UnchangedSkipped uint64     `json:"unchangedSkipped"` // queued updates not resent because nothing changed
+
+	// Throttle auto-tuning (see Broadcaster.SetAutoThrottle). The throttle
+	// ranges from ThrottleMinMs to ThrottleMaxMs to keep MessagesPerSec,
+	// measured per client, near TargetMessagesPerSec.
+	ThrottleAuto         bool    `json:"throttleAuto"`
+	ThrottleMinMs        int64   `json:"throttleMinMs"`
+	ThrottleMaxMs        int64   `json:"throttleMaxMs"`
+	TargetMessagesPerSec float64 `json:"targetMessagesPerSec"`
+	MessagesPerSec       float64 `json:"messagesPerSec"`
+
+	// Periodic snapshots.
+	SnapshotIntervalMs int64      `json:"snapshotIntervalMs"`
+	Snapshots          uint64     `json:"snapshots"`
+	LastSnapshotAt     *time.Time `json:"lastSnapshotAt,omitempty"`
+	SnapshotOverdue    bool       `json:"snapshotOverdue"`
+
+	ClientStats []ClientMetrics `json:"clientStats"`
+}
+
+// ClientMetrics describes one connected WebSocket client's send queue.
+type ClientMetrics struct {
+	RemoteAddr    string     `json:"remoteAddr,omitempty"`
+	ConnectedAt   time.Time  `json:"connectedAt"`
+	QueueDepth    int        `json:"queueDepth"`
+	QueueCapacity int        `json:"queueCapacity"`
+	Enqueued      uint64     `json:"enqueued"`
+	Written       uint64     `json:"written"`
+	LastWriteAt   *time.Time `json:"lastWriteAt,omitempty"`
+	// LagMs is how long the client has had undelivered messages: the
+	// time since its last successful write (or since it connected) while
+	// its queue is non-empty, and zero when it is caught up.
+	LagMs int64 `json:"lagMs"`
+}
+
+// snapshotOverdueFactor is how many snapshot intervals may pass without a
+// periodic snapshot before it is reported as overdue.
+const snapshotOverdueFactor = 2
+
+// recordFlushLocked updates flush timing. Caller must hold flushMu.
+func (b *Broadcaster) recordFlushLocked(now time.Time) {
+	b.flushes.Add(1)
+	b.lastFlushAt = now
+	if b.pendingSince.IsZero() {
+		return
+	}
+	b.lastFlushDelay = now.Sub(b.pendingSince)
+	if b.lastFlushDelay > b.maxFlushDelay {
+		b.maxFlushDelay = b.lastFlushDelay
+	}
+	b.pendingSince = time.Time{}
+}
+
+// Metrics returns the current queue, throttle and per-client statistics.
+// Clients are ordered by lag, worst first. Safe for concurrent use.
+func (b *Broadcaster) Metrics() BroadcasterMetrics {
+	now := time.Now()
+	m := BroadcasterMetrics{
+		Seq:              b.seq.Load(),
+		DroppedClients:   b.droppedClients.Load(),
+		Flushes:          b.flushes.Load(),
+		UnchangedSkipped: b.unchangedSkipped.Load(),
+		Snapshots:        b.snapshots.Load(),
+	}
+
+	b.flushMu.Lock()
+	m.PendingUpdates = len(b.pendingUpdates)
+	m.PendingRemovals = len(b.pendingRemoved)
+	if !b.pendingSince.IsZero() {
+		m.PendingForMs = now.Sub(b.pendingSince).Milliseconds()
+	}
+	m.ThrottleMs = b.throttleLocked().Milliseconds()
+	m.ThrottleAuto = b.autoEnabledLocked()
+	m.ThrottleMinMs = b.throttle.Milliseconds()
+	m.ThrottleMaxMs = max(b.auto.max, b.throttle).Milliseconds()
+	m.TargetMessagesPerSec = b.auto.targetRate
+	m.MessagesPerSec = b.auto.rate
+	if !b.lastFlushAt.IsZero() {
+		t := b.lastFlushAt
+		m.LastFlushAt = &t
+	}
+	m.LastFlushDelayMs = b.lastFlushDelay.Milliseconds()
+	m.MaxFlushDelayMs = b.maxFlushDelay.Milliseconds()
+	interval := b.snapshotInterval
+	b.flushMu.Unlock()
+
+	m.SnapshotIntervalMs = interval.Milliseconds()
+	lastSnapshot := b.createdAt
+	if ns := b.lastSnapshot.Load(); ns != 0 {
+		t := time.Unix(0, ns)
+		m.LastSnapshotAt = &t
+		lastSnapshot = t
+	}
+	if interval > 0 && !lastSnapshot.IsZero() {
+		m.SnapshotOverdue = now.Sub(lastSnapshot) > snapshotOverdueFactor*interval
+	}
+
+	b.mu.RLock()
+	m.Clients = len(b.clients)
+	m.MaxClients = b.maxConns
+	m.ClientStats = make([]ClientMetrics, 0, len(b.clients))
+	for c := range b.clients {
+		m.ClientStats = append(m.ClientStats, c.metrics(now))
+	}
+	b.mu.RUnlock()
+
+	sort.Slice(m.ClientStats, func(i, j int) bool {
+		return m.ClientStats[i].LagMs > m.ClientStats[j].LagMs
+	})
+	return m
+}
+
+func (c *client) metrics(now time.Time) ClientMetrics {
+	cm := ClientMetrics{
+		RemoteAddr:    c.remoteAddr,
+		ConnectedAt:   c.connectedAt,
+		QueueDepth:    len(c.send),
+		QueueCapacity: cap(c.send),
+		Enqueued:      c.enqueued.Load(),
+		Written:       c.written.Load(),
+	}
+	since := c.connectedAt
+	if ns := c.lastWrite.Load(); ns != 0 {
+		t := time.Unix(0, ns)
+		cm.LastWriteAt = &t
+		since = t
+	}
+	if cm.Enqueued > cm.Written && !since.IsZero() {
+		cm.LagMs = now.Sub(since).Milliseconds()
+	}
+	return cm
+}
@@ -0,0 +1,85 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/agent-racer/backend/internal/session"
+	"github.com/gorilla/websocket"
+)
+
+// TestShutdown_NotifiesAndClosesClients verifies that Shutdown delivers a
+// server_shutdown message followed by a going-away close frame.
+func TestShutdown_NotifiesAndClosesClients(t *testing.T) {
+	store := session.NewStore()
+	b := NewBroadcaster(store, time.Hour, time.Hour, 0)
+
+	srv, clientConn, serverConn := dialTestWSPair(t)
+	defer srv.Close()
+	defer func() { _ = clientConn.Close() }()
+
+	if _, err := b.AddClient(serverConn); err != nil {
+		t.Fatalf("AddClient: %v", err)
+	}
+	b.QueueUpdate([]*session.SessionState{{ID: "pending"}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	b.Shutdown(ctx, "restarting for upgrade")
+
+	_ = clientConn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var types []MessageType
+	var shutdown ServerShutdownPayload
+	var closeErr *websocket.CloseError
+	for {
+		_, data, err := clientConn.ReadMessage()
+		if err != nil {
+			if !errors.As(err, &closeErr) {
+				t.Fatalf("read: %v", err)
+			}
+			break
+		}
+		var msg WSMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			t.Fatalf("unmarshal: %v", err)
+		}
+		types = append(types, msg.Type)
+		if msg.Type == MsgServerShutdown {
+			_ = json.Unmarshal(msg.Payload, &shutdown)
+		}
+	}
+
+	want := []MessageType{MsgSnapshot, MsgPresence, MsgDelta, MsgServerShutdown}
+	if len(types) != len(want) {
+		t.Fatalf("messages = %v, want %v", types, want)
+	}
+	for i := 0; i < len(want); i++ {
+		if types[i] != want[i] {
+			t.Fatalf("messages = %v, want %v", types, want)
+		}
+	}
+	if shutdown.Reason != "restarting for upgrade" {
+		t.Errorf("shutdown reason = %q", shutdown.Reason)
+	}
+	if closeErr.Code != websocket.CloseGoingAway || closeErr.Text != "restarting for upgrade" {
+		t.Errorf("close = %d %q, want %d with reason", closeErr.Code, closeErr.Text, websocket.CloseGoingAway)
+	}
+	if got := b.ClientCount(); got != 0 {
+		t.Errorf("ClientCount after Shutdown = %d, want 0", got)
+	}
+}
+
+func TestShutdown_RejectsNewClients(t *testing.T) {
+	store := session.NewStore()
+	b := NewBroadcaster(store, time.Hour, time.Hour, 0)
+	b.Shutdown(context.Background(), "bye")
+
+	srv, conn := dialTestWS(t)
+	defer srv.Close()
+	if _, err := b.AddClient(conn); !errors.Is(err, ErrShuttingDown) {
+		t.Fatalf("AddClient after Shutdown: err = %v, want ErrShuttingDown", err)
+	}
+}
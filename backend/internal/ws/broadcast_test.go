@@ -1,9 +1,11 @@
 package ws
 
 import (
+	"encoding/json"
 	"testing"
 	"time"
 
+	"github.com/agent-racer/backend/internal/gamification"
 	"github.com/agent-racer/backend/internal/session"
 )
 
@@ -297,3 +299,488 @@ func TestBroadcaster_SequenceNumberIncrement(t *testing.T) {
 		}
 	}
 }
+
+func TestMetrics_ClientLagAndFlushDelay(t *testing.T) {
+	b := newTestBroadcaster(session.NewStore(), nil)
+	b.throttle = time.Hour
+
+	caughtUp := makeClient(b)
+	lagging := makeClient(b)
+	lagging.connectedAt = time.Now().Add(-time.Minute)
+
+	b.QueueUpdate([]*session.SessionState{{ID: "a"}})
+	b.flushMu.Lock()
+	b.pendingSince = time.Now().Add(-50 * time.Millisecond)
+	b.flushTimer.Stop()
+	b.flushMu.Unlock()
+	b.flush() // broadcasts one delta to both clients
+
+	<-caughtUp.send
+	caughtUp.written.Add(1)
+	caughtUp.lastWrite.Store(time.Now().UnixNano())
+
+	m := b.Metrics()
+	if m.Clients != 2 || len(m.ClientStats) != 2 {
+		t.Fatalf("Clients = %d, ClientStats = %d, want 2", m.Clients, len(m.ClientStats))
+	}
+	worst := m.ClientStats[0]
+	if worst.QueueDepth != 1 || worst.LagMs < time.Minute.Milliseconds() {
+		t.Errorf("lagging client = %+v, want queue depth 1 and lag >= 1m", worst)
+	}
+	if m.ClientStats[1].LagMs != 0 {
+		t.Errorf("caught-up client LagMs = %d, want 0", m.ClientStats[1].LagMs)
+	}
+	if m.Flushes != 1 || m.LastFlushDelayMs < 50 || m.PendingUpdates != 0 {
+		t.Errorf("flush metrics = %d flushes, %dms delay, %d pending; want 1, >=50ms, 0",
+			m.Flushes, m.LastFlushDelayMs, m.PendingUpdates)
+	}
+}
+
+func TestSetUpdateAvailable_ReachesCurrentAndLaterClients(t *testing.T) {
+	b := newTestBroadcaster(session.NewStore(), nil)
+	existing := makeClient(b)
+
+	b.SetUpdateAvailable(UpdateAvailablePayload{Current: "v1.0.0", Latest: "v1.1.0", URL: "https://example.com"})
+
+	decode := func(data []byte) UpdateAvailablePayload {
+		t.Helper()
+		var msg WSMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			t.Fatalf("unmarshal: %v", err)
+		}
+		if msg.Type != MsgUpdateAvailable {
+			t.Fatalf("type = %s, want %s", msg.Type, MsgUpdateAvailable)
+		}
+		var p UpdateAvailablePayload
+		if err := json.Unmarshal(msg.Payload, &p); err != nil {
+			t.Fatalf("unmarshal payload: %v", err)
+		}
+		return p
+	}
+
+	if p := decode(<-existing.send); p.Latest != "v1.1.0" {
+		t.Errorf("existing client got %+v", p)
+	}
+
+	later := makeClient(b)
+	b.sendUpdateNotice(later)
+	if p := decode(<-later.send); p.Latest != "v1.1.0" || p.Current != "v1.0.0" {
+		t.Errorf("later client got %+v", p)
+	}
+}
+
+// drainTypes reads every queued message for c and returns their types, plus
+// the sound cues among them.
+func drainTypes(t *testing.T, c *client) ([]MessageType, []SoundCuePayload) {
+	t.Helper()
+	var types []MessageType
+	var cues []SoundCuePayload
+	for {
+		select {
+		case data := <-c.send:
+			var msg WSMessage
+			if err := json.Unmarshal(data, &msg); err != nil {
+				t.Fatalf("unmarshal: %v", err)
+			}
+			types = append(types, msg.Type)
+			if msg.Type == MsgSoundCue {
+				var p SoundCuePayload
+				if err := json.Unmarshal(msg.Payload, &p); err != nil {
+					t.Fatalf("unmarshal cue: %v", err)
+				}
+				cues = append(cues, p)
+			}
+		default:
+			return types, cues
+		}
+	}
+}
+
+func TestSoundCues(t *testing.T) {
+	b := newTestBroadcaster(session.NewStore(), nil)
+	b.throttle = time.Hour
+	c := makeClient(b)
+
+	flush := func(states ...*session.SessionState) {
+		b.QueueUpdate(states)
+		b.flushMu.Lock()
+		b.flushTimer.Stop()
+		b.flushMu.Unlock()
+		b.flush()
+	}
+
+	fresh := &session.SessionState{ID: "fresh", Activity: session.Thinking, StartedAt: time.Now()}
+	old := &session.SessionState{ID: "old", Activity: session.Thinking, StartedAt: time.Now().Add(-time.Hour)}
+	flush(fresh, old)
+	flush(fresh, old) // already cued
+	b.BroadcastOvertake(session.Overtake{OvertakerID: "fresh", OvertakenID: "old", NewPosition: 1})
+	b.QueueCompletion("fresh", session.Complete, "fresh")
+	b.QueueCompletion("old", session.Errored, "old")
+	b.BroadcastApprovalNeeded(&session.SessionState{ID: "fresh", Activity: session.NeedsApproval}, time.Now())
+	b.BroadcastAchievement(AchievementUnlockedPayload{ID: "first_lap"})
+	b.flushUnlocks()
+
+	types, cues := drainTypes(t, c)
+	want := []SoundCuePayload{
+		{Cue: CueStart, SessionID: "fresh"},
+		{Cue: CueOvertake, SessionID: "fresh"},
+		{Cue: CueFinish, SessionID: "fresh"},
+		{Cue: CueError, SessionID: "old"},
+		{Cue: CueApproval, SessionID: "fresh"},
+		{Cue: CueAchievement},
+	}
+	if len(cues) != len(want) {
+		t.Fatalf("cues = %+v, want %+v (messages %v)", cues, want, types)
+	}
+	for i := 0; i < len(want); i++ {
+		if cues[i] != want[i] {
+			t.Errorf("cue %d = %+v, want %+v", i, cues[i], want[i])
+		}
+	}
+	if types[0] != MsgDelta || types[1] != MsgSoundCue {
+		t.Errorf("start cue should follow the delta that introduces the session, got %v", types)
+	}
+}
+
+func TestBroadcastAchievementBatchesBursts(t *testing.T) {
+	b := newTestBroadcaster(session.NewStore(), nil)
+	c := makeClient(b)
+
+	b.BroadcastAchievement(AchievementUnlockedPayload{ID: "first_lap", Tier: "bronze"})
+	b.BroadcastBattlePassProgress(BattlePassProgressPayload{XP: 110, Tier: 1, RecentXP: []gamification.XPEntry{{Reason: "session_observed", Amount: 10}}})
+	b.BroadcastAchievement(AchievementUnlockedPayload{ID: "pit_crew", Tier: "bronze"})
+	b.BroadcastAchievement(AchievementUnlockedPayload{ID: "redline", Tier: "silver"})
+	b.BroadcastBattlePassProgress(BattlePassProgressPayload{XP: 300, Tier: 2, Rewards: []string{"paint_red"}, RecentXP: []gamification.XPEntry{{Reason: "achievement", Amount: 190}}})
+	if types, _ := drainTypes(t, c); len(types) != 0 {
+		t.Fatalf("sent %v before the window closed", types)
+	}
+	b.flushUnlocks()
+
+	var msgs []WSMessage
+	for len(c.send) > 0 {
+		var msg WSMessage
+		if err := json.Unmarshal(<-c.send, &msg); err != nil {
+			t.Fatalf("unmarshal: %v", err)
+		}
+		msgs = append(msgs, msg)
+	}
+	if len(msgs) != 3 || msgs[0].Type != MsgAchievementBatch || msgs[1].Type != MsgSoundCue || msgs[2].Type != MsgBattlePassProgress {
+		t.Fatalf("messages = %+v, want one batch, its cue and one progress update", msgs)
+	}
+	var batch AchievementBatchPayload
+	if err := json.Unmarshal(msgs[0].Payload, &batch); err != nil {
+		t.Fatal(err)
+	}
+	if len(batch.Achievements) != 3 || batch.Achievements[2].ID != "redline" || batch.ByTier["bronze"] != 2 || batch.ByTier["silver"] != 1 {
+		t.Errorf("batch = %+v", batch)
+	}
+	var progress BattlePassProgressPayload
+	if err := json.Unmarshal(msgs[2].Payload, &progress); err != nil {
+		t.Fatal(err)
+	}
+	if progress.XP != 300 || progress.Tier != 2 || len(progress.RecentXP) != 2 || len(progress.Rewards) != 1 {
+		t.Errorf("progress = %+v, want the latest totals with every award", progress)
+	}
+
+	// A lone unlock still goes out as achievement_unlocked.
+	b.BroadcastAchievement(AchievementUnlockedPayload{ID: "marathon"})
+	b.flushUnlocks()
+	if types, _ := drainTypes(t, c); len(types) != 2 || types[0] != MsgAchievementUnlocked {
+		t.Errorf("lone unlock sent %v, want achievement_unlocked and its cue", types)
+	}
+}
+
+func TestBroadcastSubagentEvents(t *testing.T) {
+	b := newTestBroadcaster(session.NewStore(), &session.PrivacyFilter{
+		BlockedPaths:   []string{"/secret/*"},
+		MaskSessionIDs: true,
+	})
+	c := makeClient(b)
+
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	done := start.Add(time.Minute)
+	sub := session.SubagentState{ID: "toolu_1", ParentToolUseID: "toolu_0", Slug: "quick-fox", TokensUsed: 900, StartedAt: start}
+	shown := &session.SessionState{ID: "shown", Name: "api", WorkingDir: "/home/u/api"}
+	b.BroadcastSubagentStarted(&session.SessionState{ID: "hidden", WorkingDir: "/secret/x"}, sub)
+	b.BroadcastSubagentStarted(shown, sub)
+	sub.CompletedAt = &done
+	b.BroadcastSubagentCompleted(shown, sub)
+
+	var got []WSMessage
+	for len(c.send) > 0 {
+		var msg WSMessage
+		if err := json.Unmarshal(<-c.send, &msg); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, msg)
+	}
+	if len(got) != 2 || got[0].Type != MsgSubagentStarted || got[1].Type != MsgSubagentCompleted {
+		t.Fatalf("messages = %+v, want subagent_started and subagent_completed for the shown session", got)
+	}
+	var p SubagentPayload
+	if err := json.Unmarshal(got[0].Payload, &p); err != nil {
+		t.Fatal(err)
+	}
+	if p.SessionID == "shown" || p.SessionID == "" {
+		t.Errorf("SessionID = %q, want it masked", p.SessionID)
+	}
+	if p.Name != "api" || p.SubagentID != "toolu_1" || p.ParentToolUseID != "toolu_0" || p.TokensUsed != 900 || !p.At.Equal(start) {
+		t.Errorf("started payload = %+v", p)
+	}
+	if err := json.Unmarshal(got[1].Payload, &p); err != nil {
+		t.Fatal(err)
+	}
+	if !p.At.Equal(done) {
+		t.Errorf("completed At = %v, want %v", p.At, done)
+	}
+}
+
+func TestBroadcastSubagentBudget(t *testing.T) {
+	b := newTestBroadcaster(session.NewStore(), &session.PrivacyFilter{BlockedPaths: []string{"/secret/*"}})
+	c := makeClient(b)
+
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	sub := session.SubagentState{ID: "toolu_1", Slug: "deep-dig", TokensUsed: 120000}
+	b.BroadcastSubagentBudget(&session.SessionState{ID: "hidden", WorkingDir: "/secret/x", MaxContextTokens: 200000}, sub, 100000, at)
+	b.BroadcastSubagentBudget(&session.SessionState{ID: "shown", Name: "api", WorkingDir: "/home/u/api", MaxContextTokens: 200000}, sub, 100000, at)
+
+	if len(c.send) != 1 {
+		t.Fatalf("got %d messages, want 1 for the shown session", len(c.send))
+	}
+	var msg WSMessage
+	if err := json.Unmarshal(<-c.send, &msg); err != nil {
+		t.Fatal(err)
+	}
+	var p SubagentBudgetPayload
+	if err := json.Unmarshal(msg.Payload, &p); err != nil {
+		t.Fatal(err)
+	}
+	if msg.Type != MsgSubagentBudget || p.SessionID != "shown" || p.SubagentID != "toolu_1" ||
+		p.TokensUsed != 120000 || p.Budget != 100000 || p.ContextShare != 0.6 || !p.At.Equal(at) {
+		t.Errorf("message = %s %+v", msg.Type, p)
+	}
+}
+
+func TestSoundCues_StartRespectsPrivacy(t *testing.T) {
+	b := newTestBroadcaster(session.NewStore(), &session.PrivacyFilter{
+		BlockedPaths:   []string{"/secret/*"},
+		MaskSessionIDs: true,
+	})
+	b.throttle = time.Hour
+	c := makeClient(b)
+
+	b.QueueUpdate([]*session.SessionState{
+		{ID: "hidden", WorkingDir: "/secret/x", StartedAt: time.Now()},
+		{ID: "shown", WorkingDir: "/home/u/p", StartedAt: time.Now()},
+	})
+	b.flushMu.Lock()
+	b.flushTimer.Stop()
+	b.flushMu.Unlock()
+	b.flush()
+
+	_, cues := drainTypes(t, c)
+	if len(cues) != 1 {
+		t.Fatalf("cues = %+v, want one start cue", cues)
+	}
+	if cues[0].SessionID == "shown" || cues[0].SessionID == "" {
+		t.Errorf("start cue ID = %q, want the masked ID", cues[0].SessionID)
+	}
+
+	// Removing a session forgets it, so a new session reusing the ID is cued.
+	b.QueueRemoval([]string{"shown"})
+	b.flushMu.Lock()
+	_, tracked := b.cued["shown"]
+	b.flushMu.Unlock()
+	if tracked {
+		t.Error("removed session still tracked")
+	}
+}
+
+func TestBroadcaster_FedFromStoreEventLog(t *testing.T) {
+	store := session.NewStore()
+	store.SetEventLog(64)
+	b := NewBroadcaster(store, time.Hour, time.Hour, 0)
+	defer b.Stop()
+	c := makeClient(b)
+
+	flush := func() {
+		b.flushMu.Lock()
+		if b.flushTimer != nil {
+			b.flushTimer.Stop()
+		}
+		b.flushMu.Unlock()
+		b.flush()
+	}
+
+	running := &session.SessionState{ID: "a", Name: "a", Activity: session.Thinking}
+	store.UpdateAndNotify(running, func() {
+		b.QueueUpdate([]*session.SessionState{running}) // ignored: the store feeds the broadcaster
+	})
+	b.flushMu.Lock()
+	pending := len(b.pendingUpdates)
+	b.flushMu.Unlock()
+	if pending != 1 {
+		t.Fatalf("pending updates = %d, want 1", pending)
+	}
+	flush()
+
+	store.Update(&session.SessionState{ID: "a", Name: "a", Activity: session.Complete})
+	b.QueueCompletion("a", session.Complete, "a") // ignored
+	flush()
+	store.Remove("a")
+	flush()
+
+	types, _ := drainTypes(t, c)
+	completions, deltas := 0, 0
+	for _, typ := range types {
+		switch typ {
+		case MsgCompletion:
+			completions++
+		case MsgDelta:
+			deltas++
+		}
+	}
+	if completions != 1 || deltas != 3 {
+		t.Errorf("messages = %v, want 3 deltas and 1 completion", types)
+	}
+}
+
+func TestFlush_DisambiguatesSameNamedProjects(t *testing.T) {
+	store := session.NewStore()
+	b := newTestBroadcaster(store, nil)
+	b.throttle = time.Hour
+	c := makeClient(b)
+
+	flush := func(states ...*session.SessionState) map[string]string {
+		t.Helper()
+		for _, s := range states {
+			store.Update(s)
+		}
+		b.QueueUpdate(states)
+		b.flushMu.Lock()
+		b.flushTimer.Stop()
+		b.flushMu.Unlock()
+		b.flush()
+
+		var msg WSMessage
+		if err := json.Unmarshal(<-c.send, &msg); err != nil {
+			t.Fatalf("unmarshal: %v", err)
+		}
+		var p DeltaPayload
+		if err := json.Unmarshal(msg.Payload, &p); err != nil {
+			t.Fatalf("unmarshal delta: %v", err)
+		}
+		got := make(map[string]string)
+		for _, s := range p.Updates {
+			got[s.ID] = s.Name
+		}
+		return got
+	}
+
+	billing := &session.SessionState{ID: "a", Name: "api", WorkingDir: "/work/billing/api"}
+	if got := flush(billing); got["a"] != "api" {
+		t.Errorf("lone session named %q, want api", got["a"])
+	}
+
+	search := &session.SessionState{ID: "b", Name: "api", WorkingDir: "/work/search/api"}
+	got := flush(search)
+	if got["a"] != "api (billing)" || got["b"] != "api (search)" {
+		t.Errorf("delta names = %v, want both sessions disambiguated", got)
+	}
+	if st, _ := store.Get("a"); st.Name != "api" {
+		t.Errorf("store name = %q, disambiguation must not rewrite the store", st.Name)
+	}
+}
+
+func TestFlush_SkipsUnchangedSessions(t *testing.T) {
+	store := session.NewStore()
+	b := newTestBroadcaster(store, nil)
+	b.throttle = time.Hour
+	c := makeClient(b)
+
+	// flush queues states and returns the IDs and tokens of the delta
+	// sent, or nil when nothing went out.
+	flush := func(states ...*session.SessionState) map[string]int {
+		t.Helper()
+		for _, s := range states {
+			store.Update(s)
+		}
+		b.QueueUpdate(states)
+		b.flushMu.Lock()
+		b.flushTimer.Stop()
+		b.flushMu.Unlock()
+		b.flush()
+
+		select {
+		case data := <-c.send:
+			var msg WSMessage
+			if err := json.Unmarshal(data, &msg); err != nil {
+				t.Fatalf("unmarshal: %v", err)
+			}
+			var p DeltaPayload
+			if err := json.Unmarshal(msg.Payload, &p); err != nil {
+				t.Fatalf("unmarshal delta: %v", err)
+			}
+			got := make(map[string]int)
+			for _, s := range p.Updates {
+				got[s.ID] = s.TokensUsed
+			}
+			return got
+		default:
+			return nil
+		}
+	}
+
+	a := func(tokens int) *session.SessionState {
+		return &session.SessionState{ID: "a", Name: "api", WorkingDir: "/work/api", TokensUsed: tokens}
+	}
+	web := &session.SessionState{ID: "b", Name: "web", WorkingDir: "/work/web", TokensUsed: 10}
+	if got := flush(a(100), web); len(got) != 2 {
+		t.Fatalf("first delta = %v, want both sessions", got)
+	}
+
+	if got := flush(a(100), web); got != nil {
+		t.Errorf("delta of unchanged sessions = %v, want nothing sent", got)
+	}
+	if got := flush(a(150), web); len(got) != 1 || got["a"] != 150 {
+		t.Errorf("delta = %v, want only the session that changed", got)
+	}
+	if got := flush(a(200), a(250)); len(got) != 1 || got["a"] != 250 {
+		t.Errorf("delta = %v, want one update with the latest state", got)
+	}
+	if skipped := b.Metrics().UnchangedSkipped; skipped != 3 {
+		t.Errorf("unchangedSkipped = %d, want 3", skipped)
+	}
+
+	// A removed session starts over when it comes back.
+	b.QueueRemoval([]string{"b"})
+	b.flushMu.Lock()
+	b.flushTimer.Stop()
+	b.flushMu.Unlock()
+	b.flush()
+	<-c.send
+	if got := flush(web); len(got) != 1 {
+		t.Errorf("delta after removal = %v, want the returning session sent", got)
+	}
+}
+
+func TestFilterSessions_DescribesStatusInLanguage(t *testing.T) {
+	store := session.NewStore()
+	b := newTestBroadcaster(store, nil)
+	state := &session.SessionState{ID: "a", Name: "api", Activity: session.NeedsApproval, CurrentTool: "Bash", LastActivityAt: time.Now()}
+	store.Update(state)
+
+	got := b.FilterSessions([]*session.SessionState{state})
+	if len(got) != 1 || got[0].Severity != session.SeverityWarning || got[0].StatusText != "Session api needs your approval for Bash, 0 seconds" {
+		t.Fatalf("FilterSessions = %+v, want a warning with english status text", got)
+	}
+
+	b.SetLanguage("de")
+	got = b.FilterSessions([]*session.SessionState{state})
+	if want := "Sitzung api braucht deine Freigabe für Bash, seit 0 Sekunden"; got[0].StatusText != want {
+		t.Errorf("StatusText = %q, want %q", got[0].StatusText, want)
+	}
+	if state.StatusText != "" {
+		t.Error("FilterSessions wrote status text into the stored session")
+	}
+}
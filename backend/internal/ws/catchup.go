@@ -0,0 +1,232 @@
+package ws
+
+import (
+	"encoding/json"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	// maxBacklogEvents bounds each client's backlog however long the
+	// window is.
+	maxBacklogEvents = 5000
+	// maxBacklogClients bounds how many client identities are buffered
+	// for; past it the one gone longest is forgotten.
+	maxBacklogClients = 64
+	// maxCatchUpEvents and maxCatchUpBytes bound one catch_up message; a
+	// client that missed more gets the most recent events, complete=false
+	// and the number left out in truncated.
+	maxCatchUpEvents = 1000
+	maxCatchUpBytes  = 512 << 10
+)
+
+// CatchUpPayload carries the broadcasts a reconnecting client missed.
+type CatchUpPayload struct {
+	Since uint64 `json:"since"` // last seq the client had seen
+	// Complete is false when some of the missed events are not in Events;
+	// the snapshot that follows still brings the client up to date.
+	Complete bool              `json:"complete"`
+	Events   []json.RawMessage `json:"events"` // full messages, oldest first
+	// Truncated counts missed events that were still buffered but left
+	// out to keep the message under its caps.
+	Truncated int `json:"truncated,omitempty"`
+}
+
+type backlogEntry struct {
+	seq  uint64
+	at   time.Time
+	data []byte
+}
+
+// clientBacklog is what one client identity may still need replayed.
+type clientBacklog struct {
+	entries []backlogEntry
+	// dropped is the highest seq trimmed from entries, or the last seq
+	// broadcast before the identity was first seen.
+	dropped uint64
+	conns   int       // open connections with this identity
+	left    time.Time // when the last of them closed
+}
+
+// backlog keeps recent broadcasts for each client identity that has
+// connected within the window, so clients returning from sleep can replay
+// what they missed. Entries share their data across identities. Snapshots
+// are not kept: a fresh one follows every catch-up.
+type backlog struct {
+	mu      sync.Mutex
+	window  time.Duration // 0 keeps nothing
+	clients map[string]*clientBacklog
+}
+
+func (l *backlog) enabled() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.window > 0
+}
+
+func (l *backlog) setWindow(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.window = d
+	if d <= 0 {
+		l.clients = nil
+		return
+	}
+	l.trimLocked(time.Now())
+}
+
+// connect starts or resumes buffering for id. seq is the last seq
+// broadcast, so a new identity is not replayed anything from before it
+// was first seen.
+func (l *backlog) connect(id string, seq uint64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if id == "" || l.window <= 0 {
+		return
+	}
+	cb, ok := l.clients[id]
+	if !ok {
+		if len(l.clients) >= maxBacklogClients && !l.evictLocked() {
+			return
+		}
+		if l.clients == nil {
+			l.clients = make(map[string]*clientBacklog)
+		}
+		cb = &clientBacklog{dropped: seq}
+		l.clients[id] = cb
+	}
+	cb.conns++
+}
+
+// disconnect notes that a connection with id closed at now. Its backlog
+// is kept for the window in case it comes back.
+func (l *backlog) disconnect(id string, now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	cb, ok := l.clients[id]
+	if !ok || cb.conns == 0 {
+		return
+	}
+	cb.conns--
+	if cb.conns == 0 {
+		cb.left = now
+	}
+}
+
+// evictLocked forgets the identity that has been gone longest, reporting
+// false when every identity is connected. Caller must hold l.mu.
+func (l *backlog) evictLocked() bool {
+	var oldest string
+	var oldestLeft time.Time
+	for id, cb := range l.clients {
+		if cb.conns == 0 && (oldest == "" || cb.left.Before(oldestLeft)) {
+			oldest, oldestLeft = id, cb.left
+		}
+	}
+	if oldest == "" {
+		return false
+	}
+	delete(l.clients, oldest)
+	return true
+}
+
+func (l *backlog) add(seq uint64, at time.Time, data []byte) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.window <= 0 {
+		return
+	}
+	e := backlogEntry{seq: seq, at: at, data: data}
+	for _, cb := range l.clients {
+		cb.entries = append(cb.entries, e)
+	}
+	l.trimLocked(at)
+}
+
+// trimLocked drops entries older than the window or beyond the cap, and
+// forgets identities gone for longer than the window. Caller must hold
+// l.mu.
+func (l *backlog) trimLocked(now time.Time) {
+	for id, cb := range l.clients {
+		if cb.conns == 0 && now.Sub(cb.left) > l.window {
+			delete(l.clients, id)
+			continue
+		}
+		n := 0
+		for n < len(cb.entries) && (len(cb.entries)-n > maxBacklogEvents || now.Sub(cb.entries[n].at) > l.window) {
+			cb.dropped = max(cb.dropped, cb.entries[n].seq)
+			n++
+		}
+		if n > 0 {
+			cb.entries = append([]backlogEntry(nil), cb.entries[n:]...)
+		}
+	}
+}
+
+// since returns the broadcasts buffered for id after seq, oldest first,
+// whether nothing in between has been lost, and how many were left out
+// to fit the catch-up caps.
+func (l *backlog) since(id string, seq uint64, now time.Time) ([]json.RawMessage, bool, int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.trimLocked(now)
+	cb, ok := l.clients[id]
+	if !ok {
+		return nil, false, 0
+	}
+	complete := seq >= cb.dropped
+	// Walk back from the newest so the caps keep the latest events.
+	var events []json.RawMessage
+	size, truncated := 0, 0
+	for i := len(cb.entries) - 1; i >= 0; i-- {
+		e := cb.entries[i]
+		if e.seq <= seq {
+			continue
+		}
+		if truncated > 0 || len(events) == maxCatchUpEvents || size+len(e.data) > maxCatchUpBytes {
+			truncated++
+			continue
+		}
+		events = append(events, e.data)
+		size += len(e.data)
+	}
+	if truncated > 0 {
+		complete = false
+	}
+	slices.Reverse(events)
+	return events, complete, truncated
+}
+
+// SetCatchUpWindow sets how long broadcasts are kept for reconnecting
+// clients. 0 disables store-and-forward and frees the backlog.
+func (b *Broadcaster) SetCatchUpWindow(d time.Duration) {
+	b.backlog.setWindow(d)
+}
+
+// sendCatchUp sends c the broadcasts clientID missed after since. Nothing
+// is sent when store-and-forward is off, or for a since the server never
+// reached (a client from before a restart).
+func (b *Broadcaster) sendCatchUp(c *client, clientID string, since uint64) {
+	if since == 0 || since > b.seq.Load() || !b.backlog.enabled() {
+		return
+	}
+	events, complete, truncated := b.backlog.since(clientID, since, time.Now())
+	if truncated > 0 {
+		slog.Warn("catch-up truncated", "client", clientID, "since", since, "sent", len(events), "truncated", truncated)
+	}
+	msg, err := newMessage(MsgCatchUp, CatchUpPayload{Since: since, Complete: complete, Events: events, Truncated: truncated})
+	if err != nil {
+		slog.Error("catch-up marshal failed", "error", err)
+		return
+	}
+	msg.Seq = b.seq.Add(1)
+	data, err := json.Marshal(msg)
+	if err != nil {
+		slog.Error("catch-up marshal failed", "error", err)
+		return
+	}
+	slog.Info("websocket client catching up", "client", clientID, "since", since, "events", len(events), "complete", complete)
+	c.trySend(data)
+}
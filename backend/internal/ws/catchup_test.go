@@ -0,0 +1,194 @@
+package ws
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/agent-racer/backend/internal/session"
+)
+
+func TestBacklog_SinceAndTrim(t *testing.T) {
+	var l backlog
+	l.setWindow(time.Minute)
+	l.connect("tui@laptop", 0)
+	t0 := time.Now()
+	for i := 1; i <= 5; i++ {
+		l.add(uint64(i), t0.Add(time.Duration(i)*time.Second), []byte{byte('0' + i)})
+	}
+
+	events, complete, _ := l.since("tui@laptop", 2, t0.Add(10*time.Second))
+	if len(events) != 3 || string(events[0]) != "3" || string(events[2]) != "5" || !complete {
+		t.Fatalf("since(2) = %q complete=%v, want [3 4 5] complete", events, complete)
+	}
+
+	// Entries 1-3 fall out of the window.
+	events, complete, _ = l.since("tui@laptop", 1, t0.Add(63500*time.Millisecond))
+	if len(events) != 2 || string(events[0]) != "4" || complete {
+		t.Fatalf("since(1) after trim = %q complete=%v, want [4 5] incomplete", events, complete)
+	}
+	if events, complete, _ = l.since("tui@laptop", 3, t0.Add(63500*time.Millisecond)); len(events) != 2 || !complete {
+		t.Fatalf("since(3) after trim = %q complete=%v, want [4 5] complete", events, complete)
+	}
+
+	l.setWindow(0)
+	if events, _, _ := l.since("tui@laptop", 0, time.Now()); len(events) != 0 {
+		t.Fatalf("disabled backlog returned %d events", len(events))
+	}
+	l.connect("tui@laptop", 5)
+	l.add(6, time.Now(), []byte("6"))
+	if len(l.clients) != 0 {
+		t.Fatal("disabled backlog kept a client")
+	}
+}
+
+func TestBacklog_KeyedByClient(t *testing.T) {
+	var l backlog
+	l.setWindow(time.Minute)
+	now := time.Now()
+	l.connect("early", 0)
+	l.add(1, now, []byte("1"))
+	l.connect("late", 1)
+	l.add(2, now, []byte("2"))
+
+	if events, complete, _ := l.since("early", 0, now); len(events) != 2 || !complete {
+		t.Fatalf("early since(0) = %q complete=%v, want [1 2] complete", events, complete)
+	}
+	// late was not around for 1, so it cannot be told it saw everything.
+	if events, complete, _ := l.since("late", 0, now); len(events) != 1 || string(events[0]) != "2" || complete {
+		t.Fatalf("late since(0) = %q complete=%v, want [2] incomplete", events, complete)
+	}
+	if events, complete, _ := l.since("stranger", 1, now); len(events) != 0 || complete {
+		t.Fatalf("unknown client got %q complete=%v, want nothing incomplete", events, complete)
+	}
+
+	// A client gone longer than the window is forgotten.
+	l.disconnect("late", now)
+	l.add(3, now.Add(2*time.Minute), []byte("3"))
+	if _, ok := l.clients["late"]; ok {
+		t.Error("client gone past the window is still buffered")
+	}
+	if _, ok := l.clients["early"]; !ok {
+		t.Error("connected client was forgotten")
+	}
+}
+
+func TestBacklog_ForgetsLongestGoneClientAtCap(t *testing.T) {
+	var l backlog
+	l.setWindow(time.Hour)
+	now := time.Now()
+	for i := 0; i < maxBacklogClients; i++ {
+		id := fmt.Sprintf("c%d", i)
+		l.connect(id, 0)
+		l.disconnect(id, now.Add(time.Duration(i)*time.Second))
+	}
+	l.connect("new", 0)
+	if _, ok := l.clients["c0"]; ok {
+		t.Error("longest-gone client kept past the cap")
+	}
+	if _, ok := l.clients["new"]; !ok || len(l.clients) != maxBacklogClients {
+		t.Errorf("new client buffered=%v with %d clients, want true with %d", ok, len(l.clients), maxBacklogClients)
+	}
+}
+
+func TestBacklog_CapKeepsNewest(t *testing.T) {
+	var l backlog
+	l.setWindow(time.Hour)
+	l.connect("tui@laptop", 0)
+	now := time.Now()
+	for i := 1; i <= maxCatchUpEvents+10; i++ {
+		l.add(uint64(i), now, []byte("x"))
+	}
+	events, complete, truncated := l.since("tui@laptop", 0, now)
+	if len(events) != maxCatchUpEvents || complete || truncated != 10 {
+		t.Fatalf("got %d events complete=%v truncated=%d, want %d incomplete 10", len(events), complete, truncated, maxCatchUpEvents)
+	}
+}
+
+func TestAddResumingClient_ReconnectMissingMoreThanCap(t *testing.T) {
+	b := newTestBroadcaster(session.NewStore(), nil)
+	b.SetCatchUpWindow(time.Hour)
+
+	first := makeClient(b)
+	first.clientID = "tui@laptop"
+	b.backlog.connect(first.clientID, b.seq.Load())
+	b.RemoveClient(first)
+	since := b.seq.Load()
+
+	missed := maxCatchUpEvents + 25
+	for i := 0; i < missed; i++ {
+		b.BroadcastMessage(WSMessage{Type: MsgSoundCue, Payload: json.RawMessage(`{}`)})
+	}
+	last := b.seq.Load()
+
+	c := makeClient(b)
+	b.sendCatchUp(c, "tui@laptop", since)
+	var msg WSMessage
+	if err := json.Unmarshal(<-c.send, &msg); err != nil || msg.Type != MsgCatchUp {
+		t.Fatalf("first message = %s (%v), want %s", msg.Type, err, MsgCatchUp)
+	}
+	var p CatchUpPayload
+	if err := json.Unmarshal(msg.Payload, &p); err != nil {
+		t.Fatalf("unmarshal payload: %v", err)
+	}
+	if p.Complete || len(p.Events) != maxCatchUpEvents || p.Truncated != missed-maxCatchUpEvents {
+		t.Fatalf("catch-up = complete %v, %d events, truncated %d; want false, %d, %d",
+			p.Complete, len(p.Events), p.Truncated, maxCatchUpEvents, missed-maxCatchUpEvents)
+	}
+	var newest WSMessage
+	if err := json.Unmarshal(p.Events[len(p.Events)-1], &newest); err != nil || newest.Seq != last {
+		t.Errorf("newest replayed seq = %d (%v), want %d", newest.Seq, err, last)
+	}
+
+	// Another identity that was never connected gets nothing replayed.
+	other := makeClient(b)
+	b.sendCatchUp(other, "web@desktop", since)
+	if err := json.Unmarshal(<-other.send, &msg); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if err := json.Unmarshal(msg.Payload, &p); err != nil || len(p.Events) != 0 || p.Complete {
+		t.Errorf("unknown client catch-up = %d events complete %v (%v), want none incomplete", len(p.Events), p.Complete, err)
+	}
+}
+
+func TestAddResumingClient_SendsCatchUpBeforeSnapshot(t *testing.T) {
+	b := newTestBroadcaster(session.NewStore(), nil)
+	b.SetCatchUpWindow(time.Minute)
+	b.backlog.connect("tui@laptop", 0)
+	for i := 0; i < 3; i++ {
+		b.BroadcastMessage(WSMessage{Type: MsgSoundCue, Payload: json.RawMessage(`{}`)})
+	}
+
+	c := makeClient(b)
+	b.sendCatchUp(c, "tui@laptop", 1)
+	b.SendSnapshot(c)
+
+	var msg WSMessage
+	if err := json.Unmarshal(<-c.send, &msg); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if msg.Type != MsgCatchUp {
+		t.Fatalf("first message = %s, want %s", msg.Type, MsgCatchUp)
+	}
+	var p CatchUpPayload
+	if err := json.Unmarshal(msg.Payload, &p); err != nil {
+		t.Fatalf("unmarshal payload: %v", err)
+	}
+	if p.Since != 1 || !p.Complete || len(p.Events) != 2 {
+		t.Fatalf("catch-up = since %d complete %v %d events, want 1 true 2", p.Since, p.Complete, len(p.Events))
+	}
+	if msg.Seq <= 3 {
+		t.Errorf("catch-up seq = %d, want after the buffered events", msg.Seq)
+	}
+	if err := json.Unmarshal(<-c.send, &msg); err != nil || msg.Type != MsgSnapshot {
+		t.Fatalf("second message = %s (%v), want snapshot", msg.Type, err)
+	}
+
+	// A seq from before a restart gets no catch-up.
+	fresh := makeClient(b)
+	b.sendCatchUp(fresh, "tui@laptop", 999)
+	if types, _ := drainTypes(t, fresh); len(types) != 0 {
+		t.Errorf("unknown seq produced %v", types)
+	}
+}
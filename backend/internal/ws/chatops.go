@@ -0,0 +1,24 @@
+package ws
+
+import (
+	"net/http"
+
+	"github.com/agent-racer/backend/internal/chatops"
+)
+
+// SetChatOps enables /api/chatops/slack. Must be called before
+// SetupRoutes.
+func (s *Server) SetChatOps(b *chatops.Bridge) {
+	s.chatops = b
+}
+
+// handleSlackCommand answers a Slack slash command. The bridge checks the
+// request's Slack signature in place of the auth token, since Slack cannot
+// send one.
+func (s *Server) handleSlackCommand(w http.ResponseWriter, r *http.Request) {
+	if s.chatops == nil {
+		http.Error(w, "chat ops not available", http.StatusServiceUnavailable)
+		return
+	}
+	s.chatops.ServeHTTP(w, r)
+}
@@ -0,0 +1,75 @@
+package ws
+
+import (
+	"log/slog"
+
+	"github.com/agent-racer/backend/internal/session"
+)
+
+// latestByID keeps the last queued state of each session, in the order
+// sessions were first queued. The monitor queues a session on every poll
+// it is touched, so one throttle window can hold several states of it.
+func latestByID(updates []*session.SessionState) []*session.SessionState {
+	index := make(map[string]int, len(updates))
+	out := updates[:0:0]
+	for i := 0; i < len(updates); i++ {
+		s := updates[i]
+		if j, ok := index[s.ID]; ok {
+			out[j] = s
+			continue
+		}
+		index[s.ID] = len(out)
+		out = append(out, s)
+	}
+	return out
+}
+
+// changedSince presents updates the way clients see them and drops those
+// identical to what the last delta carried for the same session, so a
+// session that is queued without having changed is not resent. The JSON
+// of the sessions kept is left in buf.updates for the delta, and their
+// fingerprints are recorded as sent. It returns how many were kept.
+func (b *Broadcaster) changedSince(buf *encodeBuffer, pf *session.PrivacyFilter, renames map[string]string, lang string, updates []*session.SessionState) int {
+	b.flushMu.Lock()
+	defer b.flushMu.Unlock()
+
+	if b.sentHashes == nil {
+		b.sentHashes = make(map[string]uint64)
+	}
+	buf.updates = buf.updates[:0]
+	changed := 0
+	for i := 0; i < len(updates); i++ {
+		shown := present(pf, renames, lang, updates[i:i+1])
+		if len(shown) == 0 {
+			continue
+		}
+		buf.err = nil
+		buf.one = buf.session(buf.one[:0], shown[0])
+		if buf.err != nil {
+			slog.Error("session marshal failed", "session", updates[i].ID, "error", buf.err)
+			continue
+		}
+		sum := fnv64a(buf.one)
+		if prev, ok := b.sentHashes[updates[i].ID]; ok && prev == sum {
+			b.unchangedSkipped.Add(1)
+			continue
+		}
+		b.sentHashes[updates[i].ID] = sum
+		if changed > 0 {
+			buf.updates = append(buf.updates, ',')
+		}
+		buf.updates = append(buf.updates, buf.one...)
+		changed++
+	}
+	return changed
+}
+
+// fnv64a is the 64-bit FNV-1a hash of data.
+func fnv64a(data []byte) uint64 {
+	h := uint64(14695981039346656037)
+	for i := 0; i < len(data); i++ {
+		h ^= uint64(data[i])
+		h *= 1099511628211
+	}
+	return h
+}
@@ -0,0 +1,102 @@
+package ws
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/agent-racer/backend/internal/session"
+)
+
+// CurrentSessionResponse is the session a directory belongs to, served by
+// /api/sessions/current for shell prompts and editor integrations.
+type CurrentSessionResponse struct {
+	Match   session.DirMatch      `json:"match"`
+	Session *session.SessionState `json:"session"`
+}
+
+// allowedSessions returns the stored sessions the privacy filter lets
+// through, unmasked, for matching on their real working directories.
+func (s *Server) allowedSessions() []*session.SessionState {
+	filter := s.broadcaster.privacyFilter()
+	all := s.store.GetAll()
+	allowed := make([]*session.SessionState, 0, len(all))
+	for i := 0; i < len(all); i++ {
+		if filter.IsAllowed(all[i].WorkingDir) {
+			allowed = append(allowed, all[i])
+		}
+	}
+	return allowed
+}
+
+// handleCurrentSession returns the running session in the tmux pane
+// ?tmux= names, else the one that best matches ?cwd=: working in the
+// directory, then above it, then in another worktree of the same
+// repository. It answers 404 when none does.
+func (s *Server) handleCurrentSession(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.authorize(r) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	q := r.URL.Query()
+	cwd, target := q.Get("cwd"), q.Get("tmux")
+	if cwd == "" && target == "" {
+		http.Error(w, "cwd or tmux is required", http.StatusBadRequest)
+		return
+	}
+
+	allowed := s.allowedSessions()
+	var match *session.SessionState
+	var how session.DirMatch
+	if match = session.ForTmux(allowed, target); match != nil {
+		how = session.MatchTmux
+	} else {
+		match, how = session.ForDir(allowed, cwd)
+	}
+	var visible []*session.SessionState
+	if match != nil {
+		visible = s.broadcaster.FilterSessions([]*session.SessionState{match})
+	}
+	if len(visible) == 0 {
+		http.Error(w, "no matching session", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(CurrentSessionResponse{Match: how, Session: visible[0]})
+}
+
+// handleTmuxPane returns the running session in the tmux pane
+// /api/tmux/{target} names, the inverse of the PID-to-pane mapping the
+// monitor keeps, so tooling in a pane can ask which session it belongs to.
+func (s *Server) handleTmuxPane(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.authorize(r) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	target := strings.TrimPrefix(r.URL.Path, "/api/tmux/")
+	if !validTmuxTarget.MatchString(target) {
+		http.Error(w, "target must be session:window.pane", http.StatusBadRequest)
+		return
+	}
+
+	var visible []*session.SessionState
+	if match := session.ForTmux(s.allowedSessions(), target); match != nil {
+		visible = s.broadcaster.FilterSessions([]*session.SessionState{match})
+	}
+	if len(visible) == 0 {
+		http.Error(w, "no session in pane", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(visible[0])
+}
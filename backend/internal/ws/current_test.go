@@ -0,0 +1,99 @@
+package ws
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/agent-racer/backend/internal/session"
+)
+
+func TestHandleCurrentSession(t *testing.T) {
+	s := newHandlerTestServer(t, "tok")
+	s.store.Update(&session.SessionState{ID: "claude:a", Name: "app", WorkingDir: "/src/app", Activity: session.Thinking})
+	s.store.Update(&session.SessionState{ID: "claude:b", Name: "secret", WorkingDir: "/secret/repo", Activity: session.Thinking})
+	s.store.Update(&session.SessionState{ID: "claude:c", Name: "pane", WorkingDir: "/src/app/web", TmuxTarget: "dev:2.0", Activity: session.Idle})
+	s.broadcaster.SetPrivacyFilter(&session.PrivacyFilter{MaskWorkingDirs: true, BlockedPaths: []string{"/secret/*"}})
+
+	getQuery := func(q string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		s.handleCurrentSession(rec, authReq(http.MethodGet, "/api/sessions/current?"+q, "tok", ""))
+		return rec
+	}
+	get := func(cwd string) *httptest.ResponseRecorder {
+		return getQuery("cwd=" + url.QueryEscape(cwd))
+	}
+
+	rec := get("/src/app/internal")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+	var cur CurrentSessionResponse
+	if err := json.NewDecoder(rec.Body).Decode(&cur); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if cur.Match != session.MatchParent || cur.Session == nil || cur.Session.ID != "claude:a" {
+		t.Errorf("current = %+v", cur)
+	}
+	if cur.Session != nil && cur.Session.WorkingDir == "/src/app" {
+		t.Error("working directory not masked")
+	}
+
+	for cwd, want := range map[string]int{
+		"/secret/repo": http.StatusNotFound, // blocked by privacy settings
+		"/elsewhere":   http.StatusNotFound,
+		"":             http.StatusBadRequest,
+	} {
+		if rec := get(cwd); rec.Code != want {
+			t.Errorf("cwd %q: status = %d, want %d", cwd, rec.Code, want)
+		}
+	}
+
+	// The pane wins over the directory; an unknown pane falls back to it.
+	for q, want := range map[string]string{
+		"tmux=dev:2.0&cwd=/src/app": "claude:c",
+		"tmux=dev:9.0&cwd=/src/app": "claude:a",
+		"tmux=dev:2.0":              "claude:c",
+	} {
+		rec := getQuery(q)
+		var cur CurrentSessionResponse
+		if err := json.NewDecoder(rec.Body).Decode(&cur); err != nil || cur.Session == nil || cur.Session.ID != want {
+			t.Errorf("%s: status %d, session %+v, want %s", q, rec.Code, cur.Session, want)
+		}
+	}
+}
+
+func TestHandleTmuxPane(t *testing.T) {
+	s := newHandlerTestServer(t, "tok")
+	s.store.Update(&session.SessionState{ID: "claude:a", Name: "app", TmuxTarget: "dev:1.0", Activity: session.Thinking})
+	s.store.Update(&session.SessionState{ID: "claude:b", Name: "old", TmuxTarget: "dev:2.0", Activity: session.Complete})
+	s.broadcaster.SetPrivacyFilter(&session.PrivacyFilter{MaskTmuxTargets: true})
+
+	rec := httptest.NewRecorder()
+	s.handleTmuxPane(rec, authReq(http.MethodGet, "/api/tmux/dev:1.0", "tok", ""))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+	var got session.SessionState
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if got.ID != "claude:a" || got.TmuxTarget != "" {
+		t.Errorf("session = %s in %q, want claude:a with the pane masked", got.ID, got.TmuxTarget)
+	}
+
+	for target, want := range map[string]int{
+		"dev:2.0":    http.StatusNotFound, // finished
+		"dev:3.0":    http.StatusNotFound,
+		"dev":        http.StatusBadRequest,
+		"dev;ls:1.0": http.StatusBadRequest,
+	} {
+		rec := httptest.NewRecorder()
+		s.handleTmuxPane(rec, authReq(http.MethodGet, "/api/tmux/"+target, "tok", ""))
+		if rec.Code != want {
+			t.Errorf("%s: status = %d, want %d", target, rec.Code, want)
+		}
+	}
+}
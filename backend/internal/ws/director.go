@@ -0,0 +1,44 @@
+package ws
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/agent-racer/backend/internal/director"
+)
+
+// SetDirector enables /api/director. Must be called before SetupRoutes.
+func (s *Server) SetDirector(d *director.Director) {
+	s.director = d
+}
+
+// handleDirector reports the director settings and current focus (GET) or
+// changes them (PUT). A PUT body only needs the fields being changed.
+func (s *Server) handleDirector(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPut {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.authorize(r) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if s.director == nil {
+		http.Error(w, "director not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	if r.Method == http.MethodPut {
+		settings := s.director.Status().Settings
+		if !decodeBody(w, r, &settings) {
+			return
+		}
+		if err := s.director.Configure(settings); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(s.director.Status())
+}
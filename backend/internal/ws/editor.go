@@ -0,0 +1,149 @@
+package ws
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/agent-racer/backend/internal/session"
+)
+
+const (
+	// editorMaxWait bounds a long poll, inside the server's 60s write
+	// timeout and the idle timeouts of common proxies.
+	editorMaxWait = 55 * time.Second
+	// editorPollInterval is how often a long poll rechecks the store.
+	editorPollInterval = 250 * time.Millisecond
+)
+
+// EditorStatus is the session working in an editor's workspace folder,
+// served by /api/editor/status for status-bar extensions.
+type EditorStatus struct {
+	Folder string `json:"folder"`
+	// Session is the best match for the folder: a running session before
+	// a finished one, the folder itself before a subdirectory, then the
+	// most recently active. Nil when no session works there.
+	Session *EditorSession `json:"session,omitempty"`
+	// Others counts the other sessions working in the folder.
+	Others int `json:"others"`
+}
+
+// EditorSession is the fields of a session a status bar shows.
+type EditorSession struct {
+	ID                 string           `json:"id"`
+	Name               string           `json:"name"`
+	Activity           session.Activity `json:"activity"`
+	ContextUtilization float64          `json:"contextUtilization"`
+	Model              string           `json:"model,omitempty"`
+	CurrentTool        string           `json:"currentTool,omitempty"`
+	StartedAt          time.Time        `json:"startedAt"`
+}
+
+// editorStatus finds the sessions in folder. Matching uses the stored
+// working directories, so it works when the privacy filter masks them; the
+// session returned is the one clients see.
+func (s *Server) editorStatus(folder string) EditorStatus {
+	st := EditorStatus{Folder: folder}
+	matches := session.InFolder(s.allowedSessions(), folder)
+	if len(matches) == 0 {
+		return st
+	}
+	st.Others = len(matches) - 1
+	visible := s.broadcaster.FilterSessions(matches[:1])
+	if len(visible) == 0 {
+		return st
+	}
+	v := visible[0]
+	st.Session = &EditorSession{
+		ID:                 v.ID,
+		Name:               v.Name,
+		Activity:           v.Activity,
+		ContextUtilization: v.ContextUtilization,
+		Model:              v.Model,
+		CurrentTool:        v.CurrentTool,
+		StartedAt:          v.StartedAt,
+	}
+	return st
+}
+
+// encodeWithETag marshals v and derives an ETag from the bytes.
+func encodeWithETag(v any) ([]byte, string, error) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return nil, "", err
+	}
+	sum := sha256.Sum256(body)
+	return body, `"` + hex.EncodeToString(sum[:8]) + `"`, nil
+}
+
+// handleEditorStatus serves the EditorStatus for ?folder=. With ?wait=N
+// and an If-None-Match naming the current status, it holds the request
+// for up to N seconds until the status changes, then answers 200 with the
+// new status or 304 if nothing changed.
+func (s *Server) handleEditorStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.authorize(r) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	q := r.URL.Query()
+	folder := q.Get("folder")
+	if folder == "" {
+		http.Error(w, "folder is required", http.StatusBadRequest)
+		return
+	}
+	var wait time.Duration
+	if v := q.Get("wait"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			http.Error(w, "wait must be a number of seconds", http.StatusBadRequest)
+			return
+		}
+		wait = min(time.Duration(n)*time.Second, editorMaxWait)
+	}
+
+	body, etag, err := encodeWithETag(s.editorStatus(folder))
+	if err != nil {
+		http.Error(w, "encoding status", http.StatusInternalServerError)
+		return
+	}
+	match := r.Header.Get("If-None-Match")
+	if match == etag && wait > 0 {
+		deadline := time.NewTimer(wait)
+		defer deadline.Stop()
+		ticker := time.NewTicker(editorPollInterval)
+		defer ticker.Stop()
+	poll:
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-deadline.C:
+				break poll
+			case <-ticker.C:
+				if body, etag, err = encodeWithETag(s.editorStatus(folder)); err != nil {
+					http.Error(w, "encoding status", http.StatusInternalServerError)
+					return
+				}
+				if etag != match {
+					break poll
+				}
+			}
+		}
+	}
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+	if match == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(append(body, '\n'))
+}
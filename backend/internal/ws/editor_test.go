@@ -0,0 +1,82 @@
+package ws
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/agent-racer/backend/internal/session"
+)
+
+func TestHandleEditorStatus(t *testing.T) {
+	s := newHandlerTestServer(t, "tok")
+	s.store.Update(&session.SessionState{ID: "a", Name: "api", WorkingDir: "/src/app", Activity: session.Thinking, ContextUtilization: 0.4})
+	s.store.Update(&session.SessionState{ID: "b", Name: "web", WorkingDir: "/src/app/web", Activity: session.Complete})
+	s.broadcaster.SetPrivacyFilter(&session.PrivacyFilter{MaskWorkingDirs: true})
+
+	rec := httptest.NewRecorder()
+	s.handleEditorStatus(rec, authReq(http.MethodGet, "/api/editor/status?folder=/src/app", "tok", ""))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+	var st EditorStatus
+	if err := json.NewDecoder(rec.Body).Decode(&st); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if st.Session == nil || st.Session.ID != "a" || st.Session.ContextUtilization != 0.4 || st.Others != 1 {
+		t.Errorf("status = %+v (session %+v), want a with 1 other", st, st.Session)
+	}
+
+	rec = httptest.NewRecorder()
+	s.handleEditorStatus(rec, authReq(http.MethodGet, "/api/editor/status?folder=/elsewhere", "tok", ""))
+	st = EditorStatus{}
+	if err := json.NewDecoder(rec.Body).Decode(&st); err != nil || st.Session != nil {
+		t.Errorf("unmatched folder = %+v, %v; want no session", st, err)
+	}
+
+	for _, url := range []string{"/api/editor/status", "/api/editor/status?folder=/src&wait=soon"} {
+		rec = httptest.NewRecorder()
+		s.handleEditorStatus(rec, authReq(http.MethodGet, url, "tok", ""))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", url, rec.Code)
+		}
+	}
+}
+
+func TestHandleEditorStatus_LongPoll(t *testing.T) {
+	s := newHandlerTestServer(t, "tok")
+	s.store.Update(&session.SessionState{ID: "a", Name: "api", WorkingDir: "/src/app", Activity: session.Thinking})
+
+	rec := httptest.NewRecorder()
+	s.handleEditorStatus(rec, authReq(http.MethodGet, "/api/editor/status?folder=/src/app", "tok", ""))
+	etag := rec.Header().Get("ETag")
+
+	// Nothing changes: the poll times out with 304.
+	req := authReq(http.MethodGet, "/api/editor/status?folder=/src/app&wait=1", "tok", "")
+	req.Header.Set("If-None-Match", etag)
+	rec = httptest.NewRecorder()
+	start := time.Now()
+	s.handleEditorStatus(rec, req)
+	if rec.Code != http.StatusNotModified || time.Since(start) < time.Second {
+		t.Errorf("unchanged: status = %d after %v, want 304 after the wait", rec.Code, time.Since(start))
+	}
+
+	// A change mid-poll answers straight away.
+	go func() {
+		time.Sleep(300 * time.Millisecond)
+		s.store.Update(&session.SessionState{ID: "a", Name: "api", WorkingDir: "/src/app", Activity: session.NeedsApproval})
+	}()
+	req = authReq(http.MethodGet, "/api/editor/status?folder=/src/app&wait=30", "tok", "")
+	req.Header.Set("If-None-Match", etag)
+	rec = httptest.NewRecorder()
+	s.handleEditorStatus(rec, req)
+	var st EditorStatus
+	if err := json.NewDecoder(rec.Body).Decode(&st); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if rec.Code != http.StatusOK || st.Session == nil || st.Session.Activity != session.NeedsApproval {
+		t.Errorf("changed: status = %d, session %+v", rec.Code, st.Session)
+	}
+}
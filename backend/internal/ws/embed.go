@@ -0,0 +1,98 @@
+package ws
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/agent-racer/backend/internal/session"
+	"github.com/agent-racer/backend/internal/share"
+	"github.com/agent-racer/backend/internal/widget"
+)
+
+// embedRequest is the optional body of POST /api/sessions/{id}/embed.
+type embedRequest struct {
+	// TTL is a Go duration such as "72h"; empty uses share.default_ttl.
+	TTL string `json:"ttl"`
+}
+
+// embedResponse is returned when an embed token is created.
+type embedResponse struct {
+	Token     string    `json:"token"`
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// handleEmbed creates a read-only token for the /embed/{id} widget of one
+// session, so the widget never needs the auth token.
+func (s *Server) handleEmbed(w http.ResponseWriter, r *http.Request, sessionID string) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.shareManager == nil {
+		http.Error(w, "embedding is not available", http.StatusNotFound)
+		return
+	}
+
+	var req embedRequest
+	if r.ContentLength > 0 && !decodeBody(w, r, &req) {
+		return
+	}
+	ttl, ok := s.shareTTL(w, req.TTL)
+	if !ok {
+		return
+	}
+	if _, ok := s.visibleSession(sessionID); !ok {
+		http.Error(w, "session not found", http.StatusNotFound)
+		return
+	}
+
+	token, expiresAt, err := s.shareManager.EmbedToken(sessionID, ttl)
+	if err != nil {
+		slog.Error("embed: create token failed", "session", sessionID, "error", err)
+		http.Error(w, "failed to create embed token", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(embedResponse{
+		Token:     token,
+		URL:       s.Config().Server.Scheme() + "://" + r.Host + widget.RoutePrefix + url.PathEscape(sessionID) + "#token=" + token,
+		ExpiresAt: expiresAt,
+	})
+}
+
+// authorizeEmbed reports whether a widget poll may read sessionID: it must
+// carry an embed token for that session. The auth token is not accepted,
+// so it has no reason to end up in a host page. A server without an auth
+// token lets anyone read sessions already.
+func (s *Server) authorizeEmbed(r *http.Request, sessionID string) bool {
+	if s.authToken == "" {
+		return true
+	}
+	if s.shareManager == nil {
+		return false
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return false
+	}
+	id, err := s.shareManager.OpenEmbed(token)
+	return err == nil && id == sessionID
+}
+
+// embedSession returns session id as the widget may show it: filtered like
+// the dashboard, then stripped like a share link, since it ends up on
+// other pages.
+func (s *Server) embedSession(id string) (*session.SessionState, bool) {
+	state, ok := s.visibleSession(id)
+	if !ok {
+		return nil, false
+	}
+	return share.Sanitize(state), true
+}
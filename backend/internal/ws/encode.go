@@ -0,0 +1,420 @@
+package ws
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/agent-racer/backend/internal/session"
+)
+
+// errUnsupportedFloat is returned for NaN and infinite numbers, which
+// encoding/json refuses as well.
+var errUnsupportedFloat = errors.New("json: unsupported float value")
+
+// jsonAppender writes the messages sent most often, deltas and snapshots
+// of SessionState, without going through reflection. For valid UTF-8 its
+// output is byte for byte what encoding/json produces, and
+// TestAppendSessionMatchesEncodingJSON holds it to that, so a field added
+// to SessionState must be added here too. Once its scratch space has
+// grown, appending allocates nothing. Not safe for concurrent use.
+type jsonAppender struct {
+	keys []string // scratch for sorting map keys
+	err  error    // first error met, as encoding/json would return it
+}
+
+// session appends s as encoding/json would encode it.
+func (a *jsonAppender) session(dst []byte, s *session.SessionState) []byte {
+	dst = append(dst, `{"id":`...)
+	dst = appendJSONString(dst, s.ID)
+	dst = append(dst, `,"name":`...)
+	dst = appendJSONString(dst, s.Name)
+	dst = a.optString(dst, `,"topic":`, s.Topic)
+	dst = a.optString(dst, `,"slug":`, s.Slug)
+	dst = append(dst, `,"source":`...)
+	dst = appendJSONString(dst, s.Source)
+	dst = append(dst, `,"activity":`...)
+	dst = appendJSONString(dst, s.Activity.String())
+	dst = append(dst, `,"tokensUsed":`...)
+	dst = strconv.AppendInt(dst, int64(s.TokensUsed), 10)
+	dst = append(dst, `,"tokenEstimated":`...)
+	dst = strconv.AppendBool(dst, s.TokenEstimated)
+	if s.TokenBreakdown != (session.TokenBreakdown{}) {
+		b := s.TokenBreakdown
+		dst = append(dst, `,"tokenBreakdown":{"user":`...)
+		dst = strconv.AppendInt(dst, int64(b.User), 10)
+		dst = append(dst, `,"assistant":`...)
+		dst = strconv.AppendInt(dst, int64(b.Assistant), 10)
+		dst = append(dst, `,"toolResult":`...)
+		dst = strconv.AppendInt(dst, int64(b.ToolResult), 10)
+		dst = append(dst, `,"system":`...)
+		dst = strconv.AppendInt(dst, int64(b.System), 10)
+		dst = append(dst, '}')
+	}
+	dst = append(dst, `,"maxContextTokens":`...)
+	dst = strconv.AppendInt(dst, int64(s.MaxContextTokens), 10)
+	dst = append(dst, `,"contextUtilization":`...)
+	dst = a.float(dst, s.ContextUtilization)
+	dst = a.optString(dst, `,"currentTool":`, s.CurrentTool)
+	dst = append(dst, `,"model":`...)
+	dst = appendJSONString(dst, s.Model)
+	dst = append(dst, `,"workingDir":`...)
+	dst = appendJSONString(dst, s.WorkingDir)
+	dst = a.optString(dst, `,"branch":`, s.Branch)
+	dst = a.optString(dst, `,"project":`, s.Project)
+	dst = a.optString(dst, `,"worktree":`, s.Worktree)
+	dst = a.optString(dst, `,"subProject":`, s.SubProject)
+	dst = a.optStrings(dst, `,"tags":`, s.Tags)
+	dst = a.optString(dst, `,"privacy":`, string(s.Privacy))
+	dst = a.optStrings(dst, `,"muted":`, s.Muted)
+	dst = a.optString(dst, `,"issueUrl":`, s.IssueURL)
+	dst = a.optString(dst, `,"prUrl":`, s.PRURL)
+	dst = append(dst, `,"startedAt":`...)
+	dst = appendJSONTime(dst, s.StartedAt)
+	dst = append(dst, `,"lastActivityAt":`...)
+	dst = appendJSONTime(dst, s.LastActivityAt)
+	dst = append(dst, `,"lastDataReceivedAt":`...)
+	dst = appendJSONTime(dst, s.LastDataReceivedAt)
+	if s.CompletedAt != nil {
+		dst = append(dst, `,"completedAt":`...)
+		dst = appendJSONTime(dst, *s.CompletedAt)
+	}
+	dst = a.optString(dst, `,"outcome":`, string(s.Outcome))
+	dst = append(dst, `,"messageCount":`...)
+	dst = strconv.AppendInt(dst, int64(s.MessageCount), 10)
+	dst = append(dst, `,"toolCallCount":`...)
+	dst = strconv.AppendInt(dst, int64(s.ToolCallCount), 10)
+	dst = a.optCounts(dst, `,"mcpToolCalls":`, s.MCPToolCalls)
+	dst = a.optCounts(dst, `,"toolCounts":`, s.ToolCounts)
+	dst = a.optCounts(dst, `,"shellCommands":`, s.ShellCommands)
+	dst = a.optCounts(dst, `,"filesPatched":`, s.FilesPatched)
+	dst = a.optInt(dst, `,"pid":`, s.PID)
+	dst = a.optBool(dst, `,"isChurning":`, s.IsChurning)
+	dst = a.optString(dst, `,"tmuxTarget":`, s.TmuxTarget)
+	dst = a.optBool(dst, `,"launched":`, s.Launched)
+	dst = append(dst, `,"lane":`...)
+	dst = strconv.AppendInt(dst, int64(s.Lane), 10)
+	dst = a.optFloat(dst, `,"burnRatePerMinute":`, s.BurnRatePerMinute)
+	dst = a.optFloat(dst, `,"burnRateSmoothed":`, s.BurnRateSmoothed)
+	dst = a.optFloat(dst, `,"speed":`, s.Speed)
+	if p := s.Percentiles; p != nil {
+		dst = append(dst, `,"percentiles":{"duration":`...)
+		dst = strconv.AppendInt(dst, int64(p.Duration), 10)
+		dst = append(dst, `,"burnRate":`...)
+		dst = strconv.AppendInt(dst, int64(p.BurnRate), 10)
+		dst = append(dst, '}')
+	}
+	dst = a.optInt(dst, `,"estimatedSecondsToCompaction":`, s.SecondsToCompact)
+	if !s.CompactionETA.IsZero() {
+		dst = append(dst, `,"compactionEta":`...)
+		dst = appendJSONTime(dst, s.CompactionETA)
+	}
+	dst = a.optInt(dst, `,"compactionCount":`, s.CompactionCount)
+	dst = a.optInt(dst, `,"cacheReadTokens":`, s.CacheReadTokens)
+	dst = a.optInt(dst, `,"cacheWriteTokens":`, s.CacheWriteTokens)
+	dst = a.optInt(dst, `,"uncachedTokens":`, s.UncachedTokens)
+	dst = a.optFloat(dst, `,"cacheHitRatio":`, s.CacheHitRatio)
+	dst = a.optInt(dst, `,"cacheSavedTokens":`, s.CacheSavedTokens)
+	dst = append(dst, `,"lapCount":`...)
+	dst = strconv.AppendInt(dst, int64(s.LapCount), 10)
+	dst = append(dst, `,"lapProgress":`...)
+	dst = a.float(dst, s.LapProgress)
+	dst = a.optInt(dst, `,"tokenBudget":`, s.TokenBudget)
+	dst = a.optBool(dst, `,"overBudget":`, s.OverBudget)
+	dst = a.optFloat(dst, `,"energyWh":`, s.EnergyWh)
+	dst = a.optFloat(dst, `,"co2Grams":`, s.CO2Grams)
+	if len(s.Subagents) > 0 {
+		dst = append(dst, `,"subagents":[`...)
+		for i := 0; i < len(s.Subagents); i++ {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			dst = a.subagent(dst, &s.Subagents[i])
+		}
+		dst = append(dst, ']')
+	}
+	dst = a.optString(dst, `,"lastAssistantText":`, s.LastAssistantText)
+	dst = a.optString(dst, `,"lastCommand":`, s.LastCommand)
+	dst = a.optCounts(dst, `,"slashCommands":`, s.SlashCommands)
+	dst = a.optInt(dst, `,"hookEventCount":`, s.HookEventCount)
+	dst = a.optInt(dst, `,"modelSwitches":`, s.ModelSwitches)
+	dst = a.optInt(dst, `,"position":`, s.Position)
+	dst = a.optInt(dst, `,"positionDelta":`, s.PositionDelta)
+	dst = a.optString(dst, `,"severity":`, string(s.Severity))
+	dst = a.optString(dst, `,"statusText":`, s.StatusText)
+	dst = a.optCounts(dst, `,"reactions":`, s.Reactions)
+	return append(dst, '}')
+}
+
+// subagent appends sa as encoding/json would encode it.
+func (a *jsonAppender) subagent(dst []byte, sa *session.SubagentState) []byte {
+	dst = append(dst, `{"id":`...)
+	dst = appendJSONString(dst, sa.ID)
+	dst = append(dst, `,"parentToolUseId":`...)
+	dst = appendJSONString(dst, sa.ParentToolUseID)
+	dst = a.optString(dst, `,"parentId":`, sa.ParentID)
+	dst = append(dst, `,"depth":`...)
+	dst = strconv.AppendInt(dst, int64(sa.Depth), 10)
+	dst = append(dst, `,"sessionId":`...)
+	dst = appendJSONString(dst, sa.SessionID)
+	dst = append(dst, `,"slug":`...)
+	dst = appendJSONString(dst, sa.Slug)
+	dst = append(dst, `,"model":`...)
+	dst = appendJSONString(dst, sa.Model)
+	dst = append(dst, `,"activity":`...)
+	dst = appendJSONString(dst, sa.Activity.String())
+	dst = a.optString(dst, `,"currentTool":`, sa.CurrentTool)
+	dst = append(dst, `,"tokensUsed":`...)
+	dst = strconv.AppendInt(dst, int64(sa.TokensUsed), 10)
+	dst = a.optBool(dst, `,"overBudget":`, sa.OverBudget)
+	dst = append(dst, `,"messageCount":`...)
+	dst = strconv.AppendInt(dst, int64(sa.MessageCount), 10)
+	dst = append(dst, `,"toolCallCount":`...)
+	dst = strconv.AppendInt(dst, int64(sa.ToolCallCount), 10)
+	dst = append(dst, `,"startedAt":`...)
+	dst = appendJSONTime(dst, sa.StartedAt)
+	dst = append(dst, `,"lastActivityAt":`...)
+	dst = appendJSONTime(dst, sa.LastActivityAt)
+	if sa.CompletedAt != nil {
+		dst = append(dst, `,"completedAt":`...)
+		dst = appendJSONTime(dst, *sa.CompletedAt)
+	}
+	return append(dst, '}')
+}
+
+func (a *jsonAppender) optString(dst []byte, key, v string) []byte {
+	if v == "" {
+		return dst
+	}
+	return appendJSONString(append(dst, key...), v)
+}
+
+func (a *jsonAppender) optInt(dst []byte, key string, v int) []byte {
+	if v == 0 {
+		return dst
+	}
+	return strconv.AppendInt(append(dst, key...), int64(v), 10)
+}
+
+func (a *jsonAppender) optBool(dst []byte, key string, v bool) []byte {
+	if !v {
+		return dst
+	}
+	return append(append(dst, key...), "true"...)
+}
+
+func (a *jsonAppender) optFloat(dst []byte, key string, v float64) []byte {
+	if v == 0 {
+		return dst
+	}
+	return a.float(append(dst, key...), v)
+}
+
+func (a *jsonAppender) optStrings(dst []byte, key string, v []string) []byte {
+	if len(v) == 0 {
+		return dst
+	}
+	dst = append(dst, key...)
+	dst = append(dst, '[')
+	for i := 0; i < len(v); i++ {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		dst = appendJSONString(dst, v[i])
+	}
+	return append(dst, ']')
+}
+
+// optCounts appends m with its keys sorted, as encoding/json does.
+func (a *jsonAppender) optCounts(dst []byte, key string, m map[string]int) []byte {
+	if len(m) == 0 {
+		return dst
+	}
+	a.keys = a.keys[:0]
+	for k := range m {
+		a.keys = append(a.keys, k)
+	}
+	slices.Sort(a.keys)
+	dst = append(dst, key...)
+	dst = append(dst, '{')
+	for i := 0; i < len(a.keys); i++ {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		dst = appendJSONString(dst, a.keys[i])
+		dst = append(dst, ':')
+		dst = strconv.AppendInt(dst, int64(m[a.keys[i]]), 10)
+	}
+	return append(dst, '}')
+}
+
+// float appends f in the format encoding/json uses for a float64.
+func (a *jsonAppender) float(dst []byte, f float64) []byte {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		if a.err == nil {
+			a.err = errUnsupportedFloat
+		}
+		return append(dst, '0')
+	}
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		format = 'e'
+	}
+	dst = strconv.AppendFloat(dst, f, format, -1, 64)
+	if format == 'e' {
+		// Shorten e-09 to e-9.
+		n := len(dst)
+		if n >= 4 && dst[n-4] == 'e' && dst[n-3] == '-' && dst[n-2] == '0' {
+			dst[n-2] = dst[n-1]
+			dst = dst[:n-1]
+		}
+	}
+	return dst
+}
+
+// appendJSONTime appends t as time.Time.MarshalJSON does.
+func appendJSONTime(dst []byte, t time.Time) []byte {
+	dst = append(dst, '"')
+	dst = t.AppendFormat(dst, time.RFC3339Nano)
+	return append(dst, '"')
+}
+
+const hexDigits = "0123456789abcdef"
+
+// appendJSONString appends s quoted and escaped as encoding/json does by
+// default, HTML characters included. Invalid UTF-8 becomes \ufffd.
+func appendJSONString(dst []byte, s string) []byte {
+	dst = append(dst, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		if b := s[i]; b < utf8.RuneSelf {
+			if b >= 0x20 && b != '"' && b != '\\' && b != '<' && b != '>' && b != '&' {
+				i++
+				continue
+			}
+			dst = append(dst, s[start:i]...)
+			switch b {
+			case '\\', '"':
+				dst = append(dst, '\\', b)
+			case '\b':
+				dst = append(dst, '\\', 'b')
+			case '\f':
+				dst = append(dst, '\\', 'f')
+			case '\n':
+				dst = append(dst, '\\', 'n')
+			case '\r':
+				dst = append(dst, '\\', 'r')
+			case '\t':
+				dst = append(dst, '\\', 't')
+			default:
+				dst = append(dst, '\\', 'u', '0', '0', hexDigits[b>>4], hexDigits[b&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+		c, size := utf8.DecodeRuneInString(s[i:])
+		if c == utf8.RuneError && size == 1 {
+			dst = append(dst, s[start:i]...)
+			dst = append(dst, `\ufffd`...)
+			i += size
+			start = i
+			continue
+		}
+		if c == '\u2028' || c == '\u2029' {
+			dst = append(dst, s[start:i]...)
+			dst = append(dst, '\\', 'u', '2', '0', '2', hexDigits[c&0xF])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	dst = append(dst, s[start:]...)
+	return append(dst, '"')
+}
+
+// encodeBuffer is the scratch space flush builds a delta in.
+type encodeBuffer struct {
+	jsonAppender
+	one     []byte // the session being encoded
+	updates []byte // sessions going into the delta, comma-separated
+	payload []byte
+}
+
+// encodeBuffers recycles encodeBuffers between flushes.
+var encodeBuffers = sync.Pool{New: func() any { return new(encodeBuffer) }}
+
+// delta appends a DeltaPayload whose updates are already encoded,
+// comma-separated, in updates.
+func (a *jsonAppender) delta(dst, updates []byte, removed []string, teams []session.TeamInfo) []byte {
+	dst = append(dst, `{"updates":[`...)
+	dst = append(dst, updates...)
+	dst = append(dst, ']')
+	dst = a.optStrings(dst, `,"removed":`, removed)
+	if len(teams) > 0 {
+		dst = a.marshal(append(dst, `,"teams":`...), teams)
+	}
+	return append(dst, '}')
+}
+
+// snapshot appends p as encoding/json would encode it.
+func (a *jsonAppender) snapshot(dst []byte, p SnapshotPayload) []byte {
+	dst = append(dst, `{"sessions":`...)
+	if p.Sessions == nil {
+		dst = append(dst, "null"...)
+	} else {
+		dst = append(dst, '[')
+		for i := 0; i < len(p.Sessions); i++ {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			dst = a.session(dst, p.Sessions[i])
+		}
+		dst = append(dst, ']')
+	}
+	if len(p.Teams) > 0 {
+		dst = a.marshal(append(dst, `,"teams":`...), p.Teams)
+	}
+	if len(p.SourceHealth) > 0 {
+		dst = a.marshal(append(dst, `,"sourceHealth":`...), p.SourceHealth)
+	}
+	dst = a.optInt(dst, `,"page":`, p.Page)
+	dst = a.optInt(dst, `,"pages":`, p.Pages)
+	dst = a.optCounts(dst, `,"subagentCounts":`, p.SubagentCounts)
+	return append(dst, '}')
+}
+
+// marshal appends v through encoding/json, for the parts of a message
+// that are small or rarely sent.
+func (a *jsonAppender) marshal(dst []byte, v any) []byte {
+	data, err := json.Marshal(v)
+	if err != nil {
+		if a.err == nil {
+			a.err = err
+		}
+		return append(dst, "null"...)
+	}
+	return append(dst, data...)
+}
+
+// appendMessage appends msg as json.Marshal encodes it, given the compact
+// payload newMessage and the appenders make.
+func appendMessage(dst []byte, msg WSMessage) []byte {
+	dst = append(dst, `{"type":`...)
+	dst = appendJSONString(dst, string(msg.Type))
+	dst = append(dst, `,"seq":`...)
+	dst = strconv.AppendUint(dst, msg.Seq, 10)
+	dst = append(dst, `,"payload":`...)
+	if msg.Payload == nil {
+		dst = append(dst, "null"...)
+	} else {
+		dst = append(dst, msg.Payload...)
+	}
+	return append(dst, '}')
+}
@@ -0,0 +1,248 @@
+package ws
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/agent-racer/backend/internal/session"
+)
+
+// awkward exercises every escape encoding/json makes of valid UTF-8.
+// Invalid UTF-8 is covered by TestAppendSessionReplacesInvalidUTF8, as
+// encoding/json writes its replacement character differently from one
+// Go release to another.
+const awkward = "a<b>&\"c\"\\\n\r\t\b\f\x01\x1f \u2028\u2029 é 🏁"
+
+// fill sets every field v holds to a value that isn't its zero value, so
+// that no field is left out as empty.
+func fill(v reflect.Value, n int) {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(fmt.Sprintf("%s-%d", awkward, n))
+	case reflect.Int:
+		v.SetInt(int64(n%7 + 1))
+	case reflect.Bool:
+		v.SetBool(true)
+	case reflect.Float64:
+		v.SetFloat(float64(n) * 1.25)
+	case reflect.Slice:
+		s := reflect.MakeSlice(v.Type(), 2, 2)
+		for i := 0; i < 2; i++ {
+			fill(s.Index(i), n+i)
+		}
+		v.Set(s)
+	case reflect.Map:
+		m := reflect.MakeMap(v.Type())
+		for i := 0; i < 3; i++ {
+			m.SetMapIndex(reflect.ValueOf(fmt.Sprintf("k%d<%d>", 3-i, n)), reflect.ValueOf(i+1))
+		}
+		v.Set(m)
+	case reflect.Pointer:
+		p := reflect.New(v.Type().Elem())
+		fill(p.Elem(), n)
+		v.Set(p)
+	case reflect.Struct:
+		if v.Type() == reflect.TypeOf(time.Time{}) {
+			zone := time.FixedZone("", 5*3600+30*60)
+			v.Set(reflect.ValueOf(time.Date(2026, 3, 1, 12, 0, n, 123456789, zone)))
+			return
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				fill(v.Field(i), n+i)
+			}
+		}
+	default:
+		panic("fill: unhandled kind " + v.Kind().String())
+	}
+}
+
+func assertSameJSON(t *testing.T, what string, got []byte, v any) {
+	t.Helper()
+	want, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		i := 0
+		for i < len(got) && i < len(want) && got[i] == want[i] {
+			i++
+		}
+		t.Errorf("%s differs from encoding/json at byte %d:\n got %s\nwant %s", what, i, got[i:min(i+80, len(got))], want[i:min(i+80, len(want))])
+	}
+}
+
+func TestAppendSessionMatchesEncodingJSON(t *testing.T) {
+	var full session.SessionState
+	fill(reflect.ValueOf(&full).Elem(), 1)
+
+	withActivity := full
+	withActivity.Activity = session.Activity(99) // encodes as "unknown"
+
+	var floats []*session.SessionState
+	for _, f := range []float64{0.5, -3.25, 1e-7, 2.5e-12, 1e21, 123456789.123, 1e20, math.SmallestNonzeroFloat64} {
+		floats = append(floats, &session.SessionState{ID: "f", ContextUtilization: f, Speed: f, EnergyWh: f})
+	}
+
+	cases := map[string]*session.SessionState{
+		"zero value":    {},
+		"every field":   &full,
+		"odd activity":  &withActivity,
+		"local time":    {ID: "t", StartedAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)},
+		"omitted empty": {ID: "e", Tags: []string{}, ToolCounts: map[string]int{}, Subagents: []session.SubagentState{}},
+	}
+	for i, s := range floats {
+		cases[fmt.Sprintf("float %g", s.Speed)] = floats[i]
+	}
+
+	var a jsonAppender
+	for name, s := range cases {
+		got := a.session(nil, s)
+		if a.err != nil {
+			t.Fatalf("%s: %v", name, a.err)
+		}
+		assertSameJSON(t, name, got, s)
+	}
+}
+
+func TestAppendSessionReplacesInvalidUTF8(t *testing.T) {
+	var a jsonAppender
+	var got session.SessionState
+	if err := json.Unmarshal(a.session(nil, &session.SessionState{Name: "api\xff\xfe!"}), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "api\ufffd\ufffd!" {
+		t.Errorf("name = %q, want the invalid bytes replaced", got.Name)
+	}
+}
+
+func TestAppendSessionRejectsNaN(t *testing.T) {
+	var a jsonAppender
+	a.session(nil, &session.SessionState{BurnRatePerMinute: math.NaN()})
+	if !errors.Is(a.err, errUnsupportedFloat) {
+		t.Errorf("err = %v, want errUnsupportedFloat", a.err)
+	}
+}
+
+func TestAppendMessagesMatchEncodingJSON(t *testing.T) {
+	var s session.SessionState
+	fill(reflect.ValueOf(&s).Elem(), 2)
+	teams := []session.TeamInfo{{ID: "t", Name: "api", MemberIDs: []string{"a", "b"}}}
+	health := []SourceHealthPayload{{Source: "claude", Status: "healthy"}}
+
+	var a jsonAppender
+	one := a.session(nil, &s)
+	updates := append(append(append([]byte(nil), one...), ','), one...)
+	assertSameJSON(t, "delta", a.delta(nil, updates, []string{"gone<1>"}, teams),
+		DeltaPayload{Updates: []*session.SessionState{&s, &s}, Removed: []string{"gone<1>"}, Teams: teams})
+	assertSameJSON(t, "empty delta", a.delta(nil, nil, nil, nil), DeltaPayload{Updates: []*session.SessionState{}})
+
+	for _, p := range []SnapshotPayload{
+		{},
+		{Sessions: []*session.SessionState{}},
+		{Sessions: []*session.SessionState{&s}, Teams: teams, SourceHealth: health},
+		{Sessions: []*session.SessionState{&s}, Page: 2, Pages: 3, SubagentCounts: map[string]int{"b": 2, "a": 1}},
+	} {
+		payload := a.snapshot(nil, p)
+		assertSameJSON(t, fmt.Sprintf("snapshot %+v", p.Page), payload, p)
+
+		msg := WSMessage{Type: MsgSnapshot, Seq: 42, Payload: payload}
+		assertSameJSON(t, "message", appendMessage(nil, msg), msg)
+	}
+	if a.err != nil {
+		t.Fatal(a.err)
+	}
+	assertSameJSON(t, "message without payload", appendMessage(nil, WSMessage{Type: MsgDelta}), WSMessage{Type: MsgDelta})
+}
+
+func TestAppendSessionDoesNotAllocate(t *testing.T) {
+	var s session.SessionState
+	fill(reflect.ValueOf(&s).Elem(), 3)
+	var a jsonAppender
+	buf := a.session(nil, &s)
+	if allocs := testing.AllocsPerRun(100, func() { buf = a.session(buf[:0], &s) }); allocs != 0 {
+		t.Errorf("appending a session allocated %v times, want 0", allocs)
+	}
+}
+
+// benchSessions returns n sessions shaped like a busy machine's: a few
+// tool counts, a subagent and the usual timestamps.
+func benchSessions(n int) []*session.SessionState {
+	now := time.Now()
+	out := make([]*session.SessionState, n)
+	for i := 0; i < n; i++ {
+		out[i] = &session.SessionState{
+			ID: fmt.Sprintf("session-%d", i), Name: "api", Source: "claude", Activity: session.ToolUse,
+			TokensUsed: 120000 + i, MaxContextTokens: 200000, ContextUtilization: 0.6 + float64(i)/1000,
+			CurrentTool: "Bash", Model: "claude-sonnet-4-5", WorkingDir: "/home/dev/work/api", Branch: "main",
+			StartedAt: now.Add(-time.Hour), LastActivityAt: now, LastDataReceivedAt: now,
+			MessageCount: 80, ToolCallCount: 45, ToolCounts: map[string]int{"Bash": 20, "Read": 15, "Edit": 10},
+			Lane: i, BurnRatePerMinute: 2400.5, BurnRateSmoothed: 2210.25, Speed: 61.5, LapCount: 2, LapProgress: 0.35,
+			Subagents: []session.SubagentState{{ID: "toolu_1", SessionID: "s", Slug: "explore", Model: "claude-haiku-4-5", Activity: session.Thinking, TokensUsed: 9000, StartedAt: now, LastActivityAt: now}},
+			Position:  i + 1, Severity: session.SeverityInfo, StatusText: "Session api is using Bash, 1 hour",
+		}
+	}
+	return out
+}
+
+func BenchmarkEncodeSession(b *testing.B) {
+	s := benchSessions(1)[0]
+	b.Run("encoding_json", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := json.Marshal(s); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("appender", func(b *testing.B) {
+		b.ReportAllocs()
+		var a jsonAppender
+		var buf []byte
+		for i := 0; i < b.N; i++ {
+			buf = a.session(buf[:0], s)
+		}
+	})
+}
+
+func BenchmarkEncodeDelta(b *testing.B) {
+	sessions := benchSessions(50)
+	teams := session.ComputeTeams(sessions)
+	b.Run("encoding_json", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			msg, err := NewDeltaMessage(DeltaPayload{Updates: sessions, Teams: teams})
+			if err != nil {
+				b.Fatal(err)
+			}
+			msg.Seq = uint64(i)
+			if _, err := json.Marshal(msg); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("appender", func(b *testing.B) {
+		b.ReportAllocs()
+		buf := new(encodeBuffer)
+		for i := 0; i < b.N; i++ {
+			buf.updates = buf.updates[:0]
+			for j := 0; j < len(sessions); j++ {
+				if j > 0 {
+					buf.updates = append(buf.updates, ',')
+				}
+				buf.updates = buf.session(buf.updates, sessions[j])
+			}
+			buf.payload = buf.delta(buf.payload[:0], buf.updates, nil, teams)
+			frame := appendMessage(make([]byte, 0, len(buf.payload)+64), WSMessage{Type: MsgDelta, Seq: uint64(i), Payload: buf.payload})
+			if frame[len(frame)-1] != '}' {
+				b.Fatal("malformed frame")
+			}
+		}
+	})
+}
@@ -0,0 +1,97 @@
+package ws
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// maxExternalIDLen bounds the {id} of /api/external/sessions/{id}.
+const maxExternalIDLen = 128
+
+// maxExternalHeartbeat bounds heartbeatSeconds to a day, past which a
+// silent session is better treated as gone.
+const maxExternalHeartbeat = 24 * 60 * 60
+
+// ExternalSessionFunc applies a pushed session document. An error means the
+// document was refused, not that it was malformed; the handler validates it
+// first.
+type ExternalSessionFunc func(id string, doc ExternalSessionUpdate) (ExternalSessionResult, error)
+
+// SetExternalSessions enables PUT /api/external/sessions/{id} while the
+// external source is enabled in the config. Must be called before
+// SetupRoutes.
+func (s *Server) SetExternalSessions(fn ExternalSessionFunc) {
+	s.externalSessions = fn
+}
+
+// handleExternalSession takes a script's or CI job's report of a session.
+// PUT is idempotent: the body is the session's whole state, so a producer
+// retries until it gets a response and duplicates are harmless.
+func (s *Server) handleExternalSession(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.authorize(r) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if s.externalSessions == nil || !s.Config().Sources.External.Enabled {
+		http.Error(w, "external sessions not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, "/api/external/sessions/")
+	if !validExternalID(id) {
+		http.Error(w, "id must be 1-128 letters, digits, '.', '_' or '-'", http.StatusBadRequest)
+		return
+	}
+	var doc ExternalSessionUpdate
+	if !decodeBody(w, r, &doc) {
+		return
+	}
+	if msg := validateExternalUpdate(doc); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	result, err := s.externalSessions(id, doc)
+	if err != nil {
+		slog.Warn("external session refused", "session", id, "error", err)
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(result)
+}
+
+func validExternalID(id string) bool {
+	if id == "" || len(id) > maxExternalIDLen {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		c := id[i]
+		if (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') && (c < '0' || c > '9') && c != '.' && c != '_' && c != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+// validateExternalUpdate returns why doc can't be applied, or "".
+func validateExternalUpdate(doc ExternalSessionUpdate) string {
+	switch doc.Activity {
+	case "", "thinking", "tool_use", "waiting", "needs_approval", "complete", "errored":
+	default:
+		return "activity must be thinking, tool_use, waiting, needs_approval, complete or errored"
+	}
+	if doc.TokensUsed < 0 || doc.MaxContextTokens < 0 || doc.MessageCount < 0 || doc.ToolCallCount < 0 {
+		return "counts must not be negative"
+	}
+	if doc.HeartbeatSeconds < 0 || doc.HeartbeatSeconds > maxExternalHeartbeat {
+		return "heartbeatSeconds must be between 0 and 86400"
+	}
+	return ""
+}
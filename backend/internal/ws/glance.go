@@ -0,0 +1,123 @@
+package ws
+
+import (
+	"net/http"
+
+	"github.com/agent-racer/backend/internal/session"
+)
+
+// GlanceResponse is the whole race summed up in a few fields, served by
+// /api/glance for launcher extensions and menu-bar apps that poll often.
+type GlanceResponse struct {
+	// Active counts sessions that have not completed, errored or been lost.
+	Active int `json:"active"`
+	// Counts holds how many sessions are in each activity; activities with
+	// none are left out.
+	Counts map[string]int `json:"counts"`
+	// Urgent is the session most worth looking at, if any is running or
+	// has errored.
+	Urgent *GlanceSession `json:"urgent,omitempty"`
+	// Tier is battle pass progress; nil when stats are not tracked.
+	Tier *GlanceTier `json:"tier,omitempty"`
+}
+
+// GlanceSession is the urgent session in a GlanceResponse.
+type GlanceSession struct {
+	ID                 string           `json:"id"`
+	Name               string           `json:"name"`
+	Activity           session.Activity `json:"activity"`
+	ContextUtilization float64          `json:"contextUtilization"`
+	// Reason says why it was picked: "needs_approval", "errored",
+	// "waiting", or "context" for the busiest running session.
+	Reason string `json:"reason"`
+}
+
+// GlanceTier is battle pass progress in a GlanceResponse.
+type GlanceTier struct {
+	Tier int     `json:"tier"`
+	Pct  float64 `json:"pct"` // progress within the tier, 0.0–1.0
+}
+
+// glanceUrgency ranks the activities that call for a person; anything else
+// running ranks 0 and is picked by context use.
+var glanceUrgency = map[session.Activity]int{
+	session.NeedsApproval: 3,
+	session.Errored:       2,
+	session.Waiting:       1,
+}
+
+// glance summarizes sessions, already privacy-filtered.
+func glance(sessions []*session.SessionState) GlanceResponse {
+	g := GlanceResponse{Counts: make(map[string]int)}
+	var urgent *session.SessionState
+	for i := 0; i < len(sessions); i++ {
+		st := sessions[i]
+		g.Counts[st.Activity.String()]++
+		if !st.IsTerminal() {
+			g.Active++
+		}
+		if st.IsTerminal() && st.Activity != session.Errored {
+			continue
+		}
+		if urgent == nil || moreUrgent(st, urgent) {
+			urgent = st
+		}
+	}
+	if urgent != nil {
+		reason := urgent.Activity.String()
+		if glanceUrgency[urgent.Activity] == 0 {
+			reason = "context"
+		}
+		g.Urgent = &GlanceSession{
+			ID:                 urgent.ID,
+			Name:               urgent.Name,
+			Activity:           urgent.Activity,
+			ContextUtilization: urgent.ContextUtilization,
+			Reason:             reason,
+		}
+	}
+	return g
+}
+
+func moreUrgent(a, b *session.SessionState) bool {
+	if ua, ub := glanceUrgency[a.Activity], glanceUrgency[b.Activity]; ua != ub {
+		return ua > ub
+	}
+	if a.ContextUtilization != b.ContextUtilization {
+		return a.ContextUtilization > b.ContextUtilization
+	}
+	return a.ID < b.ID
+}
+
+// handleGlance serves the GlanceResponse. It carries an ETag, so a poller
+// that sends If-None-Match gets an empty 304 until something changes.
+func (s *Server) handleGlance(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.authorize(r) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	g := glance(s.broadcaster.FilterSessions(s.store.GetAll()))
+	if s.tracker != nil {
+		p := s.tracker.GetProgress()
+		g.Tier = &GlanceTier{Tier: p.Tier, Pct: p.Pct}
+	}
+	body, etag, err := encodeWithETag(g)
+	if err != nil {
+		http.Error(w, "encoding glance", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(append(body, '\n'))
+}
@@ -0,0 +1,102 @@
+package ws
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/agent-racer/backend/internal/session"
+)
+
+func TestGlance_PicksMostUrgent(t *testing.T) {
+	tests := []struct {
+		name     string
+		sessions []*session.SessionState
+		wantID   string
+		reason   string
+	}{
+		{"none", nil, "", ""},
+		{"only finished", []*session.SessionState{{ID: "a", Activity: session.Complete}}, "", ""},
+		{"busiest running", []*session.SessionState{
+			{ID: "a", Activity: session.Thinking, ContextUtilization: 0.3},
+			{ID: "b", Activity: session.ToolUse, ContextUtilization: 0.8},
+		}, "b", "context"},
+		{"errored beats running", []*session.SessionState{
+			{ID: "a", Activity: session.Thinking, ContextUtilization: 0.9},
+			{ID: "b", Activity: session.Errored},
+		}, "b", "errored"},
+		{"approval beats all", []*session.SessionState{
+			{ID: "a", Activity: session.Errored},
+			{ID: "b", Activity: session.Waiting},
+			{ID: "c", Activity: session.NeedsApproval},
+			{ID: "d", Activity: session.Lost},
+		}, "c", "needs_approval"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := glance(tt.sessions)
+			if tt.wantID == "" {
+				if g.Urgent != nil {
+					t.Errorf("urgent = %+v, want none", g.Urgent)
+				}
+				return
+			}
+			if g.Urgent == nil || g.Urgent.ID != tt.wantID || g.Urgent.Reason != tt.reason {
+				t.Errorf("urgent = %+v, want %s (%s)", g.Urgent, tt.wantID, tt.reason)
+			}
+		})
+	}
+}
+
+func TestHandleGlance(t *testing.T) {
+	s := newHandlerTestServer(t, "tok")
+	s.SetStatsTracker(newTrackerForTest(t))
+	s.store.Update(&session.SessionState{ID: "a", Name: "alpha", Activity: session.Thinking, ContextUtilization: 0.5})
+	s.store.Update(&session.SessionState{ID: "b", Name: "beta", Activity: session.Thinking})
+	s.store.Update(&session.SessionState{ID: "c", Name: "gamma", Activity: session.Complete})
+
+	rec := httptest.NewRecorder()
+	s.handleGlance(rec, authReq(http.MethodGet, "/api/glance", "tok", ""))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	var g GlanceResponse
+	if err := json.NewDecoder(rec.Body).Decode(&g); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if g.Active != 2 || g.Counts["thinking"] != 2 || g.Counts["complete"] != 1 || len(g.Counts) != 2 {
+		t.Errorf("active = %d, counts = %v", g.Active, g.Counts)
+	}
+	if g.Urgent == nil || g.Urgent.Name != "alpha" {
+		t.Errorf("urgent = %+v, want alpha", g.Urgent)
+	}
+	if g.Tier == nil || g.Tier.Tier != 1 {
+		t.Errorf("tier = %+v, want tier 1", g.Tier)
+	}
+
+	etag := rec.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("no ETag")
+	}
+	req := authReq(http.MethodGet, "/api/glance", "tok", "")
+	req.Header.Set("If-None-Match", etag)
+	rec = httptest.NewRecorder()
+	s.handleGlance(rec, req)
+	if rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+		t.Errorf("unchanged: status = %d, body %q; want empty 304", rec.Code, rec.Body.String())
+	}
+
+	s.store.Update(&session.SessionState{ID: "b", Name: "beta", Activity: session.NeedsApproval})
+	rec = httptest.NewRecorder()
+	s.handleGlance(rec, req)
+	if rec.Code != http.StatusOK || rec.Header().Get("ETag") == etag {
+		t.Errorf("changed: status = %d, ETag %s (was %s)", rec.Code, rec.Header().Get("ETag"), etag)
+	}
+
+	rec = httptest.NewRecorder()
+	s.handleGlance(rec, authReq(http.MethodGet, "/api/glance", "", ""))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("no auth: status = %d", rec.Code)
+	}
+}
@@ -0,0 +1,140 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/agent-racer/backend/internal/graphql"
+	"github.com/agent-racer/backend/internal/session"
+)
+
+var (
+	errStatsUnavailable   = errors.New("stats not available")
+	errHeatsUnavailable   = errors.New("heats not available")
+	errReplaysUnavailable = errors.New("replays not available")
+)
+
+// graphQLSchema exposes the store, replay history and stats to /graphql.
+// Fields return the same types as the matching REST endpoints, so the
+// field names are the JSON names clients already know.
+func (s *Server) graphQLSchema() *graphql.Schema {
+	return &graphql.Schema{Query: map[string]graphql.Field{
+		"sessions": {Args: []string{"metric"}, Resolve: func(_ context.Context, args graphql.Args) (any, error) {
+			metric, err := args.String("metric")
+			if err != nil {
+				return nil, err
+			}
+			return s.rankedSessions(metric)
+		}},
+		"session": {Args: []string{"id"}, Resolve: func(_ context.Context, args graphql.Args) (any, error) {
+			id, err := args.String("id")
+			if err != nil {
+				return nil, err
+			}
+			state, _ := s.visibleSession(id)
+			return state, nil
+		}},
+		"projects": {Resolve: func(context.Context, graphql.Args) (any, error) {
+			return session.ComputeTeams(s.broadcaster.FilterSessions(s.store.GetAll())), nil
+		}},
+		"timeline": {Args: []string{"id", "max"}, Resolve: func(_ context.Context, args graphql.Args) (any, error) {
+			if s.replayHandler == nil {
+				return nil, errReplaysUnavailable
+			}
+			id, err := args.String("id")
+			if err != nil {
+				return nil, err
+			}
+			max, err := args.Int("max", maxShareTimelinePoints)
+			if err != nil {
+				return nil, err
+			}
+			// Only sessions the privacy filter shows have a timeline.
+			state, ok := s.visibleSession(id)
+			if !ok {
+				return nil, nil
+			}
+			return s.replayHandler.SessionTimeline(id, state.StartedAt, max)
+		}},
+		"replays": {Resolve: func(context.Context, graphql.Args) (any, error) {
+			if s.replayHandler == nil {
+				return nil, errReplaysUnavailable
+			}
+			return s.replayHandler.List()
+		}},
+		"heats": {Resolve: func(context.Context, graphql.Args) (any, error) {
+			if s.heats == nil {
+				return nil, errHeatsUnavailable
+			}
+			return s.heats.List(), nil
+		}},
+		"stats": {Resolve: func(context.Context, graphql.Args) (any, error) {
+			if s.tracker == nil {
+				return nil, errStatsUnavailable
+			}
+			return s.tracker.Stats(), nil
+		}},
+		"achievements": {Resolve: func(context.Context, graphql.Args) (any, error) {
+			return s.achievements(), nil
+		}},
+		"challenges": {Resolve: func(context.Context, graphql.Args) (any, error) {
+			if s.tracker == nil {
+				return nil, errStatsUnavailable
+			}
+			return s.tracker.Challenges(), nil
+		}},
+		"quests": {Resolve: func(context.Context, graphql.Args) (any, error) {
+			if s.tracker == nil {
+				return nil, errStatsUnavailable
+			}
+			return s.tracker.Quests(), nil
+		}},
+	}}
+}
+
+// handleGraphQL runs a read-only GraphQL query, sent as ?query= on a GET or
+// as a JSON {query, operationName, variables} body on a POST. Invalid
+// queries get 400; a query that ran returns 200 even if some fields failed.
+func (s *Server) handleGraphQL(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.Config().GraphQL.Enabled {
+		http.NotFound(w, r)
+		return
+	}
+	if !s.authorize(r) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req graphql.Request
+	if r.Method == http.MethodGet {
+		q := r.URL.Query()
+		req.Query = q.Get("query")
+		req.OperationName = q.Get("operationName")
+		if v := q.Get("variables"); v != "" {
+			if err := json.Unmarshal([]byte(v), &req.Variables); err != nil {
+				http.Error(w, "invalid variables", http.StatusBadRequest)
+				return
+			}
+		}
+	} else if !decodeBody(w, r, &req) {
+		return
+	}
+	if req.Query == "" {
+		http.Error(w, "query is required", http.StatusBadRequest)
+		return
+	}
+
+	resp := s.graphQLSchema().Execute(r.Context(), req)
+
+	w.Header().Set("Content-Type", "application/json")
+	if resp.Data == nil {
+		w.WriteHeader(http.StatusBadRequest)
+	}
+	_ = json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,83 @@
+package ws
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/agent-racer/backend/internal/heats"
+)
+
+// SetHeats enables /api/heats. Must be called before SetupRoutes.
+func (s *Server) SetHeats(m *heats.Manager) {
+	s.heats = m
+}
+
+// handleHeats lists heats (GET) or starts a new one (POST). Standings then
+// follow as heat_standings messages.
+func (s *Server) handleHeats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.authorize(r) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if s.heats == nil {
+		http.Error(w, "heats not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(s.heats.List())
+		return
+	}
+
+	var req heats.Request
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	h, err := s.heats.Create(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.broadcaster.BroadcastHeat(h)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(h)
+}
+
+// handleHeat returns one heat: GET /api/heats/{id}.
+func (s *Server) handleHeat(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.authorize(r) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if s.heats == nil {
+		http.Error(w, "heats not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	id, err := url.PathUnescape(strings.TrimPrefix(r.URL.Path, "/api/heats/"))
+	if err != nil || id == "" {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	h, ok := s.heats.Get(id)
+	if !ok {
+		http.Error(w, "heat not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(h)
+}
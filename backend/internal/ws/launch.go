@@ -0,0 +1,133 @@
+package ws
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/agent-racer/backend/internal/launch"
+	"github.com/agent-racer/backend/internal/session"
+)
+
+// SetLauncher enables /api/launch and /api/pipelines. Must be called
+// before SetupRoutes.
+func (s *Server) SetLauncher(l *launch.Launcher) {
+	s.launcher = l
+}
+
+// handleLaunch lists the launch templates (GET) or starts a session from
+// one (POST). A launched session is on the track straight away, before the
+// agent has logged anything; the response is that placeholder, or empty
+// when privacy settings hide it.
+func (s *Server) handleLaunch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.authorize(r) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if s.launcher == nil {
+		http.Error(w, "launch not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(s.launcher.Templates())
+		return
+	}
+
+	var req launch.Request
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if req.Template == "" {
+		http.Error(w, "template is required", http.StatusBadRequest)
+		return
+	}
+	state, err := s.launcher.Launch(req)
+	switch {
+	case errors.Is(err, launch.ErrUnknownTemplate):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case errors.Is(err, launch.ErrInvalidModel):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, launch.ErrNoTmux):
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	case err != nil:
+		slog.Error("launch failed", "template", req.Template, "error", err)
+		http.Error(w, "launch failed", http.StatusInternalServerError)
+		return
+	}
+
+	visible := s.broadcaster.FilterSessions([]*session.SessionState{state})
+	if len(visible) == 0 {
+		w.WriteHeader(http.StatusCreated)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(visible[0])
+}
+
+type pipelinesResponse struct {
+	Pipelines []launch.Pipeline    `json:"pipelines"`
+	Runs      []launch.PipelineRun `json:"runs"`
+}
+
+// handlePipelines lists the configured pipelines and recent runs (GET) or
+// starts a run (POST). Run progress follows as pipeline_update messages.
+func (s *Server) handlePipelines(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.authorize(r) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if s.launcher == nil {
+		http.Error(w, "launch not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		runs := s.launcher.Runs()
+		for i := 0; i < len(runs); i++ {
+			runs[i] = s.broadcaster.maskPipeline(runs[i])
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(pipelinesResponse{Pipelines: s.launcher.Pipelines(), Runs: runs})
+		return
+	}
+
+	var req launch.PipelineRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	run, err := s.launcher.StartPipeline(req)
+	switch {
+	case errors.Is(err, launch.ErrUnknownPipeline):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case errors.Is(err, launch.ErrInvalidPipeline):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, launch.ErrNoTmux):
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	case err != nil:
+		slog.Error("pipeline launch failed", "pipeline", run.Pipeline, "error", err)
+		http.Error(w, "launch failed", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(s.broadcaster.maskPipeline(run))
+}
@@ -0,0 +1,84 @@
+package ws
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"path/filepath"
+
+	"github.com/agent-racer/backend/internal/names"
+	"github.com/agent-racer/backend/internal/session"
+)
+
+// SessionNameRequest is the body of PUT /api/sessions/{id}/name. Scope is
+// "session" (the default) to rename just this session, or "dir" to rename
+// every session in its working directory. An empty name removes the alias.
+type SessionNameRequest struct {
+	Name  string      `json:"name"`
+	Scope names.Scope `json:"scope,omitempty"`
+}
+
+// handleSessionName sets or clears a session's display alias and resends
+// the sessions it renames.
+func (s *Server) handleSessionName(w http.ResponseWriter, r *http.Request, sessionID string) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	aliases := s.broadcaster.Aliases()
+	if aliases == nil {
+		http.Error(w, "session names are not available", http.StatusServiceUnavailable)
+		return
+	}
+	var req SessionNameRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if req.Scope == "" {
+		req.Scope = names.ScopeSession
+	}
+	if req.Scope != names.ScopeSession && req.Scope != names.ScopeDir {
+		http.Error(w, `scope must be "session" or "dir"`, http.StatusBadRequest)
+		return
+	}
+
+	if _, ok := s.visibleSession(sessionID); !ok {
+		http.Error(w, "session not found", http.StatusNotFound)
+		return
+	}
+	state, _ := s.store.Get(sessionID)
+	key := sessionID
+	if req.Scope == names.ScopeDir {
+		if state.WorkingDir == "" {
+			http.Error(w, "session has no working directory", http.StatusConflict)
+			return
+		}
+		key = state.WorkingDir
+	}
+	if err := aliases.Set(req.Scope, key, req.Name); err != nil {
+		if errors.Is(err, names.ErrInvalidName) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		slog.Error("saving session name failed", "session", sessionID, "error", err)
+		http.Error(w, "failed to save name", http.StatusInternalServerError)
+		return
+	}
+
+	renamed := []*session.SessionState{state}
+	if req.Scope == names.ScopeDir {
+		renamed = renamed[:0]
+		dir := filepath.Clean(state.WorkingDir)
+		for _, other := range s.store.GetAll() {
+			if other.WorkingDir != "" && filepath.Clean(other.WorkingDir) == dir {
+				renamed = append(renamed, other)
+			}
+		}
+	}
+	s.broadcaster.queueUpdate(renamed)
+
+	visible, _ := s.visibleSession(sessionID)
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(visible)
+}
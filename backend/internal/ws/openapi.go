@@ -0,0 +1,433 @@
+package ws
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/agent-racer/backend/internal/benchmark"
+	"github.com/agent-racer/backend/internal/chatops"
+	"github.com/agent-racer/backend/internal/config"
+	"github.com/agent-racer/backend/internal/director"
+	"github.com/agent-racer/backend/internal/gamification"
+	"github.com/agent-racer/backend/internal/heats"
+	"github.com/agent-racer/backend/internal/launch"
+	"github.com/agent-racer/backend/internal/recap"
+	"github.com/agent-racer/backend/internal/replay"
+	"github.com/agent-racer/backend/internal/session"
+	"github.com/agent-racer/backend/internal/tracks"
+)
+
+// apiParam is a path or query parameter.
+type apiParam struct {
+	name, in, desc string
+	integer        bool
+}
+
+// apiOp describes one endpoint for the OpenAPI document. Body and resp are
+// zero values of the Go types the handler decodes and encodes; their
+// schemas are derived by reflection, so the document follows the code.
+type apiOp struct {
+	method, path, tag, summary string
+	public                     bool // no bearer token required
+	params                     []apiParam
+	body                       any
+	status                     int // success status; 0 means 200
+	resp                       any // nil for an empty response
+	respType                   string
+	errors                     []int
+}
+
+var sessionIDParam = apiParam{name: "id", in: "path", desc: "Session ID, as source:id"}
+
+var recapWeekParam = apiParam{name: "week", in: "query", desc: "Any day of the week to recap, as YYYY-MM-DD; defaults to the current week"}
+
+// apiOps lists every REST endpoint SetupRoutes registers under /api/ and
+// /healthz. TestOpenAPICoversRoutes checks each is routed.
+var apiOps = []apiOp{
+	{method: "GET", path: "/api/sessions", tag: "sessions", summary: "List sessions, ordered by position",
+		params: []apiParam{{name: "metric", in: "query", desc: "Rank this response by context, tokens, messages, tool_calls or elapsed"}},
+		resp:   []*session.SessionState{}, errors: []int{400}},
+	{method: "GET", path: "/api/sessions/current", tag: "sessions", summary: "The running session a tmux pane or directory belongs to: pane, exact, parent directory or worktree match",
+		params: []apiParam{{name: "cwd", in: "query", desc: "Directory to match, an absolute path"},
+			{name: "tmux", in: "query", desc: "tmux pane to match first, as session:window.pane"}},
+		resp: CurrentSessionResponse{}, errors: []int{400, 404}},
+	{method: "GET", path: "/api/tmux/{target}", tag: "sessions", summary: "The running session in a tmux pane",
+		params: []apiParam{{name: "target", in: "path", desc: "tmux pane, as session:window.pane"}},
+		resp:   session.SessionState{}, errors: []int{400, 404}},
+	{method: "GET", path: "/api/sessions/{id}", tag: "sessions", summary: "Get one session",
+		params: []apiParam{sessionIDParam}, resp: SessionDetail{}, errors: []int{404}},
+	{method: "POST", path: "/api/sessions/{id}/focus", tag: "sessions", summary: "Switch tmux to the session's pane",
+		params: []apiParam{sessionIDParam}, status: http.StatusNoContent, errors: []int{404, 409, 500}},
+	{method: "GET", path: "/api/sessions/{id}/tail", tag: "sessions", summary: "Read the session's log from an offset",
+		params: []apiParam{sessionIDParam,
+			{name: "offset", in: "query", desc: "Byte offset returned by the previous call", integer: true},
+			{name: "limit", in: "query", desc: "Maximum entries, 1-1000 (default 200)", integer: true}},
+		resp: session.TailResponse{}, errors: []int{403, 404, 409, 500}},
+	{method: "GET", path: "/api/sessions/{id}/context", tag: "sessions", summary: "Approximate what fills the session's context window",
+		params: []apiParam{sessionIDParam,
+			{name: "top", in: "query", desc: "How many of the largest tool results to list, 1-100 (default 10)", integer: true}},
+		resp: session.ContextComposition{}, errors: []int{403, 404, 409, 500}},
+	{method: "POST", path: "/api/sessions/{id}/share", tag: "sessions", summary: "Create a read-only share link",
+		params: []apiParam{sessionIDParam}, body: shareRequest{}, status: http.StatusCreated, resp: shareResponse{}, errors: []int{400, 404, 500}},
+	{method: "POST", path: "/api/sessions/{id}/embed", tag: "sessions", summary: "Create a read-only token for the session's /embed widget",
+		params: []apiParam{sessionIDParam}, body: embedRequest{}, status: http.StatusCreated, resp: embedResponse{}, errors: []int{400, 404, 500}},
+	{method: "PUT", path: "/api/sessions/{id}/name", tag: "sessions", summary: "Set or clear the session's display name, or its working directory's",
+		params: []apiParam{sessionIDParam}, body: SessionNameRequest{}, resp: session.SessionState{}, errors: []int{400, 404, 409, 500, 503}},
+	{method: "GET", path: "/api/projects", tag: "sessions", summary: "Sessions grouped by project",
+		resp: []session.TeamInfo{}},
+	{method: "GET", path: "/api/glance", tag: "sessions", summary: "Session counts, the most urgent session and tier progress, for frequent polling; honors If-None-Match",
+		resp: GlanceResponse{}},
+	{method: "GET", path: "/api/editor/status", tag: "sessions", summary: "The session working in an editor's workspace folder; long-polls with wait and If-None-Match",
+		params: []apiParam{{name: "folder", in: "query", desc: "Workspace folder, an absolute path"},
+			{name: "wait", in: "query", desc: "Seconds to hold the request while the status matches If-None-Match, up to 55", integer: true}},
+		resp: EditorStatus{}, errors: []int{400}},
+	{method: "GET", path: "/api/launch", tag: "sessions", summary: "List launch templates",
+		resp: []launch.Template{}, errors: []int{503}},
+	{method: "POST", path: "/api/launch", tag: "sessions", summary: "Start a session from a template",
+		body: launch.Request{}, status: http.StatusCreated, resp: session.SessionState{}, errors: []int{400, 404, 500, 503}},
+	{method: "GET", path: "/api/pipelines", tag: "sessions", summary: "List pipelines and recent runs",
+		resp: pipelinesResponse{}, errors: []int{503}},
+	{method: "POST", path: "/api/pipelines", tag: "sessions", summary: "Start a pipeline run",
+		body: launch.PipelineRequest{}, status: http.StatusCreated, resp: launch.PipelineRun{}, errors: []int{400, 404, 500, 503}},
+	{method: "POST", path: "/api/chatops/slack", tag: "sessions", summary: "Answer a Slack /racer slash command; authenticated by Slack's request signature", public: true,
+		resp: chatops.Response{}, errors: []int{400, 401, 404, 503}},
+	{method: "PUT", path: "/api/external/sessions/{id}", tag: "sessions", summary: "Report a script or CI job's whole state as a session; retries are safe",
+		params: []apiParam{{name: "id", in: "path", desc: "Producer's session ID"}},
+		body:   ExternalSessionUpdate{}, resp: ExternalSessionResult{}, errors: []int{400, 413, 429, 503}},
+
+	{method: "GET", path: "/api/replays", tag: "history", summary: "List recorded replays, newest first",
+		resp: []replay.ReplayInfo{}},
+	{method: "GET", path: "/api/replays/{id}", tag: "history", summary: "Download a replay, one snapshot per line",
+		params: []apiParam{{name: "id", in: "path", desc: "Replay ID"}},
+		resp:   replay.Snapshot{}, respType: "application/x-ndjson", errors: []int{400, 404, 413}},
+	{method: "GET", path: "/api/heats", tag: "history", summary: "List heats",
+		resp: []heats.Heat{}, errors: []int{503}},
+	{method: "POST", path: "/api/heats", tag: "history", summary: "Start a heat between chosen sessions",
+		body: heats.Request{}, status: http.StatusCreated, resp: heats.Heat{}, errors: []int{400, 503}},
+	{method: "GET", path: "/api/heats/{id}", tag: "history", summary: "Get one heat",
+		params: []apiParam{{name: "id", in: "path", desc: "Heat ID"}}, resp: heats.Heat{}, errors: []int{404, 503}},
+	{method: "GET", path: "/api/benchmarks", tag: "history", summary: "Benchmark tasks, runs in progress and results",
+		resp: benchmarksResponse{}, errors: []int{503}},
+	{method: "POST", path: "/api/benchmarks/run", tag: "history", summary: "Start a benchmark task, or every idle one",
+		body: struct {
+			Task string `json:"task,omitempty"`
+		}{}, status: http.StatusAccepted, resp: []benchmark.Run{}, errors: []int{400, 404, 409, 503}},
+
+	{method: "GET", path: "/api/stats", tag: "gamification", summary: "Lifetime stats and battle pass",
+		resp: gamification.Stats{}, errors: []int{503}},
+	{method: "GET", path: "/api/stats/heatmap", tag: "gamification", summary: "Session starts and completions by weekday and hour",
+		resp: heatmapResponse{}, errors: []int{503}},
+	{method: "GET", path: "/api/stats/card.svg", tag: "gamification", summary: "Lifetime stats as a shareable card",
+		params: []apiParam{{name: "show", in: "query", desc: "Comma-separated stats to show: tier, streak, tokens, sessions, achievements; defaults to tier,streak,tokens"}},
+		resp:   "", respType: "image/svg+xml", errors: []int{400, 503}},
+	{method: "GET", path: "/api/achievements", tag: "gamification", summary: "Every achievement and whether it is unlocked",
+		params: []apiParam{{name: "unseen", in: "query", desc: "true lists only unlocks no client has acknowledged yet"}},
+		resp:   []achievementResponse{}},
+	{method: "POST", path: "/api/achievements/ack", tag: "gamification", summary: "Acknowledge shown unlocks",
+		body: ackRequest{}, resp: ackResponse{}, errors: []int{400, 503}},
+	{method: "GET", path: "/api/challenges", tag: "gamification", summary: "This week's challenges",
+		resp: []gamification.ChallengeProgress{}, errors: []int{503}},
+	{method: "GET", path: "/api/quests", tag: "gamification", summary: "Today's quests and the quest streak",
+		resp: gamification.DailyQuests{}, errors: []int{503}},
+	{method: "GET", path: "/api/gamification/seasons", tag: "gamification", summary: "The battle pass season in progress and past seasons",
+		resp: gamification.SeasonHistory{}, errors: []int{503}},
+	{method: "POST", path: "/api/gamification/reset", tag: "gamification", summary: "Reset stats: request a confirmation token, then confirm with it",
+		body: resetRequest{}, resp: resetResponse{}, errors: []int{400, 409, 503}},
+	{method: "GET", path: "/api/recap", tag: "gamification", summary: "Your Week in Agents: a recap of one week",
+		params: []apiParam{recapWeekParam}, resp: recap.Recap{}, errors: []int{400, 500}},
+	{method: "GET", path: "/api/recap.svg", tag: "gamification", summary: "The weekly recap as a shareable card",
+		params: []apiParam{recapWeekParam}, resp: "", respType: "image/svg+xml", errors: []int{400, 500}},
+	{method: "POST", path: "/api/equip", tag: "gamification", summary: "Equip an unlocked reward",
+		body: equipRequest{}, resp: gamification.Equipped{}, errors: []int{400, 403, 503}},
+	{method: "POST", path: "/api/unequip", tag: "gamification", summary: "Clear a loadout slot",
+		body: unequipRequest{}, resp: gamification.Equipped{}, errors: []int{400, 503}},
+
+	{method: "GET", path: "/api/config", tag: "admin", summary: "Sound settings",
+		resp: config.SoundConfig{}},
+	{method: "GET", path: "/api/version", tag: "admin", summary: "Server build and pending update",
+		resp: VersionInfo{}},
+	{method: "GET", path: "/api/director", tag: "admin", summary: "Director settings and current focus",
+		resp: director.Status{}, errors: []int{503}},
+	{method: "PUT", path: "/api/director", tag: "admin", summary: "Change director settings; omitted fields are kept",
+		body: director.Settings{}, resp: director.Status{}, errors: []int{400, 503}},
+	{method: "GET", path: "/api/debug/broadcaster", tag: "admin", summary: "Broadcaster queue and client lag",
+		resp: BroadcasterMetrics{}},
+	{method: "GET", path: "/api/debug/store", tag: "admin", summary: "Estimated session store memory and subagent caps",
+		resp: session.StoreMetrics{}},
+	{method: "GET", path: "/api/debug/store/at", tag: "admin", summary: "Sessions the store held at a past moment",
+		params: []apiParam{{name: "t", in: "query", desc: "RFC 3339 time or Unix seconds"}},
+		resp:   storeAtResponse{}, errors: []int{400, 404, 503}},
+	{method: "GET", path: "/api/admin/clients", tag: "admin", summary: "Connected WebSocket clients with their names and addresses",
+		resp: adminClientsResponse{}},
+	{method: "GET", path: "/api/tracks", tag: "admin", summary: "List track layouts, presets first",
+		resp: []tracks.Track{}, errors: []int{500}},
+	{method: "POST", path: "/api/tracks", tag: "admin", summary: "Create a track layout",
+		body: tracks.Track{}, status: http.StatusCreated, resp: tracks.Track{}, errors: []int{400, 500}},
+	{method: "GET", path: "/api/tracks/{id}", tag: "admin", summary: "Get a track layout",
+		params: []apiParam{{name: "id", in: "path", desc: "Track ID"}}, resp: tracks.Track{}, errors: []int{404}},
+	{method: "PUT", path: "/api/tracks/{id}", tag: "admin", summary: "Replace a track layout",
+		params: []apiParam{{name: "id", in: "path", desc: "Track ID"}}, body: tracks.Track{}, resp: tracks.Track{}, errors: []int{400, 403, 500}},
+	{method: "DELETE", path: "/api/tracks/{id}", tag: "admin", summary: "Delete a track layout",
+		params: []apiParam{{name: "id", in: "path", desc: "Track ID"}}, status: http.StatusNoContent, errors: []int{403, 404}},
+	{method: "GET", path: "/healthz", tag: "admin", summary: "Server and source health", public: true,
+		resp: healthzResponse{}},
+	{method: "GET", path: "/api/health", tag: "admin", summary: "Liveness, or readiness with probe=ready", public: true,
+		params: []apiParam{{name: "probe", in: "query", desc: "\"ready\" fails with 503 while a source is failed"}},
+		resp:   probeResponse{}, errors: []int{503}},
+	{method: "GET", path: "/api/health/sources/history", tag: "admin", summary: "Recent health transitions per source, including suppressed flaps",
+		resp: []SourceHealthHistory{}, errors: []int{503}},
+	{method: "GET", path: "/api/openapi.json", tag: "admin", summary: "This document", public: true},
+}
+
+// handleOpenAPI serves the OpenAPI document for the REST API. It is public
+// so client generators can fetch it without a token.
+func (s *Server) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	s.openAPIOnce.Do(func() {
+		doc, err := json.MarshalIndent(buildOpenAPI(s.versionInfo.Version), "", "  ")
+		if err != nil {
+			slog.Error("openapi marshal failed", "error", err)
+			return
+		}
+		s.openAPI = doc
+	})
+	if s.openAPI == nil {
+		http.Error(w, "openapi document unavailable", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(s.openAPI)
+}
+
+// buildOpenAPI returns the OpenAPI 3.1 document for apiOps.
+func buildOpenAPI(version string) map[string]any {
+	if version == "" {
+		version = "dev"
+	}
+	schemas := &schemaSet{defs: map[string]any{}, names: map[reflect.Type]string{}}
+	paths := map[string]any{}
+	for i := 0; i < len(apiOps); i++ {
+		op := apiOps[i]
+		item, _ := paths[op.path].(map[string]any)
+		if item == nil {
+			item = map[string]any{}
+			paths[op.path] = item
+		}
+		item[strings.ToLower(op.method)] = op.document(schemas)
+	}
+
+	return map[string]any{
+		"openapi": "3.1.0",
+		"info": map[string]any{
+			"title":       "Agent Racer API",
+			"version":     version,
+			"description": "REST API of the Agent Racer server. Live updates are sent over the /ws WebSocket; see the README for its messages.",
+		},
+		"tags": []map[string]string{
+			{"name": "sessions", "description": "Live sessions and starting new ones"},
+			{"name": "history", "description": "Replays, heats and benchmark results"},
+			{"name": "gamification", "description": "Stats, achievements and the garage"},
+			{"name": "admin", "description": "Server settings, health and diagnostics"},
+		},
+		"security": []map[string][]string{{"bearerAuth": {}}},
+		"paths":    paths,
+		"components": map[string]any{
+			"securitySchemes": map[string]any{
+				"bearerAuth": map[string]string{"type": "http", "scheme": "bearer"},
+			},
+			"schemas": schemas.defs,
+		},
+	}
+}
+
+func (op apiOp) document(schemas *schemaSet) map[string]any {
+	doc := map[string]any{
+		"operationId": operationID(op.method, op.path),
+		"summary":     op.summary,
+		"tags":        []string{op.tag},
+	}
+	if op.public {
+		doc["security"] = []any{}
+	}
+	if len(op.params) > 0 {
+		params := make([]map[string]any, 0, len(op.params))
+		for _, p := range op.params {
+			typ := "string"
+			if p.integer {
+				typ = "integer"
+			}
+			params = append(params, map[string]any{
+				"name":        p.name,
+				"in":          p.in,
+				"required":    p.in == "path",
+				"description": p.desc,
+				"schema":      map[string]string{"type": typ},
+			})
+		}
+		doc["parameters"] = params
+	}
+	if op.body != nil {
+		doc["requestBody"] = map[string]any{
+			"required": true,
+			"content": map[string]any{
+				"application/json": map[string]any{"schema": schemas.schema(reflect.TypeOf(op.body))},
+			},
+		}
+	}
+
+	status := op.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := map[string]any{"description": http.StatusText(status)}
+	if op.resp != nil {
+		ct := op.respType
+		if ct == "" {
+			ct = "application/json"
+		}
+		success["content"] = map[string]any{ct: map[string]any{"schema": schemas.schema(reflect.TypeOf(op.resp))}}
+	}
+	responses := map[string]any{strconv.Itoa(status): success}
+	errs := op.errors
+	if !op.public {
+		errs = append([]int{http.StatusUnauthorized}, errs...)
+	}
+	for _, code := range errs {
+		responses[strconv.Itoa(code)] = map[string]any{
+			"description": http.StatusText(code),
+			"content":     map[string]any{"text/plain": map[string]any{"schema": map[string]string{"type": "string"}}},
+		}
+	}
+	doc["responses"] = responses
+	return doc
+}
+
+// operationID turns "GET /api/sessions/{id}/tail" into "getSessionsIdTail".
+func operationID(method, path string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	for _, part := range strings.FieldsFunc(strings.TrimPrefix(path, "/api"), func(r rune) bool {
+		return r == '/' || r == '{' || r == '}' || r == '.' || r == '_'
+	}) {
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
+
+// schemaSet collects the named struct schemas referenced by the document.
+type schemaSet struct {
+	defs  map[string]any
+	names map[reflect.Type]string
+}
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+	rawType  = reflect.TypeOf(json.RawMessage{})
+)
+
+// schema returns the JSON schema for t. Named structs become references to
+// components/schemas.
+func (s *schemaSet) schema(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case t == rawType:
+		return map[string]any{}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": s.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": s.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.object(t)
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + s.define(t)}
+	}
+	return map[string]any{}
+}
+
+// define registers the schema for the named struct t and returns its
+// component name: the type name, qualified by package when two packages
+// share it.
+func (s *schemaSet) define(t reflect.Type) string {
+	if name, ok := s.names[t]; ok {
+		return name
+	}
+	name := exportName(t.Name())
+	if _, taken := s.defs[name]; taken {
+		pkg := t.PkgPath()
+		name = exportName(pkg[strings.LastIndex(pkg, "/")+1:]) + name
+	}
+	s.names[t] = name
+	s.defs[name] = map[string]any{} // placeholder for recursive types
+	s.defs[name] = s.object(t)
+	return name
+}
+
+func (s *schemaSet) object(t reflect.Type) map[string]any {
+	props := map[string]any{}
+	var required []string
+	s.fields(t, props, &required)
+	obj := map[string]any{"type": "object", "properties": props}
+	if len(required) > 0 {
+		obj["required"] = required
+	}
+	return obj
+}
+
+// fields adds t's JSON fields to props, flattening embedded structs the way
+// encoding/json does.
+func (s *schemaSet) fields(t reflect.Type, props map[string]any, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			s.fields(f.Type, props, required)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		props[name] = s.schema(f.Type)
+		if !strings.Contains(opts, "omitempty") && f.Type.Kind() != reflect.Pointer {
+			*required = append(*required, name)
+		}
+	}
+}
+
+func exportName(name string) string {
+	r := []rune(name)
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
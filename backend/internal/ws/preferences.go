@@ -0,0 +1,67 @@
+package ws
+
+import (
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/agent-racer/backend/internal/config"
+)
+
+// resolvePreferences fills the fields req leaves empty from defaults and
+// checks the result. now picks the zone's offset, which changes with
+// daylight saving time.
+func resolvePreferences(defaults config.DisplayConfig, req PreferencesRequest, now time.Time) (PreferencesPayload, error) {
+	p := PreferencesPayload{TimeZone: defaults.TimeZone, Clock: defaults.Clock}
+	if req.TimeZone != "" {
+		if err := config.ValidateTimeZone(req.TimeZone); err != nil {
+			return p, err
+		}
+		p.TimeZone = req.TimeZone
+	}
+	if req.Clock != "" {
+		if err := config.ValidateClock(req.Clock); err != nil {
+			return p, err
+		}
+		p.Clock = req.Clock
+	}
+	if p.TimeZone != "" {
+		// Validated above or by the config.
+		loc, err := time.LoadLocation(p.TimeZone)
+		if err != nil {
+			return PreferencesPayload{}, err
+		}
+		_, p.UTCOffset = now.In(loc).Zone()
+	}
+	return p, nil
+}
+
+// sendPreferences answers a client's preferences request, or with req
+// empty, tells a new client the server's defaults.
+func (s *Server) sendPreferences(c *client, req PreferencesRequest) {
+	cfg := s.Config()
+	p, err := resolvePreferences(cfg.Display, req, time.Now())
+	if err != nil {
+		p.Error = err.Error()
+	}
+	if cfg.Reactions.Enabled {
+		p.Reactions = cfg.Reactions.Emoji
+	}
+	s.broadcaster.SendPreferences(c, p)
+}
+
+// SendPreferences sends p to c alone.
+func (b *Broadcaster) SendPreferences(c *client, p PreferencesPayload) {
+	msg, err := NewPreferencesMessage(p)
+	if err != nil {
+		slog.Error("preferences marshal failed", "error", err)
+		return
+	}
+	msg.Seq = b.seq.Add(1)
+	data, err := json.Marshal(msg)
+	if err != nil {
+		slog.Error("preferences marshal failed", "error", err)
+		return
+	}
+	c.trySend(data)
+}
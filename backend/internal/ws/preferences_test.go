@@ -0,0 +1,94 @@
+package ws
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agent-racer/backend/internal/config"
+	"github.com/gorilla/websocket"
+)
+
+func TestResolvePreferences(t *testing.T) {
+	summer := time.Date(2026, 7, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		defaults config.DisplayConfig
+		req      PreferencesRequest
+		want     PreferencesPayload
+		wantErr  bool
+	}{
+		{"client local", config.DisplayConfig{}, PreferencesRequest{}, PreferencesPayload{}, false},
+		{"server default", config.DisplayConfig{TimeZone: "Europe/Berlin", Clock: "24h"}, PreferencesRequest{},
+			PreferencesPayload{TimeZone: "Europe/Berlin", Clock: "24h", UTCOffset: 2 * 3600}, false},
+		{"client override", config.DisplayConfig{TimeZone: "Europe/Berlin", Clock: "24h"}, PreferencesRequest{TimeZone: "America/New_York", Clock: "12h"},
+			PreferencesPayload{TimeZone: "America/New_York", Clock: "12h", UTCOffset: -4 * 3600}, false},
+		{"unknown zone", config.DisplayConfig{Clock: "24h"}, PreferencesRequest{TimeZone: "Mars/Olympus"},
+			PreferencesPayload{Clock: "24h"}, true},
+		{"bad clock", config.DisplayConfig{}, PreferencesRequest{Clock: "25h"}, PreferencesPayload{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolvePreferences(tt.defaults, tt.req, summer)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWSPreferencesHandshake(t *testing.T) {
+	s := newHandlerTestServer(t, "")
+	cfg := *s.Config()
+	cfg.Display = config.DisplayConfig{TimeZone: "UTC", Clock: "24h"}
+	s.SetConfig(&cfg)
+	testServer := startServer(t, s)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(testServer.URL, "http")+"/ws", nil)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	next := func() PreferencesPayload {
+		t.Helper()
+		_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		for {
+			var msg WSMessage
+			if err := conn.ReadJSON(&msg); err != nil {
+				t.Fatalf("ReadJSON: %v", err)
+			}
+			if msg.Type != MsgPreferences {
+				continue
+			}
+			var p PreferencesPayload
+			if err := json.Unmarshal(msg.Payload, &p); err != nil {
+				t.Fatalf("unmarshal: %v", err)
+			}
+			return p
+		}
+	}
+
+	if p := next(); p.TimeZone != "UTC" || p.Clock != "24h" {
+		t.Errorf("on connect got %+v, want the server defaults", p)
+	}
+
+	if err := conn.WriteJSON(map[string]string{"type": "preferences", "timeZone": "Asia/Kolkata", "clock": "12h"}); err != nil {
+		t.Fatalf("WriteJSON: %v", err)
+	}
+	if p := next(); p.TimeZone != "Asia/Kolkata" || p.Clock != "12h" || p.UTCOffset != 19800 || p.Error != "" {
+		t.Errorf("after request got %+v", p)
+	}
+
+	if err := conn.WriteJSON(map[string]string{"type": "preferences", "timeZone": "Nowhere/Special"}); err != nil {
+		t.Fatalf("WriteJSON: %v", err)
+	}
+	if p := next(); p.Error == "" || p.TimeZone != "UTC" {
+		t.Errorf("bad zone got %+v, want an error and the defaults", p)
+	}
+}
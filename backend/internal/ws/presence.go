@@ -0,0 +1,192 @@
+package ws
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Limits on what a client may call itself, in runes.
+const (
+	maxViewerName = 32
+	maxViewerKind = 16
+)
+
+// minHelloGap is how soon after one accepted hello a client may rename
+// itself again; faster ones are dropped rather than flooding every client
+// with presence messages.
+const minHelloGap = 2 * time.Second
+
+// ClientInfo describes a connected client for /api/admin/clients.
+type ClientInfo struct {
+	Name        string    `json:"name,omitempty"`
+	Kind        string    `json:"kind,omitempty"`
+	ClientID    string    `json:"clientId,omitempty"` // from the client query parameter, for clients that resume
+	RemoteAddr  string    `json:"remoteAddr,omitempty"`
+	ConnectedAt time.Time `json:"connectedAt"`
+	QueueDepth  int       `json:"queueDepth"`
+}
+
+// cleanViewerName trims name and drops control and formatting characters,
+// so one viewer can't mangle how the others render the list.
+func cleanViewerName(name string) string {
+	var b strings.Builder
+	n := 0
+	for _, r := range strings.TrimSpace(name) {
+		if n == maxViewerName {
+			break
+		}
+		if !unicode.IsPrint(r) {
+			continue
+		}
+		b.WriteRune(r)
+		n++
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// cleanViewerKind lowercases kind and keeps letters, digits and dashes.
+func cleanViewerKind(kind string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(kind) {
+		if b.Len() == maxViewerKind {
+			break
+		}
+		if ('a' <= r && r <= 'z') || ('0' <= r && r <= '9') || r == '-' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// Introduce records the name and kind c gave in a hello message and tells
+// every client. A hello that changes nothing, or comes too soon after the
+// last one, is ignored.
+func (b *Broadcaster) Introduce(c *client, req HelloRequest) {
+	if !c.rename(cleanViewerName(req.Name), cleanViewerKind(req.Kind), time.Now()) {
+		return
+	}
+	b.broadcastPresence()
+}
+
+// rename sets c's name and kind and reports whether they changed. It
+// refuses within minHelloGap of the last change.
+func (c *client) rename(name, kind string, now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if name == c.name && kind == c.kind {
+		return false
+	}
+	if !c.lastHello.IsZero() && now.Sub(c.lastHello) < minHelloGap {
+		return false
+	}
+	c.name, c.kind, c.lastHello = name, kind, now
+	return true
+}
+
+// viewer returns the name and kind c introduced itself with.
+func (c *client) viewer() (name, kind string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.name, c.kind
+}
+
+// Presence returns who is watching, with names hidden if the privacy
+// filter says so.
+func (b *Broadcaster) Presence() PresencePayload {
+	b.mu.RLock()
+	hideNames := b.privacy.HideViewerNames
+	clients := make([]*client, 0, len(b.clients))
+	for c := range b.clients {
+		clients = append(clients, c)
+	}
+	b.mu.RUnlock()
+
+	p := PresencePayload{Viewers: len(clients)}
+	if hideNames {
+		return p
+	}
+	for i := 0; i < len(clients); i++ {
+		name, kind := clients[i].viewer()
+		if name == "" {
+			continue
+		}
+		p.Named = append(p.Named, Viewer{Name: name, Kind: kind, ConnectedAt: clients[i].connectedAt})
+	}
+	sort.Slice(p.Named, func(i, j int) bool {
+		if !p.Named[i].ConnectedAt.Equal(p.Named[j].ConnectedAt) {
+			return p.Named[i].ConnectedAt.Before(p.Named[j].ConnectedAt)
+		}
+		return p.Named[i].Name < p.Named[j].Name
+	})
+	return p
+}
+
+// broadcastPresence sends the current presence to every client, unless
+// the server is shutting down and they are all about to leave.
+func (b *Broadcaster) broadcastPresence() {
+	b.mu.RLock()
+	shuttingDown := b.shuttingDown
+	b.mu.RUnlock()
+	if shuttingDown {
+		return
+	}
+	msg, err := NewPresenceMessage(b.Presence())
+	if err != nil {
+		slog.Error("broadcast presence marshal failed", "error", err)
+		return
+	}
+	b.broadcast(msg)
+}
+
+// Clients lists the connected clients, longest connected first. Unlike
+// Presence it includes their addresses, so it is for admins only.
+func (b *Broadcaster) Clients() []ClientInfo {
+	b.mu.RLock()
+	out := make([]ClientInfo, 0, len(b.clients))
+	for c := range b.clients {
+		name, kind := c.viewer()
+		out = append(out, ClientInfo{
+			Name:        name,
+			Kind:        kind,
+			ClientID:    c.clientID,
+			RemoteAddr:  c.remoteAddr,
+			ConnectedAt: c.connectedAt,
+			QueueDepth:  len(c.send),
+		})
+	}
+	b.mu.RUnlock()
+
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].ConnectedAt.Equal(out[j].ConnectedAt) {
+			return out[i].ConnectedAt.Before(out[j].ConnectedAt)
+		}
+		return out[i].RemoteAddr < out[j].RemoteAddr
+	})
+	return out
+}
+
+// adminClientsResponse is the body of /api/admin/clients.
+type adminClientsResponse struct {
+	Clients []ClientInfo `json:"clients"`
+}
+
+// handleAdminClients lists the connected WebSocket clients with their
+// names and addresses.
+func (s *Server) handleAdminClients(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.authorize(r) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(adminClientsResponse{Clients: s.broadcaster.Clients()})
+}
@@ -0,0 +1,152 @@
+package ws
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/agent-racer/backend/internal/session"
+)
+
+func TestCleanViewerName(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"  alice  ", "alice"},
+		{"bob\x1b[31m", "bob[31m"},
+		{"zero\u200bwidth", "zerowidth"},
+		{"a-very-long-name-that-goes-on-and-on-and-on", "a-very-long-name-that-goes-on-an"},
+		{"\t\n", ""},
+	}
+	for _, tt := range tests {
+		if got := cleanViewerName(tt.in); got != tt.want {
+			t.Errorf("cleanViewerName(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+	if got := cleanViewerKind("Dash Board!"); got != "dashboard" {
+		t.Errorf("cleanViewerKind = %q, want dashboard", got)
+	}
+}
+
+// lastPresence drains c's queue and returns the last presence message in it.
+func lastPresence(t *testing.T, c *client) (PresencePayload, bool) {
+	t.Helper()
+	var p PresencePayload
+	found := false
+	for len(c.send) > 0 {
+		var msg WSMessage
+		if err := json.Unmarshal(<-c.send, &msg); err != nil {
+			t.Fatal(err)
+		}
+		if msg.Type != MsgPresence {
+			continue
+		}
+		if err := json.Unmarshal(msg.Payload, &p); err != nil {
+			t.Fatal(err)
+		}
+		found = true
+	}
+	return p, found
+}
+
+func TestIntroduceBroadcastsPresence(t *testing.T) {
+	b := newTestBroadcaster(session.NewStore(), nil)
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	alice := makeClient(b)
+	alice.connectedAt = start
+	bob := makeClient(b)
+	bob.connectedAt = start.Add(time.Minute)
+	makeClient(b) // connected, never introduced
+
+	b.Introduce(bob, HelloRequest{Name: "bob", Kind: "tui"})
+	b.Introduce(alice, HelloRequest{Name: "alice", Kind: "dashboard"})
+
+	p, ok := lastPresence(t, bob)
+	if !ok {
+		t.Fatal("no presence message sent")
+	}
+	if p.Viewers != 3 || len(p.Named) != 2 {
+		t.Fatalf("presence = %+v, want 3 viewers, 2 named", p)
+	}
+	if p.Named[0].Name != "alice" || p.Named[0].Kind != "dashboard" || p.Named[1].Name != "bob" {
+		t.Errorf("Named = %+v, want alice then bob", p.Named)
+	}
+}
+
+func TestIntroduceIgnoresRepeatedAndRapidHellos(t *testing.T) {
+	b := newTestBroadcaster(session.NewStore(), nil)
+	c := makeClient(b)
+	watcher := makeClient(b)
+
+	b.Introduce(c, HelloRequest{Name: "alice", Kind: "tui"})
+	if _, ok := lastPresence(t, watcher); !ok {
+		t.Fatal("first hello sent no presence")
+	}
+
+	// The same name again says nothing, however late it comes.
+	c.lastHello = time.Time{}
+	b.Introduce(c, HelloRequest{Name: "alice", Kind: "tui"})
+	if _, ok := lastPresence(t, watcher); ok {
+		t.Error("unchanged hello broadcast presence")
+	}
+
+	// A burst of renames is cut down to the first one.
+	for i := 0; i < 50; i++ {
+		b.Introduce(c, HelloRequest{Name: fmt.Sprintf("spam-%d", i)})
+	}
+	sent := 0
+	for len(watcher.send) > 0 {
+		<-watcher.send
+		sent++
+	}
+	if sent != 1 {
+		t.Errorf("burst of 50 renames sent %d messages, want 1", sent)
+	}
+	if name, _ := c.viewer(); name != "spam-0" {
+		t.Errorf("name = %q, want the first rename to stick", name)
+	}
+
+	// Once the gap has passed the client may rename again.
+	c.lastHello = time.Now().Add(-minHelloGap)
+	b.Introduce(c, HelloRequest{Name: "alice"})
+	if p, ok := lastPresence(t, watcher); !ok || len(p.Named) != 1 || p.Named[0].Name != "alice" {
+		t.Errorf("presence = %+v (sent %v), want alice after the gap", p, ok)
+	}
+}
+
+func TestPresenceHidesNames(t *testing.T) {
+	b := newTestBroadcaster(session.NewStore(), &session.PrivacyFilter{HideViewerNames: true})
+	c := makeClient(b)
+	makeClient(b)
+	b.Introduce(c, HelloRequest{Name: "alice"})
+
+	p, ok := lastPresence(t, c)
+	if !ok {
+		t.Fatal("no presence message sent")
+	}
+	if p.Viewers != 2 || len(p.Named) != 0 {
+		t.Errorf("presence = %+v, want 2 viewers and no names", p)
+	}
+	if clients := b.Clients(); len(clients) != 2 {
+		t.Errorf("Clients() = %+v, want both, for admins", clients)
+	}
+}
+
+func TestRemoveClientBroadcastsPresence(t *testing.T) {
+	b := newTestBroadcaster(session.NewStore(), nil)
+	stay := makeClient(b)
+	leave := makeClient(b)
+
+	b.RemoveClient(leave)
+	p, ok := lastPresence(t, stay)
+	if !ok || p.Viewers != 1 {
+		t.Errorf("presence = %+v (sent %v), want 1 viewer", p, ok)
+	}
+
+	// Removing it again changes nothing and says nothing.
+	b.RemoveClient(leave)
+	if _, ok := lastPresence(t, stay); ok {
+		t.Error("presence sent for a client already removed")
+	}
+}
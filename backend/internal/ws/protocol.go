@@ -5,6 +5,8 @@ import (
 	"time"
 
 	"github.com/agent-racer/backend/internal/gamification"
+	"github.com/agent-racer/backend/internal/heats"
+	"github.com/agent-racer/backend/internal/launch"
 	"github.com/agent-racer/backend/internal/session"
 )
 
@@ -17,9 +19,31 @@ const (
 	MsgEquipped            MessageType = "equipped"
 	MsgError               MessageType = "error"
 	MsgAchievementUnlocked MessageType = "achievement_unlocked"
+	MsgAchievementBatch    MessageType = "achievement_batch"
 	MsgSourceHealth        MessageType = "source_health"
 	MsgBattlePassProgress  MessageType = "battlepass_progress"
 	MsgOvertake            MessageType = "overtake"
+	MsgServerShutdown      MessageType = "server_shutdown"
+	MsgUpdateAvailable     MessageType = "update_available"
+	MsgDirectorFocus       MessageType = "director_focus"
+	MsgCommentary          MessageType = "commentary"
+	MsgSoundCue            MessageType = "sound_cue"
+	MsgLapCompleted        MessageType = "lap_completed"
+	MsgHeatStandings       MessageType = "heat_standings"
+	MsgPipelineUpdate      MessageType = "pipeline_update"
+	MsgCatchUp             MessageType = "catch_up"
+	MsgCacheCollapse       MessageType = "cache_collapse"
+	MsgApprovalNeeded      MessageType = "approval_needed"
+	MsgModelChanged        MessageType = "model_changed"
+	MsgPreferences         MessageType = "preferences"
+	MsgRaceFinished        MessageType = "race_finished"
+	MsgSubagentStarted     MessageType = "subagent_started"
+	MsgSubagentCompleted   MessageType = "subagent_completed"
+	MsgSubagentBudget      MessageType = "subagent_budget"
+	MsgPresence            MessageType = "presence"
+	MsgReaction            MessageType = "reaction"
+	MsgWatchdogAlert       MessageType = "watchdog_alert"
+	MsgMilestone           MessageType = "milestone"
 )
 
 type WSMessage struct {
@@ -57,6 +81,10 @@ func NewAchievementUnlockedMessage(payload AchievementUnlockedPayload) (WSMessag
 	return newMessage(MsgAchievementUnlocked, payload)
 }
 
+func NewAchievementBatchMessage(payload AchievementBatchPayload) (WSMessage, error) {
+	return newMessage(MsgAchievementBatch, payload)
+}
+
 func NewSourceHealthMessage(payload SourceHealthPayload) (WSMessage, error) {
 	return newMessage(MsgSourceHealth, payload)
 }
@@ -69,6 +97,86 @@ func NewOvertakeMessage(payload OvertakePayload) (WSMessage, error) {
 	return newMessage(MsgOvertake, payload)
 }
 
+func NewServerShutdownMessage(payload ServerShutdownPayload) (WSMessage, error) {
+	return newMessage(MsgServerShutdown, payload)
+}
+
+func NewUpdateAvailableMessage(payload UpdateAvailablePayload) (WSMessage, error) {
+	return newMessage(MsgUpdateAvailable, payload)
+}
+
+func NewDirectorFocusMessage(payload DirectorFocusPayload) (WSMessage, error) {
+	return newMessage(MsgDirectorFocus, payload)
+}
+
+func NewCommentaryMessage(payload CommentaryPayload) (WSMessage, error) {
+	return newMessage(MsgCommentary, payload)
+}
+
+func NewSoundCueMessage(payload SoundCuePayload) (WSMessage, error) {
+	return newMessage(MsgSoundCue, payload)
+}
+
+func NewLapCompletedMessage(payload LapCompletedPayload) (WSMessage, error) {
+	return newMessage(MsgLapCompleted, payload)
+}
+
+func NewCacheCollapseMessage(payload CacheCollapsePayload) (WSMessage, error) {
+	return newMessage(MsgCacheCollapse, payload)
+}
+
+func NewApprovalNeededMessage(payload ApprovalNeededPayload) (WSMessage, error) {
+	return newMessage(MsgApprovalNeeded, payload)
+}
+
+func NewModelChangedMessage(payload ModelChangedPayload) (WSMessage, error) {
+	return newMessage(MsgModelChanged, payload)
+}
+
+func NewSubagentStartedMessage(payload SubagentPayload) (WSMessage, error) {
+	return newMessage(MsgSubagentStarted, payload)
+}
+
+func NewSubagentCompletedMessage(payload SubagentPayload) (WSMessage, error) {
+	return newMessage(MsgSubagentCompleted, payload)
+}
+
+func NewSubagentBudgetMessage(payload SubagentBudgetPayload) (WSMessage, error) {
+	return newMessage(MsgSubagentBudget, payload)
+}
+
+func NewWatchdogAlertMessage(payload WatchdogAlertPayload) (WSMessage, error) {
+	return newMessage(MsgWatchdogAlert, payload)
+}
+
+func NewMilestoneMessage(payload MilestonePayload) (WSMessage, error) {
+	return newMessage(MsgMilestone, payload)
+}
+
+func NewPresenceMessage(payload PresencePayload) (WSMessage, error) {
+	return newMessage(MsgPresence, payload)
+}
+
+func NewReactionMessage(payload ReactionPayload) (WSMessage, error) {
+	return newMessage(MsgReaction, payload)
+}
+
+func NewPreferencesMessage(payload PreferencesPayload) (WSMessage, error) {
+	return newMessage(MsgPreferences, payload)
+}
+
+func NewHeatStandingsMessage(payload heats.Heat) (WSMessage, error) {
+	return newMessage(MsgHeatStandings, payload)
+}
+
+func NewRaceFinishedMessage(payload heats.Heat) (WSMessage, error) {
+	return newMessage(MsgRaceFinished, payload)
+}
+
+func NewPipelineUpdateMessage(payload launch.PipelineRun) (WSMessage, error) {
+	return newMessage(MsgPipelineUpdate, payload)
+}
+
 type SourceHealthStatus string
 
 const (
@@ -86,10 +194,71 @@ type SourceHealthPayload struct {
 	Timestamp        time.Time          `json:"timestamp"`
 }
 
+// SourceHealthTransition is one change in a source's health status.
+type SourceHealthTransition struct {
+	From       SourceHealthStatus `json:"from"`
+	To         SourceHealthStatus `json:"to"`
+	LastError  string             `json:"lastError,omitempty"`
+	Suppressed bool               `json:"suppressed,omitempty"` // not broadcast: the source was flapping
+	Timestamp  time.Time          `json:"timestamp"`
+}
+
+// SourceHealthHistory is a source's recent health transitions, oldest
+// first. Served by /api/health/sources/history.
+type SourceHealthHistory struct {
+	Source      string                   `json:"source"`
+	Status      SourceHealthStatus       `json:"status"`
+	Flapping    bool                     `json:"flapping"`
+	Transitions []SourceHealthTransition `json:"transitions"`
+}
+
+// ExternalSessionUpdate is the whole current state of a session reported
+// by a script or CI job with PUT /api/external/sessions/{id}. Counts are
+// totals, not increments, so sending the same document twice changes
+// nothing. Seq, when set, orders a producer's documents: one whose Seq is
+// not above the last applied is acknowledged and ignored, so a retry that
+// arrives after a newer update can't roll the session back.
+type ExternalSessionUpdate struct {
+	Seq              uint64    `json:"seq,omitempty"`
+	HeartbeatSeconds int       `json:"heartbeatSeconds,omitempty"` // longest gap between reports before the session is lost; 0 uses sources.external.session_ttl
+	Name             string    `json:"name,omitempty"`
+	Model            string    `json:"model,omitempty"`
+	Activity         string    `json:"activity,omitempty"` // thinking, tool_use, waiting, complete or errored
+	TokensUsed       int       `json:"tokensUsed,omitempty"`
+	MaxContextTokens int       `json:"maxContextTokens,omitempty"`
+	MessageCount     int       `json:"messageCount,omitempty"`
+	ToolCallCount    int       `json:"toolCallCount,omitempty"`
+	CurrentTool      string    `json:"currentTool,omitempty"`
+	LastMessage      string    `json:"lastMessage,omitempty"`
+	WorkingDir       string    `json:"workingDir,omitempty"`
+	Branch           string    `json:"branch,omitempty"`
+	StartedAt        time.Time `json:"startedAt,omitzero"`
+	LastActivityAt   time.Time `json:"lastActivityAt,omitzero"` // defaults to when the server received the document
+}
+
+// ExternalSessionResult acknowledges an ExternalSessionUpdate. Applied is
+// false when the document was a duplicate or out of order, or the session
+// had already ended; Seq is the last Seq applied.
+type ExternalSessionResult struct {
+	ID      string `json:"id"`
+	Seq     uint64 `json:"seq"`
+	Applied bool   `json:"applied"`
+}
+
 type SnapshotPayload struct {
 	Sessions     []*session.SessionState `json:"sessions"`
 	Teams        []session.TeamInfo      `json:"teams,omitempty"`
 	SourceHealth []SourceHealthPayload   `json:"sourceHealth,omitempty"`
+
+	// Page and Pages place this message in a snapshot split across
+	// several, numbering from 1; all pages share one seq and the last
+	// carries Teams and SourceHealth. Both are 0 for a whole snapshot.
+	Page  int `json:"page,omitempty"`
+	Pages int `json:"pages,omitempty"`
+	// SubagentCounts gives, by session ID, how many subagents were left
+	// out of the sessions on this page for a client that loads them
+	// lazily from GET /api/sessions/{id}.
+	SubagentCounts map[string]int `json:"subagentCounts,omitempty"`
 }
 
 type DeltaPayload struct {
@@ -124,6 +293,222 @@ type OvertakePayload struct {
 	NewPosition   int    `json:"newPosition"`
 }
 
+// ServerShutdownPayload tells clients the server is going away so they can
+// show why and reconnect later instead of treating the close as an error.
+type ServerShutdownPayload struct {
+	Reason string `json:"reason"`
+}
+
+// UpdateAvailablePayload announces a release newer than the running server.
+type UpdateAvailablePayload struct {
+	Current string `json:"current"`
+	Latest  string `json:"latest"`
+	URL     string `json:"url,omitempty"`
+}
+
+// DirectorFocusPayload suggests which session wall dashboards should show
+// until Until. Reason is errored, finishing, burn_rate or rotation.
+type DirectorFocusPayload struct {
+	SessionID string    `json:"sessionId"`
+	Name      string    `json:"name"`
+	Reason    string    `json:"reason"`
+	Until     time.Time `json:"until"`
+}
+
+// CommentaryPayload is one announcer line. SessionIDs lists the subject
+// first, then any other session the line mentions.
+type CommentaryPayload struct {
+	Event      string    `json:"event"`
+	SessionIDs []string  `json:"sessionIds"`
+	Text       string    `json:"text"`
+	At         time.Time `json:"at"`
+}
+
+// LapCompletedPayload announces that a session finished lap Lap.
+type LapCompletedPayload struct {
+	SessionID string `json:"sessionId"`
+	Name      string `json:"name"`
+	Lap       int    `json:"lap"`
+}
+
+// MilestonePayload announces that a session has been running for Value
+// seconds (kind "duration") or has burned Value tokens (kind "tokens").
+// Label is the short form, e.g. "4h" or "1M".
+type MilestonePayload struct {
+	SessionID string `json:"sessionId"`
+	Name      string `json:"name"`
+	Kind      string `json:"kind"`
+	Value     int64  `json:"value"`
+	Label     string `json:"label"`
+}
+
+// CacheCollapsePayload announces that a session which was mostly served
+// from the prompt cache just paid for most of its input again. HitRatio is
+// for the calls that collapsed; SessionHitRatio is the session's overall
+// ratio after them. MissedTokens is the input those calls paid full or
+// cache-write price for.
+type CacheCollapsePayload struct {
+	SessionID       string    `json:"sessionId"`
+	Name            string    `json:"name"`
+	HitRatio        float64   `json:"hitRatio"`
+	SessionHitRatio float64   `json:"sessionHitRatio"`
+	MissedTokens    int       `json:"missedTokens"`
+	At              time.Time `json:"at"`
+}
+
+// ModelChangedPayload announces that a session's model changed mid-session,
+// such as a fallback to a smaller model or a /model switch.
+type ModelChangedPayload struct {
+	SessionID string    `json:"sessionId"`
+	Name      string    `json:"name"`
+	OldModel  string    `json:"oldModel"`
+	NewModel  string    `json:"newModel"`
+	At        time.Time `json:"at"`
+}
+
+// HelloRequest is what a client sends, as a "hello" message, to introduce
+// itself to the other viewers. Name is shown to them if the privacy config
+// allows; Kind says what the client is, such as "dashboard" or "tui".
+type HelloRequest struct {
+	Name string `json:"name,omitempty"`
+	Kind string `json:"kind,omitempty"`
+}
+
+// PresencePayload tells every client who is watching. It is sent whenever
+// a client connects, disconnects or introduces itself. Viewers counts all
+// connected clients; Named lists the ones that gave a name, longest
+// connected first, and is empty when the privacy config hides names.
+type PresencePayload struct {
+	Viewers int      `json:"viewers"`
+	Named   []Viewer `json:"named,omitempty"`
+}
+
+// Viewer is a connected client that introduced itself.
+type Viewer struct {
+	Name        string    `json:"name"`
+	Kind        string    `json:"kind,omitempty"`
+	ConnectedAt time.Time `json:"connectedAt"`
+}
+
+// ReactionRequest is what a client sends, as a "reaction" message, to react
+// to a session. SessionID is the ID the client was sent, masked or not;
+// Emoji must be one of those the config allows.
+type ReactionRequest struct {
+	SessionID string `json:"sessionId,omitempty"`
+	Emoji     string `json:"emoji,omitempty"`
+}
+
+// ReactionPayload passes a viewer's reaction on to every client. From is
+// the name the viewer introduced itself with, left out when it gave none
+// or the privacy config hides names. Count is the session's running total
+// for the emoji when reactions are recorded, and zero otherwise.
+type ReactionPayload struct {
+	SessionID string    `json:"sessionId"`
+	Name      string    `json:"name"`
+	Emoji     string    `json:"emoji"`
+	From      string    `json:"from,omitempty"`
+	Count     int       `json:"count,omitempty"`
+	At        time.Time `json:"at"`
+}
+
+// PreferencesRequest is what a client sends, as a "preferences" message, to
+// choose how it shows timestamps. Empty fields take the server's defaults
+// from the display config.
+type PreferencesRequest struct {
+	TimeZone string `json:"timeZone,omitempty"`
+	Clock    string `json:"clock,omitempty"`
+}
+
+// PreferencesPayload tells a client how to show timestamps. It is sent on
+// connect with the server's defaults and again in answer to every
+// PreferencesRequest. An empty TimeZone means the client's local zone and
+// an empty Clock its locale's default. UTCOffset is the zone's current
+// offset in seconds, for clients without a time zone database. Error says
+// why a request was rejected; the payload then holds the server defaults.
+// Reactions lists the emoji the server accepts in reaction messages and is
+// empty while reactions are off.
+type PreferencesPayload struct {
+	TimeZone  string   `json:"timeZone,omitempty"`
+	Clock     string   `json:"clock,omitempty"`
+	UTCOffset int      `json:"utcOffset,omitempty"`
+	Error     string   `json:"error,omitempty"`
+	Reactions []string `json:"reactions,omitempty"`
+}
+
+// ApprovalNeededPayload announces that a session has stopped to wait for
+// the user to approve something, such as a plan or a permission prompt.
+// Tool is the tool call it is blocked on.
+type ApprovalNeededPayload struct {
+	SessionID string    `json:"sessionId"`
+	Name      string    `json:"name"`
+	Tool      string    `json:"tool,omitempty"`
+	At        time.Time `json:"at"`
+}
+
+// WatchdogAlertPayload warns that no session has been active since
+// QuietSince during work hours. The last session seen active is named when
+// one is known.
+type WatchdogAlertPayload struct {
+	QuietSince      time.Time `json:"quietSince"`
+	At              time.Time `json:"at"`
+	LastSessionID   string    `json:"lastSessionId,omitempty"`
+	LastSessionName string    `json:"lastSessionName,omitempty"`
+}
+
+// SubagentPayload announces a subagent (Task tool invocation) within a
+// session doing its first work, as subagent_started, or returning its
+// result, as subagent_completed. At is when that happened; the counters
+// are as of then.
+type SubagentPayload struct {
+	SessionID       string    `json:"sessionId"`
+	Name            string    `json:"name"`
+	SubagentID      string    `json:"subagentId"`
+	ParentToolUseID string    `json:"parentToolUseId"`
+	ParentID        string    `json:"parentId,omitempty"` // subagent that spawned it; empty when the session did
+	Depth           int       `json:"depth"`
+	Slug            string    `json:"slug,omitempty"`
+	Model           string    `json:"model,omitempty"`
+	TokensUsed      int       `json:"tokensUsed"`
+	ToolCallCount   int       `json:"toolCallCount"`
+	At              time.Time `json:"at"`
+}
+
+// SubagentBudgetPayload warns that a subagent's context has grown past its
+// budget, so its result may crowd out the session that spawned it.
+// ContextShare is TokensUsed over the session's context window, or 0 when
+// the window isn't known.
+type SubagentBudgetPayload struct {
+	SessionID    string    `json:"sessionId"`
+	Name         string    `json:"name"`
+	SubagentID   string    `json:"subagentId"`
+	Slug         string    `json:"slug,omitempty"`
+	Model        string    `json:"model,omitempty"`
+	TokensUsed   int       `json:"tokensUsed"`
+	Budget       int       `json:"budget"`
+	ContextShare float64   `json:"contextShare"`
+	At           time.Time `json:"at"`
+}
+
+// SoundCue names a moment clients should play a sound for. The broadcaster
+// decides when each one fires so every client agrees.
+type SoundCue string
+
+const (
+	CueStart       SoundCue = "start"
+	CueOvertake    SoundCue = "overtake"
+	CueFinish      SoundCue = "finish"
+	CueError       SoundCue = "error"
+	CueAchievement SoundCue = "achievement"
+	CueApproval    SoundCue = "approval"
+)
+
+// SoundCuePayload carries a sound cue. SessionID is empty for cues that
+// are not about one session, such as achievements.
+type SoundCuePayload struct {
+	Cue       SoundCue `json:"cue"`
+	SessionID string   `json:"sessionId,omitempty"`
+}
+
 type AchievementRewardPayload struct {
 	Type string `json:"type"`
 	ID   string `json:"id"`
@@ -137,3 +522,10 @@ type AchievementUnlockedPayload struct {
 	Tier        string                    `json:"tier"`
 	Reward      *AchievementRewardPayload `json:"reward,omitempty"`
 }
+
+// AchievementBatchPayload stands in for achievement_unlocked when several
+// achievements unlock within moments of each other.
+type AchievementBatchPayload struct {
+	Achievements []AchievementUnlockedPayload `json:"achievements"`
+	ByTier       map[string]int               `json:"byTier"` // tier -> how many unlocked
+}
@@ -0,0 +1,101 @@
+package ws
+
+import (
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/agent-racer/backend/internal/session"
+)
+
+// minReactionGap is how soon after one reaction a client may send the
+// next; faster ones are dropped rather than flooding every dashboard.
+const minReactionGap = 500 * time.Millisecond
+
+// react passes on a reaction from c if the config allows its emoji and c
+// is not sending them too fast, counting it against the session first
+// when reactions are recorded.
+func (s *Server) react(c *client, req ReactionRequest) {
+	cfg := s.Config()
+	if cfg == nil || !cfg.Reactions.Enabled || !slices.Contains(cfg.Reactions.Emoji, req.Emoji) {
+		return
+	}
+	now := time.Now()
+	if !c.allowReaction(now) {
+		return
+	}
+	state, ok := s.sessionByClientID(req.SessionID)
+	if !ok {
+		return
+	}
+
+	count := 0
+	if cfg.Reactions.Record {
+		if stored, ok := s.store.AddReaction(state.ID, req.Emoji); ok {
+			state = stored
+			count = stored.Reactions[req.Emoji]
+			s.broadcaster.QueueUpdate([]*session.SessionState{stored})
+		}
+	}
+	s.broadcaster.BroadcastReaction(c, state, req.Emoji, count, now)
+}
+
+// allowReaction reports whether c may react at now, and if so notes it.
+func (c *client) allowReaction(now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if now.Sub(c.lastReaction) < minReactionGap {
+		return false
+	}
+	c.lastReaction = now
+	return true
+}
+
+// sessionByClientID finds the stored session a client knows as id, which
+// is masked when the privacy filter masks session IDs. Sessions the filter
+// hides are not found.
+func (s *Server) sessionByClientID(id string) (*session.SessionState, bool) {
+	if id == "" {
+		return nil, false
+	}
+	filter := s.broadcaster.privacyFilter()
+	if !filter.MaskSessionIDs {
+		state, ok := s.store.Get(id)
+		if !ok || !filter.IsAllowed(state.WorkingDir) {
+			return nil, false
+		}
+		return state, true
+	}
+	all := s.store.GetAll()
+	for i := 0; i < len(all); i++ {
+		if filter.IsAllowed(all[i].WorkingDir) && filter.Apply(&session.SessionState{ID: all[i].ID}).ID == id {
+			return all[i], true
+		}
+	}
+	return nil, false
+}
+
+// BroadcastReaction tells every client that from reacted to state with
+// emoji. count is the session's recorded total for it, or zero.
+func (b *Broadcaster) BroadcastReaction(from *client, state *session.SessionState, emoji string, count int, at time.Time) {
+	filter := b.privacyFilter()
+	if !filter.IsAllowed(state.WorkingDir) {
+		return
+	}
+	payload := ReactionPayload{
+		SessionID: filter.Apply(&session.SessionState{ID: state.ID}).ID,
+		Name:      b.displayName(state.ID, state.Name),
+		Emoji:     emoji,
+		Count:     count,
+		At:        at,
+	}
+	if from != nil && !filter.HideViewerNames {
+		payload.From, _ = from.viewer()
+	}
+	msg, err := NewReactionMessage(payload)
+	if err != nil {
+		slog.Error("broadcast reaction marshal failed", "error", err)
+		return
+	}
+	b.broadcast(msg)
+}
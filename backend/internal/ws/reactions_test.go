@@ -0,0 +1,162 @@
+package ws
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agent-racer/backend/internal/config"
+	"github.com/agent-racer/backend/internal/session"
+	"github.com/gorilla/websocket"
+)
+
+// sentReactions drains c's queue and returns the reactions in it.
+func sentReactions(t *testing.T, c *client) []ReactionPayload {
+	t.Helper()
+	var out []ReactionPayload
+	for len(c.send) > 0 {
+		var msg WSMessage
+		if err := json.Unmarshal(<-c.send, &msg); err != nil {
+			t.Fatal(err)
+		}
+		if msg.Type != MsgReaction {
+			continue
+		}
+		var p ReactionPayload
+		if err := json.Unmarshal(msg.Payload, &p); err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+func newReactionTestServer(t *testing.T, record bool) *Server {
+	t.Helper()
+	s := newHandlerTestServer(t, "")
+	s.SetConfig(&config.Config{Reactions: config.ReactionsConfig{
+		Enabled: true,
+		Record:  record,
+		Emoji:   []string{"🎉", "👀"},
+	}})
+	s.store.Update(&session.SessionState{ID: "claude:abc", Name: "api"})
+	return s
+}
+
+func TestReactBroadcastsAllowedEmoji(t *testing.T) {
+	s := newReactionTestServer(t, false)
+	sender := makeClient(s.broadcaster)
+	sender.name = "alice"
+	watcher := makeClient(s.broadcaster)
+
+	s.react(sender, ReactionRequest{SessionID: "claude:abc", Emoji: "🎉"})
+	got := sentReactions(t, watcher)
+	if len(got) != 1 {
+		t.Fatalf("got %d reactions, want 1", len(got))
+	}
+	want := ReactionPayload{SessionID: "claude:abc", Name: "api", Emoji: "🎉", From: "alice", At: got[0].At}
+	if got[0] != want {
+		t.Errorf("reaction = %+v, want %+v", got[0], want)
+	}
+	if st, _ := s.store.Get("claude:abc"); st.Reactions != nil {
+		t.Errorf("unrecorded reaction stored: %v", st.Reactions)
+	}
+
+	// Too soon after the last one, then an emoji the config doesn't allow,
+	// then an unknown session.
+	s.react(sender, ReactionRequest{SessionID: "claude:abc", Emoji: "👀"})
+	sender.lastReaction = time.Time{}
+	s.react(sender, ReactionRequest{SessionID: "claude:abc", Emoji: "💩"})
+	s.react(sender, ReactionRequest{SessionID: "claude:nope", Emoji: "👀"})
+	if got := sentReactions(t, watcher); len(got) != 0 {
+		t.Errorf("dropped reactions were sent: %+v", got)
+	}
+}
+
+func TestReactRecordsAgainstMaskedSession(t *testing.T) {
+	s := newReactionTestServer(t, true)
+	filter := &session.PrivacyFilter{MaskSessionIDs: true, HideViewerNames: true}
+	s.broadcaster.SetPrivacyFilter(filter)
+	sender := makeClient(s.broadcaster)
+	sender.name = "alice"
+	masked := filter.Apply(&session.SessionState{ID: "claude:abc"}).ID
+
+	s.react(sender, ReactionRequest{SessionID: "claude:abc", Emoji: "🎉"})
+	if got := sentReactions(t, sender); len(got) != 0 {
+		t.Fatalf("unmasked ID was accepted: %+v", got)
+	}
+	sender.lastReaction = time.Time{}
+
+	s.react(sender, ReactionRequest{SessionID: masked, Emoji: "🎉"})
+	got := sentReactions(t, sender)
+	if len(got) != 1 {
+		t.Fatalf("got %d reactions, want 1", len(got))
+	}
+	if got[0].SessionID != masked || got[0].From != "" || got[0].Count != 1 {
+		t.Errorf("reaction = %+v, want masked ID, no name, count 1", got[0])
+	}
+	if st, _ := s.store.Get("claude:abc"); st.Reactions["🎉"] != 1 {
+		t.Errorf("stored reactions = %v, want one 🎉", st.Reactions)
+	}
+}
+
+func TestReactDisabled(t *testing.T) {
+	s := newReactionTestServer(t, true)
+	cfg := *s.Config()
+	cfg.Reactions.Enabled = false
+	s.SetConfig(&cfg)
+	c := makeClient(s.broadcaster)
+
+	s.react(c, ReactionRequest{SessionID: "claude:abc", Emoji: "🎉"})
+	if got := sentReactions(t, c); len(got) != 0 {
+		t.Errorf("reaction sent while disabled: %+v", got)
+	}
+	if st, _ := s.store.Get("claude:abc"); st.Reactions != nil {
+		t.Errorf("reaction recorded while disabled: %v", st.Reactions)
+	}
+}
+
+func TestWSReactionRoundTrip(t *testing.T) {
+	s := newReactionTestServer(t, false)
+	testServer := startServer(t, s)
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(testServer.URL, "http")+"/ws", nil)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	next := func(want MessageType) json.RawMessage {
+		t.Helper()
+		_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		for {
+			var msg WSMessage
+			if err := conn.ReadJSON(&msg); err != nil {
+				t.Fatalf("ReadJSON: %v", err)
+			}
+			if msg.Type == want {
+				return msg.Payload
+			}
+		}
+	}
+
+	var prefs PreferencesPayload
+	if err := json.Unmarshal(next(MsgPreferences), &prefs); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(prefs.Reactions, []string{"🎉", "👀"}) {
+		t.Errorf("preferences reactions = %v, want the configured emoji", prefs.Reactions)
+	}
+
+	if err := conn.WriteJSON(map[string]string{"type": "reaction", "sessionId": "claude:abc", "emoji": "👀"}); err != nil {
+		t.Fatalf("WriteJSON: %v", err)
+	}
+	var got ReactionPayload
+	if err := json.Unmarshal(next(MsgReaction), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.SessionID != "claude:abc" || got.Emoji != "👀" {
+		t.Errorf("reaction = %+v", got)
+	}
+}
@@ -0,0 +1,161 @@
+package ws
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/agent-racer/backend/internal/recap"
+	"github.com/agent-racer/backend/internal/session"
+)
+
+// RecapHistoryFunc returns the recorded sessions that may have started at
+// or after since, such as replay.FinalStatesSince over the replay dir.
+type RecapHistoryFunc func(since time.Time) ([]*session.SessionState, error)
+
+// SetRecapHistory sets where /api/recap finds sessions that have left the
+// store. Without it the recap covers the store's sessions alone. Must be
+// called before SetupRoutes.
+func (s *Server) SetRecapHistory(fn RecapHistoryFunc) {
+	s.recapHistory = fn
+}
+
+// handleRecap serves the weekly recap for the week containing ?week=
+// (YYYY-MM-DD, default today) in the display time zone: as JSON from
+// /api/recap, or as a shareable card from /api/recap.svg.
+func (s *Server) handleRecap(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.authorize(r) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	loc := s.Config().Display.Location()
+	day := time.Now()
+	if q := r.URL.Query().Get("week"); q != "" {
+		var err error
+		if day, err = time.ParseInLocation("2006-01-02", q, loc); err != nil {
+			http.Error(w, "week must be a date as YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+	}
+
+	rc, err := s.buildRecap(recap.WeekStart(day, loc))
+	if err != nil {
+		slog.Error("build recap failed", "error", err)
+		http.Error(w, "failed to read session history", http.StatusInternalServerError)
+		return
+	}
+
+	if r.URL.Path == "/api/recap.svg" {
+		serveSVG(w, func(buf *bytes.Buffer) error { return recap.WriteSVG(buf, rc) })
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(rc)
+}
+
+// handleStatCard serves GET /api/stats/card.svg: a card of the lifetime
+// stats named in ?show= (tier, streak, tokens, sessions, achievements;
+// default tier,streak,tokens). It carries counts only, so it can be shared
+// without exposing session or project names.
+func (s *Server) handleStatCard(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.authorize(r) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if s.tracker == nil {
+		http.Error(w, "stats not available", http.StatusServiceUnavailable)
+		return
+	}
+	keys, err := recap.ParseCardKeys(r.URL.Query().Get("show"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	stats := s.tracker.Stats()
+	progress := s.tracker.GetProgress()
+	quests := s.tracker.Quests()
+	st := recap.CardStats{
+		Season:        stats.BattlePass.Season,
+		Tier:          progress.Tier,
+		XP:            progress.XP,
+		Streak:        quests.Streak,
+		LongestStreak: quests.LongestStreak,
+		Tokens:        stats.TotalTokensBurned,
+		Sessions:      stats.TotalSessions,
+		Completions:   stats.TotalCompletions,
+	}
+	all := s.achievements()
+	st.AchievementsTotal = len(all)
+	for i := 0; i < len(all); i++ {
+		if all[i].Unlocked {
+			st.Achievements++
+		}
+	}
+	serveSVG(w, func(buf *bytes.Buffer) error { return recap.WriteStatCardSVG(buf, st, keys) })
+}
+
+// serveSVG renders a card into a buffer first, so a template error still
+// gets a clean 500.
+func serveSVG(w http.ResponseWriter, render func(*bytes.Buffer) error) {
+	var buf bytes.Buffer
+	if err := render(&buf); err != nil {
+		slog.Error("render card failed", "error", err)
+		http.Error(w, "failed to render card", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "image/svg+xml")
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'")
+	_, _ = w.Write(buf.Bytes())
+}
+
+// buildRecap gathers the week's sessions, recorded and live, and the
+// achievements unlocked so far, and summarises them.
+func (s *Server) buildRecap(weekStart time.Time) (recap.Recap, error) {
+	var sessions []*session.SessionState
+	if s.recapHistory != nil {
+		var err error
+		if sessions, err = s.recapHistory(weekStart); err != nil {
+			return recap.Recap{}, err
+		}
+	}
+
+	// The store has the latest state of the sessions it still holds.
+	live := s.broadcaster.FilterSessions(s.store.GetAll())
+	index := make(map[string]int, len(sessions))
+	for i := 0; i < len(sessions); i++ {
+		index[sessions[i].ID] = i
+	}
+	for i := 0; i < len(live); i++ {
+		if j, ok := index[live[i].ID]; ok {
+			sessions[j] = live[i]
+		} else {
+			sessions = append(sessions, live[i])
+		}
+	}
+
+	all := s.achievements()
+	var unlocked []recap.Achievement
+	for i := 0; i < len(all); i++ {
+		if all[i].Unlocked {
+			unlocked = append(unlocked, recap.Achievement{
+				ID:         all[i].ID,
+				Name:       all[i].Name,
+				Tier:       all[i].Tier,
+				UnlockedAt: *all[i].UnlockedAt,
+			})
+		}
+	}
+	return recap.Build(sessions, unlocked, weekStart), nil
+}
@@ -0,0 +1,136 @@
+package ws
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/agent-racer/backend/internal/gamification"
+)
+
+// resetConfirmTTL is how long a client has to confirm a stats reset.
+const resetConfirmTTL = time.Minute
+
+// resetRequest is the body of POST /api/gamification/reset.
+type resetRequest struct {
+	Scope   gamification.ResetScope `json:"scope"`
+	Confirm string                  `json:"confirm,omitempty"`
+}
+
+// resetResponse answers POST /api/gamification/reset: either the token
+// that confirms the reset, or that the reset was done.
+type resetResponse struct {
+	Scope     gamification.ResetScope `json:"scope"`
+	Reset     bool                    `json:"reset"`
+	Confirm   string                  `json:"confirm,omitempty"`
+	ExpiresAt *time.Time              `json:"expiresAt,omitempty"`
+}
+
+// pendingReset is a reset a client has asked for but not yet confirmed.
+type pendingReset struct {
+	scope   gamification.ResetScope
+	expires time.Time
+}
+
+// handleReset serves POST /api/gamification/reset in two steps. A request
+// with just a scope changes nothing and returns a confirmation token; the
+// same scope sent again with that token within resetConfirmTTL clears it.
+// Each token works once.
+func (s *Server) handleReset(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.authorize(r) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if s.tracker == nil {
+		http.Error(w, "stats not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	var req resetRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if !gamification.ValidResetScope(req.Scope) {
+		scopes := make([]string, len(gamification.ResetScopes))
+		for i := 0; i < len(scopes); i++ {
+			scopes[i] = string(gamification.ResetScopes[i])
+		}
+		http.Error(w, "scope must be one of "+strings.Join(scopes, ", "), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if req.Confirm == "" {
+		token, expires, err := s.requestReset(req.Scope)
+		if err != nil {
+			slog.Error("generate reset token failed", "error", err)
+			http.Error(w, "failed to generate confirmation token", http.StatusInternalServerError)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(resetResponse{Scope: req.Scope, Confirm: token, ExpiresAt: &expires})
+		return
+	}
+
+	if !s.confirmReset(req.Scope, req.Confirm) {
+		http.Error(w, "confirmation token is invalid or expired; request a new one", http.StatusConflict)
+		return
+	}
+	if err := s.tracker.Reset(req.Scope); err != nil {
+		if errors.Is(err, gamification.ErrUnknownResetScope) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		slog.Error("gamification stats reset not saved", "scope", req.Scope, "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	slog.Info("gamification stats reset", "scope", req.Scope)
+	_ = json.NewEncoder(w).Encode(resetResponse{Scope: req.Scope, Reset: true})
+}
+
+// requestReset records a pending reset of scope and returns the token
+// that confirms it and when the token expires.
+func (s *Server) requestReset(scope gamification.ResetScope) (string, time.Time, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", time.Time{}, err
+	}
+	token := hex.EncodeToString(b)
+	now := time.Now()
+	expires := now.Add(resetConfirmTTL).UTC().Truncate(time.Second)
+
+	s.resetMu.Lock()
+	defer s.resetMu.Unlock()
+	if s.pendingResets == nil {
+		s.pendingResets = make(map[string]pendingReset)
+	}
+	for t, p := range s.pendingResets {
+		if now.After(p.expires) {
+			delete(s.pendingResets, t)
+		}
+	}
+	s.pendingResets[token] = pendingReset{scope: scope, expires: expires}
+	return token, expires, nil
+}
+
+// confirmReset reports whether token confirms a pending reset of scope,
+// using the token up either way.
+func (s *Server) confirmReset(scope gamification.ResetScope, token string) bool {
+	s.resetMu.Lock()
+	defer s.resetMu.Unlock()
+	p, ok := s.pendingResets[token]
+	if !ok {
+		return false
+	}
+	delete(s.pendingResets, token)
+	return p.scope == scope && !time.Now().After(p.expires)
+}
@@ -0,0 +1,168 @@
+package ws
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/agent-racer/backend/internal/config"
+	"github.com/agent-racer/backend/internal/gamification"
+	"github.com/agent-racer/backend/internal/heats"
+	"github.com/agent-racer/backend/internal/launch"
+	"github.com/agent-racer/backend/internal/recap"
+	"github.com/agent-racer/backend/internal/session"
+	sdk "github.com/agent-racer/backend/pkg/client"
+)
+
+// jsonFields returns the wire names of t's fields, following embedded
+// structs and skipping json:"-".
+func jsonFields(t reflect.Type) []string {
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" || !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			names = append(names, jsonFields(f.Type)...)
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// TestSDKMatchesWireTypes keeps pkg/client in step with what the server
+// sends: a field added here must be added there.
+func TestSDKMatchesWireTypes(t *testing.T) {
+	pairs := []struct {
+		server, sdk any
+	}{
+		// sdk.SessionState serves both the broadcasts and GetSession, which
+		// adds the detail-only fields.
+		{SessionDetail{}, sdk.SessionState{}},
+		{session.SubagentState{}, sdk.SubagentState{}},
+		{session.TokenBreakdown{}, sdk.TokenBreakdown{}},
+		{session.TeamInfo{}, sdk.TeamInfo{}},
+		{SnapshotPayload{}, sdk.SnapshotPayload{}},
+		{DeltaPayload{}, sdk.DeltaPayload{}},
+		{CompletionPayload{}, sdk.CompletionPayload{}},
+		{EquippedPayload{}, sdk.EquippedPayload{}},
+		{BattlePassProgressPayload{}, sdk.BattlePassProgressPayload{}},
+		{AchievementRewardPayload{}, sdk.AchievementRewardPayload{}},
+		{AchievementUnlockedPayload{}, sdk.AchievementUnlockedPayload{}},
+		{AchievementBatchPayload{}, sdk.AchievementBatchPayload{}},
+		{SourceHealthPayload{}, sdk.SourceHealthPayload{}},
+		{SourceHealthTransition{}, sdk.SourceHealthTransition{}},
+		{SourceHealthHistory{}, sdk.SourceHealthHistory{}},
+		{ExternalSessionUpdate{}, sdk.ExternalSessionUpdate{}},
+		{ExternalSessionResult{}, sdk.ExternalSessionResult{}},
+		{OvertakePayload{}, sdk.OvertakePayload{}},
+		{ServerShutdownPayload{}, sdk.ServerShutdownPayload{}},
+		{UpdateAvailablePayload{}, sdk.UpdateAvailablePayload{}},
+		{DirectorFocusPayload{}, sdk.DirectorFocusPayload{}},
+		{CommentaryPayload{}, sdk.CommentaryPayload{}},
+		{SoundCuePayload{}, sdk.SoundCuePayload{}},
+		{LapCompletedPayload{}, sdk.LapCompletedPayload{}},
+		{CacheCollapsePayload{}, sdk.CacheCollapsePayload{}},
+		{ApprovalNeededPayload{}, sdk.ApprovalNeededPayload{}},
+		{WatchdogAlertPayload{}, sdk.WatchdogAlertPayload{}},
+		{MilestonePayload{}, sdk.MilestonePayload{}},
+		{ModelChangedPayload{}, sdk.ModelChangedPayload{}},
+		{SubagentPayload{}, sdk.SubagentPayload{}},
+		{SubagentBudgetPayload{}, sdk.SubagentBudgetPayload{}},
+		{PresencePayload{}, sdk.PresencePayload{}},
+		{HelloRequest{}, sdk.HelloRequest{}},
+		{ReactionRequest{}, sdk.ReactionRequest{}},
+		{ReactionPayload{}, sdk.ReactionPayload{}},
+		{PreferencesRequest{}, sdk.PreferencesRequest{}},
+		{PreferencesPayload{}, sdk.PreferencesPayload{}},
+		{GlanceResponse{}, sdk.GlanceResponse{}},
+		{GlanceSession{}, sdk.GlanceSession{}},
+		{GlanceTier{}, sdk.GlanceTier{}},
+		{EditorStatus{}, sdk.EditorStatus{}},
+		{EditorSession{}, sdk.EditorSession{}},
+		{CurrentSessionResponse{}, sdk.CurrentSessionResponse{}},
+		{SessionNameRequest{}, sdk.SessionNameRequest{}},
+		{heats.Finish{}, sdk.HeatFinish{}},
+		{heats.Standing{}, sdk.HeatStanding{}},
+		{heats.Heat{}, sdk.Heat{}},
+		{heats.Request{}, sdk.HeatRequest{}},
+		{launch.Stage{}, sdk.PipelineStage{}},
+		{launch.PipelineRun{}, sdk.PipelineRun{}},
+		{launch.Template{}, sdk.LaunchTemplate{}},
+		{launch.Request{}, sdk.LaunchRequest{}},
+		{launch.Pipeline{}, sdk.Pipeline{}},
+		{launch.PipelineRequest{}, sdk.PipelineRequest{}},
+		{pipelinesResponse{}, sdk.PipelinesResponse{}},
+		{CatchUpPayload{}, sdk.CatchUpPayload{}},
+		{gamification.XPEntry{}, sdk.XPEntry{}},
+		{gamification.Equipped{}, sdk.Equipped{}},
+		{gamification.BattlePass{}, sdk.BattlePass{}},
+		{gamification.ChallengeProgress{}, sdk.ChallengeProgress{}},
+		{gamification.DailyQuests{}, sdk.DailyQuests{}},
+		{gamification.QuestProgress{}, sdk.QuestProgress{}},
+		{gamification.SeasonTotals{}, sdk.SeasonTotals{}},
+		{gamification.CurrentSeason{}, sdk.CurrentSeason{}},
+		{gamification.ArchivedSeason{}, sdk.ArchivedSeason{}},
+		{gamification.SeasonHistory{}, sdk.SeasonHistory{}},
+		{resetResponse{}, sdk.StatsReset{}},
+		{recap.Recap{}, sdk.Recap{}},
+		{recap.ProjectTotal{}, sdk.RecapProject{}},
+		{recap.Cost{}, sdk.RecapCost{}},
+		{recap.SessionSummary{}, sdk.RecapSession{}},
+		{recap.Achievement{}, sdk.RecapAchievement{}},
+		{recap.ModelShare{}, sdk.RecapModelShare{}},
+		{achievementResponse{}, sdk.AchievementResponse{}},
+		{heatmapResponse{}, sdk.StatsHeatmap{}},
+		{session.TailEntry{}, sdk.TailEntry{}},
+		{session.TailResponse{}, sdk.TailResponse{}},
+		{session.ContextComposition{}, sdk.ContextComposition{}},
+		{session.ContextMessages{}, sdk.ContextMessages{}},
+		{session.ContextToolResult{}, sdk.ContextToolResult{}},
+		{session.ContextFileRead{}, sdk.ContextFileRead{}},
+		{config.SoundConfig{}, sdk.SoundConfig{}},
+		{VersionInfo{}, sdk.VersionInfo{}},
+		{healthzResponse{}, sdk.Health{}},
+	}
+	for _, p := range pairs {
+		st, ct := reflect.TypeOf(p.server), reflect.TypeOf(p.sdk)
+		if got, want := jsonFields(ct), jsonFields(st); !slices.Equal(got, want) {
+			t.Errorf("sdk.%s fields = %v, want %v (from %s)", ct.Name(), got, want, st)
+		}
+	}
+
+	// Stats carries only what clients use, but must not invent fields.
+	server := jsonFields(reflect.TypeOf(gamification.Stats{}))
+	for _, f := range jsonFields(reflect.TypeOf(sdk.Stats{})) {
+		if !slices.Contains(server, f) {
+			t.Errorf("sdk.Stats has %q, which gamification.Stats does not send", f)
+		}
+	}
+}
+
+func TestSDKKnowsEveryMessageType(t *testing.T) {
+	types := []MessageType{
+		MsgSnapshot, MsgDelta, MsgCompletion, MsgEquipped, MsgError,
+		MsgAchievementUnlocked, MsgAchievementBatch, MsgSourceHealth, MsgBattlePassProgress,
+		MsgOvertake, MsgServerShutdown, MsgUpdateAvailable, MsgDirectorFocus,
+		MsgCommentary, MsgSoundCue, MsgLapCompleted, MsgHeatStandings,
+		MsgPipelineUpdate, MsgCatchUp, MsgCacheCollapse, MsgApprovalNeeded,
+		MsgModelChanged, MsgPreferences, MsgRaceFinished,
+		MsgSubagentStarted, MsgSubagentCompleted, MsgSubagentBudget, MsgPresence,
+		MsgReaction, MsgWatchdogAlert, MsgMilestone,
+	}
+	for _, mt := range types {
+		v, err := sdk.Decode(sdk.WSMessage{Type: sdk.MessageType(mt), Payload: []byte(`{}`)})
+		if err != nil || v == nil {
+			t.Errorf("sdk.Decode(%s) = %v, %v; want a typed payload", mt, v, err)
+		}
+	}
+}
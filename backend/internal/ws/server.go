@@ -12,14 +12,24 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/agent-racer/backend/internal/benchmark"
+	"github.com/agent-racer/backend/internal/chatops"
 	"github.com/agent-racer/backend/internal/config"
+	"github.com/agent-racer/backend/internal/director"
 	"github.com/agent-racer/backend/internal/gamification"
+	"github.com/agent-racer/backend/internal/heats"
+	"github.com/agent-racer/backend/internal/launch"
 	"github.com/agent-racer/backend/internal/replay"
 	"github.com/agent-racer/backend/internal/session"
+	"github.com/agent-racer/backend/internal/share"
+	"github.com/agent-racer/backend/internal/status"
+	"github.com/agent-racer/backend/internal/tokenizer"
 	"github.com/agent-racer/backend/internal/tracks"
+	"github.com/agent-racer/backend/internal/widget"
 	"github.com/gorilla/websocket"
 )
 
@@ -75,7 +85,25 @@ type Server struct {
 	wsAuthRateLimiter *clientRateLimiter
 	healthHook        func() []SourceHealthPayload
 	healthCheck       HealthCheckFunc
+	healthHistory     func() []SourceHealthHistory
+	externalSessions  ExternalSessionFunc
+	versionInfo       VersionInfo
+	updateStatus      func() *UpdateAvailablePayload
+	shareManager      *share.Manager
+	statusBoard       *status.Board
+	director          *director.Director
+	heats             *heats.Manager
+	benchmarks        *benchmark.Runner
+	launcher          *launch.Launcher
+	chatops           *chatops.Bridge
+	recapHistory      RecapHistoryFunc
 	startTime         time.Time
+
+	openAPIOnce sync.Once
+	openAPI     []byte
+
+	resetMu       sync.Mutex
+	pendingResets map[string]pendingReset // confirmation token -> reset
 }
 
 func NewServer(cfg *config.Config, store *session.Store, broadcaster *Broadcaster, frontendDir string, dev bool, embeddedHandler http.Handler, allowedOrigins []string, authToken string) *Server {
@@ -137,6 +165,12 @@ func (s *Server) SetHealthCheck(fn HealthCheckFunc) {
 	s.healthCheck = fn
 }
 
+// SetHealthHistory configures the function used by
+// /api/health/sources/history. Must be called before SetupRoutes.
+func (s *Server) SetHealthHistory(fn func() []SourceHealthHistory) {
+	s.healthHistory = fn
+}
+
 // SetTrackHandler configures the track handler used by /api/tracks endpoints.
 // Must be called before SetupRoutes.
 func (s *Server) SetTrackHandler(h *tracks.Handler) {
@@ -188,12 +222,41 @@ func (s *Server) SetupRoutes(mux *http.ServeMux) {
 	apiMux := http.NewServeMux()
 	apiMux.HandleFunc("/api/sessions", s.handleSessions)
 	apiMux.HandleFunc("/api/sessions/", s.handleSessionRoutes)
+	apiMux.HandleFunc("/api/sessions/current", s.handleCurrentSession)
+	apiMux.HandleFunc("/api/tmux/", s.handleTmuxPane)
+	apiMux.HandleFunc("/api/projects", s.handleProjects)
+	apiMux.HandleFunc("/api/glance", s.handleGlance)
+	apiMux.HandleFunc("/api/editor/status", s.handleEditorStatus)
 	apiMux.HandleFunc("/api/config", s.handleConfig)
 	apiMux.HandleFunc("/api/stats", s.handleStats)
+	apiMux.HandleFunc("/api/stats/heatmap", s.handleStatsHeatmap)
+	apiMux.HandleFunc("/api/stats/card.svg", s.handleStatCard)
 	apiMux.HandleFunc("/api/achievements", s.handleAchievements)
+	apiMux.HandleFunc("/api/achievements/ack", s.handleAchievementsAck)
 	apiMux.HandleFunc("/api/equip", s.handleEquip)
 	apiMux.HandleFunc("/api/unequip", s.handleUnequip)
 	apiMux.HandleFunc("/api/challenges", s.handleChallenges)
+	apiMux.HandleFunc("/api/quests", s.handleQuests)
+	apiMux.HandleFunc("/api/gamification/seasons", s.handleSeasons)
+	apiMux.HandleFunc("/api/gamification/reset", s.handleReset)
+	apiMux.HandleFunc("/api/recap", s.handleRecap)
+	apiMux.HandleFunc("/api/recap.svg", s.handleRecap)
+	apiMux.HandleFunc("/api/debug/broadcaster", s.handleDebugBroadcaster)
+	apiMux.HandleFunc("/api/debug/store", s.handleDebugStore)
+	apiMux.HandleFunc("/api/debug/store/at", s.handleDebugStoreAt)
+	apiMux.HandleFunc("/api/admin/clients", s.handleAdminClients)
+	apiMux.HandleFunc("/api/version", s.handleVersion)
+	apiMux.HandleFunc("/api/director", s.handleDirector)
+	apiMux.HandleFunc("/api/heats", s.handleHeats)
+	apiMux.HandleFunc("/api/heats/", s.handleHeat)
+	apiMux.HandleFunc("/api/benchmarks", s.handleBenchmarks)
+	apiMux.HandleFunc("/api/benchmarks/run", s.handleBenchmarkRun)
+	apiMux.HandleFunc("/api/launch", s.handleLaunch)
+	apiMux.HandleFunc("/api/pipelines", s.handlePipelines)
+	apiMux.HandleFunc("/api/chatops/slack", s.handleSlackCommand)
+	apiMux.HandleFunc("/api/openapi.json", s.handleOpenAPI)
+	apiMux.HandleFunc("/api/health/sources/history", s.handleHealthHistory)
+	apiMux.HandleFunc("/api/external/sessions/", s.handleExternalSession)
 
 	if s.replayHandler != nil {
 		s.replayHandler.RegisterRoutes(apiMux)
@@ -215,14 +278,45 @@ func (s *Server) SetupRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("/api/health", s.handleHealth)
 	mux.Handle("/ws", s.rateLimitWS(http.HandlerFunc(s.handleWS)))
 	mux.Handle("/api/", s.rateLimitAPI(apiMux))
+	mux.Handle("/graphql", s.rateLimitAPI(http.HandlerFunc(s.handleGraphQL)))
+	if s.shareManager != nil {
+		mux.Handle(share.RoutePrefix, s.rateLimitAPI(s.shareManager))
+	}
+	if s.statusBoard != nil {
+		mux.Handle(status.Route, s.rateLimitAPI(http.HandlerFunc(s.handleStatus)))
+	}
+	mux.Handle(widget.RoutePrefix, s.rateLimitAPI(widget.NewHandler(func() []string {
+		return s.Config().Embed.FrameAncestors
+	}, s.authorizeEmbed, s.embedSession)))
 
+	var frontend http.Handler
 	if s.dev {
 		slog.Info("serving frontend from filesystem", "dir", s.frontendDir)
-		mux.Handle("/", http.FileServer(http.Dir(s.frontendDir)))
+		frontend = http.FileServer(http.Dir(s.frontendDir))
 	} else if s.embeddedHandler != nil {
 		slog.Info("serving embedded frontend")
-		mux.Handle("/", s.embeddedHandler)
+		frontend = s.embeddedHandler
 	}
+	if frontend != nil {
+		mux.Handle("/", frontend)
+		mux.Handle(overlayPrefix, overlayPage(frontend))
+	}
+}
+
+// overlayPrefix is where the stream overlays live. Every overlay is the
+// same page; it reads which one to show from the path.
+const overlayPrefix = "/overlay/"
+
+// overlayPage serves overlay.html from the frontend for any path under
+// overlayPrefix, so /overlay/track and /overlay/leaderboard need no files
+// of their own.
+func overlayPage(frontend http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r2 := r.Clone(r.Context())
+		r2.URL.Path = "/overlay.html"
+		r2.URL.RawPath = ""
+		frontend.ServeHTTP(w, r2)
+	})
 }
 
 // wsAuthMessage is the first message a WebSocket client sends to authenticate.
@@ -242,8 +336,8 @@ func (s *Server) handleWS(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Clients only send small messages (auth, resync). Limit inbound
-	// message size to 4 KiB to prevent memory abuse.
+	// Clients only send small messages (auth, resync, preferences). Limit
+	// inbound message size to 4 KiB to prevent memory abuse.
 	conn.SetReadLimit(4096)
 
 	if s.authToken != "" {
@@ -265,12 +359,20 @@ func (s *Server) handleWS(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	c, err := s.broadcaster.AddClient(conn)
+	// A client returning from sleep names itself and the last seq it saw
+	// to be sent what it missed.
+	clientID := r.URL.Query().Get("client")
+	var since uint64
+	if clientID != "" {
+		since, _ = strconv.ParseUint(r.URL.Query().Get("since"), 10, 64)
+	}
+	c, err := s.broadcaster.AddResumingClient(conn, clientID, since, ParseSnapshotOptions(r.URL.Query()))
 	if err != nil {
 		slog.Warn("websocket rejected", "addr", r.RemoteAddr, "error", err)
 		return
 	}
 	slog.Info("websocket client connected", "addr", r.RemoteAddr)
+	s.sendPreferences(c, PreferencesRequest{})
 
 	go func() {
 		defer func() {
@@ -284,9 +386,22 @@ func (s *Server) handleWS(w http.ResponseWriter, r *http.Request) {
 			}
 			var req struct {
 				Type string `json:"type"`
+				PreferencesRequest
+				HelloRequest
+				ReactionRequest
+			}
+			if json.Unmarshal(msg, &req) != nil {
+				continue
 			}
-			if json.Unmarshal(msg, &req) == nil && req.Type == "resync" {
+			switch req.Type {
+			case "resync":
 				s.broadcaster.SendSnapshot(c)
+			case "preferences":
+				s.sendPreferences(c, req.PreferencesRequest)
+			case "hello":
+				s.broadcaster.Introduce(c, req.HelloRequest)
+			case "reaction":
+				s.react(c, req.ReactionRequest)
 			}
 		}
 	}()
@@ -325,11 +440,154 @@ func (s *Server) handleSessions(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	sessions, err := s.rankedSessions(r.URL.Query().Get("metric"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	sessions := s.broadcaster.FilterSessions(s.store.GetAll())
 	_ = json.NewEncoder(w).Encode(sessions)
 }
 
+// rankedSessions returns the privacy-filtered sessions in position order.
+// A non-empty metric re-ranks this result only; positions elsewhere follow
+// race.progress_metric.
+func (s *Server) rankedSessions(metric string) ([]*session.SessionState, error) {
+	sessions := s.broadcaster.FilterSessions(s.store.GetAll())
+	if metric != "" {
+		m := session.ProgressMetric(metric)
+		if !m.Valid() {
+			return nil, fmt.Errorf("unknown metric %q", metric)
+		}
+		sessions, _ = session.AssignPositions(nil, sessions, m)
+	}
+	session.SortByPosition(sessions)
+	return sessions, nil
+}
+
+// handleProjects returns sessions grouped by project. Git worktrees are
+// grouped under their primary repository, with their labels listed in each
+// project's worktrees field.
+func (s *Server) handleProjects(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.authorize(r) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	sessions := s.broadcaster.FilterSessions(s.store.GetAll())
+	_ = json.NewEncoder(w).Encode(session.ComputeTeams(sessions))
+}
+
+// handleDebugBroadcaster reports broadcaster queue depth, throttle timing,
+// snapshot health and per-client send lag.
+func (s *Server) handleDebugBroadcaster(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.authorize(r) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(s.broadcaster.Metrics())
+}
+
+// handleDebugStore reports the estimated memory the session store holds:
+// its sessions and their subagents, and the superseded states its history
+// and event log keep alive.
+func (s *Server) handleDebugStore(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.authorize(r) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(s.store.Metrics())
+}
+
+// storeAtResponse is the body of /api/debug/store/at.
+type storeAtResponse struct {
+	Requested time.Time               `json:"requested"`
+	At        time.Time               `json:"at"` // when the store last changed before requested
+	Sessions  []*session.SessionState `json:"sessions"`
+}
+
+// handleDebugStoreAt returns what the session store held at a past moment,
+// from the history kept when debug.store_history is set. t is RFC 3339 or
+// Unix seconds.
+func (s *Server) handleDebugStoreAt(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.authorize(r) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if s.store.HistoryWindow() <= 0 {
+		http.Error(w, "store history is off; set debug.store_history", http.StatusServiceUnavailable)
+		return
+	}
+
+	t, err := parseTimeParam(r.URL.Query().Get("t"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sessions, at, ok := s.store.StateAt(t)
+	if !ok {
+		http.Error(w, "no store history at that time", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(storeAtResponse{
+		Requested: t,
+		At:        at,
+		Sessions:  s.broadcaster.FilterSessions(sessions),
+	})
+}
+
+// parseTimeParam parses an RFC 3339 timestamp or Unix seconds.
+func parseTimeParam(v string) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, fmt.Errorf("missing t")
+	}
+	if secs, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return time.Unix(secs, 0).UTC(), nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("t must be RFC 3339 or Unix seconds, got %q", v)
+	}
+	return t, nil
+}
+
+type probeSource struct {
+	Source string             `json:"source"`
+	Status SourceHealthStatus `json:"status"`
+	Error  string             `json:"error,omitempty"`
+}
+
+// probeResponse is the body of /api/health.
+type probeResponse struct {
+	Status  string        `json:"status"`
+	Uptime  string        `json:"uptime"`
+	Sources []probeSource `json:"sources,omitempty"`
+}
+
 // handleHealth serves liveness and readiness probes at /api/health.
 // No authentication or rate limiting — probes must always be reachable.
 //
@@ -341,20 +599,9 @@ func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	type sourceStatus struct {
-		Source string             `json:"source"`
-		Status SourceHealthStatus `json:"status"`
-		Error  string             `json:"error,omitempty"`
-	}
-	type healthResponse struct {
-		Status  string         `json:"status"`
-		Uptime  string         `json:"uptime"`
-		Sources []sourceStatus `json:"sources,omitempty"`
-	}
-
 	probe := r.URL.Query().Get("probe")
 
-	resp := healthResponse{
+	resp := probeResponse{
 		Status: "ok",
 		Uptime: time.Since(s.startTime).Truncate(time.Second).String(),
 	}
@@ -362,7 +609,7 @@ func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 	if probe == "ready" && s.healthCheck != nil {
 		snapshots := s.healthCheck()
 		for _, sh := range snapshots {
-			resp.Sources = append(resp.Sources, sourceStatus{
+			resp.Sources = append(resp.Sources, probeSource{
 				Source: sh.Source,
 				Status: sh.Status,
 				Error:  sh.LastError,
@@ -380,6 +627,30 @@ func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 	_ = json.NewEncoder(w).Encode(resp)
 }
 
+// handleHealthHistory serves each source's recent health transitions,
+// including the ones not broadcast while the source was flapping.
+func (s *Server) handleHealthHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.authorize(r) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if s.healthHistory == nil {
+		http.Error(w, "source health not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	history := s.healthHistory()
+	if history == nil {
+		history = []SourceHealthHistory{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(history)
+}
+
 func (s *Server) handleConfig(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
@@ -409,15 +680,44 @@ func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
 	_ = json.NewEncoder(w).Encode(s.tracker.Stats())
 }
 
+// heatmapResponse is the JSON shape returned by /api/stats/heatmap. Rows
+// are weekdays from Sunday, columns hours of the day in TimeZone.
+type heatmapResponse struct {
+	TimeZone string `json:"timeZone"`
+	gamification.Heatmap
+}
+
+// handleStatsHeatmap returns when sessions start and complete, for a
+// weekday by hour-of-day chart.
+func (s *Server) handleStatsHeatmap(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.authorize(r) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if s.tracker == nil {
+		http.Error(w, "stats not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	heatmap, zone := s.tracker.Heatmap()
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(heatmapResponse{TimeZone: zone, Heatmap: heatmap})
+}
+
 // achievementResponse is the JSON shape returned by /api/achievements.
 type achievementResponse struct {
-	ID          string     `json:"id"`
-	Name        string     `json:"name"`
-	Description string     `json:"description"`
-	Tier        string     `json:"tier"`
-	Category    string     `json:"category"`
-	Unlocked    bool       `json:"unlocked"`
-	UnlockedAt  *time.Time `json:"unlockedAt,omitempty"`
+	ID           string     `json:"id"`
+	Name         string     `json:"name"`
+	Description  string     `json:"description"`
+	Tier         string     `json:"tier"`
+	Category     string     `json:"category"`
+	Unlocked     bool       `json:"unlocked"`
+	UnlockedAt   *time.Time `json:"unlockedAt,omitempty"`
+	Acknowledged bool       `json:"acknowledged"` // false for an unlock no client has shown yet
 }
 
 func (s *Server) handleAchievements(w http.ResponseWriter, r *http.Request) {
@@ -426,15 +726,35 @@ func (s *Server) handleAchievements(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	all := s.achievements()
+	if r.URL.Query().Get("unseen") == "true" {
+		unseen := []achievementResponse{}
+		for i := 0; i < len(all); i++ {
+			if all[i].Unlocked && !all[i].Acknowledged {
+				unseen = append(unseen, all[i])
+			}
+		}
+		all = unseen
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(all)
+}
+
+// achievements lists every registered achievement with its unlock time.
+func (s *Server) achievements() []achievementResponse {
 	registry := s.achievementEngine.Registry()
+	lang := s.Config().Display.Language
 
-	var unlocked map[string]time.Time
+	var unlocked, acknowledged map[string]time.Time
 	if s.tracker != nil {
-		unlocked = s.tracker.Stats().AchievementsUnlocked
+		registry = s.tracker.Achievements()
+		stats := s.tracker.Stats()
+		unlocked, acknowledged = stats.AchievementsUnlocked, stats.AchievementsAcknowledged
 	}
 
 	out := make([]achievementResponse, 0, len(registry))
 	for _, a := range registry {
+		a = a.Localize(lang)
 		resp := achievementResponse{
 			ID:          a.ID,
 			Name:        a.Name,
@@ -445,12 +765,11 @@ func (s *Server) handleAchievements(w http.ResponseWriter, r *http.Request) {
 		if t, ok := unlocked[a.ID]; ok {
 			resp.Unlocked = true
 			resp.UnlockedAt = &t
+			_, resp.Acknowledged = acknowledged[a.ID]
 		}
 		out = append(out, resp)
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(out)
+	return out
 }
 
 func (s *Server) handleChallenges(w http.ResponseWriter, r *http.Request) {
@@ -467,6 +786,75 @@ func (s *Server) handleChallenges(w http.ResponseWriter, r *http.Request) {
 	_ = json.NewEncoder(w).Encode(s.tracker.Challenges())
 }
 
+func (s *Server) handleQuests(w http.ResponseWriter, r *http.Request) {
+	if !s.authorize(r) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if s.tracker == nil {
+		http.Error(w, "stats not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(s.tracker.Quests())
+}
+
+// handleSeasons reports the battle pass season in progress and the
+// archived ones, each with its final tier and XP and what it counted.
+func (s *Server) handleSeasons(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.authorize(r) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if s.tracker == nil {
+		http.Error(w, "stats not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(s.tracker.Seasons())
+}
+
+// ackRequest is the body of POST /api/achievements/ack.
+type ackRequest struct {
+	IDs []string `json:"ids"` // empty acknowledges every unlock
+}
+
+// ackResponse lists the unlocks a POST /api/achievements/ack newly
+// acknowledged.
+type ackResponse struct {
+	Acknowledged []string `json:"acknowledged"`
+}
+
+// handleAchievementsAck records that a client has shown unlock toasts, so
+// they are no longer listed by /api/achievements?unseen=true.
+func (s *Server) handleAchievementsAck(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.authorize(r) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if s.tracker == nil {
+		http.Error(w, "stats not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	var req ackRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(ackResponse{Acknowledged: s.tracker.AcknowledgeAchievements(req.IDs)})
+}
+
 type equipRequest struct {
 	RewardID string `json:"rewardId"`
 	Slot     string `json:"slot"`
@@ -596,10 +984,10 @@ func (s *Server) handleSessionRoutes(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Parse: /api/sessions/{id}/{action}
+	// Parse: /api/sessions/{id}[/{action}]
 	path := strings.TrimPrefix(r.URL.Path, "/api/sessions/")
 	parts := strings.SplitN(path, "/", 2)
-	if len(parts) != 2 {
+	if parts[0] == "" {
 		http.Error(w, "not found", http.StatusNotFound)
 		return
 	}
@@ -610,16 +998,69 @@ func (s *Server) handleSessionRoutes(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if len(parts) == 1 {
+		s.handleSession(w, r, sessionID)
+		return
+	}
+
 	switch parts[1] {
 	case "focus":
 		s.handleFocus(w, r, sessionID)
 	case "tail":
 		s.handleTail(w, r, sessionID)
+	case "context":
+		s.handleContext(w, r, sessionID)
+	case "share":
+		s.handleShare(w, r, sessionID)
+	case "embed":
+		s.handleEmbed(w, r, sessionID)
+	case "name":
+		s.handleSessionName(w, r, sessionID)
 	default:
 		http.Error(w, "not found", http.StatusNotFound)
 	}
 }
 
+// SessionDetail is a session as GET /api/sessions/{id} returns it: its
+// state plus what is too bulky to broadcast with every update.
+type SessionDetail struct {
+	session.SessionState
+	// FilesTouched counts the tool calls that read or edited each file,
+	// keeping the session.MaxFilesTouched most touched.
+	FilesTouched map[string]int `json:"filesTouched,omitempty"`
+}
+
+// handleSession returns one session's state, privacy-filtered like the
+// list. Sessions the filter hides are reported as not found.
+func (s *Server) handleSession(w http.ResponseWriter, r *http.Request, sessionID string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	state, ok := s.visibleSession(sessionID)
+	if !ok {
+		http.Error(w, "session not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(SessionDetail{SessionState: *state, FilesTouched: state.FilesTouched})
+}
+
+// visibleSession returns session id as clients see it, or false if it does
+// not exist or the privacy filter hides it.
+func (s *Server) visibleSession(id string) (*session.SessionState, bool) {
+	state, ok := s.store.Get(id)
+	if !ok {
+		return nil, false
+	}
+	filtered := s.broadcaster.FilterSessions([]*session.SessionState{state})
+	if len(filtered) == 0 {
+		return nil, false
+	}
+	return filtered[0], true
+}
+
 func (s *Server) handleFocus(w http.ResponseWriter, r *http.Request, sessionID string) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
@@ -701,6 +1142,56 @@ func (s *Server) handleTail(w http.ResponseWriter, r *http.Request, sessionID st
 	_ = json.NewEncoder(w).Encode(resp)
 }
 
+// handleContext returns an approximate composition of the session's
+// current context window, read from its Claude log; other formats come
+// back empty. File paths are masked like
+// the session's own when the privacy filter masks working directories.
+func (s *Server) handleContext(w http.ResponseWriter, r *http.Request, sessionID string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	filter := s.broadcaster.privacyFilter()
+	state, ok := s.store.Get(sessionID)
+	if !ok || !filter.IsAllowed(state.WorkingDir) {
+		http.Error(w, "session not found", http.StatusNotFound)
+		return
+	}
+	if state.LogPath == "" {
+		http.Error(w, "session has no log file", http.StatusConflict)
+		return
+	}
+	if err := session.ValidateLogPath(state.LogPath); err != nil {
+		slog.Warn("context: invalid log path", "session", sessionID, "error", err)
+		http.Error(w, "invalid log path", http.StatusForbidden)
+		return
+	}
+
+	top := 10
+	if v := r.URL.Query().Get("top"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 && n <= 100 {
+			top = n
+		}
+	}
+
+	tok, ok := tokenizer.Lookup(s.Config().TokenNorm.Tokenizer)
+	if !ok {
+		tok, _ = tokenizer.Lookup(tokenizer.Default)
+	}
+	comp, err := session.ParseContextComposition(state.LogPath, top, tok.Count)
+	if err != nil {
+		slog.Error("context parse failed", "session", sessionID, "error", err)
+		http.Error(w, "failed to read log", http.StatusInternalServerError)
+		return
+	}
+	if filter.MaskWorkingDirs {
+		comp.MaskPaths(state.WorkingDir)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(comp)
+}
+
 func (s *Server) authorize(r *http.Request) bool {
 	if s.authToken == "" {
 		return true
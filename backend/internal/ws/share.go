@@ -0,0 +1,113 @@
+package ws
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/agent-racer/backend/internal/replay"
+	"github.com/agent-racer/backend/internal/session"
+	"github.com/agent-racer/backend/internal/share"
+)
+
+// maxShareTimelinePoints bounds the timeline stored with a share link.
+const maxShareTimelinePoints = 500
+
+// shareRequest is the optional body of POST /api/sessions/{id}/share.
+type shareRequest struct {
+	// TTL is a Go duration such as "2h"; empty uses share.default_ttl.
+	TTL string `json:"ttl"`
+	// Timeline includes the session's recorded progress over time.
+	Timeline bool `json:"timeline"`
+}
+
+// shareResponse is returned when a share link is created.
+type shareResponse struct {
+	ID        string    `json:"id"`
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// SetShareManager enables session share links and the public /share/ route.
+// Must be called before SetupRoutes.
+func (s *Server) SetShareManager(m *share.Manager) {
+	s.shareManager = m
+}
+
+func (s *Server) handleShare(w http.ResponseWriter, r *http.Request, sessionID string) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.shareManager == nil {
+		http.Error(w, "sharing is not available", http.StatusNotFound)
+		return
+	}
+
+	var req shareRequest
+	if r.ContentLength > 0 && !decodeBody(w, r, &req) {
+		return
+	}
+	ttl, ok := s.shareTTL(w, req.TTL)
+	if !ok {
+		return
+	}
+
+	state, ok := s.store.Get(sessionID)
+	if !ok {
+		http.Error(w, "session not found", http.StatusNotFound)
+		return
+	}
+	// Sessions hidden by the privacy filter cannot be shared either.
+	filtered := s.broadcaster.FilterSessions([]*session.SessionState{state})
+	if len(filtered) == 0 {
+		http.Error(w, "session not found", http.StatusNotFound)
+		return
+	}
+
+	var timeline []replay.TimelinePoint
+	if req.Timeline && s.replayHandler != nil {
+		points, err := s.replayHandler.SessionTimeline(filtered[0].ID, state.StartedAt, maxShareTimelinePoints)
+		if err != nil {
+			slog.Warn("share: timeline unavailable", "session", sessionID, "error", err)
+		}
+		timeline = points
+	}
+
+	sh, token, err := s.shareManager.Create(filtered[0], timeline, ttl)
+	if err != nil {
+		slog.Error("share: create failed", "session", sessionID, "error", err)
+		http.Error(w, "failed to create share link", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(shareResponse{
+		ID:        sh.ID,
+		URL:       s.Config().Server.Scheme() + "://" + r.Host + share.RoutePrefix + token,
+		ExpiresAt: sh.ExpiresAt,
+	})
+}
+
+// shareTTL parses the ttl a share or embed request asked for, defaulting
+// to share.default_ttl. It writes a 400 and returns false if the value is
+// invalid or over share.max_ttl.
+func (s *Server) shareTTL(w http.ResponseWriter, raw string) (time.Duration, bool) {
+	cfg := s.Config()
+	ttl := cfg.Share.DefaultTTL
+	if raw != "" {
+		d, err := time.ParseDuration(raw)
+		if err != nil || d <= 0 {
+			http.Error(w, "invalid ttl", http.StatusBadRequest)
+			return 0, false
+		}
+		ttl = d
+	}
+	if ttl > cfg.Share.MaxTTL {
+		http.Error(w, "ttl exceeds share.max_ttl ("+cfg.Share.MaxTTL.String()+")", http.StatusBadRequest)
+		return 0, false
+	}
+	return ttl, true
+}
@@ -0,0 +1,118 @@
+package ws
+
+import (
+	"net/url"
+	"strconv"
+
+	"github.com/agent-racer/backend/internal/session"
+)
+
+const (
+	// maxSnapshotPageSize caps the page_size a client may ask for.
+	maxSnapshotPageSize = 1000
+	// maxSnapshotPages caps how many messages one snapshot is split into,
+	// so that every page fits in a client's send queue at once. Pages grow
+	// past the requested size to stay under it.
+	maxSnapshotPages = 16
+)
+
+// SnapshotOptions say how a client wants snapshots framed. The zero value
+// sends each snapshot whole, subagents included.
+type SnapshotOptions struct {
+	PageSize      int  // sessions per snapshot message; 0 sends each snapshot in one
+	LazySubagents bool // leave subagents out of snapshots and list only how many there are
+}
+
+// ParseSnapshotOptions reads the page_size and subagents=lazy query
+// parameters of a /ws request. A page_size that isn't a positive number
+// sends snapshots whole; a larger one than maxSnapshotPageSize is capped.
+func ParseSnapshotOptions(q url.Values) SnapshotOptions {
+	var opts SnapshotOptions
+	if n, err := strconv.Atoi(q.Get("page_size")); err == nil && n > 0 {
+		opts.PageSize = min(n, maxSnapshotPageSize)
+	}
+	opts.LazySubagents = q.Get("subagents") == "lazy"
+	return opts
+}
+
+// snapshotFrames encodes payload as the messages a client with opts is
+// sent, all carrying seq: a single message unless opts asks for pages and
+// there are more sessions than fit on one.
+func snapshotFrames(payload SnapshotPayload, seq uint64, opts SnapshotOptions) ([][]byte, error) {
+	sessions := payload.Sessions
+	var counts map[string]int
+	if opts.LazySubagents {
+		sessions, counts = withoutSubagents(sessions)
+	}
+
+	pages := 1
+	size := len(sessions)
+	if opts.PageSize > 0 && len(sessions) > opts.PageSize {
+		size = max(opts.PageSize, (len(sessions)+maxSnapshotPages-1)/maxSnapshotPages)
+		pages = (len(sessions) + size - 1) / size
+	}
+
+	enc := encodeBuffers.Get().(*encodeBuffer)
+	defer encodeBuffers.Put(enc)
+	enc.err = nil
+
+	frames := make([][]byte, 0, pages)
+	for i := 0; i < pages; i++ {
+		page := payload
+		if pages > 1 {
+			page = SnapshotPayload{Page: i + 1, Pages: pages}
+			if i == pages-1 {
+				page.Teams = payload.Teams
+				page.SourceHealth = payload.SourceHealth
+			}
+		}
+		page.Sessions = sessions[i*size : min((i+1)*size, len(sessions))]
+		page.SubagentCounts = countsFor(page.Sessions, counts)
+
+		enc.payload = enc.snapshot(enc.payload[:0], page)
+		if enc.err != nil {
+			return nil, enc.err
+		}
+		msg := WSMessage{Type: MsgSnapshot, Seq: seq, Payload: enc.payload}
+		frames = append(frames, appendMessage(make([]byte, 0, len(enc.payload)+64), msg))
+	}
+	return frames, nil
+}
+
+// withoutSubagents returns copies of the sessions that have subagents with
+// the subagents left out, and how many each had by session ID.
+func withoutSubagents(sessions []*session.SessionState) ([]*session.SessionState, map[string]int) {
+	out := make([]*session.SessionState, len(sessions))
+	var counts map[string]int
+	for i := 0; i < len(sessions); i++ {
+		out[i] = sessions[i]
+		if len(sessions[i].Subagents) == 0 {
+			continue
+		}
+		if counts == nil {
+			counts = make(map[string]int)
+		}
+		counts[sessions[i].ID] = len(sessions[i].Subagents)
+		cp := *sessions[i]
+		cp.Subagents = nil
+		out[i] = &cp
+	}
+	return out, counts
+}
+
+// countsFor picks the entries of counts for the given sessions.
+func countsFor(sessions []*session.SessionState, counts map[string]int) map[string]int {
+	if len(counts) == 0 {
+		return nil
+	}
+	var out map[string]int
+	for i := 0; i < len(sessions); i++ {
+		if n, ok := counts[sessions[i].ID]; ok {
+			if out == nil {
+				out = make(map[string]int)
+			}
+			out[sessions[i].ID] = n
+		}
+	}
+	return out
+}
@@ -0,0 +1,135 @@
+package ws
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"testing"
+
+	"github.com/agent-racer/backend/internal/session"
+)
+
+func TestParseSnapshotOptions(t *testing.T) {
+	tests := []struct {
+		query string
+		want  SnapshotOptions
+	}{
+		{"", SnapshotOptions{}},
+		{"page_size=50", SnapshotOptions{PageSize: 50}},
+		{"page_size=0&subagents=lazy", SnapshotOptions{LazySubagents: true}},
+		{"page_size=lots&subagents=eager", SnapshotOptions{}},
+		{"page_size=100000", SnapshotOptions{PageSize: maxSnapshotPageSize}},
+	}
+	for _, tt := range tests {
+		q, _ := url.ParseQuery(tt.query)
+		if got := ParseSnapshotOptions(q); got != tt.want {
+			t.Errorf("ParseSnapshotOptions(%q) = %+v, want %+v", tt.query, got, tt.want)
+		}
+	}
+}
+
+// readSnapshots drains c's queue as snapshot messages.
+func readSnapshots(t *testing.T, c *client) ([]WSMessage, []SnapshotPayload) {
+	t.Helper()
+	var msgs []WSMessage
+	var pages []SnapshotPayload
+	for len(c.send) > 0 {
+		var msg WSMessage
+		var p SnapshotPayload
+		if err := json.Unmarshal(<-c.send, &msg); err != nil || msg.Type != MsgSnapshot {
+			t.Fatalf("message %d = %+v (%v), want a snapshot", len(msgs), msg, err)
+		}
+		if err := json.Unmarshal(msg.Payload, &p); err != nil {
+			t.Fatalf("unmarshal snapshot: %v", err)
+		}
+		msgs = append(msgs, msg)
+		pages = append(pages, p)
+	}
+	return msgs, pages
+}
+
+func TestBroadcastSnapshot_PagesForClientsThatAskForThem(t *testing.T) {
+	store := session.NewStore()
+	for i := 0; i < 5; i++ {
+		s := &session.SessionState{ID: fmt.Sprintf("s%d", i), Name: "api", WorkingDir: "/work/api"}
+		if i == 4 {
+			s.Subagents = []session.SubagentState{{ID: "t1", SessionID: s.ID}, {ID: "t2", SessionID: s.ID}}
+		}
+		store.Update(s)
+	}
+	b := newTestBroadcaster(store, nil)
+	whole := makeClient(b)
+	paged := makeClient(b)
+	paged.snapshot = SnapshotOptions{PageSize: 2, LazySubagents: true}
+
+	b.broadcastSnapshot()
+
+	_, pages := readSnapshots(t, whole)
+	if len(pages) != 1 || len(pages[0].Sessions) != 5 || pages[0].Pages != 0 {
+		t.Fatalf("whole snapshot = %+v, want one message with every session", pages)
+	}
+
+	msgs, pages := readSnapshots(t, paged)
+	if len(pages) != 3 {
+		t.Fatalf("paged snapshot came in %d messages, want 3", len(pages))
+	}
+	var sessions int
+	for i := 0; i < len(pages); i++ {
+		if pages[i].Page != i+1 || pages[i].Pages != 3 || msgs[i].Seq != msgs[0].Seq {
+			t.Errorf("page %d = %d of %d at seq %d, want %d of 3 at seq %d", i, pages[i].Page, pages[i].Pages, msgs[i].Seq, i+1, msgs[0].Seq)
+		}
+		if hasTeams := len(pages[i].Teams) > 0; hasTeams != (i == 2) {
+			t.Errorf("page %d teams = %v, want them on the last page only", i+1, pages[i].Teams)
+		}
+		for _, s := range pages[i].Sessions {
+			if len(s.Subagents) != 0 {
+				t.Errorf("session %s sent with subagents to a lazy client", s.ID)
+			}
+		}
+		sessions += len(pages[i].Sessions)
+	}
+	if sessions != 5 {
+		t.Errorf("pages carried %d sessions, want 5", sessions)
+	}
+	if counts := pages[2].SubagentCounts; counts["s4"] != 2 || len(counts) != 1 {
+		t.Errorf("subagent counts = %v, want s4: 2", counts)
+	}
+	if st, _ := store.Get("s4"); len(st.Subagents) != 2 {
+		t.Error("leaving subagents out of a snapshot changed the store")
+	}
+}
+
+func TestSnapshotFrames_CapsPageCount(t *testing.T) {
+	var payload SnapshotPayload
+	for i := 0; i < 100; i++ {
+		payload.Sessions = append(payload.Sessions, &session.SessionState{ID: fmt.Sprintf("s%d", i)})
+	}
+	frames, err := snapshotFrames(payload, 1, SnapshotOptions{PageSize: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(frames) > maxSnapshotPages {
+		t.Errorf("snapshot split into %d messages, want at most %d", len(frames), maxSnapshotPages)
+	}
+}
+
+func TestSendSnapshot_DropsClientWithoutRoomForEveryPage(t *testing.T) {
+	store := session.NewStore()
+	for i := 0; i < 4; i++ {
+		store.Update(&session.SessionState{ID: fmt.Sprintf("s%d", i)})
+	}
+	b := newTestBroadcaster(store, nil)
+	c := makeClient(b)
+	c.snapshot = SnapshotOptions{PageSize: 1}
+	for i := 0; i < cap(c.send)-2; i++ {
+		c.send <- []byte(`{}`)
+	}
+
+	b.SendSnapshot(c)
+	if len(c.send) != cap(c.send)-2 {
+		t.Errorf("queued %d pages of 4 with room for 2, want none", len(c.send)-(cap(c.send)-2))
+	}
+	if b.ClientCount() != 0 || b.Metrics().DroppedClients != 1 {
+		t.Errorf("clients = %d, dropped = %d; want the client dropped", b.ClientCount(), b.Metrics().DroppedClients)
+	}
+}
@@ -0,0 +1,25 @@
+package ws
+
+import (
+	"net/http"
+
+	"github.com/agent-racer/backend/internal/status"
+)
+
+// SetStatusBoard enables the /status route, which serves the board while
+// status.enabled is set. Must be called before SetupRoutes.
+func (s *Server) SetStatusBoard(b *status.Board) {
+	s.statusBoard = b
+}
+
+// handleStatus serves the public status page. It is deliberately not
+// authorized: the board only holds privacy-filtered sessions, and the page
+// is opt-in through config.
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	cfg := s.Config().Status
+	if !cfg.Enabled {
+		http.NotFound(w, r)
+		return
+	}
+	s.statusBoard.Serve(w, r, cfg.MinDuration)
+}
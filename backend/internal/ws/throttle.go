@@ -0,0 +1,76 @@
+package ws
+
+import "time"
+
+// throttleTuneWindow is how often the adaptive throttle measures the
+// message rate and retunes.
+const throttleTuneWindow = time.Second
+
+// autoThrottle adapts how long deltas are batched to the message rate
+// clients actually see. It is guarded by Broadcaster.flushMu.
+type autoThrottle struct {
+	max        time.Duration // longest batch; <= the configured throttle disables tuning
+	targetRate float64       // broadcast messages per second per client; 0 disables tuning
+
+	effective time.Duration // throttle in use; 0 until first tuned
+	rate      float64       // messages per second measured over the last window
+	since     time.Time     // start of the current window
+	sent      uint64        // broadcasts counter at since
+}
+
+// SetAutoThrottle lets the delta throttle grow from the configured
+// throttle up to maxThrottle when broadcasts run above targetRate messages
+// per second, batching more updates into each delta, and shrink back when
+// they fall below it or no client is connected. A zero targetRate or a
+// maxThrottle not above the throttle keeps it fixed. Safe for concurrent
+// use.
+func (b *Broadcaster) SetAutoThrottle(maxThrottle time.Duration, targetRate float64) {
+	b.flushMu.Lock()
+	b.auto.max = maxThrottle
+	b.auto.targetRate = targetRate
+	b.auto.effective = 0
+	b.flushMu.Unlock()
+}
+
+// throttleLocked returns the delta throttle in use. Caller must hold
+// flushMu.
+func (b *Broadcaster) throttleLocked() time.Duration {
+	if !b.autoEnabledLocked() || b.auto.effective == 0 {
+		return b.throttle
+	}
+	return b.auto.effective
+}
+
+func (b *Broadcaster) autoEnabledLocked() bool {
+	return b.auto.targetRate > 0 && b.auto.max > b.throttle
+}
+
+// retuneLocked measures the broadcast rate once per throttleTuneWindow and
+// moves the throttle halfway towards the value that would bring the rate
+// to the target. With no clients there is nothing to batch for, so it
+// drops straight back to the configured throttle. Caller must hold
+// flushMu.
+func (b *Broadcaster) retuneLocked(now time.Time, clients int) {
+	a := &b.auto
+	sent := b.broadcasts.Load()
+	elapsed := now.Sub(a.since)
+	if a.since.IsZero() || elapsed < throttleTuneWindow {
+		if a.since.IsZero() {
+			a.since, a.sent = now, sent
+		}
+		return
+	}
+	a.rate = float64(sent-a.sent) / elapsed.Seconds()
+	a.since, a.sent = now, sent
+	if !b.autoEnabledLocked() {
+		return
+	}
+
+	current := b.throttleLocked()
+	if clients == 0 {
+		a.effective = b.throttle
+		return
+	}
+	want := time.Duration(float64(current) * a.rate / a.targetRate)
+	a.effective = min(max((current+want)/2, b.throttle), a.max)
+}
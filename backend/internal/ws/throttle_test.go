@@ -0,0 +1,69 @@
+package ws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/agent-racer/backend/internal/session"
+)
+
+// tuneWindows runs n tuning windows in which the broadcaster sends rate
+// messages per second to clients, and returns the throttle in use after.
+func tuneWindows(b *Broadcaster, start time.Time, n int, rate float64, clients int) time.Duration {
+	b.flushMu.Lock()
+	defer b.flushMu.Unlock()
+	b.retuneLocked(start, clients)
+	for i := 1; i <= n; i++ {
+		b.broadcasts.Add(uint64(rate * throttleTuneWindow.Seconds()))
+		b.retuneLocked(start.Add(time.Duration(i)*throttleTuneWindow), clients)
+	}
+	return b.throttleLocked()
+}
+
+func TestAutoThrottle_LoosensUnderLoadAndTightensWhenQuiet(t *testing.T) {
+	b := newTestBroadcaster(session.NewStore(), nil)
+	b.throttle = 100 * time.Millisecond
+	b.SetAutoThrottle(time.Second, 4)
+	start := time.Now()
+
+	// 10 messages a second against a target of 4 stretches the throttle,
+	// but never past the ceiling.
+	busy := tuneWindows(b, start, 10, 10, 3)
+	if busy != time.Second {
+		t.Errorf("throttle under load = %s, want the 1s ceiling", busy)
+	}
+
+	// Back under target, it works its way down towards the floor.
+	quiet := tuneWindows(b, start.Add(time.Minute), 3, 1, 3)
+	if quiet >= busy || quiet < b.throttle {
+		t.Errorf("throttle when quiet = %s, want below %s and not under %s", quiet, busy, b.throttle)
+	}
+
+	m := b.Metrics()
+	if !m.ThrottleAuto || m.ThrottleMs != quiet.Milliseconds() || m.ThrottleMaxMs != 1000 || m.MessagesPerSec != 1 {
+		t.Errorf("metrics = %+v, want auto-tuning at %s and 1 message/s", m, quiet)
+	}
+
+	// Nobody to batch for: straight back to the configured throttle.
+	tuneWindows(b, start.Add(2*time.Minute), 10, 10, 3)
+	if idle := tuneWindows(b, start.Add(3*time.Minute), 1, 10, 0); idle != b.throttle {
+		t.Errorf("throttle with no clients = %s, want %s", idle, b.throttle)
+	}
+}
+
+func TestAutoThrottle_DisabledKeepsConfiguredThrottle(t *testing.T) {
+	b := newTestBroadcaster(session.NewStore(), nil)
+	b.throttle = 100 * time.Millisecond
+	if got := tuneWindows(b, time.Now(), 5, 50, 3); got != b.throttle {
+		t.Errorf("throttle without a target = %s, want %s", got, b.throttle)
+	}
+
+	// A ceiling not above the configured throttle leaves nothing to tune.
+	b.SetAutoThrottle(50*time.Millisecond, 4)
+	if got := tuneWindows(b, time.Now().Add(time.Minute), 5, 50, 3); got != b.throttle {
+		t.Errorf("throttle with a low ceiling = %s, want %s", got, b.throttle)
+	}
+	if b.Metrics().ThrottleAuto {
+		t.Error("metrics report auto-tuning that is off")
+	}
+}
@@ -0,0 +1,106 @@
+package ws
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// unlockWindow is how long achievement unlocks and battle pass progress
+// wait for more of the same before going out. A backfill or a burst of
+// sessions can unlock dozens of achievements in a moment; clients then get
+// one achievement_batch and one battlepass_progress instead.
+const unlockWindow = 250 * time.Millisecond
+
+// unlockBatch collects the gamification broadcasts of one unlockWindow.
+type unlockBatch struct {
+	mu           sync.Mutex
+	achievements []AchievementUnlockedPayload
+	progress     *BattlePassProgressPayload // merged; nil when none is pending
+	timer        *time.Timer
+}
+
+// BroadcastAchievement announces an unlocked achievement. It is sent on its
+// own as achievement_unlocked, or, when others unlock in the same window,
+// together with them as achievement_batch.
+func (b *Broadcaster) BroadcastAchievement(payload AchievementUnlockedPayload) {
+	u := &b.unlocks
+	u.mu.Lock()
+	u.achievements = append(u.achievements, payload)
+	b.scheduleUnlocksLocked()
+	u.mu.Unlock()
+}
+
+// BroadcastBattlePassProgress sends battle pass progress. Progress within
+// the same window is merged into one message carrying the latest totals
+// and every XP award and reward since the last one.
+func (b *Broadcaster) BroadcastBattlePassProgress(payload BattlePassProgressPayload) {
+	u := &b.unlocks
+	u.mu.Lock()
+	if u.progress == nil {
+		u.progress = &payload
+	} else {
+		u.progress.XP = payload.XP
+		u.progress.Tier = payload.Tier
+		u.progress.TierProgress = payload.TierProgress
+		u.progress.RecentXP = append(u.progress.RecentXP, payload.RecentXP...)
+		u.progress.Rewards = append(u.progress.Rewards, payload.Rewards...)
+	}
+	b.scheduleUnlocksLocked()
+	u.mu.Unlock()
+}
+
+// scheduleUnlocksLocked starts the window if it is not already running.
+// Caller must hold b.unlocks.mu.
+func (b *Broadcaster) scheduleUnlocksLocked() {
+	if b.unlocks.timer == nil {
+		b.unlocks.timer = time.AfterFunc(unlockWindow, b.flushUnlocks)
+	}
+}
+
+// flushUnlocks sends what the window collected: achievements first, with
+// one sound cue, then the merged battle pass progress.
+func (b *Broadcaster) flushUnlocks() {
+	u := &b.unlocks
+	u.mu.Lock()
+	achievements, progress := u.achievements, u.progress
+	u.achievements, u.progress = nil, nil
+	if u.timer != nil {
+		u.timer.Stop()
+		u.timer = nil
+	}
+	u.mu.Unlock()
+
+	switch len(achievements) {
+	case 0:
+	case 1:
+		msg, err := NewAchievementUnlockedMessage(achievements[0])
+		if err != nil {
+			slog.Error("broadcast achievement marshal failed", "error", err)
+			break
+		}
+		b.broadcast(msg)
+		b.BroadcastSoundCue(CueAchievement, "")
+	default:
+		byTier := make(map[string]int)
+		for i := 0; i < len(achievements); i++ {
+			byTier[achievements[i].Tier]++
+		}
+		msg, err := NewAchievementBatchMessage(AchievementBatchPayload{Achievements: achievements, ByTier: byTier})
+		if err != nil {
+			slog.Error("broadcast achievements marshal failed", "error", err)
+			break
+		}
+		b.broadcast(msg)
+		b.BroadcastSoundCue(CueAchievement, "")
+	}
+
+	if progress != nil {
+		msg, err := NewBattlePassProgressMessage(*progress)
+		if err != nil {
+			slog.Error("broadcast battle pass progress marshal failed", "error", err)
+			return
+		}
+		b.broadcast(msg)
+	}
+}
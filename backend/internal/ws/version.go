@@ -0,0 +1,51 @@
+package ws
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// VersionInfo describes the running server build, served by /api/version
+// so dashboards and the TUI can detect backend/frontend version skew.
+type VersionInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	BuildDate string `json:"buildDate,omitempty"`
+	GoVersion string `json:"goVersion,omitempty"`
+	// Frontend identifies the embedded frontend build. Empty when the
+	// frontend is served from the filesystem.
+	Frontend string `json:"frontend,omitempty"`
+	// Update is set when a newer release has been published.
+	Update *UpdateAvailablePayload `json:"update,omitempty"`
+}
+
+// SetUpdateStatus registers a function returning the pending update notice,
+// or nil when the server is up to date. Must be called before SetupRoutes.
+func (s *Server) SetUpdateStatus(fn func() *UpdateAvailablePayload) {
+	s.updateStatus = fn
+}
+
+// SetVersionInfo configures the build information reported by /api/version.
+// Must be called before SetupRoutes.
+func (s *Server) SetVersionInfo(info VersionInfo) {
+	s.versionInfo = info
+}
+
+func (s *Server) handleVersion(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.authorize(r) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	info := s.versionInfo
+	if s.updateStatus != nil {
+		info.Update = s.updateStatus()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(info)
+}
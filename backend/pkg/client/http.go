@@ -0,0 +1,470 @@
+package client
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// StatusError is returned when the server answers with a non-2xx status.
+type StatusError struct {
+	Method     string
+	Path       string
+	StatusCode int
+	Body       string // the server's error text
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s %s: %d %s", e.Method, e.Path, e.StatusCode, e.Body)
+}
+
+// HTTPClient makes REST calls to the Agent Racer server.
+type HTTPClient struct {
+	baseURL string
+	token   string
+	client  *http.Client
+}
+
+// NewHTTPClient creates a client targeting the given base URL (e.g. "http://127.0.0.1:8080").
+// If tlsCfg is non-nil, it is used for HTTPS connections.
+func NewHTTPClient(baseURL, token string, tlsCfg *tls.Config) *HTTPClient {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if tlsCfg != nil {
+		transport.TLSClientConfig = tlsCfg
+	}
+	return &HTTPClient{
+		baseURL: baseURL,
+		token:   token,
+		client:  &http.Client{Timeout: 10 * time.Second, Transport: transport},
+	}
+}
+
+// SetTimeout bounds each request, 10 seconds by default. Callers that must
+// answer quickly, such as shell prompts, lower it.
+func (c *HTTPClient) SetTimeout(d time.Duration) {
+	c.client.Timeout = d
+}
+
+// GetSessions fetches /api/sessions, ordered by position. A non-empty
+// metric ranks this response by it instead of the server's default.
+func (c *HTTPClient) GetSessions(metric string) ([]*SessionState, error) {
+	path := "/api/sessions"
+	if metric != "" {
+		path += "?metric=" + url.QueryEscape(metric)
+	}
+	var out []*SessionState
+	if err := c.get(path, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetSession fetches /api/sessions/{id}.
+func (c *HTTPClient) GetSession(sessionID string) (*SessionState, error) {
+	var s SessionState
+	if err := c.get("/api/sessions/"+url.PathEscape(sessionID), &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// GetProjects fetches /api/projects.
+func (c *HTTPClient) GetProjects() ([]TeamInfo, error) {
+	var out []TeamInfo
+	if err := c.get("/api/projects", &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetStats fetches /api/stats.
+func (c *HTTPClient) GetStats() (*Stats, error) {
+	var s Stats
+	if err := c.get("/api/stats", &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// GetStatsHeatmap fetches /api/stats/heatmap.
+func (c *HTTPClient) GetStatsHeatmap() (*StatsHeatmap, error) {
+	var h StatsHeatmap
+	if err := c.get("/api/stats/heatmap", &h); err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
+
+// GetAchievements fetches /api/achievements.
+func (c *HTTPClient) GetAchievements() ([]AchievementResponse, error) {
+	var out []AchievementResponse
+	if err := c.get("/api/achievements", &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetUnseenAchievements fetches /api/achievements?unseen=true: the unlocks
+// no client has acknowledged yet.
+func (c *HTTPClient) GetUnseenAchievements() ([]AchievementResponse, error) {
+	var out []AchievementResponse
+	if err := c.get("/api/achievements?unseen=true", &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AcknowledgeAchievements sends POST /api/achievements/ack for ids, or for
+// every unlock when none are given, and returns the IDs newly acknowledged.
+func (c *HTTPClient) AcknowledgeAchievements(ids ...string) ([]string, error) {
+	body := map[string][]string{"ids": ids}
+	var out struct {
+		Acknowledged []string `json:"acknowledged"`
+	}
+	if err := c.post("/api/achievements/ack", body, &out); err != nil {
+		return nil, err
+	}
+	return out.Acknowledged, nil
+}
+
+// GetChallenges fetches /api/challenges.
+func (c *HTTPClient) GetChallenges() ([]ChallengeProgress, error) {
+	var out []ChallengeProgress
+	if err := c.get("/api/challenges", &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetQuests fetches /api/quests.
+func (c *HTTPClient) GetQuests() (*DailyQuests, error) {
+	var out DailyQuests
+	if err := c.get("/api/quests", &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetSeasons fetches /api/gamification/seasons.
+func (c *HTTPClient) GetSeasons() (*SeasonHistory, error) {
+	var out SeasonHistory
+	if err := c.get("/api/gamification/seasons", &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ResetStats sends POST /api/gamification/reset for scope ("all",
+// "challenges", "battle_pass" or "peaks"). With an empty confirm nothing is
+// reset and the response carries the token to confirm with; sending that
+// token back within a minute performs the reset.
+func (c *HTTPClient) ResetStats(scope, confirm string) (*StatsReset, error) {
+	body := map[string]string{"scope": scope}
+	if confirm != "" {
+		body["confirm"] = confirm
+	}
+	var out StatsReset
+	if err := c.post("/api/gamification/reset", body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetRecap fetches /api/recap for the week containing day, or for the
+// current week when day is zero.
+func (c *HTTPClient) GetRecap(day time.Time) (*Recap, error) {
+	path := "/api/recap"
+	if !day.IsZero() {
+		path += "?week=" + day.Format("2006-01-02")
+	}
+	var out Recap
+	if err := c.get(path, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetConfig fetches /api/config.
+func (c *HTTPClient) GetConfig() (*SoundConfig, error) {
+	var s SoundConfig
+	if err := c.get("/api/config", &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// GetVersion fetches /api/version.
+func (c *HTTPClient) GetVersion() (*VersionInfo, error) {
+	var v VersionInfo
+	if err := c.get("/api/version", &v); err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// GetGlance fetches /api/glance.
+func (c *HTTPClient) GetGlance() (*GlanceResponse, error) {
+	var g GlanceResponse
+	if err := c.get("/api/glance", &g); err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
+// GetCurrentSession fetches /api/sessions/current for cwd. It returns a
+// *StatusError with code 404 when no running session matches.
+func (c *HTTPClient) GetCurrentSession(cwd string) (*CurrentSessionResponse, error) {
+	var cur CurrentSessionResponse
+	if err := c.get("/api/sessions/current?cwd="+url.QueryEscape(cwd), &cur); err != nil {
+		return nil, err
+	}
+	return &cur, nil
+}
+
+// GetPaneSession fetches /api/sessions/current for the tmux pane target
+// ("session:window.pane"), falling back to matching cwd when no session
+// runs in the pane. Either may be empty. It returns a *StatusError with
+// code 404 when no running session matches.
+func (c *HTTPClient) GetPaneSession(target, cwd string) (*CurrentSessionResponse, error) {
+	q := url.Values{}
+	if target != "" {
+		q.Set("tmux", target)
+	}
+	if cwd != "" {
+		q.Set("cwd", cwd)
+	}
+	var cur CurrentSessionResponse
+	if err := c.get("/api/sessions/current?"+q.Encode(), &cur); err != nil {
+		return nil, err
+	}
+	return &cur, nil
+}
+
+// GetTmuxSession fetches /api/tmux/{target}, the running session in the
+// tmux pane target ("session:window.pane"). It returns a *StatusError with
+// code 404 when none runs there.
+func (c *HTTPClient) GetTmuxSession(target string) (*SessionState, error) {
+	var st SessionState
+	if err := c.get("/api/tmux/"+url.PathEscape(target), &st); err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+// GetEditorStatus fetches /api/editor/status for folder, without waiting
+// for a change.
+func (c *HTTPClient) GetEditorStatus(folder string) (*EditorStatus, error) {
+	var st EditorStatus
+	if err := c.get("/api/editor/status?folder="+url.QueryEscape(folder), &st); err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+// GetHealth fetches /healthz.
+func (c *HTTPClient) GetHealth() (*Health, error) {
+	var h Health
+	if err := c.get("/healthz", &h); err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
+
+// GetSourceHealthHistory fetches /api/health/sources/history.
+func (c *HTTPClient) GetSourceHealthHistory() ([]SourceHealthHistory, error) {
+	var history []SourceHealthHistory
+	if err := c.get("/api/health/sources/history", &history); err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+// PutExternalSession sends PUT /api/external/sessions/{id}. It is safe to
+// retry until it succeeds.
+func (c *HTTPClient) PutExternalSession(id string, u ExternalSessionUpdate) (*ExternalSessionResult, error) {
+	var out ExternalSessionResult
+	if err := c.send(http.MethodPut, "/api/external/sessions/"+url.PathEscape(id), u, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SetSessionName sends PUT /api/sessions/{id}/name. scope is "session" or
+// "dir"; an empty name removes the alias.
+func (c *HTTPClient) SetSessionName(sessionID, name, scope string) (*SessionState, error) {
+	var out SessionState
+	body := SessionNameRequest{Name: name, Scope: scope}
+	if err := c.send(http.MethodPut, "/api/sessions/"+url.PathEscape(sessionID)+"/name", body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Equip sends POST /api/equip.
+func (c *HTTPClient) Equip(rewardID, slot string) (*Equipped, error) {
+	body := map[string]string{"rewardId": rewardID, "slot": slot}
+	var out Equipped
+	if err := c.post("/api/equip", body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Unequip sends POST /api/unequip.
+func (c *HTTPClient) Unequip(slot string) (*Equipped, error) {
+	body := map[string]string{"slot": slot}
+	var out Equipped
+	if err := c.post("/api/unequip", body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetTail fetches /api/sessions/{id}/tail?offset=N.
+func (c *HTTPClient) GetTail(sessionID string, offset int64) (*TailResponse, error) {
+	path := fmt.Sprintf("/api/sessions/%s/tail?offset=%d", url.PathEscape(sessionID), offset)
+	var resp TailResponse
+	if err := c.get(path, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GetContext fetches /api/sessions/{id}/context?top=N. A top of 0 uses
+// the server's default.
+func (c *HTTPClient) GetContext(sessionID string, top int) (*ContextComposition, error) {
+	path := "/api/sessions/" + url.PathEscape(sessionID) + "/context"
+	if top > 0 {
+		path += fmt.Sprintf("?top=%d", top)
+	}
+	var resp ContextComposition
+	if err := c.get(path, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// FocusSession sends POST /api/sessions/{id}/focus.
+func (c *HTTPClient) FocusSession(sessionID string) error {
+	return c.post("/api/sessions/"+url.PathEscape(sessionID)+"/focus", nil, nil)
+}
+
+// GetHeats fetches /api/heats.
+func (c *HTTPClient) GetHeats() ([]Heat, error) {
+	var out []Heat
+	if err := c.get("/api/heats", &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetHeat fetches /api/heats/{id}.
+func (c *HTTPClient) GetHeat(id string) (*Heat, error) {
+	var h Heat
+	if err := c.get("/api/heats/"+url.PathEscape(id), &h); err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
+
+// CreateHeat sends POST /api/heats.
+func (c *HTTPClient) CreateHeat(req HeatRequest) (*Heat, error) {
+	var h Heat
+	if err := c.post("/api/heats", req, &h); err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
+
+// GetLaunchTemplates fetches GET /api/launch.
+func (c *HTTPClient) GetLaunchTemplates() ([]LaunchTemplate, error) {
+	var out []LaunchTemplate
+	if err := c.get("/api/launch", &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Launch sends POST /api/launch and returns the placeholder session.
+func (c *HTTPClient) Launch(req LaunchRequest) (*SessionState, error) {
+	var s SessionState
+	if err := c.post("/api/launch", req, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// GetPipelines fetches GET /api/pipelines.
+func (c *HTTPClient) GetPipelines() (*PipelinesResponse, error) {
+	var out PipelinesResponse
+	if err := c.get("/api/pipelines", &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// StartPipeline sends POST /api/pipelines.
+func (c *HTTPClient) StartPipeline(req PipelineRequest) (*PipelineRun, error) {
+	var run PipelineRun
+	if err := c.post("/api/pipelines", req, &run); err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+func (c *HTTPClient) get(path string, out any) error {
+	req, err := http.NewRequest(http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	return c.do(req, path, out)
+}
+
+// post sends body as JSON; a nil body sends none.
+func (c *HTTPClient) post(path string, body any, out any) error {
+	return c.send(http.MethodPost, path, body, out)
+}
+
+// send sends body as JSON with method; a nil body sends none.
+func (c *HTTPClient) send(method, path string, body any, out any) error {
+	var r io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, c.baseURL+path, r)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return c.do(req, path, out)
+}
+
+func (c *HTTPClient) do(req *http.Request, path string, out any) error {
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+		return &StatusError{Method: req.Method, Path: path, StatusCode: resp.StatusCode, Body: string(bytes.TrimSpace(body))}
+	}
+	if out != nil {
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+	return nil
+}
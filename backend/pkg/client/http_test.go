@@ -0,0 +1,71 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHTTPClient_SendsTokenAndDecodes(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer tok" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/api/sessions":
+			if r.URL.Query().Get("metric") != "tokens" {
+				t.Errorf("metric = %q, want tokens", r.URL.Query().Get("metric"))
+			}
+			_ = json.NewEncoder(w).Encode([]SessionState{{ID: "claude:a", Activity: ActivityThinking}})
+		case "/api/launch":
+			var req LaunchRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Template != "build" {
+				http.Error(w, "bad request", http.StatusBadRequest)
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(SessionState{ID: "claude:b", Launched: true})
+		default:
+			http.Error(w, "session not found", http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	c := NewHTTPClient(srv.URL, "tok", nil)
+	sessions, err := c.GetSessions("tokens")
+	if err != nil || len(sessions) != 1 || sessions[0].ID != "claude:a" {
+		t.Fatalf("GetSessions = %v, %v", sessions, err)
+	}
+	s, err := c.Launch(LaunchRequest{Template: "build"})
+	if err != nil || !s.Launched {
+		t.Fatalf("Launch = %+v, %v", s, err)
+	}
+
+	_, err = c.GetSession("missing")
+	var se *StatusError
+	if !errors.As(err, &se) || se.StatusCode != http.StatusNotFound || se.Body != "session not found" {
+		t.Fatalf("GetSession error = %v, want a 404 StatusError", err)
+	}
+
+	if _, err := NewHTTPClient(srv.URL, "", nil).GetStats(); !errors.As(err, &se) || se.StatusCode != http.StatusUnauthorized {
+		t.Errorf("unauthenticated GetStats error = %v, want 401", err)
+	}
+}
+
+func TestHTTPClient_FocusSendsNoBody(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/sessions/claude:a/focus" || r.ContentLength > 0 {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	if err := NewHTTPClient(srv.URL, "", nil).FocusSession("claude:a"); err != nil {
+		t.Fatalf("FocusSession: %v", err)
+	}
+}
@@ -0,0 +1,1004 @@
+// Package client is a typed Go client for the Agent Racer server: the
+// WebSocket message envelope and payloads, and the REST API used by the
+// TUI and third-party tools.
+//
+// The types mirror the wire protocol without importing server packages, so
+// this package only depends on the standard library and gorilla/websocket.
+// A test in the server keeps them in step with what it sends.
+package client
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// MessageType identifies the kind of WebSocket message.
+type MessageType string
+
+const (
+	MsgSnapshot            MessageType = "snapshot"
+	MsgDelta               MessageType = "delta"
+	MsgCompletion          MessageType = "completion"
+	MsgEquipped            MessageType = "equipped"
+	MsgError               MessageType = "error"
+	MsgAchievementUnlocked MessageType = "achievement_unlocked"
+	MsgAchievementBatch    MessageType = "achievement_batch"
+	MsgSourceHealth        MessageType = "source_health"
+	MsgBattlePassProgress  MessageType = "battlepass_progress"
+	MsgOvertake            MessageType = "overtake"
+	MsgServerShutdown      MessageType = "server_shutdown"
+	MsgUpdateAvailable     MessageType = "update_available"
+	MsgDirectorFocus       MessageType = "director_focus"
+	MsgCommentary          MessageType = "commentary"
+	MsgSoundCue            MessageType = "sound_cue"
+	MsgLapCompleted        MessageType = "lap_completed"
+	MsgHeatStandings       MessageType = "heat_standings"
+	MsgPipelineUpdate      MessageType = "pipeline_update"
+	MsgCatchUp             MessageType = "catch_up"
+	MsgCacheCollapse       MessageType = "cache_collapse"
+	MsgApprovalNeeded      MessageType = "approval_needed"
+	MsgModelChanged        MessageType = "model_changed"
+	MsgPreferences         MessageType = "preferences"
+	MsgRaceFinished        MessageType = "race_finished"
+	MsgSubagentStarted     MessageType = "subagent_started"
+	MsgSubagentCompleted   MessageType = "subagent_completed"
+	MsgSubagentBudget      MessageType = "subagent_budget"
+	MsgPresence            MessageType = "presence"
+	MsgReaction            MessageType = "reaction"
+	MsgWatchdogAlert       MessageType = "watchdog_alert"
+	MsgMilestone           MessageType = "milestone"
+)
+
+// WSMessage is the envelope for all WebSocket messages. Seq increases with
+// every message the server sends.
+type WSMessage struct {
+	Type    MessageType     `json:"type"`
+	Seq     uint64          `json:"seq"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// Activity represents a session's current state.
+type Activity string
+
+const (
+	ActivityStarting      Activity = "starting"
+	ActivityThinking      Activity = "thinking"
+	ActivityToolUse       Activity = "tool_use"
+	ActivityWaiting       Activity = "waiting"
+	ActivityIdle          Activity = "idle"
+	ActivityComplete      Activity = "complete"
+	ActivityErrored       Activity = "errored"
+	ActivityLost          Activity = "lost"
+	ActivityNeedsApproval Activity = "needs_approval"
+)
+
+// IsTerminal returns true if the activity represents a terminal state.
+func (a Activity) IsTerminal() bool {
+	return a == ActivityComplete || a == ActivityErrored || a == ActivityLost
+}
+
+// Severity is how urgently a client should announce a session's activity.
+type Severity string
+
+const (
+	SeverityInfo    Severity = "info"
+	SeverityNotice  Severity = "notice"
+	SeverityWarning Severity = "warning"
+	SeverityError   Severity = "error"
+	SeveritySuccess Severity = "success"
+)
+
+// SessionState is one agent session, as sent in snapshots, deltas and by
+// /api/sessions.
+type SessionState struct {
+	ID                 string          `json:"id"`
+	Name               string          `json:"name"`
+	Topic              string          `json:"topic,omitempty"`
+	Slug               string          `json:"slug,omitempty"`
+	Source             string          `json:"source"`
+	Activity           Activity        `json:"activity"`
+	TokensUsed         int             `json:"tokensUsed"`
+	TokenEstimated     bool            `json:"tokenEstimated"`
+	TokenBreakdown     TokenBreakdown  `json:"tokenBreakdown,omitzero"`
+	MaxContextTokens   int             `json:"maxContextTokens"`
+	ContextUtilization float64         `json:"contextUtilization"`
+	CurrentTool        string          `json:"currentTool,omitempty"`
+	Model              string          `json:"model"`
+	WorkingDir         string          `json:"workingDir"`
+	Branch             string          `json:"branch,omitempty"`
+	Project            string          `json:"project,omitempty"`
+	Worktree           string          `json:"worktree,omitempty"`
+	SubProject         string          `json:"subProject,omitempty"` // package or service within the repository, e.g. "services/api"
+	Tags               []string        `json:"tags,omitempty"`
+	Privacy            string          `json:"privacy,omitempty"` // "masked" when the repository masks its working directory
+	Muted              []string        `json:"muted,omitempty"`   // notification kinds the repository turned off
+	IssueURL           string          `json:"issueUrl,omitempty"`
+	PRURL              string          `json:"prUrl,omitempty"`
+	StartedAt          time.Time       `json:"startedAt"`
+	LastActivityAt     time.Time       `json:"lastActivityAt"`
+	LastDataReceivedAt time.Time       `json:"lastDataReceivedAt"`
+	CompletedAt        *time.Time      `json:"completedAt,omitempty"`
+	Outcome            string          `json:"outcome,omitempty"`
+	MessageCount       int             `json:"messageCount"`
+	ToolCallCount      int             `json:"toolCallCount"`
+	MCPToolCalls       map[string]int  `json:"mcpToolCalls,omitempty"`
+	ToolCounts         map[string]int  `json:"toolCounts,omitempty"`
+	ShellCommands      map[string]int  `json:"shellCommands,omitempty"`
+	FilesPatched       map[string]int  `json:"filesPatched,omitempty"`
+	FilesTouched       map[string]int  `json:"filesTouched,omitempty"` // only from GetSession
+	PID                int             `json:"pid,omitempty"`
+	IsChurning         bool            `json:"isChurning,omitempty"`
+	TmuxTarget         string          `json:"tmuxTarget,omitempty"`
+	Launched           bool            `json:"launched,omitempty"`
+	Lane               int             `json:"lane"`
+	BurnRatePerMinute  float64         `json:"burnRatePerMinute,omitempty"`
+	BurnRateSmoothed   float64         `json:"burnRateSmoothed,omitempty"`
+	Speed              float64         `json:"speed,omitempty"`
+	Percentiles        *Percentiles    `json:"percentiles,omitempty"`
+	SecondsToCompact   int             `json:"estimatedSecondsToCompaction,omitempty"`
+	CompactionETA      time.Time       `json:"compactionEta,omitzero"`
+	CompactionCount    int             `json:"compactionCount,omitempty"`
+	CacheReadTokens    int             `json:"cacheReadTokens,omitempty"`
+	CacheWriteTokens   int             `json:"cacheWriteTokens,omitempty"`
+	UncachedTokens     int             `json:"uncachedTokens,omitempty"`
+	CacheHitRatio      float64         `json:"cacheHitRatio,omitempty"`
+	CacheSavedTokens   int             `json:"cacheSavedTokens,omitempty"`
+	LapCount           int             `json:"lapCount"`
+	LapProgress        float64         `json:"lapProgress"`
+	TokenBudget        int             `json:"tokenBudget,omitempty"`
+	OverBudget         bool            `json:"overBudget,omitempty"`
+	EnergyWh           float64         `json:"energyWh,omitempty"`
+	CO2Grams           float64         `json:"co2Grams,omitempty"`
+	Subagents          []SubagentState `json:"subagents,omitempty"`
+	LastAssistantText  string          `json:"lastAssistantText,omitempty"`
+	LastCommand        string          `json:"lastCommand,omitempty"`
+	SlashCommands      map[string]int  `json:"slashCommands,omitempty"`
+	HookEventCount     int             `json:"hookEventCount,omitempty"`
+	ModelSwitches      int             `json:"modelSwitches,omitempty"`
+	Position           int             `json:"position,omitempty"`
+	PositionDelta      int             `json:"positionDelta,omitempty"`
+	Severity           Severity        `json:"severity,omitempty"`
+	StatusText         string          `json:"statusText,omitempty"` // spoken summary in the server's display language
+	Reactions          map[string]int  `json:"reactions,omitempty"`  // emoji -> times viewers reacted, when recorded
+}
+
+// TokenBreakdown splits a session's TokensUsed by the role of the messages
+// that put them in the context.
+type TokenBreakdown struct {
+	User       int `json:"user"`
+	Assistant  int `json:"assistant"`
+	ToolResult int `json:"toolResult"`
+	System     int `json:"system"`
+}
+
+// Percentiles place a running session among the server's recently
+// finished sessions, as the percentage of them it is ahead of (0-100).
+type Percentiles struct {
+	Duration int `json:"duration"`
+	BurnRate int `json:"burnRate"`
+}
+
+// SubagentState is a subagent running inside a session. A session lists
+// its subagents in tree order, each followed by the ones it spawned.
+type SubagentState struct {
+	ID              string     `json:"id"`
+	ParentToolUseID string     `json:"parentToolUseId"`
+	ParentID        string     `json:"parentId,omitempty"` // subagent that spawned it; empty when the session did
+	Depth           int        `json:"depth"`              // 1 for the session's own subagents
+	SessionID       string     `json:"sessionId"`
+	Slug            string     `json:"slug"`
+	Model           string     `json:"model"`
+	Activity        Activity   `json:"activity"`
+	CurrentTool     string     `json:"currentTool,omitempty"`
+	TokensUsed      int        `json:"tokensUsed"`
+	OverBudget      bool       `json:"overBudget,omitempty"` // past the server's per-subagent token budget
+	MessageCount    int        `json:"messageCount"`
+	ToolCallCount   int        `json:"toolCallCount"`
+	StartedAt       time.Time  `json:"startedAt"`
+	LastActivityAt  time.Time  `json:"lastActivityAt"`
+	CompletedAt     *time.Time `json:"completedAt,omitempty"`
+}
+
+// TeamInfo groups the sessions working on one project, as sent in
+// snapshots and deltas and by /api/projects.
+type TeamInfo struct {
+	ID              string   `json:"id"`
+	Name            string   `json:"name"`
+	Color           string   `json:"color"`
+	MemberIDs       []string `json:"memberIds"`
+	SessionCount    int      `json:"sessionCount"`
+	ActiveCount     int      `json:"activeCount"`
+	TotalTokens     int      `json:"totalTokens"`
+	AvgBurnRate     float64  `json:"avgBurnRate"`
+	CompletionCount int      `json:"completionCount"`
+	ErrorCount      int      `json:"errorCount"`
+	Worktrees       []string `json:"worktrees,omitempty"`
+	SubProjects     []string `json:"subProjects,omitempty"`
+}
+
+// --- WebSocket payload types ---
+
+// SnapshotPayload is the full state, sent on connect and periodically.
+// A client that dials with DialOptions.SnapshotPageSize may get it in
+// pages; SnapshotAssembler joins them.
+type SnapshotPayload struct {
+	Sessions     []*SessionState       `json:"sessions"`
+	Teams        []TeamInfo            `json:"teams,omitempty"`
+	SourceHealth []SourceHealthPayload `json:"sourceHealth,omitempty"`
+
+	// Page (from 1) of Pages for a snapshot sent in pages; 0 for a whole one.
+	Page  int `json:"page,omitempty"`
+	Pages int `json:"pages,omitempty"`
+	// SubagentCounts gives, by session ID, how many subagents were left
+	// out with DialOptions.LazySubagents; HTTPClient.GetSession has them.
+	SubagentCounts map[string]int `json:"subagentCounts,omitempty"`
+}
+
+// DeltaPayload contains incremental session updates.
+type DeltaPayload struct {
+	Updates []*SessionState `json:"updates"`
+	Removed []string        `json:"removed,omitempty"`
+	Teams   []TeamInfo      `json:"teams,omitempty"`
+}
+
+// CompletionPayload is sent when a session reaches a terminal state.
+type CompletionPayload struct {
+	SessionID string   `json:"sessionId"`
+	Activity  Activity `json:"activity"`
+	Name      string   `json:"name"`
+}
+
+// EquippedPayload broadcasts the current cosmetic loadout.
+type EquippedPayload struct {
+	Loadout Equipped `json:"loadout"`
+}
+
+// BattlePassProgressPayload is sent when XP is awarded.
+type BattlePassProgressPayload struct {
+	XP           int       `json:"xp"`
+	Tier         int       `json:"tier"`
+	TierProgress float64   `json:"tierProgress"`
+	RecentXP     []XPEntry `json:"recentXP"`
+	Rewards      []string  `json:"rewards,omitempty"`
+}
+
+// AchievementRewardPayload describes a reward tied to an achievement.
+type AchievementRewardPayload struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// AchievementUnlockedPayload is sent when an achievement unlocks.
+type AchievementUnlockedPayload struct {
+	ID          string                    `json:"id"`
+	Name        string                    `json:"name"`
+	Description string                    `json:"description"`
+	Tier        string                    `json:"tier"`
+	Reward      *AchievementRewardPayload `json:"reward,omitempty"`
+}
+
+// AchievementBatchPayload is sent instead of AchievementUnlockedPayload
+// when several achievements unlock at once.
+type AchievementBatchPayload struct {
+	Achievements []AchievementUnlockedPayload `json:"achievements"`
+	ByTier       map[string]int               `json:"byTier"` // tier -> how many unlocked
+}
+
+// SourceHealthStatus indicates a source's health.
+type SourceHealthStatus string
+
+const (
+	StatusHealthy  SourceHealthStatus = "healthy"
+	StatusDegraded SourceHealthStatus = "degraded"
+	StatusFailed   SourceHealthStatus = "failed"
+)
+
+// SourceHealthPayload reports the health of a session source.
+type SourceHealthPayload struct {
+	Source           string             `json:"source"`
+	Status           SourceHealthStatus `json:"status"`
+	DiscoverFailures int                `json:"discoverFailures"`
+	ParseFailures    int                `json:"parseFailures"`
+	LastError        string             `json:"lastError,omitempty"`
+	Timestamp        time.Time          `json:"timestamp"`
+}
+
+// SourceHealthTransition is one change in a source's health status.
+type SourceHealthTransition struct {
+	From       SourceHealthStatus `json:"from"`
+	To         SourceHealthStatus `json:"to"`
+	LastError  string             `json:"lastError,omitempty"`
+	Suppressed bool               `json:"suppressed,omitempty"` // not broadcast: the source was flapping
+	Timestamp  time.Time          `json:"timestamp"`
+}
+
+// SourceHealthHistory is a source's recent health transitions, oldest first.
+type SourceHealthHistory struct {
+	Source      string                   `json:"source"`
+	Status      SourceHealthStatus       `json:"status"`
+	Flapping    bool                     `json:"flapping"`
+	Transitions []SourceHealthTransition `json:"transitions"`
+}
+
+// ExternalSessionUpdate is the whole current state of a session a script or
+// CI job reports with PUT /api/external/sessions/{id}. Counts are totals.
+// Set Seq to have the server ignore documents older than one it applied.
+// Every report is a heartbeat; a session that goes HeartbeatSeconds without
+// one is marked lost.
+type ExternalSessionUpdate struct {
+	Seq              uint64    `json:"seq,omitempty"`
+	HeartbeatSeconds int       `json:"heartbeatSeconds,omitempty"`
+	Name             string    `json:"name,omitempty"`
+	Model            string    `json:"model,omitempty"`
+	Activity         string    `json:"activity,omitempty"`
+	TokensUsed       int       `json:"tokensUsed,omitempty"`
+	MaxContextTokens int       `json:"maxContextTokens,omitempty"`
+	MessageCount     int       `json:"messageCount,omitempty"`
+	ToolCallCount    int       `json:"toolCallCount,omitempty"`
+	CurrentTool      string    `json:"currentTool,omitempty"`
+	LastMessage      string    `json:"lastMessage,omitempty"`
+	WorkingDir       string    `json:"workingDir,omitempty"`
+	Branch           string    `json:"branch,omitempty"`
+	StartedAt        time.Time `json:"startedAt,omitzero"`
+	LastActivityAt   time.Time `json:"lastActivityAt,omitzero"`
+}
+
+// ExternalSessionResult acknowledges an ExternalSessionUpdate. Applied is
+// false for a duplicate, an out-of-order document or a finished session.
+type ExternalSessionResult struct {
+	ID      string `json:"id"`
+	Seq     uint64 `json:"seq"`
+	Applied bool   `json:"applied"`
+}
+
+// OvertakePayload is sent when one session passes another.
+type OvertakePayload struct {
+	OvertakerID   string `json:"overtakerId"`
+	OvertakerName string `json:"overtakerName"`
+	OvertakenID   string `json:"overtakenId"`
+	OvertakenName string `json:"overtakenName"`
+	NewPosition   int    `json:"newPosition"`
+}
+
+// ServerShutdownPayload announces that the server is going away.
+type ServerShutdownPayload struct {
+	Reason string `json:"reason"`
+}
+
+// UpdateAvailablePayload announces a release newer than the running server.
+type UpdateAvailablePayload struct {
+	Current string `json:"current"`
+	Latest  string `json:"latest"`
+	URL     string `json:"url,omitempty"`
+}
+
+// DirectorFocusPayload suggests which session dashboards should show until
+// Until.
+type DirectorFocusPayload struct {
+	SessionID string    `json:"sessionId"`
+	Name      string    `json:"name"`
+	Reason    string    `json:"reason"`
+	Until     time.Time `json:"until"`
+}
+
+// CommentaryPayload is one announcer line. SessionIDs lists the subject
+// first.
+type CommentaryPayload struct {
+	Event      string    `json:"event"`
+	SessionIDs []string  `json:"sessionIds"`
+	Text       string    `json:"text"`
+	At         time.Time `json:"at"`
+}
+
+// SoundCuePayload names a moment the server decided deserves a sound:
+// start, overtake, finish, error, achievement or approval.
+type SoundCuePayload struct {
+	Cue       string `json:"cue"`
+	SessionID string `json:"sessionId,omitempty"`
+}
+
+// LapCompletedPayload announces a session finishing a lap.
+type LapCompletedPayload struct {
+	SessionID string `json:"sessionId"`
+	Name      string `json:"name"`
+	Lap       int    `json:"lap"`
+}
+
+// MilestonePayload announces a session passing a run-time milestone
+// (Kind "duration", Value in seconds) or a token one (Kind "tokens").
+type MilestonePayload struct {
+	SessionID string `json:"sessionId"`
+	Name      string `json:"name"`
+	Kind      string `json:"kind"`
+	Value     int64  `json:"value"`
+	Label     string `json:"label"`
+}
+
+// CacheCollapsePayload announces a session's prompt cache going cold.
+type CacheCollapsePayload struct {
+	SessionID       string    `json:"sessionId"`
+	Name            string    `json:"name"`
+	HitRatio        float64   `json:"hitRatio"`
+	SessionHitRatio float64   `json:"sessionHitRatio"`
+	MissedTokens    int       `json:"missedTokens"`
+	At              time.Time `json:"at"`
+}
+
+// SessionNameRequest is the body of PUT /api/sessions/{id}/name.
+type SessionNameRequest struct {
+	Name  string `json:"name"`
+	Scope string `json:"scope,omitempty"`
+}
+
+// HelloRequest introduces a client to the other viewers; see Conn.Hello.
+type HelloRequest struct {
+	Name string `json:"name,omitempty"`
+	Kind string `json:"kind,omitempty"`
+}
+
+// PresencePayload says who is watching. Viewers counts every connected
+// client; Named lists those that introduced themselves, longest connected
+// first, and is empty when the server hides viewer names.
+type PresencePayload struct {
+	Viewers int      `json:"viewers"`
+	Named   []Viewer `json:"named,omitempty"`
+}
+
+// Viewer is a connected client that introduced itself.
+type Viewer struct {
+	Name        string    `json:"name"`
+	Kind        string    `json:"kind,omitempty"`
+	ConnectedAt time.Time `json:"connectedAt"`
+}
+
+// ReactionRequest reacts to a session on behalf of this connection; see
+// Conn.React.
+type ReactionRequest struct {
+	SessionID string `json:"sessionId,omitempty"`
+	Emoji     string `json:"emoji,omitempty"`
+}
+
+// ReactionPayload is a viewer's reaction to a session. From is empty for
+// viewers without a name; Count is zero unless the server records them.
+type ReactionPayload struct {
+	SessionID string    `json:"sessionId"`
+	Name      string    `json:"name"`
+	Emoji     string    `json:"emoji"`
+	From      string    `json:"from,omitempty"`
+	Count     int       `json:"count,omitempty"`
+	At        time.Time `json:"at"`
+}
+
+// PreferencesRequest asks the server to show this connection's timestamps
+// in another time zone or clock; see Conn.SetPreferences.
+type PreferencesRequest struct {
+	TimeZone string `json:"timeZone,omitempty"`
+	Clock    string `json:"clock,omitempty"`
+}
+
+// PreferencesPayload says how to show timestamps. An empty TimeZone means
+// local time and an empty Clock the locale's default. UTCOffset is the
+// zone's current offset in seconds.
+type PreferencesPayload struct {
+	TimeZone  string   `json:"timeZone,omitempty"`
+	Clock     string   `json:"clock,omitempty"`
+	UTCOffset int      `json:"utcOffset,omitempty"`
+	Error     string   `json:"error,omitempty"`
+	Reactions []string `json:"reactions,omitempty"` // emoji Conn.React may send; empty while reactions are off
+}
+
+// Location returns the time zone to show timestamps in: TimeZone if this
+// system knows it, a fixed zone at UTCOffset if not, and time.Local when
+// TimeZone is empty.
+func (p PreferencesPayload) Location() *time.Location {
+	if p.TimeZone == "" {
+		return time.Local
+	}
+	if loc, err := time.LoadLocation(p.TimeZone); err == nil {
+		return loc
+	}
+	return time.FixedZone(p.TimeZone, p.UTCOffset)
+}
+
+// ModelChangedPayload announces a session switching models mid-session.
+type ModelChangedPayload struct {
+	SessionID string    `json:"sessionId"`
+	Name      string    `json:"name"`
+	OldModel  string    `json:"oldModel"`
+	NewModel  string    `json:"newModel"`
+	At        time.Time `json:"at"`
+}
+
+// SubagentPayload announces a subagent within a session doing its first
+// work (MsgSubagentStarted) or returning its result (MsgSubagentCompleted).
+type SubagentPayload struct {
+	SessionID       string    `json:"sessionId"`
+	Name            string    `json:"name"`
+	SubagentID      string    `json:"subagentId"`
+	ParentToolUseID string    `json:"parentToolUseId"`
+	ParentID        string    `json:"parentId,omitempty"` // subagent that spawned it; empty when the session did
+	Depth           int       `json:"depth"`
+	Slug            string    `json:"slug,omitempty"`
+	Model           string    `json:"model,omitempty"`
+	TokensUsed      int       `json:"tokensUsed"`
+	ToolCallCount   int       `json:"toolCallCount"`
+	At              time.Time `json:"at"`
+}
+
+// SubagentBudgetPayload warns that a subagent's context grew past its
+// budget. ContextShare is TokensUsed over the session's context window, or
+// 0 when that isn't known.
+type SubagentBudgetPayload struct {
+	SessionID    string    `json:"sessionId"`
+	Name         string    `json:"name"`
+	SubagentID   string    `json:"subagentId"`
+	Slug         string    `json:"slug,omitempty"`
+	Model        string    `json:"model,omitempty"`
+	TokensUsed   int       `json:"tokensUsed"`
+	Budget       int       `json:"budget"`
+	ContextShare float64   `json:"contextShare"`
+	At           time.Time `json:"at"`
+}
+
+// ApprovalNeededPayload announces a session stopping to wait for the user
+// to approve something.
+type ApprovalNeededPayload struct {
+	SessionID string    `json:"sessionId"`
+	Name      string    `json:"name"`
+	Tool      string    `json:"tool,omitempty"`
+	At        time.Time `json:"at"`
+}
+
+// WatchdogAlertPayload warns that no session has been active since
+// QuietSince during the configured work hours.
+type WatchdogAlertPayload struct {
+	QuietSince      time.Time `json:"quietSince"`
+	At              time.Time `json:"at"`
+	LastSessionID   string    `json:"lastSessionId,omitempty"`
+	LastSessionName string    `json:"lastSessionName,omitempty"`
+}
+
+// HeatFinish is how a heat ends: "all", "first", or "laps" after Laps laps.
+type HeatFinish struct {
+	Kind string `json:"kind"`
+	Laps int    `json:"laps,omitempty"`
+}
+
+// HeatStanding is one participant's place in a heat.
+type HeatStanding struct {
+	SessionID  string     `json:"sessionId"`
+	Name       string     `json:"name"`
+	Model      string     `json:"model,omitempty"`
+	Rank       int        `json:"rank"`
+	Activity   Activity   `json:"activity"`
+	LapCount   int        `json:"lapCount"`
+	Finished   bool       `json:"finished"`
+	Out        bool       `json:"out"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+}
+
+// Heat is a race between sessions: the heat_standings and race_finished
+// payload and what /api/heats returns.
+type Heat struct {
+	ID        string         `json:"id"`
+	Name      string         `json:"name"`
+	Status    string         `json:"status"` // pending, running or finished
+	StartAt   time.Time      `json:"startAt"`
+	EndedAt   *time.Time     `json:"endedAt,omitempty"`
+	Finish    HeatFinish     `json:"finish"`
+	Metric    string         `json:"metric"`
+	WinnerID  string         `json:"winnerId,omitempty"`
+	Auto      bool           `json:"auto,omitempty"` // detected from sessions started together on one project
+	Standings []HeatStanding `json:"standings"`
+}
+
+// PipelineStage is one leg of a pipeline run.
+type PipelineStage struct {
+	Template  string     `json:"template"`
+	Status    string     `json:"status"` // pending, running, done, failed or skipped
+	SessionID string     `json:"sessionId,omitempty"`
+	StartedAt *time.Time `json:"startedAt,omitempty"`
+	EndedAt   *time.Time `json:"endedAt,omitempty"`
+	Error     string     `json:"error,omitempty"`
+}
+
+// PipelineRun is one run of a pipeline: the pipeline_update payload.
+type PipelineRun struct {
+	ID        string          `json:"id"`
+	Pipeline  string          `json:"pipeline"`
+	Status    string          `json:"status"` // running, finished or failed
+	Leg       int             `json:"leg"`    // index of the stage holding the baton
+	StartedAt time.Time       `json:"startedAt"`
+	EndedAt   *time.Time      `json:"endedAt,omitempty"`
+	Stages    []PipelineStage `json:"stages"`
+}
+
+// CatchUpPayload carries the broadcasts a reconnecting client missed,
+// oldest first, as full messages. Truncated counts the missed events
+// left out to keep the message under the server's caps.
+type CatchUpPayload struct {
+	Since     uint64            `json:"since"`
+	Complete  bool              `json:"complete"`
+	Events    []json.RawMessage `json:"events"`
+	Truncated int               `json:"truncated,omitempty"`
+}
+
+// --- HTTP types ---
+
+// XPEntry records a single XP award.
+type XPEntry struct {
+	Reason string `json:"reason"`
+	Amount int    `json:"amount"`
+}
+
+// Equipped tracks the active cosmetic in each slot.
+type Equipped struct {
+	Paint string `json:"paint,omitempty"`
+	Trail string `json:"trail,omitempty"`
+	Body  string `json:"body,omitempty"`
+	Badge string `json:"badge,omitempty"`
+	Sound string `json:"sound,omitempty"`
+	Theme string `json:"theme,omitempty"`
+	Title string `json:"title,omitempty"`
+}
+
+// Stats is the part of /api/stats clients use: aggregate counters, peaks
+// and gamification state.
+type Stats struct {
+	Version                int                  `json:"version"`
+	TotalSessions          int                  `json:"totalSessions"`
+	TotalCompletions       int                  `json:"totalCompletions"`
+	TotalErrors            int                  `json:"totalErrors"`
+	ConsecutiveCompletions int                  `json:"consecutiveCompletions"`
+	TotalTokensBurned      int                  `json:"totalTokensBurned"`
+	SessionsPerSource      map[string]int       `json:"sessionsPerSource"`
+	SessionsPerModel       map[string]int       `json:"sessionsPerModel"`
+	DistinctModelsUsed     int                  `json:"distinctModelsUsed"`
+	DistinctSourcesUsed    int                  `json:"distinctSourcesUsed"`
+	MaxContextUtilization  float64              `json:"maxContextUtilization"`
+	MaxBurnRate            float64              `json:"maxBurnRate"`
+	MaxConcurrentActive    int                  `json:"maxConcurrentActive"`
+	MaxToolCalls           int                  `json:"maxToolCalls"`
+	MaxMessages            int                  `json:"maxMessages"`
+	MaxSessionDurationSec  float64              `json:"maxSessionDurationSec"`
+	HeatsRaced             int                  `json:"heatsRaced"`
+	RacesWon               int                  `json:"racesWon"`
+	LargestFieldWon        int                  `json:"largestFieldWon"`
+	CacheHitRatio          float64              `json:"cacheHitRatio"`
+	CacheSavedTokens       int                  `json:"cacheSavedTokens"`
+	TotalEnergyWh          float64              `json:"totalEnergyWh"`
+	TotalCO2Grams          float64              `json:"totalCo2Grams"`
+	AchievementsUnlocked   map[string]time.Time `json:"achievementsUnlocked"`
+	BattlePass             BattlePass           `json:"battlePass"`
+	Equipped               Equipped             `json:"equipped"`
+	SeasonRewards          map[string]string    `json:"seasonRewards"` // reward ID -> season that granted it
+	SuppressedXP           map[string]int       `json:"suppressedXp"`
+	LastUpdated            time.Time            `json:"lastUpdated"`
+}
+
+// BattlePass tracks seasonal progression.
+type BattlePass struct {
+	Season string `json:"season"`
+	Tier   int    `json:"tier"`
+	XP     int    `json:"xp"`
+}
+
+// AchievementResponse is one entry of /api/achievements.
+type AchievementResponse struct {
+	ID           string     `json:"id"`
+	Name         string     `json:"name"`
+	Description  string     `json:"description"`
+	Tier         string     `json:"tier"`
+	Category     string     `json:"category"`
+	Unlocked     bool       `json:"unlocked"`
+	UnlockedAt   *time.Time `json:"unlockedAt,omitempty"`
+	Acknowledged bool       `json:"acknowledged"` // false for an unlock no client has shown
+}
+
+// StatsHeatmap is /api/stats/heatmap: session starts and completions
+// indexed [weekday][hour], Sunday first, in TimeZone.
+type StatsHeatmap struct {
+	TimeZone    string     `json:"timeZone"`
+	Starts      [7][24]int `json:"starts"`
+	Completions [7][24]int `json:"completions"`
+}
+
+// ChallengeProgress is one entry of /api/challenges.
+type ChallengeProgress struct {
+	ID          string `json:"id"`
+	Description string `json:"description"`
+	Current     int    `json:"current"`
+	Target      int    `json:"target"`
+	Complete    bool   `json:"complete"`
+}
+
+// QuestProgress is one of the day's quests in /api/quests.
+type QuestProgress struct {
+	ID          string `json:"id"`
+	Description string `json:"description"`
+	Current     int    `json:"current"`
+	Target      int    `json:"target"`
+	Complete    bool   `json:"complete"`
+}
+
+// DailyQuests is the response of /api/quests.
+type DailyQuests struct {
+	Day           string          `json:"day"`
+	Quests        []QuestProgress `json:"quests"`
+	Streak        int             `json:"streak"`
+	LongestStreak int             `json:"longestStreak"`
+}
+
+// SeasonTotals are what a battle pass season counted.
+type SeasonTotals struct {
+	Sessions     int     `json:"sessions"`
+	Completions  int     `json:"completions"`
+	Errors       int     `json:"errors"`
+	TokensBurned int     `json:"tokensBurned"`
+	EnergyWh     float64 `json:"energyWh"`
+	Achievements int     `json:"achievements"`
+}
+
+// CurrentSeason is the battle pass season in progress.
+type CurrentSeason struct {
+	Season  string        `json:"season"`
+	Tier    int           `json:"tier"`
+	XP      int           `json:"xp"`
+	Started string        `json:"started,omitempty"`
+	Totals  *SeasonTotals `json:"totals,omitempty"` // so far; nil when not kept
+}
+
+// ArchivedSeason is a finished battle pass season.
+type ArchivedSeason struct {
+	Season    string        `json:"season"`
+	Tier      int           `json:"tier"`
+	XP        int           `json:"xp"`
+	Archived  string        `json:"archived"`
+	Started   string        `json:"started,omitempty"`
+	Totals    *SeasonTotals `json:"totals,omitempty"`    // nil for seasons begun before totals were kept
+	Rewards   []string      `json:"rewards,omitempty"`   // cosmetics granted for good when it ended
+	CarriedXP int           `json:"carriedXp,omitempty"` // XP the next season started with
+}
+
+// SeasonHistory is the response of /api/gamification/seasons.
+type SeasonHistory struct {
+	Current  CurrentSeason    `json:"current"`
+	Archived []ArchivedSeason `json:"archived"` // newest first
+}
+
+// StatsReset is the response of POST /api/gamification/reset.
+type StatsReset struct {
+	Scope     string     `json:"scope"`
+	Reset     bool       `json:"reset"`
+	Confirm   string     `json:"confirm,omitempty"`   // token to send back to confirm
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"` // when Confirm stops working
+}
+
+// Recap is the response of /api/recap: "Your Week in Agents".
+type Recap struct {
+	WeekStart       time.Time          `json:"weekStart"`
+	WeekEnd         time.Time          `json:"weekEnd"`
+	Sessions        int                `json:"sessions"`
+	Completed       int                `json:"completed"`
+	TopProject      *RecapProject      `json:"topProject,omitempty"`
+	Cost            RecapCost          `json:"cost"`
+	LongestSession  *RecapSession      `json:"longestSession,omitempty"`
+	NewAchievements []RecapAchievement `json:"newAchievements"`
+	ModelMix        []RecapModelShare  `json:"modelMix"`
+}
+
+// RecapProject is the project the week's sessions spent the most tokens in.
+type RecapProject struct {
+	Name     string `json:"name"`
+	Sessions int    `json:"sessions"`
+	Tokens   int    `json:"tokens"`
+}
+
+// RecapCost is what the week's sessions consumed, in tokens and their
+// estimated energy and emissions.
+type RecapCost struct {
+	Tokens   int     `json:"tokens"`
+	EnergyWh float64 `json:"energyWh"`
+	CO2Grams float64 `json:"co2Grams"`
+}
+
+// RecapSession is the week's longest session.
+type RecapSession struct {
+	ID              string    `json:"id"`
+	Name            string    `json:"name"`
+	Project         string    `json:"project,omitempty"`
+	Model           string    `json:"model,omitempty"`
+	StartedAt       time.Time `json:"startedAt"`
+	DurationSeconds float64   `json:"durationSeconds"`
+}
+
+// RecapAchievement is an achievement unlocked during the week.
+type RecapAchievement struct {
+	ID         string    `json:"id"`
+	Name       string    `json:"name"`
+	Tier       string    `json:"tier"`
+	UnlockedAt time.Time `json:"unlockedAt"`
+}
+
+// RecapModelShare is one model's part of the week's sessions.
+type RecapModelShare struct {
+	Model    string  `json:"model"`
+	Sessions int     `json:"sessions"`
+	Tokens   int     `json:"tokens"`
+	Share    float64 `json:"share"` // 0-1
+}
+
+// TailEntry is a single display-ready entry from a session's log.
+type TailEntry struct {
+	Timestamp time.Time `json:"timestamp"`
+	Type      string    `json:"type"`     // "assistant", "user", "progress", "system"
+	Activity  string    `json:"activity"` // "thinking", "tool_use", "tool_result", "text", "subagent", etc.
+	Summary   string    `json:"summary"`  // one-line human-readable
+	Detail    string    `json:"detail,omitempty"`
+}
+
+// TailResponse is returned by /api/sessions/{id}/tail. Pass Offset back to
+// read on from where it stopped.
+type TailResponse struct {
+	Entries []TailEntry `json:"entries"`
+	Offset  int64       `json:"offset"`
+}
+
+// ContextComposition is returned by /api/sessions/{id}/context: an
+// estimate of what fills the session's current context window.
+type ContextComposition struct {
+	Compactions        int                 `json:"compactions"`
+	Messages           ContextMessages     `json:"messages"`
+	Tokens             TokenBreakdown      `json:"tokens"`
+	LargestToolResults []ContextToolResult `json:"largestToolResults"`
+	FileReads          []ContextFileRead   `json:"fileReads"`
+}
+
+// ContextMessages counts the messages in a context by role.
+type ContextMessages struct {
+	User        int `json:"user"`
+	Assistant   int `json:"assistant"`
+	ToolResults int `json:"toolResults"`
+	System      int `json:"system"`
+}
+
+// ContextToolResult is one tool result in a context.
+type ContextToolResult struct {
+	Tool      string    `json:"tool"`
+	Path      string    `json:"path,omitempty"`
+	Tokens    int       `json:"tokens"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// ContextFileRead sums the reads of one file in a context.
+type ContextFileRead struct {
+	Path   string `json:"path"`
+	Reads  int    `json:"reads"`
+	Tokens int    `json:"tokens"`
+}
+
+// SoundConfig is returned by /api/config.
+type SoundConfig struct {
+	Enabled       bool    `json:"enabled"`
+	MasterVolume  float64 `json:"master_volume"`
+	AmbientVolume float64 `json:"ambient_volume"`
+	SfxVolume     float64 `json:"sfx_volume"`
+	EnableAmbient bool    `json:"enable_ambient"`
+	EnableSfx     bool    `json:"enable_sfx"`
+}
+
+// VersionInfo is returned by /api/version.
+type VersionInfo struct {
+	Version   string                  `json:"version"`
+	Commit    string                  `json:"commit,omitempty"`
+	BuildDate string                  `json:"buildDate,omitempty"`
+	GoVersion string                  `json:"goVersion,omitempty"`
+	Frontend  string                  `json:"frontend,omitempty"`
+	Update    *UpdateAvailablePayload `json:"update,omitempty"`
+}
+
+// GlanceResponse is returned by /api/glance. Counts leaves out activities
+// no session is in.
+type GlanceResponse struct {
+	Active int            `json:"active"`
+	Counts map[string]int `json:"counts"`
+	Urgent *GlanceSession `json:"urgent,omitempty"`
+	Tier   *GlanceTier    `json:"tier,omitempty"`
+}
+
+// GlanceSession is the session most worth looking at. Reason is
+// "needs_approval", "errored", "waiting" or "context".
+type GlanceSession struct {
+	ID                 string   `json:"id"`
+	Name               string   `json:"name"`
+	Activity           Activity `json:"activity"`
+	ContextUtilization float64  `json:"contextUtilization"`
+	Reason             string   `json:"reason"`
+}
+
+// GlanceTier is battle pass progress; Pct is within the tier, 0.0–1.0.
+type GlanceTier struct {
+	Tier int     `json:"tier"`
+	Pct  float64 `json:"pct"`
+}
+
+// CurrentSessionResponse is returned by /api/sessions/current. Match is
+// "tmux", "exact", "parent" or "worktree".
+type CurrentSessionResponse struct {
+	Match   string        `json:"match"`
+	Session *SessionState `json:"session"`
+}
+
+// EditorStatus is returned by /api/editor/status: the session working in
+// an editor's workspace folder, if any, and how many others work there.
+type EditorStatus struct {
+	Folder  string         `json:"folder"`
+	Session *EditorSession `json:"session,omitempty"`
+	Others  int            `json:"others"`
+}
+
+// EditorSession is the part of a session an editor status bar shows.
+type EditorSession struct {
+	ID                 string    `json:"id"`
+	Name               string    `json:"name"`
+	Activity           Activity  `json:"activity"`
+	ContextUtilization float64   `json:"contextUtilization"`
+	Model              string    `json:"model,omitempty"`
+	CurrentTool        string    `json:"currentTool,omitempty"`
+	StartedAt          time.Time `json:"startedAt"`
+}
+
+// Health is returned by /healthz. Status is "ok" or "degraded".
+type Health struct {
+	Status        string                `json:"status"`
+	Uptime        string                `json:"uptime"`
+	UptimeSeconds float64               `json:"uptimeSeconds"`
+	Sources       []SourceHealthPayload `json:"sources,omitempty"`
+}
+
+// HeatRequest is the body of POST /api/heats.
+type HeatRequest struct {
+	Name       string     `json:"name"`
+	SessionIDs []string   `json:"sessionIds"`
+	StartAt    *time.Time `json:"startAt,omitempty"`
+	Finish     HeatFinish `json:"finish"`
+	Metric     string     `json:"metric,omitempty"`
+}
+
+// LaunchTemplate is a configured way to start an agent, as listed by
+// GET /api/launch.
+type LaunchTemplate struct {
+	Name        string   `json:"name"`
+	Command     []string `json:"command"`
+	Cwd         string   `json:"cwd,omitempty"`
+	Model       string   `json:"model,omitempty"`
+	Source      string   `json:"source"`
+	TmuxSession string   `json:"tmuxSession,omitempty"`
+	Window      string   `json:"window,omitempty"`
+}
+
+// LaunchRequest is the body of POST /api/launch.
+type LaunchRequest struct {
+	Template string `json:"template"`
+	Model    string `json:"model,omitempty"`
+}
+
+// Pipeline is a configured relay of launch templates.
+type Pipeline struct {
+	Name   string   `json:"name"`
+	Stages []string `json:"stages"`
+}
+
+// PipelinesResponse is returned by GET /api/pipelines.
+type PipelinesResponse struct {
+	Pipelines []Pipeline    `json:"pipelines"`
+	Runs      []PipelineRun `json:"runs"`
+}
+
+// PipelineRequest is the body of POST /api/pipelines: a configured
+// pipeline by name, or an ad hoc one when Stages is set.
+type PipelineRequest struct {
+	Pipeline string   `json:"pipeline"`
+	Stages   []string `json:"stages,omitempty"`
+}
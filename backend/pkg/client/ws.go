@@ -0,0 +1,300 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// maxMessageSize bounds one inbound message. The largest the server sends
+// is a catch_up message.
+const maxMessageSize = 1 << 20
+
+// DialOptions configure Dial.
+type DialOptions struct {
+	Token string
+	TLS   *tls.Config // used for wss:// URLs when set
+	// ClientID and Since resume a stream: the server first sends a
+	// catch_up message with what came after seq Since, if it still has it.
+	ClientID string
+	Since    uint64
+	// SnapshotPageSize splits snapshots into messages of about this many
+	// sessions, for servers with more than fit comfortably in one; see
+	// SnapshotAssembler. 0 sends each snapshot whole.
+	SnapshotPageSize int
+	// LazySubagents leaves subagents out of snapshots, for clients that
+	// only fetch them for the sessions they show in detail.
+	LazySubagents bool
+}
+
+// Conn is a connection to the server's /ws message stream.
+type Conn struct {
+	ws *websocket.Conn
+
+	writeMu sync.Mutex
+	mu      sync.Mutex
+	seq     uint64
+}
+
+// ResumeURL adds the query parameters that ask the server to catch a client
+// up from since. It returns wsURL unchanged when since is 0.
+func ResumeURL(wsURL, clientID string, since uint64) string {
+	if since == 0 {
+		return wsURL
+	}
+	u, err := url.Parse(wsURL)
+	if err != nil {
+		return wsURL
+	}
+	q := u.Query()
+	q.Set("client", clientID)
+	q.Set("since", strconv.FormatUint(since, 10))
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// SnapshotURL adds the query parameters that ask the server to send
+// snapshots in pages of pageSize sessions and, with lazySubagents, without
+// subagents. It returns wsURL unchanged when neither is asked for.
+func SnapshotURL(wsURL string, pageSize int, lazySubagents bool) string {
+	if pageSize <= 0 && !lazySubagents {
+		return wsURL
+	}
+	u, err := url.Parse(wsURL)
+	if err != nil {
+		return wsURL
+	}
+	q := u.Query()
+	if pageSize > 0 {
+		q.Set("page_size", strconv.Itoa(pageSize))
+	}
+	if lazySubagents {
+		q.Set("subagents", "lazy")
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// Dial connects to wsURL (e.g. "ws://127.0.0.1:8080/ws") and authenticates.
+func Dial(ctx context.Context, wsURL string, opts DialOptions) (*Conn, error) {
+	dialer := &websocket.Dialer{HandshakeTimeout: 10 * time.Second}
+	if opts.TLS != nil {
+		dialer.TLSClientConfig = opts.TLS
+		dialer.Proxy = http.ProxyFromEnvironment
+	}
+	wsURL = SnapshotURL(ResumeURL(wsURL, opts.ClientID, opts.Since), opts.SnapshotPageSize, opts.LazySubagents)
+	ws, _, err := dialer.DialContext(ctx, wsURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Token != "" {
+		if err := ws.WriteJSON(map[string]string{"type": "auth", "token": opts.Token}); err != nil {
+			_ = ws.Close()
+			return nil, fmt.Errorf("ws auth: %w", err)
+		}
+	}
+	ws.SetReadLimit(maxMessageSize)
+	return &Conn{ws: ws, seq: opts.Since}, nil
+}
+
+// Read returns the next message. Use Decode for its payload.
+func (c *Conn) Read() (WSMessage, error) {
+	for {
+		_, data, err := c.ws.ReadMessage()
+		if err != nil {
+			return WSMessage{}, err
+		}
+		var msg WSMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			continue
+		}
+		c.mu.Lock()
+		c.seq = msg.Seq
+		c.mu.Unlock()
+		return msg, nil
+	}
+}
+
+// Seq returns the seq of the last message read, to pass as
+// DialOptions.Since when reconnecting.
+func (c *Conn) Seq() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.seq
+}
+
+// Resync asks the server for a fresh snapshot.
+func (c *Conn) Resync() error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return c.ws.WriteJSON(map[string]string{"type": "resync"})
+}
+
+// Hello introduces this connection to the other viewers as name, a client
+// of the given kind such as "tui". The server answers every client with a
+// MsgPresence message.
+func (c *Conn) Hello(name, kind string) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return c.ws.WriteJSON(struct {
+		Type string `json:"type"`
+		HelloRequest
+	}{"hello", HelloRequest{Name: name, Kind: kind}})
+}
+
+// React sends emoji as a reaction to the session with the given ID, as
+// this connection was sent it. The server passes it on to every client as
+// a MsgReaction message, or drops it if the emoji isn't allowed or the
+// connection reacts too often.
+func (c *Conn) React(sessionID, emoji string) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return c.ws.WriteJSON(struct {
+		Type string `json:"type"`
+		ReactionRequest
+	}{"reaction", ReactionRequest{SessionID: sessionID, Emoji: emoji}})
+}
+
+// SetPreferences asks the server for timestamps in timeZone, an IANA name,
+// on a "12h" or "24h" clock. Empty values keep the server's defaults. The
+// server answers with a MsgPreferences message.
+func (c *Conn) SetPreferences(timeZone, clock string) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return c.ws.WriteJSON(struct {
+		Type string `json:"type"`
+		PreferencesRequest
+	}{"preferences", PreferencesRequest{TimeZone: timeZone, Clock: clock}})
+}
+
+// Close closes the connection.
+func (c *Conn) Close() error {
+	return c.ws.Close()
+}
+
+// Decode returns msg's payload as its typed value: SnapshotPayload for
+// MsgSnapshot, Heat for MsgHeatStandings and MsgRaceFinished, PipelineRun
+// for MsgPipelineUpdate, the raw payload for MsgError, and so on. Messages
+// of a type this package does not know decode to nil, so older clients can
+// skip what newer servers send.
+func Decode(msg WSMessage) (any, error) {
+	switch msg.Type {
+	case MsgSnapshot:
+		return decodeAs[SnapshotPayload](msg)
+	case MsgDelta:
+		return decodeAs[DeltaPayload](msg)
+	case MsgCompletion:
+		return decodeAs[CompletionPayload](msg)
+	case MsgEquipped:
+		return decodeAs[EquippedPayload](msg)
+	case MsgAchievementUnlocked:
+		return decodeAs[AchievementUnlockedPayload](msg)
+	case MsgAchievementBatch:
+		return decodeAs[AchievementBatchPayload](msg)
+	case MsgSourceHealth:
+		return decodeAs[SourceHealthPayload](msg)
+	case MsgBattlePassProgress:
+		return decodeAs[BattlePassProgressPayload](msg)
+	case MsgOvertake:
+		return decodeAs[OvertakePayload](msg)
+	case MsgServerShutdown:
+		return decodeAs[ServerShutdownPayload](msg)
+	case MsgUpdateAvailable:
+		return decodeAs[UpdateAvailablePayload](msg)
+	case MsgDirectorFocus:
+		return decodeAs[DirectorFocusPayload](msg)
+	case MsgCommentary:
+		return decodeAs[CommentaryPayload](msg)
+	case MsgSoundCue:
+		return decodeAs[SoundCuePayload](msg)
+	case MsgLapCompleted:
+		return decodeAs[LapCompletedPayload](msg)
+	case MsgHeatStandings, MsgRaceFinished:
+		return decodeAs[Heat](msg)
+	case MsgPipelineUpdate:
+		return decodeAs[PipelineRun](msg)
+	case MsgCatchUp:
+		return decodeAs[CatchUpPayload](msg)
+	case MsgCacheCollapse:
+		return decodeAs[CacheCollapsePayload](msg)
+	case MsgApprovalNeeded:
+		return decodeAs[ApprovalNeededPayload](msg)
+	case MsgModelChanged:
+		return decodeAs[ModelChangedPayload](msg)
+	case MsgPreferences:
+		return decodeAs[PreferencesPayload](msg)
+	case MsgSubagentStarted, MsgSubagentCompleted:
+		return decodeAs[SubagentPayload](msg)
+	case MsgSubagentBudget:
+		return decodeAs[SubagentBudgetPayload](msg)
+	case MsgPresence:
+		return decodeAs[PresencePayload](msg)
+	case MsgReaction:
+		return decodeAs[ReactionPayload](msg)
+	case MsgWatchdogAlert:
+		return decodeAs[WatchdogAlertPayload](msg)
+	case MsgMilestone:
+		return decodeAs[MilestonePayload](msg)
+	case MsgError:
+		return msg.Payload, nil
+	}
+	return nil, nil
+}
+
+// SnapshotAssembler joins the pages of a snapshot back into one. Feed it
+// every SnapshotPayload in the order read.
+type SnapshotAssembler struct {
+	pending SnapshotPayload
+}
+
+// Add returns the whole snapshot and true once p completes one, and false
+// while pages are still to come. A whole snapshot is returned as is. A
+// page out of order drops the pages gathered so far, and the snapshot is
+// completed by the next one sent.
+func (a *SnapshotAssembler) Add(p SnapshotPayload) (SnapshotPayload, bool) {
+	if p.Pages <= 1 {
+		a.pending = SnapshotPayload{}
+		return p, true
+	}
+	if p.Page != a.pending.Page+1 {
+		a.pending = SnapshotPayload{}
+		if p.Page != 1 {
+			return SnapshotPayload{}, false
+		}
+	}
+	a.pending.Page = p.Page
+	a.pending.Sessions = append(a.pending.Sessions, p.Sessions...)
+	for id, n := range p.SubagentCounts {
+		if a.pending.SubagentCounts == nil {
+			a.pending.SubagentCounts = make(map[string]int)
+		}
+		a.pending.SubagentCounts[id] = n
+	}
+	if p.Page < p.Pages {
+		return SnapshotPayload{}, false
+	}
+	whole := SnapshotPayload{
+		Sessions:       a.pending.Sessions,
+		Teams:          p.Teams,
+		SourceHealth:   p.SourceHealth,
+		SubagentCounts: a.pending.SubagentCounts,
+	}
+	a.pending = SnapshotPayload{}
+	return whole, true
+}
+
+func decodeAs[T any](msg WSMessage) (any, error) {
+	var p T
+	if err := json.Unmarshal(msg.Payload, &p); err != nil {
+		return nil, fmt.Errorf("decode %s: %w", msg.Type, err)
+	}
+	return p, nil
+}
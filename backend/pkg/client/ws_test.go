@@ -0,0 +1,127 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestResumeURL(t *testing.T) {
+	if got := ResumeURL("ws://host/ws", "bot", 0); got != "ws://host/ws" {
+		t.Errorf("ResumeURL(since 0) = %q", got)
+	}
+	if got := ResumeURL("ws://host/ws?x=1", "bot", 42); got != "ws://host/ws?client=bot&since=42&x=1" {
+		t.Errorf("ResumeURL = %q", got)
+	}
+}
+
+func TestSnapshotURL(t *testing.T) {
+	if got := SnapshotURL("ws://host/ws", 0, false); got != "ws://host/ws" {
+		t.Errorf("SnapshotURL(whole) = %q", got)
+	}
+	if got := SnapshotURL("ws://host/ws?client=bot", 50, true); got != "ws://host/ws?client=bot&page_size=50&subagents=lazy" {
+		t.Errorf("SnapshotURL = %q", got)
+	}
+}
+
+func TestSnapshotAssembler(t *testing.T) {
+	var a SnapshotAssembler
+	page := func(n, of int, ids ...string) SnapshotPayload {
+		p := SnapshotPayload{Page: n, Pages: of}
+		for _, id := range ids {
+			p.Sessions = append(p.Sessions, &SessionState{ID: id})
+		}
+		return p
+	}
+	ids := func(p SnapshotPayload) string {
+		var out []string
+		for _, s := range p.Sessions {
+			out = append(out, s.ID)
+		}
+		return strings.Join(out, ",")
+	}
+
+	if _, done := a.Add(page(1, 2, "a", "b")); done {
+		t.Fatal("first of two pages completed the snapshot")
+	}
+	last := page(2, 2, "c")
+	last.Teams = []TeamInfo{{Name: "api"}}
+	last.SubagentCounts = map[string]int{"c": 3}
+	got, done := a.Add(last)
+	if !done || ids(got) != "a,b,c" || len(got.Teams) != 1 || got.SubagentCounts["c"] != 3 || got.Pages != 0 {
+		t.Errorf("assembled %+v (done %v), want a,b,c with the last page's teams", got, done)
+	}
+
+	// A page missed in between: wait for the next snapshot's first page.
+	a.Add(page(1, 3, "a"))
+	if _, done := a.Add(page(3, 3, "c")); done {
+		t.Error("a snapshot with a missing page completed")
+	}
+	if _, done := a.Add(page(2, 2, "b")); done {
+		t.Error("a page after the gap completed a snapshot")
+	}
+	if got, done := a.Add(page(1, 1, "z")); !done || ids(got) != "z" {
+		t.Errorf("whole snapshot = %+v (done %v), want it as is", got, done)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	v, err := Decode(WSMessage{Type: MsgHeatStandings, Payload: []byte(`{"id":"h1","status":"running","standings":[{"sessionId":"a","rank":1}]}`)})
+	h, ok := v.(Heat)
+	if err != nil || !ok || h.Standings[0].SessionID != "a" {
+		t.Fatalf("Decode(heat_standings) = %#v, %v", v, err)
+	}
+	if _, err := Decode(WSMessage{Type: MsgDelta, Payload: []byte(`[`)}); err == nil {
+		t.Error("Decode of a malformed payload should fail")
+	}
+	if v, err := Decode(WSMessage{Type: "from_the_future", Payload: []byte(`{}`)}); v != nil || err != nil {
+		t.Errorf("Decode(unknown) = %v, %v; want nil, nil", v, err)
+	}
+}
+
+func TestDialAuthenticatesAndResumes(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("client") != "bot" || r.URL.Query().Get("since") != "7" {
+			http.Error(w, "bad query", http.StatusBadRequest)
+			return
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		var auth map[string]string
+		if err := conn.ReadJSON(&auth); err != nil || auth["token"] != "tok" {
+			return
+		}
+		_ = conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"snapshot","seq":9,"payload":{"sessions":[{"id":"a"}]}}`))
+		_, _, _ = conn.ReadMessage() // wait for the client to hang up
+	}))
+	defer srv.Close()
+
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws"
+	c, err := Dial(context.Background(), url, DialOptions{Token: "tok", ClientID: "bot", Since: 7})
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer c.Close()
+	if c.Seq() != 7 {
+		t.Errorf("Seq before reading = %d, want 7", c.Seq())
+	}
+	msg, err := c.Read()
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	v, err := Decode(msg)
+	if snap, ok := v.(SnapshotPayload); err != nil || !ok || snap.Sessions[0].ID != "a" {
+		t.Fatalf("Decode = %#v, %v", v, err)
+	}
+	if c.Seq() != 9 {
+		t.Errorf("Seq = %d, want 9", c.Seq())
+	}
+}
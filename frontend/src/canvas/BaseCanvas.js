@@ -63,6 +63,9 @@ export class BaseCanvas {
     this._trackGroupsKey = '';
     this._zoneCounts = { racing: 0, pit: 0, parked: 0 };
     this._needsResize = false;
+    this._transparent = false;
+    this._showDashboard = true;
+    this._zoom = 1;
 
     this.resize();
     this._resizeHandler = () => this.resize();
@@ -86,25 +89,43 @@ export class BaseCanvas {
     this.connected = connected;
   }
 
+  /**
+   * Stream-overlay rendering: skip the page background so the canvas can be
+   * keyed over other content, optionally drop the stats dashboard, and
+   * zoom the scene by scale.
+   */
+  setOverlayMode({ transparent = true, dashboard = false, scale = 1 } = {}) {
+    this._transparent = transparent;
+    this._showDashboard = dashboard;
+    this._zoom = scale > 0 ? scale : 1;
+    this.resize();
+  }
+
   resize() {
-    const dpr = window.devicePixelRatio || 1;
+    // Overlay zoom is folded into the pixel ratio: the scene is laid out for
+    // a viewport 1/zoom the size and drawn zoom times larger.
+    const zoom = this._zoom;
+    const dpr = (window.devicePixelRatio || 1) * zoom;
     const rect = this.canvas.parentElement.getBoundingClientRect();
-    const viewportWidth = rect.width;
-    const viewportHeight = rect.height;
+    const viewportWidth = rect.width / zoom;
+    const viewportHeight = rect.height / zoom;
 
     this.track.updateViewport(viewportHeight);
 
     const zonesHeight = this.track.getRequiredHeight(this._trackGroups, this._pitLaneCount, this._parkingLotLaneCount);
-    const dashMinHeight = this.dashboard.getRequiredHeight(this.entities.size);
-    const dashFromViewport = Math.max(0, viewportHeight - zonesHeight);
-    const dashHeight = Math.max(dashMinHeight, dashFromViewport);
+    let dashHeight = 0;
+    if (this._showDashboard) {
+      const dashMinHeight = this.dashboard.getRequiredHeight(this.entities.size);
+      const dashFromViewport = Math.max(0, viewportHeight - zonesHeight);
+      dashHeight = Math.max(dashMinHeight, dashFromViewport);
+    }
     const height = zonesHeight + dashHeight;
 
     if (this.width === viewportWidth && this.height === height && this._dpr === dpr) {
       return;
     }
 
-    this.canvas.style.height = `${height}px`;
+    this.canvas.style.height = `${height * zoom}px`;
     this.canvas.width = viewportWidth * dpr;
     this.canvas.height = height * dpr;
     this.ctx.scale(dpr, dpr);
@@ -418,8 +439,10 @@ export class BaseCanvas {
       ctx.translate(sx, sy);
     }
 
-    ctx.fillStyle = this._backgroundColor;
-    ctx.fillRect(-10, -10, this.width + 20, this.height + 20);
+    if (!this._transparent) {
+      ctx.fillStyle = this._backgroundColor;
+      ctx.fillRect(-10, -10, this.width + 20, this.height + 20);
+    }
     this.weather.drawBehind(ctx, this.width, this.height);
 
     const pitLaneCount = this._pitLaneCount;
@@ -477,6 +500,9 @@ export class BaseCanvas {
   }
 
   _drawDashboard(ctx, groups, pitLaneCount, parkingLotLaneCount) {
+    if (!this._showDashboard) {
+      return;
+    }
     const zonesHeight = this.track.getRequiredHeight(groups, pitLaneCount, parkingLotLaneCount);
     const dashAvailable = this.height - zonesHeight;
     if (dashAvailable <= 40) {
@@ -543,8 +569,8 @@ export class BaseCanvas {
   _getMousePosition(e) {
     const rect = this.canvas.getBoundingClientRect();
     return {
-      x: e.clientX - rect.left,
-      y: e.clientY - rect.top,
+      x: (e.clientX - rect.left) / this._zoom,
+      y: (e.clientY - rect.top) / this._zoom,
     };
   }
 
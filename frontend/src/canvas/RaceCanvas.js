@@ -116,6 +116,26 @@ export class RaceCanvas extends BaseCanvas {
     }
   }
 
+  onReaction(payload) {
+    const racer = this.racers.get(payload.sessionId);
+    if (racer) racer.react(payload.emoji);
+  }
+
+  onSubagentStarted(payload) {
+    const racer = this.racers.get(payload.sessionId);
+    if (racer) {
+      // A puff of air behind the car, where the new hamster will trail
+      this.particles.emit('draftTurbulence', racer.displayX - 30, racer.displayY, 6);
+    }
+  }
+
+  onSubagentCompleted(payload) {
+    const hamster = this.racers.get(payload.sessionId)?.hamsters.get(payload.subagentId);
+    if (hamster) {
+      this.particles.emit('overtakeSwoosh', hamster.displayX, hamster.displayY, 5);
+    }
+  }
+
   onError(sessionId) {
     const racer = this.racers.get(sessionId);
     if (racer) {
@@ -309,6 +329,10 @@ export class RaceCanvas extends BaseCanvas {
     if (racer.state.activity === 'waiting' && Math.sin(racer.hazardPhase) > 0) {
       this._drawGlow(gc, x, y, 25, { r: 255, g: 170, b: 0 }, 0.3);
     }
+
+    if (racer.state.activity === 'needs_approval' && Math.sin(racer.hazardPhase) > 0) {
+      this._drawGlow(gc, x, y, 30, { r: 255, g: 60, b: 90 }, 0.4);
+    }
   }
 
   _drawDraftLines(ctx) {
@@ -360,8 +384,8 @@ export class RaceCanvas extends BaseCanvas {
       ctx.fill();
 
       // Speed delta badge between the two cars
-      const leaderBurn = leader.state.burnRatePerMinute || 0;
-      const drafterBurn = drafter.state.burnRatePerMinute || 0;
+      const leaderBurn = leader.state.burnRateSmoothed ?? leader.state.burnRatePerMinute ?? 0;
+      const drafterBurn = drafter.state.burnRateSmoothed ?? drafter.state.burnRatePerMinute ?? 0;
       const deltaBurn = leaderBurn - drafterBurn;
       if (Math.abs(deltaBurn) > 100) {
         const midX = (leaderRearX + drafterNoseX) / 2;
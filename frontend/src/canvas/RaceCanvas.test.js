@@ -605,6 +605,35 @@ describe('RaceCanvas', () => {
     });
   });
 
+  describe('overlay mode', () => {
+    it('drops the dashboard from the canvas height', () => {
+      const withDashboard = rc.height;
+      rc.setOverlayMode({ transparent: true, dashboard: false });
+
+      expect(rc.height).toBe(rc.track.getRequiredHeight(rc._trackGroups, 0, 0));
+      expect(rc.height).toBeLessThan(withDashboard);
+      rc.draw();
+      expect(rc.dashboard.draw).not.toHaveBeenCalled();
+    });
+
+    it('skips the background fill when transparent', () => {
+      rc.setConnected(true);
+      rc.setOverlayMode({ transparent: true, dashboard: true });
+      rc.ctx.fillRect.mockClear();
+      rc.draw();
+
+      const fills = rc.ctx.fillRect.mock.calls.filter(([x, y]) => x === -10 && y === -10);
+      expect(fills).toHaveLength(0);
+    });
+
+    it('lays the scene out for a zoomed viewport', () => {
+      rc.setOverlayMode({ scale: 2 });
+
+      expect(rc.width).toBe(400);
+      expect(canvas.width).toBe(800);
+    });
+  });
+
   describe('animation loop', () => {
     it('requests an animation frame on construction', () => {
       expect(requestAnimationFrame).toHaveBeenCalled();
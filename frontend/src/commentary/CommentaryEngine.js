@@ -11,6 +11,7 @@ function sessionName(session) {
  */
 const PRIORITY = {
   completion: 10,
+  server: 9,
   error: 10,
   overtake: 8,
   context_90: 7,
@@ -170,6 +171,15 @@ export class CommentaryEngine {
     this._enqueue('compaction', { name });
   }
 
+  /**
+   * Queues a line the server already rendered (from a WebSocket
+   * commentary event). It goes through the same cooldown as local lines.
+   */
+  onServerLine(text) {
+    if (!text) return;
+    this._push(text, PRIORITY.server);
+  }
+
   /**
    * Returns the current message to display, or null if none.
    */
@@ -187,8 +197,10 @@ export class CommentaryEngine {
   _enqueue(trigger, vars) {
     const template = pickTemplate(trigger);
     if (!template) return;
-    const text = fillTemplate(template, vars);
-    const priority = PRIORITY[trigger] || 0;
+    this._push(fillTemplate(template, vars), PRIORITY[trigger] || 0);
+  }
+
+  _push(text, priority) {
     this._queue.push({ text, priority, time: Date.now() });
     // Sort by priority descending, then by time ascending
     this._queue.sort((a, b) => b.priority - a.priority || a.time - b.time);
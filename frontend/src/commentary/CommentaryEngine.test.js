@@ -362,6 +362,18 @@ describe('CommentaryEngine', () => {
     expect(messages.some(m => m.includes('Compactor'))).toBe(true);
   });
 
+  // --- onServerLine ---
+
+  it('emits server-rendered lines verbatim', () => {
+    const { engine, messages } = createEngine();
+
+    engine.onServerLine('Photo finish between opus and sonnet!');
+    engine.onServerLine('');
+    engine.processUpdate(new Map()); // flush
+
+    expect(messages).toEqual(['Photo finish between opus and sonnet!']);
+  });
+
   // --- getCurrentMessage / clearMessage ---
 
   it('getCurrentMessage returns the last emitted message', () => {
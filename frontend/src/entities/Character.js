@@ -219,7 +219,7 @@ export class Character {
     const activity = this.state.activity;
 
     // Burn rate intensity
-    const burnRate = this.state.burnRatePerMinute || 0;
+    const burnRate = this.state.burnRateSmoothed ?? this.state.burnRatePerMinute ?? 0;
     let burnIntensity = 0;
     if (burnRate > 5000) burnIntensity = 3;
     else if (burnRate > 2000) burnIntensity = 2;
@@ -254,6 +254,7 @@ export class Character {
         break;
 
       case 'waiting':
+      case 'needs_approval':
         // Standing: head turns, occasional yawn
         this.targetGlow = 0.05;
         this.headTurnPhase += 0.03 * dtScale;
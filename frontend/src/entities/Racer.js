@@ -13,6 +13,8 @@ const DIRECTORY_FLAG_MIN_FONT = 11;
 const DIRECTORY_FLAG_MAX_FONT = 14;
 const DIRECTORY_FLAG_MIN_WIDTH = 84;
 const TRACK_COMPLETE_ALPHA = 0.72;
+const REACTION_LIFETIME = 2; // seconds an emoji reaction floats above the car
+const MAX_REACTIONS = 6;
 
 function clamp(value, min, max) {
   return Math.min(max, Math.max(min, value));
@@ -200,6 +202,9 @@ export class Racer {
     // Comic speech bubble
     this.bubble = new SpeechBubble();
 
+    // Emoji viewers sent, floating up off the roof
+    this.reactions = [];
+
     // Draft/overtake mechanics
     this.draftIntensity = 0;   // 0-1: how deep in draft zone (set by RaceCanvas)
     this.overtakeFlash = 0;    // 0-1: overtake flash intensity (decays over time)
@@ -223,6 +228,9 @@ export class Racer {
       case 'errored':
         this.bubble.show('exclamation', '!');
         break;
+      case 'needs_approval':
+        this.bubble.show('exclamation', '?');
+        break;
       case 'complete':
         this.bubble.show('complete', '\u2713');
         break;
@@ -414,7 +422,7 @@ export class Racer {
     const S = CAR_SCALE;
 
     // Burn rate drives exhaust intensity: higher burn = more/bigger flames
-    const burnRate = this.state.burnRatePerMinute || 0;
+    const burnRate = this.state.burnRateSmoothed ?? this.state.burnRatePerMinute ?? 0;
     const burnIntensity = burnRate > 5000 ? 3 : burnRate > 2000 ? 2 : burnRate > 500 ? 1 : 0;
 
     // Draft boost: drafter car emits extra turbulent exhaust
@@ -485,6 +493,7 @@ export class Racer {
         break;
 
       case 'waiting':
+      case 'needs_approval':
         this.hazardPhase += 0.1 * dtScale;
         this.targetGlow = 0.05;
         this.colorBrightness = Math.max(0, this.colorBrightness - 1 * dtScale);
@@ -627,7 +636,9 @@ export class Racer {
       let i = 0;
       for (const hamster of this.hamsters.values()) {
         const yOffset = (i - (count - 1) / 2) * ySpacing;
-        const xStagger = -i * 15;
+        // Nested subagents trail further back, behind the one towing them
+        const depth = Math.max(1, hamster.state.depth || 1);
+        const xStagger = -i * 15 - (depth - 1) * 30;
         hamster.setTarget(baseX + xStagger, this.displayY + yOffset);
         hamster.animate(particles, dt);
         i++;
@@ -649,6 +660,16 @@ export class Racer {
     }
 
     this.bubble.update(dt || 1 / 60);
+
+    for (let i = this.reactions.length - 1; i >= 0; i--) {
+      this.reactions[i].age += dt || 1 / 60;
+      if (this.reactions[i].age >= REACTION_LIFETIME) this.reactions.splice(i, 1);
+    }
+  }
+
+  react(emoji) {
+    if (this.reactions.length >= MAX_REACTIONS) this.reactions.shift();
+    this.reactions.push({ emoji, age: 0, drift: (Math.random() - 0.5) * 24 });
   }
 
   draw(ctx) {
@@ -805,6 +826,19 @@ export class Racer {
       this.bubble.draw(ctx, x, y);
       ctx.restore();
     }
+
+    if (this.reactions.length > 0) {
+      ctx.save();
+      ctx.font = '18px sans-serif';
+      ctx.textAlign = 'center';
+      for (let i = 0; i < this.reactions.length; i++) {
+        const r = this.reactions[i];
+        const t = r.age / REACTION_LIFETIME;
+        ctx.globalAlpha = this.opacity * (1 - t);
+        ctx.fillText(r.emoji, x + r.drift, y - 12 * CAR_SCALE - 10 - t * 40);
+      }
+      ctx.restore();
+    }
   }
 
   _drawPositionBadge(ctx, x, y, color) {
@@ -1091,6 +1125,7 @@ export class Racer {
         break;
 
       case 'waiting':
+      case 'needs_approval':
         this._drawHazardLights(ctx, x, y);
         break;
 
@@ -1626,6 +1661,14 @@ export class Racer {
   }
 
   _getDirectoryFlagLabel() {
+    // Topics are opt-in on the server (privacy.show_topics) and describe
+    // the task better than the directory does.
+    if (this.state.topic) {
+      return this.state.topic;
+    }
+    if (this.state.project && this.state.worktree) {
+      return `${this.state.project} (worktree: ${this.state.worktree})`;
+    }
     const workingDirBase = this.state.workingDir ? basename(this.state.workingDir) : '';
     const sessionName = this.state.name || '';
     const slug = (workingDirBase && workingDirBase !== 'unknown')
@@ -1820,26 +1863,32 @@ export class Racer {
 
     const S = CAR_SCALE;
 
-    // Attachment points: car rear bumper to skateboard front
-    const carX = this.displayX - (17 + LIMO_STRETCH) * S;
-    const carY = this.displayY + this.springY + 1 * S;
+    // Attachment points: car rear bumper to skateboard front, or the rear
+    // of the parent's skateboard for a nested subagent
+    let anchorX = this.displayX - (17 + LIMO_STRETCH) * S;
+    let anchorY = this.displayY + this.springY + 1 * S;
+    const parent = hamster.state.parentId && this.hamsters.get(hamster.state.parentId);
+    if (parent) {
+      anchorX = parent.displayX - 10;
+      anchorY = parent.displayY;
+    }
     const hamsterX = hamster.displayX + 10;
     const hamsterY = hamster.displayY;
 
     // Rope sag increases with distance
-    const dx = hamsterX - carX;
-    const dy = hamsterY - carY;
+    const dx = hamsterX - anchorX;
+    const dy = hamsterY - anchorY;
     const sag = 8 + Math.sqrt(dx * dx + dy * dy) * 0.02;
 
     // Control point (midpoint with sag)
-    const cpX = (carX + hamsterX) / 2;
-    const cpY = (carY + hamsterY) / 2 + sag;
+    const cpX = (anchorX + hamsterX) / 2;
+    const cpY = (anchorY + hamsterY) / 2 + sag;
 
     // Main rope
     ctx.strokeStyle = '#8B7355';
     ctx.lineWidth = 1.5;
     ctx.beginPath();
-    ctx.moveTo(carX, carY);
+    ctx.moveTo(anchorX, anchorY);
     ctx.quadraticCurveTo(cpX, cpY, hamsterX, hamsterY);
     ctx.stroke();
 
@@ -1847,7 +1896,7 @@ export class Racer {
     ctx.strokeStyle = 'rgba(255,255,255,0.15)';
     ctx.lineWidth = 0.5;
     ctx.beginPath();
-    ctx.moveTo(carX, carY);
+    ctx.moveTo(anchorX, anchorY);
     ctx.quadraticCurveTo(cpX, cpY - 1, hamsterX, hamsterY);
     ctx.stroke();
   }
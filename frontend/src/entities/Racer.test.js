@@ -349,6 +349,13 @@ describe('glow intensity by activity', () => {
     // burnIntensity 2 → 0.12 + 2*0.02 = 0.16
     expect(racer.targetGlow).toBeCloseTo(0.16);
   });
+
+  it('prefers the smoothed burn rate over a raw spike', () => {
+    const racer = new Racer(makeState({ activity: 'thinking', burnRatePerMinute: 6000, burnRateSmoothed: 800 }));
+    racer.animate(null, 1 / 60);
+    // burnIntensity 1 → 0.08 + 1*0.02 = 0.10
+    expect(racer.targetGlow).toBeCloseTo(0.10);
+  });
 });
 
 describe('activity transition detection', () => {
@@ -466,6 +473,29 @@ describe('directory flag rendering', () => {
     expect(racer._getDirectoryFlagLabel()).toBe('feature-fast-flags');
   });
 
+  it('labels worktree sessions with their parent project', () => {
+    const racer = new Racer(makeState({
+      name: 'agent-racer--fix-login',
+      workingDir: '/tmp/agent-racer--fix-login',
+      branch: 'fix-login',
+      project: 'agent-racer',
+      worktree: 'fix-login',
+    }));
+
+    expect(racer._getDirectoryFlagLabel()).toBe('agent-racer (worktree: fix-login)');
+  });
+
+  it('labels the racer with its topic when the server provides one', () => {
+    const racer = new Racer(makeState({
+      name: 'agent-racer',
+      workingDir: '/tmp/agent-racer',
+      branch: 'main',
+      topic: 'fix flaky websocket test',
+    }));
+
+    expect(racer._getDirectoryFlagLabel()).toBe('fix flaky websocket test');
+  });
+
   it('allows the pennant to start off-screen left but clamps the right edge', () => {
     const racer = new Racer(makeState({
       name: 'very-long-directory-name-for-session-alpha',
@@ -1,4 +1,5 @@
 import { authFetch } from '../auth.js';
+import { formatDate } from '../ui/formatters.js';
 
 const CATEGORIES = [
   'Session Milestones',
@@ -7,6 +8,8 @@ const CATEGORIES = [
   'Performance & Endurance',
   'Spectacle',
   'Streaks',
+  'Racing',
+  'Tool Usage',
 ];
 
 const TIER_CLASSES = new Set(['bronze', 'silver', 'gold', 'platinum']);
@@ -25,6 +28,8 @@ const CATEGORY_ICONS = {
   'Performance & Endurance':'\u26A1',    // lightning bolt
   'Spectacle':              '\u{1F3A8}', // artist palette
   'Streaks':                '\u{1F525}', // fire
+  'Racing':                 '\u{1F3C6}', // trophy
+  'Tool Usage':             '\u{1F527}', // wrench
 };
 
 function escapeHTML(s) {
@@ -282,7 +287,7 @@ export class AchievementPanel {
     const desc = escapeHTML(achievement.description);
     let statusLine;
     if (achievement.unlocked && achievement.unlockedAt) {
-      const date = new Date(achievement.unlockedAt).toLocaleDateString();
+      const date = formatDate(achievement.unlockedAt);
       statusLine = `<div class="ap-tooltip-unlocked">\u2713 Unlocked ${date}</div>`;
     } else {
       statusLine = `<div class="ap-tooltip-locked">\u{1F512} ${desc}</div>`;
@@ -17,6 +17,7 @@ const MAX_TIERS = 10;
 const XP_PER_TIER = 1000;
 const MAX_XP_LOG_ENTRIES = 20;
 const NEAR_TIER_UP_THRESHOLD = 0.9;
+const MAX_PAST_SEASONS = 5;
 
 const CONFETTI_COUNT = 30;
 const CONFETTI_CANVAS_HEIGHT = 120;
@@ -35,6 +36,8 @@ export class BattlePassBar {
     this.container = container;
     this.state = { xp: 0, tier: 1, tierProgress: 0, recentXP: [], rewards: [] };
     this.challenges = [];
+    this.quests = { quests: [], streak: 0 };
+    this.pastSeasons = [];
     this.xpLog = [];
     this.expanded = false;
     this.toastTimer = null;
@@ -88,15 +91,23 @@ export class BattlePassBar {
     this.tierTrack = document.createElement('div');
     this.tierTrack.className = 'bp-tier-track';
 
+    this.questSection = document.createElement('div');
+    this.questSection.className = 'bp-challenges bp-quests';
+
     this.challengeSection = document.createElement('div');
     this.challengeSection.className = 'bp-challenges';
 
     this.xpLogSection = document.createElement('div');
     this.xpLogSection.className = 'bp-xp-log';
 
+    this.seasonSection = document.createElement('div');
+    this.seasonSection.className = 'bp-challenges bp-past-seasons';
+
     this.expandedPanel.appendChild(this.tierTrack);
+    this.expandedPanel.appendChild(this.questSection);
     this.expandedPanel.appendChild(this.challengeSection);
     this.expandedPanel.appendChild(this.xpLogSection);
+    this.expandedPanel.appendChild(this.seasonSection);
 
     this.container.appendChild(this.collapsedRow);
     this.container.appendChild(this.expandedPanel);
@@ -104,9 +115,12 @@ export class BattlePassBar {
 
   async loadInitialData() {
     try {
-      const [statsRes, challengesRes] = await Promise.all([
+      const [statsRes, challengesRes, questsRes, seasonsRes] = await Promise.all([
         authFetch('/api/stats'),
         authFetch('/api/challenges'),
+        // Servers from before daily quests or season history lack the endpoint.
+        authFetch('/api/quests').catch(() => null),
+        authFetch('/api/gamification/seasons').catch(() => null),
       ]);
 
       if (statsRes.ok) {
@@ -128,6 +142,13 @@ export class BattlePassBar {
       if (challengesRes.ok) {
         this.challenges = await challengesRes.json();
       }
+      if (questsRes?.ok) {
+        this.quests = await questsRes.json();
+      }
+      if (seasonsRes?.ok) {
+        const history = await seasonsRes.json();
+        this.pastSeasons = history.archived || [];
+      }
     } catch (err) {
       // Silently fail — bar stays at defaults until first WS message
     }
@@ -166,12 +187,19 @@ export class BattlePassBar {
 
   async refreshChallenges() {
     try {
-      const res = await authFetch('/api/challenges');
+      const [res, questsRes] = await Promise.all([
+        authFetch('/api/challenges'),
+        authFetch('/api/quests').catch(() => null),
+      ]);
       if (res.ok) {
         this.challenges = await res.json();
-        if (this.expanded) {
-          this.renderChallenges();
-        }
+      }
+      if (questsRes?.ok) {
+        this.quests = await questsRes.json();
+      }
+      if (this.expanded) {
+        this.renderQuests();
+        this.renderChallenges();
       }
     } catch {
       // ignore
@@ -343,8 +371,10 @@ export class BattlePassBar {
 
   renderExpanded() {
     this.renderTierTrack();
+    this.renderQuests();
     this.renderChallenges();
     this.renderXPLog();
+    this.renderPastSeasons();
   }
 
   renderTierTrack() {
@@ -398,6 +428,32 @@ export class BattlePassBar {
     }
   }
 
+  renderQuests() {
+    this.questSection.innerHTML = '';
+
+    const title = document.createElement('div');
+    title.className = 'bp-challenges-title';
+    title.textContent = 'Daily Quests';
+    const streak = this.quests.streak || 0;
+    if (streak > 0) {
+      const badge = document.createElement('span');
+      badge.className = 'bp-quest-streak';
+      badge.textContent = `\u{1F525} ${streak}-day streak`;
+      title.appendChild(badge);
+    }
+    this.questSection.appendChild(title);
+
+    const quests = this.quests.quests || [];
+    if (!quests.length) {
+      const empty = document.createElement('div');
+      empty.className = 'bp-empty-message';
+      empty.textContent = 'No quests today';
+      this.questSection.appendChild(empty);
+      return;
+    }
+    this.renderGoalRows(this.questSection, quests);
+  }
+
   renderChallenges() {
     this.challengeSection.innerHTML = '';
 
@@ -413,8 +469,12 @@ export class BattlePassBar {
       this.challengeSection.appendChild(empty);
       return;
     }
+    this.renderGoalRows(this.challengeSection, this.challenges);
+  }
 
-    for (const c of this.challenges) {
+  /** Append a description, count and progress bar row per challenge or quest. */
+  renderGoalRows(section, goals) {
+    for (const c of goals) {
       const row = document.createElement('div');
       row.className = 'bp-challenge-row';
 
@@ -439,7 +499,43 @@ export class BattlePassBar {
       row.appendChild(desc);
       row.appendChild(progress);
       row.appendChild(barWrap);
-      this.challengeSection.appendChild(row);
+      section.appendChild(row);
+    }
+  }
+
+  renderPastSeasons() {
+    this.seasonSection.innerHTML = '';
+    // Nothing to show until a season has ended.
+    if (!this.pastSeasons.length) return;
+
+    const title = document.createElement('div');
+    title.className = 'bp-challenges-title';
+    title.textContent = 'Past Seasons';
+    this.seasonSection.appendChild(title);
+
+    for (const s of this.pastSeasons.slice(0, MAX_PAST_SEASONS)) {
+      const row = document.createElement('div');
+      row.className = 'bp-challenge-row bp-past-season';
+
+      const name = document.createElement('span');
+      name.className = 'bp-challenge-desc';
+      name.textContent = `Season ${s.season}`;
+
+      const result = document.createElement('span');
+      result.className = 'bp-challenge-progress';
+      result.textContent = `Tier ${s.tier} · ${s.xp} XP`;
+
+      row.appendChild(name);
+      row.appendChild(result);
+
+      // Seasons archived before totals were recorded have none.
+      if (s.totals) {
+        const totals = document.createElement('span');
+        totals.className = 'bp-challenge-progress bp-past-season-totals';
+        totals.textContent = `${s.totals.sessions} sessions · ${formatCount(s.totals.tokensBurned)} tokens`;
+        row.appendChild(totals);
+      }
+      this.seasonSection.appendChild(row);
     }
   }
 
@@ -477,6 +573,12 @@ export class BattlePassBar {
   }
 }
 
+function formatCount(n) {
+  if (n >= 1_000_000) return `${(n / 1_000_000).toFixed(1)}M`;
+  if (n >= 1_000) return `${(n / 1_000).toFixed(1)}K`;
+  return String(n);
+}
+
 function formatReason(reason) {
   return reason.replace(/_/g, ' ').replace(/\b\w/g, c => c.toUpperCase());
 }
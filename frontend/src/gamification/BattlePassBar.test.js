@@ -24,11 +24,13 @@ function mockChallengesResponse(challenges = []) {
   };
 }
 
-/** Route /api/stats and /api/challenges to mock responses. */
-function mockEndpoints(stats = {}, challenges = []) {
+/** Route /api/stats, /api/challenges, /api/quests and /api/gamification/seasons to mock responses. */
+function mockEndpoints(stats = {}, challenges = [], quests = { quests: [], streak: 0 }, seasons = { archived: [] }) {
   authFetch.mockImplementation((url) => {
     if (url === '/api/stats') return Promise.resolve(mockStatsResponse(stats));
     if (url === '/api/challenges') return Promise.resolve(mockChallengesResponse(challenges));
+    if (url === '/api/quests') return Promise.resolve({ ok: true, json: () => Promise.resolve(quests) });
+    if (url === '/api/gamification/seasons') return Promise.resolve({ ok: true, json: () => Promise.resolve(seasons) });
     return Promise.reject(new Error('unexpected URL: ' + url));
   });
 }
@@ -39,8 +41,8 @@ function rejectAllFetches() {
 }
 
 /** Create a BattlePassBar and wait for loadInitialData to settle. */
-async function createAndHydrate(stats, challenges) {
-  mockEndpoints(stats, challenges);
+async function createAndHydrate(stats, challenges, quests, seasons) {
+  mockEndpoints(stats, challenges, quests, seasons);
   const bar = new BattlePassBar(container);
   await vi.runAllTimersAsync();
   await Promise.resolve();
@@ -113,6 +115,30 @@ describe('BattlePassBar', () => {
       expect(bar.challenges).toHaveLength(1);
       expect(bar.challenges[0].description).toBe('Run 5 sessions');
     });
+
+    it('loads daily quests from /api/quests', async () => {
+      const quests = {
+        day: '2026-03-02',
+        quests: [{ id: 'context_95', description: 'Push a session to 95% context', current: 0, target: 1, complete: false }],
+        streak: 4,
+        longestStreak: 9,
+      };
+      const bar = await createAndHydrate({ tier: 1, xp: 0 }, [], quests);
+
+      expect(bar.quests.streak).toBe(4);
+      expect(bar.quests.quests[0].id).toBe('context_95');
+    });
+
+    it('loads archived seasons from /api/gamification/seasons', async () => {
+      const seasons = {
+        current: { season: '2026-03', tier: 2, xp: 1400 },
+        archived: [{ season: '2026-02', tier: 7, xp: 6800 }],
+      };
+      const bar = await createAndHydrate({ tier: 2, xp: 1400 }, [], undefined, seasons);
+
+      expect(bar.pastSeasons).toHaveLength(1);
+      expect(bar.pastSeasons[0].season).toBe('2026-02');
+    });
   });
 
   describe('render', () => {
@@ -420,6 +446,55 @@ describe('BattlePassBar', () => {
     });
   });
 
+  describe('renderQuests', () => {
+    it('shows empty message when there are no quests', () => {
+      const bar = new BattlePassBar(container);
+      bar.renderQuests();
+
+      expect(bar.questSection.querySelector('.bp-empty-message').textContent).toBe('No quests today');
+      expect(bar.questSection.querySelector('.bp-quest-streak')).toBeNull();
+    });
+
+    it('renders quest rows and the streak', () => {
+      const bar = new BattlePassBar(container);
+      bar.quests = {
+        quests: [{ description: 'Complete a session today', current: 1, target: 1, complete: true }],
+        streak: 3,
+      };
+      bar.renderQuests();
+
+      const rows = bar.questSection.querySelectorAll('.bp-challenge-row');
+      expect(rows).toHaveLength(1);
+      expect(rows[0].querySelector('.bp-challenge-desc').classList.contains('complete')).toBe(true);
+      expect(bar.questSection.querySelector('.bp-quest-streak').textContent).toContain('3-day streak');
+    });
+  });
+
+  describe('renderPastSeasons', () => {
+    it('renders nothing before a season has ended', () => {
+      const bar = new BattlePassBar(container);
+      bar.renderPastSeasons();
+
+      expect(bar.seasonSection.children).toHaveLength(0);
+    });
+
+    it('renders each season with its totals when recorded', () => {
+      const bar = new BattlePassBar(container);
+      bar.pastSeasons = [
+        { season: '2026-02', tier: 7, xp: 6800, totals: { sessions: 41, tokensBurned: 2_300_000 } },
+        { season: '2026-01', tier: 3, xp: 2100 },
+      ];
+      bar.renderPastSeasons();
+
+      const rows = bar.seasonSection.querySelectorAll('.bp-past-season');
+      expect(rows).toHaveLength(2);
+      expect(rows[0].textContent).toContain('Season 2026-02');
+      expect(rows[0].textContent).toContain('Tier 7 · 6800 XP');
+      expect(rows[0].querySelector('.bp-past-season-totals').textContent).toBe('41 sessions · 2.3M tokens');
+      expect(rows[1].querySelector('.bp-past-season-totals')).toBeNull();
+    });
+  });
+
   describe('renderXPLog', () => {
     it('shows empty message when no XP entries', () => {
       const bar = new BattlePassBar(container);
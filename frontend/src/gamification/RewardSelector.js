@@ -145,6 +145,7 @@ export class RewardSelector {
     this._destroyed = false;
     this._achievements = [];      // from /api/achievements
     this._battlePassTier = 0;     // from /api/stats
+    this._seasonRewards = new Set(); // granted for good by past seasons
     this._equipping = false;      // guard against double-click
     this._returnFocus = null;
 
@@ -211,6 +212,7 @@ export class RewardSelector {
       if (statsResp.ok) {
         const stats = await statsResp.json();
         this._battlePassTier = stats.battlePass?.tier ?? 0;
+        this._seasonRewards = new Set(Object.keys(stats.seasonRewards || {}));
       }
       return true;
     } catch (err) {
@@ -284,7 +286,9 @@ export class RewardSelector {
       for (const reward of slotRewards) {
         const isEquipped = loadout[slot.key] === reward.id;
         let isUnlocked;
-        if (reward.unlockedBy === '') {
+        if (this._seasonRewards.has(reward.id)) {
+          isUnlocked = true;
+        } else if (reward.unlockedBy === '') {
           // Battle pass reward: check tier
           const requiredTier = BATTLE_PASS_TIERS[reward.id] ?? Infinity;
           isUnlocked = this._battlePassTier >= requiredTier;
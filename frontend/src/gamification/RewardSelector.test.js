@@ -232,6 +232,24 @@ describe('RewardSelector', () => {
       });
     });
 
+    it('keeps rewards granted by a past season unlocked', async () => {
+      authFetch.mockImplementation((url) => {
+        if (url === '/api/achievements') return Promise.resolve(mockAchievementsResponse([]));
+        if (url === '/api/stats') return Promise.resolve({
+          ok: true,
+          json: () => Promise.resolve({ battlePass: { tier: 1 }, seasonRewards: { flame_trail: '2026-02' } }),
+        });
+        return Promise.resolve({ ok: false, status: 404 });
+      });
+      rs = new RewardSelector();
+      rs.show();
+      await vi.waitFor(() => {
+        const tile = document.querySelector('.rs-tile[aria-label="Flame Trail, equip"]');
+        expect(tile).toBeTruthy();
+      });
+      expect(document.querySelector('.rs-tile[aria-label^="Silver Badge, locked"]')).toBeTruthy();
+    });
+
     it('shows error message on fetch failure without overwriting it', async () => {
       authFetch.mockRejectedValue(new Error('network error'));
       rs = new RewardSelector();
@@ -2,7 +2,7 @@ import { RaceConnection } from './websocket.js';
 import { createView, getViewTypes } from './ViewRenderer.js';
 import { SpeechBubble } from './entities/SpeechBubble.js';
 import { SoundEngine } from './audio/SoundEngine.js';
-import { requestPermission, notifyCompletion } from './notifications.js';
+import { requestPermission, notifyCompletion, notifyWatchdog, notifyMilestone, notifyBudget } from './notifications.js';
 import { AchievementPanel } from './gamification/AchievementPanel.js';
 import { UnlockToast } from './gamification/UnlockToast.js';
 import { RewardSelector } from './gamification/RewardSelector.js';
@@ -10,6 +10,7 @@ import { BattlePassBar } from './gamification/BattlePassBar.js';
 import { setEquipped } from './gamification/CosmeticRegistry.js';
 import { authFetch, clearStoredAuthToken, getAuthToken } from './auth.js';
 import { isTerminalActivity } from './session/constants.js';
+import { esc, formatTime, setTimePreferences } from './ui/formatters.js';
 import { createFlyout } from './ui/detailFlyout.js';
 import { createSessionTracker } from './ui/sessionTracker.js';
 import { initAmbientAudio } from './ui/ambientAudio.js';
@@ -30,18 +31,26 @@ const debugClose = document.getElementById('debug-close');
 const detailFlyout = document.getElementById('detail-flyout');
 const flyoutContent = document.getElementById('flyout-content');
 const flyoutClose = document.getElementById('flyout-close');
+const reactionBar = document.getElementById('reaction-bar');
 const statusDot = document.getElementById('connection-status');
 const statusLabel = document.getElementById('connection-status-label');
 const connectionHelp = document.getElementById('connection-help');
+const updateNotice = document.getElementById('update-notice');
 const sessionCount = document.getElementById('session-count');
+const viewerCount = document.getElementById('viewer-count');
 const canvas = document.getElementById('race-canvas');
 const trackEditor = new TrackEditor(canvas);
 
 let sessions = new Map();
 const debugEnabled = import.meta.env?.DEV || new URLSearchParams(window.location.search).has('debug');
+// Wall dashboards opt in with ?director to follow the server's focus hints.
+const directorEnabled = new URLSearchParams(window.location.search).has('director');
 let debugVisible = false;
 let muted = false;
 let bubblesEnabled = true;
+const heatsSeen = new Set();
+// Pipeline run ID -> leg last announced, so each baton pass logs once.
+const relayLegs = new Map();
 
 // Replay mode: when active, live WebSocket updates do not render to the canvas.
 let replayActive = false;
@@ -74,6 +83,7 @@ function closeReplay() {
 }
 
 const VIEW_STORAGE_KEY = 'agent-racer-view';
+const VIEWER_NAME_STORAGE_KEY = 'agent-racer-viewer-name';
 const COMMENTARY_STORAGE_KEY = 'agent-racer-commentary';
 const COMMENTARY_MODES = ['ticker', 'announcer', 'off'];
 
@@ -103,7 +113,7 @@ let minimap = new Minimap();
 
 function bindMinimapToActiveView() {
   minimap.raceCanvas = activeView;
-  minimap.onDotClick = focusRacerFromMinimap;
+  minimap.onDotClick = focusRacer;
 }
 
 function recreateMinimap() {
@@ -116,7 +126,7 @@ function recreateMinimap() {
 
 bindMinimapToActiveView();
 
-function focusRacerFromMinimap(state) {
+function focusRacer(state) {
   const container = document.getElementById('race-container');
   const entity = activeView.entities.get(state.id);
   if (!entity) return;
@@ -150,6 +160,10 @@ const CONNECTION_STATUS_UI = {
     label: 'Unauthorized',
     help: 'Open with #token=<token> or refresh with a valid token.',
   },
+  server_shutdown: {
+    label: 'Server stopped',
+    help: 'The server shut down. Reconnecting when it is back.',
+  },
 };
 
 commentary.onMessage = (text) => {
@@ -186,7 +200,7 @@ function log(msg, type = '') {
   if (!debugEnabled) return;
   const entry = document.createElement('div');
   entry.className = `log-entry ${type}`;
-  const ts = new Date().toLocaleTimeString();
+  const ts = formatTime(new Date());
   entry.textContent = `[${ts}] ${msg}`;
   debugLog.appendChild(entry);
   debugLog.scrollTop = debugLog.scrollHeight;
@@ -238,9 +252,19 @@ function handleSnapshot(payload) {
   }
 }
 
+// A repository's .agent-racer.yaml can turn notification kinds off for
+// its sessions.
+function isMuted(sessionId, kind) {
+  return sessions.get(sessionId)?.muted?.includes(kind) ?? false;
+}
+
 function handleDelta(payload) {
   if (payload.updates) {
     for (const s of payload.updates) {
+      if (s.overBudget && !sessions.get(s.id)?.overBudget) {
+        log(`Session "${s.name}" is over its ${s.tokenBudget} token budget`, 'error');
+        if (!s.muted?.includes('budget')) notifyBudget(s.name, s.tokenBudget);
+      }
       sessions.set(s.id, s);
       if (!replayActive) activeView.updateRacer(s);
     }
@@ -268,24 +292,90 @@ function handleDelta(payload) {
 function handleCompletion(payload) {
   const isSuccess = payload.activity === 'complete';
   log(`Session "${payload.name}" ${payload.activity}`, isSuccess ? 'info' : 'error');
-  notifyCompletion(payload.name, payload.activity);
+  if (!isMuted(payload.sessionId, 'completion')) notifyCompletion(payload.name, payload.activity);
   commentary.onCompletion(payload.sessionId, payload.name, payload.activity);
 
   if (isSuccess) {
     activeView.onComplete(payload.sessionId);
-    engine.playVictory();
     engine.recordCompletion();
   } else {
     activeView.onError(payload.sessionId);
-    engine.playCrash();
     engine.recordCrash();
   }
 }
 
+function handleWatchdogAlert(payload) {
+  log(`Watchdog: no session activity since ${new Date(payload.quietSince).toLocaleTimeString()}`, 'error');
+  notifyWatchdog(payload.quietSince, payload.lastSessionName);
+}
+
+function handleMilestone(payload) {
+  const what = payload.kind === 'tokens' ? `${payload.label} tokens` : `${payload.label} on track`;
+  log(`Milestone: ${payload.name} ${what}`, 'info');
+  if (!isMuted(payload.sessionId, 'milestone')) notifyMilestone(payload.name, payload.kind, payload.label);
+}
+
 function handleAchievementUnlocked(payload) {
   log(`Achievement unlocked: ${payload.name} (${payload.tier})`, 'info');
   unlockToast.show(payload);
   achievementPanel.markDirty();
+  acknowledgeAchievements([payload.id]);
+}
+
+// One toast for a burst of unlocks, headed by the highest tier among them.
+function handleAchievementBatch(payload) {
+  const unlocked = payload.achievements || [];
+  if (unlocked.length === 0) return;
+  for (const a of unlocked) {
+    log(`Achievement unlocked: ${a.name} (${a.tier})`, 'info');
+  }
+  showUnlockSummary(unlocked);
+  achievementPanel.markDirty();
+  acknowledgeAchievements(unlocked.map((a) => a.id));
+}
+
+const TIER_ORDER = ['bronze', 'silver', 'gold', 'platinum'];
+
+function showUnlockSummary(unlocked) {
+  if (unlocked.length === 1) {
+    unlockToast.show(unlocked[0]);
+    return;
+  }
+  const best = unlocked.reduce((a, b) => (TIER_ORDER.indexOf(b.tier) > TIER_ORDER.indexOf(a.tier) ? b : a));
+  const names = unlocked.slice(0, 3).map((a) => a.name).join(', ');
+  const more = unlocked.length > 3 ? ` and ${unlocked.length - 3} more` : '';
+  unlockToast.show({
+    name: `${unlocked.length} achievements unlocked`,
+    description: names + more,
+    tier: best.tier,
+  });
+}
+
+// Shows the toasts of achievements that unlocked while no dashboard was
+// connected, then tells the server they have been seen.
+async function replayMissedUnlocks() {
+  try {
+    const response = await authFetch('/api/achievements?unseen=true');
+    if (!response.ok) return;
+    const missed = await response.json();
+    if (missed.length === 0) return;
+    for (const a of missed) {
+      log(`Achievement unlocked while away: ${a.name} (${a.tier})`, 'info');
+    }
+    showUnlockSummary(missed);
+    achievementPanel.markDirty();
+    acknowledgeAchievements(missed.map((a) => a.id));
+  } catch {
+    // Missed toasts are best-effort; the panel still lists the unlocks.
+  }
+}
+
+function acknowledgeAchievements(ids) {
+  authFetch('/api/achievements/ack', {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify({ ids }),
+  }).catch(() => {});
 }
 
 function handleEquipped(payload) {
@@ -304,7 +394,160 @@ function handleBattlePassProgress(payload) {
 function handleOvertake(payload) {
   log(`Overtake! ${payload.overtakerName} passed ${payload.overtakenName} (pos ${payload.newPosition})`, 'info');
   activeView.onOvertake && activeView.onOvertake(payload);
-  engine.playOvertakeWhoosh();
+}
+
+function handleLapCompleted(payload) {
+  log(`Lap ${payload.lap}: ${payload.name}`, 'info');
+}
+
+function handleModelChanged(payload) {
+  log(`${payload.name}: ${payload.oldModel} → ${payload.newModel}`, 'info');
+}
+
+// Who else is watching. Other viewers see the name given once with
+// ?viewer=<name>, which is remembered for later visits.
+function viewerName() {
+  const fromURL = new URLSearchParams(window.location.search).get('viewer');
+  if (fromURL !== null) {
+    localStorage.setItem(VIEWER_NAME_STORAGE_KEY, fromURL);
+    return fromURL;
+  }
+  return localStorage.getItem(VIEWER_NAME_STORAGE_KEY) || '';
+}
+
+function handlePresence(payload) {
+  const viewers = payload.viewers || 0;
+  viewerCount.textContent = `${viewers} viewers`;
+  viewerCount.title = (payload.named || []).map(v => v.name).join(', ');
+  viewerCount.classList.toggle('hidden', viewers < 2);
+}
+
+// Reactions go to the session open in the detail flyout; the server says
+// which emoji it accepts in the preferences message.
+function renderReactionBar(emoji) {
+  reactionBar.innerHTML = emoji
+    .map(e => `<button class="reaction-btn" data-emoji="${esc(e)}" title="React with ${esc(e)}">${esc(e)}</button>`)
+    .join('');
+  reactionBar.classList.toggle('hidden', emoji.length === 0);
+}
+
+function handleReaction(payload) {
+  if (replayActive || !payload?.sessionId) return;
+  activeView.onReaction && activeView.onReaction(payload);
+  log(`${payload.from || 'A viewer'} reacted ${payload.emoji} to ${payload.name}`, 'info');
+}
+
+// Hamsters follow the subagents in each delta; these mark the moments one
+// is let loose or comes back.
+function handleSubagentStarted(payload) {
+  activeView.onSubagentStarted && activeView.onSubagentStarted(payload);
+}
+
+function handleSubagentCompleted(payload) {
+  activeView.onSubagentCompleted && activeView.onSubagentCompleted(payload);
+}
+
+function handlePreferences(payload) {
+  setTimePreferences(payload || {});
+  renderReactionBar(payload?.reactions || []);
+  if (payload?.error) log(`Display preferences rejected: ${payload.error}`, 'error');
+}
+
+// Heats get a log line when they start and when they end; the standings
+// in between are available from /api/heats.
+function handleHeatStandings(heat) {
+  if (!heat) return;
+  if (heat.status === 'finished') {
+    const winner = heat.standings?.find(s => s.sessionId === heat.winnerId);
+    log(`Heat "${heat.name}" over: ${winner ? `${winner.name} wins` : 'no finishers'}`, 'info');
+  } else if (heat.status === 'running' && !heatsSeen.has(heat.id)) {
+    log(`Heat "${heat.name}" started with ${heat.standings?.length || 0} sessions`, 'info');
+  }
+  if (heat.status !== 'pending') heatsSeen.add(heat.id);
+}
+
+// Pipelines run as relays: each stage's session takes the baton when the
+// one before it completes.
+function handlePipelineUpdate(run) {
+  if (!run?.stages?.length) return;
+  const leg = run.stages[run.leg];
+  if (run.status === 'finished') {
+    log(`Relay "${run.pipeline}" finished all ${run.stages.length} legs`, 'info');
+    relayLegs.delete(run.id);
+  } else if (run.status === 'failed') {
+    log(`Relay "${run.pipeline}" dropped the baton at ${leg.template}: ${leg.error || 'failed'}`, 'error');
+    relayLegs.delete(run.id);
+  } else if (relayLegs.get(run.id) !== run.leg) {
+    const what = run.leg === 0 ? 'started with' : 'baton passed to';
+    log(`Relay "${run.pipeline}" ${what} ${leg.template} (${run.leg + 1}/${run.stages.length})`, 'info');
+    relayLegs.set(run.id, run.leg);
+  }
+}
+
+// The server decides when these fire (sound_cue messages). The start and
+// achievement cues are left alone here: the session tracker's appear sound
+// and the unlock toast's chime already cover them. A session stopping for
+// approval borrows the chime, since someone has to come and look.
+const SOUND_CUE_PLAYERS = {
+  overtake: () => engine.playOvertakeWhoosh(),
+  finish: () => engine.playVictory(),
+  error: () => engine.playCrash(),
+  approval: () => engine.playUnlockChime('bronze'),
+};
+
+function handleSoundCue(payload) {
+  const play = SOUND_CUE_PLAYERS[payload?.cue];
+  if (play) play();
+}
+
+function handleDirectorFocus(payload) {
+  if (!directorEnabled || replayActive || !payload?.sessionId) return;
+  const state = sessions.get(payload.sessionId);
+  if (!state) return;
+  log(`Director: ${payload.name || payload.sessionId} (${payload.reason})`, 'info');
+  focusRacer(state);
+}
+
+function handleCommentary(payload) {
+  if (replayActive) return;
+  commentary.onServerLine(payload?.text);
+}
+
+// Frontend build hash reported by /api/version when this page loaded. A
+// different hash after a reconnect means the server was upgraded.
+let loadedFrontendVersion = null;
+
+async function checkVersionSkew() {
+  try {
+    const response = await authFetch('/api/version');
+    if (!response.ok) return;
+    const info = await response.json();
+    if (info.update) handleUpdateAvailable(info.update);
+    if (!info.frontend) return;
+    if (loadedFrontendVersion === null) {
+      loadedFrontendVersion = info.frontend;
+      return;
+    }
+    if (info.frontend !== loadedFrontendVersion) {
+      log(`Server upgraded to ${info.version}; reload to update the dashboard`, 'info');
+      connectionHelp.textContent = 'A newer dashboard is available. Reload the page to update.';
+      connectionHelp.classList.remove('hidden');
+    }
+  } catch {
+    // Version checks are best-effort.
+  }
+}
+
+function handleUpdateAvailable(payload) {
+  if (!updateNotice || !payload?.latest) return;
+  if (updateNotice.dataset.version !== payload.latest) {
+    log(`Update available: ${payload.latest} (running ${payload.current})`, 'info');
+  }
+  updateNotice.dataset.version = payload.latest;
+  updateNotice.textContent = `Update ${payload.latest}`;
+  updateNotice.title = `Agent Racer ${payload.latest} is available (running ${payload.current})`;
+  if (payload.url) updateNotice.href = payload.url;
+  updateNotice.classList.remove('hidden');
 }
 
 function handleStatus(status) {
@@ -320,6 +563,10 @@ function handleStatus(status) {
   connectionHelp.classList.toggle('hidden', !ui.help);
   activeView.setConnected(status === 'connected');
   log(`Connection: ${status}`, status === 'connected' ? 'info' : 'error');
+  if (status === 'connected') {
+    checkVersionSkew();
+    replayMissedUnlocks();
+  }
 }
 
 export function wireViewCallbacks(view, flyout, unlockToast) {
@@ -399,7 +646,39 @@ function switchView(type) {
 
 flyoutClose.addEventListener('click', () => flyout.hide());
 
+async function shareSession(btn) {
+  try {
+    const response = await authFetch(`/api/sessions/${encodeURIComponent(btn.dataset.share)}/share`, {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify({ timeline: true }),
+    });
+    if (!response.ok) {
+      log(`Share failed: ${response.status}`, 'error');
+      return;
+    }
+    const { url, expiresAt } = await response.json();
+    await navigator.clipboard.writeText(url);
+    log(`Share link copied (expires ${new Date(expiresAt).toLocaleString()})`, 'info');
+    btn.textContent = '\u2713';
+    setTimeout(() => { btn.innerHTML = '&#x1F517;'; }, 1500);
+  } catch (err) {
+    log(`Share failed: ${err.message}`, 'error');
+  }
+}
+
 detailFlyout.addEventListener('click', (e) => {
+  const reactionBtn = e.target.closest('.reaction-btn');
+  if (reactionBtn) {
+    const id = flyout.getSelectedSessionId();
+    if (id) conn.sendReaction(id, reactionBtn.dataset.emoji);
+    return;
+  }
+  const shareBtn = e.target.closest('.share-btn');
+  if (shareBtn) {
+    shareSession(shareBtn);
+    return;
+  }
   const btn = e.target.closest('.copy-btn');
   if (!btn) return;
   const text = btn.dataset.copy;
@@ -538,9 +817,27 @@ const conn = new RaceConnection({
   authToken: getAuthToken(),
   onSourceHealth: handleSourceHealth,
   onAchievementUnlocked: handleAchievementUnlocked,
+  onAchievementBatch: handleAchievementBatch,
   onEquipped: handleEquipped,
   onBattlePassProgress: handleBattlePassProgress,
   onOvertake: handleOvertake,
+  onServerShutdown: (payload) => log(`Server shutting down: ${payload?.reason || 'no reason given'}`, 'error'),
+  onUpdateAvailable: handleUpdateAvailable,
+  onDirectorFocus: handleDirectorFocus,
+  onCommentary: handleCommentary,
+  onSoundCue: handleSoundCue,
+  onLapCompleted: handleLapCompleted,
+  onHeatStandings: handleHeatStandings,
+  onPipelineUpdate: handlePipelineUpdate,
+  onModelChanged: handleModelChanged,
+  onPreferences: handlePreferences,
+  onSubagentStarted: handleSubagentStarted,
+  onSubagentCompleted: handleSubagentCompleted,
+  onPresence: handlePresence,
+  onReaction: handleReaction,
+  onWatchdogAlert: handleWatchdogAlert,
+  onMilestone: handleMilestone,
+  viewerName: viewerName(),
   onAuthFailure: () => {
     clearStoredAuthToken();
     log('Authentication failed. Cleared stored token. Re-open with #token=<token>.', 'error');
@@ -53,6 +53,7 @@ vi.mock('./audio/SoundEngine.js', () => ({
       playGearShift: vi.fn(),
       playVictory: vi.fn(),
       playCrash: vi.fn(),
+      playOvertakeWhoosh: vi.fn(),
       startAmbient: vi.fn(),
       reconcileViewSwitch: vi.fn(),
       setMuted: vi.fn(),
@@ -69,6 +70,9 @@ vi.mock('./audio/SoundEngine.js', () => ({
 vi.mock('./notifications.js', () => ({
   requestPermission: vi.fn(),
   notifyCompletion: vi.fn(),
+  notifyWatchdog: vi.fn(),
+  notifyMilestone: vi.fn(),
+  notifyBudget: vi.fn(),
 }));
 
 function setupDOM() {
@@ -82,6 +86,7 @@ function setupDOM() {
       <button id="flyout-close"></button>
     </div>
     <div class="header-right">
+      <a id="update-notice" class="hidden"></a>
       <div id="session-count"></div>
       <div class="connection-status-group">
         <div id="connection-status"></div>
@@ -220,6 +225,137 @@ describe('connection status UI', () => {
     expect(help.textContent).toBe('');
     expect(help.className).toContain('hidden');
   });
+
+  it('prompts a reload when the frontend build changes across reconnects', async () => {
+    const help = document.getElementById('connection-help');
+    const versionResponse = (frontend) => (url) => Promise.resolve(url === '/api/version' ? {
+      ok: true,
+      json: () => Promise.resolve({ version: 'v2', frontend }),
+    } : { ok: false });
+
+    globalThis.fetch = vi.fn(versionResponse('aaa'));
+    mocks.conn.onStatus('connected');
+    await vi.waitFor(() => expect(globalThis.fetch).toHaveBeenCalledWith('/api/version', expect.anything()));
+    await new Promise((resolve) => setTimeout(resolve, 0));
+    expect(help.className).toContain('hidden');
+
+    globalThis.fetch = vi.fn(versionResponse('bbb'));
+    mocks.conn.onStatus('disconnected');
+    mocks.conn.onStatus('connected');
+    await vi.waitFor(() => expect(help.className).not.toContain('hidden'));
+    expect(help.textContent).toContain('Reload the page');
+  });
+
+  it('replays unlock toasts missed while disconnected and acknowledges them', async () => {
+    const missed = [{ id: 'pit_crew', name: 'Pit Crew', description: 'Run 10 sessions', tier: 'bronze' }];
+    globalThis.fetch = vi.fn((url) => Promise.resolve(url === '/api/achievements?unseen=true'
+      ? { ok: true, json: () => Promise.resolve(missed) }
+      : { ok: true, json: () => Promise.resolve({}) }));
+
+    mocks.conn.onStatus('connected');
+
+    await vi.waitFor(() => expect(globalThis.fetch).toHaveBeenCalledWith('/api/achievements/ack', expect.anything()));
+    const ack = globalThis.fetch.mock.calls.find(([url]) => url === '/api/achievements/ack')[1];
+    expect(ack.method).toBe('POST');
+    expect(JSON.parse(ack.body)).toEqual({ ids: ['pit_crew'] });
+  });
+
+  it('acknowledges every achievement in a batch', async () => {
+    globalThis.fetch = vi.fn(() => Promise.resolve({ ok: true, json: () => Promise.resolve({}) }));
+
+    mocks.conn.onAchievementBatch({
+      achievements: [
+        { id: 'first_lap', name: 'First Lap', tier: 'bronze' },
+        { id: 'redline', name: 'Redline', tier: 'silver' },
+      ],
+      byTier: { bronze: 1, silver: 1 },
+    });
+
+    await vi.waitFor(() => expect(globalThis.fetch).toHaveBeenCalledWith('/api/achievements/ack', expect.anything()));
+    const ack = globalThis.fetch.mock.calls.find(([url]) => url === '/api/achievements/ack')[1];
+    expect(JSON.parse(ack.body)).toEqual({ ids: ['first_lap', 'redline'] });
+  });
+
+  it('shows an update notice linking to the release', () => {
+    const notice = document.getElementById('update-notice');
+
+    mocks.conn.onUpdateAvailable({ current: 'v1.0.0', latest: 'v1.1.0', url: 'https://example.com/v1.1.0' });
+
+    expect(notice.className).not.toContain('hidden');
+    expect(notice.textContent).toBe('Update v1.1.0');
+    expect(notice.getAttribute('href')).toBe('https://example.com/v1.1.0');
+  });
+});
+
+// ── Director focus ────────────────────────────────────────────────────
+
+describe('director focus', () => {
+  function focusOn(id) {
+    mocks.conn.onSnapshot({ sessions: [makeSession({ id })] });
+    mocks.activeView.entities.set(id, { displayX: 100, displayY: 200, state: makeSession({ id }) });
+    mocks.conn.onDirectorFocus({ sessionId: id, name: id, reason: 'burn_rate' });
+  }
+
+  it('ignores hints unless the page opted in', () => {
+    focusOn('s1');
+    expect(document.getElementById('detail-flyout').className).toContain('hidden');
+  });
+
+  it('opens the focused session with ?director', async () => {
+    window.history.replaceState({}, '', '/?director');
+    try {
+      vi.resetModules();
+      setupDOM();
+      await import('./main.js');
+      focusOn('s1');
+      expect(document.getElementById('detail-flyout').className).not.toContain('hidden');
+    } finally {
+      window.history.replaceState({}, '', '/');
+    }
+  });
+});
+
+describe('sound cues', () => {
+  it('plays the sound the server picked', () => {
+    mocks.conn.onSoundCue({ cue: 'finish', sessionId: 's1' });
+    mocks.conn.onSoundCue({ cue: 'error', sessionId: 's2' });
+    mocks.conn.onSoundCue({ cue: 'overtake', sessionId: 's1' });
+    expect(mocks.engine.playVictory).toHaveBeenCalledTimes(1);
+    expect(mocks.engine.playCrash).toHaveBeenCalledTimes(1);
+    expect(mocks.engine.playOvertakeWhoosh).toHaveBeenCalledTimes(1);
+  });
+
+  it('does not derive sounds from completion messages', () => {
+    mocks.conn.onCompletion({ sessionId: 's1', name: 's1', activity: 'complete' });
+    expect(mocks.engine.playVictory).not.toHaveBeenCalled();
+    expect(mocks.engine.recordCompletion).toHaveBeenCalledTimes(1);
+  });
+
+  it('ignores unknown cues', () => {
+    expect(() => mocks.conn.onSoundCue({ cue: 'honk' })).not.toThrow();
+  });
+});
+
+describe('repository notification rules', () => {
+  it('skips notifications the session muted', async () => {
+    const notifications = await import('./notifications.js');
+    mocks.conn.onSnapshot({
+      sessions: [makeSession({ id: 's1', muted: ['completion'] }), makeSession({ id: 's2' })],
+    });
+    mocks.conn.onCompletion({ sessionId: 's1', name: 's1', activity: 'complete' });
+    mocks.conn.onCompletion({ sessionId: 's2', name: 's2', activity: 'complete' });
+    expect(notifications.notifyCompletion).toHaveBeenCalledTimes(1);
+    expect(notifications.notifyCompletion).toHaveBeenCalledWith('s2', 'complete');
+  });
+
+  it('notifies once when a session goes over budget', async () => {
+    const notifications = await import('./notifications.js');
+    mocks.conn.onSnapshot({ sessions: [makeSession({ id: 's1', name: 's1', tokenBudget: 5000 })] });
+    mocks.conn.onDelta({ updates: [makeSession({ id: 's1', name: 's1', tokenBudget: 5000, overBudget: true })] });
+    mocks.conn.onDelta({ updates: [makeSession({ id: 's1', name: 's1', tokenBudget: 5000, overBudget: true })] });
+    expect(notifications.notifyBudget).toHaveBeenCalledTimes(1);
+    expect(notifications.notifyBudget).toHaveBeenCalledWith('s1', 5000);
+  });
 });
 
 // ── Session appear/disappear detection ────────────────────────────────
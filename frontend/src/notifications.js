@@ -1,3 +1,5 @@
+import { formatTokens } from './ui/formatters.js';
+
 let permissionGranted = false;
 
 export async function requestPermission() {
@@ -36,3 +38,45 @@ export function notifyCompletion(name, activity) {
     // Notifications may not be available in all contexts
   }
 }
+
+export function notifyWatchdog(quietSince, lastSessionName) {
+  if (!permissionGranted) return;
+
+  const minutes = Math.max(1, Math.round((Date.now() - new Date(quietSince).getTime()) / 60000));
+  const body = lastSessionName
+    ? `No session activity for ${minutes} min. Last active: ${lastSessionName}`
+    : `No session activity for ${minutes} min`;
+
+  try {
+    new Notification('Agents have gone quiet', { body, tag: 'race-watchdog' });
+  } catch {
+    // Notifications may not be available in all contexts
+  }
+}
+
+export function notifyMilestone(name, kind, label) {
+  if (!permissionGranted) return;
+
+  const body = kind === 'tokens'
+    ? `${name} has burned ${label} tokens`
+    : `${name} has been running for ${label}`;
+
+  try {
+    new Notification(`Milestone: ${name}`, { body, tag: `race-milestone-${name}` });
+  } catch {
+    // Notifications may not be available in all contexts
+  }
+}
+
+export function notifyBudget(name, budget) {
+  if (!permissionGranted) return;
+
+  try {
+    new Notification(`Over budget: ${name}`, {
+      body: `${name} has burned through its ${formatTokens(budget)} token budget`,
+      tag: `race-budget-${name}`,
+    });
+  } catch {
+    // Notifications may not be available in all contexts
+  }
+}
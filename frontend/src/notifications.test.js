@@ -132,3 +132,54 @@ describe('notifyCompletion', () => {
     expect(() => notifyCompletion('x', 'complete')).not.toThrow();
   });
 });
+
+describe('notifyWatchdog', () => {
+  it('names the last active session', async () => {
+    stubNotification('granted');
+
+    const { requestPermission, notifyWatchdog } = await loadModule();
+    await requestPermission();
+
+    const quietSince = new Date(Date.now() - 30 * 60000).toISOString();
+    notifyWatchdog(quietSince, 'nightly');
+    expect(Notification).toHaveBeenCalledWith(
+      'Agents have gone quiet',
+      { body: 'No session activity for 30 min. Last active: nightly', tag: 'race-watchdog' },
+    );
+  });
+});
+
+describe('notifyMilestone', () => {
+  it('describes token and duration milestones', async () => {
+    stubNotification('granted');
+
+    const { requestPermission, notifyMilestone } = await loadModule();
+    await requestPermission();
+
+    notifyMilestone('api', 'tokens', '1M');
+    expect(Notification).toHaveBeenCalledWith(
+      'Milestone: api',
+      { body: 'api has burned 1M tokens', tag: 'race-milestone-api' },
+    );
+    notifyMilestone('api', 'duration', '4h');
+    expect(Notification).toHaveBeenCalledWith(
+      'Milestone: api',
+      { body: 'api has been running for 4h', tag: 'race-milestone-api' },
+    );
+  });
+});
+
+describe('notifyBudget', () => {
+  it('names the budget the session went over', async () => {
+    stubNotification('granted');
+
+    const { requestPermission, notifyBudget } = await loadModule();
+    await requestPermission();
+
+    notifyBudget('api', 400000);
+    expect(Notification).toHaveBeenCalledWith(
+      'Over budget: api',
+      { body: 'api has burned through its 400K token budget', tag: 'race-budget-api' },
+    );
+  });
+});
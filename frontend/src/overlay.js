@@ -0,0 +1,159 @@
+// Stream overlays served at /overlay/{track,leaderboard}: transparent pages
+// meant for OBS browser sources. Query parameters:
+//   scale=1.5            zoom the whole overlay (0.25-4)
+//   sessions=id,name     only these sessions (matched on id, name or slug)
+//   source=claude,codex  only sessions from these sources
+//   finished=0           hide completed, errored and lost sessions
+//   limit=5              leaderboard rows (default 10)
+//   view=footrace        track view type (default race)
+// Auth works as on the dashboard: append #token=<token>.
+import { RaceConnection } from './websocket.js';
+import { createView, getViewTypes } from './ViewRenderer.js';
+import { getAuthToken } from './auth.js';
+import { isTerminalActivity } from './session/constants.js';
+
+const OVERLAY_KINDS = ['track', 'leaderboard'];
+const DEFAULT_LIMIT = 10;
+const MIN_SCALE = 0.25;
+const MAX_SCALE = 4;
+
+function parseList(value) {
+  if (!value) return null;
+  const items = value.split(',').map((v) => v.trim()).filter(Boolean);
+  return items.length > 0 ? new Set(items) : null;
+}
+
+export function parseOverlayOptions(loc) {
+  const kind = loc.pathname.replace(/^\/overlay\/?/, '').replace(/\/$/, '') || 'track';
+  const params = new URLSearchParams(loc.search);
+
+  const scale = Number(params.get('scale'));
+  const limit = Number.parseInt(params.get('limit'), 10);
+  const view = params.get('view');
+
+  return {
+    kind: OVERLAY_KINDS.includes(kind) ? kind : null,
+    scale: Number.isFinite(scale) && scale > 0 ? Math.min(MAX_SCALE, Math.max(MIN_SCALE, scale)) : 1,
+    sessions: parseList(params.get('sessions')),
+    sources: parseList(params.get('source')),
+    hideFinished: params.get('finished') === '0',
+    limit: limit > 0 ? limit : DEFAULT_LIMIT,
+    view: getViewTypes().includes(view) ? view : 'race',
+  };
+}
+
+export function includeSession(session, opts) {
+  if (opts.sessions && !opts.sessions.has(session.id) && !opts.sessions.has(session.name) && !opts.sessions.has(session.slug)) {
+    return false;
+  }
+  if (opts.sources && !opts.sources.has(session.source)) {
+    return false;
+  }
+  if (opts.hideFinished && isTerminalActivity(session.activity)) {
+    return false;
+  }
+  return true;
+}
+
+// Running sessions by race position, then finished ones by how far they got.
+export function rankSessions(sessions) {
+  return [...sessions].sort((a, b) => {
+    const aDone = isTerminalActivity(a.activity);
+    const bDone = isTerminalActivity(b.activity);
+    if (aDone !== bDone) return aDone ? 1 : -1;
+    if (!aDone && a.position && b.position && a.position !== b.position) {
+      return a.position - b.position;
+    }
+    return (b.contextUtilization || 0) - (a.contextUtilization || 0) || a.id.localeCompare(b.id);
+  });
+}
+
+export function renderLeaderboard(list, sessions, limit) {
+  const rows = rankSessions(sessions).slice(0, limit);
+  list.replaceChildren(...rows.map((s, i) => {
+    const pct = Math.round(Math.min(1, Math.max(0, s.contextUtilization || 0)) * 100);
+    const li = document.createElement('li');
+    li.className = s.activity;
+    li.dataset.id = s.id;
+
+    const rank = document.createElement('span');
+    rank.className = 'rank';
+    rank.textContent = String(i + 1);
+    const name = document.createElement('span');
+    name.className = 'name';
+    name.textContent = s.name || s.id;
+    const value = document.createElement('span');
+    value.className = 'pct';
+    value.textContent = `${pct}%`;
+    const bar = document.createElement('div');
+    bar.className = 'bar';
+    const fill = document.createElement('div');
+    fill.className = 'fill';
+    fill.style.width = `${pct}%`;
+    bar.appendChild(fill);
+
+    li.append(rank, name, value, bar);
+    return li;
+  }));
+}
+
+export function startOverlay(root, opts) {
+  const sessions = new Map();
+
+  let view = null;
+  let list = null;
+  if (opts.kind === 'track') {
+    const container = root.querySelector('#race-container');
+    container.classList.remove('hidden');
+    view = createView(opts.view, container.querySelector('canvas'));
+    view.setOverlayMode({ transparent: true, dashboard: false, scale: opts.scale });
+  } else {
+    list = root.querySelector('#overlay-leaderboard');
+    list.classList.remove('hidden');
+    if (opts.scale !== 1) {
+      list.style.transform = `scale(${opts.scale})`;
+    }
+  }
+
+  const render = () => {
+    const visible = [...sessions.values()].filter((s) => includeSession(s, opts));
+    if (view) {
+      view.setAllRacers(visible);
+    } else {
+      renderLeaderboard(list, visible, opts.limit);
+    }
+  };
+
+  const conn = new RaceConnection({
+    onSnapshot: (payload) => {
+      sessions.clear();
+      for (const s of payload.sessions) sessions.set(s.id, s);
+      render();
+    },
+    onDelta: (payload) => {
+      for (const s of payload.updates || []) sessions.set(s.id, s);
+      for (const id of payload.removed || []) sessions.delete(id);
+      render();
+    },
+    onCompletion: (payload) => {
+      if (!view || !sessions.has(payload.sessionId) || !includeSession(sessions.get(payload.sessionId), opts)) return;
+      if (payload.activity === 'complete') view.onComplete(payload.sessionId);
+      else view.onError(payload.sessionId);
+    },
+    onOvertake: (payload) => view?.onOvertake?.(payload),
+    onStatus: (status) => view?.setConnected(status === 'connected'),
+    authToken: getAuthToken(),
+  });
+  conn.connect();
+  return conn;
+}
+
+const overlayRoot = typeof document !== 'undefined' ? document.getElementById('overlay-root') : null;
+if (overlayRoot) {
+  const opts = parseOverlayOptions(location);
+  if (opts.kind) {
+    startOverlay(overlayRoot, opts);
+  } else {
+    overlayRoot.textContent = `Unknown overlay. Use /overlay/${OVERLAY_KINDS.join(' or /overlay/')}.`;
+  }
+}
@@ -0,0 +1,74 @@
+// @vitest-environment jsdom
+import { describe, expect, it } from 'vitest';
+import { includeSession, parseOverlayOptions, rankSessions, renderLeaderboard } from './overlay.js';
+
+function loc(pathname, search = '') {
+  return { pathname, search };
+}
+
+describe('parseOverlayOptions', () => {
+  it('reads the overlay kind from the path', () => {
+    expect(parseOverlayOptions(loc('/overlay/track')).kind).toBe('track');
+    expect(parseOverlayOptions(loc('/overlay/leaderboard/')).kind).toBe('leaderboard');
+    expect(parseOverlayOptions(loc('/overlay/')).kind).toBe('track');
+    expect(parseOverlayOptions(loc('/overlay/nope')).kind).toBeNull();
+  });
+
+  it('parses and clamps query parameters', () => {
+    const opts = parseOverlayOptions(loc('/overlay/leaderboard', '?scale=9&sessions=a, b,&source=codex&finished=0&limit=3'));
+    expect(opts.scale).toBe(4);
+    expect([...opts.sessions]).toEqual(['a', 'b']);
+    expect([...opts.sources]).toEqual(['codex']);
+    expect(opts.hideFinished).toBe(true);
+    expect(opts.limit).toBe(3);
+  });
+
+  it('falls back to defaults for bad values', () => {
+    const opts = parseOverlayOptions(loc('/overlay/track', '?scale=abc&limit=-1&view=bogus'));
+    expect(opts.scale).toBe(1);
+    expect(opts.limit).toBe(10);
+    expect(opts.view).toBe('race');
+    expect(opts.sessions).toBeNull();
+  });
+});
+
+describe('includeSession', () => {
+  const s = { id: 'id-1', name: 'migrate', slug: 'mighty-castle', source: 'claude', activity: 'complete' };
+
+  it('matches sessions by id, name or slug', () => {
+    for (const key of ['id-1', 'migrate', 'mighty-castle']) {
+      expect(includeSession(s, { sessions: new Set([key]) })).toBe(true);
+    }
+    expect(includeSession(s, { sessions: new Set(['other']) })).toBe(false);
+  });
+
+  it('filters by source and finished state', () => {
+    expect(includeSession(s, { sources: new Set(['codex']) })).toBe(false);
+    expect(includeSession(s, { hideFinished: true })).toBe(false);
+    expect(includeSession({ ...s, activity: 'thinking' }, { hideFinished: true })).toBe(true);
+  });
+});
+
+describe('leaderboard', () => {
+  const sessions = [
+    { id: 'done', name: 'done', activity: 'complete', contextUtilization: 0.9 },
+    { id: 'second', name: 'second', activity: 'thinking', position: 2, contextUtilization: 0.3 },
+    { id: 'first', name: 'first', activity: 'tool_use', position: 1, contextUtilization: 0.6 },
+  ];
+
+  it('ranks running sessions by position ahead of finished ones', () => {
+    expect(rankSessions(sessions).map((s) => s.id)).toEqual(['first', 'second', 'done']);
+  });
+
+  it('renders ranked rows up to the limit', () => {
+    const list = document.createElement('ol');
+    renderLeaderboard(list, sessions, 2);
+
+    const rows = list.querySelectorAll('li');
+    expect(rows).toHaveLength(2);
+    expect(rows[0].querySelector('.name').textContent).toBe('first');
+    expect(rows[0].querySelector('.pct').textContent).toBe('60%');
+    expect(rows[0].className).toBe('tool_use');
+    expect(rows[1].querySelector('.rank').textContent).toBe('2');
+  });
+});
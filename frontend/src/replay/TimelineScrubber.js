@@ -1,4 +1,5 @@
 import { ReplayPlayer } from './ReplayPlayer.js';
+import { formatDateTime, formatTime } from '../ui/formatters.js';
 
 const TERMINAL = new Set(['complete', 'errored', 'lost']);
 
@@ -133,7 +134,7 @@ export class TimelineScrubber {
 
       const dateSpan = document.createElement('span');
       dateSpan.className = 'ts-replay-item-date';
-      dateSpan.textContent = new Date(r.createdAt).toLocaleString();
+      dateSpan.textContent = formatDateTime(r.createdAt);
 
       item.appendChild(nameSpan);
       item.appendChild(sizeSpan);
@@ -302,7 +303,7 @@ export class TimelineScrubber {
 
     const snap = this._snapshots[index];
     if (this._timeEl && snap) {
-      this._timeEl.textContent = snap.t.toLocaleTimeString() + '\u2002' + (index + 1) + '/' + total;
+      this._timeEl.textContent = formatTime(snap.t) + '\u2002' + (index + 1) + '/' + total;
     }
 
     this._drawHeatmapCursor(index);
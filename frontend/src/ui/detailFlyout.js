@@ -1,4 +1,4 @@
-import { formatTokens, formatBurnRate, formatTime, formatElapsed, basename, esc } from './formatters.js';
+import { formatTokens, formatBurnRate, formatLap, formatPercentiles, formatTime, formatElapsed, formatCountdown, formatMCPCalls, formatCounts, basename, esc } from './formatters.js';
 
 function contextBarColor(utilization) {
   if (utilization > 0.8) return '#e94560';
@@ -33,6 +33,18 @@ function renderDetailContent(state) {
       <span class="label">Burn Rate</span>
       <span class="value burn-rate" data-field="burn-rate">${formatBurnRate(state.burnRatePerMinute)}</span>
     </div>
+    <div class="detail-row">
+      <span class="label">Compaction In</span>
+      <span class="value" data-field="compaction-eta">${formatCountdown(state.compactionEta)}</span>
+    </div>
+    <div class="detail-row">
+      <span class="label">Laps</span>
+      <span class="value" data-field="laps">${formatLap(state.lapCount, state.lapProgress)}</span>
+    </div>
+    <div class="detail-row">
+      <span class="label">Vs. Past Sessions</span>
+      <span class="value" data-field="percentiles">${formatPercentiles(state.percentiles)}</span>
+    </div>
     <div class="detail-row">
       <span class="label">Model</span>
       <span class="value">${esc(state.model) || 'unknown'}</span>
@@ -51,6 +63,10 @@ function renderDetailContent(state) {
       <span class="label">Branch</span>
       <span class="value">${esc(state.branch) || '-'}</span>
     </div>
+    ${state.subProject ? `<div class="detail-row">
+      <span class="label">Package</span>
+      <span class="value">${esc(state.subProject)}</span>
+    </div>` : ''}
     <div class="detail-row">
       <span class="label">Tmux</span>
       <span class="value">${state.tmuxTarget ? `${esc(state.tmuxTarget)} <span class="tmux-hint">(click car to jump)</span>` : 'not in tmux'}</span>
@@ -64,6 +80,7 @@ function renderDetailContent(state) {
       <span class="value session-id-value">
         <span class="session-id-text" title="${esc(state.id)}">${esc(state.id).slice(0, 12)}</span>
         <button class="copy-btn" data-copy="${esc(state.id)}" title="Copy full ID">&#x2398;</button>
+        <button class="share-btn" data-share="${esc(state.id)}" title="Copy a read-only share link">&#x1F517;</button>
       </span>
     </div>
     <div class="detail-row">
@@ -78,10 +95,36 @@ function renderDetailContent(state) {
       <span class="label">Tool Calls</span>
       <span class="value" data-field="tool-calls">${state.toolCallCount}</span>
     </div>
+    <div class="detail-row">
+      <span class="label">MCP Servers</span>
+      <span class="value" data-field="mcp-calls">${esc(formatMCPCalls(state.mcpToolCalls))}</span>
+    </div>
+    <div class="detail-row">
+      <span class="label">Shell Commands</span>
+      <span class="value" data-field="shell-commands">${esc(formatCounts(state.shellCommands, 5))}</span>
+    </div>
+    <div class="detail-row">
+      <span class="label">Files Patched</span>
+      <span class="value" data-field="files-patched">${esc(formatCounts(state.filesPatched, 3))}</span>
+    </div>
     <div class="detail-row">
       <span class="label">Current Tool</span>
       <span class="value" data-field="current-tool">${esc(state.currentTool) || '-'}</span>
     </div>
+    ${state.issueUrl ? `
+    <div class="detail-row">
+      <span class="label">Issue</span>
+      <span class="value"><a href="${esc(state.issueUrl)}" target="_blank" rel="noopener noreferrer">${esc(state.issueUrl.split('/').pop())}</a></span>
+    </div>` : ''}
+    ${state.prUrl ? `
+    <div class="detail-row">
+      <span class="label">Pull Request</span>
+      <span class="value"><a href="${esc(state.prUrl)}" target="_blank" rel="noopener noreferrer">#${esc(state.prUrl.split('/').pop())}</a></span>
+    </div>` : ''}
+    <div class="detail-row">
+      <span class="label">Last Command</span>
+      <span class="value" data-field="last-command">${esc(state.lastCommand) || '-'}</span>
+    </div>
     <div class="detail-row">
       <span class="label">Started</span>
       <span class="value">${formatTime(state.startedAt)}</span>
@@ -99,6 +142,11 @@ function renderDetailContent(state) {
       <span class="label">Completed</span>
       <span class="value" data-field="completed">${formatTime(state.completedAt)}</span>
     </div>` : ''}
+    ${state.outcome ? `
+    <div class="detail-row">
+      <span class="label">Outcome</span>
+      <span class="value" data-field="outcome">${esc(state.outcome.replace(/_/g, ' '))}</span>
+    </div>` : ''}
     <div class="detail-row">
       <span class="label">Input Tokens</span>
       <span class="value" data-field="input-tokens">${formatTokens(state.tokensUsed)}</span>
@@ -188,9 +236,16 @@ function patchDetailContent(container, state) {
     `${formatTokens(state.tokensUsed)} / ${formatTokens(state.maxContextTokens)} (${pct}%)`);
 
   patchText(container, 'burn-rate', formatBurnRate(state.burnRatePerMinute));
+  patchText(container, 'compaction-eta', formatCountdown(state.compactionEta));
+  patchText(container, 'laps', formatLap(state.lapCount, state.lapProgress));
+  patchText(container, 'percentiles', formatPercentiles(state.percentiles));
   patchText(container, 'messages', String(state.messageCount));
   patchText(container, 'tool-calls', String(state.toolCallCount));
+  patchText(container, 'mcp-calls', formatMCPCalls(state.mcpToolCalls));
+  patchText(container, 'shell-commands', formatCounts(state.shellCommands, 5));
+  patchText(container, 'files-patched', formatCounts(state.filesPatched, 3));
   patchText(container, 'current-tool', state.currentTool || '-');
+  patchText(container, 'last-command', state.lastCommand || '-');
   patchText(container, 'last-activity', formatTime(state.lastActivityAt));
   patchText(container, 'elapsed', formatElapsed(state.startedAt));
   patchText(container, 'input-tokens', formatTokens(state.tokensUsed));
@@ -231,6 +231,19 @@ describe('createFlyout', () => {
       expect(html).toContain('Bash');
     });
 
+    it('patches the lap counter in place', () => {
+      flyout.show(makeSession({ id: 's1', lapCount: 1, lapProgress: 0.9 }), 400, 300);
+      const laps = els.flyoutContent.querySelector('[data-field="laps"]');
+      expect(laps.textContent).toBe('1 (+90%)');
+
+      flyout.updateContent(new Map([
+        ['s1', makeSession({ id: 's1', lapCount: 2, lapProgress: 0.05 })],
+      ]));
+
+      expect(els.flyoutContent.querySelector('[data-field="laps"]')).toBe(laps);
+      expect(laps.textContent).toBe('2 (+5%)');
+    });
+
     it('updates hamster content when hamster is selected', () => {
       const parent = makeSession({
         id: 'p1',
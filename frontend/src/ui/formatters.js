@@ -11,10 +11,53 @@ export function formatBurnRate(rate) {
   return `${Math.round(rate)}/min`;
 }
 
+// Completed laps plus progress into the current one, e.g. "3 (+40%)".
+export function formatLap(lapCount, lapProgress) {
+  const pct = Math.round(Math.min(Math.max(lapProgress || 0, 0), 1) * 100);
+  return `${lapCount || 0} (+${pct}%)`;
+}
+
+// Where a session stands among the server's finished sessions, from the
+// `percentiles` field; '-' until the server has enough history.
+export function formatPercentiles(percentiles) {
+  if (!percentiles) return '-';
+  return `longer than ${percentiles.duration}%, faster than ${percentiles.burnRate}%`;
+}
+
+// Time zone and clock from the server's preferences message. Unset fields
+// leave the browser's own zone and locale in charge.
+let timeOptions = {};
+
+export function setTimePreferences({ timeZone, clock } = {}) {
+  const opts = {};
+  if (timeZone) {
+    try {
+      new Intl.DateTimeFormat(undefined, { timeZone });
+      opts.timeZone = timeZone;
+    } catch {
+      // Unknown to this browser; stay on local time.
+    }
+  }
+  if (clock === '12h') opts.hour12 = true;
+  if (clock === '24h') opts.hour12 = false;
+  timeOptions = opts;
+}
+
 export function formatTime(dateStr) {
   if (!dateStr) return '-';
   const d = new Date(dateStr);
-  return d.toLocaleTimeString();
+  return d.toLocaleTimeString(undefined, timeOptions);
+}
+
+export function formatDate(dateStr) {
+  if (!dateStr) return '-';
+  const opts = timeOptions.timeZone ? { timeZone: timeOptions.timeZone } : {};
+  return new Date(dateStr).toLocaleDateString(undefined, opts);
+}
+
+export function formatDateTime(dateStr) {
+  if (!dateStr) return '-';
+  return new Date(dateStr).toLocaleString(undefined, timeOptions);
 }
 
 export function formatElapsed(startStr) {
@@ -26,6 +69,34 @@ export function formatElapsed(startStr) {
   return `${mins}m ${secs}s`;
 }
 
+// Time left until etaStr, e.g. "4m 05s", or "due" once it has passed.
+export function formatCountdown(etaStr) {
+  if (!etaStr) return '-';
+  const left = Math.ceil((new Date(etaStr).getTime() - Date.now()) / 1000);
+  if (left <= 0) return 'due';
+  const hours = Math.floor(left / 3600);
+  const mins = Math.floor((left % 3600) / 60);
+  const secs = String(left % 60).padStart(2, '0');
+  if (hours > 0) return `${hours}h ${String(mins).padStart(2, '0')}m`;
+  return `${mins}m ${secs}s`;
+}
+
+export function formatMCPCalls(calls) {
+  return formatCounts(calls);
+}
+
+// formatCounts lists a name -> count map busiest first, e.g. "git 4, go 2".
+// With a limit, the rest are summarised as "+N more".
+export function formatCounts(counts, limit = Infinity) {
+  if (!counts) return '-';
+  const entries = Object.entries(counts);
+  if (entries.length === 0) return '-';
+  entries.sort((a, b) => b[1] - a[1] || a[0].localeCompare(b[0]));
+  const shown = entries.slice(0, limit).map(([name, n]) => `${name} ${n}`).join(', ');
+  const rest = entries.length - Math.min(entries.length, limit);
+  return rest > 0 ? `${shown} +${rest} more` : shown;
+}
+
 export function basename(path) {
   return path.split('/').pop();
 }
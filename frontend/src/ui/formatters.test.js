@@ -2,8 +2,16 @@ import { afterEach, beforeEach, describe, expect, it, vi } from 'vitest';
 import {
   formatTokens,
   formatBurnRate,
+  formatLap,
+  formatPercentiles,
   formatTime,
+  formatDate,
+  formatDateTime,
+  setTimePreferences,
   formatElapsed,
+  formatCountdown,
+  formatMCPCalls,
+  formatCounts,
   basename,
   esc,
 } from './formatters.js';
@@ -66,6 +74,28 @@ describe('formatBurnRate', () => {
   });
 });
 
+describe('formatPercentiles', () => {
+  it('ranks duration and burn rate against past sessions', () => {
+    expect(formatPercentiles({ duration: 95, burnRate: 20 })).toBe('longer than 95%, faster than 20%');
+  });
+
+  it('shows a dash without history', () => {
+    expect(formatPercentiles(undefined)).toBe('-');
+  });
+});
+
+describe('formatLap', () => {
+  it('shows completed laps and current-lap progress', () => {
+    expect(formatLap(3, 0.4)).toBe('3 (+40%)');
+    expect(formatLap(0, 0)).toBe('0 (+0%)');
+  });
+
+  it('tolerates missing fields and clamps progress', () => {
+    expect(formatLap(undefined, undefined)).toBe('0 (+0%)');
+    expect(formatLap(1, 1.5)).toBe('1 (+100%)');
+  });
+});
+
 describe('formatTime', () => {
   it('returns dash for falsy input', () => {
     expect(formatTime(null)).toBe('-');
@@ -82,6 +112,54 @@ describe('formatTime', () => {
   });
 });
 
+describe('setTimePreferences', () => {
+  afterEach(() => setTimePreferences());
+
+  it('shows times in the requested zone and clock', () => {
+    setTimePreferences({ timeZone: 'Asia/Tokyo', clock: '24h' });
+    expect(formatTime('2026-01-15T21:05:09Z')).toContain('06:05:09');
+    expect(formatDate('2026-01-15T21:05:09Z')).toContain('16');
+    expect(formatDateTime('2026-01-15T21:05:09Z')).toContain('06:05:09');
+  });
+
+  it('uses a 12-hour clock when asked', () => {
+    setTimePreferences({ timeZone: 'UTC', clock: '12h' });
+    expect(formatTime('2026-01-15T21:05:09Z')).toMatch(/9:05:09\s?PM/);
+  });
+
+  it('ignores zones the browser does not know', () => {
+    setTimePreferences({ timeZone: 'Nowhere/Special' });
+    expect(formatTime('2026-01-15T21:05:09Z')).toBe(new Date('2026-01-15T21:05:09Z').toLocaleTimeString());
+  });
+});
+
+describe('formatCountdown', () => {
+  beforeEach(() => {
+    vi.useFakeTimers();
+    vi.setSystemTime(new Date('2026-03-27T12:00:00Z'));
+  });
+
+  afterEach(() => {
+    vi.useRealTimers();
+  });
+
+  it('returns dash without a forecast', () => {
+    expect(formatCountdown(undefined)).toBe('-');
+  });
+
+  it('counts down minutes and seconds', () => {
+    expect(formatCountdown('2026-03-27T12:04:05Z')).toBe('4m 05s');
+  });
+
+  it('switches to hours for long forecasts', () => {
+    expect(formatCountdown('2026-03-27T14:07:00Z')).toBe('2h 07m');
+  });
+
+  it('reports a passed forecast as due', () => {
+    expect(formatCountdown('2026-03-27T11:59:00Z')).toBe('due');
+  });
+});
+
 describe('formatElapsed', () => {
   beforeEach(() => {
     vi.useFakeTimers();
@@ -121,6 +199,25 @@ describe('formatElapsed', () => {
   });
 });
 
+describe('formatMCPCalls', () => {
+  it('returns dash when there are no MCP calls', () => {
+    expect(formatMCPCalls(undefined)).toBe('-');
+    expect(formatMCPCalls({})).toBe('-');
+  });
+
+  it('lists servers busiest first', () => {
+    expect(formatMCPCalls({ linear: 1, github: 4, sentry: 1 })).toBe('github 4, linear 1, sentry 1');
+  });
+});
+
+describe('formatCounts', () => {
+  it('summarises entries past the limit', () => {
+    expect(formatCounts({ git: 4, go: 2, rg: 1, npm: 1 }, 2)).toBe('git 4, go 2 +2 more');
+    expect(formatCounts({ git: 4 }, 2)).toBe('git 4');
+    expect(formatCounts(null, 2)).toBe('-');
+  });
+});
+
 describe('basename', () => {
   it('returns the last segment of a Unix path', () => {
     expect(basename('/home/user/file.txt')).toBe('file.txt');
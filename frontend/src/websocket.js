@@ -1,5 +1,9 @@
+// Snapshots from servers with more sessions than this arrive in pages,
+// which keeps each frame well under the 1 MiB message limit below.
+const SNAPSHOT_PAGE_SIZE = 100;
+
 export class RaceConnection {
-  constructor({ onSnapshot, onDelta, onCompletion, onStatus, authToken, onSourceHealth, onAchievementUnlocked, onEquipped, onBattlePassProgress, onOvertake, onAuthFailure }) {
+  constructor({ onSnapshot, onDelta, onCompletion, onStatus, authToken, onSourceHealth, onAchievementUnlocked, onAchievementBatch, onEquipped, onBattlePassProgress, onOvertake, onAuthFailure, onServerShutdown, onUpdateAvailable, onDirectorFocus, onCommentary, onSoundCue, onLapCompleted, onHeatStandings, onPipelineUpdate, onModelChanged, onPreferences, onSubagentStarted, onSubagentCompleted, onPresence, onReaction, onWatchdogAlert, onMilestone, viewerName }) {
     this.onSnapshot = onSnapshot;
     this.onDelta = onDelta;
     this.onCompletion = onCompletion;
@@ -7,10 +11,28 @@ export class RaceConnection {
     this.authToken = authToken || '';
     this.onSourceHealth = onSourceHealth || (() => {});
     this.onAchievementUnlocked = onAchievementUnlocked || (() => {});
+    this.onAchievementBatch = onAchievementBatch || (() => {});
     this.onEquipped = onEquipped || (() => {});
     this.onBattlePassProgress = onBattlePassProgress || (() => {});
     this.onOvertake = onOvertake || (() => {});
     this.onAuthFailure = onAuthFailure || (() => {});
+    this.onServerShutdown = onServerShutdown || (() => {});
+    this.onUpdateAvailable = onUpdateAvailable || (() => {});
+    this.onDirectorFocus = onDirectorFocus || (() => {});
+    this.onCommentary = onCommentary || (() => {});
+    this.onSoundCue = onSoundCue || (() => {});
+    this.onLapCompleted = onLapCompleted || (() => {});
+    this.onHeatStandings = onHeatStandings || (() => {});
+    this.onPipelineUpdate = onPipelineUpdate || (() => {});
+    this.onModelChanged = onModelChanged || (() => {});
+    this.onPreferences = onPreferences || (() => {});
+    this.onSubagentStarted = onSubagentStarted || (() => {});
+    this.onSubagentCompleted = onSubagentCompleted || (() => {});
+    this.onPresence = onPresence || (() => {});
+    this.onReaction = onReaction || (() => {});
+    this.onWatchdogAlert = onWatchdogAlert || (() => {});
+    this.onMilestone = onMilestone || (() => {});
+    this.viewerName = viewerName || '';
     this.ws = null;
     this.reconnectDelay = 1000;
     this.maxReconnectDelay = 30000;
@@ -18,6 +40,8 @@ export class RaceConnection {
     this.reconnectTimeoutId = null;
     this.lastSeq = 0;
     this.awaitingSnapshot = true;
+    this.serverShuttingDown = false;
+    this.snapshotPages = null;
   }
 
   connect() {
@@ -27,7 +51,7 @@ export class RaceConnection {
     }
 
     const protocol = location.protocol === 'https:' ? 'wss:' : 'ws:';
-    const url = `${protocol}//${location.host}/ws`;
+    const url = `${protocol}//${location.host}/ws?page_size=${SNAPSHOT_PAGE_SIZE}`;
 
     this.onStatus('connecting');
     this.ws = new WebSocket(url);
@@ -36,10 +60,13 @@ export class RaceConnection {
       if (this.authToken) {
         this.ws.send(JSON.stringify({ type: 'auth', token: this.authToken }));
       }
+      this.ws.send(JSON.stringify({ type: 'hello', name: this.viewerName, kind: 'dashboard' }));
       this.reconnectAttempts = 0;
       this.reconnectDelay = 1000;
       this.lastSeq = 0;
       this.awaitingSnapshot = true;
+      this.serverShuttingDown = false;
+      this.snapshotPages = null;
       this.onStatus('connected');
     };
 
@@ -65,9 +92,11 @@ export class RaceConnection {
         }
 
         switch (msg.type) {
-          case 'snapshot':
-            this.onSnapshot(msg.payload);
+          case 'snapshot': {
+            const snapshot = this.assembleSnapshot(msg.payload);
+            if (snapshot) this.onSnapshot(snapshot);
             break;
+          }
           case 'delta':
             this.onDelta(msg.payload);
             break;
@@ -80,6 +109,9 @@ export class RaceConnection {
           case 'achievement_unlocked':
             this.onAchievementUnlocked(msg.payload);
             break;
+          case 'achievement_batch':
+            this.onAchievementBatch(msg.payload);
+            break;
           case 'equipped':
             this.onEquipped(msg.payload);
             break;
@@ -89,6 +121,55 @@ export class RaceConnection {
           case 'overtake':
             this.onOvertake(msg.payload);
             break;
+          case 'server_shutdown':
+            this.serverShuttingDown = true;
+            this.onServerShutdown(msg.payload);
+            break;
+          case 'update_available':
+            this.onUpdateAvailable(msg.payload);
+            break;
+          case 'director_focus':
+            this.onDirectorFocus(msg.payload);
+            break;
+          case 'commentary':
+            this.onCommentary(msg.payload);
+            break;
+          case 'sound_cue':
+            this.onSoundCue(msg.payload);
+            break;
+          case 'lap_completed':
+            this.onLapCompleted(msg.payload);
+            break;
+          case 'heat_standings':
+            this.onHeatStandings(msg.payload);
+            break;
+          case 'pipeline_update':
+            this.onPipelineUpdate(msg.payload);
+            break;
+          case 'model_changed':
+            this.onModelChanged(msg.payload);
+            break;
+          case 'preferences':
+            this.onPreferences(msg.payload);
+            break;
+          case 'subagent_started':
+            this.onSubagentStarted(msg.payload);
+            break;
+          case 'subagent_completed':
+            this.onSubagentCompleted(msg.payload);
+            break;
+          case 'presence':
+            this.onPresence(msg.payload);
+            break;
+          case 'reaction':
+            this.onReaction(msg.payload);
+            break;
+          case 'watchdog_alert':
+            this.onWatchdogAlert(msg.payload);
+            break;
+          case 'milestone':
+            this.onMilestone(msg.payload);
+            break;
         }
       } catch (err) {
         console.error('WS parse error:', err);
@@ -101,7 +182,9 @@ export class RaceConnection {
         this.onAuthFailure();
         return;
       }
-      this.onStatus('disconnected');
+      // Keep reconnecting after an announced shutdown: the server is
+      // usually being restarted.
+      this.onStatus(this.serverShuttingDown ? 'server_shutdown' : 'disconnected');
       this.scheduleReconnect();
     };
 
@@ -110,12 +193,44 @@ export class RaceConnection {
     };
   }
 
+  // Joins the pages of a snapshot, returning the whole snapshot once the
+  // last page arrives and null before then. A page out of order drops the
+  // pages so far; the next snapshot starts afresh.
+  assembleSnapshot(payload) {
+    if (!payload || !(payload.pages > 1)) {
+      this.snapshotPages = null;
+      return payload;
+    }
+    const pending = this.snapshotPages;
+    if (!pending || payload.page !== pending.page + 1) {
+      this.snapshotPages = null;
+      if (payload.page !== 1) return null;
+    }
+    const sessions = (this.snapshotPages ? this.snapshotPages.sessions : []).concat(payload.sessions || []);
+    if (payload.page < payload.pages) {
+      this.snapshotPages = { page: payload.page, sessions };
+      return null;
+    }
+    this.snapshotPages = null;
+    const whole = { ...payload, sessions };
+    delete whole.page;
+    delete whole.pages;
+    return whole;
+  }
+
   requestResync() {
     if (this.ws && this.ws.readyState === WebSocket.OPEN) {
       this.ws.send(JSON.stringify({ type: 'resync' }));
     }
   }
 
+  // The server drops emoji it doesn't allow and reactions sent too fast.
+  sendReaction(sessionId, emoji) {
+    if (this.ws && this.ws.readyState === WebSocket.OPEN) {
+      this.ws.send(JSON.stringify({ type: 'reaction', sessionId, emoji }));
+    }
+  }
+
   scheduleReconnect() {
     if (this.reconnectTimeoutId) {
       clearTimeout(this.reconnectTimeoutId);
@@ -54,6 +54,7 @@ function createConnection(overrides = {}) {
     onStatus: overrides.onStatus ?? vi.fn(),
     authToken: overrides.authToken,
     onAuthFailure: overrides.onAuthFailure ?? vi.fn(),
+    onServerShutdown: overrides.onServerShutdown ?? vi.fn(),
   });
 }
 
@@ -137,6 +138,139 @@ describe('RaceConnection', () => {
       expect(MockWebSocket.instances).toHaveLength(1);
     });
 
+    it('fires "server_shutdown" on close after a shutdown notice and keeps reconnecting', () => {
+      const onStatus = vi.fn();
+      const onServerShutdown = vi.fn();
+      const conn = createConnection({ onStatus, onServerShutdown });
+
+      conn.connect();
+      const ws = latestSocket();
+      ws.simulateOpen();
+      ws.simulateMessage({ type: 'server_shutdown', seq: 0, payload: { reason: 'received terminated' } });
+      ws.simulateClose({ code: 1001 });
+
+      expect(onServerShutdown).toHaveBeenCalledWith({ reason: 'received terminated' });
+      expect(onStatus).toHaveBeenCalledWith('server_shutdown');
+      expect(onStatus).not.toHaveBeenCalledWith('disconnected');
+
+      vi.advanceTimersByTime(1000);
+      expect(MockWebSocket.instances).toHaveLength(2);
+    });
+
+    it('passes update_available notices to onUpdateAvailable', () => {
+      const onUpdateAvailable = vi.fn();
+      const conn = createConnection({ onUpdateAvailable });
+
+      conn.connect();
+      const ws = latestSocket();
+      ws.simulateOpen();
+      ws.simulateMessage({ type: 'update_available', seq: 0, payload: { current: 'v1.0.0', latest: 'v1.1.0' } });
+
+      expect(onUpdateAvailable).toHaveBeenCalledWith({ current: 'v1.0.0', latest: 'v1.1.0' });
+    });
+
+    it('passes director_focus hints to onDirectorFocus', () => {
+      const onDirectorFocus = vi.fn();
+      const conn = createConnection({ onDirectorFocus });
+
+      conn.connect();
+      const ws = latestSocket();
+      ws.simulateOpen();
+      ws.simulateMessage({ type: 'director_focus', seq: 0, payload: { sessionId: 's1', reason: 'burn_rate' } });
+
+      expect(onDirectorFocus).toHaveBeenCalledWith({ sessionId: 's1', reason: 'burn_rate' });
+    });
+
+    it('passes commentary lines to onCommentary', () => {
+      const onCommentary = vi.fn();
+      const conn = createConnection({ onCommentary });
+
+      conn.connect();
+      const ws = latestSocket();
+      ws.simulateOpen();
+      ws.simulateMessage({ type: 'commentary', seq: 0, payload: { event: 'compaction', text: 'opus pits for compaction!' } });
+
+      expect(onCommentary).toHaveBeenCalledWith({ event: 'compaction', text: 'opus pits for compaction!' });
+    });
+
+    it('passes sound cues to onSoundCue', () => {
+      const onSoundCue = vi.fn();
+      const conn = createConnection({ onSoundCue });
+
+      conn.connect();
+      const ws = latestSocket();
+      ws.simulateOpen();
+      ws.simulateMessage({ type: 'sound_cue', seq: 0, payload: { cue: 'finish', sessionId: 's1' } });
+
+      expect(onSoundCue).toHaveBeenCalledWith({ cue: 'finish', sessionId: 's1' });
+    });
+
+    it('passes lap completions to onLapCompleted', () => {
+      const onLapCompleted = vi.fn();
+      const conn = createConnection({ onLapCompleted });
+
+      conn.connect();
+      const ws = latestSocket();
+      ws.simulateOpen();
+      ws.simulateMessage({ type: 'lap_completed', seq: 0, payload: { sessionId: 's1', name: 'opus', lap: 3 } });
+
+      expect(onLapCompleted).toHaveBeenCalledWith({ sessionId: 's1', name: 'opus', lap: 3 });
+    });
+
+    it('passes achievement batches to onAchievementBatch', () => {
+      const onAchievementBatch = vi.fn();
+      const conn = createConnection({ onAchievementBatch });
+
+      conn.connect();
+      const ws = latestSocket();
+      ws.simulateOpen();
+      const payload = { achievements: [{ id: 'first_lap' }, { id: 'pit_crew' }], byTier: { bronze: 2 } };
+      ws.simulateMessage({ type: 'achievement_batch', seq: 0, payload });
+
+      expect(onAchievementBatch).toHaveBeenCalledWith(payload);
+    });
+
+    it('passes heat standings to onHeatStandings', () => {
+      const onHeatStandings = vi.fn();
+      const conn = createConnection({ onHeatStandings });
+
+      conn.connect();
+      const ws = latestSocket();
+      ws.simulateOpen();
+      const heat = { id: 'heat-1', name: 'shootout', status: 'running', standings: [] };
+      ws.simulateMessage({ type: 'heat_standings', seq: 0, payload: heat });
+
+      expect(onHeatStandings).toHaveBeenCalledWith(heat);
+    });
+
+    it('passes pipeline runs to onPipelineUpdate', () => {
+      const onPipelineUpdate = vi.fn();
+      const conn = createConnection({ onPipelineUpdate });
+
+      conn.connect();
+      const ws = latestSocket();
+      ws.simulateOpen();
+      const run = { id: 'pipe-1', pipeline: 'relay', status: 'running', leg: 1, stages: [] };
+      ws.simulateMessage({ type: 'pipeline_update', seq: 0, payload: run });
+
+      expect(onPipelineUpdate).toHaveBeenCalledWith(run);
+    });
+
+    it('passes reactions to onReaction and sends them for a session', () => {
+      const onReaction = vi.fn();
+      const conn = createConnection({ onReaction });
+
+      conn.connect();
+      const ws = latestSocket();
+      ws.simulateOpen();
+      const reaction = { sessionId: 's1', name: 'opus', emoji: '🎉', from: 'alice' };
+      ws.simulateMessage({ type: 'reaction', seq: 0, payload: reaction });
+      expect(onReaction).toHaveBeenCalledWith(reaction);
+
+      conn.sendReaction('s1', '👀');
+      expect(ws.sentMessages.at(-1)).toBe(JSON.stringify({ type: 'reaction', sessionId: 's1', emoji: '👀' }));
+    });
+
     it('calls onAuthFailure callback on auth policy close', () => {
       const onAuthFailure = vi.fn();
       const conn = createConnection({ onAuthFailure });
@@ -160,6 +294,30 @@ describe('RaceConnection', () => {
       expect(onSnapshot).toHaveBeenCalledWith({ cars: [1, 2] });
     });
 
+    it('joins snapshot pages before calling onSnapshot', () => {
+      const onSnapshot = vi.fn();
+      const conn = createConnection({ onSnapshot });
+
+      conn.connect();
+      latestSocket().simulateOpen();
+      latestSocket().simulateMessage({ type: 'snapshot', seq: 4, payload: { sessions: [{ id: 'a' }], page: 1, pages: 2 } });
+      expect(onSnapshot).not.toHaveBeenCalled();
+
+      latestSocket().simulateMessage({ type: 'snapshot', seq: 4, payload: { sessions: [{ id: 'b' }], teams: [{ id: 't' }], page: 2, pages: 2 } });
+      expect(onSnapshot).toHaveBeenCalledWith({ sessions: [{ id: 'a' }, { id: 'b' }], teams: [{ id: 't' }] });
+    });
+
+    it('drops a paged snapshot missing a page', () => {
+      const onSnapshot = vi.fn();
+      const conn = createConnection({ onSnapshot });
+
+      conn.connect();
+      latestSocket().simulateOpen();
+      latestSocket().simulateMessage({ type: 'snapshot', seq: 4, payload: { sessions: [{ id: 'a' }], page: 1, pages: 3 } });
+      latestSocket().simulateMessage({ type: 'snapshot', seq: 4, payload: { sessions: [{ id: 'c' }], page: 3, pages: 3 } });
+      expect(onSnapshot).not.toHaveBeenCalled();
+    });
+
     it('dispatches delta messages to onDelta', () => {
       const onDelta = vi.fn();
       const conn = createConnection({ onDelta });
@@ -374,7 +532,7 @@ describe('RaceConnection', () => {
       const conn = createConnection();
       conn.connect();
 
-      expect(latestSocket().url).toBe('wss://example.com/ws');
+      expect(latestSocket().url).toBe('wss://example.com/ws?page_size=100');
     });
 
     it('uses ws: for http: protocol', () => {
@@ -382,14 +540,14 @@ describe('RaceConnection', () => {
       const conn = createConnection();
       conn.connect();
 
-      expect(latestSocket().url).toBe('ws://example.com/ws');
+      expect(latestSocket().url).toBe('ws://example.com/ws?page_size=100');
     });
 
     it('sends auth message when authToken is provided', () => {
       const conn = createConnection({ authToken: 'abc123' });
       conn.connect();
 
-      expect(latestSocket().url).toBe('wss://example.com/ws');
+      expect(latestSocket().url).toBe('wss://example.com/ws?page_size=100');
       latestSocket().simulateOpen();
       expect(latestSocket().sentMessages[0]).toBe(
         JSON.stringify({ type: 'auth', token: 'abc123' })
@@ -401,7 +559,25 @@ describe('RaceConnection', () => {
       conn.connect();
 
       latestSocket().simulateOpen();
-      expect(latestSocket().sentMessages).toHaveLength(0);
+      const types = latestSocket().sentMessages.map(m => JSON.parse(m).type);
+      expect(types).not.toContain('auth');
+    });
+
+    it('introduces itself after authenticating', () => {
+      const conn = new RaceConnection({
+        onSnapshot: vi.fn(),
+        onDelta: vi.fn(),
+        onCompletion: vi.fn(),
+        onStatus: vi.fn(),
+        authToken: 'abc123',
+        viewerName: 'alice',
+      });
+      conn.connect();
+
+      latestSocket().simulateOpen();
+      expect(latestSocket().sentMessages[1]).toBe(
+        JSON.stringify({ type: 'hello', name: 'alice', kind: 'dashboard' })
+      );
     });
   });
 
@@ -4,6 +4,12 @@ export default defineConfig({
   build: {
     outDir: 'dist',
     emptyOutDir: true,
+    rollupOptions: {
+      input: {
+        main: 'index.html',
+        overlay: 'overlay.html',
+      },
+    },
   },
   server: {
     proxy: {
@@ -1,15 +1,20 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"io"
 	"net/url"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/agent-racer/tui/internal/app"
 	"github.com/agent-racer/tui/internal/client"
 	"github.com/agent-racer/tui/internal/config"
+	"github.com/agent-racer/tui/internal/menubar"
+	"github.com/agent-racer/tui/internal/notify"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
@@ -20,6 +25,11 @@ type cliOptions struct {
 	wsURL       string
 	token       string
 	showVersion bool
+	menubar     bool
+	// notify lists the states to raise terminal notifications for, and
+	// notifyFormat the OSC sequence to raise them with.
+	notify       string
+	notifyFormat string
 }
 
 func parseArgs(args []string, output io.Writer) (cliOptions, error) {
@@ -31,6 +41,9 @@ func parseArgs(args []string, output io.Writer) (cliOptions, error) {
 	fs.StringVar(&opts.wsURL, "url", "", "WebSocket URL of the Agent Racer backend (overrides config)")
 	fs.StringVar(&opts.token, "token", "", "Auth token (overrides config)")
 	fs.BoolVar(&opts.showVersion, "version", false, "Print version information and exit")
+	fs.BoolVar(&opts.menubar, "menubar", false, "Stream the race as a SwiftBar menu bar plugin instead of opening the TUI")
+	fs.StringVar(&opts.notify, "notify", "", "Comma-separated states to raise terminal notifications for, e.g. waiting,needs_approval,complete,errored")
+	fs.StringVar(&opts.notifyFormat, "notify-format", "9", "Notification escape sequence: 9 (OSC 9) or 777 (OSC 777)")
 
 	if err := fs.Parse(args); err != nil {
 		return cliOptions{}, err
@@ -45,6 +58,17 @@ func printVersion(output io.Writer) error {
 }
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "prompt":
+			os.Exit(runPrompt(os.Args[2:], os.Stdout, os.Stderr))
+		case "tmux-status":
+			os.Exit(runTmuxStatus(os.Args[2:], os.Stdout, os.Stderr))
+		case "waybar":
+			os.Exit(runWaybar(os.Args[2:], os.Stdout, os.Stderr))
+		}
+	}
+
 	opts, err := parseArgs(os.Args[1:], os.Stderr)
 	if err != nil {
 		os.Exit(2)
@@ -94,10 +118,34 @@ func main() {
 	// Derive HTTP base URL from WebSocket URL.
 	httpBase := deriveHTTPBase(effectiveURL)
 
+	if opts.menubar {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		err := menubar.Run(ctx, os.Stdout, menubar.Options{
+			URL:       effectiveURL,
+			Token:     effectiveToken,
+			TLS:       tlsCfg,
+			Dashboard: httpBase,
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	ws := client.NewWSClient(effectiveURL, effectiveToken, tlsCfg)
 	httpClient := client.NewHTTPClient(httpBase, effectiveToken, tlsCfg)
 
 	m := app.New(ws, httpClient)
+	if opts.notify != "" {
+		n, err := notify.New(os.Stdout, notify.Format(opts.notifyFormat), opts.notify, os.Getenv("TMUX") != "")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(2)
+		}
+		m = m.WithNotifier(n)
+	}
 	p := tea.NewProgram(m, tea.WithAltScreen())
 
 	if _, err := p.Run(); err != nil {
@@ -65,3 +65,30 @@ func TestDeriveHTTPBase(t *testing.T) {
 		})
 	}
 }
+
+func TestParseArgsMenubarFlag(t *testing.T) {
+	var stderr bytes.Buffer
+
+	opts, err := parseArgs([]string{"--menubar", "--token", "tok"}, &stderr)
+	if err != nil {
+		t.Fatalf("parseArgs returned error: %v", err)
+	}
+	if !opts.menubar || opts.token != "tok" {
+		t.Fatalf("opts = %+v, want menubar with token", opts)
+	}
+}
+
+func TestParseArgsNotifyFlags(t *testing.T) {
+	var stderr bytes.Buffer
+
+	opts, err := parseArgs([]string{"-notify", "waiting,complete", "-notify-format", "777"}, &stderr)
+	if err != nil {
+		t.Fatalf("parseArgs returned error: %v", err)
+	}
+	if opts.notify != "waiting,complete" || opts.notifyFormat != "777" {
+		t.Fatalf("opts = %+v, want notify flags", opts)
+	}
+	if opts, _ := parseArgs(nil, &stderr); opts.notify != "" || opts.notifyFormat != "9" {
+		t.Fatalf("defaults = %+v, want notifications off in OSC 9", opts)
+	}
+}
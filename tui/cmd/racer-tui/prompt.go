@@ -0,0 +1,293 @@
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	sdk "github.com/agent-racer/backend/pkg/client"
+	"github.com/agent-racer/tui/internal/client"
+	"github.com/agent-racer/tui/internal/config"
+)
+
+const (
+	// promptTimeout bounds the request a prompt waits on, so drawing the
+	// prompt stays under 50ms even when the server is slow or down.
+	promptTimeout = 40 * time.Millisecond
+	// promptStale is how long a cached answer is still shown while the
+	// server can't be reached.
+	promptStale = time.Minute
+)
+
+// serverFlags are the flags every helper subcommand takes to reach the
+// server.
+type serverFlags struct {
+	configPath string
+	url        string
+	token      string
+}
+
+func (f *serverFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.configPath, "config", "", "Path to config file (defaults to ~/.config/agent-racer/config.yaml)")
+	fs.StringVar(&f.url, "url", "", "WebSocket URL of the Agent Racer backend (overrides config)")
+	fs.StringVar(&f.token, "token", "", "Auth token (overrides config)")
+}
+
+// resolve reads the config and applies the flags over it: the WebSocket
+// URL, auth token and TLS settings to reach the server with.
+func (f *serverFlags) resolve() (wsURL, token string, tlsCfg *tls.Config, err error) {
+	path := f.configPath
+	if path == "" {
+		path = config.DefaultConfigPath()
+	}
+	cfg, _ := config.LoadOrDefault(path)
+	wsURL = cfg.WebSocketURL()
+	if f.url != "" {
+		wsURL = f.url
+	}
+	token = cfg.Server.AuthToken
+	if f.token != "" {
+		token = f.token
+	}
+	tlsCfg, err = cfg.TLSConfig()
+	return wsURL, token, tlsCfg, err
+}
+
+// httpClient builds a client for the server the flags and config name,
+// and returns the server's base URL with it.
+func (f *serverFlags) httpClient() (*client.HTTPClient, string, error) {
+	wsURL, token, tlsCfg, err := f.resolve()
+	if err != nil {
+		return nil, "", err
+	}
+	base := deriveHTTPBase(wsURL)
+	return client.NewHTTPClient(base, token, tlsCfg), base, nil
+}
+
+// glance is the part of a session the prompt and tmux helpers show.
+type glance struct {
+	Name               string  `json:"name"`
+	Activity           string  `json:"activity"`
+	ContextUtilization float64 `json:"contextUtilization"`
+	BurnRatePerMinute  float64 `json:"burnRatePerMinute"`
+}
+
+func glanceOf(s *client.SessionState) *glance {
+	return &glance{
+		Name:               s.Name,
+		Activity:           string(s.Activity),
+		ContextUtilization: s.ContextUtilization,
+		BurnRatePerMinute:  s.BurnRatePerMinute,
+	}
+}
+
+// cachedGlance is a helper's last answer on disk. CheckedAt is when the
+// server was last asked, FetchedAt when it last answered.
+type cachedGlance struct {
+	CheckedAt time.Time `json:"checkedAt"`
+	FetchedAt time.Time `json:"fetchedAt"`
+	Session   *glance   `json:"session,omitempty"`
+}
+
+// cachePath is where the answer for key is cached, under kind ("prompt",
+// "tmux") in the cache directory.
+func cachePath(kind, key string) string {
+	dir := config.DefaultCacheDir()
+	if dir == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(dir, kind, hex.EncodeToString(sum[:8])+".json")
+}
+
+// lookupCached returns the session fetch finds, asking it at most once per
+// ttl. While fetch fails, the last answer is reused for up to stale; the
+// failure is cached too, so a server that is down costs one timeout per ttl
+// rather than one per prompt.
+func lookupCached(path string, ttl, stale time.Duration, now time.Time, fetch func() (*glance, error)) *glance {
+	var c cachedGlance
+	cached := false
+	if path != "" {
+		if data, err := os.ReadFile(path); err == nil && json.Unmarshal(data, &c) == nil {
+			cached = true
+		}
+	}
+	if cached && now.Sub(c.CheckedAt) < ttl && now.Sub(c.CheckedAt) >= 0 {
+		return c.Session
+	}
+
+	s, err := fetch()
+	c.CheckedAt = now
+	if err == nil {
+		c.FetchedAt = now
+		c.Session = s
+	} else if !cached || now.Sub(c.FetchedAt) >= stale {
+		c.Session = nil
+	}
+	if path != "" {
+		if data, err := json.Marshal(c); err == nil && os.MkdirAll(filepath.Dir(path), 0o700) == nil {
+			_ = writeFileAtomic(path, data)
+		}
+	}
+	return c.Session
+}
+
+// writeFileAtomic replaces path with data, so a prompt drawn meanwhile
+// never reads half a file.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// notFound reports whether err is the server saying nothing matched.
+func notFound(err error) bool {
+	var se *sdk.StatusError
+	return errors.As(err, &se) && se.StatusCode == http.StatusNotFound
+}
+
+// promptStyle says how to color a fragment and how to hide the color codes
+// from the shell's line-width count.
+type promptStyle struct {
+	color bool
+	shell string // "bash", "zsh", "tmux" or "" for none
+}
+
+// tmuxColors are the tmux names of the ANSI colors fragments use.
+var tmuxColors = map[string]string{"31": "red", "32": "green", "33": "yellow", "36": "cyan", "90": "brightblack"}
+
+// paint wraps text in the ANSI color code, escaped for the shell. For tmux
+// it uses the status line's own #[fg=...] style instead.
+func (st promptStyle) paint(code, text string) string {
+	if !st.color || code == "" {
+		return text
+	}
+	if st.shell == "tmux" {
+		return "#[fg=" + tmuxColors[code] + "]" + text + "#[default]"
+	}
+	open, end := "\x1b["+code+"m", "\x1b[0m"
+	switch st.shell {
+	case "bash":
+		open, end = `\[`+open+`\]`, `\[`+end+`\]`
+	case "zsh":
+		open, end = "%{"+open+"%}", "%{"+end+"%}"
+	}
+	return open + text + end
+}
+
+// activityGlyphs are the glyph and ANSI color for each running activity.
+var activityGlyphs = map[string][2]string{
+	"starting":       {"◌", "90"},
+	"thinking":       {"●", "32"},
+	"tool_use":       {"⚙", "36"},
+	"waiting":        {"…", "33"},
+	"idle":           {"○", "90"},
+	"needs_approval": {"✋", "31"},
+}
+
+// fragment renders g as "<glyph> <context%> <burn rate>", e.g. "● 42% 1.2k/m".
+func fragment(g *glance, st promptStyle) string {
+	glyph, ok := activityGlyphs[g.Activity]
+	if !ok {
+		glyph = [2]string{"·", ""}
+	}
+	parts := []string{st.paint(glyph[1], glyph[0])}
+
+	pct := int(g.ContextUtilization * 100)
+	ctxColor := ""
+	switch {
+	case pct >= 90:
+		ctxColor = "31"
+	case pct >= 70:
+		ctxColor = "33"
+	}
+	parts = append(parts, st.paint(ctxColor, fmt.Sprintf("%d%%", pct)))
+
+	if g.BurnRatePerMinute > 0 {
+		parts = append(parts, st.paint("90", formatRate(g.BurnRatePerMinute)+"/m"))
+	}
+	return strings.Join(parts, " ")
+}
+
+// formatRate renders tokens as "850", "1.2k" or "3.4M".
+func formatRate(n float64) string {
+	switch {
+	case n >= 1e6:
+		return fmt.Sprintf("%.1fM", n/1e6)
+	case n >= 1e3:
+		return fmt.Sprintf("%.1fk", n/1e3)
+	}
+	return fmt.Sprintf("%.0f", n)
+}
+
+// runPrompt prints the status fragment for the session working in the
+// current directory, or nothing when there is none. It always exits 0 so a
+// broken server never breaks the shell prompt.
+func runPrompt(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("agent-racer prompt", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var sf serverFlags
+	sf.register(fs)
+	cwd := fs.String("cwd", "", "Directory to show the session for (defaults to the current directory)")
+	shell := fs.String("shell", "", `Escape color codes for "bash" or "zsh" PS1; empty for starship and others`)
+	noColor := fs.Bool("no-color", false, "Print without ANSI colors")
+	ttl := fs.Duration("ttl", 2*time.Second, "How long to reuse the last answer before asking the server again")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *shell != "" && *shell != "bash" && *shell != "zsh" {
+		_, _ = fmt.Fprintf(stderr, "unknown -shell %q: use bash or zsh\n", *shell)
+		return 2
+	}
+
+	dir := *cwd
+	if dir == "" {
+		var err error
+		if dir, err = os.Getwd(); err != nil {
+			return 0
+		}
+	}
+	c, base, err := sf.httpClient()
+	if err != nil {
+		return 0
+	}
+	c.SetTimeout(promptTimeout)
+
+	g := lookupCached(cachePath("prompt", base+"\x00"+dir), *ttl, promptStale, time.Now(), func() (*glance, error) {
+		cur, err := c.GetCurrentSession(dir)
+		if notFound(err) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return glanceOf(cur.Session), nil
+	})
+	if g == nil {
+		return 0
+	}
+	color := !*noColor && os.Getenv("NO_COLOR") == ""
+	_, _ = fmt.Fprint(stdout, fragment(g, promptStyle{color: color, shell: *shell}))
+	return 0
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLookupCached(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prompt", "x.json")
+	now := time.Now()
+	calls := 0
+	answer := &glance{Activity: "thinking"}
+	var fail error
+	fetch := func() (*glance, error) {
+		calls++
+		if fail != nil {
+			return nil, fail
+		}
+		return answer, nil
+	}
+
+	if g := lookupCached(path, 2*time.Second, time.Minute, now, fetch); g == nil || g.Activity != "thinking" || calls != 1 {
+		t.Fatalf("first lookup = %+v after %d calls", g, calls)
+	}
+	// Within the TTL the cache answers without asking.
+	if g := lookupCached(path, 2*time.Second, time.Minute, now.Add(time.Second), fetch); g == nil || calls != 1 {
+		t.Fatalf("cached lookup = %+v after %d calls, want no new call", g, calls)
+	}
+
+	// The server goes away: the last answer is kept while it's fresh.
+	fail = errors.New("connection refused")
+	now = now.Add(10 * time.Second)
+	if g := lookupCached(path, 2*time.Second, time.Minute, now, fetch); g == nil || calls != 2 {
+		t.Fatalf("stale lookup = %+v after %d calls, want the last answer", g, calls)
+	}
+	// The failure is cached, so the next prompt doesn't wait on it again.
+	if lookupCached(path, 2*time.Second, time.Minute, now.Add(time.Second), fetch); calls != 2 {
+		t.Fatalf("calls = %d after a cached failure, want 2", calls)
+	}
+	// Past the stale limit nothing is shown.
+	if g := lookupCached(path, 2*time.Second, time.Minute, now.Add(2*time.Minute), fetch); g != nil {
+		t.Fatalf("expired lookup = %+v, want nil", g)
+	}
+}
+
+func TestFragment(t *testing.T) {
+	g := &glance{Activity: "needs_approval", ContextUtilization: 0.93, BurnRatePerMinute: 1250}
+	if got, want := fragment(g, promptStyle{}), "✋ 93% 1.2k/m"; got != want {
+		t.Errorf("plain fragment = %q, want %q", got, want)
+	}
+	if got, want := fragment(&glance{Activity: "idle", ContextUtilization: 0.1}, promptStyle{}), "○ 10%"; got != want {
+		t.Errorf("idle fragment = %q, want %q", got, want)
+	}
+
+	bash := fragment(&glance{Activity: "thinking"}, promptStyle{color: true, shell: "bash"})
+	if want := "\\[\x1b[32m\\]●\\[\x1b[0m\\] 0%"; bash != want {
+		t.Errorf("bash fragment = %q, want %q", bash, want)
+	}
+	zsh := fragment(&glance{Activity: "thinking"}, promptStyle{color: true, shell: "zsh"})
+	if want := "%{\x1b[32m%}●%{\x1b[0m%} 0%"; zsh != want {
+		t.Errorf("zsh fragment = %q, want %q", zsh, want)
+	}
+}
+
+func TestRunPrompt(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	var gotCwd string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotCwd = r.URL.Query().Get("cwd")
+		if gotCwd != "/src/app" {
+			http.Error(w, "no running session", http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"match":"exact","session":{"id":"a","activity":"tool_use","contextUtilization":0.5,"burnRatePerMinute":800}}`))
+	}))
+	defer srv.Close()
+	wsURL := "ws" + srv.URL[len("http"):] + "/ws"
+
+	var stdout, stderr bytes.Buffer
+	if code := runPrompt([]string{"-url", wsURL, "-cwd", "/src/app", "-no-color"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit = %d: %s", code, stderr.String())
+	}
+	if got, want := stdout.String(), "⚙ 50% 800/m"; got != want {
+		t.Errorf("prompt = %q, want %q", got, want)
+	}
+
+	stdout.Reset()
+	if runPrompt([]string{"-url", wsURL, "-cwd", "/elsewhere"}, &stdout, &stderr); stdout.Len() != 0 || gotCwd != "/elsewhere" {
+		t.Errorf("unmatched prompt = %q (asked for %q), want nothing", stdout.String(), gotCwd)
+	}
+}
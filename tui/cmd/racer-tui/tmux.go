@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// tmuxNameWidth is how much of a session's name the status line shows.
+const tmuxNameWidth = 16
+
+// currentTmuxTarget asks tmux for the "session:window.pane" target of
+// $TMUX_PANE, the pane this process runs in.
+func currentTmuxTarget() string {
+	pane := os.Getenv("TMUX_PANE")
+	if pane == "" {
+		return ""
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, "tmux", "display-message", "-p", "-t", pane,
+		"#{session_name}:#{window_index}.#{pane_index}").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// tmuxStatus renders g for status-right, e.g. "api ⚙ 42% 1.2k/m".
+func tmuxStatus(g *glance, color bool) string {
+	name := g.Name
+	if utf8.RuneCountInString(name) > tmuxNameWidth {
+		name = string([]rune(name)[:tmuxNameWidth-1]) + "…"
+	}
+	// tmux reads "#" in job output as the start of a format.
+	name = strings.ReplaceAll(name, "#", "##")
+	out := fragment(g, promptStyle{color: color, shell: "tmux"})
+	if name == "" {
+		return out
+	}
+	return name + " " + out
+}
+
+// runTmuxStatus prints the status of the session running in a tmux pane,
+// for status-right, or nothing when there is none. Like runPrompt it
+// always exits 0 once its flags parse.
+func runTmuxStatus(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("agent-racer tmux-status", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var sf serverFlags
+	sf.register(fs)
+	target := fs.String("session", "", "tmux pane as session:window.pane (defaults to the pane in $TMUX_PANE)")
+	cwd := fs.String("cwd", "", "Directory to match when no session runs in the pane, e.g. #{pane_current_path}")
+	noColor := fs.Bool("no-color", false, "Print without tmux color styles")
+	ttl := fs.Duration("ttl", 2*time.Second, "How long to reuse the last answer before asking the server again")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	pane := *target
+	if pane == "" {
+		pane = currentTmuxTarget()
+	}
+	if pane == "" && *cwd == "" {
+		return 0
+	}
+	c, base, err := sf.httpClient()
+	if err != nil {
+		return 0
+	}
+	c.SetTimeout(promptTimeout)
+
+	g := lookupCached(cachePath("tmux", base+"\x00"+pane+"\x00"+*cwd), *ttl, promptStale, time.Now(), func() (*glance, error) {
+		cur, err := c.GetPaneSession(pane, *cwd)
+		if notFound(err) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return glanceOf(cur.Session), nil
+	})
+	if g == nil {
+		return 0
+	}
+	_, _ = fmt.Fprint(stdout, tmuxStatus(g, !*noColor))
+	return 0
+}
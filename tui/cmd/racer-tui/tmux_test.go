@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTmuxStatus(t *testing.T) {
+	g := &glance{Name: "fix #42 in the login flow", Activity: "waiting", ContextUtilization: 0.75}
+	if got, want := tmuxStatus(g, true), "fix ##42 in the … #[fg=yellow]…#[default] #[fg=yellow]75%#[default]"; got != want {
+		t.Errorf("tmuxStatus = %q, want %q", got, want)
+	}
+	if got, want := tmuxStatus(&glance{Activity: "idle"}, false), "○ 0%"; got != want {
+		t.Errorf("unnamed tmuxStatus = %q, want %q", got, want)
+	}
+}
+
+func TestRunTmuxStatus(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("TMUX_PANE", "")
+	var gotQuery string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotQuery = r.URL.RawQuery
+		_, _ = w.Write([]byte(`{"match":"tmux","session":{"id":"a","name":"api","activity":"thinking","contextUtilization":0.2}}`))
+	}))
+	defer srv.Close()
+	wsURL := "ws" + srv.URL[len("http"):] + "/ws"
+
+	var stdout, stderr bytes.Buffer
+	if code := runTmuxStatus([]string{"-url", wsURL, "--session", "dev:1.0", "-no-color"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit = %d: %s", code, stderr.String())
+	}
+	if got, want := stdout.String(), "api ● 20%"; got != want {
+		t.Errorf("status = %q, want %q", got, want)
+	}
+	if gotQuery != "tmux=dev%3A1.0" {
+		t.Errorf("query = %q, want the pane", gotQuery)
+	}
+
+	// Outside tmux with nothing to match, it asks nothing.
+	stdout.Reset()
+	gotQuery = ""
+	if runTmuxStatus([]string{"-url", wsURL}, &stdout, &stderr); stdout.Len() != 0 || gotQuery != "" {
+		t.Errorf("no pane: printed %q, queried %q", stdout.String(), gotQuery)
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/agent-racer/tui/internal/waybar"
+)
+
+// runWaybar streams the race as a waybar custom module until interrupted.
+func runWaybar(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("agent-racer waybar", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var sf serverFlags
+	sf.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	wsURL, token, tlsCfg, err := sf.resolve()
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "TLS configuration error: %v\n", err)
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := waybar.Run(ctx, stdout, waybar.Options{URL: wsURL, Token: token, TLS: tlsCfg}); err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -3,6 +3,7 @@ module github.com/agent-racer/tui
 go 1.24.7
 
 require (
+	github.com/agent-racer/backend v0.0.0
 	github.com/charmbracelet/bubbles v1.0.0
 	github.com/charmbracelet/bubbletea v1.3.10
 	github.com/charmbracelet/glamour v0.10.0
@@ -46,3 +47,5 @@ require (
 	golang.org/x/term v0.31.0 // indirect
 	golang.org/x/text v0.24.0 // indirect
 )
+
+replace github.com/agent-racer/backend => ../backend
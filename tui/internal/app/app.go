@@ -4,8 +4,10 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/agent-racer/tui/internal/client"
+	"github.com/agent-racer/tui/internal/notify"
 	"github.com/agent-racer/tui/internal/theme"
 	"github.com/agent-racer/tui/internal/views/achievements"
 	"github.com/agent-racer/tui/internal/views/battlepass"
@@ -40,9 +42,20 @@ const (
 type httpBattlePassMsg struct {
 	stats      *client.Stats
 	challenges []client.ChallengeProgress
+	seasons    *client.SeasonHistory
 	err        error
 }
 
+// replayTickMsg plays back the next step of a catch-up.
+type replayTickMsg struct{}
+
+const (
+	// replayInterval is the pause between catch-up steps, and replaySteps
+	// how many steps a catch-up takes at most.
+	replayInterval = 80 * time.Millisecond
+	replaySteps    = 25
+)
+
 // Responsive breakpoints (terminal width).
 const (
 	breakpointCompact = 60  // minimal: short labels
@@ -82,8 +95,14 @@ type Model struct {
 	debugLog     debug.Model
 	tailView     tail.Model
 
+	// prefs is how the server asks for timestamps to be shown.
+	prefs client.PreferencesPayload
+
 	// Connection state.
 	connected bool
+	// shutdownReason is the reason from the last server_shutdown message,
+	// shown while disconnected; cleared on reconnect.
+	shutdownReason string
 
 	// Focus choice mode: active after pressing f on a session with a tmux target.
 	focusMode       bool
@@ -91,8 +110,18 @@ type Model struct {
 	focusTmuxTarget string
 	focusCanSplit   bool
 
+	// Catch-up playback after a reconnect: the messages still to apply, in
+	// order, and how many to apply per tick. Messages arriving meanwhile
+	// queue behind them.
+	replay     []tea.Msg
+	replayStep int
+
 	// Spinner drives animated indicators across sub-views.
 	spinner spinner.Model
+
+	// notifier raises terminal notifications as sessions change state;
+	// nil when they are off.
+	notifier *notify.Notifier
 }
 
 // focusResultMsg carries the result of a FocusSession HTTP call.
@@ -128,6 +157,13 @@ func New(ws *client.WSClient, http *client.HTTPClient) Model {
 	return m
 }
 
+// WithNotifier has the model raise a terminal notification through n
+// whenever a session enters one of n's states.
+func (m Model) WithNotifier(n *notify.Notifier) Model {
+	m.notifier = n
+	return m
+}
+
 // Init starts the WebSocket connection and fetches initial battle pass data.
 func (m Model) Init() tea.Cmd {
 	return tea.Batch(m.ws.Listen(m.ctx), m.loadBattlePassCmd(), m.spinner.Tick)
@@ -141,7 +177,8 @@ func (m Model) loadBattlePassCmd() tea.Cmd {
 			return httpBattlePassMsg{err: err}
 		}
 		challenges, _ := m.http.GetChallenges()
-		return httpBattlePassMsg{stats: stats, challenges: challenges}
+		seasons, _ := m.http.GetSeasons()
+		return httpBattlePassMsg{stats: stats, challenges: challenges, seasons: seasons}
 	}
 }
 
@@ -170,6 +207,9 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if msg.challenges != nil {
 			m.battlePass.SetChallenges(msg.challenges)
 		}
+		if msg.seasons != nil {
+			m.battlePass.SetSeasons(msg.seasons.Archived)
+		}
 		m.battlePass.SetLoaded()
 		return m, nil
 
@@ -190,6 +230,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case client.WSConnectedMsg:
 		m.connected = true
 		m.statusBar.Connected = true
+		m.shutdownReason = ""
 		m.debugLog.Add("ws", "connected")
 		return m, m.ws.ReadLoop(m.ctx)
 
@@ -207,35 +248,82 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, m.trackView.Tick()
 
 	case client.WSSnapshotMsg:
-		m.sessions = make(map[string]*client.SessionState)
-		for _, s := range msg.Payload.Sessions {
-			m.sessions[s.ID] = s
+		if len(m.replay) > 0 {
+			m.replay = append(m.replay, msg)
+			return m, m.ws.ReadLoop(m.ctx)
 		}
-		for _, h := range msg.Payload.SourceHealth {
-			m.statusBar.SourceHealth[h.Source] = h
-		}
-		animCmd := m.refreshTrack()
-		m.debugLog.Add("ws", fmt.Sprintf("snapshot: %d sessions", len(msg.Payload.Sessions)))
-		return m, tea.Batch(m.ws.ReadLoop(m.ctx), animCmd)
+		return m, tea.Batch(m.ws.ReadLoop(m.ctx), m.applySnapshot(msg.Payload))
 
 	case client.WSDeltaMsg:
-		for _, s := range msg.Payload.Updates {
-			m.sessions[s.ID] = s
+		if len(m.replay) > 0 {
+			m.replay = append(m.replay, msg)
+			return m, m.ws.ReadLoop(m.ctx)
 		}
-		for _, id := range msg.Payload.Removed {
-			delete(m.sessions, id)
-		}
-		animCmd := m.refreshTrack()
-		m.debugLog.Add("ws", fmt.Sprintf("delta: +%d -%d", len(msg.Payload.Updates), len(msg.Payload.Removed)))
-		return m, tea.Batch(m.ws.ReadLoop(m.ctx), animCmd)
+		return m, tea.Batch(m.ws.ReadLoop(m.ctx), m.applyDelta(msg.Payload))
 
 	case client.WSCompletionMsg:
-		if s, ok := m.sessions[msg.Payload.SessionID]; ok {
-			s.Activity = msg.Payload.Activity
+		if len(m.replay) > 0 {
+			m.replay = append(m.replay, msg)
+			return m, m.ws.ReadLoop(m.ctx)
 		}
-		animCmd := m.refreshTrack()
-		m.debugLog.Add("ws", fmt.Sprintf("completion: %s → %s", msg.Payload.Name, string(msg.Payload.Activity)))
-		return m, tea.Batch(m.ws.ReadLoop(m.ctx), animCmd)
+		return m, tea.Batch(m.ws.ReadLoop(m.ctx), m.applyCompletion(msg.Payload))
+
+	case client.WSCatchUpMsg:
+		var replay []tea.Msg
+		for _, ev := range msg.Events {
+			switch ev.(type) {
+			case client.WSDeltaMsg, client.WSCompletionMsg:
+				replay = append(replay, ev)
+			}
+		}
+		m.debugLog.Add("ws", fmt.Sprintf("catch-up since %d: %d events (complete=%t)", msg.Payload.Since, len(replay), msg.Payload.Complete))
+		if len(replay) == 0 {
+			return m, m.ws.ReadLoop(m.ctx)
+		}
+		m.replay = append(m.replay, replay...)
+		m.replayStep = max(1, (len(m.replay)+replaySteps-1)/replaySteps)
+		return m, tea.Batch(m.ws.ReadLoop(m.ctx), replayTick())
+
+	case replayTickMsg:
+		return m.stepReplay()
+
+	case client.WSServerShutdownMsg:
+		m.shutdownReason = msg.Payload.Reason
+		if m.shutdownReason == "" {
+			m.shutdownReason = "no reason given"
+		}
+		m.debugLog.Add("ws", "server shutting down: "+m.shutdownReason)
+		return m, m.ws.ReadLoop(m.ctx)
+
+	case client.WSUpdateAvailableMsg:
+		m.statusBar.UpdateAvailable = msg.Payload.Latest
+		m.debugLog.Add("ws", fmt.Sprintf("update available: %s (running %s) %s", msg.Payload.Latest, msg.Payload.Current, msg.Payload.URL))
+		return m, m.ws.ReadLoop(m.ctx)
+
+	case client.WSPreferencesMsg:
+		m.prefs = msg.Payload
+		m.tailView.SetPreferences(msg.Payload)
+		if msg.Payload.Error != "" {
+			m.debugLog.Add("ws", "preferences rejected: "+msg.Payload.Error)
+		}
+		return m, m.ws.ReadLoop(m.ctx)
+
+	case client.WSPresenceMsg:
+		m.statusBar.Viewers = msg.Payload.Viewers
+		names := make([]string, 0, len(msg.Payload.Named))
+		for i := 0; i < len(msg.Payload.Named); i++ {
+			names = append(names, msg.Payload.Named[i].Name)
+		}
+		m.debugLog.Add("ws", fmt.Sprintf("%d viewers %v", msg.Payload.Viewers, names))
+		return m, m.ws.ReadLoop(m.ctx)
+
+	case client.WSReactionMsg:
+		from := msg.Payload.From
+		if from == "" {
+			from = "a viewer"
+		}
+		m.debugLog.Add("ws", fmt.Sprintf("%s reacted %s to %s", from, msg.Payload.Emoji, msg.Payload.Name))
+		return m, m.ws.ReadLoop(m.ctx)
 
 	case client.WSSourceHealthMsg:
 		m.statusBar.SourceHealth[msg.Payload.Source] = msg.Payload
@@ -256,11 +344,34 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.debugLog.Add("ws", fmt.Sprintf("achievement: %s", msg.Payload.Name))
 		return m, m.ws.ReadLoop(m.ctx)
 
+	case client.WSAchievementBatchMsg:
+		for i := 0; i < len(msg.Payload.Achievements); i++ {
+			m.achievements.ApplyUnlock(msg.Payload.Achievements[i].ID)
+		}
+		m.debugLog.Add("ws", fmt.Sprintf("achievements: %d unlocked", len(msg.Payload.Achievements)))
+		return m, m.ws.ReadLoop(m.ctx)
+
 	case client.WSBattlePassMsg:
 		m.battlePass.SetProgress(msg.Payload)
 		m.debugLog.Add("ws", fmt.Sprintf("xp +%d (tier %d)", msg.Payload.XP, msg.Payload.Tier))
 		return m, m.ws.ReadLoop(m.ctx)
 
+	case client.WSLapCompletedMsg:
+		m.debugLog.Add("ws", fmt.Sprintf("lap %d: %s", msg.Payload.Lap, msg.Payload.Name))
+		return m, m.ws.ReadLoop(m.ctx)
+
+	case client.WSHeatStandingsMsg:
+		m.debugLog.Add("ws", fmt.Sprintf("heat %s: %s", msg.Payload.Name, msg.Payload.Status))
+		return m, m.ws.ReadLoop(m.ctx)
+
+	case client.WSPipelineUpdateMsg:
+		m.debugLog.Add("ws", fmt.Sprintf("pipeline %s: %s (leg %d/%d)", msg.Payload.Pipeline, msg.Payload.Status, msg.Payload.Leg+1, len(msg.Payload.Stages)))
+		return m, m.ws.ReadLoop(m.ctx)
+
+	case client.WSSoundCueMsg:
+		m.debugLog.Add("ws", fmt.Sprintf("sound cue: %s %s", msg.Payload.Cue, msg.Payload.SessionID))
+		return m, m.ws.ReadLoop(m.ctx)
+
 	case client.WSErrorMsg:
 		m.debugLog.Add("err", string(msg.Raw))
 		return m, m.ws.ReadLoop(m.ctx)
@@ -570,7 +681,12 @@ func (m Model) renderDisconnectOverlay() string {
 	sub := theme.StyleDimmed.Render(m.spinner.View() + " Reconnecting to backend...")
 	hint := theme.StyleDimmed.Render("Press q to quit")
 
-	box := lipgloss.JoinVertical(lipgloss.Center, "", icon, "", sub, "", hint, "")
+	lines := []string{"", icon, ""}
+	if m.shutdownReason != "" {
+		lines = append(lines, theme.StyleDimmed.Render("Server stopped: "+m.shutdownReason), "")
+	}
+	lines = append(lines, sub, "", hint, "")
+	box := lipgloss.JoinVertical(lipgloss.Center, lines...)
 
 	return lipgloss.NewStyle().
 		Width(w).
@@ -601,6 +717,71 @@ func (m Model) renderHelp() string {
 
 // refreshTrack rebuilds the track view, dashboard, and updates status bar counts.
 // Returns a TickCmd to start the spring animation loop if there are racing sessions.
+func (m *Model) applySnapshot(p client.SnapshotPayload) tea.Cmd {
+	m.sessions = make(map[string]*client.SessionState)
+	for _, s := range p.Sessions {
+		m.sessions[s.ID] = s
+	}
+	for _, h := range p.SourceHealth {
+		m.statusBar.SourceHealth[h.Source] = h
+	}
+	m.debugLog.Add("ws", fmt.Sprintf("snapshot: %d sessions", len(p.Sessions)))
+	return m.refreshTrack()
+}
+
+func (m *Model) applyDelta(p client.DeltaPayload) tea.Cmd {
+	for _, s := range p.Updates {
+		if prev, ok := m.sessions[s.ID]; ok {
+			m.notifier.Observe(prev.Activity, s)
+		} else {
+			m.notifier.Observe("", s)
+		}
+		m.sessions[s.ID] = s
+	}
+	for _, id := range p.Removed {
+		delete(m.sessions, id)
+	}
+	m.debugLog.Add("ws", fmt.Sprintf("delta: +%d -%d", len(p.Updates), len(p.Removed)))
+	return m.refreshTrack()
+}
+
+func (m *Model) applyCompletion(p client.CompletionPayload) tea.Cmd {
+	if s, ok := m.sessions[p.SessionID]; ok {
+		prev := s.Activity
+		s.Activity = p.Activity
+		m.notifier.Observe(prev, s)
+	}
+	m.debugLog.Add("ws", fmt.Sprintf("completion: %s → %s", p.Name, string(p.Activity)))
+	return m.refreshTrack()
+}
+
+func replayTick() tea.Cmd {
+	return tea.Tick(replayInterval, func(time.Time) tea.Msg { return replayTickMsg{} })
+}
+
+// stepReplay applies the next few queued messages, so the track animates
+// through what was missed rather than jumping to the latest snapshot.
+func (m Model) stepReplay() (tea.Model, tea.Cmd) {
+	var animCmd tea.Cmd
+	n := min(m.replayStep, len(m.replay))
+	for i := 0; i < n; i++ {
+		switch ev := m.replay[i].(type) {
+		case client.WSSnapshotMsg:
+			animCmd = m.applySnapshot(ev.Payload)
+		case client.WSDeltaMsg:
+			animCmd = m.applyDelta(ev.Payload)
+		case client.WSCompletionMsg:
+			animCmd = m.applyCompletion(ev.Payload)
+		}
+	}
+	m.replay = m.replay[n:]
+	if len(m.replay) == 0 {
+		m.replay = nil
+		return m, animCmd
+	}
+	return m, tea.Batch(animCmd, replayTick())
+}
+
 func (m *Model) refreshTrack() tea.Cmd {
 	sessions := m.filteredSessions()
 	m.trackView.SetSessions(sessions)
@@ -634,6 +815,7 @@ func (m *Model) filteredSessions() map[string]*client.SessionState {
 // openTail creates a tail view for the given session and starts polling.
 func (m Model) openTail(s *client.SessionState) (tea.Model, tea.Cmd) {
 	m.tailView = tail.New(s)
+	m.tailView.SetPreferences(m.prefs)
 	m.overlay = OverlayTail
 	m.debugLog.Add("nav", fmt.Sprintf("tailing %s", s.ID))
 	return m, tail.FetchCmd(m.http, s.ID, 0)
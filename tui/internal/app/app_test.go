@@ -6,7 +6,9 @@ import (
 	"time"
 
 	"github.com/agent-racer/tui/internal/client"
+	"github.com/agent-racer/tui/internal/notify"
 	"github.com/agent-racer/tui/internal/views/track"
+	tea "github.com/charmbracelet/bubbletea"
 )
 
 func TestClassifyZone(t *testing.T) {
@@ -87,3 +89,77 @@ func TestDisconnectOverlay(t *testing.T) {
 		t.Error("disconnect overlay should contain 'Reconnecting'")
 	}
 }
+
+func TestDisconnectOverlayShowsShutdownReason(t *testing.T) {
+	m := New(nil, nil)
+	m.width = 80
+	m.height = 24
+	m.connected = false
+	m.shutdownReason = "received terminated"
+
+	if v := m.View(); !strings.Contains(v, "Server stopped: received terminated") {
+		t.Error("disconnect overlay should show the server shutdown reason")
+	}
+}
+
+func TestCatchUpReplaysBeforeLiveMessages(t *testing.T) {
+	m := New(client.NewWSClient("ws://localhost/ws", "", nil), nil)
+	m.sessions["s1"] = &client.SessionState{ID: "s1", Activity: client.ActivityThinking}
+
+	var model tea.Model = m
+	model, _ = model.Update(client.WSCatchUpMsg{Events: []tea.Msg{
+		client.WSDeltaMsg{Payload: client.DeltaPayload{Updates: []*client.SessionState{{ID: "s1", Activity: client.ActivityToolUse}}}},
+		client.WSCompletionMsg{Payload: client.CompletionPayload{SessionID: "s1", Activity: client.ActivityComplete}},
+	}})
+	// The snapshot and a live delta arrive while the catch-up plays.
+	model, _ = model.Update(client.WSSnapshotMsg{Payload: client.SnapshotPayload{Sessions: []*client.SessionState{
+		{ID: "s1", Activity: client.ActivityComplete},
+		{ID: "s2", Activity: client.ActivityThinking},
+	}}})
+	model, _ = model.Update(client.WSDeltaMsg{Payload: client.DeltaPayload{Updates: []*client.SessionState{{ID: "s3", Activity: client.ActivityThinking}}}})
+
+	m = model.(Model)
+	if len(m.sessions) != 1 || m.sessions["s1"].Activity != client.ActivityThinking {
+		t.Fatalf("state changed before playback: %v", m.sessions)
+	}
+	if len(m.replay) != 4 {
+		t.Fatalf("replay = %d queued, want 4", len(m.replay))
+	}
+
+	m.replayStep = 1
+	model, _ = m.stepReplay()
+	m = model.(Model)
+	if m.sessions["s1"].Activity != client.ActivityToolUse {
+		t.Errorf("after first step s1 = %s, want tool_use", m.sessions["s1"].Activity)
+	}
+	for len(m.replay) > 0 {
+		model, _ = m.stepReplay()
+		m = model.(Model)
+	}
+	if len(m.sessions) != 3 || m.sessions["s1"].Activity != client.ActivityComplete {
+		t.Errorf("final sessions = %v, want the snapshot plus the live delta", m.sessions)
+	}
+}
+
+func TestDeltaRaisesNotifications(t *testing.T) {
+	var buf strings.Builder
+	n, err := notify.New(&buf, notify.OSC9, "needs_approval,complete", false)
+	if err != nil {
+		t.Fatalf("notify.New: %v", err)
+	}
+	m := New(nil, nil).WithNotifier(n)
+	m.sessions["s1"] = &client.SessionState{ID: "s1", Name: "api", Activity: client.ActivityToolUse}
+
+	m.applyDelta(client.DeltaPayload{Updates: []*client.SessionState{{ID: "s1", Name: "api", Activity: client.ActivityNeedsApproval}}})
+	if !strings.Contains(buf.String(), "api needs approval") {
+		t.Errorf("after approval: %q", buf.String())
+	}
+
+	// The completion and the delta carrying it notify once between them.
+	buf.Reset()
+	m.applyCompletion(client.CompletionPayload{SessionID: "s1", Name: "api", Activity: client.ActivityComplete})
+	m.applyDelta(client.DeltaPayload{Updates: []*client.SessionState{{ID: "s1", Name: "api", Activity: client.ActivityComplete}}})
+	if got := strings.Count(buf.String(), "api finished"); got != 1 {
+		t.Errorf("finish notified %d times: %q", got, buf.String())
+	}
+}
@@ -1,260 +1,108 @@
 // Package client provides WebSocket and HTTP clients for the Agent Racer backend.
-// Types mirror the backend wire protocol without importing backend packages.
+// The wire types come from the backend's pkg/client SDK so the two cannot
+// drift; this package adds the Bubble Tea plumbing on top.
 package client
 
 import (
-	"encoding/json"
-	"time"
+	sdk "github.com/agent-racer/backend/pkg/client"
 )
 
 // MessageType identifies the kind of WebSocket message.
-type MessageType string
+type MessageType = sdk.MessageType
 
 const (
-	MsgSnapshot            MessageType = "snapshot"
-	MsgDelta               MessageType = "delta"
-	MsgCompletion          MessageType = "completion"
-	MsgEquipped            MessageType = "equipped"
-	MsgError               MessageType = "error"
-	MsgAchievementUnlocked MessageType = "achievement_unlocked"
-	MsgSourceHealth        MessageType = "source_health"
-	MsgBattlePassProgress  MessageType = "battlepass_progress"
+	MsgSnapshot            = sdk.MsgSnapshot
+	MsgDelta               = sdk.MsgDelta
+	MsgCompletion          = sdk.MsgCompletion
+	MsgEquipped            = sdk.MsgEquipped
+	MsgError               = sdk.MsgError
+	MsgAchievementUnlocked = sdk.MsgAchievementUnlocked
+	MsgAchievementBatch    = sdk.MsgAchievementBatch
+	MsgSourceHealth        = sdk.MsgSourceHealth
+	MsgBattlePassProgress  = sdk.MsgBattlePassProgress
+	MsgServerShutdown      = sdk.MsgServerShutdown
+	MsgUpdateAvailable     = sdk.MsgUpdateAvailable
+	MsgSoundCue            = sdk.MsgSoundCue
+	MsgLapCompleted        = sdk.MsgLapCompleted
+	MsgHeatStandings       = sdk.MsgHeatStandings
+	MsgPipelineUpdate      = sdk.MsgPipelineUpdate
+	MsgCatchUp             = sdk.MsgCatchUp
+	MsgPreferences         = sdk.MsgPreferences
+	MsgPresence            = sdk.MsgPresence
+	MsgReaction            = sdk.MsgReaction
 )
 
 // WSMessage is the envelope for all WebSocket messages.
-type WSMessage struct {
-	Type    MessageType     `json:"type"`
-	Seq     uint64          `json:"seq"`
-	Payload json.RawMessage `json:"payload"`
-}
+type WSMessage = sdk.WSMessage
 
 // Activity represents a session's current state.
-type Activity string
+type Activity = sdk.Activity
 
 const (
-	ActivityStarting Activity = "starting"
-	ActivityThinking Activity = "thinking"
-	ActivityToolUse  Activity = "tool_use"
-	ActivityWaiting  Activity = "waiting"
-	ActivityIdle     Activity = "idle"
-	ActivityComplete Activity = "complete"
-	ActivityErrored  Activity = "errored"
-	ActivityLost     Activity = "lost"
+	ActivityStarting      = sdk.ActivityStarting
+	ActivityThinking      = sdk.ActivityThinking
+	ActivityToolUse       = sdk.ActivityToolUse
+	ActivityWaiting       = sdk.ActivityWaiting
+	ActivityIdle          = sdk.ActivityIdle
+	ActivityComplete      = sdk.ActivityComplete
+	ActivityErrored       = sdk.ActivityErrored
+	ActivityLost          = sdk.ActivityLost
+	ActivityNeedsApproval = sdk.ActivityNeedsApproval
 )
 
-// IsTerminal returns true if the activity represents a terminal state.
-func (a Activity) IsTerminal() bool {
-	return a == ActivityComplete || a == ActivityErrored || a == ActivityLost
-}
-
-// SessionState mirrors backend/internal/session.SessionState.
-type SessionState struct {
-	ID                 string          `json:"id"`
-	Name               string          `json:"name"`
-	Slug               string          `json:"slug,omitempty"`
-	Source             string          `json:"source"`
-	Activity           Activity        `json:"activity"`
-	TokensUsed         int             `json:"tokensUsed"`
-	TokenEstimated     bool            `json:"tokenEstimated"`
-	MaxContextTokens   int             `json:"maxContextTokens"`
-	ContextUtilization float64         `json:"contextUtilization"`
-	CurrentTool        string          `json:"currentTool,omitempty"`
-	Model              string          `json:"model"`
-	WorkingDir         string          `json:"workingDir"`
-	Branch             string          `json:"branch,omitempty"`
-	StartedAt          time.Time       `json:"startedAt"`
-	LastActivityAt     time.Time       `json:"lastActivityAt"`
-	LastDataReceivedAt time.Time       `json:"lastDataReceivedAt"`
-	CompletedAt        *time.Time      `json:"completedAt,omitempty"`
-	MessageCount       int             `json:"messageCount"`
-	ToolCallCount      int             `json:"toolCallCount"`
-	PID                int             `json:"pid,omitempty"`
-	IsChurning         bool            `json:"isChurning,omitempty"`
-	TmuxTarget         string          `json:"tmuxTarget,omitempty"`
-	Lane               int             `json:"lane"`
-	BurnRatePerMinute  float64         `json:"burnRatePerMinute,omitempty"`
-	CompactionCount    int             `json:"compactionCount,omitempty"`
-	Subagents          []SubagentState `json:"subagents,omitempty"`
-	LastAssistantText  string          `json:"lastAssistantText,omitempty"`
-}
-
-// SubagentState mirrors backend/internal/session.SubagentState.
-type SubagentState struct {
-	ID              string     `json:"id"`
-	ParentToolUseID string     `json:"parentToolUseId"`
-	SessionID       string     `json:"sessionId"`
-	Slug            string     `json:"slug"`
-	Model           string     `json:"model"`
-	Activity        Activity   `json:"activity"`
-	CurrentTool     string     `json:"currentTool,omitempty"`
-	TokensUsed      int        `json:"tokensUsed"`
-	MessageCount    int        `json:"messageCount"`
-	ToolCallCount   int        `json:"toolCallCount"`
-	StartedAt       time.Time  `json:"startedAt"`
-	LastActivityAt  time.Time  `json:"lastActivityAt"`
-	CompletedAt     *time.Time `json:"completedAt,omitempty"`
-}
+type (
+	SessionState  = sdk.SessionState
+	SubagentState = sdk.SubagentState
+)
 
 // --- WebSocket payload types ---
 
-// SnapshotPayload is sent on initial connection.
-type SnapshotPayload struct {
-	Sessions     []*SessionState       `json:"sessions"`
-	SourceHealth []SourceHealthPayload `json:"sourceHealth,omitempty"`
-}
-
-// DeltaPayload contains incremental session updates.
-type DeltaPayload struct {
-	Updates []*SessionState `json:"updates"`
-	Removed []string        `json:"removed,omitempty"`
-}
-
-// CompletionPayload is sent when a session reaches a terminal state.
-type CompletionPayload struct {
-	SessionID string   `json:"sessionId"`
-	Activity  Activity `json:"activity"`
-	Name      string   `json:"name"`
-}
-
-// EquippedPayload broadcasts the current cosmetic loadout.
-type EquippedPayload struct {
-	Loadout Equipped `json:"loadout"`
-}
-
-// BattlePassProgressPayload is sent when XP is awarded.
-type BattlePassProgressPayload struct {
-	XP           int       `json:"xp"`
-	Tier         int       `json:"tier"`
-	TierProgress float64   `json:"tierProgress"`
-	RecentXP     []XPEntry `json:"recentXP"`
-	Rewards      []string  `json:"rewards,omitempty"`
-}
-
-// AchievementRewardPayload describes a reward tied to an achievement.
-type AchievementRewardPayload struct {
-	Type string `json:"type"`
-	ID   string `json:"id"`
-	Name string `json:"name"`
-}
-
-// AchievementUnlockedPayload is sent when an achievement unlocks.
-type AchievementUnlockedPayload struct {
-	ID          string                    `json:"id"`
-	Name        string                    `json:"name"`
-	Description string                    `json:"description"`
-	Tier        string                    `json:"tier"`
-	Reward      *AchievementRewardPayload `json:"reward,omitempty"`
-}
+type (
+	SnapshotPayload            = sdk.SnapshotPayload
+	DeltaPayload               = sdk.DeltaPayload
+	CompletionPayload          = sdk.CompletionPayload
+	EquippedPayload            = sdk.EquippedPayload
+	BattlePassProgressPayload  = sdk.BattlePassProgressPayload
+	AchievementRewardPayload   = sdk.AchievementRewardPayload
+	AchievementUnlockedPayload = sdk.AchievementUnlockedPayload
+	AchievementBatchPayload    = sdk.AchievementBatchPayload
+	ServerShutdownPayload      = sdk.ServerShutdownPayload
+	UpdateAvailablePayload     = sdk.UpdateAvailablePayload
+	SoundCuePayload            = sdk.SoundCuePayload
+	LapCompletedPayload        = sdk.LapCompletedPayload
+	HeatStanding               = sdk.HeatStanding
+	HeatStandingsPayload       = sdk.Heat
+	PipelineStage              = sdk.PipelineStage
+	PipelineUpdatePayload      = sdk.PipelineRun
+	CatchUpPayload             = sdk.CatchUpPayload
+	SourceHealthPayload        = sdk.SourceHealthPayload
+	PreferencesPayload         = sdk.PreferencesPayload
+	PresencePayload            = sdk.PresencePayload
+	ReactionPayload            = sdk.ReactionPayload
+)
 
 // SourceHealthStatus indicates a source's health.
-type SourceHealthStatus string
+type SourceHealthStatus = sdk.SourceHealthStatus
 
 const (
-	StatusHealthy  SourceHealthStatus = "healthy"
-	StatusDegraded SourceHealthStatus = "degraded"
-	StatusFailed   SourceHealthStatus = "failed"
+	StatusHealthy  = sdk.StatusHealthy
+	StatusDegraded = sdk.StatusDegraded
+	StatusFailed   = sdk.StatusFailed
 )
 
-// SourceHealthPayload reports the health of a session source.
-type SourceHealthPayload struct {
-	Source           string             `json:"source"`
-	Status           SourceHealthStatus `json:"status"`
-	DiscoverFailures int                `json:"discoverFailures"`
-	ParseFailures    int                `json:"parseFailures"`
-	LastError        string             `json:"lastError,omitempty"`
-	Timestamp        time.Time          `json:"timestamp"`
-}
-
 // --- HTTP response types ---
 
-// XPEntry records a single XP award.
-type XPEntry struct {
-	Reason string `json:"reason"`
-	Amount int    `json:"amount"`
-}
-
-// Equipped tracks the active cosmetic in each slot.
-type Equipped struct {
-	Paint string `json:"paint,omitempty"`
-	Trail string `json:"trail,omitempty"`
-	Body  string `json:"body,omitempty"`
-	Badge string `json:"badge,omitempty"`
-	Sound string `json:"sound,omitempty"`
-	Theme string `json:"theme,omitempty"`
-	Title string `json:"title,omitempty"`
-}
-
-// Stats mirrors the aggregate stats returned by /api/stats.
-type Stats struct {
-	Version                int              `json:"version"`
-	TotalSessions          int              `json:"totalSessions"`
-	TotalCompletions       int              `json:"totalCompletions"`
-	TotalErrors            int              `json:"totalErrors"`
-	ConsecutiveCompletions int              `json:"consecutiveCompletions"`
-	SessionsPerSource      map[string]int   `json:"sessionsPerSource"`
-	SessionsPerModel       map[string]int   `json:"sessionsPerModel"`
-	DistinctModelsUsed     int              `json:"distinctModelsUsed"`
-	DistinctSourcesUsed    int              `json:"distinctSourcesUsed"`
-	MaxContextUtilization  float64          `json:"maxContextUtilization"`
-	MaxBurnRate            float64          `json:"maxBurnRate"`
-	MaxConcurrentActive    int              `json:"maxConcurrentActive"`
-	MaxToolCalls           int              `json:"maxToolCalls"`
-	MaxMessages            int              `json:"maxMessages"`
-	MaxSessionDurationSec  float64          `json:"maxSessionDurationSec"`
-	AchievementsUnlocked   map[string]string `json:"achievementsUnlocked"` // id -> RFC3339 time
-	BattlePass             BattlePass       `json:"battlePass"`
-	Equipped               Equipped         `json:"equipped"`
-	LastUpdated            time.Time        `json:"lastUpdated"`
-}
-
-// BattlePass tracks seasonal progression.
-type BattlePass struct {
-	Season string `json:"season"`
-	Tier   int    `json:"tier"`
-	XP     int    `json:"xp"`
-}
-
-// AchievementResponse is the shape returned by /api/achievements.
-type AchievementResponse struct {
-	ID          string     `json:"id"`
-	Name        string     `json:"name"`
-	Description string     `json:"description"`
-	Tier        string     `json:"tier"`
-	Category    string     `json:"category"`
-	Unlocked    bool       `json:"unlocked"`
-	UnlockedAt  *time.Time `json:"unlockedAt,omitempty"`
-}
-
-// ChallengeProgress is returned by /api/challenges.
-type ChallengeProgress struct {
-	ID          string `json:"id"`
-	Description string `json:"description"`
-	Current     int    `json:"current"`
-	Target      int    `json:"target"`
-	Complete    bool   `json:"complete"`
-}
-
-// TailEntry is a single display-ready entry from a session's JSONL log.
-type TailEntry struct {
-	Timestamp time.Time `json:"timestamp"`
-	Type      string    `json:"type"`              // "assistant", "user", "progress", "system"
-	Activity  string    `json:"activity"`           // "thinking", "tool_use", "tool_result", "text", "subagent", etc.
-	Summary   string    `json:"summary"`            // one-line human-readable
-	Detail    string    `json:"detail,omitempty"`    // optional longer content
-}
-
-// TailResponse is the HTTP response for the tail endpoint.
-type TailResponse struct {
-	Entries []TailEntry `json:"entries"`
-	Offset  int64       `json:"offset"`
-}
-
-// SoundConfig is returned by /api/config.
-type SoundConfig struct {
-	Enabled       bool    `json:"enabled"`
-	MasterVolume  float64 `json:"master_volume"`
-	AmbientVolume float64 `json:"ambient_volume"`
-	SfxVolume     float64 `json:"sfx_volume"`
-	EnableAmbient bool    `json:"enable_ambient"`
-	EnableSfx     bool    `json:"enable_sfx"`
-}
+type (
+	XPEntry             = sdk.XPEntry
+	Equipped            = sdk.Equipped
+	Stats               = sdk.Stats
+	BattlePass          = sdk.BattlePass
+	AchievementResponse = sdk.AchievementResponse
+	ChallengeProgress   = sdk.ChallengeProgress
+	SeasonHistory       = sdk.SeasonHistory
+	ArchivedSeason      = sdk.ArchivedSeason
+	SeasonTotals        = sdk.SeasonTotals
+	TailEntry           = sdk.TailEntry
+	TailResponse        = sdk.TailResponse
+	SoundConfig         = sdk.SoundConfig
+)
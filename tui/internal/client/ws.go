@@ -7,9 +7,11 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"os"
 	"sync"
 	"time"
 
+	sdk "github.com/agent-racer/backend/pkg/client"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/gorilla/websocket"
 )
@@ -20,6 +22,10 @@ const (
 	writeTimeout       = 10 * time.Second
 	pongTimeout        = 60 * time.Second
 	pingInterval       = 30 * time.Second
+
+	// snapshotPageSize keeps each snapshot message well under the read
+	// limit on servers with hundreds of sessions.
+	snapshotPageSize = 100
 )
 
 // WSClient manages the WebSocket connection to the Agent Racer backend.
@@ -27,12 +33,19 @@ type WSClient struct {
 	url    string
 	token  string
 	dialer *websocket.Dialer
+	// clientID names this client to the server when resuming, so it can
+	// be sent what it missed.
+	clientID string
+	// viewerName is who the other viewers see watching, from $USER.
+	viewerName string
 
 	mu      sync.Mutex
 	writeMu sync.Mutex // serialises all conn writes (ping, resync, auth)
 	conn    *websocket.Conn
 	seq     uint64
 	pingCtx context.CancelFunc // cancels the active ping goroutine
+
+	snapshots sdk.SnapshotAssembler // used by ReadLoop only
 }
 
 // NewWSClient creates a client that connects to the given WebSocket URL.
@@ -45,7 +58,18 @@ func NewWSClient(url, token string, tlsCfg *tls.Config) *WSClient {
 		dialer.TLSClientConfig = tlsCfg
 		dialer.Proxy = http.ProxyFromEnvironment
 	}
-	return &WSClient{url: url, token: token, dialer: dialer}
+	host, _ := os.Hostname()
+	return &WSClient{url: url, token: token, dialer: dialer, clientID: "tui@" + host, viewerName: os.Getenv("USER")}
+}
+
+// dialURL is the URL to connect to, asking for snapshots in pages. Once a
+// message has been seen, it asks the server to catch the client up from
+// there.
+func (c *WSClient) dialURL() string {
+	c.mu.Lock()
+	seq := c.seq
+	c.mu.Unlock()
+	return sdk.SnapshotURL(sdk.ResumeURL(c.url, c.clientID, seq), snapshotPageSize, false)
 }
 
 // --- Bubble Tea messages ---
@@ -71,9 +95,47 @@ type WSEquippedMsg struct{ Payload EquippedPayload }
 // WSAchievementMsg is sent when an achievement unlocks.
 type WSAchievementMsg struct{ Payload AchievementUnlockedPayload }
 
+// WSAchievementBatchMsg is sent when several achievements unlock at once.
+type WSAchievementBatchMsg struct{ Payload AchievementBatchPayload }
+
 // WSSourceHealthMsg reports source health changes.
 type WSSourceHealthMsg struct{ Payload SourceHealthPayload }
 
+// WSServerShutdownMsg is sent when the server announces it is shutting down.
+type WSServerShutdownMsg struct{ Payload ServerShutdownPayload }
+
+// WSUpdateAvailableMsg is sent when a newer server release is published.
+type WSUpdateAvailableMsg struct{ Payload UpdateAvailablePayload }
+
+// WSPreferencesMsg tells the client how to show timestamps.
+type WSPreferencesMsg struct{ Payload PreferencesPayload }
+
+// WSPresenceMsg says who else is watching.
+type WSPresenceMsg struct{ Payload PresencePayload }
+
+// WSReactionMsg is a viewer's emoji reaction to a session.
+type WSReactionMsg struct{ Payload ReactionPayload }
+
+// WSSoundCueMsg is sent when the server emits a sound cue.
+type WSSoundCueMsg struct{ Payload SoundCuePayload }
+
+// WSLapCompletedMsg is sent when a session completes a lap.
+type WSLapCompletedMsg struct{ Payload LapCompletedPayload }
+
+// WSHeatStandingsMsg is sent when a heat starts, changes order or ends.
+type WSHeatStandingsMsg struct{ Payload HeatStandingsPayload }
+
+// WSPipelineUpdateMsg is sent when a pipeline run starts, passes the baton
+// to its next stage or ends.
+type WSPipelineUpdateMsg struct{ Payload PipelineUpdatePayload }
+
+// WSCatchUpMsg delivers the messages missed while disconnected, oldest
+// first. The snapshot that follows it brings the state fully up to date.
+type WSCatchUpMsg struct {
+	Payload CatchUpPayload
+	Events  []tea.Msg
+}
+
 // WSBattlePassMsg is sent when XP is awarded.
 type WSBattlePassMsg struct{ Payload BattlePassProgressPayload }
 
@@ -92,7 +154,7 @@ func (c *WSClient) Listen(ctx context.Context) tea.Cmd {
 			default:
 			}
 
-			conn, _, err := c.dialer.Dial(c.url, nil)
+			conn, _, err := c.dialer.Dial(c.dialURL(), nil)
 			if err != nil {
 				log.Printf("ws dial error: %v (retry in %v)", err, delay)
 				time.Sleep(delay)
@@ -100,8 +162,9 @@ func (c *WSClient) Listen(ctx context.Context) tea.Cmd {
 				continue
 			}
 
-			// Authenticate if token is set. No write mutex needed here
-			// because the connection isn't shared yet (not stored in c.conn).
+			// Authenticate if token is set, then introduce ourselves. No
+			// write mutex needed here because the connection isn't shared
+			// yet (not stored in c.conn).
 			if c.token != "" {
 				auth := map[string]string{"type": "auth", "token": c.token}
 				if err := conn.WriteJSON(auth); err != nil {
@@ -109,6 +172,11 @@ func (c *WSClient) Listen(ctx context.Context) tea.Cmd {
 					continue
 				}
 			}
+			hello := map[string]string{"type": "hello", "name": c.viewerName, "kind": "tui"}
+			if err := conn.WriteJSON(hello); err != nil {
+				_ = conn.Close()
+				continue
+			}
 
 			// Cancel any previous ping goroutine.
 			c.mu.Lock()
@@ -117,7 +185,6 @@ func (c *WSClient) Listen(ctx context.Context) tea.Cmd {
 			}
 			pingCtx, pingCancel := context.WithCancel(ctx)
 			c.conn = conn
-			c.seq = 0
 			c.pingCtx = pingCancel
 			c.mu.Unlock()
 
@@ -227,44 +294,63 @@ func (c *WSClient) Seq() uint64 {
 }
 
 func (c *WSClient) dispatch(msg WSMessage) tea.Msg {
-	switch msg.Type {
-	case MsgSnapshot:
-		var p SnapshotPayload
-		if json.Unmarshal(msg.Payload, &p) == nil {
-			return WSSnapshotMsg{Payload: p}
-		}
-	case MsgDelta:
-		var p DeltaPayload
-		if json.Unmarshal(msg.Payload, &p) == nil {
-			return WSDeltaMsg{Payload: p}
-		}
-	case MsgCompletion:
-		var p CompletionPayload
-		if json.Unmarshal(msg.Payload, &p) == nil {
-			return WSCompletionMsg{Payload: p}
-		}
-	case MsgEquipped:
-		var p EquippedPayload
-		if json.Unmarshal(msg.Payload, &p) == nil {
-			return WSEquippedMsg{Payload: p}
-		}
-	case MsgAchievementUnlocked:
-		var p AchievementUnlockedPayload
-		if json.Unmarshal(msg.Payload, &p) == nil {
-			return WSAchievementMsg{Payload: p}
-		}
-	case MsgSourceHealth:
-		var p SourceHealthPayload
-		if json.Unmarshal(msg.Payload, &p) == nil {
-			return WSSourceHealthMsg{Payload: p}
+	v, err := sdk.Decode(msg)
+	if err != nil {
+		return nil
+	}
+	switch p := v.(type) {
+	case SnapshotPayload:
+		whole, ok := c.snapshots.Add(p)
+		if !ok {
+			return nil
 		}
-	case MsgBattlePassProgress:
-		var p BattlePassProgressPayload
-		if json.Unmarshal(msg.Payload, &p) == nil {
-			return WSBattlePassMsg{Payload: p}
+		return WSSnapshotMsg{Payload: whole}
+	case DeltaPayload:
+		return WSDeltaMsg{Payload: p}
+	case CompletionPayload:
+		return WSCompletionMsg{Payload: p}
+	case EquippedPayload:
+		return WSEquippedMsg{Payload: p}
+	case AchievementUnlockedPayload:
+		return WSAchievementMsg{Payload: p}
+	case AchievementBatchPayload:
+		return WSAchievementBatchMsg{Payload: p}
+	case SourceHealthPayload:
+		return WSSourceHealthMsg{Payload: p}
+	case BattlePassProgressPayload:
+		return WSBattlePassMsg{Payload: p}
+	case ServerShutdownPayload:
+		return WSServerShutdownMsg{Payload: p}
+	case UpdateAvailablePayload:
+		return WSUpdateAvailableMsg{Payload: p}
+	case PreferencesPayload:
+		return WSPreferencesMsg{Payload: p}
+	case PresencePayload:
+		return WSPresenceMsg{Payload: p}
+	case ReactionPayload:
+		return WSReactionMsg{Payload: p}
+	case SoundCuePayload:
+		return WSSoundCueMsg{Payload: p}
+	case LapCompletedPayload:
+		return WSLapCompletedMsg{Payload: p}
+	case HeatStandingsPayload:
+		return WSHeatStandingsMsg{Payload: p}
+	case PipelineUpdatePayload:
+		return WSPipelineUpdateMsg{Payload: p}
+	case CatchUpPayload:
+		out := WSCatchUpMsg{Payload: p}
+		for i := 0; i < len(p.Events); i++ {
+			var ev WSMessage
+			if json.Unmarshal(p.Events[i], &ev) != nil || ev.Type == MsgCatchUp {
+				continue
+			}
+			if m := c.dispatch(ev); m != nil {
+				out.Events = append(out.Events, m)
+			}
 		}
-	case MsgError:
-		return WSErrorMsg{Raw: msg.Payload}
+		return out
+	case json.RawMessage:
+		return WSErrorMsg{Raw: p}
 	}
 	return nil
 }
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
 	"testing"
 	"time"
@@ -43,6 +44,21 @@ func TestDispatchSnapshot(t *testing.T) {
 	}
 }
 
+func TestDispatchSnapshotPages(t *testing.T) {
+	c := NewWSClient("ws://localhost/ws", "", nil)
+	page := func(n int, id string) WSMessage {
+		payload, _ := json.Marshal(SnapshotPayload{Sessions: []*SessionState{{ID: id}}, Page: n, Pages: 2})
+		return WSMessage{Type: MsgSnapshot, Seq: 1, Payload: json.RawMessage(payload)}
+	}
+	if got := c.dispatch(page(1, "a")); got != nil {
+		t.Errorf("dispatch(first page) = %T, want nothing until the last", got)
+	}
+	got, ok := c.dispatch(page(2, "b")).(WSSnapshotMsg)
+	if !ok || len(got.Payload.Sessions) != 2 {
+		t.Errorf("dispatch(last page) = %+v, want both pages' sessions", got)
+	}
+}
+
 func TestDispatchDelta(t *testing.T) {
 	c := NewWSClient("ws://localhost/ws", "", nil)
 	payload, _ := json.Marshal(DeltaPayload{})
@@ -91,6 +107,20 @@ func TestDispatchAchievement(t *testing.T) {
 	}
 }
 
+func TestDispatchAchievementBatch(t *testing.T) {
+	c := NewWSClient("ws://localhost/ws", "", nil)
+	payload, _ := json.Marshal(AchievementBatchPayload{Achievements: []AchievementUnlockedPayload{{ID: "a1"}, {ID: "a2"}}})
+	msg := WSMessage{Type: MsgAchievementBatch, Payload: json.RawMessage(payload)}
+	got := c.dispatch(msg)
+	m, ok := got.(WSAchievementBatchMsg)
+	if !ok {
+		t.Fatalf("dispatch(achievement_batch) = %T, want WSAchievementBatchMsg", got)
+	}
+	if len(m.Payload.Achievements) != 2 {
+		t.Errorf("achievements = %+v, want 2", m.Payload.Achievements)
+	}
+}
+
 func TestDispatchSourceHealth(t *testing.T) {
 	c := NewWSClient("ws://localhost/ws", "", nil)
 	payload, _ := json.Marshal(SourceHealthPayload{Source: "claude", Status: StatusHealthy, Timestamp: time.Now()})
@@ -101,6 +131,118 @@ func TestDispatchSourceHealth(t *testing.T) {
 	}
 }
 
+func TestDispatchServerShutdown(t *testing.T) {
+	c := NewWSClient("ws://localhost/ws", "", nil)
+	payload, _ := json.Marshal(ServerShutdownPayload{Reason: "received terminated"})
+	msg := WSMessage{Type: MsgServerShutdown, Payload: json.RawMessage(payload)}
+	got := c.dispatch(msg)
+	m, ok := got.(WSServerShutdownMsg)
+	if !ok {
+		t.Fatalf("dispatch(server_shutdown) = %T, want WSServerShutdownMsg", got)
+	}
+	if m.Payload.Reason != "received terminated" {
+		t.Errorf("Reason = %q, want received terminated", m.Payload.Reason)
+	}
+}
+
+func TestDispatchUpdateAvailable(t *testing.T) {
+	c := NewWSClient("ws://localhost/ws", "", nil)
+	payload, _ := json.Marshal(UpdateAvailablePayload{Current: "v1.0.0", Latest: "v1.1.0"})
+	msg := WSMessage{Type: MsgUpdateAvailable, Payload: json.RawMessage(payload)}
+	got := c.dispatch(msg)
+	m, ok := got.(WSUpdateAvailableMsg)
+	if !ok {
+		t.Fatalf("dispatch(update_available) = %T, want WSUpdateAvailableMsg", got)
+	}
+	if m.Payload.Latest != "v1.1.0" {
+		t.Errorf("Latest = %q, want v1.1.0", m.Payload.Latest)
+	}
+}
+
+func TestDispatchSoundCue(t *testing.T) {
+	c := NewWSClient("ws://localhost/ws", "", nil)
+	msg := WSMessage{Type: MsgSoundCue, Payload: json.RawMessage(`{"cue":"finish","sessionId":"s1"}`)}
+	m, ok := c.dispatch(msg).(WSSoundCueMsg)
+	if !ok {
+		t.Fatalf("dispatch(sound_cue) = %T, want WSSoundCueMsg", c.dispatch(msg))
+	}
+	if m.Payload.Cue != "finish" || m.Payload.SessionID != "s1" {
+		t.Errorf("Payload = %+v", m.Payload)
+	}
+}
+
+func TestDispatchLapCompleted(t *testing.T) {
+	c := NewWSClient("ws://localhost/ws", "", nil)
+	msg := WSMessage{Type: MsgLapCompleted, Payload: json.RawMessage(`{"sessionId":"s1","name":"opus","lap":3}`)}
+	m, ok := c.dispatch(msg).(WSLapCompletedMsg)
+	if !ok {
+		t.Fatalf("dispatch(lap_completed) = %T, want WSLapCompletedMsg", c.dispatch(msg))
+	}
+	if m.Payload != (LapCompletedPayload{SessionID: "s1", Name: "opus", Lap: 3}) {
+		t.Errorf("Payload = %+v", m.Payload)
+	}
+}
+
+func TestDispatchHeatStandings(t *testing.T) {
+	c := NewWSClient("ws://localhost/ws", "", nil)
+	msg := WSMessage{Type: MsgHeatStandings, Payload: json.RawMessage(`{"id":"h1","name":"shootout","status":"finished","winnerId":"s2","standings":[{"sessionId":"s2","rank":1,"finished":true},{"sessionId":"s1","rank":2,"out":true}]}`)}
+	m, ok := c.dispatch(msg).(WSHeatStandingsMsg)
+	if !ok {
+		t.Fatalf("dispatch(heat_standings) = %T, want WSHeatStandingsMsg", c.dispatch(msg))
+	}
+	if m.Payload.WinnerID != "s2" || len(m.Payload.Standings) != 2 || !m.Payload.Standings[1].Out {
+		t.Errorf("Payload = %+v", m.Payload)
+	}
+}
+
+func TestDispatchPipelineUpdate(t *testing.T) {
+	c := NewWSClient("ws://localhost/ws", "", nil)
+	msg := WSMessage{Type: MsgPipelineUpdate, Payload: json.RawMessage(`{"id":"p1","pipeline":"relay","status":"running","leg":1,"stages":[{"template":"plan","status":"done"},{"template":"build","status":"running","sessionId":"claude:b"}]}`)}
+	m, ok := c.dispatch(msg).(WSPipelineUpdateMsg)
+	if !ok {
+		t.Fatalf("dispatch(pipeline_update) = %T, want WSPipelineUpdateMsg", c.dispatch(msg))
+	}
+	if m.Payload.Leg != 1 || len(m.Payload.Stages) != 2 || m.Payload.Stages[1].SessionID != "claude:b" {
+		t.Errorf("Payload = %+v", m.Payload)
+	}
+}
+
+func TestDispatchCatchUp(t *testing.T) {
+	c := NewWSClient("ws://localhost/ws", "", nil)
+	msg := WSMessage{Type: MsgCatchUp, Seq: 12, Payload: json.RawMessage(`{"since":9,"complete":true,"events":[` +
+		`{"type":"delta","seq":10,"payload":{"updates":[{"id":"s1"}]}},` +
+		`{"type":"completion","seq":11,"payload":{"sessionId":"s1","activity":"complete"}}]}`)}
+	m, ok := c.dispatch(msg).(WSCatchUpMsg)
+	if !ok {
+		t.Fatalf("dispatch(catch_up) = %T, want WSCatchUpMsg", c.dispatch(msg))
+	}
+	if m.Payload.Since != 9 || !m.Payload.Complete || len(m.Events) != 2 {
+		t.Fatalf("got since %d complete %v %d events", m.Payload.Since, m.Payload.Complete, len(m.Events))
+	}
+	if _, ok := m.Events[0].(WSDeltaMsg); !ok {
+		t.Errorf("Events[0] = %T, want WSDeltaMsg", m.Events[0])
+	}
+	if _, ok := m.Events[1].(WSCompletionMsg); !ok {
+		t.Errorf("Events[1] = %T, want WSCompletionMsg", m.Events[1])
+	}
+}
+
+func TestDialURLResumesFromLastSeq(t *testing.T) {
+	c := NewWSClient("wss://host:8080/ws?x=1", "", nil)
+	if got := c.dialURL(); got != "wss://host:8080/ws?page_size=100&x=1" {
+		t.Errorf("fresh dialURL = %q", got)
+	}
+	c.seq = 42
+	u, err := url.Parse(c.dialURL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	if q.Get("since") != "42" || !strings.HasPrefix(q.Get("client"), "tui@") || q.Get("x") != "1" || q.Get("page_size") != "100" {
+		t.Errorf("resume dialURL = %q", u)
+	}
+}
+
 func TestDispatchBattlePass(t *testing.T) {
 	c := NewWSClient("ws://localhost/ws", "", nil)
 	payload, _ := json.Marshal(BattlePassProgressPayload{XP: 100, Tier: 3})
@@ -76,6 +76,20 @@ func DefaultConfigPath() string {
 	return filepath.Join(dir, "agent-racer", "config.yaml")
 }
 
+// DefaultCacheDir returns the XDG cache directory for agent-racer, where
+// the prompt and tmux helpers keep their last answers.
+func DefaultCacheDir() string {
+	dir := os.Getenv("XDG_CACHE_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".cache")
+	}
+	return filepath.Join(dir, "agent-racer")
+}
+
 // WebSocketURL builds the WebSocket URL from host and port.
 func (c *Config) WebSocketURL() string {
 	scheme := "ws"
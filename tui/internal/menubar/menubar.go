@@ -0,0 +1,112 @@
+// Package menubar streams the race to a menu bar as a SwiftBar streamable
+// plugin, for people who won't keep a terminal open. Each update is a full
+// menu: a title line, then "---" and the dropdown, followed by "~~~" so
+// SwiftBar replaces the previous one.
+package menubar
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"strings"
+
+	sdk "github.com/agent-racer/backend/pkg/client"
+	"github.com/agent-racer/tui/internal/racefeed"
+)
+
+// maxFinished is how many finished sessions the dropdown lists.
+const maxFinished = 5
+
+// separator ends one menu in SwiftBar's streamable format.
+const separator = "~~~"
+
+// Options configure Run.
+type Options struct {
+	URL   string // WebSocket URL, e.g. ws://127.0.0.1:8080/ws
+	Token string
+	TLS   *tls.Config
+	// Dashboard is the web dashboard's URL, linked from the dropdown.
+	Dashboard string
+}
+
+// Run connects to the server and writes a menu to w whenever the race
+// changes, reconnecting until ctx is done.
+func Run(ctx context.Context, w io.Writer, opts Options) error {
+	view := racefeed.View{
+		Render: func(sessions map[string]*sdk.SessionState) string {
+			return Render(sessions, opts.Dashboard)
+		},
+		Disconnected: disconnected,
+	}
+	return racefeed.Run(ctx, racefeed.Options{URL: opts.URL, Token: opts.Token, TLS: opts.TLS, Kind: "menubar"}, view,
+		func(menu string) error {
+			_, err := io.WriteString(w, menu+separator+"\n")
+			return err
+		})
+}
+
+// Render draws one menu: the title counts running sessions and flags any
+// waiting on approval or errored; the dropdown lists running sessions in
+// race order, then the most recently finished.
+func Render(sessions map[string]*sdk.SessionState, dashboard string) string {
+	running, finished := racefeed.Split(sessions)
+	approvals, errored := racefeed.Alerts(sessions)
+
+	var b strings.Builder
+	title := fmt.Sprintf("🏁 %d", len(running))
+	if approvals > 0 {
+		title += fmt.Sprintf(" ✋%d", approvals)
+	}
+	if errored > 0 {
+		title += fmt.Sprintf(" ✖%d", errored)
+	}
+	b.WriteString(title + "\n---\n")
+
+	if len(running) == 0 {
+		b.WriteString("No sessions racing | color=gray\n")
+	}
+	for i := 0; i < len(running); i++ {
+		writeSession(&b, running[i])
+	}
+	if len(finished) > 0 {
+		b.WriteString("---\nFinished | color=gray\n")
+		for i := 0; i < len(finished) && i < maxFinished; i++ {
+			writeSession(&b, finished[i])
+		}
+	}
+	if dashboard != "" {
+		fmt.Fprintf(&b, "---\nOpen dashboard | href=%s\n", dashboard)
+	}
+	return b.String()
+}
+
+// writeSession adds s as a dropdown item with its details in a submenu.
+func writeSession(b *strings.Builder, s *sdk.SessionState) {
+	fmt.Fprintf(b, "%s %s — %s", racefeed.Icon(s.Activity), menuText(s.Name), strings.ReplaceAll(string(s.Activity), "_", " "))
+	if s.MaxContextTokens > 0 {
+		fmt.Fprintf(b, " · %d%%", int(s.ContextUtilization*100))
+	}
+	b.WriteString("\n")
+	if s.Model != "" {
+		b.WriteString("--" + menuText(s.Model) + "\n")
+	}
+	if s.WorkingDir != "" {
+		b.WriteString("--" + menuText(s.WorkingDir) + "\n")
+	}
+	if s.CurrentTool != "" {
+		b.WriteString("--Tool: " + menuText(s.CurrentTool) + "\n")
+	}
+}
+
+// menuText keeps s on one menu line: "|" would start SwiftBar parameters,
+// and a leading "-" would nest the item.
+func menuText(s string) string {
+	s = strings.NewReplacer("|", "¦", "\n", " ", "\r", " ").Replace(s)
+	return strings.TrimLeft(s, "-")
+}
+
+// disconnected is the menu shown while the server can't be reached.
+func disconnected(err error) string {
+	return fmt.Sprintf("🏁 ⨯ | color=gray\n---\nCan't reach Agent Racer | color=gray\n--%s\n", menuText(err.Error()))
+}
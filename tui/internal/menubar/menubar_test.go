@@ -0,0 +1,87 @@
+package menubar
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	sdk "github.com/agent-racer/backend/pkg/client"
+	"github.com/agent-racer/tui/internal/testutil"
+	"github.com/gorilla/websocket"
+)
+
+func TestRender(t *testing.T) {
+	done := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	sessions := map[string]*sdk.SessionState{
+		"b": {ID: "b", Name: "second", Activity: sdk.ActivityNeedsApproval, Position: 2},
+		"a": {ID: "a", Name: "first | fast", Activity: sdk.ActivityToolUse, Position: 1, Model: "opus",
+			MaxContextTokens: 200000, ContextUtilization: 0.42, CurrentTool: "Bash"},
+		"c": {ID: "c", Name: "broken", Activity: sdk.ActivityErrored, CompletedAt: &done},
+	}
+
+	got := Render(sessions, "http://127.0.0.1:8080")
+	want := "🏁 2 ✋1 ✖1\n" +
+		"---\n" +
+		"⚙ first ¦ fast — tool use · 42%\n" +
+		"--opus\n" +
+		"--Tool: Bash\n" +
+		"✋ second — needs approval\n" +
+		"---\n" +
+		"Finished | color=gray\n" +
+		"✖ broken — errored\n" +
+		"---\n" +
+		"Open dashboard | href=http://127.0.0.1:8080\n"
+	if got != want {
+		t.Errorf("Render() =\n%s\nwant\n%s", got, want)
+	}
+
+	if empty := Render(nil, ""); empty != "🏁 0\n---\nNo sessions racing | color=gray\n" {
+		t.Errorf("Render(nil) = %q", empty)
+	}
+}
+
+func TestRun_StreamsMenus(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		_ = conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"snapshot","seq":1,"payload":{"sessions":[{"id":"a","name":"alpha","activity":"thinking"}]}}`))
+		_ = conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"delta","seq":2,"payload":{"updates":[{"id":"b","name":"beta","activity":"idle"}]}}`))
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	defer srv.Close()
+
+	var out testutil.SyncBuffer
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() {
+		errc <- Run(ctx, &out, Options{URL: "ws" + strings.TrimPrefix(srv.URL, "http")})
+	}()
+
+	deadline := time.Now().Add(3 * time.Second)
+	for !strings.Contains(out.String(), separator) && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+	}
+	cancel()
+	if err := <-errc; err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	menu, _, ok := strings.Cut(out.String(), separator+"\n")
+	if !ok {
+		t.Fatalf("no menu written: %q", out.String())
+	}
+	if !strings.HasPrefix(menu, "🏁 2\n") || !strings.Contains(menu, "● alpha — thinking") || !strings.Contains(menu, "○ beta — idle") {
+		t.Errorf("menu =\n%s", menu)
+	}
+}
@@ -0,0 +1,105 @@
+// Package notify raises terminal notifications through OSC escape
+// sequences. The terminal shows them, so they reach the desktop the user
+// is sitting at even when the TUI runs over SSH, where desktop
+// notification APIs can't.
+package notify
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/agent-racer/tui/internal/client"
+)
+
+// Format is the OSC sequence a terminal understands.
+type Format string
+
+const (
+	// OSC9 is "ESC ] 9 ; text BEL": iTerm2, WezTerm, Windows Terminal,
+	// kitty, ghostty and others.
+	OSC9 Format = "9"
+	// OSC777 is "ESC ] 777 ; notify ; title ; text BEL": urxvt, foot and
+	// VTE terminals such as GNOME Terminal.
+	OSC777 Format = "777"
+)
+
+// messages says what each state a notification can be raised for means,
+// after the session's name.
+var messages = map[client.Activity]string{
+	client.ActivityWaiting:       "is waiting for input",
+	client.ActivityNeedsApproval: "needs approval",
+	client.ActivityIdle:          "is idle",
+	client.ActivityComplete:      "finished",
+	client.ActivityErrored:       "errored",
+	client.ActivityLost:          "was lost",
+}
+
+// Notifier writes a notification when a session enters one of the
+// configured states.
+type Notifier struct {
+	w      io.Writer
+	format Format
+	states map[client.Activity]bool
+	// tmux wraps sequences in tmux's passthrough, which needs
+	// "set -g allow-passthrough on".
+	tmux bool
+}
+
+// New returns a Notifier writing to w, raising notifications for the
+// comma-separated states. It rejects unknown states and formats.
+func New(w io.Writer, format Format, states string, tmux bool) (*Notifier, error) {
+	if format != OSC9 && format != OSC777 {
+		return nil, fmt.Errorf("unknown notification format %q: use 9 or 777", format)
+	}
+	n := &Notifier{w: w, format: format, states: make(map[client.Activity]bool), tmux: tmux}
+	for _, name := range strings.Split(states, ",") {
+		a := client.Activity(strings.TrimSpace(name))
+		if a == "" {
+			continue
+		}
+		if _, ok := messages[a]; !ok {
+			return nil, fmt.Errorf("can't notify on %q: use waiting, needs_approval, idle, complete, errored or lost", a)
+		}
+		n.states[a] = true
+	}
+	return n, nil
+}
+
+// Observe raises a notification if s has just entered a configured state.
+// prev is the session as it was before, or nil for a new session.
+func (n *Notifier) Observe(prev client.Activity, s *client.SessionState) {
+	if n == nil || s.Activity == prev || !n.states[s.Activity] {
+		return
+	}
+	name := s.Name
+	if name == "" {
+		name = s.ID
+	}
+	_, _ = io.WriteString(n.w, n.sequence("Agent Racer", name+" "+messages[s.Activity]))
+}
+
+// sequence encodes a notification in the configured format.
+func (n *Notifier) sequence(title, body string) string {
+	var seq string
+	if n.format == OSC777 {
+		seq = "\x1b]777;notify;" + clean(title, true) + ";" + clean(body, false) + "\x07"
+	} else {
+		seq = "\x1b]9;" + clean(title+": "+body, false) + "\x07"
+	}
+	if n.tmux {
+		seq = "\x1bPtmux;" + strings.ReplaceAll(seq, "\x1b", "\x1b\x1b") + "\x1b\\"
+	}
+	return seq
+}
+
+// clean drops control characters, which would end the sequence early,
+// and in a 777 title the ";" that separates it from the body.
+func clean(s string, title bool) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || (r >= 0x80 && r < 0xa0) || (title && r == ';') {
+			return -1
+		}
+		return r
+	}, s)
+}
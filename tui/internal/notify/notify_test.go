@@ -0,0 +1,48 @@
+package notify
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/agent-racer/tui/internal/client"
+)
+
+func TestObserve(t *testing.T) {
+	var buf bytes.Buffer
+	n, err := New(&buf, OSC9, "waiting, complete", false)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	n.Observe(client.ActivityThinking, &client.SessionState{ID: "a", Name: "api\x07", Activity: client.ActivityWaiting})
+	if got, want := buf.String(), "\x1b]9;Agent Racer: api is waiting for input\x07"; got != want {
+		t.Errorf("waiting = %q, want %q", got, want)
+	}
+
+	buf.Reset()
+	n.Observe(client.ActivityWaiting, &client.SessionState{ID: "a", Activity: client.ActivityWaiting}) // no change
+	n.Observe(client.ActivityWaiting, &client.SessionState{ID: "a", Activity: client.ActivityErrored}) // not configured
+	if buf.Len() != 0 {
+		t.Errorf("unexpected notification %q", buf.String())
+	}
+
+	var nilNotifier *Notifier
+	nilNotifier.Observe("", &client.SessionState{Activity: client.ActivityComplete})
+}
+
+func TestSequenceFormats(t *testing.T) {
+	var buf bytes.Buffer
+	n, _ := New(&buf, OSC777, "complete", true)
+	n.Observe(client.ActivityThinking, &client.SessionState{ID: "a", Name: "web", Activity: client.ActivityComplete})
+	want := "\x1bPtmux;\x1b\x1b]777;notify;Agent Racer;web finished\x07\x1b\\"
+	if buf.String() != want {
+		t.Errorf("777 in tmux = %q, want %q", buf.String(), want)
+	}
+
+	if _, err := New(&buf, "99", "complete", false); err == nil {
+		t.Error("New accepted format 99")
+	}
+	if _, err := New(&buf, OSC9, "complete,thinking", false); err == nil {
+		t.Error("New accepted state thinking")
+	}
+}
@@ -0,0 +1,231 @@
+// Package racefeed follows the race over the WebSocket stream for the
+// panel widgets, the menu bar and waybar, which redraw the whole race
+// whenever it changes rather than keeping a UI of their own.
+package racefeed
+
+import (
+	"context"
+	"crypto/tls"
+	"os"
+	"sort"
+	"time"
+
+	sdk "github.com/agent-racer/backend/pkg/client"
+)
+
+const (
+	// renderInterval batches deltas; the widget is redrawn at most this
+	// often.
+	renderInterval = time.Second
+
+	reconnectBaseDelay = time.Second
+	reconnectMaxDelay  = 30 * time.Second
+
+	// snapshotPageSize splits snapshots from busy servers into several
+	// messages. Widgets never show subagents, so they are left out too.
+	snapshotPageSize = 100
+)
+
+// Options configure Run.
+type Options struct {
+	URL   string // WebSocket URL, e.g. ws://127.0.0.1:8080/ws
+	Token string
+	TLS   *tls.Config
+	// Kind is the client kind announced in the hello, e.g. "menubar".
+	Kind string
+}
+
+// View turns the race into the text a widget shows.
+type View struct {
+	// Render draws the sessions, keyed by ID.
+	Render func(sessions map[string]*sdk.SessionState) string
+	// Disconnected draws the widget while the server can't be reached.
+	Disconnected func(err error) string
+}
+
+// event is what the read loop hands to stream.
+type event struct {
+	payload any
+	err     error
+}
+
+// Run connects to the server and hands write what view draws whenever it
+// changes, reconnecting until ctx is done. It only returns early when
+// write fails.
+func Run(ctx context.Context, opts Options, view View, write func(string) error) error {
+	name := os.Getenv("USER")
+	delay := reconnectBaseDelay
+	last := ""
+	emit := func(out string) error {
+		if out == last {
+			return nil
+		}
+		last = out
+		return write(out)
+	}
+
+	for ctx.Err() == nil {
+		conn, err := sdk.Dial(ctx, opts.URL, sdk.DialOptions{
+			Token:            opts.Token,
+			TLS:              opts.TLS,
+			SnapshotPageSize: snapshotPageSize,
+			LazySubagents:    true,
+		})
+		if err != nil {
+			if werr := emit(view.Disconnected(err)); werr != nil {
+				return werr
+			}
+			select {
+			case <-ctx.Done():
+			case <-time.After(delay):
+			}
+			delay = min(delay*2, reconnectMaxDelay)
+			continue
+		}
+		delay = reconnectBaseDelay
+		_ = conn.Hello(name, opts.Kind)
+
+		err = stream(ctx, conn, view, emit)
+		_ = conn.Close()
+		if err != nil && ctx.Err() == nil {
+			if werr := emit(view.Disconnected(err)); werr != nil {
+				return werr
+			}
+		}
+	}
+	return nil
+}
+
+// stream renders conn's messages until it fails or ctx is done.
+func stream(ctx context.Context, conn *sdk.Conn, view View, emit func(string) error) error {
+	events := make(chan event, 16)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			var ev event
+			msg, err := conn.Read()
+			if err != nil {
+				ev.err = err
+			} else if ev.payload, err = sdk.Decode(msg); err != nil || ev.payload == nil {
+				continue
+			}
+			select {
+			case events <- ev:
+			case <-done:
+				return
+			}
+			if ev.err != nil {
+				return
+			}
+		}
+	}()
+
+	var snapshots sdk.SnapshotAssembler
+	sessions := make(map[string]*sdk.SessionState)
+	dirty := false
+	ticker := time.NewTicker(renderInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case ev := <-events:
+			if ev.err != nil {
+				return ev.err
+			}
+			switch p := ev.payload.(type) {
+			case sdk.SnapshotPayload:
+				p, ok := snapshots.Add(p)
+				if !ok {
+					continue
+				}
+				clear(sessions)
+				for i := 0; i < len(p.Sessions); i++ {
+					sessions[p.Sessions[i].ID] = p.Sessions[i]
+				}
+				dirty = true
+			case sdk.DeltaPayload:
+				for i := 0; i < len(p.Updates); i++ {
+					sessions[p.Updates[i].ID] = p.Updates[i]
+				}
+				for i := 0; i < len(p.Removed); i++ {
+					delete(sessions, p.Removed[i])
+				}
+				dirty = true
+			}
+		case <-ticker.C:
+			if !dirty {
+				continue
+			}
+			dirty = false
+			if err := emit(view.Render(sessions)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// activityIcons mark each session's activity.
+var activityIcons = map[sdk.Activity]string{
+	sdk.ActivityStarting:      "◌",
+	sdk.ActivityThinking:      "●",
+	sdk.ActivityToolUse:       "⚙",
+	sdk.ActivityWaiting:       "…",
+	sdk.ActivityIdle:          "○",
+	sdk.ActivityComplete:      "✓",
+	sdk.ActivityErrored:       "✖",
+	sdk.ActivityLost:          "?",
+	sdk.ActivityNeedsApproval: "✋",
+}
+
+// Icon returns the glyph for activity a.
+func Icon(a sdk.Activity) string {
+	if icon := activityIcons[a]; icon != "" {
+		return icon
+	}
+	return "·"
+}
+
+// Split divides sessions into running ones in race order and finished
+// ones, most recently finished first.
+func Split(sessions map[string]*sdk.SessionState) (running, finished []*sdk.SessionState) {
+	for _, s := range sessions {
+		if s.Activity.IsTerminal() {
+			finished = append(finished, s)
+		} else {
+			running = append(running, s)
+		}
+	}
+	sort.Slice(running, func(i, j int) bool {
+		pi, pj := running[i].Position, running[j].Position
+		if (pi > 0) != (pj > 0) {
+			return pi > 0
+		}
+		if pi != pj {
+			return pi < pj
+		}
+		return running[i].ID < running[j].ID
+	})
+	sort.Slice(finished, func(i, j int) bool {
+		ci, cj := finished[i].CompletedAt, finished[j].CompletedAt
+		if ci != nil && cj != nil && !ci.Equal(*cj) {
+			return ci.After(*cj)
+		}
+		return finished[i].ID < finished[j].ID
+	})
+	return running, finished
+}
+
+// Alerts counts the sessions waiting on approval and those that errored.
+func Alerts(sessions map[string]*sdk.SessionState) (approvals, errored int) {
+	for _, s := range sessions {
+		switch s.Activity {
+		case sdk.ActivityNeedsApproval:
+			approvals++
+		case sdk.ActivityErrored:
+			errored++
+		}
+	}
+	return approvals, errored
+}
@@ -0,0 +1,92 @@
+package racefeed
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	sdk "github.com/agent-racer/backend/pkg/client"
+	"github.com/gorilla/websocket"
+)
+
+// names draws the sessions as their sorted names.
+func names(sessions map[string]*sdk.SessionState) string {
+	out := make([]string, 0, len(sessions))
+	for _, s := range sessions {
+		out = append(out, s.Name)
+	}
+	sort.Strings(out)
+	return strings.Join(out, " ")
+}
+
+func TestRun_EmitsChangedLines(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		lines []string
+	)
+	emitted := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), lines...)
+	}
+	rendered := make(chan struct{})
+
+	// The first connection gets a snapshot and a delta and is dropped once
+	// they are drawn; every later one is refused.
+	var conns atomic.Int32
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if conns.Add(1) > 1 {
+			http.Error(w, "gone", http.StatusServiceUnavailable)
+			return
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		_ = conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"snapshot","seq":1,"payload":{"sessions":[{"id":"a","name":"alpha","activity":"thinking"}]}}`))
+		_ = conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"delta","seq":2,"payload":{"updates":[{"id":"b","name":"beta","activity":"idle"}]}}`))
+		<-rendered
+	}))
+	defer srv.Close()
+
+	view := View{
+		Render:       names,
+		Disconnected: func(error) string { return "offline" },
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() {
+		errc <- Run(ctx, Options{URL: "ws" + strings.TrimPrefix(srv.URL, "http"), Kind: "test"}, view, func(s string) error {
+			mu.Lock()
+			lines = append(lines, s)
+			mu.Unlock()
+			if s == "alpha beta" {
+				close(rendered)
+			}
+			return nil
+		})
+	}()
+
+	// Wait for a refused reconnect, which must not repeat "offline".
+	deadline := time.Now().Add(5 * time.Second)
+	for conns.Load() < 2 && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+	}
+	cancel()
+	if err := <-errc; err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	got := emitted()
+	if len(got) != 2 || got[0] != "alpha beta" || got[1] != "offline" {
+		t.Errorf("emitted %q, want [alpha beta offline]", got)
+	}
+}
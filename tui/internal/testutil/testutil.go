@@ -0,0 +1,26 @@
+// Package testutil holds helpers shared by the TUI's tests.
+package testutil
+
+import (
+	"strings"
+	"sync"
+)
+
+// SyncBuffer collects a writer's output so a test can read it while the
+// writer is still running.
+type SyncBuffer struct {
+	mu sync.Mutex
+	sb strings.Builder
+}
+
+func (b *SyncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.sb.Write(p)
+}
+
+func (b *SyncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.sb.String()
+}
@@ -22,6 +22,7 @@ var (
 	ColorThinking = lipgloss.Color("#2563eb")
 	ColorToolUse  = lipgloss.Color("#d97706")
 	ColorWaiting  = lipgloss.Color("#854d0e")
+	ColorApproval = lipgloss.Color("#e11d48")
 	ColorIdle     = lipgloss.Color("#4b5563")
 	ColorStarting = lipgloss.Color("#7c3aed")
 	ColorComplete = lipgloss.Color("#16a34a")
@@ -93,6 +94,8 @@ func ActivityColor(activity string) lipgloss.Color {
 		return ColorToolUse
 	case "waiting":
 		return ColorWaiting
+	case "needs_approval":
+		return ColorApproval
 	case "idle":
 		return ColorIdle
 	case "starting":
@@ -189,6 +192,8 @@ func ActivityGlyph(activity string) string {
 		return "○"
 	case "waiting":
 		return "◌"
+	case "needs_approval":
+		return "!"
 	case "starting":
 		return "◎"
 	case "complete":
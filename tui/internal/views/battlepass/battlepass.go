@@ -1,6 +1,6 @@
 // Package battlepass provides the Battle Pass overlay and collapsed bar for
 // the Agent Racer TUI. It renders season progress, tier track, weekly
-// challenges, and a recent XP log.
+// challenges, a recent XP log and past seasons.
 package battlepass
 
 import (
@@ -17,6 +17,7 @@ const (
 	barWidthExpanded  = 30
 	maxRecentXP       = 8
 	maxChallenges     = 8
+	maxPastSeasons    = 5
 	visibleTiers      = 9
 )
 
@@ -28,6 +29,7 @@ type Model struct {
 	TierProgress float64 // 0.0–1.0 within current tier
 	RecentXP     []client.XPEntry
 	Challenges   []client.ChallengeProgress
+	PastSeasons  []client.ArchivedSeason // newest first
 	Width        int
 	SpinnerView  string // animated spinner provided by the root app
 	loading      bool   // true until the initial HTTP fetch completes
@@ -58,6 +60,11 @@ func (m *Model) SetChallenges(cs []client.ChallengeProgress) {
 	m.Challenges = cs
 }
 
+// SetSeasons replaces the archived seasons, newest first.
+func (m *Model) SetSeasons(s []client.ArchivedSeason) {
+	m.PastSeasons = s
+}
+
 // SetFromStats seeds initial state from the /api/stats response.
 func (m *Model) SetFromStats(season string, tier, xp int) {
 	if season != "" {
@@ -189,6 +196,21 @@ func (m Model) View() string {
 		}
 	}
 
+	// Past seasons.
+	if len(m.PastSeasons) > 0 {
+		sb.WriteString("\n")
+		sb.WriteString(theme.StyleHeader.Render("Past Seasons"))
+		sb.WriteString("\n")
+		limit := len(m.PastSeasons)
+		if limit > maxPastSeasons {
+			limit = maxPastSeasons
+		}
+		for i := 0; i < limit; i++ {
+			sb.WriteString(renderPastSeason(m.PastSeasons[i]))
+			sb.WriteString("\n")
+		}
+	}
+
 	// Dismiss hint.
 	sb.WriteString("\n")
 	sb.WriteString(theme.StyleDimmed.Render("[esc] close"))
@@ -309,6 +331,31 @@ func renderXPEntry(e client.XPEntry) string {
 	return "  " + amountStr + "  " + e.Reason
 }
 
+// renderPastSeason renders one archived season: its final tier and XP, and
+// what it counted when the server kept that.
+func renderPastSeason(s client.ArchivedSeason) string {
+	season := lipgloss.NewStyle().Foreground(theme.ColorBright).Render(fmt.Sprintf("%-10s", s.Season))
+	tier := lipgloss.NewStyle().Foreground(theme.ColorGold).Render(fmt.Sprintf("Tier %-2d", s.Tier))
+	line := "  " + season + "  " + tier + "  " + fmt.Sprintf("%6d XP", s.XP)
+	if t := s.Totals; t != nil {
+		line += theme.StyleDimmed.Render(fmt.Sprintf("  %d sessions · %d completed · %s tokens",
+			t.Sessions, t.Completions, formatCount(t.TokensBurned)))
+	}
+	return line
+}
+
+// formatCount formats large numbers with K/M suffixes.
+func formatCount(n int) string {
+	switch {
+	case n >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(n)/1_000_000)
+	case n >= 1_000:
+		return fmt.Sprintf("%.1fK", float64(n)/1_000)
+	default:
+		return fmt.Sprintf("%d", n)
+	}
+}
+
 // renderBar renders a filled/empty ASCII progress bar using block characters.
 func renderBar(fill, total int) string {
 	if total <= 0 {
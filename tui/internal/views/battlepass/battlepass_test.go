@@ -223,6 +223,30 @@ func TestView_WithData(t *testing.T) {
 	}
 }
 
+func TestView_PastSeasons(t *testing.T) {
+	m := New()
+	m.SetLoaded()
+	m.Width = 120
+	m.Tier = 1
+	if view := m.View(); strings.Contains(view, "Past Seasons") {
+		t.Error("should not show past seasons before there are any")
+	}
+
+	m.SetSeasons([]client.ArchivedSeason{
+		{Season: "2026-02", Tier: 6, XP: 5100, Totals: &client.SeasonTotals{Sessions: 40, Completions: 38, TokensBurned: 1_250_000}},
+		{Season: "2026-01", Tier: 4, XP: 3300},
+	})
+	view := m.View()
+	for _, want := range []string{"Past Seasons", "2026-02", "Tier 6", "5100 XP", "40 sessions · 38 completed · 1.2M tokens", "2026-01"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view lacks %q:\n%s", want, view)
+		}
+	}
+	if strings.Count(view, "sessions ·") != 1 {
+		t.Error("a season without totals should show none")
+	}
+}
+
 func TestView_NoChallenges(t *testing.T) {
 	m := New()
 	m.SetLoaded()
@@ -64,14 +64,25 @@ func New() Model {
 }
 
 // SetSessions updates the session list. The dashboard sorts its own copy
-// for the leaderboard so callers need not pre-sort.
+// for the leaderboard so callers need not pre-sort: racing sessions in the
+// server's position order, then the rest by context utilization.
 func (m *Model) SetSessions(sessions map[string]*client.SessionState) {
 	m.sessions = make([]*client.SessionState, 0, len(sessions))
 	for _, s := range sessions {
 		m.sessions = append(m.sessions, s)
 	}
 	sort.Slice(m.sessions, func(i, j int) bool {
-		return m.sessions[i].ContextUtilization > m.sessions[j].ContextUtilization
+		a, b := m.sessions[i], m.sessions[j]
+		if (a.Position > 0) != (b.Position > 0) {
+			return a.Position > 0
+		}
+		if a.Position != b.Position {
+			return a.Position < b.Position
+		}
+		if a.ContextUtilization != b.ContextUtilization {
+			return a.ContextUtilization > b.ContextUtilization
+		}
+		return a.ID < b.ID
 	})
 
 	rows := make([]bubbletable.Row, 0, len(m.sessions))
@@ -58,3 +58,18 @@ func TestLeaderboardHidesActivityForTerminalSessions(t *testing.T) {
 		}
 	}
 }
+
+func TestLeaderboardFollowsServerPositions(t *testing.T) {
+	m := New()
+	m.Width = 120
+	m.SetSessions(map[string]*client.SessionState{
+		"a": {ID: "a", Name: "alpha", Activity: client.ActivityThinking, ContextUtilization: 0.9, Position: 2},
+		"b": {ID: "b", Name: "bravo", Activity: client.ActivityThinking, ContextUtilization: 0.1, Position: 1},
+		"c": {ID: "c", Name: "charlie", Activity: client.ActivityComplete, ContextUtilization: 1},
+	})
+
+	got := []string{m.sessions[0].ID, m.sessions[1].ID, m.sessions[2].ID}
+	if strings.Join(got, "") != "bac" {
+		t.Errorf("leaderboard order = %v, want [b a c]", got)
+	}
+}
@@ -3,6 +3,7 @@ package detail
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -109,6 +110,9 @@ func (m Model) renderInner(s *client.SessionState) string {
 	b.WriteString(strings.Repeat("─", panelWidth-4) + "\n")
 
 	// Identity.
+	if s.Topic != "" {
+		writeRow(&b, "Topic", truncate(s.Topic, 40))
+	}
 	writeRow(&b, "ID", truncate(s.ID, 36))
 	writeRow(&b, "Source", theme.SourceBadge(s.Source)+" "+s.Source)
 	writeRow(&b, "Model", lipgloss.NewStyle().Foreground(theme.ModelColor(s.Model)).Render(s.Model))
@@ -119,6 +123,9 @@ func (m Model) renderInner(s *client.SessionState) string {
 	if s.CurrentTool != "" {
 		writeRow(&b, "Tool", s.CurrentTool)
 	}
+	if s.LastCommand != "" {
+		writeRow(&b, "Command", s.LastCommand)
+	}
 
 	b.WriteString("\n")
 
@@ -139,9 +146,18 @@ func (m Model) renderInner(s *client.SessionState) string {
 	if s.BurnRatePerMinute > 0 {
 		writeRow(&b, "Burn Rate", fmt.Sprintf("%.0f tok/min", s.BurnRatePerMinute))
 	}
+	if !s.CompactionETA.IsZero() {
+		writeRow(&b, "Compaction", formatCountdown(s.CompactionETA))
+	}
 
 	writeRow(&b, "Messages", fmt.Sprintf("%d msgs  %d tool calls  %d compactions",
 		s.MessageCount, s.ToolCallCount, s.CompactionCount))
+	if len(s.MCPToolCalls) > 0 {
+		writeRow(&b, "MCP Servers", formatCounts(s.MCPToolCalls))
+	}
+	if len(s.Reactions) > 0 {
+		writeRow(&b, "Reactions", formatCounts(s.Reactions))
+	}
 
 	b.WriteString("\n")
 
@@ -152,6 +168,12 @@ func (m Model) renderInner(s *client.SessionState) string {
 	if s.WorkingDir != "" {
 		writeRow(&b, "Working Dir", truncate(s.WorkingDir, 40))
 	}
+	if s.IssueURL != "" {
+		writeRow(&b, "Issue", s.IssueURL)
+	}
+	if s.PRURL != "" {
+		writeRow(&b, "PR", s.PRURL)
+	}
 	if s.TmuxTarget != "" {
 		writeRow(&b, "Tmux", s.TmuxTarget)
 	}
@@ -171,6 +193,9 @@ func (m Model) renderInner(s *client.SessionState) string {
 	if s.CompletedAt != nil {
 		writeRow(&b, "Completed", formatAge(*s.CompletedAt))
 	}
+	if s.Outcome != "" {
+		writeRow(&b, "Outcome", strings.ReplaceAll(s.Outcome, "_", " "))
+	}
 
 	// Subagents.
 	if len(s.Subagents) > 0 {
@@ -233,7 +258,14 @@ func renderSubagent(sa client.SubagentState) string {
 		slug = slug[:19] + "…"
 	}
 
-	detail := fmt.Sprintf("  %s %-22s %s  tok:%-6s msg:%d",
+	// Nested subagents sit under the one that spawned them.
+	indent := ""
+	if sa.Depth > 1 {
+		indent = strings.Repeat("  ", sa.Depth-1) + "└ "
+	}
+
+	detail := fmt.Sprintf("  %s%s %-22s %s  tok:%-6s msg:%d",
+		indent,
 		glyph,
 		lipgloss.NewStyle().Foreground(theme.ModelColor(sa.Model)).Render(slug),
 		actStr,
@@ -243,6 +275,9 @@ func renderSubagent(sa client.SubagentState) string {
 	if sa.CurrentTool != "" {
 		detail += "  [" + sa.CurrentTool + "]"
 	}
+	if sa.OverBudget {
+		detail += "  " + lipgloss.NewStyle().Foreground(theme.ColorWarning).Render("⚠ over budget")
+	}
 	return detail
 }
 
@@ -263,9 +298,13 @@ func renderBar(pct float64, width int, color lipgloss.Color) string {
 	return lipgloss.NewStyle().Foreground(color).Render(bar)
 }
 
-// DisplayName returns a human-readable label for a session, preferring
+// DisplayName returns a human-readable label for a session. Worktree
+// sessions render as "project (worktree: label)"; otherwise it prefers
 // Name, then Slug, then a truncated ID.
 func DisplayName(s *client.SessionState) string {
+	if s.Project != "" && s.Worktree != "" {
+		return s.Project + " (worktree: " + s.Worktree + ")"
+	}
 	if s.Name != "" {
 		return s.Name
 	}
@@ -295,6 +334,39 @@ func formatTokens(n int) string {
 	return fmt.Sprintf("%d", n)
 }
 
+// formatCounts renders counts such as MCP calls per server or reactions
+// per emoji, highest first.
+func formatCounts(counts map[string]int) string {
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	parts := make([]string, len(keys))
+	for i := 0; i < len(keys); i++ {
+		parts[i] = fmt.Sprintf("%s %d", keys[i], counts[keys[i]])
+	}
+	return strings.Join(parts, "  ")
+}
+
+// formatCountdown renders the time left until t, or "due" once it passes.
+func formatCountdown(t time.Time) string {
+	d := time.Until(t).Round(time.Second)
+	switch {
+	case d <= 0:
+		return "due"
+	case d < time.Hour:
+		return fmt.Sprintf("in %dm %02ds", int(d.Minutes()), int(d.Seconds())%60)
+	default:
+		return fmt.Sprintf("in %dh %02dm", int(d.Hours()), int(d.Minutes())%60)
+	}
+}
+
 func formatAge(t time.Time) string {
 	d := time.Since(t)
 	switch {
@@ -141,6 +141,27 @@ func TestView_Subagents(t *testing.T) {
 	}
 }
 
+func TestRenderSubagent_OverBudget(t *testing.T) {
+	if got := renderSubagent(client.SubagentState{ID: "sub-1", Slug: "lean"}); strings.Contains(got, "over budget") {
+		t.Errorf("subagent within budget should not be marked: %q", got)
+	}
+	if got := renderSubagent(client.SubagentState{ID: "sub-2", Slug: "greedy", OverBudget: true}); !strings.Contains(got, "over budget") {
+		t.Errorf("subagent over budget should be marked: %q", got)
+	}
+}
+
+func TestRenderSubagent_IndentsNested(t *testing.T) {
+	top := renderSubagent(client.SubagentState{ID: "sub-1", Slug: "planner", Depth: 1})
+	nested := renderSubagent(client.SubagentState{ID: "sub-2", Slug: "searcher", ParentID: "sub-1", Depth: 3})
+
+	if strings.Contains(top, "└") {
+		t.Errorf("top-level subagent should not be indented: %q", top)
+	}
+	if !strings.Contains(nested, "    └ ") {
+		t.Errorf("depth 3 subagent should be indented twice: %q", nested)
+	}
+}
+
 func TestView_FocusError(t *testing.T) {
 	s := makeSession()
 	m := New(s)
@@ -208,6 +229,7 @@ func TestDisplayName(t *testing.T) {
 		session  client.SessionState
 		expected string
 	}{
+		{"worktree", client.SessionState{ID: "abcdefgh-1234", Name: "app--fix-login", Project: "app", Worktree: "fix-login"}, "app (worktree: fix-login)"},
 		{"prefers name", client.SessionState{ID: "abcdefgh-1234", Name: "my-session", Slug: "my-slug"}, "my-session"},
 		{"falls back to slug", client.SessionState{ID: "abcdefgh-1234", Slug: "my-slug"}, "my-slug"},
 		{"falls back to short ID", client.SessionState{ID: "abcdefgh-1234"}, "abcdefgh"},
@@ -303,3 +325,86 @@ func TestRenderBar(t *testing.T) {
 		t.Error("negative bar should not be empty")
 	}
 }
+
+func TestView_MCPServers(t *testing.T) {
+	s := makeSession()
+	s.MCPToolCalls = map[string]int{"linear": 1, "github": 4}
+	view := New(s).View()
+
+	if !strings.Contains(view, "MCP Servers") {
+		t.Error("view should contain MCP Servers row")
+	}
+	if !strings.Contains(view, "github 4  linear 1") {
+		t.Error("view should list MCP servers busiest first")
+	}
+}
+
+func TestView_LastCommand(t *testing.T) {
+	s := makeSession()
+	s.LastCommand = "/compact"
+	view := New(s).View()
+
+	if !strings.Contains(view, "/compact") {
+		t.Error("view should contain last slash command")
+	}
+}
+
+func TestView_CompactionCountdown(t *testing.T) {
+	s := makeSession()
+	if strings.Contains(New(s).View(), "Compaction") {
+		t.Error("view should not show a countdown without a forecast")
+	}
+	s.CompactionETA = time.Now().Add(4*time.Minute + 5*time.Second)
+	if view := New(s).View(); !strings.Contains(view, "in 4m 0") {
+		t.Errorf("view should count down to compaction:\n%s", view)
+	}
+}
+
+func TestFormatCountdown(t *testing.T) {
+	if got := formatCountdown(time.Now().Add(-time.Second)); got != "due" {
+		t.Errorf("past = %q, want due", got)
+	}
+	if got := formatCountdown(time.Now().Add(2*time.Hour + 7*time.Minute + 30*time.Second)); got != "in 2h 07m" {
+		t.Errorf("2h07m = %q", got)
+	}
+}
+
+func TestView_Topic(t *testing.T) {
+	s := makeSession()
+	s.Topic = "fix flaky websocket test"
+	view := New(s).View()
+
+	if !strings.Contains(view, "fix flaky websocket test") {
+		t.Error("view should contain session topic")
+	}
+}
+
+func TestView_Links(t *testing.T) {
+	s := makeSession()
+	s.IssueURL = "https://github.com/acme/app/issues/12"
+	s.PRURL = "https://github.com/acme/app/pull/34"
+	view := New(s).View()
+
+	for _, want := range []string{"issues/12", "pull/34"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view should contain %q", want)
+		}
+	}
+}
+
+func TestView_Outcome(t *testing.T) {
+	s := makeSession()
+	s.Outcome = "no_changes"
+	view := New(s).View()
+
+	if !strings.Contains(view, "no changes") {
+		t.Error("view should show the session outcome")
+	}
+}
+
+func TestFormatCounts_HighestFirst(t *testing.T) {
+	got := formatCounts(map[string]int{"👀": 1, "🎉": 3, "🔥": 1})
+	if want := "🎉 3  👀 1  🔥 1"; got != want {
+		t.Errorf("formatCounts = %q, want %q", got, want)
+	}
+}
@@ -85,6 +85,9 @@ type Model struct {
 	// battlePassTier is the player's current battle pass tier.
 	battlePassTier int
 
+	// seasonRewards holds the reward IDs past seasons granted for good.
+	seasonRewards map[string]string
+
 	// slotIdx is the focused column (index into client.SlotTypes).
 	slotIdx int
 
@@ -140,6 +143,7 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 				m.unlocked[id] = true
 			}
 			m.battlePassTier = msg.Stats.BattlePass.Tier
+			m.seasonRewards = msg.Stats.SeasonRewards
 		}
 		return m, nil
 
@@ -343,6 +347,9 @@ func renderRewardRow(rw client.RewardEntry, isSelected, isEquipped, isUnlocked b
 
 // isUnlocked reports whether the player has access to a reward.
 func (m Model) isUnlocked(rw client.RewardEntry) bool {
+	if _, ok := m.seasonRewards[rw.ID]; ok {
+		return true
+	}
 	if rw.UnlockedBy == "" {
 		// Battle pass reward — check tier. We don't know the exact tier here,
 		// but the server will reject if not unlocked. Show all BP rewards as unlocked
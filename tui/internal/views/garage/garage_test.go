@@ -3,6 +3,7 @@ package garage
 import (
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/agent-racer/tui/internal/client"
 	"github.com/charmbracelet/bubbles/key"
@@ -76,8 +77,8 @@ func TestUpdate_StatsLoaded(t *testing.T) {
 
 	stats := &client.Stats{
 		Equipped: client.Equipped{Paint: "rookie_paint"},
-		AchievementsUnlocked: map[string]string{
-			"first_lap": "2026-01-01T00:00:00Z",
+		AchievementsUnlocked: map[string]time.Time{
+			"first_lap": time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
 		},
 		BattlePass: client.BattlePass{Tier: 3},
 	}
@@ -302,4 +303,10 @@ func TestIsUnlocked(t *testing.T) {
 	if m.isUnlocked(client.RewardEntry{UnlockedBy: ""}) {
 		t.Error("BP reward should be locked when battlePassTier = 0")
 	}
+
+	// Granted for good by a past season
+	m.seasonRewards = map[string]string{"flame_trail": "2026-02"}
+	if !m.isUnlocked(client.RewardEntry{ID: "flame_trail"}) {
+		t.Error("a reward granted by a past season should stay unlocked")
+	}
 }
@@ -18,6 +18,11 @@ type Model struct {
 	SourceHealth map[string]client.SourceHealthPayload
 	Width        int
 	SpinnerView  string // animated spinner view when not connected
+	// UpdateAvailable is the newer server release announced by the
+	// backend, or empty when it is up to date.
+	UpdateAvailable string
+	// Viewers counts the clients watching the race, this one included.
+	Viewers int
 }
 
 // New creates a status bar model.
@@ -72,9 +77,15 @@ func (m Model) View() string {
 
 	sep := lipgloss.NewStyle().Foreground(theme.ColorBorder).Render(" | ")
 	content := connStr + sep + counts
+	if m.Connected && m.Viewers > 1 {
+		content += sep + fmt.Sprintf("%d viewers", m.Viewers)
+	}
 	if healthStr != "" {
 		content += sep + healthStr
 	}
+	if m.UpdateAvailable != "" {
+		content += sep + lipgloss.NewStyle().Foreground(theme.ColorWarning).Render("update "+m.UpdateAvailable+" available")
+	}
 
 	bar := lipgloss.NewStyle().
 		Width(width).
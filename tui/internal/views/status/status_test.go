@@ -160,3 +160,32 @@ func TestView_ZeroCounts(t *testing.T) {
 		t.Error("should show 0 parked")
 	}
 }
+
+func TestView_UpdateAvailable(t *testing.T) {
+	m := New()
+	m.Connected = true
+	m.Width = 120
+
+	if strings.Contains(m.View(), "available") {
+		t.Error("view should not mention an update by default")
+	}
+	m.UpdateAvailable = "v1.1.0"
+	if !strings.Contains(m.View(), "update v1.1.0 available") {
+		t.Error("view should show the available update")
+	}
+}
+
+func TestView_Viewers(t *testing.T) {
+	m := New()
+	m.Connected = true
+	m.Width = 120
+
+	m.Viewers = 1
+	if strings.Contains(m.View(), "viewers") {
+		t.Error("view should not count viewers when watching alone")
+	}
+	m.Viewers = 3
+	if !strings.Contains(m.View(), "3 viewers") {
+		t.Error("view should show how many are watching")
+	}
+}
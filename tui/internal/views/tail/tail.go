@@ -37,6 +37,7 @@ type Model struct {
 	offset   int    // scroll offset from bottom (0 = at bottom)
 	autoTail bool   // stay at bottom
 	pollOff  int64  // byte offset for next poll
+	prefs    client.PreferencesPayload
 }
 
 // New creates a tail model for the given session.
@@ -178,7 +179,7 @@ func (m Model) View(width, height int) string {
 	var lines []string
 	for i := start; i < end; i++ {
 		e := m.entries[i]
-		lines = append(lines, renderEntry(e, innerW))
+		lines = append(lines, renderEntry(e, innerW, m.prefs))
 	}
 
 	body := strings.Join(lines, "\n")
@@ -192,14 +193,19 @@ func (m Model) View(width, height int) string {
 	return panelStyle(innerW).Render(content)
 }
 
+// SetPreferences sets the time zone and clock entry timestamps are shown in.
+func (m *Model) SetPreferences(p client.PreferencesPayload) {
+	m.prefs = p
+}
+
 // UpdateActivity refreshes the displayed activity from current session state.
 func (m *Model) UpdateActivity(activity string) {
 	m.Activity = activity
 }
 
 // renderEntry formats a single tail entry as a styled line.
-func renderEntry(e client.TailEntry, maxWidth int) string {
-	ts := theme.StyleDimmed.Render(e.Timestamp.Format("15:04:05"))
+func renderEntry(e client.TailEntry, maxWidth int, prefs client.PreferencesPayload) string {
+	ts := theme.StyleDimmed.Render(formatClock(e.Timestamp, prefs))
 
 	glyph, color := activityGlyphAndColor(e.Activity)
 	glyphStr := lipgloss.NewStyle().Foreground(color).Width(3).Render(glyph)
@@ -246,3 +252,13 @@ func panelStyle(width int) lipgloss.Style {
 		BorderStyle(lipgloss.DoubleBorder()).
 		BorderForeground(theme.ColorBorder)
 }
+
+// formatClock shows t in the zone and clock from prefs. The terminal has no
+// locale to ask, so the clock defaults to 24h.
+func formatClock(t time.Time, prefs client.PreferencesPayload) string {
+	t = t.In(prefs.Location())
+	if prefs.Clock == "12h" {
+		return t.Format("3:04:05 PM")
+	}
+	return t.Format("15:04:05")
+}
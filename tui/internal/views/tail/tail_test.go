@@ -337,3 +337,22 @@ func TestActivityGlyphAndColor(t *testing.T) {
 		})
 	}
 }
+
+func TestFormatClock(t *testing.T) {
+	at := time.Date(2026, 1, 15, 21, 5, 9, 0, time.UTC)
+	tests := []struct {
+		prefs client.PreferencesPayload
+		want  string
+	}{
+		{client.PreferencesPayload{TimeZone: "UTC"}, "21:05:09"},
+		{client.PreferencesPayload{TimeZone: "Asia/Tokyo", Clock: "24h"}, "06:05:09"},
+		{client.PreferencesPayload{TimeZone: "America/New_York", Clock: "12h"}, "4:05:09 PM"},
+		// Unknown to this system: fall back to the server's offset.
+		{client.PreferencesPayload{TimeZone: "Nowhere/Special", UTCOffset: 3600}, "22:05:09"},
+	}
+	for _, tt := range tests {
+		if got := formatClock(at, tt.prefs); got != tt.want {
+			t.Errorf("formatClock(%+v) = %q, want %q", tt.prefs, got, tt.want)
+		}
+	}
+}
@@ -29,6 +29,8 @@ func activityGlyph(a client.Activity) string {
 		return "○"
 	case client.ActivityWaiting:
 		return "◌"
+	case client.ActivityNeedsApproval:
+		return "!"
 	case client.ActivityStarting:
 		return "◎"
 	case client.ActivityComplete:
@@ -0,0 +1,118 @@
+// Package waybar streams the race to a waybar custom module. waybar runs
+// the command once and reads a JSON object per line for as long as it
+// lives; i3status-rust and other bars that take waybar's format work too.
+package waybar
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+
+	sdk "github.com/agent-racer/backend/pkg/client"
+	"github.com/agent-racer/tui/internal/racefeed"
+)
+
+// Options configure Run.
+type Options struct {
+	URL   string // WebSocket URL, e.g. ws://127.0.0.1:8080/ws
+	Token string
+	TLS   *tls.Config
+}
+
+// Output is one update in waybar's return-type json format.
+type Output struct {
+	Text    string `json:"text"`
+	Tooltip string `json:"tooltip"`
+	// Class is the worst state among the sessions, for styling the module:
+	// needs_approval, errored, waiting, active, idle, none or disconnected.
+	Class string `json:"class"`
+	// Percentage is the highest context use among running sessions, for
+	// format-icons.
+	Percentage int `json:"percentage"`
+}
+
+// Run connects to the server and writes an Output line to w whenever the
+// race changes, reconnecting until ctx is done.
+func Run(ctx context.Context, w io.Writer, opts Options) error {
+	view := racefeed.View{
+		Render: func(sessions map[string]*sdk.SessionState) string {
+			return encode(Render(sessions))
+		},
+		Disconnected: func(err error) string {
+			return encode(Output{Text: "🏁 ⨯", Tooltip: "Can't reach Agent Racer: " + html.EscapeString(err.Error()), Class: "disconnected"})
+		},
+	}
+	return racefeed.Run(ctx, racefeed.Options{URL: opts.URL, Token: opts.Token, TLS: opts.TLS, Kind: "waybar"}, view,
+		func(line string) error {
+			_, err := io.WriteString(w, line+"\n")
+			return err
+		})
+}
+
+func encode(out Output) string {
+	data, _ := json.Marshal(out)
+	return string(data)
+}
+
+// classRank orders the classes from calm to worst.
+var classRank = map[string]int{"none": 0, "idle": 1, "active": 2, "waiting": 3, "errored": 4, "needs_approval": 5}
+
+// classOf is the class a session in activity a puts the module in, or ""
+// when a doesn't affect it.
+func classOf(a sdk.Activity) string {
+	switch a {
+	case sdk.ActivityNeedsApproval:
+		return "needs_approval"
+	case sdk.ActivityErrored:
+		return "errored"
+	case sdk.ActivityWaiting:
+		return "waiting"
+	case sdk.ActivityStarting, sdk.ActivityThinking, sdk.ActivityToolUse:
+		return "active"
+	case sdk.ActivityIdle:
+		return "idle"
+	}
+	return ""
+}
+
+// Render summarizes the race: the text counts running sessions like the
+// menu bar title, and the tooltip lists them in race order.
+func Render(sessions map[string]*sdk.SessionState) Output {
+	running, _ := racefeed.Split(sessions)
+	approvals, errored := racefeed.Alerts(sessions)
+
+	out := Output{Text: fmt.Sprintf("🏁 %d", len(running)), Class: "none"}
+	if approvals > 0 {
+		out.Text += fmt.Sprintf(" ✋%d", approvals)
+	}
+	if errored > 0 {
+		out.Text += fmt.Sprintf(" ✖%d", errored)
+	}
+	for _, s := range sessions {
+		if c := classOf(s.Activity); c != "" && classRank[c] > classRank[out.Class] {
+			out.Class = c
+		}
+	}
+
+	if len(running) == 0 {
+		out.Tooltip = "No sessions racing"
+		return out
+	}
+	lines := make([]string, 0, len(running))
+	for i := 0; i < len(running); i++ {
+		s := running[i]
+		line := fmt.Sprintf("%s %s — %s", racefeed.Icon(s.Activity), html.EscapeString(s.Name), strings.ReplaceAll(string(s.Activity), "_", " "))
+		if s.MaxContextTokens > 0 {
+			pct := int(s.ContextUtilization * 100)
+			line += fmt.Sprintf(" · %d%%", pct)
+			out.Percentage = max(out.Percentage, pct)
+		}
+		lines = append(lines, line)
+	}
+	out.Tooltip = strings.Join(lines, "\n")
+	return out
+}
@@ -0,0 +1,85 @@
+package waybar
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	sdk "github.com/agent-racer/backend/pkg/client"
+	"github.com/agent-racer/tui/internal/testutil"
+	"github.com/gorilla/websocket"
+)
+
+func TestRender(t *testing.T) {
+	done := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	sessions := map[string]*sdk.SessionState{
+		"a": {ID: "a", Name: "api <v2>", Activity: sdk.ActivityToolUse, Position: 1, MaxContextTokens: 200000, ContextUtilization: 0.42},
+		"b": {ID: "b", Name: "web", Activity: sdk.ActivityWaiting, Position: 2, MaxContextTokens: 200000, ContextUtilization: 0.81},
+		"c": {ID: "c", Name: "broken", Activity: sdk.ActivityErrored, CompletedAt: &done},
+	}
+	got := Render(sessions)
+	want := Output{
+		Text:       "🏁 2 ✖1",
+		Tooltip:    "⚙ api &lt;v2&gt; — tool use · 42%\n… web — waiting · 81%",
+		Class:      "errored",
+		Percentage: 81,
+	}
+	if got != want {
+		t.Errorf("Render() = %+v, want %+v", got, want)
+	}
+
+	sessions["b"].Activity = sdk.ActivityNeedsApproval
+	if got := Render(sessions); got.Class != "needs_approval" || got.Text != "🏁 2 ✋1 ✖1" {
+		t.Errorf("with an approval: %+v", got)
+	}
+	if got := Render(nil); got.Class != "none" || got.Text != "🏁 0" || got.Tooltip != "No sessions racing" {
+		t.Errorf("Render(nil) = %+v", got)
+	}
+}
+
+func TestRun_StreamsLines(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		_ = conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"snapshot","seq":1,"payload":{"sessions":[{"id":"a","name":"alpha","activity":"thinking"}]}}`))
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	defer srv.Close()
+
+	var out testutil.SyncBuffer
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() {
+		errc <- Run(ctx, &out, Options{URL: "ws" + strings.TrimPrefix(srv.URL, "http")})
+	}()
+
+	deadline := time.Now().Add(3 * time.Second)
+	for !strings.Contains(out.String(), "\n") && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+	}
+	cancel()
+	if err := <-errc; err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	line, _, _ := strings.Cut(out.String(), "\n")
+	var got Output
+	if err := json.Unmarshal([]byte(line), &got); err != nil {
+		t.Fatalf("line %q: %v", line, err)
+	}
+	if got.Text != "🏁 1" || got.Class != "active" {
+		t.Errorf("output = %+v", got)
+	}
+}